@@ -20,7 +20,16 @@ $$;
 -- Drop tables in reverse order of creation to maintain referential integrity
 -- Each DROP CASCADE will automatically remove dependent objects like indexes and constraints
 
--- Drop line_items first (child table of invoices)
+-- Drop audit_logs (written by the audit triggers)
+DROP TABLE IF EXISTS audit_logs CASCADE;
+
+-- Drop bills (depends on accounts)
+DROP TABLE IF EXISTS bills CASCADE;
+
+-- Drop account_price_plans (depends on accounts and price_plans)
+DROP TABLE IF EXISTS account_price_plans CASCADE;
+
+-- Drop line_items (child table of invoices)
 DROP TABLE IF EXISTS line_items CASCADE;
 
 -- Drop invoices (depends on accounts)
@@ -41,6 +50,16 @@ DROP TABLE IF EXISTS accounts CASCADE;
 -- Drop customers last (root table)
 DROP TABLE IF EXISTS customers CASCADE;
 
+-- Drop the trigger functions and enum types the tables used
+DROP FUNCTION IF EXISTS audit_trigger_function();
+DROP FUNCTION IF EXISTS update_updated_at_column();
+
+DROP TYPE IF EXISTS currency_code;
+DROP TYPE IF EXISTS price_plan_type;
+DROP TYPE IF EXISTS bill_status;
+DROP TYPE IF EXISTS account_status;
+DROP TYPE IF EXISTS customer_status;
+
 -- Verify all tables are dropped
 DO $$
 BEGIN
@@ -49,6 +68,9 @@ BEGIN
         FROM information_schema.tables
         WHERE table_schema = 'public'
         AND table_name IN (
+            'audit_logs',
+            'bills',
+            'account_price_plans',
             'line_items',
             'invoices',
             'usage_events',
@@ -10,6 +10,7 @@ BEGIN
         SELECT 1 
         FROM pg_stat_activity 
         WHERE state = 'active' 
+        AND pid <> pg_backend_pid()
         AND query LIKE '%wallet_transactions%'
     ) THEN
         RAISE EXCEPTION 'Cannot drop tables while active transactions exist';
@@ -22,4 +23,7 @@ DROP TABLE IF EXISTS wallet_transactions CASCADE;
 
 -- Step 2: Drop wallets table (parent table) after dependent tables are removed
 -- CASCADE will clean up any remaining dependent objects
-DROP TABLE IF EXISTS wallets CASCADE;
\ No newline at end of file
+DROP TABLE IF EXISTS wallets CASCADE;
+
+-- Step 3: Drop the trigger function of the wallets table
+DROP FUNCTION IF EXISTS update_wallet_timestamp();
@@ -0,0 +1,4 @@
+-- Migration: 000003_add_operator_audit_log.down.sql
+-- Description: Drops the operator runbook audit log table.
+
+DROP TABLE IF EXISTS operator_audit_log CASCADE;
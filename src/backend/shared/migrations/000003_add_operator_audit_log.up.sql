@@ -0,0 +1,20 @@
+-- Create operator_audit_log table recording every runbook action executed by on-call operators
+CREATE TABLE operator_audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    action VARCHAR(100) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('SUCCEEDED', 'FAILED')),
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for audit review by time, action and operator
+CREATE INDEX idx_operator_audit_log_created ON operator_audit_log(created_at);
+CREATE INDEX idx_operator_audit_log_action ON operator_audit_log(action);
+CREATE INDEX idx_operator_audit_log_actor ON operator_audit_log(actor);
+
+COMMENT ON TABLE operator_audit_log IS 'Append-only audit trail of operator runbook actions';
+COMMENT ON COLUMN operator_audit_log.reason IS 'Operator supplied justification for the action';
+COMMENT ON COLUMN operator_audit_log.params IS 'Action parameters stored as JSONB';
@@ -0,0 +1,5 @@
+-- Migration: 000004_add_rate_limit_tiers.down.sql
+-- Description: Drops per-customer rate limit tables, overrides first.
+
+DROP TABLE IF EXISTS customer_rate_limits CASCADE;
+DROP TABLE IF EXISTS rate_limit_tiers CASCADE;
@@ -0,0 +1,29 @@
+-- Create rate_limit_tiers table defining request quotas per price plan type
+CREATE TABLE rate_limit_tiers (
+    name VARCHAR(50) PRIMARY KEY,
+    requests_per_window INTEGER NOT NULL CHECK (requests_per_window > 0),
+    window_seconds INTEGER NOT NULL CHECK (window_seconds > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Seed tiers matching the price_plan_type values
+INSERT INTO rate_limit_tiers (name, requests_per_window, window_seconds) VALUES
+    ('standard', 100, 60),
+    ('custom', 500, 60),
+    ('enterprise', 2000, 60);
+
+-- Create customer_rate_limits table for per-customer tier assignment and overrides
+CREATE TABLE customer_rate_limits (
+    customer_id UUID PRIMARY KEY REFERENCES customers(id) ON DELETE CASCADE,
+    tier VARCHAR(50) REFERENCES rate_limit_tiers(name) ON DELETE RESTRICT,
+    requests_per_window INTEGER CHECK (requests_per_window > 0),
+    window_seconds INTEGER CHECK (window_seconds > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE rate_limit_tiers IS 'Rate limit quotas per tier, tier names match price plan types';
+COMMENT ON TABLE customer_rate_limits IS 'Per-customer rate limit tier assignment and explicit quota overrides';
+COMMENT ON COLUMN customer_rate_limits.tier IS 'Explicit tier, falls back to the active price plan type when NULL';
+COMMENT ON COLUMN customer_rate_limits.requests_per_window IS 'Explicit quota overriding the tier quota when set';
@@ -0,0 +1,4 @@
+-- Migration: 000005_add_reconciliation_issues.down.sql
+-- Description: Drops the wallet reconciliation issues table.
+
+DROP TABLE IF EXISTS reconciliation_issues CASCADE;
@@ -0,0 +1,23 @@
+-- Create reconciliation_issues table recording wallets whose stored balance
+-- disagrees with the balance recomputed from their completed transactions
+CREATE TABLE reconciliation_issues (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    currency VARCHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    stored_balance DECIMAL(12,2) NOT NULL,
+    ledger_balance DECIMAL(12,2) NOT NULL,
+    difference DECIMAL(12,2) NOT NULL,
+    transaction_count BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'RESOLVED')),
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_checked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+-- A wallet has at most one open issue, refreshed by every reconciliation run
+CREATE UNIQUE INDEX idx_reconciliation_issues_open_wallet ON reconciliation_issues(wallet_id) WHERE status = 'OPEN';
+CREATE INDEX idx_reconciliation_issues_status_detected ON reconciliation_issues(status, detected_at);
+
+COMMENT ON TABLE reconciliation_issues IS 'Discrepancies between stored wallet balances and the transaction ledger';
+COMMENT ON COLUMN reconciliation_issues.difference IS 'stored_balance minus ledger_balance';
+COMMENT ON COLUMN reconciliation_issues.last_checked_at IS 'Time of the latest run that still observed the discrepancy';
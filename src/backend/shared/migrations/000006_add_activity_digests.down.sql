@@ -0,0 +1,5 @@
+-- Migration: 000006_add_activity_digests.down.sql
+-- Description: Drops digest subscriptions and the daily activity aggregates.
+
+DROP TABLE IF EXISTS digest_subscriptions CASCADE;
+DROP TABLE IF EXISTS wallet_activity_daily CASCADE;
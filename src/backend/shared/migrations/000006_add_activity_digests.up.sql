@@ -0,0 +1,33 @@
+-- Create wallet_activity_daily aggregates rolled up from completed transactions
+-- so digests and reports do not scan the raw transaction history
+CREATE TABLE wallet_activity_daily (
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    activity_date DATE NOT NULL,
+    category VARCHAR(64) NOT NULL,
+    top_up_count BIGINT NOT NULL DEFAULT 0,
+    top_up_total DECIMAL(14,2) NOT NULL DEFAULT 0.00,
+    spend_count BIGINT NOT NULL DEFAULT 0,
+    spend_total DECIMAL(14,2) NOT NULL DEFAULT 0.00,
+    refund_total DECIMAL(14,2) NOT NULL DEFAULT 0.00,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wallet_id, activity_date, category)
+);
+
+CREATE INDEX idx_wallet_activity_daily_date ON wallet_activity_daily(activity_date);
+
+-- Create digest_subscriptions table holding per-customer digest opt-ins
+CREATE TABLE digest_subscriptions (
+    customer_id UUID PRIMARY KEY REFERENCES customers(id) ON DELETE CASCADE,
+    frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('WEEKLY', 'MONTHLY')),
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    last_period_end TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_digest_subscriptions_due ON digest_subscriptions(frequency, last_period_end) WHERE enabled;
+
+COMMENT ON TABLE wallet_activity_daily IS 'Daily per-wallet activity totals by spend category';
+COMMENT ON COLUMN wallet_activity_daily.category IS 'Transaction metadata category, or uncategorized';
+COMMENT ON TABLE digest_subscriptions IS 'Customer opt-in for weekly or monthly wallet activity digests';
+COMMENT ON COLUMN digest_subscriptions.last_period_end IS 'End of the latest digest period sent, used to send each period once';
@@ -0,0 +1,5 @@
+-- Migration: 000007_add_balance_snapshots.down.sql
+-- Description: Drops wallet balance snapshots and the replay index.
+
+DROP INDEX IF EXISTS idx_wallet_transactions_wallet_created;
+DROP TABLE IF EXISTS wallet_balance_snapshots CASCADE;
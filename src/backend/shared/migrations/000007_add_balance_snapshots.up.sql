@@ -0,0 +1,18 @@
+-- Create wallet_balance_snapshots table holding each wallet's balance at UTC
+-- midnight so historical balances only replay the transactions since then
+CREATE TABLE wallet_balance_snapshots (
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    as_of TIMESTAMP WITH TIME ZONE NOT NULL,
+    balance DECIMAL(12,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wallet_id, as_of)
+);
+
+CREATE INDEX idx_wallet_balance_snapshots_as_of ON wallet_balance_snapshots(as_of);
+
+-- Support replaying a wallet's completed transactions over a time range
+CREATE INDEX idx_wallet_transactions_wallet_created ON wallet_transactions(wallet_id, created_at);
+
+COMMENT ON TABLE wallet_balance_snapshots IS 'Daily wallet balance snapshots for balance as of date queries';
+COMMENT ON COLUMN wallet_balance_snapshots.as_of IS 'Instant the balance applies to, transactions created before it are included';
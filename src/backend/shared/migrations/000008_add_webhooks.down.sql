@@ -0,0 +1,5 @@
+-- Migration: 000008_add_webhooks.down.sql
+-- Description: Drops webhook subscriptions and their delivery log.
+
+DROP TABLE IF EXISTS webhook_deliveries CASCADE;
+DROP TABLE IF EXISTS webhook_subscriptions CASCADE;
@@ -0,0 +1,36 @@
+-- Create webhook_subscriptions table holding customer webhook endpoints and
+-- the rules selecting which events, and which payload fields, they receive
+CREATE TABLE webhook_subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    url VARCHAR(2048) NOT NULL CHECK (url ~ '^https://'),
+    secret VARCHAR(128) NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    wallet_ids UUID[] NOT NULL DEFAULT '{}',
+    fields TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_subscriptions_customer ON webhook_subscriptions(customer_id) WHERE enabled;
+
+-- Create webhook_deliveries table recording every delivery attempt
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('DELIVERED', 'FAILED')),
+    response_code INTEGER,
+    error TEXT,
+    attempted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, attempted_at DESC);
+
+COMMENT ON TABLE webhook_subscriptions IS 'Customer webhook endpoints with event filtering and payload field selection';
+COMMENT ON COLUMN webhook_subscriptions.event_types IS 'Event types delivered, empty for all';
+COMMENT ON COLUMN webhook_subscriptions.wallet_ids IS 'Wallets whose events are delivered, empty for all of the customer''s wallets';
+COMMENT ON COLUMN webhook_subscriptions.fields IS 'Dotted payload field paths delivered, empty for the full payload';
+COMMENT ON TABLE webhook_deliveries IS 'Webhook delivery attempts and their outcome';
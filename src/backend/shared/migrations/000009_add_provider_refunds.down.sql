@@ -0,0 +1,5 @@
+-- Migration: 000009_add_provider_refunds.down.sql
+-- Description: Drops provider refunds and top-up payment links.
+
+DROP TABLE IF EXISTS provider_refunds CASCADE;
+DROP TABLE IF EXISTS provider_payments CASCADE;
@@ -0,0 +1,38 @@
+-- Create provider_payments table linking top-up transactions to the
+-- payment provider and payment that funded them
+CREATE TABLE provider_payments (
+    transaction_id UUID PRIMARY KEY REFERENCES wallet_transactions(id) ON DELETE RESTRICT,
+    provider VARCHAR(32) NOT NULL,
+    provider_payment_id VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, provider_payment_id)
+);
+
+-- Create provider_refunds table tracking refunds pushed back to the
+-- original payment method. The wallet transaction stays PROCESSING until
+-- the provider confirms or fails the refund.
+CREATE TABLE provider_refunds (
+    id UUID PRIMARY KEY,
+    transaction_id UUID NOT NULL UNIQUE REFERENCES wallet_transactions(id) ON DELETE RESTRICT,
+    original_transaction_id UUID NOT NULL REFERENCES provider_payments(transaction_id) ON DELETE RESTRICT,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    provider VARCHAR(32) NOT NULL,
+    provider_payment_id VARCHAR(128) NOT NULL,
+    provider_refund_id VARCHAR(128),
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0.00),
+    currency VARCHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    status VARCHAR(10) NOT NULL CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED')),
+    failure_reason TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_check_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_provider_refunds_original ON provider_refunds(original_transaction_id);
+CREATE INDEX idx_provider_refunds_pending ON provider_refunds(next_check_at) WHERE status = 'PENDING';
+
+COMMENT ON TABLE provider_payments IS 'Payment provider and payment behind each provider-funded top-up';
+COMMENT ON TABLE provider_refunds IS 'Refunds of top-ups pushed back to the original payment method';
+COMMENT ON COLUMN provider_refunds.provider_refund_id IS 'Provider refund ID, null until the provider accepts the submission';
+COMMENT ON COLUMN provider_refunds.next_check_at IS 'When the refund is next submitted or polled for its provider status';
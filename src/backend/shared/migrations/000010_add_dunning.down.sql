@@ -0,0 +1,4 @@
+-- Migration: 000010_add_dunning.down.sql
+-- Description: Drops the dunning cases of wallets with exhausted balances.
+
+DROP TABLE IF EXISTS dunning_cases CASCADE;
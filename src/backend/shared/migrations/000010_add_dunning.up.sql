@@ -0,0 +1,27 @@
+-- Create dunning_cases table tracking wallets whose balance ran out, from the
+-- start of their grace period until a top-up restores a positive balance
+CREATE TABLE dunning_cases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE RESTRICT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    grace_ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    notices_sent INTEGER NOT NULL DEFAULT 0 CHECK (notices_sent >= 0),
+    next_notice_at TIMESTAMP WITH TIME ZONE,
+    suspended_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (grace_ends_at >= started_at)
+);
+
+-- At most one open case per wallet
+CREATE UNIQUE INDEX idx_dunning_cases_open_wallet ON dunning_cases(wallet_id) WHERE resolved_at IS NULL;
+CREATE INDEX idx_dunning_cases_next_notice ON dunning_cases(next_notice_at) WHERE resolved_at IS NULL;
+CREATE INDEX idx_dunning_cases_grace_ends ON dunning_cases(grace_ends_at) WHERE resolved_at IS NULL AND suspended_at IS NULL;
+
+COMMENT ON TABLE dunning_cases IS 'Grace periods of wallets with a zero or negative balance';
+COMMENT ON COLUMN dunning_cases.notices_sent IS 'Number of escalating dunning notices published so far';
+COMMENT ON COLUMN dunning_cases.next_notice_at IS 'When the next notice is due, NULL once every notice was sent';
+COMMENT ON COLUMN dunning_cases.suspended_at IS 'When service was suspended after the grace period expired';
+COMMENT ON COLUMN dunning_cases.resolved_at IS 'When a top-up restored a positive balance and closed the case';
@@ -0,0 +1,6 @@
+-- Migration: 000011_add_wallet_credit_limit.down.sql
+-- Description: Removes wallet credit limits. Fails while any wallet is overdrawn.
+
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_balance_within_credit_limit;
+ALTER TABLE wallets ADD CONSTRAINT wallets_balance_check CHECK (balance >= 0.00);
+ALTER TABLE wallets DROP COLUMN IF EXISTS credit_limit;
@@ -0,0 +1,9 @@
+-- Allow wallets to go negative down to a per-wallet credit limit. Wallets
+-- default to no credit, keeping the previous non-negative balance rule.
+ALTER TABLE wallets
+    ADD COLUMN credit_limit DECIMAL(12,2) NOT NULL DEFAULT 0.00 CHECK (credit_limit >= 0.00);
+
+ALTER TABLE wallets DROP CONSTRAINT wallets_balance_check;
+ALTER TABLE wallets ADD CONSTRAINT wallets_balance_within_credit_limit CHECK (balance >= -credit_limit);
+
+COMMENT ON COLUMN wallets.credit_limit IS 'How far below zero the balance may go, zero for prepaid-only wallets';
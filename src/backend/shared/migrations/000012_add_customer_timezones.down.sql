@@ -0,0 +1,6 @@
+-- Migration: 000012_add_customer_timezones.down.sql
+-- Description: Removes customer timezones. Daily activity aggregates keep the
+-- local dates they were computed with until they are refreshed.
+
+COMMENT ON COLUMN wallet_activity_daily.activity_date IS NULL;
+ALTER TABLE customers DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,7 @@
+-- Record each customer's timezone so digest periods and daily activity
+-- aggregates are cut off at the customer's local midnight instead of UTC
+ALTER TABLE customers
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+COMMENT ON COLUMN customers.timezone IS 'IANA timezone of the customer, used for statement and reporting cutoffs';
+COMMENT ON COLUMN wallet_activity_daily.activity_date IS 'Date in the timezone of the wallet owner when the day was aggregated';
@@ -0,0 +1,9 @@
+-- Migration: 000013_add_transaction_metadata.down.sql
+-- Description: Reverts transaction metadata to an optional column. Stored
+-- metadata is kept.
+
+COMMENT ON COLUMN wallet_transactions.metadata IS NULL;
+DROP INDEX IF EXISTS idx_wallet_transactions_metadata;
+ALTER TABLE wallet_transactions
+    DROP CONSTRAINT IF EXISTS wallet_transactions_metadata_object,
+    ALTER COLUMN metadata DROP NOT NULL;
@@ -0,0 +1,13 @@
+-- Make the transaction metadata column, unused until now, hold caller-defined
+-- key-value pairs such as order IDs, channels and tags. The GIN index serves
+-- containment lookups of key and value pairs (metadata @> ...). Its
+-- jsonb_path_ops operator class cannot serve lookups of a key alone.
+UPDATE wallet_transactions SET metadata = '{}'::jsonb WHERE metadata IS NULL;
+
+ALTER TABLE wallet_transactions
+    ALTER COLUMN metadata SET NOT NULL,
+    ADD CONSTRAINT wallet_transactions_metadata_object CHECK (jsonb_typeof(metadata) = 'object');
+
+CREATE INDEX idx_wallet_transactions_metadata ON wallet_transactions USING GIN (metadata jsonb_path_ops);
+
+COMMENT ON COLUMN wallet_transactions.metadata IS 'Caller-defined string key-value pairs, at most 20 keys';
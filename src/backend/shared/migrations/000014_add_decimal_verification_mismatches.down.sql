@@ -0,0 +1,4 @@
+-- Migration: 000014_add_decimal_verification_mismatches.down.sql
+-- Description: Drops the decimal migration verification report table.
+
+DROP TABLE IF EXISTS decimal_verification_mismatches CASCADE;
@@ -0,0 +1,19 @@
+-- Create decimal_verification_mismatches table reporting amounts whose
+-- float64 reading drifted from the exact NUMERIC value during the float to
+-- decimal migration
+CREATE TABLE decimal_verification_mismatches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entity VARCHAR(20) NOT NULL CHECK (entity IN ('wallet', 'transaction')),
+    entity_id UUID NOT NULL,
+    field VARCHAR(50) NOT NULL,
+    float_value DOUBLE PRECISION NOT NULL,
+    decimal_value DECIMAL(12,2) NOT NULL,
+    drift NUMERIC NOT NULL CHECK (drift >= 0),
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_decimal_verification_mismatches_detected ON decimal_verification_mismatches(detected_at);
+CREATE INDEX idx_decimal_verification_mismatches_entity ON decimal_verification_mismatches(entity, entity_id);
+
+COMMENT ON TABLE decimal_verification_mismatches IS 'Dual-read verification report of the float to decimal migration';
+COMMENT ON COLUMN decimal_verification_mismatches.drift IS 'Absolute difference between float_value and decimal_value';
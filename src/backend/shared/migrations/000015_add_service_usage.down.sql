@@ -0,0 +1,4 @@
+-- Migration: 000015_add_service_usage.down.sql
+-- Description: Drops the internal service usage table.
+
+DROP TABLE IF EXISTS service_usage CASCADE;
@@ -0,0 +1,17 @@
+-- Create service_usage table accumulating the requests, database time and
+-- bytes served of each internal service per month for chargeback
+CREATE TABLE service_usage (
+    month DATE NOT NULL CHECK (EXTRACT(DAY FROM month) = 1),
+    caller VARCHAR(255) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0 CHECK (requests >= 0),
+    db_time_us BIGINT NOT NULL DEFAULT 0 CHECK (db_time_us >= 0),
+    bytes_served BIGINT NOT NULL DEFAULT 0 CHECK (bytes_served >= 0),
+    charged_at TIMESTAMP WITH TIME ZONE,
+    charged_transaction_id UUID REFERENCES wallet_transactions(id) ON DELETE RESTRICT,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (month, caller)
+);
+
+COMMENT ON TABLE service_usage IS 'Monthly load generated by internal services, billed to their wallets';
+COMMENT ON COLUMN service_usage.db_time_us IS 'Time spent in database calls, in microseconds';
+COMMENT ON COLUMN service_usage.charged_at IS 'Time the monthly chargeback claimed the row';
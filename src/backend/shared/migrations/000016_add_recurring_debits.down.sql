@@ -0,0 +1,5 @@
+-- Migration: 000016_add_recurring_debits.down.sql
+-- Description: Drops recurring debits and their run log.
+
+DROP TABLE IF EXISTS recurring_debit_runs CASCADE;
+DROP TABLE IF EXISTS recurring_debits CASCADE;
@@ -0,0 +1,43 @@
+-- Create recurring_debits table holding customer standing instructions that
+-- debit a wallet on a cadence
+CREATE TABLE recurring_debits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE RESTRICT,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0.00),
+    currency VARCHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    description VARCHAR(255),
+    cadence VARCHAR(20) NOT NULL CHECK (cadence IN ('DAILY', 'WEEKLY', 'MONTHLY')),
+    start_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_at TIMESTAMP WITH TIME ZONE,
+    run_count INTEGER NOT NULL DEFAULT 0 CHECK (run_count >= 0),
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_at IS NULL OR end_at >= start_at)
+);
+
+CREATE INDEX idx_recurring_debits_customer ON recurring_debits(customer_id, created_at);
+CREATE INDEX idx_recurring_debits_due ON recurring_debits(next_run_at) WHERE next_run_at IS NOT NULL;
+
+-- Create recurring_debit_runs table recording each scheduled run, claimed
+-- before its debit so a run executes once across schedulers and restarts
+CREATE TABLE recurring_debit_runs (
+    recurring_debit_id UUID NOT NULL REFERENCES recurring_debits(id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED', 'SKIPPED')),
+    transaction_id UUID REFERENCES wallet_transactions(id) ON DELETE RESTRICT,
+    error TEXT,
+    claimed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (recurring_debit_id, scheduled_for)
+);
+
+COMMENT ON TABLE recurring_debits IS 'Standing instructions debiting a wallet on a cadence';
+COMMENT ON COLUMN recurring_debits.run_count IS 'Number of scheduled runs finished, whatever their outcome';
+COMMENT ON COLUMN recurring_debits.next_run_at IS 'When the next run is due, NULL once completed or cancelled';
+COMMENT ON TABLE recurring_debit_runs IS 'Outcome of each scheduled run of a recurring debit';
+COMMENT ON COLUMN recurring_debit_runs.idempotency_key IS 'Reference ID of the debit transaction of the run';
+COMMENT ON COLUMN recurring_debit_runs.claimed_at IS 'When a scheduler claimed the run; stale pending claims are taken over';
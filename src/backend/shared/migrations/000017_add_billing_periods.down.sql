@@ -0,0 +1,6 @@
+-- Migration: 000017_add_billing_periods.down.sql
+-- Description: Drops billing period locks and transaction effective dates.
+
+DROP TABLE IF EXISTS billing_periods CASCADE;
+COMMENT ON COLUMN wallet_transactions.effective_at IS NULL;
+ALTER TABLE wallet_transactions DROP COLUMN IF EXISTS effective_at;
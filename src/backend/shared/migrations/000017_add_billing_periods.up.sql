@@ -0,0 +1,22 @@
+-- Record the date a backdated transaction applies to, which decides the
+-- billing period it posts into. Transactions without one post into the
+-- period of their creation.
+ALTER TABLE wallet_transactions ADD COLUMN effective_at TIMESTAMP WITH TIME ZONE;
+
+-- Create billing_periods table holding the close state of calendar months.
+-- Months without a row are open.
+CREATE TABLE billing_periods (
+    period DATE PRIMARY KEY CHECK (EXTRACT(DAY FROM period) = 1),
+    status VARCHAR(10) NOT NULL CHECK (status IN ('OPEN', 'CLOSED')),
+    closed_by VARCHAR(255),
+    closed_at TIMESTAMP WITH TIME ZONE,
+    reopened_by VARCHAR(255),
+    reopened_at TIMESTAMP WITH TIME ZONE,
+    reason TEXT,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON COLUMN wallet_transactions.effective_at IS 'Date a backdated transaction applies to, NULL when it applies at creation';
+COMMENT ON TABLE billing_periods IS 'Close state of billing periods; closed periods refuse postings until reopened';
+COMMENT ON COLUMN billing_periods.period IS 'First day of the calendar month, in UTC';
+COMMENT ON COLUMN billing_periods.reason IS 'Reason given for the latest close or reopening';
@@ -0,0 +1,5 @@
+-- Migration: 000018_index_transaction_effective_dates.down.sql
+-- Description: Drops the transaction effective date indexes.
+
+DROP INDEX IF EXISTS idx_wallet_transactions_effective;
+DROP INDEX IF EXISTS idx_wallet_transactions_wallet_effective;
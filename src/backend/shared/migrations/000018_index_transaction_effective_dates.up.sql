@@ -0,0 +1,4 @@
+-- Index the effective date of transactions, their creation date unless
+-- backdated, which statements and activity aggregates filter and order by
+CREATE INDEX idx_wallet_transactions_wallet_effective ON wallet_transactions(wallet_id, (COALESCE(effective_at, created_at)));
+CREATE INDEX idx_wallet_transactions_effective ON wallet_transactions((COALESCE(effective_at, created_at)));
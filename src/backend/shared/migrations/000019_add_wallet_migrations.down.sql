@@ -0,0 +1,4 @@
+-- Migration: 000019_add_wallet_migrations.down.sql
+-- Description: Drops wallet migration records, unfreezing migrated wallets.
+
+DROP TABLE IF EXISTS wallet_migrations CASCADE;
@@ -0,0 +1,17 @@
+-- Create wallet_migrations table recording wallets moved between
+-- deployments. A FROZEN wallet refuses postings: on the source once it has
+-- been exported, on the target until its import is activated.
+CREATE TABLE wallet_migrations (
+    wallet_id UUID PRIMARY KEY REFERENCES wallets(id) ON DELETE RESTRICT,
+    direction VARCHAR(6) NOT NULL CHECK (direction IN ('EXPORT', 'IMPORT')),
+    status VARCHAR(6) NOT NULL CHECK (status IN ('FROZEN', 'ACTIVE')),
+    checksum CHAR(64) NOT NULL,
+    exported_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    activated_at TIMESTAMP WITH TIME ZONE,
+    CHECK (direction = 'IMPORT' OR status = 'FROZEN')
+);
+
+COMMENT ON TABLE wallet_migrations IS 'Latest move of each wallet out of or into this deployment';
+COMMENT ON COLUMN wallet_migrations.checksum IS 'SHA-256 of the wallet export, confirmed when activating an import';
+COMMENT ON COLUMN wallet_migrations.activated_at IS 'When an imported wallet was activated, NULL while frozen';
@@ -0,0 +1,6 @@
+-- Migration: 000020_add_customer_consents.down.sql
+-- Description: Drops customer consents and their history, so no customer has
+-- consented to notifications.
+
+DROP TABLE IF EXISTS customer_consent_events CASCADE;
+DROP TABLE IF EXISTS customer_consents CASCADE;
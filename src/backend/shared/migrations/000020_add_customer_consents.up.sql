@@ -0,0 +1,27 @@
+-- Create customer_consents table holding each customer's current consent to
+-- be contacted on a channel. Customers without a row have not consented.
+CREATE TABLE customer_consents (
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    channel VARCHAR(5) NOT NULL CHECK (channel IN ('EMAIL', 'SMS')),
+    granted BOOLEAN NOT NULL,
+    source VARCHAR(7) NOT NULL CHECK (source IN ('PORTAL', 'SUPPORT', 'API')),
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (customer_id, channel)
+);
+
+-- Create customer_consent_events table, the append-only log of every consent
+-- change kept as evidence of when and where consent was given or withdrawn
+CREATE TABLE customer_consent_events (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    channel VARCHAR(5) NOT NULL CHECK (channel IN ('EMAIL', 'SMS')),
+    granted BOOLEAN NOT NULL,
+    source VARCHAR(7) NOT NULL CHECK (source IN ('PORTAL', 'SUPPORT', 'API')),
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_customer_consent_events_customer ON customer_consent_events(customer_id, recorded_at DESC);
+
+COMMENT ON TABLE customer_consents IS 'Current consent of each customer to notifications per channel';
+COMMENT ON COLUMN customer_consents.source IS 'Where the latest consent change was made';
+COMMENT ON TABLE customer_consent_events IS 'Append-only history of customer consent changes';
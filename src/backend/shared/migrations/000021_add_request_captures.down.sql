@@ -0,0 +1,4 @@
+-- Migration: 000021_add_request_captures.down.sql
+-- Description: Drops the captured request bodies.
+
+DROP TABLE IF EXISTS request_captures CASCADE;
@@ -0,0 +1,25 @@
+-- Create request_captures table holding the redacted bodies of sampled API
+-- requests and their responses, kept to investigate payment disputes
+CREATE TABLE request_captures (
+    id UUID PRIMARY KEY,
+    correlation_id VARCHAR(128) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    customer_id UUID,
+    subject VARCHAR(255),
+    request_body TEXT NOT NULL,
+    response_body TEXT NOT NULL,
+    truncated BOOLEAN NOT NULL DEFAULT false,
+    duration_ms BIGINT NOT NULL,
+    captured_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_request_captures_captured_at ON request_captures(captured_at);
+CREATE INDEX idx_request_captures_correlation ON request_captures(correlation_id);
+CREATE INDEX idx_request_captures_customer ON request_captures(customer_id, captured_at DESC) WHERE customer_id IS NOT NULL;
+
+COMMENT ON TABLE request_captures IS 'Redacted bodies of sampled API requests, purged after the retention period';
+COMMENT ON COLUMN request_captures.customer_id IS 'Customer the request was authenticated as, not a foreign key so captures outlive customers until purged';
+COMMENT ON COLUMN request_captures.truncated IS 'Whether a body was cut to the configured size cap after redaction';
@@ -0,0 +1,4 @@
+-- Migration: 000022_add_currencies.down.sql
+-- Description: Drops the supported currencies.
+
+DROP TABLE IF EXISTS currencies CASCADE;
@@ -0,0 +1,20 @@
+-- Create currencies table listing the currencies transactions may use and the
+-- decimal places their amounts are rounded to, read at startup when the
+-- service takes its currencies from the database
+CREATE TABLE currencies (
+    code CHAR(3) PRIMARY KEY CHECK (code ~ '^[A-Z]{3}$'),
+    precision SMALLINT NOT NULL CHECK (precision BETWEEN 0 AND 4),
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO currencies (code, precision) VALUES
+    ('USD', 2),
+    ('INR', 2),
+    ('IDR', 2)
+ON CONFLICT DO NOTHING;
+
+COMMENT ON TABLE currencies IS 'Currencies transactions may use; codes are validated against ISO 4217 at startup';
+COMMENT ON COLUMN currencies.precision IS 'Decimal places transaction amounts are rounded to, at most the scale of the amount columns';
+COMMENT ON COLUMN currencies.enabled IS 'Disabled currencies are refused for new transactions while existing wallets keep them';
@@ -0,0 +1,4 @@
+-- Migration: 000023_add_report_jobs.down.sql
+-- Description: Drops the report jobs.
+
+DROP TABLE IF EXISTS report_jobs CASCADE;
@@ -0,0 +1,30 @@
+-- Create report_jobs table holding long-running reports such as statements,
+-- exports and reconciliation runs. Workers claim queued jobs, report progress
+-- through heartbeats, and store the result with the job.
+CREATE TABLE report_jobs (
+    id UUID PRIMARY KEY,
+    kind VARCHAR(64) NOT NULL,
+    status VARCHAR(9) NOT NULL CHECK (status IN ('QUEUED', 'RUNNING', 'SUCCEEDED', 'FAILED', 'CANCELLED')),
+    params JSONB,
+    progress SMALLINT NOT NULL DEFAULT 0 CHECK (progress BETWEEN 0 AND 100),
+    attempts INTEGER NOT NULL DEFAULT 0 CHECK (attempts >= 0),
+    max_attempts INTEGER NOT NULL CHECK (max_attempts >= 1),
+    result JSONB,
+    error TEXT,
+    submitted_by VARCHAR(255) NOT NULL,
+    cancel_requested BOOLEAN NOT NULL DEFAULT false,
+    run_after TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    heartbeat_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_report_jobs_runnable ON report_jobs(run_after) WHERE status IN ('QUEUED', 'RUNNING');
+CREATE INDEX idx_report_jobs_created ON report_jobs(created_at DESC);
+CREATE INDEX idx_report_jobs_kind ON report_jobs(kind, created_at DESC);
+
+COMMENT ON TABLE report_jobs IS 'Long-running report jobs with their progress and results';
+COMMENT ON COLUMN report_jobs.run_after IS 'Earliest time a queued job may run, pushed back between retries';
+COMMENT ON COLUMN report_jobs.heartbeat_at IS 'Last sign of life of the worker running the job; a stale heartbeat hands the job to another worker';
+COMMENT ON COLUMN report_jobs.cancel_requested IS 'Set when cancellation is requested for a running job, which stops at its next heartbeat';
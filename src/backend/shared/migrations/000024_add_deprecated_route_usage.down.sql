@@ -0,0 +1,4 @@
+-- Migration: 000024_add_deprecated_route_usage.down.sql
+-- Description: Drops the usage counters of deprecated API routes.
+
+DROP TABLE IF EXISTS deprecated_route_usage CASCADE;
@@ -0,0 +1,18 @@
+-- Create deprecated_route_usage table counting the calls each integration
+-- still makes to deprecated API routes, so it can be asked to migrate before
+-- the routes are removed
+CREATE TABLE deprecated_route_usage (
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    caller VARCHAR(255) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0 CHECK (requests >= 0),
+    first_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (method, route, caller)
+);
+
+CREATE INDEX idx_deprecated_route_usage_last_seen ON deprecated_route_usage (last_seen_at);
+
+COMMENT ON TABLE deprecated_route_usage IS 'Calls to deprecated API routes by caller';
+COMMENT ON COLUMN deprecated_route_usage.route IS 'Router path of the route, e.g. /api/v1/wallets/:id/balance';
+COMMENT ON COLUMN deprecated_route_usage.caller IS 'Token subject of the caller';
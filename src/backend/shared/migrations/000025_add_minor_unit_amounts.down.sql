@@ -0,0 +1,14 @@
+-- Migration: 000025_add_minor_unit_amounts.down.sql
+-- Description: Drops the minor unit amounts and currency rounding modes.
+
+DROP TRIGGER IF EXISTS set_wallet_transactions_amount_minor ON wallet_transactions;
+DROP FUNCTION IF EXISTS set_transaction_amount_minor();
+
+ALTER TABLE wallet_transactions
+    DROP CONSTRAINT IF EXISTS wallet_transactions_amount_minor_exact,
+    DROP COLUMN IF EXISTS amount_minor,
+    DROP COLUMN IF EXISTS amount_exponent;
+
+UPDATE currencies SET precision = 2, updated_at = CURRENT_TIMESTAMP WHERE code = 'IDR';
+
+ALTER TABLE currencies DROP COLUMN IF EXISTS rounding_mode;
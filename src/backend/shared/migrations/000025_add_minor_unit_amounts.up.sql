@@ -0,0 +1,58 @@
+-- Store transaction amounts as integer minor units with the exponent of
+-- their currency alongside the decimal amount, and round each currency in
+-- its own mode. IDR amounts have no decimals.
+ALTER TABLE currencies
+    ADD COLUMN rounding_mode VARCHAR(10) NOT NULL DEFAULT 'HALF_UP'
+        CHECK (rounding_mode IN ('HALF_UP', 'HALF_EVEN', 'DOWN', 'UP', 'CEILING', 'FLOOR'));
+
+UPDATE currencies SET precision = 0, updated_at = CURRENT_TIMESTAMP WHERE code = 'IDR';
+
+ALTER TABLE wallet_transactions
+    ADD COLUMN amount_minor BIGINT,
+    ADD COLUMN amount_exponent SMALLINT CHECK (amount_exponent BETWEEN 0 AND 4);
+
+-- Derives the minor units of transactions written without them from the
+-- precision of their currency, keeping amounts recorded at a finer
+-- precision exact
+CREATE OR REPLACE FUNCTION set_transaction_amount_minor()
+RETURNS TRIGGER AS $$
+DECLARE
+    places SMALLINT;
+BEGIN
+    IF NEW.amount_minor IS NULL THEN
+        SELECT precision INTO places FROM currencies WHERE code = NEW.currency;
+        places := COALESCE(places, SCALE(NEW.amount));
+        IF NEW.amount <> ROUND(NEW.amount, places) THEN
+            places := SCALE(NEW.amount);
+        END IF;
+        NEW.amount_exponent := places;
+        NEW.amount_minor := NEW.amount * POWER(10::NUMERIC, places);
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+-- Backfill existing transactions the same way
+UPDATE wallet_transactions t
+SET amount_exponent = CASE WHEN t.amount = ROUND(t.amount, c.precision) THEN c.precision ELSE SCALE(t.amount) END
+FROM currencies c
+WHERE c.code = t.currency;
+
+UPDATE wallet_transactions SET amount_exponent = SCALE(amount) WHERE amount_exponent IS NULL;
+
+UPDATE wallet_transactions SET amount_minor = amount * POWER(10::NUMERIC, amount_exponent);
+
+ALTER TABLE wallet_transactions
+    ALTER COLUMN amount_minor SET NOT NULL,
+    ALTER COLUMN amount_exponent SET NOT NULL,
+    ADD CONSTRAINT wallet_transactions_amount_minor_exact
+        CHECK (amount = amount_minor / POWER(10::NUMERIC, amount_exponent));
+
+CREATE TRIGGER set_wallet_transactions_amount_minor
+    BEFORE INSERT ON wallet_transactions
+    FOR EACH ROW
+    EXECUTE FUNCTION set_transaction_amount_minor();
+
+COMMENT ON COLUMN currencies.rounding_mode IS 'How amounts are rounded to the precision of the currency';
+COMMENT ON COLUMN wallet_transactions.amount_minor IS 'Amount in integer minor units of the currency, e.g. cents';
+COMMENT ON COLUMN wallet_transactions.amount_exponent IS 'Decimal places of the minor units: amount = amount_minor / 10^amount_exponent';
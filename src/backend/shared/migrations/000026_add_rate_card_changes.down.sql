@@ -0,0 +1,4 @@
+-- Migration: 000026_add_rate_card_changes.down.sql
+-- Description: Drops the reviewed changes of the chargeback rate card.
+
+DROP TABLE IF EXISTS rate_card_changes CASCADE;
@@ -0,0 +1,39 @@
+-- Create rate_card_changes table holding proposed changes of the internal
+-- usage chargeback rates with their diffs and reviews. A change takes effect
+-- only once approved by an operator other than its proposer and after
+-- finance was notified; the latest activated change holds the rates in effect.
+CREATE TABLE rate_card_changes (
+    id UUID PRIMARY KEY,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'ACTIVE', 'SUPERSEDED')),
+    per_thousand_requests DECIMAL(19,6) NOT NULL CHECK (per_thousand_requests >= 0),
+    per_db_second DECIMAL(19,6) NOT NULL CHECK (per_db_second >= 0),
+    per_gb DECIMAL(19,6) NOT NULL CHECK (per_gb >= 0),
+    previous_per_thousand_requests DECIMAL(19,6) NOT NULL,
+    previous_per_db_second DECIMAL(19,6) NOT NULL,
+    previous_per_gb DECIMAL(19,6) NOT NULL,
+    diff JSONB NOT NULL,
+    effective_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason TEXT NOT NULL,
+    proposed_by VARCHAR(255) NOT NULL,
+    reviewed_by VARCHAR(255),
+    review_note TEXT,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    finance_notified_at TIMESTAMP WITH TIME ZONE,
+    activated_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT rate_card_changes_four_eyes CHECK (reviewed_by IS NULL OR reviewed_by <> proposed_by OR status = 'REJECTED'),
+    CONSTRAINT rate_card_changes_notified CHECK (status NOT IN ('ACTIVE', 'SUPERSEDED') OR (finance_notified_at IS NOT NULL AND activated_at IS NOT NULL))
+);
+
+-- At most one change is open for review or awaiting activation
+CREATE UNIQUE INDEX idx_rate_card_changes_open ON rate_card_changes ((true))
+    WHERE status IN ('PENDING', 'APPROVED');
+
+CREATE INDEX idx_rate_card_changes_activated ON rate_card_changes (activated_at DESC)
+    WHERE status IN ('ACTIVE', 'SUPERSEDED');
+
+CREATE INDEX idx_rate_card_changes_created ON rate_card_changes (created_at DESC);
+
+COMMENT ON TABLE rate_card_changes IS 'Reviewed changes of the internal usage chargeback rates';
+COMMENT ON COLUMN rate_card_changes.diff IS 'Rates changed from the previous rate card, as field, from and to';
+COMMENT ON COLUMN rate_card_changes.finance_notified_at IS 'When finance was sent the approved change; changes activate only after';
@@ -0,0 +1,8 @@
+-- Migration: 000027_add_wallet_settings.down.sql
+-- Description: Removes wallet notification preferences and the auto top-up toggle.
+
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS settings_version,
+    DROP COLUMN IF EXISTS auto_topup_enabled,
+    DROP COLUMN IF EXISTS notify_credit_limit_warning,
+    DROP COLUMN IF EXISTS notify_low_balance;
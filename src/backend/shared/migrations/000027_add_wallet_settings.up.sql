@@ -0,0 +1,12 @@
+-- Per-wallet notification preferences and the auto top-up toggle. Settings
+-- carry their own version so balance updates don't conflict with edits.
+ALTER TABLE wallets
+    ADD COLUMN notify_low_balance BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN notify_credit_limit_warning BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN auto_topup_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN settings_version BIGINT NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN wallets.notify_low_balance IS 'Whether the customer is alerted when the balance falls to the low balance threshold';
+COMMENT ON COLUMN wallets.notify_credit_limit_warning IS 'Whether the customer is alerted as the credit limit runs out';
+COMMENT ON COLUMN wallets.auto_topup_enabled IS 'Whether the customer opted into automatic top-ups of a low balance';
+COMMENT ON COLUMN wallets.settings_version IS 'Optimistic lock of the wallet settings, bumped on every settings update';
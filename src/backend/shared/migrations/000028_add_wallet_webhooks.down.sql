@@ -0,0 +1,6 @@
+-- Migration: 000028_add_wallet_webhooks.down.sql
+-- Description: Removes wallet-level webhook subscriptions and their delivery logs.
+
+DELETE FROM webhook_subscriptions WHERE wallet_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_webhook_subscriptions_wallet;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS wallet_id;
@@ -0,0 +1,8 @@
+-- Webhook subscriptions scoped to a single wallet, managed through the wallet
+-- rather than the tenant and receiving only that wallet's events
+ALTER TABLE webhook_subscriptions
+    ADD COLUMN wallet_id UUID REFERENCES wallets(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_webhook_subscriptions_wallet ON webhook_subscriptions(wallet_id) WHERE wallet_id IS NOT NULL;
+
+COMMENT ON COLUMN webhook_subscriptions.wallet_id IS 'Wallet owning a wallet-level subscription, NULL for tenant-level subscriptions';
@@ -0,0 +1,4 @@
+-- Migration: 000029_add_wallet_merges.down.sql
+-- Description: Removes wallet merge records. Merged histories stay with their surviving wallets.
+
+DROP TABLE IF EXISTS wallet_merges;
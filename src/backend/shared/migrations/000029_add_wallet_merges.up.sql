@@ -0,0 +1,26 @@
+-- Create wallet_merges table recording duplicate wallets merged into a
+-- surviving wallet. A MERGED source wallet refuses postings and redirects to
+-- its target; the moved history and balance can be put back until
+-- reversible_until.
+CREATE TABLE wallet_merges (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    source_wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    target_wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    status VARCHAR(8) NOT NULL CHECK (status IN ('MERGED', 'REVERSED')),
+    amount DECIMAL(20,4) NOT NULL,
+    transaction_ids UUID[] NOT NULL DEFAULT '{}',
+    merged_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reversible_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    reversed_at TIMESTAMP WITH TIME ZONE,
+    CHECK (source_wallet_id <> target_wallet_id),
+    CHECK (status = 'MERGED' OR reversed_at IS NOT NULL)
+);
+
+-- A wallet redirects to at most one surviving wallet
+CREATE UNIQUE INDEX idx_wallet_merges_source ON wallet_merges(source_wallet_id) WHERE status = 'MERGED';
+CREATE INDEX idx_wallet_merges_target ON wallet_merges(target_wallet_id) WHERE status = 'MERGED';
+
+COMMENT ON TABLE wallet_merges IS 'Duplicate wallets merged into a surviving wallet, reversible within a window';
+COMMENT ON COLUMN wallet_merges.amount IS 'Balance moved from the source wallet to the target wallet';
+COMMENT ON COLUMN wallet_merges.transaction_ids IS 'Transactions moved from the source wallet, moved back on reversal';
+COMMENT ON COLUMN wallet_merges.reversible_until IS 'Until when the merge may be reversed';
@@ -0,0 +1,4 @@
+-- Migration: 000030_add_fee_rules.down.sql
+-- Description: Removes fee rules and their history.
+
+DROP TABLE IF EXISTS fee_rules;
@@ -0,0 +1,33 @@
+-- Create fee_rules table pricing the fees of transactions by source and
+-- currency. A fee is a fixed amount plus a percentage of the transaction
+-- amount, held between an optional minimum and maximum. Rules are never
+-- edited: a change is a new rule with a later effective date, and the most
+-- specific rule in effect at the transaction's time applies.
+CREATE TABLE fee_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    source VARCHAR(64),
+    currency VARCHAR(3) CHECK (currency ~ '^[A-Z]{3}$'),
+    fixed_amount DECIMAL(20,4) NOT NULL DEFAULT 0 CHECK (fixed_amount >= 0),
+    percentage DECIMAL(7,4) NOT NULL DEFAULT 0 CHECK (percentage >= 0 AND percentage <= 100),
+    min_fee DECIMAL(20,4) CHECK (min_fee >= 0),
+    max_fee DECIMAL(20,4) CHECK (max_fee >= 0),
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    effective_until TIMESTAMP WITH TIME ZONE,
+    reason TEXT NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CHECK (max_fee IS NULL OR min_fee IS NULL OR max_fee >= min_fee),
+    CHECK (effective_until IS NULL OR effective_until > effective_from),
+    -- Amounts are in the rule's currency, so rules for every currency are
+    -- percentage-only
+    CHECK (currency IS NOT NULL OR (fixed_amount = 0 AND min_fee IS NULL AND max_fee IS NULL))
+);
+
+CREATE INDEX idx_fee_rules_effective ON fee_rules(effective_from DESC);
+CREATE INDEX idx_fee_rules_created ON fee_rules(created_at DESC);
+
+COMMENT ON TABLE fee_rules IS 'Effective-dated fee rules by transaction source and currency';
+COMMENT ON COLUMN fee_rules.source IS 'Transaction source the rule applies to, from the source metadata of transactions; NULL applies to every source';
+COMMENT ON COLUMN fee_rules.currency IS 'Currency the rule applies to; NULL applies to every currency';
+COMMENT ON COLUMN fee_rules.percentage IS 'Percent of the transaction amount charged on top of fixed_amount';
+COMMENT ON COLUMN fee_rules.effective_until IS 'When the rule was ended; NULL applies until superseded';
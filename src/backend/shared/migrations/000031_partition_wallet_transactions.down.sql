@@ -0,0 +1,49 @@
+-- Migration: 000031_partition_wallet_transactions.down.sql
+-- Description: Moves transactions, archived ones included, back into a
+-- single table and restores the foreign keys to them.
+
+CREATE TABLE wallet_transactions_unpartitioned (
+    LIKE wallet_transactions INCLUDING DEFAULTS INCLUDING CONSTRAINTS
+);
+
+INSERT INTO wallet_transactions_unpartitioned SELECT * FROM wallet_transactions;
+
+ALTER TABLE wallet_transactions_archive DROP COLUMN archived_at;
+INSERT INTO wallet_transactions_unpartitioned SELECT * FROM wallet_transactions_archive;
+
+DROP TABLE IF EXISTS wallet_transactions_archive;
+DROP TABLE wallet_transactions;
+DROP FUNCTION IF EXISTS create_wallet_transactions_partition(DATE);
+
+ALTER TABLE wallet_transactions_unpartitioned RENAME TO wallet_transactions;
+
+ALTER TABLE wallet_transactions
+    ADD PRIMARY KEY (id),
+    ADD FOREIGN KEY (wallet_id) REFERENCES wallets(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_wallet_transactions_wallet ON wallet_transactions(wallet_id);
+CREATE INDEX idx_wallet_transactions_status ON wallet_transactions(status);
+CREATE INDEX idx_wallet_transactions_created ON wallet_transactions(created_at);
+CREATE INDEX idx_wallet_transactions_reference ON wallet_transactions(reference_id);
+CREATE INDEX idx_wallet_transactions_metadata ON wallet_transactions USING GIN (metadata jsonb_path_ops);
+CREATE INDEX idx_wallet_transactions_wallet_created ON wallet_transactions(wallet_id, created_at);
+CREATE INDEX idx_wallet_transactions_wallet_effective ON wallet_transactions(wallet_id, (COALESCE(effective_at, created_at)));
+CREATE INDEX idx_wallet_transactions_effective ON wallet_transactions((COALESCE(effective_at, created_at)));
+
+CREATE TRIGGER set_wallet_transactions_amount_minor
+    BEFORE INSERT ON wallet_transactions
+    FOR EACH ROW
+    EXECUTE FUNCTION set_transaction_amount_minor();
+
+ALTER TABLE provider_payments
+    ADD CONSTRAINT provider_payments_transaction_id_fkey
+        FOREIGN KEY (transaction_id) REFERENCES wallet_transactions(id) ON DELETE RESTRICT;
+ALTER TABLE provider_refunds
+    ADD CONSTRAINT provider_refunds_transaction_id_fkey
+        FOREIGN KEY (transaction_id) REFERENCES wallet_transactions(id) ON DELETE RESTRICT;
+ALTER TABLE service_usage
+    ADD CONSTRAINT service_usage_charged_transaction_id_fkey
+        FOREIGN KEY (charged_transaction_id) REFERENCES wallet_transactions(id) ON DELETE RESTRICT;
+ALTER TABLE recurring_debit_runs
+    ADD CONSTRAINT recurring_debit_runs_transaction_id_fkey
+        FOREIGN KEY (transaction_id) REFERENCES wallet_transactions(id) ON DELETE RESTRICT;
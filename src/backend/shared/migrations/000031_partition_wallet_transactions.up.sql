@@ -0,0 +1,90 @@
+-- Partition wallet_transactions by month of created_at, so history queries
+-- bounded by date scan only the months they cover and old months can be
+-- archived by detaching their partition. The keys of a partitioned table
+-- must include created_at, so the foreign keys to a transaction by ID alone
+-- are dropped; the referencing rows keep their transaction IDs.
+ALTER TABLE provider_payments DROP CONSTRAINT IF EXISTS provider_payments_transaction_id_fkey;
+ALTER TABLE provider_refunds DROP CONSTRAINT IF EXISTS provider_refunds_transaction_id_fkey;
+ALTER TABLE service_usage DROP CONSTRAINT IF EXISTS service_usage_charged_transaction_id_fkey;
+ALTER TABLE recurring_debit_runs DROP CONSTRAINT IF EXISTS recurring_debit_runs_transaction_id_fkey;
+
+ALTER TABLE wallet_transactions RENAME TO wallet_transactions_unpartitioned;
+
+CREATE TABLE wallet_transactions (
+    LIKE wallet_transactions_unpartitioned INCLUDING DEFAULTS INCLUDING CONSTRAINTS,
+    PRIMARY KEY (id, created_at),
+    FOREIGN KEY (wallet_id) REFERENCES wallets(id) ON DELETE RESTRICT
+) PARTITION BY RANGE (created_at);
+
+-- Creates the partition of the month of a date, named
+-- wallet_transactions_yYYYYmMM, when it does not exist yet. Months are UTC.
+CREATE OR REPLACE FUNCTION create_wallet_transactions_partition(month DATE)
+RETURNS TEXT AS $$
+DECLARE
+    starts_on DATE := date_trunc('month', month)::DATE;
+    partition TEXT := 'wallet_transactions_' || to_char(starts_on, '"y"YYYY"m"MM');
+BEGIN
+    EXECUTE format(
+        'CREATE TABLE IF NOT EXISTS %I PARTITION OF wallet_transactions FOR VALUES FROM (%L) TO (%L)',
+        partition,
+        starts_on::TIMESTAMP AT TIME ZONE 'UTC',
+        (starts_on + INTERVAL '1 month')::TIMESTAMP AT TIME ZONE 'UTC');
+    RETURN partition;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Partitions for every month with transactions and the next three, which
+-- the archival worker keeps extending
+SELECT create_wallet_transactions_partition(month::DATE)
+FROM generate_series(
+    date_trunc('month', COALESCE(
+        (SELECT MIN(created_at) FROM wallet_transactions_unpartitioned),
+        CURRENT_TIMESTAMP) AT TIME ZONE 'UTC'),
+    date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC') + INTERVAL '3 months',
+    INTERVAL '1 month') AS month;
+
+-- Catches transactions outside the created months, which would otherwise
+-- fail to insert
+CREATE TABLE wallet_transactions_default PARTITION OF wallet_transactions DEFAULT;
+
+INSERT INTO wallet_transactions SELECT * FROM wallet_transactions_unpartitioned;
+
+DROP TABLE wallet_transactions_unpartitioned;
+
+CREATE INDEX idx_wallet_transactions_wallet ON wallet_transactions(wallet_id);
+CREATE INDEX idx_wallet_transactions_status ON wallet_transactions(status);
+CREATE INDEX idx_wallet_transactions_created ON wallet_transactions(created_at);
+CREATE INDEX idx_wallet_transactions_reference ON wallet_transactions(reference_id);
+CREATE INDEX idx_wallet_transactions_metadata ON wallet_transactions USING GIN (metadata jsonb_path_ops);
+CREATE INDEX idx_wallet_transactions_wallet_created ON wallet_transactions(wallet_id, created_at);
+CREATE INDEX idx_wallet_transactions_wallet_effective ON wallet_transactions(wallet_id, (COALESCE(effective_at, created_at)));
+CREATE INDEX idx_wallet_transactions_effective ON wallet_transactions((COALESCE(effective_at, created_at)));
+-- Lookups by ID alone, which the primary key no longer serves uniquely
+CREATE INDEX idx_wallet_transactions_id ON wallet_transactions(id);
+
+CREATE TRIGGER set_wallet_transactions_amount_minor
+    BEFORE INSERT ON wallet_transactions
+    FOR EACH ROW
+    EXECUTE FUNCTION set_transaction_amount_minor();
+
+-- Create wallet_transactions_archive table holding the transactions of
+-- months past retention, moved out of their detached partitions
+CREATE TABLE wallet_transactions_archive (
+    LIKE wallet_transactions INCLUDING DEFAULTS INCLUDING CONSTRAINTS,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, created_at)
+);
+
+CREATE INDEX idx_wallet_transactions_archive_wallet ON wallet_transactions_archive(wallet_id, created_at);
+CREATE INDEX idx_wallet_transactions_archive_reference ON wallet_transactions_archive(reference_id);
+
+COMMENT ON TABLE wallet_transactions IS 'Records all wallet transactions with complete state management, partitioned by month of creation';
+COMMENT ON TABLE wallet_transactions_archive IS 'Transactions of months past retention, moved out of wallet_transactions';
+COMMENT ON COLUMN wallet_transactions.type IS 'Transaction type: CREDIT, DEBIT, or REFUND';
+COMMENT ON COLUMN wallet_transactions.status IS 'Transaction state: INITIATED, PROCESSING, COMPLETED, FAILED, or REVERSED';
+COMMENT ON COLUMN wallet_transactions.reference_id IS 'External reference ID for transaction tracking';
+COMMENT ON COLUMN wallet_transactions.metadata IS 'Caller-defined string key-value pairs, at most 20 keys';
+COMMENT ON COLUMN wallet_transactions.effective_at IS 'Date a backdated transaction applies to, NULL when it applies at creation';
+COMMENT ON COLUMN wallet_transactions.amount_minor IS 'Amount in integer minor units of the currency, e.g. cents';
+COMMENT ON COLUMN wallet_transactions.amount_exponent IS 'Decimal places of the minor units: amount = amount_minor / 10^amount_exponent';
+COMMENT ON COLUMN wallet_transactions_archive.archived_at IS 'When the month of the transaction was archived';
@@ -0,0 +1,4 @@
+-- Migration: 000032_add_wallet_batches.down.sql
+-- Description: Drops the rows of bulk wallet provisioning batches.
+
+DROP TABLE IF EXISTS wallet_batch_rows;
@@ -0,0 +1,24 @@
+-- Create wallet_batch_rows table holding the rows of bulk wallet
+-- provisioning batches. Rows start PENDING and record their outcome as the
+-- provisioning job works through them in chunks, so a retried or taken over
+-- job resumes after the last recorded chunk.
+CREATE TABLE wallet_batch_rows (
+    batch_id UUID NOT NULL,
+    row_index INTEGER NOT NULL CHECK (row_index >= 0),
+    customer_id TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    status VARCHAR(7) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'CREATED', 'EXISTS', 'FAILED')),
+    wallet_id UUID,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (batch_id, row_index),
+    CHECK (status = 'PENDING' OR processed_at IS NOT NULL)
+);
+
+CREATE INDEX idx_wallet_batch_rows_created ON wallet_batch_rows(created_at);
+
+COMMENT ON TABLE wallet_batch_rows IS 'Rows of bulk wallet provisioning batches and their per-row outcome';
+COMMENT ON COLUMN wallet_batch_rows.customer_id IS 'Customer ID as submitted, which may not be a valid UUID';
+COMMENT ON COLUMN wallet_batch_rows.currency IS 'Currency as submitted, which may not be supported';
+COMMENT ON COLUMN wallet_batch_rows.wallet_id IS 'Wallet created, or the existing wallet of the customer in the currency';
@@ -0,0 +1,4 @@
+-- Migration: 000033_add_notification_queue.down.sql
+-- Description: Drops the queue of undelivered notifications.
+
+DROP TABLE IF EXISTS notification_queue;
@@ -0,0 +1,26 @@
+-- Create notification_queue table holding the notifications the notification
+-- pipeline could not take while it or its providers were down. Queued
+-- notifications are retried with backoff and deleted once handed over;
+-- those still undelivered at their expiry are kept as EXPIRED for review
+-- until purged.
+CREATE TABLE notification_queue (
+    id UUID PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    event_type VARCHAR(64) NOT NULL,
+    envelope JSONB NOT NULL,
+    status VARCHAR(7) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'EXPIRED')),
+    attempts INTEGER NOT NULL DEFAULT 0 CHECK (attempts >= 0),
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_notification_queue_due ON notification_queue(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX idx_notification_queue_status ON notification_queue(status, created_at);
+
+COMMENT ON TABLE notification_queue IS 'Notifications awaiting redelivery to the notification pipeline';
+COMMENT ON COLUMN notification_queue.event_id IS 'Envelope ID, so a notification published twice is queued once';
+COMMENT ON COLUMN notification_queue.envelope IS 'Event envelope as it was to be published';
+COMMENT ON COLUMN notification_queue.expires_at IS 'Time after which the notification is stale and no longer sent';
@@ -0,0 +1,4 @@
+-- Migration: 000034_add_balance_thresholds.down.sql
+-- Description: Drops the balance alert levels of wallets.
+
+DROP TABLE IF EXISTS wallet_balance_thresholds;
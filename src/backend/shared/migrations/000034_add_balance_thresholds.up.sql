@@ -0,0 +1,24 @@
+-- Create wallet_balance_thresholds table holding the balance alert levels of
+-- wallets as percentages of a reference balance. Each level fires once as
+-- the balance falls below its trigger and re-arms only once the balance
+-- rises above its reset, so a balance hovering around the trigger does not
+-- send a webhook on every transaction.
+CREATE TABLE wallet_balance_thresholds (
+    wallet_id UUID PRIMARY KEY REFERENCES wallets(id) ON DELETE CASCADE,
+    reference_balance DECIMAL(20,4) NOT NULL CHECK (reference_balance > 0),
+    warning_trigger_percent DECIMAL(7,4) NOT NULL,
+    warning_reset_percent DECIMAL(7,4) NOT NULL,
+    critical_trigger_percent DECIMAL(7,4) NOT NULL,
+    critical_reset_percent DECIMAL(7,4) NOT NULL,
+    warning_triggered BOOLEAN NOT NULL DEFAULT FALSE,
+    critical_triggered BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CHECK (warning_trigger_percent > 0 AND warning_reset_percent > warning_trigger_percent AND warning_reset_percent <= 100),
+    CHECK (critical_trigger_percent > 0 AND critical_reset_percent > critical_trigger_percent AND critical_reset_percent <= 100),
+    CHECK (critical_trigger_percent < warning_trigger_percent)
+);
+
+COMMENT ON TABLE wallet_balance_thresholds IS 'Per-wallet warning and critical balance alert levels with hysteresis';
+COMMENT ON COLUMN wallet_balance_thresholds.reference_balance IS 'Balance the percentages are of, such as the usual top-up amount';
+COMMENT ON COLUMN wallet_balance_thresholds.warning_triggered IS 'Whether the warning fired and waits for the balance to rise above its reset';
+COMMENT ON COLUMN wallet_balance_thresholds.critical_triggered IS 'Whether the critical alert fired and waits for the balance to rise above its reset';
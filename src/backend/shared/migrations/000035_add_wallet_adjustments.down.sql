@@ -0,0 +1,15 @@
+-- Migration: 000035_add_wallet_adjustments.down.sql
+-- Description: Drops the adjustment approvals and the adjustment
+-- transaction type. Fails while adjustment transactions are stored.
+
+DROP TABLE IF EXISTS wallet_adjustments CASCADE;
+
+COMMENT ON COLUMN wallet_transactions.type IS 'Transaction type: CREDIT, DEBIT, or REFUND';
+ALTER TABLE wallet_transactions_archive DROP CONSTRAINT IF EXISTS wallet_transactions_type_check;
+ALTER TABLE wallet_transactions_archive
+    ADD CONSTRAINT wallet_transactions_type_check CHECK (type IN ('CREDIT', 'DEBIT', 'REFUND'));
+ALTER TABLE wallet_transactions
+    DROP CONSTRAINT IF EXISTS wallet_transactions_adjustment_linked,
+    DROP CONSTRAINT IF EXISTS wallet_transactions_type_check;
+ALTER TABLE wallet_transactions
+    ADD CONSTRAINT wallet_transactions_type_check CHECK (type IN ('CREDIT', 'DEBIT', 'REFUND'));
@@ -0,0 +1,49 @@
+-- Make manual corrections a transaction type of their own instead of
+-- credits and debits. An adjustment transaction names its reason code, its
+-- direction and the adjustment approving it in its metadata, so ledger sums
+-- can sign it and reports can tell it apart from customer spend.
+ALTER TABLE wallet_transactions DROP CONSTRAINT wallet_transactions_type_check;
+ALTER TABLE wallet_transactions
+    ADD CONSTRAINT wallet_transactions_type_check CHECK (type IN ('CREDIT', 'DEBIT', 'REFUND', 'ADJUSTMENT')),
+    ADD CONSTRAINT wallet_transactions_adjustment_linked CHECK (
+        type <> 'ADJUSTMENT' OR (
+            metadata ? 'adjustment_id'
+            AND metadata ? 'adjustment_reason'
+            AND metadata->>'adjustment_direction' IN ('INCREASE', 'DECREASE')));
+
+ALTER TABLE wallet_transactions_archive DROP CONSTRAINT wallet_transactions_type_check;
+ALTER TABLE wallet_transactions_archive
+    ADD CONSTRAINT wallet_transactions_type_check CHECK (type IN ('CREDIT', 'DEBIT', 'REFUND', 'ADJUSTMENT'));
+
+-- Create wallet_adjustments table holding the adjustments requested by
+-- operators with their reviews. An adjustment is posted only once approved
+-- by an operator other than its requester, and links to its transaction.
+CREATE TABLE wallet_adjustments (
+    id UUID PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING', 'APPROVED', 'POSTED', 'REJECTED')),
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('INCREASE', 'DECREASE')),
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0.00),
+    currency VARCHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    reason_code VARCHAR(40) NOT NULL,
+    note TEXT NOT NULL,
+    requested_by VARCHAR(255) NOT NULL,
+    reviewed_by VARCHAR(255),
+    review_note TEXT,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    transaction_id UUID,
+    posted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT wallet_adjustments_four_eyes CHECK (reviewed_by IS NULL OR reviewed_by <> requested_by OR status = 'REJECTED'),
+    CONSTRAINT wallet_adjustments_posted CHECK (status <> 'POSTED' OR (transaction_id IS NOT NULL AND posted_at IS NOT NULL))
+);
+
+CREATE INDEX idx_wallet_adjustments_wallet ON wallet_adjustments (wallet_id, created_at DESC);
+CREATE INDEX idx_wallet_adjustments_created ON wallet_adjustments (created_at DESC);
+CREATE INDEX idx_wallet_adjustments_posted ON wallet_adjustments (posted_at)
+    WHERE status = 'POSTED';
+
+COMMENT ON TABLE wallet_adjustments IS 'Manual balance corrections requested and approved by two operators';
+COMMENT ON COLUMN wallet_adjustments.reason_code IS 'Reason code reported on: BILLING_ERROR, DUPLICATE_CHARGE, SYSTEM_ERROR, GOODWILL or WRITE_OFF';
+COMMENT ON COLUMN wallet_adjustments.transaction_id IS 'Adjustment transaction posting the correction, assigned on approval';
+COMMENT ON COLUMN wallet_transactions.type IS 'Transaction type: CREDIT, DEBIT, REFUND, or ADJUSTMENT';
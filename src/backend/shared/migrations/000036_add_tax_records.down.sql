@@ -0,0 +1,8 @@
+-- Migration: 000036_add_tax_records.down.sql
+-- Description: Drops the recorded tax breakdowns and the tax regions of
+-- customers. Debits keep the tax amounts in their metadata.
+
+DROP TABLE IF EXISTS tax_records;
+ALTER TABLE customers
+    DROP COLUMN IF EXISTS tax_exempt,
+    DROP COLUMN IF EXISTS tax_region;
@@ -0,0 +1,31 @@
+-- Record the region whose tax rules apply to each customer, set by
+-- operators with any exemption the customer holds
+ALTER TABLE customers
+    ADD COLUMN tax_region VARCHAR(10) CHECK (tax_region ~ '^[A-Z]{2}(-[A-Z0-9]{1,3})?$'),
+    ADD COLUMN tax_exempt BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create tax_records table holding the tax breakdown of each taxed invoice
+-- and debit for filing. A source's reference is recorded once, so retried
+-- invoice runs are not taxed twice.
+CREATE TABLE tax_records (
+    id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL,
+    source VARCHAR(10) NOT NULL CHECK (source IN ('INVOICE', 'DEBIT')),
+    reference VARCHAR(64) NOT NULL,
+    currency VARCHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    region VARCHAR(10) NOT NULL,
+    tax_name VARCHAR(40) NOT NULL,
+    taxable DECIMAL(20,4) NOT NULL CHECK (taxable >= 0),
+    total DECIMAL(20,4) NOT NULL CHECK (total >= 0),
+    lines JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT tax_records_reference_unique UNIQUE (source, reference)
+);
+
+CREATE INDEX idx_tax_records_created ON tax_records (created_at);
+CREATE INDEX idx_tax_records_customer ON tax_records (customer_id, created_at DESC);
+
+COMMENT ON TABLE tax_records IS 'Tax breakdowns of taxed invoices and debits, reported for filing';
+COMMENT ON COLUMN tax_records.reference IS 'Invoice number of INVOICE records, transaction ID of DEBIT records';
+COMMENT ON COLUMN tax_records.lines IS 'Tax lines as [{"name", "rate", "amount"}], one per component of the tax';
+COMMENT ON COLUMN customers.tax_region IS 'ISO 3166 country or subdivision code whose tax rules apply to the customer';
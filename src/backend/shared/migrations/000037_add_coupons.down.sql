@@ -0,0 +1,6 @@
+-- Migration: 000037_add_coupons.down.sql
+-- Description: Drops coupons and the audit of their redemptions. Discounts
+-- already applied to rated charges and invoices are kept there.
+
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
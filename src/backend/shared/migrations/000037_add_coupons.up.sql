@@ -0,0 +1,57 @@
+-- Create coupons table holding the discounts redeemable by code. A coupon
+-- restricted to one customer is a negotiated discount. Percentage coupons
+-- apply to any currency; fixed coupons only to their own.
+CREATE TABLE coupons (
+    id UUID PRIMARY KEY,
+    code VARCHAR(40) NOT NULL UNIQUE CHECK (code ~ '^[A-Z0-9][A-Z0-9_-]*$'),
+    description TEXT NOT NULL DEFAULT '',
+    discount_type VARCHAR(20) NOT NULL CHECK (discount_type IN ('PERCENTAGE', 'FIXED')),
+    value DECIMAL(12,4) NOT NULL CHECK (value > 0),
+    currency VARCHAR(3) CHECK (currency ~ '^[A-Z]{3}$'),
+    customer_id UUID REFERENCES customers(id) ON DELETE RESTRICT,
+    valid_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    valid_until TIMESTAMP WITH TIME ZONE,
+    max_redemptions BIGINT CHECK (max_redemptions > 0),
+    max_redemptions_per_customer BIGINT CHECK (max_redemptions_per_customer > 0),
+    redemptions BIGINT NOT NULL DEFAULT 0 CHECK (redemptions >= 0),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('ACTIVE', 'VOIDED')),
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    voided_by VARCHAR(255),
+    void_reason TEXT,
+    voided_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT coupons_percentage CHECK (discount_type <> 'PERCENTAGE' OR (value <= 100 AND currency IS NULL)),
+    CONSTRAINT coupons_fixed CHECK (discount_type <> 'FIXED' OR currency IS NOT NULL),
+    CONSTRAINT coupons_validity CHECK (valid_until IS NULL OR valid_until > valid_from),
+    CONSTRAINT coupons_limit CHECK (max_redemptions IS NULL OR redemptions <= max_redemptions),
+    CONSTRAINT coupons_voided CHECK (status <> 'VOIDED' OR (voided_by IS NOT NULL AND voided_at IS NOT NULL))
+);
+
+CREATE INDEX idx_coupons_created ON coupons (created_at DESC);
+CREATE INDEX idx_coupons_customer ON coupons (customer_id) WHERE customer_id IS NOT NULL;
+
+-- Create coupon_redemptions table auditing every redemption with the amount
+-- it discounted. A coupon is redeemed once per rated charge or invoice.
+CREATE TABLE coupon_redemptions (
+    id UUID PRIMARY KEY,
+    coupon_id UUID NOT NULL REFERENCES coupons(id) ON DELETE RESTRICT,
+    customer_id UUID NOT NULL,
+    source VARCHAR(20) NOT NULL CHECK (source IN ('RATING', 'INVOICE')),
+    reference VARCHAR(64) NOT NULL,
+    currency VARCHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    amount DECIMAL(12,2) NOT NULL CHECK (amount >= 0.00),
+    discount DECIMAL(12,2) NOT NULL CHECK (discount >= 0.00 AND discount <= amount),
+    redeemed_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (coupon_id, source, reference)
+);
+
+CREATE INDEX idx_coupon_redemptions_coupon ON coupon_redemptions (coupon_id, created_at DESC);
+CREATE INDEX idx_coupon_redemptions_customer ON coupon_redemptions (coupon_id, customer_id);
+
+COMMENT ON TABLE coupons IS 'Percentage and fixed discounts redeemable by code, optionally negotiated for one customer';
+COMMENT ON COLUMN coupons.value IS 'Percentage off for PERCENTAGE coupons, amount off in currency for FIXED coupons';
+COMMENT ON COLUMN coupons.redemptions IS 'Redemptions so far, counted against max_redemptions';
+COMMENT ON TABLE coupon_redemptions IS 'Audit of every coupon redemption at rating or invoice time';
+COMMENT ON COLUMN coupon_redemptions.reference IS 'Rated charge or invoice number the coupon was redeemed on';
+COMMENT ON COLUMN coupon_redemptions.redeemed_by IS 'Service or operator that redeemed the coupon';
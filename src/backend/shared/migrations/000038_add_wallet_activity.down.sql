@@ -0,0 +1,13 @@
+-- Migration: 000038_add_wallet_activity.down.sql
+-- Description: Drops the wallet activity tracking. Inactivity events already
+-- published are not retracted.
+
+DROP TRIGGER IF EXISTS record_wallet_transactions_activity ON wallet_transactions;
+DROP FUNCTION IF EXISTS record_wallet_activity();
+
+DROP INDEX IF EXISTS idx_wallets_inactive_since;
+DROP INDEX IF EXISTS idx_wallets_last_activity;
+
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS inactive_since,
+    DROP COLUMN IF EXISTS last_activity_at;
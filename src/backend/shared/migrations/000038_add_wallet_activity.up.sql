@@ -0,0 +1,41 @@
+-- Track when each wallet last transacted, so wallets idle for longer than
+-- the inactivity period can be flagged inactive and announced, and flagged
+-- wallets announced again once they transact. Existing wallets start from
+-- their latest transaction, or their creation when they have none.
+ALTER TABLE wallets
+    ADD COLUMN last_activity_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN inactive_since TIMESTAMP WITH TIME ZONE;
+
+UPDATE wallets w
+SET last_activity_at = COALESCE(
+    (SELECT MAX(t.created_at) FROM wallet_transactions t WHERE t.wallet_id = w.id),
+    w.created_at);
+
+ALTER TABLE wallets
+    ALTER COLUMN last_activity_at SET DEFAULT CURRENT_TIMESTAMP,
+    ALTER COLUMN last_activity_at SET NOT NULL;
+
+-- Moves a wallet's last activity forward to each transaction recorded for
+-- it. Imported transactions older than the last activity leave it as is.
+CREATE OR REPLACE FUNCTION record_wallet_activity()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE wallets
+    SET last_activity_at = NEW.created_at
+    WHERE id = NEW.wallet_id AND last_activity_at < NEW.created_at;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_wallet_transactions_activity
+    AFTER INSERT ON wallet_transactions
+    FOR EACH ROW
+    EXECUTE FUNCTION record_wallet_activity();
+
+-- Active wallets by last activity, scanned for wallets turning inactive
+CREATE INDEX idx_wallets_last_activity ON wallets(last_activity_at) WHERE inactive_since IS NULL;
+-- Inactive wallets, scanned for wallets that transacted again
+CREATE INDEX idx_wallets_inactive_since ON wallets(inactive_since) WHERE inactive_since IS NOT NULL;
+
+COMMENT ON COLUMN wallets.last_activity_at IS 'When the latest transaction of the wallet was recorded, or its creation';
+COMMENT ON COLUMN wallets.inactive_since IS 'When the wallet was flagged inactive, NULL while it is active';
@@ -0,0 +1,5 @@
+-- Migration: 000039_add_wallet_disputes.down.sql
+-- Description: Drops the dispute records. Their hold transactions are kept,
+-- and unresolved holds stay PROCESSING until released by adjustment.
+
+DROP TABLE IF EXISTS wallet_disputes;
@@ -0,0 +1,35 @@
+-- Create wallet_disputes table tracking chargebacks of wallet top-ups. The
+-- disputed amount is held by a PROCESSING debit until the dispute settles:
+-- a lost dispute completes the debit, reversing the top-up, and a won one
+-- reverses the debit, releasing the hold. Transactions are referenced by ID
+-- alone as wallet_transactions is partitioned.
+CREATE TABLE wallet_disputes (
+    id UUID PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    transaction_id UUID NOT NULL UNIQUE,
+    hold_transaction_id UUID NOT NULL UNIQUE,
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0.00),
+    currency VARCHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('OPEN', 'EVIDENCE_SUBMITTED', 'WON', 'LOST')),
+    reason_code VARCHAR(32) NOT NULL,
+    note TEXT NOT NULL,
+    provider_case_id VARCHAR(128),
+    evidence TEXT,
+    evidence_submitted_at TIMESTAMP WITH TIME ZONE,
+    resolution_note TEXT,
+    opened_by VARCHAR(255) NOT NULL,
+    resolved_by VARCHAR(255),
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT wallet_disputes_evidence CHECK (status <> 'EVIDENCE_SUBMITTED' OR evidence_submitted_at IS NOT NULL),
+    CONSTRAINT wallet_disputes_resolved CHECK (status NOT IN ('WON', 'LOST') OR (resolved_by IS NOT NULL AND resolved_at IS NOT NULL))
+);
+
+CREATE INDEX idx_wallet_disputes_wallet ON wallet_disputes(wallet_id, created_at DESC);
+CREATE INDEX idx_wallet_disputes_unresolved ON wallet_disputes(created_at) WHERE status IN ('OPEN', 'EVIDENCE_SUBMITTED');
+
+COMMENT ON TABLE wallet_disputes IS 'Chargebacks of wallet top-ups, holding the disputed amount until settled';
+COMMENT ON COLUMN wallet_disputes.transaction_id IS 'Disputed top-up, disputed at most once';
+COMMENT ON COLUMN wallet_disputes.hold_transaction_id IS 'PROCESSING debit holding the amount, COMPLETED when lost and REVERSED when won';
+COMMENT ON COLUMN wallet_disputes.provider_case_id IS 'Case ID of the chargeback at the payment provider';
@@ -0,0 +1,5 @@
+-- Migration: 000040_add_bulk_credits.down.sql
+-- Description: Drops bulk credit operations and their rows.
+
+DROP TABLE IF EXISTS bulk_credit_rows;
+DROP TABLE IF EXISTS bulk_credits;
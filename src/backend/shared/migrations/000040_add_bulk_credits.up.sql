@@ -0,0 +1,44 @@
+-- Create bulk_credits table holding bulk credit operations, such as
+-- goodwill credits after an incident, and bulk_credit_rows holding their
+-- wallets. Rows start PENDING and record their outcome as the credit job
+-- works through them in chunks, so a retried or taken over job resumes
+-- after the last recorded chunk. Rolling an operation back reverses its
+-- POSTED rows with debits.
+CREATE TABLE bulk_credits (
+    id UUID PRIMARY KEY,
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0.00),
+    currency VARCHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    description TEXT NOT NULL,
+    total_rows INTEGER NOT NULL CHECK (total_rows > 0),
+    job_id UUID,
+    rollback_job_id UUID,
+    created_by VARCHAR(255) NOT NULL,
+    rollback_requested_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    rollback_requested_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT bulk_credits_rollback CHECK (rollback_job_id IS NULL OR (rollback_requested_by IS NOT NULL AND rollback_requested_at IS NOT NULL))
+);
+
+CREATE INDEX idx_bulk_credits_created ON bulk_credits(created_at);
+
+CREATE TABLE bulk_credit_rows (
+    bulk_credit_id UUID NOT NULL REFERENCES bulk_credits(id) ON DELETE CASCADE,
+    row_index INTEGER NOT NULL CHECK (row_index >= 0),
+    wallet_id TEXT NOT NULL,
+    status VARCHAR(12) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'POSTED', 'FAILED', 'REVERSED', 'NOT_REVERSED')),
+    transaction_id UUID,
+    reversal_id UUID,
+    error TEXT,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (bulk_credit_id, row_index),
+    CHECK (status = 'PENDING' OR processed_at IS NOT NULL),
+    CHECK (status NOT IN ('POSTED', 'REVERSED', 'NOT_REVERSED') OR transaction_id IS NOT NULL),
+    CHECK (status <> 'REVERSED' OR reversal_id IS NOT NULL)
+);
+
+COMMENT ON TABLE bulk_credits IS 'Bulk credit operations crediting one amount to many wallets';
+COMMENT ON COLUMN bulk_credits.job_id IS 'Report job posting the credits, set once it is submitted';
+COMMENT ON COLUMN bulk_credits.rollback_job_id IS 'Report job reversing the posted credits, once rolled back';
+COMMENT ON TABLE bulk_credit_rows IS 'Wallets of bulk credit operations and their per-row outcome';
+COMMENT ON COLUMN bulk_credit_rows.wallet_id IS 'Wallet ID as submitted, which may not be a valid UUID';
+COMMENT ON COLUMN bulk_credit_rows.reversal_id IS 'Debit reversing the credit when the operation was rolled back';
@@ -0,0 +1,4 @@
+-- Migration: 000041_add_balance_alerts.down.sql
+-- Description: Drops the per-wallet balance alert thresholds.
+
+DROP TABLE IF EXISTS wallet_balance_alerts;
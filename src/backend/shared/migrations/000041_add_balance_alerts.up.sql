@@ -0,0 +1,26 @@
+-- Create wallet_balance_alerts table holding per-wallet lists of balance
+-- alert thresholds, each an absolute balance or a percentage of the
+-- wallet's average monthly debits, with the channels it is sent on. A
+-- threshold fires once as the balance falls below its trigger amount and
+-- re-arms once a recharge brings the balance back up to it.
+CREATE TABLE wallet_balance_alerts (
+    id UUID PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    basis VARCHAR(16) NOT NULL CHECK (basis IN ('ABSOLUTE', 'USAGE_PERCENT')),
+    value DECIMAL(20,4) NOT NULL CHECK (value > 0),
+    channels TEXT[] NOT NULL CHECK (cardinality(channels) > 0 AND channels <@ ARRAY['EMAIL', 'SMS', 'WEBHOOK']),
+    trigger_amount DECIMAL(20,4) NOT NULL CHECK (trigger_amount >= 0),
+    triggered BOOLEAN NOT NULL DEFAULT FALSE,
+    triggered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CHECK (basis <> 'USAGE_PERCENT' OR value <= 1000),
+    CHECK (NOT triggered OR triggered_at IS NOT NULL)
+);
+
+CREATE INDEX idx_wallet_balance_alerts_wallet ON wallet_balance_alerts(wallet_id);
+
+COMMENT ON TABLE wallet_balance_alerts IS 'Per-wallet balance alert thresholds with their notification channels';
+COMMENT ON COLUMN wallet_balance_alerts.value IS 'Balance of ABSOLUTE thresholds, or percentage of the average monthly debits of USAGE_PERCENT ones';
+COMMENT ON COLUMN wallet_balance_alerts.trigger_amount IS 'Balance the threshold fires below, resolved from usage when set and on each re-arm';
+COMMENT ON COLUMN wallet_balance_alerts.triggered IS 'Whether the threshold fired and waits for a recharge';
@@ -0,0 +1,4 @@
+-- Migration: 000042_add_incidents.down.sql
+-- Description: Drops the incident switches.
+
+DROP TABLE IF EXISTS incidents;
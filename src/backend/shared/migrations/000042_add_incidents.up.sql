@@ -0,0 +1,30 @@
+-- Create incidents table holding the operator switch pausing transaction
+-- types across the service, such as automated debits during a suspected
+-- rating bug. Transactions from allowlisted sources still go through, and
+-- an incident resumes by itself at its scheduled time. At most one incident
+-- is active; resumed incidents are kept as history.
+CREATE TABLE incidents (
+    id UUID PRIMARY KEY,
+    reason TEXT NOT NULL,
+    pause_debits BOOLEAN NOT NULL,
+    pause_credits BOOLEAN NOT NULL,
+    pause_refunds BOOLEAN NOT NULL,
+    pause_adjustments BOOLEAN NOT NULL,
+    allowed_sources TEXT[] NOT NULL DEFAULT '{}',
+    resume_at TIMESTAMP WITH TIME ZONE,
+    started_by VARCHAR(255) NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_by VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    resumed_by VARCHAR(255),
+    resumed_at TIMESTAMP WITH TIME ZONE,
+    CHECK (pause_debits OR pause_credits OR pause_refunds OR pause_adjustments),
+    CHECK ((resumed_by IS NULL) = (resumed_at IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_incidents_active ON incidents((TRUE)) WHERE resumed_at IS NULL;
+CREATE INDEX idx_incidents_started ON incidents(started_at);
+
+COMMENT ON TABLE incidents IS 'Operator switches pausing transaction types, one active at a time';
+COMMENT ON COLUMN incidents.allowed_sources IS 'Transaction sources let through while their type is paused';
+COMMENT ON COLUMN incidents.resume_at IS 'When the incident resumes by itself, if scheduled';
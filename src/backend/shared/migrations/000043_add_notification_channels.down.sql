@@ -0,0 +1,4 @@
+-- Migration: 000043_add_notification_channels.down.sql
+-- Description: Drops the customer notification channels.
+
+DROP TABLE IF EXISTS notification_channels;
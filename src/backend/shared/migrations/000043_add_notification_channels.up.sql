@@ -0,0 +1,21 @@
+-- Create notification_channels table holding the email addresses, phone
+-- numbers and Slack webhooks customers receive low balance, dunning,
+-- invoice and large transaction notifications on besides webhooks
+CREATE TABLE notification_channels (
+    id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('EMAIL', 'SMS', 'SLACK')),
+    destination VARCHAR(2048) NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    large_transaction_amount DECIMAL(20,4) CHECK (large_transaction_amount > 0),
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_notification_channels_destination ON notification_channels(customer_id, kind, destination);
+CREATE INDEX idx_notification_channels_customer ON notification_channels(customer_id) WHERE enabled;
+
+COMMENT ON TABLE notification_channels IS 'Customer email, SMS and Slack destinations for notifications';
+COMMENT ON COLUMN notification_channels.event_types IS 'Event types notified, empty for all';
+COMMENT ON COLUMN notification_channels.large_transaction_amount IS 'Amount from which completed transactions are notified, none when NULL';
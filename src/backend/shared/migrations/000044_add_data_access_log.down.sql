@@ -0,0 +1,4 @@
+-- Migration: 000044_add_data_access_log.down.sql
+-- Description: Drops the customer data access log.
+
+DROP TABLE IF EXISTS data_access_log;
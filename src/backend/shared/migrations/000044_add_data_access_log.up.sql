@@ -0,0 +1,24 @@
+-- Create data_access_log table recording which credentials viewed balances
+-- or exported transactions of each customer, for their access log
+CREATE TABLE data_access_log (
+    id UUID PRIMARY KEY,
+    customer_id UUID,
+    wallet_id UUID,
+    action VARCHAR(30) NOT NULL CHECK (action IN ('BALANCE_VIEWED', 'TRANSACTIONS_VIEWED', 'TRANSACTIONS_EXPORTED')),
+    accessor_kind VARCHAR(20) NOT NULL CHECK (accessor_kind IN ('API_KEY', 'ADMIN', 'SUPPORT', 'SERVICE', 'ANALYTICS')),
+    subject VARCHAR(255),
+    accessor_customer_id UUID,
+    roles TEXT[] NOT NULL DEFAULT '{}',
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    correlation_id VARCHAR(128) NOT NULL,
+    accessed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_data_access_log_customer ON data_access_log(customer_id, accessed_at DESC);
+CREATE INDEX idx_data_access_log_accessed_at ON data_access_log(accessed_at);
+
+COMMENT ON TABLE data_access_log IS 'Accesses to customer balances and transactions, purged after the retention period';
+COMMENT ON COLUMN data_access_log.customer_id IS 'Customer whose data was accessed, NULL for accesses to every customer such as unfiltered exports; not a foreign key so entries outlive customers until purged';
+COMMENT ON COLUMN data_access_log.subject IS 'Authenticated subject, shown to the customer only for their own API keys';
+COMMENT ON COLUMN data_access_log.roles IS 'Roles of the accessor, internal only';
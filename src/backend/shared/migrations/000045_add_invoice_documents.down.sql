@@ -0,0 +1,4 @@
+-- Migration: 000045_add_invoice_documents.down.sql
+-- Description: Drops the stored invoice PDFs.
+
+DROP TABLE IF EXISTS invoice_documents;
@@ -0,0 +1,19 @@
+-- Create invoice_documents table storing the PDF of each invoice closed by
+-- the billing cycle, for download and re-sends
+CREATE TABLE invoice_documents (
+    invoice_id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL,
+    invoice_number VARCHAR(64) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    total_amount DECIMAL(20,4) NOT NULL CHECK (total_amount >= 0),
+    due_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    pdf BYTEA NOT NULL,
+    rendered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deliveries INTEGER NOT NULL DEFAULT 0,
+    last_delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_invoice_documents_number ON invoice_documents(customer_id, invoice_number);
+
+COMMENT ON TABLE invoice_documents IS 'Rendered invoice PDFs, kept as issued so downloads and re-sends match what the customer first received';
+COMMENT ON COLUMN invoice_documents.deliveries IS 'Times the invoice was sent to the customer''s notification channels, re-sends included';
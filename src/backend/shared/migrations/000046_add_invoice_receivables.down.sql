@@ -0,0 +1,6 @@
+-- Migration: 000046_add_invoice_receivables.down.sql
+-- Description: Drops invoice payment tracking and customer payment terms.
+-- Stored invoice PDFs keep their due dates.
+
+DROP TABLE IF EXISTS invoice_receivables;
+ALTER TABLE customers DROP COLUMN IF EXISTS payment_terms;
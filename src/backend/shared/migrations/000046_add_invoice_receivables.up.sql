@@ -0,0 +1,31 @@
+-- Record the payment terms of each customer, set by operators. Customers
+-- without terms of their own get the deployment's default.
+ALTER TABLE customers
+    ADD COLUMN payment_terms VARCHAR(10) CHECK (payment_terms IN ('NET_15', 'NET_30', 'NET_45', 'NET_60'));
+
+-- Create invoice_receivables table tracking each issued invoice until it is
+-- paid, with the late fee or interest charged after its due date
+CREATE TABLE invoice_receivables (
+    invoice_id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL,
+    invoice_number VARCHAR(64) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    total_amount DECIMAL(20,4) NOT NULL CHECK (total_amount >= 0),
+    due_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    payment_terms VARCHAR(10) NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'OVERDUE', 'PAID')),
+    amount_paid DECIMAL(20,4) NOT NULL DEFAULT 0 CHECK (amount_paid >= 0),
+    paid_at TIMESTAMP WITH TIME ZONE,
+    late_fee DECIMAL(20,4) NOT NULL DEFAULT 0 CHECK (late_fee >= 0),
+    interest DECIMAL(20,4) NOT NULL DEFAULT 0 CHECK (interest >= 0),
+    interest_through TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_invoice_receivables_unpaid ON invoice_receivables(due_date) WHERE status <> 'PAID';
+CREATE INDEX idx_invoice_receivables_customer ON invoice_receivables(customer_id, due_date);
+
+COMMENT ON TABLE invoice_receivables IS 'Issued invoices and their payments, late charges and aging';
+COMMENT ON COLUMN customers.payment_terms IS 'Days the customer has to pay invoices; NULL for the deployment default';
+COMMENT ON COLUMN invoice_receivables.interest_through IS 'End of the last day interest was accrued for';
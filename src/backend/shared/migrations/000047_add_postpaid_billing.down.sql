@@ -0,0 +1,8 @@
+-- Migration: 000047_add_postpaid_billing.down.sql
+-- Description: Drops postpaid charges and statements and customer billing
+-- modes. Statements already settled from wallets keep their debits.
+
+DROP TABLE IF EXISTS postpaid_charges;
+DROP TABLE IF EXISTS postpaid_statements;
+ALTER TABLE customers DROP COLUMN IF EXISTS postpaid_settlement;
+ALTER TABLE customers DROP COLUMN IF EXISTS billing_mode;
@@ -0,0 +1,49 @@
+-- Record how each customer is billed: prepaid customers are debited as they
+-- use, postpaid customers accrue usage billed at the end of each cycle and
+-- settled against the wallet or paid externally
+ALTER TABLE customers
+    ADD COLUMN billing_mode VARCHAR(10) NOT NULL DEFAULT 'PREPAID' CHECK (billing_mode IN ('PREPAID', 'POSTPAID')),
+    ADD COLUMN postpaid_settlement VARCHAR(10) CHECK (postpaid_settlement IN ('WALLET', 'EXTERNAL'));
+
+-- Create postpaid_statements table billing the charges a wallet accrued in
+-- a cycle, pending until debited from the wallet or invoiced
+CREATE TABLE postpaid_statements (
+    id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    currency VARCHAR(3) NOT NULL,
+    invoice_number VARCHAR(64) NOT NULL UNIQUE,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    charges INTEGER NOT NULL DEFAULT 0,
+    total_amount DECIMAL(20,4) NOT NULL DEFAULT 0 CHECK (total_amount >= 0),
+    status VARCHAR(10) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SETTLED', 'INVOICED')),
+    settlement VARCHAR(10) CHECK (settlement IN ('WALLET', 'EXTERNAL')),
+    transaction_id UUID,
+    settled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create postpaid_charges table holding the debits of postpaid customers
+-- until a statement bills them
+CREATE TABLE postpaid_charges (
+    id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    amount DECIMAL(20,4) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    description TEXT,
+    reference_id VARCHAR(255),
+    metadata JSONB,
+    statement_id UUID REFERENCES postpaid_statements(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_postpaid_charges_unbilled ON postpaid_charges(wallet_id, created_at) WHERE statement_id IS NULL;
+CREATE INDEX idx_postpaid_statements_wallet ON postpaid_statements(wallet_id, period_start DESC);
+CREATE INDEX idx_postpaid_statements_pending ON postpaid_statements(created_at) WHERE status = 'PENDING';
+
+COMMENT ON COLUMN customers.billing_mode IS 'PREPAID debits usage as used; POSTPAID bills it at the end of each cycle';
+COMMENT ON COLUMN customers.postpaid_settlement IS 'How postpaid statements are paid; NULL settles against the wallet';
+COMMENT ON TABLE postpaid_charges IS 'Accrued debits of postpaid customers, billed by postpaid_statements';
+COMMENT ON TABLE postpaid_statements IS 'Cycle statements of postpaid wallets and how they were settled';
@@ -0,0 +1,5 @@
+-- Migration: 000048_add_allowance_usage.down.sql
+-- Description: Drops free-tier allowance usage. Debits already discounted
+-- by allowances keep their amounts.
+
+DROP TABLE IF EXISTS allowance_usage;
@@ -0,0 +1,14 @@
+-- Create allowance_usage table counting the free units of each product a
+-- wallet used in a billing period. A new period starts with no row, which
+-- resets the allowance.
+CREATE TABLE allowance_usage (
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    product VARCHAR(100) NOT NULL,
+    period VARCHAR(7) NOT NULL CHECK (period ~ '^[0-9]{4}-[0-9]{2}$'),
+    used BIGINT NOT NULL DEFAULT 0 CHECK (used >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (wallet_id, period, product)
+);
+
+COMMENT ON TABLE allowance_usage IS 'Free-tier units used per wallet, product and billing period';
+COMMENT ON COLUMN allowance_usage.period IS 'Billing period as YYYY-MM in UTC';
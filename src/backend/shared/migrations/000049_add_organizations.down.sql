@@ -0,0 +1,7 @@
+-- Migration: 000049_add_organizations.down.sql
+-- Description: Drops organizations and the organization of customers and
+-- wallets.
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS org_id;
+ALTER TABLE customers DROP COLUMN IF EXISTS org_id;
+DROP TABLE IF EXISTS organizations;
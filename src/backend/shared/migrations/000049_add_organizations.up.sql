@@ -0,0 +1,21 @@
+-- Create organizations table, the tenants owning groups of customers and
+-- their wallets
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Customers and wallets carry the organization they belong to, so tenancy
+-- is enforced on every lookup without joins. Wallets follow their customer.
+ALTER TABLE customers
+    ADD COLUMN org_id UUID REFERENCES organizations(id) ON DELETE RESTRICT;
+ALTER TABLE wallets
+    ADD COLUMN org_id UUID REFERENCES organizations(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_customers_org ON customers(org_id) WHERE org_id IS NOT NULL;
+CREATE INDEX idx_wallets_org ON wallets(org_id, created_at) WHERE org_id IS NOT NULL;
+
+COMMENT ON COLUMN customers.org_id IS 'Organization of the customer; NULL for customers belonging to none';
+COMMENT ON COLUMN wallets.org_id IS 'Organization of the wallet''s customer, kept in step with customers.org_id';
@@ -0,0 +1,4 @@
+-- Migration: 000050_add_wallet_buckets.down.sql
+-- Description: Drops the sub-balances of wallets.
+
+DROP TABLE IF EXISTS wallet_buckets;
@@ -0,0 +1,19 @@
+-- Create wallet_buckets table holding the named sub-balances a wallet's
+-- balance is split into. Buckets earmark part of the balance; what is not
+-- in a bucket is the wallet's unallocated balance.
+CREATE TABLE wallet_buckets (
+    id UUID PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    name VARCHAR(50) NOT NULL,
+    balance DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    low_balance_threshold DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (low_balance_threshold >= 0),
+    fallback VARCHAR(20) NOT NULL DEFAULT 'NONE' CHECK (fallback IN ('NONE', 'UNALLOCATED')),
+    low_balance BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (wallet_id, name)
+);
+
+COMMENT ON TABLE wallet_buckets IS 'Named sub-balances of wallets that debits can be targeted to';
+COMMENT ON COLUMN wallet_buckets.fallback IS 'Where a targeted debit takes what the bucket lacks: NONE refuses it, UNALLOCATED charges the rest to the wallet';
+COMMENT ON COLUMN wallet_buckets.low_balance IS 'Set once the bucket alerted below its threshold, until it is topped up above it';
@@ -0,0 +1,10 @@
+-- Migration: 000051_add_adjustment_expiry.down.sql
+-- Description: Drops the expiry of pending adjustments, rejecting the
+-- expired ones.
+
+DROP INDEX IF EXISTS idx_wallet_adjustments_expiring;
+UPDATE wallet_adjustments SET status = 'REJECTED', review_note = 'Expired' WHERE status = 'EXPIRED';
+ALTER TABLE wallet_adjustments DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE wallet_adjustments DROP CONSTRAINT wallet_adjustments_status_check;
+ALTER TABLE wallet_adjustments
+    ADD CONSTRAINT wallet_adjustments_status_check CHECK (status IN ('PENDING', 'APPROVED', 'POSTED', 'REJECTED'));
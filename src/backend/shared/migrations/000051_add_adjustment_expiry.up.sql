@@ -0,0 +1,14 @@
+-- Let pending adjustments expire when nobody reviews them in time. Small
+-- adjustments posted without a second approver have no reviewer, and
+-- requests still pending get the default review period.
+ALTER TABLE wallet_adjustments DROP CONSTRAINT wallet_adjustments_status_check;
+ALTER TABLE wallet_adjustments
+    ADD CONSTRAINT wallet_adjustments_status_check CHECK (status IN ('PENDING', 'APPROVED', 'POSTED', 'REJECTED', 'EXPIRED')),
+    ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE wallet_adjustments SET expires_at = created_at + INTERVAL '72 hours' WHERE status = 'PENDING';
+
+CREATE INDEX idx_wallet_adjustments_expiring ON wallet_adjustments (expires_at)
+    WHERE status = 'PENDING';
+
+COMMENT ON COLUMN wallet_adjustments.expires_at IS 'When the adjustment expires unless reviewed, NULL for adjustments posted without approval';
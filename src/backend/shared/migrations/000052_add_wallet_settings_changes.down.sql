@@ -0,0 +1,4 @@
+-- Migration: 000052_add_wallet_settings_changes.down.sql
+-- Description: Drops the staged wallet settings changes.
+
+DROP TABLE IF EXISTS wallet_settings_changes;
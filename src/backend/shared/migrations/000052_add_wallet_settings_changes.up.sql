@@ -0,0 +1,28 @@
+-- Create wallet_settings_changes table holding the sensitive wallet settings
+-- changes staged by operators, such as credit limit increases, with their
+-- reviews. A change is applied only once approved by an operator other than
+-- its requester.
+CREATE TABLE wallet_settings_changes (
+    id UUID PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING', 'APPROVED', 'APPLIED', 'REJECTED')),
+    diff JSONB NOT NULL,
+    reason TEXT NOT NULL,
+    requested_by VARCHAR(255) NOT NULL,
+    reviewed_by VARCHAR(255),
+    review_note TEXT,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    applied_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT wallet_settings_changes_four_eyes CHECK (reviewed_by IS NULL OR reviewed_by <> requested_by OR status = 'REJECTED'),
+    CONSTRAINT wallet_settings_changes_applied CHECK (status <> 'APPLIED' OR applied_at IS NOT NULL)
+);
+
+CREATE INDEX idx_wallet_settings_changes_wallet ON wallet_settings_changes (wallet_id, created_at DESC);
+CREATE INDEX idx_wallet_settings_changes_created ON wallet_settings_changes (created_at DESC);
+-- A wallet has one open change at a time, so approvals never race
+CREATE UNIQUE INDEX idx_wallet_settings_changes_open ON wallet_settings_changes (wallet_id)
+    WHERE status IN ('PENDING', 'APPROVED');
+
+COMMENT ON TABLE wallet_settings_changes IS 'Sensitive wallet settings changes requested and approved by two operators';
+COMMENT ON COLUMN wallet_settings_changes.diff IS 'Settings moved by the change, with their values when requested';
@@ -0,0 +1,5 @@
+-- Migration: 000053_add_inbound_webhooks.down.sql
+-- Description: Drops the received webhooks and their dead letters.
+
+DROP TABLE IF EXISTS inbound_webhook_dead_letters;
+DROP TABLE IF EXISTS inbound_webhooks;
@@ -0,0 +1,40 @@
+-- Create inbound_webhooks table holding the webhooks received from payment
+-- gateways and partner systems once their signature was verified. The event
+-- ID is unique per provider so redeliveries are recorded once. Received
+-- webhooks are processed in the order they arrived within their ordering
+-- key, and retried with backoff until processed or dead-lettered.
+CREATE TABLE inbound_webhooks (
+    id UUID PRIMARY KEY,
+    provider VARCHAR(64) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    ordering_key VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(13) NOT NULL DEFAULT 'RECEIVED' CHECK (status IN ('RECEIVED', 'PROCESSED', 'DEAD_LETTERED')),
+    payload BYTEA NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0 CHECK (attempts >= 0),
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT inbound_webhooks_event UNIQUE (provider, event_id)
+);
+
+CREATE INDEX idx_inbound_webhooks_received ON inbound_webhooks(received_at, id) WHERE status = 'RECEIVED';
+
+-- Create inbound_webhook_dead_letters table holding the webhooks whose
+-- processing kept failing, for review until replayed
+CREATE TABLE inbound_webhook_dead_letters (
+    id UUID PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES inbound_webhooks(id) ON DELETE CASCADE,
+    attempts INTEGER NOT NULL CHECK (attempts > 0),
+    error TEXT NOT NULL,
+    dead_lettered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    replayed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_inbound_webhook_dead_letters_created ON inbound_webhook_dead_letters(dead_lettered_at DESC);
+
+COMMENT ON TABLE inbound_webhooks IS 'Verified webhooks received from external providers';
+COMMENT ON COLUMN inbound_webhooks.payload IS 'Body as the provider signed it';
+COMMENT ON COLUMN inbound_webhooks.ordering_key IS 'Key of the events processed in the order received, such as a payment ID';
+COMMENT ON TABLE inbound_webhook_dead_letters IS 'Inbound webhooks whose processing kept failing';
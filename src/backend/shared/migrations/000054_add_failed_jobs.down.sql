@@ -0,0 +1,4 @@
+-- Migration: 000054_add_failed_jobs.down.sql
+-- Description: Drops the failed background jobs.
+
+DROP TABLE IF EXISTS failed_jobs;
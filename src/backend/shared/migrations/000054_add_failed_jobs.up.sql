@@ -0,0 +1,32 @@
+-- Create failed_jobs table holding background work that failed, such as
+-- webhook deliveries and recurring debit runs. Jobs are retried under the
+-- retry policy of their kind, then dead-lettered until an operator retries
+-- or discards them. Only one job is open per reference, so work failing
+-- again while retried is not queued twice.
+CREATE TABLE failed_jobs (
+    id UUID PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    reference VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(9) NOT NULL CHECK (status IN ('RETRYING', 'DEAD', 'SUCCEEDED', 'DISCARDED')),
+    attempts INTEGER NOT NULL CHECK (attempts > 0),
+    max_attempts INTEGER NOT NULL CHECK (max_attempts > 0),
+    last_error TEXT NOT NULL,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    dead_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    resolved_by VARCHAR(255),
+    resolution TEXT,
+    CONSTRAINT failed_jobs_retry_scheduled CHECK (status <> 'RETRYING' OR next_attempt_at IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX idx_failed_jobs_open_reference ON failed_jobs(kind, reference) WHERE status IN ('RETRYING', 'DEAD');
+CREATE INDEX idx_failed_jobs_due ON failed_jobs(next_attempt_at) WHERE status = 'RETRYING';
+CREATE INDEX idx_failed_jobs_created ON failed_jobs(created_at DESC);
+
+COMMENT ON TABLE failed_jobs IS 'Failed background work retried with backoff, then dead-lettered for operators';
+COMMENT ON COLUMN failed_jobs.reference IS 'Identity of the failed work within its kind';
+COMMENT ON COLUMN failed_jobs.payload IS 'What the retrier of the kind needs to repeat the work';
+COMMENT ON COLUMN failed_jobs.resolved_by IS 'Operator who discarded the job';
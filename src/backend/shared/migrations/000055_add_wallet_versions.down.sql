@@ -0,0 +1,10 @@
+-- Migration: 000055_add_wallet_versions.down.sql
+-- Description: Drops the wallet versions, soft deletes and transaction update times.
+
+ALTER TABLE wallet_transactions_archive DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE wallet_transactions DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS version;
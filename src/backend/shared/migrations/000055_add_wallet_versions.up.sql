@@ -0,0 +1,34 @@
+-- Add the columns the wallet repositories rely on: the version wallets are
+-- optimistically locked on, the time a wallet was soft-deleted and the time
+-- a transaction last changed status
+ALTER TABLE wallets
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1 CHECK (version > 0),
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE wallet_transactions ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE;
+
+-- Transactions recorded so far last changed when they were created
+UPDATE wallet_transactions SET updated_at = created_at;
+
+ALTER TABLE wallet_transactions
+    ALTER COLUMN updated_at SET NOT NULL,
+    ALTER COLUMN updated_at SET DEFAULT CURRENT_TIMESTAMP;
+
+-- Partitions are archived by copying their rows followed by the archive
+-- time, so archived_at is recreated to stay the last column of the archive
+ALTER TABLE wallet_transactions_archive ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE;
+UPDATE wallet_transactions_archive SET updated_at = created_at;
+ALTER TABLE wallet_transactions_archive
+    ALTER COLUMN updated_at SET NOT NULL,
+    ALTER COLUMN updated_at SET DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE wallet_transactions_archive RENAME COLUMN archived_at TO archived_at_before_updated_at;
+ALTER TABLE wallet_transactions_archive
+    ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+UPDATE wallet_transactions_archive SET archived_at = archived_at_before_updated_at;
+ALTER TABLE wallet_transactions_archive DROP COLUMN archived_at_before_updated_at;
+
+COMMENT ON COLUMN wallets.version IS 'Incremented on every change to the wallet, for optimistic locking';
+COMMENT ON COLUMN wallets.deleted_at IS 'When the wallet was soft-deleted, NULL while it is in use';
+COMMENT ON COLUMN wallet_transactions.updated_at IS 'When the transaction last changed status';
+COMMENT ON COLUMN wallet_transactions_archive.archived_at IS 'When the month of the transaction was archived';
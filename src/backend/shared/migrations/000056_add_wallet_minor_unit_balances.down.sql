@@ -0,0 +1,13 @@
+-- Migration: 000056_add_wallet_minor_unit_balances.down.sql
+-- Description: Drops the minor unit balances, credit limits and thresholds of wallets.
+
+DROP TRIGGER IF EXISTS set_wallets_amounts_minor ON wallets;
+DROP FUNCTION IF EXISTS set_wallet_amounts_minor();
+
+ALTER TABLE wallets
+    DROP CONSTRAINT IF EXISTS wallets_balance_minor_within_credit_limit,
+    DROP CONSTRAINT IF EXISTS wallets_amounts_minor_exact,
+    DROP COLUMN IF EXISTS balance_minor,
+    DROP COLUMN IF EXISTS credit_limit_minor,
+    DROP COLUMN IF EXISTS low_balance_threshold_minor,
+    DROP COLUMN IF EXISTS amount_exponent;
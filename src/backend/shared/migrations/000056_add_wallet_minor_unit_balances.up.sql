@@ -0,0 +1,71 @@
+-- Store wallet balances, credit limits and low balance thresholds as integer
+-- minor units alongside the decimal amounts, in the same exponent as the
+-- transactions of the wallet's currency. The minor units are derived from
+-- the decimal amounts on every write so the two never disagree.
+ALTER TABLE wallets
+    ADD COLUMN balance_minor BIGINT,
+    ADD COLUMN credit_limit_minor BIGINT,
+    ADD COLUMN low_balance_threshold_minor BIGINT,
+    ADD COLUMN amount_exponent SMALLINT CHECK (amount_exponent BETWEEN 0 AND 4);
+
+-- Derives the minor units of a wallet from the precision of its currency,
+-- falling back to the scale of the amounts when one of them is recorded at
+-- a finer precision
+CREATE OR REPLACE FUNCTION set_wallet_amounts_minor()
+RETURNS TRIGGER AS $$
+DECLARE
+    places SMALLINT;
+BEGIN
+    SELECT precision INTO places FROM currencies WHERE code = NEW.currency;
+    places := COALESCE(places, 2);
+    IF NEW.balance <> ROUND(NEW.balance, places)
+        OR NEW.credit_limit <> ROUND(NEW.credit_limit, places)
+        OR NEW.low_balance_threshold <> ROUND(NEW.low_balance_threshold, places) THEN
+        places := 2;
+    END IF;
+    NEW.amount_exponent := places;
+    NEW.balance_minor := NEW.balance * POWER(10::NUMERIC, places);
+    NEW.credit_limit_minor := NEW.credit_limit * POWER(10::NUMERIC, places);
+    NEW.low_balance_threshold_minor := NEW.low_balance_threshold * POWER(10::NUMERIC, places);
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+-- Backfill existing wallets the same way
+UPDATE wallets w
+SET amount_exponent = CASE
+        WHEN w.balance = ROUND(w.balance, c.precision)
+            AND w.credit_limit = ROUND(w.credit_limit, c.precision)
+            AND w.low_balance_threshold = ROUND(w.low_balance_threshold, c.precision)
+        THEN c.precision ELSE 2 END
+FROM currencies c
+WHERE c.code = w.currency;
+
+UPDATE wallets SET amount_exponent = 2 WHERE amount_exponent IS NULL;
+
+UPDATE wallets
+SET balance_minor = balance * POWER(10::NUMERIC, amount_exponent),
+    credit_limit_minor = credit_limit * POWER(10::NUMERIC, amount_exponent),
+    low_balance_threshold_minor = low_balance_threshold * POWER(10::NUMERIC, amount_exponent);
+
+ALTER TABLE wallets
+    ALTER COLUMN balance_minor SET NOT NULL,
+    ALTER COLUMN credit_limit_minor SET NOT NULL,
+    ALTER COLUMN low_balance_threshold_minor SET NOT NULL,
+    ALTER COLUMN amount_exponent SET NOT NULL,
+    ADD CONSTRAINT wallets_amounts_minor_exact CHECK (
+        balance = balance_minor / POWER(10::NUMERIC, amount_exponent)
+        AND credit_limit = credit_limit_minor / POWER(10::NUMERIC, amount_exponent)
+        AND low_balance_threshold = low_balance_threshold_minor / POWER(10::NUMERIC, amount_exponent)
+    ),
+    ADD CONSTRAINT wallets_balance_minor_within_credit_limit CHECK (balance_minor >= -credit_limit_minor);
+
+CREATE TRIGGER set_wallets_amounts_minor
+    BEFORE INSERT OR UPDATE OF balance, credit_limit, low_balance_threshold, currency ON wallets
+    FOR EACH ROW
+    EXECUTE FUNCTION set_wallet_amounts_minor();
+
+COMMENT ON COLUMN wallets.balance_minor IS 'Balance in integer minor units of the currency, e.g. cents';
+COMMENT ON COLUMN wallets.credit_limit_minor IS 'Credit limit in integer minor units of the currency';
+COMMENT ON COLUMN wallets.low_balance_threshold_minor IS 'Low balance threshold in integer minor units of the currency';
+COMMENT ON COLUMN wallets.amount_exponent IS 'Decimal places of the minor units: balance = balance_minor / 10^amount_exponent';
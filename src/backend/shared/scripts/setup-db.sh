@@ -166,6 +166,216 @@ ON CONFLICT DO NOTHING;
 -- Apply wallet tables
 \i '../migrations/000002_add_wallet_tables.up.sql'
 
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000003_add_operator_audit_log')
+ON CONFLICT DO NOTHING;
+
+-- Apply operator audit log
+\i '../migrations/000003_add_operator_audit_log.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000004_add_rate_limit_tiers')
+ON CONFLICT DO NOTHING;
+
+-- Apply rate limit tiers
+\i '../migrations/000004_add_rate_limit_tiers.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000005_add_reconciliation_issues')
+ON CONFLICT DO NOTHING;
+
+-- Apply reconciliation issues
+\i '../migrations/000005_add_reconciliation_issues.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000006_add_activity_digests')
+ON CONFLICT DO NOTHING;
+
+-- Apply activity digests
+\i '../migrations/000006_add_activity_digests.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000007_add_balance_snapshots')
+ON CONFLICT DO NOTHING;
+
+-- Apply balance snapshots
+\i '../migrations/000007_add_balance_snapshots.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000008_add_webhooks')
+ON CONFLICT DO NOTHING;
+
+-- Add customer webhook subscriptions and delivery log
+\i '../migrations/000008_add_webhooks.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000009_add_provider_refunds')
+ON CONFLICT DO NOTHING;
+
+-- Add refund-to-source tracking for provider-funded top-ups
+\i '../migrations/000009_add_provider_refunds.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000010_add_dunning')
+ON CONFLICT DO NOTHING;
+
+-- Dunning grace periods for exhausted wallet balances
+\i '../migrations/000010_add_dunning.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000011_add_wallet_credit_limit')
+ON CONFLICT DO NOTHING;
+
+-- Per-wallet overdraft credit limits
+\i '../migrations/000011_add_wallet_credit_limit.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000012_add_customer_timezones')
+ON CONFLICT DO NOTHING;
+
+-- Add customer timezones for statement and reporting cutoffs
+\i '../migrations/000012_add_customer_timezones.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000013_add_transaction_metadata')
+ON CONFLICT DO NOTHING;
+
+-- Add transaction metadata
+\i '../migrations/000013_add_transaction_metadata.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000014_add_decimal_verification_mismatches')
+ON CONFLICT DO NOTHING;
+
+-- Report table of the float to decimal migration verification
+\i '../migrations/000014_add_decimal_verification_mismatches.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000015_add_service_usage')
+ON CONFLICT DO NOTHING;
+
+-- Internal service usage for chargeback
+\i '../migrations/000015_add_service_usage.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000016_add_recurring_debits')
+ON CONFLICT DO NOTHING;
+
+-- Recurring debit schedules
+\i '../migrations/000016_add_recurring_debits.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000017_add_billing_periods')
+ON CONFLICT DO NOTHING;
+
+-- Billing period close and transaction effective dates
+\i '../migrations/000017_add_billing_periods.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000018_index_transaction_effective_dates')
+ON CONFLICT DO NOTHING;
+
+-- Transaction effective date indexes
+\i '../migrations/000018_index_transaction_effective_dates.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000019_add_wallet_migrations')
+ON CONFLICT DO NOTHING;
+
+-- Wallet migrations between deployments
+\i '../migrations/000019_add_wallet_migrations.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000020_add_customer_consents')
+ON CONFLICT DO NOTHING;
+
+-- Customer notification consents
+\i '../migrations/000020_add_customer_consents.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000021_add_request_captures')
+ON CONFLICT DO NOTHING;
+
+-- Request body captures for payment disputes
+\i '../migrations/000021_add_request_captures.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000022_add_currencies')
+ON CONFLICT DO NOTHING;
+
+-- Supported currencies and their precision
+\i '../migrations/000022_add_currencies.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000023_add_report_jobs')
+ON CONFLICT DO NOTHING;
+
+-- Long-running report jobs
+\i '../migrations/000023_add_report_jobs.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000024_add_deprecated_route_usage')
+ON CONFLICT DO NOTHING;
+
+-- Calls to deprecated API routes by integration
+\i '../migrations/000024_add_deprecated_route_usage.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000025_add_minor_unit_amounts')
+ON CONFLICT DO NOTHING;
+
+-- Transaction amounts in minor units and currency rounding modes
+\i '../migrations/000025_add_minor_unit_amounts.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000026_add_rate_card_changes')
+ON CONFLICT DO NOTHING;
+
+-- Reviewed chargeback rate card changes
+\i '../migrations/000026_add_rate_card_changes.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000027_add_wallet_settings')
+ON CONFLICT DO NOTHING;
+
+-- Wallet notification preferences and auto top-up
+\i '../migrations/000027_add_wallet_settings.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000028_add_wallet_webhooks')
+ON CONFLICT DO NOTHING;
+
+-- Wallet-level webhook subscriptions
+\i '../migrations/000028_add_wallet_webhooks.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000029_add_wallet_merges')
+ON CONFLICT DO NOTHING;
+
+-- Duplicate wallet merges
+\i '../migrations/000029_add_wallet_merges.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000030_add_fee_rules')
+ON CONFLICT DO NOTHING;
+
+-- Effective-dated fee rules
+\i '../migrations/000030_add_fee_rules.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000031_partition_wallet_transactions')
+ON CONFLICT DO NOTHING;
+
+-- Monthly partitions and archive of wallet transactions
+\i '../migrations/000031_partition_wallet_transactions.up.sql'
+
+-- Track migration
+INSERT INTO schema_migrations (version) VALUES ('000032_add_wallet_batches')
+ON CONFLICT DO NOTHING;
+
+-- Bulk wallet provisioning batches
+\i '../migrations/000032_add_wallet_batches.up.sql'
+
 -- Verify critical tables exist
 DO \$\$
 BEGIN
@@ -0,0 +1,29 @@
+// Package main writes the OpenAPI document of the wallet service REST API,
+// generated from the handler types. It runs from go generate in internal/api.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"internal/api"
+)
+
+func main() {
+	out := flag.String("out", "openapi.json", "file to write the OpenAPI document to")
+	flag.Parse()
+
+	data, err := api.GenerateOpenAPI()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to generate OpenAPI document: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write OpenAPI document: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("wrote %s\n", *out)
+}
@@ -0,0 +1,28 @@
+// Package main provides the build-time compatibility checker for the event
+// schemas embedded in the wallet service
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"internal/events"
+)
+
+func main() {
+	registry, err := events.DefaultRegistry()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load event schemas: %v\n", err)
+		os.Exit(1)
+	}
+
+	problems := registry.CheckCompatibility()
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "incompatible schema: %v\n", p)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+
+	fmt.Println("event schemas compatible")
+}
@@ -3,24 +3,67 @@ package main
 
 import (
     "context"
+    "database/sql"
+    "database/sql/driver"
+    "errors"
     "fmt"
+    "io"
+    "math/rand"
     "net/http"
     "os"
     "os/signal"
+    "strings"
     "syscall"
     "time"
 
     "github.com/gin-gonic/gin"         // v1.9.1
     "github.com/go-redis/redis/v8"     // v8.11.5
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
     "go.uber.org/zap"                  // v1.24.0
+    "gorm.io/driver/postgres"          // v1.5.0
     "gorm.io/gorm"                     // v1.25.0
+    "github.com/jackc/pgx/v5"          // v5.3.1
+    "github.com/jackc/pgx/v5/stdlib"   // v5.3.1
     "github.com/prometheus/client_golang/prometheus" // v1.16.0
     "github.com/prometheus/client_golang/prometheus/promauto"
 
     "internal/config"
     "internal/api"
+    "internal/auth"
+    "internal/breaker"
+    "internal/bodycapture"
+    "internal/calendar"
+    "internal/classification"
+    "internal/currency"
+    "internal/deprecation"
+    "internal/descriptions"
+    "internal/eventbus"
+    "internal/events"
+    "internal/graphql"
+    "internal/health"
+    "internal/inbound"
+    "internal/logging"
+    "internal/invoicepdf"
+    "internal/invoices"
+    "internal/models"
+    "internal/notify"
+    "internal/objectstore"
+    "internal/payment"
     "internal/service"
+    "internal/ratelimit"
     "internal/repository"
+    "internal/runbook"
+    "internal/secrets"
+    "internal/selfcheck"
+    "internal/sensitive"
+    "internal/slo"
+    "internal/startup"
+    "internal/tax"
+    "internal/usage"
+    "internal/walletfeed"
+    "internal/webhook"
+    "internal/worker"
 )
 
 // Build information, set during compilation
@@ -29,8 +72,8 @@ var (
     buildTime = "unknown"
 )
 
-// Global logger instance
-var logger *zap.Logger
+// Global logger instance, with its level adjustable on configuration reload
+var logger *logging.Logger
 
 // Metrics
 var (
@@ -53,68 +96,2286 @@ var (
 )
 
 func main() {
-    // Initialize logger
+    // Initialize a bootstrap logger, replaced once the configuration has
+    // been loaded
     var err error
-    logger, err = setupLogger()
+    logger, err = logging.New(logging.Options{})
+    if err != nil {
+        fmt.Printf("Failed to setup logger: %v\n", err)
+        os.Exit(1)
+    }
+
+    // Load configuration. Operational settings are reloaded from the watched
+    // config file; cfg is the snapshot the service started with.
+    watcher, err := config.NewWatcher("config/config.yaml", logger)
+    if err != nil {
+        logger.Fatal("Failed to load configuration",
+            zap.Error(err),
+        )
+    }
+    cfg := watcher.Current()
+
+    logger, err = logging.New(logging.Options{
+        Level:    cfg.Logging.Level,
+        Encoding: cfg.Logging.Encoding,
+    })
     if err != nil {
         fmt.Printf("Failed to setup logger: %v\n", err)
         os.Exit(1)
     }
     defer logger.Sync()
+    watcher.SetLogger(logger)
+    watcher.Subscribe("log-level", applyLogLevel)
+
+    // Database credentials are refreshed from the secrets provider when one
+    // is configured
+    secretsCtx, cancelSecrets := context.WithTimeout(context.Background(), cfg.Secrets.Timeout)
+    secretsProvider, err := config.NewSecretsProvider(secretsCtx, &cfg.Secrets)
+    cancelSecrets()
+    if err != nil {
+        logger.Fatal("Failed to create secrets provider",
+            zap.Error(err),
+        )
+    }
+
+    // Setup database connection
+    db, credentials, err := setupDatabase(cfg, secretsProvider)
+    if err != nil {
+        logger.Fatal("Failed to setup database",
+            zap.Error(err),
+        )
+    }
+
+    sqlDB, err := db.DB()
+    if err != nil {
+        logger.Fatal("Failed to get database instance",
+            zap.Error(err),
+        )
+    }
+
+    // Wait for the database and Redis, which may still be starting when
+    // the service is deployed alongside them. Traffic is only served once
+    // the database is reachable. Redis is a soft dependency: when allowed,
+    // the service starts degraded without it and each feature applies its
+    // configured failure policy.
+    startupCtx, cancelStartup := context.WithTimeout(context.Background(), cfg.Startup.MaxWait)
+    backoff := startup.Backoff{
+        Min:            cfg.Startup.MinBackoff,
+        Max:            cfg.Startup.MaxBackoff,
+        AttemptTimeout: cfg.Startup.AttemptTimeout,
+    }
+    if err := startup.Wait(startupCtx, "database", backoff, sqlDB.PingContext, logger); err != nil {
+        logger.Fatal("Failed to connect to database",
+            zap.Error(err),
+        )
+    }
+
+    redisClient := setupRedis(cfg)
+    err = startup.Wait(startupCtx, "redis", backoff, func(ctx context.Context) error {
+        return redisClient.Ping(ctx).Err()
+    }, logger)
+    cancelStartup()
+    if err != nil {
+        if !cfg.Startup.AllowDegraded {
+            logger.Fatal("Refusing to start without Redis",
+                zap.Error(err),
+            )
+        }
+        logger.Warn("Redis unavailable, starting in degraded mode",
+            zap.Error(err),
+        )
+    }
+
+    // Redis calls fail fast while its circuit breaker is open, so features
+    // fall back under their failure policy without waiting on timeouts
+    if cfg.CircuitBreakers.Redis.Enabled {
+        redisBreaker, err := newBreaker("redis", cfg.CircuitBreakers.Redis, breaker.RedisSuccessful)
+        if err != nil {
+            logger.Fatal("Failed to create Redis circuit breaker",
+                zap.Error(err),
+            )
+        }
+        redisClient.AddHook(breaker.RedisHook(redisBreaker))
+    }
+
+    // Background workers run on every replica, except singleton workers
+    // which only run on the replica holding their lease in Redis
+    hostname, _ := os.Hostname()
+    elector, err := worker.NewRedisElector(redisClient, hostname+"-"+uuid.NewString(), cfg.Workers.LeaseTTL)
+    if err != nil {
+        logger.Fatal("Failed to create leader elector",
+            zap.Error(err),
+        )
+    }
+
+    runner, err := worker.NewRunner(elector, cfg.Workers.RestartDelay, logger)
+    if err != nil {
+        logger.Fatal("Failed to create worker runner",
+            zap.Error(err),
+        )
+    }
+
+    // Read-only deployments serve analytics consumers from the replica,
+    // away from the transactional path. Only workers changing no data run.
+    if cfg.ReadOnly.Enabled {
+        logger.Info("Starting in read-only mode",
+            zap.Int("rateLimitMultiplier", cfg.ReadOnly.RateLimitMultiplier),
+        )
+        runner.SetReadOnly(true)
+    }
+
+    // Connections close once the workers using them have stopped
+    runner.OnShutdown("redis", func(context.Context) error {
+        return redisClient.Close()
+    })
+    runner.OnShutdown("database", func(context.Context) error {
+        return sqlDB.Close()
+    })
+
+    // Register dependencies and feature degradation policies for /readyz
+    rateLimitPolicy, err := health.ParseFailurePolicy(cfg.Degradation.RateLimit)
+    if err != nil {
+        logger.Fatal("Invalid rate limit failure policy",
+            zap.Error(err),
+        )
+    }
+    idempotencyPolicy, err := health.ParseFailurePolicy(cfg.Degradation.Idempotency)
+    if err != nil {
+        logger.Fatal("Invalid idempotency failure policy",
+            zap.Error(err),
+        )
+    }
+
+    // Services publish domain events to the bus; transports such as the
+    // Redis event stream subscribe to carry them out of the service
+    bus := eventbus.New()
+
+    monitor := health.NewMonitor(cfg.Degradation.ProbeTimeout)
+    monitor.RegisterDependency("database", true, sqlDB.PingContext)
+    monitor.RegisterDependency("redis", false, func(ctx context.Context) error {
+        return redisClient.Ping(ctx).Err()
+    })
+    monitor.RegisterFeature(health.FeatureRateLimiting, "redis", rateLimitPolicy)
+    monitor.RegisterFeature(health.FeatureIdempotency, "redis", idempotencyPolicy)
+
+    // The primary may refuse writes during a failover. Writes are then
+    // refused with a Retry-After and the workers changing data pause until
+    // a check finds the database writable again, while reads are still
+    // served. Read-only deployments are on the replica and never write.
+    var databaseMode service.DatabaseMode
+    if !cfg.ReadOnly.Enabled {
+        databaseModeRepo, err := repository.NewDatabaseModeRepository(sqlDB)
+        if err != nil {
+            logger.Fatal("Failed to create database mode repository",
+                zap.Error(err),
+            )
+        }
+        databaseMode, err = service.NewDatabaseMode(databaseModeRepo, bus, logger)
+        if err != nil {
+            logger.Fatal("Failed to create database mode",
+                zap.Error(err),
+            )
+        }
+        monitor.RegisterDependency("database_writes", false, databaseMode.Check)
+        runner.PauseWhile(databaseMode.ReadOnly)
+    }
+
+    // Capabilities reported to clients, and how each is affected by a
+    // dependency outage. Redis carries idempotency, the event stream behind
+    // webhooks, live updates and the leases of the recurring debit scheduler.
+    // A read-only database still serves reads.
+    database := health.Requirement{Dependency: "database", Impact: health.CapabilityDown}
+    writes := health.Requirement{Dependency: "database_writes", Impact: health.CapabilityDegraded}
+    monitor.RegisterCapability(health.CapabilityTransactions, database, writes,
+        health.Requirement{Dependency: "redis", Impact: health.ImpactOf(idempotencyPolicy)})
+    monitor.RegisterCapability(health.CapabilityBalances, database)
+    monitor.RegisterCapability(health.CapabilityWebhooks, database,
+        health.Requirement{Dependency: "redis", Impact: health.CapabilityDown})
+    monitor.RegisterCapability(health.CapabilityLiveUpdates, database,
+        health.Requirement{Dependency: "redis", Impact: health.CapabilityDown})
+    monitor.RegisterCapability(health.CapabilityRefunds, database, writes)
+    monitor.RegisterCapability(health.CapabilityRecurringDebits, database, writes,
+        health.Requirement{Dependency: "redis", Impact: health.CapabilityDegraded})
+
+    // Dependencies are probed in the background so responses can be marked
+    // with degraded capabilities without probing per request
+    // Connections opened after a credential rotation log in with the new
+    // credentials. Idle connections are closed so the pool moves over
+    // promptly; busy ones are replaced once past their lifetime.
+    if credentials != nil && cfg.Secrets.RotationInterval > 0 {
+        addWorker(runner, worker.Worker{
+            Name:     "database-credentials",
+            Interval: cfg.Secrets.RotationInterval,
+            ReadOnly: true,
+            Job: func(ctx context.Context) error {
+                rotated, err := credentials.Refresh(ctx)
+                if err != nil || !rotated {
+                    return err
+                }
+                sqlDB.SetMaxIdleConns(0)
+                sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+                logger.Info("Database credentials rotated")
+                return nil
+            },
+        })
+    }
+
+    addPoolStatsWorker(runner, cfg, databaseName(cfg), sqlDB)
+
+    addWorker(runner, worker.Worker{
+        Name:     "health-monitor",
+        Interval: cfg.Degradation.CheckInterval,
+        ReadOnly: true,
+        Job: func(ctx context.Context) error {
+            monitor.Check(ctx)
+            return nil
+        },
+    })
+
+    healthHandler, err := api.NewHealthHandler(monitor)
+    if err != nil {
+        logger.Fatal("Failed to create health handler",
+            zap.Error(err),
+        )
+    }
+
+    // Verify dependencies before accepting traffic
+    if cfg.SelfCheck.Enabled {
+        if err := runSelfCheck(cfg, sqlDB, redisClient); err != nil {
+            logger.Fatal("Refusing to start",
+                zap.Error(err),
+            )
+        }
+    }
+
+    // Initialize the repositories. Their prepared statements close before
+    // the connection they were prepared on.
+    repos := newRepositories(sqlDB, runner)
+    walletRepo := openRepository(repos, "wallet", repository.NewWalletRepository)
+    repo := walletRepo
+
+    // Transaction history and balance reads are served by the read replica
+    // when one is configured. The service starts on the primary alone when
+    // the replica cannot be reached. Read-only deployments are already on
+    // the replica.
+    if cfg.Database.ReplicaDSN != "" && !cfg.ReadOnly.Enabled {
+        replicaRepo, err := setupReplica(cfg, runner)
+        if err != nil {
+            logger.Warn("Read replica unavailable, reading from primary",
+                zap.Error(err),
+            )
+        } else {
+            router, err := service.NewReplicaRouter(repo, replicaRepo.wallets, replicaRepo.status, cfg.Database.ReplicaMaxLag, logger)
+            if err != nil {
+                logger.Fatal("Failed to create replica router",
+                    zap.Error(err),
+                )
+            }
+            addWorker(runner, worker.Worker{
+                Name:     "replica-lag",
+                Interval: cfg.Database.ReplicaCheckInterval,
+                Job:      router.CheckLag,
+            })
+            repo = router
+        }
+    }
+
+    // During the float to decimal migration, a sample of reads is compared
+    // with the exact decimal columns before the write path is switched
+    if cfg.DecimalVerification.Enabled {
+        verificationRepo := openRepository(repos, "decimal verification", repository.NewDecimalVerificationRepository)
+
+        repo, err = service.NewDecimalVerifyingRepository(repo, verificationRepo, service.DecimalVerificationOptions{
+            SampleRate:      cfg.DecimalVerification.SampleRate,
+            Tolerance:       cfg.DecimalVerification.Tolerance,
+            AlertDrift:      cfg.DecimalVerification.AlertDrift,
+            AlertMismatches: cfg.DecimalVerification.AlertMismatches,
+            AlertWindow:     cfg.DecimalVerification.AlertWindow,
+        }, logger)
+        if err != nil {
+            logger.Fatal("Failed to create decimal verification",
+                zap.Error(err),
+            )
+        }
+    }
+
+    // Load the supported currencies and their precision
+    currencies, err := loadCurrencies(cfg, sqlDB)
+    if err != nil {
+        logger.Fatal("Failed to load currencies",
+            zap.Error(err),
+        )
+    }
+    logger.Info("Currencies loaded",
+        zap.String("source", cfg.Currencies.Source),
+        zap.Strings("codes", currencies.Codes()),
+    )
+
+    // Initialize service
+    walletService, err := service.NewWalletService(repo, currencies, decimal.NewFromFloat(cfg.Wallet.LowBalanceThreshold), bus, logger)
+    if err != nil {
+        logger.Fatal("Failed to create wallet service",
+            zap.Error(err),
+        )
+    }
+    if err := walletService.SetTransactionQueryHorizon(cfg.Wallet.TransactionQueryHorizon); err != nil {
+        logger.Fatal("Failed to set transaction query horizon",
+            zap.Error(err),
+        )
+    }
+
+    // Transactions are posted with descriptions rendered from the template
+    // of their source and product, or with their own tidied up, whichever
+    // service posts them
+    if cfg.Descriptions.Enabled {
+        defs := make([]descriptions.Definition, 0, len(cfg.Descriptions.Templates))
+        for _, template := range cfg.Descriptions.Templates {
+            defs = append(defs, descriptions.Definition{
+                Source:   template.Source,
+                Product:  template.Product,
+                Template: template.Template,
+            })
+        }
+        descriptionTemplates, err := descriptions.NewRegistry(defs, cfg.Descriptions.MaxLength)
+        if err != nil {
+            logger.Fatal("Failed to load description templates",
+                zap.Error(err),
+            )
+        }
+
+        walletService, err = service.NewDescribedWalletService(walletService, descriptionTemplates, logger)
+        if err != nil {
+            logger.Fatal("Failed to create described wallet service",
+                zap.Error(err),
+            )
+        }
+        logger.Info("Transaction descriptions normalized",
+            zap.Int("templates", descriptionTemplates.Templates()),
+        )
+    }
+
+    // Operator actions are audited, starting with the incident switch below
+    auditRepo := openRepository(repos, "audit", repository.NewAuditRepository)
+
+    // Initialize the incident switch pausing transaction types. It wraps the
+    // wallet service before anything uses it, so API, recurring and usage
+    // transactions are paused alike. Every replica reloads the active
+    // incident, and one of them applies its scheduled resume.
+    incidentRepo := openRepository(repos, "incident", repository.NewIncidentRepository)
+
+    incidentService, err := service.NewIncidentService(incidentRepo, auditRepo, cfg.Incidents.MaxDuration, logger)
+    if err != nil {
+        logger.Fatal("Failed to create incident service",
+            zap.Error(err),
+        )
+    }
+
+    walletService, err = service.NewIncidentWalletService(walletService, incidentService)
+    if err != nil {
+        logger.Fatal("Failed to create incident wallet service",
+            zap.Error(err),
+        )
+    }
+
+    addWorker(runner, worker.Worker{
+        Name:     "incident-refresh",
+        Interval: cfg.Incidents.RefreshInterval,
+        Job:      incidentService.Refresh,
+    })
+
+    incidentHandler, err := api.NewIncidentHandler(incidentService)
+    if err != nil {
+        logger.Fatal("Failed to create incident handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize balance snapshots for historical balance queries
+    snapshotRepo := openRepository(repos, "snapshot", repository.NewSnapshotRepository)
+
+    historyService, err := service.NewBalanceHistoryService(snapshotRepo, logger)
+    if err != nil {
+        logger.Fatal("Failed to create balance history service",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize free-text scanning for card and Aadhaar numbers. Detections
+    // are logged as compliance events without the detected values.
+    scanner, err := sensitive.NewScanner(sensitive.Policy(cfg.Security.SensitiveDataPolicy), func(e sensitive.Event) {
+        logger.Warn("Compliance event: sensitive data in free-text field",
+            zap.String("event", "sensitive_data_detected"),
+            zap.String("field", e.Field),
+            zap.Any("kinds", e.Kinds),
+            zap.Int("count", e.Count),
+            zap.String("action", string(e.Action)),
+        )
+    })
+    if err != nil {
+        logger.Fatal("Failed to create sensitive data scanner",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize customer settings such as the reporting timezone and tax
+    // region
+    customerRepo := openRepository(repos, "customer", repository.NewCustomerRepository)
+
+    // Initialize invoice payment tracking. Issued invoices are tracked from
+    // the event bus whether rendered here or reported by the invoice
+    // service, and are marked overdue and charged late fees on schedule.
+    receivableRepo := openRepository(repos, "invoice receivable", repository.NewInvoiceReceivableRepository)
+
+    receivableService, err := service.NewInvoiceReceivableService(receivableRepo, customerRepo, currencies,
+        models.PaymentTerms(cfg.Receivables.DefaultTerms), service.LateFeePolicy{
+            Mode:        models.LateFeeMode(cfg.Receivables.LateFeeMode),
+            FeePercent:  decimal.NewFromFloat(cfg.Receivables.LateFeePercent),
+            AnnualRate:  decimal.NewFromFloat(cfg.Receivables.InterestRate),
+            GracePeriod: cfg.Receivables.GracePeriod,
+        }, logger)
+    if err != nil {
+        logger.Fatal("Failed to create invoice receivable service",
+            zap.Error(err),
+        )
+    }
+    if err := bus.Subscribe("invoice-receivables", receivableService.Handle, eventbus.TypeInvoiceIssued); err != nil {
+        logger.Fatal("Failed to subscribe invoice receivables to the event bus",
+            zap.Error(err),
+        )
+    }
+
+    addWorker(runner, worker.Worker{
+        Name:      "invoice-late-charges",
+        Interval:  cfg.Receivables.AccrualInterval,
+        Singleton: true,
+        Job: func(ctx context.Context) error {
+            _, err := receivableService.AccrueLateCharges(ctx, time.Now().UTC())
+            return err
+        },
+    })
+
+    receivableHandler, err := api.NewInvoiceReceivableHandler(receivableService)
+    if err != nil {
+        logger.Fatal("Failed to create invoice receivable handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize prepaid and postpaid billing. Debits of postpaid customers
+    // posted through the API are accrued instead of taken from the wallet,
+    // and billed at the end of each monthly cycle against the wallet or by
+    // invoice.
+    postpaidRepo := openRepository(repos, "postpaid", repository.NewPostpaidRepository)
+
+    postpaidService, err := service.NewPostpaidService(postpaidRepo, customerRepo, walletService, receivableService, bus, currencies, logger)
+    if err != nil {
+        logger.Fatal("Failed to create postpaid service",
+            zap.Error(err),
+        )
+    }
+
+    addWorker(runner, worker.Worker{
+        Name:      "postpaid-cycle-close",
+        Interval:  cfg.Postpaid.CloseInterval,
+        Singleton: true,
+        Job: func(ctx context.Context) error {
+            _, err := postpaidService.CloseCycle(ctx, time.Now().UTC())
+            return err
+        },
+    })
+
+    postpaidHandler, err := api.NewPostpaidHandler(postpaidService)
+    if err != nil {
+        logger.Fatal("Failed to create postpaid handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize organizations. Requests made with tokens scoped to an
+    // organization only see its customers and wallets, which the wallet and
+    // customer repositories enforce on every lookup.
+    organizationRepo := openRepository(repos, "organization", repository.NewOrganizationRepository)
+
+    organizationService, err := service.NewOrganizationService(organizationRepo, logger)
+    if err != nil {
+        logger.Fatal("Failed to create organization service",
+            zap.Error(err),
+        )
+    }
+
+    organizationHandler, err := api.NewOrganizationHandler(organizationService)
+    if err != nil {
+        logger.Fatal("Failed to create organization handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize budget buckets. Debits naming a bucket are taken out of it
+    // as they post to the wallet, after allowances and tax applied, so the
+    // bucket covers what is charged; postpaid debits accrue without it.
+    bucketRepo := openRepository(repos, "bucket", repository.NewWalletBucketRepository)
+
+    bucketService, err := service.NewWalletBucketService(bucketRepo, walletService, currencies, bus, logger)
+    if err != nil {
+        logger.Fatal("Failed to create bucket service",
+            zap.Error(err),
+        )
+    }
+
+    bucketHandler, err := api.NewWalletBucketHandler(bucketService)
+    if err != nil {
+        logger.Fatal("Failed to create bucket handler",
+            zap.Error(err),
+        )
+    }
+
+    bucketWallets, err := service.NewBucketWalletService(walletService, bucketService, logger)
+    if err != nil {
+        logger.Fatal("Failed to create bucket wallet service",
+            zap.Error(err),
+        )
+    }
+
+    handlerWallets, err := service.NewPostpaidWalletService(bucketWallets, postpaidService)
+    if err != nil {
+        logger.Fatal("Failed to create postpaid wallet service",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize sales tax by the rules of each customer's region, charged
+    // on invoices on request of invoice generation and, when configured, on
+    // debits posted through the API
+    var taxHandler *api.TaxHandler
+    var debitTaxes service.TaxService
+    if cfg.Tax.Enabled {
+        defs := make(map[string]tax.Definition, len(cfg.Tax.Regions))
+        for region, regionCfg := range cfg.Tax.Regions {
+            def := tax.Definition{
+                Name:             regionCfg.Name,
+                ExemptCategories: regionCfg.ExemptCategories,
+            }
+            for _, component := range regionCfg.Components {
+                def.Components = append(def.Components, tax.Component{
+                    Name: component.Name,
+                    Rate: decimal.NewFromFloat(component.Rate),
+                })
+            }
+            defs[region] = def
+        }
+        taxRules, err := tax.NewRegistry(defs)
+        if err != nil {
+            logger.Fatal("Failed to load tax rules",
+                zap.Error(err),
+            )
+        }
+
+        taxRepo := openRepository(repos, "tax", repository.NewTaxRepository)
+
+        taxService, err := service.NewTaxService(taxRepo, customerRepo, taxRules, currencies, logger)
+        if err != nil {
+            logger.Fatal("Failed to create tax service",
+                zap.Error(err),
+            )
+        }
+
+        taxHandler, err = api.NewTaxHandler(taxService)
+        if err != nil {
+            logger.Fatal("Failed to create tax handler",
+                zap.Error(err),
+            )
+        }
+
+        if cfg.Tax.ApplyToDebits {
+            debitTaxes = taxService
+            handlerWallets, err = service.NewTaxedWalletService(handlerWallets, taxService, logger)
+            if err != nil {
+                logger.Fatal("Failed to create taxed wallet service",
+                    zap.Error(err),
+                )
+            }
+        }
+        logger.Info("Tax enabled",
+            zap.Strings("regions", taxRules.Regions()),
+            zap.Bool("apply_to_debits", cfg.Tax.ApplyToDebits),
+        )
+    }
+
+    // Initialize free-tier allowances. Debits posted through the API use the
+    // free units of their product left in the cycle before the wallet is
+    // charged; usage is counted per monthly cycle, so it resets as the next
+    // one starts.
+    allowanceRepo := openRepository(repos, "allowance", repository.NewAllowanceRepository)
+
+    allowanceService, err := service.NewAllowanceService(allowanceRepo, walletService, cfg.Allowances.Products, currencies, logger)
+    if err != nil {
+        logger.Fatal("Failed to create allowance service",
+            zap.Error(err),
+        )
+    }
+
+    allowanceHandler, err := api.NewAllowanceHandler(allowanceService)
+    if err != nil {
+        logger.Fatal("Failed to create allowance handler",
+            zap.Error(err),
+        )
+    }
+
+    if len(cfg.Allowances.Products) > 0 {
+        handlerWallets, err = service.NewAllowanceWalletService(handlerWallets, allowanceService, logger)
+        if err != nil {
+            logger.Fatal("Failed to create allowance wallet service",
+                zap.Error(err),
+            )
+        }
+    }
+
+    // Initialize wallet settings changes; credit limit increases are staged
+    // for approval by a second operator and applied to the unwrapped wallets
+    settingsChangeRepo := openRepository(repos, "settings change", repository.NewWalletSettingsChangeRepository)
+
+    settingsChangeService, err := service.NewWalletSettingsChangeService(settingsChangeRepo, walletService, auditRepo, currencies, logger)
+    if err != nil {
+        logger.Fatal("Failed to create settings change service",
+            zap.Error(err),
+        )
+    }
+
+    settingsChangeHandler, err := api.NewWalletSettingsChangeHandler(settingsChangeService)
+    if err != nil {
+        logger.Fatal("Failed to create settings change handler",
+            zap.Error(err),
+        )
+    }
+
+    handlerWallets, err = service.NewSettingsApprovalWalletService(handlerWallets)
+    if err != nil {
+        logger.Fatal("Failed to create settings approval wallet service",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize HTTP handler
+    handler, err := api.NewWalletHandler(handlerWallets, historyService, currencies, scanner)
+    if err != nil {
+        logger.Fatal("Failed to create handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize operator runbook actions
+    runbookRegistry, err := runbook.NewRegistry(auditRepo)
+    if err != nil {
+        logger.Fatal("Failed to create runbook registry",
+            zap.Error(err),
+        )
+    }
+
+    if err := runbookRegistry.Register(runbook.CacheFlushAction(redisClient, []string{"wallet:", "ratelimit:", "analytics:"})); err != nil {
+        logger.Fatal("Failed to register runbook action",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize sandbox demo data cloning
+    sandboxRepo := openRepository(repos, "sandbox", repository.NewSandboxRepository)
+
+    sandboxService, err := service.NewSandboxService(repo, sandboxRepo, logger)
+    if err != nil {
+        logger.Fatal("Failed to create sandbox service",
+            zap.Error(err),
+        )
+    }
+
+    if err := runbookRegistry.Register(runbook.SandboxCloneAction(sandboxService)); err != nil {
+        logger.Fatal("Failed to register runbook action",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize long-running report jobs; report kinds are registered
+    // with the services computing them
+    reportJobRepo := openRepository(repos, "report job", repository.NewReportJobRepository)
+
+    reportJobService, err := service.NewReportJobService(reportJobRepo, service.ReportJobPolicy{
+        MaxAttempts:       cfg.ReportJobs.MaxAttempts,
+        RetryDelay:        cfg.ReportJobs.RetryDelay,
+        HeartbeatInterval: cfg.ReportJobs.HeartbeatInterval,
+        StaleAfter:        cfg.ReportJobs.StaleAfter,
+    }, logger)
+    if err != nil {
+        logger.Fatal("Failed to create report job service",
+            zap.Error(err),
+        )
+    }
+
+    reportJobHandler, err := api.NewReportJobHandler(reportJobService)
+    if err != nil {
+        logger.Fatal("Failed to create report job handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize bulk wallet provisioning, run as report jobs
+    walletBatchRepo := openRepository(repos, "wallet batch", repository.NewWalletBatchRepository)
+
+    provisioningService, err := service.NewWalletProvisioningService(repo, walletBatchRepo, reportJobService, currencies, bus, logger)
+    if err != nil {
+        logger.Fatal("Failed to create wallet provisioning service",
+            zap.Error(err),
+        )
+    }
+
+    if err := reportJobService.Register(service.WalletProvisioningReport(provisioningService)); err != nil {
+        logger.Fatal("Failed to register report kind",
+            zap.Error(err),
+        )
+    }
+
+    provisioningHandler, err := api.NewProvisioningHandler(provisioningService)
+    if err != nil {
+        logger.Fatal("Failed to create provisioning handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize bulk credits, posted and rolled back by report jobs
+    bulkCreditRepo := openRepository(repos, "bulk credit", repository.NewBulkCreditRepository)
+
+    bulkCreditService, err := service.NewBulkCreditService(bulkCreditRepo, walletService, repo, incidentService, reportJobService, currencies, bus, service.BulkCreditPolicy{
+        MaxRows:       cfg.BulkCredits.MaxRows,
+        ChunkSize:     cfg.BulkCredits.ChunkSize,
+        RowsPerSecond: cfg.BulkCredits.RowsPerSecond,
+    }, logger)
+    if err != nil {
+        logger.Fatal("Failed to create bulk credit service",
+            zap.Error(err),
+        )
+    }
+
+    for _, kind := range []service.ReportKind{
+        service.BulkCreditReport(bulkCreditService),
+        service.BulkCreditRollbackReport(bulkCreditService),
+    } {
+        if err := reportJobService.Register(kind); err != nil {
+            logger.Fatal("Failed to register report kind",
+                zap.Error(err),
+            )
+        }
+    }
+
+    bulkCreditHandler, err := api.NewBulkCreditHandler(bulkCreditService)
+    if err != nil {
+        logger.Fatal("Failed to create bulk credit handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize bulk transaction exports to object storage, run as report
+    // jobs
+    var exportHandler *api.ExportHandler
+    if cfg.Exports.Enabled {
+        exportStore, err := objectstore.New(context.Background(), cfg.Exports.Provider, cfg.Exports.Region, cfg.Exports.Bucket)
+        if err != nil {
+            logger.Fatal("Failed to create export object store",
+                zap.Error(err),
+            )
+        }
+
+        exportRepo := openRepository(repos, "transaction export", repository.NewTransactionExportRepository)
+
+        exportService, err := service.NewTransactionExportService(exportRepo, exportStore, reportJobService, service.TransactionExportOptions{
+            Prefix:                cfg.Exports.Prefix,
+            URLExpiry:             cfg.Exports.URLExpiry,
+            NormalizeDescriptions: cfg.Descriptions.Enabled,
+        }, logger)
+        if err != nil {
+            logger.Fatal("Failed to create transaction export service",
+                zap.Error(err),
+            )
+        }
+
+        if err := reportJobService.Register(service.TransactionExportReport(exportService)); err != nil {
+            logger.Fatal("Failed to register report kind",
+                zap.Error(err),
+            )
+        }
+
+        exportHandler, err = api.NewExportHandler(exportService)
+        if err != nil {
+            logger.Fatal("Failed to create export handler",
+                zap.Error(err),
+            )
+        }
+    }
+
+    // Every replica runs jobs; claims keep two replicas off the same job
+    for i := 1; i <= cfg.ReportJobs.Concurrency; i++ {
+        addWorker(runner, worker.Worker{
+            Name:     fmt.Sprintf("report-jobs-%d", i),
+            Interval: cfg.ReportJobs.PollInterval,
+            Job: func(ctx context.Context) error {
+                for ctx.Err() == nil {
+                    ran, err := reportJobService.RunNext(ctx)
+                    if err != nil || !ran {
+                        return err
+                    }
+                }
+                return nil
+            },
+        })
+    }
+    addWorker(runner, worker.Worker{
+        Name:      "report-job-retention",
+        Interval:  time.Hour,
+        Singleton: true,
+        Job: func(ctx context.Context) error {
+            cutoff := time.Now().Add(-cfg.ReportJobs.Retention)
+            purged, err := reportJobService.Purge(ctx, cutoff)
+            if err != nil {
+                return err
+            }
+            if purged > 0 {
+                logger.Info("Report jobs purged",
+                    zap.Int64("count", purged),
+                )
+            }
+
+            // Provisioning batches go with their jobs
+            rows, err := provisioningService.Purge(ctx, cutoff)
+            if err != nil {
+                return err
+            }
+            if rows > 0 {
+                logger.Info("Wallet batch rows purged",
+                    zap.Int64("count", rows),
+                )
+            }
+
+            // As do bulk credit operations
+            operations, err := bulkCreditService.Purge(ctx, cutoff)
+            if err != nil {
+                return err
+            }
+            if operations > 0 {
+                logger.Info("Bulk credits purged",
+                    zap.Int64("count", operations),
+                )
+            }
+            return nil
+        },
+    })
+
+    // Initialize maintenance of the monthly transaction partitions
+    if cfg.TransactionArchive.Enabled {
+        partitionRepo := openRepository(repos, "transaction partition", repository.NewTransactionPartitionRepository)
+
+        archiver, err := service.NewTransactionArchiver(partitionRepo, cfg.TransactionArchive.RetentionMonths, cfg.TransactionArchive.MonthsAhead, logger)
+        if err != nil {
+            logger.Fatal("Failed to create transaction archiver",
+                zap.Error(err),
+            )
+        }
+
+        addWorker(runner, worker.Worker{
+            Name:      "transaction-archive",
+            Interval:  cfg.TransactionArchive.Interval,
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                _, err := archiver.Maintain(ctx, time.Now())
+                return err
+            },
+        })
+    }
+
+    // Initialize ledger reconciliation
+    reconRepo := openRepository(repos, "reconciliation", repository.NewReconciliationRepository)
+
+    reconService, err := service.NewReconciliationService(reconRepo, logger)
+    if err != nil {
+        logger.Fatal("Failed to create reconciliation service",
+            zap.Error(err),
+        )
+    }
+
+    if err := runbookRegistry.Register(runbook.ReconciliationAction(reconService)); err != nil {
+        logger.Fatal("Failed to register runbook action",
+            zap.Error(err),
+        )
+    }
+    if err := reportJobService.Register(service.ReconciliationReport(reconService)); err != nil {
+        logger.Fatal("Failed to register report kind",
+            zap.Error(err),
+        )
+    }
+
+    reconHandler, err := api.NewReconciliationHandler(reconService)
+    if err != nil {
+        logger.Fatal("Failed to create reconciliation handler",
+            zap.Error(err),
+        )
+    }
+
+    if cfg.Reconciliation.Enabled {
+        addWorker(runner, worker.Worker{
+            Name:      "reconciliation",
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                reconService.RunNightly(ctx, cfg.Reconciliation.RunAt)
+                return nil
+            },
+        })
+    }
+
+    if cfg.Snapshots.Enabled {
+        addWorker(runner, worker.Worker{
+            Name:      "balance-snapshots",
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                historyService.RunDaily(ctx, cfg.Snapshots.RunAt)
+                return nil
+            },
+        })
+    }
+
+    // Initialize event publishing to the notification pipeline
+    eventRegistry, err := events.DefaultRegistry()
+    if err != nil {
+        logger.Fatal("Failed to load event schema registry",
+            zap.Error(err),
+        )
+    }
+
+    publisher, err := events.NewStreamPublisher(redisClient, eventRegistry, cfg.Events.Stream, cfg.Events.StreamMaxLen)
+    if err != nil {
+        logger.Fatal("Failed to create event publisher",
+            zap.Error(err),
+        )
+    }
+
+    // Notifications the pipeline cannot take while it is down are queued
+    // and redelivered rather than dropped
+    var eventPublisher events.Publisher = publisher
+    var notificationHandler *api.NotificationHandler
+    if cfg.NotificationQueue.Enabled {
+        notificationRepo := openRepository(repos, "notification queue", repository.NewNotificationQueueRepository)
+
+        notificationQueue, err := service.NewNotificationQueueService(notificationRepo, publisher, service.NotificationQueueOptions{
+            AlertTTL:  cfg.NotificationQueue.AlertTTL,
+            NoticeTTL: cfg.NotificationQueue.NoticeTTL,
+        }, logger)
+        if err != nil {
+            logger.Fatal("Failed to create notification queue service",
+                zap.Error(err),
+            )
+        }
+        eventPublisher = notificationQueue
+
+        notificationHandler, err = api.NewNotificationHandler(notificationQueue)
+        if err != nil {
+            logger.Fatal("Failed to create notification handler",
+                zap.Error(err),
+            )
+        }
+
+        addWorker(runner, worker.Worker{
+            Name:      "notification-retries",
+            Interval:  cfg.NotificationQueue.RetryInterval,
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                _, err := notificationQueue.RetryDue(ctx, time.Now().UTC())
+                return err
+            },
+        })
+        addWorker(runner, worker.Worker{
+            Name:      "notification-retention",
+            Interval:  time.Hour,
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                purged, err := notificationQueue.Purge(ctx, time.Now().Add(-cfg.NotificationQueue.Retention))
+                if err != nil {
+                    return err
+                }
+                if purged > 0 {
+                    logger.Info("Expired notifications purged",
+                        zap.Int64("count", purged),
+                    )
+                }
+                return nil
+            },
+        })
+    }
+
+    // Initialize customer consent, which gates optional notifications
+    consentRepo := openRepository(repos, "consent", repository.NewConsentRepository)
+
+    consentService, err := service.NewConsentService(consentRepo, logger)
+    if err != nil {
+        logger.Fatal("Failed to create consent service",
+            zap.Error(err),
+        )
+    }
+
+    consentHandler, err := api.NewConsentHandler(consentService)
+    if err != nil {
+        logger.Fatal("Failed to create consent handler",
+            zap.Error(err),
+        )
+    }
+
+    // The Redis event stream carries domain events to downstream consumers
+    forwarder, err := events.NewForwarder(eventRegistry, eventPublisher, consentService)
+    if err != nil {
+        logger.Fatal("Failed to create event stream forwarder",
+            zap.Error(err),
+        )
+    }
+    if err := bus.Subscribe("event-stream", forwarder.Handle, forwarder.EventTypes()...); err != nil {
+        logger.Fatal("Failed to subscribe event stream to the event bus",
+            zap.Error(err),
+        )
+    }
+
+    // Wallet flows feed both the per-wallet spending summaries and the
+    // usage the balance alert thresholds are a percentage of
+    walletAnalyticsRepo := openRepository(repos, "wallet analytics", repository.NewWalletAnalyticsRepository)
+
+    // Balance thresholds alert as wallet balances fall below their warning
+    // and critical levels, re-arming only once the balance recovers, and
+    // below each of the wallet's alert thresholds, re-arming on recharge
+    var thresholdHandler *api.BalanceThresholdHandler
+    if cfg.BalanceThresholds.Enabled {
+        thresholdRepo := openRepository(repos, "balance threshold", repository.NewBalanceThresholdRepository)
+
+        thresholdService, err := service.NewBalanceThresholdService(thresholdRepo, walletAnalyticsRepo, walletService, bus, service.BalanceThresholdPolicy{
+            WarningPercent:       cfg.BalanceThresholds.WarningPercent,
+            WarningResetPercent:  cfg.BalanceThresholds.WarningResetPercent,
+            CriticalPercent:      cfg.BalanceThresholds.CriticalPercent,
+            CriticalResetPercent: cfg.BalanceThresholds.CriticalResetPercent,
+        }, logger)
+        if err != nil {
+            logger.Fatal("Failed to create balance threshold service",
+                zap.Error(err),
+            )
+        }
+        if err := bus.Subscribe("balance-thresholds", thresholdService.Handle, eventbus.TypeBalanceChanged); err != nil {
+            logger.Fatal("Failed to subscribe balance thresholds to the event bus",
+                zap.Error(err),
+            )
+        }
+
+        thresholdHandler, err = api.NewBalanceThresholdHandler(thresholdService)
+        if err != nil {
+            logger.Fatal("Failed to create balance threshold handler",
+                zap.Error(err),
+            )
+        }
+    }
+
+    // Live wallet updates reach event stream clients on every replica
+    // through Redis pub/sub
+    var streamHandler *api.StreamHandler
+    var wsHandler *api.WebSocketHandler
+    if cfg.LiveUpdates.Enabled {
+        walletFeed, err := walletfeed.NewRedisFeed(redisClient, cfg.LiveUpdates.History, cfg.LiveUpdates.HistoryTTL)
+        if err != nil {
+            logger.Fatal("Failed to create wallet feed",
+                zap.Error(err),
+            )
+        }
+
+        relay, err := walletfeed.NewRelay(walletFeed, walletService)
+        if err != nil {
+            logger.Fatal("Failed to create wallet feed relay",
+                zap.Error(err),
+            )
+        }
+        if err := bus.Subscribe("wallet-feed", relay.Handle, relay.EventTypes()...); err != nil {
+            logger.Fatal("Failed to subscribe wallet feed to the event bus",
+                zap.Error(err),
+            )
+        }
+
+        streamHandler, err = api.NewStreamHandler(walletService, walletFeed, cfg.LiveUpdates.Heartbeat)
+        if err != nil {
+            logger.Fatal("Failed to create stream handler",
+                zap.Error(err),
+            )
+        }
+
+        if cfg.WebSocket.Enabled {
+            wsHandler, err = api.NewWebSocketHandler(walletService, walletFeed, api.WebSocketOptions{
+                MaxSubscriptions: cfg.WebSocket.MaxSubscriptions,
+                MessageRate:      cfg.WebSocket.MessageRate,
+                MessageBurst:     cfg.WebSocket.MessageBurst,
+                PingInterval:     cfg.WebSocket.PingInterval,
+                WriteTimeout:     cfg.WebSocket.WriteTimeout,
+            })
+            if err != nil {
+                logger.Fatal("Failed to create WebSocket handler",
+                    zap.Error(err),
+                )
+            }
+        }
+    }
+
+    // Initialize customer settings such as the reporting timezone
+    customerService, err := service.NewCustomerService(customerRepo, logger)
+    if err != nil {
+        logger.Fatal("Failed to create customer service",
+            zap.Error(err),
+        )
+    }
+
+    customerHandler, err := api.NewCustomerHandler(customerService)
+    if err != nil {
+        logger.Fatal("Failed to create customer handler",
+            zap.Error(err),
+        )
+    }
+
+    // Customer balances are totalled across wallets at the configured
+    // exchange rates
+    fxRates := make(map[string]decimal.Decimal, len(cfg.Currencies.FXRates))
+    for code, rate := range cfg.Currencies.FXRates {
+        fxRates[code] = decimal.NewFromFloat(rate)
+    }
+    rateTable, err := currency.NewRateTable(cfg.Currencies.ReportingCurrency, fxRates)
+    if err != nil {
+        logger.Fatal("Failed to load exchange rates",
+            zap.Error(err),
+        )
+    }
+
+    customerBalanceService, err := service.NewCustomerBalanceService(walletService, currencies, rateTable, logger)
+    if err != nil {
+        logger.Fatal("Failed to create customer balance service",
+            zap.Error(err),
+        )
+    }
+
+    customerBalanceHandler, err := api.NewCustomerBalanceHandler(customerBalanceService)
+    if err != nil {
+        logger.Fatal("Failed to create customer balance handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize wallet activity digests
+    digestRepo := openRepository(repos, "digest", repository.NewDigestRepository)
+
+    digestService, err := service.NewDigestService(digestRepo, bus, logger)
+    if err != nil {
+        logger.Fatal("Failed to create digest service",
+            zap.Error(err),
+        )
+    }
+
+    digestHandler, err := api.NewDigestHandler(digestService)
+    if err != nil {
+        logger.Fatal("Failed to create digest handler",
+            zap.Error(err),
+        )
+    }
+
+    if cfg.Digest.Enabled {
+        addWorker(runner, worker.Worker{
+            Name:      "digests",
+            Interval:  cfg.Digest.Interval,
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                _, err := digestService.SendDue(ctx, time.Now())
+                return err
+            },
+        })
+    }
+
+    // Initialize dunning of wallets whose balance ran out
+    calendarDefs := make(map[string]calendar.Definition, len(cfg.Calendar.Countries))
+    for country, c := range cfg.Calendar.Countries {
+        calendarDefs[country] = calendar.Definition{
+            Timezone: c.Timezone,
+            Weekend:  c.Weekend,
+            Holidays: c.Holidays,
+        }
+    }
+    calendars, err := calendar.NewRegistry(calendarDefs)
+    if err != nil {
+        logger.Fatal("Failed to create business calendars",
+            zap.Error(err),
+        )
+    }
+    billingCalendar := calendars.For(cfg.Calendar.Country)
+
+    dunningRepo := openRepository(repos, "dunning", repository.NewDunningRepository)
+
+    dunningService, err := service.NewDunningService(dunningRepo, bus, service.DunningPolicy{
+        GracePeriod:       cfg.Dunning.GracePeriod,
+        NoticeSchedule:    cfg.Dunning.NoticeSchedule,
+        SuspendAfterGrace: cfg.Dunning.SuspendAfterGrace,
+    }, billingCalendar, logger)
+    if err != nil {
+        logger.Fatal("Failed to create dunning service",
+            zap.Error(err),
+        )
+    }
+
+    if cfg.Dunning.Enabled {
+        addWorker(runner, worker.Worker{
+            Name:      "dunning",
+            Interval:  cfg.Dunning.Interval,
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                return dunningService.Evaluate(ctx, time.Now())
+            },
+        })
+    }
+
+    // Initialize the inactivity lifecycle of wallets without transactions
+    if cfg.WalletActivity.Enabled {
+        activityRepo := openRepository(repos, "wallet activity", repository.NewWalletActivityRepository)
+
+        activityService, err := service.NewWalletActivityService(activityRepo, bus, service.InactivityPolicy{
+            After:     cfg.WalletActivity.InactiveAfter,
+            BatchSize: cfg.WalletActivity.BatchSize,
+        }, logger)
+        if err != nil {
+            logger.Fatal("Failed to create wallet activity service",
+                zap.Error(err),
+            )
+        }
+
+        addWorker(runner, worker.Worker{
+            Name:      "wallet-activity",
+            Interval:  cfg.WalletActivity.Interval,
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                return activityService.Sweep(ctx, time.Now().UTC())
+            },
+        })
+    }
+
+    // Initialize the retries of failed background jobs, whose kinds are
+    // registered by the services running them
+    failedJobRepo := openRepository(repos, "failed job", repository.NewFailedJobRepository)
+
+    failedJobService, err := service.NewFailedJobService(failedJobRepo, auditRepo, logger)
+    if err != nil {
+        logger.Fatal("Failed to create failed job service",
+            zap.Error(err),
+        )
+    }
+
+    failedJobHandler, err := api.NewFailedJobHandler(failedJobService)
+    if err != nil {
+        logger.Fatal("Failed to create failed job handler",
+            zap.Error(err),
+        )
+    }
+
+    addWorker(runner, worker.Worker{
+        Name:      "failed-job-retries",
+        Interval:  cfg.FailedJobs.RetryInterval,
+        Singleton: true,
+        Job: func(ctx context.Context) error {
+            _, err := failedJobService.ProcessRetries(ctx, time.Now())
+            return err
+        },
+    })
+
+    // Initialize customer webhook delivery from the event stream
+    webhookRepo := openRepository(repos, "webhook", repository.NewWebhookRepository)
+
+    webhookSender, err := webhook.NewSender(cfg.Webhooks.DeliveryTimeout)
+    if err != nil {
+        logger.Fatal("Failed to create webhook sender",
+            zap.Error(err),
+        )
+    }
+
+    webhookService, err := service.NewWebhookService(webhookRepo, eventRegistry, webhookSender, failedJobService, logger)
+    if err != nil {
+        logger.Fatal("Failed to create webhook service",
+            zap.Error(err),
+        )
+    }
+
+    err = failedJobService.Register(models.FailedJobWebhookDelivery, service.FailedJobPolicy{
+        MaxAttempts: cfg.FailedJobs.WebhookDelivery.MaxAttempts,
+        MinBackoff:  cfg.FailedJobs.WebhookDelivery.MinBackoff,
+        MaxBackoff:  cfg.FailedJobs.WebhookDelivery.MaxBackoff,
+    }, webhookService.RetryDelivery)
+    if err != nil {
+        logger.Fatal("Failed to register webhook delivery retries",
+            zap.Error(err),
+        )
+    }
+
+    webhookHandler, err := api.NewWebhookHandler(webhookService)
+    if err != nil {
+        logger.Fatal("Failed to create webhook handler",
+            zap.Error(err),
+        )
+    }
+
+    if cfg.Webhooks.Enabled {
+        // Consumers are named by host so a restarted replica retries the
+        // deliveries it left pending
+        hostname, _ := os.Hostname()
+        consumer, err := events.NewStreamConsumer(redisClient, cfg.Events.Stream, cfg.Webhooks.ConsumerGroup,
+            hostname, cfg.Webhooks.BatchSize)
+        if err != nil {
+            logger.Fatal("Failed to create webhook event consumer",
+                zap.Error(err),
+            )
+        }
+        // Replicas share the stream through the consumer group
+        addWorker(runner, worker.Worker{
+            Name: "webhook-dispatcher",
+            Job: func(ctx context.Context) error {
+                webhookService.RunDispatcher(ctx, consumer)
+                return nil
+            },
+        })
+    }
+
+    // Initialize customer email, SMS and Slack notifications from the event
+    // stream. Email and SMS channels are only offered where their mail
+    // server or gateway is configured. Invoice PDFs, once branded, are
+    // delivered through the same channels with the PDF attached to emails.
+    var channelHandler *api.NotificationChannelHandler
+    var invoiceHandler *api.InvoiceHandler
+    if cfg.Notifications.Enabled {
+        channelRepo := openRepository(repos, "notification channel", repository.NewNotificationChannelRepository)
+
+        slackSender, err := notify.NewSlackSender(cfg.Notifications.Timeout)
+        if err != nil {
+            logger.Fatal("Failed to create Slack sender",
+                zap.Error(err),
+            )
+        }
+        senders := map[models.NotificationChannelKind]notify.Sender{
+            models.NotificationChannelSlack: slackSender,
+        }
+        if smtpCfg := cfg.Notifications.SMTP; smtpCfg.Host != "" {
+            smtpSender, err := notify.NewSMTPSender(notify.SMTPConfig{
+                Host:     smtpCfg.Host,
+                Port:     smtpCfg.Port,
+                Username: smtpCfg.Username,
+                Password: smtpCfg.Password,
+                From:     smtpCfg.From,
+                Timeout:  cfg.Notifications.Timeout,
+            })
+            if err != nil {
+                logger.Fatal("Failed to create SMTP sender",
+                    zap.Error(err),
+                )
+            }
+            senders[models.NotificationChannelEmail] = smtpSender
+        }
+        if smsCfg := cfg.Notifications.SMS; smsCfg.URL != "" {
+            provider, err := notify.NewHTTPSMSProvider(smsCfg.URL, smsCfg.APIKey, smsCfg.From, cfg.Notifications.Timeout)
+            if err != nil {
+                logger.Fatal("Failed to create SMS provider",
+                    zap.Error(err),
+                )
+            }
+            smsSender, err := notify.NewSMSSender(provider)
+            if err != nil {
+                logger.Fatal("Failed to create SMS sender",
+                    zap.Error(err),
+                )
+            }
+            senders[models.NotificationChannelSMS] = smsSender
+        }
+
+        templates := make([]notify.Template, len(cfg.Notifications.Templates))
+        for i, t := range cfg.Notifications.Templates {
+            templates[i] = notify.Template{EventType: t.EventType, Subject: t.Subject, Body: t.Body}
+        }
+        notificationTemplates, err := notify.NewTemplates(templates)
+        if err != nil {
+            logger.Fatal("Failed to parse notification templates",
+                zap.Error(err),
+            )
+        }
+
+        channelService, err := service.NewNotificationChannelService(channelRepo, senders, notificationTemplates, bus, logger)
+        if err != nil {
+            logger.Fatal("Failed to create notification channel service",
+                zap.Error(err),
+            )
+        }
+
+        channelHandler, err = api.NewNotificationChannelHandler(channelService)
+        if err != nil {
+            logger.Fatal("Failed to create notification channel handler",
+                zap.Error(err),
+            )
+        }
+
+        if invoiceCfg := cfg.InvoiceDocuments; invoiceCfg.Enabled {
+            invoiceRepo := openRepository(repos, "invoice document", repository.NewInvoiceDocumentRepository)
+
+            renderer, err := invoicepdf.NewRenderer(invoicepdf.Branding{
+                CompanyName: invoiceCfg.CompanyName,
+                Address:     invoiceCfg.Address,
+                TaxID:       invoiceCfg.TaxID,
+                AccentColor: invoiceCfg.AccentColor,
+            }, invoicepdf.Templates{
+                Title:  invoiceCfg.Templates.Title,
+                Notes:  invoiceCfg.Templates.Notes,
+                Footer: invoiceCfg.Templates.Footer,
+            })
+            if err != nil {
+                logger.Fatal("Failed to create invoice renderer",
+                    zap.Error(err),
+                )
+            }
+
+            invoiceService, err := service.NewInvoiceDocumentService(invoiceRepo, renderer, channelService, receivableService,
+                invoiceCfg.PublicURL, logger)
+            if err != nil {
+                logger.Fatal("Failed to create invoice document service",
+                    zap.Error(err),
+                )
+            }
+            if err := channelService.RegisterAttachments(events.TypeInvoiceIssued, invoiceService.Attachments); err != nil {
+                logger.Fatal("Failed to register invoice attachments",
+                    zap.Error(err),
+                )
+            }
+
+            invoiceHandler, err = api.NewInvoiceHandler(invoiceService)
+            if err != nil {
+                logger.Fatal("Failed to create invoice handler",
+                    zap.Error(err),
+                )
+            }
+        }
+
+        hostname, _ := os.Hostname()
+        consumer, err := events.NewStreamConsumer(redisClient, cfg.Events.Stream, cfg.Notifications.ConsumerGroup,
+            hostname, cfg.Notifications.BatchSize)
+        if err != nil {
+            logger.Fatal("Failed to create notification event consumer",
+                zap.Error(err),
+            )
+        }
+        addWorker(runner, worker.Worker{
+            Name: "notification-dispatcher",
+            Job: func(ctx context.Context) error {
+                channelService.RunDispatcher(ctx, consumer)
+                return nil
+            },
+        })
+    }
+
+    // Initialize recurring debits executed by the standing instruction scheduler
+    recurringRepo := openRepository(repos, "recurring debit", repository.NewRecurringDebitRepository)
+
+    recurringService, err := service.NewRecurringDebitService(recurringRepo, walletService, service.RecurringDebitPolicy{
+        CatchUpWindow: cfg.RecurringDebits.CatchUpWindow,
+        ClaimTimeout:  cfg.RecurringDebits.ClaimTimeout,
+    }, failedJobService, logger)
+    if err != nil {
+        logger.Fatal("Failed to create recurring debit service",
+            zap.Error(err),
+        )
+    }
+
+    err = failedJobService.Register(models.FailedJobRecurringDebitRun, service.FailedJobPolicy{
+        MaxAttempts: cfg.FailedJobs.RecurringDebitRun.MaxAttempts,
+        MinBackoff:  cfg.FailedJobs.RecurringDebitRun.MinBackoff,
+        MaxBackoff:  cfg.FailedJobs.RecurringDebitRun.MaxBackoff,
+    }, recurringService.RetryRun)
+    if err != nil {
+        logger.Fatal("Failed to register recurring debit run retries",
+            zap.Error(err),
+        )
+    }
+
+    recurringHandler, err := api.NewRecurringDebitHandler(recurringService)
+    if err != nil {
+        logger.Fatal("Failed to create recurring debit handler",
+            zap.Error(err),
+        )
+    }
+
+    if cfg.RecurringDebits.Enabled {
+        addWorker(runner, worker.Worker{
+            Name:      "recurring-debits",
+            Interval:  cfg.RecurringDebits.Interval,
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                _, err := recurringService.ProcessDue(ctx, time.Now())
+                return err
+            },
+        })
+    }
+
+    // Initialize refunds to the original payment method. Gateway adapters
+    // are registered here; top-ups of unregistered providers cannot be linked.
+    gateways, err := guardPaymentProviders(cfg)
+    if err != nil {
+        logger.Fatal("Failed to guard payment providers",
+            zap.Error(err),
+        )
+    }
+    paymentProviders, err := payment.NewRegistry(gateways...)
+    if err != nil {
+        logger.Fatal("Failed to create payment provider registry",
+            zap.Error(err),
+        )
+    }
+
+    refundRepo := openRepository(repos, "refund", repository.NewRefundRepository)
+
+    refundService, err := service.NewRefundService(refundRepo, paymentProviders, billingCalendar, currencies, bus, logger)
+    if err != nil {
+        logger.Fatal("Failed to create refund service",
+            zap.Error(err),
+        )
+    }
+
+    refundHandler, err := api.NewRefundHandler(refundService)
+    if err != nil {
+        logger.Fatal("Failed to create refund handler",
+            zap.Error(err),
+        )
+    }
+
+    if cfg.Refunds.Enabled {
+        addWorker(runner, worker.Worker{
+            Name:      "refunds",
+            Interval:  cfg.Refunds.PollInterval,
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                _, err := refundService.ProcessDue(ctx, time.Now().UTC())
+                return err
+            },
+        })
+    }
+
+    var graphqlHandler http.Handler
+    if cfg.GraphQL.Enabled {
+        var invoiceClient *invoices.Client
+        if cfg.Invoices.BaseURL != "" {
+            invoiceClient, err = invoices.NewClient(cfg.Invoices.BaseURL, cfg.Invoices.Timeout)
+            if err != nil {
+                logger.Fatal("Failed to create invoice service client",
+                    zap.Error(err),
+                )
+            }
+        }
+
+        graphqlHandler, err = graphql.NewHandler(walletService, invoiceClient, graphql.Options{
+            ComplexityLimit: cfg.GraphQL.ComplexityLimit,
+        })
+        if err != nil {
+            logger.Fatal("Failed to create GraphQL handler",
+                zap.Error(err),
+            )
+        }
+    }
+
+    adminHandler, err := api.NewAdminHandler(runbookRegistry, auditRepo)
+    if err != nil {
+        logger.Fatal("Failed to create admin handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize the billing period close and reopening by finance
+    periodRepo := openRepository(repos, "billing period", repository.NewBillingPeriodRepository)
+
+    periodService, err := service.NewBillingPeriodService(periodRepo, auditRepo, logger)
+    if err != nil {
+        logger.Fatal("Failed to create billing period service",
+            zap.Error(err),
+        )
+    }
+
+    periodHandler, err := api.NewBillingPeriodHandler(periodService)
+    if err != nil {
+        logger.Fatal("Failed to create billing period handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize wallet export and import between deployments
+    migrationRepo := openRepository(repos, "wallet migration", repository.NewWalletMigrationRepository)
+
+    migrationService, err := service.NewWalletMigrationService(migrationRepo, auditRepo, service.WalletMigrationOptions{
+        HistoryWindow: cfg.Migrations.HistoryWindow,
+        HistoryLimit:  cfg.Migrations.HistoryLimit,
+    }, logger)
+    if err != nil {
+        logger.Fatal("Failed to create wallet migration service",
+            zap.Error(err),
+        )
+    }
+
+    migrationHandler, err := api.NewMigrationHandler(migrationService)
+    if err != nil {
+        logger.Fatal("Failed to create wallet migration handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize duplicate wallet merges
+    mergeRepo := openRepository(repos, "wallet merge", repository.NewWalletMergeRepository)
+
+    mergeService, err := service.NewWalletMergeService(mergeRepo, auditRepo, cfg.Migrations.MergeWindow, logger)
+    if err != nil {
+        logger.Fatal("Failed to create wallet merge service",
+            zap.Error(err),
+        )
+    }
+
+    mergeHandler, err := api.NewMergeHandler(mergeService)
+    if err != nil {
+        logger.Fatal("Failed to create wallet merge handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize effective-dated fee rules and fee previews
+    feeRepo := openRepository(repos, "fee rule", repository.NewFeeRuleRepository)
+
+    feeService, err := service.NewFeeService(feeRepo, auditRepo, currencies, logger)
+    if err != nil {
+        logger.Fatal("Failed to create fee service",
+            zap.Error(err),
+        )
+    }
+
+    feeHandler, err := api.NewFeeHandler(feeService)
+    if err != nil {
+        logger.Fatal("Failed to create fee handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize coupons and negotiated discounts, redeemed at rating and
+    // invoice time
+    couponRepo := openRepository(repos, "coupon", repository.NewCouponRepository)
+
+    couponService, err := service.NewCouponService(couponRepo, auditRepo, currencies, logger)
+    if err != nil {
+        logger.Fatal("Failed to create coupon service",
+            zap.Error(err),
+        )
+    }
+
+    couponHandler, err := api.NewCouponHandler(couponService)
+    if err != nil {
+        logger.Fatal("Failed to create coupon handler",
+            zap.Error(err),
+        )
+    }
 
-    // Load configuration
-    cfg, err := config.LoadConfig("config/config.yaml")
+    // Initialize charge estimates, pricing debits as they would be posted
+    estimateService, err := service.NewEstimateService(walletService, feeService, couponService, debitTaxes, postpaidService,
+        currencies, rateTable, logger)
     if err != nil {
-        logger.Fatal("Failed to load configuration",
+        logger.Fatal("Failed to create estimate service",
             zap.Error(err),
         )
     }
 
-    // Setup database connection
-    db, err := setupDatabase(cfg)
+    estimateHandler, err := api.NewEstimateHandler(estimateService)
     if err != nil {
-        logger.Fatal("Failed to setup database",
+        logger.Fatal("Failed to create estimate handler",
             zap.Error(err),
         )
     }
 
-    // Setup Redis connection
-    redisClient, err := setupRedis(cfg)
+    // Initialize manual balance adjustments, posted once approved by a
+    // second operator when above their currency's approval threshold
+    adjustmentRepo := openRepository(repos, "adjustment", repository.NewAdjustmentRepository)
+
+    approvalThresholds := make(map[string]decimal.Decimal, len(cfg.Adjustments.ApprovalThresholds))
+    for code, threshold := range cfg.Adjustments.ApprovalThresholds {
+        approvalThresholds[strings.ToUpper(code)] = decimal.NewFromFloat(threshold)
+    }
+    adjustmentService, err := service.NewAdjustmentService(adjustmentRepo, walletService, auditRepo, service.AdjustmentPolicy{
+        ApprovalThresholds: approvalThresholds,
+        PendingTTL:         cfg.Adjustments.PendingTTL,
+    }, logger)
     if err != nil {
-        logger.Fatal("Failed to setup Redis",
+        logger.Fatal("Failed to create adjustment service",
             zap.Error(err),
         )
     }
-    defer redisClient.Close()
 
-    // Initialize repository
-    repo, err := repository.NewWalletRepository(db)
+    addWorker(runner, worker.Worker{
+        Name:      "adjustment-expiry",
+        Interval:  cfg.Adjustments.ExpiryInterval,
+        Singleton: true,
+        Job: func(ctx context.Context) error {
+            _, err := adjustmentService.Expire(ctx, time.Now().UTC())
+            return err
+        },
+    })
+
+    adjustmentHandler, err := api.NewAdjustmentHandler(adjustmentService)
     if err != nil {
-        logger.Fatal("Failed to create repository",
+        logger.Fatal("Failed to create adjustment handler",
             zap.Error(err),
         )
     }
 
-    // Initialize service
-    walletService, err := service.NewWalletService(repo, cfg.Wallet.LowBalanceThreshold, logger)
+    // Initialize the receiver of webhooks sent by payment gateways and
+    // partner systems, processed in order and dead-lettered when they keep
+    // failing
+    var inboundHandler *api.InboundWebhookHandler
+    if len(cfg.InboundWebhooks.Providers) > 0 {
+        providers := make([]inbound.Provider, 0, len(cfg.InboundWebhooks.Providers))
+        for name, provider := range cfg.InboundWebhooks.Providers {
+            providers = append(providers, &inbound.HMACProvider{
+                ProviderName:     name,
+                Secret:           provider.Secret,
+                SignatureHeader:  provider.SignatureHeader,
+                SignaturePrefix:  provider.SignaturePrefix,
+                TimestampHeader:  provider.TimestampHeader,
+                Tolerance:        provider.Tolerance,
+                EventIDField:     provider.EventIDField,
+                EventTypeField:   provider.EventTypeField,
+                OrderingKeyField: provider.OrderingKeyField,
+            })
+        }
+        inboundProviders, err := inbound.NewRegistry(providers...)
+        if err != nil {
+            logger.Fatal("Failed to create webhook provider registry",
+                zap.Error(err),
+            )
+        }
+
+        inboundRepo := openRepository(repos, "inbound webhook", repository.NewInboundWebhookRepository)
+
+        inboundService, err := service.NewInboundWebhookService(inboundRepo, inboundProviders, cfg.InboundWebhooks.MaxAttempts, logger)
+        if err != nil {
+            logger.Fatal("Failed to create inbound webhook service",
+                zap.Error(err),
+            )
+        }
+
+        addWorker(runner, worker.Worker{
+            Name:      "inbound-webhooks",
+            Interval:  cfg.InboundWebhooks.ProcessInterval,
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                _, err := inboundService.ProcessReceived(ctx, time.Now().UTC())
+                return err
+            },
+        })
+
+        inboundHandler, err = api.NewInboundWebhookHandler(inboundService)
+        if err != nil {
+            logger.Fatal("Failed to create inbound webhook handler",
+                zap.Error(err),
+            )
+        }
+    }
+
+    // Initialize chargeback disputes, holding disputed top-ups until the
+    // provider decides
+    disputeRepo := openRepository(repos, "dispute", repository.NewDisputeRepository)
+
+    disputeService, err := service.NewDisputeService(disputeRepo, walletService, bus, logger)
     if err != nil {
-        logger.Fatal("Failed to create wallet service",
+        logger.Fatal("Failed to create dispute service",
             zap.Error(err),
         )
     }
 
-    // Initialize HTTP handler
-    handler, err := api.NewWalletHandler(walletService)
+    disputeHandler, err := api.NewDisputeHandler(disputeService)
     if err != nil {
-        logger.Fatal("Failed to create handler",
+        logger.Fatal("Failed to create dispute handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize product analytics aggregates
+    analyticsRepo := openRepository(repos, "analytics", repository.NewAnalyticsRepository)
+
+    analyticsService, err := service.NewAnalyticsService(analyticsRepo, service.AnalyticsOptions{
+        MinGroupSize: cfg.Analytics.MinGroupSize,
+        NoiseEpsilon: cfg.Analytics.NoiseEpsilon,
+        MedianBucket: cfg.Analytics.MedianBucket,
+    }, rand.New(rand.NewSource(time.Now().UnixNano())), logger)
+    if err != nil {
+        logger.Fatal("Failed to create analytics service",
+            zap.Error(err),
+        )
+    }
+
+    analyticsHandler, err := api.NewAnalyticsHandler(analyticsService)
+    if err != nil {
+        logger.Fatal("Failed to create analytics handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize per-wallet spending summaries, aggregated in SQL and cached
+    // in Redis
+    walletAnalyticsService, err := service.NewWalletAnalyticsService(walletAnalyticsRepo, walletService, redisClient, cfg.Analytics.WalletCacheTTL, logger)
+    if err != nil {
+        logger.Fatal("Failed to create wallet analytics service",
+            zap.Error(err),
+        )
+    }
+
+    walletAnalyticsHandler, err := api.NewWalletAnalyticsHandler(walletAnalyticsService)
+    if err != nil {
+        logger.Fatal("Failed to create wallet analytics handler",
+            zap.Error(err),
+        )
+    }
+
+    // Initialize usage accounting of internal services for chargeback
+    var usageHandler *api.UsageHandler
+    var rateCardHandler *api.RateCardHandler
+    if cfg.Usage.Enabled {
+        usageRepo := openRepository(repos, "usage", repository.NewUsageRepository)
+
+        chargebackWallets := make(map[string]uuid.UUID, len(cfg.Usage.Wallets))
+        for caller, walletID := range cfg.Usage.Wallets {
+            id, err := uuid.Parse(walletID)
+            if err != nil {
+                logger.Fatal("Invalid chargeback wallet ID",
+                    zap.String("caller", caller),
+                    zap.Error(err),
+                )
+            }
+            chargebackWallets[caller] = id
+        }
+
+        // Chargeback rates change only through reviewed rate card changes;
+        // the configured rates apply until the first is activated
+        rateCardRepo := openRepository(repos, "rate card", repository.NewRateCardRepository)
+
+        rateCardService, err := service.NewRateCardService(rateCardRepo, bus, models.RateCard{
+            PerThousandRequests: decimal.NewFromFloat(cfg.Usage.PerThousandRequests),
+            PerDBSecond:         decimal.NewFromFloat(cfg.Usage.PerDBSecond),
+            PerGB:               decimal.NewFromFloat(cfg.Usage.PerGB),
+        }, service.RateCardPolicy{
+            MinNotice: cfg.RateCards.MinNotice,
+        }, logger)
+        if err != nil {
+            logger.Fatal("Failed to create rate card service",
+                zap.Error(err),
+            )
+        }
+
+        rateCardHandler, err = api.NewRateCardHandler(rateCardService)
+        if err != nil {
+            logger.Fatal("Failed to create rate card handler",
+                zap.Error(err),
+            )
+        }
+
+        addWorker(runner, worker.Worker{
+            Name:      "rate-card-activation",
+            Interval:  cfg.RateCards.ActivationInterval,
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                return rateCardService.ActivateDue(ctx, time.Now())
+            },
+        })
+
+        usageService, err := service.NewUsageService(usageRepo, walletService, currencies, rateCardService, service.ChargebackPolicy{
+            Currency: cfg.Usage.Currency,
+            Wallets:  chargebackWallets,
+        }, logger)
+        if err != nil {
+            logger.Fatal("Failed to create usage service",
+                zap.Error(err),
+            )
+        }
+
+        if err := runbookRegistry.Register(runbook.UsageChargebackAction(usageService)); err != nil {
+            logger.Fatal("Failed to register runbook action",
+                zap.Error(err),
+            )
+        }
+        if err := reportJobService.Register(service.UsageReport(usageService)); err != nil {
+            logger.Fatal("Failed to register report kind",
+                zap.Error(err),
+            )
+        }
+
+        usageHandler, err = api.NewUsageHandler(usageService)
+        if err != nil {
+            logger.Fatal("Failed to create usage handler",
+                zap.Error(err),
+            )
+        }
+
+        // Every replica flushes the usage it metered itself
+        addWorker(runner, worker.Worker{
+            Name: "usage-flusher",
+            Job: func(ctx context.Context) error {
+                usageService.RunFlusher(ctx, cfg.Usage.FlushInterval)
+                return nil
+            },
+        })
+        if cfg.Usage.Chargeback {
+            addWorker(runner, worker.Worker{
+                Name:      "usage-chargeback",
+                Singleton: true,
+                Job: func(ctx context.Context) error {
+                    usageService.RunMonthlyChargeback(ctx, cfg.Usage.RunAt)
+                    return nil
+                },
+            })
+        }
+    }
+
+    // Initialize endpoint SLO tracking
+    var sloHandler *api.SLOHandler
+    if cfg.SLO.Enabled {
+        defs := make([]slo.Definition, 0, len(cfg.SLO.Objectives))
+        for name, objective := range cfg.SLO.Objectives {
+            defs = append(defs, slo.Definition{
+                Name:             name,
+                Method:           objective.Method,
+                Route:            objective.Route,
+                Availability:     objective.Availability,
+                Latency:          objective.Latency,
+                LatencyThreshold: objective.LatencyThreshold,
+            })
+        }
+
+        tracker, err := slo.NewTracker(defs)
+        if err != nil {
+            logger.Fatal("Failed to create SLO tracker",
+                zap.Error(err),
+            )
+        }
+
+        sloHandler, err = api.NewSLOHandler(tracker)
+        if err != nil {
+            logger.Fatal("Failed to create SLO handler",
+                zap.Error(err),
+            )
+        }
+
+        addWorker(runner, worker.Worker{
+            Name:     "slo-tracker",
+            ReadOnly: true,
+            Job: func(ctx context.Context) error {
+                tracker.Run(ctx, cfg.SLO.RefreshInterval)
+                return nil
+            },
+        })
+    }
+
+    // Initialize deprecated route signaling and caller accounting
+    deprecationDefs := make([]deprecation.Definition, 0, len(cfg.Deprecations.Routes))
+    for name, route := range cfg.Deprecations.Routes {
+        def := deprecation.Definition{
+            Name:   name,
+            Method: route.Method,
+            Route:  route.Route,
+            Link:   route.Link,
+        }
+        if def.Deprecated, err = time.Parse(deprecation.DateLayout, route.Deprecated); err != nil {
+            logger.Fatal("Invalid deprecation date",
+                zap.String("route", name),
+                zap.Error(err),
+            )
+        }
+        if route.Sunset != "" {
+            if def.Sunset, err = time.Parse(deprecation.DateLayout, route.Sunset); err != nil {
+                logger.Fatal("Invalid sunset date",
+                    zap.String("route", name),
+                    zap.Error(err),
+                )
+            }
+        }
+        deprecationDefs = append(deprecationDefs, def)
+    }
+
+    deprecationRegistry, err := deprecation.NewRegistry(deprecationDefs)
+    if err != nil {
+        logger.Fatal("Failed to create deprecation registry",
+            zap.Error(err),
+        )
+    }
+
+    deprecationRepo := openRepository(repos, "deprecation", repository.NewDeprecationRepository)
+
+    deprecationService, err := service.NewDeprecationService(deprecationRepo, deprecationRegistry, logger)
+    if err != nil {
+        logger.Fatal("Failed to create deprecation service",
+            zap.Error(err),
+        )
+    }
+
+    deprecationHandler, err := api.NewDeprecationHandler(deprecationRegistry, deprecationService)
+    if err != nil {
+        logger.Fatal("Failed to create deprecation handler",
+            zap.Error(err),
+        )
+    }
+
+    // Every replica flushes the calls it counted itself
+    addWorker(runner, worker.Worker{
+        Name: "deprecation-flusher",
+        Job: func(ctx context.Context) error {
+            deprecationService.RunFlusher(ctx, cfg.Deprecations.FlushInterval)
+            return nil
+        },
+    })
+
+    // Initialize token validation
+    validator, err := auth.NewValidator(cfg.Security)
+    if err != nil {
+        logger.Fatal("Failed to create token validator",
+            zap.Error(err),
+        )
+    }
+
+    warmCtx, warmCancel := context.WithTimeout(context.Background(), 10*time.Second)
+    if err := validator.Warm(warmCtx); err != nil {
+        logger.Warn("Failed to pre-load JWKS keys, will retry on first request",
+            zap.Error(err),
+        )
+    }
+    warmCancel()
+
+    // Initialize per-customer rate limit policies
+    rateLimitRepo := openRepository(repos, "rate limit", repository.NewRateLimitRepository)
+
+    policyResolver, err := ratelimit.NewPolicyResolver(rateLimitRepo, redisClient, cfg.Security.RateLimitPolicyTTL, models.RateLimitPolicy{
+        Tier:   "default",
+        Limit:  cfg.Security.RateLimit,
+        Window: cfg.Security.RateLimitWindow,
+    })
+    if err != nil {
+        logger.Fatal("Failed to create rate limit policy resolver",
+            zap.Error(err),
+        )
+    }
+
+    // Apply reloaded operational settings to the running components
+    watcher.Subscribe("rate-limit", func(cfg *config.Config) error {
+        return policyResolver.SetDefaultPolicy(models.RateLimitPolicy{
+            Tier:   "default",
+            Limit:  cfg.Security.RateLimit,
+            Window: cfg.Security.RateLimitWindow,
+        })
+    })
+    watcher.Subscribe("low-balance-threshold", func(cfg *config.Config) error {
+        return walletService.SetLowBalanceThreshold(decimal.NewFromFloat(cfg.Wallet.LowBalanceThreshold))
+    })
+    watcher.Subscribe("transaction-query-horizon", func(cfg *config.Config) error {
+        return walletService.SetTransactionQueryHorizon(cfg.Wallet.TransactionQueryHorizon)
+    })
+    watcher.Watch()
+
+    // Read-only deployments count requests apart from the primary API, so
+    // analytics consumers do not use up the quota of the transactional path
+    rateLimitNamespace := ""
+    if cfg.ReadOnly.Enabled {
+        rateLimitNamespace = "readonly"
+        if err := policyResolver.SetLimitMultiplier(cfg.ReadOnly.RateLimitMultiplier); err != nil {
+            logger.Fatal("Invalid read-only rate limit multiplier",
+                zap.Error(err),
+            )
+        }
+    }
+
+    limiter, err := ratelimit.NewLimiter(redisClient, rateLimitNamespace)
+    if err != nil {
+        logger.Fatal("Failed to create rate limiter",
             zap.Error(err),
         )
     }
 
+    // Initialize data classification of API responses
+    var dataAccessHandler *api.DataAccessHandler
+    if cfg.Classification.Enabled {
+        scopes := make(map[classification.Class]string, len(cfg.Classification.Scopes))
+        for class, scope := range cfg.Classification.Scopes {
+            scopes[classification.Class(class)] = scope
+        }
+
+        dataAccessHandler, err = api.NewDataAccessHandler(classification.Policy{
+            Enforce: cfg.Classification.Enforce,
+            Scopes:  scopes,
+        })
+        if err != nil {
+            logger.Fatal("Failed to create data access handler",
+                zap.Error(err),
+            )
+        }
+    }
+
+    // Capture a sample of redacted request bodies for payment disputes
+    var databaseModeMiddleware gin.HandlerFunc
+    if databaseMode != nil {
+        databaseModeMiddleware = api.DatabaseModeMiddleware(databaseMode, cfg.Degradation.ReadOnlyRetryAfter)
+    }
+
+    var bodyCapture gin.HandlerFunc
+    if cfg.BodyCapture.Enabled {
+        captureRepo := openRepository(repos, "request capture", repository.NewRequestCaptureRepository)
+
+        redactor, err := bodycapture.NewRedactor(bodycapture.Rules{
+            FieldMasks: cfg.BodyCapture.FieldMasks,
+            Patterns:   cfg.BodyCapture.Patterns,
+        })
+        if err != nil {
+            logger.Fatal("Failed to create body redactor",
+                zap.Error(err),
+            )
+        }
+
+        recorder, err := bodycapture.NewRecorder(redactor, captureRepo, bodycapture.Options{
+            SampleRate:   cfg.BodyCapture.SampleRate,
+            MaxBodyBytes: cfg.BodyCapture.MaxBodyBytes,
+            QueueSize:    cfg.BodyCapture.QueueSize,
+        }, logger)
+        if err != nil {
+            logger.Fatal("Failed to create body capture recorder",
+                zap.Error(err),
+            )
+        }
+        bodyCapture = api.BodyCaptureMiddleware(recorder)
+
+        // Every replica stores the captures of its own requests
+        addWorker(runner, worker.Worker{
+            Name: "body-capture",
+            Job:  recorder.Run,
+        })
+        addWorker(runner, worker.Worker{
+            Name:      "body-capture-retention",
+            Interval:  time.Hour,
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                purged, err := captureRepo.PurgeCaptures(ctx, time.Now().Add(-cfg.BodyCapture.Retention))
+                if err != nil {
+                    return err
+                }
+                if purged > 0 {
+                    logger.Info("Request captures purged",
+                        zap.Int64("count", purged),
+                    )
+                }
+                return nil
+            },
+        })
+    }
+
+    // Log accesses to customers' balances and transactions for their access log
+    var accessLogHandler *api.AccessLogHandler
+    if cfg.AccessLog.Enabled {
+        dataAccessRepo := openRepository(repos, "data access", repository.NewDataAccessRepository)
+
+        dataAccessService, err := service.NewDataAccessService(dataAccessRepo, cfg.AccessLog.MaxPending, logger)
+        if err != nil {
+            logger.Fatal("Failed to create data access service",
+                zap.Error(err),
+            )
+        }
+
+        accessLogHandler, err = api.NewAccessLogHandler(dataAccessService)
+        if err != nil {
+            logger.Fatal("Failed to create access log handler",
+                zap.Error(err),
+            )
+        }
+
+        // Every replica flushes the accesses it recorded itself
+        addWorker(runner, worker.Worker{
+            Name: "access-log-flusher",
+            Job: func(ctx context.Context) error {
+                dataAccessService.RunFlusher(ctx, cfg.AccessLog.FlushInterval)
+                return nil
+            },
+        })
+        addWorker(runner, worker.Worker{
+            Name:      "access-log-retention",
+            Interval:  time.Hour,
+            Singleton: true,
+            Job: func(ctx context.Context) error {
+                purged, err := dataAccessRepo.PurgeDataAccesses(ctx, time.Now().Add(-cfg.AccessLog.Retention))
+                if err != nil {
+                    return err
+                }
+                if purged > 0 {
+                    logger.Info("Data accesses purged",
+                        zap.Int64("count", purged),
+                    )
+                }
+                return nil
+            },
+        })
+    }
+
     // Setup Gin router
     gin.SetMode(gin.ReleaseMode)
     router := gin.New()
-    router = api.SetupRouter(router, cfg, handler)
+    router = api.SetupRouter(router, cfg, api.Middleware{
+        Logging:      api.LoggerMiddleware(logger),
+        Auth:         api.AuthMiddleware(validator),
+        RateLimit:    api.RateLimitMiddleware(limiter, policyResolver, rateLimitPolicy),
+        Idempotency:  api.IdempotencyMiddleware(redisClient, cfg.API.IdempotencyTTL, idempotencyPolicy),
+        BodyCapture:  bodyCapture,
+        DatabaseMode: databaseModeMiddleware,
+    }, api.Handlers{
+        Wallet:         handler,
+        Admin:          adminHandler,
+        Analytics:      analyticsHandler,
+        Spending:       walletAnalyticsHandler,
+        Health:         healthHandler,
+        Reconciliation: reconHandler,
+        Digest:         digestHandler,
+        Customer:       customerHandler,
+        Customers:      customerBalanceHandler,
+        Consent:        consentHandler,
+        Webhook:        webhookHandler,
+        Channels:       channelHandler,
+        AccessLog:      accessLogHandler,
+        Invoices:       invoiceHandler,
+        Receivables:    receivableHandler,
+        Postpaid:       postpaidHandler,
+        Allowances:     allowanceHandler,
+        Buckets:        bucketHandler,
+        Organizations:  organizationHandler,
+        Recurring:      recurringHandler,
+        Refund:         refundHandler,
+        Stream:         streamHandler,
+        WebSocket:      wsHandler,
+        DataAccess:     dataAccessHandler,
+        Usage:          usageHandler,
+        RateCard:       rateCardHandler,
+        Adjustment:     adjustmentHandler,
+        SettingsChange: settingsChangeHandler,
+        Inbound:        inboundHandler,
+        FailedJob:      failedJobHandler,
+        Tax:            taxHandler,
+        Fee:            feeHandler,
+        Coupon:         couponHandler,
+        Dispute:        disputeHandler,
+        Estimate:       estimateHandler,
+        SLO:            sloHandler,
+        BillingPeriod:  periodHandler,
+        Migration:      migrationHandler,
+        Merge:          mergeHandler,
+        Provisioning:   provisioningHandler,
+        BulkCredit:     bulkCreditHandler,
+        Incident:       incidentHandler,
+        ReportJob:      reportJobHandler,
+        Export:         exportHandler,
+        Notification:   notificationHandler,
+        Thresholds:     thresholdHandler,
+        Deprecation:    deprecationHandler,
+        GraphQL:        graphqlHandler,
+    })
 
     // Create HTTP server
     srv := &http.Server{
@@ -125,6 +2386,9 @@ func main() {
         IdleTimeout:  cfg.API.IdleTimeout,
     }
 
+    // Start background workers
+    runner.Start(context.Background())
+
     // Start server in goroutine
     go func() {
         logger.Info("Starting server",
@@ -159,52 +2423,211 @@ func main() {
     ctx, cancel := context.WithTimeout(context.Background(), cfg.API.ShutdownTimeout)
     defer cancel()
 
+    // Hijacked WebSocket connections are not closed by the server shutdown
+    if wsHandler != nil {
+        if err := wsHandler.Drain(ctx); err != nil {
+            logger.Error("WebSocket connections forced to close", err)
+        }
+    }
+
     // Attempt graceful shutdown
     if err := srv.Shutdown(ctx); err != nil {
-        logger.Error("Server forced to shutdown",
+        logger.Error("Server forced to shutdown", err)
+    }
+
+    // Stop the workers once in-flight requests are done, then close the
+    // connections they use
+    if err := runner.Shutdown(ctx); err != nil {
+        logger.Error("Workers forced to stop", err)
+    }
+
+    logger.Info("Server exited")
+}
+
+// addWorker registers a background worker with the runner
+func addWorker(runner *worker.Runner, w worker.Worker) {
+    if err := runner.Add(w); err != nil {
+        logger.Fatal("Failed to register worker",
+            zap.String("worker", w.Name),
             zap.Error(err),
         )
     }
+}
 
-    logger.Info("Server exited")
+// repositories are the repositories of the primary database. Their prepared
+// statements close in reverse order of construction once every worker
+// stopped.
+type repositories struct {
+    db      *sql.DB
+    closers []io.Closer
 }
 
-// setupLogger initializes the production logger
-func setupLogger() (*zap.Logger, error) {
-    config := zap.NewProductionConfig()
-    config.OutputPaths = []string{"stdout"}
-    config.ErrorOutputPaths = []string{"stderr"}
-    
-    return config.Build(
-        zap.AddCaller(),
-        zap.AddStacktrace(zap.ErrorLevel),
-    )
+// newRepositories returns the repositories of a database, closed by the
+// runner at shutdown before the database itself
+func newRepositories(db *sql.DB, runner *worker.Runner) *repositories {
+    repos := &repositories{db: db}
+    runner.OnShutdown("repository-statements", repos.close)
+    return repos
 }
 
-// setupDatabase establishes the database connection with proper configuration
-func setupDatabase(cfg *config.Config) (*gorm.DB, error) {
-    dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-        cfg.Database.Host,
-        cfg.Database.Port,
-        cfg.Database.User,
-        cfg.Database.Password,
-        cfg.Database.DBName,
-        cfg.Database.SSLMode,
-    )
+// openRepository creates a repository with its constructor and closes it
+// with the others at shutdown
+func openRepository[T io.Closer](repos *repositories, name string, newRepository func(*sql.DB) (T, error)) T {
+    repo, err := newRepository(repos.db)
+    if err != nil {
+        logger.Fatal("Failed to create "+name+" repository",
+            zap.Error(err),
+        )
+    }
+    repos.closers = append(repos.closers, repo)
+    return repo
+}
+
+// close closes the repositories, the latest created first
+func (r *repositories) close(context.Context) error {
+    var errs []error
+    for i := len(r.closers) - 1; i >= 0; i-- {
+        errs = append(errs, r.closers[i].Close())
+    }
+    return errors.Join(errs...)
+}
+
+// databaseName names the database the service connects to in metrics and
+// logs: the primary, or the replica in read-only deployments
+func databaseName(cfg *config.Config) string {
+    if cfg.ReadOnly.Enabled {
+        return "replica"
+    }
+    return "primary"
+}
+
+// newSlowQueryLog creates the slow query log of the named database, nil when
+// slow queries are not logged
+func newSlowQueryLog(cfg *config.Config, database string) (*usage.SlowQueryLog, error) {
+    if cfg.Database.SlowQueryThreshold == 0 {
+        return nil, nil
+    }
+    slowQueries, err := usage.NewSlowQueryLog(database, cfg.Database.SlowQueryThreshold, logger)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create slow query log: %w", err)
+    }
+    return slowQueries, nil
+}
+
+// addPoolStatsWorker exports the connection pool statistics of the named
+// database as metrics on a ticker
+func addPoolStatsWorker(runner *worker.Runner, cfg *config.Config, database string, db *sql.DB) {
+    poolStats, err := usage.NewPoolStats(database, db)
+    if err != nil {
+        logger.Fatal("Failed to create connection pool statistics",
+            zap.String("database", database),
+            zap.Error(err),
+        )
+    }
+    addWorker(runner, worker.Worker{
+        Name:     database + "-pool-stats",
+        Interval: cfg.Database.PoolStatsInterval,
+        ReadOnly: true,
+        Job:      poolStats.Record,
+    })
+}
+
+// newBreaker creates the circuit breaker of a dependency from its
+// configuration
+func newBreaker(name string, cfg config.BreakerConfig, successful func(error) bool) (*breaker.Breaker, error) {
+    return breaker.New(name, breaker.Settings{
+        ConsecutiveFailures: uint32(cfg.ConsecutiveFailures),
+        Interval:            cfg.Interval,
+        OpenTimeout:         cfg.OpenTimeout,
+        HalfOpenRequests:    uint32(cfg.HalfOpenRequests),
+    }, successful, logger)
+}
 
-    db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-        Logger: logger.WithOptions(zap.AddCallerSkip(1)),
+// guardPaymentProviders wraps each payment gateway adapter in a circuit
+// breaker of its own, so an outage of one gateway leaves the others alone
+func guardPaymentProviders(cfg *config.Config, providers ...payment.Provider) ([]payment.Provider, error) {
+    if !cfg.CircuitBreakers.PaymentProvider.Enabled {
+        return providers, nil
+    }
+
+    guarded := make([]payment.Provider, 0, len(providers))
+    for _, provider := range providers {
+        b, err := newBreaker("payment:"+provider.Name(), cfg.CircuitBreakers.PaymentProvider, payment.Successful)
+        if err != nil {
+            return nil, err
+        }
+        p, err := payment.WithBreaker(provider, b)
+        if err != nil {
+            return nil, err
+        }
+        guarded = append(guarded, p)
+    }
+    return guarded, nil
+}
+
+// applyLogLevel sets the level of the global logger from the configuration
+func applyLogLevel(cfg *config.Config) error {
+    return logger.SetLevel(cfg.Logging.Level)
+}
+
+// setupDatabase establishes the database connection with proper
+// configuration. With a secrets provider, the connection's credentials are
+// refreshed through the returned rotating connector. Read-only deployments
+// connect to the read replica instead.
+func setupDatabase(cfg *config.Config, provider secrets.Provider) (*gorm.DB, *secrets.RotatingConnector, error) {
+    open := func(creds secrets.Credentials) (driver.Connector, error) {
+        return pgxConnector(fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+            dsnValue(cfg.Database.Host),
+            cfg.Database.Port,
+            dsnValue(creds.User),
+            dsnValue(creds.Password),
+            dsnValue(cfg.Database.DBName),
+            dsnValue(cfg.Database.SSLMode),
+        ))
+    }
+    initial := secrets.Credentials{User: cfg.Database.User, Password: cfg.Database.Password}
+
+    // Read-only deployments connect to the replica, which refuses writes
+    // however they are attempted
+    var connector driver.Connector
+    var rotating *secrets.RotatingConnector
+    var err error
+    if cfg.ReadOnly.Enabled {
+        connector, err = pgxConnector(cfg.Database.ReplicaDSN)
+    } else if provider != nil {
+        rotating, err = secrets.NewRotatingConnector(provider, initial, open)
+        connector = rotating
+    } else {
+        connector, err = open(initial)
+    }
+    if err != nil {
+        return nil, nil, fmt.Errorf("invalid database configuration: %w", err)
+    }
+
+    // Database calls are metered so internal usage accounting can attribute
+    // database time to the requests that made them
+    slowQueries, err := newSlowQueryLog(cfg, databaseName(cfg))
+    if err != nil {
+        return nil, nil, err
+    }
+
+    db, err := gorm.Open(postgres.New(postgres.Config{
+        Conn: sql.OpenDB(usage.MeteredConnector(connector, slowQueries)),
+    }), &gorm.Config{
+        Logger: logging.NewGormLogger(logger),
+        // The database may not be up yet; main waits for it
+        DisableAutomaticPing: true,
         NowFunc: func() time.Time {
             return time.Now().UTC()
         },
     })
     if err != nil {
-        return nil, fmt.Errorf("failed to connect to database: %w", err)
+        return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
     }
 
     sqlDB, err := db.DB()
     if err != nil {
-        return nil, fmt.Errorf("failed to get database instance: %w", err)
+        return nil, nil, fmt.Errorf("failed to get database instance: %w", err)
     }
 
     // Configure connection pool
@@ -212,12 +2635,75 @@ func setupDatabase(cfg *config.Config) (*gorm.DB, error) {
     sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
     sqlDB.SetConnMaxLifetime(cfg.Database.MaxConnLifetime)
 
-    return db, nil
+    return db, rotating, nil
+}
+
+// replicaRepositories are the repositories of a read replica
+type replicaRepositories struct {
+    wallets repository.WalletRepository
+    status  repository.ReplicaRepository
+}
+
+// setupReplica connects to the read replica with the primary's pool
+// settings. The connection closes once the workers have stopped.
+func setupReplica(cfg *config.Config, runner *worker.Runner) (*replicaRepositories, error) {
+    connector, err := pgxConnector(cfg.Database.ReplicaDSN)
+    if err != nil {
+        return nil, fmt.Errorf("invalid replica DSN: %w", err)
+    }
+
+    slowQueries, err := newSlowQueryLog(cfg, "replica")
+    if err != nil {
+        return nil, err
+    }
+
+    replicaDB := sql.OpenDB(usage.MeteredConnector(connector, slowQueries))
+    replicaDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+    replicaDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+    replicaDB.SetConnMaxLifetime(cfg.Database.MaxConnLifetime)
+    addPoolStatsWorker(runner, cfg, "replica", replicaDB)
+
+    wallets, err := repository.NewWalletRepository(replicaDB)
+    if err != nil {
+        replicaDB.Close()
+        return nil, err
+    }
+    status, err := repository.NewReplicaRepository(replicaDB)
+    if err != nil {
+        replicaDB.Close()
+        return nil, err
+    }
+
+    runner.OnShutdown("database-replica", func(context.Context) error {
+        return replicaDB.Close()
+    })
+    runner.OnShutdown("wallet-replica-statements", func(context.Context) error {
+        return wallets.Close()
+    })
+
+    return &replicaRepositories{wallets: wallets, status: status}, nil
+}
+
+// pgxConnector creates a pgx connector from a connection string, in
+// keyword/value or URL form. Connections cache the statements they prepare.
+func pgxConnector(dsn string) (driver.Connector, error) {
+    connConfig, err := pgx.ParseConfig(dsn)
+    if err != nil {
+        return nil, err
+    }
+    return stdlib.GetConnector(*connConfig), nil
+}
+
+// dsnValue quotes a connection string value, as generated passwords may
+// contain spaces and quotes
+func dsnValue(value string) string {
+    return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
 }
 
-// setupRedis establishes Redis connection with proper configuration
-func setupRedis(cfg *config.Config) (*redis.Client, error) {
-    client := redis.NewClient(&redis.Options{
+// setupRedis creates the Redis client. Connections are made on first use,
+// so the client reconnects whenever Redis becomes available.
+func setupRedis(cfg *config.Config) *redis.Client {
+    return redis.NewClient(&redis.Options{
         Addr:         fmt.Sprintf("%s:%d", cfg.Cache.Host, cfg.Cache.Port),
         Password:     cfg.Cache.Password,
         DB:          cfg.Cache.DB,
@@ -228,14 +2714,61 @@ func setupRedis(cfg *config.Config) (*redis.Client, error) {
         MinIdleConns: 5,
         MaxRetries:   cfg.Cache.MaxRetries,
     })
+}
+
+// loadCurrencies builds the registry of supported currencies from the
+// configuration or from the currencies table
+func loadCurrencies(cfg *config.Config, sqlDB *sql.DB) (*currency.Registry, error) {
+    if cfg.Currencies.Source != "database" {
+        rounding := make(map[string]models.RoundingMode, len(cfg.Currencies.Rounding))
+        for code, mode := range cfg.Currencies.Rounding {
+            rounding[code] = models.RoundingMode(strings.ToUpper(mode))
+        }
+        return currency.FromCodes(cfg.Currencies.Supported, cfg.Currencies.Precision, rounding)
+    }
 
-    // Test connection
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    currencyRepo, err := repository.NewCurrencyRepository(sqlDB)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create currency repository: %w", err)
+    }
+    defer currencyRepo.Close()
+
+    ctx, cancel := context.WithTimeout(context.Background(), cfg.Database.ConnTimeout)
     defer cancel()
 
-    if err := client.Ping(ctx).Err(); err != nil {
-        return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+    currencies, err := currencyRepo.ListCurrencies(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return currency.New(currencies)
+}
+
+// runSelfCheck runs the startup self-check, prints the diagnostic report and
+// returns an error when the service must not start
+func runSelfCheck(cfg *config.Config, sqlDB *sql.DB, redisClient *redis.Client) error {
+    checker, err := selfcheck.NewChecker(cfg, sqlDB, redisClient)
+    if err != nil {
+        return fmt.Errorf("failed to create self-checker: %w", err)
+    }
+
+    report := checker.Run(context.Background())
+    fmt.Fprint(os.Stderr, report.String())
+
+    for _, res := range report.Results {
+        logger.Info("Self-check result",
+            zap.String("check", res.Name),
+            zap.String("status", res.Status.String()),
+            zap.String("message", res.Message),
+            zap.Duration("duration", res.Duration),
+        )
+    }
+
+    if err := report.Err(cfg.SelfCheck.AllowDegraded); err != nil {
+        return err
+    }
+    if report.Status() == selfcheck.StatusDegraded {
+        logger.Warn("Starting in degraded mode")
     }
 
-    return client, nil
-}
\ No newline at end of file
+    return nil
+}
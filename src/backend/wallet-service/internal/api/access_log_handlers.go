@@ -0,0 +1,195 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// accessTargetsKey is the context key under which handlers of routes with
+// reportedScope report the data they accessed
+const accessTargetsKey = "accessed_data"
+
+// accessScope is how a tracked route names the data it accesses
+type accessScope int
+
+const (
+	// walletScope routes access the wallet of their id parameter
+	walletScope accessScope = iota
+	// customerScope routes access the customer of their id parameter
+	customerScope
+	// reportedScope routes report the data they accessed with
+	// reportDataAccess, as it depends on the request body or results
+	reportedScope
+)
+
+// accessTarget is data a request accessed: a wallet, a customer, or every
+// customer when neither is set
+type accessTarget struct {
+	customerID *uuid.UUID
+	walletID   *uuid.UUID
+}
+
+// accessorKinds maps roles to the accessor kind shown in access logs, in
+// order of precedence
+var accessorKinds = []struct {
+	role string
+	kind models.DataAccessorKind
+}{
+	{adminRole, models.DataAccessorAdmin},
+	{supportRole, models.DataAccessorSupport},
+	{serviceRole, models.DataAccessorService},
+	{analyticsRole, models.DataAccessorAnalytics},
+}
+
+// AccessLogHandler records accesses to customers' balances and transactions
+// and serves customers their access log
+type AccessLogHandler struct {
+	service service.DataAccessService
+}
+
+// NewAccessLogHandler creates a new instance of AccessLogHandler
+func NewAccessLogHandler(service service.DataAccessService) (*AccessLogHandler, error) {
+	if service == nil {
+		return nil, errors.New("data access service is required")
+	}
+
+	return &AccessLogHandler{service: service}, nil
+}
+
+// Track records the given actions on the data named by the scope once the
+// request succeeded. Failed requests accessed nothing and are not recorded.
+func (h *AccessLogHandler) Track(scope accessScope, actions ...models.DataAccessAction) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		var targets []accessTarget
+		switch scope {
+		case walletScope, customerScope:
+			id, err := uuid.Parse(c.Param("id"))
+			if err != nil {
+				return
+			}
+			if scope == walletScope {
+				targets = []accessTarget{{walletID: &id}}
+			} else {
+				targets = []accessTarget{{customerID: &id}}
+			}
+		case reportedScope:
+			reported, ok := c.Get(accessTargetsKey)
+			if !ok {
+				return
+			}
+			targets, _ = reported.([]accessTarget)
+		}
+
+		roles := rolesFromContext(c)
+		var accessorCustomerID *uuid.UUID
+		if id, err := uuid.Parse(c.GetString("customer_id")); err == nil {
+			accessorCustomerID = &id
+		}
+		now := time.Now().UTC()
+		for _, target := range targets {
+			for _, action := range actions {
+				h.service.Record(&models.DataAccess{
+					CustomerID:         target.customerID,
+					WalletID:           target.walletID,
+					Action:             action,
+					AccessorKind:       accessorKind(roles),
+					Subject:            c.GetString("subject"),
+					AccessorCustomerID: accessorCustomerID,
+					Roles:              roles,
+					Method:             c.Request.Method,
+					Route:              c.FullPath(),
+					CorrelationID:      c.GetString("correlation_id"),
+					AccessedAt:         now,
+				})
+			}
+		}
+	}
+}
+
+// ListAccesses handles GET /access-log endpoint, listing who viewed the
+// authenticated customer's balances or exported their transactions
+func (h *AccessLogHandler) ListAccesses(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	filter := models.DataAccessFilter{Action: models.DataAccessAction(c.Query("action"))}
+	for name, value := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%s must be an RFC 3339 timestamp", name))
+			return
+		}
+		*value = parsed
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if pageSize <= 0 || pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	accesses, err := h.service.ListAccesses(c.Request.Context(), customerID, filter, service.Pagination{
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDataAccessFilter) {
+			err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+		}
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   accesses,
+		Meta: map[string]interface{}{
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// reportDataAccess reports the data a request of a route with reportedScope
+// accessed, for its access log entries
+func reportDataAccess(c *gin.Context, targets ...accessTarget) {
+	c.Set(accessTargetsKey, targets)
+}
+
+// accessorKind returns the accessor kind of a caller with the given roles
+func accessorKind(roles []string) models.DataAccessorKind {
+	granted := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		granted[role] = true
+	}
+	for _, k := range accessorKinds {
+		if granted[k.role] {
+			return k.kind
+		}
+	}
+	return models.DataAccessorAPIKey
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// adjustmentReportWindow is the default window of adjustment reports
+const adjustmentReportWindow = 30 * 24 * time.Hour
+
+// AdjustmentHandler handles HTTP requests for manual balance corrections,
+// approved by a second operator when large
+type AdjustmentHandler struct {
+	service service.AdjustmentService
+}
+
+// adjustmentRequest is the body of POST /admin/adjustments
+type adjustmentRequest struct {
+	WalletID   uuid.UUID                  `json:"wallet_id" binding:"required"`
+	Direction  models.AdjustmentDirection `json:"direction" binding:"required"`
+	Amount     *decimal.Decimal           `json:"amount" binding:"required"`
+	ReasonCode models.AdjustmentReason    `json:"reason_code" binding:"required"`
+	Note       string                     `json:"note" binding:"required"`
+}
+
+// adjustmentReviewRequest is the body of adjustment approvals and rejections
+type adjustmentReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// NewAdjustmentHandler creates a new instance of AdjustmentHandler
+func NewAdjustmentHandler(service service.AdjustmentService) (*AdjustmentHandler, error) {
+	if service == nil {
+		return nil, errors.New("adjustment service is required")
+	}
+
+	return &AdjustmentHandler{service: service}, nil
+}
+
+// ListAdjustments handles GET /admin/adjustments endpoint, listing the
+// latest adjustments newest first
+func (h *AdjustmentHandler) ListAdjustments(c *gin.Context) {
+	filter := models.AdjustmentFilter{Status: models.AdjustmentStatus(c.Query("status"))}
+	if value := c.Query("wallet_id"); value != "" {
+		walletID, err := uuid.Parse(value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+			return
+		}
+		filter.WalletID = &walletID
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("limit must be an integer"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	adjustments, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		respondAdjustmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   adjustments,
+	})
+}
+
+// GetAdjustment handles GET /admin/adjustments/:id endpoint
+func (h *AdjustmentHandler) GetAdjustment(c *gin.Context) {
+	id, ok := parseAdjustmentID(c)
+	if !ok {
+		return
+	}
+
+	adjustment, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   adjustment,
+	})
+}
+
+// RequestAdjustment handles POST /admin/adjustments endpoint. Adjustments
+// above the approval threshold are posted only once another operator
+// approved them; smaller ones are returned posted.
+func (h *AdjustmentHandler) RequestAdjustment(c *gin.Context) {
+	var req adjustmentRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	adjustment, err := h.service.Request(c.Request.Context(), service.AdjustmentRequest{
+		WalletID:   req.WalletID,
+		Direction:  req.Direction,
+		Amount:     *req.Amount,
+		ReasonCode: req.ReasonCode,
+		Note:       req.Note,
+	}, actorFromContext(c))
+	if err != nil {
+		respondAdjustmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   adjustment,
+	})
+}
+
+// ApproveAdjustment handles POST /admin/adjustments/:id/approve endpoint
+func (h *AdjustmentHandler) ApproveAdjustment(c *gin.Context) {
+	h.review(c, h.service.Approve)
+}
+
+// RejectAdjustment handles POST /admin/adjustments/:id/reject endpoint
+func (h *AdjustmentHandler) RejectAdjustment(c *gin.Context) {
+	h.review(c, h.service.Reject)
+}
+
+// GetReport handles GET /admin/adjustments/report endpoint, totalling the
+// adjustments posted in a window apart from customer spend
+func (h *AdjustmentHandler) GetReport(c *gin.Context) {
+	to := time.Now().UTC()
+	from := to.Add(-adjustmentReportWindow)
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%s must be an RFC 3339 timestamp", param.name))
+			return
+		}
+		*param.value = parsed
+	}
+
+	report, err := h.service.Report(c.Request.Context(), from, to)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   report,
+	})
+}
+
+// review binds a review request and records it with the given outcome
+func (h *AdjustmentHandler) review(c *gin.Context, outcome func(ctx context.Context, id uuid.UUID, actor, note string) (*models.Adjustment, error)) {
+	id, ok := parseAdjustmentID(c)
+	if !ok {
+		return
+	}
+
+	var req adjustmentReviewRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	adjustment, err := outcome(c.Request.Context(), id, actorFromContext(c), req.Note)
+	if err != nil {
+		respondAdjustmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   adjustment,
+	})
+}
+
+// parseAdjustmentID parses the adjustment ID path parameter, responding
+// when it is invalid
+func parseAdjustmentID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid adjustment ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondAdjustmentError responds with the validation failure as details so
+// operators can tell why the adjustment was refused
+func respondAdjustmentError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidAdjustment) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
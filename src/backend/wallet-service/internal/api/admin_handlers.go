@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/repository"
+	"internal/runbook"
+)
+
+// AdminHandler handles HTTP requests for operator administration endpoints
+type AdminHandler struct {
+	runbook *runbook.Registry
+	audit   repository.AuditRepository
+}
+
+// runbookRequest is the body of POST /admin/runbook/:action
+type runbookRequest struct {
+	Reason string            `json:"reason" binding:"required"`
+	Params map[string]string `json:"params"`
+}
+
+// NewAdminHandler creates a new instance of AdminHandler
+func NewAdminHandler(registry *runbook.Registry, audit repository.AuditRepository) (*AdminHandler, error) {
+	if registry == nil {
+		return nil, errors.New("runbook registry is required")
+	}
+	if audit == nil {
+		return nil, errors.New("audit repository is required")
+	}
+
+	return &AdminHandler{
+		runbook: registry,
+		audit:   audit,
+	}, nil
+}
+
+// ListRunbookActions handles GET /admin/runbook endpoint
+func (h *AdminHandler) ListRunbookActions(c *gin.Context) {
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   h.runbook.List(),
+	})
+}
+
+// ExecuteRunbookAction handles POST /admin/runbook/:action endpoint
+func (h *AdminHandler) ExecuteRunbookAction(c *gin.Context) {
+	var req runbookRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	result, err := h.runbook.Execute(c.Request.Context(), c.Param("action"), actorFromContext(c), req.Reason, req.Params)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   result,
+	})
+}
+
+// ListAuditLog handles GET /admin/audit endpoint
+func (h *AdminHandler) ListAuditLog(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if pageSize <= 0 || pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	actions, err := h.audit.ListOperatorActions(c.Request.Context(), pageSize, (page-1)*pageSize)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   actions,
+		Meta: map[string]interface{}{
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// actorFromContext returns the authenticated principal recorded by the auth middleware
+func actorFromContext(c *gin.Context) string {
+	if actor := c.GetString("subject"); actor != "" {
+		return actor
+	}
+	if actor := c.GetString("customer_id"); actor != "" {
+		return actor
+	}
+	return "unknown"
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/service"
+)
+
+// AllowanceHandler handles the free-tier allowances of wallets
+type AllowanceHandler struct {
+	service service.AllowanceService
+}
+
+// NewAllowanceHandler creates a new instance of AllowanceHandler
+func NewAllowanceHandler(service service.AllowanceService) (*AllowanceHandler, error) {
+	if service == nil {
+		return nil, errors.New("allowance service is required")
+	}
+
+	return &AllowanceHandler{service: service}, nil
+}
+
+// GetAllowances handles GET /wallets/:id/allowances endpoint, returning the
+// free units of each product the wallet has used and has left in the
+// current cycle
+func (h *AllowanceHandler) GetAllowances(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	allowances, err := h.service.Allowances(c.Request.Context(), walletID, time.Now().UTC())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   allowances,
+	})
+}
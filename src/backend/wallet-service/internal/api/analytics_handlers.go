@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/apierror"
+	"internal/service"
+)
+
+// defaultAnalyticsWindowDays is the default look-back period for aggregates
+const defaultAnalyticsWindowDays = 30
+
+// AnalyticsHandler handles HTTP requests for product analytics aggregates
+type AnalyticsHandler struct {
+	service service.AnalyticsService
+}
+
+// NewAnalyticsHandler creates a new instance of AnalyticsHandler
+func NewAnalyticsHandler(service service.AnalyticsService) (*AnalyticsHandler, error) {
+	if service == nil {
+		return nil, errors.New("analytics service is required")
+	}
+
+	return &AnalyticsHandler{
+		service: service,
+	}, nil
+}
+
+// GetAdoptionStats handles GET /analytics/adoption endpoint
+func (h *AnalyticsHandler) GetAdoptionStats(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultAnalyticsWindowDays)))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("days must be an integer"))
+		return
+	}
+
+	window := time.Duration(days) * 24 * time.Hour
+	stats, err := h.service.GetAdoptionStats(c.Request.Context(), window)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   stats,
+		Meta: map[string]interface{}{
+			"days": days,
+		},
+	})
+}
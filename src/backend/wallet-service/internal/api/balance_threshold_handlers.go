@@ -0,0 +1,187 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// BalanceThresholdHandler handles HTTP requests for the balance alert
+// levels and alert thresholds of wallets
+type BalanceThresholdHandler struct {
+	service service.BalanceThresholdService
+}
+
+// balanceThresholdRequest is the body of balance threshold updates.
+// Percentages are of the reference balance, and omitted ones take the
+// service defaults.
+type balanceThresholdRequest struct {
+	ReferenceBalance     decimal.Decimal `json:"reference_balance" binding:"required"`
+	WarningPercent       *float64        `json:"warning_percent"`
+	WarningResetPercent  *float64        `json:"warning_reset_percent"`
+	CriticalPercent      *float64        `json:"critical_percent"`
+	CriticalResetPercent *float64        `json:"critical_reset_percent"`
+}
+
+// balanceAlertsRequest is the body of balance alert updates, an empty list
+// removing the wallet's alerts
+type balanceAlertsRequest struct {
+	Thresholds []balanceAlertThresholdRequest `json:"thresholds" binding:"required"`
+}
+
+// balanceAlertThresholdRequest is one alert threshold of a balance alerts
+// update. Value is a balance for ABSOLUTE thresholds and a percentage of
+// average monthly usage for USAGE_PERCENT ones.
+type balanceAlertThresholdRequest struct {
+	Basis    models.BalanceAlertBasis     `json:"basis" binding:"required"`
+	Value    decimal.Decimal              `json:"value" binding:"required"`
+	Channels []models.BalanceAlertChannel `json:"channels" binding:"required"`
+}
+
+// NewBalanceThresholdHandler creates a new instance of
+// BalanceThresholdHandler
+func NewBalanceThresholdHandler(service service.BalanceThresholdService) (*BalanceThresholdHandler, error) {
+	if service == nil {
+		return nil, errors.New("balance threshold service is required")
+	}
+
+	return &BalanceThresholdHandler{service: service}, nil
+}
+
+// GetThresholds handles GET /wallets/:id/balance-thresholds endpoint
+func (h *BalanceThresholdHandler) GetThresholds(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	thresholds, err := h.service.Get(c.Request.Context(), walletID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   thresholds,
+	})
+}
+
+// UpdateThresholds handles PUT /wallets/:id/balance-thresholds endpoint,
+// replacing the thresholds and re-arming both levels
+func (h *BalanceThresholdHandler) UpdateThresholds(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	var req balanceThresholdRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	thresholds, err := h.service.Update(c.Request.Context(), walletID, service.BalanceThresholdInput{
+		ReferenceBalance:     req.ReferenceBalance,
+		WarningPercent:       req.WarningPercent,
+		WarningResetPercent:  req.WarningResetPercent,
+		CriticalPercent:      req.CriticalPercent,
+		CriticalResetPercent: req.CriticalResetPercent,
+	})
+	if errors.Is(err, service.ErrInvalidBalanceThresholds) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   thresholds,
+	})
+}
+
+// DeleteThresholds handles DELETE /wallets/:id/balance-thresholds endpoint
+func (h *BalanceThresholdHandler) DeleteThresholds(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), walletID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetAlerts handles GET /wallets/:id/balance-alerts endpoint
+func (h *BalanceThresholdHandler) GetAlerts(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	alerts, err := h.service.ListAlerts(c.Request.Context(), walletID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   alerts,
+	})
+}
+
+// SetAlerts handles PUT /wallets/:id/balance-alerts endpoint, replacing the
+// alert thresholds armed
+func (h *BalanceThresholdHandler) SetAlerts(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	var req balanceAlertsRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	inputs := make([]service.BalanceAlertInput, len(req.Thresholds))
+	for i, threshold := range req.Thresholds {
+		inputs[i] = service.BalanceAlertInput{
+			Basis:    threshold.Basis,
+			Value:    threshold.Value,
+			Channels: threshold.Channels,
+		}
+	}
+
+	alerts, err := h.service.SetAlerts(c.Request.Context(), walletID, inputs)
+	if errors.Is(err, service.ErrInvalidBalanceAlerts) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   alerts,
+	})
+}
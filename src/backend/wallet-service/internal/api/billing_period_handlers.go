@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/apierror"
+	"internal/service"
+)
+
+// BillingPeriodHandler handles HTTP requests for closing and reopening
+// billing periods
+type BillingPeriodHandler struct {
+	service service.BillingPeriodService
+}
+
+// billingPeriodRequest is the body of billing period close and reopen requests
+type billingPeriodRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// NewBillingPeriodHandler creates a new instance of BillingPeriodHandler
+func NewBillingPeriodHandler(service service.BillingPeriodService) (*BillingPeriodHandler, error) {
+	if service == nil {
+		return nil, errors.New("billing period service is required")
+	}
+
+	return &BillingPeriodHandler{service: service}, nil
+}
+
+// ListBillingPeriods handles GET /admin/billing-periods endpoint, listing
+// the latest periods ever closed
+func (h *BillingPeriodHandler) ListBillingPeriods(c *gin.Context) {
+	periods, err := h.service.List(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   periods,
+	})
+}
+
+// GetBillingPeriod handles GET /admin/billing-periods/:period endpoint
+func (h *BillingPeriodHandler) GetBillingPeriod(c *gin.Context) {
+	period, err := h.service.Get(c.Request.Context(), c.Param("period"))
+	if err != nil {
+		respondBillingPeriodError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   period,
+	})
+}
+
+// CloseBillingPeriod handles POST /admin/billing-periods/:period/close
+// endpoint. Postings into a closed period are refused until it is reopened.
+func (h *BillingPeriodHandler) CloseBillingPeriod(c *gin.Context) {
+	var req billingPeriodRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	period, err := h.service.Close(c.Request.Context(), c.Param("period"), actorFromContext(c), req.Reason)
+	if err != nil {
+		respondBillingPeriodError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   period,
+	})
+}
+
+// ReopenBillingPeriod handles POST /admin/billing-periods/:period/reopen
+// endpoint
+func (h *BillingPeriodHandler) ReopenBillingPeriod(c *gin.Context) {
+	var req billingPeriodRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	period, err := h.service.Reopen(c.Request.Context(), c.Param("period"), actorFromContext(c), req.Reason)
+	if err != nil {
+		respondBillingPeriodError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   period,
+	})
+}
+
+// respondBillingPeriodError responds with the validation failure as details
+// so operators can tell why the period was rejected
+func respondBillingPeriodError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidBillingPeriod) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"         // v1.9.1
+	"github.com/gin-gonic/gin/binding" // v1.9.1
+
+	"internal/apierror"
+)
+
+// bindJSON strictly decodes the request body into obj, rejecting unknown
+// fields and trailing data, and then runs the struct validation tags
+func bindJSON(c *gin.Context, obj interface{}) error {
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(obj); err != nil {
+		return apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	if decoder.More() {
+		err := errors.New("request body must contain a single JSON object")
+		return apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/bodycapture"
+	"internal/models"
+)
+
+// BodyCaptureMiddleware captures the bodies of a sample of requests and of
+// their JSON responses for payment disputes. The recorder redacts them
+// before they are queued for the audit store. Event streams and upgraded
+// connections are captured without a response body.
+func BodyCaptureMiddleware(recorder *bodycapture.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !recorder.Sample() {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		var request []byte
+		if c.Request.Body != nil {
+			body, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err))
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			request = body
+		}
+
+		writer := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		capture := &models.RequestCapture{
+			CorrelationID: c.GetString("correlation_id"),
+			Method:        c.Request.Method,
+			Route:         c.FullPath(),
+			Path:          c.Request.URL.Path,
+			Status:        c.Writer.Status(),
+			Subject:       c.GetString("subject"),
+			DurationMs:    time.Since(start).Milliseconds(),
+			CapturedAt:    start.UTC(),
+		}
+		if customerID, err := uuid.Parse(c.GetString("customer_id")); err == nil {
+			capture.CustomerID = &customerID
+		}
+		recorder.Record(capture, request, writer.body.Bytes())
+	}
+}
+
+// captureWriter copies JSON response bodies as they are written
+type captureWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	decided bool
+	copied  bool
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.copied = strings.HasPrefix(w.Header().Get("Content-Type"), gin.MIMEJSON)
+	}
+	if w.copied {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/service"
+)
+
+// BulkCreditHandler handles HTTP requests for crediting many wallets in one
+// operation
+type BulkCreditHandler struct {
+	service service.BulkCreditService
+}
+
+// bulkCreditRequest is the JSON body of POST /admin/bulk-credits
+type bulkCreditRequest struct {
+	WalletIDs   []string        `json:"wallet_ids" binding:"required"`
+	Amount      decimal.Decimal `json:"amount" binding:"required"`
+	Currency    string          `json:"currency" binding:"required"`
+	Description string          `json:"description" binding:"required"`
+}
+
+// NewBulkCreditHandler creates a new instance of BulkCreditHandler
+func NewBulkCreditHandler(service service.BulkCreditService) (*BulkCreditHandler, error) {
+	if service == nil {
+		return nil, errors.New("bulk credit service is required")
+	}
+
+	return &BulkCreditHandler{service: service}, nil
+}
+
+// SubmitBulkCredit handles POST /admin/bulk-credits endpoint, queueing a job
+// that credits each wallet the amount. The wallets are a JSON list of IDs,
+// or a CSV of a wallet_id column with the amount, currency and description
+// query parameters. The operation is returned at once; its job's progress
+// is polled with GET /jobs/:id and its per-row results are read from GET
+// /admin/bulk-credits/:id.
+func (h *BulkCreditHandler) SubmitBulkCredit(c *gin.Context) {
+	var req bulkCreditRequest
+	var err error
+	if c.ContentType() == mimeCSV {
+		req.Currency = c.Query("currency")
+		req.Description = c.Query("description")
+		if req.Amount, err = decimal.NewFromString(c.Query("amount")); err != nil {
+			err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("amount must be a decimal")
+		} else {
+			req.WalletIDs, err = parseBulkCreditCSV(c.Request.Body)
+		}
+	} else {
+		err = bindJSON(c, &req)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	credit, err := h.service.Submit(c.Request.Context(), service.BulkCreditRequest{
+		WalletIDs:   req.WalletIDs,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+	}, actorFromContext(c))
+	if err != nil {
+		respondBulkCreditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, Response{
+		Status: "success",
+		Data:   credit,
+	})
+}
+
+// GetBulkCredit handles GET /admin/bulk-credits/:id endpoint, returning the
+// operation with the outcome of its rows so far
+func (h *BulkCreditHandler) GetBulkCredit(c *gin.Context) {
+	id, ok := parseBulkCreditID(c)
+	if !ok {
+		return
+	}
+
+	details, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   details,
+	})
+}
+
+// RollbackBulkCredit handles POST /admin/bulk-credits/:id/rollback endpoint,
+// queueing a job that reverses the posted credits once they are no longer
+// being posted
+func (h *BulkCreditHandler) RollbackBulkCredit(c *gin.Context) {
+	id, ok := parseBulkCreditID(c)
+	if !ok {
+		return
+	}
+
+	credit, err := h.service.Rollback(c.Request.Context(), id, actorFromContext(c))
+	if err != nil {
+		respondBulkCreditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, Response{
+		Status: "success",
+		Data:   credit,
+	})
+}
+
+// parseBulkCreditCSV reads the wallet IDs of a CSV operation. A first row
+// naming the wallet_id column is a header; blank lines are skipped.
+func parseBulkCreditCSV(body io.Reader) ([]string, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	var walletIDs []string
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid CSV: %v", err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "wallet_id") {
+			continue
+		}
+		if len(record) != 1 {
+			err := fmt.Errorf("line %d has %d columns, expected wallet_id", line, len(record))
+			return nil, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+		}
+		walletIDs = append(walletIDs, record[0])
+	}
+
+	return walletIDs, nil
+}
+
+// parseBulkCreditID parses the bulk credit ID path parameter, responding
+// when it is invalid
+func parseBulkCreditID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid bulk credit ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondBulkCreditError responds with the validation failure or the reason
+// the operation cannot be rolled back as details
+func respondBulkCreditError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidBulkCredit):
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	case errors.Is(err, service.ErrBulkCreditConflict):
+		err = apierror.Wrap(apierror.CodeBulkCreditConflict, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/models"
+	"internal/service"
+)
+
+// ConsentHandler handles HTTP requests for customer notification consent
+type ConsentHandler struct {
+	service service.ConsentService
+}
+
+// consentRequest is the body of PUT /consents/:channel
+type consentRequest struct {
+	Granted *bool  `json:"granted" binding:"required"`
+	Source  string `json:"source" binding:"required"`
+}
+
+// NewConsentHandler creates a new instance of ConsentHandler
+func NewConsentHandler(service service.ConsentService) (*ConsentHandler, error) {
+	if service == nil {
+		return nil, errors.New("consent service is required")
+	}
+
+	return &ConsentHandler{service: service}, nil
+}
+
+// GetConsents handles GET /consents endpoint
+func (h *ConsentHandler) GetConsents(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	consents, err := h.service.GetConsents(c.Request.Context(), customerID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   consents,
+	})
+}
+
+// UpdateConsent handles PUT /consents/:channel endpoint
+func (h *ConsentHandler) UpdateConsent(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req consentRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	channel := models.ConsentChannel(strings.ToUpper(c.Param("channel")))
+	consent, err := h.service.UpdateConsent(c.Request.Context(), customerID, channel, *req.Granted, models.ConsentSource(req.Source))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   consent,
+	})
+}
+
+// GetHistory handles GET /consents/history endpoint, listing the customer's
+// consent changes newest first
+func (h *ConsentHandler) GetHistory(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	history, err := h.service.GetHistory(c.Request.Context(), customerID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   history,
+	})
+}
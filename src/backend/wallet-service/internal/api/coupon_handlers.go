@@ -0,0 +1,257 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// CouponHandler handles HTTP requests for coupons and their redemptions
+type CouponHandler struct {
+	service service.CouponService
+}
+
+// couponRequest is the body of POST /admin/coupons. Limits left out do not
+// apply.
+type couponRequest struct {
+	Code                      string              `json:"code" binding:"required"`
+	Description               string              `json:"description"`
+	DiscountType              models.DiscountType `json:"discount_type" binding:"required"`
+	Value                     decimal.Decimal     `json:"value" binding:"required"`
+	Currency                  string              `json:"currency"`
+	CustomerID                *uuid.UUID          `json:"customer_id"`
+	ValidFrom                 *time.Time          `json:"valid_from"`
+	ValidUntil                *time.Time          `json:"valid_until"`
+	MaxRedemptions            *int64              `json:"max_redemptions"`
+	MaxRedemptionsPerCustomer *int64              `json:"max_redemptions_per_customer"`
+	Reason                    string              `json:"reason" binding:"required"`
+}
+
+// couponVoidRequest is the body of POST /admin/coupons/:id/void
+type couponVoidRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// couponApplicationRequest is the body of POST /coupons/redeem and its
+// quotes. Source and reference are required when the coupon is redeemed.
+type couponApplicationRequest struct {
+	Code       string                  `json:"code" binding:"required"`
+	CustomerID uuid.UUID               `json:"customer_id" binding:"required"`
+	Source     models.RedemptionSource `json:"source"`
+	Reference  string                  `json:"reference"`
+	Currency   string                  `json:"currency" binding:"required"`
+	Amount     decimal.Decimal         `json:"amount" binding:"required"`
+	At         *time.Time              `json:"at"`
+}
+
+// NewCouponHandler creates a new instance of CouponHandler
+func NewCouponHandler(service service.CouponService) (*CouponHandler, error) {
+	if service == nil {
+		return nil, errors.New("coupon service is required")
+	}
+
+	return &CouponHandler{service: service}, nil
+}
+
+// CreateCoupon handles POST /admin/coupons endpoint
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var req couponRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	coupon := &models.Coupon{
+		Code:                      req.Code,
+		Description:               req.Description,
+		Type:                      req.DiscountType,
+		Value:                     req.Value,
+		Currency:                  req.Currency,
+		CustomerID:                req.CustomerID,
+		MaxRedemptions:            req.MaxRedemptions,
+		MaxRedemptionsPerCustomer: req.MaxRedemptionsPerCustomer,
+	}
+	if req.ValidFrom != nil {
+		coupon.ValidFrom = req.ValidFrom.UTC()
+	}
+	if req.ValidUntil != nil {
+		until := req.ValidUntil.UTC()
+		coupon.ValidUntil = &until
+	}
+
+	coupon, err := h.service.Create(c.Request.Context(), coupon, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   coupon,
+	})
+}
+
+// ListCoupons handles GET /admin/coupons endpoint, listing the latest
+// coupons newest first
+func (h *CouponHandler) ListCoupons(c *gin.Context) {
+	var limit int
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("limit must be an integer"))
+			return
+		}
+	}
+
+	coupons, err := h.service.List(c.Request.Context(), models.CouponStatus(c.Query("status")), limit)
+	if err != nil {
+		respondCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   coupons,
+	})
+}
+
+// GetCoupon handles GET /admin/coupons/:id endpoint, returning the coupon
+// with its latest redemptions
+func (h *CouponHandler) GetCoupon(c *gin.Context) {
+	id, ok := parseCouponID(c)
+	if !ok {
+		return
+	}
+
+	details, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   details,
+	})
+}
+
+// VoidCoupon handles POST /admin/coupons/:id/void endpoint
+func (h *CouponHandler) VoidCoupon(c *gin.Context) {
+	id, ok := parseCouponID(c)
+	if !ok {
+		return
+	}
+
+	var req couponVoidRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	coupon, err := h.service.Void(c.Request.Context(), id, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   coupon,
+	})
+}
+
+// QuoteCoupon handles POST /coupons/quote endpoint, returning the discount
+// a coupon would give without redeeming it
+func (h *CouponHandler) QuoteCoupon(c *gin.Context) {
+	application, ok := bindCouponApplication(c)
+	if !ok {
+		return
+	}
+
+	quote, err := h.service.Quote(c.Request.Context(), application)
+	if err != nil {
+		respondCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   quote,
+	})
+}
+
+// RedeemCoupon handles POST /coupons/redeem endpoint, called at rating or
+// invoice time to apply a coupon to a charge or an invoice
+func (h *CouponHandler) RedeemCoupon(c *gin.Context) {
+	application, ok := bindCouponApplication(c)
+	if !ok {
+		return
+	}
+
+	redemption, err := h.service.Redeem(c.Request.Context(), application, actorFromContext(c))
+	if err != nil {
+		respondCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   redemption,
+	})
+}
+
+// bindCouponApplication binds a coupon application body, responding when
+// it is invalid
+func bindCouponApplication(c *gin.Context) (service.CouponApplication, bool) {
+	var req couponApplicationRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return service.CouponApplication{}, false
+	}
+
+	application := service.CouponApplication{
+		Code:       req.Code,
+		CustomerID: req.CustomerID,
+		Source:     req.Source,
+		Reference:  req.Reference,
+		Currency:   req.Currency,
+		Amount:     req.Amount,
+	}
+	if req.At != nil {
+		application.At = req.At.UTC()
+	}
+	return application, true
+}
+
+// parseCouponID parses the coupon ID path parameter, responding when it is
+// invalid
+func parseCouponID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid coupon ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondCouponError responds with why a coupon was refused or could not
+// be redeemed as details
+func respondCouponError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCoupon):
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	case errors.Is(err, service.ErrCouponNotRedeemable):
+		err = apierror.Wrap(apierror.CodeCouponNotRedeemable, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// CustomerBalanceHandler handles HTTP requests for the wallets of a customer
+// taken together
+type CustomerBalanceHandler struct {
+	service service.CustomerBalanceService
+}
+
+// NewCustomerBalanceHandler creates a new instance of CustomerBalanceHandler
+func NewCustomerBalanceHandler(service service.CustomerBalanceService) (*CustomerBalanceHandler, error) {
+	if service == nil {
+		return nil, errors.New("customer balance service is required")
+	}
+
+	return &CustomerBalanceHandler{service: service}, nil
+}
+
+// ListWallets handles GET /customers/:id/wallets endpoint
+func (h *CustomerBalanceHandler) ListWallets(c *gin.Context) {
+	customerID, err := customerParam(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	wallets, err := h.service.ListWallets(c.Request.Context(), customerID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if wallets == nil {
+		wallets = []*models.Wallet{}
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   wallets,
+	})
+}
+
+// GetBalance handles GET /customers/:id/balance endpoint, totalling the
+// customer's wallets in the currency query parameter or the configured
+// reporting currency
+func (h *CustomerBalanceHandler) GetBalance(c *gin.Context) {
+	customerID, err := customerParam(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	balance, err := h.service.GetBalance(c.Request.Context(), customerID, strings.ToUpper(c.Query("currency")))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   balance,
+	})
+}
+
+// customerParam returns the customer ID of the path. Customers may only read
+// their own wallets; operators and support may read any customer's.
+func customerParam(c *gin.Context) (uuid.UUID, error) {
+	customerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("customer ID must be a UUID")
+	}
+	if hasRole(c, adminRole) || hasRole(c, supportRole) {
+		return customerID, nil
+	}
+
+	caller, err := customerFromContext(c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if caller != customerID {
+		return uuid.Nil, apierror.New(apierror.CodeForbidden).WithDetails("customers may only read their own wallets")
+	}
+	return customerID, nil
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/service"
+)
+
+// CustomerHandler handles HTTP requests for the authenticated customer's settings
+type CustomerHandler struct {
+	service service.CustomerService
+}
+
+// customerSettingsRequest is the body of PATCH /customer-settings
+type customerSettingsRequest struct {
+	Timezone string `json:"timezone" binding:"required"`
+}
+
+// NewCustomerHandler creates a new instance of CustomerHandler
+func NewCustomerHandler(service service.CustomerService) (*CustomerHandler, error) {
+	if service == nil {
+		return nil, errors.New("customer service is required")
+	}
+
+	return &CustomerHandler{service: service}, nil
+}
+
+// GetSettings handles GET /customer-settings endpoint
+func (h *CustomerHandler) GetSettings(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	settings, err := h.service.GetSettings(c.Request.Context(), customerID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   settings,
+	})
+}
+
+// UpdateSettings handles PATCH /customer-settings endpoint
+func (h *CustomerHandler) UpdateSettings(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req customerSettingsRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	settings, err := h.service.UpdateTimezone(c.Request.Context(), customerID, req.Timezone)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   settings,
+	})
+}
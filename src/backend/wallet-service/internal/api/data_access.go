@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/classification"
+	"internal/logging"
+	"internal/models"
+)
+
+// classifiedModels are the response types whose class tags make up the data
+// classification catalog
+var classifiedModels = []interface{}{
+	models.Wallet{},
+	models.Transaction{},
+	models.CreditLimitWarning{},
+	models.HistoricalBalance{},
+	models.ProviderRefund{},
+	models.TransactionMatch{},
+	balanceResponse{},
+}
+
+// dataAccessReport is the per-field access report of GET /admin/data-access
+type dataAccessReport struct {
+	Enforced bool                         `json:"enforced"`
+	Fields   []classification.ReportEntry `json:"fields"`
+}
+
+// DataAccessHandler applies the data classification policy to JSON
+// responses and reports the accesses to classified fields
+type DataAccessHandler struct {
+	catalog *classification.Catalog
+	policy  classification.Policy
+	report  *classification.Report
+}
+
+// NewDataAccessHandler creates a new instance of DataAccessHandler
+func NewDataAccessHandler(policy classification.Policy) (*DataAccessHandler, error) {
+	catalog, err := classification.NewCatalog(classifiedModels...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataAccessHandler{
+		catalog: catalog,
+		policy:  policy,
+		report:  classification.NewReport(catalog),
+	}, nil
+}
+
+// Middleware buffers JSON responses, records the classified fields they
+// carry and, when the policy is enforced, removes the fields the caller's
+// roles do not grant. Event streams and upgraded connections pass through.
+func (h *DataAccessHandler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &classifiedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if !writer.buffered {
+			return
+		}
+
+		roles := rolesFromContext(c)
+		body, accesses, err := h.catalog.Filter(writer.body.Bytes(), h.policy, roles)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Warn("failed to classify response fields", "error", err)
+		}
+		if _, err := writer.ResponseWriter.Write(body); err != nil {
+			return
+		}
+		if len(accesses) == 0 {
+			return
+		}
+
+		h.report.Record(accesses)
+
+		var fields, denied []string
+		for _, a := range accesses {
+			fields = append(fields, a.Field)
+			if a.Decision != classification.DecisionAllowed {
+				denied = append(denied, a.Field)
+			}
+		}
+		logging.FromContext(c.Request.Context()).Info("classified fields accessed",
+			"subject", c.GetString("subject"),
+			"roles", roles,
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"fields", fields,
+			"unscoped", denied,
+			"enforced", h.policy.Enforce,
+		)
+	}
+}
+
+// GetReport handles GET /admin/data-access endpoint
+func (h *DataAccessHandler) GetReport(c *gin.Context) {
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data: dataAccessReport{
+			Enforced: h.policy.Enforce,
+			Fields:   h.report.Entries(),
+		},
+	})
+}
+
+// classifiedWriter holds back JSON response bodies until the classification
+// policy has been applied to them
+type classifiedWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	decided  bool
+	buffered bool
+}
+
+func (w *classifiedWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.buffered = strings.HasPrefix(w.Header().Get("Content-Type"), gin.MIMEJSON)
+	}
+	if w.buffered {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *classifiedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// rolesFromContext returns the roles granted to the authenticated caller
+func rolesFromContext(c *gin.Context) []string {
+	if roles, ok := c.Get("roles"); ok {
+		if list, ok := roles.([]string); ok {
+			return list
+		}
+	}
+	return nil
+}
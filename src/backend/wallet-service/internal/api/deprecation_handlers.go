@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/apierror"
+	"internal/deprecation"
+	"internal/service"
+)
+
+// deprecationReportWindow is how far back the deprecation report looks for
+// callers by default
+const deprecationReportWindow = 30 * 24 * time.Hour
+
+// DeprecationHandler signals the deprecation of routes to their callers and
+// reports the integrations that still call them
+type DeprecationHandler struct {
+	registry *deprecation.Registry
+	service  service.DeprecationService
+}
+
+// NewDeprecationHandler creates a new instance of DeprecationHandler
+func NewDeprecationHandler(registry *deprecation.Registry, service service.DeprecationService) (*DeprecationHandler, error) {
+	if registry == nil {
+		return nil, errors.New("deprecation registry is required")
+	}
+	if service == nil {
+		return nil, errors.New("deprecation service is required")
+	}
+
+	return &DeprecationHandler{
+		registry: registry,
+		service:  service,
+	}, nil
+}
+
+// Middleware adds the deprecation headers to the responses of deprecated
+// routes and counts their calls by caller. Other routes pass through.
+func (h *DeprecationHandler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		def, ok := h.registry.Lookup(c.Request.Method, c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		def.SetHeaders(c.Writer.Header())
+		c.Next()
+		h.service.Record(def, actorFromContext(c), time.Now().UTC())
+	}
+}
+
+// GetReport handles GET /admin/deprecations endpoint
+func (h *DeprecationHandler) GetReport(c *gin.Context) {
+	since := time.Now().UTC().Add(-deprecationReportWindow)
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("since must be an RFC 3339 timestamp"))
+			return
+		}
+		since = parsed
+	}
+
+	report, err := h.service.GetReport(c.Request.Context(), since)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   report,
+	})
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// DigestHandler handles HTTP requests for activity digest subscriptions
+type DigestHandler struct {
+	service service.DigestService
+}
+
+// digestRequest is the body of PUT /digest-subscription
+type digestRequest struct {
+	Frequency string `json:"frequency" binding:"required"`
+	Enabled   *bool  `json:"enabled" binding:"required"`
+}
+
+// NewDigestHandler creates a new instance of DigestHandler
+func NewDigestHandler(service service.DigestService) (*DigestHandler, error) {
+	if service == nil {
+		return nil, errors.New("digest service is required")
+	}
+
+	return &DigestHandler{service: service}, nil
+}
+
+// GetSubscription handles GET /digest-subscription endpoint
+func (h *DigestHandler) GetSubscription(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	sub, err := h.service.GetSubscription(c.Request.Context(), customerID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   sub,
+	})
+}
+
+// UpdateSubscription handles PUT /digest-subscription endpoint
+func (h *DigestHandler) UpdateSubscription(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req digestRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	sub, err := h.service.UpdateSubscription(c.Request.Context(), customerID, models.DigestFrequency(req.Frequency), *req.Enabled)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   sub,
+	})
+}
+
+// customerFromContext returns the customer ID recorded by the auth middleware
+func customerFromContext(c *gin.Context) (uuid.UUID, error) {
+	customerID, err := uuid.Parse(c.GetString("customer_id"))
+	if err != nil {
+		return uuid.Nil, apierror.Wrap(apierror.CodeForbidden, err).WithDetails("token is not bound to a customer")
+	}
+	return customerID, nil
+}
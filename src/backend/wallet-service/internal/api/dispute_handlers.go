@@ -0,0 +1,213 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// DisputeHandler handles HTTP requests for chargeback disputes of top-ups
+type DisputeHandler struct {
+	service service.DisputeService
+}
+
+// disputeRequest is the body of POST /admin/disputes. The amount defaults to
+// the whole top-up.
+type disputeRequest struct {
+	WalletID       uuid.UUID            `json:"wallet_id" binding:"required"`
+	TransactionID  uuid.UUID            `json:"transaction_id" binding:"required"`
+	Amount         *decimal.Decimal     `json:"amount"`
+	ReasonCode     models.DisputeReason `json:"reason_code" binding:"required"`
+	Note           string               `json:"note" binding:"required"`
+	ProviderCaseID string               `json:"provider_case_id"`
+}
+
+// disputeEvidenceRequest is the body of POST /admin/disputes/:id/evidence
+type disputeEvidenceRequest struct {
+	Evidence string `json:"evidence" binding:"required"`
+}
+
+// disputeResolutionRequest is the body of POST /admin/disputes/:id/resolve
+type disputeResolutionRequest struct {
+	Outcome models.DisputeStatus `json:"outcome" binding:"required"`
+	Note    string               `json:"note"`
+}
+
+// NewDisputeHandler creates a new instance of DisputeHandler
+func NewDisputeHandler(service service.DisputeService) (*DisputeHandler, error) {
+	if service == nil {
+		return nil, errors.New("dispute service is required")
+	}
+
+	return &DisputeHandler{service: service}, nil
+}
+
+// ListDisputes handles GET /admin/disputes endpoint, listing the latest
+// disputes newest first
+func (h *DisputeHandler) ListDisputes(c *gin.Context) {
+	filter := models.DisputeFilter{Status: models.DisputeStatus(c.Query("status"))}
+	if value := c.Query("wallet_id"); value != "" {
+		walletID, err := uuid.Parse(value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+			return
+		}
+		filter.WalletID = &walletID
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("limit must be an integer"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	disputes, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		respondDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   disputes,
+	})
+}
+
+// GetDispute handles GET /admin/disputes/:id endpoint
+func (h *DisputeHandler) GetDispute(c *gin.Context) {
+	id, ok := parseDisputeID(c)
+	if !ok {
+		return
+	}
+
+	dispute, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   dispute,
+	})
+}
+
+// OpenDispute handles POST /admin/disputes endpoint, holding the disputed
+// amount until the dispute is resolved
+func (h *DisputeHandler) OpenDispute(c *gin.Context) {
+	var req disputeRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	request := service.DisputeRequest{
+		WalletID:       req.WalletID,
+		TransactionID:  req.TransactionID,
+		ReasonCode:     req.ReasonCode,
+		Note:           req.Note,
+		ProviderCaseID: req.ProviderCaseID,
+	}
+	if req.Amount != nil {
+		if !req.Amount.IsPositive() {
+			respondError(c, apierror.New(apierror.CodeInvalidRequest).WithDetails("amount must be positive"))
+			return
+		}
+		request.Amount = *req.Amount
+	}
+
+	dispute, err := h.service.Open(c.Request.Context(), request, actorFromContext(c))
+	if err != nil {
+		respondDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   dispute,
+	})
+}
+
+// SubmitEvidence handles POST /admin/disputes/:id/evidence endpoint
+func (h *DisputeHandler) SubmitEvidence(c *gin.Context) {
+	id, ok := parseDisputeID(c)
+	if !ok {
+		return
+	}
+
+	var req disputeEvidenceRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	dispute, err := h.service.SubmitEvidence(c.Request.Context(), id, req.Evidence, actorFromContext(c))
+	if err != nil {
+		respondDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   dispute,
+	})
+}
+
+// ResolveDispute handles POST /admin/disputes/:id/resolve endpoint, releasing
+// the hold of won disputes and reversing the amount of lost ones
+func (h *DisputeHandler) ResolveDispute(c *gin.Context) {
+	id, ok := parseDisputeID(c)
+	if !ok {
+		return
+	}
+
+	var req disputeResolutionRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	dispute, err := h.service.Resolve(c.Request.Context(), id, req.Outcome, actorFromContext(c), req.Note)
+	if err != nil {
+		respondDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   dispute,
+	})
+}
+
+// parseDisputeID parses the dispute ID path parameter, responding when it
+// is invalid
+func parseDisputeID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid dispute ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondDisputeError responds with the validation failure or the reason
+// the transaction cannot be disputed as details
+func respondDisputeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidDispute):
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	case errors.Is(err, service.ErrDisputeNotAllowed):
+		err = apierror.Wrap(apierror.CodeDisputeNotAllowed, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
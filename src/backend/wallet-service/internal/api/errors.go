@@ -0,0 +1,34 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/apierror"
+)
+
+// respondError aborts the request with a structured error response whose
+// status, code and localized message are resolved from the error catalogue
+func respondError(c *gin.Context, err error) {
+	apiErr := apierror.FromError(err)
+	lang := apierror.ParseLanguage(c.GetHeader("Accept-Language"))
+
+	resp := Response{
+		Status: "error",
+		Code:   string(apiErr.Code),
+		Error:  apiErr.Message(lang),
+	}
+	if apiErr.Details != "" {
+		resp.Meta = map[string]interface{}{
+			"details": apiErr.Details,
+		}
+	}
+
+	if apiErr.Code == apierror.CodeDatabaseReadOnly {
+		if retryAfter := c.GetString(retryAfterKey); retryAfter != "" {
+			c.Header("Retry-After", retryAfter)
+		}
+	}
+
+	_ = c.Error(err)
+	c.AbortWithStatusJSON(apiErr.Status, resp)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/service"
+)
+
+// EstimateHandler handles HTTP requests for charge estimates
+type EstimateHandler struct {
+	service service.EstimateService
+}
+
+// estimateRequest is the body of POST /estimates. The currency defaults to
+// the wallet's.
+type estimateRequest struct {
+	WalletID   uuid.UUID       `json:"wallet_id" binding:"required"`
+	Amount     decimal.Decimal `json:"amount" binding:"required"`
+	Currency   string          `json:"currency"`
+	Source     string          `json:"source"`
+	Category   string          `json:"category"`
+	CouponCode string          `json:"coupon_code"`
+	At         *time.Time      `json:"at"`
+}
+
+// NewEstimateHandler creates a new instance of EstimateHandler
+func NewEstimateHandler(service service.EstimateService) (*EstimateHandler, error) {
+	if service == nil {
+		return nil, errors.New("estimate service is required")
+	}
+
+	return &EstimateHandler{service: service}, nil
+}
+
+// Estimate handles POST /estimates endpoint, pricing a debit with its
+// discount, fee, exchange rate and tax without committing it
+func (h *EstimateHandler) Estimate(c *gin.Context) {
+	var req estimateRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	charge := service.ChargeRequest{
+		WalletID:   req.WalletID,
+		Amount:     req.Amount,
+		Currency:   req.Currency,
+		Source:     req.Source,
+		Category:   req.Category,
+		CouponCode: req.CouponCode,
+	}
+	if req.At != nil {
+		charge.At = req.At.UTC()
+	}
+
+	estimate, err := h.service.Estimate(c.Request.Context(), charge)
+	if err != nil {
+		respondCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   estimate,
+	})
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// ExportHandler handles HTTP requests for bulk transaction exports
+type ExportHandler struct {
+	service service.TransactionExportService
+}
+
+// exportRequest is the body of POST /exports
+type exportRequest struct {
+	// Format is csv or parquet, csv by default
+	Format     models.ExportFormat `json:"format"`
+	From       time.Time           `json:"from" binding:"required"`
+	To         time.Time           `json:"to" binding:"required"`
+	CustomerID *uuid.UUID          `json:"customer_id"`
+	Type       string              `json:"type"`
+}
+
+// NewExportHandler creates a new instance of ExportHandler
+func NewExportHandler(service service.TransactionExportService) (*ExportHandler, error) {
+	if service == nil {
+		return nil, errors.New("transaction export service is required")
+	}
+
+	return &ExportHandler{service: service}, nil
+}
+
+// CreateExport handles POST /exports endpoint, queueing an export of the
+// transactions created in [from, to). The export is returned at once; its
+// progress and, once written, its download link are read from
+// GET /exports/:id.
+func (h *ExportHandler) CreateExport(c *gin.Context) {
+	var req exportRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	export, err := h.service.Submit(c.Request.Context(), service.TransactionExportRequest{
+		Format: req.Format,
+		Filter: models.TransactionExportFilter{
+			From:       req.From,
+			To:         req.To,
+			CustomerID: req.CustomerID,
+			Type:       req.Type,
+		},
+	}, actorFromContext(c))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidExport) {
+			err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+		}
+		respondError(c, err)
+		return
+	}
+
+	reportDataAccess(c, accessTarget{customerID: req.CustomerID})
+	c.JSON(http.StatusAccepted, Response{
+		Status: "success",
+		Data:   export,
+	})
+}
+
+// GetExport handles GET /exports/:id endpoint, reporting an export's status
+// and progress with a freshly signed download link once it succeeded
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid export ID"))
+		return
+	}
+
+	export, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   export,
+	})
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// FailedJobHandler handles HTTP requests of the console of failed
+// background jobs, such as webhook deliveries and recurring debit runs
+type FailedJobHandler struct {
+	service service.FailedJobService
+}
+
+// failedJobDiscardRequest is the body of POST
+// /admin/failed-jobs/:id/discard
+type failedJobDiscardRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// failedJobBulkRequest is the body of the bulk retry and discard of failed
+// jobs; the reason is required to discard
+type failedJobBulkRequest struct {
+	IDs    []uuid.UUID `json:"ids" binding:"required"`
+	Reason string      `json:"reason"`
+}
+
+// NewFailedJobHandler creates a new instance of FailedJobHandler
+func NewFailedJobHandler(service service.FailedJobService) (*FailedJobHandler, error) {
+	if service == nil {
+		return nil, errors.New("failed job service is required")
+	}
+
+	return &FailedJobHandler{service: service}, nil
+}
+
+// ListFailedJobs handles GET /admin/failed-jobs endpoint, listing the latest
+// jobs newest first with their failure reasons
+func (h *FailedJobHandler) ListFailedJobs(c *gin.Context) {
+	filter := models.FailedJobFilter{
+		Kind:   models.FailedJobKind(c.Query("kind")),
+		Status: models.FailedJobStatus(c.Query("status")),
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("limit must be an integer"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	jobs, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		respondFailedJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   jobs,
+	})
+}
+
+// GetFailedJob handles GET /admin/failed-jobs/:id endpoint
+func (h *FailedJobHandler) GetFailedJob(c *gin.Context) {
+	id, ok := parseFailedJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   job,
+	})
+}
+
+// RetryFailedJob handles POST /admin/failed-jobs/:id/retry endpoint. The job
+// is retried by the next retry run rather than during the request.
+func (h *FailedJobHandler) RetryFailedJob(c *gin.Context) {
+	id, ok := parseFailedJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.service.Retry(c.Request.Context(), id, actorFromContext(c))
+	if err != nil {
+		respondFailedJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   job,
+	})
+}
+
+// DiscardFailedJob handles POST /admin/failed-jobs/:id/discard endpoint
+func (h *FailedJobHandler) DiscardFailedJob(c *gin.Context) {
+	id, ok := parseFailedJobID(c)
+	if !ok {
+		return
+	}
+
+	var req failedJobDiscardRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	job, err := h.service.Discard(c.Request.Context(), id, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondFailedJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   job,
+	})
+}
+
+// RetryFailedJobs handles POST /admin/failed-jobs/retry endpoint, retrying
+// each of the listed jobs and reporting the outcome of each
+func (h *FailedJobHandler) RetryFailedJobs(c *gin.Context) {
+	var req failedJobBulkRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	outcomes, err := h.service.RetryMany(c.Request.Context(), req.IDs, actorFromContext(c))
+	if err != nil {
+		respondFailedJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   outcomes,
+	})
+}
+
+// DiscardFailedJobs handles POST /admin/failed-jobs/discard endpoint,
+// discarding each of the listed jobs and reporting the outcome of each
+func (h *FailedJobHandler) DiscardFailedJobs(c *gin.Context) {
+	var req failedJobBulkRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	outcomes, err := h.service.DiscardMany(c.Request.Context(), req.IDs, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondFailedJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   outcomes,
+	})
+}
+
+// parseFailedJobID parses the failed job ID path parameter, responding when
+// it is invalid
+func parseFailedJobID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid failed job ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondFailedJobError responds with the validation failure as details so
+// operators can tell why the request was refused
+func respondFailedJobError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidFailedJob) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// FeeHandler handles HTTP requests for fee rules and fee previews
+type FeeHandler struct {
+	service service.FeeService
+}
+
+// feeRuleRequest is the body of POST /admin/fee-rules. Amounts left out are
+// zero and caps left out do not apply.
+type feeRuleRequest struct {
+	Source         string           `json:"source"`
+	Currency       string           `json:"currency"`
+	FixedAmount    *decimal.Decimal `json:"fixed_amount"`
+	Percentage     *decimal.Decimal `json:"percentage"`
+	MinFee         *decimal.Decimal `json:"min_fee"`
+	MaxFee         *decimal.Decimal `json:"max_fee"`
+	EffectiveFrom  *time.Time       `json:"effective_from"`
+	EffectiveUntil *time.Time       `json:"effective_until"`
+	Reason         string           `json:"reason" binding:"required"`
+}
+
+// feeRuleEndRequest is the body of POST /admin/fee-rules/:id/end
+type feeRuleEndRequest struct {
+	EffectiveUntil *time.Time `json:"effective_until"`
+	Reason         string     `json:"reason" binding:"required"`
+}
+
+// feePreviewRequest is the body of POST /fees/preview
+type feePreviewRequest struct {
+	Amount   decimal.Decimal `json:"amount" binding:"required"`
+	Currency string          `json:"currency" binding:"required"`
+	Source   string          `json:"source"`
+	At       *time.Time      `json:"at"`
+}
+
+// NewFeeHandler creates a new instance of FeeHandler
+func NewFeeHandler(service service.FeeService) (*FeeHandler, error) {
+	if service == nil {
+		return nil, errors.New("fee service is required")
+	}
+
+	return &FeeHandler{service: service}, nil
+}
+
+// CreateRule handles POST /admin/fee-rules endpoint
+func (h *FeeHandler) CreateRule(c *gin.Context) {
+	var req feeRuleRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	rule := &models.FeeRule{
+		Source:         req.Source,
+		Currency:       req.Currency,
+		MinFee:         req.MinFee,
+		MaxFee:         req.MaxFee,
+		EffectiveUntil: req.EffectiveUntil,
+	}
+	if req.FixedAmount != nil {
+		rule.FixedAmount = *req.FixedAmount
+	}
+	if req.Percentage != nil {
+		rule.Percentage = *req.Percentage
+	}
+	if req.EffectiveFrom != nil {
+		rule.EffectiveFrom = req.EffectiveFrom.UTC()
+	}
+
+	rule, err := h.service.CreateRule(c.Request.Context(), rule, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondFeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   rule,
+	})
+}
+
+// ListRules handles GET /admin/fee-rules endpoint, listing the latest rules
+// newest first
+func (h *FeeHandler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   rules,
+	})
+}
+
+// GetRule handles GET /admin/fee-rules/:id endpoint
+func (h *FeeHandler) GetRule(c *gin.Context) {
+	id, ok := parseFeeRuleID(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.service.GetRule(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   rule,
+	})
+}
+
+// EndRule handles POST /admin/fee-rules/:id/end endpoint, stopping the rule
+// from applying from the given time, or now
+func (h *FeeHandler) EndRule(c *gin.Context) {
+	id, ok := parseFeeRuleID(c)
+	if !ok {
+		return
+	}
+
+	var req feeRuleEndRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var until time.Time
+	if req.EffectiveUntil != nil {
+		until = req.EffectiveUntil.UTC()
+	}
+
+	rule, err := h.service.EndRule(c.Request.Context(), id, until, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondFeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   rule,
+	})
+}
+
+// PreviewFee handles POST /fees/preview endpoint, showing the fee a
+// transaction would incur without making it
+func (h *FeeHandler) PreviewFee(c *gin.Context) {
+	var req feePreviewRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	preview := service.FeePreview{
+		Amount:   req.Amount,
+		Currency: req.Currency,
+		Source:   req.Source,
+	}
+	if req.At != nil {
+		preview.At = req.At.UTC()
+	}
+
+	quote, err := h.service.Preview(c.Request.Context(), preview)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   quote,
+	})
+}
+
+// parseFeeRuleID parses the fee rule ID path parameter, responding when it
+// is invalid
+func parseFeeRuleID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid fee rule ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondFeeError responds with the validation failure as details so
+// operators can tell why the rule was refused
+func respondFeeError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidFeeRule) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
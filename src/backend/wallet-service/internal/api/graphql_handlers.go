@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/apierror"
+	"internal/graphql"
+)
+
+// serveGraphQL runs the GraphQL handler as the principal recorded by the
+// auth middleware
+func serveGraphQL(h http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		customerID, err := customerFromContext(c)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		principal := &graphql.Principal{
+			CustomerID: customerID,
+			Token:      strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "),
+			Language:   apierror.ParseLanguage(c.GetHeader("Accept-Language")),
+		}
+
+		c.Request = c.Request.WithContext(graphql.WithPrincipal(c.Request.Context(), principal))
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
@@ -3,7 +3,6 @@ package api
 
 import (
     "errors"
-    "fmt"
     "net/http"
     "strconv"
     "time"
@@ -12,79 +11,336 @@ import (
     "github.com/google/uuid"           // v1.3.0
     "github.com/opentracing/opentracing-go" // v1.2.0
     "github.com/opentracing/opentracing-go/ext"
+    "github.com/shopspring/decimal"    // v1.3.1
 
+    "internal/apierror"
+    "internal/currency"
     "internal/models"
+    "internal/sensitive"
     "internal/service"
 )
 
-// Constants for pagination and supported currencies
+// Constants for pagination and the default currency
 const (
     defaultPageSize = 20
     maxPageSize = 100
     defaultCurrency = "USD"
+    defaultOverviewTransactions = 10
 )
 
-var supportedCurrencies = []string{"USD", "INR", "IDR"}
+// balanceResponse is the body of GET /wallets/:id/balance
+type balanceResponse struct {
+    Balance           decimal.Decimal `json:"balance" class:"financial"`
+    Currency          string          `json:"currency"`
+    CreditLimit       decimal.Decimal `json:"credit_limit" class:"financial"`
+    AvailableBalance  decimal.Decimal `json:"available_balance" class:"financial"`
+    CreditUtilization decimal.Decimal `json:"credit_utilization" class:"financial"`
+    LastActivityAt    *time.Time      `json:"last_activity_at,omitempty"`
+    InactiveSince     *time.Time      `json:"inactive_since,omitempty"`
+}
+
+// walletOverviewResponse is the body of GET /wallets/:id/overview. The
+// balance accounts for exactly the transactions up to the snapshot.
+type walletOverviewResponse struct {
+    WalletID          uuid.UUID             `json:"wallet_id"`
+    Balance           decimal.Decimal       `json:"balance" class:"financial"`
+    Currency          string                `json:"currency"`
+    CreditLimit       decimal.Decimal       `json:"credit_limit" class:"financial"`
+    AvailableBalance  decimal.Decimal       `json:"available_balance" class:"financial"`
+    Version           int64                 `json:"version"`
+    LastActivityAt    *time.Time            `json:"last_activity_at,omitempty"`
+    InactiveSince     *time.Time            `json:"inactive_since,omitempty"`
+    Transactions      []*models.Transaction `json:"transactions"`
+    AsOf              time.Time             `json:"as_of"`
+}
+
+// walletSettingsRequest is the body of PATCH /wallets/:id/settings. Omitted
+// fields are left unchanged; version is the settings version last read.
+type walletSettingsRequest struct {
+    LowBalanceThreshold *decimal.Decimal            `json:"low_balance_threshold"`
+    CreditLimit         *decimal.Decimal            `json:"credit_limit"`
+    Notifications       *walletNotificationsRequest `json:"notifications"`
+    AutoTopUp           *bool                       `json:"auto_topup"`
+    Version             *int64                      `json:"version"`
+}
+
+// walletNotificationsRequest changes the alerts sent about a wallet
+type walletNotificationsRequest struct {
+    LowBalance         *bool `json:"low_balance"`
+    CreditLimitWarning *bool `json:"credit_limit_warning"`
+}
+
+// transactionRequest is the body of POST /wallets/:id/transactions. A past
+// effective date backdates the transaction into its billing period.
+type transactionRequest struct {
+    Type        string            `json:"type" binding:"required"`
+    Amount      float64           `json:"amount" binding:"required,gt=0"`
+    Currency    string            `json:"currency" binding:"required"`
+    Description string            `json:"description"`
+    ReferenceID string            `json:"reference_id"`
+    Metadata    map[string]string `json:"metadata"`
+    EffectiveAt *time.Time        `json:"effective_at"`
+}
 
 // Response represents a standardized API response format
 type Response struct {
     Status  string      `json:"status"`
     Data    interface{} `json:"data,omitempty"`
+    Code    string      `json:"code,omitempty"`
     Error   string      `json:"error,omitempty"`
     Meta    interface{} `json:"meta,omitempty"`
 }
 
 // WalletHandler handles HTTP requests for wallet operations
 type WalletHandler struct {
-    service   service.WalletService
-    tracer    opentracing.Tracer
+    service    service.WalletService
+    history    service.BalanceHistoryService
+    currencies *currency.Registry
+    scanner    *sensitive.Scanner
+    tracer     opentracing.Tracer
 }
 
-// NewWalletHandler creates a new instance of WalletHandler
-func NewWalletHandler(service service.WalletService) (*WalletHandler, error) {
+// NewWalletHandler creates a new instance of WalletHandler. Balance and
+// transaction responses carry the display formats of their currencies from
+// currencies in their meta.
+func NewWalletHandler(service service.WalletService, history service.BalanceHistoryService, currencies *currency.Registry, scanner *sensitive.Scanner) (*WalletHandler, error) {
     if service == nil {
         return nil, errors.New("wallet service is required")
     }
+    if history == nil {
+        return nil, errors.New("balance history service is required")
+    }
+    if currencies == nil {
+        return nil, errors.New("currencies are required")
+    }
+    if scanner == nil {
+        return nil, errors.New("sensitive data scanner is required")
+    }
 
     return &WalletHandler{
-        service: service,
-        tracer:  opentracing.GlobalTracer(),
+        service:    service,
+        history:    history,
+        currencies: currencies,
+        scanner:    scanner,
+        tracer:     opentracing.GlobalTracer(),
     }, nil
 }
 
-// GetBalance handles GET /wallets/:id/balance endpoint
+// currencyMeta returns the response meta holding the display formats of
+// the given currencies, keyed by code
+func (h *WalletHandler) currencyMeta(codes ...string) map[string]interface{} {
+    return map[string]interface{}{
+        "currency_formats": h.currencies.Formats(codes...),
+    }
+}
+
+// transactionCurrencies returns the distinct currencies of transactions
+func transactionCurrencies(transactions []*models.Transaction) []string {
+    var codes []string
+    seen := make(map[string]bool)
+    for _, tx := range transactions {
+        if tx != nil && !seen[tx.Currency] {
+            seen[tx.Currency] = true
+            codes = append(codes, tx.Currency)
+        }
+    }
+    return codes
+}
+
+// GetBalance handles GET /wallets/:id/balance endpoint. With an as_of query
+// parameter it returns the balance at that RFC 3339 timestamp instead.
 func (h *WalletHandler) GetBalance(c *gin.Context) {
     span, ctx := opentracing.StartSpanFromContext(c.Request.Context(), "WalletHandler.GetBalance")
     defer span.Finish()
 
     walletID, err := uuid.Parse(c.Param("id"))
     if err != nil {
-        c.JSON(http.StatusBadRequest, Response{
-            Status: "error",
-            Error:  "invalid wallet ID format",
-        })
+        respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
         return
     }
 
-    balance, currency, err := h.service.GetWalletBalance(ctx, walletID)
-    if err != nil {
-        code := http.StatusInternalServerError
-        if errors.Is(err, service.ErrWalletNotFound) {
-            code = http.StatusNotFound
+    if asOfParam := c.Query("as_of"); asOfParam != "" {
+        asOf, err := time.Parse(time.RFC3339, asOfParam)
+        if err != nil {
+            respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("as_of must be an RFC 3339 timestamp"))
+            return
+        }
+
+        balance, err := h.history.GetBalanceAsOf(ctx, walletID, asOf)
+        if err != nil {
+            respondError(c, err)
+            return
         }
-        c.JSON(code, Response{
-            Status: "error",
-            Error:  err.Error(),
+
+        c.JSON(http.StatusOK, Response{
+            Status: "success",
+            Data:   balance,
+            Meta:   h.currencyMeta(balance.Currency),
         })
         return
     }
 
+    wallet, err := h.service.GetWallet(ctx, walletID)
+    if err != nil {
+        respondError(c, err)
+        return
+    }
+
     c.JSON(http.StatusOK, Response{
         Status: "success",
-        Data: map[string]interface{}{
-            "balance":  balance,
-            "currency": currency,
+        Data: balanceResponse{
+            Balance:           decimal.NewFromFloat(wallet.Balance),
+            Currency:          wallet.Currency,
+            CreditLimit:       decimal.NewFromFloat(wallet.CreditLimit),
+            AvailableBalance:  decimal.NewFromFloat(wallet.AvailableBalance()),
+            CreditUtilization: decimal.NewFromFloat(wallet.CreditUtilization()).Round(4),
+            LastActivityAt:    wallet.LastActivityAt,
+            InactiveSince:     wallet.InactiveSince,
         },
+        Meta: h.currencyMeta(wallet.Currency),
+    })
+}
+
+// GetWalletOverview handles GET /wallets/:id/overview endpoint, returning
+// the balance with the newest transactions read from one snapshot, so that
+// support screens combining them always reconcile. The limit query
+// parameter sets how many transactions, 10 by default.
+func (h *WalletHandler) GetWalletOverview(c *gin.Context) {
+    span, ctx := opentracing.StartSpanFromContext(c.Request.Context(), "WalletHandler.GetWalletOverview")
+    defer span.Finish()
+
+    walletID, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+        return
+    }
+
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultOverviewTransactions)))
+    overview, err := h.service.GetWalletOverview(ctx, walletID, limit)
+    if err != nil {
+        respondError(c, err)
+        return
+    }
+
+    wallet := overview.Wallet
+    transactions := overview.Transactions
+    if transactions == nil {
+        transactions = []*models.Transaction{}
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data: walletOverviewResponse{
+            WalletID:         wallet.ID,
+            Balance:          decimal.NewFromFloat(wallet.Balance),
+            Currency:         wallet.Currency,
+            CreditLimit:      decimal.NewFromFloat(wallet.CreditLimit),
+            AvailableBalance: decimal.NewFromFloat(wallet.AvailableBalance()),
+            Version:          wallet.Version,
+            LastActivityAt:   wallet.LastActivityAt,
+            InactiveSince:    wallet.InactiveSince,
+            Transactions:     transactions,
+            AsOf:             overview.AsOf,
+        },
+        Meta: h.currencyMeta(append([]string{wallet.Currency}, transactionCurrencies(transactions)...)...),
+    })
+}
+
+// GetWalletSettings handles GET /wallets/:id/settings endpoint
+func (h *WalletHandler) GetWalletSettings(c *gin.Context) {
+    span, ctx := opentracing.StartSpanFromContext(c.Request.Context(), "WalletHandler.GetWalletSettings")
+    defer span.Finish()
+
+    walletID, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+        return
+    }
+
+    settings, err := h.service.GetWalletSettings(ctx, walletID)
+    if err != nil {
+        respondError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   settings,
+    })
+}
+
+// UpdateWalletSettings handles PATCH /wallets/:id/settings endpoint. Only
+// operators may grant credit, as it lets the balance go negative, and
+// raising it needs a second operator's approval through the settings
+// changes. Settings other than the credit limit need the version last read,
+// and a stale one is refused as a concurrent modification.
+func (h *WalletHandler) UpdateWalletSettings(c *gin.Context) {
+    span, ctx := opentracing.StartSpanFromContext(c.Request.Context(), "WalletHandler.UpdateWalletSettings")
+    defer span.Finish()
+
+    walletID, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+        return
+    }
+
+    var req walletSettingsRequest
+    if err := bindJSON(c, &req); err != nil {
+        respondError(c, err)
+        return
+    }
+
+    if req.CreditLimit != nil && !hasRole(c, adminRole) {
+        respondError(c, apierror.New(apierror.CodeForbidden).WithDetails("credit_limit can only be set by operators"))
+        return
+    }
+
+    update := service.WalletSettingsUpdate{
+        LowBalanceThreshold: req.LowBalanceThreshold,
+        CreditLimit:         req.CreditLimit,
+        AutoTopUp:           req.AutoTopUp,
+        Version:             req.Version,
+    }
+    if req.Notifications != nil {
+        update.NotifyLowBalance = req.Notifications.LowBalance
+        update.NotifyCreditLimit = req.Notifications.CreditLimitWarning
+    }
+
+    settings, err := h.service.UpdateWalletSettings(ctx, walletID, update)
+    if errors.Is(err, service.ErrInvalidWalletSettings) || errors.Is(err, service.ErrCreditLimitBelowBalance) {
+        err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+    }
+    if err != nil {
+        respondError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   settings,
+    })
+}
+
+// GetWalletHealth handles GET /wallets/:id/health endpoint
+func (h *WalletHandler) GetWalletHealth(c *gin.Context) {
+    span, ctx := opentracing.StartSpanFromContext(c.Request.Context(), "WalletHandler.GetWalletHealth")
+    defer span.Finish()
+
+    walletID, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+        return
+    }
+
+    health, err := h.service.GetWalletHealth(ctx, walletID)
+    if err != nil {
+        respondError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   health,
     })
 }
 
@@ -95,36 +351,20 @@ func (h *WalletHandler) ProcessTransaction(c *gin.Context) {
 
     walletID, err := uuid.Parse(c.Param("id"))
     if err != nil {
-        c.JSON(http.StatusBadRequest, Response{
-            Status: "error",
-            Error:  "invalid wallet ID format",
-        })
+        respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
         return
     }
 
     // Validate idempotency key
     idempotencyKey := c.GetHeader("Idempotency-Key")
     if idempotencyKey == "" {
-        c.JSON(http.StatusBadRequest, Response{
-            Status: "error",
-            Error:  "idempotency key is required",
-        })
+        respondError(c, apierror.New(apierror.CodeIdempotencyKeyRequired))
         return
     }
 
-    var req struct {
-        Type        string  `json:"type" binding:"required"`
-        Amount      float64 `json:"amount" binding:"required,gt=0"`
-        Currency    string  `json:"currency" binding:"required"`
-        Description string  `json:"description"`
-        ReferenceID string  `json:"reference_id"`
-    }
-
-    if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, Response{
-            Status: "error",
-            Error:  fmt.Sprintf("invalid request format: %v", err),
-        })
+    var req transactionRequest
+    if err := bindJSON(c, &req); err != nil {
+        respondError(c, err)
         return
     }
 
@@ -137,29 +377,42 @@ func (h *WalletHandler) ProcessTransaction(c *gin.Context) {
         txType = models.TransactionTypeDebit
     case "REFUND":
         txType = models.TransactionTypeRefund
+    case "ADJUSTMENT":
+        // Adjustments are posted once approved through the admin API
+        respondError(c, apierror.New(apierror.CodeInvalidTransactionType).WithDetails("adjustments are requested through %s", apiV1+adjustmentsPath))
+        return
     default:
-        c.JSON(http.StatusBadRequest, Response{
-            Status: "error",
-            Error:  "invalid transaction type",
-        })
+        respondError(c, apierror.New(apierror.CodeInvalidTransactionType))
         return
     }
 
-    // Validate currency
-    validCurrency := false
-    for _, curr := range supportedCurrencies {
-        if curr == req.Currency {
-            validCurrency = true
-            break
-        }
+    // Reject or mask card and Aadhaar numbers pasted into free-text fields
+    description, err := h.scanner.Scan("description", req.Description)
+    if err != nil {
+        respondError(c, err)
+        return
     }
-    if !validCurrency {
-        c.JSON(http.StatusBadRequest, Response{
-            Status: "error",
-            Error:  "unsupported currency",
-        })
+    referenceID, err := h.scanner.Scan("reference_id", req.ReferenceID)
+    if err != nil {
+        respondError(c, err)
+        return
+    }
+
+    // Validate metadata limits, then scan its values like other free text
+    if err := models.ValidateMetadata(req.Metadata); err != nil {
+        respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err))
         return
     }
+    var metadata map[string]string
+    if len(req.Metadata) > 0 {
+        metadata = make(map[string]string, len(req.Metadata))
+        for key, value := range req.Metadata {
+            if metadata[key], err = h.scanner.Scan("metadata."+key, value); err != nil {
+                respondError(c, err)
+                return
+            }
+        }
+    }
 
     tx := &models.Transaction{
         ID:          uuid.New(),
@@ -168,32 +421,77 @@ func (h *WalletHandler) ProcessTransaction(c *gin.Context) {
         Status:      models.TransactionStatusInitiated,
         Amount:      req.Amount,
         Currency:    req.Currency,
-        Description: req.Description,
-        ReferenceID: req.ReferenceID,
+        Description: description,
+        ReferenceID: referenceID,
+        Metadata:    metadata,
         CreatedAt:   time.Now().UTC(),
         UpdatedAt:   time.Now().UTC(),
     }
-
-    if err := h.service.ProcessTransaction(ctx, tx); err != nil {
-        code := http.StatusInternalServerError
-        switch {
-        case errors.Is(err, service.ErrInsufficientBalance):
-            code = http.StatusUnprocessableEntity
-        case errors.Is(err, service.ErrWalletNotFound):
-            code = http.StatusNotFound
-        case errors.Is(err, service.ErrCurrencyMismatch):
-            code = http.StatusUnprocessableEntity
+    if req.EffectiveAt != nil {
+        // Backdated corrections are posted by operators only
+        if !hasRole(c, adminRole) {
+            respondError(c, apierror.New(apierror.CodeForbidden).WithDetails("effective_at requires the %s role", adminRole))
+            return
         }
-        c.JSON(code, Response{
-            Status: "error",
-            Error:  err.Error(),
-        })
+        effectiveAt := req.EffectiveAt.UTC()
+        tx.EffectiveAt = &effectiveAt
+    }
+
+    warning, err := h.service.ProcessTransaction(ctx, tx)
+    if err != nil {
+        respondError(c, err)
         return
     }
 
-    c.JSON(http.StatusCreated, Response{
+    meta := h.currencyMeta(tx.Currency)
+    if warning != nil {
+        meta["warnings"] = []*models.CreditLimitWarning{warning}
+    }
+    resp := Response{
         Status: "success",
         Data:   tx,
+        Meta:   meta,
+    }
+
+    c.JSON(http.StatusCreated, resp)
+}
+
+// SearchTransactions handles GET /transactions endpoint, looking up
+// transactions by reference ID across all wallets for billing support
+func (h *WalletHandler) SearchTransactions(c *gin.Context) {
+    span, ctx := opentracing.StartSpanFromContext(c.Request.Context(), "WalletHandler.SearchTransactions")
+    defer span.Finish()
+
+    referenceID := c.Query("reference_id")
+    if referenceID == "" {
+        respondError(c, apierror.New(apierror.CodeInvalidRequest).WithDetails("reference_id is required"))
+        return
+    }
+
+    matches, err := h.service.FindTransactionsByReference(ctx, referenceID)
+    if err != nil {
+        respondError(c, err)
+        return
+    }
+
+    transactions := make([]*models.Transaction, 0, len(matches))
+    targets := make([]accessTarget, 0, len(matches))
+    seen := make(map[uuid.UUID]bool, len(matches))
+    for _, match := range matches {
+        transactions = append(transactions, match.Transaction)
+        if walletID := match.Transaction.WalletID; !seen[walletID] {
+            seen[walletID] = true
+            targets = append(targets, accessTarget{walletID: &walletID})
+        }
+    }
+    reportDataAccess(c, targets...)
+    meta := h.currencyMeta(transactionCurrencies(transactions)...)
+    meta["total"] = len(matches)
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   matches,
+        Meta:   meta,
     })
 }
 
@@ -204,10 +502,7 @@ func (h *WalletHandler) GetTransactions(c *gin.Context) {
 
     walletID, err := uuid.Parse(c.Param("id"))
     if err != nil {
-        c.JSON(http.StatusBadRequest, Response{
-            Status: "error",
-            Error:  "invalid wallet ID format",
-        })
+        respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
         return
     }
 
@@ -238,31 +533,31 @@ func (h *WalletHandler) GetTransactions(c *gin.Context) {
             filter.ToDate = parsed
         }
     }
+    filter.MetadataKey = c.Query("metadata_key")
+    filter.MetadataValue = c.Query("metadata_value")
+    if filter.MetadataValue != "" && filter.MetadataKey == "" {
+        respondError(c, apierror.New(apierror.CodeInvalidRequest).WithDetails("metadata_value requires metadata_key"))
+        return
+    }
 
     transactions, total, err := h.service.GetTransactionHistory(ctx, walletID, filter, service.Pagination{
         Limit:  pageSize,
         Offset: offset,
     })
     if err != nil {
-        code := http.StatusInternalServerError
-        if errors.Is(err, service.ErrWalletNotFound) {
-            code = http.StatusNotFound
-        }
-        c.JSON(code, Response{
-            Status: "error",
-            Error:  err.Error(),
-        })
+        respondError(c, err)
         return
     }
 
+    meta := h.currencyMeta(transactionCurrencies(transactions)...)
+    meta["total"] = total
+    meta["page"] = page
+    meta["page_size"] = pageSize
+    meta["total_pages"] = (total + pageSize - 1) / pageSize
+
     c.JSON(http.StatusOK, Response{
         Status: "success",
         Data:   transactions,
-        Meta: map[string]interface{}{
-            "total":      total,
-            "page":       page,
-            "page_size":  pageSize,
-            "total_pages": (total + pageSize - 1) / pageSize,
-        },
+        Meta:   meta,
     })
 }
\ No newline at end of file
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/health"
+)
+
+// HealthHandler serves readiness probes backed by the dependency monitor
+type HealthHandler struct {
+	monitor *health.Monitor
+}
+
+// NewHealthHandler creates a new instance of HealthHandler
+func NewHealthHandler(monitor *health.Monitor) (*HealthHandler, error) {
+	if monitor == nil {
+		return nil, errors.New("health monitor is required")
+	}
+
+	return &HealthHandler{monitor: monitor}, nil
+}
+
+// Readiness handles GET /readyz endpoint. A degraded service still accepts
+// traffic; only a hard dependency failure reports 503.
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.monitor.Check(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status == health.StatusNotReady {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, report)
+}
+
+// degradedCapabilitiesHeader lists the capabilities a response relies on
+// that are not fully available, as name=status pairs
+const degradedCapabilitiesHeader = "X-Degraded-Capabilities"
+
+// GetCapabilities handles GET /capabilities endpoint, reporting which
+// capabilities of the service are ok, degraded or down so clients can adapt
+func (h *HealthHandler) GetCapabilities(c *gin.Context) {
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   h.monitor.Matrix(),
+	})
+}
+
+// Capability returns middleware marking responses of routes relying on the
+// capabilities with the degraded capabilities header while any of them is
+// not ok. Requests are still served; features apply their own policies.
+func (h *HealthHandler) Capability(names ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var degraded []string
+		for _, name := range names {
+			if status := h.monitor.CapabilityStatus(name); status != health.CapabilityOK {
+				degraded = append(degraded, name+"="+status)
+			}
+		}
+		if len(degraded) > 0 {
+			c.Header(degradedCapabilitiesHeader, strings.Join(degraded, ", "))
+		}
+
+		c.Next()
+	}
+}
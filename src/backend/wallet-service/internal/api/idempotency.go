@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"     // v1.9.1
+	"github.com/go-redis/redis/v8" // v8.11.5
+
+	"internal/apierror"
+	"internal/health"
+	"internal/logging"
+)
+
+// idempotencyKeyPrefix namespaces idempotency records in Redis
+const idempotencyKeyPrefix = "idempotency:"
+
+// idempotencyRecord is the stored state of an idempotent request. A zero
+// Status marks a request that is still being processed.
+type idempotencyRecord struct {
+	RequestHash string          `json:"request_hash"`
+	Status      int             `json:"status"`
+	Body        json.RawMessage `json:"body,omitempty"`
+}
+
+// responseRecorder captures the response body so it can be replayed
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// IdempotencyMiddleware reserves the Idempotency-Key of a request in Redis and
+// replays the stored response for retries. When Redis is unavailable the
+// failure policy decides whether requests are rejected with 503 or processed
+// without duplicate protection.
+func IdempotencyMiddleware(rdb *redis.Client, ttl time.Duration, failurePolicy health.FailurePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			// Handlers decide whether the header is required
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		ctx := c.Request.Context()
+		redisKey := idempotencyKeyPrefix + c.GetString("customer_id") + ":" + key
+
+		pending, _ := json.Marshal(idempotencyRecord{RequestHash: requestHash})
+		reserved, err := rdb.SetNX(ctx, redisKey, pending, ttl).Result()
+		if err != nil {
+			handleIdempotencyStoreError(c, failurePolicy, err)
+			return
+		}
+
+		if !reserved {
+			replayIdempotentResponse(c, rdb, redisKey, requestHash, failurePolicy)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Release the key on server errors so the client can retry
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			if err := rdb.Del(ctx, redisKey).Err(); err != nil {
+				logging.FromContext(ctx).Warn("failed to release idempotency key", "error", err)
+			}
+			return
+		}
+
+		completed, _ := json.Marshal(idempotencyRecord{
+			RequestHash: requestHash,
+			Status:      c.Writer.Status(),
+			Body:        recorder.body.Bytes(),
+		})
+		if err := rdb.Set(ctx, redisKey, completed, ttl).Err(); err != nil {
+			logging.FromContext(ctx).Warn("failed to store idempotent response", "error", err)
+		}
+	}
+}
+
+// replayIdempotentResponse answers a request whose key was already reserved
+func replayIdempotentResponse(c *gin.Context, rdb *redis.Client, redisKey, requestHash string, failurePolicy health.FailurePolicy) {
+	raw, err := rdb.Get(c.Request.Context(), redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The reservation expired between SETNX and GET
+		respondError(c, apierror.New(apierror.CodeConcurrentModification))
+		return
+	}
+	if err != nil {
+		handleIdempotencyStoreError(c, failurePolicy, err)
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInternal, err))
+		return
+	}
+
+	switch {
+	case record.RequestHash != requestHash:
+		respondError(c, apierror.New(apierror.CodeIdempotencyConflict))
+	case record.Status == 0:
+		respondError(c, apierror.New(apierror.CodeConcurrentModification).WithDetails("a request with this idempotency key is still in progress"))
+	default:
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(record.Status, gin.MIMEJSON, record.Body)
+		c.Abort()
+	}
+}
+
+// handleIdempotencyStoreError applies the failure policy when Redis is unavailable
+func handleIdempotencyStoreError(c *gin.Context, failurePolicy health.FailurePolicy, err error) {
+	if failurePolicy == health.FailOpen {
+		logging.FromContext(c.Request.Context()).Warn("idempotency store unavailable, processing request without duplicate protection", "error", err)
+		c.Next()
+		return
+	}
+	logging.FromContext(c.Request.Context()).Error("idempotency store unavailable, rejecting request", err)
+	respondError(c, apierror.Wrap(apierror.CodeServiceUnavailable, err))
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/service"
+)
+
+// InboundWebhookHandler handles the webhooks payment gateways and partner
+// systems send, and their dead letters
+type InboundWebhookHandler struct {
+	service service.InboundWebhookService
+}
+
+// inboundWebhookReceipt acknowledges a received webhook
+type inboundWebhookReceipt struct {
+	EventID string `json:"event_id"`
+	// Duplicate is set for events the provider already delivered
+	Duplicate bool `json:"duplicate"`
+}
+
+// NewInboundWebhookHandler creates a new instance of InboundWebhookHandler
+func NewInboundWebhookHandler(service service.InboundWebhookService) (*InboundWebhookHandler, error) {
+	if service == nil {
+		return nil, errors.New("inbound webhook service is required")
+	}
+
+	return &InboundWebhookHandler{service: service}, nil
+}
+
+// ReceiveWebhook handles POST /inbound-webhooks/:provider endpoint. The
+// request is authenticated by the provider's signature rather than a JWT,
+// and is acknowledged once recorded; it is processed in the background.
+func (h *InboundWebhookHandler) ReceiveWebhook(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err))
+		return
+	}
+
+	webhook, recorded, err := h.service.Receive(c.Request.Context(), c.Param("provider"), c.Request.Header, body)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   inboundWebhookReceipt{EventID: webhook.EventID, Duplicate: !recorded},
+	})
+}
+
+// ListDeadLetters handles GET /admin/inbound-webhooks/dead-letters endpoint
+func (h *InboundWebhookHandler) ListDeadLetters(c *gin.Context) {
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("limit must be an integer"))
+			return
+		}
+		limit = parsed
+	}
+
+	letters, err := h.service.ListDeadLetters(c.Request.Context(), c.Query("provider"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   letters,
+	})
+}
+
+// ReplayDeadLetter handles POST /admin/inbound-webhooks/dead-letters/:id/replay
+// endpoint
+func (h *InboundWebhookHandler) ReplayDeadLetter(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid dead letter ID"))
+		return
+	}
+
+	letter, err := h.service.ReplayDeadLetter(c.Request.Context(), id, actorFromContext(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   letter,
+	})
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/apierror"
+	"internal/service"
+)
+
+// IncidentHandler handles HTTP requests for the incident switch pausing
+// transaction types
+type IncidentHandler struct {
+	service service.IncidentService
+}
+
+// incidentRequest is the body of PUT /admin/incident. Transaction types
+// left out are not paused.
+type incidentRequest struct {
+	Reason           string     `json:"reason" binding:"required"`
+	PauseDebits      bool       `json:"pause_debits"`
+	PauseCredits     bool       `json:"pause_credits"`
+	PauseRefunds     bool       `json:"pause_refunds"`
+	PauseAdjustments bool       `json:"pause_adjustments"`
+	AllowedSources   []string   `json:"allowed_sources"`
+	ResumeAt         *time.Time `json:"resume_at"`
+}
+
+// incidentResumeRequest is the body of POST /admin/incident/resume
+type incidentResumeRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// NewIncidentHandler creates a new instance of IncidentHandler
+func NewIncidentHandler(service service.IncidentService) (*IncidentHandler, error) {
+	if service == nil {
+		return nil, errors.New("incident service is required")
+	}
+
+	return &IncidentHandler{service: service}, nil
+}
+
+// GetIncident handles GET /admin/incident endpoint, returning the active
+// incident
+func (h *IncidentHandler) GetIncident(c *gin.Context) {
+	incident, err := h.service.Get(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   incident,
+	})
+}
+
+// PauseTransactions handles PUT /admin/incident endpoint, starting an
+// incident or replacing what the active one pauses. Other replicas apply
+// the change within the configured refresh interval.
+func (h *IncidentHandler) PauseTransactions(c *gin.Context) {
+	var req incidentRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	incident, err := h.service.Pause(c.Request.Context(), service.IncidentInput{
+		Reason:           req.Reason,
+		PauseDebits:      req.PauseDebits,
+		PauseCredits:     req.PauseCredits,
+		PauseRefunds:     req.PauseRefunds,
+		PauseAdjustments: req.PauseAdjustments,
+		AllowedSources:   req.AllowedSources,
+		ResumeAt:         req.ResumeAt,
+	}, actorFromContext(c))
+	if err != nil {
+		respondIncidentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   incident,
+	})
+}
+
+// ResumeTransactions handles POST /admin/incident/resume endpoint, resuming
+// the active incident
+func (h *IncidentHandler) ResumeTransactions(c *gin.Context) {
+	var req incidentResumeRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	incident, err := h.service.Resume(c.Request.Context(), actorFromContext(c), req.Reason)
+	if err != nil {
+		respondIncidentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   incident,
+	})
+}
+
+// respondIncidentError responds with the validation failure as details
+func respondIncidentError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidIncident) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
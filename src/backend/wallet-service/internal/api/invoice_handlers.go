@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// InvoiceHandler handles the PDFs of invoices closed by the billing cycle
+type InvoiceHandler struct {
+	service service.InvoiceDocumentService
+}
+
+// issueInvoiceRequest is the body of POST /invoices. Invoices without a
+// due date fall due by the customer's payment terms.
+type issueInvoiceRequest struct {
+	InvoiceID     uuid.UUID                `json:"invoice_id" binding:"required"`
+	CustomerID    uuid.UUID                `json:"customer_id" binding:"required"`
+	InvoiceNumber string                   `json:"invoice_number" binding:"required"`
+	Currency      string                   `json:"currency" binding:"required"`
+	IssueDate     time.Time                `json:"issue_date" binding:"required"`
+	DueDate       time.Time                `json:"due_date"`
+	BillTo        []string                 `json:"bill_to"`
+	LineItems     []models.InvoiceLineItem `json:"line_items" binding:"required"`
+	Subtotal      *decimal.Decimal         `json:"subtotal" binding:"required"`
+	TaxType       string                   `json:"tax_type"`
+	TaxAmount     decimal.Decimal          `json:"tax_amount"`
+	TotalAmount   *decimal.Decimal         `json:"total_amount" binding:"required"`
+	Notes         string                   `json:"notes"`
+}
+
+// NewInvoiceHandler creates a new instance of InvoiceHandler
+func NewInvoiceHandler(service service.InvoiceDocumentService) (*InvoiceHandler, error) {
+	if service == nil {
+		return nil, errors.New("invoice document service is required")
+	}
+
+	return &InvoiceHandler{service: service}, nil
+}
+
+// IssueInvoice handles POST /invoices endpoint, called at billing cycle close
+// to render an invoice's PDF and deliver it to the customer
+func (h *InvoiceHandler) IssueInvoice(c *gin.Context) {
+	var req issueInvoiceRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	doc, err := h.service.Issue(c.Request.Context(), &models.InvoiceDocument{
+		InvoiceID:     req.InvoiceID,
+		CustomerID:    req.CustomerID,
+		InvoiceNumber: req.InvoiceNumber,
+		Currency:      req.Currency,
+		IssueDate:     req.IssueDate,
+		DueDate:       req.DueDate,
+		BillTo:        req.BillTo,
+		LineItems:     req.LineItems,
+		Subtotal:      *req.Subtotal,
+		TaxType:       req.TaxType,
+		TaxAmount:     req.TaxAmount,
+		TotalAmount:   *req.TotalAmount,
+		Notes:         req.Notes,
+	})
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   doc,
+	})
+}
+
+// ResendInvoice handles POST /invoices/:id/resend endpoint, delivering the
+// stored PDF to the customer again
+func (h *InvoiceHandler) ResendInvoice(c *gin.Context) {
+	id, err := invoiceID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	doc, err := h.service.Resend(c.Request.Context(), id)
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   doc,
+	})
+}
+
+// GetPDF handles GET /invoices/:id/pdf endpoint, serving the stored PDF.
+// Customers only get their own invoices; others are reported as not found
+// so their existence is not disclosed.
+func (h *InvoiceHandler) GetPDF(c *gin.Context) {
+	id, err := invoiceID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	doc, pdf, err := h.service.GetPDF(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if !hasRole(c, adminRole) && !hasRole(c, supportRole) {
+		customerID, err := customerFromContext(c)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		if customerID != doc.CustomerID {
+			respondError(c, service.ErrInvoiceDocumentNotFound)
+			return
+		}
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", doc.FileName()))
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// invoiceID returns the invoice ID path parameter
+func invoiceID(c *gin.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid invoice ID")
+	}
+	return id, nil
+}
+
+// respondInvoiceError responds with the validation failure as details so the
+// billing cycle can tell which figures were rejected
+func respondInvoiceError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidInvoiceDocument) || errors.Is(err, service.ErrInvalidInvoiceNotice) ||
+		errors.Is(err, service.ErrInvalidInvoicePayment) || errors.Is(err, service.ErrInvalidPaymentTerms) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
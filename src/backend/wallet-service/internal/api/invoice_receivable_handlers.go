@@ -0,0 +1,160 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// InvoiceReceivableHandler handles payment terms and the payment status
+// and aging of issued invoices
+type InvoiceReceivableHandler struct {
+	service service.InvoiceReceivableService
+}
+
+// paymentTermsRequest is the body of PUT /customers/:id/payment-terms
+type paymentTermsRequest struct {
+	// PaymentTerms are NET_15, NET_30, NET_45 or NET_60; empty returns the
+	// customer to the default
+	PaymentTerms models.PaymentTerms `json:"payment_terms"`
+}
+
+// invoicePaymentRequest is the body of POST /invoices/:id/payments
+type invoicePaymentRequest struct {
+	Amount *decimal.Decimal `json:"amount" binding:"required"`
+	// PaidAt defaults to now
+	PaidAt time.Time `json:"paid_at"`
+}
+
+// NewInvoiceReceivableHandler creates a new instance of
+// InvoiceReceivableHandler
+func NewInvoiceReceivableHandler(service service.InvoiceReceivableService) (*InvoiceReceivableHandler, error) {
+	if service == nil {
+		return nil, errors.New("invoice receivable service is required")
+	}
+
+	return &InvoiceReceivableHandler{service: service}, nil
+}
+
+// GetInvoice handles GET /invoices/:id endpoint, returning an invoice's
+// due date, payments and late charges. Customers only get their own
+// invoices.
+func (h *InvoiceReceivableHandler) GetInvoice(c *gin.Context) {
+	id, err := invoiceID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	receivable, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if !hasRole(c, adminRole) && !hasRole(c, supportRole) {
+		customerID, err := customerFromContext(c)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		if customerID != receivable.CustomerID {
+			respondError(c, service.ErrInvoiceReceivableNotFound)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   receivable,
+	})
+}
+
+// RecordPayment handles POST /invoices/:id/payments endpoint, called when
+// a payment towards an invoice is received
+func (h *InvoiceReceivableHandler) RecordPayment(c *gin.Context) {
+	id, err := invoiceID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req invoicePaymentRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	receivable, err := h.service.RecordPayment(c.Request.Context(), id, *req.Amount, req.PaidAt)
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   receivable,
+	})
+}
+
+// GetAging handles GET /admin/invoices/aging endpoint, splitting what is
+// left to pay of unpaid invoices by how long they are past due. The
+// currency and customer_id query parameters narrow the report.
+func (h *InvoiceReceivableHandler) GetAging(c *gin.Context) {
+	var customerID uuid.UUID
+	if value := c.Query("customer_id"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid customer ID"))
+			return
+		}
+		customerID = parsed
+	}
+
+	report, err := h.service.Aging(c.Request.Context(), time.Now().UTC(), strings.ToUpper(c.Query("currency")), customerID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   report,
+	})
+}
+
+// UpdatePaymentTerms handles PUT /customers/:id/payment-terms endpoint
+func (h *InvoiceReceivableHandler) UpdatePaymentTerms(c *gin.Context) {
+	customerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid customer ID"))
+		return
+	}
+
+	var req paymentTermsRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	settings, err := h.service.UpdatePaymentTerms(c.Request.Context(), customerID,
+		models.PaymentTerms(strings.ToUpper(string(req.PaymentTerms))), actorFromContext(c))
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   settings,
+	})
+}
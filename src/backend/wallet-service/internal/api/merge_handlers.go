@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/service"
+)
+
+// MergeHandler handles HTTP requests for merging duplicate wallets
+type MergeHandler struct {
+	service service.WalletMergeService
+}
+
+// mergeRequest is the body of wallet merge requests
+type mergeRequest struct {
+	SourceWalletID uuid.UUID `json:"source_wallet_id" binding:"required"`
+	TargetWalletID uuid.UUID `json:"target_wallet_id" binding:"required"`
+	Reason         string    `json:"reason" binding:"required"`
+}
+
+// mergeReversalRequest is the body of wallet merge reversal requests
+type mergeReversalRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// NewMergeHandler creates a new instance of MergeHandler
+func NewMergeHandler(service service.WalletMergeService) (*MergeHandler, error) {
+	if service == nil {
+		return nil, errors.New("wallet merge service is required")
+	}
+
+	return &MergeHandler{service: service}, nil
+}
+
+// MergeWallets handles POST /admin/wallet-merges endpoint. The source
+// wallet's balance and history move to the target wallet and the source
+// redirects to it from then on.
+func (h *MergeHandler) MergeWallets(c *gin.Context) {
+	var req mergeRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	merge, err := h.service.Merge(c.Request.Context(), req.SourceWalletID, req.TargetWalletID, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondMergeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   merge,
+	})
+}
+
+// GetMerge handles GET /admin/wallet-merges/:id endpoint
+func (h *MergeHandler) GetMerge(c *gin.Context) {
+	id, ok := parseMergeID(c)
+	if !ok {
+		return
+	}
+
+	merge, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   merge,
+	})
+}
+
+// ReverseMerge handles POST /admin/wallet-merges/:id/reverse endpoint,
+// putting the merged balance and history back on the source wallet
+func (h *MergeHandler) ReverseMerge(c *gin.Context) {
+	id, ok := parseMergeID(c)
+	if !ok {
+		return
+	}
+
+	var req mergeReversalRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	merge, err := h.service.Reverse(c.Request.Context(), id, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondMergeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   merge,
+	})
+}
+
+// GetWalletRedirect handles GET /wallets/:id/merge endpoint, telling clients
+// of a merged wallet which wallet replaced it
+func (h *MergeHandler) GetWalletRedirect(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	merge, err := h.service.GetRedirect(c.Request.Context(), walletID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   merge,
+	})
+}
+
+// parseMergeID parses the merge ID path parameter, responding when it is
+// invalid
+func parseMergeID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid wallet merge ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondMergeError responds with the reason a merge or its reversal was
+// refused as details
+func respondMergeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidWalletMerge):
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	case errors.Is(err, service.ErrWalletMergeConflict):
+		err = apierror.Wrap(apierror.CodeMergeConflict, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
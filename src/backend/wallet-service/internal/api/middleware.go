@@ -3,50 +3,55 @@
 package api
 
 import (
-	"context"
 	"errors"
 	"fmt"
-	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin" // v1.9.x
-	"github.com/golang-jwt/jwt/v5" // v5.0.0
-	"github.com/go-redis/redis/v8" // v8.11.5
-	"github.com/sirupsen/logrus" // v1.9.0
-	"golang.org/x/time/rate" // v0.3.0
+	"github.com/google/uuid" // v1.3.0
 	"go.opentelemetry.io/otel" // v1.11.0
 	"go.opentelemetry.io/otel/trace"
 	
-	"internal/config"
+	"internal/apierror"
+	"internal/auth"
+	"internal/execctx"
+	"internal/health"
+	"internal/logging"
+	"internal/ratelimit"
+	"internal/service"
+	"internal/tenancy"
 )
 
+// correlationIDHeader carries the correlation ID of a request, taken from
+// the client when valid and returned on every response
+const correlationIDHeader = "X-Request-ID"
+
+// maxCorrelationIDLength bounds client supplied correlation IDs
+const maxCorrelationIDLength = 128
+
 // Error variables for common middleware errors
 var (
-	errUnauthorized = errors.New("unauthorized access")
+	errUnauthorized      = errors.New("unauthorized access")
 	errRateLimitExceeded = errors.New("rate limit exceeded")
-	errInvalidToken = errors.New("invalid or expired token")
-	errInvalidClaims = errors.New("invalid token claims")
 )
 
-// Custom claims structure for JWT tokens
-type Claims struct {
-	jwt.RegisteredClaims
-	CustomerID string   `json:"customer_id"`
-	Roles      []string `json:"roles"`
-}
-
-// AuthMiddleware creates a new authentication middleware handler
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// AuthMiddleware creates the authentication middleware validating bearer
+// tokens and recording the authenticated principal on the request context
+func AuthMiddleware(validator *auth.Validator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Start authentication span
 		ctx, span := otel.Tracer("middleware").Start(c.Request.Context(), "auth_middleware")
 		defer span.End()
 
-		// Generate correlation ID
-		correlationID := generateCorrelationID()
+		// Correlation IDs are assigned by the logger middleware
+		correlationID := c.GetString("correlation_id")
+		if correlationID == "" {
+			correlationID = generateCorrelationID()
+			c.Set("correlation_id", correlationID)
+		}
 		span.SetAttributes(trace.StringAttribute("correlation_id", correlationID))
-		c.Set("correlation_id", correlationID)
 
 		// Extract token from Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -56,37 +61,33 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		}
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		// Parse and validate JWT token
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			// Verify signing algorithm
-			if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			// Return public key for validation
-			return loadPublicKey(cfg.Security.JWTSecret)
-		})
-
+		// Verify signature, algorithm, expiry, issuer and audience
+		claims, err := validator.Validate(ctx, tokenString)
 		if err != nil {
-			handleAuthError(c, errInvalidToken, err.Error())
+			handleAuthError(c, err, err.Error())
 			return
 		}
 
-		// Validate claims
-		claims, ok := token.Claims.(*Claims)
-		if !ok || !token.Valid {
-			handleAuthError(c, errInvalidClaims, "invalid token claims")
-			return
-		}
+		// Set principal context
+		c.Set("subject", claims.Subject)
+		c.Set("customer_id", claims.CustomerID)
+		c.Set("roles", claims.Roles)
 
-		// Validate expiration
-		if time.Now().After(claims.ExpiresAt.Time) {
-			handleAuthError(c, errInvalidToken, "token expired")
-			return
+		// Sandbox credentials never reach real customers
+		if claims.HasRole(sandboxRole) {
+			c.Request = c.Request.WithContext(execctx.WithMode(c.Request.Context(), execctx.ModeSandbox))
 		}
 
-		// Set customer context
-		c.Set("customer_id", claims.CustomerID)
-		c.Set("roles", claims.Roles)
+		// Organization-scoped credentials only see their organization
+		if claims.OrgID != "" {
+			orgID, err := uuid.Parse(claims.OrgID)
+			if err != nil {
+				handleAuthError(c, auth.ErrInvalidToken, "invalid organization claim")
+				return
+			}
+			c.Set("org_id", claims.OrgID)
+			c.Request = c.Request.WithContext(tenancy.WithOrganization(c.Request.Context(), orgID))
+		}
 
 		span.SetAttributes(
 			trace.StringAttribute("customer_id", claims.CustomerID),
@@ -97,39 +98,43 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
-// RateLimitMiddleware creates a new rate limiting middleware handler
-func RateLimitMiddleware(cfg *config.Config) gin.HandlerFunc {
-	// Initialize Redis client for distributed rate limiting
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.Cache.Host, cfg.Cache.Port),
-		Password: cfg.Cache.Password,
-		DB:       cfg.Cache.DB,
-	})
-
+// RateLimitMiddleware creates a new rate limiting middleware handler that
+// enforces each customer's resolved policy with a counter shared in Redis
+func RateLimitMiddleware(limiter *ratelimit.Limiter, resolver *ratelimit.PolicyResolver, failurePolicy health.FailurePolicy) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, span := otel.Tracer("middleware").Start(c.Request.Context(), "rate_limit_middleware")
 		defer span.End()
 
-		customerID, exists := c.Get("customer_id")
-		if !exists {
-			handleAuthError(c, errUnauthorized, "customer context not found")
-			return
+		// Resolve customer or plan tier, the default policy is returned on
+		// failure and for operator and service tokens without a customer
+		policy, err := resolver.Resolve(ctx, c.GetString("customer_id"))
+		if err != nil {
+			logging.FromContext(ctx).Warn("rate limit policy lookup failed, using default policy", "error", err)
 		}
 
-		// Create rate limiter key
-		key := fmt.Sprintf("ratelimit:%s", customerID)
-
-		// Check rate limit
-		limited, err := isRateLimited(ctx, rdb, key, cfg.Security.RateLimit, cfg.Security.RateLimitWindow)
+		// Check rate limit against the window shared by all replicas
+		result, err := limiter.Allow(ctx, rateLimitSubject(c), policy)
 		if err != nil {
-			logrus.WithError(err).Error("rate limit check failed")
-			c.Next() // Allow request on error
+			span.SetAttributes(trace.BoolAttribute("rate_limit_degraded", true))
+			if failurePolicy == health.FailClosed {
+				logging.FromContext(ctx).Error("rate limit check failed, rejecting request", err)
+				respondError(c, apierror.Wrap(apierror.CodeServiceUnavailable, err))
+				return
+			}
+			logging.FromContext(ctx).Warn("rate limit check failed, allowing request", "error", err)
+			c.Next()
 			return
 		}
 
-		if limited {
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(result.ResetAt.Unix(), 10))
+
+		span.SetAttributes(trace.StringAttribute("rate_limit_tier", policy.Tier))
+		if !result.Allowed {
 			span.SetAttributes(trace.BoolAttribute("rate_limited", true))
-			c.Header("Retry-After", fmt.Sprintf("%d", cfg.Security.RateLimitWindow/time.Second))
+			retryAfter := time.Until(result.ResetAt).Round(time.Second)
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter/time.Second)))
 			handleRateLimitError(c, errRateLimitExceeded)
 			return
 		}
@@ -139,22 +144,72 @@ func RateLimitMiddleware(cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
-// LoggerMiddleware creates a new logging middleware with enhanced observability
-func LoggerMiddleware() gin.HandlerFunc {
+// rateLimitSubject returns the key the caller's requests are counted under:
+// the customer, else the token subject, else the client address
+func rateLimitSubject(c *gin.Context) string {
+	if customerID := c.GetString("customer_id"); customerID != "" {
+		return customerID
+	}
+	if subject := c.GetString("subject"); subject != "" {
+		return "subject:" + subject
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// retryAfterKey is the context key of the Retry-After, in seconds, of
+// writes refused while the database is read-only
+const retryAfterKey = "retry_after"
+
+// DatabaseModeMiddleware creates a middleware refusing requests that could
+// change data with 503 and a Retry-After while the database is read-only, as
+// during a failover. Reads are still served. A write the database refused
+// switches the service into read-only mode for the requests that follow.
+func DatabaseModeMiddleware(mode service.DatabaseMode, retryAfter time.Duration) gin.HandlerFunc {
+	seconds := strconv.Itoa(int(retryAfter / time.Second))
+	return func(c *gin.Context) {
+		c.Set(retryAfterKey, seconds)
+		if mode.ReadOnly() && mayChangeData(c) {
+			respondError(c, apierror.Wrap(apierror.CodeDatabaseReadOnly, service.ErrDatabaseReadOnly))
+			return
+		}
+
+		c.Next()
+
+		for _, err := range c.Errors {
+			mode.Observe(c.Request.Context(), err.Err)
+		}
+	}
+}
+
+// LoggerMiddleware creates a new logging middleware assigning each request
+// a correlation ID and a child logger carrying it, which handlers and the
+// components they call read from the request context
+func LoggerMiddleware(logger *logging.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
 		query := c.Request.URL.RawQuery
 
+		correlationID := c.GetHeader(correlationIDHeader)
+		if !validCorrelationID(correlationID) {
+			correlationID = generateCorrelationID()
+		}
+		c.Set("correlation_id", correlationID)
+		c.Header(correlationIDHeader, correlationID)
+		requestLogger := logger.With("correlation_id", correlationID)
+
 		// Start request span
-		ctx, span := otel.Tracer("middleware").Start(c.Request.Context(), "request")
+		ctx := logging.WithCorrelationID(c.Request.Context(), correlationID)
+		ctx, span := otel.Tracer("middleware").Start(logging.WithContext(ctx, requestLogger), "request")
 		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
 
 		// Set span attributes
 		span.SetAttributes(
 			trace.StringAttribute("http.method", c.Request.Method),
 			trace.StringAttribute("http.path", path),
 			trace.StringAttribute("http.query", query),
+			trace.StringAttribute("correlation_id", correlationID),
 		)
 
 		// Process request
@@ -163,17 +218,21 @@ func LoggerMiddleware() gin.HandlerFunc {
 		// Calculate duration
 		duration := time.Since(start)
 
+		// Probes and scrapes are not logged
+		if path == healthPath || path == readyzPath || path == metricsPath {
+			return
+		}
+
 		// Log request details
-		logrus.WithFields(logrus.Fields{
-			"correlation_id": c.GetString("correlation_id"),
-			"method":        c.Request.Method,
-			"path":         path,
-			"query":        query,
-			"status":       c.Writer.Status(),
-			"duration_ms":  duration.Milliseconds(),
-			"client_ip":    c.ClientIP(),
-			"user_agent":   c.Request.UserAgent(),
-		}).Info("request processed")
+		requestLogger.Info("request processed",
+			"method", c.Request.Method,
+			"path", path,
+			"query", query,
+			"status", c.Writer.Status(),
+			"duration_ms", duration.Milliseconds(),
+			"client_ip", c.ClientIP(),
+			"user_agent", c.Request.UserAgent(),
+		)
 
 		// Update metrics
 		updateRequestMetrics(c, duration)
@@ -190,20 +249,15 @@ func ErrorMiddleware() gin.HandlerFunc {
 				defer span.End()
 
 				// Log error with stack trace
-				logrus.WithFields(logrus.Fields{
-					"correlation_id": c.GetString("correlation_id"),
-					"error":         err,
-					"stack_trace":   getStackTrace(),
-				}).Error("panic recovered")
+				logging.FromContext(ctx).Error("panic recovered", fmt.Errorf("panic: %v", err),
+					"stack_trace", getStackTrace(),
+				)
 
 				// Update error metrics
 				updateErrorMetrics("panic", c.Request.URL.Path)
 
 				// Return 500 error
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-					"error": "internal server error",
-					"code":  "INTERNAL_ERROR",
-				})
+				respondError(c, apierror.Wrap(apierror.CodeInternal, fmt.Errorf("panic: %v", err)))
 			}
 		}()
 
@@ -214,63 +268,42 @@ func ErrorMiddleware() gin.HandlerFunc {
 // Helper functions
 
 func handleAuthError(c *gin.Context, err error, details string) {
-	logrus.WithFields(logrus.Fields{
-		"correlation_id": c.GetString("correlation_id"),
-		"error":         err,
-		"details":       details,
-	}).Warn("authentication failed")
+	logging.FromContext(c.Request.Context()).Warn("authentication failed",
+		"error", err,
+		"details", details,
+	)
 
 	updateErrorMetrics("auth", c.Request.URL.Path)
 
-	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-		"error": err.Error(),
-		"code":  "UNAUTHORIZED",
-	})
+	respondError(c, apierror.Wrap(apierror.CodeUnauthorized, err))
 }
 
 func handleRateLimitError(c *gin.Context, err error) {
-	logrus.WithFields(logrus.Fields{
-		"correlation_id": c.GetString("correlation_id"),
-		"customer_id":   c.GetString("customer_id"),
-	}).Warn("rate limit exceeded")
+	logging.FromContext(c.Request.Context()).Warn("rate limit exceeded",
+		"customer_id", c.GetString("customer_id"),
+	)
 
 	updateErrorMetrics("rate_limit", c.Request.URL.Path)
 
-	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-		"error": err.Error(),
-		"code":  "RATE_LIMITED",
-	})
+	respondError(c, apierror.Wrap(apierror.CodeRateLimited, err))
 }
 
-func isRateLimited(ctx context.Context, rdb *redis.Client, key string, limit int, window time.Duration) (bool, error) {
-	pipe := rdb.Pipeline()
-	now := time.Now().UnixNano()
-	
-	// Clean old requests
-	pipe.ZRemRangeByScore(ctx, key, "0", fmt.Sprintf("%d", now-window.Nanoseconds()))
-	
-	// Count requests in window
-	pipe.ZCard(ctx, key)
-	
-	// Add current request
-	pipe.ZAdd(ctx, key, &redis.Z{Score: float64(now), Member: now})
-	
-	// Set key expiration
-	pipe.Expire(ctx, key, window)
-	
-	cmders, err := pipe.Exec(ctx)
-	if err != nil {
-		return false, err
-	}
-	
-	// Get request count
-	count := cmders[1].(*redis.IntCmd).Val()
-	return count > int64(limit), nil
+func generateCorrelationID() string {
+	return uuid.NewString()
 }
 
-func generateCorrelationID() string {
-	// Implementation of correlation ID generation
-	return fmt.Sprintf("req_%d", time.Now().UnixNano())
+// validCorrelationID reports whether a client supplied correlation ID can be
+// logged and echoed as is
+func validCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r <= ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
 }
 
 func updateRequestMetrics(c *gin.Context, duration time.Duration) {
@@ -287,9 +320,3 @@ func getStackTrace() string {
 	// Implementation of stack trace collection
 	return "stack trace implementation"
 }
-
-func loadPublicKey(keyData string) (interface{}, error) {
-	// Implementation of public key loading
-	// This would load and parse the RSA public key
-	return nil, nil
-}
\ No newline at end of file
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// MigrationHandler handles HTTP requests for moving wallets between
+// deployments
+type MigrationHandler struct {
+	service service.WalletMigrationService
+}
+
+// migrationRequest is the body of wallet export and export cancellation requests
+type migrationRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// importRequest is the body of wallet import requests
+type importRequest struct {
+	Reason string               `json:"reason" binding:"required"`
+	Export *models.WalletExport `json:"export" binding:"required"`
+}
+
+// activationRequest is the body of imported wallet activation requests
+type activationRequest struct {
+	Reason   string `json:"reason" binding:"required"`
+	Checksum string `json:"checksum" binding:"required"`
+}
+
+// NewMigrationHandler creates a new instance of MigrationHandler
+func NewMigrationHandler(service service.WalletMigrationService) (*MigrationHandler, error) {
+	if service == nil {
+		return nil, errors.New("wallet migration service is required")
+	}
+
+	return &MigrationHandler{service: service}, nil
+}
+
+// ExportWallet handles POST /admin/wallet-migrations/:id/export endpoint.
+// The wallet is frozen on this deployment from then on. Exports must be
+// taken with the data scopes of every class, as an export with fields
+// removed by the classification policy fails its checksum on import.
+func (h *MigrationHandler) ExportWallet(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	var req migrationRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	export, err := h.service.Export(c.Request.Context(), walletID, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondMigrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   export,
+	})
+}
+
+// ImportWallet handles POST /admin/wallet-migrations/imports endpoint. The
+// imported wallet stays frozen until it is activated.
+func (h *MigrationHandler) ImportWallet(c *gin.Context) {
+	var req importRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	migration, err := h.service.Import(c.Request.Context(), req.Export, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondMigrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   migration,
+	})
+}
+
+// ActivateWallet handles POST /admin/wallet-migrations/:id/activate endpoint
+func (h *MigrationHandler) ActivateWallet(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	var req activationRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	migration, err := h.service.Activate(c.Request.Context(), walletID, req.Checksum, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondMigrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   migration,
+	})
+}
+
+// CancelExport handles POST /admin/wallet-migrations/:id/cancel endpoint,
+// unfreezing an exported wallet that was not activated elsewhere
+func (h *MigrationHandler) CancelExport(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	var req migrationRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := h.service.CancelExport(c.Request.Context(), walletID, actorFromContext(c), req.Reason); err != nil {
+		respondMigrationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMigration handles GET /admin/wallet-migrations/:id endpoint
+func (h *MigrationHandler) GetMigration(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	migration, err := h.service.Get(c.Request.Context(), walletID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   migration,
+	})
+}
+
+// respondMigrationError responds with the reason an export was rejected or
+// a step refused as details
+func respondMigrationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidWalletExport):
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	case errors.Is(err, service.ErrWalletMigrationConflict):
+		err = apierror.Wrap(apierror.CodeMigrationConflict, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
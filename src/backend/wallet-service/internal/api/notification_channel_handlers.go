@@ -0,0 +1,229 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// NotificationChannelHandler handles HTTP requests for customer email, SMS
+// and Slack notification channels
+type NotificationChannelHandler struct {
+	service service.NotificationChannelService
+}
+
+// notificationChannelRequest is the body of notification channel create and
+// update requests. Omitted event types mean every notification event; the
+// kind of an existing channel cannot be changed.
+type notificationChannelRequest struct {
+	Kind                   models.NotificationChannelKind `json:"kind"`
+	Destination            string                         `json:"destination" binding:"required"`
+	EventTypes             []string                       `json:"event_types"`
+	LargeTransactionAmount *decimal.Decimal               `json:"large_transaction_amount"`
+	Enabled                *bool                          `json:"enabled"`
+}
+
+// input converts the request into service input, enabling by default
+func (r *notificationChannelRequest) input() service.NotificationChannelInput {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+
+	return service.NotificationChannelInput{
+		Kind:                   r.Kind,
+		Destination:            r.Destination,
+		EventTypes:             r.EventTypes,
+		LargeTransactionAmount: r.LargeTransactionAmount,
+		Enabled:                enabled,
+	}
+}
+
+// invoiceNoticeRequest is the body of POST /admin/notifications/invoices,
+// sent by the invoice service for each invoice it issues
+type invoiceNoticeRequest struct {
+	InvoiceID     uuid.UUID        `json:"invoice_id" binding:"required"`
+	CustomerID    uuid.UUID        `json:"customer_id" binding:"required"`
+	InvoiceNumber string           `json:"invoice_number" binding:"required"`
+	Currency      string           `json:"currency" binding:"required"`
+	TotalAmount   *decimal.Decimal `json:"total_amount" binding:"required"`
+	DueDate       time.Time        `json:"due_date" binding:"required"`
+	URL           string           `json:"url"`
+}
+
+// NewNotificationChannelHandler creates a new instance of
+// NotificationChannelHandler
+func NewNotificationChannelHandler(service service.NotificationChannelService) (*NotificationChannelHandler, error) {
+	if service == nil {
+		return nil, errors.New("notification channel service is required")
+	}
+
+	return &NotificationChannelHandler{service: service}, nil
+}
+
+// CreateChannel handles POST /notification-channels endpoint
+func (h *NotificationChannelHandler) CreateChannel(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req notificationChannelRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	channel, err := h.service.CreateChannel(c.Request.Context(), customerID, req.input())
+	if err != nil {
+		respondNotificationChannelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   channel,
+	})
+}
+
+// ListChannels handles GET /notification-channels endpoint
+func (h *NotificationChannelHandler) ListChannels(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	channels, err := h.service.ListChannels(c.Request.Context(), customerID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   channels,
+	})
+}
+
+// GetChannel handles GET /notification-channels/:id endpoint
+func (h *NotificationChannelHandler) GetChannel(c *gin.Context) {
+	customerID, id, err := notificationChannelIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	channel, err := h.service.GetChannel(c.Request.Context(), customerID, id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   channel,
+	})
+}
+
+// UpdateChannel handles PUT /notification-channels/:id endpoint
+func (h *NotificationChannelHandler) UpdateChannel(c *gin.Context) {
+	customerID, id, err := notificationChannelIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req notificationChannelRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	channel, err := h.service.UpdateChannel(c.Request.Context(), customerID, id, req.input())
+	if err != nil {
+		respondNotificationChannelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   channel,
+	})
+}
+
+// DeleteChannel handles DELETE /notification-channels/:id endpoint
+func (h *NotificationChannelHandler) DeleteChannel(c *gin.Context) {
+	customerID, id, err := notificationChannelIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := h.service.DeleteChannel(c.Request.Context(), customerID, id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// NotifyInvoice handles POST /admin/notifications/invoices endpoint,
+// delivering an issued invoice to the customer's channels and webhooks
+func (h *NotificationChannelHandler) NotifyInvoice(c *gin.Context) {
+	var req invoiceNoticeRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	notice := &models.InvoiceNotice{
+		InvoiceID:     req.InvoiceID,
+		CustomerID:    req.CustomerID,
+		InvoiceNumber: req.InvoiceNumber,
+		Currency:      req.Currency,
+		TotalAmount:   *req.TotalAmount,
+		DueDate:       req.DueDate,
+		URL:           req.URL,
+	}
+	if err := h.service.NotifyInvoice(c.Request.Context(), notice); err != nil {
+		respondNotificationChannelError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// notificationChannelIDs returns the authenticated customer and the channel
+// ID path parameter
+func notificationChannelIDs(c *gin.Context) (uuid.UUID, uuid.UUID, error) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid notification channel ID")
+	}
+
+	return customerID, id, nil
+}
+
+// respondNotificationChannelError responds with the validation failure as
+// details so callers can tell which rule was rejected
+func respondNotificationChannelError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidNotificationChannel) || errors.Is(err, service.ErrInvalidInvoiceNotice) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// NotificationHandler handles HTTP requests for the queue of notifications
+// awaiting redelivery
+type NotificationHandler struct {
+	service service.NotificationQueueService
+}
+
+// NewNotificationHandler creates a new instance of NotificationHandler
+func NewNotificationHandler(service service.NotificationQueueService) (*NotificationHandler, error) {
+	if service == nil {
+		return nil, errors.New("notification queue service is required")
+	}
+
+	return &NotificationHandler{service: service}, nil
+}
+
+// GetBacklog handles GET /admin/notifications endpoint, counting the queued
+// notifications and listing the oldest, optionally filtered by status and
+// event type
+func (h *NotificationHandler) GetBacklog(c *gin.Context) {
+	limit, err := deliveryLimit(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	backlog, err := h.service.Backlog(c.Request.Context(),
+		models.QueuedNotificationStatus(c.Query("status")), c.Query("type"), limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBacklogQuery) {
+			err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+		}
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   backlog,
+	})
+}
@@ -0,0 +1,2436 @@
+package api
+
+//go:generate go run ../../cmd/openapigen -out openapi.json
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3" // v0.118.0
+	"github.com/getkin/kin-openapi/openapi3gen"
+	"github.com/gin-gonic/gin"             // v1.9.1
+	"github.com/google/uuid"               // v1.3.0
+	"github.com/shopspring/decimal"        // v1.3.1
+	swaggerFiles "github.com/swaggo/files" // v1.0.1
+
+	"internal/apierror"
+	"internal/health"
+	"internal/models"
+	"internal/runbook"
+	"internal/service"
+	"internal/slo"
+)
+
+// openAPIDocument is the generated OpenAPI document served by the API.
+// Regenerate it with go generate after changing a route or handler type.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// swaggerInitializer points the bundled Swagger UI at the served document
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "../openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// bearerAuth is the name of the JWT security scheme of the document
+const bearerAuth = "bearerAuth"
+
+// Header usage of an operation
+const (
+	headerNone = iota
+	headerOptional
+	headerRequired
+)
+
+// apiOperation documents one REST endpoint of the OpenAPI document
+type apiOperation struct {
+	id      string
+	method  string
+	path    string
+	tag     string
+	summary string
+	// description documents behavior beyond the summary, such as a protocol
+	description string
+	// role is the JWT role the endpoint requires, if any
+	role  string
+	query []*openapi3.Parameter
+	// idempotencyKey is how the endpoint uses the Idempotency-Key header
+	idempotencyKey int
+	// request is the JSON body type, nil when the endpoint takes no body
+	request interface{}
+	// status is the success status and response the type of its data; a nil
+	// response documents an empty body
+	status   int
+	response interface{}
+	// meta lists the integer fields of the response meta object
+	meta []string
+	// warnings documents the credit limit warnings in the meta of debits
+	warnings bool
+	// currencyFormats documents the display formats of the response's
+	// currencies in its meta
+	currencyFormats bool
+	// events documents a server-sent event stream instead of a JSON body
+	events bool
+	// pdf documents a PDF document instead of a JSON body
+	pdf bool
+	// signed documents an endpoint authenticated by a provider's webhook
+	// signature instead of a JWT
+	signed bool
+	// examples are request and response pairs that the contract tests replay
+	examples []apiExample
+}
+
+// apiExample documents a request and the response it gets. The contract
+// tests replay it against a fresh service with a USD wallet holding 100.00
+// as {id}. IDs and timestamps in the response stand for any value.
+type apiExample struct {
+	name    string
+	summary string
+	// request is the JSON body, empty when the endpoint takes none
+	request string
+	// status is the response status; a status other than the operation's
+	// documents an error response
+	status   int
+	response string
+}
+
+// usdFormatExample is the display format of USD in the meta of examples
+const usdFormatExample = `"currency_formats":{"USD":{"code":"USD","symbol":"$","decimal_places":2,` +
+	`"symbol_position":"before","symbol_spacing":false,"decimal_separator":".","group_separator":",",` +
+	`"grouping":[3],"locale":"en-US"}}`
+
+// oneOf documents a response whose data is one of several types
+type oneOf []interface{}
+
+// anyJSON documents data of no fixed shape
+type anyJSON struct{}
+
+// Query parameters shared by list endpoints
+var (
+	pageQuery     = intQuery("page", "Page number, starting at 1")
+	pageSizeQuery = intQuery("page_size", "Items per page, at most 100")
+	// deliveryLimitQuery bounds webhook delivery logs
+	deliveryLimitQuery = intQuery("limit", "Delivery attempts to return, 50 by default and at most 200")
+)
+
+// apiOperations documents every REST endpoint under /api/v1. Keep it in step
+// with SetupRouter; the OpenAPI tests fail on undocumented routes.
+var apiOperations = []apiOperation{
+	{
+		id:              "getBalance",
+		method:          http.MethodGet,
+		path:            walletsPath + "/:id/balance",
+		tag:             "Wallets",
+		summary:         "Get the current balance, or the historical balance at as_of",
+		query:           []*openapi3.Parameter{timeQuery("as_of", "Return the balance at this RFC 3339 timestamp")},
+		status:          http.StatusOK,
+		response:        oneOf{balanceResponse{}, models.HistoricalBalance{}},
+		currencyFormats: true,
+		examples: []apiExample{{
+			name:    "current",
+			summary: "Current balance",
+			status:  http.StatusOK,
+			response: `{"status":"success","data":{"balance":"100","currency":"USD","credit_limit":"0",` +
+				`"available_balance":"100","credit_utilization":"0","last_activity_at":"2024-01-01T00:00:00Z"},` +
+				`"meta":{` + usdFormatExample + `}}`,
+		}},
+	},
+	{
+		id:      "getWalletOverview",
+		method:  http.MethodGet,
+		path:    walletsPath + "/:id/overview",
+		tag:     "Wallets",
+		summary: "Get the balance with the newest transactions, read from one consistent snapshot",
+		description: "The balance and the transactions are read together, so a transaction posted meanwhile shows in " +
+			"both or in neither. as_of is the time of the snapshot.",
+		query:           []*openapi3.Parameter{intQuery("limit", "Transactions to return, 10 by default and at most 100")},
+		status:          http.StatusOK,
+		response:        walletOverviewResponse{},
+		currencyFormats: true,
+	},
+	{
+		id:      "processTransaction",
+		method:  http.MethodPost,
+		path:    walletsPath + "/:id/transactions",
+		tag:     "Wallets",
+		summary: "Credit, debit or refund a wallet",
+		description: "A past effective_at backdates the transaction into an open billing period; it requires the admin role. " +
+			"Transactions into closed billing periods are refused with BILLING_PERIOD_CLOSED.",
+		idempotencyKey:  headerRequired,
+		request:         transactionRequest{},
+		status:          http.StatusCreated,
+		response:        models.Transaction{},
+		warnings:        true,
+		currencyFormats: true,
+		examples: []apiExample{
+			{
+				name:    "credit",
+				summary: "Credit a card top-up",
+				request: `{"type":"CREDIT","amount":25.5,"currency":"USD","description":"Top-up by card",` +
+					`"reference_id":"order-1001","metadata":{"channel":"dashboard"}}`,
+				status: http.StatusCreated,
+				response: `{"status":"success","data":{"id":"3f2b8c1e-5a4d-4e6f-8b7a-9c0d1e2f3a4b",` +
+					`"wallet_id":"8d7c6b5a-4e3f-4a2b-9c1d-0e9f8a7b6c5d","type":0,"status":0,"amount":25.5,` +
+					`"currency":"USD","description":"Top-up by card","reference_id":"order-1001",` +
+					`"metadata":{"channel":"dashboard"},"created_at":"2024-01-01T00:00:00Z",` +
+					`"updated_at":"2024-01-01T00:00:00Z"},"meta":{` + usdFormatExample + `}}`,
+			},
+			{
+				name:     "insufficientBalance",
+				summary:  "Debit more than the balance",
+				request:  `{"type":"DEBIT","amount":150,"currency":"USD","description":"API usage"}`,
+				status:   http.StatusUnprocessableEntity,
+				response: `{"status":"error","code":"INSUFFICIENT_BALANCE","error":"Insufficient wallet balance"}`,
+			},
+		},
+	},
+	{
+		id:      "getTransactions",
+		method:  http.MethodGet,
+		path:    walletsPath + "/:id/transactions",
+		tag:     "Wallets",
+		summary: "List a wallet's transactions",
+		query: []*openapi3.Parameter{
+			pageQuery,
+			pageSizeQuery,
+			timeQuery("from_date", "Only transactions effective at or after this RFC 3339 timestamp; without it only the transactions of the query horizon, 90 days by default, are listed"),
+			timeQuery("to_date", "Only transactions effective before this RFC 3339 timestamp"),
+			stringQuery("metadata_key", "Only transactions whose metadata has this key"),
+			stringQuery("metadata_value", "Only transactions whose metadata_key has this value"),
+		},
+		status:          http.StatusOK,
+		response:        []*models.Transaction{},
+		meta:            []string{"total", "page", "page_size", "total_pages"},
+		currencyFormats: true,
+	},
+	{
+		id:              "searchTransactions",
+		method:          http.MethodGet,
+		path:            transactionsPath,
+		tag:             "Admin",
+		role:            adminRole + " or " + supportRole,
+		summary:         "Find transactions by reference ID across all wallets, with their wallet and customer",
+		query:           []*openapi3.Parameter{stringQuery("reference_id", "Reference ID to look up")},
+		status:          http.StatusOK,
+		response:        []*models.TransactionMatch{},
+		meta:            []string{"total"},
+		currencyFormats: true,
+	},
+	{
+		id:       "getWalletHealth",
+		method:   http.MethodGet,
+		path:     walletsPath + "/:id/health",
+		tag:      "Wallets",
+		summary:  "Get a wallet's balance status, recent transaction failure rate and credit limit utilization",
+		status:   http.StatusOK,
+		response: models.WalletHealth{},
+	},
+	{
+		id:       "getWalletMergeRedirect",
+		method:   http.MethodGet,
+		path:     walletsPath + "/:id/merge",
+		tag:      "Wallets",
+		summary:  "Get the merge redirecting a duplicate wallet to the wallet that replaced it",
+		status:   http.StatusOK,
+		response: models.WalletMerge{},
+	},
+	{
+		id:      "streamWalletEvents",
+		method:  http.MethodGet,
+		path:    walletsPath + "/:id/events",
+		tag:     "Wallets",
+		summary: "Stream balance and transaction updates as server-sent events",
+		status:  http.StatusOK,
+		events:  true,
+	},
+	{
+		id:      "connectWebSocket",
+		method:  http.MethodGet,
+		path:    wsPath,
+		tag:     "Wallets",
+		summary: "Receive transaction lifecycle events of subscribed wallets over a WebSocket",
+		description: "Send {\"type\": \"subscribe\", \"wallet_id\": ...} or unsubscribe messages to choose wallets; " +
+			"each is acknowledged as subscribed, unsubscribed or an error with a code. Events are transaction.created, " +
+			"transaction.completed and transaction.failed with the transaction ID and status as data. Client messages " +
+			"are rate limited per connection, and connections are closed with code 1001 when the server shuts down.",
+		status: http.StatusSwitchingProtocols,
+	},
+	{
+		id:       "getWalletSettings",
+		method:   http.MethodGet,
+		path:     walletsPath + "/:id/settings",
+		tag:      "Wallets",
+		summary:  "Get a wallet's threshold, credit limit, notification preferences and auto top-up toggle",
+		status:   http.StatusOK,
+		response: models.WalletSettings{},
+	},
+	{
+		id:      "updateWalletSettings",
+		method:  http.MethodPatch,
+		path:    walletsPath + "/:id/settings",
+		tag:     "Wallets",
+		summary: "Update wallet settings; only operators may set the credit limit",
+		description: "Omitted fields are left unchanged. Changing anything but the credit limit requires the settings " +
+			"version last read, and a stale version is refused with CONCURRENT_MODIFICATION. Raising the credit limit " +
+			"is refused with APPROVAL_REQUIRED; it is requested as a settings change instead.",
+		request:  walletSettingsRequest{},
+		status:   http.StatusOK,
+		response: models.WalletSettings{},
+	},
+	{
+		id:       "getBalanceThresholds",
+		method:   http.MethodGet,
+		path:     walletsPath + "/:id/balance-thresholds",
+		tag:      "Wallets",
+		summary:  "Get a wallet's warning and critical balance thresholds and whether each has triggered",
+		status:   http.StatusOK,
+		response: models.BalanceThresholds{},
+	},
+	{
+		id:      "updateBalanceThresholds",
+		method:  http.MethodPut,
+		path:    walletsPath + "/:id/balance-thresholds",
+		tag:     "Wallets",
+		summary: "Replace a wallet's balance thresholds",
+		description: "Percentages are of the reference balance, and omitted ones take the service defaults of a " +
+			"warning below 20% and a critical alert below 5%. A level publishes wallet.balance_warning or " +
+			"wallet.balance_critical once as the balance falls below it, and alerts again only after the balance " +
+			"rose above its reset percentage. Both levels are re-armed and checked against the current balance.",
+		request:  balanceThresholdRequest{},
+		status:   http.StatusOK,
+		response: models.BalanceThresholds{},
+	},
+	{
+		id:      "deleteBalanceThresholds",
+		method:  http.MethodDelete,
+		path:    walletsPath + "/:id/balance-thresholds",
+		tag:     "Wallets",
+		summary: "Remove a wallet's balance thresholds, stopping its threshold alerts",
+		status:  http.StatusNoContent,
+	},
+	{
+		id:       "getBalanceAlerts",
+		method:   http.MethodGet,
+		path:     walletsPath + "/:id/balance-alerts",
+		tag:      "Wallets",
+		summary:  "List a wallet's balance alert thresholds, highest first, and whether each has triggered",
+		status:   http.StatusOK,
+		response: []*models.BalanceAlertThreshold{},
+	},
+	{
+		id:      "setBalanceAlerts",
+		method:  http.MethodPut,
+		path:    walletsPath + "/:id/balance-alerts",
+		tag:     "Wallets",
+		summary: "Replace a wallet's balance alert thresholds",
+		description: "Up to 10 thresholds, each an ABSOLUTE balance or a USAGE_PERCENT of the wallet's average " +
+			"monthly debits over the last 90 days, sent on its own EMAIL, SMS or WEBHOOK channels. A threshold " +
+			"publishes wallet.balance_alert once as the balance falls below it and re-arms when a recharge brings " +
+			"the balance back up to it. EMAIL and SMS are only sent to customers that consented to them. An empty " +
+			"list removes the wallet's alerts.",
+		request:  balanceAlertsRequest{},
+		status:   http.StatusOK,
+		response: []*models.BalanceAlertThreshold{},
+	},
+	{
+		id:       "recordPayment",
+		method:   http.MethodPost,
+		path:     walletsPath + "/:id/transactions/:transaction_id/payment",
+		tag:      "Refunds",
+		summary:  "Link a top-up to the provider payment that funded it",
+		request:  paymentRequest{},
+		status:   http.StatusCreated,
+		response: models.ProviderPayment{},
+	},
+	{
+		id:             "refundToSource",
+		method:         http.MethodPost,
+		path:           walletsPath + "/:id/transactions/:transaction_id/refund-to-source",
+		tag:            "Refunds",
+		summary:        "Refund a top-up to its original payment method",
+		idempotencyKey: headerOptional,
+		request:        refundRequest{},
+		status:         http.StatusAccepted,
+		response:       models.ProviderRefund{},
+	},
+	{
+		id:       "getRefund",
+		method:   http.MethodGet,
+		path:     walletsPath + "/:id/refunds/:refund_id",
+		tag:      "Refunds",
+		summary:  "Get a refund to source",
+		status:   http.StatusOK,
+		response: models.ProviderRefund{},
+	},
+	{
+		id:      "getCapabilities",
+		method:  http.MethodGet,
+		path:    capabilitiesPath,
+		tag:     "Status",
+		summary: "Report whether each capability of the service is ok, degraded or down",
+		description: "Capabilities are transactions, balances, webhooks, live_updates, refunds and recurring_debits. " +
+			"While a capability is not ok, responses of the endpoints relying on it carry an X-Degraded-Capabilities " +
+			"header listing name=status pairs, e.g. webhooks=down.",
+		status:   http.StatusOK,
+		response: health.CapabilityMatrix{},
+	},
+	{
+		id:       "getCustomerSettings",
+		method:   http.MethodGet,
+		path:     customerPath,
+		tag:      "Customer",
+		summary:  "Get the customer's settings",
+		status:   http.StatusOK,
+		response: models.CustomerSettings{},
+	},
+	{
+		id:       "updateCustomerSettings",
+		method:   http.MethodPatch,
+		path:     customerPath,
+		tag:      "Customer",
+		summary:  "Set the timezone of the customer's digests and daily aggregates",
+		request:  customerSettingsRequest{},
+		status:   http.StatusOK,
+		response: models.CustomerSettings{},
+	},
+	{
+		id:      "listCustomerWallets",
+		method:  http.MethodGet,
+		path:    customersPath + "/:id/wallets",
+		tag:     "Customer",
+		summary: "List a customer's wallets, oldest first",
+		description: "Customers may only list their own wallets; the admin and support roles may list any " +
+			"customer's.",
+		status:   http.StatusOK,
+		response: []*models.Wallet{},
+	},
+	{
+		id:      "getCustomerBalance",
+		method:  http.MethodGet,
+		path:    customersPath + "/:id/balance",
+		tag:     "Customer",
+		summary: "Get the balance of each of a customer's wallets and their total in a reporting currency",
+		description: "Balances are converted at the configured exchange rates. Wallets in a currency without a " +
+			"rate are listed unconverted, left out of the total and named in unconverted_currencies. Customers may " +
+			"only read their own balance; the admin and support roles may read any customer's.",
+		query: []*openapi3.Parameter{
+			stringQuery("currency", "Reporting currency of the total, defaulting to the configured one"),
+		},
+		status:   http.StatusOK,
+		response: models.CustomerBalance{},
+	},
+	{
+		id:       "getDigestSubscription",
+		method:   http.MethodGet,
+		path:     digestPath,
+		tag:      "Customer",
+		summary:  "Get the customer's activity digest subscription",
+		status:   http.StatusOK,
+		response: models.DigestSubscription{},
+	},
+	{
+		id:       "updateDigestSubscription",
+		method:   http.MethodPut,
+		path:     digestPath,
+		tag:      "Customer",
+		summary:  "Opt in or out of activity digests",
+		request:  digestRequest{},
+		status:   http.StatusOK,
+		response: models.DigestSubscription{},
+	},
+	{
+		id:       "listConsents",
+		method:   http.MethodGet,
+		path:     consentsPath,
+		tag:      "Customer",
+		summary:  "Get the customer's notification consent for every channel",
+		status:   http.StatusOK,
+		response: []*models.CustomerConsent{},
+	},
+	{
+		id:       "listConsentHistory",
+		method:   http.MethodGet,
+		path:     consentsPath + "/history",
+		tag:      "Customer",
+		summary:  "List the customer's consent changes, newest first",
+		status:   http.StatusOK,
+		response: []*models.CustomerConsent{},
+	},
+	{
+		id:       "updateConsent",
+		method:   http.MethodPut,
+		path:     consentsPath + "/:channel",
+		tag:      "Customer",
+		summary:  "Grant or withdraw consent to notifications on a channel",
+		request:  consentRequest{},
+		status:   http.StatusOK,
+		response: models.CustomerConsent{},
+	},
+	{
+		id:      "listAccessLog",
+		method:  http.MethodGet,
+		path:    accessLogPath,
+		tag:     "Customer",
+		summary: "List who viewed the customer's balances or transactions, newest first",
+		description: "Operators, support staff and internal services are shown by kind only; the accessor is shown " +
+			"for the customer's own API keys. Exports of every customer's transactions are listed for each customer.",
+		query: []*openapi3.Parameter{
+			timeQuery("from", "Only accesses at or after this RFC 3339 timestamp"),
+			timeQuery("to", "Only accesses before this RFC 3339 timestamp"),
+			stringQuery("action", "Only accesses of this kind",
+				string(models.DataAccessBalanceViewed), string(models.DataAccessTransactionsViewed),
+				string(models.DataAccessTransactionsExported)),
+			pageQuery,
+			pageSizeQuery,
+		},
+		status:   http.StatusOK,
+		response: []*models.CustomerDataAccess{},
+		meta:     []string{"page", "page_size"},
+	},
+	{
+		id:       "createWebhook",
+		method:   http.MethodPost,
+		path:     webhooksPath,
+		tag:      "Webhooks",
+		summary:  "Register a webhook endpoint",
+		request:  webhookRequest{},
+		status:   http.StatusCreated,
+		response: models.WebhookSubscription{},
+	},
+	{
+		id:       "listWebhooks",
+		method:   http.MethodGet,
+		path:     webhooksPath,
+		tag:      "Webhooks",
+		summary:  "List the customer's webhook subscriptions",
+		status:   http.StatusOK,
+		response: []*models.WebhookSubscription{},
+	},
+	{
+		id:       "getWebhook",
+		method:   http.MethodGet,
+		path:     webhooksPath + "/:id",
+		tag:      "Webhooks",
+		summary:  "Get a webhook subscription",
+		status:   http.StatusOK,
+		response: models.WebhookSubscription{},
+	},
+	{
+		id:       "updateWebhook",
+		method:   http.MethodPut,
+		path:     webhooksPath + "/:id",
+		tag:      "Webhooks",
+		summary:  "Replace a webhook subscription",
+		request:  webhookRequest{},
+		status:   http.StatusOK,
+		response: models.WebhookSubscription{},
+	},
+	{
+		id:      "deleteWebhook",
+		method:  http.MethodDelete,
+		path:    webhooksPath + "/:id",
+		tag:     "Webhooks",
+		summary: "Delete a webhook subscription",
+		status:  http.StatusNoContent,
+	},
+	{
+		id:       "listWebhookDeliveries",
+		method:   http.MethodGet,
+		path:     webhooksPath + "/:id/deliveries",
+		tag:      "Webhooks",
+		summary:  "List the most recent delivery attempts of a webhook subscription, newest first",
+		query:    []*openapi3.Parameter{deliveryLimitQuery},
+		status:   http.StatusOK,
+		response: []*models.WebhookDelivery{},
+	},
+	{
+		id:      "createWalletWebhook",
+		method:  http.MethodPost,
+		path:    walletsPath + "/:id/webhooks",
+		tag:     "Webhooks",
+		summary: "Register a webhook endpoint receiving only the wallet's events",
+		description: "Wallet webhooks are managed through the wallet, separately from the customer's webhooks, " +
+			"and have their own signing secret. wallet_ids cannot be set on them.",
+		request:  webhookRequest{},
+		status:   http.StatusCreated,
+		response: models.WebhookSubscription{},
+	},
+	{
+		id:       "listWalletWebhooks",
+		method:   http.MethodGet,
+		path:     walletsPath + "/:id/webhooks",
+		tag:      "Webhooks",
+		summary:  "List the wallet's webhook subscriptions",
+		status:   http.StatusOK,
+		response: []*models.WebhookSubscription{},
+	},
+	{
+		id:       "getWalletWebhook",
+		method:   http.MethodGet,
+		path:     walletsPath + "/:id/webhooks/:webhook_id",
+		tag:      "Webhooks",
+		summary:  "Get a wallet webhook subscription",
+		status:   http.StatusOK,
+		response: models.WebhookSubscription{},
+	},
+	{
+		id:       "updateWalletWebhook",
+		method:   http.MethodPut,
+		path:     walletsPath + "/:id/webhooks/:webhook_id",
+		tag:      "Webhooks",
+		summary:  "Replace a wallet webhook subscription",
+		request:  webhookRequest{},
+		status:   http.StatusOK,
+		response: models.WebhookSubscription{},
+	},
+	{
+		id:      "deleteWalletWebhook",
+		method:  http.MethodDelete,
+		path:    walletsPath + "/:id/webhooks/:webhook_id",
+		tag:     "Webhooks",
+		summary: "Delete a wallet webhook subscription",
+		status:  http.StatusNoContent,
+	},
+	{
+		id:       "listWalletWebhookDeliveries",
+		method:   http.MethodGet,
+		path:     walletsPath + "/:id/webhooks/:webhook_id/deliveries",
+		tag:      "Webhooks",
+		summary:  "List the most recent delivery attempts of a wallet webhook subscription, newest first",
+		query:    []*openapi3.Parameter{deliveryLimitQuery},
+		status:   http.StatusOK,
+		response: []*models.WebhookDelivery{},
+	},
+	{
+		id:      "createNotificationChannel",
+		method:  http.MethodPost,
+		path:    channelsPath,
+		tag:     "Notifications",
+		summary: "Register an email, SMS or Slack destination for notifications",
+		description: "Channels receive low balance alerts, dunning notices, issued invoices and, from " +
+			"large_transaction_amount, completed transactions, rendered from the configured templates. Email and " +
+			"SMS channels are only available where the deployment configured a mail server or SMS gateway.",
+		request:  notificationChannelRequest{},
+		status:   http.StatusCreated,
+		response: models.NotificationChannel{},
+	},
+	{
+		id:       "listNotificationChannels",
+		method:   http.MethodGet,
+		path:     channelsPath,
+		tag:      "Notifications",
+		summary:  "List the customer's notification channels",
+		status:   http.StatusOK,
+		response: []*models.NotificationChannel{},
+	},
+	{
+		id:       "getNotificationChannel",
+		method:   http.MethodGet,
+		path:     channelsPath + "/:id",
+		tag:      "Notifications",
+		summary:  "Get a notification channel",
+		status:   http.StatusOK,
+		response: models.NotificationChannel{},
+	},
+	{
+		id:       "updateNotificationChannel",
+		method:   http.MethodPut,
+		path:     channelsPath + "/:id",
+		tag:      "Notifications",
+		summary:  "Replace the destination and rules of a notification channel, keeping its kind",
+		request:  notificationChannelRequest{},
+		status:   http.StatusOK,
+		response: models.NotificationChannel{},
+	},
+	{
+		id:      "deleteNotificationChannel",
+		method:  http.MethodDelete,
+		path:    channelsPath + "/:id",
+		tag:     "Notifications",
+		summary: "Delete a notification channel",
+		status:  http.StatusNoContent,
+	},
+	{
+		id:       "createRecurringDebit",
+		method:   http.MethodPost,
+		path:     recurringPath,
+		tag:      "Recurring",
+		summary:  "Schedule a recurring debit of one of the customer's wallets",
+		request:  recurringDebitRequest{},
+		status:   http.StatusCreated,
+		response: models.RecurringDebit{},
+	},
+	{
+		id:       "listRecurringDebits",
+		method:   http.MethodGet,
+		path:     recurringPath,
+		tag:      "Recurring",
+		summary:  "List the customer's recurring debits",
+		status:   http.StatusOK,
+		response: []*models.RecurringDebit{},
+	},
+	{
+		id:       "getRecurringDebit",
+		method:   http.MethodGet,
+		path:     recurringPath + "/:id",
+		tag:      "Recurring",
+		summary:  "Get a recurring debit",
+		status:   http.StatusOK,
+		response: models.RecurringDebit{},
+	},
+	{
+		id:       "cancelRecurringDebit",
+		method:   http.MethodDelete,
+		path:     recurringPath + "/:id",
+		tag:      "Recurring",
+		summary:  "Cancel a recurring debit, keeping it and its runs for the record",
+		status:   http.StatusOK,
+		response: models.RecurringDebit{},
+	},
+	{
+		id:       "listRecurringDebitRuns",
+		method:   http.MethodGet,
+		path:     recurringPath + "/:id/runs",
+		tag:      "Recurring",
+		summary:  "List the latest runs of a recurring debit, newest first",
+		status:   http.StatusOK,
+		response: []*models.RecurringDebitRun{},
+	},
+	{
+		id:       "listRunbookActions",
+		method:   http.MethodGet,
+		path:     adminPath + "/runbook",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "List the operator runbook actions",
+		status:   http.StatusOK,
+		response: []runbook.Action{},
+	},
+	{
+		id:       "executeRunbookAction",
+		method:   http.MethodPost,
+		path:     adminPath + "/runbook/:action",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Execute a runbook action, recording it in the audit log",
+		request:  runbookRequest{},
+		status:   http.StatusOK,
+		response: anyJSON{},
+	},
+	{
+		id:       "listAuditLog",
+		method:   http.MethodGet,
+		path:     adminPath + "/audit",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "List the operator audit log",
+		query:    []*openapi3.Parameter{pageQuery, pageSizeQuery},
+		status:   http.StatusOK,
+		response: []*models.OperatorAction{},
+		meta:     []string{"page", "page_size"},
+	},
+	{
+		id:       "listBillingPeriods",
+		method:   http.MethodGet,
+		path:     periodsPath,
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "List the latest closed or reopened billing periods, newest first",
+		status:   http.StatusOK,
+		response: []*models.BillingPeriod{},
+	},
+	{
+		id:       "getBillingPeriod",
+		method:   http.MethodGet,
+		path:     periodsPath + "/:period",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get the close state of a YYYY-MM billing period",
+		status:   http.StatusOK,
+		response: models.BillingPeriod{},
+	},
+	{
+		id:       "closeBillingPeriod",
+		method:   http.MethodPost,
+		path:     periodsPath + "/:period/close",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Close an ended billing period to postings, recording it in the audit log",
+		request:  billingPeriodRequest{},
+		status:   http.StatusOK,
+		response: models.BillingPeriod{},
+	},
+	{
+		id:       "reopenBillingPeriod",
+		method:   http.MethodPost,
+		path:     periodsPath + "/:period/reopen",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Reopen a closed billing period to postings, recording it in the audit log",
+		request:  billingPeriodRequest{},
+		status:   http.StatusOK,
+		response: models.BillingPeriod{},
+	},
+	{
+		id:       "importWallet",
+		method:   http.MethodPost,
+		path:     migrationsPath + "/imports",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Import a wallet exported from another deployment, frozen until it is activated",
+		request:  importRequest{},
+		status:   http.StatusCreated,
+		response: models.WalletMigration{},
+	},
+	{
+		id:       "getWalletMigration",
+		method:   http.MethodGet,
+		path:     migrationsPath + "/:id",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get the latest move of a wallet between deployments",
+		status:   http.StatusOK,
+		response: models.WalletMigration{},
+	},
+	{
+		id:       "exportWallet",
+		method:   http.MethodPost,
+		path:     migrationsPath + "/:id/export",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Export a wallet with its open holds and recent history, freezing it on this deployment",
+		request:  migrationRequest{},
+		status:   http.StatusOK,
+		response: models.WalletExport{},
+	},
+	{
+		id:       "activateWallet",
+		method:   http.MethodPost,
+		path:     migrationsPath + "/:id/activate",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Activate an imported wallet with the checksum of its export",
+		request:  activationRequest{},
+		status:   http.StatusOK,
+		response: models.WalletMigration{},
+	},
+	{
+		id:      "cancelWalletExport",
+		method:  http.MethodPost,
+		path:    migrationsPath + "/:id/cancel",
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Unfreeze an exported wallet whose import was abandoned",
+		request: migrationRequest{},
+		status:  http.StatusNoContent,
+	},
+	{
+		id:      "mergeWallets",
+		method:  http.MethodPost,
+		path:    mergesPath,
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Merge a duplicate wallet into the surviving wallet of the same customer and currency",
+		description: "The source wallet's balance and transactions move to the target wallet. The source then refuses " +
+			"postings with WALLET_MERGED and its merge record, at GET /wallets/{id}/merge, points to the target. " +
+			"Sources with pending refunds or scheduled recurring debits cannot be merged.",
+		request:  mergeRequest{},
+		status:   http.StatusCreated,
+		response: models.WalletMerge{},
+	},
+	{
+		id:       "getWalletMerge",
+		method:   http.MethodGet,
+		path:     mergesPath + "/:id",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get a wallet merge",
+		status:   http.StatusOK,
+		response: models.WalletMerge{},
+	},
+	{
+		id:      "reverseWalletMerge",
+		method:  http.MethodPost,
+		path:    mergesPath + "/:id/reverse",
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Move a merge's balance and transactions back to the source wallet within the reversal window",
+		description: "Transactions posted to the target since the merge stay on the target. The reversal is refused " +
+			"when the target no longer holds the merged balance.",
+		request:  mergeReversalRequest{},
+		status:   http.StatusOK,
+		response: models.WalletMerge{},
+	},
+	{
+		id:      "provisionWallets",
+		method:  http.MethodPost,
+		path:    provisioningPath,
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Queue a job provisioning a wallet for each customer of a batch",
+		description: "The batch is a JSON list of customer IDs, or a text/csv body of customer_id and optional currency " +
+			"columns with the currency query parameter as default. Customers already holding a wallet in the currency " +
+			"are reported as existing. The job's progress is read from GET /jobs/{id} and its per-row results from " +
+			"GET /jobs/{id}/result; a retried job resumes after the rows already provisioned.",
+		query:    []*openapi3.Parameter{stringQuery("currency", "Currency of CSV rows without one")},
+		request:  walletBatchRequest{},
+		status:   http.StatusAccepted,
+		response: models.ReportJob{},
+	},
+	{
+		id:      "submitBulkCredit",
+		method:  http.MethodPost,
+		path:    bulkCreditsPath,
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Queue a job crediting each wallet of a bulk operation the same amount",
+		description: "The wallets are a JSON list of wallet IDs, or a text/csv body of a wallet_id column with the amount, " +
+			"currency and description query parameters. Credits are posted in chunks at a capped rate; wallets that " +
+			"cannot be credited are reported as failed without holding up the rest. The job's progress is read from " +
+			"GET /jobs/{id} and the per-row results from GET /admin/bulk-credits/{id}.",
+		query: []*openapi3.Parameter{
+			stringQuery("amount", "Amount of CSV operations"),
+			stringQuery("currency", "Currency of CSV operations"),
+			stringQuery("description", "Description of CSV operations"),
+		},
+		request:  bulkCreditRequest{},
+		status:   http.StatusAccepted,
+		response: models.BulkCredit{},
+	},
+	{
+		id:       "getBulkCredit",
+		method:   http.MethodGet,
+		path:     bulkCreditsPath + "/:id",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get a bulk credit operation with the outcome of each wallet so far",
+		status:   http.StatusOK,
+		response: models.BulkCreditDetails{},
+	},
+	{
+		id:      "rollbackBulkCredit",
+		method:  http.MethodPost,
+		path:    bulkCreditsPath + "/:id/rollback",
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Queue a job reversing the posted credits of a bulk operation",
+		description: "Allowed once the credit job has finished, successful or not, and once per operation. Wallets " +
+			"that have since spent the credit beyond their credit limit keep it and are reported as NOT_REVERSED.",
+		status:   http.StatusAccepted,
+		response: models.BulkCredit{},
+	},
+	{
+		id:       "getIncident",
+		method:   http.MethodGet,
+		path:     incidentPath,
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get the active incident pausing transactions",
+		status:   http.StatusOK,
+		response: models.Incident{},
+	},
+	{
+		id:      "pauseTransactions",
+		method:  http.MethodPut,
+		path:    incidentPath,
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Start an incident pausing transaction types, or change the active one",
+		description: "Transactions of the paused types are refused with TRANSACTIONS_PAUSED, whichever caller posts " +
+			"them, including recurring debits and usage chargebacks, unless their source metadata is one of the " +
+			"allowed sources. Other replicas apply the change within the refresh interval. An incident with " +
+			"resume_at resumes by itself then, at most 72 hours ahead by default.",
+		request:  incidentRequest{},
+		status:   http.StatusOK,
+		response: models.Incident{},
+	},
+	{
+		id:       "resumeTransactions",
+		method:   http.MethodPost,
+		path:     incidentPath + "/resume",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Resume the active incident, letting every transaction through again",
+		request:  incidentResumeRequest{},
+		status:   http.StatusOK,
+		response: models.Incident{},
+	},
+	{
+		id:      "createExport",
+		method:  http.MethodPost,
+		path:    exportsPath,
+		tag:     "Admin",
+		role:    adminRole + " or " + analyticsRole,
+		summary: "Queue an export of the transactions created in [from, to) to object storage",
+		description: "Exports are written as CSV or Parquet, optionally only for one customer or transaction type, " +
+			"and cover at most 366 days. GET /exports/{id} reports progress and, once the file is written, a signed " +
+			"download link.",
+		request:  exportRequest{},
+		status:   http.StatusAccepted,
+		response: models.TransactionExport{},
+	},
+	{
+		id:       "getExport",
+		method:   http.MethodGet,
+		path:     exportsPath + "/:id",
+		tag:      "Admin",
+		role:     adminRole + " or " + analyticsRole,
+		summary:  "Get the status of an export, with a freshly signed download link once it succeeded",
+		status:   http.StatusOK,
+		response: models.TransactionExport{},
+	},
+	{
+		id:      "getNotificationBacklog",
+		method:  http.MethodGet,
+		path:    notificationPath,
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Count the notifications awaiting redelivery to the notification pipeline and list the oldest",
+		description: "Notifications the pipeline could not take are retried with backoff until delivered or expired. " +
+			"Low balance and credit limit warnings expire sooner than digests and dunning notices.",
+		query: []*openapi3.Parameter{
+			stringQuery("status", "Only notifications in this status",
+				string(models.QueuedNotificationPending), string(models.QueuedNotificationExpired)),
+			stringQuery("type", "Only notifications of this event type"),
+			intQuery("limit", "Notifications to list, 50 by default and at most 200"),
+		},
+		status:   http.StatusOK,
+		response: models.NotificationBacklog{},
+	},
+	{
+		id:      "notifyInvoice",
+		method:  http.MethodPost,
+		path:    notificationPath + "/invoices",
+		tag:     "Admin",
+		role:    adminRole + " or " + serviceRole,
+		summary: "Deliver an issued invoice to the customer",
+		description: "Called by the invoice service for each invoice it issues. The invoice is published as an " +
+			"invoice.issued event to the customer's notification channels and webhooks.",
+		request: invoiceNoticeRequest{},
+		status:  http.StatusAccepted,
+	},
+	{
+		id:      "issueInvoice",
+		method:  http.MethodPost,
+		path:    invoicesPath,
+		tag:     "Invoices",
+		role:    adminRole + " or " + serviceRole,
+		summary: "Render the branded PDF of an invoice closed by the billing cycle and deliver it to the customer",
+		description: "The PDF is stored and emailed with the invoice.issued notification to the customer's email " +
+			"channels; other channels and webhooks receive the notice with a link to the PDF. Line items must add up " +
+			"to the subtotal, and the subtotal and tax to the total. Invoices without a due date fall due by the " +
+			"customer's payment terms. Issuing an invoice again returns its stored PDF without notifying the " +
+			"customer twice.",
+		request:  issueInvoiceRequest{},
+		status:   http.StatusOK,
+		response: models.InvoicePDF{},
+	},
+	{
+		id:      "getInvoicePdf",
+		method:  http.MethodGet,
+		path:    invoicesPath + "/:id/pdf",
+		tag:     "Invoices",
+		summary: "Download the stored PDF of an invoice",
+		description: "Customers can download their own invoices; admin and support can download any. The PDF is " +
+			"served as first issued.",
+		status: http.StatusOK,
+		pdf:    true,
+	},
+	{
+		id:       "resendInvoice",
+		method:   http.MethodPost,
+		path:     invoicesPath + "/:id/resend",
+		tag:      "Invoices",
+		role:     adminRole + " or " + serviceRole,
+		summary:  "Deliver the stored PDF of an invoice to the customer again",
+		status:   http.StatusOK,
+		response: models.InvoicePDF{},
+	},
+	{
+		id:      "getInvoice",
+		method:  http.MethodGet,
+		path:    invoicesPath + "/:id",
+		tag:     "Invoices",
+		summary: "Get the due date, payments and late charges of an issued invoice",
+		description: "Customers can get their own invoices; admin and support can get any. Late fees and interest " +
+			"are listed as the line items added to the invoice.",
+		status:   http.StatusOK,
+		response: models.InvoiceReceivable{},
+	},
+	{
+		id:      "recordInvoicePayment",
+		method:  http.MethodPost,
+		path:    invoicesPath + "/:id/payments",
+		tag:     "Invoices",
+		role:    adminRole + " or " + serviceRole,
+		summary: "Record a payment towards an invoice and its late charges",
+		description: "The invoice is paid once nothing is left to pay; payments above what is left are refused. " +
+			"paid_at defaults to now.",
+		request:  invoicePaymentRequest{},
+		status:   http.StatusOK,
+		response: models.InvoiceReceivable{},
+	},
+	{
+		id:      "getInvoiceAging",
+		method:  http.MethodGet,
+		path:    agingPath,
+		tag:     "Invoices",
+		role:    adminRole,
+		summary: "Split what is left to pay of unpaid invoices by how long they are past due",
+		description: "Outstanding amounts, late charges included, are totalled per currency and per customer in " +
+			"current, 1-30, 31-60, 61-90 and over 90 days past due buckets.",
+		query: []*openapi3.Parameter{
+			stringQuery("currency", "Only invoices in this currency"),
+			stringQuery("customer_id", "Only invoices of this customer"),
+		},
+		status:   http.StatusOK,
+		response: models.InvoiceAgingReport{},
+	},
+	{
+		id:      "updateCustomerPaymentTerms",
+		method:  http.MethodPut,
+		path:    customersPath + "/:id/payment-terms",
+		tag:     "Invoices",
+		role:    adminRole,
+		summary: "Set how long a customer has to pay invoices",
+		description: "Invoices issued afterwards fall due by the new terms; issued invoices keep their due dates. " +
+			"Empty terms return the customer to the default.",
+		request:  paymentTermsRequest{},
+		status:   http.StatusOK,
+		response: models.CustomerSettings{},
+	},
+	{
+		id:      "updateCustomerBillingMode",
+		method:  http.MethodPut,
+		path:    customersPath + "/:id/billing-mode",
+		tag:     "Customer",
+		role:    adminRole,
+		summary: "Set whether a customer is billed prepaid or postpaid",
+		description: "Prepaid customers are debited as they use. Debits of postpaid customers are accrued and billed at " +
+			"the end of each monthly cycle, settled against the wallet or, with EXTERNAL settlement or when the wallet " +
+			"cannot cover it, invoiced. Charges accrued before switching to prepaid are still billed.",
+		request:  billingModeRequest{},
+		status:   http.StatusOK,
+		response: models.CustomerSettings{},
+	},
+	{
+		id:       "createOrganization",
+		method:   http.MethodPost,
+		path:     orgsPath,
+		tag:      "Organizations",
+		role:     adminRole,
+		summary:  "Create an organization to group customers and their wallets under",
+		request:  createOrganizationRequest{},
+		status:   http.StatusCreated,
+		response: models.Organization{},
+	},
+	{
+		id:      "updateCustomerOrganization",
+		method:  http.MethodPut,
+		path:    customersPath + "/:id/organization",
+		tag:     "Organizations",
+		role:    adminRole,
+		summary: "Move a customer and its wallets to an organization",
+		description: "A null org_id takes the customer out of its organization. Tokens scoped to an organization " +
+			"no longer see customers moved out of it.",
+		request:  customerOrganizationRequest{},
+		status:   http.StatusOK,
+		response: models.CustomerSettings{},
+	},
+	{
+		id:      "getOrganization",
+		method:  http.MethodGet,
+		path:    orgsPath + "/:id",
+		tag:     "Organizations",
+		role:    adminRole + " or " + orgAdminRole,
+		summary: "Get an organization with the count of its customers and wallets and their balance in each currency",
+		description: "Organization admins must use a token scoped to their organization and only see that " +
+			"organization.",
+		status:   http.StatusOK,
+		response: models.OrganizationSummary{},
+	},
+	{
+		id:      "listOrganizationWallets",
+		method:  http.MethodGet,
+		path:    orgsPath + "/:id/wallets",
+		tag:     "Organizations",
+		role:    adminRole + " or " + orgAdminRole,
+		summary: "List the wallets of every customer of an organization, oldest first",
+		query: []*openapi3.Parameter{
+			pageQuery,
+			pageSizeQuery,
+		},
+		status:   http.StatusOK,
+		response: []*models.Wallet{},
+		meta:     []string{"page", "page_size"},
+	},
+	{
+		id:      "getOrganizationInvoice",
+		method:  http.MethodGet,
+		path:    orgsPath + "/:id/invoices/:period",
+		tag:     "Organizations",
+		role:    adminRole + " or " + orgAdminRole,
+		summary: "Get the invoices issued to an organization's customers in a YYYY-MM billing period with their totals",
+		description: "Totals are by currency and include late charges; outstanding is what is left to pay of " +
+			"them.",
+		status:   http.StatusOK,
+		response: models.OrganizationInvoice{},
+	},
+	{
+		id:       "submitReportJob",
+		method:   http.MethodPost,
+		path:     jobsPath,
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Queue a long-running report job of a registered kind",
+		request:  reportJobRequest{},
+		status:   http.StatusAccepted,
+		response: models.ReportJob{},
+	},
+	{
+		id:      "listReportJobs",
+		method:  http.MethodGet,
+		path:    jobsPath,
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "List report jobs, newest first",
+		query: []*openapi3.Parameter{
+			stringQuery("kind", "Only jobs of this kind"),
+			stringQuery("status", "Only jobs in this status",
+				string(models.ReportJobQueued), string(models.ReportJobRunning), string(models.ReportJobSucceeded),
+				string(models.ReportJobFailed), string(models.ReportJobCancelled)),
+			pageQuery,
+			pageSizeQuery,
+		},
+		status:   http.StatusOK,
+		response: []*models.ReportJob{},
+		meta:     []string{"page", "page_size"},
+	},
+	{
+		id:       "listReportJobKinds",
+		method:   http.MethodGet,
+		path:     jobsPath + "/kinds",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "List the kinds of report jobs that can be submitted",
+		status:   http.StatusOK,
+		response: []string{},
+	},
+	{
+		id:       "getReportJob",
+		method:   http.MethodGet,
+		path:     jobsPath + "/:id",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get the status and progress of a report job",
+		status:   http.StatusOK,
+		response: models.ReportJob{},
+	},
+	{
+		id:       "cancelReportJob",
+		method:   http.MethodPost,
+		path:     jobsPath + "/:id/cancel",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Cancel a queued report job, or stop a running one at its next heartbeat",
+		status:   http.StatusOK,
+		response: models.ReportJob{},
+	},
+	{
+		id:       "getReportJobResult",
+		method:   http.MethodGet,
+		path:     jobsPath + "/:id/result",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get the result of a succeeded report job",
+		status:   http.StatusOK,
+		response: anyJSON{},
+	},
+	{
+		id:       "getDataAccessReport",
+		method:   http.MethodGet,
+		path:     dataAccessPath,
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Report the accesses to each classified response field since the replica started",
+		status:   http.StatusOK,
+		response: dataAccessReport{},
+	},
+	{
+		id:       "getSLOStatus",
+		method:   http.MethodGet,
+		path:     sloPath,
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Report the compliance and error budget burn rates of each endpoint SLO objective",
+		status:   http.StatusOK,
+		response: []slo.Status{},
+	},
+	{
+		id:       "getDeprecationReport",
+		method:   http.MethodGet,
+		path:     deprecationsPath,
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Report the integrations still calling deprecated routes, earliest sunset first",
+		query:    []*openapi3.Parameter{timeQuery("since", "Only callers seen since this RFC 3339 timestamp, 30 days ago by default")},
+		status:   http.StatusOK,
+		response: models.DeprecationReport{},
+	},
+	{
+		id:       "getUsageReport",
+		method:   http.MethodGet,
+		path:     usagePath,
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Report the monthly usage and chargeback cost of each internal service",
+		query:    []*openapi3.Parameter{stringQuery("month", "Month as YYYY-MM, the current month by default")},
+		status:   http.StatusOK,
+		response: models.UsageReport{},
+	},
+	{
+		id:       "getCurrentRateCard",
+		method:   http.MethodGet,
+		path:     rateCardsPath + "/current",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get the chargeback rates in effect",
+		status:   http.StatusOK,
+		response: models.RateCard{},
+	},
+	{
+		id:       "listRateCardChanges",
+		method:   http.MethodGet,
+		path:     rateCardsPath + "/changes",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "List the latest rate card changes with their diffs and reviews, newest first",
+		status:   http.StatusOK,
+		response: []*models.RateCardChange{},
+	},
+	{
+		id:       "proposeRateCardChange",
+		method:   http.MethodPost,
+		path:     rateCardsPath + "/changes",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Propose new chargeback rates from an effective date, pending approval by another operator",
+		request:  rateCardChangeRequest{},
+		status:   http.StatusCreated,
+		response: models.RateCardChange{},
+	},
+	{
+		id:       "getRateCardChange",
+		method:   http.MethodGet,
+		path:     rateCardsPath + "/changes/:id",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get a rate card change",
+		status:   http.StatusOK,
+		response: models.RateCardChange{},
+	},
+	{
+		id:       "approveRateCardChange",
+		method:   http.MethodPost,
+		path:     rateCardsPath + "/changes/:id/approve",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Approve a rate card change proposed by another operator, notifying finance before it takes effect",
+		request:  rateCardReviewRequest{},
+		status:   http.StatusOK,
+		response: models.RateCardChange{},
+	},
+	{
+		id:       "rejectRateCardChange",
+		method:   http.MethodPost,
+		path:     rateCardsPath + "/changes/:id/reject",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Reject or withdraw a pending rate card change",
+		request:  rateCardReviewRequest{},
+		status:   http.StatusOK,
+		response: models.RateCardChange{},
+	},
+	{
+		id:      "listAdjustments",
+		method:  http.MethodGet,
+		path:    adjustmentsPath,
+		tag:     "Admin",
+		role:    adminRole + " or " + approverRole,
+		summary: "List the latest adjustments with their reviews, newest first",
+		query: []*openapi3.Parameter{
+			stringQuery("wallet_id", "Only adjustments of this wallet"),
+			stringQuery("status", "Only adjustments in this status",
+				string(models.AdjustmentPending), string(models.AdjustmentApproved),
+				string(models.AdjustmentPosted), string(models.AdjustmentRejected),
+				string(models.AdjustmentExpired)),
+			intQuery("limit", "Adjustments to list, 50 by default and at most 500"),
+		},
+		status:   http.StatusOK,
+		response: []*models.Adjustment{},
+	},
+	{
+		id:      "requestAdjustment",
+		method:  http.MethodPost,
+		path:    adjustmentsPath,
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Request a manual balance correction with a reason code, pending approval by another operator when large",
+		description: "Adjustments up to the approval threshold of the wallet's currency are posted at once. " +
+			"Larger ones stay PENDING until approved by another operator, and expire when not reviewed in time.",
+		request:  adjustmentRequest{},
+		status:   http.StatusCreated,
+		response: models.Adjustment{},
+	},
+	{
+		id:      "getAdjustmentReport",
+		method:  http.MethodGet,
+		path:    adjustmentsPath + "/report",
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Total the adjustments posted in a window by reason code, direction and currency",
+		description: "Adjustments are reported here rather than as customer spend, " +
+			"and are left out of wallet analytics.",
+		query: []*openapi3.Parameter{
+			timeQuery("from", "Start of the window as an RFC 3339 timestamp, 30 days ago by default"),
+			timeQuery("to", "End of the window as an RFC 3339 timestamp, now by default"),
+		},
+		status:   http.StatusOK,
+		response: models.AdjustmentReport{},
+	},
+	{
+		id:       "getAdjustment",
+		method:   http.MethodGet,
+		path:     adjustmentsPath + "/:id",
+		tag:      "Admin",
+		role:     adminRole + " or " + approverRole,
+		summary:  "Get an adjustment",
+		status:   http.StatusOK,
+		response: models.Adjustment{},
+	},
+	{
+		id:       "approveAdjustment",
+		method:   http.MethodPost,
+		path:     adjustmentsPath + "/:id/approve",
+		tag:      "Admin",
+		role:     approverRole,
+		summary:  "Approve an adjustment requested by another operator, posting it as an ADJUSTMENT transaction",
+		request:  adjustmentReviewRequest{},
+		status:   http.StatusOK,
+		response: models.Adjustment{},
+	},
+	{
+		id:       "rejectAdjustment",
+		method:   http.MethodPost,
+		path:     adjustmentsPath + "/:id/reject",
+		tag:      "Admin",
+		role:     approverRole,
+		summary:  "Reject or withdraw a pending adjustment",
+		request:  adjustmentReviewRequest{},
+		status:   http.StatusOK,
+		response: models.Adjustment{},
+	},
+	{
+		id:      "listSettingsChanges",
+		method:  http.MethodGet,
+		path:    changesPath,
+		tag:     "Admin",
+		role:    adminRole + " or " + approverRole,
+		summary: "List the latest wallet settings changes with their reviews, newest first",
+		query: []*openapi3.Parameter{
+			stringQuery("wallet_id", "Only changes of this wallet"),
+			stringQuery("status", "Only changes in this status",
+				string(models.WalletSettingsChangePending), string(models.WalletSettingsChangeApproved),
+				string(models.WalletSettingsChangeApplied), string(models.WalletSettingsChangeRejected)),
+			intQuery("limit", "Changes to list, 50 by default and at most 500"),
+		},
+		status:   http.StatusOK,
+		response: []*models.WalletSettingsChange{},
+	},
+	{
+		id:      "requestSettingsChange",
+		method:  http.MethodPost,
+		path:    changesPath,
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Stage a credit limit increase until approved by another operator",
+		description: "A wallet has at most one change awaiting review. Lower credit limits need no approval " +
+			"and are set through the wallet settings instead.",
+		request:  settingsChangeRequest{},
+		status:   http.StatusCreated,
+		response: models.WalletSettingsChange{},
+	},
+	{
+		id:       "getSettingsChange",
+		method:   http.MethodGet,
+		path:     changesPath + "/:id",
+		tag:      "Admin",
+		role:     adminRole + " or " + approverRole,
+		summary:  "Get a wallet settings change with its review",
+		status:   http.StatusOK,
+		response: models.WalletSettingsChange{},
+	},
+	{
+		id:      "approveSettingsChange",
+		method:  http.MethodPost,
+		path:    changesPath + "/:id/approve",
+		tag:     "Admin",
+		role:    approverRole,
+		summary: "Approve and apply a pending settings change requested by another operator",
+		description: "A change whose settings were modified since it was requested is refused with " +
+			"SETTINGS_CHANGE_CONFLICT and stays pending until rejected.",
+		request:  settingsChangeReviewRequest{},
+		status:   http.StatusOK,
+		response: models.WalletSettingsChange{},
+	},
+	{
+		id:       "rejectSettingsChange",
+		method:   http.MethodPost,
+		path:     changesPath + "/:id/reject",
+		tag:      "Admin",
+		role:     approverRole,
+		summary:  "Reject or withdraw a pending settings change",
+		request:  settingsChangeReviewRequest{},
+		status:   http.StatusOK,
+		response: models.WalletSettingsChange{},
+	},
+	{
+		id:      "receiveInboundWebhook",
+		method:  http.MethodPost,
+		path:    inboundPath + "/:provider",
+		tag:     "Webhooks",
+		summary: "Receive a signed webhook from a payment gateway or partner system",
+		description: "Authenticated by the provider's signature rather than a bearer token; unsigned, wrongly signed " +
+			"and stale requests are refused with INVALID_WEBHOOK_SIGNATURE. The event is acknowledged once recorded and " +
+			"processed in the background, in the order received within its ordering key. Redelivered events are " +
+			"acknowledged as duplicates without being processed again.",
+		signed:   true,
+		request:  anyJSON{},
+		status:   http.StatusOK,
+		response: inboundWebhookReceipt{},
+	},
+	{
+		id:      "listDeadLetters",
+		method:  http.MethodGet,
+		path:    deadLettersPath,
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "List the inbound webhooks whose processing kept failing, newest first",
+		query: []*openapi3.Parameter{
+			stringQuery("provider", "Only dead letters of this provider"),
+			intQuery("limit", "Dead letters to list, 50 by default and at most 200"),
+		},
+		status:   http.StatusOK,
+		response: []*models.InboundDeadLetter{},
+	},
+	{
+		id:       "replayDeadLetter",
+		method:   http.MethodPost,
+		path:     deadLettersPath + "/:id/replay",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Return a dead-lettered inbound webhook to processing with fresh attempts",
+		status:   http.StatusOK,
+		response: models.InboundDeadLetter{},
+	},
+	{
+		id:      "listFailedJobs",
+		method:  http.MethodGet,
+		path:    failedJobsPath,
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "List the latest failed background jobs with their failure reasons, newest first",
+		query: []*openapi3.Parameter{
+			stringQuery("kind", "Only jobs of this kind",
+				string(models.FailedJobWebhookDelivery), string(models.FailedJobRecurringDebitRun)),
+			stringQuery("status", "Only jobs in this status",
+				string(models.FailedJobRetrying), string(models.FailedJobDead),
+				string(models.FailedJobSucceeded), string(models.FailedJobDiscarded)),
+			intQuery("limit", "Jobs to list, 50 by default and at most 500"),
+		},
+		status:   http.StatusOK,
+		response: []*models.FailedJob{},
+	},
+	{
+		id:      "retryFailedJobs",
+		method:  http.MethodPost,
+		path:    failedJobsPath + "/retry",
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Retry up to 100 retrying or dead jobs, reporting the outcome of each",
+		description: "Each job is granted one more attempt, made by the next retry run. A job that cannot be " +
+			"retried does not stop the others.",
+		request:  failedJobBulkRequest{},
+		status:   http.StatusOK,
+		response: []*models.FailedJobOutcome{},
+	},
+	{
+		id:       "discardFailedJobs",
+		method:   http.MethodPost,
+		path:     failedJobsPath + "/discard",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Discard up to 100 retrying or dead jobs for a reason, reporting the outcome of each",
+		request:  failedJobBulkRequest{},
+		status:   http.StatusOK,
+		response: []*models.FailedJobOutcome{},
+	},
+	{
+		id:       "getFailedJob",
+		method:   http.MethodGet,
+		path:     failedJobsPath + "/:id",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get a failed background job with its payload and last failure",
+		status:   http.StatusOK,
+		response: models.FailedJob{},
+	},
+	{
+		id:      "retryFailedJob",
+		method:  http.MethodPost,
+		path:    failedJobsPath + "/:id/retry",
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "Grant a retrying or dead job one more attempt, made by the next retry run",
+		description: "Jobs that already succeeded or were discarded are refused with FAILED_JOB_CONFLICT. " +
+			"A job failing again is dead-lettered.",
+		status:   http.StatusOK,
+		response: models.FailedJob{},
+	},
+	{
+		id:       "discardFailedJob",
+		method:   http.MethodPost,
+		path:     failedJobsPath + "/:id/discard",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Discard a retrying or dead job for a reason without retrying it",
+		request:  failedJobDiscardRequest{},
+		status:   http.StatusOK,
+		response: models.FailedJob{},
+	},
+	{
+		id:      "listDisputes",
+		method:  http.MethodGet,
+		path:    disputesPath,
+		tag:     "Admin",
+		role:    adminRole + " or " + serviceRole,
+		summary: "List the latest chargeback disputes, newest first",
+		query: []*openapi3.Parameter{
+			stringQuery("wallet_id", "Only disputes of this wallet"),
+			stringQuery("status", "Only disputes in this status",
+				string(models.DisputeOpen), string(models.DisputeEvidenceSubmitted),
+				string(models.DisputeWon), string(models.DisputeLost)),
+			intQuery("limit", "Disputes to list, 50 by default and at most 500"),
+		},
+		status:   http.StatusOK,
+		response: []*models.Dispute{},
+	},
+	{
+		id:          "openDispute",
+		method:      http.MethodPost,
+		path:        disputesPath,
+		tag:         "Admin",
+		role:        adminRole + " or " + serviceRole,
+		summary:     "Open a chargeback dispute of a completed top-up, holding the disputed amount",
+		description: "The amount defaults to the whole top-up, and is held with a PROCESSING debit that may take the balance down to the credit limit. A top-up is disputed once.",
+		request:     disputeRequest{},
+		status:      http.StatusCreated,
+		response:    models.Dispute{},
+	},
+	{
+		id:       "getDispute",
+		method:   http.MethodGet,
+		path:     disputesPath + "/:id",
+		tag:      "Admin",
+		role:     adminRole + " or " + serviceRole,
+		summary:  "Get a chargeback dispute",
+		status:   http.StatusOK,
+		response: models.Dispute{},
+	},
+	{
+		id:       "submitDisputeEvidence",
+		method:   http.MethodPost,
+		path:     disputesPath + "/:id/evidence",
+		tag:      "Admin",
+		role:     adminRole + " or " + serviceRole,
+		summary:  "Record the evidence submitted to the provider for an open dispute",
+		request:  disputeEvidenceRequest{},
+		status:   http.StatusOK,
+		response: models.Dispute{},
+	},
+	{
+		id:          "resolveDispute",
+		method:      http.MethodPost,
+		path:        disputesPath + "/:id/resolve",
+		tag:         "Admin",
+		role:        adminRole + " or " + serviceRole,
+		summary:     "Settle a dispute with the provider's decision",
+		description: "A WON dispute releases the hold, reversing its debit. A LOST dispute completes the debit, reversing the disputed amount out of the wallet.",
+		request:     disputeResolutionRequest{},
+		status:      http.StatusOK,
+		response:    models.Dispute{},
+	},
+	{
+		id:       "quoteInvoiceTax",
+		method:   http.MethodPost,
+		path:     taxPath + "/invoices/quote",
+		tag:      "Tax",
+		role:     adminRole + " or " + serviceRole,
+		summary:  "Quote the tax an invoice would be charged by the rules of the customer's region, without recording it",
+		request:  invoiceTaxRequest{},
+		status:   http.StatusOK,
+		response: models.TaxBreakdown{},
+	},
+	{
+		id:          "taxInvoice",
+		method:      http.MethodPost,
+		path:        taxPath + "/invoices",
+		tag:         "Tax",
+		role:        adminRole + " or " + serviceRole,
+		summary:     "Charge and record the tax of an invoice by the rules of the customer's region",
+		description: "Items in categories exempt in the region are not taxed. An invoice number taxed already returns its first record, so retried invoice runs are not taxed twice.",
+		request:     invoiceTaxRequest{},
+		status:      http.StatusOK,
+		response:    models.TaxRecord{},
+	},
+	{
+		id:       "updateCustomerTaxProfile",
+		method:   http.MethodPut,
+		path:     taxPath + "/customers/:customer_id",
+		tag:      "Tax",
+		role:     adminRole,
+		summary:  "Set the region whose tax rules apply to a customer and whether the customer holds an exemption",
+		request:  taxProfileRequest{},
+		status:   http.StatusOK,
+		response: models.CustomerSettings{},
+	},
+	{
+		id:      "getTaxReport",
+		method:  http.MethodGet,
+		path:    taxPath + "/report",
+		tag:     "Tax",
+		role:    adminRole,
+		summary: "Total the tax recorded in a window by region, component, source and currency",
+		query: []*openapi3.Parameter{
+			timeQuery("from", "Start of the window as an RFC 3339 timestamp, 30 days ago by default"),
+			timeQuery("to", "End of the window as an RFC 3339 timestamp, now by default"),
+		},
+		status:   http.StatusOK,
+		response: models.TaxReport{},
+	},
+	{
+		id:       "previewFee",
+		method:   http.MethodPost,
+		path:     feesPath + "/preview",
+		tag:      "Fees",
+		summary:  "Preview the fee a transaction would incur under the fee rules in effect at its time",
+		request:  feePreviewRequest{},
+		status:   http.StatusOK,
+		response: models.FeeQuote{},
+	},
+	{
+		id:       "listFeeRules",
+		method:   http.MethodGet,
+		path:     feeRulesPath,
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "List the latest fee rules, newest first",
+		status:   http.StatusOK,
+		response: []*models.FeeRule{},
+	},
+	{
+		id:          "createFeeRule",
+		method:      http.MethodPost,
+		path:        feeRulesPath,
+		tag:         "Admin",
+		role:        adminRole,
+		summary:     "Create a fee rule for a transaction source and currency from an effective date",
+		description: "The fee is the fixed amount plus the percentage of the transaction amount, held between the minimum and maximum fee. The most specific rule in effect applies, and of those the one effective last.",
+		request:     feeRuleRequest{},
+		status:      http.StatusCreated,
+		response:    models.FeeRule{},
+	},
+	{
+		id:       "getFeeRule",
+		method:   http.MethodGet,
+		path:     feeRulesPath + "/:id",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get a fee rule",
+		status:   http.StatusOK,
+		response: models.FeeRule{},
+	},
+	{
+		id:       "endFeeRule",
+		method:   http.MethodPost,
+		path:     feeRulesPath + "/:id/end",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Stop a fee rule from applying from a time, now by default",
+		request:  feeRuleEndRequest{},
+		status:   http.StatusOK,
+		response: models.FeeRule{},
+	},
+	{
+		id:      "estimateCharge",
+		method:  http.MethodPost,
+		path:    estimatesPath,
+		tag:     "Estimates",
+		summary: "Estimate what a debit would be charged, without committing it",
+		description: "The amount is discounted by the named coupon, charged the fee of the rule in effect, converted into the wallet's currency at the configured exchange rates " +
+			"and taxed by the customer's region when debits are taxed. Nothing is recorded, and the coupon is not redeemed.",
+		request:  estimateRequest{},
+		status:   http.StatusOK,
+		response: models.ChargeEstimate{},
+	},
+	{
+		id:          "quoteCoupon",
+		method:      http.MethodPost,
+		path:        couponsPath + "/quote",
+		tag:         "Coupons",
+		role:        adminRole + " or " + serviceRole,
+		summary:     "Quote the discount a coupon would give an amount, without redeeming it",
+		description: "The coupon must be active, valid at the given time, for the customer when restricted to one, in the amount's currency when fixed, and within its redemption limit.",
+		request:     couponApplicationRequest{},
+		status:      http.StatusOK,
+		response:    models.CouponQuote{},
+	},
+	{
+		id:          "redeemCoupon",
+		method:      http.MethodPost,
+		path:        couponsPath + "/redeem",
+		tag:         "Coupons",
+		role:        adminRole + " or " + serviceRole,
+		summary:     "Redeem a coupon on a rated charge or an invoice, recording and auditing the redemption",
+		description: "A coupon is redeemed once per source and reference; a retried redemption returns the first one. Redemptions beyond the coupon's limits, overall or per customer, are refused.",
+		request:     couponApplicationRequest{},
+		status:      http.StatusOK,
+		response:    models.CouponRedemption{},
+	},
+	{
+		id:      "listCoupons",
+		method:  http.MethodGet,
+		path:    adminCouponsPath,
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "List the latest coupons, newest first",
+		query: []*openapi3.Parameter{
+			stringQuery("status", "Only coupons in this status",
+				string(models.CouponActive), string(models.CouponVoided)),
+			intQuery("limit", "Coupons to list, 50 by default and at most 500"),
+		},
+		status:   http.StatusOK,
+		response: []*models.Coupon{},
+	},
+	{
+		id:          "createCoupon",
+		method:      http.MethodPost,
+		path:        adminCouponsPath,
+		tag:         "Admin",
+		role:        adminRole,
+		summary:     "Create a coupon, or a negotiated discount when restricted to a customer",
+		description: "Percentage coupons take up to 100 percent off an amount in any currency; fixed coupons take their value off amounts in their currency, at most the whole amount.",
+		request:     couponRequest{},
+		status:      http.StatusCreated,
+		response:    models.Coupon{},
+	},
+	{
+		id:       "getCoupon",
+		method:   http.MethodGet,
+		path:     adminCouponsPath + "/:id",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get a coupon with its latest redemptions",
+		status:   http.StatusOK,
+		response: models.CouponDetails{},
+	},
+	{
+		id:       "voidCoupon",
+		method:   http.MethodPost,
+		path:     adminCouponsPath + "/:id/void",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Void a coupon so it can no longer be redeemed; past redemptions stand",
+		request:  couponVoidRequest{},
+		status:   http.StatusOK,
+		response: models.Coupon{},
+	},
+	{
+		id:      "listReconciliationIssues",
+		method:  http.MethodGet,
+		path:    reconPath,
+		tag:     "Admin",
+		role:    adminRole,
+		summary: "List ledger reconciliation issues",
+		query: []*openapi3.Parameter{
+			stringQuery("status", "Only issues in this status", string(models.ReconciliationIssueOpen), string(models.ReconciliationIssueResolved)),
+			pageQuery,
+			pageSizeQuery,
+		},
+		status:   http.StatusOK,
+		response: []*models.ReconciliationIssue{},
+		meta:     []string{"page", "page_size"},
+	},
+	{
+		id:       "getReconciliationIssue",
+		method:   http.MethodGet,
+		path:     reconPath + "/:id",
+		tag:      "Admin",
+		role:     adminRole,
+		summary:  "Get a reconciliation issue with its ledger detail",
+		status:   http.StatusOK,
+		response: service.ReconciliationIssueDetail{},
+	},
+	{
+		id:      "getWalletAnalytics",
+		method:  http.MethodGet,
+		path:    walletsPath + "/:id/analytics",
+		tag:     "Analytics",
+		summary: "Get debit and credit trends, top spend categories and a burn rate projection of a wallet",
+		description: "Completed transactions are totalled per day, week or month by effective date, with empty periods " +
+			"listed as zero. Debits are categorised by their category metadata, else their description. The burn rate " +
+			"is the average daily net spend over the window; depletes_at is omitted when the wallet is not net spending. " +
+			"Summaries are cached for a few minutes, so they may lag new postings.",
+		query: []*openapi3.Parameter{
+			stringQuery("granularity", "Period length, day by default", "day", "week", "month"),
+			intQuery("days", "Look-back window in days, 30 by default and at most 365"),
+			intQuery("top", "Spend categories to list, 5 by default and at most 20"),
+		},
+		status:   http.StatusOK,
+		response: models.WalletAnalytics{},
+	},
+	{
+		id:      "getPostpaidUsage",
+		method:  http.MethodGet,
+		path:    walletsPath + "/:id/postpaid-usage",
+		tag:     "Wallets",
+		summary: "Get the unbilled usage and latest cycle statements of a wallet",
+		description: "Debits of postpaid customers are accrued as charges until the end of their monthly cycle, when a " +
+			"statement bills them. Prepaid wallets report no unbilled usage.",
+		status:   http.StatusOK,
+		response: models.PostpaidUsage{},
+	},
+	{
+		id:      "getWalletAllowances",
+		method:  http.MethodGet,
+		path:    walletsPath + "/:id/allowances",
+		tag:     "Wallets",
+		summary: "Get the free-tier allowances of a wallet in the current cycle",
+		description: "Debits use the free units left of their product's allowance before the wallet is charged. " +
+			"Usage resets when the next monthly cycle starts.",
+		status:   http.StatusOK,
+		response: models.WalletAllowances{},
+	},
+	{
+		id:      "listWalletBuckets",
+		method:  http.MethodGet,
+		path:    walletsPath + "/:id/buckets",
+		tag:     "Wallets",
+		summary: "List a wallet's budget buckets and its unallocated balance",
+		description: "Buckets split the wallet's balance into named sub-balances such as an SMS budget. " +
+			"The unallocated balance is what the buckets leave of the wallet's balance.",
+		status:   http.StatusOK,
+		response: models.WalletBuckets{},
+	},
+	{
+		id:      "createWalletBucket",
+		method:  http.MethodPost,
+		path:    walletsPath + "/:id/buckets",
+		tag:     "Wallets",
+		summary: "Add an empty budget bucket to a wallet",
+		description: "Debits naming the bucket in their bucket metadata key are taken out of it. With fallback NONE " +
+			"a debit the bucket cannot cover is refused; with UNALLOCATED the bucket gives what it holds and the " +
+			"rest is charged to the unallocated balance. A bucket with a low balance threshold publishes " +
+			"wallet.bucket_low_balance once as a debit takes it to the threshold.",
+		request:  createBucketRequest{},
+		status:   http.StatusCreated,
+		response: models.WalletBucket{},
+	},
+	{
+		id:       "updateWalletBucket",
+		method:   http.MethodPut,
+		path:     walletsPath + "/:id/buckets/:name",
+		tag:      "Wallets",
+		summary:  "Replace the low balance threshold and fallback of a budget bucket",
+		request:  updateBucketRequest{},
+		status:   http.StatusOK,
+		response: models.WalletBucket{},
+	},
+	{
+		id:          "deleteWalletBucket",
+		method:      http.MethodDelete,
+		path:        walletsPath + "/:id/buckets/:name",
+		tag:         "Wallets",
+		summary:     "Remove a budget bucket",
+		description: "The bucket's balance becomes unallocated.",
+		status:      http.StatusNoContent,
+	},
+	{
+		id:      "transferBucketFunds",
+		method:  http.MethodPost,
+		path:    walletsPath + "/:id/bucket-transfers",
+		tag:     "Wallets",
+		summary: "Move funds between budget buckets",
+		description: "An omitted from or to is the wallet's unallocated balance, so buckets are funded from and " +
+			"emptied into it. Returns the buckets the funds moved out of and into.",
+		idempotencyKey: headerOptional,
+		request:        bucketTransferRequest{},
+		status:         http.StatusOK,
+		response:       []*models.WalletBucket{},
+	},
+	{
+		id:       "getAdoptionStats",
+		method:   http.MethodGet,
+		path:     analyticsPath + "/adoption",
+		tag:      "Analytics",
+		role:     analyticsRole,
+		summary:  "Get privacy-safe adoption statistics per currency",
+		query:    []*openapi3.Parameter{intQuery("days", "Look-back window in days, 30 by default")},
+		status:   http.StatusOK,
+		response: []*service.AdoptionStats{},
+		meta:     []string{"days"},
+	},
+}
+
+// OpenAPIDocument returns the generated OpenAPI document served by the API
+func OpenAPIDocument() []byte {
+	return openAPIDocument
+}
+
+// GenerateOpenAPI builds the OpenAPI document of the REST API from the
+// request and response types of the handlers, as written to openapi.json
+func GenerateOpenAPI() ([]byte, error) {
+	spec, err := OpenAPISpec()
+	if err != nil {
+		return nil, err
+	}
+	if err := spec.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// OpenAPISpec builds the OpenAPI document of the REST API
+func OpenAPISpec() (*openapi3.T, error) {
+	components := openapi3.NewComponents()
+	components.Schemas = openapi3.Schemas{
+		"Error": errorSchema().NewRef(),
+	}
+	components.SecuritySchemes = openapi3.SecuritySchemes{
+		bearerAuth: &openapi3.SecuritySchemeRef{Value: openapi3.NewJWTSecurityScheme()},
+	}
+
+	spec := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:       "Wallet Service API",
+			Description: "Prepaid wallets, transactions and refunds of the billing platform.",
+			Version:     Version,
+		},
+		Servers:    openapi3.Servers{{URL: apiV1}},
+		Paths:      openapi3.Paths{},
+		Components: &components,
+		Security:   openapi3.SecurityRequirements{{bearerAuth: []string{}}},
+	}
+
+	gen := &schemaGenerator{
+		schemas: components.Schemas,
+		types:   make(map[string]reflect.Type),
+	}
+	for _, op := range apiOperations {
+		operation, err := op.build(gen)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.method, op.path, err)
+		}
+		spec.AddOperation(pathTemplate(op.path), op.method, operation)
+	}
+
+	return spec, nil
+}
+
+// build converts the operation into its OpenAPI form
+func (op apiOperation) build(gen *schemaGenerator) (*openapi3.Operation, error) {
+	operation := openapi3.NewOperation()
+	operation.OperationID = op.id
+	operation.Summary = op.summary
+	operation.Description = op.description
+	operation.Tags = []string{op.tag}
+	if op.role != "" {
+		operation.Description = strings.TrimSpace(fmt.Sprintf("Requires the %s role. %s", op.role, op.description))
+	}
+	if op.signed {
+		operation.Security = openapi3.NewSecurityRequirements()
+	}
+
+	for _, name := range pathParamPattern.FindAllStringSubmatch(op.path, -1) {
+		schema := openapi3.NewStringSchema()
+		if strings.HasSuffix(name[1], "id") {
+			schema = openapi3.NewUUIDSchema()
+		}
+		operation.AddParameter(openapi3.NewPathParameter(name[1]).WithSchema(schema))
+	}
+	for _, param := range op.query {
+		operation.AddParameter(param)
+	}
+	if op.idempotencyKey != headerNone {
+		operation.AddParameter(openapi3.NewHeaderParameter("Idempotency-Key").
+			WithDescription("Client-chosen key making retries of the request safe").
+			WithRequired(op.idempotencyKey == headerRequired).
+			WithSchema(openapi3.NewStringSchema()))
+	}
+
+	if op.request != nil {
+		body, err := gen.ref(reflect.TypeOf(op.request))
+		if err != nil {
+			return nil, err
+		}
+		operation.RequestBody = &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().WithRequired(true).WithJSONSchemaRef(body),
+		}
+	}
+
+	success := openapi3.NewResponse().WithDescription(http.StatusText(op.status))
+	if op.events {
+		operation.AddParameter(openapi3.NewHeaderParameter("Last-Event-ID").
+			WithDescription("ID of the last event received, to resume after a reconnection").
+			WithSchema(openapi3.NewInt64Schema()))
+		success.WithDescription("Balance and transaction events. The stream opens with the current balance unless resumed").
+			WithContent(openapi3.NewContentWithSchema(openapi3.NewStringSchema(), []string{"text/event-stream"}))
+	}
+	if op.pdf {
+		success.WithContent(openapi3.NewContentWithSchema(openapi3.NewStringSchema().WithFormat("binary"), []string{"application/pdf"}))
+	}
+	if op.response != nil {
+		data, err := gen.data(op.response)
+		if err != nil {
+			return nil, err
+		}
+		envelope := envelopeSchema(data, op.meta)
+		if op.warnings {
+			warnings, err := gen.ref(reflect.TypeOf([]models.CreditLimitWarning{}))
+			if err != nil {
+				return nil, err
+			}
+			warnings.Value.Description = "Present when a debit leaves the credit limit close to exhausted"
+			envelopeMeta(envelope).Properties["warnings"] = warnings
+		}
+		if op.currencyFormats {
+			format, err := gen.ref(reflect.TypeOf(models.CurrencyFormat{}))
+			if err != nil {
+				return nil, err
+			}
+			formats := openapi3.NewObjectSchema()
+			formats.Description = "Display formats of the currencies of the response's amounts, keyed by currency code"
+			formats.AdditionalProperties = openapi3.AdditionalProperties{Schema: format}
+			envelopeMeta(envelope).WithProperty("currency_formats", formats)
+		}
+		success.WithJSONSchema(envelope)
+	}
+	operation.AddResponse(op.status, success)
+	errorRef := openapi3.NewSchemaRef("#/components/schemas/Error", gen.schemas["Error"].Value)
+	operation.Responses["default"] = &openapi3.ResponseRef{
+		Value: openapi3.NewResponse().
+			WithDescription("Error with a stable code and a localized message").
+			WithJSONSchemaRef(errorRef),
+	}
+
+	for _, example := range op.examples {
+		if err := addExample(operation, example, errorRef); err != nil {
+			return nil, fmt.Errorf("example %s: %w", example.name, err)
+		}
+	}
+
+	return operation, nil
+}
+
+// addExample documents an example in the request body and in the response
+// of its status, adding an error response for a status not documented yet
+func addExample(operation *openapi3.Operation, example apiExample, errorRef *openapi3.SchemaRef) error {
+	if example.request != "" {
+		if operation.RequestBody == nil {
+			return errors.New("the endpoint takes no request body")
+		}
+		if err := addMediaExample(operation.RequestBody.Value.Content.Get("application/json"), example, example.request); err != nil {
+			return err
+		}
+	}
+
+	response := operation.Responses.Get(example.status)
+	if response == nil {
+		operation.AddResponse(example.status, openapi3.NewResponse().
+			WithDescription(http.StatusText(example.status)).
+			WithJSONSchemaRef(errorRef))
+		response = operation.Responses.Get(example.status)
+	}
+	return addMediaExample(response.Value.Content.Get("application/json"), example, example.response)
+}
+
+// addMediaExample decodes the JSON of an example into a media type
+func addMediaExample(media *openapi3.MediaType, example apiExample, data string) error {
+	if media == nil {
+		return errors.New("no JSON body to document it in")
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if media.Examples == nil {
+		media.Examples = openapi3.Examples{}
+	}
+	media.Examples[example.name] = &openapi3.ExampleRef{
+		Value: &openapi3.Example{Summary: example.summary, Value: value},
+	}
+	return nil
+}
+
+// pathParamPattern matches the parameters of a gin route
+var pathParamPattern = regexp.MustCompile(`:([a-z_]+)`)
+
+// pathTemplate converts a gin route into an OpenAPI path template
+func pathTemplate(route string) string {
+	return pathParamPattern.ReplaceAllString(route, "{$1}")
+}
+
+// envelopeSchema describes the Response envelope of successful requests
+func envelopeSchema(data *openapi3.SchemaRef, meta []string) *openapi3.Schema {
+	schema := openapi3.NewObjectSchema().
+		WithProperty("status", openapi3.NewStringSchema().WithEnum("success"))
+	schema.Properties["data"] = data
+	schema.Required = []string{"status", "data"}
+
+	if len(meta) > 0 {
+		metaSchema := openapi3.NewObjectSchema()
+		for _, name := range meta {
+			metaSchema.WithProperty(name, openapi3.NewIntegerSchema())
+		}
+		schema.WithProperty("meta", metaSchema)
+	}
+
+	return schema
+}
+
+// envelopeMeta returns the meta object of an envelope, adding it if missing
+func envelopeMeta(envelope *openapi3.Schema) *openapi3.Schema {
+	if meta, ok := envelope.Properties["meta"]; ok {
+		return meta.Value
+	}
+	meta := openapi3.NewObjectSchema()
+	envelope.WithProperty("meta", meta)
+	return meta
+}
+
+// errorSchema describes the Response envelope of failed requests
+func errorSchema() *openapi3.Schema {
+	codes := apierror.Codes()
+	enum := make([]interface{}, len(codes))
+	for i, code := range codes {
+		enum[i] = string(code)
+	}
+
+	schema := openapi3.NewObjectSchema().
+		WithProperty("status", openapi3.NewStringSchema().WithEnum("error")).
+		WithProperty("code", openapi3.NewStringSchema().WithEnum(enum...)).
+		WithProperty("error", openapi3.NewStringSchema()).
+		WithProperty("meta", openapi3.NewObjectSchema().
+			WithProperty("details", openapi3.NewStringSchema()))
+	schema.Required = []string{"status", "code", "error"}
+	return schema
+}
+
+// intQuery documents an integer query parameter
+func intQuery(name, description string) *openapi3.Parameter {
+	return openapi3.NewQueryParameter(name).WithDescription(description).WithSchema(openapi3.NewIntegerSchema())
+}
+
+// timeQuery documents an RFC 3339 timestamp query parameter
+func timeQuery(name, description string) *openapi3.Parameter {
+	return openapi3.NewQueryParameter(name).WithDescription(description).WithSchema(openapi3.NewDateTimeSchema())
+}
+
+// stringQuery documents a string query parameter limited to values
+func stringQuery(name, description string, values ...interface{}) *openapi3.Parameter {
+	return openapi3.NewQueryParameter(name).WithDescription(description).WithSchema(openapi3.NewStringSchema().WithEnum(values...))
+}
+
+// Types with a JSON form that reflection cannot derive
+var (
+	uuidType    = reflect.TypeOf(uuid.UUID{})
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+	anyJSONType = reflect.TypeOf(anyJSON{})
+)
+
+// schemaGenerator derives component schemas from Go types
+type schemaGenerator struct {
+	schemas openapi3.Schemas
+	// types records the Go type behind each component name
+	types map[string]reflect.Type
+}
+
+// data returns the schema of a response's data
+func (g *schemaGenerator) data(value interface{}) (*openapi3.SchemaRef, error) {
+	alternatives, ok := value.(oneOf)
+	if !ok {
+		return g.ref(reflect.TypeOf(value))
+	}
+
+	schema := &openapi3.Schema{}
+	for _, alt := range alternatives {
+		ref, err := g.ref(reflect.TypeOf(alt))
+		if err != nil {
+			return nil, err
+		}
+		// Data matches exactly one alternative only when each requires the
+		// fields it always carries
+		ref.Value.Required = encodedFields(reflect.TypeOf(alt))
+		schema.OneOf = append(schema.OneOf, ref)
+	}
+	return schema.NewRef(), nil
+}
+
+// encodedFields lists the JSON fields of a struct encoded without
+// omitempty, which every value carries
+func encodedFields(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")
+		if tag[0] == "" || tag[0] == "-" {
+			continue
+		}
+		omitted := false
+		for _, option := range tag[1:] {
+			omitted = omitted || option == "omitempty"
+		}
+		if !omitted {
+			names = append(names, tag[0])
+		}
+	}
+	return names
+}
+
+// ref returns the schema of t, referencing a component for named structs
+func (g *schemaGenerator) ref(t reflect.Type) (*openapi3.SchemaRef, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == anyJSONType:
+		return (&openapi3.Schema{}).NewRef(), nil
+	case t.Kind() == reflect.Slice:
+		items, err := g.ref(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		schema := openapi3.NewArraySchema()
+		schema.Items = items
+		return schema.NewRef(), nil
+	case t.Kind() != reflect.Struct || t.Name() == "":
+		return g.generate(t)
+	}
+
+	name := componentName(t)
+	if existing, ok := g.types[name]; ok && existing != t {
+		return nil, fmt.Errorf("schema name %s is used by %s and %s", name, existing, t)
+	}
+	if _, ok := g.types[name]; !ok {
+		ref, err := g.generate(t)
+		if err != nil {
+			return nil, err
+		}
+		g.types[name] = t
+		g.schemas[name] = ref
+	}
+
+	// The value lets validation follow the reference; only the reference
+	// is encoded
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, g.schemas[name].Value), nil
+}
+
+// generate derives the inline schema of t
+func (g *schemaGenerator) generate(t reflect.Type) (*openapi3.SchemaRef, error) {
+	ref, err := openapi3gen.NewSchemaRefForValue(reflect.New(t).Interface(), nil, openapi3gen.SchemaCustomizer(customizeSchema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate schema of %s: %w", t, err)
+	}
+	return ref, nil
+}
+
+// customizeSchema corrects the schemas of types marshalled as strings and
+// marks the fields handlers bind as required
+func customizeSchema(name string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) error {
+	switch t {
+	case uuidType:
+		*schema = *openapi3.NewUUIDSchema()
+		return nil
+	case decimalType:
+		*schema = *openapi3.NewStringSchema().WithFormat("decimal")
+		return nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if jsonName == "" || jsonName == "-" {
+			continue
+		}
+		for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+			if rule == "required" {
+				schema.Required = append(schema.Required, jsonName)
+			}
+		}
+	}
+	return nil
+}
+
+// componentName names the component schema of a struct type
+func componentName(t reflect.Type) string {
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
+
+// serveOpenAPI handles GET /openapi.json endpoint
+func serveOpenAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", openAPIDocument)
+}
+
+// serveSwaggerUI handles GET /docs/*filepath endpoint, serving the bundled
+// Swagger UI configured to load the served OpenAPI document
+func serveSwaggerUI(c *gin.Context) {
+	// Swagger UI sets inline styles and embeds its icons as data URIs
+	c.Header("Content-Security-Policy", "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:")
+
+	switch file := c.Param("filepath"); file {
+	case "/", "/index.html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", swaggerFiles.FileIndexHTML)
+	case "/swagger-initializer.js":
+		c.Data(http.StatusOK, "application/javascript", []byte(swaggerInitializer))
+	default:
+		c.Request.URL.Path = file
+		http.FileServer(swaggerFiles.HTTP).ServeHTTP(c.Writer, c.Request)
+	}
+}
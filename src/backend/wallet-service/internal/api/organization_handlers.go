@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/service"
+)
+
+// OrganizationHandler handles organizations and the rollups, wallets and
+// consolidated invoices of their customers
+type OrganizationHandler struct {
+	service service.OrganizationService
+}
+
+// createOrganizationRequest is the body of POST /organizations
+type createOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// customerOrganizationRequest is the body of PUT
+// /customers/:id/organization; a null org_id takes the customer out of its
+// organization
+type customerOrganizationRequest struct {
+	OrgID *uuid.UUID `json:"org_id"`
+}
+
+// NewOrganizationHandler creates a new instance of OrganizationHandler
+func NewOrganizationHandler(service service.OrganizationService) (*OrganizationHandler, error) {
+	if service == nil {
+		return nil, errors.New("organization service is required")
+	}
+
+	return &OrganizationHandler{service: service}, nil
+}
+
+// CreateOrganization handles POST /organizations endpoint
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req createOrganizationRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	org, err := h.service.Create(c.Request.Context(), req.Name, actorFromContext(c))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidOrganization) {
+			err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+		}
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   org,
+	})
+}
+
+// AssignCustomer handles PUT /customers/:id/organization endpoint, moving a
+// customer and its wallets to an organization
+func (h *OrganizationHandler) AssignCustomer(c *gin.Context) {
+	customerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid customer ID"))
+		return
+	}
+
+	var req customerOrganizationRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	settings, err := h.service.AssignCustomer(c.Request.Context(), customerID, req.OrgID, actorFromContext(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   settings,
+	})
+}
+
+// GetOrganization handles GET /organizations/:id endpoint, returning the
+// organization with the count of its customers and wallets and their
+// combined balance in each currency
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	id, ok := organizationID(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   summary,
+	})
+}
+
+// ListWallets handles GET /organizations/:id/wallets endpoint, paging
+// through the wallets of every customer of the organization
+func (h *OrganizationHandler) ListWallets(c *gin.Context) {
+	id, ok := organizationID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if pageSize <= 0 || pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	wallets, err := h.service.ListWallets(c.Request.Context(), id, service.Pagination{
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   wallets,
+		Meta: map[string]interface{}{
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetConsolidatedInvoice handles GET /organizations/:id/invoices/:period
+// endpoint, combining the invoices issued to the organization's customers
+// in a YYYY-MM billing period
+func (h *OrganizationHandler) GetConsolidatedInvoice(c *gin.Context) {
+	id, ok := organizationID(c)
+	if !ok {
+		return
+	}
+
+	invoice, err := h.service.ConsolidatedInvoice(c.Request.Context(), id, c.Param("period"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBillingPeriod) {
+			err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+		}
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   invoice,
+	})
+}
+
+// organizationID parses the organization ID of a request, responding with
+// an error when it is invalid
+func organizationID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid organization ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// requireOrganizationAdmin restricts access to operators and to the admins
+// of an organization. Organization admins must hold a token scoped to their
+// organization, so they never see another.
+func requireOrganizationAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if hasRole(c, adminRole) || (hasRole(c, orgAdminRole) && c.GetString("org_id") != "") {
+			c.Next()
+			return
+		}
+
+		respondError(c, apierror.New(apierror.CodeForbidden))
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// PostpaidHandler handles customer billing modes and the postpaid usage of
+// wallets
+type PostpaidHandler struct {
+	service service.PostpaidService
+}
+
+// billingModeRequest is the body of PUT /customers/:id/billing-mode
+type billingModeRequest struct {
+	// BillingMode is PREPAID or POSTPAID
+	BillingMode models.BillingMode `json:"billing_mode" binding:"required"`
+	// PostpaidSettlement is WALLET or EXTERNAL for postpaid customers,
+	// defaulting to WALLET
+	PostpaidSettlement models.PostpaidSettlement `json:"postpaid_settlement"`
+}
+
+// NewPostpaidHandler creates a new instance of PostpaidHandler
+func NewPostpaidHandler(service service.PostpaidService) (*PostpaidHandler, error) {
+	if service == nil {
+		return nil, errors.New("postpaid service is required")
+	}
+
+	return &PostpaidHandler{service: service}, nil
+}
+
+// UpdateBillingMode handles PUT /customers/:id/billing-mode endpoint,
+// switching a customer between debits taken as used and usage billed at the
+// end of each cycle
+func (h *PostpaidHandler) UpdateBillingMode(c *gin.Context) {
+	customerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid customer ID"))
+		return
+	}
+
+	var req billingModeRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	settings, err := h.service.UpdateBillingMode(c.Request.Context(), customerID,
+		models.BillingMode(strings.ToUpper(string(req.BillingMode))),
+		models.PostpaidSettlement(strings.ToUpper(string(req.PostpaidSettlement))),
+		actorFromContext(c))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBillingMode) {
+			err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+		}
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   settings,
+	})
+}
+
+// GetUsage handles GET /wallets/:id/postpaid-usage endpoint, returning what
+// the wallet accrued and has not been billed for yet, with its latest
+// statements
+func (h *PostpaidHandler) GetUsage(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	usage, err := h.service.GetUsage(c.Request.Context(), walletID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   usage,
+	})
+}
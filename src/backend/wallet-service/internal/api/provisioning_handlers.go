@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// mimeCSV is the content type of CSV provisioning batches
+const mimeCSV = "text/csv"
+
+// ProvisioningHandler handles HTTP requests for provisioning wallets in bulk
+type ProvisioningHandler struct {
+	service service.WalletProvisioningService
+}
+
+// walletBatchRequest is the JSON body of POST /admin/wallets/batch
+type walletBatchRequest struct {
+	Currency    string   `json:"currency" binding:"required"`
+	CustomerIDs []string `json:"customer_ids" binding:"required"`
+}
+
+// NewProvisioningHandler creates a new instance of ProvisioningHandler
+func NewProvisioningHandler(service service.WalletProvisioningService) (*ProvisioningHandler, error) {
+	if service == nil {
+		return nil, errors.New("wallet provisioning service is required")
+	}
+
+	return &ProvisioningHandler{service: service}, nil
+}
+
+// ProvisionWallets handles POST /admin/wallets/batch endpoint, queueing a job
+// that provisions a wallet for each customer. The batch is a JSON list of
+// customer IDs, or a CSV of customer_id and optional currency columns with
+// the currency query parameter as default. The job is returned at once; its
+// progress is polled with GET /jobs/:id and its per-row results are read
+// from GET /jobs/:id/result.
+func (h *ProvisioningHandler) ProvisionWallets(c *gin.Context) {
+	var rows []*models.WalletBatchRow
+	var err error
+	if c.ContentType() == mimeCSV {
+		rows, err = parseWalletBatchCSV(c.Request.Body, c.Query("currency"))
+	} else {
+		var req walletBatchRequest
+		if err = bindJSON(c, &req); err == nil {
+			for _, customerID := range req.CustomerIDs {
+				rows = append(rows, &models.WalletBatchRow{CustomerID: customerID, Currency: req.Currency})
+			}
+		}
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	job, err := h.service.Submit(c.Request.Context(), rows, actorFromContext(c))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWalletBatch) {
+			err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+		}
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, Response{
+		Status: "success",
+		Data:   job,
+	})
+}
+
+// parseWalletBatchCSV reads the rows of a CSV batch. A first row naming the
+// customer_id column is a header; blank lines are skipped.
+func parseWalletBatchCSV(body io.Reader, currency string) ([]*models.WalletBatchRow, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []*models.WalletBatchRow
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid CSV: %v", err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "customer_id") {
+			continue
+		}
+		if len(record) > 2 {
+			err := fmt.Errorf("line %d has %d columns, expected customer_id and optional currency", line, len(record))
+			return nil, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+		}
+
+		row := &models.WalletBatchRow{CustomerID: record[0], Currency: currency}
+		if len(record) == 2 && strings.TrimSpace(record[1]) != "" {
+			row.Currency = record[1]
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// RateCardHandler handles HTTP requests for reviewed changes of the
+// chargeback rate card
+type RateCardHandler struct {
+	service service.RateCardService
+}
+
+// rateCardChangeRequest is the body of POST /admin/rate-cards/changes
+type rateCardChangeRequest struct {
+	PerThousandRequests *decimal.Decimal `json:"per_thousand_requests" binding:"required"`
+	PerDBSecond         *decimal.Decimal `json:"per_db_second" binding:"required"`
+	PerGB               *decimal.Decimal `json:"per_gb" binding:"required"`
+	EffectiveAt         time.Time        `json:"effective_at" binding:"required"`
+	Reason              string           `json:"reason" binding:"required"`
+}
+
+// rateCardReviewRequest is the body of rate card change approvals and
+// rejections
+type rateCardReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// NewRateCardHandler creates a new instance of RateCardHandler
+func NewRateCardHandler(service service.RateCardService) (*RateCardHandler, error) {
+	if service == nil {
+		return nil, errors.New("rate card service is required")
+	}
+
+	return &RateCardHandler{service: service}, nil
+}
+
+// GetCurrent handles GET /admin/rate-cards/current endpoint
+func (h *RateCardHandler) GetCurrent(c *gin.Context) {
+	rates, err := h.service.Current(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   rates,
+	})
+}
+
+// ListChanges handles GET /admin/rate-cards/changes endpoint, listing the
+// latest changes newest first
+func (h *RateCardHandler) ListChanges(c *gin.Context) {
+	changes, err := h.service.List(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   changes,
+	})
+}
+
+// GetChange handles GET /admin/rate-cards/changes/:id endpoint
+func (h *RateCardHandler) GetChange(c *gin.Context) {
+	id, ok := parseRateCardChangeID(c)
+	if !ok {
+		return
+	}
+
+	change, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   change,
+	})
+}
+
+// ProposeChange handles POST /admin/rate-cards/changes endpoint. The change
+// takes effect only once another operator approved it.
+func (h *RateCardHandler) ProposeChange(c *gin.Context) {
+	var req rateCardChangeRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	rates := models.RateCard{
+		PerThousandRequests: *req.PerThousandRequests,
+		PerDBSecond:         *req.PerDBSecond,
+		PerGB:               *req.PerGB,
+	}
+	change, err := h.service.Propose(c.Request.Context(), rates, req.EffectiveAt, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondRateCardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   change,
+	})
+}
+
+// ApproveChange handles POST /admin/rate-cards/changes/:id/approve endpoint
+func (h *RateCardHandler) ApproveChange(c *gin.Context) {
+	h.review(c, h.service.Approve)
+}
+
+// RejectChange handles POST /admin/rate-cards/changes/:id/reject endpoint
+func (h *RateCardHandler) RejectChange(c *gin.Context) {
+	h.review(c, h.service.Reject)
+}
+
+// review binds a review request and records it with the given outcome
+func (h *RateCardHandler) review(c *gin.Context, outcome func(ctx context.Context, id uuid.UUID, actor, note string) (*models.RateCardChange, error)) {
+	id, ok := parseRateCardChangeID(c)
+	if !ok {
+		return
+	}
+
+	var req rateCardReviewRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	change, err := outcome(c.Request.Context(), id, actorFromContext(c), req.Note)
+	if err != nil {
+		respondRateCardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   change,
+	})
+}
+
+// parseRateCardChangeID parses the change ID path parameter, responding when
+// it is invalid
+func parseRateCardChangeID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid rate card change ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondRateCardError responds with the validation failure as details so
+// operators can tell why the change was refused
+func respondRateCardError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidRateCardChange) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
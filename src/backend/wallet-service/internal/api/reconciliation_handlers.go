@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// ReconciliationHandler handles HTTP requests for ledger reconciliation issues
+type ReconciliationHandler struct {
+	service service.ReconciliationService
+}
+
+// NewReconciliationHandler creates a new instance of ReconciliationHandler
+func NewReconciliationHandler(service service.ReconciliationService) (*ReconciliationHandler, error) {
+	if service == nil {
+		return nil, errors.New("reconciliation service is required")
+	}
+
+	return &ReconciliationHandler{service: service}, nil
+}
+
+// ListIssues handles GET /admin/reconciliation endpoint
+func (h *ReconciliationHandler) ListIssues(c *gin.Context) {
+	status := models.ReconciliationIssueStatus(c.Query("status"))
+	switch status {
+	case "", models.ReconciliationIssueOpen, models.ReconciliationIssueResolved:
+	default:
+		respondError(c, apierror.New(apierror.CodeInvalidRequest).WithDetails("status must be OPEN or RESOLVED"))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if pageSize <= 0 || pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	issues, err := h.service.ListIssues(c.Request.Context(), status, service.Pagination{
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   issues,
+		Meta: map[string]interface{}{
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetIssue handles GET /admin/reconciliation/:id endpoint
+func (h *ReconciliationHandler) GetIssue(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid issue ID"))
+		return
+	}
+
+	detail, err := h.service.GetIssueDetail(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   detail,
+	})
+}
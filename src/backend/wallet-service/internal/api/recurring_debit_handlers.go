@@ -0,0 +1,188 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// RecurringDebitHandler handles HTTP requests for the recurring debits of
+// the authenticated customer
+type RecurringDebitHandler struct {
+	service service.RecurringDebitService
+}
+
+// recurringDebitRequest is the body of recurring debit create requests. An
+// omitted start runs the first debit right away, an omitted end never ends.
+type recurringDebitRequest struct {
+	WalletID    uuid.UUID               `json:"wallet_id" binding:"required"`
+	Amount      decimal.Decimal         `json:"amount" binding:"required"`
+	Cadence     models.RecurringCadence `json:"cadence" binding:"required"`
+	Description string                  `json:"description"`
+	StartAt     *time.Time              `json:"start_at"`
+	EndAt       *time.Time              `json:"end_at"`
+}
+
+// input converts the request into service input
+func (r *recurringDebitRequest) input() service.RecurringDebitInput {
+	input := service.RecurringDebitInput{
+		WalletID:    r.WalletID,
+		Amount:      r.Amount,
+		Cadence:     r.Cadence,
+		Description: r.Description,
+		EndAt:       r.EndAt,
+	}
+	if r.StartAt != nil {
+		input.StartAt = *r.StartAt
+	}
+	return input
+}
+
+// NewRecurringDebitHandler creates a new instance of RecurringDebitHandler
+func NewRecurringDebitHandler(service service.RecurringDebitService) (*RecurringDebitHandler, error) {
+	if service == nil {
+		return nil, errors.New("recurring debit service is required")
+	}
+
+	return &RecurringDebitHandler{service: service}, nil
+}
+
+// CreateRecurringDebit handles POST /recurring-debits endpoint
+func (h *RecurringDebitHandler) CreateRecurringDebit(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req recurringDebitRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	debit, err := h.service.Create(c.Request.Context(), customerID, req.input())
+	if err != nil {
+		respondRecurringDebitError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   debit,
+	})
+}
+
+// ListRecurringDebits handles GET /recurring-debits endpoint
+func (h *RecurringDebitHandler) ListRecurringDebits(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	debits, err := h.service.List(c.Request.Context(), customerID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   debits,
+	})
+}
+
+// GetRecurringDebit handles GET /recurring-debits/:id endpoint
+func (h *RecurringDebitHandler) GetRecurringDebit(c *gin.Context) {
+	customerID, id, err := recurringDebitIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	debit, err := h.service.Get(c.Request.Context(), customerID, id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   debit,
+	})
+}
+
+// CancelRecurringDebit handles DELETE /recurring-debits/:id endpoint. The
+// debit and its runs are kept for the customer's records.
+func (h *RecurringDebitHandler) CancelRecurringDebit(c *gin.Context) {
+	customerID, id, err := recurringDebitIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	debit, err := h.service.Cancel(c.Request.Context(), customerID, id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   debit,
+	})
+}
+
+// ListRuns handles GET /recurring-debits/:id/runs endpoint
+func (h *RecurringDebitHandler) ListRuns(c *gin.Context) {
+	customerID, id, err := recurringDebitIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	runs, err := h.service.ListRuns(c.Request.Context(), customerID, id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   runs,
+	})
+}
+
+// recurringDebitIDs returns the authenticated customer and the recurring
+// debit ID path parameter
+func recurringDebitIDs(c *gin.Context) (uuid.UUID, uuid.UUID, error) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid recurring debit ID")
+	}
+
+	return customerID, id, nil
+}
+
+// respondRecurringDebitError responds with the validation failure as details
+// so customers can tell which field was rejected
+func respondRecurringDebitError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidRecurringDebit) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/service"
+)
+
+// RefundHandler handles HTTP requests for refunds to the original payment method
+type RefundHandler struct {
+	service service.RefundService
+}
+
+// paymentRequest is the body of POST /wallets/:id/transactions/:transaction_id/payment
+type paymentRequest struct {
+	Provider  string `json:"provider" binding:"required"`
+	PaymentID string `json:"payment_id" binding:"required"`
+}
+
+// refundRequest is the body of POST /wallets/:id/transactions/:transaction_id/refund-to-source
+type refundRequest struct {
+	Amount decimal.Decimal `json:"amount" binding:"required"`
+}
+
+// NewRefundHandler creates a new instance of RefundHandler
+func NewRefundHandler(service service.RefundService) (*RefundHandler, error) {
+	if service == nil {
+		return nil, errors.New("refund service is required")
+	}
+
+	return &RefundHandler{service: service}, nil
+}
+
+// RecordPayment handles POST /wallets/:id/transactions/:transaction_id/payment
+// endpoint, linking a top-up to the provider payment that funded it
+func (h *RefundHandler) RecordPayment(c *gin.Context) {
+	walletID, transactionID, err := walletTransactionParams(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req paymentRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	payment, err := h.service.RecordTopUpPayment(c.Request.Context(), walletID, transactionID, req.Provider, req.PaymentID)
+	if err != nil {
+		respondRefundError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   payment,
+	})
+}
+
+// RefundToSource handles POST /wallets/:id/transactions/:transaction_id/refund-to-source
+// endpoint. The refund settles asynchronously with the provider, so it is
+// accepted while still pending.
+func (h *RefundHandler) RefundToSource(c *gin.Context) {
+	walletID, transactionID, err := walletTransactionParams(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req refundRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	refund, err := h.service.RefundToSource(c.Request.Context(), walletID, transactionID, req.Amount)
+	if err != nil {
+		respondRefundError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, Response{
+		Status: "success",
+		Data:   refund,
+	})
+}
+
+// GetRefund handles GET /wallets/:id/refunds/:refund_id endpoint
+func (h *RefundHandler) GetRefund(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+	refundID, err := uuid.Parse(c.Param("refund_id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid refund ID"))
+		return
+	}
+
+	refund, err := h.service.GetRefund(c.Request.Context(), walletID, refundID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   refund,
+	})
+}
+
+// walletTransactionParams parses the wallet and transaction IDs from the path
+func walletTransactionParams(c *gin.Context) (uuid.UUID, uuid.UUID, error) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, apierror.Wrap(apierror.CodeInvalidWalletID, err)
+	}
+	transactionID, err := uuid.Parse(c.Param("transaction_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid transaction ID")
+	}
+
+	return walletID, transactionID, nil
+}
+
+// respondRefundError responds with the reason a payment could not be linked or
+// a top-up refunded as details
+func respondRefundError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidPayment):
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	case errors.Is(err, service.ErrRefundNotAllowed):
+		err = apierror.Wrap(apierror.CodeRefundNotAllowed, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// ReportJobHandler handles HTTP requests for long-running report jobs
+type ReportJobHandler struct {
+	service service.ReportJobService
+}
+
+// reportJobRequest is the body of POST /jobs
+type reportJobRequest struct {
+	Kind   string          `json:"kind" binding:"required"`
+	Params json.RawMessage `json:"params"`
+}
+
+// NewReportJobHandler creates a new instance of ReportJobHandler
+func NewReportJobHandler(service service.ReportJobService) (*ReportJobHandler, error) {
+	if service == nil {
+		return nil, errors.New("report job service is required")
+	}
+
+	return &ReportJobHandler{service: service}, nil
+}
+
+// SubmitJob handles POST /jobs endpoint, queueing a report. The job is
+// returned at once; its progress is polled with GET /jobs/:id.
+func (h *ReportJobHandler) SubmitJob(c *gin.Context) {
+	var req reportJobRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	job, err := h.service.Submit(c.Request.Context(), req.Kind, req.Params, actorFromContext(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, Response{
+		Status: "success",
+		Data:   job,
+	})
+}
+
+// ListKinds handles GET /jobs/kinds endpoint
+func (h *ReportJobHandler) ListKinds(c *gin.Context) {
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   h.service.Kinds(),
+	})
+}
+
+// ListJobs handles GET /jobs endpoint
+func (h *ReportJobHandler) ListJobs(c *gin.Context) {
+	status := models.ReportJobStatus(c.Query("status"))
+	switch status {
+	case "", models.ReportJobQueued, models.ReportJobRunning, models.ReportJobSucceeded, models.ReportJobFailed, models.ReportJobCancelled:
+	default:
+		respondError(c, apierror.New(apierror.CodeInvalidRequest).WithDetails("status must be QUEUED, RUNNING, SUCCEEDED, FAILED or CANCELLED"))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if pageSize <= 0 || pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	jobs, err := h.service.List(c.Request.Context(), c.Query("kind"), status, service.Pagination{
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   jobs,
+		Meta: map[string]interface{}{
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetJob handles GET /jobs/:id endpoint, reporting a job's status and progress
+func (h *ReportJobHandler) GetJob(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   job,
+	})
+}
+
+// CancelJob handles POST /jobs/:id/cancel endpoint. A running job keeps the
+// RUNNING status until it stops at its next heartbeat.
+func (h *ReportJobHandler) CancelJob(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.service.Cancel(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   job,
+	})
+}
+
+// GetResult handles GET /jobs/:id/result endpoint for succeeded jobs
+func (h *ReportJobHandler) GetResult(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.GetResult(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   result,
+	})
+}
+
+// parseJobID parses the job ID path parameter, responding when it is invalid
+func parseJobID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid job ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
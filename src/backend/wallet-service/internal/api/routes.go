@@ -2,29 +2,177 @@
 package api
 
 import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "io"
     "net/http"
     "time"
 
     "github.com/gin-gonic/gin" // v1.9.1
     "github.com/prometheus/client_golang/prometheus/promhttp" // v1.16.0
-    "github.com/ulule/limiter/v3" // v3.11.1
-    "github.com/ulule/limiter/v3/drivers/store/memory"
     "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin" // v0.42.0
 
+    "internal/apierror"
     "internal/config"
+    "internal/health"
+    "internal/models"
 )
 
+// Version is the public API version. Breaking changes to any response shape
+// require a new version, which the golden response tests enforce.
+const Version = "v1"
+
 // API route constants
 const (
-    apiV1       = "/api/v1"
-    walletsPath = "/wallets"
-    healthPath  = "/health"
-    metricsPath = "/metrics"
+    apiV1            = "/api/" + Version
+    walletsPath      = "/wallets"
+    transactionsPath = "/transactions"
+    adminPath        = "/admin"
+    reconPath        = "/admin/reconciliation"
+    dataAccessPath   = "/admin/data-access"
+    usagePath        = "/admin/usage"
+    rateCardsPath    = "/admin/rate-cards"
+    adjustmentsPath  = "/admin/adjustments"
+    changesPath      = "/admin/settings-changes"
+    deadLettersPath  = "/admin/inbound-webhooks/dead-letters"
+    failedJobsPath   = "/admin/failed-jobs"
+    taxPath          = "/tax"
+    feeRulesPath     = "/admin/fee-rules"
+    adminCouponsPath = "/admin/coupons"
+    disputesPath     = "/admin/disputes"
+    sloPath          = "/admin/slo"
+    deprecationsPath = "/admin/deprecations"
+    periodsPath      = "/admin/billing-periods"
+    migrationsPath   = "/admin/wallet-migrations"
+    mergesPath       = "/admin/wallet-merges"
+    provisioningPath = "/admin/wallets/batch"
+    bulkCreditsPath  = "/admin/bulk-credits"
+    incidentPath     = "/admin/incident"
+    notificationPath = "/admin/notifications"
+    jobsPath         = "/jobs"
+    exportsPath      = "/exports"
+    digestPath       = "/digest-subscription"
+    customerPath     = "/customer-settings"
+    customersPath    = "/customers"
+    orgsPath         = "/organizations"
+    consentsPath     = "/consents"
+    webhooksPath     = "/webhooks"
+    inboundPath      = "/inbound-webhooks"
+    channelsPath     = "/notification-channels"
+    accessLogPath    = "/access-log"
+    invoicesPath     = "/invoices"
+    agingPath        = "/admin/invoices/aging"
+    recurringPath    = "/recurring-debits"
+    feesPath         = "/fees"
+    couponsPath      = "/coupons"
+    estimatesPath    = "/estimates"
+    capabilitiesPath = "/capabilities"
+    analyticsPath    = "/analytics"
+    graphqlPath      = "/graphql"
+    wsPath           = "/ws"
+    openAPIPath      = "/openapi.json"
+    docsPath         = "/docs"
+    healthPath       = "/health"
+    readyzPath       = "/readyz"
+    metricsPath      = "/metrics"
 )
 
+// Role constants for role-restricted route groups
+const (
+    // adminRole is the JWT role required for operator endpoints
+    adminRole = "admin"
+    // analyticsRole is the JWT role required for aggregate analytics endpoints
+    analyticsRole = "analytics"
+    // supportRole is the JWT role of billing support staff looking up
+    // transactions across customers
+    supportRole = "support"
+    // sandboxRole marks credentials whose requests run in sandbox mode,
+    // suppressing webhooks and customer notifications
+    sandboxRole = "sandbox"
+    // serviceRole marks the credentials of internal services, whose usage
+    // is accounted for chargeback
+    serviceRole = "service"
+    // orgAdminRole is the JWT role of organization admins, managing the
+    // customers and wallets of the organization their token is scoped to
+    orgAdminRole = "org_admin"
+    // approverRole is the JWT role of operators reviewing the manual
+    // balance adjustments and wallet settings changes requested by others
+    approverRole = "approver"
+)
+
+// Handlers groups the HTTP handlers mounted by SetupRouter. Optional
+// handlers left nil are not routed.
+type Handlers struct {
+    Wallet         *WalletHandler
+    Admin          *AdminHandler
+    Analytics      *AnalyticsHandler
+    Spending       *WalletAnalyticsHandler
+    Health         *HealthHandler
+    Reconciliation *ReconciliationHandler
+    Digest         *DigestHandler
+    Customer       *CustomerHandler
+    Customers      *CustomerBalanceHandler
+    Consent        *ConsentHandler
+    Webhook        *WebhookHandler
+    Channels       *NotificationChannelHandler
+    AccessLog      *AccessLogHandler
+    Invoices       *InvoiceHandler
+    Receivables    *InvoiceReceivableHandler
+    Postpaid       *PostpaidHandler
+    Allowances     *AllowanceHandler
+    Buckets        *WalletBucketHandler
+    Organizations  *OrganizationHandler
+    Recurring      *RecurringDebitHandler
+    Refund         *RefundHandler
+    Stream         *StreamHandler
+    WebSocket      *WebSocketHandler
+    DataAccess     *DataAccessHandler
+    Usage          *UsageHandler
+    RateCard       *RateCardHandler
+    Adjustment     *AdjustmentHandler
+    SettingsChange *WalletSettingsChangeHandler
+    Inbound        *InboundWebhookHandler
+    FailedJob      *FailedJobHandler
+    Tax            *TaxHandler
+    Fee            *FeeHandler
+    Coupon         *CouponHandler
+    Dispute        *DisputeHandler
+    Estimate       *EstimateHandler
+    SLO            *SLOHandler
+    BillingPeriod  *BillingPeriodHandler
+    Migration      *MigrationHandler
+    Merge          *MergeHandler
+    Provisioning   *ProvisioningHandler
+    BulkCredit     *BulkCreditHandler
+    Incident       *IncidentHandler
+    ReportJob      *ReportJobHandler
+    Export         *ExportHandler
+    Notification   *NotificationHandler
+    Thresholds     *BalanceThresholdHandler
+    Deprecation    *DeprecationHandler
+    GraphQL        http.Handler
+}
+
+// Middleware groups the request middleware built from shared components such
+// as the token validator and the distributed rate limiter
+type Middleware struct {
+    // Logging assigns correlation IDs and request loggers; optional
+    Logging      gin.HandlerFunc
+    Auth         gin.HandlerFunc
+    RateLimit    gin.HandlerFunc
+    Idempotency  gin.HandlerFunc
+    // BodyCapture captures redacted request bodies for disputes; optional
+    BodyCapture  gin.HandlerFunc
+    // DatabaseMode refuses writes while the database is read-only; optional
+    DatabaseMode gin.HandlerFunc
+}
+
 // SetupRouter configures and initializes the HTTP router with all API routes,
 // middleware, security controls, and monitoring capabilities
-func SetupRouter(router *gin.Engine, cfg *config.Config, handler *WalletHandler) *gin.Engine {
+func SetupRouter(router *gin.Engine, cfg *config.Config, mw Middleware, handlers Handlers) *gin.Engine {
+    handler := handlers.Wallet
+
     // Configure gin mode based on environment
     if cfg.API.Environment == "production" {
         gin.SetMode(gin.ReleaseMode)
@@ -32,43 +180,599 @@ func SetupRouter(router *gin.Engine, cfg *config.Config, handler *WalletHandler)
 
     // Configure global middleware
     router.Use(gin.Recovery())
+    if mw.Logging != nil {
+        router.Use(mw.Logging)
+    }
     router.Use(otelgin.Middleware("wallet-service"))
     router.Use(corsMiddleware())
     router.Use(securityHeaders())
-    router.Use(requestLogger())
-
-    // Configure rate limiter
-    rate := limiter.Rate{
-        Period: cfg.Security.RateLimitWindow,
-        Limit:  int64(cfg.Security.RateLimit),
+    if slo := handlers.SLO; slo != nil {
+        router.Use(slo.Middleware())
     }
-    store := memory.NewStore()
-    rateLimiter := limiter.New(store, rate)
 
     // Health check endpoints
     router.GET(healthPath, healthCheck)
+    if handlers.Health != nil {
+        router.GET(readyzPath, handlers.Health.Readiness)
+    }
     router.GET(metricsPath, gin.WrapH(promhttp.Handler()))
 
+    // Webhooks of payment gateways and partner systems, authenticated by
+    // their provider's signature instead of a JWT
+    if inbound := handlers.Inbound; inbound != nil {
+        router.POST(apiV1+inboundPath+"/:provider", jsonBodyMiddleware(cfg.API.MaxRequestSize), inbound.ReceiveWebhook)
+    }
+
+    // Public API documentation, generated from the handler types
+    router.GET(apiV1+openAPIPath, serveOpenAPI)
+    if cfg.API.SwaggerUI {
+        router.GET(apiV1+docsPath+"/*filepath", serveSwaggerUI)
+    }
+
+    // capability marks responses of routes relying on capabilities that are
+    // degraded or down
+    capability := func(names ...string) gin.HandlerFunc {
+        if handlers.Health == nil {
+            return func(c *gin.Context) { c.Next() }
+        }
+        return handlers.Health.Capability(names...)
+    }
+
+    // tracked records successful reads of customers' balances and
+    // transactions in their access log
+    tracked := func(scope accessScope, actions ...models.DataAccessAction) gin.HandlerFunc {
+        if handlers.AccessLog == nil {
+            return func(c *gin.Context) { c.Next() }
+        }
+        return handlers.AccessLog.Track(scope, actions...)
+    }
+
     // API v1 routes
     v1 := router.Group(apiV1)
     {
-        // Apply authentication and rate limiting middleware
-        v1.Use(authMiddleware(cfg.Security.JWTSecret))
-        v1.Use(rateLimitMiddleware(rateLimiter))
+        // Apply authentication, rate limiting and request body middleware
+        v1.Use(mw.Auth)
+        if cfg.ReadOnly.Enabled {
+            v1.Use(readOnlyMiddleware())
+        }
+        if mw.DatabaseMode != nil {
+            v1.Use(mw.DatabaseMode)
+        }
+        if usage := handlers.Usage; usage != nil {
+            v1.Use(usage.Middleware())
+        }
+        v1.Use(mw.RateLimit)
+        v1.Use(jsonBodyMiddleware(cfg.API.MaxRequestSize))
+
+        // Signal deprecated routes and count their remaining callers
+        if deprecations := handlers.Deprecation; deprecations != nil {
+            v1.Use(deprecations.Middleware())
+        }
+
+        // Capture a sample of bodies as the client sent and received them
+        if mw.BodyCapture != nil {
+            v1.Use(mw.BodyCapture)
+        }
+
+        // Apply the data classification policy to responses
+        if dataAccess := handlers.DataAccess; dataAccess != nil {
+            v1.Use(dataAccess.Middleware())
+        }
 
         // Wallet routes
         wallets := v1.Group(walletsPath)
         {
             // Balance operations
-            wallets.GET("/:id/balance", handler.GetBalance)
+            wallets.GET("/:id/balance", tracked(walletScope, models.DataAccessBalanceViewed), capability(health.CapabilityBalances), handler.GetBalance)
+            wallets.GET("/:id/overview", tracked(walletScope, models.DataAccessBalanceViewed, models.DataAccessTransactionsViewed),
+                capability(health.CapabilityBalances, health.CapabilityTransactions), handler.GetWalletOverview)
             
             // Transaction operations
-            wallets.POST("/:id/transactions", handler.ProcessTransaction)
-            wallets.GET("/:id/transactions", handler.GetTransactions)
+            wallets.POST("/:id/transactions", capability(health.CapabilityTransactions), mw.Idempotency, handler.ProcessTransaction)
+            wallets.GET("/:id/transactions", tracked(walletScope, models.DataAccessTransactionsViewed), capability(health.CapabilityTransactions), handler.GetTransactions)
             
+            // Spending trends, top categories and burn rate projection
+            if spending := handlers.Spending; spending != nil {
+                wallets.GET("/:id/analytics", tracked(walletScope, models.DataAccessTransactionsViewed), capability(health.CapabilityTransactions), spending.GetWalletAnalytics)
+            }
+
             // Wallet health and settings
             wallets.GET("/:id/health", handler.GetWalletHealth)
+            wallets.GET("/:id/settings", handler.GetWalletSettings)
             wallets.PATCH("/:id/settings", handler.UpdateWalletSettings)
+
+            // Warning and critical balance alerts with hysteresis, and
+            // per-wallet alert thresholds re-armed by recharges
+            if thresholds := handlers.Thresholds; thresholds != nil {
+                wallets.GET("/:id/balance-thresholds", thresholds.GetThresholds)
+                wallets.PUT("/:id/balance-thresholds", thresholds.UpdateThresholds)
+                wallets.DELETE("/:id/balance-thresholds", thresholds.DeleteThresholds)
+                wallets.GET("/:id/balance-alerts", thresholds.GetAlerts)
+                wallets.PUT("/:id/balance-alerts", thresholds.SetAlerts)
+            }
+
+            // Refunds of top-ups to their original payment method
+            if refunds := handlers.Refund; refunds != nil {
+                refundCapability := capability(health.CapabilityRefunds)
+                wallets.POST("/:id/transactions/:transaction_id/payment", refundCapability, refunds.RecordPayment)
+                wallets.POST("/:id/transactions/:transaction_id/refund-to-source", refundCapability, mw.Idempotency, refunds.RefundToSource)
+                wallets.GET("/:id/refunds/:refund_id", refundCapability, refunds.GetRefund)
+            }
+
+            // Webhooks scoped to a single wallet, with their own secrets
+            // and delivery logs
+            if webhooks := handlers.Webhook; webhooks != nil {
+                webhookCapability := capability(health.CapabilityWebhooks)
+                wallets.POST("/:id/webhooks", webhookCapability, webhooks.CreateWalletSubscription)
+                wallets.GET("/:id/webhooks", webhookCapability, webhooks.ListWalletSubscriptions)
+                wallets.GET("/:id/webhooks/:webhook_id", webhookCapability, webhooks.GetWalletSubscription)
+                wallets.PUT("/:id/webhooks/:webhook_id", webhookCapability, webhooks.UpdateWalletSubscription)
+                wallets.DELETE("/:id/webhooks/:webhook_id", webhookCapability, webhooks.DeleteWalletSubscription)
+                wallets.GET("/:id/webhooks/:webhook_id/deliveries", webhookCapability, webhooks.ListWalletDeliveries)
+            }
+
+            // Redirect of a duplicate wallet merged into another
+            if merges := handlers.Merge; merges != nil {
+                wallets.GET("/:id/merge", merges.GetWalletRedirect)
+            }
+
+            // Unbilled usage and cycle statements of postpaid customers
+            if postpaid := handlers.Postpaid; postpaid != nil {
+                wallets.GET("/:id/postpaid-usage", tracked(walletScope, models.DataAccessBalanceViewed), capability(health.CapabilityBalances), postpaid.GetUsage)
+            }
+
+            // Free-tier allowances of the current cycle
+            if allowances := handlers.Allowances; allowances != nil {
+                wallets.GET("/:id/allowances", capability(health.CapabilityBalances), allowances.GetAllowances)
+            }
+
+            // Budget buckets splitting the balance, and transfers between
+            // them
+            if buckets := handlers.Buckets; buckets != nil {
+                wallets.GET("/:id/buckets", tracked(walletScope, models.DataAccessBalanceViewed), capability(health.CapabilityBalances), buckets.ListBuckets)
+                wallets.POST("/:id/buckets", buckets.CreateBucket)
+                wallets.PUT("/:id/buckets/:name", buckets.UpdateBucket)
+                wallets.DELETE("/:id/buckets/:name", buckets.DeleteBucket)
+                wallets.POST("/:id/bucket-transfers", capability(health.CapabilityBalances), mw.Idempotency, buckets.TransferFunds)
+            }
+
+            // Live balance and transaction updates as server-sent events
+            if stream := handlers.Stream; stream != nil {
+                wallets.GET("/:id/events", capability(health.CapabilityLiveUpdates), stream.WalletEvents)
+            }
+        }
+
+        // Cross-wallet transaction lookup for billing support
+        v1.GET(transactionsPath, requireRole(adminRole, supportRole), tracked(reportedScope, models.DataAccessTransactionsViewed), handler.SearchTransactions)
+
+        // Transaction lifecycle events over WebSocket
+        if ws := handlers.WebSocket; ws != nil {
+            v1.GET(wsPath, capability(health.CapabilityLiveUpdates), ws.Connect)
+        }
+
+        // Capability matrix reporting partial outages to clients
+        if h := handlers.Health; h != nil {
+            v1.GET(capabilitiesPath, h.GetCapabilities)
+        }
+
+        // Operator administration routes
+        if admin := handlers.Admin; admin != nil {
+            adminRoutes := v1.Group(adminPath)
+            adminRoutes.Use(requireRole(adminRole))
+            {
+                adminRoutes.GET("/runbook", admin.ListRunbookActions)
+                adminRoutes.POST("/runbook/:action", admin.ExecuteRunbookAction)
+                adminRoutes.GET("/audit", admin.ListAuditLog)
+            }
+        }
+
+        // Billing period close and reopening by finance
+        if periods := handlers.BillingPeriod; periods != nil {
+            periodRoutes := v1.Group(periodsPath)
+            periodRoutes.Use(requireRole(adminRole))
+            {
+                periodRoutes.GET("", periods.ListBillingPeriods)
+                periodRoutes.GET("/:period", periods.GetBillingPeriod)
+                periodRoutes.POST("/:period/close", periods.CloseBillingPeriod)
+                periodRoutes.POST("/:period/reopen", periods.ReopenBillingPeriod)
+            }
+        }
+
+        // Wallet moves between deployments, frozen on both sides until cutover
+        if migrations := handlers.Migration; migrations != nil {
+            migrationRoutes := v1.Group(migrationsPath)
+            migrationRoutes.Use(requireRole(adminRole))
+            {
+                migrationRoutes.POST("/imports", migrations.ImportWallet)
+                migrationRoutes.GET("/:id", migrations.GetMigration)
+                migrationRoutes.POST("/:id/export", migrations.ExportWallet)
+                migrationRoutes.POST("/:id/activate", migrations.ActivateWallet)
+                migrationRoutes.POST("/:id/cancel", migrations.CancelExport)
+            }
+        }
+
+        // Duplicate wallet merges, reversible within a window
+        if merges := handlers.Merge; merges != nil {
+            mergeRoutes := v1.Group(mergesPath)
+            mergeRoutes.Use(requireRole(adminRole))
+            {
+                mergeRoutes.POST("", merges.MergeWallets)
+                mergeRoutes.GET("/:id", merges.GetMerge)
+                mergeRoutes.POST("/:id/reverse", merges.ReverseMerge)
+            }
+        }
+
+        // Bulk wallet provisioning for enterprise onboarding, run as a job
+        if provisioning := handlers.Provisioning; provisioning != nil {
+            v1.POST(provisioningPath, requireRole(adminRole), provisioning.ProvisionWallets)
+        }
+
+        // Bulk credits such as goodwill credits after an incident, posted
+        // and rolled back by jobs
+        if bulkCredits := handlers.BulkCredit; bulkCredits != nil {
+            bulkCreditRoutes := v1.Group(bulkCreditsPath)
+            bulkCreditRoutes.Use(requireRole(adminRole))
+            {
+                bulkCreditRoutes.POST("", bulkCredits.SubmitBulkCredit)
+                bulkCreditRoutes.GET("/:id", bulkCredits.GetBulkCredit)
+                bulkCreditRoutes.POST("/:id/rollback", bulkCredits.RollbackBulkCredit)
+            }
+        }
+
+        // Incident switch pausing transaction types, such as automated
+        // debits during a suspected rating bug
+        if incident := handlers.Incident; incident != nil {
+            incidentRoutes := v1.Group(incidentPath)
+            incidentRoutes.Use(requireRole(adminRole))
+            {
+                incidentRoutes.GET("", incident.GetIncident)
+                incidentRoutes.PUT("", incident.PauseTransactions)
+                incidentRoutes.POST("/resume", incident.ResumeTransactions)
+            }
+        }
+
+        // Long-running report jobs such as statements, exports and
+        // reconciliation runs
+        if jobs := handlers.ReportJob; jobs != nil {
+            jobRoutes := v1.Group(jobsPath)
+            jobRoutes.Use(requireRole(adminRole))
+            {
+                jobRoutes.POST("", jobs.SubmitJob)
+                jobRoutes.GET("", jobs.ListJobs)
+                jobRoutes.GET("/kinds", jobs.ListKinds)
+                jobRoutes.GET("/:id", jobs.GetJob)
+                jobRoutes.POST("/:id/cancel", jobs.CancelJob)
+                jobRoutes.GET("/:id/result", jobs.GetResult)
+            }
+        }
+
+        // Bulk transaction exports to object storage for finance
+        if exports := handlers.Export; exports != nil {
+            exportRoutes := v1.Group(exportsPath)
+            exportRoutes.Use(requireRole(adminRole, analyticsRole))
+            {
+                exportRoutes.POST("", tracked(reportedScope, models.DataAccessTransactionsExported), exports.CreateExport)
+                exportRoutes.GET("/:id", exports.GetExport)
+            }
+        }
+
+        // Backlog of notifications awaiting redelivery to the notification
+        // pipeline
+        if notifications := handlers.Notification; notifications != nil {
+            v1.GET(notificationPath, requireRole(adminRole), notifications.GetBacklog)
+        }
+
+        // Issued invoices reported by the invoice service for delivery to
+        // customers
+        if channels := handlers.Channels; channels != nil {
+            v1.POST(notificationPath+"/invoices", requireRole(adminRole, serviceRole), channels.NotifyInvoice)
+        }
+
+        // Per-field access report of classified data
+        if dataAccess := handlers.DataAccess; dataAccess != nil {
+            v1.GET(dataAccessPath, requireRole(adminRole), dataAccess.GetReport)
+        }
+
+        // Monthly internal service usage report for chargeback
+        if usage := handlers.Usage; usage != nil {
+            v1.GET(usagePath, requireRole(adminRole), usage.GetReport)
+        }
+
+        // Chargeback rate card changes, approved by a second operator
+        if rateCards := handlers.RateCard; rateCards != nil {
+            rateCardRoutes := v1.Group(rateCardsPath)
+            rateCardRoutes.Use(requireRole(adminRole))
+            {
+                rateCardRoutes.GET("/current", rateCards.GetCurrent)
+                rateCardRoutes.GET("/changes", rateCards.ListChanges)
+                rateCardRoutes.POST("/changes", rateCards.ProposeChange)
+                rateCardRoutes.GET("/changes/:id", rateCards.GetChange)
+                rateCardRoutes.POST("/changes/:id/approve", rateCards.ApproveChange)
+                rateCardRoutes.POST("/changes/:id/reject", rateCards.RejectChange)
+            }
+        }
+
+        // Manual balance corrections, posted once approved by a second
+        // operator when large and reported apart from customer spend
+        if adjustments := handlers.Adjustment; adjustments != nil {
+            adjustmentRoutes := v1.Group(adjustmentsPath)
+            {
+                adjustmentRoutes.GET("", requireRole(adminRole, approverRole), adjustments.ListAdjustments)
+                adjustmentRoutes.POST("", requireRole(adminRole), adjustments.RequestAdjustment)
+                adjustmentRoutes.GET("/report", requireRole(adminRole), adjustments.GetReport)
+                adjustmentRoutes.GET("/:id", requireRole(adminRole, approverRole), adjustments.GetAdjustment)
+                adjustmentRoutes.POST("/:id/approve", requireRole(approverRole), adjustments.ApproveAdjustment)
+                adjustmentRoutes.POST("/:id/reject", requireRole(approverRole), adjustments.RejectAdjustment)
+            }
+        }
+
+        // Sensitive wallet settings changes, such as credit limit increases,
+        // applied once approved by a second operator
+        if changes := handlers.SettingsChange; changes != nil {
+            changeRoutes := v1.Group(changesPath)
+            {
+                changeRoutes.GET("", requireRole(adminRole, approverRole), changes.ListChanges)
+                changeRoutes.POST("", requireRole(adminRole), changes.RequestChange)
+                changeRoutes.GET("/:id", requireRole(adminRole, approverRole), changes.GetChange)
+                changeRoutes.POST("/:id/approve", requireRole(approverRole), changes.ApproveChange)
+                changeRoutes.POST("/:id/reject", requireRole(approverRole), changes.RejectChange)
+            }
+        }
+
+        // Inbound webhooks whose processing kept failing, replayed once
+        // the cause was fixed
+        if inbound := handlers.Inbound; inbound != nil {
+            deadLetterRoutes := v1.Group(deadLettersPath)
+            deadLetterRoutes.Use(requireRole(adminRole))
+            {
+                deadLetterRoutes.GET("", inbound.ListDeadLetters)
+                deadLetterRoutes.POST("/:id/replay", inbound.ReplayDeadLetter)
+            }
+        }
+
+        // Webhook deliveries and recurring debit runs that failed, retried
+        // with backoff and then left for operators to retry or discard
+        if failedJobs := handlers.FailedJob; failedJobs != nil {
+            failedJobRoutes := v1.Group(failedJobsPath)
+            failedJobRoutes.Use(requireRole(adminRole))
+            {
+                failedJobRoutes.GET("", failedJobs.ListFailedJobs)
+                failedJobRoutes.POST("/retry", failedJobs.RetryFailedJobs)
+                failedJobRoutes.POST("/discard", failedJobs.DiscardFailedJobs)
+                failedJobRoutes.GET("/:id", failedJobs.GetFailedJob)
+                failedJobRoutes.POST("/:id/retry", failedJobs.RetryFailedJob)
+                failedJobRoutes.POST("/:id/discard", failedJobs.DiscardFailedJob)
+            }
+        }
+
+        // Chargebacks of top-ups, opened by operators or payment provider
+        // integrations and holding the disputed amount until resolved
+        if disputes := handlers.Dispute; disputes != nil {
+            disputeRoutes := v1.Group(disputesPath)
+            disputeRoutes.Use(requireRole(adminRole, serviceRole))
+            {
+                disputeRoutes.GET("", disputes.ListDisputes)
+                disputeRoutes.POST("", disputes.OpenDispute)
+                disputeRoutes.GET("/:id", disputes.GetDispute)
+                disputeRoutes.POST("/:id/evidence", disputes.SubmitEvidence)
+                disputeRoutes.POST("/:id/resolve", disputes.ResolveDispute)
+            }
+        }
+
+        // Sales tax charged on invoices by invoice generation, and the tax
+        // profiles and reports kept by operators
+        if taxes := handlers.Tax; taxes != nil {
+            taxRoutes := v1.Group(taxPath)
+            {
+                taxRoutes.POST("/invoices/quote", requireRole(adminRole, serviceRole), taxes.QuoteInvoice)
+                taxRoutes.POST("/invoices", requireRole(adminRole, serviceRole), taxes.TaxInvoice)
+                taxRoutes.PUT("/customers/:customer_id", requireRole(adminRole), taxes.UpdateProfile)
+                taxRoutes.GET("/report", requireRole(adminRole), taxes.GetReport)
+            }
+        }
+
+        // Effective-dated fee rules, and previews of the fee a transaction
+        // would incur under them
+        if fees := handlers.Fee; fees != nil {
+            v1.POST(feesPath+"/preview", fees.PreviewFee)
+
+            feeRoutes := v1.Group(feeRulesPath)
+            feeRoutes.Use(requireRole(adminRole))
+            {
+                feeRoutes.GET("", fees.ListRules)
+                feeRoutes.POST("", fees.CreateRule)
+                feeRoutes.GET("/:id", fees.GetRule)
+                feeRoutes.POST("/:id/end", fees.EndRule)
+            }
+        }
+
+        // Coupons and negotiated discounts kept by operators, applied by
+        // rating and invoice generation
+        if coupons := handlers.Coupon; coupons != nil {
+            couponRoutes := v1.Group(couponsPath)
+            couponRoutes.Use(requireRole(adminRole, serviceRole))
+            {
+                couponRoutes.POST("/quote", coupons.QuoteCoupon)
+                couponRoutes.POST("/redeem", coupons.RedeemCoupon)
+            }
+
+            adminCouponRoutes := v1.Group(adminCouponsPath)
+            adminCouponRoutes.Use(requireRole(adminRole))
+            {
+                adminCouponRoutes.GET("", coupons.ListCoupons)
+                adminCouponRoutes.POST("", coupons.CreateCoupon)
+                adminCouponRoutes.GET("/:id", coupons.GetCoupon)
+                adminCouponRoutes.POST("/:id/void", coupons.VoidCoupon)
+            }
+        }
+
+        // Dry runs of debits through discounts, fees, conversion and tax
+        if estimates := handlers.Estimate; estimates != nil {
+            v1.POST(estimatesPath, capability(health.CapabilityBalances), estimates.Estimate)
+        }
+
+        // Endpoint SLO compliance and burn rates
+        if slo := handlers.SLO; slo != nil {
+            v1.GET(sloPath, requireRole(adminRole), slo.GetStatus)
+        }
+
+        // Integrations still calling deprecated routes
+        if deprecations := handlers.Deprecation; deprecations != nil {
+            v1.GET(deprecationsPath, requireRole(adminRole), deprecations.GetReport)
+        }
+
+        // Activity digest opt-in routes for the authenticated customer
+        if digest := handlers.Digest; digest != nil {
+            v1.GET(digestPath, digest.GetSubscription)
+            v1.PUT(digestPath, digest.UpdateSubscription)
+        }
+
+        // Wallets of a customer taken together, with a total in the
+        // reporting currency
+        if customers := handlers.Customers; customers != nil {
+            customerRoutes := v1.Group(customersPath)
+            customerRoutes.Use(capability(health.CapabilityBalances))
+            {
+                customerRoutes.GET("/:id/wallets", tracked(customerScope, models.DataAccessBalanceViewed), customers.ListWallets)
+                customerRoutes.GET("/:id/balance", tracked(customerScope, models.DataAccessBalanceViewed), customers.GetBalance)
+            }
+        }
+
+        // Account-wide settings of the authenticated customer
+        if customer := handlers.Customer; customer != nil {
+            v1.GET(customerPath, customer.GetSettings)
+            v1.PATCH(customerPath, customer.UpdateSettings)
+        }
+
+        // Notification consent routes for the authenticated customer
+        if consent := handlers.Consent; consent != nil {
+            consentRoutes := v1.Group(consentsPath)
+            {
+                consentRoutes.GET("", consent.GetConsents)
+                consentRoutes.GET("/history", consent.GetHistory)
+                consentRoutes.PUT("/:channel", consent.UpdateConsent)
+            }
+        }
+
+        // Webhook subscription routes for the authenticated customer
+        if webhooks := handlers.Webhook; webhooks != nil {
+            webhookRoutes := v1.Group(webhooksPath)
+            webhookRoutes.Use(capability(health.CapabilityWebhooks))
+            {
+                webhookRoutes.POST("", webhooks.CreateSubscription)
+                webhookRoutes.GET("", webhooks.ListSubscriptions)
+                webhookRoutes.GET("/:id", webhooks.GetSubscription)
+                webhookRoutes.PUT("/:id", webhooks.UpdateSubscription)
+                webhookRoutes.DELETE("/:id", webhooks.DeleteSubscription)
+                webhookRoutes.GET("/:id/deliveries", webhooks.ListDeliveries)
+            }
+        }
+
+        // Email, SMS and Slack notification channels of the authenticated
+        // customer
+        if channels := handlers.Channels; channels != nil {
+            channelRoutes := v1.Group(channelsPath)
+            {
+                channelRoutes.POST("", channels.CreateChannel)
+                channelRoutes.GET("", channels.ListChannels)
+                channelRoutes.GET("/:id", channels.GetChannel)
+                channelRoutes.PUT("/:id", channels.UpdateChannel)
+                channelRoutes.DELETE("/:id", channels.DeleteChannel)
+            }
+        }
+
+        // Accesses to the authenticated customer's balances and transactions
+        if accessLog := handlers.AccessLog; accessLog != nil {
+            v1.GET(accessLogPath, accessLog.ListAccesses)
+        }
+
+        // Invoice PDFs issued at billing cycle close, downloadable by the
+        // customer billed
+        if invoices := handlers.Invoices; invoices != nil {
+            invoiceRoutes := v1.Group(invoicesPath)
+            {
+                invoiceRoutes.POST("", requireRole(adminRole, serviceRole), invoices.IssueInvoice)
+                invoiceRoutes.GET("/:id/pdf", invoices.GetPDF)
+                invoiceRoutes.POST("/:id/resend", requireRole(adminRole, serviceRole), invoices.ResendInvoice)
+            }
+        }
+
+        // Payment terms of customers, and the payments, late charges and
+        // aging of issued invoices
+        if receivables := handlers.Receivables; receivables != nil {
+            v1.PUT(customersPath+"/:id/payment-terms", requireRole(adminRole), receivables.UpdatePaymentTerms)
+            v1.GET(invoicesPath+"/:id", receivables.GetInvoice)
+            v1.POST(invoicesPath+"/:id/payments", requireRole(adminRole, serviceRole), receivables.RecordPayment)
+            v1.GET(agingPath, requireRole(adminRole), receivables.GetAging)
+        }
+
+        // Prepaid or postpaid billing of customers
+        if postpaid := handlers.Postpaid; postpaid != nil {
+            v1.PUT(customersPath+"/:id/billing-mode", requireRole(adminRole), postpaid.UpdateBillingMode)
+        }
+
+        // Organizations owning customers and their wallets, created by
+        // operators and overseen by the admins of each organization
+        if organizations := handlers.Organizations; organizations != nil {
+            v1.POST(orgsPath, requireRole(adminRole), organizations.CreateOrganization)
+            v1.PUT(customersPath+"/:id/organization", requireRole(adminRole), organizations.AssignCustomer)
+            orgRoutes := v1.Group(orgsPath)
+            orgRoutes.Use(requireOrganizationAdmin())
+            {
+                orgRoutes.GET("/:id", capability(health.CapabilityBalances), organizations.GetOrganization)
+                orgRoutes.GET("/:id/wallets", capability(health.CapabilityBalances), organizations.ListWallets)
+                orgRoutes.GET("/:id/invoices/:period", organizations.GetConsolidatedInvoice)
+            }
+        }
+
+        // Recurring debit routes for the authenticated customer
+        if recurring := handlers.Recurring; recurring != nil {
+            recurringRoutes := v1.Group(recurringPath)
+            recurringRoutes.Use(capability(health.CapabilityRecurringDebits))
+            {
+                recurringRoutes.POST("", recurring.CreateRecurringDebit)
+                recurringRoutes.GET("", recurring.ListRecurringDebits)
+                recurringRoutes.GET("/:id", recurring.GetRecurringDebit)
+                recurringRoutes.DELETE("/:id", recurring.CancelRecurringDebit)
+                recurringRoutes.GET("/:id/runs", recurring.ListRuns)
+            }
+        }
+
+        // Ledger reconciliation routes
+        if recon := handlers.Reconciliation; recon != nil {
+            reconRoutes := v1.Group(reconPath)
+            reconRoutes.Use(requireRole(adminRole))
+            {
+                reconRoutes.GET("", recon.ListIssues)
+                reconRoutes.GET("/:id", recon.GetIssue)
+            }
+        }
+
+        // Product analytics routes exposing only privacy-safe aggregates
+        if analytics := handlers.Analytics; analytics != nil {
+            analyticsRoutes := v1.Group(analyticsPath)
+            analyticsRoutes.Use(requireRole(analyticsRole))
+            {
+                analyticsRoutes.GET("/adoption", analytics.GetAdoptionStats)
+            }
+        }
+    }
+
+    // GraphQL API for dashboard consumers, authenticated like the REST API
+    if handlers.GraphQL != nil {
+        gql := router.Group(graphqlPath)
+        gql.Use(mw.Auth)
+        if usage := handlers.Usage; usage != nil {
+            gql.Use(usage.Middleware())
+        }
+        gql.Use(mw.RateLimit)
+        gql.Use(jsonBodyMiddleware(cfg.API.MaxRequestSize))
+        if deprecations := handlers.Deprecation; deprecations != nil {
+            gql.Use(deprecations.Middleware())
+        }
+        {
+            gql.GET("", serveGraphQL(handlers.GraphQL))
+            gql.POST("", serveGraphQL(handlers.GraphQL))
         }
     }
 
@@ -79,8 +783,9 @@ func SetupRouter(router *gin.Engine, cfg *config.Config, handler *WalletHandler)
 func corsMiddleware() gin.HandlerFunc {
     return func(c *gin.Context) {
         c.Header("Access-Control-Allow-Origin", "*")
-        c.Header("Access-Control-Allow-Methods", "GET, POST, PATCH, OPTIONS")
-        c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type, Idempotency-Key")
+        c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+        c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type, Idempotency-Key, "+correlationIDHeader)
+        c.Header("Access-Control-Expose-Headers", correlationIDHeader+", Deprecation, Sunset, Link")
         c.Header("Access-Control-Max-Age", "86400")
 
         if c.Request.Method == "OPTIONS" {
@@ -104,65 +809,120 @@ func securityHeaders() gin.HandlerFunc {
     }
 }
 
-// requestLogger implements structured logging for HTTP requests
-func requestLogger() gin.HandlerFunc {
-    return gin.LoggerWithConfig(gin.LoggerConfig{
-        SkipPaths: []string{healthPath, metricsPath},
-        Formatter: func(params gin.LogFormatterParams) string {
-            return ""
-        },
-    })
+// readOnlyRoutes are the routes taking a body that change no data, served
+// by read-only deployments too
+var readOnlyRoutes = map[string]bool{
+    apiV1 + feesPath + "/preview":       true,
+    apiV1 + taxPath + "/invoices/quote": true,
+    apiV1 + couponsPath + "/quote":      true,
+    apiV1 + estimatesPath:               true,
 }
 
-// authMiddleware validates JWT tokens and enforces authentication
-func authMiddleware(jwtSecret string) gin.HandlerFunc {
+// mayChangeData reports whether a request could change data, as every
+// request but reads and the read-only routes may
+func mayChangeData(c *gin.Context) bool {
+    switch c.Request.Method {
+    case http.MethodGet, http.MethodHead, http.MethodOptions:
+        return false
+    }
+    return !readOnlyRoutes[c.FullPath()]
+}
+
+// readOnlyMiddleware refuses requests that could change data on read-only
+// deployments, pointing clients back to the primary API
+func readOnlyMiddleware() gin.HandlerFunc {
     return func(c *gin.Context) {
-        token := c.GetHeader("Authorization")
-        if token == "" {
-            c.AbortWithStatusJSON(http.StatusUnauthorized, Response{
-                Status: "error",
-                Error:  "missing authorization token",
-            })
+        if !mayChangeData(c) {
+            c.Next()
             return
         }
 
-        // TODO: Implement JWT validation logic here
-        // This would typically validate the token signature, expiry, and claims
-
-        c.Next()
+        c.Header("Allow", "GET, HEAD")
+        respondError(c, apierror.New(apierror.CodeReadOnlyDeployment))
     }
 }
 
-// rateLimitMiddleware enforces rate limiting per client
-func rateLimitMiddleware(limiter *limiter.Limiter) gin.HandlerFunc {
+// csvBodyRoutes are the routes that also take CSV bodies
+var csvBodyRoutes = map[string]bool{
+    apiV1 + provisioningPath: true,
+    apiV1 + bulkCreditsPath:  true,
+}
+
+// jsonBodyMiddleware enforces the configured request size limit and requires
+// a well-formed JSON body on POST, PUT and PATCH requests, or CSV on the
+// routes taking it
+func jsonBodyMiddleware(maxSize int) gin.HandlerFunc {
+    limit := int64(maxSize)
+
     return func(c *gin.Context) {
-        key := c.ClientIP()
-        context, err := limiter.Get(c, key)
-        
-        if err != nil {
-            c.AbortWithStatusJSON(http.StatusInternalServerError, Response{
-                Status: "error",
-                Error:  "rate limit error",
-            })
+        switch c.Request.Method {
+        case http.MethodPost, http.MethodPut, http.MethodPatch:
+        default:
+            c.Next()
             return
         }
 
-        c.Header("X-RateLimit-Limit", string(context.Limit))
-        c.Header("X-RateLimit-Remaining", string(context.Remaining))
-        c.Header("X-RateLimit-Reset", string(context.Reset))
+        if c.Request.ContentLength > limit {
+            respondError(c, apierror.New(apierror.CodePayloadTooLarge).WithDetails("maximum request size is %d bytes", limit))
+            return
+        }
 
-        if context.Reached {
-            c.AbortWithStatusJSON(http.StatusTooManyRequests, Response{
-                Status: "error",
-                Error:  "rate limit exceeded",
-            })
+        body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+        if err != nil {
+            var maxBytesErr *http.MaxBytesError
+            if errors.As(err, &maxBytesErr) {
+                respondError(c, apierror.Wrap(apierror.CodePayloadTooLarge, err).WithDetails("maximum request size is %d bytes", limit))
+                return
+            }
+            respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err))
             return
         }
 
+        csv := c.ContentType() == mimeCSV && csvBodyRoutes[c.FullPath()]
+        if len(body) > 0 && !csv {
+            if c.ContentType() != gin.MIMEJSON {
+                respondError(c, apierror.New(apierror.CodeUnsupportedMediaType))
+                return
+            }
+            if !json.Valid(body) {
+                respondError(c, apierror.New(apierror.CodeInvalidRequest).WithDetails("malformed JSON body"))
+                return
+            }
+        }
+
+        c.Request.Body = io.NopCloser(bytes.NewReader(body))
         c.Next()
     }
 }
 
+// requireRole restricts access to principals holding any of the given roles
+func requireRole(roles ...string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        for _, role := range roles {
+            if hasRole(c, role) {
+                c.Next()
+                return
+            }
+        }
+
+        respondError(c, apierror.New(apierror.CodeForbidden))
+    }
+}
+
+// hasRole reports whether the authenticated caller was granted role
+func hasRole(c *gin.Context, role string) bool {
+    if roles, ok := c.Get("roles"); ok {
+        if list, ok := roles.([]string); ok {
+            for _, r := range list {
+                if r == role {
+                    return true
+                }
+            }
+        }
+    }
+    return false
+}
+
 // healthCheck handles the health check endpoint
 func healthCheck(c *gin.Context) {
     c.JSON(http.StatusOK, gin.H{
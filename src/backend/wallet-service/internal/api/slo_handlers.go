@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/slo"
+)
+
+// SLOHandler records requests against the endpoint SLOs and reports their
+// error budget burn rates
+type SLOHandler struct {
+	tracker *slo.Tracker
+}
+
+// NewSLOHandler creates a new instance of SLOHandler
+func NewSLOHandler(tracker *slo.Tracker) (*SLOHandler, error) {
+	if tracker == nil {
+		return nil, errors.New("SLO tracker is required")
+	}
+
+	return &SLOHandler{
+		tracker: tracker,
+	}, nil
+}
+
+// Middleware records the status and latency of every routed request.
+// Unrouted requests have no endpoint and are not counted.
+func (h *SLOHandler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			return
+		}
+		h.tracker.Record(c.Request.Method, route, c.Writer.Status(), time.Since(start), start)
+	}
+}
+
+// GetStatus handles GET /admin/slo endpoint
+func (h *SLOHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   h.tracker.Status(time.Now()),
+	})
+}
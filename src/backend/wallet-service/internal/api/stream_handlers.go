@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/service"
+	"internal/walletfeed"
+)
+
+// sseRetry is the reconnection delay suggested to event stream clients
+const sseRetry = 3 * time.Second
+
+// StreamHandler handles server-sent event streams of wallet updates
+type StreamHandler struct {
+	wallets   service.WalletService
+	feed      walletfeed.Feed
+	heartbeat time.Duration
+}
+
+// NewStreamHandler creates a new instance of StreamHandler. Idle streams
+// send a heartbeat every heartbeat so proxies keep them open.
+func NewStreamHandler(wallets service.WalletService, feed walletfeed.Feed, heartbeat time.Duration) (*StreamHandler, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if feed == nil {
+		return nil, errors.New("wallet feed is required")
+	}
+	if heartbeat <= 0 {
+		return nil, errors.New("heartbeat interval must be positive")
+	}
+
+	return &StreamHandler{
+		wallets:   wallets,
+		feed:      feed,
+		heartbeat: heartbeat,
+	}, nil
+}
+
+// WalletEvents handles GET /wallets/:id/events endpoint, streaming balance
+// and transaction updates of one of the customer's wallets as server-sent
+// events. New streams start with the current balance. Reconnecting clients
+// send the Last-Event-ID header and get the updates they missed, or the
+// current balance when those are no longer available.
+func (h *StreamHandler) WalletEvents(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var lastID int64
+	if header := c.GetHeader("Last-Event-ID"); header != "" {
+		lastID, err = strconv.ParseInt(header, 10, 64)
+		if err != nil || lastID < 0 {
+			respondError(c, apierror.New(apierror.CodeInvalidRequest).WithDetails("Last-Event-ID must be an event ID of this stream"))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	if err := h.authorize(ctx, walletID, customerID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	updates, complete, err := h.feed.Subscribe(ctx, walletID, lastID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Streams outlive the server write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetry.Milliseconds()); err != nil {
+		return
+	}
+
+	if lastID == 0 || !complete {
+		// Read after subscribing, so no later change is missed
+		wallet, err := h.wallets.GetWallet(ctx, walletID)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		if err := writeEvent(c.Writer, walletfeed.Update{Kind: walletfeed.KindBalance}, walletfeed.NewBalance(wallet)); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			if err := writeEvent(c.Writer, update, update.Data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// authorize checks that the wallet belongs to the customer. Other
+// customers' wallets are reported as not found.
+func (h *StreamHandler) authorize(ctx context.Context, walletID, customerID uuid.UUID) error {
+	wallet, err := h.wallets.GetWallet(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	if wallet.CustomerID != customerID {
+		return service.ErrWalletNotFound
+	}
+	return nil
+}
+
+// writeEvent writes one server-sent event. Updates without an ID, such as
+// the opening balance, leave the client's last event ID unchanged.
+func writeEvent(w io.Writer, update walletfeed.Update, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if update.ID > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", update.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", update.Kind, encoded)
+	return err
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// taxReportWindow is the default window of tax reports
+const taxReportWindow = 30 * 24 * time.Hour
+
+// TaxHandler handles HTTP requests for the tax of invoices, the tax
+// profiles of customers and tax reports
+type TaxHandler struct {
+	service service.TaxService
+}
+
+// invoiceTaxRequest is the body of POST /tax/invoices and its quotes. The
+// invoice number is required when the tax is recorded.
+type invoiceTaxRequest struct {
+	CustomerID    uuid.UUID            `json:"customer_id" binding:"required"`
+	InvoiceNumber string               `json:"invoice_number"`
+	Currency      string               `json:"currency" binding:"required"`
+	Items         []models.TaxableItem `json:"items" binding:"required"`
+}
+
+// taxProfileRequest is the body of PUT /tax/customers/:customer_id
+type taxProfileRequest struct {
+	Region string `json:"region"`
+	Exempt bool   `json:"exempt"`
+}
+
+// NewTaxHandler creates a new instance of TaxHandler
+func NewTaxHandler(service service.TaxService) (*TaxHandler, error) {
+	if service == nil {
+		return nil, errors.New("tax service is required")
+	}
+
+	return &TaxHandler{service: service}, nil
+}
+
+// QuoteInvoice handles POST /tax/invoices/quote endpoint, returning the tax
+// lines of an invoice without recording them
+func (h *TaxHandler) QuoteInvoice(c *gin.Context) {
+	invoice, ok := bindInvoiceTax(c)
+	if !ok {
+		return
+	}
+
+	breakdown, err := h.service.QuoteInvoice(c.Request.Context(), invoice)
+	if err != nil {
+		respondTaxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   breakdown,
+	})
+}
+
+// TaxInvoice handles POST /tax/invoices endpoint, called by invoice
+// generation to charge and record the tax lines of an invoice
+func (h *TaxHandler) TaxInvoice(c *gin.Context) {
+	invoice, ok := bindInvoiceTax(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.service.TaxInvoice(c.Request.Context(), invoice)
+	if err != nil {
+		respondTaxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   record,
+	})
+}
+
+// UpdateProfile handles PUT /tax/customers/:customer_id endpoint
+func (h *TaxHandler) UpdateProfile(c *gin.Context) {
+	customerID, err := uuid.Parse(c.Param("customer_id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid customer ID"))
+		return
+	}
+
+	var req taxProfileRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	settings, err := h.service.UpdateProfile(c.Request.Context(), customerID, req.Region, req.Exempt, actorFromContext(c))
+	if err != nil {
+		respondTaxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   settings,
+	})
+}
+
+// GetReport handles GET /tax/report endpoint, totalling the tax recorded in
+// a window for filing
+func (h *TaxHandler) GetReport(c *gin.Context) {
+	to := time.Now().UTC()
+	from := to.Add(-taxReportWindow)
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%s must be an RFC 3339 timestamp", param.name))
+			return
+		}
+		*param.value = parsed
+	}
+
+	report, err := h.service.Report(c.Request.Context(), from, to)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   report,
+	})
+}
+
+// bindInvoiceTax binds an invoice tax request, responding when it is invalid
+func bindInvoiceTax(c *gin.Context) (service.InvoiceTax, bool) {
+	var req invoiceTaxRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return service.InvoiceTax{}, false
+	}
+
+	return service.InvoiceTax{
+		CustomerID:    req.CustomerID,
+		InvoiceNumber: req.InvoiceNumber,
+		Currency:      req.Currency,
+		Items:         req.Items,
+	}, true
+}
+
+// respondTaxError responds with the validation failure as details so
+// callers can tell why the request was refused
+func respondTaxError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidTaxRequest) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+
+	"internal/apierror"
+	"internal/service"
+	"internal/usage"
+)
+
+// UsageHandler accounts the load internal services put on the API and
+// reports it monthly for chargeback
+type UsageHandler struct {
+	service service.UsageService
+}
+
+// NewUsageHandler creates a new instance of UsageHandler
+func NewUsageHandler(service service.UsageService) (*UsageHandler, error) {
+	if service == nil {
+		return nil, errors.New("usage service is required")
+	}
+
+	return &UsageHandler{
+		service: service,
+	}, nil
+}
+
+// Middleware meters the requests of principals holding the service role,
+// identified by their token subject, counting the request, the time spent
+// in database calls and the response bytes. Other callers pass through.
+func (h *UsageHandler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := c.GetString("subject")
+		if caller == "" || !hasRole(c, serviceRole) {
+			c.Next()
+			return
+		}
+
+		ctx, meter := usage.WithMeter(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		start := time.Now()
+
+		c.Next()
+
+		bytesServed := int64(c.Writer.Size())
+		if bytesServed < 0 {
+			bytesServed = 0
+		}
+		h.service.Record(caller, start, meter.DBTime(), bytesServed)
+	}
+}
+
+// GetReport handles GET /admin/usage endpoint
+func (h *UsageHandler) GetReport(c *gin.Context) {
+	month := time.Now().UTC()
+	if value := c.Query("month"); value != "" {
+		parsed, err := time.Parse(service.UsageMonthLayout, value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("month must be formatted as YYYY-MM"))
+			return
+		}
+		month = parsed
+	}
+
+	report, err := h.service.GetMonthlyReport(c.Request.Context(), month)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   report,
+	})
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// defaultTopSpendCategories is the default number of spend categories listed
+const defaultTopSpendCategories = 5
+
+// WalletAnalyticsHandler handles HTTP requests for the spending summaries of
+// wallets
+type WalletAnalyticsHandler struct {
+	service service.WalletAnalyticsService
+}
+
+// NewWalletAnalyticsHandler creates a new instance of WalletAnalyticsHandler
+func NewWalletAnalyticsHandler(service service.WalletAnalyticsService) (*WalletAnalyticsHandler, error) {
+	if service == nil {
+		return nil, errors.New("wallet analytics service is required")
+	}
+
+	return &WalletAnalyticsHandler{service: service}, nil
+}
+
+// GetWalletAnalytics handles GET /wallets/:id/analytics endpoint
+func (h *WalletAnalyticsHandler) GetWalletAnalytics(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultAnalyticsWindowDays)))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("days must be an integer"))
+		return
+	}
+	top, err := strconv.Atoi(c.DefaultQuery("top", strconv.Itoa(defaultTopSpendCategories)))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("top must be an integer"))
+		return
+	}
+
+	analytics, err := h.service.GetWalletAnalytics(c.Request.Context(), walletID, service.WalletAnalyticsQuery{
+		Granularity:   models.AnalyticsGranularity(c.DefaultQuery("granularity", string(models.GranularityDay))),
+		Window:        time.Duration(days) * 24 * time.Hour,
+		TopCategories: top,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   analytics,
+	})
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// WalletBucketHandler handles the budget buckets wallets are split into
+type WalletBucketHandler struct {
+	service service.WalletBucketService
+}
+
+// createBucketRequest is the body of POST /wallets/:id/buckets. An omitted
+// fallback is NONE, and an omitted threshold never alerts.
+type createBucketRequest struct {
+	Name                string                `json:"name" binding:"required"`
+	LowBalanceThreshold decimal.Decimal       `json:"low_balance_threshold"`
+	Fallback            models.BucketFallback `json:"fallback"`
+}
+
+// updateBucketRequest is the body of PUT /wallets/:id/buckets/:name
+type updateBucketRequest struct {
+	LowBalanceThreshold decimal.Decimal       `json:"low_balance_threshold"`
+	Fallback            models.BucketFallback `json:"fallback"`
+}
+
+// bucketTransferRequest is the body of POST /wallets/:id/bucket-transfers.
+// An omitted from or to is the wallet's unallocated balance.
+type bucketTransferRequest struct {
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Amount decimal.Decimal `json:"amount" binding:"required"`
+}
+
+// NewWalletBucketHandler creates a new instance of WalletBucketHandler
+func NewWalletBucketHandler(service service.WalletBucketService) (*WalletBucketHandler, error) {
+	if service == nil {
+		return nil, errors.New("bucket service is required")
+	}
+
+	return &WalletBucketHandler{service: service}, nil
+}
+
+// ListBuckets handles GET /wallets/:id/buckets endpoint, returning the
+// wallet's buckets and its unallocated balance
+func (h *WalletBucketHandler) ListBuckets(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	buckets, err := h.service.List(c.Request.Context(), walletID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   buckets,
+	})
+}
+
+// CreateBucket handles POST /wallets/:id/buckets endpoint
+func (h *WalletBucketHandler) CreateBucket(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	var req createBucketRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	bucket, err := h.service.Create(c.Request.Context(), walletID, req.Name, service.BucketInput{
+		LowBalanceThreshold: req.LowBalanceThreshold,
+		Fallback:            req.Fallback,
+	})
+	if err != nil {
+		respondError(c, bucketError(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   bucket,
+	})
+}
+
+// UpdateBucket handles PUT /wallets/:id/buckets/:name endpoint
+func (h *WalletBucketHandler) UpdateBucket(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	var req updateBucketRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	bucket, err := h.service.Update(c.Request.Context(), walletID, c.Param("name"), service.BucketInput{
+		LowBalanceThreshold: req.LowBalanceThreshold,
+		Fallback:            req.Fallback,
+	})
+	if err != nil {
+		respondError(c, bucketError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   bucket,
+	})
+}
+
+// DeleteBucket handles DELETE /wallets/:id/buckets/:name endpoint
+func (h *WalletBucketHandler) DeleteBucket(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), walletID, c.Param("name")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TransferFunds handles POST /wallets/:id/bucket-transfers endpoint,
+// returning the buckets funds moved in or out of
+func (h *WalletBucketHandler) TransferFunds(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	var req bucketTransferRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	buckets, err := h.service.Transfer(c.Request.Context(), &models.BucketTransfer{
+		WalletID: walletID,
+		From:     req.From,
+		To:       req.To,
+		Amount:   req.Amount,
+	})
+	if err != nil {
+		respondError(c, bucketError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   buckets,
+	})
+}
+
+// bucketError adds the reason to errors about bucket settings and
+// transfers
+func bucketError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidBucket):
+		return apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	case errors.Is(err, service.ErrInsufficientBucketBalance):
+		return apierror.Wrap(apierror.CodeInsufficientBalance, err).WithDetails("%v", err)
+	}
+	return err
+}
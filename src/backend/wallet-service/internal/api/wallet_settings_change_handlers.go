@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+)
+
+// WalletSettingsChangeHandler handles HTTP requests for sensitive wallet
+// settings changes, staged until approved by a second operator
+type WalletSettingsChangeHandler struct {
+	service service.WalletSettingsChangeService
+}
+
+// settingsChangeRequest is the body of POST /admin/settings-changes
+type settingsChangeRequest struct {
+	WalletID    uuid.UUID        `json:"wallet_id" binding:"required"`
+	CreditLimit *decimal.Decimal `json:"credit_limit" binding:"required"`
+	Reason      string           `json:"reason" binding:"required"`
+}
+
+// settingsChangeReviewRequest is the body of settings change approvals and
+// rejections
+type settingsChangeReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// NewWalletSettingsChangeHandler creates a new instance of
+// WalletSettingsChangeHandler
+func NewWalletSettingsChangeHandler(service service.WalletSettingsChangeService) (*WalletSettingsChangeHandler, error) {
+	if service == nil {
+		return nil, errors.New("settings change service is required")
+	}
+
+	return &WalletSettingsChangeHandler{service: service}, nil
+}
+
+// ListChanges handles GET /admin/settings-changes endpoint, listing the
+// latest changes newest first
+func (h *WalletSettingsChangeHandler) ListChanges(c *gin.Context) {
+	filter := models.WalletSettingsChangeFilter{Status: models.WalletSettingsChangeStatus(c.Query("status"))}
+	if value := c.Query("wallet_id"); value != "" {
+		walletID, err := uuid.Parse(value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+			return
+		}
+		filter.WalletID = &walletID
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("limit must be an integer"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	changes, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		respondSettingsChangeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   changes,
+	})
+}
+
+// GetChange handles GET /admin/settings-changes/:id endpoint
+func (h *WalletSettingsChangeHandler) GetChange(c *gin.Context) {
+	id, ok := parseSettingsChangeID(c)
+	if !ok {
+		return
+	}
+
+	change, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   change,
+	})
+}
+
+// RequestChange handles POST /admin/settings-changes endpoint, staging a
+// credit limit increase until another operator approves it
+func (h *WalletSettingsChangeHandler) RequestChange(c *gin.Context) {
+	var req settingsChangeRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	change, err := h.service.RequestCreditLimit(c.Request.Context(), req.WalletID, *req.CreditLimit, actorFromContext(c), req.Reason)
+	if err != nil {
+		respondSettingsChangeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   change,
+	})
+}
+
+// ApproveChange handles POST /admin/settings-changes/:id/approve endpoint
+func (h *WalletSettingsChangeHandler) ApproveChange(c *gin.Context) {
+	h.review(c, h.service.Approve)
+}
+
+// RejectChange handles POST /admin/settings-changes/:id/reject endpoint
+func (h *WalletSettingsChangeHandler) RejectChange(c *gin.Context) {
+	h.review(c, h.service.Reject)
+}
+
+// review binds a review request and records it with the given outcome
+func (h *WalletSettingsChangeHandler) review(c *gin.Context, outcome func(ctx context.Context, id uuid.UUID, actor, note string) (*models.WalletSettingsChange, error)) {
+	id, ok := parseSettingsChangeID(c)
+	if !ok {
+		return
+	}
+
+	var req settingsChangeReviewRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	change, err := outcome(c.Request.Context(), id, actorFromContext(c), req.Note)
+	if err != nil {
+		respondSettingsChangeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   change,
+	})
+}
+
+// parseSettingsChangeID parses the settings change ID path parameter,
+// responding when it is invalid
+func parseSettingsChangeID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid settings change ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondSettingsChangeError responds with the validation failure or
+// conflict as details so operators can tell why the change was refused
+func respondSettingsChangeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidSettingsChange):
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	case errors.Is(err, service.ErrSettingsChangeConflict):
+		err = apierror.Wrap(apierror.CodeSettingsChangeConflict, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
@@ -0,0 +1,381 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin" // v1.9.1
+	"github.com/google/uuid"   // v1.3.0
+
+	"internal/apierror"
+	"internal/service"
+)
+
+// WebhookHandler handles HTTP requests for customer webhook subscriptions
+type WebhookHandler struct {
+	service service.WebhookService
+}
+
+// webhookRequest is the body of webhook create and update requests. Omitted
+// rule lists mean all event types, all wallets and the full payload.
+type webhookRequest struct {
+	URL        string      `json:"url" binding:"required"`
+	EventTypes []string    `json:"event_types"`
+	WalletIDs  []uuid.UUID `json:"wallet_ids"`
+	Fields     []string    `json:"fields"`
+	Enabled    *bool       `json:"enabled"`
+}
+
+// input converts the request into service input, enabling by default
+func (r *webhookRequest) input() service.WebhookInput {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+
+	return service.WebhookInput{
+		URL:        r.URL,
+		EventTypes: r.EventTypes,
+		WalletIDs:  r.WalletIDs,
+		Fields:     r.Fields,
+		Enabled:    enabled,
+	}
+}
+
+// NewWebhookHandler creates a new instance of WebhookHandler
+func NewWebhookHandler(service service.WebhookService) (*WebhookHandler, error) {
+	if service == nil {
+		return nil, errors.New("webhook service is required")
+	}
+
+	return &WebhookHandler{service: service}, nil
+}
+
+// CreateSubscription handles POST /webhooks endpoint
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req webhookRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	sub, err := h.service.CreateSubscription(c.Request.Context(), customerID, req.input())
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   sub,
+	})
+}
+
+// ListSubscriptions handles GET /webhooks endpoint
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	subs, err := h.service.ListSubscriptions(c.Request.Context(), customerID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   subs,
+	})
+}
+
+// GetSubscription handles GET /webhooks/:id endpoint
+func (h *WebhookHandler) GetSubscription(c *gin.Context) {
+	customerID, id, err := webhookIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	sub, err := h.service.GetSubscription(c.Request.Context(), customerID, id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   sub,
+	})
+}
+
+// UpdateSubscription handles PUT /webhooks/:id endpoint
+func (h *WebhookHandler) UpdateSubscription(c *gin.Context) {
+	customerID, id, err := webhookIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req webhookRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	sub, err := h.service.UpdateSubscription(c.Request.Context(), customerID, id, req.input())
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   sub,
+	})
+}
+
+// DeleteSubscription handles DELETE /webhooks/:id endpoint
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	customerID, id, err := webhookIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := h.service.DeleteSubscription(c.Request.Context(), customerID, id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /webhooks/:id/deliveries endpoint
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	customerID, id, err := webhookIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	limit, err := deliveryLimit(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), customerID, id, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   deliveries,
+	})
+}
+
+// CreateWalletSubscription handles POST /wallets/:id/webhooks endpoint
+func (h *WebhookHandler) CreateWalletSubscription(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	var req webhookRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	sub, err := h.service.CreateWalletSubscription(c.Request.Context(), customerID, walletID, req.input())
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Status: "success",
+		Data:   sub,
+	})
+}
+
+// ListWalletSubscriptions handles GET /wallets/:id/webhooks endpoint
+func (h *WebhookHandler) ListWalletSubscriptions(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	subs, err := h.service.ListWalletSubscriptions(c.Request.Context(), walletID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   subs,
+	})
+}
+
+// GetWalletSubscription handles GET /wallets/:id/webhooks/:webhook_id endpoint
+func (h *WebhookHandler) GetWalletSubscription(c *gin.Context) {
+	walletID, id, err := walletWebhookIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	sub, err := h.service.GetWalletSubscription(c.Request.Context(), walletID, id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   sub,
+	})
+}
+
+// UpdateWalletSubscription handles PUT /wallets/:id/webhooks/:webhook_id endpoint
+func (h *WebhookHandler) UpdateWalletSubscription(c *gin.Context) {
+	walletID, id, err := walletWebhookIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req webhookRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	sub, err := h.service.UpdateWalletSubscription(c.Request.Context(), walletID, id, req.input())
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   sub,
+	})
+}
+
+// DeleteWalletSubscription handles DELETE /wallets/:id/webhooks/:webhook_id endpoint
+func (h *WebhookHandler) DeleteWalletSubscription(c *gin.Context) {
+	walletID, id, err := walletWebhookIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := h.service.DeleteWalletSubscription(c.Request.Context(), walletID, id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListWalletDeliveries handles GET /wallets/:id/webhooks/:webhook_id/deliveries endpoint
+func (h *WebhookHandler) ListWalletDeliveries(c *gin.Context) {
+	walletID, id, err := walletWebhookIDs(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	limit, err := deliveryLimit(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	deliveries, err := h.service.ListWalletDeliveries(c.Request.Context(), walletID, id, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Status: "success",
+		Data:   deliveries,
+	})
+}
+
+// webhookIDs returns the authenticated customer and the subscription ID path parameter
+func webhookIDs(c *gin.Context) (uuid.UUID, uuid.UUID, error) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid webhook ID")
+	}
+
+	return customerID, id, nil
+}
+
+// walletWebhookIDs returns the wallet and subscription ID path parameters
+func walletWebhookIDs(c *gin.Context) (uuid.UUID, uuid.UUID, error) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, apierror.Wrap(apierror.CodeInvalidWalletID, err)
+	}
+
+	id, err := uuid.Parse(c.Param("webhook_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("invalid webhook ID")
+	}
+
+	return walletID, id, nil
+}
+
+// deliveryLimit returns the limit query parameter of delivery log requests,
+// zero when omitted
+func deliveryLimit(c *gin.Context) (int, error) {
+	value := c.Query("limit")
+	if value == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, apierror.New(apierror.CodeInvalidRequest).WithDetails("limit must be a positive integer")
+	}
+	return limit, nil
+}
+
+// respondWebhookError responds with the validation failure as details so
+// customers can tell which rule was rejected
+func respondWebhookError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidWebhook) {
+		err = apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("%v", err)
+	}
+	respondError(c, err)
+}
@@ -0,0 +1,438 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"     // v1.9.1
+	"github.com/google/uuid"       // v1.3.0
+	"github.com/gorilla/websocket" // v1.5.0
+
+	"internal/apierror"
+	"internal/models"
+	"internal/service"
+	"internal/walletfeed"
+)
+
+// WebSocket message types. Clients send subscribe and unsubscribe messages;
+// the server acknowledges them and sends transaction lifecycle events of the
+// subscribed wallets.
+const (
+	wsSubscribe            = "subscribe"
+	wsUnsubscribe          = "unsubscribe"
+	wsSubscribed           = "subscribed"
+	wsUnsubscribed         = "unsubscribed"
+	wsError                = "error"
+	wsTransactionCreated   = "transaction.created"
+	wsTransactionCompleted = "transaction.completed"
+	wsTransactionFailed    = "transaction.failed"
+)
+
+const (
+	// wsOutboundBuffer bounds the messages queued for a connection. Clients
+	// too slow to keep up are disconnected rather than buffered without bound.
+	wsOutboundBuffer = 64
+	// wsReadLimit bounds the size of client messages
+	wsReadLimit = 4096
+)
+
+// WebSocketOptions configures the WebSocket channel
+type WebSocketOptions struct {
+	// MaxSubscriptions bounds the wallets one connection subscribes to
+	MaxSubscriptions int
+	// MessageRate and MessageBurst limit the messages each connection may
+	// send, per second and in a burst
+	MessageRate  float64
+	MessageBurst int
+	// PingInterval is how often connections are pinged; peers silent for
+	// two intervals are disconnected
+	PingInterval time.Duration
+	// WriteTimeout bounds each write to a client
+	WriteTimeout time.Duration
+}
+
+// wsClientMessage is a message sent by a client
+type wsClientMessage struct {
+	Type     string `json:"type"`
+	WalletID string `json:"wallet_id"`
+}
+
+// wsServerMessage is a message sent to a client
+type wsServerMessage struct {
+	Type     string      `json:"type"`
+	WalletID string      `json:"wallet_id,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
+	Code     string      `json:"code,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// WebSocketHandler serves the WebSocket channel of transaction lifecycle
+// events. Connections are authenticated once, when they are opened, and
+// tracked so they can be drained on shutdown.
+type WebSocketHandler struct {
+	wallets  service.WalletService
+	feed     walletfeed.Feed
+	opts     WebSocketOptions
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	conns    map[*wsConn]struct{}
+	draining bool
+	active   sync.WaitGroup
+}
+
+// NewWebSocketHandler creates a new instance of WebSocketHandler
+func NewWebSocketHandler(wallets service.WalletService, feed walletfeed.Feed, opts WebSocketOptions) (*WebSocketHandler, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if feed == nil {
+		return nil, errors.New("wallet feed is required")
+	}
+	if opts.MaxSubscriptions <= 0 {
+		return nil, errors.New("max subscriptions must be positive")
+	}
+	if opts.MessageRate <= 0 || opts.MessageBurst <= 0 {
+		return nil, errors.New("message rate and burst must be positive")
+	}
+	if opts.PingInterval <= 0 || opts.WriteTimeout <= 0 {
+		return nil, errors.New("ping interval and write timeout must be positive")
+	}
+
+	return &WebSocketHandler{
+		wallets: wallets,
+		feed:    feed,
+		opts:    opts,
+		conns:   make(map[*wsConn]struct{}),
+	}, nil
+}
+
+// Connect handles GET /ws endpoint, upgrading the authenticated request to
+// a WebSocket connection
+func (h *WebSocketHandler) Connect(c *gin.Context) {
+	customerID, err := customerFromContext(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	h.mu.Lock()
+	if h.draining {
+		h.mu.Unlock()
+		respondError(c, apierror.New(apierror.CodeServiceUnavailable).WithDetails("server is shutting down"))
+		return
+	}
+	h.active.Add(1)
+	h.mu.Unlock()
+	defer h.active.Done()
+
+	// The upgrader responds with an HTTP error itself on failure
+	ws, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	conn := newWSConn(h, ws, customerID, apierror.ParseLanguage(c.GetHeader("Accept-Language")))
+
+	h.mu.Lock()
+	h.conns[conn] = struct{}{}
+	h.mu.Unlock()
+
+	conn.run()
+
+	h.mu.Lock()
+	delete(h.conns, conn)
+	h.mu.Unlock()
+}
+
+// Drain stops accepting connections, asks every open connection to close
+// and waits for them to finish. Connections still open when ctx is done are
+// closed without the closing handshake.
+func (h *WebSocketHandler) Drain(ctx context.Context) error {
+	h.mu.Lock()
+	h.draining = true
+	for conn := range h.conns {
+		conn.close(websocket.CloseGoingAway, "server shutting down")
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.active.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		h.mu.Lock()
+		for conn := range h.conns {
+			_ = conn.ws.Close()
+		}
+		h.mu.Unlock()
+		return fmt.Errorf("websocket connections not drained: %w", ctx.Err())
+	}
+}
+
+// wsConn is one client connection. The read loop handles client messages
+// and owns the subscriptions; a writer goroutine sends queued messages and
+// pings.
+type wsConn struct {
+	h          *WebSocketHandler
+	ws         *websocket.Conn
+	customerID uuid.UUID
+	lang       string
+	limiter    *tokenBucket
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	out    chan wsServerMessage
+	subs   map[uuid.UUID]context.CancelFunc
+
+	closeOnce sync.Once
+	closeCode int
+	closeText string
+}
+
+// newWSConn creates the state of an upgraded connection
+func newWSConn(h *WebSocketHandler, ws *websocket.Conn, customerID uuid.UUID, lang string) *wsConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &wsConn{
+		h:          h,
+		ws:         ws,
+		customerID: customerID,
+		lang:       lang,
+		limiter:    newTokenBucket(h.opts.MessageRate, h.opts.MessageBurst),
+		ctx:        ctx,
+		cancel:     cancel,
+		out:        make(chan wsServerMessage, wsOutboundBuffer),
+		subs:       make(map[uuid.UUID]context.CancelFunc),
+	}
+}
+
+// close ends the connection with the given close frame. Only the first
+// call takes effect.
+func (c *wsConn) close(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeText = text
+		c.cancel()
+	})
+}
+
+// run serves the connection until it is closed by either side
+func (c *wsConn) run() {
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		c.write()
+	}()
+
+	c.ws.SetReadLimit(wsReadLimit)
+	_ = c.ws.SetReadDeadline(time.Now().Add(2 * c.h.opts.PingInterval))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(2 * c.h.opts.PingInterval))
+	})
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			break
+		}
+		c.handle(data)
+	}
+
+	c.close(websocket.CloseNormalClosure, "")
+	<-written
+	_ = c.ws.Close()
+}
+
+// write sends queued messages and pings until the connection is closed,
+// then sends the close frame
+func (c *wsConn) write() {
+	ticker := time.NewTicker(c.h.opts.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-c.out:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(c.h.opts.WriteTimeout))
+			if err := c.ws.WriteJSON(msg); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.h.opts.WriteTimeout)); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-c.ctx.Done():
+			// Abnormal closure is never sent on the wire
+			if c.closeCode != websocket.CloseAbnormalClosure {
+				_ = c.ws.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(c.closeCode, c.closeText),
+					time.Now().Add(c.h.opts.WriteTimeout))
+			}
+			// Unblock a read loop waiting for the peer's close frame
+			_ = c.ws.SetReadDeadline(time.Now().Add(c.h.opts.WriteTimeout))
+			return
+		}
+	}
+}
+
+// send queues a message, disconnecting clients too slow to read them
+func (c *wsConn) send(msg wsServerMessage) {
+	select {
+	case c.out <- msg:
+	case <-c.ctx.Done():
+	default:
+		c.close(websocket.CloseTryAgainLater, "client too slow")
+	}
+}
+
+// sendError sends an error with its code and localized message
+func (c *wsConn) sendError(walletID string, err error) {
+	apiErr := apierror.FromError(err)
+	c.send(wsServerMessage{
+		Type:     wsError,
+		WalletID: walletID,
+		Code:     string(apiErr.Code),
+		Error:    apiErr.Message(c.lang),
+	})
+}
+
+// handle processes one client message
+func (c *wsConn) handle(data []byte) {
+	if !c.limiter.allow(time.Now()) {
+		c.sendError("", apierror.New(apierror.CodeRateLimited))
+		return
+	}
+
+	var msg wsClientMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.sendError("", apierror.Wrap(apierror.CodeInvalidRequest, err).WithDetails("messages must be JSON objects"))
+		return
+	}
+
+	walletID, err := uuid.Parse(msg.WalletID)
+	if err != nil {
+		c.sendError(msg.WalletID, apierror.Wrap(apierror.CodeInvalidWalletID, err))
+		return
+	}
+
+	switch msg.Type {
+	case wsSubscribe:
+		if err := c.subscribe(walletID); err != nil {
+			c.sendError(msg.WalletID, err)
+			return
+		}
+		c.send(wsServerMessage{Type: wsSubscribed, WalletID: walletID.String()})
+	case wsUnsubscribe:
+		if cancel, ok := c.subs[walletID]; ok {
+			cancel()
+			delete(c.subs, walletID)
+		}
+		c.send(wsServerMessage{Type: wsUnsubscribed, WalletID: walletID.String()})
+	default:
+		c.sendError(msg.WalletID, apierror.New(apierror.CodeInvalidRequest).WithDetails("type must be subscribe or unsubscribe"))
+	}
+}
+
+// subscribe starts forwarding the transaction events of one of the
+// customer's wallets. Subscribing again is a no-op.
+func (c *wsConn) subscribe(walletID uuid.UUID) error {
+	if _, ok := c.subs[walletID]; ok {
+		return nil
+	}
+	if len(c.subs) >= c.h.opts.MaxSubscriptions {
+		return apierror.New(apierror.CodeInvalidRequest).WithDetails("a connection subscribes to at most %d wallets", c.h.opts.MaxSubscriptions)
+	}
+
+	wallet, err := c.h.wallets.GetWallet(c.ctx, walletID)
+	if err != nil {
+		return err
+	}
+	if wallet.CustomerID != c.customerID {
+		return service.ErrWalletNotFound
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	updates, _, err := c.h.feed.Subscribe(ctx, walletID, 0)
+	if err != nil {
+		cancel()
+		return err
+	}
+	c.subs[walletID] = cancel
+
+	go func() {
+		for update := range updates {
+			if update.Kind != walletfeed.KindTransaction {
+				continue
+			}
+			var tx walletfeed.TransactionStatus
+			if err := json.Unmarshal(update.Data, &tx); err != nil {
+				continue
+			}
+			if eventType := transactionEventType(tx.Status); eventType != "" {
+				c.send(wsServerMessage{Type: eventType, WalletID: walletID.String(), Data: tx})
+			}
+		}
+	}()
+
+	return nil
+}
+
+// transactionEventType maps a transaction status to its lifecycle event.
+// Transactions applied immediately only report completion.
+func transactionEventType(status string) string {
+	switch status {
+	case models.TransactionStatusInitiated.String(), models.TransactionStatusProcessing.String():
+		return wsTransactionCreated
+	case models.TransactionStatusCompleted.String():
+		return wsTransactionCompleted
+	case models.TransactionStatusFailed.String(), models.TransactionStatusReversed.String():
+		return wsTransactionFailed
+	default:
+		return ""
+	}
+}
+
+// tokenBucket limits the rate of a connection's messages. It is used by the
+// connection's read loop only.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a full bucket
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// allow takes a token if one is available
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,395 @@
+// Package apierror provides the catalogue of machine-readable error codes
+// returned by the wallet service API, their HTTP status mapping and
+// localized messages
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"internal/inbound"
+	"internal/invoices"
+	"internal/models"
+	"internal/repository"
+	"internal/runbook"
+	"internal/sensitive"
+	"internal/service"
+)
+
+// Code is a stable, machine-readable error identifier exposed to API clients
+type Code string
+
+// Error codes returned by the wallet service API. Codes are part of the
+// public contract and must never be renamed once released.
+const (
+	CodeInvalidRequest         Code = "INVALID_REQUEST"
+	CodePayloadTooLarge        Code = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMediaType   Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeInvalidWalletID        Code = "INVALID_WALLET_ID"
+	CodeInvalidTransactionType Code = "INVALID_TRANSACTION_TYPE"
+	CodeInvalidAmount          Code = "INVALID_AMOUNT"
+	CodeInvalidDateRange       Code = "INVALID_DATE_RANGE"
+	CodeUnsupportedCurrency    Code = "UNSUPPORTED_CURRENCY"
+	CodeSensitiveData          Code = "SENSITIVE_DATA_DETECTED"
+	CodeIdempotencyKeyRequired Code = "IDEMPOTENCY_KEY_REQUIRED"
+	CodeIdempotencyConflict    Code = "IDEMPOTENCY_CONFLICT"
+	CodeWalletNotFound         Code = "WALLET_NOT_FOUND"
+	CodeInsufficientBalance    Code = "INSUFFICIENT_BALANCE"
+	CodeCurrencyMismatch       Code = "CURRENCY_MISMATCH"
+	CodeConcurrentModification Code = "CONCURRENT_MODIFICATION"
+	CodeUnauthorized           Code = "UNAUTHORIZED"
+	CodeForbidden              Code = "FORBIDDEN"
+	CodeActionNotFound         Code = "ACTION_NOT_FOUND"
+	CodeIssueNotFound          Code = "RECONCILIATION_ISSUE_NOT_FOUND"
+	CodeWebhookNotFound        Code = "WEBHOOK_NOT_FOUND"
+	CodeRefundNotFound         Code = "REFUND_NOT_FOUND"
+	CodeRefundNotAllowed       Code = "REFUND_NOT_ALLOWED"
+	CodeRecurringNotFound      Code = "RECURRING_DEBIT_NOT_FOUND"
+	CodeCustomerNotFound       Code = "CUSTOMER_NOT_FOUND"
+	CodePeriodClosed           Code = "BILLING_PERIOD_CLOSED"
+	CodeBillingPeriodConflict  Code = "BILLING_PERIOD_CONFLICT"
+	CodeWalletFrozen           Code = "WALLET_FROZEN"
+	CodeMigrationConflict      Code = "WALLET_MIGRATION_CONFLICT"
+	CodeMigrationNotFound      Code = "WALLET_MIGRATION_NOT_FOUND"
+	CodeWalletMerged           Code = "WALLET_MERGED"
+	CodeMergeConflict          Code = "WALLET_MERGE_CONFLICT"
+	CodeMergeNotFound          Code = "WALLET_MERGE_NOT_FOUND"
+	CodeReportJobNotFound      Code = "REPORT_JOB_NOT_FOUND"
+	CodeReportJobConflict      Code = "REPORT_JOB_CONFLICT"
+	CodeRateCardNotFound       Code = "RATE_CARD_CHANGE_NOT_FOUND"
+	CodeRateCardConflict       Code = "RATE_CARD_CHANGE_CONFLICT"
+	CodeFeeRuleNotFound        Code = "FEE_RULE_NOT_FOUND"
+	CodeFeeRuleConflict        Code = "FEE_RULE_CONFLICT"
+	CodeThresholdsNotFound     Code = "BALANCE_THRESHOLDS_NOT_FOUND"
+	CodeAdjustmentNotFound     Code = "ADJUSTMENT_NOT_FOUND"
+	CodeAdjustmentConflict     Code = "ADJUSTMENT_CONFLICT"
+	CodeCouponNotFound         Code = "COUPON_NOT_FOUND"
+	CodeCouponConflict         Code = "COUPON_CONFLICT"
+	CodeCouponNotRedeemable    Code = "COUPON_NOT_REDEEMABLE"
+	CodeDisputeNotFound        Code = "DISPUTE_NOT_FOUND"
+	CodeDisputeConflict        Code = "DISPUTE_CONFLICT"
+	CodeDisputeNotAllowed      Code = "DISPUTE_NOT_ALLOWED"
+	CodeBulkCreditNotFound     Code = "BULK_CREDIT_NOT_FOUND"
+	CodeBulkCreditConflict     Code = "BULK_CREDIT_CONFLICT"
+	CodeIncidentNotFound       Code = "INCIDENT_NOT_FOUND"
+	CodeIncidentConflict       Code = "INCIDENT_CONFLICT"
+	CodeTransactionsPaused     Code = "TRANSACTIONS_PAUSED"
+	CodeChannelNotFound        Code = "CHANNEL_NOT_FOUND"
+	CodeChannelExists          Code = "CHANNEL_EXISTS"
+	CodeInvoiceNotFound        Code = "INVOICE_NOT_FOUND"
+	CodeInvoiceConflict        Code = "INVOICE_CONFLICT"
+	CodeOrganizationNotFound   Code = "ORGANIZATION_NOT_FOUND"
+	CodeBucketNotFound         Code = "BUCKET_NOT_FOUND"
+	CodeBucketExists           Code = "BUCKET_EXISTS"
+	CodeSettingsChangeNotFound Code = "SETTINGS_CHANGE_NOT_FOUND"
+	CodeSettingsChangeConflict Code = "SETTINGS_CHANGE_CONFLICT"
+	CodeApprovalRequired       Code = "APPROVAL_REQUIRED"
+	CodeProviderNotFound       Code = "WEBHOOK_PROVIDER_NOT_FOUND"
+	CodeInvalidSignature       Code = "INVALID_WEBHOOK_SIGNATURE"
+	CodeDeadLetterNotFound     Code = "DEAD_LETTER_NOT_FOUND"
+	CodeDeadLetterReplayed     Code = "DEAD_LETTER_REPLAYED"
+	CodeFailedJobNotFound      Code = "FAILED_JOB_NOT_FOUND"
+	CodeFailedJobConflict      Code = "FAILED_JOB_CONFLICT"
+	CodeReadOnlyDeployment     Code = "READ_ONLY_DEPLOYMENT"
+	CodeDatabaseReadOnly       Code = "DATABASE_READ_ONLY"
+	CodeRateLimited            Code = "RATE_LIMITED"
+	CodeServiceUnavailable     Code = "SERVICE_UNAVAILABLE"
+	CodeInternal               Code = "INTERNAL_ERROR"
+)
+
+// statusByCode is the single place where error codes are mapped to HTTP statuses
+var statusByCode = map[Code]int{
+	CodeInvalidRequest:         http.StatusBadRequest,
+	CodePayloadTooLarge:        http.StatusRequestEntityTooLarge,
+	CodeUnsupportedMediaType:   http.StatusUnsupportedMediaType,
+	CodeInvalidWalletID:        http.StatusBadRequest,
+	CodeInvalidTransactionType: http.StatusBadRequest,
+	CodeInvalidAmount:          http.StatusBadRequest,
+	CodeInvalidDateRange:       http.StatusBadRequest,
+	CodeUnsupportedCurrency:    http.StatusBadRequest,
+	CodeSensitiveData:          http.StatusUnprocessableEntity,
+	CodeIdempotencyKeyRequired: http.StatusBadRequest,
+	CodeIdempotencyConflict:    http.StatusConflict,
+	CodeWalletNotFound:         http.StatusNotFound,
+	CodeInsufficientBalance:    http.StatusUnprocessableEntity,
+	CodeCurrencyMismatch:       http.StatusUnprocessableEntity,
+	CodeConcurrentModification: http.StatusConflict,
+	CodeUnauthorized:           http.StatusUnauthorized,
+	CodeForbidden:              http.StatusForbidden,
+	CodeActionNotFound:         http.StatusNotFound,
+	CodeIssueNotFound:          http.StatusNotFound,
+	CodeWebhookNotFound:        http.StatusNotFound,
+	CodeRefundNotFound:         http.StatusNotFound,
+	CodeRefundNotAllowed:       http.StatusUnprocessableEntity,
+	CodeRecurringNotFound:      http.StatusNotFound,
+	CodeCustomerNotFound:       http.StatusNotFound,
+	CodePeriodClosed:           http.StatusConflict,
+	CodeBillingPeriodConflict:  http.StatusConflict,
+	CodeWalletFrozen:           http.StatusConflict,
+	CodeMigrationConflict:      http.StatusConflict,
+	CodeMigrationNotFound:      http.StatusNotFound,
+	CodeWalletMerged:           http.StatusConflict,
+	CodeMergeConflict:          http.StatusConflict,
+	CodeMergeNotFound:          http.StatusNotFound,
+	CodeReportJobNotFound:      http.StatusNotFound,
+	CodeReportJobConflict:      http.StatusConflict,
+	CodeRateCardNotFound:       http.StatusNotFound,
+	CodeRateCardConflict:       http.StatusConflict,
+	CodeFeeRuleNotFound:        http.StatusNotFound,
+	CodeFeeRuleConflict:        http.StatusConflict,
+	CodeThresholdsNotFound:     http.StatusNotFound,
+	CodeAdjustmentNotFound:     http.StatusNotFound,
+	CodeAdjustmentConflict:     http.StatusConflict,
+	CodeCouponNotFound:         http.StatusNotFound,
+	CodeCouponConflict:         http.StatusConflict,
+	CodeCouponNotRedeemable:    http.StatusUnprocessableEntity,
+	CodeDisputeNotFound:        http.StatusNotFound,
+	CodeDisputeConflict:        http.StatusConflict,
+	CodeDisputeNotAllowed:      http.StatusUnprocessableEntity,
+	CodeBulkCreditNotFound:     http.StatusNotFound,
+	CodeBulkCreditConflict:     http.StatusConflict,
+	CodeIncidentNotFound:       http.StatusNotFound,
+	CodeIncidentConflict:       http.StatusConflict,
+	CodeTransactionsPaused:     http.StatusServiceUnavailable,
+	CodeChannelNotFound:        http.StatusNotFound,
+	CodeChannelExists:          http.StatusConflict,
+	CodeInvoiceNotFound:        http.StatusNotFound,
+	CodeInvoiceConflict:        http.StatusConflict,
+	CodeOrganizationNotFound:   http.StatusNotFound,
+	CodeBucketNotFound:         http.StatusNotFound,
+	CodeBucketExists:           http.StatusConflict,
+	CodeSettingsChangeNotFound: http.StatusNotFound,
+	CodeSettingsChangeConflict: http.StatusConflict,
+	CodeApprovalRequired:       http.StatusForbidden,
+	CodeProviderNotFound:       http.StatusNotFound,
+	CodeInvalidSignature:       http.StatusUnauthorized,
+	CodeDeadLetterNotFound:     http.StatusNotFound,
+	CodeDeadLetterReplayed:     http.StatusConflict,
+	CodeFailedJobNotFound:      http.StatusNotFound,
+	CodeFailedJobConflict:      http.StatusConflict,
+	CodeReadOnlyDeployment:     http.StatusMethodNotAllowed,
+	CodeDatabaseReadOnly:       http.StatusServiceUnavailable,
+	CodeRateLimited:            http.StatusTooManyRequests,
+	CodeServiceUnavailable:     http.StatusServiceUnavailable,
+	CodeInternal:               http.StatusInternalServerError,
+}
+
+// domainErrors maps sentinel errors of the lower layers to their API codes
+var domainErrors = []struct {
+	target error
+	code   Code
+}{
+	{service.ErrWalletNotFound, CodeWalletNotFound},
+	{service.ErrInsufficientBalance, CodeInsufficientBalance},
+	{service.ErrCurrencyMismatch, CodeCurrencyMismatch},
+	{service.ErrUnsupportedCurrency, CodeUnsupportedCurrency},
+	{service.ErrOptimisticLock, CodeConcurrentModification},
+	{service.ErrInvalidAmount, CodeInvalidAmount},
+	{service.ErrInvalidWalletID, CodeInvalidWalletID},
+	{service.ErrInvalidDateRange, CodeInvalidDateRange},
+	{service.ErrInvalidCreditLimit, CodeInvalidRequest},
+	{service.ErrCreditLimitBelowBalance, CodeInvalidRequest},
+	{service.ErrInvalidTransactionLimit, CodeInvalidRequest},
+	{service.ErrInvalidReferenceID, CodeInvalidRequest},
+	{service.ErrInvalidAnalyticsWindow, CodeInvalidRequest},
+	{service.ErrInvalidAnalyticsQuery, CodeInvalidRequest},
+	{service.ErrReconciliationIssueNotFound, CodeIssueNotFound},
+	{service.ErrInvalidDigestFrequency, CodeInvalidRequest},
+	{service.ErrFutureBalanceDate, CodeInvalidDateRange},
+	{service.ErrWebhookNotFound, CodeWebhookNotFound},
+	{service.ErrInvalidWebhook, CodeInvalidRequest},
+	{service.ErrRefundNotFound, CodeRefundNotFound},
+	{service.ErrRefundNotAllowed, CodeRefundNotAllowed},
+	{service.ErrInvalidPayment, CodeInvalidRequest},
+	{service.ErrCustomerNotFound, CodeCustomerNotFound},
+	{service.ErrInvalidTimezone, CodeInvalidRequest},
+	{service.ErrRecurringDebitNotFound, CodeRecurringNotFound},
+	{service.ErrInvalidRecurringDebit, CodeInvalidRequest},
+	{service.ErrPeriodClosed, CodePeriodClosed},
+	{service.ErrFutureEffectiveDate, CodeInvalidDateRange},
+	{service.ErrInvalidBillingPeriod, CodeInvalidRequest},
+	{service.ErrBillingPeriodConflict, CodeBillingPeriodConflict},
+	{service.ErrWalletFrozen, CodeWalletFrozen},
+	{service.ErrInvalidWalletExport, CodeInvalidRequest},
+	{service.ErrWalletMigrationConflict, CodeMigrationConflict},
+	{service.ErrWalletMigrationNotFound, CodeMigrationNotFound},
+	{service.ErrWalletMerged, CodeWalletMerged},
+	{service.ErrInvalidWalletMerge, CodeInvalidRequest},
+	{service.ErrWalletMergeConflict, CodeMergeConflict},
+	{service.ErrWalletMergeNotFound, CodeMergeNotFound},
+	{service.ErrInvalidConsentChannel, CodeInvalidRequest},
+	{service.ErrInvalidConsentSource, CodeInvalidRequest},
+	{service.ErrReportJobNotFound, CodeReportJobNotFound},
+	{service.ErrReportJobFinished, CodeReportJobConflict},
+	{service.ErrReportResultNotReady, CodeReportJobConflict},
+	{service.ErrUnknownReportKind, CodeInvalidRequest},
+	{service.ErrInvalidReportParams, CodeInvalidRequest},
+	{service.ErrInvalidRateCardChange, CodeInvalidRequest},
+	{service.ErrRateCardChangeNotFound, CodeRateCardNotFound},
+	{service.ErrRateCardChangeConflict, CodeRateCardConflict},
+	{service.ErrSelfApproval, CodeForbidden},
+	{service.ErrInvalidFeeRule, CodeInvalidRequest},
+	{service.ErrFeeRuleNotFound, CodeFeeRuleNotFound},
+	{service.ErrFeeRuleConflict, CodeFeeRuleConflict},
+	{service.ErrInvalidWalletBatch, CodeInvalidRequest},
+	{service.ErrInvalidExport, CodeInvalidRequest},
+	{service.ErrInvalidBacklogQuery, CodeInvalidRequest},
+	{service.ErrBalanceThresholdsNotFound, CodeThresholdsNotFound},
+	{service.ErrInvalidBalanceThresholds, CodeInvalidRequest},
+	{service.ErrInvalidBalanceAlerts, CodeInvalidRequest},
+	{service.ErrInvalidAdjustment, CodeInvalidRequest},
+	{service.ErrAdjustmentNotFound, CodeAdjustmentNotFound},
+	{service.ErrAdjustmentConflict, CodeAdjustmentConflict},
+	{service.ErrInvalidTaxRequest, CodeInvalidRequest},
+	{service.ErrInvalidCoupon, CodeInvalidRequest},
+	{service.ErrCouponNotFound, CodeCouponNotFound},
+	{service.ErrCouponConflict, CodeCouponConflict},
+	{service.ErrCouponNotRedeemable, CodeCouponNotRedeemable},
+	{service.ErrInvalidDispute, CodeInvalidRequest},
+	{service.ErrDisputeNotFound, CodeDisputeNotFound},
+	{service.ErrDisputeConflict, CodeDisputeConflict},
+	{service.ErrDisputeNotAllowed, CodeDisputeNotAllowed},
+	{service.ErrInvalidBulkCredit, CodeInvalidRequest},
+	{service.ErrBulkCreditNotFound, CodeBulkCreditNotFound},
+	{service.ErrBulkCreditConflict, CodeBulkCreditConflict},
+	{service.ErrInvalidIncident, CodeInvalidRequest},
+	{service.ErrIncidentNotFound, CodeIncidentNotFound},
+	{service.ErrIncidentConflict, CodeIncidentConflict},
+	{service.ErrTransactionsPaused, CodeTransactionsPaused},
+	{service.ErrInvalidNotificationChannel, CodeInvalidRequest},
+	{service.ErrInvalidInvoiceNotice, CodeInvalidRequest},
+	{service.ErrNotificationChannelNotFound, CodeChannelNotFound},
+	{service.ErrNotificationChannelExists, CodeChannelExists},
+	{service.ErrInvalidDataAccessFilter, CodeInvalidRequest},
+	{service.ErrInvalidInvoiceDocument, CodeInvalidRequest},
+	{service.ErrInvoiceDocumentNotFound, CodeInvoiceNotFound},
+	{service.ErrInvoiceConflict, CodeInvoiceConflict},
+	{service.ErrInvalidPaymentTerms, CodeInvalidRequest},
+	{service.ErrInvoiceReceivableNotFound, CodeInvoiceNotFound},
+	{service.ErrInvalidInvoicePayment, CodeInvalidRequest},
+	{service.ErrInvalidBillingMode, CodeInvalidRequest},
+	{service.ErrInvalidQuantity, CodeInvalidRequest},
+	{service.ErrOrganizationNotFound, CodeOrganizationNotFound},
+	{service.ErrInvalidOrganization, CodeInvalidRequest},
+	{service.ErrBucketNotFound, CodeBucketNotFound},
+	{service.ErrBucketExists, CodeBucketExists},
+	{service.ErrInvalidBucket, CodeInvalidRequest},
+	{service.ErrInsufficientBucketBalance, CodeInsufficientBalance},
+	{service.ErrInvalidSettingsChange, CodeInvalidRequest},
+	{service.ErrSettingsChangeNotFound, CodeSettingsChangeNotFound},
+	{service.ErrSettingsChangeConflict, CodeSettingsChangeConflict},
+	{service.ErrApprovalRequired, CodeApprovalRequired},
+	{service.ErrDeadLetterNotFound, CodeDeadLetterNotFound},
+	{service.ErrDeadLetterReplayed, CodeDeadLetterReplayed},
+	{service.ErrInvalidFailedJob, CodeInvalidRequest},
+	{service.ErrFailedJobNotFound, CodeFailedJobNotFound},
+	{service.ErrFailedJobConflict, CodeFailedJobConflict},
+	{service.ErrDatabaseReadOnly, CodeDatabaseReadOnly},
+	{repository.ErrWalletNotFound, CodeWalletNotFound},
+	{repository.ErrInsufficientBalance, CodeInsufficientBalance},
+	{repository.ErrOptimisticLock, CodeConcurrentModification},
+	{repository.ErrPeriodClosed, CodePeriodClosed},
+	{repository.ErrWalletFrozen, CodeWalletFrozen},
+	{repository.ErrWalletMerged, CodeWalletMerged},
+	{models.ErrInvalidAmount, CodeInvalidAmount},
+	{models.ErrInvalidTransactionType, CodeInvalidTransactionType},
+	{models.ErrInvalidCurrency, CodeUnsupportedCurrency},
+	{models.ErrInvalidMetadata, CodeInvalidRequest},
+	{models.ErrInvalidAdjustment, CodeInvalidRequest},
+	{inbound.ErrUnknownProvider, CodeProviderNotFound},
+	{inbound.ErrInvalidSignature, CodeInvalidSignature},
+	{inbound.ErrInvalidEvent, CodeInvalidRequest},
+	{runbook.ErrActionNotFound, CodeActionNotFound},
+	{runbook.ErrMissingParameter, CodeInvalidRequest},
+	{runbook.ErrReasonRequired, CodeInvalidRequest},
+	{sensitive.ErrSensitiveData, CodeSensitiveData},
+	{invoices.ErrUnavailable, CodeServiceUnavailable},
+}
+
+// Error is a structured API error carrying a stable code and its HTTP status
+type Error struct {
+	Code    Code
+	Status  int
+	Details string
+	cause   error
+}
+
+// New creates an API error for the given code
+func New(code Code) *Error {
+	return &Error{
+		Code:   code,
+		Status: Status(code),
+	}
+}
+
+// Wrap creates an API error for the given code retaining the underlying cause
+func Wrap(code Code, err error) *Error {
+	e := New(code)
+	e.cause = err
+	return e
+}
+
+// WithDetails attaches client-safe details to the error
+func (e *Error) WithDetails(format string, args ...interface{}) *Error {
+	e.Details = fmt.Sprintf(format, args...)
+	return e
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %v", e.Code, e.cause)
+	}
+	return string(e.Code)
+}
+
+// Unwrap returns the underlying cause of the error
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Message returns the error message localized for the given language
+func (e *Error) Message(lang string) string {
+	return Message(e.Code, lang)
+}
+
+// Status returns the HTTP status for an error code
+func Status(code Code) int {
+	if status, ok := statusByCode[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Codes returns every error code of the catalogue in alphabetical order
+func Codes() []Code {
+	codes := make([]Code, 0, len(statusByCode))
+	for code := range statusByCode {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// FromError converts any error into an API error. Errors that are already API
+// errors are returned unchanged, known domain errors are mapped to their codes
+// and everything else is reported as an internal error.
+func FromError(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	for _, de := range domainErrors {
+		if errors.Is(err, de.target) {
+			return Wrap(de.code, err)
+		}
+	}
+	if repository.IsReadOnly(err) {
+		return Wrap(CodeDatabaseReadOnly, err)
+	}
+
+	return Wrap(CodeInternal, err)
+}
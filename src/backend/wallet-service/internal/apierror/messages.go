@@ -0,0 +1,185 @@
+package apierror
+
+import "strings"
+
+// DefaultLanguage is used when the client does not request a supported language
+const DefaultLanguage = "en"
+
+// messages holds the localized message catalogue keyed by language and code
+var messages = map[string]map[Code]string{
+	"en": {
+		CodeInvalidRequest:         "The request is malformed or contains invalid fields",
+		CodePayloadTooLarge:        "The request body exceeds the maximum allowed size",
+		CodeUnsupportedMediaType:   "The request body must be JSON",
+		CodeInvalidWalletID:        "The wallet ID format is invalid",
+		CodeInvalidTransactionType: "The transaction type is invalid",
+		CodeInvalidAmount:          "The transaction amount is invalid",
+		CodeInvalidDateRange:       "The requested date range is invalid",
+		CodeUnsupportedCurrency:    "The currency is not supported",
+		CodeSensitiveData:          "Free-text fields must not contain card or Aadhaar numbers",
+		CodeIdempotencyKeyRequired: "An Idempotency-Key header is required",
+		CodeIdempotencyConflict:    "The idempotency key was already used with a different request",
+		CodeWalletNotFound:         "Wallet not found",
+		CodeInsufficientBalance:    "Insufficient wallet balance",
+		CodeCurrencyMismatch:       "The transaction currency does not match the wallet currency",
+		CodeConcurrentModification: "The wallet was modified concurrently, please retry",
+		CodeUnauthorized:           "Unauthorized access",
+		CodeForbidden:              "You do not have permission to perform this operation",
+		CodeActionNotFound:         "The requested operator action does not exist",
+		CodeIssueNotFound:          "The requested reconciliation issue does not exist",
+		CodeWebhookNotFound:        "The requested webhook subscription does not exist",
+		CodeRefundNotFound:         "The requested refund does not exist",
+		CodeCustomerNotFound:       "The customer does not exist",
+		CodePeriodClosed:           "The billing period of the transaction is closed",
+		CodeBillingPeriodConflict:  "The billing period is already in the requested state",
+		CodeWalletFrozen:           "The wallet is frozen while it moves to another deployment",
+		CodeMigrationConflict:      "The wallet migration is not in a state allowing this step",
+		CodeMigrationNotFound:      "The wallet has not been migrated",
+		CodeWalletMerged:           "The wallet was merged into another wallet; use the wallet its merge record points to",
+		CodeMergeConflict:          "The wallets cannot be merged, or the merge can no longer be reversed",
+		CodeMergeNotFound:          "The wallet merge was not found",
+		CodeReportJobNotFound:      "The requested report job does not exist",
+		CodeReportJobConflict:      "The report job is not in a state allowing this request",
+		CodeRateCardNotFound:       "The requested rate card change does not exist",
+		CodeRateCardConflict:       "The rate card change is not in a state allowing this request",
+		CodeFeeRuleNotFound:        "The requested fee rule does not exist",
+		CodeFeeRuleConflict:        "The fee rule cannot be ended at that time",
+		CodeThresholdsNotFound:     "The wallet has no balance thresholds configured",
+		CodeAdjustmentNotFound:     "The requested adjustment does not exist",
+		CodeAdjustmentConflict:     "The adjustment is not in a state allowing this request",
+		CodeCouponNotFound:         "The requested coupon does not exist",
+		CodeCouponConflict:         "The coupon code is taken or the coupon is already voided",
+		CodeCouponNotRedeemable:    "The coupon cannot be applied to this amount",
+		CodeDisputeNotFound:        "The requested dispute does not exist",
+		CodeDisputeConflict:        "The dispute is not in a state allowing this request",
+		CodeDisputeNotAllowed:      "The transaction cannot be disputed",
+		CodeBulkCreditNotFound:     "The requested bulk credit does not exist",
+		CodeBulkCreditConflict:     "The bulk credit cannot be rolled back now",
+		CodeIncidentNotFound:       "No incident is active",
+		CodeIncidentConflict:       "The incident was changed concurrently, please retry",
+		CodeTransactionsPaused:     "Transactions of this type are paused by an incident, please retry later",
+		CodeChannelNotFound:        "The requested notification channel does not exist",
+		CodeChannelExists:          "A notification channel to this destination already exists",
+		CodeInvoiceNotFound:        "The requested invoice does not exist",
+		CodeInvoiceConflict:        "This invoice number was already issued as another invoice",
+		CodeOrganizationNotFound:   "The requested organization does not exist",
+		CodeBucketNotFound:         "The wallet has no bucket of this name",
+		CodeBucketExists:           "The wallet already has a bucket of this name",
+		CodeSettingsChangeNotFound: "The requested settings change does not exist",
+		CodeSettingsChangeConflict: "The settings change is not in a state allowing this request",
+		CodeApprovalRequired:       "This change needs approval by a second operator; request it as a settings change",
+		CodeProviderNotFound:       "Webhooks of this provider are not accepted",
+		CodeInvalidSignature:       "The webhook signature is missing, invalid or expired",
+		CodeDeadLetterNotFound:     "The requested dead letter does not exist",
+		CodeDeadLetterReplayed:     "The dead letter was already replayed",
+		CodeFailedJobNotFound:      "The requested failed job does not exist",
+		CodeFailedJobConflict:      "The failed job already succeeded, was discarded or changed meanwhile",
+		CodeRefundNotAllowed:       "The top-up cannot be refunded to its original payment method",
+		CodeRecurringNotFound:      "The requested recurring debit does not exist",
+		CodeReadOnlyDeployment:     "This deployment is read-only; send changes to the primary API",
+		CodeDatabaseReadOnly:       "Changes are paused while the database fails over, please retry later",
+		CodeRateLimited:            "Rate limit exceeded",
+		CodeServiceUnavailable:     "The service is temporarily unavailable, please retry later",
+		CodeInternal:               "Internal server error",
+	},
+	"hi": {
+		CodeInvalidRequest:         "अनुरोध अमान्य है या इसमें अमान्य फ़ील्ड हैं",
+		CodePayloadTooLarge:        "अनुरोध का आकार अधिकतम सीमा से अधिक है",
+		CodeUnsupportedMediaType:   "अनुरोध का मुख्य भाग JSON होना चाहिए",
+		CodeInvalidWalletID:        "वॉलेट आईडी का प्रारूप अमान्य है",
+		CodeInvalidTransactionType: "लेनदेन का प्रकार अमान्य है",
+		CodeInvalidAmount:          "लेनदेन राशि अमान्य है",
+		CodeInvalidDateRange:       "अनुरोधित तिथि सीमा अमान्य है",
+		CodeUnsupportedCurrency:    "यह मुद्रा समर्थित नहीं है",
+		CodeSensitiveData:          "विवरण फ़ील्ड में कार्ड या आधार नंबर नहीं होना चाहिए",
+		CodeIdempotencyKeyRequired: "Idempotency-Key हेडर आवश्यक है",
+		CodeIdempotencyConflict:    "यह idempotency कुंजी किसी अन्य अनुरोध के साथ पहले ही उपयोग की जा चुकी है",
+		CodeWalletNotFound:         "वॉलेट नहीं मिला",
+		CodeInsufficientBalance:    "वॉलेट में पर्याप्त शेष राशि नहीं है",
+		CodeCurrencyMismatch:       "लेनदेन की मुद्रा वॉलेट की मुद्रा से मेल नहीं खाती",
+		CodeConcurrentModification: "वॉलेट को एक साथ संशोधित किया गया, कृपया पुनः प्रयास करें",
+		CodeUnauthorized:           "अनधिकृत पहुंच",
+		CodeForbidden:              "आपको यह कार्य करने की अनुमति नहीं है",
+		CodeActionNotFound:         "अनुरोधित ऑपरेटर कार्रवाई मौजूद नहीं है",
+		CodeIssueNotFound:          "अनुरोधित मिलान समस्या मौजूद नहीं है",
+		CodeWebhookNotFound:        "अनुरोधित वेबहुक सदस्यता मौजूद नहीं है",
+		CodeRefundNotFound:         "अनुरोधित रिफ़ंड मौजूद नहीं है",
+		CodeCustomerNotFound:       "ग्राहक मौजूद नहीं है",
+		CodePeriodClosed:           "लेनदेन की बिलिंग अवधि बंद हो चुकी है",
+		CodeBillingPeriodConflict:  "बिलिंग अवधि पहले से ही अनुरोधित स्थिति में है",
+		CodeWalletFrozen:           "वॉलेट दूसरे परिनियोजन में स्थानांतरित होने तक स्थिर है",
+		CodeMigrationConflict:      "वॉलेट स्थानांतरण इस चरण की अनुमति देने वाली स्थिति में नहीं है",
+		CodeMigrationNotFound:      "वॉलेट स्थानांतरित नहीं किया गया है",
+		CodeWalletMerged:           "वॉलेट को दूसरे वॉलेट में मिला दिया गया है; उसके विलय रिकॉर्ड में बताए गए वॉलेट का उपयोग करें",
+		CodeMergeConflict:          "वॉलेट मिलाए नहीं जा सकते, या विलय अब वापस नहीं लिया जा सकता",
+		CodeMergeNotFound:          "वॉलेट विलय नहीं मिला",
+		CodeReportJobNotFound:      "अनुरोधित रिपोर्ट जॉब मौजूद नहीं है",
+		CodeReportJobConflict:      "रिपोर्ट जॉब इस अनुरोध की अनुमति देने वाली स्थिति में नहीं है",
+		CodeRateCardNotFound:       "अनुरोधित रेट कार्ड परिवर्तन मौजूद नहीं है",
+		CodeRateCardConflict:       "रेट कार्ड परिवर्तन इस अनुरोध की अनुमति देने वाली स्थिति में नहीं है",
+		CodeFeeRuleNotFound:        "अनुरोधित शुल्क नियम मौजूद नहीं है",
+		CodeFeeRuleConflict:        "शुल्क नियम को उस समय समाप्त नहीं किया जा सकता",
+		CodeThresholdsNotFound:     "वॉलेट के लिए कोई बैलेंस सीमा कॉन्फ़िगर नहीं है",
+		CodeAdjustmentNotFound:     "अनुरोधित समायोजन मौजूद नहीं है",
+		CodeAdjustmentConflict:     "समायोजन इस अनुरोध की अनुमति देने वाली स्थिति में नहीं है",
+		CodeCouponNotFound:         "अनुरोधित कूपन मौजूद नहीं है",
+		CodeCouponConflict:         "कूपन कोड पहले से उपयोग में है या कूपन पहले ही रद्द हो चुका है",
+		CodeCouponNotRedeemable:    "कूपन इस राशि पर लागू नहीं किया जा सकता",
+		CodeDisputeNotFound:        "अनुरोधित विवाद मौजूद नहीं है",
+		CodeDisputeConflict:        "विवाद इस अनुरोध की अनुमति देने वाली स्थिति में नहीं है",
+		CodeDisputeNotAllowed:      "इस लेनदेन पर विवाद नहीं किया जा सकता",
+		CodeBulkCreditNotFound:     "अनुरोधित बल्क क्रेडिट मौजूद नहीं है",
+		CodeBulkCreditConflict:     "बल्क क्रेडिट को अभी वापस नहीं लिया जा सकता",
+		CodeIncidentNotFound:       "कोई घटना सक्रिय नहीं है",
+		CodeIncidentConflict:       "घटना एक साथ बदली गई, कृपया पुनः प्रयास करें",
+		CodeTransactionsPaused:     "इस प्रकार के लेनदेन एक घटना के कारण रोके गए हैं, कृपया बाद में पुनः प्रयास करें",
+		CodeChannelNotFound:        "अनुरोधित सूचना चैनल मौजूद नहीं है",
+		CodeChannelExists:          "इस गंतव्य के लिए सूचना चैनल पहले से मौजूद है",
+		CodeInvoiceNotFound:        "अनुरोधित चालान मौजूद नहीं है",
+		CodeInvoiceConflict:        "यह चालान संख्या पहले ही किसी अन्य चालान के लिए जारी की जा चुकी है",
+		CodeOrganizationNotFound:   "अनुरोधित संगठन मौजूद नहीं है",
+		CodeBucketNotFound:         "वॉलेट में इस नाम का कोई बकेट नहीं है",
+		CodeBucketExists:           "वॉलेट में इस नाम का बकेट पहले से मौजूद है",
+		CodeSettingsChangeNotFound: "अनुरोधित सेटिंग परिवर्तन मौजूद नहीं है",
+		CodeSettingsChangeConflict: "सेटिंग परिवर्तन इस अनुरोध की अनुमति देने वाली स्थिति में नहीं है",
+		CodeApprovalRequired:       "इस परिवर्तन के लिए दूसरे ऑपरेटर की स्वीकृति आवश्यक है; इसे सेटिंग परिवर्तन के रूप में अनुरोध करें",
+		CodeProviderNotFound:       "इस प्रदाता के वेबहुक स्वीकार नहीं किए जाते",
+		CodeInvalidSignature:       "वेबहुक हस्ताक्षर अनुपलब्ध, अमान्य या समाप्त है",
+		CodeDeadLetterNotFound:     "अनुरोधित डेड लेटर मौजूद नहीं है",
+		CodeDeadLetterReplayed:     "डेड लेटर पहले ही दोबारा चलाया जा चुका है",
+		CodeFailedJobNotFound:      "अनुरोधित विफल जॉब मौजूद नहीं है",
+		CodeFailedJobConflict:      "विफल जॉब पहले ही सफल, रद्द या इस बीच बदल चुका है",
+		CodeRefundNotAllowed:       "इस टॉप-अप को मूल भुगतान विधि में रिफ़ंड नहीं किया जा सकता",
+		CodeRecurringNotFound:      "अनुरोधित आवर्ती डेबिट मौजूद नहीं है",
+		CodeReadOnlyDeployment:     "यह परिनियोजन केवल पढ़ने के लिए है; परिवर्तन प्राथमिक API को भेजें",
+		CodeDatabaseReadOnly:       "डेटाबेस फ़ेलओवर के दौरान परिवर्तन रुके हुए हैं, कृपया बाद में पुनः प्रयास करें",
+		CodeRateLimited:            "अनुरोध सीमा पार हो गई",
+		CodeServiceUnavailable:     "सेवा अस्थायी रूप से उपलब्ध नहीं है, कृपया बाद में पुनः प्रयास करें",
+		CodeInternal:               "आंतरिक सर्वर त्रुटि",
+	},
+}
+
+// Message returns the message for a code in the given language, falling back
+// to the default language and finally to the code itself
+func Message(code Code, lang string) string {
+	if msg, ok := messages[lang][code]; ok {
+		return msg
+	}
+	if msg, ok := messages[DefaultLanguage][code]; ok {
+		return msg
+	}
+	return string(code)
+}
+
+// ParseLanguage selects the first supported language from an Accept-Language
+// header value, returning DefaultLanguage when none match
+func ParseLanguage(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		primary := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if _, ok := messages[primary]; ok {
+			return primary
+		}
+	}
+	return DefaultLanguage
+}
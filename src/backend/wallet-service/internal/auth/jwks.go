@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrKeyNotFound is returned when no signing key matches the token key ID
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// jsonWebKey is a single RSA key of a JWKS document
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKSProvider fetches and caches RSA signing keys from a JWKS endpoint. Keys
+// are refreshed periodically and on demand when an unknown key ID is seen so
+// that identity provider key rotation is picked up without a restart.
+type JWKSProvider struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	// minRefreshInterval throttles refreshes triggered by unknown key IDs so
+	// a flood of forged tokens cannot hammer the identity provider
+	minRefreshInterval time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// NewJWKSProvider creates a new JWKS key provider
+func NewJWKSProvider(url string, refreshInterval, minRefreshInterval time.Duration) (*JWKSProvider, error) {
+	if url == "" {
+		return nil, errors.New("JWKS URL is required")
+	}
+	if refreshInterval <= 0 {
+		return nil, errors.New("JWKS refresh interval must be positive")
+	}
+	if minRefreshInterval <= 0 {
+		return nil, errors.New("JWKS minimum refresh interval must be positive")
+	}
+
+	return &JWKSProvider{
+		url:                url,
+		client:             &http.Client{Timeout: 10 * time.Second},
+		refreshInterval:    refreshInterval,
+		minRefreshInterval: minRefreshInterval,
+		keys:               make(map[string]*rsa.PublicKey),
+	}, nil
+}
+
+// Key returns the public key for the given key ID, refreshing the cache when
+// it is stale or the key ID is unknown
+func (p *JWKSProvider) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.RLock()
+	key, ok := p.keys[kid]
+	stale := time.Since(p.fetchedAt) > p.refreshInterval
+	throttled := time.Since(p.lastAttempt) < p.minRefreshInterval
+	p.mu.RUnlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+
+	if !throttled {
+		if err := p.Refresh(ctx); err != nil && !ok {
+			return nil, err
+		}
+		p.mu.RLock()
+		key, ok = p.keys[kid]
+		p.mu.RUnlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+	}
+	return key, nil
+}
+
+// Refresh fetches the JWKS document and replaces the cached key set
+func (p *JWKSProvider) Refresh(ctx context.Context) error {
+	p.mu.Lock()
+	p.lastAttempt = time.Now()
+	p.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := parseRSAKey(jwk)
+		if err != nil {
+			return fmt.Errorf("invalid JWKS key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.fetchedAt = time.Now()
+	p.mu.Unlock()
+
+	return nil
+}
+
+// parseRSAKey builds an RSA public key from base64url encoded modulus and exponent
+func parseRSAKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("exponent too large")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
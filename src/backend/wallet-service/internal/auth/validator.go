@@ -0,0 +1,136 @@
+// Package auth implements JWT bearer token validation for the wallet service
+// with RS256 keys resolved from a JWKS endpoint
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5" // v5.2.1
+
+	"internal/config"
+)
+
+// Common authentication errors
+var (
+	ErrInvalidToken         = errors.New("invalid or expired token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+)
+
+// Claims is the custom claims structure of wallet service access tokens
+type Claims struct {
+	jwt.RegisteredClaims
+	CustomerID string   `json:"customer_id"`
+	Roles      []string `json:"roles"`
+	// OrgID confines the token to the customers and wallets of one
+	// organization; tokens without it see every tenant
+	OrgID string `json:"org_id,omitempty"`
+}
+
+// HasRole reports whether the claims grant the given role
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Validator validates bearer tokens against the configured algorithms, keys,
+// issuer, audience and clock skew tolerance
+type Validator struct {
+	keys       *JWKSProvider
+	secret     []byte
+	algorithms []string
+	parser     *jwt.Parser
+}
+
+// NewValidator creates a token validator from the security configuration
+func NewValidator(cfg config.SecurityConfig) (*Validator, error) {
+	if len(cfg.JWTAlgorithms) == 0 {
+		return nil, errors.New("at least one JWT algorithm is required")
+	}
+
+	v := &Validator{
+		algorithms: cfg.JWTAlgorithms,
+	}
+
+	for _, alg := range cfg.JWTAlgorithms {
+		switch {
+		case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "PS"):
+			if v.keys != nil {
+				continue
+			}
+			keys, err := NewJWKSProvider(cfg.JWKSURL, cfg.JWKSRefreshInterval, cfg.JWKSMinRefreshInterval)
+			if err != nil {
+				return nil, fmt.Errorf("algorithm %s requires JWKS: %w", alg, err)
+			}
+			v.keys = keys
+		case strings.HasPrefix(alg, "HS"):
+			if cfg.JWTSecret == "" {
+				return nil, fmt.Errorf("algorithm %s requires a JWT secret", alg)
+			}
+			v.secret = []byte(cfg.JWTSecret)
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+		}
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(cfg.JWTAlgorithms),
+		jwt.WithLeeway(cfg.JWTClockSkew),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if cfg.JWTIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.JWTAudience))
+	}
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+// Warm pre-loads the JWKS key set so the first request does not pay for it
+func (v *Validator) Warm(ctx context.Context) error {
+	if v.keys == nil {
+		return nil
+	}
+	return v.keys.Refresh(ctx)
+}
+
+// Validate parses and verifies a raw bearer token and returns its claims
+func (v *Validator) Validate(ctx context.Context, tokenString string) (*Claims, error) {
+	claims := &Claims{}
+
+	token, err := v.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			if v.keys == nil {
+				return nil, ErrUnsupportedAlgorithm
+			}
+			kid, _ := token.Header["kid"].(string)
+			return v.keys.Key(ctx, kid)
+		case *jwt.SigningMethodHMAC:
+			if v.secret == nil {
+				return nil, ErrUnsupportedAlgorithm
+			}
+			return v.secret, nil
+		default:
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, token.Header["alg"])
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
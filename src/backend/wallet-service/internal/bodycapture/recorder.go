@@ -0,0 +1,156 @@
+package bodycapture
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/models"
+)
+
+// Capture outcomes
+const (
+	outcomeStored  = "stored"
+	outcomeDropped = "dropped"
+	outcomeFailed  = "failed"
+)
+
+// sinkTimeout bounds each write to the sink
+const sinkTimeout = 5 * time.Second
+
+// captures counts sampled requests by what became of their capture
+var captures = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_body_captures_total",
+		Help: "Sampled request body captures, by outcome",
+	},
+	[]string{"outcome"},
+)
+
+// Sink stores redacted captures
+type Sink interface {
+	RecordCapture(ctx context.Context, capture *models.RequestCapture) error
+}
+
+// Logger defines the logging used by the recorder
+type Logger interface {
+	Error(msg string, err error, fields ...interface{})
+}
+
+// Options configures the sampling and size caps of a Recorder
+type Options struct {
+	// SampleRate is the fraction of requests captured, from 0 to 1
+	SampleRate float64
+	// MaxBodyBytes caps each stored body, after redaction
+	MaxBodyBytes int
+	// QueueSize bounds the captures waiting for the sink
+	QueueSize int
+}
+
+// Recorder redacts the bodies of sampled requests and hands them to the
+// sink in the background
+type Recorder struct {
+	redactor *Redactor
+	sink     Sink
+	opts     Options
+	logger   Logger
+	queue    chan *models.RequestCapture
+}
+
+// NewRecorder creates a recorder. Run must be running for captures to be
+// stored.
+func NewRecorder(redactor *Redactor, sink Sink, opts Options, logger Logger) (*Recorder, error) {
+	if redactor == nil {
+		return nil, errors.New("redactor is required")
+	}
+	if sink == nil {
+		return nil, errors.New("sink is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if opts.SampleRate < 0 || opts.SampleRate > 1 {
+		return nil, errors.New("sample rate must be between 0 and 1")
+	}
+	if opts.MaxBodyBytes <= 0 || opts.QueueSize <= 0 {
+		return nil, errors.New("max body bytes and queue size must be positive")
+	}
+
+	return &Recorder{
+		redactor: redactor,
+		sink:     sink,
+		opts:     opts,
+		logger:   logger,
+		queue:    make(chan *models.RequestCapture, opts.QueueSize),
+	}, nil
+}
+
+// Sample decides whether a request is captured
+func (r *Recorder) Sample() bool {
+	return r.opts.SampleRate > 0 && rand.Float64() < r.opts.SampleRate
+}
+
+// Record redacts and caps the bodies of a sampled request and queues its
+// capture. Captures are dropped while the queue is full.
+func (r *Recorder) Record(capture *models.RequestCapture, request, response []byte) {
+	var requestCut, responseCut bool
+	capture.RequestBody, requestCut = r.truncate(r.redactor.Redact(request))
+	capture.ResponseBody, responseCut = r.truncate(r.redactor.Redact(response))
+	capture.Truncated = requestCut || responseCut
+	if capture.ID == uuid.Nil {
+		capture.ID = uuid.New()
+	}
+
+	select {
+	case r.queue <- capture:
+	default:
+		captures.WithLabelValues(outcomeDropped).Inc()
+	}
+}
+
+// Run stores queued captures until the context is cancelled. Captures still
+// queued then are lost, as they are a sample.
+func (r *Recorder) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case capture := <-r.queue:
+			r.store(ctx, capture)
+		}
+	}
+}
+
+// store writes one capture to the sink
+func (r *Recorder) store(ctx context.Context, capture *models.RequestCapture) {
+	ctx, cancel := context.WithTimeout(ctx, sinkTimeout)
+	defer cancel()
+
+	if err := r.sink.RecordCapture(ctx, capture); err != nil {
+		captures.WithLabelValues(outcomeFailed).Inc()
+		r.logger.Error("failed to store request capture", err,
+			"correlation_id", capture.CorrelationID,
+			"route", capture.Route)
+		return
+	}
+	captures.WithLabelValues(outcomeStored).Inc()
+}
+
+// truncate cuts a body to the size cap without splitting a UTF-8 sequence
+func (r *Recorder) truncate(body []byte) (string, bool) {
+	if len(body) <= r.opts.MaxBodyBytes {
+		return string(body), false
+	}
+
+	cut := r.opts.MaxBodyBytes
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return string(body[:cut]), true
+}
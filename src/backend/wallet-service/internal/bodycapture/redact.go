@@ -0,0 +1,106 @@
+// Package bodycapture captures the request and response bodies of a sample
+// of API requests for payment disputes. Bodies are redacted before they
+// leave the request and stored asynchronously in an audit sink, so a slow
+// or failing sink never delays responses.
+package bodycapture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"internal/sensitive"
+)
+
+// Redacted replaces redacted values
+const Redacted = "[REDACTED]"
+
+// Rules configures what a Redactor removes. Card and Aadhaar numbers are
+// always masked.
+type Rules struct {
+	// FieldMasks are JSON field names whose values are redacted at any
+	// depth, matched case-insensitively
+	FieldMasks []string
+	// Patterns are regular expressions redacted from every string value, or
+	// from the whole body when it is not JSON
+	Patterns []string
+}
+
+// Redactor removes PII from captured bodies
+type Redactor struct {
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor compiles the redaction rules
+func NewRedactor(rules Rules) (*Redactor, error) {
+	r := &Redactor{fields: make(map[string]bool, len(rules.FieldMasks))}
+	for _, field := range rules.FieldMasks {
+		r.fields[strings.ToLower(field)] = true
+	}
+	for _, pattern := range rules.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns body with masked fields and pattern matches replaced.
+// JSON bodies are re-encoded with their keys sorted; other bodies are
+// redacted as text, where field masks cannot apply.
+func (r *Redactor) Redact(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return []byte(r.redactText(string(body)))
+	}
+
+	redacted, err := json.Marshal(r.redactValue(value))
+	if err != nil {
+		return []byte(r.redactText(string(body)))
+	}
+	return redacted
+}
+
+// redactValue redacts a decoded JSON value
+func (r *Redactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if r.fields[strings.ToLower(key)] {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = r.redactValue(field)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.redactValue(item)
+		}
+		return v
+	case string:
+		return r.redactText(v)
+	default:
+		return v
+	}
+}
+
+// redactText masks card and Aadhaar numbers and replaces pattern matches
+func (r *Redactor) redactText(text string) string {
+	text = sensitive.Mask(text, sensitive.Detect(text))
+	for _, re := range r.patterns {
+		text = re.ReplaceAllLiteralString(text, Redacted)
+	}
+	return text
+}
@@ -0,0 +1,168 @@
+// Package breaker guards calls to external dependencies with circuit
+// breakers, so that while a dependency is down calls to it fail fast and the
+// callers fall back instead of waiting out a timeout on every request
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+	"github.com/sony/gobreaker"                               // v0.5.0
+)
+
+// ErrOpen is returned for calls refused by an open breaker, or by a half-open
+// one already running its trial calls. The call was not made.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Metrics
+var (
+	breakerState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wallet_circuit_breaker_state",
+			Help: "State of the circuit breaker of a dependency: 0 closed, 1 half-open, 2 open",
+		},
+		[]string{"dependency"},
+	)
+
+	breakerTransitions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_circuit_breaker_transitions_total",
+			Help: "State changes of the circuit breaker of a dependency, by the state entered",
+		},
+		[]string{"dependency", "state"},
+	)
+
+	breakerRejections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_circuit_breaker_rejections_total",
+			Help: "Calls to a dependency refused by its circuit breaker",
+		},
+		[]string{"dependency"},
+	)
+)
+
+// Logger defines the logging used by breakers
+type Logger interface {
+	Info(msg string, fields ...interface{})
+	Warn(msg string, fields ...interface{})
+}
+
+// Settings holds the thresholds of a breaker
+type Settings struct {
+	// ConsecutiveFailures opens the breaker
+	ConsecutiveFailures uint32
+	// Interval is how often the failure count of a closed breaker is reset;
+	// zero only resets it on a success
+	Interval time.Duration
+	// OpenTimeout is how long the breaker stays open before letting trial
+	// calls through
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of trial calls let through while
+	// half-open, all of which must succeed to close the breaker
+	HalfOpenRequests uint32
+}
+
+// Breaker is the circuit breaker of one dependency
+type Breaker struct {
+	name       string
+	cb         *gobreaker.TwoStepCircuitBreaker
+	successful func(err error) bool
+}
+
+// New creates a closed breaker for the named dependency. Successful reports
+// whether a call outcome shows the dependency is up, such as a cache miss or
+// a declined payment; nil counts only calls without an error.
+func New(name string, settings Settings, successful func(err error) bool, logger Logger) (*Breaker, error) {
+	if name == "" {
+		return nil, errors.New("dependency name is required")
+	}
+	if settings.ConsecutiveFailures == 0 {
+		return nil, errors.New("consecutive failures must be positive")
+	}
+	if settings.OpenTimeout <= 0 {
+		return nil, errors.New("open timeout must be positive")
+	}
+	if settings.HalfOpenRequests == 0 {
+		return nil, errors.New("half-open requests must be positive")
+	}
+	if settings.Interval < 0 {
+		return nil, errors.New("interval must not be negative")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if successful == nil {
+		successful = func(err error) bool { return err == nil }
+	}
+
+	breakerState.WithLabelValues(name).Set(stateValue(gobreaker.StateClosed))
+	return &Breaker{
+		name:       name,
+		successful: successful,
+		cb: gobreaker.NewTwoStepCircuitBreaker(gobreaker.Settings{
+			Name:        name,
+			MaxRequests: settings.HalfOpenRequests,
+			Interval:    settings.Interval,
+			Timeout:     settings.OpenTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= settings.ConsecutiveFailures
+			},
+			OnStateChange: func(name string, from, to gobreaker.State) {
+				breakerState.WithLabelValues(name).Set(stateValue(to))
+				breakerTransitions.WithLabelValues(name, to.String()).Inc()
+				if to == gobreaker.StateOpen {
+					logger.Warn("circuit breaker opened", "dependency", name, "from", from.String())
+					return
+				}
+				logger.Info("circuit breaker state changed", "dependency", name, "from", from.String(), "to", to.String())
+			},
+		}),
+	}, nil
+}
+
+// Name returns the dependency the breaker guards
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the state of the breaker: closed, half-open or open
+func (b *Breaker) State() string {
+	return b.cb.State().String()
+}
+
+// Do makes the call unless the breaker is open, recording its outcome
+func (b *Breaker) Do(call func() error) error {
+	done, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = call()
+	done(err)
+	return err
+}
+
+// allow admits a call, returning the function recording its outcome, or
+// ErrOpen when the call is refused
+func (b *Breaker) allow() (func(err error), error) {
+	done, err := b.cb.Allow()
+	if err != nil {
+		breakerRejections.WithLabelValues(b.name).Inc()
+		return nil, fmt.Errorf("%w: %s", ErrOpen, b.name)
+	}
+	return func(err error) { done(b.successful(err)) }, nil
+}
+
+// stateValue is the gauge value of a state
+func stateValue(state gobreaker.State) float64 {
+	switch state {
+	case gobreaker.StateHalfOpen:
+		return 1
+	case gobreaker.StateOpen:
+		return 2
+	}
+	return 0
+}
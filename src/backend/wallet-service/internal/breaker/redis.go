@@ -0,0 +1,71 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-redis/redis/v8" // v8.11.5
+)
+
+// redisDoneKey is the context key of the outcome recorder of a Redis call
+type redisDoneKey struct{}
+
+// RedisHook guards every command and pipeline of a Redis client with the
+// breaker. While it is open commands fail with ErrOpen without waiting on
+// the connection, and features apply their degradation policy right away.
+func RedisHook(b *Breaker) redis.Hook {
+	return redisHook{breaker: b}
+}
+
+// redisHook implements redis.Hook
+type redisHook struct {
+	breaker *Breaker
+}
+
+// RedisSuccessful reports whether a Redis call outcome shows Redis is up: a
+// missing key is a reply and a cancelled caller says nothing of Redis
+func RedisSuccessful(err error) bool {
+	return err == nil || errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled)
+}
+
+func (h redisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return h.before(ctx)
+}
+
+func (h redisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.after(ctx, cmd.Err())
+	return nil
+}
+
+func (h redisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return h.before(ctx)
+}
+
+func (h redisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmdErr := cmd.Err(); !RedisSuccessful(cmdErr) {
+			err = cmdErr
+			break
+		}
+	}
+	h.after(ctx, err)
+	return nil
+}
+
+// before admits the call, carrying its outcome recorder in the context
+func (h redisHook) before(ctx context.Context) (context.Context, error) {
+	done, err := h.breaker.allow()
+	if err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, redisDoneKey{}, done), nil
+}
+
+// after records the outcome of an admitted call; refused calls carry no
+// recorder
+func (h redisHook) after(ctx context.Context, err error) {
+	if done, ok := ctx.Value(redisDoneKey{}).(func(error)); ok {
+		done(err)
+	}
+}
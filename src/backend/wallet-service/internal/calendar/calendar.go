@@ -0,0 +1,205 @@
+// Package calendar provides per-country business-day calendars so billing
+// schedulers can defer customer-facing and provider-facing work, such as
+// payment reminders and refund submissions, past weekends and holidays.
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxLookahead bounds the days searched for the next business day, guarding
+// against calendars whose holidays cover every remaining day
+const maxLookahead = 366
+
+// Calendar errors
+var (
+	ErrInvalidWeekday = errors.New("invalid weekday")
+	ErrInvalidHoliday = errors.New("invalid holiday date")
+)
+
+// Calendar decides which days are business days in one region. Days begin
+// and end in the calendar's time zone.
+type Calendar struct {
+	location *time.Location
+	weekend  map[time.Weekday]bool
+	// holidays holds one-off dates as YYYY-MM-DD
+	holidays map[string]bool
+	// annual holds holidays recurring every year as MM-DD
+	annual map[string]bool
+}
+
+// Definition describes a calendar in configuration form
+type Definition struct {
+	// Timezone is an IANA zone name, defaulting to UTC
+	Timezone string
+	// Weekend lists non-working weekdays by English name, e.g. Saturday
+	Weekend []string
+	// Holidays lists dates as YYYY-MM-DD, or MM-DD for annual holidays
+	Holidays []string
+}
+
+// New creates a calendar from a definition
+func New(def Definition) (*Calendar, error) {
+	location := time.UTC
+	if def.Timezone != "" {
+		loc, err := time.LoadLocation(def.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", def.Timezone, err)
+		}
+		location = loc
+	}
+
+	c := &Calendar{
+		location: location,
+		weekend:  make(map[time.Weekday]bool),
+		holidays: make(map[string]bool),
+		annual:   make(map[string]bool),
+	}
+
+	for _, name := range def.Weekend {
+		day, err := parseWeekday(name)
+		if err != nil {
+			return nil, err
+		}
+		c.weekend[day] = true
+	}
+	if len(c.weekend) == 7 {
+		return nil, errors.New("weekend must leave at least one working day")
+	}
+
+	for _, date := range def.Holidays {
+		date = strings.TrimSpace(date)
+		if _, err := time.Parse("2006-01-02", date); err == nil {
+			c.holidays[date] = true
+			continue
+		}
+		// Annual dates are checked against a leap year so 02-29 is accepted
+		if _, err := time.Parse("2006-01-02", "2024-"+date); err == nil && len(date) == 5 {
+			c.annual[date] = true
+			continue
+		}
+		return nil, fmt.Errorf("%w: %q", ErrInvalidHoliday, date)
+	}
+
+	return c, nil
+}
+
+// Standard returns a calendar with a Saturday and Sunday weekend in UTC and
+// no holidays
+func Standard() *Calendar {
+	c, _ := New(Definition{Weekend: []string{"Saturday", "Sunday"}})
+	return c
+}
+
+// Location returns the time zone in which the calendar's days begin and end
+func (c *Calendar) Location() *time.Location {
+	return c.location
+}
+
+// IsBusinessDay reports whether t falls on a working day that is not a holiday
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	local := t.In(c.location)
+	if c.weekend[local.Weekday()] {
+		return false
+	}
+	return !c.holidays[local.Format("2006-01-02")] && !c.annual[local.Format("01-02")]
+}
+
+// NextBusinessDay returns t when it falls on a business day, and otherwise
+// the start of the next business day
+func (c *Calendar) NextBusinessDay(t time.Time) time.Time {
+	if c.IsBusinessDay(t) {
+		return t
+	}
+
+	day := startOfDay(t.In(c.location))
+	for i := 0; i < maxLookahead; i++ {
+		day = day.AddDate(0, 0, 1)
+		if c.IsBusinessDay(day) {
+			break
+		}
+	}
+	return day.In(t.Location())
+}
+
+// AddBusinessDays moves t by n business days, keeping its local time of day.
+// Negative n moves backwards. A t outside business days first rolls forward
+// to the next business day.
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	local := c.NextBusinessDay(t).In(c.location)
+
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for moved := 0; moved < n; moved++ {
+		for i := 0; i < maxLookahead; i++ {
+			local = local.AddDate(0, 0, step)
+			if c.IsBusinessDay(local) {
+				break
+			}
+		}
+	}
+	return local.In(t.Location())
+}
+
+// startOfDay returns local midnight of the day of t
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// parseWeekday parses an English weekday name, case-insensitively
+func parseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(strings.TrimSpace(name), day.String()) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrInvalidWeekday, name)
+}
+
+// Registry holds the calendars of each configured country
+type Registry struct {
+	calendars map[string]*Calendar
+	fallback  *Calendar
+}
+
+// NewRegistry creates calendars for the given countries, keyed by ISO 3166
+// country code. Countries without a definition use the Standard calendar.
+func NewRegistry(defs map[string]Definition) (*Registry, error) {
+	r := &Registry{
+		calendars: make(map[string]*Calendar, len(defs)),
+		fallback:  Standard(),
+	}
+	for country, def := range defs {
+		c, err := New(def)
+		if err != nil {
+			return nil, fmt.Errorf("calendar %s: %w", strings.ToUpper(country), err)
+		}
+		r.calendars[strings.ToUpper(country)] = c
+	}
+	return r, nil
+}
+
+// For returns the calendar of a country, or the Standard calendar when the
+// country has none
+func (r *Registry) For(country string) *Calendar {
+	if c, ok := r.calendars[strings.ToUpper(country)]; ok {
+		return c
+	}
+	return r.fallback
+}
+
+// Countries returns the configured country codes in order
+func (r *Registry) Countries() []string {
+	countries := make([]string, 0, len(r.calendars))
+	for country := range r.calendars {
+		countries = append(countries, country)
+	}
+	sort.Strings(countries)
+	return countries
+}
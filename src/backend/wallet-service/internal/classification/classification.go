@@ -0,0 +1,207 @@
+// Package classification tags model fields with data sensitivity classes and
+// decides per caller which classified fields a response may carry. Every
+// access to a classified field is counted so the data-governance program can
+// report who reads financial and personal data, field by field.
+package classification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Tag is the struct tag holding the class of a model field
+const Tag = "class"
+
+// Class is the sensitivity class of a field. Untagged fields are public.
+type Class string
+
+const (
+	// ClassFinancial covers balances, limits and amounts
+	ClassFinancial Class = "financial"
+	// ClassPII covers data identifying or describing a customer
+	ClassPII Class = "pii"
+	// ClassInternal covers bookkeeping details of no use to integrators
+	ClassInternal Class = "internal"
+)
+
+// Classes lists every sensitivity class
+var Classes = []Class{ClassFinancial, ClassPII, ClassInternal}
+
+// Decision is the outcome of an access to a classified field
+type Decision string
+
+const (
+	// DecisionAllowed is an access by a caller holding the class scope
+	DecisionAllowed Decision = "allowed"
+	// DecisionLogged is an access that lacked the scope but was only logged
+	// because the policy is not enforced
+	DecisionLogged Decision = "logged"
+	// DecisionDenied is an access that lacked the scope; the field was removed
+	DecisionDenied Decision = "denied"
+)
+
+// Field is a classified field of the API responses
+type Field struct {
+	Name  string `json:"name"`
+	Class Class  `json:"class"`
+}
+
+// Access is a classified field read by a request
+type Access struct {
+	Field    string
+	Class    Class
+	Decision Decision
+}
+
+// Catalog maps the JSON names of classified fields to their class. Responses
+// are classified by field name, so a name carries one class service-wide.
+type Catalog struct {
+	fields map[string]Class
+}
+
+// NewCatalog builds the catalog from the class tags of the given models,
+// following nested structs, pointers, slices and maps
+func NewCatalog(models ...interface{}) (*Catalog, error) {
+	c := &Catalog{fields: make(map[string]Class)}
+	seen := make(map[reflect.Type]bool)
+	for _, m := range models {
+		if err := c.add(reflect.TypeOf(m), seen); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// add records the classified fields of t
+func (c *Catalog) add(t reflect.Type, seen map[reflect.Type]bool) error {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" || !f.IsExported() && !f.Anonymous {
+			continue
+		}
+
+		if tag := f.Tag.Get(Tag); tag != "" {
+			class := Class(tag)
+			if !class.valid() {
+				return fmt.Errorf("%s.%s: unknown data class %q", t.Name(), f.Name, tag)
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if existing, ok := c.fields[name]; ok && existing != class {
+				return fmt.Errorf("%s.%s: field %q is classified both %s and %s", t.Name(), f.Name, name, existing, class)
+			}
+			c.fields[name] = class
+		}
+
+		if err := c.add(f.Type, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Class returns the class of a JSON field name
+func (c *Catalog) Class(field string) (Class, bool) {
+	class, ok := c.fields[field]
+	return class, ok
+}
+
+// Fields returns the classified fields sorted by name
+func (c *Catalog) Fields() []Field {
+	fields := make([]Field, 0, len(c.fields))
+	for name, class := range c.fields {
+		fields = append(fields, Field{Name: name, Class: class})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// Filter walks a JSON document and returns the classified fields it carries,
+// once per field name. When the policy is enforced, fields the caller's
+// scopes do not cover are removed from the returned document; otherwise the
+// document is returned unchanged.
+func (c *Catalog) Filter(body []byte, policy Policy, scopes []string) ([]byte, []Access, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return body, nil, err
+	}
+
+	w := walker{catalog: c, policy: policy, scopes: scopes, seen: make(map[string]bool)}
+	w.walk(doc)
+	sort.Slice(w.accesses, func(i, j int) bool { return w.accesses[i].Field < w.accesses[j].Field })
+	if !w.removed {
+		return body, w.accesses, nil
+	}
+
+	filtered, err := json.Marshal(doc)
+	if err != nil {
+		return body, w.accesses, err
+	}
+	return filtered, w.accesses, nil
+}
+
+// walker collects and, when enforcing, removes classified fields
+type walker struct {
+	catalog  *Catalog
+	policy   Policy
+	scopes   []string
+	seen     map[string]bool
+	accesses []Access
+	removed  bool
+}
+
+func (w *walker) walk(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			class, ok := w.catalog.fields[key]
+			if !ok {
+				w.walk(value)
+				continue
+			}
+
+			decision := w.policy.Decide(class, w.scopes)
+			if !w.seen[key] {
+				w.seen[key] = true
+				w.accesses = append(w.accesses, Access{Field: key, Class: class, Decision: decision})
+			}
+			if decision == DecisionDenied {
+				delete(v, key)
+				w.removed = true
+				continue
+			}
+			w.walk(value)
+		}
+	case []interface{}:
+		for _, item := range v {
+			w.walk(item)
+		}
+	}
+}
+
+// valid reports whether the class is known
+func (c Class) valid() bool {
+	for _, class := range Classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package classification
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+)
+
+// fieldAccesses counts classified field accesses for per-field reports
+// across replicas
+var fieldAccesses = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_classified_field_access_total",
+		Help: "Classified response fields read, by field, class and decision",
+	},
+	[]string{"field", "class", "decision"},
+)
+
+// DefaultScopes returns the token role granting each class, data:<class>
+func DefaultScopes() map[Class]string {
+	scopes := make(map[Class]string, len(Classes))
+	for _, class := range Classes {
+		scopes[class] = "data:" + string(class)
+	}
+	return scopes
+}
+
+// Policy maps each class to the scope granting it. Callers lacking the scope
+// of a class have its fields removed when Enforce is set and are only logged
+// otherwise, so a rollout can measure who would lose access first.
+type Policy struct {
+	Enforce bool
+	Scopes  map[Class]string
+}
+
+// Decide returns the decision for a caller with scopes reading a field of class
+func (p Policy) Decide(class Class, scopes []string) Decision {
+	required, ok := p.Scopes[class]
+	if !ok || required == "" {
+		return DecisionAllowed
+	}
+	for _, scope := range scopes {
+		if scope == required {
+			return DecisionAllowed
+		}
+	}
+	if p.Enforce {
+		return DecisionDenied
+	}
+	return DecisionLogged
+}
+
+// ReportEntry is the access count of a classified field
+type ReportEntry struct {
+	Field   string `json:"field"`
+	Class   Class  `json:"class"`
+	Allowed int64  `json:"allowed"`
+	Logged  int64  `json:"logged"`
+	Denied  int64  `json:"denied"`
+}
+
+// Report aggregates the field accesses of this replica since it started.
+// Fleet-wide reports come from the wallet_classified_field_access_total metric.
+type Report struct {
+	mu     sync.Mutex
+	counts map[string]*ReportEntry
+}
+
+// NewReport returns an empty report covering every field of the catalog
+func NewReport(catalog *Catalog) *Report {
+	counts := make(map[string]*ReportEntry, len(catalog.fields))
+	for name, class := range catalog.fields {
+		counts[name] = &ReportEntry{Field: name, Class: class}
+	}
+	return &Report{counts: counts}
+}
+
+// Record counts the accesses of a request
+func (r *Report) Record(accesses []Access) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, a := range accesses {
+		fieldAccesses.WithLabelValues(a.Field, string(a.Class), string(a.Decision)).Inc()
+
+		entry, ok := r.counts[a.Field]
+		if !ok {
+			entry = &ReportEntry{Field: a.Field, Class: a.Class}
+			r.counts[a.Field] = entry
+		}
+		switch a.Decision {
+		case DecisionAllowed:
+			entry.Allowed++
+		case DecisionLogged:
+			entry.Logged++
+		case DecisionDenied:
+			entry.Denied++
+		}
+	}
+}
+
+// Entries returns the access counts of every classified field, sorted by name
+func (r *Report) Entries() []ReportEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]ReportEntry, 0, len(r.counts))
+	for _, entry := range r.counts {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Field < entries[j].Field })
+	return entries
+}
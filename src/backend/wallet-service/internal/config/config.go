@@ -5,6 +5,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper" // v1.16.0
@@ -21,10 +23,61 @@ const (
 
 // Config represents the main configuration container for all service settings
 type Config struct {
-	Database DatabaseConfig
-	Cache    RedisConfig
-	API      APIConfig
-	Security SecurityConfig
+	Database            DatabaseConfig
+	Cache               RedisConfig
+	API                 APIConfig
+	Security            SecurityConfig
+	SelfCheck           SelfCheckConfig
+	Analytics           AnalyticsConfig
+	Degradation         DegradationConfig
+	Reconciliation      ReconciliationConfig
+	Events              EventsConfig
+	Digest              DigestConfig
+	Snapshots           SnapshotConfig
+	Webhooks            WebhookConfig
+	Refunds             RefundConfig
+	Dunning             DunningConfig
+	Calendar            CalendarConfig
+	GraphQL             GraphQLConfig
+	Invoices            InvoiceServiceConfig
+	LiveUpdates         LiveUpdatesConfig
+	WebSocket           WebSocketConfig
+	Classification      ClassificationConfig
+	DecimalVerification DecimalVerificationConfig
+	Usage               UsageConfig
+	RateCards           RateCardConfig
+	SLO                 SLOConfig
+	Deprecations        DeprecationConfig
+	RecurringDebits     RecurringDebitConfig
+	Workers             WorkerConfig
+	Wallet              WalletConfig
+	Logging             LoggingConfig
+	Secrets             SecretsConfig
+	Migrations          MigrationConfig
+	BodyCapture         BodyCaptureConfig
+	Currencies          CurrencyConfig
+	ReportJobs          ReportJobConfig
+	TransactionArchive  TransactionArchiveConfig
+	Exports             ExportConfig
+	NotificationQueue   NotificationQueueConfig
+	BalanceThresholds   BalanceThresholdConfig
+	ReadOnly            ReadOnlyConfig
+	Tax                 TaxConfig
+	WalletActivity      WalletActivityConfig
+	BulkCredits         BulkCreditConfig
+	Incidents           IncidentConfig
+	Descriptions        DescriptionConfig
+	Notifications       NotificationChannelConfig
+	AccessLog           AccessLogConfig
+	InvoiceDocuments    InvoiceDocumentConfig
+	Receivables         ReceivableConfig
+	Postpaid            PostpaidConfig
+	Allowances          AllowanceConfig
+	Adjustments         AdjustmentConfig
+	InboundWebhooks     InboundWebhookConfig
+	FailedJobs          FailedJobConfig
+	CircuitBreakers     CircuitBreakerConfig
+	Startup             StartupConfig
 }
 
 // DatabaseConfig holds PostgreSQL database configuration with connection pooling
@@ -39,6 +92,21 @@ type DatabaseConfig struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	MaxConnLifetime time.Duration
+	// ReplicaDSN is the connection string of a read replica serving
+	// transaction history and balance reads. Reads use the primary when it
+	// is empty.
+	ReplicaDSN string
+	// ReplicaMaxLag is how far behind the replica may be for balances to be
+	// read from it
+	ReplicaMaxLag time.Duration
+	// ReplicaCheckInterval is how often the replica's lag is checked
+	ReplicaCheckInterval time.Duration
+	// SlowQueryThreshold is the latency above which statements are logged
+	// and counted as slow; zero disables the slow query log
+	SlowQueryThreshold time.Duration
+	// PoolStatsInterval is how often connection pool statistics are
+	// exported as metrics
+	PoolStatsInterval time.Duration
 }
 
 // RedisConfig holds Redis cache configuration with high availability settings
@@ -60,21 +128,825 @@ type APIConfig struct {
 	WriteTimeout    time.Duration
 	ShutdownTimeout time.Duration
 	MaxRequestSize  int
+	IdempotencyTTL  time.Duration
+	// SwaggerUI serves an interactive API explorer at /api/v1/docs
+	SwaggerUI bool
 }
 
 // SecurityConfig holds security settings for authentication and rate limiting
 type SecurityConfig struct {
-	JWTSecret      string
-	JWTExpiry      time.Duration
-	RateLimit      int
-	RateLimitWindow time.Duration
-	EnableTLS      bool
-	TLSCertPath    string
-	TLSKeyPath     string
+	JWTSecret           string
+	JWTExpiry           time.Duration
+	JWTAlgorithms       []string
+	JWTIssuer           string
+	JWTAudience         string
+	JWTClockSkew        time.Duration
+	JWKSURL             string
+	JWKSRefreshInterval time.Duration
+	// JWKSMinRefreshInterval throttles the key set refreshes tokens with
+	// unknown key IDs trigger
+	JWKSMinRefreshInterval time.Duration
+	RateLimit              int
+	RateLimitWindow        time.Duration
+	RateLimitPolicyTTL     time.Duration
+	SensitiveDataPolicy    string
+	EnableTLS              bool
+	TLSCertPath            string
+	TLSKeyPath             string
+}
+
+// SelfCheckConfig holds settings for the startup dependency self-check
+type SelfCheckConfig struct {
+	Enabled       bool
+	AllowDegraded bool
+	Timeout       time.Duration
+	NTPServer     string
+	MaxClockSkew  time.Duration
+}
+
+// AnalyticsConfig holds privacy settings for aggregate analytics endpoints
+// and caching of per-wallet spending summaries
+type AnalyticsConfig struct {
+	MinGroupSize int
+	NoiseEpsilon float64
+	// MedianBucket is the width of the buckets published medians are
+	// rounded to
+	MedianBucket float64
+	// WalletCacheTTL is how long wallet spending summaries are cached in
+	// Redis; zero disables caching
+	WalletCacheTTL time.Duration
+}
+
+// DegradationConfig holds the failure policies ("fail-open" or "fail-closed")
+// applied by features when Redis is unavailable
+type DegradationConfig struct {
+	RateLimit    string
+	Idempotency  string
+	ProbeTimeout time.Duration
+	// CheckInterval is how often dependencies are probed to refresh the
+	// capability matrix
+	CheckInterval time.Duration
+	// ReadOnlyRetryAfter is the Retry-After of writes refused while the
+	// primary database is read-only, as during a failover
+	ReadOnlyRetryAfter time.Duration
+}
+
+// ReconciliationConfig holds settings for the nightly ledger reconciliation
+type ReconciliationConfig struct {
+	Enabled bool
+	// RunAt is the time of day, as an offset from midnight UTC, of the run
+	RunAt time.Duration
+}
+
+// EventsConfig holds settings for publishing events to the notification pipeline
+type EventsConfig struct {
+	Stream       string
+	StreamMaxLen int64
+}
+
+// DigestConfig holds settings for wallet activity digest delivery
+type DigestConfig struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
+// SnapshotConfig holds settings for the daily wallet balance snapshots
+type SnapshotConfig struct {
+	Enabled bool
+	// RunAt is the time of day, as an offset from midnight UTC, of the run
+	RunAt time.Duration
+}
+
+// WebhookConfig holds settings for delivering events to customer webhooks
+type WebhookConfig struct {
+	Enabled bool
+	// ConsumerGroup is the events stream consumer group shared by replicas
+	ConsumerGroup   string
+	BatchSize       int64
+	DeliveryTimeout time.Duration
+}
+
+// RefundConfig holds settings for tracking refunds to the payment provider
+type RefundConfig struct {
+	Enabled bool
+	// PollInterval is how often pending refunds are submitted or checked
+	PollInterval time.Duration
+}
+
+// DunningConfig holds settings for grace periods of exhausted wallets
+type DunningConfig struct {
+	Enabled  bool
+	Interval time.Duration
+	// GracePeriod is how long service continues after the balance ran out
+	GracePeriod time.Duration
+	// NoticeSchedule holds the offsets from the start of the grace period at
+	// which escalating notices are sent
+	NoticeSchedule []time.Duration
+	// SuspendAfterGrace emits customer.suspend when the grace period expires
+	SuspendAfterGrace bool
+}
+
+// RecurringDebitConfig holds the scheduler of recurring debits
+type RecurringDebitConfig struct {
+	Enabled  bool
+	Interval time.Duration
+	// CatchUpWindow is how late a run missed during downtime may still
+	// debit; older missed runs are skipped. Zero catches up every run.
+	CatchUpWindow time.Duration
+	// ClaimTimeout is how long a run left pending by a stopped scheduler
+	// waits before it is taken over
+	ClaimTimeout time.Duration
+}
+
+// WorkerConfig holds settings for the background worker runner
+type WorkerConfig struct {
+	// LeaseTTL is how long a replica leads a singleton worker without
+	// renewing its lease, and so how long a crashed leader blocks takeover
+	LeaseTTL time.Duration
+	// RestartDelay is how long a failed long-running worker waits before it
+	// is restarted
+	RestartDelay time.Duration
+}
+
+// WalletConfig holds wallet defaults, reloaded without restart
+type WalletConfig struct {
+	// LowBalanceThreshold applies to wallets without a threshold of their own
+	LowBalanceThreshold float64
+	// TransactionQueryHorizon is how far back transaction history goes
+	// without a start date, so unbounded queries scan recent partitions only
+	TransactionQueryHorizon time.Duration
+}
+
+// LoggingConfig holds logging settings. The level is reloaded without
+// restart.
+type LoggingConfig struct {
+	// Level is debug, info, warn or error
+	Level string
+	// Encoding is json or console
+	Encoding string
+}
+
+// SecretsConfig selects where the database credentials and the JWT secret
+// are loaded from. Secrets the provider does not hold keep the values of
+// the configuration file.
+type SecretsConfig struct {
+	// Provider is env, file, aws or vault; empty reads the configuration
+	// file only
+	Provider string
+	// EnvPrefix prefixes the environment variables of the env provider
+	EnvPrefix string
+	// Dir is the directory of the secret files of the file provider
+	Dir string
+	// AWSRegion and AWSSecretID locate the AWS Secrets Manager secret
+	AWSRegion   string
+	AWSSecretID string
+	// VaultAddress, VaultMount and VaultPath locate the Vault KV secret.
+	// VaultToken falls back to the VAULT_TOKEN environment variable.
+	VaultAddress string
+	VaultToken   string
+	VaultMount   string
+	VaultPath    string
+	// Timeout bounds each read from the provider
+	Timeout time.Duration
+	// RotationInterval is how often the database credentials are refreshed
+	// from the provider; zero disables rotation
+	RotationInterval time.Duration
+}
+
+// MigrationConfig holds settings for moving wallets between deployments and
+// merging duplicate wallets
+type MigrationConfig struct {
+	// HistoryWindow is how far back the transactions of a wallet export go
+	HistoryWindow time.Duration
+	// HistoryLimit bounds the transactions of a wallet export within the window
+	HistoryLimit int
+	// MergeWindow is how long a merge of duplicate wallets may be reversed
+	MergeWindow time.Duration
+}
+
+// BodyCaptureConfig holds settings for capturing request and response bodies
+// to the audit store for payment disputes. Captured bodies are redacted
+// before they leave the request.
+type BodyCaptureConfig struct {
+	Enabled bool
+	// SampleRate is the fraction of requests captured, from 0 to 1
+	SampleRate float64
+	// MaxBodyBytes caps each stored body, after redaction
+	MaxBodyBytes int
+	// FieldMasks are the JSON field names whose values are redacted at any
+	// depth, matched case-insensitively
+	FieldMasks []string
+	// Patterns are regular expressions redacted from every string value
+	Patterns []string
+	// QueueSize bounds the captures waiting to be stored; captures beyond it
+	// are dropped
+	QueueSize int
+	// Retention is how long captures are kept
+	Retention time.Duration
+}
+
+// CurrencyConfig holds the currencies transactions may use and the decimal
+// places their amounts are rounded to
+type CurrencyConfig struct {
+	// Source is config, reading Supported and Precision, or database,
+	// reading the enabled rows of the currencies table
+	Source string
+	// Supported are ISO 4217 currency codes
+	Supported []string
+	// Precision overrides the ISO 4217 decimal places of supported
+	// currencies, by code
+	Precision map[string]int32
+	// Rounding sets the rounding mode of supported currencies by code:
+	// HALF_UP (default), HALF_EVEN, DOWN, UP, CEILING or FLOOR
+	Rounding map[string]string
+	// ReportingCurrency is the currency customer balances are totalled in
+	// unless a request asks for another
+	ReportingCurrency string
+	// FXRates are the units of the reporting currency per unit of other
+	// currencies, by code. Wallets in currencies without a rate are left
+	// out of customer totals.
+	FXRates map[string]float64
+}
+
+// ReportJobConfig holds settings for long-running report jobs
+type ReportJobConfig struct {
+	// Concurrency is the number of jobs each replica runs at once
+	Concurrency int
+	// PollInterval is how often idle workers look for queued jobs
+	PollInterval time.Duration
+	// MaxAttempts bounds the runs of a job, retries included
+	MaxAttempts int
+	// RetryDelay is the wait before the first retry, doubled for each
+	// further one
+	RetryDelay time.Duration
+	// HeartbeatInterval is how often a running job records its progress
+	// and checks for cancellation
+	HeartbeatInterval time.Duration
+	// StaleAfter is how long a running job may miss heartbeats before
+	// another replica takes it over
+	StaleAfter time.Duration
+	// Retention is how long finished jobs and their results are kept
+	Retention time.Duration
+}
+
+// TransactionArchiveConfig holds the maintenance of the monthly partitions
+// of wallet transactions
+type TransactionArchiveConfig struct {
+	Enabled  bool
+	Interval time.Duration
+	// RetentionMonths is how many full months before the current one stay
+	// in wallet_transactions; older months move to the archive table
+	RetentionMonths int
+	// MonthsAhead is how many months past the current one have their
+	// partition created in advance
+	MonthsAhead int
+}
+
+// ExportConfig holds the object storage bulk transaction exports are
+// written to
+type ExportConfig struct {
+	Enabled bool
+	// Provider is s3 or gcs
+	Provider string
+	Bucket   string
+	// Prefix is prepended to the keys of export files
+	Prefix string
+	// Region is the AWS region of an S3 bucket; empty uses the region of
+	// the default AWS configuration
+	Region string
+	// URLExpiry is how long signed download links stay valid
+	URLExpiry time.Duration
+}
+
+// NotificationQueueConfig holds settings for redelivering notifications the
+// notification pipeline could not take
+type NotificationQueueConfig struct {
+	Enabled bool
+	// RetryInterval is how often due notifications are redelivered
+	RetryInterval time.Duration
+	// AlertTTL is how long low balance and credit limit warnings are
+	// retried before they are too stale to send
+	AlertTTL time.Duration
+	// NoticeTTL is how long digests and dunning notices are retried
+	NoticeTTL time.Duration
+	// Retention is how long expired notifications are kept for review
+	Retention time.Duration
+}
+
+// BalanceThresholdConfig holds the defaults of wallet balance alert levels,
+// as percentages of each wallet's reference balance
+type BalanceThresholdConfig struct {
+	Enabled bool
+	// WarningPercent and CriticalPercent are the balances the alerts
+	// trigger below, and the reset percentages the balances they re-arm
+	// above
+	WarningPercent       float64
+	WarningResetPercent  float64
+	CriticalPercent      float64
+	CriticalResetPercent float64
+}
+
+// ReadOnlyConfig holds the read-only deployment mode, which serves heavy
+// analytics reads from the replica away from the transactional path
+type ReadOnlyConfig struct {
+	// Enabled connects to Database.ReplicaDSN, refuses mutating routes and
+	// runs only the workers that change no data
+	Enabled bool
+	// RateLimitMultiplier scales every rate limit policy. Requests are
+	// counted in windows of their own, apart from the transactional path's.
+	RateLimitMultiplier int
+}
+
+// TaxConfig holds the sales tax rules, such as GST and VAT, charged to
+// customers by their tax region
+type TaxConfig struct {
+	Enabled bool
+	// ApplyToDebits charges tax on top of debits posted through the API
+	ApplyToDebits bool
+	// Regions holds each region's tax by ISO 3166 country code, or by
+	// subdivision code such as IN-KA where it differs from the country's
+	Regions map[string]TaxRegionConfig
+}
+
+// TaxRegionConfig holds the tax of a region
+type TaxRegionConfig struct {
+	// Name of the tax, e.g. GST or VAT
+	Name string
+	// Components split the tax into named rates, e.g. CGST and SGST
+	Components []TaxComponentConfig
+	// ExemptCategories are the categories of supplies charged no tax
+	ExemptCategories []string
+}
+
+// TaxComponentConfig holds a named rate of a tax as a percentage
+type TaxComponentConfig struct {
+	Name string
+	Rate float64
+}
+
+// WalletActivityConfig holds the sweep flagging wallets inactive after a
+// period without transactions, and active again once they transact
+type WalletActivityConfig struct {
+	Enabled  bool
+	Interval time.Duration
+	// InactiveAfter is how long a wallet goes without transactions before
+	// it is flagged inactive
+	InactiveAfter time.Duration
+	// BatchSize is how many wallets each sweep flags either way at most
+	BatchSize int
+}
+
+// BulkCreditConfig holds the quota and database load of bulk credit jobs
+type BulkCreditConfig struct {
+	// MaxRows is how many wallets one operation may credit at most
+	MaxRows int
+	// ChunkSize is how many rows are posted between recording their
+	// outcome and reporting progress
+	ChunkSize int
+	// RowsPerSecond caps the rate a job posts rows at
+	RowsPerSecond int
+}
+
+// IncidentConfig holds the incident switch pausing transaction types
+type IncidentConfig struct {
+	// RefreshInterval is how often each replica reloads the active
+	// incident, which bounds how long a change takes to apply everywhere,
+	// and applies a scheduled resume
+	RefreshInterval time.Duration
+	// MaxDuration is how far ahead an incident's resume can be scheduled
+	MaxDuration time.Duration
+}
+
+// DescriptionConfig holds the templates and normalization of transaction
+// descriptions
+type DescriptionConfig struct {
+	// Enabled normalizes the descriptions of posted and exported
+	// transactions, rendering them from templates where one applies
+	Enabled bool
+	// MaxLength is the longest description in characters; longer ones are
+	// cut with an ellipsis
+	MaxLength int
+	// Templates are the descriptions of transactions by source and product
+	Templates []DescriptionTemplateConfig
+}
+
+// DescriptionTemplateConfig holds the description template of the
+// transactions of a source and product
+type DescriptionTemplateConfig struct {
+	// Source and Product select the transactions by their source and
+	// product metadata; empty matches any
+	Source  string
+	Product string
+	// Template is the description with {name} variables: amount, currency
+	// and description of the transaction, or else its metadata
+	Template string
+}
+
+// NotificationChannelConfig holds settings for notifying customers on their
+// email, SMS and Slack channels
+type NotificationChannelConfig struct {
+	Enabled bool
+	// ConsumerGroup is the events stream consumer group shared by replicas
+	ConsumerGroup string
+	BatchSize     int64
+	// Timeout bounds each send to a mail server, SMS gateway or Slack
+	Timeout time.Duration
+	// SMTP is the mail server of email channels, which are unavailable
+	// without a host
+	SMTP SMTPConfig
+	// SMS is the gateway of SMS channels, which are unavailable without a
+	// URL
+	SMS SMSGatewayConfig
+	// Templates replace the subject or body of the default notification of
+	// an event type
+	Templates []NotificationTemplateConfig
+}
+
+// SMTPConfig holds the mail server notifications are emailed through
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// From is the sender address of notification emails
+	From string
+}
+
+// SMSGatewayConfig holds the HTTP gateway notifications are texted through
+type SMSGatewayConfig struct {
+	URL    string
+	APIKey string
+	// From is the sender ID of notification texts
+	From string
+}
+
+// NotificationTemplateConfig holds the template of an event type's
+// notification. Subject and Body are Go text templates over the event
+// payload; either left empty keeps the default.
+type NotificationTemplateConfig struct {
+	EventType string
+	Subject   string
+	Body      string
+}
+
+// AccessLogConfig holds settings for the customer-visible log of accesses
+// to their balances and transactions
+type AccessLogConfig struct {
+	Enabled bool
+	// FlushInterval is how often recorded accesses are stored
+	FlushInterval time.Duration
+	// MaxPending bounds the accesses held between flushes; accesses beyond
+	// it are dropped
+	MaxPending int
+	// Retention is how long accesses are kept
+	Retention time.Duration
+}
+
+// InvoiceDocumentConfig holds the branding and wording of invoice PDFs
+// delivered at billing cycle close
+type InvoiceDocumentConfig struct {
+	Enabled bool
+	// CompanyName, Address and TaxID identify the seller on every invoice
+	CompanyName string
+	Address     []string
+	TaxID       string
+	// AccentColor is the #RRGGBB color of the header band and table headings
+	AccentColor string
+	// PublicURL is the base URL of the API linked from invoice notifications;
+	// notifications carry no link while unset
+	PublicURL string
+	// Templates word the title, notes and footer of invoices; empty ones keep
+	// the default
+	Templates InvoiceTemplateConfig
+}
+
+// InvoiceTemplateConfig holds the Go text templates of invoice PDFs,
+// executed with the invoice
+type InvoiceTemplateConfig struct {
+	Title  string
+	Notes  string
+	Footer string
+}
+
+// ReceivableConfig holds the payment terms of invoices and the charges on
+// invoices paid late
+type ReceivableConfig struct {
+	// DefaultTerms are the payment terms of customers without terms of
+	// their own: NET_15, NET_30, NET_45 or NET_60
+	DefaultTerms string
+	// LateFeeMode is FEE for a one-off fee, INTEREST for daily interest on
+	// the unpaid amount, or empty to charge nothing
+	LateFeeMode string
+	// LateFeePercent is the fee of FEE mode, a percentage of the invoice
+	// total
+	LateFeePercent float64
+	// InterestRate is the annual percentage rate of INTEREST mode
+	InterestRate float64
+	// GracePeriod is how long after the due date late charges start
+	GracePeriod time.Duration
+	// AccrualInterval is how often overdue invoices are marked and charged
+	AccrualInterval time.Duration
+}
+
+// PostpaidConfig holds the billing of postpaid customers' accrued usage
+type PostpaidConfig struct {
+	// CloseInterval is how often charges of closed monthly cycles are
+	// billed and pending statements settled
+	CloseInterval time.Duration
+}
+
+// AllowanceConfig holds the free-tier allowances every wallet gets
+type AllowanceConfig struct {
+	// Products holds the free units of each product per monthly billing
+	// cycle, consumed by debits before the wallet is charged
+	Products map[string]int64
+}
+
+// AdjustmentConfig holds the approval of manual balance adjustments
+type AdjustmentConfig struct {
+	// ApprovalThresholds holds by currency the largest adjustment posted
+	// without a second approver. Adjustments in other currencies always
+	// need one.
+	ApprovalThresholds map[string]float64
+	// PendingTTL is how long an adjustment awaits approval before expiring
+	PendingTTL time.Duration
+	// ExpiryInterval is how often stale adjustments are expired
+	ExpiryInterval time.Duration
+}
+
+// InboundWebhookConfig holds the receiver of webhooks sent by payment
+// gateways and partner systems
+type InboundWebhookConfig struct {
+	// Providers holds by name the providers whose webhooks are accepted;
+	// without any the receiver is not mounted
+	Providers map[string]InboundProviderConfig
+	// MaxAttempts is how often a webhook is processed before it is
+	// dead-lettered
+	MaxAttempts int
+	// ProcessInterval is how often received webhooks are processed
+	ProcessInterval time.Duration
+}
+
+// InboundProviderConfig holds how a provider signs and identifies its
+// webhooks
+type InboundProviderConfig struct {
+	// Secret is the provider's HMAC-SHA256 signing secret
+	Secret string
+	// SignatureHeader carries the hex signature after SignaturePrefix, such
+	// as "sha256="
+	SignatureHeader string
+	SignaturePrefix string
+	// TimestampHeader carries the Unix time the request was signed at,
+	// signed along with the body; without one the body alone is signed
+	TimestampHeader string
+	// Tolerance is how old a signed timestamp may be before the request is
+	// refused as a replay
+	Tolerance time.Duration
+	// EventIDField, EventTypeField and OrderingKeyField are dot-separated
+	// paths of the JSON body holding the event ID, its type and the key,
+	// such as a payment ID, whose events are processed in order
+	EventIDField     string
+	EventTypeField   string
+	OrderingKeyField string
+}
+
+// FailedJobConfig holds the retries of failed background jobs
+type FailedJobConfig struct {
+	// RetryInterval is how often due retries are made
+	RetryInterval time.Duration
+	// WebhookDelivery and RecurringDebitRun are the retry policies of
+	// failed webhook deliveries and of recurring debit runs the wallet
+	// rejected
+	WebhookDelivery   RetryPolicyConfig
+	RecurringDebitRun RetryPolicyConfig
+}
+
+// RetryPolicyConfig holds the retry policy of a kind of failed job
+type RetryPolicyConfig struct {
+	// MaxAttempts counts the attempt that failed first; the job is
+	// dead-lettered once out of attempts
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry, doubled for every
+	// further retry up to MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// CircuitBreakerConfig holds the circuit breakers guarding external
+// dependencies, which fail calls fast while a dependency is down
+type CircuitBreakerConfig struct {
+	Redis           BreakerConfig
+	PaymentProvider BreakerConfig
+}
+
+// BreakerConfig holds the thresholds of a circuit breaker
+type BreakerConfig struct {
+	Enabled bool
+	// ConsecutiveFailures opens the breaker
+	ConsecutiveFailures int
+	// Interval is how often the failure count of a closed breaker is
+	// reset; zero only resets it on a success
+	Interval time.Duration
+	// OpenTimeout is how long the breaker stays open before trial calls
+	// are let through
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of trial calls, all of which must
+	// succeed to close the breaker
+	HalfOpenRequests int
+}
+
+// StartupConfig holds how the service waits at startup for the database
+// and Redis to become reachable before serving traffic
+type StartupConfig struct {
+	// MaxWait is how long the service waits for its dependencies in total
+	MaxWait time.Duration
+	// MinBackoff is the delay before the second connection attempt,
+	// doubled for every further attempt up to MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// AttemptTimeout bounds each connection attempt
+	AttemptTimeout time.Duration
+	// AllowDegraded starts the service without Redis once MaxWait has
+	// passed, features falling back under their failure policies. The
+	// database is always required.
+	AllowDegraded bool
+}
+
+// CalendarConfig holds the business-day calendars followed by billing jobs
+type CalendarConfig struct {
+	// Country selects the calendar of the schedulers, which otherwise use a
+	// Saturday and Sunday weekend in UTC
+	Country string
+	// Countries holds each country's calendar by ISO 3166 country code
+	Countries map[string]CountryCalendarConfig
+}
+
+// CountryCalendarConfig holds the time zone, weekend and holidays of a country
+type CountryCalendarConfig struct {
+	Timezone string
+	// Weekend lists non-working weekdays by name, e.g. Saturday
+	Weekend []string
+	// Holidays lists dates as YYYY-MM-DD, or MM-DD for annual holidays
+	Holidays []string
+}
+
+// GraphQLConfig holds settings for the dashboard GraphQL API
+type GraphQLConfig struct {
+	Enabled bool
+	// ComplexityLimit rejects queries whose computed complexity exceeds it
+	ComplexityLimit int
+}
+
+// InvoiceServiceConfig holds the location of the invoice service queried
+// for the GraphQL API's invoices
+type InvoiceServiceConfig struct {
+	// BaseURL of the invoice service; invoice queries fail while unset
+	BaseURL string
+	Timeout time.Duration
+}
+
+// LiveUpdatesConfig holds settings for the server-sent event streams of
+// wallet balance and transaction updates
+type LiveUpdatesConfig struct {
+	Enabled bool
+	// Heartbeat is how often idle streams send a comment to stay open
+	Heartbeat time.Duration
+	// History is how many recent updates of each wallet are kept for
+	// reconnecting clients, for HistoryTTL after the latest update
+	History    int64
+	HistoryTTL time.Duration
+}
+
+// WebSocketConfig holds settings for the WebSocket channel of transaction
+// lifecycle events, which is fed by the live updates feed
+type WebSocketConfig struct {
+	Enabled bool
+	// MaxSubscriptions bounds the wallets one connection subscribes to
+	MaxSubscriptions int
+	// MessageRate and MessageBurst limit the messages each connection may
+	// send, per second and in a burst
+	MessageRate  float64
+	MessageBurst int
+	// PingInterval is how often connections are pinged to detect dead peers
+	PingInterval time.Duration
+	// WriteTimeout bounds each write to a client
+	WriteTimeout time.Duration
+}
+
+// ClassificationConfig holds the data classification policy applied to API
+// responses
+type ClassificationConfig struct {
+	Enabled bool
+	// Enforce removes classified fields the caller lacks the scope for;
+	// otherwise such accesses are only logged
+	Enforce bool
+	// Scopes maps each class (financial, pii, internal) to the token role
+	// granting access to its fields
+	Scopes map[string]string
+}
+
+// DecimalVerificationConfig holds the dual-read verification of the float to
+// decimal migration
+type DecimalVerificationConfig struct {
+	Enabled bool
+	// SampleRate is the fraction of wallet and transaction reads verified
+	SampleRate float64
+	// Tolerance is the largest drift between the float and exact readings
+	// not recorded as a mismatch
+	Tolerance float64
+	// AlertDrift is the drift of a single value that raises an alert
+	AlertDrift float64
+	// AlertMismatches within AlertWindow raise an alert
+	AlertMismatches int
+	AlertWindow     time.Duration
+}
+
+// UsageConfig holds the usage accounting of internal services and its
+// optional chargeback to their wallets
+type UsageConfig struct {
+	Enabled bool
+	// FlushInterval is how often counted usage is stored
+	FlushInterval time.Duration
+	// Chargeback debits each month's usage from the service wallets on the
+	// first day of the next month at RunAt, an offset from midnight UTC
+	Chargeback bool
+	RunAt      time.Duration
+	Currency   string
+	// Wallets maps each service's token subject to its wallet ID
+	Wallets map[string]string
+	// Rates of a thousand requests, a second of database time and a GB
+	// served, which apply until a rate card change is activated
+	PerThousandRequests float64
+	PerDBSecond         float64
+	PerGB               float64
+}
+
+// RateCardConfig holds the review of chargeback rate card changes, which
+// need a second operator's approval and notify finance before taking effect
+type RateCardConfig struct {
+	// MinNotice is the least time between approval and the effective date
+	MinNotice time.Duration
+	// ActivationInterval is how often approved changes are activated
+	ActivationInterval time.Duration
+}
+
+// SLOConfig holds the endpoint service level objectives
+type SLOConfig struct {
+	Enabled bool
+	// RefreshInterval is how often the burn rate gauges are recomputed
+	RefreshInterval time.Duration
+	// Objectives maps each SLO name to the objectives of its endpoint
+	Objectives map[string]SLOObjectiveConfig
+}
+
+// SLOObjectiveConfig declares the objectives of one endpoint, identified by
+// its method and router path. A zero target leaves the objective undefined.
+type SLOObjectiveConfig struct {
+	Method string
+	Route  string
+	// Availability is the target fraction of requests without a 5xx status
+	Availability float64
+	// Latency is the target fraction of requests served within
+	// LatencyThreshold
+	Latency          float64
+	LatencyThreshold time.Duration
+}
+
+// DeprecationConfig holds the deprecated API routes and the accounting of
+// their remaining callers
+type DeprecationConfig struct {
+	// FlushInterval is how often counted calls to deprecated routes are stored
+	FlushInterval time.Duration
+	// Routes maps a name to each deprecated route
+	Routes map[string]DeprecatedRouteConfig
+}
+
+// DeprecatedRouteConfig declares a deprecated endpoint, identified by its
+// method and router path. Dates are formatted as YYYY-MM-DD.
+type DeprecatedRouteConfig struct {
+	Method string
+	Route  string
+	// Deprecated is the date the route was deprecated
+	Deprecated string
+	// Sunset is the date the route is removed; optional until planned
+	Sunset string
+	// Link is the documentation of the replacement; optional
+	Link string
 }
 
 // LoadConfig loads and validates service configuration from files and environment variables
 func LoadConfig(configPath string) (*Config, error) {
+	v, err := newViper(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeConfig(v)
+}
+
+// newViper creates a viper instance with the service defaults and reads the
+// configuration file
+func newViper(configPath string) (*viper.Viper, error) {
 	v := viper.New()
 
 	// Set configuration defaults
@@ -92,6 +964,11 @@ func LoadConfig(configPath string) (*Config, error) {
 		}
 	}
 
+	return v, nil
+}
+
+// decodeConfig unmarshals and validates the configuration held by viper
+func decodeConfig(v *viper.Viper) (*Config, error) {
 	// Initialize configuration struct
 	config := &Config{}
 
@@ -100,6 +977,14 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
+	// Secrets from the secrets provider take precedence over the file
+	if err := validateSecretsConfig(&config.Secrets); err != nil {
+		return nil, fmt.Errorf("config validation error: secrets config error: %w", err)
+	}
+	if err := resolveSecrets(config); err != nil {
+		return nil, fmt.Errorf("error loading secrets: %w", err)
+	}
+
 	// Validate configuration
 	if err := validateConfig(config); err != nil {
 		return nil, fmt.Errorf("config validation error: %w", err)
@@ -118,6 +1003,10 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("database.maxopenconns", 25)
 	v.SetDefault("database.maxidleconns", 5)
 	v.SetDefault("database.maxconnlifetime", time.Hour)
+	v.SetDefault("database.replicamaxlag", 5*time.Second)
+	v.SetDefault("database.replicacheckinterval", 5*time.Second)
+	v.SetDefault("database.slowquerythreshold", 500*time.Millisecond)
+	v.SetDefault("database.poolstatsinterval", 15*time.Second)
 
 	// Redis defaults
 	v.SetDefault("cache.host", "localhost")
@@ -134,12 +1023,326 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("api.writetimeout", time.Second*15)
 	v.SetDefault("api.shutdowntimeout", time.Second*30)
 	v.SetDefault("api.maxrequestsize", 1<<20) // 1MB
+	v.SetDefault("api.idempotencyttl", time.Hour*24)
+	v.SetDefault("api.swaggerui", false)
 
 	// Security defaults
 	v.SetDefault("security.jwtexpiry", time.Hour)
+	v.SetDefault("security.jwtalgorithms", []string{"RS256"})
+	v.SetDefault("security.jwtclockskew", time.Second*30)
+	v.SetDefault("security.jwksrefreshinterval", time.Hour)
+	v.SetDefault("security.jwksminrefreshinterval", time.Second*30)
 	v.SetDefault("security.ratelimit", 100)
 	v.SetDefault("security.ratelimitwindow", defaultRateLimitWindow)
+	v.SetDefault("security.ratelimitpolicyttl", time.Minute*5)
+	v.SetDefault("security.sensitivedatapolicy", "reject")
 	v.SetDefault("security.enabletls", true)
+
+	// Self-check defaults
+	v.SetDefault("selfcheck.enabled", true)
+	v.SetDefault("selfcheck.allowdegraded", true) // soft dependencies such as Redis only degrade features
+	v.SetDefault("selfcheck.timeout", time.Second*10)
+	v.SetDefault("selfcheck.ntpserver", "pool.ntp.org")
+	v.SetDefault("selfcheck.maxclockskew", time.Second*2)
+
+	// Analytics defaults
+	v.SetDefault("analytics.mingroupsize", 10)
+	v.SetDefault("analytics.noiseepsilon", 1.0)
+	v.SetDefault("analytics.medianbucket", 10.0)
+	v.SetDefault("analytics.walletcachettl", time.Minute*5)
+
+	// Degradation defaults
+	v.SetDefault("degradation.ratelimit", "fail-open")
+	v.SetDefault("degradation.idempotency", "fail-closed")
+	v.SetDefault("degradation.probetimeout", time.Second*2)
+	v.SetDefault("degradation.checkinterval", time.Second*15)
+	v.SetDefault("degradation.readonlyretryafter", time.Second*30)
+
+	// Reconciliation defaults
+	v.SetDefault("reconciliation.enabled", true)
+	v.SetDefault("reconciliation.runat", time.Hour*2)
+
+	// Events defaults
+	v.SetDefault("events.stream", "wallet-events")
+	v.SetDefault("events.streammaxlen", 100000)
+
+	// Digest defaults
+	v.SetDefault("digest.enabled", true)
+	v.SetDefault("digest.interval", time.Hour)
+
+	// Snapshot defaults
+	v.SetDefault("snapshots.enabled", true)
+	v.SetDefault("snapshots.runat", time.Minute*15)
+
+	// Webhook defaults
+	v.SetDefault("webhooks.enabled", true)
+	v.SetDefault("webhooks.consumergroup", "webhooks")
+	v.SetDefault("webhooks.batchsize", 50)
+	v.SetDefault("webhooks.deliverytimeout", time.Second*10)
+
+	// Refund defaults
+	v.SetDefault("refunds.enabled", true)
+	v.SetDefault("refunds.pollinterval", time.Minute)
+
+	// Dunning defaults
+	v.SetDefault("dunning.enabled", true)
+	v.SetDefault("dunning.interval", time.Minute*5)
+	v.SetDefault("dunning.graceperiod", time.Hour*72)
+	v.SetDefault("dunning.noticeschedule", []time.Duration{0, time.Hour * 24, time.Hour * 60})
+	v.SetDefault("dunning.suspendaftergrace", false)
+
+	// Recurring debit scheduler defaults
+	v.SetDefault("recurringdebits.enabled", true)
+	v.SetDefault("recurringdebits.interval", time.Minute)
+	v.SetDefault("recurringdebits.catchupwindow", time.Hour*24*7)
+	v.SetDefault("recurringdebits.claimtimeout", time.Minute*10)
+
+	// Worker runner defaults
+	v.SetDefault("workers.leasettl", time.Second*30)
+	v.SetDefault("workers.restartdelay", time.Second*5)
+
+	// Wallet and logging defaults
+	v.SetDefault("wallet.lowbalancethreshold", 0.0)
+	v.SetDefault("wallet.transactionqueryhorizon", time.Hour*24*90)
+	v.SetDefault("logging.level", "info")
+	v.SetDefault("logging.encoding", "json")
+
+	// Secrets defaults
+	v.SetDefault("secrets.provider", "")
+	v.SetDefault("secrets.envprefix", "WALLET_")
+	v.SetDefault("secrets.vaultmount", "secret")
+	v.SetDefault("secrets.timeout", time.Second*10)
+	v.SetDefault("secrets.rotationinterval", time.Minute*5)
+
+	// Wallet migration defaults
+	v.SetDefault("migrations.historywindow", time.Hour*24*90)
+	v.SetDefault("migrations.historylimit", 1000)
+	v.SetDefault("migrations.mergewindow", time.Hour*24*7)
+
+	// Body capture defaults
+	v.SetDefault("bodycapture.enabled", false)
+	v.SetDefault("bodycapture.samplerate", 0.05)
+	v.SetDefault("bodycapture.maxbodybytes", 8192)
+	v.SetDefault("bodycapture.fieldmasks", []string{"email", "phone", "name", "address", "password", "token", "secret"})
+	v.SetDefault("bodycapture.patterns", []string{
+		`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+		`(?:\+91[ -]?)?\b[6-9]\d{9}\b`,
+	})
+	v.SetDefault("bodycapture.queuesize", 1000)
+	v.SetDefault("bodycapture.retention", time.Hour*24*180)
+
+	// Currency defaults
+	v.SetDefault("currencies.source", "config")
+	v.SetDefault("currencies.supported", []string{"USD", "INR", "IDR"})
+	// Rupiah amounts are whole in practice, though ISO 4217 lists two places
+	v.SetDefault("currencies.precision", map[string]interface{}{"idr": 0})
+	v.SetDefault("currencies.reportingcurrency", "USD")
+	v.SetDefault("currencies.fxrates", map[string]interface{}{})
+
+	// Report job defaults
+	v.SetDefault("reportjobs.concurrency", 2)
+	v.SetDefault("reportjobs.pollinterval", time.Second*5)
+	v.SetDefault("reportjobs.maxattempts", 3)
+	v.SetDefault("reportjobs.retrydelay", time.Minute)
+	v.SetDefault("reportjobs.heartbeatinterval", time.Second*10)
+	v.SetDefault("reportjobs.staleafter", time.Minute*2)
+	v.SetDefault("reportjobs.retention", time.Hour*24*30)
+
+	// Transaction archive defaults
+	v.SetDefault("transactionarchive.enabled", true)
+	v.SetDefault("transactionarchive.interval", time.Hour*6)
+	v.SetDefault("transactionarchive.retentionmonths", 24)
+	v.SetDefault("transactionarchive.monthsahead", 3)
+
+	// Export defaults
+	v.SetDefault("exports.enabled", false)
+	v.SetDefault("exports.provider", "s3")
+	v.SetDefault("exports.prefix", "exports")
+	v.SetDefault("exports.urlexpiry", time.Hour)
+
+	// Notification queue defaults
+	v.SetDefault("notificationqueue.enabled", true)
+	v.SetDefault("notificationqueue.retryinterval", time.Second*30)
+	v.SetDefault("notificationqueue.alertttl", time.Hour*2)
+	v.SetDefault("notificationqueue.noticettl", time.Hour*72)
+	v.SetDefault("notificationqueue.retention", time.Hour*24*7)
+
+	// Balance threshold defaults
+	v.SetDefault("balancethresholds.enabled", true)
+	v.SetDefault("balancethresholds.warningpercent", 20.0)
+	v.SetDefault("balancethresholds.warningresetpercent", 25.0)
+	v.SetDefault("balancethresholds.criticalpercent", 5.0)
+	v.SetDefault("balancethresholds.criticalresetpercent", 10.0)
+
+	// Read-only deployment defaults
+	v.SetDefault("readonly.enabled", false)
+	v.SetDefault("readonly.ratelimitmultiplier", 10)
+
+	// Tax defaults
+	v.SetDefault("tax.enabled", false)
+	v.SetDefault("tax.applytodebits", false)
+
+	// Wallet activity defaults
+	v.SetDefault("walletactivity.enabled", true)
+	v.SetDefault("walletactivity.interval", time.Hour)
+	v.SetDefault("walletactivity.inactiveafter", time.Hour*24*90)
+	v.SetDefault("walletactivity.batchsize", 500)
+
+	// Bulk credit defaults
+	v.SetDefault("bulkcredits.maxrows", 50000)
+	v.SetDefault("bulkcredits.chunksize", 200)
+	v.SetDefault("bulkcredits.rowspersecond", 200)
+
+	// Incident defaults
+	v.SetDefault("incidents.refreshinterval", 5*time.Second)
+	v.SetDefault("incidents.maxduration", 72*time.Hour)
+
+	// Description defaults
+	v.SetDefault("descriptions.enabled", true)
+	v.SetDefault("descriptions.maxlength", 255)
+
+	// Notification channel defaults
+	v.SetDefault("notifications.enabled", true)
+	v.SetDefault("notifications.consumergroup", "notification-channels")
+	v.SetDefault("notifications.batchsize", 50)
+	v.SetDefault("notifications.timeout", time.Second*10)
+	v.SetDefault("notifications.smtp.port", 587)
+
+	// Access log defaults
+	v.SetDefault("accesslog.enabled", true)
+	v.SetDefault("accesslog.flushinterval", time.Second*10)
+	v.SetDefault("accesslog.maxpending", 10000)
+	v.SetDefault("accesslog.retention", time.Hour*24*365)
+
+	// Invoice document defaults; invoices are not rendered until the
+	// deployment's branding is configured
+	v.SetDefault("invoicedocuments.enabled", false)
+	v.SetDefault("invoicedocuments.accentcolor", "#1F4E79")
+
+	// Receivable defaults; invoices paid late are not charged unless a
+	// late fee mode is set
+	v.SetDefault("receivables.defaultterms", "NET_30")
+	v.SetDefault("receivables.accrualinterval", time.Hour)
+
+	// Postpaid defaults
+	v.SetDefault("postpaid.closeinterval", time.Hour)
+
+	// Adjustment defaults; every adjustment needs a second approver unless
+	// its currency has a threshold
+	v.SetDefault("adjustments.pendingttl", time.Hour*72)
+	v.SetDefault("adjustments.expiryinterval", time.Minute*15)
+
+	// Inbound webhook defaults; no provider is accepted until configured
+	v.SetDefault("inboundwebhooks.maxattempts", 8)
+	v.SetDefault("inboundwebhooks.processinterval", time.Second*15)
+
+	// Failed job defaults; rejected recurring debits are retried over a
+	// day while the customer tops up
+	v.SetDefault("failedjobs.retryinterval", time.Second*30)
+	v.SetDefault("failedjobs.webhookdelivery.maxattempts", 6)
+	v.SetDefault("failedjobs.webhookdelivery.minbackoff", time.Minute)
+	v.SetDefault("failedjobs.webhookdelivery.maxbackoff", time.Hour)
+	v.SetDefault("failedjobs.recurringdebitrun.maxattempts", 4)
+	v.SetDefault("failedjobs.recurringdebitrun.minbackoff", time.Hour*3)
+	v.SetDefault("failedjobs.recurringdebitrun.maxbackoff", time.Hour*12)
+
+	// Circuit breaker defaults; Redis is retried sooner as every request
+	// falls back while it is open
+	v.SetDefault("circuitbreakers.redis.enabled", true)
+	v.SetDefault("circuitbreakers.redis.consecutivefailures", 5)
+	v.SetDefault("circuitbreakers.redis.interval", time.Minute)
+	v.SetDefault("circuitbreakers.redis.opentimeout", time.Second*10)
+	v.SetDefault("circuitbreakers.redis.halfopenrequests", 1)
+	v.SetDefault("circuitbreakers.paymentprovider.enabled", true)
+	v.SetDefault("circuitbreakers.paymentprovider.consecutivefailures", 5)
+	v.SetDefault("circuitbreakers.paymentprovider.interval", time.Minute*5)
+	v.SetDefault("circuitbreakers.paymentprovider.opentimeout", time.Minute)
+	v.SetDefault("circuitbreakers.paymentprovider.halfopenrequests", 1)
+
+	// Startup defaults; dependencies started alongside the service are
+	// usually reachable within seconds
+	v.SetDefault("startup.maxwait", time.Minute)
+	v.SetDefault("startup.minbackoff", time.Millisecond*500)
+	v.SetDefault("startup.maxbackoff", time.Second*10)
+	v.SetDefault("startup.attempttimeout", time.Second*5)
+	v.SetDefault("startup.allowdegraded", true)
+
+	// Calendar defaults
+	v.SetDefault("calendar.country", "")
+
+	// GraphQL defaults
+	v.SetDefault("graphql.enabled", true)
+	v.SetDefault("graphql.complexitylimit", 200)
+	v.SetDefault("invoices.baseurl", "")
+	v.SetDefault("invoices.timeout", time.Second*5)
+
+	// Live update defaults
+	v.SetDefault("liveupdates.enabled", true)
+	v.SetDefault("liveupdates.heartbeat", time.Second*15)
+	v.SetDefault("liveupdates.history", 100)
+	v.SetDefault("liveupdates.historyttl", time.Hour)
+
+	// WebSocket defaults
+	v.SetDefault("websocket.enabled", true)
+	v.SetDefault("websocket.maxsubscriptions", 20)
+	v.SetDefault("websocket.messagerate", 5)
+	v.SetDefault("websocket.messageburst", 10)
+	v.SetDefault("websocket.pinginterval", time.Second*30)
+	v.SetDefault("websocket.writetimeout", time.Second*10)
+
+	// Data classification defaults
+	v.SetDefault("classification.enabled", true)
+	v.SetDefault("classification.enforce", false)
+	v.SetDefault("classification.scopes", map[string]string{
+		"financial": "data:financial",
+		"pii":       "data:pii",
+		"internal":  "data:internal",
+	})
+
+	// Decimal migration verification defaults
+	v.SetDefault("decimalverification.enabled", false)
+	v.SetDefault("decimalverification.samplerate", 0.1)
+	v.SetDefault("decimalverification.tolerance", 0.0)
+	v.SetDefault("decimalverification.alertdrift", 0.01)
+	v.SetDefault("decimalverification.alertmismatches", 100)
+	v.SetDefault("decimalverification.alertwindow", time.Hour)
+
+	// Internal usage accounting defaults
+	v.SetDefault("usage.enabled", true)
+	v.SetDefault("usage.flushinterval", time.Minute)
+	v.SetDefault("usage.chargeback", false)
+	v.SetDefault("usage.runat", time.Hour*3)
+	v.SetDefault("usage.currency", "INR")
+	v.SetDefault("usage.perthousandrequests", 0.0)
+	v.SetDefault("usage.perdbsecond", 0.0)
+	v.SetDefault("usage.pergb", 0.0)
+
+	// Rate card change review defaults
+	v.SetDefault("ratecards.minnotice", 72*time.Hour)
+	v.SetDefault("ratecards.activationinterval", time.Minute)
+
+	// Endpoint SLO defaults, covering the money movement and balance paths
+	v.SetDefault("slo.enabled", true)
+	v.SetDefault("slo.refreshinterval", 30*time.Second)
+	v.SetDefault("slo.objectives", map[string]interface{}{
+		"process-transaction": map[string]interface{}{
+			"method":           "POST",
+			"route":            "/api/v1/wallets/:id/transactions",
+			"availability":     0.999,
+			"latency":          0.99,
+			"latencythreshold": 500 * time.Millisecond,
+		},
+		"get-balance": map[string]interface{}{
+			"method":           "GET",
+			"route":            "/api/v1/wallets/:id/balance",
+			"availability":     0.999,
+			"latency":          0.99,
+			"latencythreshold": 200 * time.Millisecond,
+		},
+	})
+
+	// Deprecated routes are declared as v2 replaces them
+	v.SetDefault("deprecations.flushinterval", time.Minute)
 }
 
 // validateConfig performs comprehensive validation of all configuration values
@@ -164,68 +1367,1177 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("security config error: %w", err)
 	}
 
-	return nil
-}
+	// Validate Self-check configuration
+	if err := validateSelfCheckConfig(&config.SelfCheck); err != nil {
+		return fmt.Errorf("selfcheck config error: %w", err)
+	}
 
-func validateDatabaseConfig(config *DatabaseConfig) error {
-	if config.User == "" {
-		return fmt.Errorf("database user is required")
+	// Validate Analytics configuration
+	if err := validateAnalyticsConfig(&config.Analytics); err != nil {
+		return fmt.Errorf("analytics config error: %w", err)
 	}
-	if config.Password == "" {
-		return fmt.Errorf("database password is required")
+
+	// Validate Degradation configuration
+	if err := validateDegradationConfig(&config.Degradation); err != nil {
+		return fmt.Errorf("degradation config error: %w", err)
 	}
-	if config.DBName == "" {
-		return fmt.Errorf("database name is required")
+
+	// Validate Reconciliation configuration
+	if err := validateReconciliationConfig(&config.Reconciliation); err != nil {
+		return fmt.Errorf("reconciliation config error: %w", err)
 	}
-	if config.MaxOpenConns < config.MaxIdleConns {
-		return fmt.Errorf("maxOpenConns must be greater than or equal to maxIdleConns")
+
+	// Validate Events configuration
+	if err := validateEventsConfig(&config.Events); err != nil {
+		return fmt.Errorf("events config error: %w", err)
 	}
-	return nil
-}
 
-func validateRedisConfig(config *RedisConfig) error {
-	if config.TTL <= 0 {
-		return fmt.Errorf("cache TTL must be positive")
+	// Validate Digest configuration
+	if err := validateDigestConfig(&config.Digest); err != nil {
+		return fmt.Errorf("digest config error: %w", err)
 	}
-	if config.MaxRetries < 0 {
-		return fmt.Errorf("maxRetries must be non-negative")
+
+	// Validate Snapshots configuration
+	if err := validateSnapshotConfig(&config.Snapshots); err != nil {
+		return fmt.Errorf("snapshots config error: %w", err)
 	}
-	return nil
-}
 
-func validateAPIConfig(config *APIConfig) error {
-	if config.ReadTimeout <= 0 {
-		return fmt.Errorf("readTimeout must be positive")
+	// Validate Webhooks configuration
+	if err := validateWebhookConfig(&config.Webhooks); err != nil {
+		return fmt.Errorf("webhooks config error: %w", err)
 	}
-	if config.WriteTimeout <= 0 {
-		return fmt.Errorf("writeTimeout must be positive")
+
+	// Validate Refunds configuration
+	if err := validateRefundConfig(&config.Refunds); err != nil {
+		return fmt.Errorf("refunds config error: %w", err)
 	}
-	if config.ShutdownTimeout <= 0 {
-		return fmt.Errorf("shutdownTimeout must be positive")
+
+	// Validate Dunning configuration
+	if err := validateDunningConfig(&config.Dunning); err != nil {
+		return fmt.Errorf("dunning config error: %w", err)
 	}
-	if config.MaxRequestSize <= 0 {
-		return fmt.Errorf("maxRequestSize must be positive")
+
+	// Validate recurring debit configuration
+	if err := validateRecurringDebitConfig(&config.RecurringDebits); err != nil {
+		return fmt.Errorf("recurring debits config error: %w", err)
 	}
-	return nil
-}
 
-func validateSecurityConfig(config *SecurityConfig) error {
-	if config.JWTSecret == "" {
-		return fmt.Errorf("JWT secret is required")
+	// Validate worker configuration
+	if err := validateWorkerConfig(&config.Workers); err != nil {
+		return fmt.Errorf("workers config error: %w", err)
 	}
-	if config.JWTExpiry <= 0 {
-		return fmt.Errorf("JWT expiry must be positive")
+
+	// Validate wallet defaults
+	if err := validateWalletConfig(&config.Wallet); err != nil {
+		return fmt.Errorf("wallet config error: %w", err)
 	}
-	if config.RateLimit <= 0 {
-		return fmt.Errorf("rate limit must be positive")
+
+	// Validate logging configuration
+	if err := validateLoggingConfig(&config.Logging); err != nil {
+		return fmt.Errorf("logging config error: %w", err)
 	}
-	if config.EnableTLS {
-		if _, err := os.Stat(config.TLSCertPath); err != nil {
-			return fmt.Errorf("TLS cert file not found: %w", err)
-		}
-		if _, err := os.Stat(config.TLSKeyPath); err != nil {
-			return fmt.Errorf("TLS key file not found: %w", err)
-		}
+
+	// Validate wallet migration configuration
+	if err := validateMigrationConfig(&config.Migrations); err != nil {
+		return fmt.Errorf("migrations config error: %w", err)
 	}
-	return nil
-}
\ No newline at end of file
+
+	// Validate body capture configuration
+	if err := validateBodyCaptureConfig(&config.BodyCapture); err != nil {
+		return fmt.Errorf("bodyCapture config error: %w", err)
+	}
+
+	// Validate currency configuration
+	if err := validateCurrencyConfig(&config.Currencies); err != nil {
+		return fmt.Errorf("currencies config error: %w", err)
+	}
+
+	// Validate report job configuration
+	if err := validateReportJobConfig(&config.ReportJobs); err != nil {
+		return fmt.Errorf("reportJobs config error: %w", err)
+	}
+
+	// Validate transaction archive configuration
+	if err := validateTransactionArchiveConfig(&config.TransactionArchive); err != nil {
+		return fmt.Errorf("transactionArchive config error: %w", err)
+	}
+
+	// Validate export configuration
+	if err := validateExportConfig(&config.Exports); err != nil {
+		return fmt.Errorf("exports config error: %w", err)
+	}
+
+	// Validate notification queue configuration
+	if err := validateNotificationQueueConfig(&config.NotificationQueue); err != nil {
+		return fmt.Errorf("notificationQueue config error: %w", err)
+	}
+
+	// Validate balance threshold configuration
+	if err := validateBalanceThresholdConfig(&config.BalanceThresholds); err != nil {
+		return fmt.Errorf("balanceThresholds config error: %w", err)
+	}
+
+	// Validate read-only deployment configuration
+	if err := validateReadOnlyConfig(&config.ReadOnly, &config.Database); err != nil {
+		return fmt.Errorf("readOnly config error: %w", err)
+	}
+
+	// Validate tax configuration
+	if err := validateTaxConfig(&config.Tax); err != nil {
+		return fmt.Errorf("tax config error: %w", err)
+	}
+
+	// Validate wallet activity configuration
+	if err := validateWalletActivityConfig(&config.WalletActivity); err != nil {
+		return fmt.Errorf("walletActivity config error: %w", err)
+	}
+
+	// Validate bulk credit configuration
+	if err := validateBulkCreditConfig(&config.BulkCredits); err != nil {
+		return fmt.Errorf("bulkCredits config error: %w", err)
+	}
+
+	// Validate incident configuration
+	if err := validateIncidentConfig(&config.Incidents); err != nil {
+		return fmt.Errorf("incidents config error: %w", err)
+	}
+
+	// Validate description configuration
+	if err := validateDescriptionConfig(&config.Descriptions); err != nil {
+		return fmt.Errorf("descriptions config error: %w", err)
+	}
+
+	// Validate notification channel configuration
+	if err := validateNotificationChannelConfig(&config.Notifications); err != nil {
+		return fmt.Errorf("notifications config error: %w", err)
+	}
+
+	// Validate access log configuration
+	if err := validateAccessLogConfig(&config.AccessLog); err != nil {
+		return fmt.Errorf("accessLog config error: %w", err)
+	}
+
+	// Validate invoice document configuration
+	if err := validateInvoiceDocumentConfig(&config.InvoiceDocuments, &config.Notifications); err != nil {
+		return fmt.Errorf("invoiceDocuments config error: %w", err)
+	}
+
+	// Validate receivable configuration
+	if err := validateReceivableConfig(&config.Receivables); err != nil {
+		return fmt.Errorf("receivables config error: %w", err)
+	}
+
+	// Validate postpaid configuration
+	if err := validatePostpaidConfig(&config.Postpaid); err != nil {
+		return fmt.Errorf("postpaid config error: %w", err)
+	}
+
+	// Validate allowance configuration
+	if err := validateAllowanceConfig(&config.Allowances); err != nil {
+		return fmt.Errorf("allowances config error: %w", err)
+	}
+
+	// Validate adjustment configuration
+	if err := validateAdjustmentConfig(&config.Adjustments); err != nil {
+		return fmt.Errorf("adjustments config error: %w", err)
+	}
+
+	// Validate inbound webhook configuration
+	if err := validateInboundWebhookConfig(&config.InboundWebhooks); err != nil {
+		return fmt.Errorf("inboundWebhooks config error: %w", err)
+	}
+
+	// Validate failed job configuration
+	if err := validateFailedJobConfig(&config.FailedJobs); err != nil {
+		return fmt.Errorf("failedJobs config error: %w", err)
+	}
+
+	// Validate circuit breaker configuration
+	if err := validateCircuitBreakerConfig(&config.CircuitBreakers); err != nil {
+		return fmt.Errorf("circuitBreakers config error: %w", err)
+	}
+
+	// Validate startup configuration
+	if err := validateStartupConfig(&config.Startup); err != nil {
+		return fmt.Errorf("startup config error: %w", err)
+	}
+
+	// Validate Calendar configuration
+	if err := validateCalendarConfig(&config.Calendar); err != nil {
+		return fmt.Errorf("calendar config error: %w", err)
+	}
+
+	// Validate GraphQL configuration
+	if err := validateGraphQLConfig(&config.GraphQL, &config.Invoices); err != nil {
+		return fmt.Errorf("graphql config error: %w", err)
+	}
+
+	// Validate live updates configuration
+	if err := validateLiveUpdatesConfig(&config.LiveUpdates); err != nil {
+		return fmt.Errorf("live updates config error: %w", err)
+	}
+
+	// Validate WebSocket configuration
+	if err := validateWebSocketConfig(&config.WebSocket, &config.LiveUpdates); err != nil {
+		return fmt.Errorf("websocket config error: %w", err)
+	}
+
+	// Validate data classification configuration
+	if err := validateClassificationConfig(&config.Classification); err != nil {
+		return fmt.Errorf("classification config error: %w", err)
+	}
+
+	// Validate decimal migration verification configuration
+	if err := validateDecimalVerificationConfig(&config.DecimalVerification); err != nil {
+		return fmt.Errorf("decimal verification config error: %w", err)
+	}
+
+	// Validate internal usage accounting configuration
+	if err := validateUsageConfig(&config.Usage); err != nil {
+		return fmt.Errorf("usage config error: %w", err)
+	}
+
+	// Validate rate card change review configuration
+	if err := validateRateCardConfig(&config.RateCards); err != nil {
+		return fmt.Errorf("rate cards config error: %w", err)
+	}
+
+	// Validate endpoint SLO configuration
+	if err := validateSLOConfig(&config.SLO); err != nil {
+		return fmt.Errorf("slo config error: %w", err)
+	}
+
+	// Validate deprecated route configuration
+	if err := validateDeprecationConfig(&config.Deprecations); err != nil {
+		return fmt.Errorf("deprecations config error: %w", err)
+	}
+
+	return nil
+}
+
+func validateDatabaseConfig(config *DatabaseConfig) error {
+	if config.User == "" {
+		return fmt.Errorf("database user is required")
+	}
+	if config.Password == "" {
+		return fmt.Errorf("database password is required")
+	}
+	if config.DBName == "" {
+		return fmt.Errorf("database name is required")
+	}
+	if config.MaxOpenConns < config.MaxIdleConns {
+		return fmt.Errorf("maxOpenConns must be greater than or equal to maxIdleConns")
+	}
+	if config.ReplicaDSN != "" {
+		if config.ReplicaMaxLag <= 0 {
+			return fmt.Errorf("replicaMaxLag must be positive")
+		}
+		if config.ReplicaCheckInterval <= 0 {
+			return fmt.Errorf("replicaCheckInterval must be positive")
+		}
+	}
+	if config.SlowQueryThreshold < 0 {
+		return fmt.Errorf("slowQueryThreshold must be non-negative")
+	}
+	if config.PoolStatsInterval <= 0 {
+		return fmt.Errorf("poolStatsInterval must be positive")
+	}
+	return nil
+}
+
+func validateRedisConfig(config *RedisConfig) error {
+	if config.TTL <= 0 {
+		return fmt.Errorf("cache TTL must be positive")
+	}
+	if config.MaxRetries < 0 {
+		return fmt.Errorf("maxRetries must be non-negative")
+	}
+	return nil
+}
+
+func validateAPIConfig(config *APIConfig) error {
+	if config.ReadTimeout <= 0 {
+		return fmt.Errorf("readTimeout must be positive")
+	}
+	if config.WriteTimeout <= 0 {
+		return fmt.Errorf("writeTimeout must be positive")
+	}
+	if config.ShutdownTimeout <= 0 {
+		return fmt.Errorf("shutdownTimeout must be positive")
+	}
+	if config.MaxRequestSize <= 0 {
+		return fmt.Errorf("maxRequestSize must be positive")
+	}
+	if config.IdempotencyTTL <= 0 {
+		return fmt.Errorf("idempotencyTTL must be positive")
+	}
+	return nil
+}
+
+func validateSecurityConfig(config *SecurityConfig) error {
+	if config.JWTSecret == "" && config.JWKSURL == "" {
+		return fmt.Errorf("either JWT secret or JWKS URL is required")
+	}
+	if len(config.JWTAlgorithms) == 0 {
+		return fmt.Errorf("at least one JWT algorithm is required")
+	}
+	if config.JWTClockSkew < 0 || config.JWTClockSkew > 5*time.Minute {
+		return fmt.Errorf("JWT clock skew must be between 0 and 5m")
+	}
+	if config.JWKSURL != "" && config.JWKSRefreshInterval <= 0 {
+		return fmt.Errorf("JWKS refresh interval must be positive")
+	}
+	if config.JWKSURL != "" && config.JWKSMinRefreshInterval <= 0 {
+		return fmt.Errorf("JWKS minimum refresh interval must be positive")
+	}
+	if config.JWTExpiry <= 0 {
+		return fmt.Errorf("JWT expiry must be positive")
+	}
+	if config.RateLimit <= 0 {
+		return fmt.Errorf("rate limit must be positive")
+	}
+	if config.RateLimitWindow <= 0 {
+		return fmt.Errorf("rate limit window must be positive")
+	}
+	if config.RateLimitPolicyTTL <= 0 {
+		return fmt.Errorf("rate limit policy TTL must be positive")
+	}
+	if config.SensitiveDataPolicy != "reject" && config.SensitiveDataPolicy != "mask" {
+		return fmt.Errorf("sensitive data policy must be reject or mask")
+	}
+	if config.EnableTLS {
+		if _, err := os.Stat(config.TLSCertPath); err != nil {
+			return fmt.Errorf("TLS cert file not found: %w", err)
+		}
+		if _, err := os.Stat(config.TLSKeyPath); err != nil {
+			return fmt.Errorf("TLS key file not found: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateSelfCheckConfig(config *SelfCheckConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.Timeout <= 0 {
+		return fmt.Errorf("self-check timeout must be positive")
+	}
+	if config.MaxClockSkew <= 0 {
+		return fmt.Errorf("maxClockSkew must be positive")
+	}
+	return nil
+}
+
+func validateAnalyticsConfig(config *AnalyticsConfig) error {
+	if config.MinGroupSize < 2 {
+		return fmt.Errorf("minGroupSize must be at least 2")
+	}
+	if config.NoiseEpsilon < 0 {
+		return fmt.Errorf("noiseEpsilon must be non-negative")
+	}
+	if config.MedianBucket <= 0 {
+		return fmt.Errorf("medianBucket must be positive")
+	}
+	if config.WalletCacheTTL < 0 {
+		return fmt.Errorf("walletCacheTTL must be non-negative")
+	}
+	return nil
+}
+
+func validateDegradationConfig(config *DegradationConfig) error {
+	for name, policy := range map[string]string{
+		"rateLimit":   config.RateLimit,
+		"idempotency": config.Idempotency,
+	} {
+		if policy != "fail-open" && policy != "fail-closed" {
+			return fmt.Errorf("%s policy must be fail-open or fail-closed", name)
+		}
+	}
+	if config.ProbeTimeout <= 0 {
+		return fmt.Errorf("probeTimeout must be positive")
+	}
+	if config.CheckInterval <= 0 {
+		return fmt.Errorf("checkInterval must be positive")
+	}
+	if config.ReadOnlyRetryAfter < time.Second {
+		return fmt.Errorf("readOnlyRetryAfter must be at least a second")
+	}
+	return nil
+}
+
+func validateReconciliationConfig(config *ReconciliationConfig) error {
+	if config.RunAt < 0 || config.RunAt >= 24*time.Hour {
+		return fmt.Errorf("runAt must be within a day")
+	}
+	return nil
+}
+
+func validateEventsConfig(config *EventsConfig) error {
+	if config.Stream == "" {
+		return fmt.Errorf("stream is required")
+	}
+	if config.StreamMaxLen < 0 {
+		return fmt.Errorf("streamMaxLen must be non-negative")
+	}
+	return nil
+}
+
+func validateDigestConfig(config *DigestConfig) error {
+	if config.Enabled && config.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	return nil
+}
+
+func validateSnapshotConfig(config *SnapshotConfig) error {
+	if config.RunAt < 0 || config.RunAt >= 24*time.Hour {
+		return fmt.Errorf("runAt must be within a day")
+	}
+	return nil
+}
+
+func validateWebhookConfig(config *WebhookConfig) error {
+	if config.ConsumerGroup == "" {
+		return fmt.Errorf("consumerGroup is required")
+	}
+	if config.BatchSize <= 0 {
+		return fmt.Errorf("batchSize must be positive")
+	}
+	if config.DeliveryTimeout <= 0 {
+		return fmt.Errorf("deliveryTimeout must be positive")
+	}
+	return nil
+}
+
+func validateRefundConfig(config *RefundConfig) error {
+	if config.PollInterval <= 0 {
+		return fmt.Errorf("pollInterval must be positive")
+	}
+	return nil
+}
+
+func validateDunningConfig(config *DunningConfig) error {
+	if config.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if config.GracePeriod < 0 {
+		return fmt.Errorf("gracePeriod must not be negative")
+	}
+	if len(config.NoticeSchedule) == 0 {
+		return fmt.Errorf("noticeSchedule requires at least one notice")
+	}
+	for i, offset := range config.NoticeSchedule {
+		if offset < 0 || offset > config.GracePeriod {
+			return fmt.Errorf("noticeSchedule offsets must be within the grace period")
+		}
+		if i > 0 && offset <= config.NoticeSchedule[i-1] {
+			return fmt.Errorf("noticeSchedule offsets must be ascending")
+		}
+	}
+	return nil
+}
+
+func validateRecurringDebitConfig(config *RecurringDebitConfig) error {
+	if config.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if config.CatchUpWindow < 0 {
+		return fmt.Errorf("catchUpWindow must not be negative")
+	}
+	if config.ClaimTimeout <= 0 {
+		return fmt.Errorf("claimTimeout must be positive")
+	}
+	return nil
+}
+
+func validateWorkerConfig(config *WorkerConfig) error {
+	if config.LeaseTTL < time.Second {
+		return fmt.Errorf("leaseTTL must be at least one second")
+	}
+	if config.RestartDelay <= 0 {
+		return fmt.Errorf("restartDelay must be positive")
+	}
+	return nil
+}
+
+func validateWalletConfig(config *WalletConfig) error {
+	if config.LowBalanceThreshold < 0 {
+		return fmt.Errorf("lowBalanceThreshold must be non-negative")
+	}
+	if config.TransactionQueryHorizon <= 0 {
+		return fmt.Errorf("transactionQueryHorizon must be positive")
+	}
+	return nil
+}
+
+func validateLoggingConfig(config *LoggingConfig) error {
+	switch config.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("unsupported log level: %s", config.Level)
+	}
+	switch config.Encoding {
+	case "json", "console":
+	default:
+		return fmt.Errorf("unsupported log encoding: %s", config.Encoding)
+	}
+	return nil
+}
+
+func validateSecretsConfig(config *SecretsConfig) error {
+	switch config.Provider {
+	case "", "env":
+	case "file":
+		if config.Dir == "" {
+			return fmt.Errorf("secrets directory is required for the file provider")
+		}
+	case "aws":
+		if config.AWSSecretID == "" {
+			return fmt.Errorf("AWS secret ID is required for the aws provider")
+		}
+	case "vault":
+		if config.VaultAddress == "" || config.VaultMount == "" || config.VaultPath == "" {
+			return fmt.Errorf("vault address, mount and path are required for the vault provider")
+		}
+	default:
+		return fmt.Errorf("unsupported secrets provider: %s", config.Provider)
+	}
+	if config.Timeout <= 0 {
+		return fmt.Errorf("secrets timeout must be positive")
+	}
+	if config.RotationInterval < 0 {
+		return fmt.Errorf("secrets rotation interval must not be negative")
+	}
+	return nil
+}
+
+func validateMigrationConfig(config *MigrationConfig) error {
+	if config.HistoryWindow < 0 {
+		return fmt.Errorf("historyWindow must not be negative")
+	}
+	if config.HistoryLimit < 1 {
+		return fmt.Errorf("historyLimit must be at least 1")
+	}
+	if config.MergeWindow <= 0 {
+		return fmt.Errorf("mergeWindow must be positive")
+	}
+	return nil
+}
+
+func validateBodyCaptureConfig(config *BodyCaptureConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		return fmt.Errorf("sampleRate must be between 0 and 1")
+	}
+	if config.MaxBodyBytes <= 0 {
+		return fmt.Errorf("maxBodyBytes must be positive")
+	}
+	if config.QueueSize <= 0 {
+		return fmt.Errorf("queueSize must be positive")
+	}
+	if config.Retention <= 0 {
+		return fmt.Errorf("retention must be positive")
+	}
+	for _, pattern := range config.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func validateCurrencyConfig(config *CurrencyConfig) error {
+	switch config.Source {
+	case "config":
+		if len(config.Supported) == 0 {
+			return fmt.Errorf("at least one supported currency is required")
+		}
+	case "database":
+	default:
+		return fmt.Errorf("unsupported currencies source: %s", config.Source)
+	}
+	for _, code := range config.Supported {
+		if len(code) != 3 {
+			return fmt.Errorf("currency %q must be a three-letter code", code)
+		}
+	}
+	for code, precision := range config.Precision {
+		if precision < 0 || precision > 4 {
+			return fmt.Errorf("precision of %s must be between 0 and 4", strings.ToUpper(code))
+		}
+	}
+	for code, mode := range config.Rounding {
+		switch strings.ToUpper(mode) {
+		case "HALF_UP", "HALF_EVEN", "DOWN", "UP", "CEILING", "FLOOR":
+		default:
+			return fmt.Errorf("unsupported rounding mode %s of %s", mode, strings.ToUpper(code))
+		}
+	}
+	if len(config.ReportingCurrency) != 3 {
+		return fmt.Errorf("reporting currency %q must be a three-letter code", config.ReportingCurrency)
+	}
+	for code, rate := range config.FXRates {
+		if rate <= 0 {
+			return fmt.Errorf("exchange rate of %s must be positive", strings.ToUpper(code))
+		}
+	}
+	return nil
+}
+
+func validateReportJobConfig(config *ReportJobConfig) error {
+	if config.Concurrency < 0 {
+		return fmt.Errorf("concurrency must not be negative")
+	}
+	if config.PollInterval <= 0 {
+		return fmt.Errorf("pollInterval must be positive")
+	}
+	if config.MaxAttempts < 1 {
+		return fmt.Errorf("maxAttempts must be at least 1")
+	}
+	if config.RetryDelay < 0 {
+		return fmt.Errorf("retryDelay must not be negative")
+	}
+	if config.HeartbeatInterval <= 0 || config.StaleAfter <= config.HeartbeatInterval {
+		return fmt.Errorf("staleAfter must exceed a positive heartbeatInterval")
+	}
+	if config.Retention <= 0 {
+		return fmt.Errorf("retention must be positive")
+	}
+	return nil
+}
+
+func validateTransactionArchiveConfig(config *TransactionArchiveConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if config.RetentionMonths < 1 {
+		return fmt.Errorf("retentionMonths must be at least 1")
+	}
+	if config.MonthsAhead < 1 {
+		return fmt.Errorf("monthsAhead must be at least 1")
+	}
+	return nil
+}
+
+func validateExportConfig(config *ExportConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	switch config.Provider {
+	case "s3", "gcs":
+	default:
+		return fmt.Errorf("unsupported export provider: %s", config.Provider)
+	}
+	if config.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	// S3 presigned links are valid for at most a week
+	if config.URLExpiry <= 0 || config.URLExpiry > 7*24*time.Hour {
+		return fmt.Errorf("urlExpiry must be positive and at most 7 days")
+	}
+	return nil
+}
+
+func validateNotificationQueueConfig(config *NotificationQueueConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.RetryInterval <= 0 {
+		return fmt.Errorf("retryInterval must be positive")
+	}
+	if config.AlertTTL <= 0 || config.NoticeTTL <= 0 {
+		return fmt.Errorf("alertTTL and noticeTTL must be positive")
+	}
+	if config.Retention <= 0 {
+		return fmt.Errorf("retention must be positive")
+	}
+	return nil
+}
+
+func validateBalanceThresholdConfig(config *BalanceThresholdConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.WarningPercent <= 0 || config.WarningResetPercent <= config.WarningPercent || config.WarningResetPercent > 100 {
+		return fmt.Errorf("warningPercent must be positive and below warningResetPercent, at most 100")
+	}
+	if config.CriticalPercent <= 0 || config.CriticalResetPercent <= config.CriticalPercent || config.CriticalResetPercent > 100 {
+		return fmt.Errorf("criticalPercent must be positive and below criticalResetPercent, at most 100")
+	}
+	if config.CriticalPercent >= config.WarningPercent {
+		return fmt.Errorf("criticalPercent must be below warningPercent")
+	}
+	return nil
+}
+
+func validateReadOnlyConfig(config *ReadOnlyConfig, database *DatabaseConfig) error {
+	if config.RateLimitMultiplier < 1 {
+		return fmt.Errorf("rateLimitMultiplier must be at least 1")
+	}
+	if config.Enabled && database.ReplicaDSN == "" {
+		return fmt.Errorf("database replicaDSN is required in read-only mode")
+	}
+	return nil
+}
+
+func validateTaxConfig(config *TaxConfig) error {
+	if !config.Enabled {
+		if config.ApplyToDebits {
+			return fmt.Errorf("applyToDebits requires tax to be enabled")
+		}
+		return nil
+	}
+	for region, tax := range config.Regions {
+		if tax.Name == "" {
+			return fmt.Errorf("region %s has no tax name", region)
+		}
+		if len(tax.Components) == 0 {
+			return fmt.Errorf("region %s has no tax components", region)
+		}
+		for _, component := range tax.Components {
+			if component.Name == "" || component.Rate <= 0 || component.Rate > 100 {
+				return fmt.Errorf("region %s components must be named with a rate above 0 and at most 100", region)
+			}
+		}
+	}
+	return nil
+}
+
+func validateWalletActivityConfig(config *WalletActivityConfig) error {
+	if config.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if config.InactiveAfter < time.Hour*24 {
+		return fmt.Errorf("inactiveAfter must be at least a day")
+	}
+	if config.BatchSize <= 0 {
+		return fmt.Errorf("batchSize must be positive")
+	}
+	return nil
+}
+
+func validateBulkCreditConfig(config *BulkCreditConfig) error {
+	if config.MaxRows <= 0 {
+		return fmt.Errorf("maxRows must be positive")
+	}
+	if config.ChunkSize <= 0 || config.ChunkSize > config.MaxRows {
+		return fmt.Errorf("chunkSize must be positive and at most maxRows")
+	}
+	if config.RowsPerSecond <= 0 {
+		return fmt.Errorf("rowsPerSecond must be positive")
+	}
+	return nil
+}
+
+func validateIncidentConfig(config *IncidentConfig) error {
+	if config.RefreshInterval <= 0 {
+		return fmt.Errorf("refreshInterval must be positive")
+	}
+	if config.MaxDuration <= 0 {
+		return fmt.Errorf("maxDuration must be positive")
+	}
+	return nil
+}
+
+func validateDescriptionConfig(config *DescriptionConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.MaxLength < 16 {
+		return fmt.Errorf("maxLength must be at least 16")
+	}
+	for i, template := range config.Templates {
+		if strings.TrimSpace(template.Template) == "" {
+			return fmt.Errorf("templates[%d]: template is required", i)
+		}
+	}
+	return nil
+}
+
+func validateNotificationChannelConfig(config *NotificationChannelConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.ConsumerGroup == "" {
+		return fmt.Errorf("consumerGroup is required")
+	}
+	if config.BatchSize <= 0 {
+		return fmt.Errorf("batchSize must be positive")
+	}
+	if config.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	if config.SMTP.Host != "" && (config.SMTP.Port <= 0 || config.SMTP.From == "") {
+		return fmt.Errorf("smtp port and from are required with a host")
+	}
+	if config.SMS.URL != "" && config.SMS.APIKey == "" {
+		return fmt.Errorf("sms apiKey is required with a URL")
+	}
+	for i, template := range config.Templates {
+		if template.EventType == "" {
+			return fmt.Errorf("templates[%d]: eventType is required", i)
+		}
+	}
+	return nil
+}
+
+func validateAccessLogConfig(config *AccessLogConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.FlushInterval <= 0 {
+		return fmt.Errorf("flushInterval must be positive")
+	}
+	if config.MaxPending <= 0 {
+		return fmt.Errorf("maxPending must be positive")
+	}
+	if config.Retention <= 0 {
+		return fmt.Errorf("retention must be positive")
+	}
+	return nil
+}
+
+func validateInvoiceDocumentConfig(config *InvoiceDocumentConfig, notifications *NotificationChannelConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if !notifications.Enabled {
+		return fmt.Errorf("notifications must be enabled to deliver invoices")
+	}
+	if strings.TrimSpace(config.CompanyName) == "" {
+		return fmt.Errorf("companyName is required")
+	}
+	if ok, _ := regexp.MatchString(`^#[0-9A-Fa-f]{6}$`, config.AccentColor); !ok {
+		return fmt.Errorf("accentColor must be #RRGGBB")
+	}
+	if config.PublicURL != "" && !strings.HasPrefix(config.PublicURL, "https://") {
+		return fmt.Errorf("publicURL must be an https URL")
+	}
+	return nil
+}
+
+func validateReceivableConfig(config *ReceivableConfig) error {
+	switch config.DefaultTerms {
+	case "NET_15", "NET_30", "NET_45", "NET_60":
+	default:
+		return fmt.Errorf("defaultTerms must be NET_15, NET_30, NET_45 or NET_60")
+	}
+	switch config.LateFeeMode {
+	case "":
+	case "FEE":
+		if config.LateFeePercent <= 0 || config.LateFeePercent > 100 {
+			return fmt.Errorf("lateFeePercent must be between 0 and 100 in FEE mode")
+		}
+	case "INTEREST":
+		if config.InterestRate <= 0 || config.InterestRate > 100 {
+			return fmt.Errorf("interestRate must be between 0 and 100 in INTEREST mode")
+		}
+	default:
+		return fmt.Errorf("lateFeeMode must be FEE, INTEREST or empty")
+	}
+	if config.GracePeriod < 0 {
+		return fmt.Errorf("gracePeriod must not be negative")
+	}
+	if config.AccrualInterval <= 0 {
+		return fmt.Errorf("accrualInterval must be positive")
+	}
+	return nil
+}
+
+func validatePostpaidConfig(config *PostpaidConfig) error {
+	if config.CloseInterval <= 0 {
+		return fmt.Errorf("closeInterval must be positive")
+	}
+	return nil
+}
+
+func validateAllowanceConfig(config *AllowanceConfig) error {
+	for product, units := range config.Products {
+		if strings.TrimSpace(product) == "" {
+			return fmt.Errorf("product is required")
+		}
+		if units <= 0 {
+			return fmt.Errorf("allowance of %s must be positive", product)
+		}
+	}
+	return nil
+}
+
+func validateAdjustmentConfig(config *AdjustmentConfig) error {
+	for currency, threshold := range config.ApprovalThresholds {
+		if threshold < 0 {
+			return fmt.Errorf("approval threshold of %s must not be negative", strings.ToUpper(currency))
+		}
+	}
+	if config.PendingTTL <= 0 {
+		return fmt.Errorf("pendingTTL must be positive")
+	}
+	if config.ExpiryInterval <= 0 {
+		return fmt.Errorf("expiryInterval must be positive")
+	}
+	return nil
+}
+
+func validateInboundWebhookConfig(config *InboundWebhookConfig) error {
+	if config.MaxAttempts <= 0 {
+		return fmt.Errorf("maxAttempts must be positive")
+	}
+	if config.ProcessInterval <= 0 {
+		return fmt.Errorf("processInterval must be positive")
+	}
+	for name, provider := range config.Providers {
+		if provider.Secret == "" || provider.SignatureHeader == "" {
+			return fmt.Errorf("provider %s needs a secret and a signatureHeader", name)
+		}
+		if provider.TimestampHeader != "" && provider.Tolerance <= 0 {
+			return fmt.Errorf("provider %s needs a positive tolerance with a timestampHeader", name)
+		}
+		if provider.EventIDField == "" || provider.EventTypeField == "" {
+			return fmt.Errorf("provider %s needs an eventIDField and an eventTypeField", name)
+		}
+	}
+	return nil
+}
+
+func validateFailedJobConfig(config *FailedJobConfig) error {
+	if config.RetryInterval <= 0 {
+		return fmt.Errorf("retryInterval must be positive")
+	}
+	policies := map[string]RetryPolicyConfig{
+		"webhookDelivery":   config.WebhookDelivery,
+		"recurringDebitRun": config.RecurringDebitRun,
+	}
+	for name, policy := range policies {
+		if policy.MaxAttempts <= 0 {
+			return fmt.Errorf("%s maxAttempts must be positive", name)
+		}
+		if policy.MinBackoff <= 0 || policy.MaxBackoff < policy.MinBackoff {
+			return fmt.Errorf("%s minBackoff must be positive and maxBackoff at least minBackoff", name)
+		}
+	}
+	return nil
+}
+
+func validateCircuitBreakerConfig(config *CircuitBreakerConfig) error {
+	breakers := map[string]BreakerConfig{
+		"redis":           config.Redis,
+		"paymentProvider": config.PaymentProvider,
+	}
+	for name, breaker := range breakers {
+		if !breaker.Enabled {
+			continue
+		}
+		if breaker.ConsecutiveFailures <= 0 || breaker.HalfOpenRequests <= 0 {
+			return fmt.Errorf("%s consecutiveFailures and halfOpenRequests must be positive", name)
+		}
+		if breaker.OpenTimeout <= 0 {
+			return fmt.Errorf("%s openTimeout must be positive", name)
+		}
+		if breaker.Interval < 0 {
+			return fmt.Errorf("%s interval must not be negative", name)
+		}
+	}
+	return nil
+}
+
+func validateStartupConfig(config *StartupConfig) error {
+	if config.MaxWait <= 0 {
+		return fmt.Errorf("maxWait must be positive")
+	}
+	if config.MinBackoff <= 0 || config.MaxBackoff < config.MinBackoff {
+		return fmt.Errorf("minBackoff must be positive and maxBackoff at least minBackoff")
+	}
+	if config.AttemptTimeout <= 0 {
+		return fmt.Errorf("attemptTimeout must be positive")
+	}
+	return nil
+}
+
+func validateCalendarConfig(config *CalendarConfig) error {
+	if config.Country == "" {
+		return nil
+	}
+	for country := range config.Countries {
+		if strings.EqualFold(country, config.Country) {
+			return nil
+		}
+	}
+	return fmt.Errorf("country %s has no calendar in countries", config.Country)
+}
+
+func validateGraphQLConfig(config *GraphQLConfig, invoices *InvoiceServiceConfig) error {
+	if config.ComplexityLimit <= 0 {
+		return fmt.Errorf("complexityLimit must be positive")
+	}
+	if invoices.BaseURL != "" && invoices.Timeout <= 0 {
+		return fmt.Errorf("invoices timeout must be positive")
+	}
+	return nil
+}
+
+func validateLiveUpdatesConfig(config *LiveUpdatesConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.Heartbeat <= 0 {
+		return fmt.Errorf("heartbeat must be positive")
+	}
+	if config.History <= 0 {
+		return fmt.Errorf("history must be positive")
+	}
+	if config.HistoryTTL <= 0 {
+		return fmt.Errorf("historyTTL must be positive")
+	}
+	return nil
+}
+
+func validateWebSocketConfig(config *WebSocketConfig, liveUpdates *LiveUpdatesConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if !liveUpdates.Enabled {
+		return fmt.Errorf("websocket requires live updates to be enabled")
+	}
+	if config.MaxSubscriptions <= 0 {
+		return fmt.Errorf("maxSubscriptions must be positive")
+	}
+	if config.MessageRate <= 0 || config.MessageBurst <= 0 {
+		return fmt.Errorf("messageRate and messageBurst must be positive")
+	}
+	if config.PingInterval <= 0 {
+		return fmt.Errorf("pingInterval must be positive")
+	}
+	if config.WriteTimeout <= 0 {
+		return fmt.Errorf("writeTimeout must be positive")
+	}
+	return nil
+}
+
+func validateClassificationConfig(config *ClassificationConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	for class, scope := range config.Scopes {
+		switch class {
+		case "financial", "pii", "internal":
+		default:
+			return fmt.Errorf("unknown data class %q", class)
+		}
+		if scope == "" {
+			return fmt.Errorf("scope of data class %s is required", class)
+		}
+	}
+	return nil
+}
+
+func validateDecimalVerificationConfig(config *DecimalVerificationConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		return fmt.Errorf("sampleRate must be between 0 and 1")
+	}
+	if config.Tolerance < 0 {
+		return fmt.Errorf("tolerance must be non-negative")
+	}
+	if config.AlertDrift < config.Tolerance {
+		return fmt.Errorf("alertDrift must not be below tolerance")
+	}
+	if config.AlertMismatches <= 0 {
+		return fmt.Errorf("alertMismatches must be positive")
+	}
+	if config.AlertWindow <= 0 {
+		return fmt.Errorf("alertWindow must be positive")
+	}
+	return nil
+}
+
+func validateUsageConfig(config *UsageConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.FlushInterval <= 0 {
+		return fmt.Errorf("flushInterval must be positive")
+	}
+	if config.PerThousandRequests < 0 || config.PerDBSecond < 0 || config.PerGB < 0 {
+		return fmt.Errorf("rates must be non-negative")
+	}
+	if !config.Chargeback {
+		return nil
+	}
+	if config.RunAt < 0 || config.RunAt >= 24*time.Hour {
+		return fmt.Errorf("runAt must be within a day")
+	}
+	if len(config.Currency) != 3 {
+		return fmt.Errorf("currency must be a three-letter code")
+	}
+	for caller, walletID := range config.Wallets {
+		if caller == "" || walletID == "" {
+			return fmt.Errorf("wallets must map service subjects to wallet IDs")
+		}
+	}
+	return nil
+}
+
+func validateRateCardConfig(config *RateCardConfig) error {
+	if config.MinNotice < 0 {
+		return fmt.Errorf("minNotice must be non-negative")
+	}
+	if config.ActivationInterval <= 0 {
+		return fmt.Errorf("activationInterval must be positive")
+	}
+	return nil
+}
+
+func validateSLOConfig(config *SLOConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.RefreshInterval <= 0 {
+		return fmt.Errorf("refreshInterval must be positive")
+	}
+	for name, objective := range config.Objectives {
+		if objective.Method == "" || objective.Route == "" {
+			return fmt.Errorf("SLO %s requires a method and route", name)
+		}
+		if objective.Availability < 0 || objective.Availability >= 1 || objective.Latency < 0 || objective.Latency >= 1 {
+			return fmt.Errorf("SLO %s targets must be between 0 and 1", name)
+		}
+		if objective.Latency > 0 && objective.LatencyThreshold <= 0 {
+			return fmt.Errorf("SLO %s latency objective requires a latencyThreshold", name)
+		}
+	}
+	return nil
+}
+
+func validateDeprecationConfig(config *DeprecationConfig) error {
+	if config.FlushInterval <= 0 {
+		return fmt.Errorf("flushInterval must be positive")
+	}
+	for name, route := range config.Routes {
+		if route.Method == "" || route.Route == "" {
+			return fmt.Errorf("deprecated route %s requires a method and route", name)
+		}
+		deprecated, err := time.Parse("2006-01-02", route.Deprecated)
+		if err != nil {
+			return fmt.Errorf("deprecated route %s requires a deprecated date as YYYY-MM-DD", name)
+		}
+		if route.Sunset == "" {
+			continue
+		}
+		sunset, err := time.Parse("2006-01-02", route.Sunset)
+		if err != nil {
+			return fmt.Errorf("deprecated route %s sunset must be a date as YYYY-MM-DD", name)
+		}
+		if !sunset.After(deprecated) {
+			return fmt.Errorf("deprecated route %s must sunset after its deprecation", name)
+		}
+	}
+	return nil
+}
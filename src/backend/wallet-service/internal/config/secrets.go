@@ -0,0 +1,63 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"internal/secrets"
+)
+
+// NewSecretsProvider creates the secrets provider selected by the
+// configuration, or nil when secrets are read from the configuration file only
+func NewSecretsProvider(ctx context.Context, cfg *SecretsConfig) (secrets.Provider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "env":
+		return secrets.NewEnvProvider(cfg.EnvPrefix), nil
+	case "file":
+		return secrets.NewFileProvider(cfg.Dir)
+	case "aws":
+		return secrets.NewAWSProvider(ctx, cfg.AWSRegion, cfg.AWSSecretID)
+	case "vault":
+		return secrets.NewVaultProvider(cfg.VaultAddress, cfg.VaultToken, cfg.VaultMount, cfg.VaultPath, cfg.Timeout)
+	default:
+		return nil, fmt.Errorf("unsupported secrets provider: %s", cfg.Provider)
+	}
+}
+
+// resolveSecrets replaces the database credentials, the JWT secret and the
+// notification channel credentials of the configuration with those held by
+// the secrets provider
+func resolveSecrets(cfg *Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Secrets.Timeout)
+	defer cancel()
+
+	provider, err := NewSecretsProvider(ctx, &cfg.Secrets)
+	if err != nil || provider == nil {
+		return err
+	}
+
+	targets := []struct {
+		name  string
+		value *string
+	}{
+		{secrets.DatabaseUser, &cfg.Database.User},
+		{secrets.DatabasePassword, &cfg.Database.Password},
+		{secrets.JWTSecret, &cfg.Security.JWTSecret},
+		{secrets.SMTPPassword, &cfg.Notifications.SMTP.Password},
+		{secrets.SMSAPIKey, &cfg.Notifications.SMS.APIKey},
+	}
+	for _, target := range targets {
+		value, err := provider.Get(ctx, target.name)
+		if errors.Is(err, secrets.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read secret %s: %w", target.name, err)
+		}
+		*target.value = value
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"                            // v1.6.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+	"github.com/spf13/viper"                                  // v1.16.0
+)
+
+// Reload outcomes
+const (
+	reloadApplied    = "applied"
+	reloadInvalid    = "invalid"
+	reloadRolledBack = "rolled_back"
+)
+
+// configReloads counts configuration reloads by outcome
+var configReloads = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_config_reloads_total",
+		Help: "Configuration reloads triggered by config file changes, by outcome",
+	},
+	[]string{"outcome"},
+)
+
+// Logger defines the logging used by the config watcher
+type Logger interface {
+	Info(msg string, fields ...interface{})
+	Error(msg string, err error, fields ...interface{})
+	Warn(msg string, fields ...interface{})
+}
+
+// Subscriber applies a reloaded configuration to a component. Returning an
+// error rejects the reload, rolling every subscriber back to the previous
+// configuration.
+type Subscriber func(cfg *Config) error
+
+// subscription is a named subscriber
+type subscription struct {
+	name  string
+	apply Subscriber
+}
+
+// Watcher holds the current configuration snapshot and reloads it when the
+// config file changes. Only operational settings are reloaded: the rate
+// limit, the default low balance threshold and the log level. Other changes
+// take effect on restart.
+type Watcher struct {
+	v      *viper.Viper
+	logger Logger
+
+	current atomic.Pointer[Config]
+
+	// mu serializes reloads and subscriptions
+	mu            sync.Mutex
+	subscriptions []subscription
+}
+
+// NewWatcher loads and validates the configuration at configPath
+func NewWatcher(configPath string, logger Logger) (*Watcher, error) {
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	v, err := newViper(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := decodeConfig(v)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{v: v, logger: logger}
+	w.current.Store(cfg)
+	return w, nil
+}
+
+// SetLogger replaces the logger of the watcher, such as the bootstrap logger
+// used before the configuration was loaded
+func (w *Watcher) SetLogger(logger Logger) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.logger = logger
+}
+
+// Current returns the current configuration snapshot. Snapshots are shared
+// and must not be modified.
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// Subscribe registers a component to be notified of reloaded configurations
+func (w *Watcher) Subscribe(name string, apply Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscriptions = append(w.subscriptions, subscription{name: name, apply: apply})
+}
+
+// Watch starts reloading the configuration whenever the config file changes
+func (w *Watcher) Watch() {
+	w.v.OnConfigChange(func(e fsnotify.Event) {
+		// Failures are logged and counted by Reload
+		_ = w.Reload()
+	})
+	w.v.WatchConfig()
+}
+
+// Reload reads and validates the config file and applies its operational
+// settings to every subscriber. An invalid configuration or a subscriber
+// rejecting it leaves the previous configuration in effect.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	loaded, err := w.read()
+	if err != nil {
+		configReloads.WithLabelValues(reloadInvalid).Inc()
+		w.logger.Error("invalid configuration reload ignored", err)
+		return err
+	}
+
+	previous := w.current.Load()
+	next := reloadable(previous, loaded)
+
+	for i, sub := range w.subscriptions {
+		if err := sub.apply(next); err != nil {
+			w.rollback(previous, w.subscriptions[:i])
+			configReloads.WithLabelValues(reloadRolledBack).Inc()
+			w.logger.Error("configuration reload rejected, rolled back", err, "subscriber", sub.name)
+			return fmt.Errorf("subscriber %s rejected configuration: %w", sub.name, err)
+		}
+	}
+
+	w.current.Store(next)
+	configReloads.WithLabelValues(reloadApplied).Inc()
+	w.logger.Info("configuration reloaded",
+		"rateLimit", next.Security.RateLimit,
+		"rateLimitWindow", next.Security.RateLimitWindow,
+		"lowBalanceThreshold", next.Wallet.LowBalanceThreshold,
+		"logLevel", next.Logging.Level)
+	return nil
+}
+
+// read reads the config file into a new configuration
+func (w *Watcher) read() (*Config, error) {
+	if err := w.v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+	return decodeConfig(w.v)
+}
+
+// rollback reapplies the previous configuration to the subscribers that
+// accepted the rejected one, most recent first
+func (w *Watcher) rollback(previous *Config, applied []subscription) {
+	for i := len(applied) - 1; i >= 0; i-- {
+		if err := applied[i].apply(previous); err != nil {
+			w.logger.Error("failed to roll back configuration", err, "subscriber", applied[i].name)
+		}
+	}
+}
+
+// reloadable returns a copy of the current configuration with the
+// operational settings of the loaded one
+func reloadable(current, loaded *Config) *Config {
+	next := *current
+	next.Security.RateLimit = loaded.Security.RateLimit
+	next.Security.RateLimitWindow = loaded.Security.RateLimitWindow
+	next.Wallet.LowBalanceThreshold = loaded.Wallet.LowBalanceThreshold
+	next.Logging.Level = loaded.Logging.Level
+	return &next
+}
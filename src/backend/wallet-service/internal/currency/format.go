@@ -0,0 +1,91 @@
+package currency
+
+import "internal/models"
+
+// display holds the locale conventions of a currency's amounts
+type display struct {
+	symbol   string
+	after    bool
+	spacing  bool
+	decimal  string
+	group    string
+	grouping []int
+	locale   string
+}
+
+// displays holds the display conventions of the currencies wallets commonly
+// hold, in the locale of their issuing country. Other currencies are shown
+// with their code before the amount.
+var displays = map[string]display{
+	"AED": {symbol: "AED", spacing: true, decimal: ".", group: ",", grouping: []int{3}, locale: "en-AE"},
+	"AUD": {symbol: "A$", decimal: ".", group: ",", grouping: []int{3}, locale: "en-AU"},
+	"BDT": {symbol: "৳", decimal: ".", group: ",", grouping: []int{3, 2}, locale: "bn-BD"},
+	"BRL": {symbol: "R$", spacing: true, decimal: ",", group: ".", grouping: []int{3}, locale: "pt-BR"},
+	"CAD": {symbol: "CA$", decimal: ".", group: ",", grouping: []int{3}, locale: "en-CA"},
+	"CHF": {symbol: "CHF", spacing: true, decimal: ".", group: "’", grouping: []int{3}, locale: "de-CH"},
+	"CNY": {symbol: "¥", decimal: ".", group: ",", grouping: []int{3}, locale: "zh-CN"},
+	"EUR": {symbol: "€", after: true, spacing: true, decimal: ",", group: ".", grouping: []int{3}, locale: "de-DE"},
+	"GBP": {symbol: "£", decimal: ".", group: ",", grouping: []int{3}, locale: "en-GB"},
+	"HKD": {symbol: "HK$", decimal: ".", group: ",", grouping: []int{3}, locale: "zh-HK"},
+	"IDR": {symbol: "Rp", decimal: ",", group: ".", grouping: []int{3}, locale: "id-ID"},
+	"INR": {symbol: "₹", decimal: ".", group: ",", grouping: []int{3, 2}, locale: "en-IN"},
+	"JPY": {symbol: "¥", decimal: ".", group: ",", grouping: []int{3}, locale: "ja-JP"},
+	"KES": {symbol: "KSh", decimal: ".", group: ",", grouping: []int{3}, locale: "en-KE"},
+	"KRW": {symbol: "₩", decimal: ".", group: ",", grouping: []int{3}, locale: "ko-KR"},
+	"LKR": {symbol: "Rs", spacing: true, decimal: ".", group: ",", grouping: []int{3}, locale: "en-LK"},
+	"MXN": {symbol: "MX$", decimal: ".", group: ",", grouping: []int{3}, locale: "es-MX"},
+	"MYR": {symbol: "RM", decimal: ".", group: ",", grouping: []int{3}, locale: "ms-MY"},
+	"NGN": {symbol: "₦", decimal: ".", group: ",", grouping: []int{3}, locale: "en-NG"},
+	"NPR": {symbol: "Rs", spacing: true, decimal: ".", group: ",", grouping: []int{3, 2}, locale: "ne-NP"},
+	"PHP": {symbol: "₱", decimal: ".", group: ",", grouping: []int{3}, locale: "en-PH"},
+	"PKR": {symbol: "Rs", spacing: true, decimal: ".", group: ",", grouping: []int{3}, locale: "en-PK"},
+	"SAR": {symbol: "SAR", spacing: true, decimal: ".", group: ",", grouping: []int{3}, locale: "en-SA"},
+	"SEK": {symbol: "kr", after: true, spacing: true, decimal: ",", group: " ", grouping: []int{3}, locale: "sv-SE"},
+	"SGD": {symbol: "S$", decimal: ".", group: ",", grouping: []int{3}, locale: "en-SG"},
+	"THB": {symbol: "฿", decimal: ".", group: ",", grouping: []int{3}, locale: "th-TH"},
+	"USD": {symbol: "$", decimal: ".", group: ",", grouping: []int{3}, locale: "en-US"},
+	"VND": {symbol: "₫", after: true, spacing: true, decimal: ",", group: ".", grouping: []int{3}, locale: "vi-VN"},
+	"ZAR": {symbol: "R", decimal: ",", group: " ", grouping: []int{3}, locale: "en-ZA"},
+}
+
+// Format returns the display hints of a supported currency, at the
+// precision its amounts are rounded to
+func (r *Registry) Format(code string) (models.CurrencyFormat, bool) {
+	c, ok := r.currencies[code]
+	if !ok {
+		return models.CurrencyFormat{}, false
+	}
+
+	d, ok := displays[code]
+	if !ok {
+		d = display{symbol: code, spacing: true, decimal: ".", group: ",", grouping: []int{3}, locale: "en"}
+	}
+	position := models.SymbolBefore
+	if d.after {
+		position = models.SymbolAfter
+	}
+
+	return models.CurrencyFormat{
+		Code:             code,
+		Symbol:           d.symbol,
+		DecimalPlaces:    c.Precision,
+		SymbolPosition:   position,
+		SymbolSpacing:    d.spacing,
+		DecimalSeparator: d.decimal,
+		GroupSeparator:   d.group,
+		Grouping:         append([]int(nil), d.grouping...),
+		Locale:           d.locale,
+	}, true
+}
+
+// Formats returns the display hints of the supported currencies among
+// codes, keyed by code
+func (r *Registry) Formats(codes ...string) map[string]models.CurrencyFormat {
+	formats := make(map[string]models.CurrencyFormat, len(codes))
+	for _, code := range codes {
+		if format, ok := r.Format(code); ok {
+			formats[code] = format
+		}
+	}
+	return formats
+}
@@ -0,0 +1,71 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// ErrNoRate is returned when no exchange rate converts between two
+// currencies
+var ErrNoRate = errors.New("no exchange rate")
+
+// RateTable holds exchange rates against a base currency, the units of the
+// base per unit of each currency. Rates between two other currencies are
+// crossed through the base. It is read-only once created.
+type RateTable struct {
+	base  string
+	rates map[string]decimal.Decimal
+}
+
+// NewRateTable creates a table of rates to base. Keys are matched
+// case-insensitively, as configuration keys are lowercased.
+func NewRateTable(base string, rates map[string]decimal.Decimal) (*RateTable, error) {
+	base = strings.ToUpper(base)
+	if !IsISO4217(base) {
+		return nil, fmt.Errorf("%q is not an ISO 4217 currency code", base)
+	}
+
+	t := &RateTable{base: base, rates: map[string]decimal.Decimal{base: decimal.NewFromInt(1)}}
+	for code, rate := range rates {
+		code = strings.ToUpper(code)
+		if !IsISO4217(code) {
+			return nil, fmt.Errorf("%q is not an ISO 4217 currency code", code)
+		}
+		if !rate.IsPositive() {
+			return nil, fmt.Errorf("exchange rate of %s must be positive", code)
+		}
+		if code == base && !rate.Equal(decimal.NewFromInt(1)) {
+			return nil, fmt.Errorf("exchange rate of the base currency %s must be 1", code)
+		}
+		t.rates[code] = rate
+	}
+
+	return t, nil
+}
+
+// Base returns the currency the rates are against
+func (t *RateTable) Base() string {
+	return t.base
+}
+
+// Rate returns the units of to per unit of from, for use with
+// Registry.Convert
+func (t *RateTable) Rate(from, to string) (decimal.Decimal, error) {
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+
+	fromRate, ok := t.rates[from]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("%w from %s", ErrNoRate, from)
+	}
+	toRate, ok := t.rates[to]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("%w to %s", ErrNoRate, to)
+	}
+
+	return fromRate.DivRound(toRate, 12), nil
+}
@@ -0,0 +1,182 @@
+// Package currency validates ISO 4217 currency codes and rounds amounts to
+// the precision of each currency the service supports, in the currency's
+// rounding mode.
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+)
+
+// MaxPrecision bounds the decimal places of a currency, matching the scale
+// of the amount columns
+const MaxPrecision = 4
+
+// Currency registry errors
+var (
+	ErrNoCurrencies = errors.New("at least one currency must be supported")
+	ErrUnsupported  = errors.New("unsupported currency")
+)
+
+// IsISO4217 reports whether code is an active ISO 4217 currency code
+func IsISO4217(code string) bool {
+	_, ok := minorUnits[code]
+	return ok
+}
+
+// MinorUnits returns the ISO 4217 decimal places of a currency
+func MinorUnits(code string) (int32, bool) {
+	units, ok := minorUnits[code]
+	return units, ok
+}
+
+// Registry holds the supported currencies. It is read-only once created.
+type Registry struct {
+	currencies map[string]models.Currency
+	codes      []string
+}
+
+// New creates a registry of the given currencies
+func New(currencies []models.Currency) (*Registry, error) {
+	if len(currencies) == 0 {
+		return nil, ErrNoCurrencies
+	}
+
+	r := &Registry{currencies: make(map[string]models.Currency, len(currencies))}
+	for _, c := range currencies {
+		if !IsISO4217(c.Code) {
+			return nil, fmt.Errorf("%q is not an ISO 4217 currency code", c.Code)
+		}
+		if c.Precision < 0 || c.Precision > MaxPrecision {
+			return nil, fmt.Errorf("precision of %s must be between 0 and %d", c.Code, MaxPrecision)
+		}
+		if c.Rounding == "" {
+			c.Rounding = models.RoundHalfUp
+		}
+		if !c.Rounding.Valid() {
+			return nil, fmt.Errorf("unsupported rounding mode %s of %s", c.Rounding, c.Code)
+		}
+		if _, ok := r.currencies[c.Code]; ok {
+			return nil, fmt.Errorf("currency %s is listed twice", c.Code)
+		}
+		r.currencies[c.Code] = c
+		r.codes = append(r.codes, c.Code)
+	}
+	sort.Strings(r.codes)
+
+	return r, nil
+}
+
+// FromCodes creates a registry of the given codes at their ISO 4217
+// precision unless overridden, rounding half up unless another mode is set.
+// Keys are matched case-insensitively, as configuration keys are lowercased.
+func FromCodes(codes []string, overrides map[string]int32, rounding map[string]models.RoundingMode) (*Registry, error) {
+	precision := make(map[string]int32, len(overrides))
+	for code, places := range overrides {
+		precision[strings.ToUpper(code)] = places
+	}
+	modes := make(map[string]models.RoundingMode, len(rounding))
+	for code, mode := range rounding {
+		modes[strings.ToUpper(code)] = mode
+	}
+
+	currencies := make([]models.Currency, 0, len(codes))
+	for _, code := range codes {
+		code = strings.ToUpper(code)
+		places, ok := precision[code]
+		if !ok {
+			if places, ok = MinorUnits(code); !ok {
+				return nil, fmt.Errorf("%q is not an ISO 4217 currency code", code)
+			}
+		}
+		currencies = append(currencies, models.Currency{Code: code, Precision: places, Rounding: modes[code]})
+	}
+	for code := range precision {
+		if !contains(codes, code) {
+			return nil, fmt.Errorf("precision is set for unsupported currency %s", code)
+		}
+	}
+	for code := range modes {
+		if !contains(codes, code) {
+			return nil, fmt.Errorf("rounding is set for unsupported currency %s", code)
+		}
+	}
+
+	return New(currencies)
+}
+
+// Supported reports whether transactions may use a currency
+func (r *Registry) Supported(code string) bool {
+	_, ok := r.currencies[code]
+	return ok
+}
+
+// Precision returns the decimal places amounts of a currency are rounded to
+func (r *Registry) Precision(code string) (int32, bool) {
+	c, ok := r.currencies[code]
+	return c.Precision, ok
+}
+
+// Rounding returns the rounding mode of a currency
+func (r *Registry) Rounding(code string) (models.RoundingMode, bool) {
+	c, ok := r.currencies[code]
+	return c.Rounding, ok
+}
+
+// Round rounds an amount to the precision of its currency in the currency's
+// rounding mode. Amounts of unsupported currencies are rounded half up to
+// MaxPrecision.
+func (r *Registry) Round(code string, amount decimal.Decimal) decimal.Decimal {
+	c, ok := r.currencies[code]
+	if !ok {
+		return amount.Round(MaxPrecision)
+	}
+	return c.Rounding.Round(amount, c.Precision)
+}
+
+// ToMinorUnits rounds an amount like Round and returns it in minor units of
+// its currency
+func (r *Registry) ToMinorUnits(code string, amount decimal.Decimal) (models.MinorAmount, error) {
+	c, ok := r.currencies[code]
+	if !ok {
+		return models.MinorAmount{}, fmt.Errorf("%w: %s", ErrUnsupported, code)
+	}
+	return models.ToMinorUnits(amount, c.Precision, c.Rounding)
+}
+
+// Convert converts an amount between currencies at the given rate, the
+// units of the target currency per unit of the source, and rounds the
+// result in the target currency's mode
+func (r *Registry) Convert(amount decimal.Decimal, from, to string, rate decimal.Decimal) (decimal.Decimal, error) {
+	if !r.Supported(from) {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrUnsupported, from)
+	}
+	if !r.Supported(to) {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrUnsupported, to)
+	}
+	if !rate.IsPositive() {
+		return decimal.Zero, errors.New("exchange rate must be positive")
+	}
+	return r.Round(to, amount.Mul(rate)), nil
+}
+
+// Codes returns the supported currency codes in order
+func (r *Registry) Codes() []string {
+	return append([]string(nil), r.codes...)
+}
+
+// contains reports whether codes holds code, ignoring case
+func contains(codes []string, code string) bool {
+	for _, c := range codes {
+		if strings.EqualFold(c, code) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+// Package deprecation declares the deprecated API routes and how their
+// responses signal it: a Deprecation header with the date the route was
+// deprecated (RFC 9745), a Sunset header with the date it is removed
+// (RFC 8594) and a Link to the migration guide.
+package deprecation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// DateLayout formats the deprecation and sunset dates of the configuration
+const DateLayout = "2006-01-02"
+
+// Definition declares a deprecated endpoint
+type Definition struct {
+	Name string
+	// Method and Route identify the endpoint by its router path, e.g.
+	// /api/v1/wallets/:id/balance
+	Method string
+	Route  string
+	// Deprecated is when the endpoint was deprecated
+	Deprecated time.Time
+	// Sunset is when the endpoint is removed; zero when not yet planned
+	Sunset time.Time
+	// Link is the documentation of the replacement; optional
+	Link string
+}
+
+// Validate checks the dates of a definition
+func (d Definition) Validate() error {
+	if d.Name == "" || d.Method == "" || d.Route == "" {
+		return errors.New("name, method and route are required")
+	}
+	if d.Deprecated.IsZero() {
+		return fmt.Errorf("deprecated route %s requires a deprecation date", d.Name)
+	}
+	if !d.Sunset.IsZero() && !d.Sunset.After(d.Deprecated) {
+		return fmt.Errorf("deprecated route %s must sunset after its deprecation", d.Name)
+	}
+	return nil
+}
+
+// SetHeaders adds the deprecation headers of the endpoint to a response
+func (d Definition) SetHeaders(h http.Header) {
+	h.Set("Deprecation", fmt.Sprintf("@%d", d.Deprecated.Unix()))
+	if !d.Sunset.IsZero() {
+		h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		h.Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, d.Link))
+	}
+}
+
+// Registry looks up the deprecated endpoints. It is read-only after
+// creation and safe for concurrent use.
+type Registry struct {
+	defs    []Definition
+	byRoute map[string]Definition
+}
+
+// NewRegistry creates a registry of the given deprecated endpoints
+func NewRegistry(defs []Definition) (*Registry, error) {
+	r := &Registry{byRoute: make(map[string]Definition, len(defs))}
+
+	names := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if err := def.Validate(); err != nil {
+			return nil, err
+		}
+		if names[def.Name] {
+			return nil, fmt.Errorf("deprecated route %s is defined twice", def.Name)
+		}
+		key := routeKey(def.Method, def.Route)
+		if _, ok := r.byRoute[key]; ok {
+			return nil, fmt.Errorf("endpoint %s %s is deprecated twice", def.Method, def.Route)
+		}
+		names[def.Name] = true
+		r.byRoute[key] = def
+		r.defs = append(r.defs, def)
+	}
+	sort.Slice(r.defs, func(i, j int) bool { return r.defs[i].Name < r.defs[j].Name })
+
+	return r, nil
+}
+
+// Lookup returns the definition of a deprecated endpoint
+func (r *Registry) Lookup(method, route string) (Definition, bool) {
+	def, ok := r.byRoute[routeKey(method, route)]
+	return def, ok
+}
+
+// Definitions returns the deprecated endpoints sorted by name
+func (r *Registry) Definitions() []Definition {
+	defs := make([]Definition, len(r.defs))
+	copy(defs, r.defs)
+	return defs
+}
+
+// routeKey identifies an endpoint
+func routeKey(method, route string) string {
+	return method + " " + route
+}
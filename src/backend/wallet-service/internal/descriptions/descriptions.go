@@ -0,0 +1,227 @@
+// Package descriptions renders transaction descriptions from templates
+// configured per source and product, and normalizes the free text callers
+// send, so statements and exports read consistently whichever service
+// posted the transaction.
+package descriptions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/currency"
+	"internal/models"
+)
+
+// Template errors
+var (
+	// ErrInvalidTemplate is returned for templates that cannot be parsed
+	ErrInvalidTemplate = errors.New("invalid description template")
+	// ErrMissingVariable is returned when a transaction lacks the metadata
+	// a template refers to
+	ErrMissingVariable = errors.New("description template variable missing")
+)
+
+// Built-in variables of templates, taken from the transaction rather than
+// its metadata. The amount is written to the currency's decimal places.
+const (
+	VariableAmount      = "amount"
+	VariableCurrency    = "currency"
+	VariableDescription = "description"
+)
+
+// ellipsis ends descriptions cut to the maximum length
+const ellipsis = "…"
+
+// Definition describes a template in configuration form
+type Definition struct {
+	// Source and Product select the transactions the template describes,
+	// by their source and product metadata; empty matches any
+	Source  string
+	Product string
+	// Template is the description with {name} variables, replaced by the
+	// built-in variables or else by the metadata of that name
+	Template string
+}
+
+// segment is a literal part of a template, or a variable when name is set
+type segment struct {
+	text string
+	name string
+}
+
+// Template is a parsed description template
+type Template struct {
+	source   string
+	product  string
+	segments []segment
+}
+
+// Parse parses a template of literal text and {name} variables
+func Parse(def Definition) (*Template, error) {
+	text := strings.TrimSpace(def.Template)
+	if text == "" {
+		return nil, fmt.Errorf("%w: template is required", ErrInvalidTemplate)
+	}
+
+	t := &Template{
+		source:  strings.TrimSpace(def.Source),
+		product: strings.TrimSpace(def.Product),
+	}
+	for text != "" {
+		open := strings.IndexAny(text, "{}")
+		if open < 0 {
+			t.segments = append(t.segments, segment{text: text})
+			break
+		}
+		if text[open] == '}' {
+			return nil, fmt.Errorf("%w: unmatched } in %q", ErrInvalidTemplate, def.Template)
+		}
+		if open > 0 {
+			t.segments = append(t.segments, segment{text: text[:open]})
+		}
+		end := strings.IndexAny(text[open+1:], "{}")
+		if end < 0 || text[open+1+end] != '}' {
+			return nil, fmt.Errorf("%w: unmatched { in %q", ErrInvalidTemplate, def.Template)
+		}
+		name := strings.TrimSpace(text[open+1 : open+1+end])
+		if name == "" {
+			return nil, fmt.Errorf("%w: empty variable in %q", ErrInvalidTemplate, def.Template)
+		}
+		t.segments = append(t.segments, segment{name: name})
+		text = text[open+end+2:]
+	}
+	return t, nil
+}
+
+// Render renders the template with a transaction's variables. A variable
+// the transaction lacks fails with ErrMissingVariable.
+func (t *Template) Render(tx *models.Transaction) (string, error) {
+	var b strings.Builder
+	for _, seg := range t.segments {
+		if seg.name == "" {
+			b.WriteString(seg.text)
+			continue
+		}
+		value, ok := variable(tx, seg.name)
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrMissingVariable, seg.name)
+		}
+		b.WriteString(value)
+	}
+	return b.String(), nil
+}
+
+// variable returns the value of a variable for a transaction
+func variable(tx *models.Transaction, name string) (string, bool) {
+	switch name {
+	case VariableAmount:
+		places, ok := currency.MinorUnits(strings.ToUpper(tx.Currency))
+		if !ok {
+			places = 2
+		}
+		return decimal.NewFromFloat(tx.Amount).StringFixed(places), true
+	case VariableCurrency:
+		return strings.ToUpper(tx.Currency), true
+	case VariableDescription:
+		return tx.Description, strings.TrimSpace(tx.Description) != ""
+	}
+	value, ok := tx.Metadata[name]
+	return value, ok && strings.TrimSpace(value) != ""
+}
+
+// key identifies the transactions a template describes
+type key struct {
+	source  string
+	product string
+}
+
+// Registry holds the configured templates and the longest description
+type Registry struct {
+	templates map[key]*Template
+	maxLength int
+}
+
+// NewRegistry parses the templates, of which at most one may be given per
+// source and product. Descriptions are cut to maxLength characters.
+func NewRegistry(defs []Definition, maxLength int) (*Registry, error) {
+	if maxLength <= 0 {
+		return nil, errors.New("max length must be positive")
+	}
+
+	r := &Registry{
+		templates: make(map[key]*Template, len(defs)),
+		maxLength: maxLength,
+	}
+	for _, def := range defs {
+		t, err := Parse(def)
+		if err != nil {
+			return nil, err
+		}
+		k := key{source: t.source, product: t.product}
+		if _, ok := r.templates[k]; ok {
+			return nil, fmt.Errorf("%w: more than one template for source %q and product %q", ErrInvalidTemplate, k.source, k.product)
+		}
+		r.templates[k] = t
+	}
+	return r, nil
+}
+
+// For returns the template describing transactions of a source and
+// product, preferring one naming both, then the source, then the product,
+// then the default
+func (r *Registry) For(source, product string) (*Template, bool) {
+	for _, k := range []key{{source, product}, {source, ""}, {"", product}, {"", ""}} {
+		if t, ok := r.templates[k]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// Describe returns the normalized description of a transaction, rendered
+// from its template when one applies. A transaction lacking a variable of
+// its template keeps its own description, returned with the error.
+func (r *Registry) Describe(tx *models.Transaction) (string, error) {
+	t, ok := r.For(tx.Metadata[models.TransactionSourceKey], tx.Metadata[models.TransactionProductKey])
+	if !ok {
+		return Normalize(tx.Description, r.maxLength), nil
+	}
+	rendered, err := t.Render(tx)
+	if err != nil {
+		return Normalize(tx.Description, r.maxLength), err
+	}
+	return Normalize(rendered, r.maxLength), nil
+}
+
+// Templates returns the number of configured templates
+func (r *Registry) Templates() int {
+	return len(r.templates)
+}
+
+// Normalize tidies a description: control characters and runs of
+// whitespace become single spaces, trailing punctuation is dropped and the
+// first letter is capitalized. Descriptions longer than maxLength
+// characters are cut with an ellipsis; a maxLength of 0 keeps any length.
+func Normalize(text string, maxLength int) string {
+	text = strings.Join(strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}), " ")
+	text = strings.TrimRight(text, " .,;:-")
+	if text == "" {
+		return ""
+	}
+
+	first, size := utf8.DecodeRuneInString(text)
+	text = string(unicode.ToUpper(first)) + text[size:]
+
+	if maxLength > 0 && utf8.RuneCountInString(text) > maxLength {
+		runes := []rune(text)
+		text = strings.TrimRight(string(runes[:maxLength-1]), " ") + ellipsis
+	}
+	return text
+}
@@ -0,0 +1,113 @@
+// Package eventbus is the in-process publish/subscribe bus between the
+// service layer and the transports that carry domain events out of the
+// service. Services publish typed events without knowing how, or whether,
+// they leave the process; transports such as the Redis event stream
+// subscribe to the events they carry.
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Event is a typed domain event
+type Event interface {
+	// EventType names the event for subscription filters
+	EventType() string
+}
+
+// Handler processes a published event
+type Handler func(ctx context.Context, event Event) error
+
+// Publisher publishes domain events. Services depend on this rather than on
+// the Bus so tests can record events.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// subscription is a named handler and the event types it receives
+type subscription struct {
+	name    string
+	types   map[string]bool
+	handler Handler
+}
+
+// Bus dispatches events synchronously to its subscribers in subscription order
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions []subscription
+}
+
+// New creates an empty bus
+func New() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler under a name for the given event types, or
+// for every event when no types are given
+func (b *Bus) Subscribe(name string, handler Handler, eventTypes ...string) error {
+	if name == "" {
+		return errors.New("subscriber name is required")
+	}
+	if handler == nil {
+		return errors.New("handler is required")
+	}
+
+	var types map[string]bool
+	if len(eventTypes) > 0 {
+		types = make(map[string]bool, len(eventTypes))
+		for _, t := range eventTypes {
+			types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, sub := range b.subscriptions {
+		if sub.name == name {
+			return fmt.Errorf("duplicate subscriber %q", name)
+		}
+	}
+	b.subscriptions = append(b.subscriptions, subscription{name: name, types: types, handler: handler})
+
+	return nil
+}
+
+// Publish delivers the event to every matching subscriber, even when an
+// earlier one fails, and returns the failures joined. Callers treat an error
+// as a failed publish and retry, so subscribers must tolerate duplicates.
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	if event == nil {
+		return errors.New("event is required")
+	}
+
+	b.mu.RLock()
+	subscriptions := b.subscriptions
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, sub := range subscriptions {
+		if sub.types != nil && !sub.types[event.EventType()] {
+			continue
+		}
+		if err := sub.handler(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("subscriber %s failed on %s: %w", sub.name, event.EventType(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Typed adapts a handler of one concrete event type, ignoring other events
+func Typed[T Event](handler func(ctx context.Context, event T) error) Handler {
+	return func(ctx context.Context, event Event) error {
+		typed, ok := event.(T)
+		if !ok {
+			return nil
+		}
+		return handler(ctx, typed)
+	}
+}
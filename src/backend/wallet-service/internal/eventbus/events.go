@@ -0,0 +1,228 @@
+package eventbus
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// Domain event types, named after the wire events transports publish them as
+const (
+	TypeTransactionCompleted = "transaction.completed"
+	TypeLowBalance           = "wallet.low_balance"
+	TypeActivityDigest       = "wallet.activity_digest"
+	TypeDunningNotice        = "wallet.dunning_notice"
+	TypeCustomerSuspended    = "customer.suspend"
+	TypeCustomerResumed      = "customer.resume"
+	TypeCreditLimitWarning   = "wallet.credit_limit_warning"
+	TypeRateCardApproved     = "pricing.rate_card_approved"
+	TypeWalletCreated        = "wallet.created"
+	TypeBalanceWarning       = "wallet.balance_warning"
+	TypeBalanceCritical      = "wallet.balance_critical"
+	TypeWalletInactive       = "wallet.inactive"
+	TypeWalletReactivated    = "wallet.reactivated"
+	TypeDisputeUpdated       = "wallet.dispute_updated"
+	TypeBalanceAlert         = "wallet.balance_alert"
+	TypeInvoiceIssued        = "invoice.issued"
+	TypeBucketLowBalance     = "wallet.bucket_low_balance"
+	TypeDatabaseModeChanged  = "service.database_mode_changed"
+)
+
+// Domain event types of wallet state changes, which only feed live updates
+// to connected clients
+const (
+	TypeBalanceChanged           = "wallet.balance_changed"
+	TypeTransactionStatusChanged = "transaction.status_changed"
+)
+
+// TransactionCompleted is published when a transaction was applied to a wallet
+type TransactionCompleted struct {
+	Transaction *models.Transaction
+}
+
+// EventType implements Event
+func (TransactionCompleted) EventType() string { return TypeTransactionCompleted }
+
+// LowBalance is published when a wallet balance falls to its low balance threshold
+type LowBalance struct {
+	Wallet *models.Wallet
+}
+
+// EventType implements Event
+func (LowBalance) EventType() string { return TypeLowBalance }
+
+// ActivityDigest is published with a customer's wallet activity for a period
+type ActivityDigest struct {
+	Subscription *models.DigestSubscription
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+	Wallets      []*models.WalletActivity
+}
+
+// EventType implements Event
+func (ActivityDigest) EventType() string { return TypeActivityDigest }
+
+// DunningNotice is published for each escalating notice of a dunning case
+type DunningNotice struct {
+	Case   *models.DunningCase
+	Notice int
+	Final  bool
+}
+
+// EventType implements Event
+func (DunningNotice) EventType() string { return TypeDunningNotice }
+
+// CustomerSuspended is published when a customer's service must be suspended
+type CustomerSuspended struct {
+	Case        *models.DunningCase
+	Reason      string
+	SuspendedAt time.Time
+}
+
+// EventType implements Event
+func (CustomerSuspended) EventType() string { return TypeCustomerSuspended }
+
+// CustomerResumed is published when a customer's suspension is lifted
+type CustomerResumed struct {
+	Case      *models.DunningCase
+	ResumedAt time.Time
+}
+
+// EventType implements Event
+func (CustomerResumed) EventType() string { return TypeCustomerResumed }
+
+// CreditLimitWarning is published when a debit takes a wallet's credit
+// utilization across a warning level
+type CreditLimitWarning struct {
+	Wallet  *models.Wallet
+	Warning *models.CreditLimitWarning
+}
+
+// EventType implements Event
+func (CreditLimitWarning) EventType() string { return TypeCreditLimitWarning }
+
+// RateCardApproved is published when a rate card change was approved, to
+// notify finance before it takes effect
+type RateCardApproved struct {
+	Change *models.RateCardChange
+}
+
+// EventType implements Event
+func (RateCardApproved) EventType() string { return TypeRateCardApproved }
+
+// WalletCreated is published when a wallet was provisioned for a customer
+type WalletCreated struct {
+	Wallet *models.Wallet
+}
+
+// EventType implements Event
+func (WalletCreated) EventType() string { return TypeWalletCreated }
+
+// BalanceThresholdCrossed is published when a wallet balance falls below the
+// warning or critical threshold configured for it. The level stays quiet
+// until the balance rises above its reset amount.
+type BalanceThresholdCrossed struct {
+	Wallet     *models.Wallet
+	Level      models.BalanceAlertLevel
+	Thresholds *models.BalanceThresholds
+}
+
+// EventType implements Event, typing each level separately so subscribers
+// can follow only critical alerts
+func (e BalanceThresholdCrossed) EventType() string {
+	if e.Level == models.BalanceAlertCritical {
+		return TypeBalanceCritical
+	}
+	return TypeBalanceWarning
+}
+
+// WalletInactive is published when a wallet went without transactions for
+// the inactivity period and was flagged inactive
+type WalletInactive struct {
+	Wallet *models.Wallet
+}
+
+// EventType implements Event
+func (WalletInactive) EventType() string { return TypeWalletInactive }
+
+// WalletReactivated is published when an inactive wallet transacted again
+type WalletReactivated struct {
+	Reactivation *models.WalletReactivation
+}
+
+// EventType implements Event
+func (WalletReactivated) EventType() string { return TypeWalletReactivated }
+
+// DisputeUpdated is published when a dispute was opened, had evidence
+// submitted or was resolved
+type DisputeUpdated struct {
+	Dispute    *models.Dispute
+	CustomerID uuid.UUID
+}
+
+// EventType implements Event
+func (DisputeUpdated) EventType() string { return TypeDisputeUpdated }
+
+// BalanceAlertTriggered is published when a wallet balance falls below one
+// of its alert thresholds. Channels are the channels to alert on, which the
+// forwarder narrows to those the customer consented to.
+type BalanceAlertTriggered struct {
+	Wallet   *models.Wallet
+	Alert    *models.BalanceAlertThreshold
+	Channels []models.BalanceAlertChannel
+}
+
+// EventType implements Event
+func (BalanceAlertTriggered) EventType() string { return TypeBalanceAlert }
+
+// InvoiceIssued is published when the invoice service reported an invoice
+// it issued, to deliver it to the customer
+type InvoiceIssued struct {
+	Notice *models.InvoiceNotice
+}
+
+// EventType implements Event
+func (InvoiceIssued) EventType() string { return TypeInvoiceIssued }
+
+// BucketLowBalance is published when a debit takes a wallet bucket to its
+// low balance threshold. The bucket stays quiet until a transfer tops it up
+// above the threshold.
+type BucketLowBalance struct {
+	Wallet *models.Wallet
+	Bucket *models.WalletBucket
+}
+
+// EventType implements Event
+func (BucketLowBalance) EventType() string { return TypeBucketLowBalance }
+
+// DatabaseModeChanged is published when the primary database stops or
+// resumes accepting writes, as during a failover
+type DatabaseModeChanged struct {
+	ReadOnly  bool
+	Reason    string
+	ChangedAt time.Time
+}
+
+// EventType implements Event
+func (DatabaseModeChanged) EventType() string { return TypeDatabaseModeChanged }
+
+// BalanceChanged is published when a wallet balance changed
+type BalanceChanged struct {
+	WalletID uuid.UUID
+}
+
+// EventType implements Event
+func (BalanceChanged) EventType() string { return TypeBalanceChanged }
+
+// TransactionStatusChanged is published when a transaction is recorded or
+// moves to another status
+type TransactionStatusChanged struct {
+	WalletID      uuid.UUID
+	TransactionID uuid.UUID
+	Status        models.TransactionStatus
+}
+
+// EventType implements Event
+func (TransactionStatusChanged) EventType() string { return TypeTransactionStatusChanged }
@@ -0,0 +1,130 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8" // v8.11.5
+)
+
+// consumeBlock is how long a read waits for new stream entries
+const consumeBlock = 5 * time.Second
+
+// Handler processes one envelope read from the stream. Envelopes whose
+// handler fails stay pending and are handed to the consumer again.
+type Handler func(ctx context.Context, env *Envelope) error
+
+// StreamConsumer reads envelopes published by StreamPublisher through a Redis
+// consumer group, so replicas sharing a group each process a share of events
+type StreamConsumer struct {
+	client    *redis.Client
+	stream    string
+	group     string
+	consumer  string
+	batchSize int64
+	// cursor is the stream ID read after, "0" while replaying this
+	// consumer's pending entries and ">" once only new entries are read
+	cursor     string
+	groupReady bool
+}
+
+// NewStreamConsumer creates a new consumer reading the stream as the named
+// member of a consumer group
+func NewStreamConsumer(client *redis.Client, stream, group, consumer string, batchSize int64) (*StreamConsumer, error) {
+	if client == nil {
+		return nil, errors.New("redis client is required")
+	}
+	if stream == "" || group == "" || consumer == "" {
+		return nil, errors.New("stream, group and consumer names are required")
+	}
+	if batchSize <= 0 {
+		return nil, errors.New("batch size must be positive")
+	}
+
+	return &StreamConsumer{
+		client:    client,
+		stream:    stream,
+		group:     group,
+		consumer:  consumer,
+		batchSize: batchSize,
+		cursor:    "0",
+	}, nil
+}
+
+// Consume reads one batch and passes each envelope to the handler,
+// acknowledging those handled successfully. Entries left pending by a failure
+// or crash are retried once when the consumer starts, before new entries are
+// read. Entries that do not hold an envelope are acknowledged and reported.
+// Returns the number of entries acknowledged.
+func (c *StreamConsumer) Consume(ctx context.Context, handle Handler) (int, error) {
+	if !c.groupReady {
+		err := c.client.XGroupCreateMkStream(ctx, c.stream, c.group, "$").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return 0, fmt.Errorf("failed to create consumer group: %w", err)
+		}
+		c.groupReady = true
+	}
+
+	streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    c.group,
+		Consumer: c.consumer,
+		Streams:  []string{c.stream, c.cursor},
+		Count:    c.batchSize,
+		Block:    consumeBlock,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s stream: %w", c.stream, err)
+	}
+
+	handled := 0
+	var handleErr error
+	for _, stream := range streams {
+		if c.cursor != ">" {
+			// Continue with new entries once the pending entries are replayed
+			if len(stream.Messages) == 0 {
+				c.cursor = ">"
+			} else {
+				c.cursor = stream.Messages[len(stream.Messages)-1].ID
+			}
+		}
+
+		for _, msg := range stream.Messages {
+			env, err := decodeMessage(msg)
+			if err != nil {
+				handleErr = fmt.Errorf("dropped stream entry %s: %w", msg.ID, err)
+			} else if err := handle(ctx, env); err != nil {
+				handleErr = fmt.Errorf("failed to handle stream entry %s: %w", msg.ID, err)
+				continue
+			}
+
+			if err := c.client.XAck(ctx, c.stream, c.group, msg.ID).Err(); err != nil {
+				return handled, fmt.Errorf("failed to acknowledge stream entry %s: %w", msg.ID, err)
+			}
+			handled++
+		}
+	}
+
+	return handled, handleErr
+}
+
+// decodeMessage decodes the envelope stored in a stream entry
+func decodeMessage(msg redis.XMessage) (*Envelope, error) {
+	data, ok := msg.Values["envelope"].(string)
+	if !ok {
+		return nil, errors.New("stream entry has no envelope")
+	}
+
+	env := &Envelope{}
+	if err := json.Unmarshal([]byte(data), env); err != nil {
+		return nil, fmt.Errorf("failed to decode envelope: %w", err)
+	}
+
+	return env, nil
+}
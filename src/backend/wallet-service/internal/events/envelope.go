@@ -0,0 +1,61 @@
+// Package events defines the versioned event envelopes published by the
+// wallet service together with the schema registry used to negotiate,
+// validate and upgrade event payloads
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/execctx"
+)
+
+// contentTypePrefix is the media type prefix advertised for wallet events
+const contentTypePrefix = "application/vnd.otpless.wallet"
+
+// Envelope wraps an event payload with the metadata downstream consumers and
+// the data warehouse need to interpret it
+type Envelope struct {
+	ID            uuid.UUID       `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schema_version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+	// Mode is the execution mode of the operation that produced the event.
+	// Consumers must not notify customers of events whose mode is set, as
+	// only live events omit it.
+	Mode execctx.Mode `json:"mode,omitempty"`
+}
+
+// ExecutionMode returns the execution mode of the producing operation
+func (e *Envelope) ExecutionMode() execctx.Mode {
+	if e.Mode == "" {
+		return execctx.ModeLive
+	}
+	return e.Mode
+}
+
+// ContentType returns the versioned media type of the envelope, e.g.
+// application/vnd.otpless.wallet.transaction.completed+json; version=2
+func (e *Envelope) ContentType() string {
+	return fmt.Sprintf("%s.%s+json; version=%d", contentTypePrefix, e.Type, e.SchemaVersion)
+}
+
+// NewEnvelope creates an envelope for a payload at the given schema version
+func NewEnvelope(eventType string, version int, payload interface{}) (*Envelope, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+
+	return &Envelope{
+		ID:            uuid.New(),
+		Type:          eventType,
+		SchemaVersion: version,
+		OccurredAt:    time.Now().UTC(),
+		Payload:       data,
+	}, nil
+}
@@ -0,0 +1,224 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/eventbus"
+	"internal/execctx"
+	"internal/models"
+)
+
+// ConsentChecker reports whether a customer consented to notifications on a
+// channel
+type ConsentChecker interface {
+	HasConsent(ctx context.Context, customerID uuid.UUID, channel models.ConsentChannel) (bool, error)
+}
+
+// Forwarder is the event bus subscriber that carries domain events out of
+// the service as versioned envelopes. Each event is published at the latest
+// schema version of its type. Notifications are only forwarded to customers
+// that consented to their channel.
+type Forwarder struct {
+	registry  *Registry
+	publisher Publisher
+	consents  ConsentChecker
+}
+
+// NewForwarder creates a forwarder publishing envelopes to publisher
+func NewForwarder(registry *Registry, publisher Publisher, consents ConsentChecker) (*Forwarder, error) {
+	if registry == nil {
+		return nil, errors.New("schema registry is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if consents == nil {
+		return nil, errors.New("consent checker is required")
+	}
+
+	return &Forwarder{
+		registry:  registry,
+		publisher: publisher,
+		consents:  consents,
+	}, nil
+}
+
+// EventTypes lists the domain event types the forwarder publishes, for use
+// as the subscription filter
+func (f *Forwarder) EventTypes() []string {
+	return []string{
+		eventbus.TypeTransactionCompleted,
+		eventbus.TypeLowBalance,
+		eventbus.TypeActivityDigest,
+		eventbus.TypeDunningNotice,
+		eventbus.TypeCustomerSuspended,
+		eventbus.TypeCustomerResumed,
+		eventbus.TypeCreditLimitWarning,
+		eventbus.TypeRateCardApproved,
+		eventbus.TypeWalletCreated,
+		eventbus.TypeBalanceWarning,
+		eventbus.TypeBalanceCritical,
+		eventbus.TypeWalletInactive,
+		eventbus.TypeWalletReactivated,
+		eventbus.TypeDisputeUpdated,
+		eventbus.TypeBalanceAlert,
+		eventbus.TypeInvoiceIssued,
+		eventbus.TypeBucketLowBalance,
+		eventbus.TypeDatabaseModeChanged,
+	}
+}
+
+// notificationTypes are the events the notification pipeline renders into
+// customer emails and SMS alerts, which are dropped outside live mode
+var notificationTypes = map[string]bool{
+	eventbus.TypeLowBalance:         true,
+	eventbus.TypeActivityDigest:     true,
+	eventbus.TypeDunningNotice:      true,
+	eventbus.TypeCreditLimitWarning: true,
+	eventbus.TypeDisputeUpdated:     true,
+	eventbus.TypeBalanceAlert:       true,
+	eventbus.TypeInvoiceIssued:      true,
+	eventbus.TypeBucketLowBalance:   true,
+}
+
+// IsNotification reports whether events of a type are rendered into customer
+// notifications by the notification pipeline
+func IsNotification(eventType string) bool {
+	return notificationTypes[eventType]
+}
+
+// consentChannels are the channels the notification pipeline sends each
+// optional notification on. Dunning notices, dispute updates and issued
+// invoices concern money owed or held and are sent regardless of consent.
+// Balance alerts pick their channels per threshold and are narrowed by
+// consentedChannels.
+var consentChannels = map[string]models.ConsentChannel{
+	eventbus.TypeLowBalance:         models.ConsentSMS,
+	eventbus.TypeBucketLowBalance:   models.ConsentSMS,
+	eventbus.TypeCreditLimitWarning: models.ConsentSMS,
+	eventbus.TypeActivityDigest:     models.ConsentEmail,
+}
+
+// Handle implements eventbus.Handler
+func (f *Forwarder) Handle(ctx context.Context, event eventbus.Event) error {
+	if notificationTypes[event.EventType()] && execctx.Suppress(ctx, execctx.ChannelNotification) {
+		return nil
+	}
+	if consented, err := f.consented(ctx, event); err != nil || !consented {
+		return err
+	}
+	if alert, ok := event.(eventbus.BalanceAlertTriggered); ok {
+		channels, err := f.consentedChannels(ctx, alert)
+		if err != nil || len(channels) == 0 {
+			return err
+		}
+		alert.Channels = channels
+		event = alert
+	}
+
+	env, err := f.Envelope(event)
+	if err != nil {
+		return err
+	}
+	return f.publisher.Publish(ctx, env)
+}
+
+// Envelope builds the envelope of a domain event at the latest schema version
+func (f *Forwarder) Envelope(event eventbus.Event) (*Envelope, error) {
+	version, err := f.registry.Latest(event.EventType())
+	if err != nil {
+		return nil, err
+	}
+
+	switch e := event.(type) {
+	case eventbus.TransactionCompleted:
+		return NewTransactionCompleted(e.Transaction, version)
+	case eventbus.LowBalance:
+		return NewLowBalance(e.Wallet, version)
+	case eventbus.CreditLimitWarning:
+		return NewCreditLimitWarning(e.Wallet, e.Warning, version)
+	case eventbus.ActivityDigest:
+		return NewActivityDigest(e.Subscription, e.PeriodStart, e.PeriodEnd, e.Wallets, version)
+	case eventbus.DunningNotice:
+		return NewDunningNotice(e.Case, e.Notice, e.Final, version)
+	case eventbus.CustomerSuspended:
+		return NewSuspendCustomer(e.Case, e.Reason, e.SuspendedAt, version)
+	case eventbus.CustomerResumed:
+		return NewResumeCustomer(e.Case, e.ResumedAt, version)
+	case eventbus.RateCardApproved:
+		return NewRateCardApproved(e.Change, version)
+	case eventbus.WalletCreated:
+		return NewWalletCreated(e.Wallet, version)
+	case eventbus.BalanceThresholdCrossed:
+		return NewBalanceThreshold(e.Wallet, e.Level, e.Thresholds, version)
+	case eventbus.WalletInactive:
+		return NewWalletInactive(e.Wallet, version)
+	case eventbus.WalletReactivated:
+		return NewWalletReactivated(e.Reactivation, version)
+	case eventbus.DisputeUpdated:
+		return NewDisputeUpdated(e.Dispute, e.CustomerID.String(), version)
+	case eventbus.BalanceAlertTriggered:
+		return NewBalanceAlert(e.Wallet, e.Alert, e.Channels, version)
+	case eventbus.InvoiceIssued:
+		return NewInvoiceIssued(e.Notice, version)
+	case eventbus.BucketLowBalance:
+		return NewBucketLowBalance(e.Wallet, e.Bucket, version)
+	case eventbus.DatabaseModeChanged:
+		return NewDatabaseModeChanged(e.ReadOnly, e.Reason, e.ChangedAt, version)
+	default:
+		return nil, fmt.Errorf("%w: no envelope for %T", ErrUnknownEventType, event)
+	}
+}
+
+// consented reports whether the customer notified by an event consented to
+// its channel, always true for events without one
+func (f *Forwarder) consented(ctx context.Context, event eventbus.Event) (bool, error) {
+	channel, ok := consentChannels[event.EventType()]
+	if !ok {
+		return true, nil
+	}
+
+	var customerID uuid.UUID
+	switch e := event.(type) {
+	case eventbus.LowBalance:
+		customerID = e.Wallet.CustomerID
+	case eventbus.BucketLowBalance:
+		customerID = e.Wallet.CustomerID
+	case eventbus.CreditLimitWarning:
+		customerID = e.Wallet.CustomerID
+	case eventbus.ActivityDigest:
+		customerID = e.Subscription.CustomerID
+	default:
+		return false, fmt.Errorf("%w: no customer for %T", ErrUnknownEventType, event)
+	}
+
+	consented, err := f.consents.HasConsent(ctx, customerID, channel)
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s consent: %w", channel, err)
+	}
+	return consented, nil
+}
+
+// consentedChannels narrows the channels of a balance alert to those the
+// customer consented to. Webhooks need no consent.
+func (f *Forwarder) consentedChannels(ctx context.Context, alert eventbus.BalanceAlertTriggered) ([]models.BalanceAlertChannel, error) {
+	var channels []models.BalanceAlertChannel
+	for _, channel := range alert.Channels {
+		consent, ok := channel.ConsentChannel()
+		if ok {
+			consented, err := f.consents.HasConsent(ctx, alert.Wallet.CustomerID, consent)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check %s consent: %w", consent, err)
+			}
+			if !consented {
+				continue
+			}
+		}
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"internal/eventbus"
+	"internal/models"
+)
+
+// TypeRateCardApproved is published to finance for approved rate card changes
+const TypeRateCardApproved = eventbus.TypeRateCardApproved
+
+// RateCardApprovedV1 is the v1 payload of pricing.rate_card_approved, sent
+// to finance by the notification pipeline before the change takes effect
+type RateCardApprovedV1 struct {
+	ChangeID    string            `json:"change_id"`
+	Rates       models.RateCard   `json:"rates"`
+	Diff        []models.RateDiff `json:"diff"`
+	EffectiveAt string            `json:"effective_at"`
+	Reason      string            `json:"reason"`
+	ProposedBy  string            `json:"proposed_by"`
+	ApprovedBy  string            `json:"approved_by"`
+	ApprovedAt  string            `json:"approved_at"`
+	ReviewNote  string            `json:"review_note,omitempty"`
+}
+
+// NewRateCardApproved builds a pricing.rate_card_approved envelope at the
+// given schema version
+func NewRateCardApproved(change *models.RateCardChange, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeRateCardApproved, version)
+	}
+	if change.ReviewedAt == nil {
+		return nil, fmt.Errorf("rate card change %s was not reviewed", change.ID)
+	}
+
+	diff := change.Diff
+	if diff == nil {
+		diff = []models.RateDiff{}
+	}
+	return NewEnvelope(TypeRateCardApproved, version, RateCardApprovedV1{
+		ChangeID:    change.ID.String(),
+		Rates:       change.Rates,
+		Diff:        diff,
+		EffectiveAt: change.EffectiveAt.UTC().Format(time.RFC3339),
+		Reason:      change.Reason,
+		ProposedBy:  change.ProposedBy,
+		ApprovedBy:  change.ReviewedBy,
+		ApprovedAt:  change.ReviewedAt.UTC().Format(time.RFC3339),
+		ReviewNote:  change.ReviewNote,
+	})
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8" // v8.11.5
+
+	"internal/execctx"
+)
+
+// ErrPublishFailed is returned when the stream could not take an envelope,
+// as opposed to an envelope that failed validation
+var ErrPublishFailed = errors.New("failed to publish")
+
+// StreamPublisher publishes envelopes to a Redis stream consumed by the
+// downstream notification pipeline. Envelopes are validated against the
+// registry before publishing so consumers never see malformed payloads.
+type StreamPublisher struct {
+	client   *redis.Client
+	registry *Registry
+	stream   string
+	maxLen   int64
+}
+
+// NewStreamPublisher creates a new Redis stream publisher. The stream is
+// trimmed to approximately maxLen entries; zero disables trimming.
+func NewStreamPublisher(client *redis.Client, registry *Registry, stream string, maxLen int64) (*StreamPublisher, error) {
+	if client == nil {
+		return nil, errors.New("redis client is required")
+	}
+	if registry == nil {
+		return nil, errors.New("schema registry is required")
+	}
+	if stream == "" {
+		return nil, errors.New("stream name is required")
+	}
+
+	return &StreamPublisher{
+		client:   client,
+		registry: registry,
+		stream:   stream,
+		maxLen:   maxLen,
+	}, nil
+}
+
+// Publish validates the envelope and appends it to the stream, marking it
+// with the execution mode of ctx unless it already carries one
+func (p *StreamPublisher) Publish(ctx context.Context, env *Envelope) error {
+	if err := p.registry.Validate(env); err != nil {
+		return err
+	}
+
+	if env.Mode == "" && !execctx.IsLive(ctx) {
+		marked := *env
+		marked.Mode = execctx.ModeOf(ctx)
+		env = &marked
+	}
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("failed to encode envelope: %w", err)
+	}
+
+	err = p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		MaxLen: p.maxLen,
+		Approx: p.maxLen > 0,
+		Values: map[string]interface{}{
+			"id":           env.ID.String(),
+			"type":         env.Type,
+			"content_type": env.ContentType(),
+			"mode":         string(env.ExecutionMode()),
+			"envelope":     data,
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("%w %s event: %w", ErrPublishFailed, env.Type, err)
+	}
+
+	return nil
+}
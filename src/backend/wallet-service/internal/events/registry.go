@@ -0,0 +1,205 @@
+package events
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed schemas/*/*.json
+var schemaFS embed.FS
+
+// Common registry errors
+var (
+	ErrUnknownEventType   = errors.New("unknown event type")
+	ErrUnsupportedVersion = errors.New("unsupported event schema version")
+	ErrNoUpgradePath      = errors.New("no upgrade path between schema versions")
+)
+
+// Upgrader converts a payload from one schema version to the next
+type Upgrader func(payload json.RawMessage) (json.RawMessage, error)
+
+// Registry holds every known schema version per event type and the upgraders
+// between consecutive versions
+type Registry struct {
+	schemas   map[string]map[int]*Schema
+	upgraders map[string]map[int]Upgrader
+}
+
+// LoadRegistry loads the schemas embedded under schemas/<event type>/v<N>.json
+func LoadRegistry() (*Registry, error) {
+	r := &Registry{
+		schemas:   make(map[string]map[int]*Schema),
+		upgraders: make(map[string]map[int]Upgrader),
+	}
+
+	files, err := fs.Glob(schemaFS, "schemas/*/*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list event schemas: %w", err)
+	}
+
+	for _, file := range files {
+		eventType := path.Base(path.Dir(file))
+		version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path.Base(file), "v"), ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema file name %s: %w", file, err)
+		}
+
+		data, err := schemaFS.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema %s: %w", file, err)
+		}
+
+		schema := &Schema{}
+		if err := json.Unmarshal(data, schema); err != nil {
+			return nil, fmt.Errorf("failed to parse schema %s: %w", file, err)
+		}
+
+		if r.schemas[eventType] == nil {
+			r.schemas[eventType] = make(map[int]*Schema)
+		}
+		r.schemas[eventType][version] = schema
+	}
+
+	return r, nil
+}
+
+// RegisterUpgrader registers the upgrader from version from to from+1
+func (r *Registry) RegisterUpgrader(eventType string, from int, up Upgrader) {
+	if r.upgraders[eventType] == nil {
+		r.upgraders[eventType] = make(map[int]Upgrader)
+	}
+	r.upgraders[eventType][from] = up
+}
+
+// Versions returns the known schema versions of an event type in ascending order
+func (r *Registry) Versions(eventType string) ([]int, error) {
+	schemas, ok := r.schemas[eventType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+
+	versions := make([]int, 0, len(schemas))
+	for v := range schemas {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// Latest returns the newest schema version of an event type
+func (r *Registry) Latest(eventType string) (int, error) {
+	versions, err := r.Versions(eventType)
+	if err != nil {
+		return 0, err
+	}
+	return versions[len(versions)-1], nil
+}
+
+// Negotiate selects the newest schema version that is both known and accepted
+// by the consumer. An empty accepted list selects the latest version.
+func (r *Registry) Negotiate(eventType string, accepted []int) (int, error) {
+	versions, err := r.Versions(eventType)
+	if err != nil {
+		return 0, err
+	}
+	if len(accepted) == 0 {
+		return versions[len(versions)-1], nil
+	}
+
+	acceptable := make(map[int]bool, len(accepted))
+	for _, v := range accepted {
+		acceptable[v] = true
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if acceptable[versions[i]] {
+			return versions[i], nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %s accepts %v, supported %v", ErrUnsupportedVersion, eventType, accepted, versions)
+}
+
+// Validate checks an envelope payload against the schema of its version
+func (r *Registry) Validate(env *Envelope) error {
+	schema, ok := r.schemas[env.Type][env.SchemaVersion]
+	if !ok {
+		return fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, env.Type, env.SchemaVersion)
+	}
+	if err := schema.Validate(env.Payload); err != nil {
+		return fmt.Errorf("invalid %s v%d payload: %w", env.Type, env.SchemaVersion, err)
+	}
+	return nil
+}
+
+// Upgrade converts an envelope to the target schema version by applying the
+// registered upgraders in sequence. The original envelope is not modified.
+func (r *Registry) Upgrade(env *Envelope, target int) (*Envelope, error) {
+	if err := r.Validate(env); err != nil {
+		return nil, err
+	}
+	if env.SchemaVersion > target {
+		return nil, fmt.Errorf("%w: cannot downgrade %s from v%d to v%d", ErrNoUpgradePath, env.Type, env.SchemaVersion, target)
+	}
+
+	upgraded := *env
+	for upgraded.SchemaVersion < target {
+		up, ok := r.upgraders[env.Type][upgraded.SchemaVersion]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s v%d to v%d", ErrNoUpgradePath, env.Type, upgraded.SchemaVersion, upgraded.SchemaVersion+1)
+		}
+
+		payload, err := up(upgraded.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upgrade %s from v%d: %w", env.Type, upgraded.SchemaVersion, err)
+		}
+		upgraded.Payload = payload
+		upgraded.SchemaVersion++
+
+		if err := r.Validate(&upgraded); err != nil {
+			return nil, err
+		}
+	}
+
+	return &upgraded, nil
+}
+
+// CheckCompatibility verifies every event type has contiguous versions and
+// that each version is compatible with its predecessor
+func (r *Registry) CheckCompatibility() []error {
+	types := make([]string, 0, len(r.schemas))
+	for eventType := range r.schemas {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+
+	var problems []error
+	for _, eventType := range types {
+		versions, _ := r.Versions(eventType)
+		for i, v := range versions {
+			if v != i+1 {
+				problems = append(problems, fmt.Errorf("%s: versions must be contiguous from v1, found v%d", eventType, v))
+				break
+			}
+			if i == 0 {
+				continue
+			}
+
+			_, hasUpgrader := r.upgraders[eventType][v-1]
+			if !hasUpgrader {
+				problems = append(problems, fmt.Errorf("%s: no upgrader from v%d to v%d", eventType, v-1, v))
+			}
+			for _, p := range CheckCompatibility(r.schemas[eventType][v-1], r.schemas[eventType][v], hasUpgrader) {
+				problems = append(problems, fmt.Errorf("%s v%d: %w", eventType, v, p))
+			}
+		}
+	}
+
+	return problems
+}
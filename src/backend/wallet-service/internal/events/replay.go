@@ -0,0 +1,63 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"internal/execctx"
+)
+
+// Publisher delivers event envelopes to downstream consumers
+type Publisher interface {
+	Publish(ctx context.Context, env *Envelope) error
+}
+
+// Replayer re-emits historical events upgraded to the newest schema so that
+// consumers rebuilding state only need to understand the latest version
+type Replayer struct {
+	registry  *Registry
+	publisher Publisher
+}
+
+// NewReplayer creates a new event replayer
+func NewReplayer(registry *Registry, publisher Publisher) (*Replayer, error) {
+	if registry == nil {
+		return nil, errors.New("schema registry is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+
+	return &Replayer{
+		registry:  registry,
+		publisher: publisher,
+	}, nil
+}
+
+// Replay upgrades each envelope to the latest version of its type and
+// publishes it, stopping at the first failure. Replayed events are marked
+// as such so they never notify customers again.
+func (r *Replayer) Replay(ctx context.Context, envelopes []*Envelope) (int, error) {
+	if execctx.IsLive(ctx) {
+		ctx = execctx.WithMode(ctx, execctx.ModeReplay)
+	}
+
+	for i, env := range envelopes {
+		latest, err := r.registry.Latest(env.Type)
+		if err != nil {
+			return i, err
+		}
+
+		upgraded, err := r.registry.Upgrade(env, latest)
+		if err != nil {
+			return i, fmt.Errorf("failed to upgrade event %s: %w", env.ID, err)
+		}
+
+		if err := r.publisher.Publish(ctx, upgraded); err != nil {
+			return i, fmt.Errorf("failed to publish event %s: %w", env.ID, err)
+		}
+	}
+
+	return len(envelopes), nil
+}
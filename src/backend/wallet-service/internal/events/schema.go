@@ -0,0 +1,99 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Schema is the subset of JSON Schema used to describe event payloads
+type Schema struct {
+	Required   []string            `json:"required"`
+	Properties map[string]Property `json:"properties"`
+}
+
+// Property describes a single payload field
+type Property struct {
+	Type string `json:"type"`
+}
+
+// Validate checks that a payload contains every required field with the
+// declared JSON type
+func (s *Schema) Validate(payload json.RawMessage) error {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return fmt.Errorf("payload is not a JSON object: %w", err)
+	}
+
+	for _, name := range s.Required {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("missing required field %q", name)
+		}
+	}
+
+	for name, value := range fields {
+		prop, ok := s.Properties[name]
+		if !ok || value == nil {
+			continue
+		}
+		if got := jsonType(value); got != prop.Type {
+			return fmt.Errorf("field %q has type %s, expected %s", name, got, prop.Type)
+		}
+	}
+
+	return nil
+}
+
+// CheckCompatibility reports the changes in next that would break consumers
+// of prev. Fields may be added but never removed or retyped, and new required
+// fields are only allowed when an upgrader can populate them for old events.
+func CheckCompatibility(prev, next *Schema, hasUpgrader bool) []error {
+	var problems []error
+
+	names := make([]string, 0, len(prev.Properties))
+	for name := range prev.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		nextProp, ok := next.Properties[name]
+		if !ok {
+			problems = append(problems, fmt.Errorf("field %q removed", name))
+			continue
+		}
+		if nextProp.Type != prev.Properties[name].Type {
+			problems = append(problems, fmt.Errorf("field %q changed type from %s to %s", name, prev.Properties[name].Type, nextProp.Type))
+		}
+	}
+
+	prevRequired := make(map[string]bool, len(prev.Required))
+	for _, name := range prev.Required {
+		prevRequired[name] = true
+	}
+	for _, name := range next.Required {
+		if !prevRequired[name] && !hasUpgrader {
+			problems = append(problems, fmt.Errorf("field %q became required without an upgrader", name))
+		}
+	}
+
+	return problems
+}
+
+// jsonType returns the JSON Schema type name of a decoded JSON value
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "null"
+	}
+}
@@ -0,0 +1,43 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"internal/eventbus"
+)
+
+// TypeDatabaseModeChanged is published to operators when the primary
+// database stops or resumes accepting writes
+const TypeDatabaseModeChanged = eventbus.TypeDatabaseModeChanged
+
+// Database modes of service.database_mode_changed
+const (
+	DatabaseModeReadWrite = "read-write"
+	DatabaseModeReadOnly  = "read-only"
+)
+
+// DatabaseModeChangedV1 is the v1 payload of service.database_mode_changed
+type DatabaseModeChangedV1 struct {
+	Mode      string `json:"mode"`
+	Reason    string `json:"reason"`
+	ChangedAt string `json:"changed_at"`
+}
+
+// NewDatabaseModeChanged builds a service.database_mode_changed envelope at
+// the given schema version
+func NewDatabaseModeChanged(readOnly bool, reason string, changedAt time.Time, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeDatabaseModeChanged, version)
+	}
+
+	mode := DatabaseModeReadWrite
+	if readOnly {
+		mode = DatabaseModeReadOnly
+	}
+	return NewEnvelope(TypeDatabaseModeChanged, version, DatabaseModeChangedV1{
+		Mode:      mode,
+		Reason:    reason,
+		ChangedAt: changedAt.UTC().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,550 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"internal/eventbus"
+	"internal/models"
+)
+
+// Event types published by the wallet service, shared with the domain events
+// they are built from
+const (
+	TypeTransactionCompleted = eventbus.TypeTransactionCompleted
+	TypeLowBalance           = eventbus.TypeLowBalance
+	TypeActivityDigest       = eventbus.TypeActivityDigest
+	TypeDunningNotice        = eventbus.TypeDunningNotice
+	TypeSuspendCustomer      = eventbus.TypeCustomerSuspended
+	TypeResumeCustomer       = eventbus.TypeCustomerResumed
+	TypeCreditLimitWarning   = eventbus.TypeCreditLimitWarning
+	TypeWalletCreated        = eventbus.TypeWalletCreated
+	TypeBalanceWarning       = eventbus.TypeBalanceWarning
+	TypeBalanceCritical      = eventbus.TypeBalanceCritical
+	TypeWalletInactive       = eventbus.TypeWalletInactive
+	TypeWalletReactivated    = eventbus.TypeWalletReactivated
+	TypeDisputeUpdated       = eventbus.TypeDisputeUpdated
+	TypeBalanceAlert         = eventbus.TypeBalanceAlert
+	TypeInvoiceIssued        = eventbus.TypeInvoiceIssued
+	TypeBucketLowBalance     = eventbus.TypeBucketLowBalance
+)
+
+// TransactionCompletedV1 is the v1 payload of transaction.completed
+type TransactionCompletedV1 struct {
+	TransactionID string  `json:"transaction_id"`
+	WalletID      string  `json:"wallet_id"`
+	Type          string  `json:"type"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+}
+
+// TransactionCompletedV2 is the v2 payload of transaction.completed which adds
+// the transaction status and reference ID
+type TransactionCompletedV2 struct {
+	TransactionCompletedV1
+	Status      string `json:"status"`
+	ReferenceID string `json:"reference_id,omitempty"`
+}
+
+// TransactionCompletedV3 is the v3 payload of transaction.completed which adds
+// the caller-defined transaction metadata
+type TransactionCompletedV3 struct {
+	TransactionCompletedV2
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// LowBalanceV1 is the v1 payload of wallet.low_balance
+type LowBalanceV1 struct {
+	WalletID  string  `json:"wallet_id"`
+	Balance   float64 `json:"balance"`
+	Threshold float64 `json:"threshold"`
+	Currency  string  `json:"currency"`
+}
+
+// CreditLimitWarningV1 is the v1 payload of wallet.credit_limit_warning. Level
+// is the warning level crossed, as a fraction of the credit limit.
+type CreditLimitWarningV1 struct {
+	WalletID         string  `json:"wallet_id"`
+	CustomerID       string  `json:"customer_id"`
+	Level            float64 `json:"level"`
+	Utilization      float64 `json:"utilization"`
+	CreditLimit      float64 `json:"credit_limit"`
+	Balance          float64 `json:"balance"`
+	AvailableBalance float64 `json:"available_balance"`
+	Currency         string  `json:"currency"`
+}
+
+// BalanceThresholdV1 is the v1 payload of wallet.balance_warning and
+// wallet.balance_critical. Threshold is the balance the wallet fell below and
+// ResetAbove the balance it must rise above before the level alerts again.
+type BalanceThresholdV1 struct {
+	WalletID         string  `json:"wallet_id"`
+	CustomerID       string  `json:"customer_id"`
+	Level            string  `json:"level"`
+	Balance          float64 `json:"balance"`
+	Threshold        float64 `json:"threshold"`
+	ResetAbove       float64 `json:"reset_above"`
+	ReferenceBalance float64 `json:"reference_balance"`
+	Currency         string  `json:"currency"`
+}
+
+// BalanceAlertV1 is the v1 payload of wallet.balance_alert, sent on each of
+// Channels by the notification pipeline. Threshold is the balance the wallet
+// fell below; Value is the configured threshold, a balance for ABSOLUTE
+// alerts or a percentage of average monthly usage for USAGE_PERCENT ones.
+type BalanceAlertV1 struct {
+	WalletID   string   `json:"wallet_id"`
+	CustomerID string   `json:"customer_id"`
+	AlertID    string   `json:"alert_id"`
+	Basis      string   `json:"basis"`
+	Value      float64  `json:"value"`
+	Threshold  float64  `json:"threshold"`
+	Balance    float64  `json:"balance"`
+	Currency   string   `json:"currency"`
+	Channels   []string `json:"channels"`
+}
+
+// BucketLowBalanceV1 is the v1 payload of wallet.bucket_low_balance. Balance
+// and Threshold are the bucket's, not the wallet's.
+type BucketLowBalanceV1 struct {
+	WalletID   string  `json:"wallet_id"`
+	CustomerID string  `json:"customer_id"`
+	Bucket     string  `json:"bucket"`
+	Balance    float64 `json:"balance"`
+	Threshold  float64 `json:"threshold"`
+	Currency   string  `json:"currency"`
+}
+
+// InvoiceIssuedV1 is the v1 payload of invoice.issued. TotalAmount is the
+// exact decimal amount and DueDate a calendar date; URL is empty when the
+// invoice service gave none.
+type InvoiceIssuedV1 struct {
+	InvoiceID     string `json:"invoice_id"`
+	CustomerID    string `json:"customer_id"`
+	InvoiceNumber string `json:"invoice_number"`
+	Currency      string `json:"currency"`
+	TotalAmount   string `json:"total_amount"`
+	DueDate       string `json:"due_date"`
+	URL           string `json:"url"`
+}
+
+// WalletCreatedV1 is the v1 payload of wallet.created
+type WalletCreatedV1 struct {
+	WalletID   string `json:"wallet_id"`
+	CustomerID string `json:"customer_id"`
+	Currency   string `json:"currency"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// WalletInactiveV1 is the v1 payload of wallet.inactive, feeding dormancy,
+// marketing and escheatment workflows
+type WalletInactiveV1 struct {
+	WalletID       string  `json:"wallet_id"`
+	CustomerID     string  `json:"customer_id"`
+	Balance        float64 `json:"balance"`
+	Currency       string  `json:"currency"`
+	LastActivityAt string  `json:"last_activity_at"`
+	InactiveSince  string  `json:"inactive_since"`
+}
+
+// WalletReactivatedV1 is the v1 payload of wallet.reactivated. ReactivatedAt
+// is the first activity after InactiveSince.
+type WalletReactivatedV1 struct {
+	WalletID      string  `json:"wallet_id"`
+	CustomerID    string  `json:"customer_id"`
+	Balance       float64 `json:"balance"`
+	Currency      string  `json:"currency"`
+	InactiveSince string  `json:"inactive_since"`
+	ReactivatedAt string  `json:"reactivated_at"`
+}
+
+// DisputeUpdatedV1 is the v1 payload of wallet.dispute_updated, rendered
+// into a notice of the dispute's progress by the notification pipeline.
+// Status is the state the dispute moved to.
+type DisputeUpdatedV1 struct {
+	DisputeID     string  `json:"dispute_id"`
+	WalletID      string  `json:"wallet_id"`
+	CustomerID    string  `json:"customer_id"`
+	TransactionID string  `json:"transaction_id"`
+	Status        string  `json:"status"`
+	ReasonCode    string  `json:"reason_code"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	UpdatedAt     string  `json:"updated_at"`
+}
+
+// ActivityDigestV1 is the v1 payload of wallet.activity_digest, rendered into
+// a digest email by the notification pipeline. The period bounds are UTC
+// instants of midnight in Timezone, which dates should be rendered in.
+type ActivityDigestV1 struct {
+	CustomerID  string                   `json:"customer_id"`
+	Frequency   string                   `json:"frequency"`
+	PeriodStart string                   `json:"period_start"`
+	PeriodEnd   string                   `json:"period_end"`
+	Timezone    string                   `json:"timezone,omitempty"`
+	Wallets     []*models.WalletActivity `json:"wallets"`
+}
+
+// DunningNoticeV1 is the v1 payload of wallet.dunning_notice, rendered into an
+// escalating reminder to top up by the notification pipeline
+type DunningNoticeV1 struct {
+	WalletID    string  `json:"wallet_id"`
+	CustomerID  string  `json:"customer_id"`
+	Notice      int     `json:"notice"`
+	Final       bool    `json:"final"`
+	Balance     float64 `json:"balance"`
+	Currency    string  `json:"currency"`
+	StartedAt   string  `json:"started_at"`
+	GraceEndsAt string  `json:"grace_ends_at"`
+}
+
+// SuspendCustomerV1 is the v1 payload of customer.suspend, asking downstream
+// services to suspend the customer
+type SuspendCustomerV1 struct {
+	CustomerID  string `json:"customer_id"`
+	WalletID    string `json:"wallet_id"`
+	Reason      string `json:"reason"`
+	SuspendedAt string `json:"suspended_at"`
+}
+
+// ResumeCustomerV1 is the v1 payload of customer.resume, lifting a suspension
+type ResumeCustomerV1 struct {
+	CustomerID string `json:"customer_id"`
+	WalletID   string `json:"wallet_id"`
+	ResumedAt  string `json:"resumed_at"`
+}
+
+// DefaultRegistry loads the embedded wallet event schemas and registers the
+// upgraders between their versions
+func DefaultRegistry() (*Registry, error) {
+	r, err := LoadRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	r.RegisterUpgrader(TypeTransactionCompleted, 1, upgradeTransactionCompletedV1)
+	r.RegisterUpgrader(TypeTransactionCompleted, 2, upgradeTransactionCompletedV2)
+
+	return r, nil
+}
+
+// NewTransactionCompleted builds a transaction.completed envelope at the given
+// schema version, which should come from Registry.Negotiate
+func NewTransactionCompleted(tx *models.Transaction, version int) (*Envelope, error) {
+	v1 := TransactionCompletedV1{
+		TransactionID: tx.ID.String(),
+		WalletID:      tx.WalletID.String(),
+		Type:          tx.Type.String(),
+		Amount:        tx.Amount,
+		Currency:      tx.Currency,
+	}
+
+	v2 := TransactionCompletedV2{
+		TransactionCompletedV1: v1,
+		Status:                 tx.Status.String(),
+		ReferenceID:            tx.ReferenceID,
+	}
+
+	switch version {
+	case 1:
+		return NewEnvelope(TypeTransactionCompleted, version, v1)
+	case 2:
+		return NewEnvelope(TypeTransactionCompleted, version, v2)
+	case 3:
+		return NewEnvelope(TypeTransactionCompleted, version, TransactionCompletedV3{
+			TransactionCompletedV2: v2,
+			Metadata:               tx.Metadata,
+		})
+	default:
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeTransactionCompleted, version)
+	}
+}
+
+// NewLowBalance builds a wallet.low_balance envelope at the given schema version
+func NewLowBalance(wallet *models.Wallet, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeLowBalance, version)
+	}
+
+	return NewEnvelope(TypeLowBalance, version, LowBalanceV1{
+		WalletID:  wallet.ID.String(),
+		Balance:   wallet.Balance,
+		Threshold: wallet.LowBalanceThreshold,
+		Currency:  wallet.Currency,
+	})
+}
+
+// NewCreditLimitWarning builds a wallet.credit_limit_warning envelope at the
+// given schema version
+func NewCreditLimitWarning(wallet *models.Wallet, warning *models.CreditLimitWarning, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeCreditLimitWarning, version)
+	}
+
+	return NewEnvelope(TypeCreditLimitWarning, version, CreditLimitWarningV1{
+		WalletID:         wallet.ID.String(),
+		CustomerID:       wallet.CustomerID.String(),
+		Level:            warning.Level,
+		Utilization:      warning.Utilization,
+		CreditLimit:      warning.CreditLimit,
+		Balance:          wallet.Balance,
+		AvailableBalance: warning.AvailableBalance,
+		Currency:         wallet.Currency,
+	})
+}
+
+// NewBalanceThreshold builds a wallet.balance_warning or
+// wallet.balance_critical envelope, by level, at the given schema version
+func NewBalanceThreshold(wallet *models.Wallet, level models.BalanceAlertLevel, thresholds *models.BalanceThresholds, version int) (*Envelope, error) {
+	eventType := TypeBalanceWarning
+	if level == models.BalanceAlertCritical {
+		eventType = TypeBalanceCritical
+	}
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, eventType, version)
+	}
+
+	return NewEnvelope(eventType, version, BalanceThresholdV1{
+		WalletID:         wallet.ID.String(),
+		CustomerID:       wallet.CustomerID.String(),
+		Level:            string(level),
+		Balance:          wallet.Balance,
+		Threshold:        thresholds.TriggerAmount(level),
+		ResetAbove:       thresholds.ResetAmount(level),
+		ReferenceBalance: thresholds.ReferenceBalance,
+		Currency:         wallet.Currency,
+	})
+}
+
+// NewBalanceAlert builds a wallet.balance_alert envelope at the given schema
+// version
+func NewBalanceAlert(wallet *models.Wallet, alert *models.BalanceAlertThreshold, channels []models.BalanceAlertChannel, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeBalanceAlert, version)
+	}
+
+	value, _ := alert.Value.Float64()
+	threshold, _ := alert.TriggerAmount.Float64()
+	names := make([]string, len(channels))
+	for i, channel := range channels {
+		names[i] = string(channel)
+	}
+	return NewEnvelope(TypeBalanceAlert, version, BalanceAlertV1{
+		WalletID:   wallet.ID.String(),
+		CustomerID: wallet.CustomerID.String(),
+		AlertID:    alert.ID.String(),
+		Basis:      string(alert.Basis),
+		Value:      value,
+		Threshold:  threshold,
+		Balance:    wallet.Balance,
+		Currency:   wallet.Currency,
+		Channels:   names,
+	})
+}
+
+// NewBucketLowBalance builds a wallet.bucket_low_balance envelope at the
+// given schema version
+func NewBucketLowBalance(wallet *models.Wallet, bucket *models.WalletBucket, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeBucketLowBalance, version)
+	}
+
+	balance, _ := bucket.Balance.Float64()
+	threshold, _ := bucket.LowBalanceThreshold.Float64()
+	return NewEnvelope(TypeBucketLowBalance, version, BucketLowBalanceV1{
+		WalletID:   wallet.ID.String(),
+		CustomerID: wallet.CustomerID.String(),
+		Bucket:     bucket.Name,
+		Balance:    balance,
+		Threshold:  threshold,
+		Currency:   wallet.Currency,
+	})
+}
+
+// NewInvoiceIssued builds an invoice.issued envelope at the given schema
+// version
+func NewInvoiceIssued(notice *models.InvoiceNotice, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeInvoiceIssued, version)
+	}
+
+	return NewEnvelope(TypeInvoiceIssued, version, InvoiceIssuedV1{
+		InvoiceID:     notice.InvoiceID.String(),
+		CustomerID:    notice.CustomerID.String(),
+		InvoiceNumber: notice.InvoiceNumber,
+		Currency:      notice.Currency,
+		TotalAmount:   notice.TotalAmount.String(),
+		DueDate:       notice.DueDate.UTC().Format("2006-01-02"),
+		URL:           notice.URL,
+	})
+}
+
+// NewWalletCreated builds a wallet.created envelope at the given schema version
+func NewWalletCreated(wallet *models.Wallet, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeWalletCreated, version)
+	}
+
+	return NewEnvelope(TypeWalletCreated, version, WalletCreatedV1{
+		WalletID:   wallet.ID.String(),
+		CustomerID: wallet.CustomerID.String(),
+		Currency:   wallet.Currency,
+		CreatedAt:  wallet.CreatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// NewWalletInactive builds a wallet.inactive envelope at the given schema
+// version
+func NewWalletInactive(wallet *models.Wallet, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeWalletInactive, version)
+	}
+
+	payload := WalletInactiveV1{
+		WalletID:   wallet.ID.String(),
+		CustomerID: wallet.CustomerID.String(),
+		Balance:    wallet.Balance,
+		Currency:   wallet.Currency,
+	}
+	if wallet.LastActivityAt != nil {
+		payload.LastActivityAt = wallet.LastActivityAt.UTC().Format(time.RFC3339)
+	}
+	if wallet.InactiveSince != nil {
+		payload.InactiveSince = wallet.InactiveSince.UTC().Format(time.RFC3339)
+	}
+	return NewEnvelope(TypeWalletInactive, version, payload)
+}
+
+// NewWalletReactivated builds a wallet.reactivated envelope at the given
+// schema version
+func NewWalletReactivated(reactivation *models.WalletReactivation, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeWalletReactivated, version)
+	}
+
+	wallet := reactivation.Wallet
+	payload := WalletReactivatedV1{
+		WalletID:      wallet.ID.String(),
+		CustomerID:    wallet.CustomerID.String(),
+		Balance:       wallet.Balance,
+		Currency:      wallet.Currency,
+		InactiveSince: reactivation.InactiveSince.UTC().Format(time.RFC3339),
+	}
+	if wallet.LastActivityAt != nil {
+		payload.ReactivatedAt = wallet.LastActivityAt.UTC().Format(time.RFC3339)
+	}
+	return NewEnvelope(TypeWalletReactivated, version, payload)
+}
+
+// NewDisputeUpdated builds a wallet.dispute_updated envelope at the given
+// schema version
+func NewDisputeUpdated(dispute *models.Dispute, customerID string, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeDisputeUpdated, version)
+	}
+
+	amount, _ := dispute.Amount.Float64()
+	return NewEnvelope(TypeDisputeUpdated, version, DisputeUpdatedV1{
+		DisputeID:     dispute.ID.String(),
+		WalletID:      dispute.WalletID.String(),
+		CustomerID:    customerID,
+		TransactionID: dispute.TransactionID.String(),
+		Status:        string(dispute.Status),
+		ReasonCode:    string(dispute.ReasonCode),
+		Amount:        amount,
+		Currency:      dispute.Currency,
+		UpdatedAt:     dispute.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// NewActivityDigest builds a wallet.activity_digest envelope at the given schema version
+func NewActivityDigest(sub *models.DigestSubscription, periodStart, periodEnd time.Time, wallets []*models.WalletActivity, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeActivityDigest, version)
+	}
+	if wallets == nil {
+		wallets = []*models.WalletActivity{}
+	}
+
+	return NewEnvelope(TypeActivityDigest, version, ActivityDigestV1{
+		CustomerID:  sub.CustomerID.String(),
+		Frequency:   string(sub.Frequency),
+		PeriodStart: periodStart.UTC().Format(time.RFC3339),
+		PeriodEnd:   periodEnd.UTC().Format(time.RFC3339),
+		Timezone:    sub.Timezone,
+		Wallets:     wallets,
+	})
+}
+
+// NewDunningNotice builds a wallet.dunning_notice envelope at the given schema
+// version for the case's notice-th notice
+func NewDunningNotice(dc *models.DunningCase, notice int, final bool, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeDunningNotice, version)
+	}
+
+	balance, _ := dc.Balance.Float64()
+	return NewEnvelope(TypeDunningNotice, version, DunningNoticeV1{
+		WalletID:    dc.WalletID.String(),
+		CustomerID:  dc.CustomerID.String(),
+		Notice:      notice,
+		Final:       final,
+		Balance:     balance,
+		Currency:    dc.Currency,
+		StartedAt:   dc.StartedAt.UTC().Format(time.RFC3339),
+		GraceEndsAt: dc.GraceEndsAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// NewSuspendCustomer builds a customer.suspend envelope at the given schema version
+func NewSuspendCustomer(dc *models.DunningCase, reason string, suspendedAt time.Time, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeSuspendCustomer, version)
+	}
+
+	return NewEnvelope(TypeSuspendCustomer, version, SuspendCustomerV1{
+		CustomerID:  dc.CustomerID.String(),
+		WalletID:    dc.WalletID.String(),
+		Reason:      reason,
+		SuspendedAt: suspendedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// NewResumeCustomer builds a customer.resume envelope at the given schema version
+func NewResumeCustomer(dc *models.DunningCase, resumedAt time.Time, version int) (*Envelope, error) {
+	if version != 1 {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, TypeResumeCustomer, version)
+	}
+
+	return NewEnvelope(TypeResumeCustomer, version, ResumeCustomerV1{
+		CustomerID: dc.CustomerID.String(),
+		WalletID:   dc.WalletID.String(),
+		ResumedAt:  resumedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// upgradeTransactionCompletedV1 upgrades v1 payloads to v2. Only completed
+// transactions were ever published as v1 so the status is implied.
+func upgradeTransactionCompletedV1(payload json.RawMessage) (json.RawMessage, error) {
+	var v1 TransactionCompletedV1
+	if err := json.Unmarshal(payload, &v1); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(TransactionCompletedV2{
+		TransactionCompletedV1: v1,
+		Status:                 models.TransactionStatusCompleted.String(),
+	})
+}
+
+// upgradeTransactionCompletedV2 upgrades v2 payloads to v3. Transactions
+// published as v2 predate metadata, so they have none.
+func upgradeTransactionCompletedV2(payload json.RawMessage) (json.RawMessage, error) {
+	var v2 TransactionCompletedV2
+	if err := json.Unmarshal(payload, &v2); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(TransactionCompletedV3{TransactionCompletedV2: v2})
+}
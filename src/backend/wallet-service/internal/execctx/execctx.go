@@ -0,0 +1,74 @@
+// Package execctx carries the execution mode of an operation in its context.
+// Modules with customer-facing side effects, such as webhook delivery and
+// notification events, consult it so sandbox requests, event replays and
+// backfills never reach real customers.
+package execctx
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+)
+
+// Mode is the execution mode of an operation
+type Mode string
+
+const (
+	// ModeLive is normal operation with every side effect enabled
+	ModeLive Mode = "live"
+	// ModeSandbox is a request made with sandbox credentials
+	ModeSandbox Mode = "sandbox"
+	// ModeReplay re-emits historical events
+	ModeReplay Mode = "replay"
+	// ModeBackfill recomputes historical data
+	ModeBackfill Mode = "backfill"
+)
+
+// Outbound channels whose sends are suppressed outside live mode
+const (
+	ChannelWebhook      = "webhook"
+	ChannelNotification = "notification"
+)
+
+// suppressedSends counts customer-facing sends skipped outside live mode
+var suppressedSends = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_suppressed_sends_total",
+		Help: "Customer-facing sends suppressed because the operation was not live",
+	},
+	[]string{"channel", "mode"},
+)
+
+// modeKey is the context key of the execution mode
+type modeKey struct{}
+
+// WithMode returns a context running in the given mode
+func WithMode(ctx context.Context, mode Mode) context.Context {
+	return context.WithValue(ctx, modeKey{}, mode)
+}
+
+// ModeOf returns the execution mode of a context, ModeLive when unset
+func ModeOf(ctx context.Context) Mode {
+	if mode, ok := ctx.Value(modeKey{}).(Mode); ok && mode != "" {
+		return mode
+	}
+	return ModeLive
+}
+
+// IsLive reports whether customer-facing side effects are enabled
+func IsLive(ctx context.Context) bool {
+	return ModeOf(ctx) == ModeLive
+}
+
+// Suppress reports whether a send on channel must be skipped, counting it
+// as suppressed when it must
+func Suppress(ctx context.Context, channel string) bool {
+	mode := ModeOf(ctx)
+	if mode == ModeLive {
+		return false
+	}
+
+	suppressedSends.WithLabelValues(channel, string(mode)).Inc()
+	return true
+}
@@ -0,0 +1,150 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+	"internal/service"
+)
+
+// DataLoader constants
+const (
+	// loaderWait is how long a batch collects keys before it is fetched,
+	// long enough for sibling field resolvers running concurrently to join
+	loaderWait = 2 * time.Millisecond
+	// loaderMaxBatch dispatches a batch early once it holds this many keys
+	loaderMaxBatch = 100
+)
+
+// Loader batches the loads of keys requested concurrently into one fetch
+// and caches the results for the lifetime of the loader, which is a single
+// GraphQL request. This turns the per-item queries of list fields, such as
+// the transactions of every wallet, into one query.
+type Loader[K comparable, V any] struct {
+	wait  time.Duration
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending *loaderBatch[K, V]
+	cache   map[K]*loaderBatch[K, V]
+}
+
+// loaderBatch is a set of keys fetched together
+type loaderBatch[K comparable, V any] struct {
+	keys    []K
+	once    sync.Once
+	done    chan struct{}
+	results map[K]V
+	err     error
+}
+
+// NewLoader creates a loader collecting keys for wait before fetching them
+// with fetch. Keys missing from the fetched map load as the zero value.
+func NewLoader[K comparable, V any](wait time.Duration, fetch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		wait:  wait,
+		fetch: fetch,
+		cache: make(map[K]*loaderBatch[K, V]),
+	}
+}
+
+// Load returns the value of key, waiting for the batch it joins
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	b, ok := l.cache[key]
+	if !ok {
+		b = l.pending
+		if b == nil {
+			b = &loaderBatch[K, V]{done: make(chan struct{})}
+			l.pending = b
+			time.AfterFunc(l.wait, func() { l.dispatch(ctx, b) })
+		}
+		b.keys = append(b.keys, key)
+		l.cache[key] = b
+		if len(b.keys) >= loaderMaxBatch {
+			l.pending = nil
+			go l.dispatch(ctx, b)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return b.results[key], b.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// dispatch closes a batch to new keys and fetches it, once
+func (l *Loader[K, V]) dispatch(ctx context.Context, b *loaderBatch[K, V]) {
+	b.once.Do(func() {
+		l.mu.Lock()
+		if l.pending == b {
+			l.pending = nil
+		}
+		keys := b.keys
+		l.mu.Unlock()
+
+		b.results, b.err = l.fetch(ctx, keys)
+		if b.err != nil {
+			// Failed keys are retried by later loads instead of cached
+			l.mu.Lock()
+			for _, key := range keys {
+				delete(l.cache, key)
+			}
+			l.mu.Unlock()
+		}
+		close(b.done)
+	})
+}
+
+// transactionsKey identifies the recent transactions of a wallet
+type transactionsKey struct {
+	WalletID uuid.UUID
+	Limit    int
+}
+
+// loaders holds the request-scoped loaders of the resolvers
+type loaders struct {
+	recentTransactions *Loader[transactionsKey, []*models.Transaction]
+}
+
+// loadersKey is the context key of the request's loaders
+type loadersKey struct{}
+
+// newLoaders creates the loaders of one request
+func newLoaders(wallets service.WalletService) *loaders {
+	return &loaders{
+		recentTransactions: NewLoader(loaderWait, func(ctx context.Context, keys []transactionsKey) (map[transactionsKey][]*models.Transaction, error) {
+			// Wallets asking for the same number of transactions share a query
+			byLimit := make(map[int][]uuid.UUID)
+			for _, key := range keys {
+				byLimit[key.Limit] = append(byLimit[key.Limit], key.WalletID)
+			}
+
+			results := make(map[transactionsKey][]*models.Transaction, len(keys))
+			for limit, walletIDs := range byLimit {
+				byWallet, err := wallets.GetRecentTransactions(ctx, walletIDs, limit)
+				if err != nil {
+					return nil, err
+				}
+				for _, walletID := range walletIDs {
+					results[transactionsKey{WalletID: walletID, Limit: limit}] = byWallet[walletID]
+				}
+			}
+			return results, nil
+		}),
+	}
+}
+
+// loadersFor returns the loaders of the request in ctx
+func loadersFor(ctx context.Context) *loaders {
+	l, _ := ctx.Value(loadersKey{}).(*loaders)
+	return l
+}
@@ -0,0 +1,134 @@
+// Package graphql serves the wallet service's GraphQL API, which lets the
+// dashboard fetch wallets, their recent transactions and invoices in one
+// round trip. Resolvers delegate to the same services as the REST API.
+//
+// The executable schema in the generated package is produced by gqlgen from
+// schema.graphqls; regenerate it after editing the schema.
+package graphql
+
+//go:generate go run github.com/99designs/gqlgen generate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"         // v0.17.45
+	"github.com/99designs/gqlgen/graphql/handler" // v0.17.45
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/google/uuid"                  // v1.3.0
+	"github.com/vektah/gqlparser/v2/gqlerror" // v2.5.11
+
+	"internal/apierror"
+	"internal/graphql/generated"
+	"internal/invoices"
+	"internal/service"
+)
+
+// maxListLimit bounds the limit argument of list fields
+const maxListLimit = 100
+
+// Resolver is the root resolver holding the services queries delegate to
+type Resolver struct {
+	wallets service.WalletService
+	// invoices is nil when no invoice service is configured
+	invoices *invoices.Client
+}
+
+// Principal is the authenticated caller of a GraphQL request
+type Principal struct {
+	CustomerID uuid.UUID
+	// Token is the caller's bearer token, forwarded to the invoice service
+	Token string
+	// Language selects the language of error messages
+	Language string
+}
+
+// principalKey is the context key of the request principal
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the authenticated caller
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// principalFrom returns the authenticated caller of the request in ctx
+func principalFrom(ctx context.Context) (*Principal, error) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	if !ok || principal.CustomerID == uuid.Nil {
+		return nil, apierror.New(apierror.CodeUnauthorized)
+	}
+	return principal, nil
+}
+
+// Options tunes the GraphQL handler
+type Options struct {
+	// ComplexityLimit rejects queries whose computed complexity exceeds it
+	ComplexityLimit int
+}
+
+// NewHandler creates the GraphQL HTTP handler. Requests must carry a
+// Principal in their context, set by the authentication middleware.
+// invoiceClient may be nil, in which case invoice queries fail as unavailable.
+func NewHandler(wallets service.WalletService, invoiceClient *invoices.Client, opts Options) (http.Handler, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if opts.ComplexityLimit <= 0 {
+		return nil, errors.New("complexity limit must be positive")
+	}
+
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers: &Resolver{
+			wallets:  wallets,
+			invoices: invoiceClient,
+		},
+	}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(opts.ComplexityLimit))
+	srv.SetErrorPresenter(presentError)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), loadersKey{}, newLoaders(wallets))
+		srv.ServeHTTP(w, r.WithContext(ctx))
+	}), nil
+}
+
+// presentError reports resolver errors with the stable code and localized
+// message of the error catalogue. Parse and validation errors are reported
+// as gqlgen produces them.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	if gqlErr.Err == nil {
+		return gqlErr
+	}
+
+	lang := apierror.DefaultLanguage
+	if principal, perr := principalFrom(ctx); perr == nil && principal.Language != "" {
+		lang = principal.Language
+	}
+
+	apiErr := apierror.FromError(gqlErr.Err)
+	gqlErr.Message = apiErr.Message(lang)
+	gqlErr.Extensions = map[string]interface{}{
+		"code": apiErr.Code,
+	}
+	if apiErr.Details != "" {
+		gqlErr.Extensions["details"] = apiErr.Details
+	}
+	return gqlErr
+}
+
+// listLimit validates the limit argument of a list field
+func listLimit(limit *int) (int, error) {
+	if limit == nil {
+		return 10, nil
+	}
+	if *limit < 1 || *limit > maxListLimit {
+		return 0, apierror.New(apierror.CodeInvalidRequest).WithDetails("limit must be between 1 and %d", maxListLimit)
+	}
+	return *limit, nil
+}
@@ -0,0 +1,53 @@
+// Package scalars implements the custom GraphQL scalars of the wallet
+// service's schema
+package scalars
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql" // v0.17.45
+	"github.com/shopspring/decimal"       // v1.3.1
+)
+
+// MarshalDecimal writes an exact amount as a string, matching the REST API
+func MarshalDecimal(d decimal.Decimal) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		_, _ = io.WriteString(w, strconv.Quote(d.String()))
+	})
+}
+
+// UnmarshalDecimal reads an amount given as a string or a number
+func UnmarshalDecimal(v interface{}) (decimal.Decimal, error) {
+	switch v := v.(type) {
+	case string:
+		return decimal.NewFromString(v)
+	case json.Number:
+		return decimal.NewFromString(v.String())
+	case int:
+		return decimal.NewFromInt(int64(v)), nil
+	case int64:
+		return decimal.NewFromInt(v), nil
+	case float64:
+		return decimal.NewFromFloat(v), nil
+	default:
+		return decimal.Zero, fmt.Errorf("%T is not a decimal", v)
+	}
+}
+
+// MarshalMoney writes an amount stored as float64 as an exact decimal string
+func MarshalMoney(f float64) graphql.Marshaler {
+	return MarshalDecimal(decimal.NewFromFloat(f))
+}
+
+// UnmarshalMoney reads an amount into the float64 form the models store
+func UnmarshalMoney(v interface{}) (float64, error) {
+	d, err := UnmarshalDecimal(v)
+	if err != nil {
+		return 0, err
+	}
+	f, _ := d.Float64()
+	return f, nil
+}
@@ -0,0 +1,120 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/apierror"
+	"internal/graphql/generated"
+	"internal/invoices"
+	"internal/models"
+	"internal/service"
+)
+
+// Wallets is the resolver for the wallets field.
+func (r *queryResolver) Wallets(ctx context.Context) ([]*models.Wallet, error) {
+	principal, err := principalFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.wallets.ListCustomerWallets(ctx, principal.CustomerID)
+}
+
+// Wallet is the resolver for the wallet field.
+func (r *queryResolver) Wallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	principal, err := principalFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := r.wallets.GetWallet(ctx, id)
+	if errors.Is(err, service.ErrWalletNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Other customers' wallets are reported as missing rather than forbidden
+	// so wallet IDs cannot be probed
+	if wallet.CustomerID != principal.CustomerID {
+		return nil, nil
+	}
+
+	return wallet, nil
+}
+
+// Invoices is the resolver for the invoices field.
+func (r *queryResolver) Invoices(ctx context.Context, status *string, limit *int) ([]*invoices.Invoice, error) {
+	principal, err := principalFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := listLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.invoices == nil {
+		return nil, fmt.Errorf("%w: no invoice service configured", invoices.ErrUnavailable)
+	}
+
+	filter := invoices.ListFilter{
+		CustomerID: principal.CustomerID,
+		Limit:      n,
+	}
+	if status != nil {
+		filter.Status = *status
+	}
+
+	return r.invoices.List(ctx, principal.Token, filter)
+}
+
+// Type is the resolver for the type field.
+func (r *transactionResolver) Type(ctx context.Context, obj *models.Transaction) (generated.TransactionType, error) {
+	t := generated.TransactionType(obj.Type.String())
+	if !t.IsValid() {
+		return "", apierror.New(apierror.CodeInternal).WithDetails("unknown transaction type %d", obj.Type)
+	}
+	return t, nil
+}
+
+// Status is the resolver for the status field.
+func (r *transactionResolver) Status(ctx context.Context, obj *models.Transaction) (generated.TransactionStatus, error) {
+	s := generated.TransactionStatus(obj.Status.String())
+	if !s.IsValid() {
+		return "", apierror.New(apierror.CodeInternal).WithDetails("unknown transaction status %d", obj.Status)
+	}
+	return s, nil
+}
+
+// RecentTransactions is the resolver for the recentTransactions field.
+func (r *walletResolver) RecentTransactions(ctx context.Context, obj *models.Wallet, limit *int) ([]*models.Transaction, error) {
+	n, err := listLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadersFor(ctx).recentTransactions.Load(ctx, transactionsKey{WalletID: obj.ID, Limit: n})
+}
+
+// Query returns generated.QueryResolver implementation.
+func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
+
+// Transaction returns generated.TransactionResolver implementation.
+func (r *Resolver) Transaction() generated.TransactionResolver { return &transactionResolver{r} }
+
+// Wallet returns generated.WalletResolver implementation.
+func (r *Resolver) Wallet() generated.WalletResolver { return &walletResolver{r} }
+
+type queryResolver struct{ *Resolver }
+type transactionResolver struct{ *Resolver }
+type walletResolver struct{ *Resolver }
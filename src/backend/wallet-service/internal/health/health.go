@@ -0,0 +1,308 @@
+// Package health tracks the availability of service dependencies, the
+// degradation policy each feature applies when a soft dependency is down and
+// the resulting status of each client-facing capability
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FailurePolicy controls how a feature behaves when its dependency is down
+type FailurePolicy string
+
+const (
+	// FailOpen keeps the feature running without the dependency's guarantees
+	FailOpen FailurePolicy = "fail-open"
+	// FailClosed rejects requests that need the feature with 503
+	FailClosed FailurePolicy = "fail-closed"
+)
+
+// ParseFailurePolicy converts a configuration value to a FailurePolicy
+func ParseFailurePolicy(value string) (FailurePolicy, error) {
+	switch FailurePolicy(value) {
+	case FailOpen, FailClosed:
+		return FailurePolicy(value), nil
+	default:
+		return "", fmt.Errorf("unknown failure policy %q", value)
+	}
+}
+
+// Features relying on soft dependencies
+const (
+	FeatureRateLimiting = "rate_limiting"
+	FeatureIdempotency  = "idempotency"
+)
+
+// Feature modes reported by readiness checks
+const (
+	ModeNormal      = "normal"
+	ModeDegraded    = "degraded"
+	ModeUnavailable = "unavailable"
+)
+
+// Client-facing capabilities of the service
+const (
+	CapabilityTransactions    = "transactions"
+	CapabilityBalances        = "balances"
+	CapabilityWebhooks        = "webhooks"
+	CapabilityLiveUpdates     = "live_updates"
+	CapabilityRefunds         = "refunds"
+	CapabilityRecurringDebits = "recurring_debits"
+)
+
+// Capability statuses reported by the capability matrix
+const (
+	CapabilityOK       = "ok"
+	CapabilityDegraded = "degraded"
+	CapabilityDown     = "down"
+)
+
+// ImpactOf returns the capability status a feature failure policy leads to
+// while the feature's dependency is down
+func ImpactOf(policy FailurePolicy) string {
+	if policy == FailOpen {
+		return CapabilityDegraded
+	}
+	return CapabilityDown
+}
+
+// Readiness statuses
+const (
+	StatusReady    = "ready"
+	StatusDegraded = "degraded"
+	StatusNotReady = "not_ready"
+)
+
+// CheckFunc probes a dependency and returns an error when it is unavailable
+type CheckFunc func(ctx context.Context) error
+
+// dependency is a registered dependency probe
+type dependency struct {
+	check CheckFunc
+	hard  bool
+}
+
+// feature is a capability relying on a soft dependency
+type feature struct {
+	dependency string
+	policy     FailurePolicy
+}
+
+// Requirement is a dependency of a capability and the status of the
+// capability while the dependency is down
+type Requirement struct {
+	Dependency string
+	Impact     string
+}
+
+// DependencyStatus is the readiness view of one dependency
+type DependencyStatus struct {
+	Up    bool   `json:"up"`
+	Hard  bool   `json:"hard"`
+	Error string `json:"error,omitempty"`
+}
+
+// FeatureStatus is the readiness view of one feature
+type FeatureStatus struct {
+	Dependency string        `json:"dependency"`
+	Policy     FailurePolicy `json:"policy"`
+	Mode       string        `json:"mode"`
+}
+
+// Report is the readiness report of the service
+type Report struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+	Features     map[string]FeatureStatus    `json:"features"`
+	Capabilities map[string]string           `json:"capabilities"`
+	CheckedAt    time.Time                   `json:"checked_at"`
+}
+
+// CapabilityMatrix is the status of every capability as of the latest check.
+// CheckedAt is nil until the first check, when every capability is ok.
+type CapabilityMatrix struct {
+	Capabilities map[string]string `json:"capabilities"`
+	CheckedAt    *time.Time        `json:"checked_at,omitempty"`
+}
+
+// Monitor holds dependency probes, feature degradation policies and the
+// requirements of capabilities. The latest report is kept so requests can
+// consult capability statuses without probing.
+type Monitor struct {
+	mu           sync.RWMutex
+	dependencies map[string]dependency
+	features     map[string]feature
+	capabilities map[string][]Requirement
+	latest       *Report
+	timeout      time.Duration
+}
+
+// NewMonitor creates a new dependency monitor with a per-probe timeout
+func NewMonitor(timeout time.Duration) *Monitor {
+	return &Monitor{
+		dependencies: make(map[string]dependency),
+		features:     make(map[string]feature),
+		capabilities: make(map[string][]Requirement),
+		timeout:      timeout,
+	}
+}
+
+// RegisterDependency registers a probe. The service is not ready while a hard
+// dependency is down; soft dependencies only degrade their features.
+func (m *Monitor) RegisterDependency(name string, hard bool, check CheckFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dependencies[name] = dependency{check: check, hard: hard}
+}
+
+// RegisterFeature declares a feature, the dependency it needs and its policy
+func (m *Monitor) RegisterFeature(name, dependency string, policy FailurePolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.features[name] = feature{dependency: dependency, policy: policy}
+}
+
+// RegisterCapability declares a capability and the dependencies it needs
+func (m *Monitor) RegisterCapability(name string, requirements ...Requirement) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.capabilities[name] = requirements
+}
+
+// Policy returns the failure policy of a feature, failing closed when unknown
+func (m *Monitor) Policy(name string) FailurePolicy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if f, ok := m.features[name]; ok {
+		return f.policy
+	}
+	return FailClosed
+}
+
+// Check probes every dependency concurrently and builds the readiness report
+func (m *Monitor) Check(ctx context.Context) *Report {
+	m.mu.RLock()
+	deps := make(map[string]dependency, len(m.dependencies))
+	for name, dep := range m.dependencies {
+		deps[name] = dep
+	}
+	features := make(map[string]feature, len(m.features))
+	for name, f := range m.features {
+		features[name] = f
+	}
+	capabilities := make(map[string][]Requirement, len(m.capabilities))
+	for name, requirements := range m.capabilities {
+		capabilities[name] = requirements
+	}
+	m.mu.RUnlock()
+
+	report := &Report{
+		Status:       StatusReady,
+		Dependencies: make(map[string]DependencyStatus, len(deps)),
+		Features:     make(map[string]FeatureStatus, len(features)),
+		Capabilities: make(map[string]string, len(capabilities)),
+		CheckedAt:    time.Now().UTC(),
+	}
+
+	var wg sync.WaitGroup
+	var resultsMu sync.Mutex
+	for name, dep := range deps {
+		wg.Add(1)
+		go func(name string, dep dependency) {
+			defer wg.Done()
+
+			probeCtx, cancel := context.WithTimeout(ctx, m.timeout)
+			defer cancel()
+
+			status := DependencyStatus{Up: true, Hard: dep.hard}
+			if err := dep.check(probeCtx); err != nil {
+				status.Up = false
+				status.Error = err.Error()
+			}
+
+			resultsMu.Lock()
+			report.Dependencies[name] = status
+			resultsMu.Unlock()
+		}(name, dep)
+	}
+	wg.Wait()
+
+	for _, status := range report.Dependencies {
+		if status.Up {
+			continue
+		}
+		if status.Hard {
+			report.Status = StatusNotReady
+		} else if report.Status == StatusReady {
+			report.Status = StatusDegraded
+		}
+	}
+
+	for name, f := range features {
+		mode := ModeNormal
+		if dep, ok := report.Dependencies[f.dependency]; ok && !dep.Up {
+			mode = ModeDegraded
+			if f.policy == FailClosed {
+				mode = ModeUnavailable
+			}
+		}
+		report.Features[name] = FeatureStatus{
+			Dependency: f.dependency,
+			Policy:     f.policy,
+			Mode:       mode,
+		}
+	}
+
+	for name, requirements := range capabilities {
+		status := CapabilityOK
+		for _, requirement := range requirements {
+			if dep, ok := report.Dependencies[requirement.Dependency]; ok && !dep.Up && status != CapabilityDown {
+				status = requirement.Impact
+			}
+		}
+		report.Capabilities[name] = status
+	}
+
+	m.mu.Lock()
+	m.latest = report
+	m.mu.Unlock()
+
+	return report
+}
+
+// Matrix returns the capability statuses of the latest check
+func (m *Monitor) Matrix() CapabilityMatrix {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	matrix := CapabilityMatrix{Capabilities: make(map[string]string, len(m.capabilities))}
+	for name := range m.capabilities {
+		matrix.Capabilities[name] = m.capabilityStatus(name)
+	}
+	if m.latest != nil {
+		checkedAt := m.latest.CheckedAt
+		matrix.CheckedAt = &checkedAt
+	}
+	return matrix
+}
+
+// CapabilityStatus returns the status of a capability as of the latest check
+func (m *Monitor) CapabilityStatus(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.capabilityStatus(name)
+}
+
+// capabilityStatus looks a capability up in the latest report; the caller
+// holds the lock
+func (m *Monitor) capabilityStatus(name string) string {
+	if m.latest != nil {
+		if status, ok := m.latest.Capabilities[name]; ok {
+			return status
+		}
+	}
+	return CapabilityOK
+}
@@ -0,0 +1,194 @@
+// Package inbound defines the adapters verifying and identifying the
+// webhooks payment gateways and partner systems send to the service
+package inbound
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Inbound webhook errors
+var (
+	// ErrUnknownProvider is returned for webhooks of providers without an
+	// adapter
+	ErrUnknownProvider = errors.New("webhook provider not configured")
+	// ErrInvalidSignature is returned for webhooks whose signature is
+	// missing, wrong or too old to rule out a replay
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrInvalidEvent is returned for payloads without an event ID or type
+	ErrInvalidEvent = errors.New("invalid webhook event")
+)
+
+// Event identifies a webhook. Providers redeliver an event with the same ID,
+// and events sharing an ordering key, such as the payment they are about,
+// are processed in the order they were received.
+type Event struct {
+	ID          string
+	Type        string
+	OrderingKey string
+}
+
+// Provider is implemented by each webhook sender's adapter
+type Provider interface {
+	// Name identifies the provider in the receiving URL
+	Name() string
+	// Verify checks that the provider signed the request at most a
+	// tolerated time before now
+	Verify(header http.Header, body []byte, now time.Time) error
+	// Parse identifies the event carried by a verified request
+	Parse(header http.Header, body []byte) (*Event, error)
+}
+
+// HMACProvider verifies webhooks signed with a shared secret as the hex
+// HMAC-SHA256 of "<timestamp>.<body>", or of the body alone when the
+// provider sends no timestamp, and reads the event from JSON fields given
+// as dot-separated paths
+type HMACProvider struct {
+	ProviderName string
+	Secret       string
+	// SignatureHeader carries the signature, after SignaturePrefix if set
+	SignatureHeader string
+	SignaturePrefix string
+	// TimestampHeader carries the Unix time the request was signed at.
+	// Requests signed more than Tolerance ago are refused as replays.
+	TimestampHeader string
+	Tolerance       time.Duration
+	// EventIDField and EventTypeField locate the event ID and type, and
+	// OrderingKeyField what orders the events; without one the provider's
+	// events are processed in the order received
+	EventIDField     string
+	EventTypeField   string
+	OrderingKeyField string
+}
+
+// Name returns the provider name
+func (p *HMACProvider) Name() string {
+	return p.ProviderName
+}
+
+// Verify recomputes the signature of the request and compares it in
+// constant time
+func (p *HMACProvider) Verify(header http.Header, body []byte, now time.Time) error {
+	signature, ok := strings.CutPrefix(header.Get(p.SignatureHeader), p.SignaturePrefix)
+	if !ok || signature == "" {
+		return fmt.Errorf("%w: missing %s", ErrInvalidSignature, p.SignatureHeader)
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: signature is not hex", ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.Secret))
+	if p.TimestampHeader != "" {
+		timestamp := header.Get(p.TimestampHeader)
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: missing or invalid %s", ErrInvalidSignature, p.TimestampHeader)
+		}
+		if age := now.Sub(time.Unix(seconds, 0)); age > p.Tolerance || age < -p.Tolerance {
+			return fmt.Errorf("%w: signed outside the tolerated %s", ErrInvalidSignature, p.Tolerance)
+		}
+		mac.Write([]byte(timestamp))
+		mac.Write([]byte("."))
+	}
+	mac.Write(body)
+
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Parse reads the event ID, type and ordering key from the JSON body
+func (p *HMACProvider) Parse(header http.Header, body []byte) (*Event, error) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: body is not a JSON object", ErrInvalidEvent)
+	}
+
+	event := &Event{
+		ID:          lookup(payload, p.EventIDField),
+		Type:        lookup(payload, p.EventTypeField),
+		OrderingKey: lookup(payload, p.OrderingKeyField),
+	}
+	if event.ID == "" || event.Type == "" {
+		return nil, fmt.Errorf("%w: %s and %s are required", ErrInvalidEvent, p.EventIDField, p.EventTypeField)
+	}
+	return event, nil
+}
+
+// lookup returns the string or number at a dot-separated path of a JSON
+// object, or "" when there is none
+func lookup(payload map[string]interface{}, path string) string {
+	if path == "" {
+		return ""
+	}
+
+	var value interface{} = payload
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// Registry holds the configured provider adapters by name
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry creates a registry of the given providers
+func NewRegistry(providers ...Provider) (*Registry, error) {
+	r := &Registry{providers: make(map[string]Provider)}
+	for _, p := range providers {
+		if p == nil {
+			return nil, errors.New("provider is required")
+		}
+		if p.Name() == "" {
+			return nil, errors.New("provider name is required")
+		}
+		if _, exists := r.providers[p.Name()]; exists {
+			return nil, fmt.Errorf("duplicate webhook provider %q", p.Name())
+		}
+		r.providers[p.Name()] = p
+	}
+	return r, nil
+}
+
+// Get returns the adapter of a provider
+func (r *Registry) Get(name string) (Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return p, nil
+}
+
+// Names returns the configured provider names in order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,188 @@
+package invoicepdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+)
+
+// font is one of the standard PDF fonts every reader has, so documents need
+// no embedded font files
+type font int
+
+const (
+	regular font = iota
+	bold
+)
+
+// resourceName is how page content refers to the font
+func (f font) resourceName() string {
+	if f == bold {
+		return "F2"
+	}
+	return "F1"
+}
+
+// baseFont is the standard font's PDF name
+func (f font) baseFont() string {
+	if f == bold {
+		return "Helvetica-Bold"
+	}
+	return "Helvetica"
+}
+
+// Glyph widths of printable ASCII in thousandths of the font size, from the
+// Adobe font metrics of the standard fonts
+var (
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// defaultGlyphWidth is assumed for characters outside printable ASCII
+const defaultGlyphWidth = 556
+
+// textWidth returns the width in points of text set in the font and size
+func textWidth(f font, size float64, text string) float64 {
+	widths := &helveticaWidths
+	if f == bold {
+		widths = &helveticaBoldWidths
+	}
+
+	total := 0
+	for _, r := range text {
+		if r >= 32 && r <= 126 {
+			total += widths[r-32]
+		} else {
+			total += defaultGlyphWidth
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// color is an RGB color with components from 0 to 1
+type color struct {
+	r, g, b float64
+}
+
+// page is the content stream of one page
+type page struct {
+	content bytes.Buffer
+}
+
+// text draws text with its baseline starting at x, y
+func (p *page) text(f font, size, x, y float64, c color, text string) {
+	fmt.Fprintf(&p.content, "BT /%s %.1f Tf %.3f %.3f %.3f rg %.2f %.2f Td (%s) Tj ET\n",
+		f.resourceName(), size, c.r, c.g, c.b, x, y, escapeText(text))
+}
+
+// textRight draws text ending at x
+func (p *page) textRight(f font, size, x, y float64, c color, text string) {
+	p.text(f, size, x-textWidth(f, size, text), y, c, text)
+}
+
+// rect fills a rectangle whose lower left corner is at x, y
+func (p *page) rect(x, y, width, height float64, c color) {
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n", c.r, c.g, c.b, x, y, width, height)
+}
+
+// line strokes a line from x1, y1 to x2, y2
+func (p *page) line(x1, y1, x2, y2, width float64, c color) {
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f RG %.2f w %.2f %.2f m %.2f %.2f l S\n", c.r, c.g, c.b, width, x1, y1, x2, y2)
+}
+
+// document is a PDF being assembled page by page
+type document struct {
+	title string
+	pages []*page
+}
+
+// newPage appends a blank page
+func (d *document) newPage() *page {
+	p := &page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// bytes serializes the document: the catalog, the page tree, the two fonts,
+// each page with its content stream, then the cross-reference table
+func (d *document) bytes() []byte {
+	var b bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1 to 5 are the catalog, page tree, fonts and info; pages and
+	// their contents follow in pairs
+	const firstPage = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for _, f := range []font{regular, bold} {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.baseFont()))
+	}
+	object(fmt.Sprintf("<< /Title (%s) /Producer (wallet-service) >>", escapeText(d.title)))
+	for i, p := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()))
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return b.Bytes()
+}
+
+// escapeText encodes text as the contents of a PDF string in the fonts'
+// WinAnsi encoding. Latin-1 characters are kept; others, which the standard
+// fonts cannot show, become question marks.
+func escapeText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r >= 32 && r <= 126:
+			b.WriteByte(byte(r))
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case r == '\t':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
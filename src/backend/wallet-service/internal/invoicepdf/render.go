@@ -0,0 +1,392 @@
+// Package invoicepdf renders issued invoices as branded A4 PDFs. Documents
+// use the standard PDF fonts and need no external tools or font files; the
+// title, notes and footer are text templates so deployments can word them.
+package invoicepdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/currency"
+	"internal/models"
+)
+
+// ErrInvalidTemplate is returned for invoice templates that cannot be parsed
+// or rendered
+var ErrInvalidTemplate = errors.New("invalid invoice template")
+
+// defaultAccentColor is the color of the header band and table headings when
+// the branding sets none
+const defaultAccentColor = "#1F4E79"
+
+// Layout in points
+const (
+	margin       = 40.0
+	headerHeight = 90.0
+	rowHeight    = 18.0
+	// footerSpace is kept free at the bottom of every page for the footer
+	footerSpace = 80.0
+	// Right edges of the line item columns
+	quantityRight  = 340.0
+	unitPriceRight = 450.0
+	amountRight    = pageWidth - margin
+	// descriptionWidth is the room of the description column
+	descriptionWidth = 230.0
+)
+
+var (
+	white = color{1, 1, 1}
+	ink   = color{0.13, 0.13, 0.13}
+	muted = color{0.4, 0.4, 0.4}
+	rule  = color{0.8, 0.8, 0.8}
+)
+
+// Branding is the seller's identity printed on every invoice
+type Branding struct {
+	CompanyName string
+	// Address lines printed under the header
+	Address []string
+	// TaxID is the seller's tax registration, such as a GSTIN
+	TaxID string
+	// AccentColor is the #RRGGBB color of the header band and table headings
+	AccentColor string
+}
+
+// Templates hold the wording of an invoice as text/template sources executed
+// with the invoice document, whose fields are referred to by their Go names,
+// e.g. {{.InvoiceNumber}}. The company name is {{.Company}}; {{money .Currency
+// .TotalAmount}} and {{date .DueDate}} format amounts and dates. Empty
+// templates are replaced by the defaults.
+type Templates struct {
+	Title  string
+	Notes  string
+	Footer string
+}
+
+// defaultTemplates word invoices whose templates are not configured
+var defaultTemplates = Templates{
+	Title:  "Tax Invoice",
+	Notes:  "{{.Notes}}",
+	Footer: "Payment of {{money .Currency .TotalAmount}} is due by {{date .DueDate}}. Thank you for your business.",
+}
+
+// funcs are the functions available to invoice templates
+var funcs = template.FuncMap{
+	"money": formatMoney,
+	"date":  formatDate,
+}
+
+// templateData is what invoice templates are executed with
+type templateData struct {
+	*models.InvoiceDocument
+	Company string
+}
+
+// Renderer renders invoice documents as PDFs
+type Renderer struct {
+	branding Branding
+	accent   color
+	title    *template.Template
+	notes    *template.Template
+	footer   *template.Template
+}
+
+// NewRenderer creates a new Renderer printing the branding and worded by the
+// templates
+func NewRenderer(branding Branding, templates Templates) (*Renderer, error) {
+	if strings.TrimSpace(branding.CompanyName) == "" {
+		return nil, errors.New("company name is required")
+	}
+	if branding.AccentColor == "" {
+		branding.AccentColor = defaultAccentColor
+	}
+	accent, err := parseColor(branding.AccentColor)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Renderer{branding: branding, accent: accent}
+	for _, t := range []struct {
+		name   string
+		source string
+		dflt   string
+		dst    **template.Template
+	}{
+		{"title", templates.Title, defaultTemplates.Title, &r.title},
+		{"notes", templates.Notes, defaultTemplates.Notes, &r.notes},
+		{"footer", templates.Footer, defaultTemplates.Footer, &r.footer},
+	} {
+		source := t.source
+		if source == "" {
+			source = t.dflt
+		}
+		parsed, err := template.New(t.name).Funcs(funcs).Option("missingkey=error").Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+		}
+		*t.dst = parsed
+	}
+
+	return r, nil
+}
+
+// Render renders an invoice as a PDF. Line items that do not fit on the first
+// page continue on the following ones.
+func (r *Renderer) Render(doc *models.InvoiceDocument) ([]byte, error) {
+	data := templateData{InvoiceDocument: doc, Company: r.branding.CompanyName}
+	title, err := execute(r.title, data)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := execute(r.notes, data)
+	if err != nil {
+		return nil, err
+	}
+	footer, err := execute(r.footer, data)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := &document{title: fmt.Sprintf("%s %s", title, doc.InvoiceNumber)}
+	p := pdf.newPage()
+	y := r.drawHeader(p, doc, title)
+	y = r.drawTableHeading(p, y)
+
+	for _, item := range doc.LineItems {
+		if y-rowHeight < footerSpace {
+			p = pdf.newPage()
+			p.rect(0, pageHeight-8, pageWidth, 8, r.accent)
+			p.text(regular, 9, margin, pageHeight-margin, muted, fmt.Sprintf("%s %s (continued)", title, doc.InvoiceNumber))
+			y = r.drawTableHeading(p, pageHeight-margin-20)
+		}
+		description := truncate(regular, 9, descriptionWidth, item.Description)
+		p.text(regular, 9, margin+6, y-12, ink, description)
+		p.textRight(regular, 9, quantityRight, y-12, ink, item.Quantity.String())
+		p.textRight(regular, 9, unitPriceRight, y-12, ink, formatMoney(doc.Currency, item.UnitPrice))
+		p.textRight(regular, 9, amountRight-6, y-12, ink, formatMoney(doc.Currency, item.Amount))
+		y -= rowHeight
+		p.line(margin, y, amountRight, y, 0.5, rule)
+	}
+
+	// Totals and notes need about five rows and the notes' lines
+	noteLines := wrap(regular, 9, pageWidth-2*margin, notes)
+	if y-float64(5+len(noteLines))*rowHeight < footerSpace {
+		p = pdf.newPage()
+		p.rect(0, pageHeight-8, pageWidth, 8, r.accent)
+		y = pageHeight - margin
+	}
+	y = r.drawTotals(p, doc, y)
+
+	if len(noteLines) > 0 {
+		y -= rowHeight
+		p.text(bold, 9, margin, y, ink, "Notes")
+		for _, line := range noteLines {
+			y -= 12
+			p.text(regular, 9, margin, y, muted, line)
+		}
+	}
+
+	// The footer has room for three lines
+	footerLines := wrap(regular, 8, pageWidth-2*margin-60, footer)
+	if len(footerLines) > 3 {
+		footerLines = footerLines[:3]
+	}
+	for i, pg := range pdf.pages {
+		pg.line(margin, footerSpace-30, amountRight, footerSpace-30, 0.5, rule)
+		fy := footerSpace - 44.0
+		for _, line := range footerLines {
+			pg.text(regular, 8, margin, fy, muted, line)
+			fy -= 10
+		}
+		pg.textRight(regular, 8, amountRight, footerSpace-44, muted, fmt.Sprintf("Page %d of %d", i+1, len(pdf.pages)))
+	}
+
+	return pdf.bytes(), nil
+}
+
+// drawHeader draws the branded header band, the seller, the invoice's
+// numbers and dates and the customer billed, returning where the line items
+// start
+func (r *Renderer) drawHeader(p *page, doc *models.InvoiceDocument, title string) float64 {
+	top := pageHeight - headerHeight
+	p.rect(0, top, pageWidth, headerHeight, r.accent)
+	p.text(bold, 20, margin, top+headerHeight/2-4, white, r.branding.CompanyName)
+	p.textRight(bold, 16, amountRight, top+headerHeight/2-4, white, strings.ToUpper(title))
+
+	// Seller on the left, invoice details on the right
+	y := top - 24
+	sellerY := y
+	for _, line := range r.branding.Address {
+		p.text(regular, 9, margin, sellerY, muted, line)
+		sellerY -= 12
+	}
+	if r.branding.TaxID != "" {
+		p.text(regular, 9, margin, sellerY, muted, "Tax ID: "+r.branding.TaxID)
+		sellerY -= 12
+	}
+
+	detailsY := y
+	for _, detail := range [][2]string{
+		{"Invoice number", doc.InvoiceNumber},
+		{"Issue date", formatDate(doc.IssueDate)},
+		{"Due date", formatDate(doc.DueDate)},
+		{"Currency", doc.Currency},
+	} {
+		p.textRight(regular, 9, amountRight-110, detailsY, muted, detail[0])
+		p.textRight(bold, 9, amountRight, detailsY, ink, detail[1])
+		detailsY -= 12
+	}
+
+	y = sellerY
+	if detailsY < y {
+		y = detailsY
+	}
+	y -= 18
+	p.text(bold, 10, margin, y, ink, "Bill to")
+	for _, line := range doc.BillTo {
+		y -= 12
+		p.text(regular, 9, margin, y, ink, line)
+	}
+	return y - 24
+}
+
+// drawTableHeading draws the heading row of the line items, returning where
+// the first row starts
+func (r *Renderer) drawTableHeading(p *page, y float64) float64 {
+	p.rect(margin, y-rowHeight, amountRight-margin, rowHeight, r.accent)
+	p.text(bold, 9, margin+6, y-12, white, "Description")
+	p.textRight(bold, 9, quantityRight, y-12, white, "Qty")
+	p.textRight(bold, 9, unitPriceRight, y-12, white, "Unit price")
+	p.textRight(bold, 9, amountRight-6, y-12, white, "Amount")
+	return y - rowHeight
+}
+
+// drawTotals draws the subtotal, tax and total under the line items,
+// returning where the notes start
+func (r *Renderer) drawTotals(p *page, doc *models.InvoiceDocument, y float64) float64 {
+	taxLabel := "Tax"
+	if doc.TaxType != "" {
+		taxLabel = doc.TaxType
+	}
+
+	y -= 8
+	for _, total := range []struct {
+		label  string
+		amount decimal.Decimal
+	}{
+		{"Subtotal", doc.Subtotal},
+		{taxLabel, doc.TaxAmount},
+	} {
+		y -= rowHeight
+		p.textRight(regular, 9, unitPriceRight, y+6, muted, total.label)
+		p.textRight(regular, 9, amountRight-6, y+6, ink, formatMoney(doc.Currency, total.amount))
+	}
+
+	y -= rowHeight + 4
+	p.rect(unitPriceRight-90, y, amountRight-unitPriceRight+90, rowHeight+4, r.accent)
+	p.textRight(bold, 10, unitPriceRight, y+7, white, "Total due")
+	p.textRight(bold, 10, amountRight-6, y+7, white, formatMoney(doc.Currency, doc.TotalAmount))
+	return y - 8
+}
+
+// execute renders a template to a string
+func execute(t *template.Template, data templateData) (string, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// formatMoney formats an amount with its currency code at the currency's
+// precision, grouping thousands, e.g. INR 1,234.50
+func formatMoney(code string, amount decimal.Decimal) string {
+	precision, ok := currency.MinorUnits(code)
+	if !ok {
+		precision = 2
+	}
+
+	fixed := amount.Abs().StringFixed(precision)
+	whole, fraction := fixed, ""
+	if i := strings.IndexByte(fixed, '.'); i >= 0 {
+		whole, fraction = fixed[:i], fixed[i:]
+	}
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+
+	sign := ""
+	if amount.IsNegative() {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s %s%s%s", code, sign, grouped.String(), fraction)
+}
+
+// formatDate formats a date as printed on invoices, e.g. 05 Oct 2026
+func formatDate(t time.Time) string {
+	return t.Format("02 Jan 2006")
+}
+
+// wrap breaks text into lines no wider than width, keeping its line breaks
+func wrap(f font, size, width float64, text string) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if line != "" && textWidth(f, size, candidate) > width {
+				lines = append(lines, truncate(f, size, width, line))
+				candidate = word
+			}
+			line = candidate
+		}
+		lines = append(lines, truncate(f, size, width, line))
+	}
+	return lines
+}
+
+// truncate shortens text to fit width, ending it with an ellipsis
+func truncate(f font, size, width float64, text string) string {
+	if textWidth(f, size, text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && textWidth(f, size, string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "..."
+}
+
+// parseColor parses a #RRGGBB color
+func parseColor(hex string) (color, error) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return color{}, fmt.Errorf("accent color %q must be #RRGGBB", hex)
+	}
+	value, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return color{}, fmt.Errorf("accent color %q must be #RRGGBB", hex)
+	}
+	return color{
+		r: float64(value>>16&0xff) / 255,
+		g: float64(value>>8&0xff) / 255,
+		b: float64(value&0xff) / 255,
+	}, nil
+}
@@ -0,0 +1,146 @@
+// Package invoices provides a client for the invoice service so wallet
+// consumers can read a customer's invoices alongside their wallets
+package invoices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// listPath is the invoice service's list endpoint
+const listPath = "/api/v1/invoices/"
+
+// maxErrorBody bounds the error response body read from the invoice service
+const maxErrorBody = 1 << 10
+
+// ErrUnavailable is returned when the invoice service cannot be reached or fails
+var ErrUnavailable = errors.New("invoice service unavailable")
+
+// Invoice is the invoice service's view of an invoice
+type Invoice struct {
+	ID            uuid.UUID       `json:"id"`
+	CustomerID    uuid.UUID       `json:"customer_id"`
+	InvoiceNumber string          `json:"invoice_number"`
+	Status        string          `json:"status"`
+	Currency      string          `json:"currency_code"`
+	Subtotal      decimal.Decimal `json:"subtotal"`
+	TaxAmount     decimal.Decimal `json:"tax_amount"`
+	TotalAmount   decimal.Decimal `json:"total_amount"`
+	IssueDate     time.Time       `json:"issue_date"`
+	DueDate       time.Time       `json:"due_date"`
+}
+
+// UnmarshalJSON decodes an invoice, accepting the invoice service's
+// timestamps, which are UTC and may omit the zone offset
+func (i *Invoice) UnmarshalJSON(data []byte) error {
+	type plain Invoice
+	var raw struct {
+		plain
+		IssueDate string  `json:"issue_date"`
+		DueDate   *string `json:"due_date"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*i = Invoice(raw.plain)
+
+	var err error
+	if i.IssueDate, err = parseTimestamp(raw.IssueDate); err != nil {
+		return fmt.Errorf("invalid issue_date: %w", err)
+	}
+	if raw.DueDate != nil {
+		if i.DueDate, err = parseTimestamp(*raw.DueDate); err != nil {
+			return fmt.Errorf("invalid due_date: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseTimestamp parses an RFC 3339 timestamp, treating one without a zone
+// offset as UTC
+func parseTimestamp(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04:05.999999999", value, time.UTC)
+}
+
+// ListFilter narrows the invoices listed
+type ListFilter struct {
+	CustomerID uuid.UUID
+	// Status filters by invoice status when set
+	Status string
+	Limit  int
+}
+
+// Client reads invoices from the invoice service
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the invoice service at baseURL
+func NewClient(baseURL string, timeout time.Duration) (*Client, error) {
+	if baseURL == "" {
+		return nil, errors.New("invoice service URL is required")
+	}
+	if _, err := url.ParseRequestURI(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid invoice service URL: %w", err)
+	}
+	if timeout <= 0 {
+		return nil, errors.New("timeout must be positive")
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// List returns the customer's newest invoices. The caller's bearer token is
+// forwarded so the invoice service applies its own access controls.
+func (c *Client) List(ctx context.Context, token string, filter ListFilter) ([]*Invoice, error) {
+	query := url.Values{}
+	query.Set("customer_id", filter.CustomerID.String())
+	query.Set("limit", strconv.Itoa(filter.Limit))
+	if filter.Status != "" {
+		query.Set("status", filter.Status)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+listPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invoice request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("%w: status %d: %s", ErrUnavailable, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var invoices []*Invoice
+	if err := json.NewDecoder(resp.Body).Decode(&invoices); err != nil {
+		return nil, fmt.Errorf("%w: invalid response: %v", ErrUnavailable, err)
+	}
+
+	return invoices, nil
+}
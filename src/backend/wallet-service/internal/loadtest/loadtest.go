@@ -0,0 +1,227 @@
+// Package loadtest drives concurrent debits and credits through the wallet
+// service and reports throughput, latency percentiles and the rate of
+// optimistic lock conflicts, so that changes to the locking strategy can be
+// compared on the same workload. It backs the loadtest command and the
+// ProcessTransaction benchmarks.
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+	"internal/service"
+)
+
+// Options shape the workload of a run
+type Options struct {
+	// Workers is the number of concurrent callers
+	Workers int
+	// Transactions bounds the run by the number of transactions posted,
+	// across all workers. Zero runs until Duration elapses.
+	Transactions int64
+	// Duration bounds the run by time when Transactions is zero
+	Duration time.Duration
+	// CreditRatio is the share of transactions that are credits, the rest
+	// being debits
+	CreditRatio float64
+	// Amount is the amount of every transaction
+	Amount float64
+	// Currency is the currency of the wallets
+	Currency string
+	// Seed seeds the choice of wallet and transaction type, so runs with
+	// the same options post the same workload
+	Seed int64
+}
+
+// Validate checks that the options describe a bounded workload
+func (o Options) Validate() error {
+	if o.Workers <= 0 {
+		return errors.New("workers must be positive")
+	}
+	if o.Transactions < 0 {
+		return errors.New("transactions must not be negative")
+	}
+	if o.Transactions == 0 && o.Duration <= 0 {
+		return errors.New("either transactions or duration must bound the run")
+	}
+	if o.CreditRatio < 0 || o.CreditRatio > 1 {
+		return errors.New("credit ratio must be between 0 and 1")
+	}
+	if o.Amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	if o.Currency == "" {
+		return errors.New("currency is required")
+	}
+	return nil
+}
+
+// Report is the outcome of a run. Every transaction attempted is counted
+// exactly once, as succeeded, conflicted, insufficient or failed.
+type Report struct {
+	Transactions int64
+	Succeeded    int64
+	// Conflicts were refused as a concurrent transaction changed the
+	// wallet first
+	Conflicts int64
+	// Insufficient were debits refused for lack of balance
+	Insufficient int64
+	// Failed were refused for any other reason
+	Failed int64
+	// FirstError is the first unexpected error, if any
+	FirstError error
+
+	Elapsed time.Duration
+	P50     time.Duration
+	P99     time.Duration
+	Max     time.Duration
+}
+
+// Throughput returns the transactions attempted per second
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Transactions) / r.Elapsed.Seconds()
+}
+
+// ConflictRate returns the share of transactions refused by optimistic lock
+// conflicts
+func (r *Report) ConflictRate() float64 {
+	if r.Transactions == 0 {
+		return 0
+	}
+	return float64(r.Conflicts) / float64(r.Transactions)
+}
+
+// String summarizes the report on a few lines
+func (r *Report) String() string {
+	return fmt.Sprintf("transactions: %d in %s (%.1f/s)\n"+
+		"succeeded: %d, conflicts: %d (%.2f%%), insufficient: %d, failed: %d\n"+
+		"latency p50: %s, p99: %s, max: %s",
+		r.Transactions, r.Elapsed.Round(time.Millisecond), r.Throughput(),
+		r.Succeeded, r.Conflicts, 100*r.ConflictRate(), r.Insufficient, r.Failed,
+		r.P50, r.P99, r.Max)
+}
+
+// Run posts transactions through the wallet service from concurrent
+// workers, each picking a random wallet per transaction, until the
+// transaction count or duration of the options is reached or ctx is done.
+// Fewer wallets mean more contention. Transactions still running when the
+// duration elapses complete.
+func Run(ctx context.Context, wallets service.WalletService, walletIDs []uuid.UUID, opts Options) (*Report, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if len(walletIDs) == 0 {
+		return nil, errors.New("at least one wallet is required")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	running := ctx
+	if opts.Transactions == 0 {
+		var cancel context.CancelFunc
+		running, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	report := &Report{}
+	var remaining atomic.Int64
+	remaining.Store(opts.Transactions)
+	latencies := make([][]time.Duration, opts.Workers)
+	var firstError sync.Once
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			random := rand.New(rand.NewSource(opts.Seed + int64(w)))
+
+			for running.Err() == nil {
+				if opts.Transactions > 0 && remaining.Add(-1) < 0 {
+					return
+				}
+
+				walletID := walletIDs[random.Intn(len(walletIDs))]
+				txType := models.TransactionTypeDebit
+				if random.Float64() < opts.CreditRatio {
+					txType = models.TransactionTypeCredit
+				}
+
+				began := time.Now()
+				_, err := wallets.ProcessTransaction(ctx, Transaction(walletID, txType, opts.Amount, opts.Currency))
+				latencies[w] = append(latencies[w], time.Since(began))
+
+				atomic.AddInt64(&report.Transactions, 1)
+				switch {
+				case err == nil:
+					atomic.AddInt64(&report.Succeeded, 1)
+				case errors.Is(err, service.ErrOptimisticLock):
+					atomic.AddInt64(&report.Conflicts, 1)
+				case errors.Is(err, service.ErrInsufficientBalance):
+					atomic.AddInt64(&report.Insufficient, 1)
+				default:
+					atomic.AddInt64(&report.Failed, 1)
+					firstError.Do(func() { report.FirstError = err })
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	report.Elapsed = time.Since(start)
+
+	var all []time.Duration
+	for _, l := range latencies {
+		all = append(all, l...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	report.P50 = percentile(all, 0.50)
+	report.P99 = percentile(all, 0.99)
+	if len(all) > 0 {
+		report.Max = all[len(all)-1]
+	}
+
+	return report, nil
+}
+
+// Transaction builds a completed transaction of a load test
+func Transaction(walletID uuid.UUID, txType models.TransactionType, amount float64, currency string) *models.Transaction {
+	now := time.Now().UTC()
+	return &models.Transaction{
+		ID:          uuid.New(),
+		WalletID:    walletID,
+		Type:        txType,
+		Status:      models.TransactionStatusCompleted,
+		Amount:      amount,
+		Currency:    currency,
+		Description: "Load test",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,65 @@
+package loadtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+	"internal/repository"
+	"internal/service"
+)
+
+// SeedCustomers inserts n customers to own the wallets of a load test into
+// a PostgreSQL database, returning their IDs
+func SeedCustomers(ctx context.Context, db *sql.DB, n int) ([]uuid.UUID, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	customers := make([]uuid.UUID, n)
+	for i := range customers {
+		id := uuid.New()
+		_, err := db.ExecContext(ctx, `
+            INSERT INTO customers (id, name, email, last_modified_by)
+            VALUES ($1, $2, $3, $1)`,
+			id, fmt.Sprintf("Load test customer %d", i+1), "loadtest+"+id.String()+"@example.com")
+		if err != nil {
+			return nil, fmt.Errorf("failed to seed customer: %w", err)
+		}
+		customers[i] = id
+	}
+
+	return customers, nil
+}
+
+// CreateWallets creates a wallet in currency for each customer, funded with
+// balance through a credit so that debits can draw on it
+func CreateWallets(ctx context.Context, repo repository.WalletRepository, wallets service.WalletService, customers []uuid.UUID, currency string, balance float64) ([]uuid.UUID, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+
+	ids := make([]uuid.UUID, len(customers))
+	for i, customerID := range customers {
+		wallet := &models.Wallet{CustomerID: customerID, Currency: currency}
+		if err := repo.CreateWallet(ctx, wallet); err != nil {
+			return nil, fmt.Errorf("failed to create wallet: %w", err)
+		}
+		if balance > 0 {
+			funding := Transaction(wallet.ID, models.TransactionTypeCredit, balance, currency)
+			if _, err := wallets.ProcessTransaction(ctx, funding); err != nil {
+				return nil, fmt.Errorf("failed to fund wallet: %w", err)
+			}
+		}
+		ids[i] = wallet.ID
+	}
+
+	return ids, nil
+}
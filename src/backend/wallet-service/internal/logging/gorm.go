@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	gormlogger "gorm.io/gorm/logger" // v1.25.0
+)
+
+// slowQueryThreshold is the duration past which queries are logged as slow
+const slowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger logs database queries through the logger of the query's
+// context, falling back to the base logger outside requests
+type GormLogger struct {
+	logger *Logger
+	level  gormlogger.LogLevel
+}
+
+// NewGormLogger creates a new GORM logger logging failed and slow queries
+func NewGormLogger(logger *Logger) *GormLogger {
+	return &GormLogger{logger: logger, level: gormlogger.Warn}
+}
+
+// LogMode returns a copy of the GORM logger at the level
+func (g *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *g
+	copied.level = level
+	return &copied
+}
+
+// Info logs an informational GORM message
+func (g *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if g.level >= gormlogger.Info {
+		g.from(ctx).Info(fmt.Sprintf(msg, data...))
+	}
+}
+
+// Warn logs a GORM warning
+func (g *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if g.level >= gormlogger.Warn {
+		g.from(ctx).Warn(fmt.Sprintf(msg, data...))
+	}
+}
+
+// Error logs a GORM error
+func (g *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if g.level >= gormlogger.Error {
+		g.from(ctx).Error("database error", errors.New(fmt.Sprintf(msg, data...)))
+	}
+}
+
+// Trace logs failed queries as errors and slow queries as warnings. Missing
+// records are expected and not logged.
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if g.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gormlogger.ErrRecordNotFound) && g.level >= gormlogger.Error:
+		sql, rows := fc()
+		g.from(ctx).Error("database query failed", err, "sql", sql, "rows", rows, "elapsed", elapsed)
+	case elapsed > slowQueryThreshold && g.level >= gormlogger.Warn:
+		sql, rows := fc()
+		g.from(ctx).Warn("slow database query", "sql", sql, "rows", rows, "elapsed", elapsed)
+	case g.level >= gormlogger.Info:
+		sql, rows := fc()
+		g.from(ctx).Debug("database query", "sql", sql, "rows", rows, "elapsed", elapsed)
+	}
+}
+
+// from returns the logger of the context, or the base logger
+func (g *GormLogger) from(ctx context.Context) *Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return logger
+	}
+	return g.logger
+}
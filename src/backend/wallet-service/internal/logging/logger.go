@@ -0,0 +1,151 @@
+// Package logging provides the structured logger shared by every component
+// of the service: a zap logger taking loosely typed key-value fields, with a
+// level adjustable at runtime and child loggers carried by request contexts
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/zap"         // v1.24.0
+	"go.uber.org/zap/zapcore" // v1.24.0
+)
+
+// Options configures a logger
+type Options struct {
+	// Level is debug, info, warn or error; empty is info
+	Level string
+	// Encoding is json or console; empty is json
+	Encoding string
+	// Output receives the log entries; nil is standard output
+	Output io.Writer
+}
+
+// Logger is a structured logger. Fields are key-value pairs, as in
+// logger.Info("wallet created", "walletID", id), and may mix in zap fields.
+type Logger struct {
+	sugar *zap.SugaredLogger
+	level zap.AtomicLevel
+}
+
+// New creates a new logger
+func New(opts Options) (*Logger, error) {
+	level := zap.NewAtomicLevel()
+	if opts.Level != "" {
+		if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+			return nil, fmt.Errorf("unsupported log level: %s", opts.Level)
+		}
+	}
+
+	var encoder zapcore.Encoder
+	switch opts.Encoding {
+	case "", "json":
+		encoder = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	case "console":
+		encoder = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	default:
+		return nil, fmt.Errorf("unsupported log encoding: %s", opts.Encoding)
+	}
+
+	output := zapcore.Lock(os.Stdout)
+	if opts.Output != nil {
+		output = zapcore.AddSync(opts.Output)
+	}
+
+	// Callers are reported past the wrapping methods of Logger
+	base := zap.New(zapcore.NewCore(encoder, output, level),
+		zap.AddCaller(),
+		zap.AddCallerSkip(1),
+		zap.AddStacktrace(zap.ErrorLevel),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	)
+
+	return &Logger{sugar: base.Sugar(), level: level}, nil
+}
+
+// NewNop creates a logger discarding every entry
+func NewNop() *Logger {
+	return &Logger{sugar: zap.NewNop().Sugar(), level: zap.NewAtomicLevel()}
+}
+
+// Debug logs a debug message
+func (l *Logger) Debug(msg string, fields ...interface{}) {
+	l.sugar.Debugw(msg, fields...)
+}
+
+// Info logs an informational message
+func (l *Logger) Info(msg string, fields ...interface{}) {
+	l.sugar.Infow(msg, fields...)
+}
+
+// Warn logs a warning
+func (l *Logger) Warn(msg string, fields ...interface{}) {
+	l.sugar.Warnw(msg, fields...)
+}
+
+// Error logs an error with the error that caused it
+func (l *Logger) Error(msg string, err error, fields ...interface{}) {
+	l.sugar.Errorw(msg, append([]interface{}{zap.Error(err)}, fields...)...)
+}
+
+// Fatal logs a message and exits the process
+func (l *Logger) Fatal(msg string, fields ...interface{}) {
+	l.sugar.Fatalw(msg, fields...)
+}
+
+// With returns a child logger adding the fields to every entry. Child
+// loggers share the level of their parent.
+func (l *Logger) With(fields ...interface{}) *Logger {
+	return &Logger{sugar: l.sugar.With(fields...), level: l.level}
+}
+
+// SetLevel changes the level of the logger and of every logger sharing it
+func (l *Logger) SetLevel(level string) error {
+	if err := l.level.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("unsupported log level: %s", level)
+	}
+	return nil
+}
+
+// Sync flushes buffered log entries
+func (l *Logger) Sync() error {
+	return l.sugar.Sync()
+}
+
+// contextKey is the context key of request loggers
+type contextKey struct{}
+
+// nop is the logger of contexts without one
+var nop = NewNop()
+
+// WithContext returns a context carrying the logger
+func WithContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by the context, which discards
+// every entry when the context has none
+func FromContext(ctx context.Context) *Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return logger
+	}
+	return nop
+}
+
+// correlationIDKey is the context key of request correlation IDs
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a context carrying the correlation ID of the
+// request it serves, for components logging without the request logger
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationID returns the correlation ID carried by the context, empty
+// outside requests
+func CorrelationID(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// Metadata keys linking an adjustment transaction to its approved adjustment
+const (
+	MetadataAdjustmentID        = "adjustment_id"
+	MetadataAdjustmentReason    = "adjustment_reason"
+	MetadataAdjustmentDirection = "adjustment_direction"
+)
+
+// ErrInvalidAdjustment is returned for adjustment transactions missing their
+// reason code, direction or approved adjustment
+var ErrInvalidAdjustment = errors.New("invalid adjustment transaction")
+
+// AdjustmentDirection is whether an adjustment raises or lowers the balance
+type AdjustmentDirection string
+
+const (
+	// AdjustmentIncrease raises the balance
+	AdjustmentIncrease AdjustmentDirection = "INCREASE"
+	// AdjustmentDecrease lowers the balance
+	AdjustmentDecrease AdjustmentDirection = "DECREASE"
+)
+
+// IsValid checks if the direction is one of the defined directions
+func (d AdjustmentDirection) IsValid() bool {
+	return d == AdjustmentIncrease || d == AdjustmentDecrease
+}
+
+// AdjustmentReason is the reason code of an adjustment, reported on
+type AdjustmentReason string
+
+const (
+	// AdjustmentReasonBillingError corrects a wrongly priced charge
+	AdjustmentReasonBillingError AdjustmentReason = "BILLING_ERROR"
+	// AdjustmentReasonDuplicateCharge reverses a charge posted twice
+	AdjustmentReasonDuplicateCharge AdjustmentReason = "DUPLICATE_CHARGE"
+	// AdjustmentReasonSystemError corrects a balance left wrong by a fault
+	AdjustmentReasonSystemError AdjustmentReason = "SYSTEM_ERROR"
+	// AdjustmentReasonGoodwill credits a customer as a gesture of goodwill
+	AdjustmentReasonGoodwill AdjustmentReason = "GOODWILL"
+	// AdjustmentReasonWriteOff writes off a balance that will not be settled
+	AdjustmentReasonWriteOff AdjustmentReason = "WRITE_OFF"
+)
+
+// AdjustmentReasons lists the reason codes in a stable order
+var AdjustmentReasons = []AdjustmentReason{
+	AdjustmentReasonBillingError,
+	AdjustmentReasonDuplicateCharge,
+	AdjustmentReasonSystemError,
+	AdjustmentReasonGoodwill,
+	AdjustmentReasonWriteOff,
+}
+
+// IsValid checks if the reason is one of the defined reason codes
+func (r AdjustmentReason) IsValid() bool {
+	for _, reason := range AdjustmentReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAdjustmentMetadata checks that the metadata of an adjustment
+// transaction names its approved adjustment, reason code and direction
+func ValidateAdjustmentMetadata(metadata map[string]string) error {
+	if _, err := uuid.Parse(metadata[MetadataAdjustmentID]); err != nil {
+		return fmt.Errorf("%w: %s must be the approved adjustment", ErrInvalidAdjustment, MetadataAdjustmentID)
+	}
+	if !AdjustmentReason(metadata[MetadataAdjustmentReason]).IsValid() {
+		return fmt.Errorf("%w: unknown %s", ErrInvalidAdjustment, MetadataAdjustmentReason)
+	}
+	if !AdjustmentDirection(metadata[MetadataAdjustmentDirection]).IsValid() {
+		return fmt.Errorf("%w: %s must be %s or %s", ErrInvalidAdjustment, MetadataAdjustmentDirection, AdjustmentIncrease, AdjustmentDecrease)
+	}
+	return nil
+}
+
+// AdjustmentStatus is the review state of an adjustment
+type AdjustmentStatus string
+
+const (
+	// AdjustmentPending awaits approval by a second operator
+	AdjustmentPending AdjustmentStatus = "PENDING"
+	// AdjustmentApproved was approved and is being posted
+	AdjustmentApproved AdjustmentStatus = "APPROVED"
+	// AdjustmentPosted was posted as an adjustment transaction
+	AdjustmentPosted AdjustmentStatus = "POSTED"
+	// AdjustmentRejected was rejected or withdrawn
+	AdjustmentRejected AdjustmentStatus = "REJECTED"
+	// AdjustmentExpired was not reviewed before it expired
+	AdjustmentExpired AdjustmentStatus = "EXPIRED"
+)
+
+// Adjustment is a manual correction of a wallet balance requested by an
+// operator and kept as its audit record. Adjustments above the approval
+// threshold of their currency are posted as an adjustment transaction only
+// once approved by an operator other than the requester; smaller ones are
+// posted on request, without a reviewer.
+type Adjustment struct {
+	ID          uuid.UUID           `json:"id"`
+	WalletID    uuid.UUID           `json:"wallet_id"`
+	Status      AdjustmentStatus    `json:"status"`
+	Direction   AdjustmentDirection `json:"direction"`
+	Amount      decimal.Decimal     `json:"amount" class:"financial"`
+	Currency    string              `json:"currency"`
+	ReasonCode  AdjustmentReason    `json:"reason_code"`
+	Note        string              `json:"note"`
+	RequestedBy string              `json:"requested_by"`
+	ReviewedBy  string              `json:"reviewed_by,omitempty"`
+	ReviewNote  string              `json:"review_note,omitempty"`
+	ReviewedAt  *time.Time          `json:"reviewed_at,omitempty"`
+	// TransactionID is the adjustment transaction, set once approved
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	PostedAt      *time.Time `json:"posted_at,omitempty"`
+	// ExpiresAt is when a pending adjustment expires unless reviewed
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// AdjustmentFilter selects the adjustments listed
+type AdjustmentFilter struct {
+	WalletID *uuid.UUID
+	Status   AdjustmentStatus
+	Limit    int
+}
+
+// AdjustmentTotal totals the posted adjustments of a reason code, direction
+// and currency
+type AdjustmentTotal struct {
+	ReasonCode AdjustmentReason    `json:"reason_code"`
+	Direction  AdjustmentDirection `json:"direction"`
+	Currency   string              `json:"currency"`
+	Count      int64               `json:"count"`
+	Total      decimal.Decimal     `json:"total" class:"financial"`
+}
+
+// AdjustmentReport totals the adjustments posted in a window, reported
+// apart from customer spend
+type AdjustmentReport struct {
+	From        time.Time          `json:"from"`
+	To          time.Time          `json:"to"`
+	Totals      []*AdjustmentTotal `json:"totals"`
+	GeneratedAt time.Time          `json:"generated_at"`
+}
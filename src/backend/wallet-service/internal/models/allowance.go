@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// TransactionQuantityKey is the metadata key counting the units of its
+// product a debit is for, such as OTP verifications; debits without it are
+// for one unit
+const TransactionQuantityKey = "quantity"
+
+// Metadata keys of debits covered in part or in full by a free allowance.
+// The debit's amount is what was left to pay after the free units.
+const (
+	MetadataAllowanceUnits  = "allowance_units"
+	MetadataAllowanceAmount = "allowance_amount"
+)
+
+// ProductAllowance is how many free units of a product a wallet has in a
+// cycle and how many it used
+type ProductAllowance struct {
+	Product   string `json:"product"`
+	Allowance int64  `json:"allowance"`
+	Used      int64  `json:"used"`
+	Remaining int64  `json:"remaining"`
+}
+
+// WalletAllowances are the free-tier allowances of a wallet in a billing
+// period, reset when the next period starts
+type WalletAllowances struct {
+	WalletID   uuid.UUID           `json:"wallet_id"`
+	Period     string              `json:"period"`
+	ResetsAt   time.Time           `json:"resets_at"`
+	Allowances []*ProductAllowance `json:"allowances"`
+}
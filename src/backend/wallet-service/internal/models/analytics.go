@@ -0,0 +1,10 @@
+package models
+
+// AdoptionAggregate holds raw adoption figures for one currency group. It never
+// carries wallet or customer identifiers.
+type AdoptionAggregate struct {
+	Currency      string
+	ActiveWallets int64
+	TopUpCount    int64
+	MedianTopUp   float64
+}
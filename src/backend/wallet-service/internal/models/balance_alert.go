@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// BalanceAlertBasis is what the value of a balance alert threshold is
+type BalanceAlertBasis string
+
+const (
+	// BalanceAlertAbsolute thresholds are a balance in the wallet's currency
+	BalanceAlertAbsolute BalanceAlertBasis = "ABSOLUTE"
+	// BalanceAlertUsagePercent thresholds are a percentage of the wallet's
+	// average monthly debits
+	BalanceAlertUsagePercent BalanceAlertBasis = "USAGE_PERCENT"
+)
+
+// IsValid checks if the basis is one of the defined bases
+func (b BalanceAlertBasis) IsValid() bool {
+	return b == BalanceAlertAbsolute || b == BalanceAlertUsagePercent
+}
+
+// BalanceAlertChannel is a channel a balance alert is sent on
+type BalanceAlertChannel string
+
+const (
+	// BalanceAlertEmail is sent to the customer's email, with consent
+	BalanceAlertEmail BalanceAlertChannel = "EMAIL"
+	// BalanceAlertSMS is sent to the customer's phone, with consent
+	BalanceAlertSMS BalanceAlertChannel = "SMS"
+	// BalanceAlertWebhook is delivered to the customer's webhook endpoint,
+	// which needs no consent
+	BalanceAlertWebhook BalanceAlertChannel = "WEBHOOK"
+)
+
+// IsValid checks if the channel is one of the defined channels
+func (c BalanceAlertChannel) IsValid() bool {
+	switch c {
+	case BalanceAlertEmail, BalanceAlertSMS, BalanceAlertWebhook:
+		return true
+	}
+	return false
+}
+
+// ConsentChannel returns the consent channel the alert channel needs, if
+// any
+func (c BalanceAlertChannel) ConsentChannel() (ConsentChannel, bool) {
+	switch c {
+	case BalanceAlertEmail:
+		return ConsentEmail, true
+	case BalanceAlertSMS:
+		return ConsentSMS, true
+	}
+	return "", false
+}
+
+// BalanceAlertThreshold is one of the balance alerts of a wallet. It is
+// edge-triggered: it alerts once as the balance falls below its trigger
+// amount and re-arms once a recharge brings the balance back up to it.
+type BalanceAlertThreshold struct {
+	ID       uuid.UUID         `json:"id"`
+	WalletID uuid.UUID         `json:"wallet_id"`
+	Basis    BalanceAlertBasis `json:"basis"`
+	// Value is the balance of absolute thresholds, or the percentage of
+	// the average monthly debits of usage thresholds
+	Value    decimal.Decimal       `json:"value"`
+	Channels []BalanceAlertChannel `json:"channels"`
+	// TriggerAmount is the balance the threshold alerts below, resolved
+	// from the usage of usage thresholds when set and on each re-arm
+	TriggerAmount decimal.Decimal `json:"trigger_amount" class:"financial"`
+	// Triggered is set from the alert until a recharge re-arms it
+	Triggered   bool       `json:"triggered"`
+	TriggeredAt *time.Time `json:"triggered_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// BalanceAlertLevel names a balance threshold of a wallet
+type BalanceAlertLevel string
+
+const (
+	// BalanceAlertWarning is the early warning that the balance runs low
+	BalanceAlertWarning BalanceAlertLevel = "warning"
+	// BalanceAlertCritical warns that the balance is nearly exhausted
+	BalanceAlertCritical BalanceAlertLevel = "critical"
+)
+
+// BalanceThresholdLevel is one alert level with hysteresis: it triggers as
+// the balance falls below TriggerPercent of the reference balance and
+// re-arms only once the balance rises above ResetPercent, so a balance
+// hovering around the trigger alerts once
+type BalanceThresholdLevel struct {
+	TriggerPercent float64 `json:"trigger_percent"`
+	ResetPercent   float64 `json:"reset_percent"`
+	// Triggered is set from the alert until the balance rises above the
+	// reset amount
+	Triggered bool `json:"triggered"`
+}
+
+// BalanceThresholds holds the balance alert levels of a wallet, as
+// percentages of a reference balance such as the usual top-up amount
+type BalanceThresholds struct {
+	WalletID         uuid.UUID             `json:"wallet_id"`
+	ReferenceBalance float64               `json:"reference_balance" class:"financial"`
+	Warning          BalanceThresholdLevel `json:"warning"`
+	Critical         BalanceThresholdLevel `json:"critical"`
+	UpdatedAt        time.Time             `json:"updated_at"`
+}
+
+// Level returns the thresholds of an alert level
+func (t *BalanceThresholds) Level(level BalanceAlertLevel) *BalanceThresholdLevel {
+	if level == BalanceAlertCritical {
+		return &t.Critical
+	}
+	return &t.Warning
+}
+
+// TriggerAmount is the balance below which an alert level triggers
+func (t *BalanceThresholds) TriggerAmount(level BalanceAlertLevel) float64 {
+	return t.ReferenceBalance * t.Level(level).TriggerPercent / 100
+}
+
+// ResetAmount is the balance above which a triggered alert level re-arms
+func (t *BalanceThresholds) ResetAmount(level BalanceAlertLevel) float64 {
+	return t.ReferenceBalance * t.Level(level).ResetPercent / 100
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// BillingMode is how a customer pays for what it uses
+type BillingMode string
+
+const (
+	// BillingPrepaid debits usage from the wallet as it is used
+	BillingPrepaid BillingMode = "PREPAID"
+	// BillingPostpaid accrues usage and bills it at the end of each monthly
+	// cycle
+	BillingPostpaid BillingMode = "POSTPAID"
+)
+
+// IsValid checks if the mode is one of the defined modes
+func (m BillingMode) IsValid() bool {
+	return m == BillingPrepaid || m == BillingPostpaid
+}
+
+// PostpaidSettlement is how the statement of a postpaid cycle is paid
+type PostpaidSettlement string
+
+const (
+	// SettleWallet debits the statement from the wallet, invoicing it
+	// instead when the wallet cannot cover it
+	SettleWallet PostpaidSettlement = "WALLET"
+	// SettleExternal invoices the statement for payment outside the wallet
+	SettleExternal PostpaidSettlement = "EXTERNAL"
+)
+
+// IsValid checks if the settlement is one of the defined settlements
+func (s PostpaidSettlement) IsValid() bool {
+	return s == SettleWallet || s == SettleExternal
+}
+
+// PostpaidCharge is a debit of a postpaid customer, accrued until the
+// statement of its cycle bills it
+type PostpaidCharge struct {
+	// ID is the ID of the debit the charge was accrued for
+	ID          uuid.UUID         `json:"id"`
+	CustomerID  uuid.UUID         `json:"customer_id"`
+	WalletID    uuid.UUID         `json:"wallet_id"`
+	Amount      decimal.Decimal   `json:"amount" class:"financial"`
+	Currency    string            `json:"currency"`
+	Description string            `json:"description,omitempty"`
+	ReferenceID string            `json:"reference_id,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	// StatementID is the statement billing the charge, nil while unbilled
+	StatementID *uuid.UUID `json:"statement_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// PostpaidStatementStatus is the settlement state of a postpaid statement
+type PostpaidStatementStatus string
+
+const (
+	// StatementPending is billed but not yet settled
+	StatementPending PostpaidStatementStatus = "PENDING"
+	// StatementSettled was debited from the wallet
+	StatementSettled PostpaidStatementStatus = "SETTLED"
+	// StatementInvoiced was invoiced for payment outside the wallet
+	StatementInvoiced PostpaidStatementStatus = "INVOICED"
+)
+
+// PostpaidStatement bills the charges a wallet accrued in a cycle
+type PostpaidStatement struct {
+	ID            uuid.UUID `json:"id"`
+	CustomerID    uuid.UUID `json:"customer_id"`
+	WalletID      uuid.UUID `json:"wallet_id"`
+	Currency      string    `json:"currency"`
+	InvoiceNumber string    `json:"invoice_number"`
+	// PeriodStart and PeriodEnd bound the cycle billed; charges accrued
+	// before the start and not billed yet are billed with it
+	PeriodStart time.Time               `json:"period_start"`
+	PeriodEnd   time.Time               `json:"period_end"`
+	Charges     int                     `json:"charges"`
+	TotalAmount decimal.Decimal         `json:"total_amount" class:"financial"`
+	Status      PostpaidStatementStatus `json:"status"`
+	// Settlement is how the statement was settled, empty while pending
+	Settlement PostpaidSettlement `json:"settlement,omitempty"`
+	// TransactionID is the debit settling the statement from the wallet
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	SettledAt     *time.Time `json:"settled_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// PostpaidInvoiceNumber returns the invoice number of the statement id of
+// the cycle starting at periodStart
+func PostpaidInvoiceNumber(periodStart time.Time, id uuid.UUID) string {
+	return fmt.Sprintf("PP-%s-%s", periodStart.UTC().Format("200601"), strings.ToUpper(id.String()[:8]))
+}
+
+// PostpaidUsage is what a wallet accrued and has not been billed for yet,
+// with its latest statements
+type PostpaidUsage struct {
+	WalletID        uuid.UUID            `json:"wallet_id"`
+	Currency        string               `json:"currency"`
+	BillingMode     BillingMode          `json:"billing_mode"`
+	UnbilledCharges int                  `json:"unbilled_charges"`
+	UnbilledAmount  decimal.Decimal      `json:"unbilled_amount" class:"financial"`
+	Statements      []*PostpaidStatement `json:"statements"`
+}
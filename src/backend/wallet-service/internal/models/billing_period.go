@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// BillingPeriodLayout formats billing periods, which are calendar months in UTC
+const BillingPeriodLayout = "2006-01"
+
+// BillingPeriodStatus is the close state of a billing period
+type BillingPeriodStatus string
+
+const (
+	// BillingPeriodOpen accepts postings
+	BillingPeriodOpen BillingPeriodStatus = "OPEN"
+	// BillingPeriodClosed was closed by finance and refuses postings, such
+	// as backdated transactions, until it is reopened
+	BillingPeriodClosed BillingPeriodStatus = "CLOSED"
+)
+
+// BillingPeriod is the close state of a billing period. Periods never
+// closed are open and have no stored state. Reason is the one given for the
+// latest close or reopening; the audit log keeps the full history.
+type BillingPeriod struct {
+	Period     string              `json:"period"`
+	Status     BillingPeriodStatus `json:"status"`
+	ClosedBy   string              `json:"closed_by,omitempty"`
+	ClosedAt   *time.Time          `json:"closed_at,omitempty"`
+	ReopenedBy string              `json:"reopened_by,omitempty"`
+	ReopenedAt *time.Time          `json:"reopened_at,omitempty"`
+	Reason     string              `json:"reason,omitempty"`
+	UpdatedAt  *time.Time          `json:"updated_at,omitempty"`
+}
+
+// BillingPeriodOf returns the first instant of the billing period holding t
+func BillingPeriodOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
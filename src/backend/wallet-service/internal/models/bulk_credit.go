@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// BulkCreditRowStatus is the outcome of a wallet of a bulk credit operation
+type BulkCreditRowStatus string
+
+const (
+	// BulkCreditRowPending has not been credited yet
+	BulkCreditRowPending BulkCreditRowStatus = "PENDING"
+	// BulkCreditRowPosted credited the wallet
+	BulkCreditRowPosted BulkCreditRowStatus = "POSTED"
+	// BulkCreditRowFailed could not be credited, as Error explains
+	BulkCreditRowFailed BulkCreditRowStatus = "FAILED"
+	// BulkCreditRowReversed had its credit reversed by a rollback
+	BulkCreditRowReversed BulkCreditRowStatus = "REVERSED"
+	// BulkCreditRowNotReversed kept its credit when the operation was
+	// rolled back, as Error explains
+	BulkCreditRowNotReversed BulkCreditRowStatus = "NOT_REVERSED"
+)
+
+// BulkCredit is an operation crediting one amount to many wallets, such as
+// goodwill credits after an incident
+type BulkCredit struct {
+	ID          uuid.UUID       `json:"id"`
+	Amount      decimal.Decimal `json:"amount" class:"financial"`
+	Currency    string          `json:"currency"`
+	Description string          `json:"description"`
+	TotalRows   int             `json:"total_rows"`
+	// JobID is the report job posting the credits, set once it is submitted
+	JobID *uuid.UUID `json:"job_id,omitempty"`
+	// RollbackJobID is the report job reversing the posted credits, once
+	// the operation was rolled back
+	RollbackJobID       *uuid.UUID `json:"rollback_job_id,omitempty"`
+	CreatedBy           string     `json:"created_by"`
+	RollbackRequestedBy string     `json:"rollback_requested_by,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	RollbackRequestedAt *time.Time `json:"rollback_requested_at,omitempty"`
+}
+
+// BulkCreditRow is one wallet to credit in a bulk credit operation
+type BulkCreditRow struct {
+	// Index is the position of the row in the submitted operation, from 0
+	Index int `json:"index"`
+	// WalletID is as submitted, and may not be a valid UUID
+	WalletID      string              `json:"wallet_id"`
+	Status        BulkCreditRowStatus `json:"status"`
+	TransactionID *uuid.UUID          `json:"transaction_id,omitempty"`
+	ReversalID    *uuid.UUID          `json:"reversal_id,omitempty"`
+	Error         string              `json:"error,omitempty"`
+}
+
+// BulkCreditResult is the outcome of a bulk credit operation, the result of
+// its credit and rollback jobs
+type BulkCreditResult struct {
+	BulkCreditID uuid.UUID        `json:"bulk_credit_id"`
+	Total        int              `json:"total"`
+	Pending      int              `json:"pending"`
+	Posted       int              `json:"posted"`
+	Failed       int              `json:"failed"`
+	Reversed     int              `json:"reversed"`
+	NotReversed  int              `json:"not_reversed"`
+	Rows         []*BulkCreditRow `json:"rows"`
+}
+
+// BulkCreditDetails is a bulk credit operation with the outcome of its rows
+type BulkCreditDetails struct {
+	BulkCredit *BulkCredit       `json:"bulk_credit"`
+	Result     *BulkCreditResult `json:"result"`
+}
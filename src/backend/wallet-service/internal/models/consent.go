@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// ConsentChannel is a channel customers are notified on
+type ConsentChannel string
+
+const (
+	// ConsentEmail covers emails such as activity digests
+	ConsentEmail ConsentChannel = "EMAIL"
+	// ConsentSMS covers SMS alerts such as low balance warnings
+	ConsentSMS ConsentChannel = "SMS"
+)
+
+// ConsentChannels lists every channel consent is recorded for
+var ConsentChannels = []ConsentChannel{ConsentEmail, ConsentSMS}
+
+// ConsentSource is where a consent change was made
+type ConsentSource string
+
+const (
+	// ConsentSourcePortal is the customer portal
+	ConsentSourcePortal ConsentSource = "PORTAL"
+	// ConsentSourceSupport is a support agent acting on the customer's request
+	ConsentSourceSupport ConsentSource = "SUPPORT"
+	// ConsentSourceAPI is an API client integrated by the customer
+	ConsentSourceAPI ConsentSource = "API"
+)
+
+// CustomerConsent is a customer's consent to notifications on a channel.
+// Customers are only notified on channels they consented to; channels
+// without a recorded change are reported as not granted with no source.
+type CustomerConsent struct {
+	CustomerID uuid.UUID      `json:"customer_id"`
+	Channel    ConsentChannel `json:"channel"`
+	Granted    bool           `json:"granted"`
+	Source     ConsentSource  `json:"source,omitempty"`
+	RecordedAt *time.Time     `json:"recorded_at,omitempty"`
+}
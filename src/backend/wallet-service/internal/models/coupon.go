@@ -0,0 +1,125 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// DiscountType is how a coupon's value discounts an amount
+type DiscountType string
+
+const (
+	// DiscountPercentage takes a percentage off the amount
+	DiscountPercentage DiscountType = "PERCENTAGE"
+	// DiscountFixed takes a fixed amount off, at most the whole amount
+	DiscountFixed DiscountType = "FIXED"
+)
+
+// Valid reports whether the discount type is known
+func (t DiscountType) Valid() bool {
+	return t == DiscountPercentage || t == DiscountFixed
+}
+
+// CouponStatus is whether a coupon can still be redeemed
+type CouponStatus string
+
+const (
+	// CouponActive coupons are redeemable within their validity
+	CouponActive CouponStatus = "ACTIVE"
+	// CouponVoided coupons were withdrawn by an operator
+	CouponVoided CouponStatus = "VOIDED"
+)
+
+// RedemptionSource is when a coupon was redeemed
+type RedemptionSource string
+
+const (
+	// RedemptionRating is a coupon redeemed on a charge as it is rated,
+	// referenced by the charge
+	RedemptionRating RedemptionSource = "RATING"
+	// RedemptionInvoice is a coupon redeemed on an invoice as it is
+	// generated, referenced by its invoice number
+	RedemptionInvoice RedemptionSource = "INVOICE"
+)
+
+// Coupon is a discount redeemable by its code. A coupon restricted to a
+// customer is a negotiated discount.
+type Coupon struct {
+	ID          uuid.UUID    `json:"id"`
+	Code        string       `json:"code"`
+	Description string       `json:"description,omitempty"`
+	Type        DiscountType `json:"discount_type"`
+	// Value is the percentage off for percentage coupons and the amount
+	// off for fixed coupons
+	Value decimal.Decimal `json:"value"`
+	// Currency is the currency of fixed coupons; percentage coupons apply
+	// to any currency
+	Currency   string     `json:"currency,omitempty"`
+	CustomerID *uuid.UUID `json:"customer_id,omitempty"`
+	ValidFrom  time.Time  `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	// MaxRedemptions and MaxRedemptionsPerCustomer are unlimited when unset
+	MaxRedemptions            *int64       `json:"max_redemptions,omitempty"`
+	MaxRedemptionsPerCustomer *int64       `json:"max_redemptions_per_customer,omitempty"`
+	Redemptions               int64        `json:"redemptions"`
+	Status                    CouponStatus `json:"status"`
+	CreatedBy                 string       `json:"created_by"`
+	CreatedAt                 time.Time    `json:"created_at"`
+	VoidedBy                  string       `json:"voided_by,omitempty"`
+	VoidReason                string       `json:"void_reason,omitempty"`
+	VoidedAt                  *time.Time   `json:"voided_at,omitempty"`
+}
+
+// ValidAt reports whether t is within the coupon's validity
+func (c *Coupon) ValidAt(t time.Time) bool {
+	return !t.Before(c.ValidFrom) && (c.ValidUntil == nil || t.Before(*c.ValidUntil))
+}
+
+// Discount returns the discount of an amount before rounding, never more
+// than the amount
+func (c *Coupon) Discount(amount decimal.Decimal) decimal.Decimal {
+	discount := c.Value
+	if c.Type == DiscountPercentage {
+		discount = amount.Mul(c.Value).Div(decimal.NewFromInt(100))
+	}
+	if discount.GreaterThan(amount) {
+		return amount
+	}
+	return discount
+}
+
+// CouponRedemption is the audit record of a coupon redeemed on a rated
+// charge or an invoice
+type CouponRedemption struct {
+	ID         uuid.UUID        `json:"id"`
+	CouponID   uuid.UUID        `json:"coupon_id"`
+	Code       string           `json:"code"`
+	CustomerID uuid.UUID        `json:"customer_id"`
+	Source     RedemptionSource `json:"source"`
+	Reference  string           `json:"reference"`
+	Currency   string           `json:"currency"`
+	// Amount is the amount before the discount
+	Amount     decimal.Decimal `json:"amount" class:"financial"`
+	Discount   decimal.Decimal `json:"discount" class:"financial"`
+	RedeemedBy string          `json:"redeemed_by"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// CouponDetails is a coupon with its latest redemptions
+type CouponDetails struct {
+	Coupon      *Coupon             `json:"coupon"`
+	Redemptions []*CouponRedemption `json:"redemptions"`
+}
+
+// CouponQuote is the discount a coupon would give an amount if redeemed
+type CouponQuote struct {
+	CouponID uuid.UUID `json:"coupon_id"`
+	Code     string    `json:"code"`
+	Currency string    `json:"currency"`
+	// Amount is the amount before the discount and Total the amount after
+	Amount   decimal.Decimal `json:"amount" class:"financial"`
+	Discount decimal.Decimal `json:"discount" class:"financial"`
+	Total    decimal.Decimal `json:"total" class:"financial"`
+}
@@ -0,0 +1,43 @@
+package models
+
+// CreditLimitWarningCode identifies credit limit warnings in API responses
+const CreditLimitWarningCode = "CREDIT_LIMIT_APPROACHING"
+
+// CreditLimitWarningLevels are the fractions of the credit limit in use from
+// which debits carry a warning, ahead of their rejection once it is exhausted
+var CreditLimitWarningLevels = []float64{0.8, 0.9}
+
+// CreditLimitWarning reports that a wallet is close to exhausting its credit
+// limit, so integrators can warn end users before debits start failing
+type CreditLimitWarning struct {
+	Code string `json:"code"`
+	// Level is the highest warning level the utilization reached
+	Level            float64 `json:"level"`
+	Utilization      float64 `json:"utilization" class:"financial"`
+	CreditLimit      float64 `json:"credit_limit" class:"financial"`
+	AvailableBalance float64 `json:"available_balance" class:"financial"`
+}
+
+// CreditLimitWarning returns the warning for the wallet's credit utilization,
+// or nil while it is below every warning level
+func (w *Wallet) CreditLimitWarning() *CreditLimitWarning {
+	utilization := w.CreditUtilization()
+
+	var level float64
+	for _, l := range CreditLimitWarningLevels {
+		if utilization >= l {
+			level = l
+		}
+	}
+	if level == 0 {
+		return nil
+	}
+
+	return &CreditLimitWarning{
+		Code:             CreditLimitWarningCode,
+		Level:            level,
+		Utilization:      utilization,
+		CreditLimit:      w.CreditLimit,
+		AvailableBalance: w.AvailableBalance(),
+	}
+}
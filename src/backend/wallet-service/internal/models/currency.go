@@ -0,0 +1,85 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// Currency is a currency transactions may use, with the number of decimal
+// places its amounts are rounded to and how they are rounded
+type Currency struct {
+	Code      string `json:"code"`
+	Precision int32  `json:"precision"`
+	// Rounding defaults to RoundHalfUp
+	Rounding RoundingMode `json:"rounding"`
+}
+
+// Positions of a currency symbol relative to the amount
+const (
+	SymbolBefore = "before"
+	SymbolAfter  = "after"
+)
+
+// CurrencyFormat holds the hints clients display amounts of a currency
+// with, so that no client hard-codes formatting rules
+type CurrencyFormat struct {
+	Code   string `json:"code"`
+	Symbol string `json:"symbol"`
+	// DecimalPlaces is the precision amounts are rounded to
+	DecimalPlaces  int32  `json:"decimal_places"`
+	SymbolPosition string `json:"symbol_position"`
+	// SymbolSpacing separates the symbol from the amount with a space
+	SymbolSpacing    bool   `json:"symbol_spacing"`
+	DecimalSeparator string `json:"decimal_separator"`
+	GroupSeparator   string `json:"group_separator"`
+	// Grouping holds the sizes of digit groups from the decimal separator
+	// leftwards, the last repeating: [3] for 1,234,567 and [3, 2] for
+	// 12,34,567
+	Grouping []int `json:"grouping"`
+	// Locale is the BCP 47 tag of the locale the hints follow
+	Locale string `json:"locale"`
+}
+
+// Format renders an amount by the hints, e.g. ₹1,23,456.78
+func (f CurrencyFormat) Format(amount decimal.Decimal) string {
+	amount = amount.Round(f.DecimalPlaces)
+	digits := amount.Abs().StringFixed(f.DecimalPlaces)
+	whole, fraction, _ := strings.Cut(digits, ".")
+
+	var groups []string
+	for i := 0; len(whole) > 0; i++ {
+		size := len(whole)
+		if len(f.Grouping) > 0 {
+			size = f.Grouping[len(f.Grouping)-1]
+			if i < len(f.Grouping) {
+				size = f.Grouping[i]
+			}
+		}
+		if size <= 0 || size > len(whole) {
+			size = len(whole)
+		}
+		groups = append([]string{whole[len(whole)-size:]}, groups...)
+		whole = whole[:len(whole)-size]
+	}
+
+	text := strings.Join(groups, f.GroupSeparator)
+	if fraction != "" {
+		text += f.DecimalSeparator + fraction
+	}
+
+	space := ""
+	if f.SymbolSpacing {
+		space = " "
+	}
+	if f.SymbolPosition == SymbolAfter {
+		text = text + space + f.Symbol
+	} else {
+		text = f.Symbol + space + text
+	}
+
+	if amount.IsNegative() {
+		return "-" + text
+	}
+	return text
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// DefaultTimezone is the timezone of customers that never set one
+const DefaultTimezone = "UTC"
+
+// CustomerSettings holds a customer's account-wide preferences
+type CustomerSettings struct {
+	CustomerID uuid.UUID `json:"customer_id"`
+	// Timezone is the IANA zone whose midnight cuts off the customer's
+	// digest periods and daily activity aggregates
+	Timezone string `json:"timezone"`
+	// TaxRegion is the ISO 3166 country or subdivision code whose tax
+	// rules apply to the customer; customers without one are not taxed
+	TaxRegion string `json:"tax_region,omitempty"`
+	// TaxExempt marks customers holding a tax exemption
+	TaxExempt bool `json:"tax_exempt"`
+	// PaymentTerms are how long the customer has to pay invoices;
+	// customers without terms of their own get the deployment's default
+	PaymentTerms PaymentTerms `json:"payment_terms,omitempty"`
+	// BillingMode is whether the customer's debits are taken from the
+	// wallet as used or accrued and billed at the end of each cycle
+	BillingMode BillingMode `json:"billing_mode"`
+	// PostpaidSettlement is how the statements of a postpaid customer are
+	// paid; postpaid customers without one settle against the wallet
+	PostpaidSettlement PostpaidSettlement `json:"postpaid_settlement,omitempty"`
+	// OrgID is the organization the customer belongs to, if any
+	OrgID     *uuid.UUID `json:"org_id,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// CustomerWalletBalance is the balance of one of a customer's wallets and
+// its value in the reporting currency
+type CustomerWalletBalance struct {
+	WalletID         uuid.UUID       `json:"wallet_id"`
+	Currency         string          `json:"currency"`
+	Balance          decimal.Decimal `json:"balance" class:"financial"`
+	AvailableBalance decimal.Decimal `json:"available_balance" class:"financial"`
+	// Rate and Converted are omitted for wallets in a currency without an
+	// exchange rate to the reporting currency
+	Rate      *decimal.Decimal `json:"rate,omitempty"`
+	Converted *decimal.Decimal `json:"converted_balance,omitempty" class:"financial"`
+}
+
+// CustomerBalance aggregates the balances of a customer's wallets, which
+// hold one currency each, in a reporting currency
+type CustomerBalance struct {
+	CustomerID        uuid.UUID                `json:"customer_id"`
+	ReportingCurrency string                   `json:"reporting_currency"`
+	Total             decimal.Decimal          `json:"total" class:"financial"`
+	Wallets           []*CustomerWalletBalance `json:"wallets"`
+	// UnconvertedCurrencies lists the wallet currencies left out of Total
+	// for lack of an exchange rate
+	UnconvertedCurrencies []string  `json:"unconverted_currencies,omitempty"`
+	AsOf                  time.Time `json:"as_of"`
+}
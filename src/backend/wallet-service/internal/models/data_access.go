@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// DataAccessAction is what a request did with a customer's financial data
+type DataAccessAction string
+
+const (
+	// DataAccessBalanceViewed is a read of wallet balances
+	DataAccessBalanceViewed DataAccessAction = "BALANCE_VIEWED"
+	// DataAccessTransactionsViewed is a read of transaction history
+	DataAccessTransactionsViewed DataAccessAction = "TRANSACTIONS_VIEWED"
+	// DataAccessTransactionsExported is a bulk export of transactions
+	DataAccessTransactionsExported DataAccessAction = "TRANSACTIONS_EXPORTED"
+)
+
+// IsValid checks if the action is one of the defined actions
+func (a DataAccessAction) IsValid() bool {
+	switch a {
+	case DataAccessBalanceViewed, DataAccessTransactionsViewed, DataAccessTransactionsExported:
+		return true
+	}
+	return false
+}
+
+// DataAccessorKind is the kind of credential that accessed a customer's data
+type DataAccessorKind string
+
+const (
+	// DataAccessorAPIKey is an API key without a staff or service role
+	DataAccessorAPIKey DataAccessorKind = "API_KEY"
+	// DataAccessorAdmin is an operator
+	DataAccessorAdmin DataAccessorKind = "ADMIN"
+	// DataAccessorSupport is billing support staff
+	DataAccessorSupport DataAccessorKind = "SUPPORT"
+	// DataAccessorService is an internal service
+	DataAccessorService DataAccessorKind = "SERVICE"
+	// DataAccessorAnalytics is finance or analytics tooling
+	DataAccessorAnalytics DataAccessorKind = "ANALYTICS"
+)
+
+// DataAccess is an audited access to a customer's balances or transactions
+type DataAccess struct {
+	ID uuid.UUID `json:"id"`
+	// CustomerID is the customer whose data was accessed, nil for accesses
+	// to every customer's data such as unfiltered exports. Accesses naming
+	// only a wallet are attributed to its owner when stored.
+	CustomerID   *uuid.UUID       `json:"customer_id,omitempty"`
+	WalletID     *uuid.UUID       `json:"wallet_id,omitempty"`
+	Action       DataAccessAction `json:"action"`
+	AccessorKind DataAccessorKind `json:"accessor_kind"`
+	// Subject and AccessorCustomerID identify the credential; they and the
+	// fields below are internal and redacted from customer access logs
+	Subject            string     `json:"subject,omitempty"`
+	AccessorCustomerID *uuid.UUID `json:"accessor_customer_id,omitempty"`
+	Roles              []string   `json:"roles"`
+	Method             string     `json:"method"`
+	Route              string     `json:"route"`
+	CorrelationID      string     `json:"correlation_id"`
+	AccessedAt         time.Time  `json:"accessed_at"`
+}
+
+// CustomerDataAccess is an entry of a customer's access log, with internal
+// details redacted
+type CustomerDataAccess struct {
+	ID           uuid.UUID        `json:"id"`
+	Action       DataAccessAction `json:"action"`
+	AccessorKind DataAccessorKind `json:"accessor_kind"`
+	// Accessor is the subject of the API key, shown only for the customer's
+	// own keys
+	Accessor   string     `json:"accessor,omitempty"`
+	WalletID   *uuid.UUID `json:"wallet_id,omitempty"`
+	AccessedAt time.Time  `json:"accessed_at"`
+}
+
+// DataAccessFilter selects entries of a customer's access log. Zero fields
+// match any.
+type DataAccessFilter struct {
+	From   time.Time
+	To     time.Time
+	Action DataAccessAction
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// Entities whose amounts are verified during the float to decimal migration
+const (
+	DecimalEntityWallet      = "wallet"
+	DecimalEntityTransaction = "transaction"
+)
+
+// ExactWalletValues are the NUMERIC wallet columns read without float64
+// conversion
+type ExactWalletValues struct {
+	Balance             decimal.Decimal
+	CreditLimit         decimal.Decimal
+	LowBalanceThreshold decimal.Decimal
+}
+
+// DecimalMismatch records a value whose float64 reading differs from its
+// exact decimal reading by more than the verification tolerance
+type DecimalMismatch struct {
+	ID       uuid.UUID `json:"id"`
+	Entity   string    `json:"entity"`
+	EntityID uuid.UUID `json:"entity_id"`
+	Field    string    `json:"field"`
+	// FloatValue is the value the service computed with, DecimalValue the
+	// exact value and Drift the absolute difference between them
+	FloatValue   float64         `json:"float_value"`
+	DecimalValue decimal.Decimal `json:"decimal_value"`
+	Drift        decimal.Decimal `json:"drift"`
+	DetectedAt   time.Time       `json:"detected_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// DeprecatedRouteUsage is the calls of one caller to a deprecated route
+type DeprecatedRouteUsage struct {
+	Method    string
+	Route     string
+	Caller    string
+	Requests  int64
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// DeprecationReport lists the integrations still calling deprecated routes,
+// those with the earliest sunset first
+type DeprecationReport struct {
+	// Since is the start of the period callers were seen in
+	Since        time.Time                `json:"since"`
+	Integrations []*DeprecatedIntegration `json:"integrations"`
+}
+
+// DeprecatedIntegration is a caller that must migrate off deprecated routes
+type DeprecatedIntegration struct {
+	Caller string `json:"caller"`
+	// Sunset is the earliest sunset of the routes it calls, if any is planned
+	Sunset   *time.Time             `json:"sunset,omitempty"`
+	LastSeen time.Time              `json:"last_seen"`
+	Routes   []*DeprecatedRouteCall `json:"routes"`
+}
+
+// DeprecatedRouteCall is the use of one deprecated route by an integration
+type DeprecatedRouteCall struct {
+	Name       string     `json:"name"`
+	Method     string     `json:"method"`
+	Route      string     `json:"route"`
+	Requests   int64      `json:"requests"`
+	FirstSeen  time.Time  `json:"first_seen"`
+	LastSeen   time.Time  `json:"last_seen"`
+	Deprecated time.Time  `json:"deprecated"`
+	Sunset     *time.Time `json:"sunset,omitempty"`
+	Link       string     `json:"link,omitempty"`
+}
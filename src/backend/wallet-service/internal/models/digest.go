@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// DigestFrequency is how often a customer receives activity digests
+type DigestFrequency string
+
+const (
+	// DigestWeekly sends a digest every Monday for the previous week, in the
+	// customer's timezone
+	DigestWeekly DigestFrequency = "WEEKLY"
+	// DigestMonthly sends a digest on the first of the month for the previous
+	// month, in the customer's timezone
+	DigestMonthly DigestFrequency = "MONTHLY"
+)
+
+// UncategorizedSpend is the category of transactions without one in metadata
+const UncategorizedSpend = "uncategorized"
+
+// DigestSubscription is a customer's opt-in for activity digests. Digest
+// periods end at midnight in Timezone, the customer's timezone.
+type DigestSubscription struct {
+	CustomerID    uuid.UUID       `json:"customer_id"`
+	Frequency     DigestFrequency `json:"frequency"`
+	Enabled       bool            `json:"enabled"`
+	LastPeriodEnd *time.Time      `json:"last_period_end,omitempty"`
+	Timezone      string          `json:"timezone,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// CategorySpend totals a wallet's debits in one category
+type CategorySpend struct {
+	Category string          `json:"category"`
+	Count    int64           `json:"count"`
+	Total    decimal.Decimal `json:"total"`
+}
+
+// WalletActivity summarises a wallet over a digest period
+type WalletActivity struct {
+	WalletID       uuid.UUID        `json:"wallet_id"`
+	Currency       string           `json:"currency"`
+	TopUpCount     int64            `json:"top_up_count"`
+	TopUpTotal     decimal.Decimal  `json:"top_up_total"`
+	RefundTotal    decimal.Decimal  `json:"refund_total"`
+	Spend          []*CategorySpend `json:"spend"`
+	OpeningBalance decimal.Decimal  `json:"opening_balance"`
+	ClosingBalance decimal.Decimal  `json:"closing_balance"`
+}
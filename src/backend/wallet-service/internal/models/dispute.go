@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// DisputeStatus is the state of a chargeback dispute
+type DisputeStatus string
+
+const (
+	// DisputeOpen was opened and holds the disputed amount
+	DisputeOpen DisputeStatus = "OPEN"
+	// DisputeEvidenceSubmitted awaits the provider's decision on the
+	// evidence submitted
+	DisputeEvidenceSubmitted DisputeStatus = "EVIDENCE_SUBMITTED"
+	// DisputeWon was decided for the merchant; the hold was released
+	DisputeWon DisputeStatus = "WON"
+	// DisputeLost was decided for the cardholder; the held amount was
+	// reversed out of the wallet
+	DisputeLost DisputeStatus = "LOST"
+)
+
+// IsValid checks if the status is one of the defined statuses
+func (s DisputeStatus) IsValid() bool {
+	switch s {
+	case DisputeOpen, DisputeEvidenceSubmitted, DisputeWon, DisputeLost:
+		return true
+	}
+	return false
+}
+
+// IsResolved reports whether the dispute was decided
+func (s DisputeStatus) IsResolved() bool {
+	return s == DisputeWon || s == DisputeLost
+}
+
+// DisputeReason is the reason code a chargeback was raised with
+type DisputeReason string
+
+const (
+	// DisputeReasonFraud disputes a payment the cardholder did not make
+	DisputeReasonFraud DisputeReason = "FRAUD"
+	// DisputeReasonUnrecognized disputes a payment the cardholder does not
+	// recognize
+	DisputeReasonUnrecognized DisputeReason = "UNRECOGNIZED"
+	// DisputeReasonDuplicate disputes a payment taken twice
+	DisputeReasonDuplicate DisputeReason = "DUPLICATE"
+	// DisputeReasonProcessingError disputes a wrongly processed payment
+	DisputeReasonProcessingError DisputeReason = "PROCESSING_ERROR"
+	// DisputeReasonOther covers the remaining reasons, told by the note
+	DisputeReasonOther DisputeReason = "OTHER"
+)
+
+// DisputeReasons lists the reason codes in a stable order
+var DisputeReasons = []DisputeReason{
+	DisputeReasonFraud,
+	DisputeReasonUnrecognized,
+	DisputeReasonDuplicate,
+	DisputeReasonProcessingError,
+	DisputeReasonOther,
+}
+
+// IsValid checks if the reason is one of the defined reason codes
+func (r DisputeReason) IsValid() bool {
+	for _, reason := range DisputeReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispute is a chargeback of a wallet top-up. Opening it holds the
+// disputed amount out of the balance with a PROCESSING debit, which is
+// completed when the dispute is lost and reversed when it is won.
+type Dispute struct {
+	ID                uuid.UUID       `json:"id"`
+	WalletID          uuid.UUID       `json:"wallet_id"`
+	TransactionID     uuid.UUID       `json:"transaction_id"`
+	HoldTransactionID uuid.UUID       `json:"hold_transaction_id"`
+	Amount            decimal.Decimal `json:"amount" class:"financial"`
+	Currency          string          `json:"currency"`
+	Status            DisputeStatus   `json:"status"`
+	ReasonCode        DisputeReason   `json:"reason_code"`
+	Note              string          `json:"note"`
+	// ProviderCaseID is the chargeback's case at the payment provider
+	ProviderCaseID      string     `json:"provider_case_id,omitempty"`
+	Evidence            string     `json:"evidence,omitempty"`
+	EvidenceSubmittedAt *time.Time `json:"evidence_submitted_at,omitempty"`
+	ResolutionNote      string     `json:"resolution_note,omitempty"`
+	OpenedBy            string     `json:"opened_by"`
+	ResolvedBy          string     `json:"resolved_by,omitempty"`
+	ResolvedAt          *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// DisputeFilter selects the disputes listed
+type DisputeFilter struct {
+	WalletID *uuid.UUID
+	Status   DisputeStatus
+	Limit    int
+}
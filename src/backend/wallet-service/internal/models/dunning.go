@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// DunningStatus is the state of a dunning case
+type DunningStatus string
+
+const (
+	// DunningInGrace means the balance ran out and the grace period is running
+	DunningInGrace DunningStatus = "IN_GRACE"
+	// DunningSuspended means the grace period expired and service was suspended
+	DunningSuspended DunningStatus = "SUSPENDED"
+	// DunningResolved means a top-up restored a positive balance
+	DunningResolved DunningStatus = "RESOLVED"
+)
+
+// SuspendReasonGraceExpired is the suspension reason published when a dunning
+// grace period expires without a top-up
+const SuspendReasonGraceExpired = "DUNNING_GRACE_EXPIRED"
+
+// DunningCase tracks a wallet from the moment its balance reached zero or
+// below until a top-up restores it
+type DunningCase struct {
+	ID           uuid.UUID       `json:"id"`
+	WalletID     uuid.UUID       `json:"wallet_id"`
+	CustomerID   uuid.UUID       `json:"customer_id"`
+	Balance      decimal.Decimal `json:"balance"`
+	Currency     string          `json:"currency"`
+	StartedAt    time.Time       `json:"started_at"`
+	GraceEndsAt  time.Time       `json:"grace_ends_at"`
+	NoticesSent  int             `json:"notices_sent"`
+	NextNoticeAt *time.Time      `json:"next_notice_at,omitempty"`
+	SuspendedAt  *time.Time      `json:"suspended_at,omitempty"`
+	ResolvedAt   *time.Time      `json:"resolved_at,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// Status derives the case status from its timestamps
+func (d *DunningCase) Status() DunningStatus {
+	switch {
+	case d.ResolvedAt != nil:
+		return DunningResolved
+	case d.SuspendedAt != nil:
+		return DunningSuspended
+	default:
+		return DunningInGrace
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// ChargeEstimate is the breakdown of what a debit would be charged, priced
+// as it would be posted but without changing anything. Amounts up to
+// Subtotal are in the charge's currency and the rest in the wallet's.
+type ChargeEstimate struct {
+	WalletID uuid.UUID       `json:"wallet_id"`
+	Currency string          `json:"currency"`
+	Amount   decimal.Decimal `json:"amount" class:"financial"`
+	// Coupon is the coupon discounting the amount, if one was named
+	Coupon   *CouponQuote    `json:"coupon,omitempty"`
+	Discount decimal.Decimal `json:"discount" class:"financial"`
+	// Fee is the fee of the discounted amount under the fee rule in effect
+	Fee       decimal.Decimal `json:"fee" class:"financial"`
+	FeeRuleID *uuid.UUID      `json:"fee_rule_id,omitempty"`
+	// Subtotal is the amount less the discount plus the fee
+	Subtotal       decimal.Decimal `json:"subtotal" class:"financial"`
+	WalletCurrency string          `json:"wallet_currency"`
+	// ExchangeRate converts the subtotal into the wallet's currency when
+	// the currencies differ
+	ExchangeRate *decimal.Decimal `json:"exchange_rate,omitempty"`
+	Converted    decimal.Decimal  `json:"converted" class:"financial"`
+	// Tax is the tax charged on the converted subtotal, when debits are
+	// taxed
+	Tax *TaxBreakdown `json:"tax,omitempty"`
+	// Total is what the wallet would be debited
+	Total            decimal.Decimal `json:"total" class:"financial"`
+	AvailableBalance decimal.Decimal `json:"available_balance" class:"financial"`
+	// SufficientBalance reports whether the wallet could be debited the
+	// total, down to its credit limit. It always holds for postpaid
+	// customers, whose debits are billed at the end of the cycle.
+	SufficientBalance bool        `json:"sufficient_balance"`
+	BillingMode       BillingMode `json:"billing_mode"`
+	At                time.Time   `json:"at"`
+}
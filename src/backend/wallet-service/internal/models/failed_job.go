@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// FailedJobKind is the kind of background work a failed job retries
+type FailedJobKind string
+
+const (
+	// FailedJobWebhookDelivery is an event a customer webhook endpoint did
+	// not accept
+	FailedJobWebhookDelivery FailedJobKind = "WEBHOOK_DELIVERY"
+	// FailedJobRecurringDebitRun is a recurring debit run the wallet
+	// rejected, e.g. for insufficient balance
+	FailedJobRecurringDebitRun FailedJobKind = "RECURRING_DEBIT_RUN"
+)
+
+// IsValid reports whether the kind is known
+func (k FailedJobKind) IsValid() bool {
+	switch k {
+	case FailedJobWebhookDelivery, FailedJobRecurringDebitRun:
+		return true
+	}
+	return false
+}
+
+// FailedJobStatus is the state of a failed job
+type FailedJobStatus string
+
+const (
+	// FailedJobRetrying awaits its next automatic attempt
+	FailedJobRetrying FailedJobStatus = "RETRYING"
+	// FailedJobDead ran out of attempts and awaits an operator
+	FailedJobDead FailedJobStatus = "DEAD"
+	// FailedJobSucceeded succeeded on a retry
+	FailedJobSucceeded FailedJobStatus = "SUCCEEDED"
+	// FailedJobDiscarded was discarded by an operator, or no longer applied
+	// when retried
+	FailedJobDiscarded FailedJobStatus = "DISCARDED"
+)
+
+// IsValid reports whether the status is known
+func (s FailedJobStatus) IsValid() bool {
+	switch s {
+	case FailedJobRetrying, FailedJobDead, FailedJobSucceeded, FailedJobDiscarded:
+		return true
+	}
+	return false
+}
+
+// FailedJob is background work that failed and is retried under the retry
+// policy of its kind, then dead-lettered once out of attempts for an
+// operator to retry or discard. A job is open while retrying or dead, and only one
+// job is open per reference.
+type FailedJob struct {
+	ID   uuid.UUID     `json:"id"`
+	Kind FailedJobKind `json:"kind"`
+	// Reference identifies the failed work within its kind, such as the
+	// subscription and event of a webhook delivery
+	Reference string `json:"reference"`
+	// Payload holds what the retrier of the kind needs to repeat the work
+	Payload       json.RawMessage `json:"payload"`
+	Status        FailedJobStatus `json:"status"`
+	Attempts      int             `json:"attempts"`
+	MaxAttempts   int             `json:"max_attempts"`
+	LastError     string          `json:"last_error"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	DeadAt        *time.Time      `json:"dead_at,omitempty"`
+	ResolvedAt    *time.Time      `json:"resolved_at,omitempty"`
+	// ResolvedBy is the operator who discarded the job, empty when it was
+	// resolved by a retry
+	ResolvedBy string `json:"resolved_by,omitempty"`
+	Resolution string `json:"resolution,omitempty"`
+}
+
+// Open reports whether the job is retrying or dead
+func (j *FailedJob) Open() bool {
+	return j.Status == FailedJobRetrying || j.Status == FailedJobDead
+}
+
+// FailedJobFilter narrows a failed job listing; zero fields match all
+type FailedJobFilter struct {
+	Kind   FailedJobKind
+	Status FailedJobStatus
+	Limit  int
+}
+
+// FailedJobOutcome is the result of a bulk operation on one failed job
+type FailedJobOutcome struct {
+	ID    uuid.UUID  `json:"id"`
+	Job   *FailedJob `json:"job,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// TransactionSourceKey is the metadata key naming where a transaction came
+// from, such as a payment channel, which decides the fee rule applied to it
+const TransactionSourceKey = "source"
+
+// hundred converts fee percentages to fractions
+var hundred = decimal.NewFromInt(100)
+
+// FeeRule prices the fee of transactions from a source in a currency. The
+// fee is FixedAmount plus Percentage percent of the transaction amount, held
+// between MinFee and MaxFee when set. Rules are never edited: a change is a
+// new rule with a later effective date.
+type FeeRule struct {
+	ID uuid.UUID `json:"id"`
+	// Source is the transaction source the rule applies to, empty for every
+	// source
+	Source string `json:"source,omitempty"`
+	// Currency is the currency the rule applies to, empty for every
+	// currency. Amounts of the rule are in this currency.
+	Currency    string           `json:"currency,omitempty"`
+	FixedAmount decimal.Decimal  `json:"fixed_amount"`
+	Percentage  decimal.Decimal  `json:"percentage"`
+	MinFee      *decimal.Decimal `json:"min_fee,omitempty"`
+	MaxFee      *decimal.Decimal `json:"max_fee,omitempty"`
+	// EffectiveFrom is when the rule starts to apply, and EffectiveUntil
+	// when it stops when it was given an end
+	EffectiveFrom  time.Time  `json:"effective_from"`
+	EffectiveUntil *time.Time `json:"effective_until,omitempty"`
+	Reason         string     `json:"reason"`
+	CreatedBy      string     `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// InEffect reports whether the rule applies at a time
+func (r *FeeRule) InEffect(at time.Time) bool {
+	return !at.Before(r.EffectiveFrom) && (r.EffectiveUntil == nil || at.Before(*r.EffectiveUntil))
+}
+
+// Matches reports whether the rule applies to transactions from a source in
+// a currency
+func (r *FeeRule) Matches(source, currency string) bool {
+	return (r.Source == "" || r.Source == source) && (r.Currency == "" || r.Currency == currency)
+}
+
+// specificity ranks rules for the same transaction: a rule for the source
+// wins over one for the currency, which wins over a rule for everything
+func (r *FeeRule) specificity() int {
+	rank := 0
+	if r.Source != "" {
+		rank += 2
+	}
+	if r.Currency != "" {
+		rank++
+	}
+	return rank
+}
+
+// Fee returns the unrounded fee the rule charges on an amount
+func (r *FeeRule) Fee(amount decimal.Decimal) decimal.Decimal {
+	fee := r.FixedAmount.Add(amount.Abs().Mul(r.Percentage).Div(hundred))
+	if r.MinFee != nil && fee.LessThan(*r.MinFee) {
+		fee = *r.MinFee
+	}
+	if r.MaxFee != nil && fee.GreaterThan(*r.MaxFee) {
+		fee = *r.MaxFee
+	}
+	return fee
+}
+
+// SelectFeeRule returns the rule applying to a transaction from a source in
+// a currency at a time: the most specific rule in effect, and of those the
+// one effective last. It returns nil when no rule applies.
+func SelectFeeRule(rules []*FeeRule, source, currency string, at time.Time) *FeeRule {
+	var selected *FeeRule
+	for _, rule := range rules {
+		if !rule.Matches(source, currency) || !rule.InEffect(at) {
+			continue
+		}
+		if selected == nil ||
+			rule.specificity() > selected.specificity() ||
+			rule.specificity() == selected.specificity() && rule.EffectiveFrom.After(selected.EffectiveFrom) {
+			selected = rule
+		}
+	}
+	return selected
+}
+
+// FeeQuote is the fee a transaction would incur
+type FeeQuote struct {
+	Amount   decimal.Decimal `json:"amount"`
+	Currency string          `json:"currency"`
+	Source   string          `json:"source,omitempty"`
+	// Fee is rounded to the currency, and zero when no rule applies
+	Fee decimal.Decimal `json:"fee"`
+	// Rule is the rule the fee was priced by
+	Rule *FeeRule  `json:"rule,omitempty"`
+	At   time.Time `json:"at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// InboundWebhookStatus is the processing state of a received webhook
+type InboundWebhookStatus string
+
+const (
+	// InboundWebhookReceived awaits processing, or another attempt
+	InboundWebhookReceived InboundWebhookStatus = "RECEIVED"
+	// InboundWebhookProcessed was handled, or had no handler
+	InboundWebhookProcessed InboundWebhookStatus = "PROCESSED"
+	// InboundWebhookDeadLettered kept failing and was moved to the dead
+	// letters
+	InboundWebhookDeadLettered InboundWebhookStatus = "DEAD_LETTERED"
+)
+
+// InboundWebhook is a webhook received from a payment gateway or partner
+// system. Its event ID is unique per provider, so redeliveries are recorded
+// once.
+type InboundWebhook struct {
+	ID        uuid.UUID `json:"id"`
+	Provider  string    `json:"provider"`
+	EventID   string    `json:"event_id"`
+	EventType string    `json:"event_type"`
+	// OrderingKey groups the events processed in the order received
+	OrderingKey string               `json:"ordering_key,omitempty"`
+	Status      InboundWebhookStatus `json:"status"`
+	// Payload is the body as the provider signed it
+	Payload       json.RawMessage `json:"-"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	ReceivedAt    time.Time       `json:"received_at"`
+	ProcessedAt   *time.Time      `json:"processed_at,omitempty"`
+}
+
+// InboundDeadLetter is a received webhook whose processing kept failing,
+// kept with its payload for review until replayed
+type InboundDeadLetter struct {
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	Provider       string          `json:"provider"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Attempts       int             `json:"attempts"`
+	Error          string          `json:"error"`
+	DeadLetteredAt time.Time       `json:"dead_lettered_at"`
+	ReplayedAt     *time.Time      `json:"replayed_at,omitempty"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// Incident is an operator switch pausing transaction types across the
+// service, such as automated debits during a suspected rating bug. At most
+// one incident is active at a time; resumed incidents are kept as history.
+type Incident struct {
+	ID     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"`
+	// PauseDebits, PauseCredits, PauseRefunds and PauseAdjustments are the
+	// transaction types the incident pauses
+	PauseDebits      bool `json:"pause_debits"`
+	PauseCredits     bool `json:"pause_credits"`
+	PauseRefunds     bool `json:"pause_refunds"`
+	PauseAdjustments bool `json:"pause_adjustments"`
+	// AllowedSources are the transaction sources, as in the source metadata
+	// set by callers, still allowed through while their type is paused
+	AllowedSources []string `json:"allowed_sources"`
+	// ResumeAt is when the incident resumes by itself, if scheduled
+	ResumeAt  *time.Time `json:"resume_at,omitempty"`
+	StartedBy string     `json:"started_by"`
+	StartedAt time.Time  `json:"started_at"`
+	UpdatedBy string     `json:"updated_by"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ResumedBy string     `json:"resumed_by,omitempty"`
+	ResumedAt *time.Time `json:"resumed_at,omitempty"`
+}
+
+// Pauses reports whether the incident pauses a transaction type
+func (i *Incident) Pauses(t TransactionType) bool {
+	switch t {
+	case TransactionTypeDebit:
+		return i.PauseDebits
+	case TransactionTypeCredit:
+		return i.PauseCredits
+	case TransactionTypeRefund:
+		return i.PauseRefunds
+	case TransactionTypeAdjustment:
+		return i.PauseAdjustments
+	}
+	return false
+}
+
+// AllowsSource reports whether transactions from a source are let through
+// while their type is paused
+func (i *Incident) AllowsSource(source string) bool {
+	if source == "" {
+		return false
+	}
+	for _, allowed := range i.AllowedSources {
+		if allowed == source {
+			return true
+		}
+	}
+	return false
+}
+
+// InEffect reports whether the incident pauses transactions at a time: it
+// has not been resumed and its scheduled resume, if any, is still ahead
+func (i *Incident) InEffect(at time.Time) bool {
+	if i.ResumedAt != nil {
+		return false
+	}
+	return i.ResumeAt == nil || at.Before(*i.ResumeAt)
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// unsafeFileNameChars matches characters kept out of invoice file names
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// InvoiceLineItem is one billed line of an invoice
+type InvoiceLineItem struct {
+	Description string          `json:"description"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	UnitPrice   decimal.Decimal `json:"unit_price" class:"financial"`
+	Amount      decimal.Decimal `json:"amount" class:"financial"`
+}
+
+// InvoiceDocument is an invoice closed by the billing cycle, as printed on
+// its PDF
+type InvoiceDocument struct {
+	InvoiceID     uuid.UUID `json:"invoice_id"`
+	CustomerID    uuid.UUID `json:"customer_id"`
+	InvoiceNumber string    `json:"invoice_number"`
+	Currency      string    `json:"currency"`
+	IssueDate     time.Time `json:"issue_date"`
+	DueDate       time.Time `json:"due_date"`
+	// BillTo is the customer's name and address, one line each
+	BillTo      []string          `json:"bill_to" class:"pii"`
+	LineItems   []InvoiceLineItem `json:"line_items"`
+	Subtotal    decimal.Decimal   `json:"subtotal" class:"financial"`
+	TaxType     string            `json:"tax_type,omitempty"`
+	TaxAmount   decimal.Decimal   `json:"tax_amount" class:"financial"`
+	TotalAmount decimal.Decimal   `json:"total_amount" class:"financial"`
+	Notes       string            `json:"notes,omitempty"`
+}
+
+// InvoicePDF is the stored PDF of an issued invoice and its delivery to the
+// customer
+type InvoicePDF struct {
+	InvoiceID     uuid.UUID       `json:"invoice_id"`
+	CustomerID    uuid.UUID       `json:"customer_id"`
+	InvoiceNumber string          `json:"invoice_number"`
+	Currency      string          `json:"currency"`
+	TotalAmount   decimal.Decimal `json:"total_amount" class:"financial"`
+	DueDate       time.Time       `json:"due_date"`
+	SizeBytes     int             `json:"size_bytes"`
+	RenderedAt    time.Time       `json:"rendered_at"`
+	// Deliveries counts the times the invoice was sent to the customer's
+	// channels, re-sends included
+	Deliveries      int        `json:"deliveries"`
+	LastDeliveredAt *time.Time `json:"last_delivered_at,omitempty"`
+}
+
+// FileName is the file name the PDF is downloaded and emailed as, after the
+// invoice number
+func (d *InvoicePDF) FileName() string {
+	return "invoice-" + strings.Trim(unsafeFileNameChars.ReplaceAllString(d.InvoiceNumber, "-"), "-.") + ".pdf"
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// PaymentTerms is how long a customer has to pay an invoice after it is
+// issued
+type PaymentTerms string
+
+const (
+	PaymentTermsNet15 PaymentTerms = "NET_15"
+	PaymentTermsNet30 PaymentTerms = "NET_30"
+	PaymentTermsNet45 PaymentTerms = "NET_45"
+	PaymentTermsNet60 PaymentTerms = "NET_60"
+)
+
+// IsValid checks if the terms are one of the defined terms
+func (t PaymentTerms) IsValid() bool {
+	return t.Days() > 0
+}
+
+// Days returns the number of days after issue an invoice falls due, zero
+// for undefined terms
+func (t PaymentTerms) Days() int {
+	switch t {
+	case PaymentTermsNet15:
+		return 15
+	case PaymentTermsNet30:
+		return 30
+	case PaymentTermsNet45:
+		return 45
+	case PaymentTermsNet60:
+		return 60
+	}
+	return 0
+}
+
+// DueDate returns the date an invoice issued at issued falls due
+func (t PaymentTerms) DueDate(issued time.Time) time.Time {
+	return issued.AddDate(0, 0, t.Days())
+}
+
+// InvoiceStatus is the payment state of an issued invoice
+type InvoiceStatus string
+
+const (
+	// InvoiceOpen is unpaid and not yet due
+	InvoiceOpen InvoiceStatus = "OPEN"
+	// InvoiceOverdue is unpaid past its due date
+	InvoiceOverdue InvoiceStatus = "OVERDUE"
+	// InvoicePaid was paid in full, late charges included
+	InvoicePaid InvoiceStatus = "PAID"
+)
+
+// LateFeeMode is how invoices unpaid past their due date are charged
+type LateFeeMode string
+
+const (
+	// LateFeeNone charges nothing for late payment
+	LateFeeNone LateFeeMode = ""
+	// LateFeeFlat charges a one-off fee, a percentage of the invoice total
+	LateFeeFlat LateFeeMode = "FEE"
+	// LateFeeInterest accrues daily simple interest on the unpaid amount
+	LateFeeInterest LateFeeMode = "INTEREST"
+)
+
+// IsValid checks if the mode is one of the defined modes
+func (m LateFeeMode) IsValid() bool {
+	switch m {
+	case LateFeeNone, LateFeeFlat, LateFeeInterest:
+		return true
+	}
+	return false
+}
+
+// InvoiceReceivable tracks an issued invoice until it is paid
+type InvoiceReceivable struct {
+	InvoiceID     uuid.UUID       `json:"invoice_id"`
+	CustomerID    uuid.UUID       `json:"customer_id"`
+	InvoiceNumber string          `json:"invoice_number"`
+	Currency      string          `json:"currency"`
+	TotalAmount   decimal.Decimal `json:"total_amount" class:"financial"`
+	DueDate       time.Time       `json:"due_date"`
+	// PaymentTerms are the customer's terms when the invoice was issued
+	PaymentTerms PaymentTerms    `json:"payment_terms"`
+	Status       InvoiceStatus   `json:"status"`
+	AmountPaid   decimal.Decimal `json:"amount_paid" class:"financial"`
+	PaidAt       *time.Time      `json:"paid_at,omitempty"`
+	// LateFee is the one-off fee charged after the due date
+	LateFee decimal.Decimal `json:"late_fee" class:"financial"`
+	// Interest is the interest accrued on the unpaid amount through
+	// InterestThrough
+	Interest        decimal.Decimal `json:"interest" class:"financial"`
+	InterestThrough *time.Time      `json:"interest_through,omitempty"`
+	// LateFeeItems are the late charges as line items added to the invoice
+	LateFeeItems []InvoiceLineItem `json:"late_fee_items"`
+	// Outstanding is what is left to pay, late charges included
+	Outstanding decimal.Decimal `json:"outstanding" class:"financial"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// AmountDue returns the invoice total plus its late charges
+func (r *InvoiceReceivable) AmountDue() decimal.Decimal {
+	return r.TotalAmount.Add(r.LateFee).Add(r.Interest)
+}
+
+// InvoiceAging splits outstanding amounts by how long they are past due
+type InvoiceAging struct {
+	Current    decimal.Decimal `json:"current" class:"financial"`
+	Days1To30  decimal.Decimal `json:"days_1_30" class:"financial"`
+	Days31To60 decimal.Decimal `json:"days_31_60" class:"financial"`
+	Days61To90 decimal.Decimal `json:"days_61_90" class:"financial"`
+	Over90     decimal.Decimal `json:"over_90" class:"financial"`
+	Total      decimal.Decimal `json:"total" class:"financial"`
+}
+
+// Add counts an amount days past due into its bucket
+func (a *InvoiceAging) Add(amount decimal.Decimal, daysPastDue int) {
+	switch {
+	case daysPastDue <= 0:
+		a.Current = a.Current.Add(amount)
+	case daysPastDue <= 30:
+		a.Days1To30 = a.Days1To30.Add(amount)
+	case daysPastDue <= 60:
+		a.Days31To60 = a.Days31To60.Add(amount)
+	case daysPastDue <= 90:
+		a.Days61To90 = a.Days61To90.Add(amount)
+	default:
+		a.Over90 = a.Over90.Add(amount)
+	}
+	a.Total = a.Total.Add(amount)
+}
+
+// CurrencyAging is the aging of all outstanding invoices in a currency
+type CurrencyAging struct {
+	Currency string       `json:"currency"`
+	Aging    InvoiceAging `json:"aging"`
+}
+
+// CustomerAging is the aging of a customer's outstanding invoices in a
+// currency
+type CustomerAging struct {
+	CustomerID   uuid.UUID    `json:"customer_id"`
+	Currency     string       `json:"currency"`
+	OpenInvoices int          `json:"open_invoices"`
+	Aging        InvoiceAging `json:"aging"`
+}
+
+// InvoiceAgingReport is the accounts receivable aging of unpaid invoices
+type InvoiceAgingReport struct {
+	AsOf      time.Time        `json:"as_of"`
+	Totals    []*CurrencyAging `json:"totals"`
+	Customers []*CustomerAging `json:"customers"`
+}
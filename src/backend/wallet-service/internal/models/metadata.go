@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Transaction metadata limits, bounding the JSONB stored with each row
+const (
+	MaxMetadataKeys        = 20
+	MaxMetadataKeyLength   = 40
+	MaxMetadataValueLength = 500
+)
+
+// TransactionProductKey is the metadata key naming the product a
+// transaction is for, which with its source selects the template its
+// description is rendered from
+const TransactionProductKey = "product"
+
+// ErrInvalidMetadata is returned for transaction metadata exceeding the limits
+var ErrInvalidMetadata = errors.New("invalid transaction metadata")
+
+// ValidateMetadata checks the key count and the key and value sizes of
+// transaction metadata
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataKeys {
+		return fmt.Errorf("%w: at most %d keys are allowed", ErrInvalidMetadata, MaxMetadataKeys)
+	}
+	for key, value := range metadata {
+		if key == "" || utf8.RuneCountInString(key) > MaxMetadataKeyLength {
+			return fmt.Errorf("%w: keys must be 1 to %d characters", ErrInvalidMetadata, MaxMetadataKeyLength)
+		}
+		if utf8.RuneCountInString(value) > MaxMetadataValueLength {
+			return fmt.Errorf("%w: value of %q exceeds %d characters", ErrInvalidMetadata, key, MaxMetadataValueLength)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// ErrAmountOutOfRange is returned for amounts whose minor units overflow int64
+var ErrAmountOutOfRange = errors.New("amount exceeds the range of minor units")
+
+// RoundingMode is how amounts are rounded to the precision of a currency
+type RoundingMode string
+
+const (
+	// RoundHalfUp rounds halves away from zero
+	RoundHalfUp RoundingMode = "HALF_UP"
+	// RoundHalfEven rounds halves to the even neighbour, as banks do
+	RoundHalfEven RoundingMode = "HALF_EVEN"
+	// RoundDown truncates toward zero
+	RoundDown RoundingMode = "DOWN"
+	// RoundUp rounds away from zero
+	RoundUp RoundingMode = "UP"
+	// RoundCeiling rounds toward positive infinity
+	RoundCeiling RoundingMode = "CEILING"
+	// RoundFloor rounds toward negative infinity
+	RoundFloor RoundingMode = "FLOOR"
+)
+
+// RoundingModes lists the supported rounding modes
+var RoundingModes = []RoundingMode{RoundHalfUp, RoundHalfEven, RoundDown, RoundUp, RoundCeiling, RoundFloor}
+
+// Valid reports whether the rounding mode is supported
+func (m RoundingMode) Valid() bool {
+	for _, mode := range RoundingModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Round rounds an amount to the given decimal places. Unknown modes round
+// half up.
+func (m RoundingMode) Round(amount decimal.Decimal, places int32) decimal.Decimal {
+	switch m {
+	case RoundHalfEven:
+		return amount.RoundBank(places)
+	case RoundDown:
+		return amount.RoundDown(places)
+	case RoundUp:
+		return amount.RoundUp(places)
+	case RoundCeiling:
+		return amount.RoundCeil(places)
+	case RoundFloor:
+		return amount.RoundFloor(places)
+	default:
+		return amount.Round(places)
+	}
+}
+
+// MinorAmount is an amount in integer minor units of its currency, such as
+// cents, with the currency's exponent: 1050 with exponent 2 is 10.50, and
+// 15000 with exponent 0 is 15000
+type MinorAmount struct {
+	Units    int64
+	Exponent int32
+}
+
+// ToMinorUnits rounds an amount to exponent decimal places with the given
+// mode and returns it in minor units
+func ToMinorUnits(amount decimal.Decimal, exponent int32, mode RoundingMode) (MinorAmount, error) {
+	units := mode.Round(amount, exponent).Shift(exponent).BigInt()
+	if !units.IsInt64() {
+		return MinorAmount{}, ErrAmountOutOfRange
+	}
+	return MinorAmount{Units: units.Int64(), Exponent: exponent}, nil
+}
+
+// Decimal returns the amount in major units
+func (m MinorAmount) Decimal() decimal.Decimal {
+	return decimal.New(m.Units, -m.Exponent)
+}
+
+// IsZero reports whether the amount is unset or zero
+func (m MinorAmount) IsZero() bool {
+	return m.Units == 0
+}
+
+// MinorOf returns an amount in minor units at the exponent of its decimal
+// places, 0.3 being 3 with exponent 1
+func MinorOf(amount decimal.Decimal) (MinorAmount, error) {
+	exponent := -amount.Exponent()
+	if exponent < 0 {
+		exponent = 0
+	}
+	return ToMinorUnits(amount, exponent, RoundDown)
+}
+
+// MinorOfFloat returns a float amount in minor units at the exponent of its
+// shortest decimal representation
+func MinorOfFloat(amount float64) (MinorAmount, error) {
+	return MinorOf(decimal.NewFromFloat(amount))
+}
+
+// Add returns the sum of two amounts at the larger of their exponents
+func (m MinorAmount) Add(other MinorAmount) (MinorAmount, error) {
+	exponent := m.Exponent
+	if other.Exponent > exponent {
+		exponent = other.Exponent
+	}
+	return ToMinorUnits(m.Decimal().Add(other.Decimal()), exponent, RoundDown)
+}
+
+// Neg returns the amount with its sign flipped
+func (m MinorAmount) Neg() MinorAmount {
+	return MinorAmount{Units: -m.Units, Exponent: m.Exponent}
+}
+
+// Cmp compares two amounts whatever their exponents, returning -1, 0 or +1
+func (m MinorAmount) Cmp(other MinorAmount) int {
+	return m.Decimal().Cmp(other.Decimal())
+}
+
+// Float64 returns the amount in major units as the nearest float
+func (m MinorAmount) Float64() float64 {
+	f, _ := m.Decimal().Float64()
+	return f
+}
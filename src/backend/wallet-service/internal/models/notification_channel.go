@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// NotificationChannelKind is how a notification channel reaches the customer
+type NotificationChannelKind string
+
+const (
+	// NotificationChannelEmail sends to an email address over SMTP
+	NotificationChannelEmail NotificationChannelKind = "EMAIL"
+	// NotificationChannelSMS sends to a phone number through the SMS
+	// provider
+	NotificationChannelSMS NotificationChannelKind = "SMS"
+	// NotificationChannelSlack posts to a Slack incoming webhook
+	NotificationChannelSlack NotificationChannelKind = "SLACK"
+)
+
+// IsValid checks if the kind is one of the defined kinds
+func (k NotificationChannelKind) IsValid() bool {
+	switch k {
+	case NotificationChannelEmail, NotificationChannelSMS, NotificationChannelSlack:
+		return true
+	}
+	return false
+}
+
+// NotificationChannel is a destination a customer receives notifications on
+// besides webhooks. Empty EventTypes means every notification event.
+type NotificationChannel struct {
+	ID         uuid.UUID               `json:"id"`
+	CustomerID uuid.UUID               `json:"customer_id"`
+	Kind       NotificationChannelKind `json:"kind"`
+	// Destination is the email address, E.164 phone number or Slack
+	// webhook URL of the channel
+	Destination string   `json:"destination" class:"pii"`
+	EventTypes  []string `json:"event_types"`
+	// LargeTransactionAmount is the amount from which completed
+	// transactions are notified; none are when unset
+	LargeTransactionAmount *decimal.Decimal `json:"large_transaction_amount,omitempty" class:"financial"`
+	Enabled                bool             `json:"enabled"`
+	CreatedAt              time.Time        `json:"created_at"`
+	UpdatedAt              time.Time        `json:"updated_at"`
+}
+
+// InvoiceNotice is an invoice issued by the invoice service, notified to the
+// customer on their channels and webhooks
+type InvoiceNotice struct {
+	InvoiceID     uuid.UUID       `json:"invoice_id"`
+	CustomerID    uuid.UUID       `json:"customer_id"`
+	InvoiceNumber string          `json:"invoice_number"`
+	Currency      string          `json:"currency"`
+	TotalAmount   decimal.Decimal `json:"total_amount" class:"financial"`
+	DueDate       time.Time       `json:"due_date"`
+	// URL is where the customer views or downloads the invoice
+	URL string `json:"url,omitempty"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// QueuedNotificationStatus is the state of a notification awaiting
+// redelivery
+type QueuedNotificationStatus string
+
+const (
+	// QueuedNotificationPending is retried until delivered or expired
+	QueuedNotificationPending QueuedNotificationStatus = "PENDING"
+	// QueuedNotificationExpired went stale before it could be delivered and
+	// is no longer sent
+	QueuedNotificationExpired QueuedNotificationStatus = "EXPIRED"
+)
+
+// IsValid reports whether the status is known
+func (s QueuedNotificationStatus) IsValid() bool {
+	return s == QueuedNotificationPending || s == QueuedNotificationExpired
+}
+
+// QueuedNotification is a notification the notification pipeline could not
+// take, kept with its event envelope until it is delivered or expires
+type QueuedNotification struct {
+	ID        uuid.UUID                `json:"id"`
+	EventID   uuid.UUID                `json:"event_id"`
+	EventType string                   `json:"event_type"`
+	Status    QueuedNotificationStatus `json:"status"`
+	// Envelope is the event envelope as it was to be published. It holds
+	// customer details and is left out of the admin view.
+	Envelope      json.RawMessage `json:"-"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// NotificationBacklog summarises the notification queue with a page of its
+// notifications, oldest first
+type NotificationBacklog struct {
+	Pending int64 `json:"pending"`
+	Expired int64 `json:"expired"`
+	// PendingByType counts the pending notifications of each event type
+	PendingByType map[string]int64 `json:"pending_by_type"`
+	// OldestPending is when the longest waiting notification was queued
+	OldestPending *time.Time            `json:"oldest_pending,omitempty"`
+	Notifications []*QueuedNotification `json:"notifications"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// OperatorActionStatus represents the outcome of an operator runbook action
+type OperatorActionStatus string
+
+const (
+	// OperatorActionSucceeded indicates the action completed successfully
+	OperatorActionSucceeded OperatorActionStatus = "SUCCEEDED"
+	// OperatorActionFailed indicates the action returned an error
+	OperatorActionFailed OperatorActionStatus = "FAILED"
+)
+
+// OperatorAction is an audit record of a runbook action executed by an operator
+type OperatorAction struct {
+	ID        uuid.UUID            `json:"id"`
+	Action    string               `json:"action"`
+	Actor     string               `json:"actor"`
+	Reason    string               `json:"reason"`
+	Params    map[string]string    `json:"params"`
+	Status    OperatorActionStatus `json:"status"`
+	Error     string               `json:"error,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+}
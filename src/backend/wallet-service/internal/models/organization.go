@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// Organization is the tenant owning a group of customers and, through
+// them, their wallets
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OrganizationBalance is the combined balance of an organization's wallets
+// in one currency
+type OrganizationBalance struct {
+	Currency string          `json:"currency"`
+	Wallets  int             `json:"wallets"`
+	Balance  decimal.Decimal `json:"balance" class:"financial"`
+}
+
+// OrganizationSummary is an organization with the rollup of its customers'
+// wallets
+type OrganizationSummary struct {
+	Organization *Organization `json:"organization"`
+	Customers    int           `json:"customers"`
+	Wallets      int           `json:"wallets"`
+	// Balances holds the combined balance of each currency
+	Balances []*OrganizationBalance `json:"balances"`
+}
+
+// OrganizationInvoiceTotal is the total of an organization's invoices in
+// one currency
+type OrganizationInvoiceTotal struct {
+	Currency    string          `json:"currency"`
+	Invoices    int             `json:"invoices"`
+	TotalAmount decimal.Decimal `json:"total_amount" class:"financial"`
+	Outstanding decimal.Decimal `json:"outstanding" class:"financial"`
+}
+
+// OrganizationInvoice consolidates the invoices issued to an
+// organization's customers in a billing period
+type OrganizationInvoice struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	// Period is the billing period, formatted with BillingPeriodLayout
+	Period   string                      `json:"period"`
+	Totals   []*OrganizationInvoiceTotal `json:"totals"`
+	Invoices []*InvoiceReceivable        `json:"invoices"`
+}
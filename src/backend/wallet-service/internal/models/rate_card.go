@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// RateCard prices the usage of internal services charged back to their wallets
+type RateCard struct {
+	PerThousandRequests decimal.Decimal `json:"per_thousand_requests"`
+	PerDBSecond         decimal.Decimal `json:"per_db_second"`
+	PerGB               decimal.Decimal `json:"per_gb"`
+}
+
+// Rate card fields, as named in diffs
+const (
+	RatePerThousandRequests = "per_thousand_requests"
+	RatePerDBSecond         = "per_db_second"
+	RatePerGB               = "per_gb"
+)
+
+// Diff lists the rates that differ in next, in a stable order
+func (c RateCard) Diff(next RateCard) []RateDiff {
+	var diff []RateDiff
+	for _, rate := range []struct {
+		field    string
+		from, to decimal.Decimal
+	}{
+		{RatePerThousandRequests, c.PerThousandRequests, next.PerThousandRequests},
+		{RatePerDBSecond, c.PerDBSecond, next.PerDBSecond},
+		{RatePerGB, c.PerGB, next.PerGB},
+	} {
+		if !rate.from.Equal(rate.to) {
+			diff = append(diff, RateDiff{Field: rate.field, From: rate.from, To: rate.to})
+		}
+	}
+	return diff
+}
+
+// RateDiff is a rate changed by a rate card change
+type RateDiff struct {
+	Field string          `json:"field"`
+	From  decimal.Decimal `json:"from"`
+	To    decimal.Decimal `json:"to"`
+}
+
+// RateCardChangeStatus is the review state of a rate card change
+type RateCardChangeStatus string
+
+const (
+	// RateCardChangePending awaits review by a second operator
+	RateCardChangePending RateCardChangeStatus = "PENDING"
+	// RateCardChangeApproved was approved and is activated at its effective
+	// date once finance was notified
+	RateCardChangeApproved RateCardChangeStatus = "APPROVED"
+	// RateCardChangeRejected was rejected or withdrawn
+	RateCardChangeRejected RateCardChangeStatus = "REJECTED"
+	// RateCardChangeActive holds the rates in effect
+	RateCardChangeActive RateCardChangeStatus = "ACTIVE"
+	// RateCardChangeSuperseded was in effect until a later change
+	RateCardChangeSuperseded RateCardChangeStatus = "SUPERSEDED"
+)
+
+// RateCardChange is a proposed change of the rate card, kept as its audit
+// record. Changes take effect only once approved by an operator other than
+// the one who proposed them, and only after finance was notified.
+type RateCardChange struct {
+	ID     uuid.UUID            `json:"id"`
+	Status RateCardChangeStatus `json:"status"`
+	Rates  RateCard             `json:"rates"`
+	// Previous is the rate card in effect when the change was proposed, and
+	// Diff the rates the change moves from it
+	Previous    RateCard   `json:"previous"`
+	Diff        []RateDiff `json:"diff"`
+	EffectiveAt time.Time  `json:"effective_at"`
+	Reason      string     `json:"reason"`
+	ProposedBy  string     `json:"proposed_by"`
+	ReviewedBy  string     `json:"reviewed_by,omitempty"`
+	ReviewNote  string     `json:"review_note,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	// FinanceNotifiedAt is when finance was sent the approved change
+	FinanceNotifiedAt *time.Time `json:"finance_notified_at,omitempty"`
+	ActivatedAt       *time.Time `json:"activated_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// RateLimitPolicy is the request quota applied to a customer
+type RateLimitPolicy struct {
+	Tier   string        `json:"tier"`
+	Limit  int           `json:"limit"`
+	Window time.Duration `json:"window"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// ReconciliationIssueStatus represents the lifecycle state of a discrepancy
+type ReconciliationIssueStatus string
+
+const (
+	// ReconciliationIssueOpen indicates the discrepancy was seen by the latest run
+	ReconciliationIssueOpen ReconciliationIssueStatus = "OPEN"
+	// ReconciliationIssueResolved indicates a later run found the balances in agreement
+	ReconciliationIssueResolved ReconciliationIssueStatus = "RESOLVED"
+)
+
+// ReconciliationIssue records a wallet whose stored balance differs from the
+// balance recomputed from its completed transactions
+type ReconciliationIssue struct {
+	ID               uuid.UUID                 `json:"id"`
+	WalletID         uuid.UUID                 `json:"wallet_id"`
+	Currency         string                    `json:"currency"`
+	StoredBalance    decimal.Decimal           `json:"stored_balance"`
+	LedgerBalance    decimal.Decimal           `json:"ledger_balance"`
+	Difference       decimal.Decimal           `json:"difference"`
+	TransactionCount int64                     `json:"transaction_count"`
+	Status           ReconciliationIssueStatus `json:"status"`
+	DetectedAt       time.Time                 `json:"detected_at"`
+	LastCheckedAt    time.Time                 `json:"last_checked_at"`
+	ResolvedAt       *time.Time                `json:"resolved_at,omitempty"`
+}
+
+// LedgerEntrySummary totals a wallet's transactions of one type and status,
+// used to drill into a reconciliation issue
+type LedgerEntrySummary struct {
+	Type   string          `json:"type"`
+	Status string          `json:"status"`
+	Count  int64           `json:"count"`
+	Total  decimal.Decimal `json:"total"`
+}
+
+// ReconciliationRun summarises a single reconciliation pass
+type ReconciliationRun struct {
+	StartedAt      time.Time `json:"started_at"`
+	CompletedAt    time.Time `json:"completed_at"`
+	WalletsChecked int64     `json:"wallets_checked"`
+	IssuesOpen     int       `json:"issues_open"`
+	IssuesResolved int64     `json:"issues_resolved"`
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// RecurringCadence is how often a recurring debit runs
+type RecurringCadence string
+
+const (
+	// CadenceDaily runs every day at the time of day of the start
+	CadenceDaily RecurringCadence = "DAILY"
+	// CadenceWeekly runs every week on the weekday of the start
+	CadenceWeekly RecurringCadence = "WEEKLY"
+	// CadenceMonthly runs every month on the day of the start, or the last
+	// day of months too short for it
+	CadenceMonthly RecurringCadence = "MONTHLY"
+)
+
+// RecurringDebitStatus is the state of a recurring debit
+type RecurringDebitStatus string
+
+const (
+	// RecurringDebitActive has runs left to execute
+	RecurringDebitActive RecurringDebitStatus = "ACTIVE"
+	// RecurringDebitCompleted ran every run before its end
+	RecurringDebitCompleted RecurringDebitStatus = "COMPLETED"
+	// RecurringDebitCancelled was cancelled by the customer
+	RecurringDebitCancelled RecurringDebitStatus = "CANCELLED"
+)
+
+// RecurringRunStatus is the outcome of one run of a recurring debit
+type RecurringRunStatus string
+
+const (
+	// RecurringRunPending is claimed by a scheduler and not finished yet
+	RecurringRunPending RecurringRunStatus = "PENDING"
+	// RecurringRunSucceeded debited the wallet
+	RecurringRunSucceeded RecurringRunStatus = "SUCCEEDED"
+	// RecurringRunFailed was rejected by the wallet, e.g. for insufficient
+	// balance, and is not retried
+	RecurringRunFailed RecurringRunStatus = "FAILED"
+	// RecurringRunSkipped was missed for longer than the catch-up window
+	RecurringRunSkipped RecurringRunStatus = "SKIPPED"
+)
+
+// RecurringDebit is a standing instruction debiting a fixed amount from a
+// wallet on a cadence, from StartAt until EndAt when set. NextRunAt is the
+// time of run RunCount, nil once the debit is completed or cancelled.
+type RecurringDebit struct {
+	ID          uuid.UUID        `json:"id"`
+	CustomerID  uuid.UUID        `json:"customer_id"`
+	WalletID    uuid.UUID        `json:"wallet_id"`
+	Amount      decimal.Decimal  `json:"amount"`
+	Currency    string           `json:"currency"`
+	Description string           `json:"description,omitempty"`
+	Cadence     RecurringCadence `json:"cadence"`
+	StartAt     time.Time        `json:"start_at"`
+	EndAt       *time.Time       `json:"end_at,omitempty"`
+	RunCount    int              `json:"run_count"`
+	NextRunAt   *time.Time       `json:"next_run_at,omitempty"`
+	CancelledAt *time.Time       `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// Status derives the debit status from its timestamps
+func (r *RecurringDebit) Status() RecurringDebitStatus {
+	switch {
+	case r.CancelledAt != nil:
+		return RecurringDebitCancelled
+	case r.NextRunAt == nil:
+		return RecurringDebitCompleted
+	default:
+		return RecurringDebitActive
+	}
+}
+
+// RunAt returns the scheduled time of run n, counting from zero. Runs are
+// derived from the start rather than the previous run, so monthly debits
+// starting on the 31st return to it after shorter months.
+func (r *RecurringDebit) RunAt(n int) time.Time {
+	start := r.StartAt.UTC()
+	switch r.Cadence {
+	case CadenceDaily:
+		return start.AddDate(0, 0, n)
+	case CadenceWeekly:
+		return start.AddDate(0, 0, 7*n)
+	default:
+		first := time.Date(start.Year(), start.Month()+time.Month(n), 1,
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), time.UTC)
+		lastDay := first.AddDate(0, 1, -1).Day()
+		day := start.Day()
+		if day > lastDay {
+			day = lastDay
+		}
+		return first.AddDate(0, 0, day-1)
+	}
+}
+
+// NextRun returns the time of run n, or nil when it falls after the end
+func (r *RecurringDebit) NextRun(n int) *time.Time {
+	at := r.RunAt(n)
+	if r.EndAt != nil && at.After(*r.EndAt) {
+		return nil
+	}
+	return &at
+}
+
+// RecurringDebitRun records one scheduled run of a recurring debit. The
+// idempotency key is derived from the debit and the scheduled time, so a run
+// is executed at most once however many schedulers reach it.
+type RecurringDebitRun struct {
+	RecurringDebitID uuid.UUID          `json:"recurring_debit_id"`
+	ScheduledFor     time.Time          `json:"scheduled_for"`
+	IdempotencyKey   string             `json:"idempotency_key"`
+	Status           RecurringRunStatus `json:"status"`
+	TransactionID    *uuid.UUID         `json:"transaction_id,omitempty"`
+	Error            string             `json:"error,omitempty"`
+	ClaimedAt        time.Time          `json:"claimed_at"`
+	FinishedAt       *time.Time         `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// ProviderRefundStatus is the lifecycle state of a refund to the original
+// payment method
+type ProviderRefundStatus string
+
+const (
+	// ProviderRefundPending is awaiting submission or provider confirmation
+	ProviderRefundPending ProviderRefundStatus = "PENDING"
+	// ProviderRefundSucceeded was confirmed by the provider
+	ProviderRefundSucceeded ProviderRefundStatus = "SUCCEEDED"
+	// ProviderRefundFailed was refused by the provider and its funds returned to the wallet
+	ProviderRefundFailed ProviderRefundStatus = "FAILED"
+)
+
+// ProviderPayment links a top-up transaction to the payment that funded it
+type ProviderPayment struct {
+	TransactionID     uuid.UUID `json:"transaction_id"`
+	Provider          string    `json:"provider"`
+	ProviderPaymentID string    `json:"provider_payment_id" class:"internal"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ProviderRefund is a top-up refund pushed back to its payment method. Its
+// wallet transaction holds the refunded funds until the provider settles.
+type ProviderRefund struct {
+	ID                    uuid.UUID            `json:"id"`
+	TransactionID         uuid.UUID            `json:"transaction_id"`
+	OriginalTransactionID uuid.UUID            `json:"original_transaction_id"`
+	WalletID              uuid.UUID            `json:"wallet_id"`
+	Provider              string               `json:"provider"`
+	ProviderPaymentID     string               `json:"provider_payment_id" class:"internal"`
+	ProviderRefundID      string               `json:"provider_refund_id,omitempty" class:"internal"`
+	Amount                decimal.Decimal      `json:"amount" class:"financial"`
+	Currency              string               `json:"currency"`
+	Status                ProviderRefundStatus `json:"status"`
+	FailureReason         string               `json:"failure_reason,omitempty"`
+	Attempts              int                  `json:"attempts"`
+	NextCheckAt           time.Time            `json:"-"`
+	CreatedAt             time.Time            `json:"created_at"`
+	UpdatedAt             time.Time            `json:"updated_at"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// ReportJobStatus is the lifecycle state of a report job
+type ReportJobStatus string
+
+const (
+	// ReportJobQueued waits for a worker, including between retries
+	ReportJobQueued ReportJobStatus = "QUEUED"
+	// ReportJobRunning is held by a worker
+	ReportJobRunning ReportJobStatus = "RUNNING"
+	// ReportJobSucceeded finished with a result
+	ReportJobSucceeded ReportJobStatus = "SUCCEEDED"
+	// ReportJobFailed ran out of attempts
+	ReportJobFailed ReportJobStatus = "FAILED"
+	// ReportJobCancelled was cancelled before it finished
+	ReportJobCancelled ReportJobStatus = "CANCELLED"
+)
+
+// Finished reports whether a job reached a final state
+func (s ReportJobStatus) Finished() bool {
+	return s == ReportJobSucceeded || s == ReportJobFailed || s == ReportJobCancelled
+}
+
+// ReportJob is a long-running report, such as a statement or an export,
+// computed in the background. Its result is kept with the job for retrieval.
+type ReportJob struct {
+	ID     uuid.UUID       `json:"id"`
+	Kind   string          `json:"kind"`
+	Status ReportJobStatus `json:"status"`
+	Params json.RawMessage `json:"params,omitempty"`
+	// Progress is the percentage of the work done, from 0 to 100
+	Progress        int             `json:"progress"`
+	Attempts        int             `json:"attempts"`
+	MaxAttempts     int             `json:"max_attempts"`
+	Result          json.RawMessage `json:"-"`
+	Error           string          `json:"error,omitempty"`
+	SubmittedBy     string          `json:"submitted_by"`
+	CancelRequested bool            `json:"cancel_requested"`
+	// RunAfter delays a queued job, as between retries
+	RunAfter    time.Time  `json:"run_after"`
+	HeartbeatAt *time.Time `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
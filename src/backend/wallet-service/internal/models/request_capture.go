@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// RequestCapture is the redacted request and response bodies of a sampled
+// API request, kept to investigate payment disputes
+type RequestCapture struct {
+	ID            uuid.UUID `json:"id"`
+	CorrelationID string    `json:"correlation_id"`
+	Method        string    `json:"method"`
+	// Route is the matched route template and Path the request path without
+	// its query, which may carry PII
+	Route      string     `json:"route"`
+	Path       string     `json:"path"`
+	Status     int        `json:"status"`
+	CustomerID *uuid.UUID `json:"customer_id,omitempty"`
+	Subject    string     `json:"subject,omitempty"`
+	// RequestBody and ResponseBody are redacted, then cut to the size cap
+	RequestBody  string    `json:"request_body"`
+	ResponseBody string    `json:"response_body"`
+	Truncated    bool      `json:"truncated"`
+	DurationMs   int64     `json:"duration_ms"`
+	CapturedAt   time.Time `json:"captured_at"`
+}
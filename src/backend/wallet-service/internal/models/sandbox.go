@@ -0,0 +1,15 @@
+package models
+
+import (
+	"github.com/google/uuid" // v1.3.0
+)
+
+// SandboxClone describes a wallet cloned into the sandbox for demos. The
+// clone shares no identifiers with its source wallet.
+type SandboxClone struct {
+	WalletID     uuid.UUID `json:"wallet_id"`
+	CustomerID   uuid.UUID `json:"customer_id"`
+	Currency     string    `json:"currency"`
+	Balance      float64   `json:"balance"`
+	Transactions int       `json:"transactions"`
+}
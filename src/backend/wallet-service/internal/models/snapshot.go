@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// HistoricalBalance is a wallet balance at a point in time, derived from the
+// latest snapshot before that time plus the transactions replayed since
+type HistoricalBalance struct {
+	WalletID             uuid.UUID       `json:"wallet_id"`
+	AsOf                 time.Time       `json:"as_of"`
+	Balance              decimal.Decimal `json:"balance" class:"financial"`
+	Currency             string          `json:"currency"`
+	SnapshotAt           *time.Time      `json:"snapshot_at,omitempty"`
+	ReplayedTransactions int64           `json:"replayed_transactions"`
+}
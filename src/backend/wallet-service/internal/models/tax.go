@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// Metadata keys of debits charged tax. The debit's amount includes the
+// tax; the taxable amount is what was debited before it.
+const (
+	MetadataTaxableAmount = "taxable_amount"
+	MetadataTaxAmount     = "tax_amount"
+	MetadataTaxRegion     = "tax_region"
+)
+
+// TaxSource is what a tax record was charged on
+type TaxSource string
+
+const (
+	// TaxSourceInvoice is tax charged on an invoice, referenced by its
+	// invoice number
+	TaxSourceInvoice TaxSource = "INVOICE"
+	// TaxSourceDebit is tax charged on a debit, referenced by its
+	// transaction ID
+	TaxSourceDebit TaxSource = "DEBIT"
+)
+
+// Reasons no tax was charged
+const (
+	// TaxExemptRegion is a customer without a region or in a region
+	// without tax rules
+	TaxExemptRegion = "UNTAXED_REGION"
+	// TaxExemptCustomer is a customer holding a tax exemption
+	TaxExemptCustomer = "CUSTOMER_EXEMPT"
+	// TaxExemptCategory is a supply whose category is exempt in the region
+	TaxExemptCategory = "CATEGORY_EXEMPT"
+)
+
+// TaxableItem is an amount to tax and the category it is supplied in
+type TaxableItem struct {
+	Amount   decimal.Decimal `json:"amount" class:"financial"`
+	Category string          `json:"category,omitempty"`
+}
+
+// TaxLine is the tax charged by one component of a tax, e.g. CGST
+type TaxLine struct {
+	Name string `json:"name"`
+	// Rate is a percentage, e.g. 9 for 9%
+	Rate   decimal.Decimal `json:"rate"`
+	Amount decimal.Decimal `json:"amount" class:"financial"`
+}
+
+// TaxBreakdown is the tax charged on a taxable amount, line by line
+type TaxBreakdown struct {
+	Region  string          `json:"region,omitempty"`
+	Tax     string          `json:"tax,omitempty"`
+	Taxable decimal.Decimal `json:"taxable" class:"financial"`
+	Lines   []*TaxLine      `json:"lines"`
+	Total   decimal.Decimal `json:"total" class:"financial"`
+	// ExemptReason says why no tax was charged
+	ExemptReason string `json:"exempt_reason,omitempty"`
+}
+
+// TaxRecord is a tax breakdown recorded for reporting
+type TaxRecord struct {
+	ID         uuid.UUID `json:"id"`
+	CustomerID uuid.UUID `json:"customer_id"`
+	Source     TaxSource `json:"source"`
+	// Reference is the invoice number or the debit's transaction ID
+	Reference string       `json:"reference"`
+	Currency  string       `json:"currency"`
+	Breakdown TaxBreakdown `json:"breakdown"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// TaxReportRow totals the tax charged by a component of a region's tax in
+// a currency
+type TaxReportRow struct {
+	Region    string          `json:"region"`
+	Tax       string          `json:"tax"`
+	Component string          `json:"component"`
+	Source    TaxSource       `json:"source"`
+	Currency  string          `json:"currency"`
+	Count     int64           `json:"count"`
+	Taxable   decimal.Decimal `json:"taxable" class:"financial"`
+	Total     decimal.Decimal `json:"total" class:"financial"`
+}
+
+// TaxReport totals the tax recorded in a window for filing
+type TaxReport struct {
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"`
+	Rows        []*TaxReportRow `json:"rows"`
+	GeneratedAt time.Time       `json:"generated_at"`
+}
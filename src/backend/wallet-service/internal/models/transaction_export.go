@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// ExportFormat is the file format of a transaction export
+type ExportFormat string
+
+const (
+	// ExportCSV writes a header row and one row per transaction
+	ExportCSV ExportFormat = "csv"
+	// ExportParquet writes a Parquet file for loading into a warehouse
+	ExportParquet ExportFormat = "parquet"
+)
+
+// TransactionExportFilter selects the transactions of an export, created
+// in [From, To)
+type TransactionExportFilter struct {
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	CustomerID *uuid.UUID `json:"customer_id,omitempty"`
+	// Type is CREDIT, DEBIT, REFUND or ADJUSTMENT; empty exports every type
+	Type string `json:"type,omitempty"`
+}
+
+// TransactionExportRow is one exported transaction. Amounts are kept as
+// their exact decimal text.
+type TransactionExportRow struct {
+	TransactionID string     `parquet:"transaction_id"`
+	WalletID      string     `parquet:"wallet_id"`
+	CustomerID    string     `parquet:"customer_id"`
+	Type          string     `parquet:"type"`
+	Status        string     `parquet:"status"`
+	Amount        string     `parquet:"amount"`
+	Currency      string     `parquet:"currency"`
+	ReferenceID   string     `parquet:"reference_id"`
+	Description   string     `parquet:"description"`
+	EffectiveAt   *time.Time `parquet:"effective_at,optional,timestamp(millisecond)"`
+	CreatedAt     time.Time  `parquet:"created_at,timestamp(millisecond)"`
+}
+
+// ContentType returns the media type of files in the format
+func (f ExportFormat) ContentType() string {
+	if f == ExportParquet {
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv"
+}
+
+// TransactionExportFile is a written export file, the result of its job
+type TransactionExportFile struct {
+	Key   string `json:"key"`
+	Rows  int64  `json:"rows"`
+	Bytes int64  `json:"bytes"`
+}
+
+// TransactionExport is the state of an export job, with a download link
+// once its file is written
+type TransactionExport struct {
+	ID       uuid.UUID               `json:"id"`
+	Status   ReportJobStatus         `json:"status"`
+	Progress int                     `json:"progress"`
+	Format   ExportFormat            `json:"format"`
+	Filter   TransactionExportFilter `json:"filter"`
+	Error    string                  `json:"error,omitempty"`
+	// Rows and Bytes are set once the file is written
+	Rows  int64 `json:"rows,omitempty"`
+	Bytes int64 `json:"bytes,omitempty"`
+	// DownloadURL is a signed link to the file, valid until URLExpiresAt
+	DownloadURL  string     `json:"download_url,omitempty"`
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty"`
+	SubmittedBy  string     `json:"submitted_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
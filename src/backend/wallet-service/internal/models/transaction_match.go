@@ -0,0 +1,11 @@
+package models
+
+// TransactionMatch is a transaction found by reference ID together with the
+// wallet and customer it belongs to, for support lookups across wallets
+type TransactionMatch struct {
+	Transaction    *Transaction `json:"transaction"`
+	Wallet         *Wallet      `json:"wallet"`
+	CustomerName   string       `json:"customer_name" class:"pii"`
+	CustomerEmail  string       `json:"customer_email" class:"pii"`
+	CustomerStatus string       `json:"customer_status"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// TransactionPartition is the partition holding one month of wallet
+// transactions, by their creation time
+type TransactionPartition struct {
+	Name string `json:"name"`
+	// Month is the start of the month in UTC
+	Month time.Time `json:"month"`
+}
+
+// End returns the start of the month after the partition's
+func (p TransactionPartition) End() time.Time {
+	return p.Month.AddDate(0, 1, 0)
+}
+
+// MonthStart returns the start of the month of a time in UTC
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
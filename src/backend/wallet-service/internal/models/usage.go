@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// ServiceUsage is the load an internal service generated in a month
+type ServiceUsage struct {
+	// Month is the first day of the month in UTC
+	Month       time.Time
+	Caller      string
+	Requests    int64
+	DBTime      time.Duration
+	BytesServed int64
+	// ChargedTransactionID is the wallet debit billing the month, once charged
+	ChargedTransactionID *uuid.UUID
+}
+
+// UsageReport is the monthly internal usage report used for chargeback
+type UsageReport struct {
+	Month    string              `json:"month"`
+	Currency string              `json:"currency,omitempty"`
+	Services []*ServiceUsageCost `json:"services"`
+}
+
+// ServiceUsageCost is the usage of one internal service with its cost at the
+// configured chargeback rates
+type ServiceUsageCost struct {
+	Caller      string          `json:"caller"`
+	Requests    int64           `json:"requests"`
+	DBSeconds   float64         `json:"db_seconds"`
+	BytesServed int64           `json:"bytes_served"`
+	Cost        decimal.Decimal `json:"cost"`
+	// WalletID is the wallet the service is billed through, if any
+	WalletID             *uuid.UUID `json:"wallet_id,omitempty"`
+	ChargedTransactionID *uuid.UUID `json:"charged_transaction_id,omitempty"`
+}
@@ -2,9 +2,13 @@
 package models
 
 import (
+    "database/sql/driver"
     "errors"
+    "fmt"
+    "math"
     "time"
     "github.com/google/uuid" // v1.3.0
+    "github.com/shopspring/decimal" // v1.3.1
 )
 
 // TransactionType represents the type of wallet transaction
@@ -20,6 +24,9 @@ const (
     TransactionTypeDebit
     // TransactionTypeRefund represents a refund transaction
     TransactionTypeRefund
+    // TransactionTypeAdjustment represents a manual correction by operators,
+    // raising or lowering the balance as its adjustment direction says
+    TransactionTypeAdjustment
 )
 
 const (
@@ -35,6 +42,10 @@ const (
     TransactionStatusReversed
 )
 
+// MaxTransactionAmount is the largest amount the DECIMAL(12,2) amount and
+// balance columns can hold
+const MaxTransactionAmount = 9999999999.99
+
 // Common error definitions for domain validation
 var (
     ErrInvalidTransactionType   = errors.New("invalid transaction type")
@@ -46,13 +57,21 @@ var (
 // Wallet represents a customer's wallet with balance management capabilities
 type Wallet struct {
     ID                 uuid.UUID `json:"id"`
-    CustomerID         uuid.UUID `json:"customer_id"`
-    Balance           float64   `json:"balance"`
+    CustomerID         uuid.UUID `json:"customer_id" class:"pii"`
+    Balance           float64   `json:"balance" class:"financial"`
     Currency          string    `json:"currency"`
-    LowBalanceThreshold float64   `json:"low_balance_threshold"`
+    LowBalanceThreshold float64   `json:"low_balance_threshold" class:"financial"`
+    CreditLimit       float64   `json:"credit_limit" class:"financial"` // How far below zero the balance may go
+    // BalanceMinor and CreditLimitMinor are the balance and credit limit in
+    // integer minor units of the currency, stored alongside the decimal
+    // amounts. Postings move the balance in minor units.
+    BalanceMinor      MinorAmount `json:"-"`
+    CreditLimitMinor  MinorAmount `json:"-"`
     CreatedAt         time.Time `json:"created_at"`
     UpdatedAt         time.Time `json:"updated_at"`
-    Version           int64     `json:"version"` // For optimistic locking
+    LastActivityAt    *time.Time `json:"last_activity_at,omitempty"` // When the latest transaction was recorded
+    InactiveSince     *time.Time `json:"inactive_since,omitempty"`   // When the wallet was flagged inactive, nil while active
+    Version           int64     `json:"version" class:"internal"` // For optimistic locking
 }
 
 // Transaction represents a wallet transaction with comprehensive validation
@@ -61,17 +80,36 @@ type Transaction struct {
     WalletID    uuid.UUID         `json:"wallet_id"`
     Type        TransactionType   `json:"type"`
     Status      TransactionStatus `json:"status"`
-    Amount      float64           `json:"amount"`
+    Amount      float64           `json:"amount" class:"financial"`
     Currency    string            `json:"currency"`
-    Description string            `json:"description"`
-    ReferenceID string            `json:"reference_id"`
+    // Minor is the amount in integer minor units of the currency, stored
+    // alongside the decimal amount
+    Minor       MinorAmount       `json:"-"`
+    Description string            `json:"description" class:"pii"`
+    ReferenceID string            `json:"reference_id" class:"internal"`
+    // Metadata holds caller-defined keys such as order IDs, channels and tags
+    Metadata    map[string]string `json:"metadata,omitempty"`
+    // EffectiveAt is the date a backdated transaction applies to, which
+    // decides the billing period it posts into
+    EffectiveAt *time.Time        `json:"effective_at,omitempty"`
     CreatedAt   time.Time         `json:"created_at"`
     UpdatedAt   time.Time         `json:"updated_at"`
 }
 
+// EffectiveTime returns the time the transaction applies at: its effective
+// date when backdated, otherwise its creation. It decides the billing period
+// and dates the transaction in statements and activity aggregates; balance
+// snapshots stay on creation time so they never change once taken.
+func (t *Transaction) EffectiveTime() time.Time {
+    if t.EffectiveAt != nil {
+        return *t.EffectiveAt
+    }
+    return t.CreatedAt
+}
+
 // IsValidTransactionType checks if the transaction type is supported
 func IsValidTransactionType(t TransactionType) bool {
-    return t >= TransactionTypeCredit && t <= TransactionTypeRefund
+    return t >= TransactionTypeCredit && t <= TransactionTypeAdjustment
 }
 
 // IsValidTransactionStatus checks if the transaction status is valid
@@ -84,12 +122,120 @@ func (w *Wallet) IsLowBalance() bool {
     return w.Balance <= w.LowBalanceThreshold
 }
 
-// HasSufficientBalance checks if the wallet has sufficient balance for a debit operation
+// HasSufficientBalance checks if the wallet has sufficient balance for a debit
+// operation, allowing the balance to go negative down to the credit limit
 func (w *Wallet) HasSufficientBalance(amount float64) bool {
-    if amount <= 0 {
+    minor, err := MinorOfFloat(amount)
+    if err != nil {
+        return false
+    }
+    return w.HasSufficientMinorBalance(minor)
+}
+
+// HasSufficientMinorBalance checks in minor units if the wallet has
+// sufficient balance for a debit operation
+func (w *Wallet) HasSufficientMinorBalance(amount MinorAmount) bool {
+    return amount.Units > 0 && w.CanChangeBalance(amount.Neg())
+}
+
+// CanChangeBalance reports whether the balance can move by a signed amount
+// in minor units without going below the credit limit
+func (w *Wallet) CanChangeBalance(delta MinorAmount) bool {
+    available, err := w.MinorBalance().Add(w.MinorCreditLimit())
+    if err != nil {
         return false
     }
-    return w.Balance >= amount
+    after, err := available.Add(delta)
+    return err == nil && after.Units >= 0
+}
+
+// BalanceAfter returns the balance in minor units once the transaction is
+// applied
+func (w *Wallet) BalanceAfter(tx *Transaction) (MinorAmount, error) {
+    return w.MinorBalance().Add(tx.SignedMinor())
+}
+
+// SetBalance sets the balance from minor units
+func (w *Wallet) SetBalance(balance MinorAmount) {
+    w.BalanceMinor = balance
+    w.Balance = balance.Float64()
+}
+
+// MinorBalance returns the balance in minor units. Wallets whose minor
+// units were not read or are stale derive them from the decimal balance.
+func (w *Wallet) MinorBalance() MinorAmount {
+    return minorOrDecimal(w.BalanceMinor, w.Balance)
+}
+
+// MinorCreditLimit returns the credit limit in minor units, derived from
+// the decimal limit like MinorBalance
+func (w *Wallet) MinorCreditLimit() MinorAmount {
+    return minorOrDecimal(w.CreditLimitMinor, w.CreditLimit)
+}
+
+// minorOrDecimal returns minor units unless they disagree with the decimal
+// amount, which is then converted instead
+func minorOrDecimal(minor MinorAmount, amount float64) MinorAmount {
+    if minor.Decimal().Equal(decimal.NewFromFloat(amount)) {
+        return minor
+    }
+    derived, err := MinorOfFloat(amount)
+    if err != nil {
+        return minor
+    }
+    return derived
+}
+
+// SignedMinor returns the minor units the transaction moves the balance by,
+// signed as SignedAmount. Transactions without minor units derive them from
+// their amount.
+func (t *Transaction) SignedMinor() MinorAmount {
+    minor := minorOrDecimal(t.Minor, t.Amount)
+    switch signed := t.SignedAmount(); {
+    case signed < 0:
+        return minor.Neg()
+    case signed > 0:
+        return minor
+    }
+    return MinorAmount{}
+}
+
+// SignedAmount returns the amount the transaction moves the balance by:
+// positive for credits, refunds and increasing adjustments, negative for
+// debits and decreasing adjustments
+func (t *Transaction) SignedAmount() float64 {
+    switch t.Type {
+    case TransactionTypeCredit, TransactionTypeRefund:
+        return t.Amount
+    case TransactionTypeDebit:
+        return -t.Amount
+    case TransactionTypeAdjustment:
+        switch AdjustmentDirection(t.Metadata[MetadataAdjustmentDirection]) {
+        case AdjustmentIncrease:
+            return t.Amount
+        case AdjustmentDecrease:
+            return -t.Amount
+        }
+    }
+    return 0
+}
+
+// AvailableBalance returns the balance plus the unused credit
+func (w *Wallet) AvailableBalance() float64 {
+    available, err := w.MinorBalance().Add(w.MinorCreditLimit())
+    if err != nil {
+        return w.Balance + w.CreditLimit
+    }
+    return available.Float64()
+}
+
+// CreditUtilization returns the fraction of the credit limit in use, from 0
+// while the balance is non-negative to 1 when the limit is exhausted
+func (w *Wallet) CreditUtilization() float64 {
+    if w.CreditLimit <= 0 || w.Balance >= 0 {
+        return 0
+    }
+    return -w.Balance / w.CreditLimit
 }
 
 // Validate performs comprehensive validation of transaction data
@@ -104,13 +250,13 @@ func (t *Transaction) Validate() error {
         return ErrInvalidTransactionStatus
     }
 
-    // Validate amount
-    if t.Amount <= 0 {
+    // Validate amount, checking NaN explicitly as it fails every comparison
+    if math.IsNaN(t.Amount) || t.Amount <= 0 || t.Amount > MaxTransactionAmount {
         return ErrInvalidAmount
     }
 
-    // Validate currency (basic check - in production, use a proper currency validation library)
-    if len(t.Currency) != 3 {
+    // Validate currency as three uppercase ASCII letters, matching the schema
+    if !isCurrencyCode(t.Currency) {
         return ErrInvalidCurrency
     }
 
@@ -121,9 +267,34 @@ func (t *Transaction) Validate() error {
         }
     }
 
+    // Validate metadata key count and sizes
+    if err := ValidateMetadata(t.Metadata); err != nil {
+        return err
+    }
+
+    // Adjustments carry their reason, direction and approved adjustment
+    if t.Type == TransactionTypeAdjustment {
+        if err := ValidateAdjustmentMetadata(t.Metadata); err != nil {
+            return err
+        }
+    }
+
     return nil
 }
 
+// isCurrencyCode checks for an ISO 4217 style code of three uppercase letters
+func isCurrencyCode(code string) bool {
+    if len(code) != 3 {
+        return false
+    }
+    for i := 0; i < len(code); i++ {
+        if code[i] < 'A' || code[i] > 'Z' {
+            return false
+        }
+    }
+    return true
+}
+
 // String returns string representation of TransactionType
 func (t TransactionType) String() string {
     switch t {
@@ -133,6 +304,8 @@ func (t TransactionType) String() string {
         return "DEBIT"
     case TransactionTypeRefund:
         return "REFUND"
+    case TransactionTypeAdjustment:
+        return "ADJUSTMENT"
     default:
         return "UNKNOWN"
     }
@@ -154,4 +327,81 @@ func (s TransactionStatus) String() string {
     default:
         return "UNKNOWN"
     }
-}
\ No newline at end of file
+}
+
+// transactionTypesByName maps the names the type column of
+// wallet_transactions holds to transaction types
+var transactionTypesByName = map[string]TransactionType{
+    "CREDIT":     TransactionTypeCredit,
+    "DEBIT":      TransactionTypeDebit,
+    "REFUND":     TransactionTypeRefund,
+    "ADJUSTMENT": TransactionTypeAdjustment,
+}
+
+// transactionStatusesByName maps the names the status column of
+// wallet_transactions holds to transaction statuses
+var transactionStatusesByName = map[string]TransactionStatus{
+    "INITIATED":  TransactionStatusInitiated,
+    "PROCESSING": TransactionStatusProcessing,
+    "COMPLETED":  TransactionStatusCompleted,
+    "FAILED":     TransactionStatusFailed,
+    "REVERSED":   TransactionStatusReversed,
+}
+
+// Value stores a transaction type by name, as the type column of
+// wallet_transactions holds it
+func (t TransactionType) Value() (driver.Value, error) {
+    if !IsValidTransactionType(t) {
+        return nil, ErrInvalidTransactionType
+    }
+    return t.String(), nil
+}
+
+// Scan reads a transaction type stored by name
+func (t *TransactionType) Scan(src interface{}) error {
+    name, err := scanName(src)
+    if err != nil {
+        return err
+    }
+    candidate, ok := transactionTypesByName[name]
+    if !ok {
+        return fmt.Errorf("%w: %q", ErrInvalidTransactionType, name)
+    }
+    *t = candidate
+    return nil
+}
+
+// Value stores a transaction status by name, as the status column of
+// wallet_transactions holds it
+func (s TransactionStatus) Value() (driver.Value, error) {
+    if !IsValidTransactionStatus(s) {
+        return nil, ErrInvalidTransactionStatus
+    }
+    return s.String(), nil
+}
+
+// Scan reads a transaction status stored by name
+func (s *TransactionStatus) Scan(src interface{}) error {
+    name, err := scanName(src)
+    if err != nil {
+        return err
+    }
+    candidate, ok := transactionStatusesByName[name]
+    if !ok {
+        return fmt.Errorf("%w: %q", ErrInvalidTransactionStatus, name)
+    }
+    *s = candidate
+    return nil
+}
+
+// scanName returns the text of a column holding an enumeration by name
+func scanName(src interface{}) (string, error) {
+    switch v := src.(type) {
+    case string:
+        return v, nil
+    case []byte:
+        return string(v), nil
+    default:
+        return "", fmt.Errorf("cannot scan %T into an enumeration", src)
+    }
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// AnalyticsGranularity is the length of the periods wallet flows are
+// grouped by. Periods start at UTC midnight, weeks on Monday.
+type AnalyticsGranularity string
+
+const (
+	// GranularityDay groups flows by calendar day
+	GranularityDay AnalyticsGranularity = "day"
+	// GranularityWeek groups flows by ISO week
+	GranularityWeek AnalyticsGranularity = "week"
+	// GranularityMonth groups flows by calendar month
+	GranularityMonth AnalyticsGranularity = "month"
+)
+
+// IsValid checks if the granularity is one of the defined granularities
+func (g AnalyticsGranularity) IsValid() bool {
+	switch g {
+	case GranularityDay, GranularityWeek, GranularityMonth:
+		return true
+	}
+	return false
+}
+
+// Truncate returns the start of the period holding t, as PostgreSQL's
+// date_trunc does in UTC
+func (g AnalyticsGranularity) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch g {
+	case GranularityWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// Next returns the start of the period after the one starting at start
+func (g AnalyticsGranularity) Next(start time.Time) time.Time {
+	switch g {
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// WalletFlow totals the completed debits and credits of a wallet in one
+// period. Refunds count as credits.
+type WalletFlow struct {
+	PeriodStart time.Time       `json:"period_start"`
+	Debits      decimal.Decimal `json:"debits" class:"financial"`
+	DebitCount  int64           `json:"debit_count"`
+	Credits     decimal.Decimal `json:"credits" class:"financial"`
+	CreditCount int64           `json:"credit_count"`
+}
+
+// BurnRate projects when a wallet's balance runs out at its average daily
+// net spend over the analytics window
+type BurnRate struct {
+	DailyNetSpend decimal.Decimal `json:"daily_net_spend" class:"financial"`
+	// DaysRemaining and DepletesAt are omitted when the wallet took in at
+	// least as much as it spent
+	DaysRemaining *float64   `json:"days_remaining,omitempty"`
+	DepletesAt    *time.Time `json:"depletes_at,omitempty"`
+}
+
+// WalletAnalytics summarises the spending of a wallet over a window
+type WalletAnalytics struct {
+	WalletID      uuid.UUID            `json:"wallet_id"`
+	Currency      string               `json:"currency"`
+	Granularity   AnalyticsGranularity `json:"granularity"`
+	From          time.Time            `json:"from"`
+	To            time.Time            `json:"to"`
+	Balance       decimal.Decimal      `json:"balance" class:"financial"`
+	TotalDebits   decimal.Decimal      `json:"total_debits" class:"financial"`
+	TotalCredits  decimal.Decimal      `json:"total_credits" class:"financial"`
+	Periods       []*WalletFlow        `json:"periods"`
+	TopCategories []*CategorySpend     `json:"top_categories"`
+	BurnRate      BurnRate             `json:"burn_rate"`
+	GeneratedAt   time.Time            `json:"generated_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"github.com/google/uuid" // v1.3.0
+)
+
+// WalletBatchRowStatus is the outcome of a row of a wallet provisioning batch
+type WalletBatchRowStatus string
+
+const (
+	// WalletBatchRowPending has not been processed yet
+	WalletBatchRowPending WalletBatchRowStatus = "PENDING"
+	// WalletBatchRowCreated created a wallet for the customer
+	WalletBatchRowCreated WalletBatchRowStatus = "CREATED"
+	// WalletBatchRowExists found the customer already had a wallet in the
+	// currency, which is left as it is
+	WalletBatchRowExists WalletBatchRowStatus = "EXISTS"
+	// WalletBatchRowFailed could not be provisioned, as Error explains
+	WalletBatchRowFailed WalletBatchRowStatus = "FAILED"
+)
+
+// WalletBatchRow is one wallet to provision in a batch
+type WalletBatchRow struct {
+	// Index is the position of the row in the submitted batch, from 0
+	Index int `json:"index"`
+	// CustomerID is as submitted, and may not be a valid UUID
+	CustomerID string               `json:"customer_id"`
+	Currency   string               `json:"currency"`
+	Status     WalletBatchRowStatus `json:"status"`
+	WalletID   *uuid.UUID           `json:"wallet_id,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// WalletBatchResult is the outcome of a wallet provisioning batch, the
+// result of its job
+type WalletBatchResult struct {
+	BatchID  uuid.UUID         `json:"batch_id"`
+	Total    int               `json:"total"`
+	Created  int               `json:"created"`
+	Existing int               `json:"existing"`
+	Failed   int               `json:"failed"`
+	Rows     []*WalletBatchRow `json:"rows"`
+}
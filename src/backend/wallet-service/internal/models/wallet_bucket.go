@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// Metadata keys of debits targeted to a bucket. MetadataBucket names the
+// bucket on the request; MetadataBucketAmount is set to the part of the
+// debit the bucket covered, the rest having come from the unallocated
+// balance.
+const (
+	MetadataBucket       = "bucket"
+	MetadataBucketAmount = "bucket_amount"
+)
+
+// MaxBucketNameLength bounds the names of buckets
+const MaxBucketNameLength = 50
+
+// BucketFallback is what happens to a debit targeted to a bucket holding
+// less than its amount
+type BucketFallback string
+
+const (
+	// BucketFallbackNone refuses the debit, keeping the bucket a hard budget
+	BucketFallbackNone BucketFallback = "NONE"
+	// BucketFallbackUnallocated takes what the bucket holds and charges the
+	// rest to the wallet's unallocated balance
+	BucketFallbackUnallocated BucketFallback = "UNALLOCATED"
+)
+
+// IsValid checks if the fallback is one of the defined fallbacks
+func (f BucketFallback) IsValid() bool {
+	return f == BucketFallbackNone || f == BucketFallbackUnallocated
+}
+
+// WalletBucket is a named sub-balance of a wallet, such as an SMS budget.
+// Its balance is part of the wallet's balance set aside for debits naming
+// the bucket.
+type WalletBucket struct {
+	ID       uuid.UUID       `json:"id"`
+	WalletID uuid.UUID       `json:"wallet_id"`
+	Name     string          `json:"name"`
+	Balance  decimal.Decimal `json:"balance" class:"financial"`
+	// LowBalanceThreshold is the balance the bucket alerts at, zero for
+	// buckets that never alert
+	LowBalanceThreshold decimal.Decimal `json:"low_balance_threshold" class:"financial"`
+	Fallback            BucketFallback  `json:"fallback"`
+	// LowBalance is set from the alert until a transfer brings the balance
+	// back above the threshold
+	LowBalance bool      `json:"low_balance"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// IsLowBalance checks if the bucket is at or below its low balance
+// threshold
+func (b *WalletBucket) IsLowBalance() bool {
+	return b.LowBalanceThreshold.IsPositive() && b.Balance.LessThanOrEqual(b.LowBalanceThreshold)
+}
+
+// WalletBuckets is how a wallet's balance splits into its buckets and the
+// unallocated rest, which debits naming no bucket are charged to
+type WalletBuckets struct {
+	WalletID    uuid.UUID       `json:"wallet_id"`
+	Currency    string          `json:"currency"`
+	Balance     decimal.Decimal `json:"balance" class:"financial"`
+	Unallocated decimal.Decimal `json:"unallocated" class:"financial"`
+	Buckets     []*WalletBucket `json:"buckets"`
+}
+
+// BucketTransfer moves funds between two buckets of a wallet, or between a
+// bucket and the unallocated balance when From or To is empty
+type BucketTransfer struct {
+	WalletID uuid.UUID       `json:"wallet_id"`
+	From     string          `json:"from,omitempty"`
+	To       string          `json:"to,omitempty"`
+	Amount   decimal.Decimal `json:"amount" class:"financial"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// WalletReactivation is a wallet that transacted again after it was flagged
+// inactive. The wallet is active again; InactiveSince is when it had been
+// flagged.
+type WalletReactivation struct {
+	Wallet        *Wallet
+	InactiveSince time.Time
+}
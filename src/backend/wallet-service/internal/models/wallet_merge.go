@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// WalletMergeStatus is the state of a duplicate wallet merge
+type WalletMergeStatus string
+
+const (
+	// WalletMerged redirects the source wallet to the target wallet, which
+	// holds the source's balance and history
+	WalletMerged WalletMergeStatus = "MERGED"
+	// WalletMergeReversed put the balance and history back on the source
+	WalletMergeReversed WalletMergeStatus = "REVERSED"
+)
+
+// WalletMerge records a duplicate wallet merged into a surviving wallet of
+// the same customer and currency. While merged, the source wallet refuses
+// postings and redirects to the target.
+type WalletMerge struct {
+	ID             uuid.UUID         `json:"id"`
+	SourceWalletID uuid.UUID         `json:"source_wallet_id"`
+	TargetWalletID uuid.UUID         `json:"target_wallet_id"`
+	Status         WalletMergeStatus `json:"status"`
+	// Amount is the balance moved from the source to the target
+	Amount float64 `json:"amount" class:"financial"`
+	// TransactionIDs are the source's transactions moved to the target
+	TransactionIDs  []uuid.UUID `json:"transaction_ids"`
+	MergedAt        time.Time   `json:"merged_at"`
+	ReversibleUntil time.Time   `json:"reversible_until"`
+	ReversedAt      *time.Time  `json:"reversed_at,omitempty"`
+}
+
+// Reversible reports whether the merge may still be reversed at now
+func (m *WalletMerge) Reversible(now time.Time) bool {
+	return m.Status == WalletMerged && now.Before(m.ReversibleUntil)
+}
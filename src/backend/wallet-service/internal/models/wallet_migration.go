@@ -0,0 +1,73 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// WalletExportVersion is the format version of wallet exports
+const WalletExportVersion = 1
+
+// WalletMigrationDirection tells whether a wallet moved out of or into this
+// deployment
+type WalletMigrationDirection string
+
+const (
+	// WalletMigrationExport moved the wallet out to another deployment
+	WalletMigrationExport WalletMigrationDirection = "EXPORT"
+	// WalletMigrationImport moved the wallet in from another deployment
+	WalletMigrationImport WalletMigrationDirection = "IMPORT"
+)
+
+// WalletMigrationStatus is the cutover state of a migrated wallet
+type WalletMigrationStatus string
+
+const (
+	// WalletMigrationFrozen refuses postings: an exported wallet on the
+	// source, or an imported wallet awaiting activation on the target
+	WalletMigrationFrozen WalletMigrationStatus = "FROZEN"
+	// WalletMigrationActive is an imported wallet activated on the target
+	WalletMigrationActive WalletMigrationStatus = "ACTIVE"
+)
+
+// WalletMigration is the latest move of a wallet between deployments
+type WalletMigration struct {
+	WalletID    uuid.UUID                `json:"wallet_id"`
+	Direction   WalletMigrationDirection `json:"direction"`
+	Status      WalletMigrationStatus    `json:"status"`
+	Checksum    string                   `json:"checksum"`
+	ExportedAt  time.Time                `json:"exported_at"`
+	CreatedAt   time.Time                `json:"created_at"`
+	ActivatedAt *time.Time               `json:"activated_at,omitempty"`
+}
+
+// WalletExport moves a wallet to another deployment: its balance, its open
+// holds with the top-ups and payments they refund, and its recent history.
+// The checksum covers every other field.
+type WalletExport struct {
+	Version      int                `json:"version"`
+	ExportedAt   time.Time          `json:"exported_at"`
+	Wallet       Wallet             `json:"wallet"`
+	Transactions []*Transaction     `json:"transactions"`
+	Payments     []*ProviderPayment `json:"payments"`
+	Holds        []*ProviderRefund  `json:"holds"`
+	Checksum     string             `json:"checksum,omitempty"`
+}
+
+// ComputeChecksum returns the hex SHA-256 of the export's JSON encoding
+// without its checksum
+func (e *WalletExport) ComputeChecksum() (string, error) {
+	unsigned := *e
+	unsigned.Checksum = ""
+
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
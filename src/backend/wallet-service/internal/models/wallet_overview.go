@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// WalletOverview is a wallet with its latest transactions, both read from
+// one database snapshot so the balance accounts for exactly the
+// transactions listed
+type WalletOverview struct {
+	Wallet *Wallet
+	// Transactions are the newest first
+	Transactions []*Transaction
+	// AsOf is the time of the snapshot
+	AsOf time.Time
+}
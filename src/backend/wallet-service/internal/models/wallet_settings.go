@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// WalletNotificationPreferences chooses the alerts sent about a wallet. The
+// customer's channel consents still apply on top.
+type WalletNotificationPreferences struct {
+	LowBalance         bool `json:"low_balance"`
+	CreditLimitWarning bool `json:"credit_limit_warning"`
+}
+
+// WalletSettings holds the configurable behaviour of a wallet. Settings are
+// versioned separately from the balance, so edits never race transactions.
+type WalletSettings struct {
+	WalletID uuid.UUID `json:"wallet_id"`
+	// LowBalanceThreshold is zero for wallets using the service default
+	LowBalanceThreshold float64                       `json:"low_balance_threshold" class:"financial"`
+	CreditLimit         float64                       `json:"credit_limit" class:"financial"`
+	Notifications       WalletNotificationPreferences `json:"notifications"`
+	// AutoTopUp records whether the customer opted into automatic top-ups
+	// of a low balance
+	AutoTopUp bool      `json:"auto_topup"`
+	Version   int64     `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultWalletSettings returns the settings of a wallet never configured
+func DefaultWalletSettings(wallet *Wallet) *WalletSettings {
+	return &WalletSettings{
+		WalletID:            wallet.ID,
+		LowBalanceThreshold: wallet.LowBalanceThreshold,
+		CreditLimit:         wallet.CreditLimit,
+		Notifications:       WalletNotificationPreferences{LowBalance: true, CreditLimitWarning: true},
+		Version:             1,
+		UpdatedAt:           wallet.UpdatedAt,
+	}
+}
+
+// BalanceStatus summarises a wallet balance against its thresholds
+type BalanceStatus string
+
+const (
+	// BalanceHealthy is a balance above the low balance threshold
+	BalanceHealthy BalanceStatus = "HEALTHY"
+	// BalanceLow is a balance at or below the low balance threshold
+	BalanceLow BalanceStatus = "LOW"
+	// BalanceOverdrawn is a negative balance drawing on the credit limit
+	BalanceOverdrawn BalanceStatus = "OVERDRAWN"
+)
+
+// WalletHealth reports the state of a wallet for monitoring
+type WalletHealth struct {
+	WalletID            uuid.UUID     `json:"wallet_id"`
+	BalanceStatus       BalanceStatus `json:"balance_status"`
+	Balance             float64       `json:"balance" class:"financial"`
+	AvailableBalance    float64       `json:"available_balance" class:"financial"`
+	LowBalanceThreshold float64       `json:"low_balance_threshold" class:"financial"`
+	// RecentTransactions and RecentFailures count the transactions created
+	// since WindowStart, FailureRate being their ratio
+	RecentTransactions int       `json:"recent_transactions"`
+	RecentFailures     int       `json:"recent_failures"`
+	FailureRate        float64   `json:"failure_rate"`
+	WindowStart        time.Time `json:"window_start"`
+	// LimitUtilization is the fraction of the credit limit in use
+	LimitUtilization float64   `json:"limit_utilization"`
+	CheckedAt        time.Time `json:"checked_at"`
+}
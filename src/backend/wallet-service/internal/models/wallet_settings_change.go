@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// SettingCreditLimit names the credit limit in wallet settings changes
+const SettingCreditLimit = "credit_limit"
+
+// WalletSettingsChangeStatus is the review state of a wallet settings change
+type WalletSettingsChangeStatus string
+
+const (
+	// WalletSettingsChangePending awaits approval by a second operator
+	WalletSettingsChangePending WalletSettingsChangeStatus = "PENDING"
+	// WalletSettingsChangeApproved was approved and is being applied
+	WalletSettingsChangeApproved WalletSettingsChangeStatus = "APPROVED"
+	// WalletSettingsChangeApplied was applied to the wallet
+	WalletSettingsChangeApplied WalletSettingsChangeStatus = "APPLIED"
+	// WalletSettingsChangeRejected was rejected or withdrawn
+	WalletSettingsChangeRejected WalletSettingsChangeStatus = "REJECTED"
+)
+
+// IsValid checks if the status is one of the defined statuses
+func (s WalletSettingsChangeStatus) IsValid() bool {
+	switch s {
+	case WalletSettingsChangePending, WalletSettingsChangeApproved, WalletSettingsChangeApplied, WalletSettingsChangeRejected:
+		return true
+	}
+	return false
+}
+
+// SettingDiff is a wallet setting moved by a settings change
+type SettingDiff struct {
+	Field string          `json:"field"`
+	From  decimal.Decimal `json:"from" class:"financial"`
+	To    decimal.Decimal `json:"to" class:"financial"`
+}
+
+// WalletSettingsChange is a staged change of a sensitive wallet setting,
+// such as a credit limit increase, kept as its audit record. It is applied
+// only once approved by an operator other than the one who requested it,
+// and only while the settings are still as they were when requested.
+type WalletSettingsChange struct {
+	ID       uuid.UUID                  `json:"id"`
+	WalletID uuid.UUID                  `json:"wallet_id"`
+	Status   WalletSettingsChangeStatus `json:"status"`
+	// Diff holds the settings the change moves and their values when the
+	// change was requested
+	Diff        []SettingDiff `json:"diff"`
+	Reason      string        `json:"reason"`
+	RequestedBy string        `json:"requested_by"`
+	ReviewedBy  string        `json:"reviewed_by,omitempty"`
+	ReviewNote  string        `json:"review_note,omitempty"`
+	ReviewedAt  *time.Time    `json:"reviewed_at,omitempty"`
+	AppliedAt   *time.Time    `json:"applied_at,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// WalletSettingsChangeFilter selects the settings changes listed
+type WalletSettingsChangeFilter struct {
+	WalletID *uuid.UUID
+	Status   WalletSettingsChangeStatus
+	Limit    int
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// WebhookDeliveryStatus is the outcome of a webhook delivery attempt
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDelivered means the endpoint responded with a 2xx status
+	WebhookDelivered WebhookDeliveryStatus = "DELIVERED"
+	// WebhookFailed means the request failed or the endpoint returned an error
+	WebhookFailed WebhookDeliveryStatus = "FAILED"
+)
+
+// WebhookSubscription is a customer endpoint receiving wallet events. Empty
+// EventTypes, WalletIDs and Fields mean all event types, all of the
+// customer's wallets and the full payload respectively.
+type WebhookSubscription struct {
+	ID         uuid.UUID `json:"id"`
+	CustomerID uuid.UUID `json:"customer_id"`
+	// WalletID is set on wallet-level subscriptions, which are managed
+	// through the wallet and only receive its events
+	WalletID   *uuid.UUID  `json:"wallet_id,omitempty"`
+	URL        string      `json:"url"`
+	Secret     string      `json:"secret,omitempty"`
+	EventTypes []string    `json:"event_types"`
+	WalletIDs  []uuid.UUID `json:"wallet_ids"`
+	Fields     []string    `json:"fields"`
+	Enabled    bool        `json:"enabled"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// WebhookDelivery records one attempt to deliver an event to a subscription
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id"`
+	SubscriptionID uuid.UUID             `json:"subscription_id"`
+	EventID        uuid.UUID             `json:"event_id"`
+	EventType      string                `json:"event_type"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	ResponseCode   int                   `json:"response_code,omitempty"`
+	Error          string                `json:"error,omitempty"`
+	AttemptedAt    time.Time             `json:"attempted_at"`
+}
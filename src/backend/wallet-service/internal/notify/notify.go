@@ -0,0 +1,73 @@
+// Package notify sends customer notifications over email, SMS and Slack.
+// Each notification event is rendered into a message from its template, and
+// every channel kind is sent through its own pluggable Sender.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+
+	"internal/models"
+)
+
+// maxResponseBody bounds how much of a provider's response is read
+const maxResponseBody = 4 << 10
+
+// slackHost is the host of Slack incoming webhook URLs
+const slackHost = "hooks.slack.com"
+
+// ErrInvalidDestination is returned for destinations a channel kind cannot
+// send to
+var ErrInvalidDestination = errors.New("invalid notification destination")
+
+// phonePattern matches E.164 phone numbers
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// Message is a rendered notification. Channels without subjects send the
+// body only, and only email carries attachments.
+type Message struct {
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Attachment is a file sent with an email notification
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Sender sends messages on one channel kind
+type Sender interface {
+	Send(ctx context.Context, destination string, msg Message) error
+}
+
+// ValidateDestination checks that a destination can be sent to on a channel
+// kind: a bare email address, an E.164 phone number or a Slack incoming
+// webhook URL
+func ValidateDestination(kind models.NotificationChannelKind, destination string) error {
+	switch kind {
+	case models.NotificationChannelEmail:
+		addr, err := mail.ParseAddress(destination)
+		if err != nil || addr.Address != destination {
+			return fmt.Errorf("%w: destination must be an email address", ErrInvalidDestination)
+		}
+	case models.NotificationChannelSMS:
+		if !phonePattern.MatchString(destination) {
+			return fmt.Errorf("%w: destination must be an E.164 phone number", ErrInvalidDestination)
+		}
+	case models.NotificationChannelSlack:
+		endpoint, err := url.Parse(destination)
+		if err != nil || endpoint.Scheme != "https" || endpoint.Host != slackHost || endpoint.User != nil {
+			return fmt.Errorf("%w: destination must be a Slack incoming webhook URL", ErrInvalidDestination)
+		}
+	default:
+		return fmt.Errorf("%w: unknown channel kind %q", ErrInvalidDestination, kind)
+	}
+	return nil
+}
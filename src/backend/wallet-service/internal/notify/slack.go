@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SlackSender posts notifications to Slack incoming webhooks
+type SlackSender struct {
+	client *http.Client
+}
+
+// NewSlackSender creates a new SlackSender whose requests time out after
+// timeout
+func NewSlackSender(timeout time.Duration) (*SlackSender, error) {
+	if timeout <= 0 {
+		return nil, errors.New("Slack timeout must be positive")
+	}
+
+	return &SlackSender{
+		client: &http.Client{
+			Timeout: timeout,
+			// Webhook URLs are validated as Slack's and must not bounce
+			// notifications elsewhere
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+// Send posts the message to the destination webhook URL, with the subject
+// in bold above the body
+func (s *SlackSender) Send(ctx context.Context, destination string, msg Message) error {
+	text := msg.Body
+	if msg.Subject != "" {
+		text = "*" + msg.Subject + "*\n" + msg.Body
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("failed to encode Slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, destination, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Slack request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Slack returned %d", resp.StatusCode)
+	}
+	return nil
+}
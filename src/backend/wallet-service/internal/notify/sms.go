@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SMSProvider sends text messages to phone numbers. Deployments plug in
+// their SMS gateway by implementing it.
+type SMSProvider interface {
+	SendSMS(ctx context.Context, to, text string) error
+}
+
+// SMSSender sends the body of notifications through an SMS provider
+type SMSSender struct {
+	provider SMSProvider
+}
+
+// NewSMSSender creates a new SMSSender sending through provider
+func NewSMSSender(provider SMSProvider) (*SMSSender, error) {
+	if provider == nil {
+		return nil, errors.New("SMS provider is required")
+	}
+
+	return &SMSSender{provider: provider}, nil
+}
+
+// Send texts the message body to the destination phone number
+func (s *SMSSender) Send(ctx context.Context, destination string, msg Message) error {
+	return s.provider.SendSMS(ctx, destination, msg.Body)
+}
+
+// HTTPSMSProvider sends text messages through an HTTP gateway taking a JSON
+// body of from, to and text, authenticated with a bearer API key
+type HTTPSMSProvider struct {
+	url    string
+	apiKey string
+	from   string
+	client *http.Client
+}
+
+// NewHTTPSMSProvider creates a new HTTPSMSProvider for the gateway at
+// gatewayURL whose requests time out after timeout
+func NewHTTPSMSProvider(gatewayURL, apiKey, from string, timeout time.Duration) (*HTTPSMSProvider, error) {
+	endpoint, err := url.Parse(gatewayURL)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, errors.New("SMS gateway URL must be an absolute https URL")
+	}
+	if apiKey == "" {
+		return nil, errors.New("SMS gateway API key is required")
+	}
+	if timeout <= 0 {
+		return nil, errors.New("SMS gateway timeout must be positive")
+	}
+
+	return &HTTPSMSProvider{
+		url:    gatewayURL,
+		apiKey: apiKey,
+		from:   from,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// SendSMS posts the text message to the gateway. Any status outside 2xx is
+// reported as an error.
+func (p *HTTPSMSProvider) SendSMS(ctx context.Context, to, text string) error {
+	body, err := json.Marshal(map[string]string{
+		"from": p.from,
+		"to":   to,
+		"text": text,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode SMS request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("SMS gateway request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("SMS gateway returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"internal/eventbus"
+)
+
+// ErrInvalidTemplate is returned for notification templates that cannot be
+// parsed or rendered
+var ErrInvalidTemplate = errors.New("invalid notification template")
+
+// Template is a notification template in configuration form. Subject and
+// Body are text/template sources executed with the event payload, whose
+// fields are referred to by their JSON names, e.g. {{.balance}}.
+type Template struct {
+	EventType string
+	Subject   string
+	Body      string
+}
+
+// defaultTemplates are the templates of the events notified on channels,
+// which configured templates replace field by field
+var defaultTemplates = []Template{
+	{
+		EventType: eventbus.TypeLowBalance,
+		Subject:   "Low wallet balance",
+		Body: "Your wallet balance is {{.balance}} {{.currency}}, at or below your low balance threshold " +
+			"of {{.threshold}} {{.currency}}. Recharge to avoid interruption.",
+	},
+	{
+		EventType: eventbus.TypeBucketLowBalance,
+		Subject:   "Low {{.bucket}} balance",
+		Body: "Your {{.bucket}} balance is {{.balance}} {{.currency}}, at or below its low balance threshold " +
+			"of {{.threshold}} {{.currency}}. Move funds into it to keep debits charged to it going.",
+	},
+	{
+		EventType: eventbus.TypeDunningNotice,
+		Subject:   "{{if .final}}Final notice: {{end}}wallet balance exhausted",
+		Body: "Your wallet balance is {{.balance}} {{.currency}}. Service continues until {{.grace_ends_at}}; " +
+			"recharge before then to avoid suspension.",
+	},
+	{
+		EventType: eventbus.TypeInvoiceIssued,
+		Subject:   "Invoice {{.invoice_number}}",
+		Body: "Invoice {{.invoice_number}} for {{.total_amount}} {{.currency}} is due on {{.due_date}}." +
+			"{{if .url}} View it at {{.url}}{{end}}",
+	},
+	{
+		EventType: eventbus.TypeTransactionCompleted,
+		Subject:   "Large transaction on your wallet",
+		Body:      "A {{lower .type}} of {{.amount}} {{.currency}} was completed on wallet {{.wallet_id}}.",
+	},
+}
+
+// funcs are the functions templates may call besides the text/template
+// builtins
+var funcs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// compiled is a parsed notification template
+type compiled struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// Templates renders notification events into messages
+type Templates struct {
+	byType map[string]*compiled
+}
+
+// NewTemplates parses the default templates with the configured ones
+// replacing their subject or body. Only events with a default template can
+// be configured.
+func NewTemplates(overrides []Template) (*Templates, error) {
+	sources := make(map[string]Template, len(defaultTemplates))
+	for _, t := range defaultTemplates {
+		sources[t.EventType] = t
+	}
+	for _, override := range overrides {
+		t, ok := sources[override.EventType]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not notified on channels", ErrInvalidTemplate, override.EventType)
+		}
+		if strings.TrimSpace(override.Subject) != "" {
+			t.Subject = override.Subject
+		}
+		if strings.TrimSpace(override.Body) != "" {
+			t.Body = override.Body
+		}
+		sources[override.EventType] = t
+	}
+
+	templates := &Templates{byType: make(map[string]*compiled, len(sources))}
+	for eventType, t := range sources {
+		subject, err := parse(eventType+" subject", t.Subject)
+		if err != nil {
+			return nil, err
+		}
+		body, err := parse(eventType+" body", t.Body)
+		if err != nil {
+			return nil, err
+		}
+		templates.byType[eventType] = &compiled{subject: subject, body: body}
+	}
+	return templates, nil
+}
+
+// parse parses one template source, failing on fields the payload lacks
+// when executed
+func parse(name, source string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return t, nil
+}
+
+// EventTypes lists the event types that have a template, sorted
+func (t *Templates) EventTypes() []string {
+	types := make([]string, 0, len(t.byType))
+	for eventType := range t.byType {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Supports reports whether events of a type have a template
+func (t *Templates) Supports(eventType string) bool {
+	_, ok := t.byType[eventType]
+	return ok
+}
+
+// Render renders an event payload into the message of its type
+func (t *Templates) Render(eventType string, payload json.RawMessage) (Message, error) {
+	c, ok := t.byType[eventType]
+	if !ok {
+		return Message{}, fmt.Errorf("%w: no template for %q", ErrInvalidTemplate, eventType)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var data map[string]interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return Message{}, fmt.Errorf("failed to decode %s payload: %w", eventType, err)
+	}
+
+	var subject, body strings.Builder
+	if err := c.subject.Execute(&subject, data); err != nil {
+		return Message{}, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	if err := c.body.Execute(&body, data); err != nil {
+		return Message{}, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(body.String()),
+	}, nil
+}
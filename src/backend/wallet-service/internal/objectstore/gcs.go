@@ -0,0 +1,66 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/storage" // v1.30.1
+)
+
+// GCSStore keeps objects in a Google Cloud Storage bucket. Credentials come
+// from Application Default Credentials, whose service account also signs
+// the download links.
+type GCSStore struct {
+	bucket *storage.BucketHandle
+	name   string
+}
+
+// NewGCSStore creates a new Google Cloud Storage object store for a bucket
+func NewGCSStore(ctx context.Context, bucket string) (*GCSStore, error) {
+	if bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+
+	return &GCSStore{bucket: client.Bucket(bucket), name: bucket}, nil
+}
+
+// Upload streams body to the object in resumable chunks. A body failing
+// part way cancels the upload rather than leaving a truncated object.
+func (s *GCSStore) Upload(ctx context.Context, key, contentType string, body io.Reader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := s.bucket.Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+	if _, err := io.Copy(w, body); err != nil {
+		cancel()
+		w.Close()
+		return fmt.Errorf("failed to upload gs://%s/%s: %w", s.name, key, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to upload gs://%s/%s: %w", s.name, key, err)
+	}
+	return nil
+}
+
+// SignedURL signs a V4 GET of the object
+func (s *GCSStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	url, err := s.bucket.SignedURL(key, &storage.SignedURLOptions{
+		Scheme:  storage.SigningSchemeV4,
+		Method:  http.MethodGet,
+		Expires: time.Now().Add(expiry),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign gs://%s/%s: %w", s.name, key, err)
+	}
+	return url, nil
+}
@@ -0,0 +1,73 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"                // v1.18.1
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"   // v1.18.27
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager" // v1.11.71
+	"github.com/aws/aws-sdk-go-v2/service/s3"         // v1.36.0
+)
+
+// S3Store keeps objects in an S3 bucket. Credentials for AWS come from the
+// default chain.
+type S3Store struct {
+	bucket   string
+	uploader *manager.Uploader
+	presign  *s3.PresignClient
+}
+
+// NewS3Store creates a new S3 object store for a bucket. An empty region
+// uses the region of the default AWS configuration.
+func NewS3Store(ctx context.Context, region, bucket string) (*S3Store, error) {
+	if bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg)
+	return &S3Store{
+		bucket:   bucket,
+		uploader: manager.NewUploader(client),
+		presign:  s3.NewPresignClient(client),
+	}, nil
+}
+
+// Upload streams body to the object as a multipart upload, so its size
+// need not be known up front
+func (s *S3Store) Upload(ctx context.Context, key, contentType string, body io.Reader) error {
+	_, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		Body:        body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return nil
+}
+
+// SignedURL presigns a GET of the object
+func (s *S3Store) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	req, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return req.URL, nil
+}
@@ -0,0 +1,30 @@
+// Package objectstore writes files to cloud object storage, S3 or Google
+// Cloud Storage, and hands out time-limited links for downloading them
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Store writes objects and signs links to them
+type Store interface {
+	// Upload streams body to the object at key until body is drained
+	Upload(ctx context.Context, key, contentType string, body io.Reader) error
+	// SignedURL returns a link downloading the object at key until expiry
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// New creates the object store of a provider, s3 or gcs
+func New(ctx context.Context, provider, region, bucket string) (Store, error) {
+	switch provider {
+	case "s3":
+		return NewS3Store(ctx, region, bucket)
+	case "gcs":
+		return NewGCSStore(ctx, bucket)
+	default:
+		return nil, fmt.Errorf("unsupported object store provider: %s", provider)
+	}
+}
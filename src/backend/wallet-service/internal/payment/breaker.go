@@ -0,0 +1,56 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"internal/breaker"
+)
+
+// guardedProvider is a provider whose calls go through a circuit breaker
+type guardedProvider struct {
+	Provider
+	breaker *breaker.Breaker
+}
+
+// WithBreaker guards the calls to a provider with a circuit breaker. While
+// the breaker is open calls fail with breaker.ErrOpen without reaching the
+// provider.
+func WithBreaker(p Provider, b *breaker.Breaker) (Provider, error) {
+	if p == nil {
+		return nil, errors.New("provider is required")
+	}
+	if b == nil {
+		return nil, fmt.Errorf("circuit breaker of provider %s is required", p.Name())
+	}
+	return &guardedProvider{Provider: p, breaker: b}, nil
+}
+
+// Successful reports whether a provider call outcome shows the provider is
+// up; a rejected refund is an answer of a working provider
+func Successful(err error) bool {
+	return err == nil || errors.Is(err, ErrRefundRejected) || errors.Is(err, context.Canceled)
+}
+
+// Refund submits a refund of a payment through the breaker
+func (p *guardedProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	var result *RefundResult
+	err := p.breaker.Do(func() error {
+		var err error
+		result, err = p.Provider.Refund(ctx, req)
+		return err
+	})
+	return result, err
+}
+
+// GetRefund fetches the state of a submitted refund through the breaker
+func (p *guardedProvider) GetRefund(ctx context.Context, refundID string) (*RefundResult, error) {
+	var result *RefundResult
+	err := p.breaker.Do(func() error {
+		var err error
+		result, err = p.Provider.GetRefund(ctx, refundID)
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,99 @@
+// Package payment defines the adapter interface to external payment
+// providers used to push money back to a customer's original payment method
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// Provider errors
+var (
+	// ErrProviderNotConfigured is returned for providers without an adapter
+	ErrProviderNotConfigured = errors.New("payment provider not configured")
+	// ErrRefundRejected is returned when a provider permanently refuses a
+	// refund, e.g. because the payment method was closed. Other errors are
+	// treated as transient and retried.
+	ErrRefundRejected = errors.New("refund rejected by payment provider")
+)
+
+// RefundStatus is the provider-side state of a refund
+type RefundStatus string
+
+const (
+	// RefundPending means the provider accepted the refund but has not settled it
+	RefundPending RefundStatus = "PENDING"
+	// RefundSucceeded means the money was returned to the payment method
+	RefundSucceeded RefundStatus = "SUCCEEDED"
+	// RefundFailed means the provider could not return the money
+	RefundFailed RefundStatus = "FAILED"
+)
+
+// RefundRequest asks a provider to refund part or all of a payment
+type RefundRequest struct {
+	PaymentID string
+	Amount    decimal.Decimal
+	Currency  string
+	// IdempotencyKey lets the provider deduplicate resubmitted refunds
+	IdempotencyKey string
+}
+
+// RefundResult is a provider's view of a refund
+type RefundResult struct {
+	RefundID      string
+	Status        RefundStatus
+	FailureReason string
+}
+
+// Provider is implemented by each payment gateway adapter
+type Provider interface {
+	// Name identifies the provider, matching the name recorded with payments
+	Name() string
+	// Refund submits a refund of a payment
+	Refund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+	// GetRefund fetches the current state of a submitted refund
+	GetRefund(ctx context.Context, refundID string) (*RefundResult, error)
+}
+
+// Registry holds the configured provider adapters by name
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry creates a registry of the given providers
+func NewRegistry(providers ...Provider) (*Registry, error) {
+	r := &Registry{providers: make(map[string]Provider)}
+	for _, p := range providers {
+		if p == nil {
+			return nil, errors.New("provider is required")
+		}
+		if _, exists := r.providers[p.Name()]; exists {
+			return nil, fmt.Errorf("duplicate payment provider %q", p.Name())
+		}
+		r.providers[p.Name()] = p
+	}
+	return r, nil
+}
+
+// Get returns the adapter of a provider
+func (r *Registry) Get(name string) (Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotConfigured, name)
+	}
+	return p, nil
+}
+
+// Names returns the configured provider names in order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8" // v8.11.5
+	"github.com/google/uuid"       // v1.3.0
+
+	"internal/models"
+)
+
+// windowKeyPrefix is the Redis key prefix for per-subject request windows
+const windowKeyPrefix = "ratelimit:"
+
+// slidingWindowScript trims the window, admits the request when there is
+// capacity and reports the remaining budget in a single atomic step. Redis
+// server time is used so replicas with skewed clocks share one window.
+//
+// KEYS[1] window key, ARGV[1] limit, ARGV[2] window in microseconds,
+// ARGV[3] unique request member. Returns {allowed, remaining, reset_us}.
+var slidingWindowScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], math.ceil(window / 1000))
+
+local reset = now + window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if #oldest > 0 then
+	reset = tonumber(oldest[2]) + window
+end
+
+return {allowed, limit - count, reset}
+`)
+
+// Result is the outcome of a rate limit check
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
+}
+
+// Limiter enforces sliding window rate limits with state shared in Redis,
+// so every service replica counts against the same window
+type Limiter struct {
+	redis  *redis.Client
+	prefix string
+}
+
+// NewLimiter creates a new Redis backed sliding window limiter. Deployments
+// sharing Redis count in separate windows when their namespaces differ; the
+// empty namespace is the default one.
+func NewLimiter(client *redis.Client, namespace string) (*Limiter, error) {
+	if client == nil {
+		return nil, errors.New("redis client is required")
+	}
+
+	prefix := windowKeyPrefix
+	if namespace != "" {
+		prefix += namespace + ":"
+	}
+	return &Limiter{redis: client, prefix: prefix}, nil
+}
+
+// Allow records a request for the subject and reports whether it fits in the
+// policy window. Rejected requests do not consume capacity.
+func (l *Limiter) Allow(ctx context.Context, subject string, policy models.RateLimitPolicy) (Result, error) {
+	if policy.Limit <= 0 || policy.Window <= 0 {
+		return Result{}, errors.New("policy must have a positive limit and window")
+	}
+
+	values, err := slidingWindowScript.Run(ctx, l.redis,
+		[]string{l.prefix + subject},
+		policy.Limit,
+		policy.Window.Microseconds(),
+		uuid.NewString(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to evaluate rate limit: %w", err)
+	}
+	if len(values) != 3 {
+		return Result{}, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+
+	return Result{
+		Allowed:   values[0] == 1,
+		Limit:     policy.Limit,
+		Remaining: int(values[1]),
+		ResetAt:   time.UnixMicro(values[2]),
+	}, nil
+}
@@ -0,0 +1,150 @@
+// Package ratelimit resolves per-customer rate limit policies and enforces
+// them with a counter shared across service replicas
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8" // v8.11.5
+	"github.com/google/uuid"       // v1.3.0
+
+	"internal/models"
+	"internal/repository"
+)
+
+// policyCachePrefix is the Redis key prefix for cached customer policies
+const policyCachePrefix = "ratelimit:policy:"
+
+// defaultPolicyMarker is cached for customers without a tier, so they are not
+// looked up per request either. The marker rather than the policy is cached
+// so a reloaded default policy applies to them at once.
+const defaultPolicyMarker = "default"
+
+// PolicyResolver resolves the rate limit policy of a customer from the
+// database, caching results in Redis so the lookup is not paid per request
+type PolicyResolver struct {
+	repo     repository.RateLimitRepository
+	redis    *redis.Client
+	cacheTTL time.Duration
+
+	mu            sync.RWMutex
+	defaultPolicy models.RateLimitPolicy
+	multiplier    int
+}
+
+// NewPolicyResolver creates a new policy resolver. The default policy applies
+// to unauthenticated callers and customers without a resolvable tier.
+func NewPolicyResolver(repo repository.RateLimitRepository, client *redis.Client, cacheTTL time.Duration, defaultPolicy models.RateLimitPolicy) (*PolicyResolver, error) {
+	if repo == nil {
+		return nil, errors.New("rate limit repository is required")
+	}
+	if client == nil {
+		return nil, errors.New("redis client is required")
+	}
+	if defaultPolicy.Limit <= 0 || defaultPolicy.Window <= 0 {
+		return nil, errors.New("default policy must have a positive limit and window")
+	}
+
+	return &PolicyResolver{
+		repo:          repo,
+		redis:         client,
+		cacheTTL:      cacheTTL,
+		defaultPolicy: defaultPolicy,
+		multiplier:    1,
+	}, nil
+}
+
+// SetLimitMultiplier scales the limit of every resolved policy, as for
+// read-only deployments relaxing the limits of the transactional path
+func (r *PolicyResolver) SetLimitMultiplier(multiplier int) error {
+	if multiplier < 1 {
+		return errors.New("limit multiplier must be at least 1")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.multiplier = multiplier
+	return nil
+}
+
+// scaled returns the policy with its limit multiplied
+func (r *PolicyResolver) scaled(policy models.RateLimitPolicy) models.RateLimitPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policy.Limit *= r.multiplier
+	return policy
+}
+
+// SetDefaultPolicy replaces the default policy, as on configuration reload
+func (r *PolicyResolver) SetDefaultPolicy(policy models.RateLimitPolicy) error {
+	if policy.Limit <= 0 || policy.Window <= 0 {
+		return errors.New("default policy must have a positive limit and window")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.defaultPolicy = policy
+	return nil
+}
+
+// defaults returns the current default policy
+func (r *PolicyResolver) defaults() models.RateLimitPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.defaultPolicy
+}
+
+// Resolve returns the policy for the customer, falling back to the default
+// policy when the customer is unknown or the lookup fails
+func (r *PolicyResolver) Resolve(ctx context.Context, customerID string) (models.RateLimitPolicy, error) {
+	policy, err := r.resolve(ctx, customerID)
+	return r.scaled(policy), err
+}
+
+// resolve returns the unscaled policy for the customer
+func (r *PolicyResolver) resolve(ctx context.Context, customerID string) (models.RateLimitPolicy, error) {
+	id, err := uuid.Parse(customerID)
+	if err != nil {
+		return r.defaults(), nil
+	}
+
+	key := policyCachePrefix + id.String()
+	if cached, err := r.redis.Get(ctx, key).Bytes(); err == nil {
+		if string(cached) == defaultPolicyMarker {
+			return r.defaults(), nil
+		}
+		var policy models.RateLimitPolicy
+		if err := json.Unmarshal(cached, &policy); err == nil {
+			return policy, nil
+		}
+	}
+
+	policy, err := r.repo.GetCustomerPolicy(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrRateLimitPolicyNotFound) {
+			r.redis.Set(ctx, key, defaultPolicyMarker, r.cacheTTL)
+			return r.defaults(), nil
+		}
+		return r.defaults(), fmt.Errorf("failed to resolve rate limit policy: %w", err)
+	}
+
+	if data, err := json.Marshal(policy); err == nil {
+		r.redis.Set(ctx, key, data, r.cacheTTL)
+	}
+
+	return *policy, nil
+}
+
+// Invalidate drops the cached policy of a customer after a tier change
+func (r *PolicyResolver) Invalidate(ctx context.Context, customerID uuid.UUID) error {
+	return r.redis.Del(ctx, policyCachePrefix+customerID.String()).Err()
+}
@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// Adjustment repository errors
+var (
+	ErrAdjustmentNotFound = errors.New("adjustment not found")
+	// ErrAdjustmentConflict is returned when an adjustment is reviewed or
+	// posted out of order
+	ErrAdjustmentConflict = errors.New("adjustment is not in the required state")
+)
+
+// signedAmountSQL is the amount a transaction aliased t moves its wallet's
+// balance by, as models.Transaction.SignedAmount computes it
+const signedAmountSQL = `CASE WHEN t.type = 'DEBIT' OR (t.type = 'ADJUSTMENT' AND t.metadata->>'adjustment_direction' = 'DECREASE')
+                     THEN -t.amount ELSE t.amount END`
+
+// adjustmentColumns are the columns scanned by scanAdjustment
+const adjustmentColumns = `id, wallet_id, status, direction, amount, currency, reason_code, note,
+            requested_by, COALESCE(reviewed_by, ''), COALESCE(review_note, ''), reviewed_at,
+            transaction_id, posted_at, expires_at, created_at`
+
+// AdjustmentRepository defines the interface for adjustment persistence
+type AdjustmentRepository interface {
+	CreateAdjustment(ctx context.Context, adjustment *models.Adjustment) error
+	GetAdjustment(ctx context.Context, id uuid.UUID) (*models.Adjustment, error)
+	ListAdjustments(ctx context.Context, filter models.AdjustmentFilter) ([]*models.Adjustment, error)
+	// ReviewAdjustment approves or rejects a pending adjustment that has not
+	// expired. Approvals assign the transaction the adjustment is posted as;
+	// an empty reviewer approves an adjustment needing no approval.
+	ReviewAdjustment(ctx context.Context, id uuid.UUID, status models.AdjustmentStatus, reviewer, note string, transactionID *uuid.UUID, now time.Time) (*models.Adjustment, error)
+	// ReleaseAdjustment returns an approved adjustment whose transaction
+	// could not be posted to review
+	ReleaseAdjustment(ctx context.Context, id uuid.UUID) error
+	MarkPosted(ctx context.Context, id uuid.UUID, now time.Time) (*models.Adjustment, error)
+	// ExpireAdjustments expires the pending adjustments due to expire by
+	// now, returning them
+	ExpireAdjustments(ctx context.Context, now time.Time) ([]*models.Adjustment, error)
+	// SummarizeAdjustments totals the adjustments posted in [from, to) by
+	// reason code, direction and currency
+	SummarizeAdjustments(ctx context.Context, from, to time.Time) ([]*models.AdjustmentTotal, error)
+	Close() error
+}
+
+// adjustmentRepository implements AdjustmentRepository interface
+type adjustmentRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewAdjustmentRepository creates a new instance of AdjustmentRepository
+func NewAdjustmentRepository(db *sql.DB) (AdjustmentRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &adjustmentRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *adjustmentRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	createAdjustmentQuery = namedQuery{name: "createAdjustment", sql: `
+            INSERT INTO wallet_adjustments (
+                id, wallet_id, status, direction, amount, currency, reason_code, note,
+                requested_by, expires_at, created_at
+            ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`}
+
+	getAdjustmentQuery = namedQuery{name: "getAdjustment", sql: `
+            SELECT ` + adjustmentColumns + `
+            FROM wallet_adjustments
+            WHERE id = $1`}
+
+	listAdjustmentsQuery = namedQuery{name: "listAdjustments", sql: `
+            SELECT ` + adjustmentColumns + `
+            FROM wallet_adjustments
+            WHERE ($1::uuid IS NULL OR wallet_id = $1) AND ($2 = '' OR status = $2)
+            ORDER BY created_at DESC
+            LIMIT $3`}
+
+	reviewAdjustmentQuery = namedQuery{name: "reviewAdjustment", sql: `
+            UPDATE wallet_adjustments
+            SET status = $2, reviewed_by = NULLIF($3, ''), review_note = NULLIF($4, ''), transaction_id = $5, reviewed_at = $6
+            WHERE id = $1 AND status = 'PENDING' AND (expires_at IS NULL OR expires_at > $6)
+            RETURNING ` + adjustmentColumns}
+
+	releaseAdjustmentQuery = namedQuery{name: "releaseAdjustment", sql: `
+            UPDATE wallet_adjustments
+            SET status = 'PENDING', reviewed_by = NULL, review_note = NULL, transaction_id = NULL, reviewed_at = NULL
+            WHERE id = $1 AND status = 'APPROVED'`}
+
+	markPostedQuery = namedQuery{name: "markPosted", sql: `
+            UPDATE wallet_adjustments
+            SET status = 'POSTED', posted_at = $2
+            WHERE id = $1 AND status = 'APPROVED'
+            RETURNING ` + adjustmentColumns}
+
+	// Served by idx_wallet_adjustments_expiring
+	expireAdjustmentsQuery = namedQuery{name: "expireAdjustments", sql: `
+            UPDATE wallet_adjustments
+            SET status = 'EXPIRED'
+            WHERE status = 'PENDING' AND expires_at <= $1
+            RETURNING ` + adjustmentColumns}
+
+	// Served by idx_wallet_adjustments_posted
+	summarizeAdjustmentsQuery = namedQuery{name: "summarizeAdjustments", sql: `
+            SELECT reason_code, direction, currency, COUNT(*), SUM(amount)
+            FROM wallet_adjustments
+            WHERE status = 'POSTED' AND posted_at >= $1 AND posted_at < $2
+            GROUP BY reason_code, direction, currency
+            ORDER BY reason_code, direction, currency`}
+)
+
+// CreateAdjustment stores a requested adjustment
+func (r *adjustmentRepository) CreateAdjustment(ctx context.Context, adjustment *models.Adjustment) error {
+	_, err := r.statements.ExecContext(ctx, createAdjustmentQuery,
+		adjustment.ID,
+		adjustment.WalletID,
+		adjustment.Status,
+		adjustment.Direction,
+		adjustment.Amount,
+		adjustment.Currency,
+		adjustment.ReasonCode,
+		adjustment.Note,
+		adjustment.RequestedBy,
+		adjustment.ExpiresAt,
+		adjustment.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create adjustment: %w", err)
+	}
+	return nil
+}
+
+// GetAdjustment retrieves an adjustment by ID
+func (r *adjustmentRepository) GetAdjustment(ctx context.Context, id uuid.UUID) (*models.Adjustment, error) {
+	adjustment, err := scanAdjustment(r.statements.QueryRowContext(ctx, getAdjustmentQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAdjustmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get adjustment: %w", err)
+	}
+	return adjustment, nil
+}
+
+// ListAdjustments retrieves the latest adjustments matching the filter,
+// newest first
+func (r *adjustmentRepository) ListAdjustments(ctx context.Context, filter models.AdjustmentFilter) ([]*models.Adjustment, error) {
+	rows, err := r.statements.QueryContext(ctx, listAdjustmentsQuery, filter.WalletID, string(filter.Status), filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list adjustments: %w", err)
+	}
+	defer rows.Close()
+
+	var adjustments []*models.Adjustment
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan adjustment: %w", err)
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating adjustments: %w", err)
+	}
+
+	return adjustments, nil
+}
+
+// ReviewAdjustment approves or rejects a pending adjustment
+func (r *adjustmentRepository) ReviewAdjustment(ctx context.Context, id uuid.UUID, status models.AdjustmentStatus, reviewer, note string, transactionID *uuid.UUID, now time.Time) (*models.Adjustment, error) {
+	adjustment, err := scanAdjustment(r.statements.QueryRowContext(ctx, reviewAdjustmentQuery, id, status, reviewer, note, transactionID, now))
+	if err == nil {
+		return adjustment, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to review adjustment: %w", err)
+	}
+
+	if _, err := r.GetAdjustment(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, ErrAdjustmentConflict
+}
+
+// ReleaseAdjustment returns an approved adjustment to review
+func (r *adjustmentRepository) ReleaseAdjustment(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.statements.ExecContext(ctx, releaseAdjustmentQuery, id); err != nil {
+		return fmt.Errorf("failed to release adjustment: %w", err)
+	}
+	return nil
+}
+
+// MarkPosted records that an approved adjustment was posted
+func (r *adjustmentRepository) MarkPosted(ctx context.Context, id uuid.UUID, now time.Time) (*models.Adjustment, error) {
+	adjustment, err := scanAdjustment(r.statements.QueryRowContext(ctx, markPostedQuery, id, now))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAdjustmentConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark adjustment posted: %w", err)
+	}
+	return adjustment, nil
+}
+
+// ExpireAdjustments expires the pending adjustments due to expire by now
+func (r *adjustmentRepository) ExpireAdjustments(ctx context.Context, now time.Time) ([]*models.Adjustment, error) {
+	rows, err := r.statements.QueryContext(ctx, expireAdjustmentsQuery, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire adjustments: %w", err)
+	}
+	defer rows.Close()
+
+	var adjustments []*models.Adjustment
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan adjustment: %w", err)
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating adjustments: %w", err)
+	}
+
+	return adjustments, nil
+}
+
+// SummarizeAdjustments totals the adjustments posted in a window
+func (r *adjustmentRepository) SummarizeAdjustments(ctx context.Context, from, to time.Time) ([]*models.AdjustmentTotal, error) {
+	rows, err := r.statements.QueryContext(ctx, summarizeAdjustmentsQuery, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize adjustments: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []*models.AdjustmentTotal
+	for rows.Next() {
+		total := &models.AdjustmentTotal{}
+		if err := rows.Scan(&total.ReasonCode, &total.Direction, &total.Currency, &total.Count, &total.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan adjustment total: %w", err)
+		}
+		totals = append(totals, total)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating adjustment totals: %w", err)
+	}
+
+	return totals, nil
+}
+
+// scanAdjustment scans an adjustment row selected with adjustmentColumns
+func scanAdjustment(row rowScanner) (*models.Adjustment, error) {
+	var adjustment models.Adjustment
+	err := row.Scan(
+		&adjustment.ID,
+		&adjustment.WalletID,
+		&adjustment.Status,
+		&adjustment.Direction,
+		&adjustment.Amount,
+		&adjustment.Currency,
+		&adjustment.ReasonCode,
+		&adjustment.Note,
+		&adjustment.RequestedBy,
+		&adjustment.ReviewedBy,
+		&adjustment.ReviewNote,
+		&adjustment.ReviewedAt,
+		&adjustment.TransactionID,
+		&adjustment.PostedAt,
+		&adjustment.ExpiresAt,
+		&adjustment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// AllowanceRepository defines the interface for counting the free units
+// wallets used of their products' allowances per billing period
+type AllowanceRepository interface {
+	// ConsumeAllowance uses up to units of what is left of a wallet's
+	// allowance of a product in a period, returning how many it used
+	ConsumeAllowance(ctx context.Context, walletID uuid.UUID, product, period string, allowance, units int64) (int64, error)
+	// ReleaseAllowance gives back units consumed for a debit that did not
+	// post
+	ReleaseAllowance(ctx context.Context, walletID uuid.UUID, product, period string, units int64) error
+	// ListAllowanceUsage returns the units a wallet used in a period by
+	// product
+	ListAllowanceUsage(ctx context.Context, walletID uuid.UUID, period string) (map[string]int64, error)
+	Close() error
+}
+
+// allowanceRepository implements AllowanceRepository interface
+type allowanceRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewAllowanceRepository creates a new instance of AllowanceRepository
+func NewAllowanceRepository(db *sql.DB) (AllowanceRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &allowanceRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *allowanceRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	allowanceEnsureQuery = namedQuery{name: "allowanceEnsure", sql: `
+            INSERT INTO allowance_usage (wallet_id, product, period, used, updated_at)
+            VALUES ($1, $2, $3, 0, $4)
+            ON CONFLICT (wallet_id, period, product) DO NOTHING`}
+
+	allowanceLockQuery = namedQuery{name: "allowanceLock", sql: `
+            SELECT used
+            FROM allowance_usage
+            WHERE wallet_id = $1 AND product = $2 AND period = $3
+            FOR UPDATE`}
+
+	allowanceConsumeQuery = namedQuery{name: "allowanceConsume", sql: `
+            UPDATE allowance_usage
+            SET used = used + $4,
+                updated_at = $5
+            WHERE wallet_id = $1 AND product = $2 AND period = $3`}
+
+	allowanceReleaseQuery = namedQuery{name: "allowanceRelease", sql: `
+            UPDATE allowance_usage
+            SET used = GREATEST(used - $4, 0),
+                updated_at = $5
+            WHERE wallet_id = $1 AND product = $2 AND period = $3`}
+
+	allowanceListQuery = namedQuery{name: "allowanceList", sql: `
+            SELECT product, used
+            FROM allowance_usage
+            WHERE wallet_id = $1 AND period = $2`}
+)
+
+// ConsumeAllowance locks the wallet's usage of the product in the period so
+// concurrent debits cannot use the same free units
+func (r *allowanceRepository) ConsumeAllowance(ctx context.Context, walletID uuid.UUID, product, period string, allowance, units int64) (int64, error) {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	now := time.Now().UTC()
+	if _, err := statements.ExecContext(ctx, allowanceEnsureQuery, walletID, product, period, now); err != nil {
+		return 0, fmt.Errorf("failed to create allowance usage: %w", err)
+	}
+	var used int64
+	if err := statements.QueryRowContext(ctx, allowanceLockQuery, walletID, product, period).Scan(&used); err != nil {
+		return 0, fmt.Errorf("failed to lock allowance usage: %w", err)
+	}
+
+	consumed := allowance - used
+	if consumed > units {
+		consumed = units
+	}
+	if consumed <= 0 {
+		return 0, nil
+	}
+	if _, err := statements.ExecContext(ctx, allowanceConsumeQuery, walletID, product, period, consumed, now); err != nil {
+		return 0, fmt.Errorf("failed to consume allowance: %w", err)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit allowance usage: %w", err)
+	}
+	return consumed, nil
+}
+
+// ReleaseAllowance gives back consumed units
+func (r *allowanceRepository) ReleaseAllowance(ctx context.Context, walletID uuid.UUID, product, period string, units int64) error {
+	if _, err := r.statements.ExecContext(ctx, allowanceReleaseQuery, walletID, product, period, units, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to release allowance: %w", err)
+	}
+	return nil
+}
+
+// ListAllowanceUsage returns the units a wallet used in a period by product
+func (r *allowanceRepository) ListAllowanceUsage(ctx context.Context, walletID uuid.UUID, period string) (map[string]int64, error) {
+	rows, err := r.statements.QueryContext(ctx, allowanceListQuery, walletID, period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list allowance usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make(map[string]int64)
+	for rows.Next() {
+		var product string
+		var used int64
+		if err := rows.Scan(&product, &used); err != nil {
+			return nil, fmt.Errorf("failed to scan allowance usage: %w", err)
+		}
+		usage[product] = used
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate allowance usage: %w", err)
+	}
+
+	return usage, nil
+}
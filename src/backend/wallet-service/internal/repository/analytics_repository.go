@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"internal/models"
+)
+
+// AnalyticsRepository defines the interface for aggregate analytics queries
+type AnalyticsRepository interface {
+	GetAdoptionAggregates(ctx context.Context, since time.Time) ([]*models.AdoptionAggregate, error)
+	Close() error
+}
+
+// analyticsRepository implements AnalyticsRepository interface
+type analyticsRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewAnalyticsRepository creates a new instance of AnalyticsRepository
+func NewAnalyticsRepository(db *sql.DB) (AnalyticsRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &analyticsRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *analyticsRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	adoptionAggregatesQuery = namedQuery{name: "adoptionAggregates", sql: `
+            SELECT currency,
+                   COUNT(DISTINCT wallet_id),
+                   COUNT(*) FILTER (WHERE type = 'CREDIT'),
+                   COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY amount)
+                            FILTER (WHERE type = 'CREDIT'), 0)
+            FROM wallet_transactions
+            WHERE COALESCE(effective_at, created_at) >= $1 AND status = 'COMPLETED'
+            GROUP BY currency
+            ORDER BY currency`}
+)
+
+// GetAdoptionAggregates retrieves per-currency adoption figures since the given time
+func (r *analyticsRepository) GetAdoptionAggregates(ctx context.Context, since time.Time) ([]*models.AdoptionAggregate, error) {
+	rows, err := r.statements.QueryContext(ctx, adoptionAggregatesQuery, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adoption aggregates: %w", err)
+	}
+	defer rows.Close()
+
+	var aggregates []*models.AdoptionAggregate
+	for rows.Next() {
+		agg := &models.AdoptionAggregate{}
+		if err := rows.Scan(&agg.Currency, &agg.ActiveWallets, &agg.TopUpCount, &agg.MedianTopUp); err != nil {
+			return nil, fmt.Errorf("failed to scan adoption aggregate: %w", err)
+		}
+		aggregates = append(aggregates, agg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating adoption aggregates: %w", err)
+	}
+
+	return aggregates, nil
+}
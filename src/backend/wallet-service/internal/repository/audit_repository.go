@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// AuditRepository defines the interface for operator audit log persistence
+type AuditRepository interface {
+	RecordOperatorAction(ctx context.Context, action *models.OperatorAction) error
+	ListOperatorActions(ctx context.Context, limit, offset int) ([]*models.OperatorAction, error)
+	Close() error
+}
+
+// auditRepository implements AuditRepository interface
+type auditRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewAuditRepository creates a new instance of AuditRepository
+func NewAuditRepository(db *sql.DB) (AuditRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &auditRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *auditRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	insertOperatorActionQuery = namedQuery{name: "insertOperatorAction", sql: `
+            INSERT INTO operator_audit_log (id, action, actor, reason, params, status, error, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`}
+
+	listOperatorActionsQuery = namedQuery{name: "listOperatorActions", sql: `
+            SELECT id, action, actor, reason, params, status, error, created_at
+            FROM operator_audit_log
+            ORDER BY created_at DESC
+            LIMIT $1 OFFSET $2`}
+)
+
+// RecordOperatorAction appends an operator action to the audit log
+func (r *auditRepository) RecordOperatorAction(ctx context.Context, action *models.OperatorAction) error {
+	action.ID = uuid.New()
+	action.CreatedAt = time.Now().UTC()
+
+	params, err := json.Marshal(action.Params)
+	if err != nil {
+		return fmt.Errorf("failed to encode action params: %w", err)
+	}
+
+	_, err = r.statements.ExecContext(ctx, insertOperatorActionQuery,
+		action.ID,
+		action.Action,
+		action.Actor,
+		action.Reason,
+		params,
+		action.Status,
+		action.Error,
+		action.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record operator action: %w", err)
+	}
+
+	return nil
+}
+
+// ListOperatorActions retrieves paginated operator audit log entries
+func (r *auditRepository) ListOperatorActions(ctx context.Context, limit, offset int) ([]*models.OperatorAction, error) {
+	rows, err := r.statements.QueryContext(ctx, listOperatorActionsQuery, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list operator actions: %w", err)
+	}
+	defer rows.Close()
+
+	var actions []*models.OperatorAction
+	for rows.Next() {
+		action := &models.OperatorAction{}
+		var params []byte
+		err := rows.Scan(
+			&action.ID,
+			&action.Action,
+			&action.Actor,
+			&action.Reason,
+			&params,
+			&action.Status,
+			&action.Error,
+			&action.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan operator action: %w", err)
+		}
+		if err := json.Unmarshal(params, &action.Params); err != nil {
+			return nil, fmt.Errorf("failed to decode action params: %w", err)
+		}
+		actions = append(actions, action)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating operator actions: %w", err)
+	}
+
+	return actions, nil
+}
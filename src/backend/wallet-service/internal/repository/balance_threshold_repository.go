@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+)
+
+// ErrBalanceThresholdsNotFound is returned for wallets without balance
+// thresholds
+var ErrBalanceThresholdsNotFound = errors.New("balance thresholds not found")
+
+// balanceThresholdColumns is the column list scanned by scanBalanceThresholds
+const balanceThresholdColumns = `wallet_id, reference_balance, warning_trigger_percent, warning_reset_percent,
+                                 warning_triggered, critical_trigger_percent, critical_reset_percent,
+                                 critical_triggered, updated_at`
+
+// balanceAlertColumns is the column list scanned by scanBalanceAlert
+const balanceAlertColumns = `id, wallet_id, basis, value, channels, trigger_amount, triggered, triggered_at,
+                             created_at, updated_at`
+
+// BalanceThresholdRepository defines the interface for the balance alert
+// levels of wallets
+type BalanceThresholdRepository interface {
+	// GetBalanceThresholds retrieves the thresholds of a wallet
+	GetBalanceThresholds(ctx context.Context, walletID uuid.UUID) (*models.BalanceThresholds, error)
+	// UpsertBalanceThresholds stores the thresholds of a wallet, re-arming
+	// both levels
+	UpsertBalanceThresholds(ctx context.Context, thresholds *models.BalanceThresholds) error
+	// DeleteBalanceThresholds removes the thresholds of a wallet
+	DeleteBalanceThresholds(ctx context.Context, walletID uuid.UUID) error
+	// SetBalanceAlertTriggered moves a level to triggered or back, reporting
+	// false when it was already in that state
+	SetBalanceAlertTriggered(ctx context.Context, walletID uuid.UUID, level models.BalanceAlertLevel, triggered bool) (bool, error)
+	// ListBalanceAlerts lists the alert thresholds of a wallet, highest
+	// trigger amount first
+	ListBalanceAlerts(ctx context.Context, walletID uuid.UUID) ([]*models.BalanceAlertThreshold, error)
+	// ReplaceBalanceAlerts replaces the alert thresholds of a wallet
+	ReplaceBalanceAlerts(ctx context.Context, walletID uuid.UUID, alerts []*models.BalanceAlertThreshold) error
+	// SetBalanceAlertState moves an alert threshold to triggered or back
+	// with its trigger amount, reporting false when it was already in that
+	// state
+	SetBalanceAlertState(ctx context.Context, id uuid.UUID, triggered bool, triggerAmount decimal.Decimal, at time.Time) (bool, error)
+	Close() error
+}
+
+// balanceThresholdRepository implements BalanceThresholdRepository interface
+type balanceThresholdRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewBalanceThresholdRepository creates a new instance of
+// BalanceThresholdRepository
+func NewBalanceThresholdRepository(db *sql.DB) (BalanceThresholdRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &balanceThresholdRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *balanceThresholdRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	balanceThresholdGetQuery = namedQuery{name: "balanceThresholdGet", sql: `
+            SELECT ` + balanceThresholdColumns + `
+            FROM wallet_balance_thresholds
+            WHERE wallet_id = $1`}
+
+	balanceThresholdUpsertQuery = namedQuery{name: "balanceThresholdUpsert", sql: `
+            INSERT INTO wallet_balance_thresholds (wallet_id, reference_balance, warning_trigger_percent,
+                                                   warning_reset_percent, critical_trigger_percent,
+                                                   critical_reset_percent, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)
+            ON CONFLICT (wallet_id) DO UPDATE
+            SET reference_balance = EXCLUDED.reference_balance,
+                warning_trigger_percent = EXCLUDED.warning_trigger_percent,
+                warning_reset_percent = EXCLUDED.warning_reset_percent,
+                critical_trigger_percent = EXCLUDED.critical_trigger_percent,
+                critical_reset_percent = EXCLUDED.critical_reset_percent,
+                warning_triggered = FALSE,
+                critical_triggered = FALSE,
+                updated_at = EXCLUDED.updated_at`}
+
+	balanceThresholdDeleteQuery = namedQuery{name: "balanceThresholdDelete", sql: `
+            DELETE FROM wallet_balance_thresholds
+            WHERE wallet_id = $1`}
+
+	setWarningQuery = namedQuery{name: "setWarning", sql: `
+            UPDATE wallet_balance_thresholds
+            SET warning_triggered = $2, updated_at = CURRENT_TIMESTAMP
+            WHERE wallet_id = $1 AND warning_triggered <> $2`}
+
+	setCriticalQuery = namedQuery{name: "setCritical", sql: `
+            UPDATE wallet_balance_thresholds
+            SET critical_triggered = $2, updated_at = CURRENT_TIMESTAMP
+            WHERE wallet_id = $1 AND critical_triggered <> $2`}
+
+	listAlertsQuery = namedQuery{name: "listAlerts", sql: `
+            SELECT ` + balanceAlertColumns + `
+            FROM wallet_balance_alerts
+            WHERE wallet_id = $1
+            ORDER BY trigger_amount DESC, created_at, id`}
+
+	deleteAlertsQuery = namedQuery{name: "deleteAlerts", sql: `
+            DELETE FROM wallet_balance_alerts
+            WHERE wallet_id = $1`}
+
+	insertAlertQuery = namedQuery{name: "insertAlert", sql: `
+            INSERT INTO wallet_balance_alerts (id, wallet_id, basis, value, channels, trigger_amount,
+                                               created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $7)`}
+
+	setAlertStateQuery = namedQuery{name: "setAlertState", sql: `
+            UPDATE wallet_balance_alerts
+            SET triggered = $2,
+                trigger_amount = $3,
+                triggered_at = CASE WHEN $2 THEN $4 ELSE triggered_at END,
+                updated_at = $4
+            WHERE id = $1 AND triggered <> $2`}
+)
+
+// GetBalanceThresholds retrieves the balance thresholds of a wallet
+func (r *balanceThresholdRepository) GetBalanceThresholds(ctx context.Context, walletID uuid.UUID) (*models.BalanceThresholds, error) {
+	thresholds, err := scanBalanceThresholds(r.statements.QueryRowContext(ctx, balanceThresholdGetQuery, walletID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBalanceThresholdsNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance thresholds: %w", err)
+	}
+	return thresholds, nil
+}
+
+// UpsertBalanceThresholds stores the balance thresholds of a wallet. Both
+// levels start armed, so the next balance change re-evaluates them against
+// the new amounts.
+func (r *balanceThresholdRepository) UpsertBalanceThresholds(ctx context.Context, thresholds *models.BalanceThresholds) error {
+	thresholds.Warning.Triggered = false
+	thresholds.Critical.Triggered = false
+
+	_, err := r.statements.ExecContext(ctx, balanceThresholdUpsertQuery,
+		thresholds.WalletID,
+		thresholds.ReferenceBalance,
+		thresholds.Warning.TriggerPercent,
+		thresholds.Warning.ResetPercent,
+		thresholds.Critical.TriggerPercent,
+		thresholds.Critical.ResetPercent,
+		thresholds.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store balance thresholds: %w", err)
+	}
+	return nil
+}
+
+// DeleteBalanceThresholds removes the balance thresholds of a wallet
+func (r *balanceThresholdRepository) DeleteBalanceThresholds(ctx context.Context, walletID uuid.UUID) error {
+	result, err := r.statements.ExecContext(ctx, balanceThresholdDeleteQuery, walletID)
+	if err != nil {
+		return fmt.Errorf("failed to delete balance thresholds: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get deleted count: %w", err)
+	}
+	if deleted == 0 {
+		return ErrBalanceThresholdsNotFound
+	}
+
+	return nil
+}
+
+// SetBalanceAlertTriggered flips the triggered state of a level only when it
+// differs, so of concurrent balance changes crossing a threshold exactly one
+// sees the flip and alerts
+func (r *balanceThresholdRepository) SetBalanceAlertTriggered(ctx context.Context, walletID uuid.UUID, level models.BalanceAlertLevel, triggered bool) (bool, error) {
+	q := setWarningQuery
+	if level == models.BalanceAlertCritical {
+		q = setCriticalQuery
+	}
+
+	result, err := r.statements.ExecContext(ctx, q, walletID, triggered)
+	if err != nil {
+		return false, fmt.Errorf("failed to set %s alert state: %w", level, err)
+	}
+
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get changed count: %w", err)
+	}
+
+	return changed > 0, nil
+}
+
+// scanBalanceThresholds scans a row of balanceThresholdColumns
+func scanBalanceThresholds(row rowScanner) (*models.BalanceThresholds, error) {
+	t := &models.BalanceThresholds{}
+	err := row.Scan(
+		&t.WalletID,
+		&t.ReferenceBalance,
+		&t.Warning.TriggerPercent,
+		&t.Warning.ResetPercent,
+		&t.Warning.Triggered,
+		&t.Critical.TriggerPercent,
+		&t.Critical.ResetPercent,
+		&t.Critical.Triggered,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ListBalanceAlerts lists the alert thresholds of a wallet, highest trigger
+// amount first
+func (r *balanceThresholdRepository) ListBalanceAlerts(ctx context.Context, walletID uuid.UUID) ([]*models.BalanceAlertThreshold, error) {
+	rows, err := r.statements.QueryContext(ctx, listAlertsQuery, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list balance alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []*models.BalanceAlertThreshold
+	for rows.Next() {
+		alert, err := scanBalanceAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan balance alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating balance alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// ReplaceBalanceAlerts replaces the alert thresholds of a wallet in one
+// transaction. The new thresholds start armed.
+func (r *balanceThresholdRepository) ReplaceBalanceAlerts(ctx context.Context, walletID uuid.UUID, alerts []*models.BalanceAlertThreshold) error {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	if _, err := statements.ExecContext(ctx, deleteAlertsQuery, walletID); err != nil {
+		return fmt.Errorf("failed to delete balance alerts: %w", err)
+	}
+
+	for _, alert := range alerts {
+		channels := make([]string, len(alert.Channels))
+		for i, channel := range alert.Channels {
+			channels[i] = string(channel)
+		}
+		alert.Triggered = false
+		alert.TriggeredAt = nil
+		_, err := statements.ExecContext(ctx, insertAlertQuery,
+			alert.ID,
+			walletID,
+			alert.Basis,
+			alert.Value,
+			channels,
+			alert.TriggerAmount,
+			alert.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store balance alert: %w", err)
+		}
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetBalanceAlertState flips the triggered state of an alert threshold only
+// when it differs, so of concurrent balance changes crossing it exactly one
+// sees the flip and alerts
+func (r *balanceThresholdRepository) SetBalanceAlertState(ctx context.Context, id uuid.UUID, triggered bool, triggerAmount decimal.Decimal, at time.Time) (bool, error) {
+	result, err := r.statements.ExecContext(ctx, setAlertStateQuery, id, triggered, triggerAmount, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to set balance alert state: %w", err)
+	}
+
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get changed count: %w", err)
+	}
+
+	return changed > 0, nil
+}
+
+// scanBalanceAlert scans a row of balanceAlertColumns
+func scanBalanceAlert(row rowScanner) (*models.BalanceAlertThreshold, error) {
+	alert := &models.BalanceAlertThreshold{}
+	var channels []string
+	var triggeredAt sql.NullTime
+	err := row.Scan(
+		&alert.ID,
+		&alert.WalletID,
+		&alert.Basis,
+		&alert.Value,
+		pgArray(&channels),
+		&alert.TriggerAmount,
+		&alert.Triggered,
+		&triggeredAt,
+		&alert.CreatedAt,
+		&alert.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, channel := range channels {
+		alert.Channels = append(alert.Channels, models.BalanceAlertChannel(channel))
+	}
+	if triggeredAt.Valid {
+		alert.TriggeredAt = &triggeredAt.Time
+	}
+	return alert, nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"internal/models"
+)
+
+// ErrBillingPeriodConflict is returned when a billing period is closed while
+// already closed, or reopened while open
+var ErrBillingPeriodConflict = errors.New("billing period is not in the expected state")
+
+// billingPeriodColumns is the column list scanned by scanBillingPeriod
+const billingPeriodColumns = `period, status, COALESCE(closed_by, ''), closed_at, COALESCE(reopened_by, ''),
+            reopened_at, COALESCE(reason, ''), updated_at`
+
+// BillingPeriodRepository defines the interface for billing period close state
+type BillingPeriodRepository interface {
+	GetBillingPeriod(ctx context.Context, period time.Time) (*models.BillingPeriod, error)
+	ListBillingPeriods(ctx context.Context, limit int) ([]*models.BillingPeriod, error)
+	CloseBillingPeriod(ctx context.Context, period time.Time, actor, reason string, now time.Time) (*models.BillingPeriod, error)
+	ReopenBillingPeriod(ctx context.Context, period time.Time, actor, reason string, now time.Time) (*models.BillingPeriod, error)
+	Close() error
+}
+
+// billingPeriodRepository implements BillingPeriodRepository interface
+type billingPeriodRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewBillingPeriodRepository creates a new instance of BillingPeriodRepository
+func NewBillingPeriodRepository(db *sql.DB) (BillingPeriodRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &billingPeriodRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *billingPeriodRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	getBillingPeriodQuery = namedQuery{name: "getBillingPeriod", sql: `
+            SELECT ` + billingPeriodColumns + `
+            FROM billing_periods
+            WHERE period = $1`}
+
+	listBillingPeriodsQuery = namedQuery{name: "listBillingPeriods", sql: `
+            SELECT ` + billingPeriodColumns + `
+            FROM billing_periods
+            ORDER BY period DESC
+            LIMIT $1`}
+
+	closeBillingPeriodQuery = namedQuery{name: "closeBillingPeriod", sql: `
+            INSERT INTO billing_periods (period, status, closed_by, closed_at, reason, updated_at)
+            VALUES ($1, 'CLOSED', $2, $3, NULLIF($4, ''), $3)
+            ON CONFLICT (period) DO UPDATE
+            SET status = 'CLOSED', closed_by = EXCLUDED.closed_by, closed_at = EXCLUDED.closed_at,
+                reason = EXCLUDED.reason, updated_at = EXCLUDED.updated_at
+            WHERE billing_periods.status = 'OPEN'
+            RETURNING ` + billingPeriodColumns}
+
+	reopenBillingPeriodQuery = namedQuery{name: "reopenBillingPeriod", sql: `
+            UPDATE billing_periods
+            SET status = 'OPEN', reopened_by = $2, reopened_at = $3, reason = NULLIF($4, ''), updated_at = $3
+            WHERE period = $1 AND status = 'CLOSED'
+            RETURNING ` + billingPeriodColumns}
+)
+
+// GetBillingPeriod returns the close state of a period, open when never closed
+func (r *billingPeriodRepository) GetBillingPeriod(ctx context.Context, period time.Time) (*models.BillingPeriod, error) {
+	state, err := scanBillingPeriod(r.statements.QueryRowContext(ctx, getBillingPeriodQuery, period))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &models.BillingPeriod{
+			Period: period.Format(models.BillingPeriodLayout),
+			Status: models.BillingPeriodOpen,
+		}, nil
+	}
+	return state, err
+}
+
+// ListBillingPeriods returns the latest periods ever closed, newest first
+func (r *billingPeriodRepository) ListBillingPeriods(ctx context.Context, limit int) ([]*models.BillingPeriod, error) {
+	rows, err := r.statements.QueryContext(ctx, listBillingPeriodsQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list billing periods: %w", err)
+	}
+	defer rows.Close()
+
+	var periods []*models.BillingPeriod
+	for rows.Next() {
+		state, err := scanBillingPeriod(rows)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, state)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating billing periods: %w", err)
+	}
+
+	return periods, nil
+}
+
+// CloseBillingPeriod closes an open period
+func (r *billingPeriodRepository) CloseBillingPeriod(ctx context.Context, period time.Time, actor, reason string, now time.Time) (*models.BillingPeriod, error) {
+	state, err := scanBillingPeriod(r.statements.QueryRowContext(ctx, closeBillingPeriodQuery, period, actor, now, reason))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBillingPeriodConflict
+	}
+	return state, err
+}
+
+// ReopenBillingPeriod reopens a closed period
+func (r *billingPeriodRepository) ReopenBillingPeriod(ctx context.Context, period time.Time, actor, reason string, now time.Time) (*models.BillingPeriod, error) {
+	state, err := scanBillingPeriod(r.statements.QueryRowContext(ctx, reopenBillingPeriodQuery, period, actor, now, reason))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBillingPeriodConflict
+	}
+	return state, err
+}
+
+// scanBillingPeriod reads a billing period row selected with billingPeriodColumns
+func scanBillingPeriod(row rowScanner) (*models.BillingPeriod, error) {
+	state := &models.BillingPeriod{}
+	var period time.Time
+	err := row.Scan(
+		&period,
+		&state.Status,
+		&state.ClosedBy,
+		&state.ClosedAt,
+		&state.ReopenedBy,
+		&state.ReopenedAt,
+		&state.Reason,
+		&state.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan billing period: %w", err)
+	}
+
+	state.Period = period.Format(models.BillingPeriodLayout)
+	return state, nil
+}
@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"  // v1.3.0
+	"github.com/jackc/pgx/v5" // v5.3.1
+
+	"internal/models"
+)
+
+// Bulk credit errors
+var (
+	ErrBulkCreditNotFound = errors.New("bulk credit not found")
+	ErrBulkCreditConflict = errors.New("bulk credit was already rolled back")
+)
+
+// BulkCreditRepository defines the interface for bulk credit operations and
+// the rows of their wallets
+type BulkCreditRepository interface {
+	// CreateBulkCredit stores an operation with its rows as pending
+	CreateBulkCredit(ctx context.Context, credit *models.BulkCredit, rows []*models.BulkCreditRow) error
+	// SetBulkCreditJob records the job posting an operation's credits
+	SetBulkCreditJob(ctx context.Context, id, jobID uuid.UUID) error
+	GetBulkCredit(ctx context.Context, id uuid.UUID) (*models.BulkCredit, error)
+	// ListBulkCreditRows lists the rows of an operation in order
+	ListBulkCreditRows(ctx context.Context, id uuid.UUID) ([]*models.BulkCreditRow, error)
+	// RecordBulkCreditRows stores the outcome of processed rows
+	RecordBulkCreditRows(ctx context.Context, id uuid.UUID, rows []*models.BulkCreditRow) error
+	// RequestBulkCreditRollback records the job rolling an operation back,
+	// once only
+	RequestBulkCreditRollback(ctx context.Context, id, jobID uuid.UUID, actor string, now time.Time) (*models.BulkCredit, error)
+	// PurgeBulkCredits deletes operations created before a time with their
+	// rows
+	PurgeBulkCredits(ctx context.Context, before time.Time) (int64, error)
+	Close() error
+}
+
+// bulkCreditRepository implements BulkCreditRepository interface
+type bulkCreditRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewBulkCreditRepository creates a new instance of BulkCreditRepository
+func NewBulkCreditRepository(db *sql.DB) (BulkCreditRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &bulkCreditRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *bulkCreditRepository) Close() error {
+	return r.statements.Close()
+}
+
+const bulkCreditColumns = `id, amount, currency, description, total_rows, job_id, rollback_job_id,
+            created_by, COALESCE(rollback_requested_by, ''), created_at, rollback_requested_at`
+
+var (
+	bulkCreditCreateQuery = namedQuery{name: "bulkCreditCreate", sql: `
+            INSERT INTO bulk_credits (id, amount, currency, description, total_rows, created_by, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)`}
+
+	bulkCreditCreateRowsQuery = namedQuery{name: "bulkCreditCreateRows", sql: `
+            INSERT INTO bulk_credit_rows (bulk_credit_id, row_index, wallet_id)
+            SELECT $1, row_index, wallet_id
+            FROM unnest($2::INTEGER[], $3::TEXT[]) AS rows(row_index, wallet_id)`}
+
+	setJobQuery = namedQuery{name: "setJob", sql: `
+            UPDATE bulk_credits SET job_id = $2 WHERE id = $1`}
+
+	bulkCreditGetQuery = namedQuery{name: "bulkCreditGet", sql: `
+            SELECT ` + bulkCreditColumns + `
+            FROM bulk_credits
+            WHERE id = $1`}
+
+	bulkCreditListRowsQuery = namedQuery{name: "bulkCreditListRows", sql: `
+            SELECT row_index, wallet_id, status, transaction_id, reversal_id, COALESCE(error, '')
+            FROM bulk_credit_rows
+            WHERE bulk_credit_id = $1
+            ORDER BY row_index`}
+
+	requestRollbackQuery = namedQuery{name: "requestRollback", sql: `
+            UPDATE bulk_credits
+            SET rollback_job_id = $2, rollback_requested_by = $3, rollback_requested_at = $4
+            WHERE id = $1 AND rollback_job_id IS NULL
+            RETURNING ` + bulkCreditColumns}
+
+	bulkCreditPurgeQuery = namedQuery{name: "bulkCreditPurge", sql: `
+            DELETE FROM bulk_credits
+            WHERE created_at < $1`}
+)
+
+// scanBulkCredit scans a bulk credit row
+func scanBulkCredit(row rowScanner) (*models.BulkCredit, error) {
+	var credit models.BulkCredit
+	var jobID, rollbackJobID uuid.NullUUID
+	var rollbackRequestedAt sql.NullTime
+	err := row.Scan(
+		&credit.ID,
+		&credit.Amount,
+		&credit.Currency,
+		&credit.Description,
+		&credit.TotalRows,
+		&jobID,
+		&rollbackJobID,
+		&credit.CreatedBy,
+		&credit.RollbackRequestedBy,
+		&credit.CreatedAt,
+		&rollbackRequestedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if jobID.Valid {
+		credit.JobID = &jobID.UUID
+	}
+	if rollbackJobID.Valid {
+		credit.RollbackJobID = &rollbackJobID.UUID
+	}
+	if rollbackRequestedAt.Valid {
+		credit.RollbackRequestedAt = &rollbackRequestedAt.Time
+	}
+	return &credit, nil
+}
+
+// nullUUID converts an optional ID to a nullable column value
+func nullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+// CreateBulkCredit stores an operation and its rows as pending in one
+// transaction
+func (r *bulkCreditRepository) CreateBulkCredit(ctx context.Context, credit *models.BulkCredit, rows []*models.BulkCreditRow) error {
+	indexes := make([]int64, len(rows))
+	wallets := make([]string, len(rows))
+	for i, row := range rows {
+		indexes[i] = int64(row.Index)
+		wallets[i] = row.WalletID
+	}
+
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	_, err = statements.ExecContext(ctx, bulkCreditCreateQuery,
+		credit.ID,
+		credit.Amount,
+		credit.Currency,
+		credit.Description,
+		credit.TotalRows,
+		credit.CreatedBy,
+		credit.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create bulk credit: %w", err)
+	}
+	_, err = statements.ExecContext(ctx, bulkCreditCreateRowsQuery,
+		credit.ID,
+		indexes,
+		wallets,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create bulk credit rows: %w", err)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetBulkCreditJob records the job posting an operation's credits
+func (r *bulkCreditRepository) SetBulkCreditJob(ctx context.Context, id, jobID uuid.UUID) error {
+	result, err := r.statements.ExecContext(ctx, setJobQuery, id, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to set bulk credit job: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get updated count: %w", err)
+	}
+	if updated == 0 {
+		return ErrBulkCreditNotFound
+	}
+	return nil
+}
+
+// GetBulkCredit retrieves a bulk credit operation by ID
+func (r *bulkCreditRepository) GetBulkCredit(ctx context.Context, id uuid.UUID) (*models.BulkCredit, error) {
+	credit, err := scanBulkCredit(r.statements.QueryRowContext(ctx, bulkCreditGetQuery, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrBulkCreditNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bulk credit: %w", err)
+	}
+	return credit, nil
+}
+
+// ListBulkCreditRows lists the rows of an operation in order
+func (r *bulkCreditRepository) ListBulkCreditRows(ctx context.Context, id uuid.UUID) ([]*models.BulkCreditRow, error) {
+	rows, err := r.statements.QueryContext(ctx, bulkCreditListRowsQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bulk credit rows: %w", err)
+	}
+	defer rows.Close()
+
+	var listed []*models.BulkCreditRow
+	for rows.Next() {
+		var row models.BulkCreditRow
+		var transactionID, reversalID uuid.NullUUID
+		if err := rows.Scan(&row.Index, &row.WalletID, &row.Status, &transactionID, &reversalID, &row.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan bulk credit row: %w", err)
+		}
+		if transactionID.Valid {
+			row.TransactionID = &transactionID.UUID
+		}
+		if reversalID.Valid {
+			row.ReversalID = &reversalID.UUID
+		}
+		listed = append(listed, &row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating bulk credit rows: %w", err)
+	}
+
+	return listed, nil
+}
+
+// recordBulkCreditRowQuery stores the outcome of a processed row
+var recordBulkCreditRowQuery = namedQuery{name: "RecordBulkCreditRows", sql: `
+            UPDATE bulk_credit_rows
+            SET status = $3, transaction_id = $4, reversal_id = $5, error = NULLIF($6, ''), processed_at = $7
+            WHERE bulk_credit_id = $1 AND row_index = $2`}
+
+// RecordBulkCreditRows stores the outcome of processed rows in one batch
+// and transaction, so a chunk is recorded in a single round trip, whole or
+// not at all
+func (r *bulkCreditRepository) RecordBulkCreditRows(ctx context.Context, id uuid.UUID, rows []*models.BulkCreditRow) error {
+	now := time.Now().UTC()
+	batch := &pgx.Batch{}
+	for _, row := range rows {
+		batch.Queue(recordBulkCreditRowQuery.text(),
+			id, row.Index, row.Status, nullUUID(row.TransactionID), nullUUID(row.ReversalID), row.Error, now)
+	}
+
+	if err := execBatch(ctx, r.db, recordBulkCreditRowQuery, batch); err != nil {
+		return fmt.Errorf("failed to record bulk credit rows: %w", err)
+	}
+	return nil
+}
+
+// RequestBulkCreditRollback records the job rolling an operation back. An
+// operation already rolled back is a conflict.
+func (r *bulkCreditRepository) RequestBulkCreditRollback(ctx context.Context, id, jobID uuid.UUID, actor string, now time.Time) (*models.BulkCredit, error) {
+	credit, err := scanBulkCredit(r.statements.QueryRowContext(ctx, requestRollbackQuery, id, jobID, actor, now))
+	if err == sql.ErrNoRows {
+		if _, err := r.GetBulkCredit(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrBulkCreditConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to request bulk credit rollback: %w", err)
+	}
+	return credit, nil
+}
+
+// PurgeBulkCredits deletes operations created before a time, their rows
+// cascading
+func (r *bulkCreditRepository) PurgeBulkCredits(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.statements.ExecContext(ctx, bulkCreditPurgeQuery, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge bulk credits: %w", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get purged count: %w", err)
+	}
+	return purged, nil
+}
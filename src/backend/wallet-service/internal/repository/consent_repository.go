@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"         // v1.3.0
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+
+	"internal/models"
+)
+
+// consentColumns are the columns scanned by scanConsent
+const consentColumns = `customer_id, channel, granted, source, recorded_at`
+
+// ConsentRepository defines the interface for customer consent persistence
+type ConsentRepository interface {
+	ListConsents(ctx context.Context, customerID uuid.UUID) ([]*models.CustomerConsent, error)
+	RecordConsent(ctx context.Context, consent *models.CustomerConsent) error
+	ListConsentHistory(ctx context.Context, customerID uuid.UUID, limit int) ([]*models.CustomerConsent, error)
+	HasConsent(ctx context.Context, customerID uuid.UUID, channel models.ConsentChannel) (bool, error)
+	Close() error
+}
+
+// consentRepository implements ConsentRepository interface
+type consentRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewConsentRepository creates a new instance of ConsentRepository
+func NewConsentRepository(db *sql.DB) (ConsentRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &consentRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *consentRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	listConsentsQuery = namedQuery{name: "listConsents", sql: `
+            SELECT ` + consentColumns + `
+            FROM customer_consents
+            WHERE customer_id = $1
+            ORDER BY channel`}
+
+	upsertConsentQuery = namedQuery{name: "upsertConsent", sql: `
+            INSERT INTO customer_consents (customer_id, channel, granted, source, recorded_at)
+            VALUES ($1, $2, $3, $4, $5)
+            ON CONFLICT (customer_id, channel) DO UPDATE
+            SET granted = EXCLUDED.granted,
+                source = EXCLUDED.source,
+                recorded_at = EXCLUDED.recorded_at`}
+
+	insertConsentEventQuery = namedQuery{name: "insertConsentEvent", sql: `
+            INSERT INTO customer_consent_events (customer_id, channel, granted, source, recorded_at)
+            VALUES ($1, $2, $3, $4, $5)`}
+
+	listConsentHistoryQuery = namedQuery{name: "listConsentHistory", sql: `
+            SELECT ` + consentColumns + `
+            FROM customer_consent_events
+            WHERE customer_id = $1
+            ORDER BY recorded_at DESC, id DESC
+            LIMIT $2`}
+
+	hasConsentQuery = namedQuery{name: "hasConsent", sql: `
+            SELECT EXISTS (
+                SELECT 1 FROM customer_consents
+                WHERE customer_id = $1 AND channel = $2 AND granted
+            )`}
+)
+
+// ListConsents retrieves the channels a customer recorded consent for
+func (r *consentRepository) ListConsents(ctx context.Context, customerID uuid.UUID) ([]*models.CustomerConsent, error) {
+	rows, err := r.statements.QueryContext(ctx, listConsentsQuery, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consents: %w", err)
+	}
+	return scanConsents(rows)
+}
+
+// RecordConsent stores a customer's consent for a channel and appends it to
+// the consent history, stamping it with the current time
+func (r *consentRepository) RecordConsent(ctx context.Context, consent *models.CustomerConsent) error {
+	now := time.Now().UTC()
+
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	for _, q := range []namedQuery{upsertConsentQuery, insertConsentEventQuery} {
+		_, err := statements.ExecContext(ctx, q,
+			consent.CustomerID,
+			consent.Channel,
+			consent.Granted,
+			consent.Source,
+			now,
+		)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrCustomerNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record consent: %w", err)
+		}
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit consent: %w", err)
+	}
+
+	consent.RecordedAt = &now
+	return nil
+}
+
+// ListConsentHistory retrieves a customer's consent changes, newest first
+func (r *consentRepository) ListConsentHistory(ctx context.Context, customerID uuid.UUID, limit int) ([]*models.CustomerConsent, error) {
+	rows, err := r.statements.QueryContext(ctx, listConsentHistoryQuery, customerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consent history: %w", err)
+	}
+	return scanConsents(rows)
+}
+
+// HasConsent reports whether a customer currently consents to a channel
+func (r *consentRepository) HasConsent(ctx context.Context, customerID uuid.UUID, channel models.ConsentChannel) (bool, error) {
+	var granted bool
+	if err := r.statements.QueryRowContext(ctx, hasConsentQuery, customerID, channel).Scan(&granted); err != nil {
+		return false, fmt.Errorf("failed to check consent: %w", err)
+	}
+	return granted, nil
+}
+
+// scanConsents scans and closes consent rows
+func scanConsents(rows *sql.Rows) ([]*models.CustomerConsent, error) {
+	defer rows.Close()
+
+	var consents []*models.CustomerConsent
+	for rows.Next() {
+		consent := &models.CustomerConsent{}
+		var recordedAt time.Time
+		if err := rows.Scan(
+			&consent.CustomerID,
+			&consent.Channel,
+			&consent.Granted,
+			&consent.Source,
+			&recordedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan consent: %w", err)
+		}
+		consent.RecordedAt = &recordedAt
+		consents = append(consents, consent)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating consents: %w", err)
+	}
+
+	return consents, nil
+}
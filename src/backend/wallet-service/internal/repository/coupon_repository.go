@@ -0,0 +1,393 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"         // v1.3.0
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+
+	"internal/models"
+)
+
+// Coupon repository errors
+var (
+	ErrCouponNotFound = errors.New("coupon not found")
+	// ErrCouponConflict is returned when a coupon's code is taken, or when
+	// a coupon is voided or redeemed once voided
+	ErrCouponConflict = errors.New("coupon is not in the required state")
+	// ErrCouponExhausted is returned when a redemption would exceed a
+	// coupon's redemption limits
+	ErrCouponExhausted = errors.New("coupon redemption limit reached")
+)
+
+// couponColumns are the columns scanned by scanCoupon
+const couponColumns = `id, code, description, discount_type, value, COALESCE(currency, ''), customer_id,
+            valid_from, valid_until, max_redemptions, max_redemptions_per_customer, redemptions, status,
+            created_by, created_at, COALESCE(voided_by, ''), COALESCE(void_reason, ''), voided_at`
+
+// couponRedemptionColumns are the columns scanned by scanCouponRedemption,
+// of coupon_redemptions aliased r joined with coupons aliased c
+const couponRedemptionColumns = `r.id, r.coupon_id, c.code, r.customer_id, r.source, r.reference, r.currency,
+            r.amount, r.discount, r.redeemed_by, r.created_at`
+
+// CouponRepository defines the interface for coupons and their redemptions
+type CouponRepository interface {
+	CreateCoupon(ctx context.Context, coupon *models.Coupon) error
+	GetCoupon(ctx context.Context, id uuid.UUID) (*models.Coupon, error)
+	GetCouponByCode(ctx context.Context, code string) (*models.Coupon, error)
+	// ListCoupons returns the latest coupons in a status, or in any status
+	// when it is empty, newest first
+	ListCoupons(ctx context.Context, status models.CouponStatus, limit int) ([]*models.Coupon, error)
+	VoidCoupon(ctx context.Context, id uuid.UUID, actor, reason string, now time.Time) (*models.Coupon, error)
+	// RedeemCoupon records a redemption of an active coupon within its
+	// redemption limits. A coupon redeemed already on the same source and
+	// reference returns that redemption, reporting that none was created.
+	RedeemCoupon(ctx context.Context, redemption *models.CouponRedemption) (*models.CouponRedemption, bool, error)
+	// ListRedemptions returns the latest redemptions of a coupon, newest
+	// first
+	ListRedemptions(ctx context.Context, couponID uuid.UUID, limit int) ([]*models.CouponRedemption, error)
+	Close() error
+}
+
+// couponRepository implements CouponRepository interface
+type couponRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewCouponRepository creates a new instance of CouponRepository
+func NewCouponRepository(db *sql.DB) (CouponRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &couponRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *couponRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	createCouponQuery = namedQuery{name: "createCoupon", sql: `
+            INSERT INTO coupons (
+                id, code, description, discount_type, value, currency, customer_id, valid_from,
+                valid_until, max_redemptions, max_redemptions_per_customer, status, created_by, created_at
+            ) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, $11, $12, $13, $14)`}
+
+	getCouponQuery = namedQuery{name: "getCoupon", sql: `
+            SELECT ` + couponColumns + `
+            FROM coupons
+            WHERE id = $1`}
+
+	getCouponByCodeQuery = namedQuery{name: "getCouponByCode", sql: `
+            SELECT ` + couponColumns + `
+            FROM coupons
+            WHERE code = $1`}
+
+	listCouponsQuery = namedQuery{name: "listCoupons", sql: `
+            SELECT ` + couponColumns + `
+            FROM coupons
+            WHERE $1 = '' OR status = $1
+            ORDER BY created_at DESC
+            LIMIT $2`}
+
+	voidCouponQuery = namedQuery{name: "voidCoupon", sql: `
+            UPDATE coupons
+            SET status = 'VOIDED', voided_by = $2, void_reason = $3, voided_at = $4
+            WHERE id = $1 AND status = 'ACTIVE'
+            RETURNING ` + couponColumns}
+
+	lockCouponQuery = namedQuery{name: "lockCoupon", sql: `
+            SELECT status, max_redemptions, max_redemptions_per_customer, redemptions
+            FROM coupons
+            WHERE id = $1
+            FOR UPDATE`}
+
+	getRedemptionQuery = namedQuery{name: "getRedemption", sql: `
+            SELECT ` + couponRedemptionColumns + `
+            FROM coupon_redemptions r
+            JOIN coupons c ON c.id = r.coupon_id
+            WHERE r.coupon_id = $1 AND r.source = $2 AND r.reference = $3`}
+
+	// Served by idx_coupon_redemptions_customer
+	countCustomerRedemptionsQuery = namedQuery{name: "countCustomerRedemptions", sql: `
+            SELECT COUNT(*)
+            FROM coupon_redemptions
+            WHERE coupon_id = $1 AND customer_id = $2`}
+
+	insertRedemptionQuery = namedQuery{name: "insertRedemption", sql: `
+            INSERT INTO coupon_redemptions (
+                id, coupon_id, customer_id, source, reference, currency, amount, discount,
+                redeemed_by, created_at
+            ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`}
+
+	countRedemptionQuery = namedQuery{name: "countRedemption", sql: `
+            UPDATE coupons
+            SET redemptions = redemptions + 1
+            WHERE id = $1`}
+
+	// Served by idx_coupon_redemptions_coupon
+	listRedemptionsQuery = namedQuery{name: "listRedemptions", sql: `
+            SELECT ` + couponRedemptionColumns + `
+            FROM coupon_redemptions r
+            JOIN coupons c ON c.id = r.coupon_id
+            WHERE r.coupon_id = $1
+            ORDER BY r.created_at DESC
+            LIMIT $2`}
+)
+
+// CreateCoupon stores a new coupon
+func (r *couponRepository) CreateCoupon(ctx context.Context, coupon *models.Coupon) error {
+	_, err := r.statements.ExecContext(ctx, createCouponQuery,
+		coupon.ID,
+		coupon.Code,
+		coupon.Description,
+		coupon.Type,
+		coupon.Value,
+		coupon.Currency,
+		coupon.CustomerID,
+		coupon.ValidFrom,
+		coupon.ValidUntil,
+		coupon.MaxRedemptions,
+		coupon.MaxRedemptionsPerCustomer,
+		coupon.Status,
+		coupon.CreatedBy,
+		coupon.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrCouponConflict
+	}
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrCustomerNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create coupon: %w", err)
+	}
+	return nil
+}
+
+// GetCoupon retrieves a coupon by ID
+func (r *couponRepository) GetCoupon(ctx context.Context, id uuid.UUID) (*models.Coupon, error) {
+	return r.getCoupon(ctx, getCouponQuery, id)
+}
+
+// GetCouponByCode retrieves a coupon by its code
+func (r *couponRepository) GetCouponByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	return r.getCoupon(ctx, getCouponByCodeQuery, code)
+}
+
+// getCoupon retrieves a coupon with the named statement
+func (r *couponRepository) getCoupon(ctx context.Context, q namedQuery, key interface{}) (*models.Coupon, error) {
+	coupon, err := scanCoupon(r.statements.QueryRowContext(ctx, q, key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+	return coupon, nil
+}
+
+// ListCoupons retrieves the latest coupons, newest first
+func (r *couponRepository) ListCoupons(ctx context.Context, status models.CouponStatus, limit int) ([]*models.Coupon, error) {
+	rows, err := r.statements.QueryContext(ctx, listCouponsQuery, string(status), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coupons: %w", err)
+	}
+	defer rows.Close()
+
+	var coupons []*models.Coupon
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan coupon: %w", err)
+		}
+		coupons = append(coupons, coupon)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating coupons: %w", err)
+	}
+
+	return coupons, nil
+}
+
+// VoidCoupon withdraws an active coupon
+func (r *couponRepository) VoidCoupon(ctx context.Context, id uuid.UUID, actor, reason string, now time.Time) (*models.Coupon, error) {
+	coupon, err := scanCoupon(r.statements.QueryRowContext(ctx, voidCouponQuery, id, actor, reason, now))
+	if err == nil {
+		return coupon, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to void coupon: %w", err)
+	}
+
+	if _, err := r.GetCoupon(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, ErrCouponConflict
+}
+
+// RedeemCoupon locks the coupon so concurrent redemptions cannot exceed its
+// limits, then records the redemption and counts it
+func (r *couponRepository) RedeemCoupon(ctx context.Context, redemption *models.CouponRedemption) (*models.CouponRedemption, bool, error) {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	var status models.CouponStatus
+	var maxRedemptions, maxPerCustomer sql.NullInt64
+	var redemptions int64
+	err = statements.QueryRowContext(ctx, lockCouponQuery, redemption.CouponID).
+		Scan(&status, &maxRedemptions, &maxPerCustomer, &redemptions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lock coupon: %w", err)
+	}
+
+	existing, err := scanCouponRedemption(statements.QueryRowContext(ctx, getRedemptionQuery,
+		redemption.CouponID,
+		redemption.Source,
+		redemption.Reference,
+	))
+	if err == nil {
+		return existing, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("failed to get coupon redemption: %w", err)
+	}
+
+	if status != models.CouponActive {
+		return nil, false, ErrCouponConflict
+	}
+	if maxRedemptions.Valid && redemptions >= maxRedemptions.Int64 {
+		return nil, false, ErrCouponExhausted
+	}
+	if maxPerCustomer.Valid {
+		var customerRedemptions int64
+		err = statements.QueryRowContext(ctx, countCustomerRedemptionsQuery,
+			redemption.CouponID,
+			redemption.CustomerID,
+		).Scan(&customerRedemptions)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to count customer redemptions: %w", err)
+		}
+		if customerRedemptions >= maxPerCustomer.Int64 {
+			return nil, false, ErrCouponExhausted
+		}
+	}
+
+	_, err = statements.ExecContext(ctx, insertRedemptionQuery,
+		redemption.ID,
+		redemption.CouponID,
+		redemption.CustomerID,
+		redemption.Source,
+		redemption.Reference,
+		redemption.Currency,
+		redemption.Amount,
+		redemption.Discount,
+		redemption.RedeemedBy,
+		redemption.CreatedAt,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to insert coupon redemption: %w", err)
+	}
+	if _, err := statements.ExecContext(ctx, countRedemptionQuery, redemption.CouponID); err != nil {
+		return nil, false, fmt.Errorf("failed to count coupon redemption: %w", err)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit coupon redemption: %w", err)
+	}
+	return redemption, true, nil
+}
+
+// ListRedemptions retrieves the latest redemptions of a coupon
+func (r *couponRepository) ListRedemptions(ctx context.Context, couponID uuid.UUID, limit int) ([]*models.CouponRedemption, error) {
+	rows, err := r.statements.QueryContext(ctx, listRedemptionsQuery, couponID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coupon redemptions: %w", err)
+	}
+	defer rows.Close()
+
+	var redemptions []*models.CouponRedemption
+	for rows.Next() {
+		redemption, err := scanCouponRedemption(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan coupon redemption: %w", err)
+		}
+		redemptions = append(redemptions, redemption)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating coupon redemptions: %w", err)
+	}
+
+	return redemptions, nil
+}
+
+// scanCoupon scans a coupon row selected with couponColumns
+func scanCoupon(row rowScanner) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := row.Scan(
+		&coupon.ID,
+		&coupon.Code,
+		&coupon.Description,
+		&coupon.Type,
+		&coupon.Value,
+		&coupon.Currency,
+		&coupon.CustomerID,
+		&coupon.ValidFrom,
+		&coupon.ValidUntil,
+		&coupon.MaxRedemptions,
+		&coupon.MaxRedemptionsPerCustomer,
+		&coupon.Redemptions,
+		&coupon.Status,
+		&coupon.CreatedBy,
+		&coupon.CreatedAt,
+		&coupon.VoidedBy,
+		&coupon.VoidReason,
+		&coupon.VoidedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// scanCouponRedemption scans a redemption row selected with
+// couponRedemptionColumns
+func scanCouponRedemption(row rowScanner) (*models.CouponRedemption, error) {
+	var redemption models.CouponRedemption
+	err := row.Scan(
+		&redemption.ID,
+		&redemption.CouponID,
+		&redemption.Code,
+		&redemption.CustomerID,
+		&redemption.Source,
+		&redemption.Reference,
+		&redemption.Currency,
+		&redemption.Amount,
+		&redemption.Discount,
+		&redemption.RedeemedBy,
+		&redemption.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
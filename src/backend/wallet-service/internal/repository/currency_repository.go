@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"internal/models"
+)
+
+// CurrencyRepository defines the interface for reading the supported
+// currencies
+type CurrencyRepository interface {
+	ListCurrencies(ctx context.Context) ([]models.Currency, error)
+	Close() error
+}
+
+// currencyRepository implements CurrencyRepository interface
+type currencyRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewCurrencyRepository creates a new instance of CurrencyRepository
+func NewCurrencyRepository(db *sql.DB) (CurrencyRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &currencyRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *currencyRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	listCurrenciesQuery = namedQuery{name: "listCurrencies", sql: `
+            SELECT code, precision, rounding_mode
+            FROM currencies
+            WHERE enabled
+            ORDER BY code`}
+)
+
+// ListCurrencies retrieves the enabled currencies
+func (r *currencyRepository) ListCurrencies(ctx context.Context) ([]models.Currency, error) {
+	rows, err := r.statements.QueryContext(ctx, listCurrenciesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list currencies: %w", err)
+	}
+	defer rows.Close()
+
+	var currencies []models.Currency
+	for rows.Next() {
+		var c models.Currency
+		if err := rows.Scan(&c.Code, &c.Precision, &c.Rounding); err != nil {
+			return nil, fmt.Errorf("failed to scan currency: %w", err)
+		}
+		currencies = append(currencies, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate currencies: %w", err)
+	}
+	return currencies, nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+	"internal/tenancy"
+)
+
+// ErrCustomerNotFound is returned when a customer does not exist
+var ErrCustomerNotFound = errors.New("customer not found")
+
+// CustomerRepository defines the interface for customer settings
+// persistence. Customers outside the organization a context is confined to
+// are not found.
+type CustomerRepository interface {
+	GetSettings(ctx context.Context, customerID uuid.UUID) (*models.CustomerSettings, error)
+	UpdateTimezone(ctx context.Context, customerID uuid.UUID, timezone string) (*models.CustomerSettings, error)
+	// UpdateTaxProfile sets the tax region and exemption of a customer; an
+	// empty region clears it
+	UpdateTaxProfile(ctx context.Context, customerID uuid.UUID, region string, exempt bool) (*models.CustomerSettings, error)
+	// UpdatePaymentTerms sets the payment terms of a customer; empty terms
+	// clear them
+	UpdatePaymentTerms(ctx context.Context, customerID uuid.UUID, terms models.PaymentTerms) (*models.CustomerSettings, error)
+	// UpdateBillingMode sets the billing mode of a customer and how its
+	// postpaid statements are settled; an empty settlement clears it
+	UpdateBillingMode(ctx context.Context, customerID uuid.UUID, mode models.BillingMode, settlement models.PostpaidSettlement) (*models.CustomerSettings, error)
+	Close() error
+}
+
+// customerSettingsColumns are the columns scanned by scanCustomerSettings
+const customerSettingsColumns = `id, timezone, COALESCE(tax_region, ''), tax_exempt, COALESCE(payment_terms, ''),
+                   billing_mode, COALESCE(postpaid_settlement, ''), org_id, updated_at`
+
+// customerRepository implements CustomerRepository interface
+type customerRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewCustomerRepository creates a new instance of CustomerRepository
+func NewCustomerRepository(db *sql.DB) (CustomerRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &customerRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *customerRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	getSettingsQuery = namedQuery{name: "getSettings", sql: `
+            SELECT ` + customerSettingsColumns + `
+            FROM customers
+            WHERE id = $1 AND ($2::uuid IS NULL OR org_id = $2)`}
+
+	updateTimezoneQuery = namedQuery{name: "updateTimezone", sql: `
+            UPDATE customers
+            SET timezone = $2,
+                updated_at = $3,
+                version = version + 1
+            WHERE id = $1 AND ($4::uuid IS NULL OR org_id = $4)
+            RETURNING ` + customerSettingsColumns}
+
+	updateTaxProfileQuery = namedQuery{name: "updateTaxProfile", sql: `
+            UPDATE customers
+            SET tax_region = NULLIF($2, ''),
+                tax_exempt = $3,
+                updated_at = $4,
+                version = version + 1
+            WHERE id = $1 AND ($5::uuid IS NULL OR org_id = $5)
+            RETURNING ` + customerSettingsColumns}
+
+	updatePaymentTermsQuery = namedQuery{name: "updatePaymentTerms", sql: `
+            UPDATE customers
+            SET payment_terms = NULLIF($2, ''),
+                updated_at = $3,
+                version = version + 1
+            WHERE id = $1 AND ($4::uuid IS NULL OR org_id = $4)
+            RETURNING ` + customerSettingsColumns}
+
+	updateBillingModeQuery = namedQuery{name: "updateBillingMode", sql: `
+            UPDATE customers
+            SET billing_mode = $2,
+                postpaid_settlement = NULLIF($3, ''),
+                updated_at = $4,
+                version = version + 1
+            WHERE id = $1 AND ($5::uuid IS NULL OR org_id = $5)
+            RETURNING ` + customerSettingsColumns}
+)
+
+// GetSettings retrieves a customer's settings
+func (r *customerRepository) GetSettings(ctx context.Context, customerID uuid.UUID) (*models.CustomerSettings, error) {
+	return scanCustomerSettings(r.statements.QueryRowContext(ctx, getSettingsQuery, customerID, tenancy.Scope(ctx)))
+}
+
+// UpdateTimezone sets the timezone of a customer
+func (r *customerRepository) UpdateTimezone(ctx context.Context, customerID uuid.UUID, timezone string) (*models.CustomerSettings, error) {
+	return scanCustomerSettings(r.statements.QueryRowContext(ctx, updateTimezoneQuery,
+		customerID,
+		timezone,
+		time.Now().UTC(),
+		tenancy.Scope(ctx),
+	))
+}
+
+// UpdateTaxProfile sets the tax region and exemption of a customer
+func (r *customerRepository) UpdateTaxProfile(ctx context.Context, customerID uuid.UUID, region string, exempt bool) (*models.CustomerSettings, error) {
+	return scanCustomerSettings(r.statements.QueryRowContext(ctx, updateTaxProfileQuery,
+		customerID,
+		region,
+		exempt,
+		time.Now().UTC(),
+		tenancy.Scope(ctx),
+	))
+}
+
+// UpdatePaymentTerms sets the payment terms of a customer
+func (r *customerRepository) UpdatePaymentTerms(ctx context.Context, customerID uuid.UUID, terms models.PaymentTerms) (*models.CustomerSettings, error) {
+	return scanCustomerSettings(r.statements.QueryRowContext(ctx, updatePaymentTermsQuery,
+		customerID,
+		string(terms),
+		time.Now().UTC(),
+		tenancy.Scope(ctx),
+	))
+}
+
+// UpdateBillingMode sets the billing mode of a customer
+func (r *customerRepository) UpdateBillingMode(ctx context.Context, customerID uuid.UUID, mode models.BillingMode, settlement models.PostpaidSettlement) (*models.CustomerSettings, error) {
+	return scanCustomerSettings(r.statements.QueryRowContext(ctx, updateBillingModeQuery,
+		customerID,
+		string(mode),
+		string(settlement),
+		time.Now().UTC(),
+		tenancy.Scope(ctx),
+	))
+}
+
+// scanCustomerSettings scans a customer settings row
+func scanCustomerSettings(row rowScanner) (*models.CustomerSettings, error) {
+	settings := &models.CustomerSettings{}
+	err := row.Scan(
+		&settings.CustomerID,
+		&settings.Timezone,
+		&settings.TaxRegion,
+		&settings.TaxExempt,
+		&settings.PaymentTerms,
+		&settings.BillingMode,
+		&settings.PostpaidSettlement,
+		&settings.OrgID,
+		&settings.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan customer settings: %w", err)
+	}
+
+	return settings, nil
+}
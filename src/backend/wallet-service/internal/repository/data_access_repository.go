@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// DataAccessRepository defines the interface for the audit log of accesses
+// to customers' financial data
+type DataAccessRepository interface {
+	RecordDataAccess(ctx context.Context, access *models.DataAccess) error
+	ListDataAccesses(ctx context.Context, customerID uuid.UUID, filter models.DataAccessFilter, limit, offset int) ([]*models.DataAccess, error)
+	PurgeDataAccesses(ctx context.Context, before time.Time) (int64, error)
+	Close() error
+}
+
+// dataAccessRepository implements DataAccessRepository interface
+type dataAccessRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewDataAccessRepository creates a new instance of DataAccessRepository
+func NewDataAccessRepository(db *sql.DB) (DataAccessRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &dataAccessRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *dataAccessRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	dataAccessRecordQuery = namedQuery{name: "dataAccessRecord", sql: `
+            INSERT INTO data_access_log (id, customer_id, wallet_id, action, accessor_kind, subject, accessor_customer_id,
+                                         roles, method, route, correlation_id, accessed_at)
+            VALUES ($1, $2, NULL, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11)`}
+
+	recordWalletQuery = namedQuery{name: "recordWallet", sql: `
+            INSERT INTO data_access_log (id, customer_id, wallet_id, action, accessor_kind, subject, accessor_customer_id,
+                                         roles, method, route, correlation_id, accessed_at)
+            SELECT $1, w.customer_id, w.id, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11
+            FROM wallets w
+            WHERE w.id = $2
+            RETURNING customer_id`}
+
+	dataAccessListQuery = namedQuery{name: "dataAccessList", sql: `
+            SELECT id, customer_id, wallet_id, action, accessor_kind, COALESCE(subject, ''), accessor_customer_id,
+                   roles, method, route, correlation_id, accessed_at
+            FROM data_access_log
+            WHERE (customer_id = $1 OR customer_id IS NULL)
+              AND ($2::timestamptz IS NULL OR accessed_at >= $2)
+              AND ($3::timestamptz IS NULL OR accessed_at < $3)
+              AND ($4 = '' OR action = $4)
+            ORDER BY accessed_at DESC
+            LIMIT $5 OFFSET $6`}
+
+	dataAccessPurgeQuery = namedQuery{name: "dataAccessPurge", sql: `
+            DELETE FROM data_access_log
+            WHERE accessed_at < $1`}
+)
+
+// RecordDataAccess appends an access to the log. Accesses naming a wallet
+// are attributed to its owner; those to a wallet that does not exist are
+// not recorded.
+func (r *dataAccessRepository) RecordDataAccess(ctx context.Context, access *models.DataAccess) error {
+	if access.ID == uuid.Nil {
+		access.ID = uuid.New()
+	}
+
+	args := []interface{}{
+		access.ID,
+		nil,
+		access.Action,
+		access.AccessorKind,
+		access.Subject,
+		access.AccessorCustomerID,
+		access.Roles,
+		access.Method,
+		access.Route,
+		access.CorrelationID,
+		access.AccessedAt,
+	}
+
+	if access.WalletID != nil {
+		args[1] = *access.WalletID
+		var customerID uuid.UUID
+		err := r.statements.QueryRowContext(ctx, recordWalletQuery, args...).Scan(&customerID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record data access: %w", err)
+		}
+		access.CustomerID = &customerID
+		return nil
+	}
+
+	args[1] = access.CustomerID
+	if _, err := r.statements.ExecContext(ctx, dataAccessRecordQuery, args...); err != nil {
+		return fmt.Errorf("failed to record data access: %w", err)
+	}
+
+	return nil
+}
+
+// ListDataAccesses retrieves a customer's accesses matching the filter,
+// newest first, including accesses to every customer's data
+func (r *dataAccessRepository) ListDataAccesses(ctx context.Context, customerID uuid.UUID, filter models.DataAccessFilter, limit, offset int) ([]*models.DataAccess, error) {
+	var from, to *time.Time
+	if !filter.From.IsZero() {
+		from = &filter.From
+	}
+	if !filter.To.IsZero() {
+		to = &filter.To
+	}
+
+	rows, err := r.statements.QueryContext(ctx, dataAccessListQuery, customerID, from, to, string(filter.Action), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data accesses: %w", err)
+	}
+	defer rows.Close()
+
+	var accesses []*models.DataAccess
+	for rows.Next() {
+		access := &models.DataAccess{}
+		err := rows.Scan(
+			&access.ID,
+			&access.CustomerID,
+			&access.WalletID,
+			&access.Action,
+			&access.AccessorKind,
+			&access.Subject,
+			&access.AccessorCustomerID,
+			pgArray(&access.Roles),
+			&access.Method,
+			&access.Route,
+			&access.CorrelationID,
+			&access.AccessedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan data access: %w", err)
+		}
+		accesses = append(accesses, access)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating data accesses: %w", err)
+	}
+
+	return accesses, nil
+}
+
+// PurgeDataAccesses deletes accesses older than the given time
+func (r *dataAccessRepository) PurgeDataAccesses(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.statements.ExecContext(ctx, dataAccessPurgeQuery, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge data accesses: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get purged count: %w", err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+)
+
+// readOnlySQLTransaction is the SQLSTATE of writes refused by a read-only
+// database
+const readOnlySQLTransaction = "25006"
+
+// DatabaseModeRepository reads whether the primary database accepts writes
+type DatabaseModeRepository interface {
+	// ReadOnly reports whether new transactions are read-only, as on a
+	// primary demoted or fenced during a failover
+	ReadOnly(ctx context.Context) (bool, error)
+}
+
+// databaseModeRepository implements DatabaseModeRepository interface
+type databaseModeRepository struct {
+	db *sql.DB
+}
+
+// NewDatabaseModeRepository creates a new instance of DatabaseModeRepository
+func NewDatabaseModeRepository(db *sql.DB) (DatabaseModeRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &databaseModeRepository{db: db}, nil
+}
+
+// ReadOnly reports whether the session default is read-only, which covers
+// both a standby in recovery and default_transaction_read_only
+func (r *databaseModeRepository) ReadOnly(ctx context.Context) (bool, error) {
+	var readOnly bool
+	err := r.db.QueryRowContext(ctx, `SELECT current_setting('transaction_read_only') = 'on'`).Scan(&readOnly)
+	if err != nil {
+		return false, fmt.Errorf("failed to read database mode: %w", err)
+	}
+
+	return readOnly, nil
+}
+
+// IsReadOnly reports whether err is the database refusing a write because
+// it is read-only
+func IsReadOnly(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == readOnlySQLTransaction
+}
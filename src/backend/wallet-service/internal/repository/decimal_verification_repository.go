@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+)
+
+// DecimalVerificationRepository reads amounts as exact decimals and records
+// the mismatches found by the float to decimal migration verification
+type DecimalVerificationRepository interface {
+	GetExactWalletValues(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.ExactWalletValues, error)
+	GetExactTransactionAmounts(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]decimal.Decimal, error)
+	RecordMismatch(ctx context.Context, mismatch *models.DecimalMismatch) error
+	Close() error
+}
+
+// decimalVerificationRepository implements DecimalVerificationRepository interface
+type decimalVerificationRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewDecimalVerificationRepository creates a new instance of DecimalVerificationRepository
+func NewDecimalVerificationRepository(db *sql.DB) (DecimalVerificationRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &decimalVerificationRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *decimalVerificationRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	getExactWalletValuesQuery = namedQuery{name: "getExactWalletValues", sql: `
+            SELECT id, balance, credit_limit, low_balance_threshold
+            FROM wallets
+            WHERE id = ANY($1::uuid[])`}
+
+	getExactTransactionAmountsQuery = namedQuery{name: "getExactTransactionAmounts", sql: `
+            SELECT id, amount
+            FROM wallet_transactions
+            WHERE id = ANY($1::uuid[])`}
+
+	recordMismatchQuery = namedQuery{name: "recordMismatch", sql: `
+            INSERT INTO decimal_verification_mismatches (id, entity, entity_id, field, float_value,
+                                                         decimal_value, drift, detected_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`}
+)
+
+// GetExactWalletValues reads the amounts of wallets as exact decimals
+func (r *decimalVerificationRepository) GetExactWalletValues(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.ExactWalletValues, error) {
+	rows, err := r.statements.QueryContext(ctx, getExactWalletValuesQuery, uuidStrings(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exact wallet values: %w", err)
+	}
+	defer rows.Close()
+
+	values := make(map[uuid.UUID]*models.ExactWalletValues, len(ids))
+	for rows.Next() {
+		var id uuid.UUID
+		v := &models.ExactWalletValues{}
+		if err := rows.Scan(&id, &v.Balance, &v.CreditLimit, &v.LowBalanceThreshold); err != nil {
+			return nil, fmt.Errorf("failed to scan exact wallet values: %w", err)
+		}
+		values[id] = v
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating exact wallet values: %w", err)
+	}
+
+	return values, nil
+}
+
+// GetExactTransactionAmounts reads the amounts of transactions as exact decimals
+func (r *decimalVerificationRepository) GetExactTransactionAmounts(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]decimal.Decimal, error) {
+	rows, err := r.statements.QueryContext(ctx, getExactTransactionAmountsQuery, uuidStrings(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exact transaction amounts: %w", err)
+	}
+	defer rows.Close()
+
+	amounts := make(map[uuid.UUID]decimal.Decimal, len(ids))
+	for rows.Next() {
+		var id uuid.UUID
+		var amount decimal.Decimal
+		if err := rows.Scan(&id, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan exact transaction amount: %w", err)
+		}
+		amounts[id] = amount
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating exact transaction amounts: %w", err)
+	}
+
+	return amounts, nil
+}
+
+// RecordMismatch stores a mismatch in the verification report table
+func (r *decimalVerificationRepository) RecordMismatch(ctx context.Context, mismatch *models.DecimalMismatch) error {
+	if mismatch.ID == uuid.Nil {
+		mismatch.ID = uuid.New()
+	}
+
+	_, err := r.statements.ExecContext(ctx, recordMismatchQuery,
+		mismatch.ID,
+		mismatch.Entity,
+		mismatch.EntityID,
+		mismatch.Field,
+		mismatch.FloatValue,
+		mismatch.DecimalValue,
+		mismatch.Drift,
+		mismatch.DetectedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record decimal mismatch: %w", err)
+	}
+
+	return nil
+}
+
+// uuidStrings formats IDs for a PostgreSQL uuid array parameter
+func uuidStrings(ids []uuid.UUID) []string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	return strs
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"internal/models"
+)
+
+// DeprecationRepository defines the interface for deprecated route usage
+// persistence
+type DeprecationRepository interface {
+	AddUsage(ctx context.Context, usage *models.DeprecatedRouteUsage) error
+	ListUsage(ctx context.Context, since time.Time) ([]*models.DeprecatedRouteUsage, error)
+	Close() error
+}
+
+// deprecationRepository implements DeprecationRepository interface
+type deprecationRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewDeprecationRepository creates a new instance of DeprecationRepository
+func NewDeprecationRepository(db *sql.DB) (DeprecationRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &deprecationRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *deprecationRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	deprecationAddUsageQuery = namedQuery{name: "deprecationAddUsage", sql: `
+            INSERT INTO deprecated_route_usage (method, route, caller, requests, first_seen_at, last_seen_at)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            ON CONFLICT (method, route, caller) DO UPDATE
+            SET requests = deprecated_route_usage.requests + EXCLUDED.requests,
+                first_seen_at = LEAST(deprecated_route_usage.first_seen_at, EXCLUDED.first_seen_at),
+                last_seen_at = GREATEST(deprecated_route_usage.last_seen_at, EXCLUDED.last_seen_at)`}
+
+	deprecationListUsageQuery = namedQuery{name: "deprecationListUsage", sql: `
+            SELECT method, route, caller, requests, first_seen_at, last_seen_at
+            FROM deprecated_route_usage
+            WHERE last_seen_at >= $1
+            ORDER BY caller, method, route`}
+)
+
+// AddUsage adds calls to a caller's use of a deprecated route
+func (r *deprecationRepository) AddUsage(ctx context.Context, usage *models.DeprecatedRouteUsage) error {
+	_, err := r.statements.ExecContext(ctx, deprecationAddUsageQuery,
+		usage.Method,
+		usage.Route,
+		usage.Caller,
+		usage.Requests,
+		usage.FirstSeen,
+		usage.LastSeen,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add deprecated route usage: %w", err)
+	}
+
+	return nil
+}
+
+// ListUsage retrieves the use of deprecated routes by callers seen since the
+// given time, ordered by caller
+func (r *deprecationRepository) ListUsage(ctx context.Context, since time.Time) ([]*models.DeprecatedRouteUsage, error) {
+	rows, err := r.statements.QueryContext(ctx, deprecationListUsageQuery, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deprecated route usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usages []*models.DeprecatedRouteUsage
+	for rows.Next() {
+		u := &models.DeprecatedRouteUsage{}
+		if err := rows.Scan(&u.Method, &u.Route, &u.Caller, &u.Requests, &u.FirstSeen, &u.LastSeen); err != nil {
+			return nil, fmt.Errorf("failed to scan deprecated route usage: %w", err)
+		}
+		usages = append(usages, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deprecated route usage: %w", err)
+	}
+
+	return usages, nil
+}
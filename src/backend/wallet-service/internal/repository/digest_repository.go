@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+)
+
+// ErrDigestSubscriptionNotFound is returned when a customer has no digest subscription
+var ErrDigestSubscriptionNotFound = errors.New("digest subscription not found")
+
+// dateLayout formats dates for DATE parameters
+const dateLayout = "2006-01-02"
+
+// digestTruncUnits are the date_trunc units of each digest frequency's period
+var digestTruncUnits = map[models.DigestFrequency]string{
+	models.DigestWeekly:  "week",
+	models.DigestMonthly: "month",
+}
+
+// DigestRepository defines the interface for activity digest persistence
+type DigestRepository interface {
+	GetSubscription(ctx context.Context, customerID uuid.UUID) (*models.DigestSubscription, error)
+	UpsertSubscription(ctx context.Context, sub *models.DigestSubscription) error
+	ListDueSubscriptions(ctx context.Context, frequency models.DigestFrequency, now time.Time, limit int) ([]*models.DigestSubscription, error)
+	MarkSent(ctx context.Context, customerID uuid.UUID, periodEnd time.Time) error
+	RefreshDailyActivity(ctx context.Context, from, to time.Time) error
+	GetWalletActivity(ctx context.Context, customerID uuid.UUID, from, to time.Time) ([]*models.WalletActivity, error)
+	Close() error
+}
+
+// digestRepository implements DigestRepository interface
+type digestRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewDigestRepository creates a new instance of DigestRepository
+func NewDigestRepository(db *sql.DB) (DigestRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &digestRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *digestRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	digestGetSubscriptionQuery = namedQuery{name: "digestGetSubscription", sql: `
+            SELECT s.customer_id, s.frequency, s.enabled, s.last_period_end, s.created_at, s.updated_at, c.timezone
+            FROM digest_subscriptions s
+            JOIN customers c ON c.id = s.customer_id
+            WHERE s.customer_id = $1`}
+
+	upsertSubscriptionQuery = namedQuery{name: "upsertSubscription", sql: `
+            INSERT INTO digest_subscriptions (customer_id, frequency, enabled, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $4)
+            ON CONFLICT (customer_id) DO UPDATE
+            SET frequency = EXCLUDED.frequency,
+                enabled = EXCLUDED.enabled,
+                updated_at = EXCLUDED.updated_at
+            RETURNING last_period_end, created_at, (SELECT timezone FROM customers WHERE id = $1)`}
+
+	listDueSubscriptionsQuery = namedQuery{name: "listDueSubscriptions", sql: `
+            SELECT s.customer_id, s.frequency, s.enabled, s.last_period_end, s.created_at, s.updated_at, c.timezone
+            FROM digest_subscriptions s
+            JOIN customers c ON c.id = s.customer_id
+            WHERE s.enabled AND s.frequency = $1
+              AND (s.last_period_end IS NULL
+                   OR s.last_period_end < date_trunc($2::text, $3::timestamptz AT TIME ZONE c.timezone) AT TIME ZONE c.timezone)
+            ORDER BY s.customer_id
+            LIMIT $4`}
+
+	markSentQuery = namedQuery{name: "markSent", sql: `
+            UPDATE digest_subscriptions
+            SET last_period_end = $2
+            WHERE customer_id = $1`}
+
+	refreshDailyActivityQuery = namedQuery{name: "refreshDailyActivity", sql: `
+            INSERT INTO wallet_activity_daily (wallet_id, activity_date, category, top_up_count, top_up_total,
+                                               spend_count, spend_total, refund_total, updated_at)
+            SELECT t.wallet_id,
+                   (COALESCE(t.effective_at, t.created_at) AT TIME ZONE c.timezone)::date,
+                   COALESCE(NULLIF(t.metadata->>'category', ''), '` + models.UncategorizedSpend + `'),
+                   COUNT(*) FILTER (WHERE t.type = 'CREDIT'),
+                   COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'CREDIT'), 0),
+                   COUNT(*) FILTER (WHERE t.type = 'DEBIT'),
+                   COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'DEBIT'), 0),
+                   COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'REFUND'), 0),
+                   CURRENT_TIMESTAMP
+            FROM wallet_transactions t
+            JOIN wallets w ON w.id = t.wallet_id
+            JOIN customers c ON c.id = w.customer_id
+            WHERE t.status = 'COMPLETED'
+              -- Activity is dated by effective date, so backdated corrections
+              -- count on the day they apply to. Local dates are within a day
+              -- of UTC, so the range scan stays indexed.
+              AND COALESCE(t.effective_at, t.created_at) >= ($1::date - 1)::timestamp AT TIME ZONE 'UTC'
+              AND COALESCE(t.effective_at, t.created_at) < ($2::date + 1)::timestamp AT TIME ZONE 'UTC'
+              AND (COALESCE(t.effective_at, t.created_at) AT TIME ZONE c.timezone)::date >= $1::date
+              AND (COALESCE(t.effective_at, t.created_at) AT TIME ZONE c.timezone)::date < $2::date
+            GROUP BY 1, 2, 3
+            ON CONFLICT (wallet_id, activity_date, category) DO UPDATE
+            SET top_up_count = EXCLUDED.top_up_count,
+                top_up_total = EXCLUDED.top_up_total,
+                spend_count = EXCLUDED.spend_count,
+                spend_total = EXCLUDED.spend_total,
+                refund_total = EXCLUDED.refund_total,
+                updated_at = EXCLUDED.updated_at`}
+
+	getWalletActivityQuery = namedQuery{name: "getWalletActivity", sql: `
+            SELECT w.id, w.currency, w.balance, a.category,
+                   COALESCE(SUM(a.top_up_count), 0), COALESCE(SUM(a.top_up_total), 0),
+                   COALESCE(SUM(a.spend_count), 0), COALESCE(SUM(a.spend_total), 0),
+                   COALESCE(SUM(a.refund_total), 0), n.net_after
+            FROM wallets w
+            LEFT JOIN wallet_activity_daily a
+                   ON a.wallet_id = w.id AND a.activity_date >= $2::date AND a.activity_date < $3::date
+            CROSS JOIN LATERAL (
+                SELECT COALESCE(SUM(` + signedAmountSQL + `), 0) AS net_after
+                FROM wallet_transactions t
+                WHERE t.wallet_id = w.id AND t.status = 'COMPLETED' AND COALESCE(t.effective_at, t.created_at) >= $4
+            ) n
+            WHERE w.customer_id = $1
+            GROUP BY w.id, w.currency, w.balance, a.category, n.net_after
+            ORDER BY w.id, a.category`}
+)
+
+// GetSubscription retrieves a customer's digest subscription
+func (r *digestRepository) GetSubscription(ctx context.Context, customerID uuid.UUID) (*models.DigestSubscription, error) {
+	sub, err := scanSubscription(r.statements.QueryRowContext(ctx, digestGetSubscriptionQuery, customerID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDigestSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// UpsertSubscription creates or updates a customer's digest subscription
+func (r *digestRepository) UpsertSubscription(ctx context.Context, sub *models.DigestSubscription) error {
+	sub.UpdatedAt = time.Now().UTC()
+
+	var lastPeriodEnd sql.NullTime
+	err := r.statements.QueryRowContext(ctx, upsertSubscriptionQuery,
+		sub.CustomerID,
+		sub.Frequency,
+		sub.Enabled,
+		sub.UpdatedAt,
+	).Scan(&lastPeriodEnd, &sub.CreatedAt, &sub.Timezone)
+	if err != nil {
+		return fmt.Errorf("failed to save digest subscription: %w", err)
+	}
+
+	sub.LastPeriodEnd = nil
+	if lastPeriodEnd.Valid {
+		sub.LastPeriodEnd = &lastPeriodEnd.Time
+	}
+
+	return nil
+}
+
+// ListDueSubscriptions retrieves enabled subscriptions of a frequency that
+// have not yet been sent the digest of the latest period completed at now,
+// with periods starting at midnight in each customer's timezone
+func (r *digestRepository) ListDueSubscriptions(ctx context.Context, frequency models.DigestFrequency, now time.Time, limit int) ([]*models.DigestSubscription, error) {
+	rows, err := r.statements.QueryContext(ctx, listDueSubscriptionsQuery, frequency, digestTruncUnits[frequency], now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due digest subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []*models.DigestSubscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating digest subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+// MarkSent records that the digest ending at periodEnd was sent
+func (r *digestRepository) MarkSent(ctx context.Context, customerID uuid.UUID, periodEnd time.Time) error {
+	if _, err := r.statements.ExecContext(ctx, markSentQuery, customerID, periodEnd); err != nil {
+		return fmt.Errorf("failed to mark digest sent: %w", err)
+	}
+	return nil
+}
+
+// RefreshDailyActivity recomputes the daily activity aggregates for every
+// date in [from, to), taking the dates of from and to in their own location.
+// Transactions are bucketed by their date in the wallet owner's timezone.
+func (r *digestRepository) RefreshDailyActivity(ctx context.Context, from, to time.Time) error {
+	_, err := r.statements.ExecContext(ctx, refreshDailyActivityQuery,
+		from.Format(dateLayout),
+		to.Format(dateLayout),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to refresh daily activity: %w", err)
+	}
+	return nil
+}
+
+// GetWalletActivity summarises each of a customer's wallets over [from, to)
+// from the daily aggregates, where from and to are midnights in the
+// customer's timezone. The closing balance is derived from the current
+// balance minus the completed transactions since the end of the period.
+func (r *digestRepository) GetWalletActivity(ctx context.Context, customerID uuid.UUID, from, to time.Time) ([]*models.WalletActivity, error) {
+	rows, err := r.statements.QueryContext(ctx, getWalletActivityQuery,
+		customerID,
+		from.Format(dateLayout),
+		to.Format(dateLayout),
+		to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet activity: %w", err)
+	}
+	defer rows.Close()
+
+	var activities []*models.WalletActivity
+	var current *models.WalletActivity
+	for rows.Next() {
+		var (
+			walletID                        uuid.UUID
+			currency                        string
+			category                        sql.NullString
+			balance, topUpTotal, spendTotal decimal.Decimal
+			refundTotal, netAfter           decimal.Decimal
+			topUpCount, spendCount          int64
+		)
+		err := rows.Scan(
+			&walletID,
+			&currency,
+			&balance,
+			&category,
+			&topUpCount,
+			&topUpTotal,
+			&spendCount,
+			&spendTotal,
+			&refundTotal,
+			&netAfter,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet activity: %w", err)
+		}
+
+		if current == nil || current.WalletID != walletID {
+			current = &models.WalletActivity{
+				WalletID:       walletID,
+				Currency:       currency,
+				ClosingBalance: balance.Sub(netAfter),
+			}
+			activities = append(activities, current)
+		}
+
+		current.TopUpCount += topUpCount
+		current.TopUpTotal = current.TopUpTotal.Add(topUpTotal)
+		current.RefundTotal = current.RefundTotal.Add(refundTotal)
+		if category.Valid && spendCount > 0 {
+			current.Spend = append(current.Spend, &models.CategorySpend{
+				Category: category.String,
+				Count:    spendCount,
+				Total:    spendTotal,
+			})
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating wallet activity: %w", err)
+	}
+
+	// Reverse the period's net change to find the opening balance
+	for _, activity := range activities {
+		opening := activity.ClosingBalance.Sub(activity.TopUpTotal).Sub(activity.RefundTotal)
+		for _, spend := range activity.Spend {
+			opening = opening.Add(spend.Total)
+		}
+		activity.OpeningBalance = opening
+	}
+
+	return activities, nil
+}
+
+// scanSubscription scans a digest subscription row
+func scanSubscription(row rowScanner) (*models.DigestSubscription, error) {
+	sub := &models.DigestSubscription{}
+	var lastPeriodEnd sql.NullTime
+	err := row.Scan(
+		&sub.CustomerID,
+		&sub.Frequency,
+		&sub.Enabled,
+		&lastPeriodEnd,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+		&sub.Timezone,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan digest subscription: %w", err)
+	}
+	if lastPeriodEnd.Valid {
+		sub.LastPeriodEnd = &lastPeriodEnd.Time
+	}
+
+	return sub, nil
+}
@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+)
+
+// Dispute repository errors
+var (
+	ErrDisputeNotFound = errors.New("dispute not found")
+	// ErrDisputeConflict is returned when a transaction is disputed twice or
+	// a dispute is updated out of order
+	ErrDisputeConflict          = errors.New("dispute is not in the required state")
+	ErrTransactionNotDisputable = errors.New("transaction is not a completed top-up of the wallet")
+	ErrDisputeExceedsAmount     = errors.New("disputed amount exceeds the transaction amount")
+)
+
+// disputeColumns are the columns scanned by scanDispute
+const disputeColumns = `id, wallet_id, transaction_id, hold_transaction_id, amount, currency, status,
+            reason_code, note, COALESCE(provider_case_id, ''), COALESCE(evidence, ''), evidence_submitted_at,
+            COALESCE(resolution_note, ''), opened_by, COALESCE(resolved_by, ''), resolved_at,
+            created_at, updated_at`
+
+// DisputeRepository defines the interface for chargeback dispute persistence
+type DisputeRepository interface {
+	// OpenDispute records a dispute of a completed top-up and holds the
+	// disputed amount with a PROCESSING debit in one unit of work. The
+	// dispute's WalletID, TransactionID, ReasonCode, Note and OpenedBy must
+	// be set; a zero Amount disputes the whole top-up. The remaining fields
+	// are filled in.
+	OpenDispute(ctx context.Context, dispute *models.Dispute, description string) error
+	GetDispute(ctx context.Context, id uuid.UUID) (*models.Dispute, error)
+	ListDisputes(ctx context.Context, filter models.DisputeFilter) ([]*models.Dispute, error)
+	// SubmitEvidence records the evidence submitted for an open dispute
+	SubmitEvidence(ctx context.Context, id uuid.UUID, evidence string, now time.Time) (*models.Dispute, error)
+	// ResolveDispute settles an unresolved dispute: a won dispute releases
+	// the hold and reverses its transaction, a lost one completes it
+	ResolveDispute(ctx context.Context, id uuid.UUID, status models.DisputeStatus, resolver, note string, now time.Time) (*models.Dispute, error)
+	Close() error
+}
+
+// disputeRepository implements DisputeRepository interface
+type disputeRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewDisputeRepository creates a new instance of DisputeRepository
+func NewDisputeRepository(db *sql.DB) (DisputeRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &disputeRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *disputeRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	// lockDisputedTransactionQuery reads a completed top-up of a wallet,
+	// locking it until the transaction ends
+	lockDisputedTransactionQuery = namedQuery{name: "lockDisputedTransaction", sql: `
+            SELECT amount, currency
+            FROM wallet_transactions
+            WHERE id = $1 AND wallet_id = $2 AND type = 'CREDIT' AND status = 'COMPLETED'
+            FOR UPDATE`}
+
+	// insertDisputeQuery records a dispute unless its top-up is disputed
+	// already
+	insertDisputeQuery = namedQuery{name: "insertDispute", sql: `
+            INSERT INTO wallet_disputes (
+                id, wallet_id, transaction_id, hold_transaction_id, amount, currency, status,
+                reason_code, note, provider_case_id, opened_by, created_at, updated_at
+            ) VALUES ($1, $2, $3, $4, $5, $6, 'OPEN', $7, $8, NULLIF($9, ''), $10, $11, $11)
+            ON CONFLICT (transaction_id) DO NOTHING`}
+
+	// getDisputeQuery reads a dispute
+	getDisputeQuery = namedQuery{name: "getDispute", sql: `
+            SELECT ` + disputeColumns + `
+            FROM wallet_disputes
+            WHERE id = $1`}
+
+	// listDisputesQuery reads the latest disputes of a wallet or status
+	listDisputesQuery = namedQuery{name: "listDisputes", sql: `
+            SELECT ` + disputeColumns + `
+            FROM wallet_disputes
+            WHERE ($1::uuid IS NULL OR wallet_id = $1) AND ($2 = '' OR status = $2)
+            ORDER BY created_at DESC
+            LIMIT $3`}
+
+	// submitEvidenceQuery records the evidence of an open dispute
+	submitEvidenceQuery = namedQuery{name: "submitEvidence", sql: `
+            UPDATE wallet_disputes
+            SET status = 'EVIDENCE_SUBMITTED', evidence = $2, evidence_submitted_at = $3, updated_at = $3
+            WHERE id = $1 AND status = 'OPEN'
+            RETURNING ` + disputeColumns}
+
+	// resolveDisputeQuery settles an unresolved dispute
+	resolveDisputeQuery = namedQuery{name: "resolveDispute", sql: `
+            UPDATE wallet_disputes
+            SET status = $2, resolved_by = $3, resolution_note = NULLIF($4, ''), resolved_at = $5, updated_at = $5
+            WHERE id = $1 AND status IN ('OPEN', 'EVIDENCE_SUBMITTED')
+            RETURNING ` + disputeColumns}
+
+	// settleHoldQuery completes or reverses the hold of a dispute
+	settleHoldQuery = namedQuery{name: "settleHold", sql: `
+            UPDATE wallet_transactions
+            SET status = $2
+            WHERE id = $1 AND status = 'PROCESSING'`}
+)
+
+// OpenDispute records a dispute and holds the disputed amount
+func (r *disputeRepository) OpenDispute(ctx context.Context, dispute *models.Dispute, description string) error {
+	return inWalletTx(ctx, r.db, r.statements, func(tx *walletTx) error {
+		// Lock the top-up so it is disputed once, at most for its amount
+		var paid decimal.Decimal
+		err := tx.statements.QueryRowContext(ctx, lockDisputedTransactionQuery,
+			dispute.TransactionID,
+			dispute.WalletID,
+		).Scan(&paid, &dispute.Currency)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTransactionNotDisputable
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock disputed transaction: %w", err)
+		}
+		if dispute.Amount.IsZero() {
+			dispute.Amount = paid
+		}
+		if dispute.Amount.GreaterThan(paid) {
+			return ErrDisputeExceedsAmount
+		}
+
+		// Disputed amounts may take the balance into the wallet's credit
+		// limit, as the top-up may have been spent already
+		hold, err := placeHold(ctx, tx, dispute.WalletID, dispute.Amount, dispute.Currency,
+			description, dispute.TransactionID.String())
+		if err != nil {
+			return err
+		}
+
+		dispute.ID = uuid.New()
+		dispute.HoldTransactionID = hold.ID
+		dispute.Status = models.DisputeOpen
+		dispute.CreatedAt = hold.CreatedAt
+		dispute.UpdatedAt = hold.CreatedAt
+
+		result, err := tx.statements.ExecContext(ctx, insertDisputeQuery,
+			dispute.ID,
+			dispute.WalletID,
+			dispute.TransactionID,
+			dispute.HoldTransactionID,
+			dispute.Amount,
+			dispute.Currency,
+			dispute.ReasonCode,
+			dispute.Note,
+			dispute.ProviderCaseID,
+			dispute.OpenedBy,
+			dispute.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert dispute: %w", err)
+		}
+		if inserted, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to get inserted count: %w", err)
+		} else if inserted == 0 {
+			return ErrDisputeConflict
+		}
+
+		return nil
+	})
+}
+
+// GetDispute retrieves a dispute by ID
+func (r *disputeRepository) GetDispute(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	dispute, err := scanDispute(r.statements.QueryRowContext(ctx, getDisputeQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDisputeNotFound
+		}
+		return nil, fmt.Errorf("failed to get dispute: %w", err)
+	}
+	return dispute, nil
+}
+
+// ListDisputes retrieves the latest disputes matching the filter, newest
+// first
+func (r *disputeRepository) ListDisputes(ctx context.Context, filter models.DisputeFilter) ([]*models.Dispute, error) {
+	rows, err := r.statements.QueryContext(ctx, listDisputesQuery, filter.WalletID, string(filter.Status), filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disputes: %w", err)
+	}
+	defer rows.Close()
+
+	var disputes []*models.Dispute
+	for rows.Next() {
+		dispute, err := scanDispute(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dispute: %w", err)
+		}
+		disputes = append(disputes, dispute)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating disputes: %w", err)
+	}
+
+	return disputes, nil
+}
+
+// SubmitEvidence records the evidence submitted for an open dispute
+func (r *disputeRepository) SubmitEvidence(ctx context.Context, id uuid.UUID, evidence string, now time.Time) (*models.Dispute, error) {
+	dispute, err := scanDispute(r.statements.QueryRowContext(ctx, submitEvidenceQuery, id, evidence, now))
+	if err == nil {
+		return dispute, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to submit dispute evidence: %w", err)
+	}
+
+	if _, err := r.GetDispute(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, ErrDisputeConflict
+}
+
+// ResolveDispute settles an unresolved dispute and its hold in one unit of
+// work
+func (r *disputeRepository) ResolveDispute(ctx context.Context, id uuid.UUID, status models.DisputeStatus, resolver, note string, now time.Time) (*models.Dispute, error) {
+	var dispute *models.Dispute
+	err := inWalletTx(ctx, r.db, r.statements, func(tx *walletTx) error {
+		var err error
+		dispute, err = scanDispute(tx.statements.QueryRowContext(ctx, resolveDisputeQuery,
+			id, status, resolver, note, now))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDisputeConflict
+		}
+		if err != nil {
+			return fmt.Errorf("failed to resolve dispute: %w", err)
+		}
+
+		// A lost dispute keeps the held amount, completing the hold as the
+		// reversal of the top-up. A won one credits the held amount back.
+		holdStatus := models.TransactionStatusCompleted
+		if status == models.DisputeWon {
+			holdStatus = models.TransactionStatusReversed
+			if err := releaseHold(ctx, tx, dispute.WalletID, dispute.Amount, dispute.Currency); err != nil {
+				return fmt.Errorf("failed to release disputed funds: %w", err)
+			}
+		}
+
+		if _, err := tx.statements.ExecContext(ctx, settleHoldQuery, dispute.HoldTransactionID, holdStatus.String()); err != nil {
+			return fmt.Errorf("failed to settle dispute hold: %w", err)
+		}
+		return nil
+	})
+	if errors.Is(err, ErrDisputeConflict) {
+		if _, err := r.GetDispute(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrDisputeConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dispute, nil
+}
+
+// scanDispute scans a dispute row selected with disputeColumns
+func scanDispute(row rowScanner) (*models.Dispute, error) {
+	var dispute models.Dispute
+	err := row.Scan(
+		&dispute.ID,
+		&dispute.WalletID,
+		&dispute.TransactionID,
+		&dispute.HoldTransactionID,
+		&dispute.Amount,
+		&dispute.Currency,
+		&dispute.Status,
+		&dispute.ReasonCode,
+		&dispute.Note,
+		&dispute.ProviderCaseID,
+		&dispute.Evidence,
+		&dispute.EvidenceSubmittedAt,
+		&dispute.ResolutionNote,
+		&dispute.OpenedBy,
+		&dispute.ResolvedBy,
+		&dispute.ResolvedAt,
+		&dispute.CreatedAt,
+		&dispute.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
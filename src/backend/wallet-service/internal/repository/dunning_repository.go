@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// ErrDunningCaseNotFound is returned when a wallet has no open dunning case
+var ErrDunningCaseNotFound = errors.New("dunning case not found")
+
+// dunningColumns selects a dunning case joined with its wallet's balance
+const dunningColumns = `
+            d.id, d.wallet_id, d.customer_id, w.balance, w.currency, d.started_at, d.grace_ends_at,
+            d.notices_sent, d.next_notice_at, d.suspended_at, d.resolved_at, d.created_at, d.updated_at`
+
+// DunningRepository defines the interface for dunning case persistence
+type DunningRepository interface {
+	OpenCases(ctx context.Context, now, graceEndsAt, firstNoticeAt time.Time) (int64, error)
+	GetOpenCase(ctx context.Context, walletID uuid.UUID) (*models.DunningCase, error)
+	ListDueNotices(ctx context.Context, now time.Time, limit int) ([]*models.DunningCase, error)
+	RecordNotice(ctx context.Context, id uuid.UUID, noticesSent int, nextNoticeAt *time.Time) error
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]*models.DunningCase, error)
+	MarkSuspended(ctx context.Context, id uuid.UUID, suspendedAt time.Time) error
+	ListRecovered(ctx context.Context, limit int) ([]*models.DunningCase, error)
+	Resolve(ctx context.Context, id uuid.UUID, resolvedAt time.Time) error
+	Close() error
+}
+
+// dunningRepository implements DunningRepository interface
+type dunningRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewDunningRepository creates a new instance of DunningRepository
+func NewDunningRepository(db *sql.DB) (DunningRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &dunningRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *dunningRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	// Wallets that never spent anything are unfunded rather than exhausted
+	openCasesQuery = namedQuery{name: "openCases", sql: `
+            INSERT INTO dunning_cases (wallet_id, customer_id, started_at, grace_ends_at, next_notice_at, created_at, updated_at)
+            SELECT w.id, w.customer_id, $1, $2, $3, $1, $1
+            FROM wallets w
+            WHERE w.balance <= 0
+              AND EXISTS (SELECT 1 FROM wallet_transactions t WHERE t.wallet_id = w.id AND t.type = 'DEBIT')
+              AND NOT EXISTS (SELECT 1 FROM dunning_cases d WHERE d.wallet_id = w.id AND d.resolved_at IS NULL)
+            ON CONFLICT (wallet_id) WHERE resolved_at IS NULL DO NOTHING`}
+
+	getOpenCaseQuery = namedQuery{name: "getOpenCase", sql: `
+            SELECT` + dunningColumns + `
+            FROM dunning_cases d
+            JOIN wallets w ON w.id = d.wallet_id
+            WHERE d.wallet_id = $1 AND d.resolved_at IS NULL`}
+
+	listDueNoticesQuery = namedQuery{name: "listDueNotices", sql: `
+            SELECT` + dunningColumns + `
+            FROM dunning_cases d
+            JOIN wallets w ON w.id = d.wallet_id
+            WHERE d.resolved_at IS NULL AND d.next_notice_at <= $1 AND w.balance <= 0
+            ORDER BY d.next_notice_at
+            LIMIT $2`}
+
+	recordNoticeQuery = namedQuery{name: "recordNotice", sql: `
+            UPDATE dunning_cases
+            SET notices_sent = notices_sent + 1,
+                next_notice_at = $3,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1 AND notices_sent = $2 AND resolved_at IS NULL`}
+
+	listExpiredQuery = namedQuery{name: "listExpired", sql: `
+            SELECT` + dunningColumns + `
+            FROM dunning_cases d
+            JOIN wallets w ON w.id = d.wallet_id
+            WHERE d.resolved_at IS NULL AND d.suspended_at IS NULL AND d.grace_ends_at <= $1 AND w.balance <= 0
+            ORDER BY d.grace_ends_at
+            LIMIT $2`}
+
+	markSuspendedQuery = namedQuery{name: "markSuspended", sql: `
+            UPDATE dunning_cases
+            SET suspended_at = $2,
+                next_notice_at = NULL,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1 AND suspended_at IS NULL AND resolved_at IS NULL`}
+
+	listRecoveredQuery = namedQuery{name: "listRecovered", sql: `
+            SELECT` + dunningColumns + `
+            FROM dunning_cases d
+            JOIN wallets w ON w.id = d.wallet_id
+            WHERE d.resolved_at IS NULL AND w.balance > 0
+            ORDER BY d.started_at
+            LIMIT $1`}
+
+	dunningResolveQuery = namedQuery{name: "dunningResolve", sql: `
+            UPDATE dunning_cases
+            SET resolved_at = $2,
+                next_notice_at = NULL,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1 AND resolved_at IS NULL`}
+)
+
+// OpenCases starts a grace period for every spent wallet whose balance is zero
+// or negative and that has no open case, returning the number opened
+func (r *dunningRepository) OpenCases(ctx context.Context, now, graceEndsAt, firstNoticeAt time.Time) (int64, error) {
+	result, err := r.statements.ExecContext(ctx, openCasesQuery, now, graceEndsAt, firstNoticeAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open dunning cases: %w", err)
+	}
+
+	opened, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get opened dunning case count: %w", err)
+	}
+
+	return opened, nil
+}
+
+// GetOpenCase retrieves the open dunning case of a wallet
+func (r *dunningRepository) GetOpenCase(ctx context.Context, walletID uuid.UUID) (*models.DunningCase, error) {
+	dc, err := scanDunningCase(r.statements.QueryRowContext(ctx, getOpenCaseQuery, walletID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDunningCaseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dunning case: %w", err)
+	}
+
+	return dc, nil
+}
+
+// ListDueNotices retrieves open cases whose next notice is due
+func (r *dunningRepository) ListDueNotices(ctx context.Context, now time.Time, limit int) ([]*models.DunningCase, error) {
+	return r.list(ctx, listDueNoticesQuery, now, limit)
+}
+
+// RecordNotice records that the next notice of a case was sent and when the
+// one after it is due. The update is skipped if another replica recorded it.
+func (r *dunningRepository) RecordNotice(ctx context.Context, id uuid.UUID, noticesSent int, nextNoticeAt *time.Time) error {
+	if _, err := r.statements.ExecContext(ctx, recordNoticeQuery, id, noticesSent, nextNoticeAt); err != nil {
+		return fmt.Errorf("failed to record dunning notice: %w", err)
+	}
+	return nil
+}
+
+// ListExpired retrieves open unsuspended cases whose grace period has ended
+func (r *dunningRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*models.DunningCase, error) {
+	return r.list(ctx, listExpiredQuery, now, limit)
+}
+
+// MarkSuspended records that service was suspended for a case
+func (r *dunningRepository) MarkSuspended(ctx context.Context, id uuid.UUID, suspendedAt time.Time) error {
+	if _, err := r.statements.ExecContext(ctx, markSuspendedQuery, id, suspendedAt); err != nil {
+		return fmt.Errorf("failed to mark dunning case suspended: %w", err)
+	}
+	return nil
+}
+
+// ListRecovered retrieves open cases whose wallet balance is positive again
+func (r *dunningRepository) ListRecovered(ctx context.Context, limit int) ([]*models.DunningCase, error) {
+	return r.list(ctx, listRecoveredQuery, limit)
+}
+
+// Resolve closes a case after its wallet was topped up
+func (r *dunningRepository) Resolve(ctx context.Context, id uuid.UUID, resolvedAt time.Time) error {
+	if _, err := r.statements.ExecContext(ctx, dunningResolveQuery, id, resolvedAt); err != nil {
+		return fmt.Errorf("failed to resolve dunning case: %w", err)
+	}
+	return nil
+}
+
+// list runs one of the dunning case list statements
+func (r *dunningRepository) list(ctx context.Context, q namedQuery, args ...interface{}) ([]*models.DunningCase, error) {
+	rows, err := r.statements.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dunning cases: %w", err)
+	}
+	defer rows.Close()
+
+	var cases []*models.DunningCase
+	for rows.Next() {
+		dc, err := scanDunningCase(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dunning case: %w", err)
+		}
+		cases = append(cases, dc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dunning cases: %w", err)
+	}
+
+	return cases, nil
+}
+
+// scanDunningCase scans a row selected with dunningColumns
+func scanDunningCase(row rowScanner) (*models.DunningCase, error) {
+	dc := &models.DunningCase{}
+	var nextNoticeAt, suspendedAt, resolvedAt sql.NullTime
+	err := row.Scan(
+		&dc.ID,
+		&dc.WalletID,
+		&dc.CustomerID,
+		&dc.Balance,
+		&dc.Currency,
+		&dc.StartedAt,
+		&dc.GraceEndsAt,
+		&dc.NoticesSent,
+		&nextNoticeAt,
+		&suspendedAt,
+		&resolvedAt,
+		&dc.CreatedAt,
+		&dc.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if nextNoticeAt.Valid {
+		dc.NextNoticeAt = &nextNoticeAt.Time
+	}
+	if suspendedAt.Valid {
+		dc.SuspendedAt = &suspendedAt.Time
+	}
+	if resolvedAt.Valid {
+		dc.ResolvedAt = &resolvedAt.Time
+	}
+
+	return dc, nil
+}
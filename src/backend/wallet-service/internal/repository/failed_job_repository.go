@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// Failed job repository errors
+var (
+	ErrFailedJobNotFound = errors.New("failed job not found")
+	// ErrFailedJobConflict is returned when a job changed status since it
+	// was read
+	ErrFailedJobConflict = errors.New("failed job is not in the expected state")
+)
+
+// failedJobColumns are the columns scanned by scanFailedJob
+const failedJobColumns = `id, kind, reference, payload, status, attempts, max_attempts, last_error, next_attempt_at,
+            created_at, updated_at, dead_at, resolved_at, COALESCE(resolved_by, ''), COALESCE(resolution, '')`
+
+// FailedJobRepository defines the interface for failed background job
+// persistence
+type FailedJobRepository interface {
+	// RecordFailedJob stores a failed job, reporting false when a job of
+	// the same reference is already open
+	RecordFailedJob(ctx context.Context, job *models.FailedJob) (bool, error)
+	GetFailedJob(ctx context.Context, id uuid.UUID) (*models.FailedJob, error)
+	ListFailedJobs(ctx context.Context, filter models.FailedJobFilter) ([]*models.FailedJob, error)
+	// ListDueFailedJobs lists the retrying jobs due by now, earliest first
+	ListDueFailedJobs(ctx context.Context, now time.Time, limit int) ([]*models.FailedJob, error)
+	// UpdateFailedJob saves a job read with the given status, returning
+	// ErrFailedJobConflict when its status changed meanwhile
+	UpdateFailedJob(ctx context.Context, job *models.FailedJob, from models.FailedJobStatus) error
+	Close() error
+}
+
+// failedJobRepository implements FailedJobRepository interface
+type failedJobRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewFailedJobRepository creates a new instance of FailedJobRepository
+func NewFailedJobRepository(db *sql.DB) (FailedJobRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &failedJobRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *failedJobRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	recordFailedJobQuery = namedQuery{name: "recordFailedJob", sql: `
+            INSERT INTO failed_jobs (
+                id, kind, reference, payload, status, attempts, max_attempts, last_error,
+                next_attempt_at, created_at, updated_at, dead_at
+            ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10, $11)
+            ON CONFLICT (kind, reference) WHERE status IN ('RETRYING', 'DEAD') DO NOTHING`}
+
+	getFailedJobQuery = namedQuery{name: "getFailedJob", sql: `
+            SELECT ` + failedJobColumns + `
+            FROM failed_jobs
+            WHERE id = $1`}
+
+	listFailedJobsQuery = namedQuery{name: "listFailedJobs", sql: `
+            SELECT ` + failedJobColumns + `
+            FROM failed_jobs
+            WHERE ($1 = '' OR kind = $1) AND ($2 = '' OR status = $2)
+            ORDER BY created_at DESC
+            LIMIT $3`}
+
+	listDueFailedJobsQuery = namedQuery{name: "listDueFailedJobs", sql: `
+            SELECT ` + failedJobColumns + `
+            FROM failed_jobs
+            WHERE status = 'RETRYING' AND next_attempt_at <= $1
+            ORDER BY next_attempt_at, id
+            LIMIT $2`}
+
+	updateFailedJobQuery = namedQuery{name: "updateFailedJob", sql: `
+            UPDATE failed_jobs
+            SET status = $3, attempts = $4, max_attempts = $5, last_error = $6, next_attempt_at = $7,
+                updated_at = $8, dead_at = $9, resolved_at = $10, resolved_by = NULLIF($11, ''),
+                resolution = NULLIF($12, '')
+            WHERE id = $1 AND status = $2`}
+)
+
+// RecordFailedJob stores a failed job unless its reference is already open
+func (r *failedJobRepository) RecordFailedJob(ctx context.Context, job *models.FailedJob) (bool, error) {
+	result, err := r.statements.ExecContext(ctx, recordFailedJobQuery,
+		job.ID,
+		job.Kind,
+		job.Reference,
+		[]byte(job.Payload),
+		job.Status,
+		job.Attempts,
+		job.MaxAttempts,
+		job.LastError,
+		job.NextAttemptAt,
+		job.CreatedAt,
+		job.DeadAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record failed job: %w", err)
+	}
+
+	recorded, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return recorded > 0, nil
+}
+
+// GetFailedJob retrieves a failed job by ID
+func (r *failedJobRepository) GetFailedJob(ctx context.Context, id uuid.UUID) (*models.FailedJob, error) {
+	job, err := scanFailedJob(r.statements.QueryRowContext(ctx, getFailedJobQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFailedJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get failed job: %w", err)
+	}
+	return job, nil
+}
+
+// ListFailedJobs retrieves the latest failed jobs matching the filter,
+// newest first
+func (r *failedJobRepository) ListFailedJobs(ctx context.Context, filter models.FailedJobFilter) ([]*models.FailedJob, error) {
+	return r.query(ctx, listFailedJobsQuery, string(filter.Kind), string(filter.Status), filter.Limit)
+}
+
+// ListDueFailedJobs retrieves the retrying jobs due by now
+func (r *failedJobRepository) ListDueFailedJobs(ctx context.Context, now time.Time, limit int) ([]*models.FailedJob, error) {
+	return r.query(ctx, listDueFailedJobsQuery, now, limit)
+}
+
+// UpdateFailedJob saves the mutable fields of a job still in status from
+func (r *failedJobRepository) UpdateFailedJob(ctx context.Context, job *models.FailedJob, from models.FailedJobStatus) error {
+	result, err := r.statements.ExecContext(ctx, updateFailedJobQuery,
+		job.ID,
+		from,
+		job.Status,
+		job.Attempts,
+		job.MaxAttempts,
+		job.LastError,
+		job.NextAttemptAt,
+		job.UpdatedAt,
+		job.DeadAt,
+		job.ResolvedAt,
+		job.ResolvedBy,
+		job.Resolution,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update failed job: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if updated == 0 {
+		if _, err := r.GetFailedJob(ctx, job.ID); err != nil {
+			return err
+		}
+		return ErrFailedJobConflict
+	}
+	return nil
+}
+
+// query runs a statement selecting failedJobColumns
+func (r *failedJobRepository) query(ctx context.Context, q namedQuery, args ...interface{}) ([]*models.FailedJob, error) {
+	rows, err := r.statements.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*models.FailedJob
+	for rows.Next() {
+		job, err := scanFailedJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan failed job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating failed jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// scanFailedJob scans a failed job row selected with failedJobColumns
+func scanFailedJob(row rowScanner) (*models.FailedJob, error) {
+	var job models.FailedJob
+	var payload []byte
+	err := row.Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Reference,
+		&payload,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.LastError,
+		&job.NextAttemptAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.DeadAt,
+		&job.ResolvedAt,
+		&job.ResolvedBy,
+		&job.Resolution,
+	)
+	if err != nil {
+		return nil, err
+	}
+	job.Payload = payload
+	return &job, nil
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+)
+
+// Fee rule repository errors
+var (
+	ErrFeeRuleNotFound = errors.New("fee rule not found")
+	// ErrFeeRuleConflict is returned when a rule is ended before it starts
+	// or after it already ended
+	ErrFeeRuleConflict = errors.New("fee rule cannot be ended at that time")
+)
+
+// feeRuleColumns are the columns scanned by scanFeeRule
+const feeRuleColumns = `id, COALESCE(source, ''), COALESCE(currency, ''), fixed_amount, percentage,
+            min_fee, max_fee, effective_from, effective_until, reason, created_by, created_at`
+
+// FeeRuleRepository defines the interface for fee rule persistence
+type FeeRuleRepository interface {
+	CreateFeeRule(ctx context.Context, rule *models.FeeRule) error
+	GetFeeRule(ctx context.Context, id uuid.UUID) (*models.FeeRule, error)
+	ListFeeRules(ctx context.Context, limit int) ([]*models.FeeRule, error)
+	// ListFeeRulesAt lists the rules matching a source and currency that
+	// are in effect at a time
+	ListFeeRulesAt(ctx context.Context, source, currency string, at time.Time) ([]*models.FeeRule, error)
+	EndFeeRule(ctx context.Context, id uuid.UUID, until time.Time) (*models.FeeRule, error)
+	Close() error
+}
+
+// feeRuleRepository implements FeeRuleRepository interface
+type feeRuleRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewFeeRuleRepository creates a new instance of FeeRuleRepository
+func NewFeeRuleRepository(db *sql.DB) (FeeRuleRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &feeRuleRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *feeRuleRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	createFeeRuleQuery = namedQuery{name: "createFeeRule", sql: `
+            INSERT INTO fee_rules (
+                id, source, currency, fixed_amount, percentage, min_fee, max_fee,
+                effective_from, effective_until, reason, created_by, created_at
+            ) VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10, $11, $12)`}
+
+	getFeeRuleQuery = namedQuery{name: "getFeeRule", sql: `
+            SELECT ` + feeRuleColumns + `
+            FROM fee_rules
+            WHERE id = $1`}
+
+	listFeeRulesQuery = namedQuery{name: "listFeeRules", sql: `
+            SELECT ` + feeRuleColumns + `
+            FROM fee_rules
+            ORDER BY created_at DESC
+            LIMIT $1`}
+
+	listFeeRulesAtQuery = namedQuery{name: "listFeeRulesAt", sql: `
+            SELECT ` + feeRuleColumns + `
+            FROM fee_rules
+            WHERE (source IS NULL OR source = $1)
+              AND (currency IS NULL OR currency = $2)
+              AND effective_from <= $3
+              AND (effective_until IS NULL OR effective_until > $3)`}
+
+	endFeeRuleQuery = namedQuery{name: "endFeeRule", sql: `
+            UPDATE fee_rules
+            SET effective_until = $2
+            WHERE id = $1 AND effective_from < $2
+              AND (effective_until IS NULL OR effective_until > $2)
+            RETURNING ` + feeRuleColumns}
+)
+
+// CreateFeeRule stores a fee rule
+func (r *feeRuleRepository) CreateFeeRule(ctx context.Context, rule *models.FeeRule) error {
+	_, err := r.statements.ExecContext(ctx, createFeeRuleQuery,
+		rule.ID,
+		rule.Source,
+		rule.Currency,
+		rule.FixedAmount,
+		rule.Percentage,
+		nullDecimal(rule.MinFee),
+		nullDecimal(rule.MaxFee),
+		rule.EffectiveFrom,
+		rule.EffectiveUntil,
+		rule.Reason,
+		rule.CreatedBy,
+		rule.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create fee rule: %w", err)
+	}
+	return nil
+}
+
+// GetFeeRule retrieves a fee rule by ID
+func (r *feeRuleRepository) GetFeeRule(ctx context.Context, id uuid.UUID) (*models.FeeRule, error) {
+	rule, err := scanFeeRule(r.statements.QueryRowContext(ctx, getFeeRuleQuery, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrFeeRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee rule: %w", err)
+	}
+	return rule, nil
+}
+
+// ListFeeRules retrieves the latest fee rules, newest first
+func (r *feeRuleRepository) ListFeeRules(ctx context.Context, limit int) ([]*models.FeeRule, error) {
+	return r.queryFeeRules(ctx, listFeeRulesQuery, limit)
+}
+
+// ListFeeRulesAt retrieves the rules matching a source and currency that are
+// in effect at a time
+func (r *feeRuleRepository) ListFeeRulesAt(ctx context.Context, source, currency string, at time.Time) ([]*models.FeeRule, error) {
+	return r.queryFeeRules(ctx, listFeeRulesAtQuery, source, currency, at)
+}
+
+// EndFeeRule stops a rule from applying from a time on. ErrFeeRuleConflict
+// is returned when the rule starts at or after that time or already ended
+// before it.
+func (r *feeRuleRepository) EndFeeRule(ctx context.Context, id uuid.UUID, until time.Time) (*models.FeeRule, error) {
+	rule, err := scanFeeRule(r.statements.QueryRowContext(ctx, endFeeRuleQuery, id, until))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := r.GetFeeRule(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrFeeRuleConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to end fee rule: %w", err)
+	}
+	return rule, nil
+}
+
+// queryFeeRules runs a statement selecting feeRuleColumns
+func (r *feeRuleRepository) queryFeeRules(ctx context.Context, q namedQuery, args ...interface{}) ([]*models.FeeRule, error) {
+	rows, err := r.statements.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fee rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*models.FeeRule
+	for rows.Next() {
+		rule, err := scanFeeRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fee rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating fee rules: %w", err)
+	}
+	return rules, nil
+}
+
+// scanFeeRule scans a row of feeRuleColumns
+func scanFeeRule(row rowScanner) (*models.FeeRule, error) {
+	var rule models.FeeRule
+	var minFee, maxFee decimal.NullDecimal
+	err := row.Scan(
+		&rule.ID,
+		&rule.Source,
+		&rule.Currency,
+		&rule.FixedAmount,
+		&rule.Percentage,
+		&minFee,
+		&maxFee,
+		&rule.EffectiveFrom,
+		&rule.EffectiveUntil,
+		&rule.Reason,
+		&rule.CreatedBy,
+		&rule.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if minFee.Valid {
+		rule.MinFee = &minFee.Decimal
+	}
+	if maxFee.Valid {
+		rule.MaxFee = &maxFee.Decimal
+	}
+	return &rule, nil
+}
+
+// nullDecimal converts an optional amount to a nullable column value
+func nullDecimal(amount *decimal.Decimal) decimal.NullDecimal {
+	if amount == nil {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NullDecimal{Decimal: *amount, Valid: true}
+}
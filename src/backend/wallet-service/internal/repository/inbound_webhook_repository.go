@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// Inbound webhook repository errors
+var (
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	ErrDeadLetterReplayed = errors.New("dead letter already replayed")
+)
+
+// inboundWebhookColumns is the column list scanned by scanInboundWebhook
+const inboundWebhookColumns = `id, provider, event_id, event_type, ordering_key, status, payload, attempts,
+                               COALESCE(last_error, ''), next_attempt_at, received_at, processed_at`
+
+// deadLetterColumns is the column list scanned by scanDeadLetter
+const deadLetterColumns = `d.id, d.webhook_id, w.provider, w.event_id, w.event_type, w.payload, d.attempts, d.error,
+                           d.dead_lettered_at, d.replayed_at`
+
+// InboundWebhookRepository defines the interface for the webhooks received
+// from external providers and their dead letters
+type InboundWebhookRepository interface {
+	// RecordInboundWebhook stores a received webhook, reporting false when
+	// its provider already delivered the event
+	RecordInboundWebhook(ctx context.Context, webhook *models.InboundWebhook) (bool, error)
+	// ListReceivedInboundWebhooks lists up to limit webhooks awaiting
+	// processing, in the order received
+	ListReceivedInboundWebhooks(ctx context.Context, limit int) ([]*models.InboundWebhook, error)
+	// MarkInboundWebhookProcessed records that a webhook was processed
+	MarkInboundWebhookProcessed(ctx context.Context, id uuid.UUID, now time.Time) error
+	// RescheduleInboundWebhook counts a failed attempt and schedules the next
+	RescheduleInboundWebhook(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, lastError string) error
+	// DeadLetterInboundWebhook counts a last failed attempt and moves the
+	// webhook to the dead letters
+	DeadLetterInboundWebhook(ctx context.Context, id uuid.UUID, lastError string, now time.Time) error
+	// ListDeadLetters lists up to limit dead letters, newest first,
+	// optionally of one provider
+	ListDeadLetters(ctx context.Context, provider string, limit int) ([]*models.InboundDeadLetter, error)
+	// ReplayDeadLetter returns a dead-lettered webhook to processing
+	ReplayDeadLetter(ctx context.Context, id uuid.UUID, now time.Time) (*models.InboundDeadLetter, error)
+	Close() error
+}
+
+// inboundWebhookRepository implements InboundWebhookRepository interface
+type inboundWebhookRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewInboundWebhookRepository creates a new instance of
+// InboundWebhookRepository
+func NewInboundWebhookRepository(db *sql.DB) (InboundWebhookRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &inboundWebhookRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *inboundWebhookRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	inboundWebhookRecordQuery = namedQuery{name: "inboundWebhookRecord", sql: `
+            INSERT INTO inbound_webhooks (id, provider, event_id, event_type, ordering_key, payload,
+                                          next_attempt_at, received_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+            ON CONFLICT (provider, event_id) DO NOTHING`}
+
+	listReceivedQuery = namedQuery{name: "listReceived", sql: `
+            SELECT ` + inboundWebhookColumns + `
+            FROM inbound_webhooks
+            WHERE status = 'RECEIVED'
+            ORDER BY received_at, id
+            LIMIT $1`}
+
+	markProcessedQuery = namedQuery{name: "markProcessed", sql: `
+            UPDATE inbound_webhooks
+            SET status = 'PROCESSED', attempts = attempts + 1, last_error = NULL, processed_at = $2
+            WHERE id = $1 AND status = 'RECEIVED'`}
+
+	inboundWebhookRescheduleQuery = namedQuery{name: "inboundWebhookReschedule", sql: `
+            UPDATE inbound_webhooks
+            SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3
+            WHERE id = $1 AND status = 'RECEIVED'`}
+
+	deadLetterQuery = namedQuery{name: "deadLetter", sql: `
+            WITH moved AS (
+                UPDATE inbound_webhooks
+                SET status = 'DEAD_LETTERED', attempts = attempts + 1, last_error = $2
+                WHERE id = $1 AND status = 'RECEIVED'
+                RETURNING id, attempts
+            )
+            INSERT INTO inbound_webhook_dead_letters (id, webhook_id, attempts, error, dead_lettered_at)
+            SELECT $3, id, attempts, $2, $4 FROM moved`}
+
+	listDeadLettersQuery = namedQuery{name: "listDeadLetters", sql: `
+            SELECT ` + deadLetterColumns + `
+            FROM inbound_webhook_dead_letters d
+            JOIN inbound_webhooks w ON w.id = d.webhook_id
+            WHERE $1 = '' OR w.provider = $1
+            ORDER BY d.dead_lettered_at DESC, d.id
+            LIMIT $2`}
+
+	getDeadLetterQuery = namedQuery{name: "getDeadLetter", sql: `
+            SELECT ` + deadLetterColumns + `
+            FROM inbound_webhook_dead_letters d
+            JOIN inbound_webhooks w ON w.id = d.webhook_id
+            WHERE d.id = $1`}
+
+	inboundWebhookReplayQuery = namedQuery{name: "inboundWebhookReplay", sql: `
+            WITH replayed AS (
+                UPDATE inbound_webhook_dead_letters
+                SET replayed_at = $2
+                WHERE id = $1 AND replayed_at IS NULL
+                RETURNING webhook_id
+            )
+            UPDATE inbound_webhooks w
+            SET status = 'RECEIVED', attempts = 0, last_error = NULL, next_attempt_at = $2
+            FROM replayed
+            WHERE w.id = replayed.webhook_id`}
+)
+
+// RecordInboundWebhook stores a webhook as received, assigning its ID. The
+// event ID is unique per provider, so a redelivered event is recorded once.
+func (r *inboundWebhookRepository) RecordInboundWebhook(ctx context.Context, webhook *models.InboundWebhook) (bool, error) {
+	webhook.ID = uuid.New()
+	webhook.Status = models.InboundWebhookReceived
+	webhook.NextAttemptAt = webhook.ReceivedAt
+
+	result, err := r.statements.ExecContext(ctx, inboundWebhookRecordQuery,
+		webhook.ID,
+		webhook.Provider,
+		webhook.EventID,
+		webhook.EventType,
+		webhook.OrderingKey,
+		[]byte(webhook.Payload),
+		webhook.ReceivedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record inbound webhook: %w", err)
+	}
+
+	recorded, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get recorded count: %w", err)
+	}
+
+	return recorded > 0, nil
+}
+
+// ListReceivedInboundWebhooks retrieves the webhooks awaiting processing,
+// due or not, so later events of an ordering key wait behind a retried one
+func (r *inboundWebhookRepository) ListReceivedInboundWebhooks(ctx context.Context, limit int) ([]*models.InboundWebhook, error) {
+	rows, err := r.statements.QueryContext(ctx, listReceivedQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inbound webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []*models.InboundWebhook{}
+	for rows.Next() {
+		webhook, err := scanInboundWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inbound webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// MarkInboundWebhookProcessed records that a received webhook was processed
+func (r *inboundWebhookRepository) MarkInboundWebhookProcessed(ctx context.Context, id uuid.UUID, now time.Time) error {
+	if _, err := r.statements.ExecContext(ctx, markProcessedQuery, id, now); err != nil {
+		return fmt.Errorf("failed to mark inbound webhook processed: %w", err)
+	}
+	return nil
+}
+
+// RescheduleInboundWebhook records a failed attempt of a received webhook
+func (r *inboundWebhookRepository) RescheduleInboundWebhook(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	if _, err := r.statements.ExecContext(ctx, inboundWebhookRescheduleQuery, id, nextAttemptAt, lastError); err != nil {
+		return fmt.Errorf("failed to reschedule inbound webhook: %w", err)
+	}
+	return nil
+}
+
+// DeadLetterInboundWebhook moves a received webhook to the dead letters in
+// one statement, so it is never left in both or neither
+func (r *inboundWebhookRepository) DeadLetterInboundWebhook(ctx context.Context, id uuid.UUID, lastError string, now time.Time) error {
+	if _, err := r.statements.ExecContext(ctx, deadLetterQuery, id, lastError, uuid.New(), now); err != nil {
+		return fmt.Errorf("failed to dead-letter inbound webhook: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters retrieves the latest dead letters with their webhooks
+func (r *inboundWebhookRepository) ListDeadLetters(ctx context.Context, provider string, limit int) ([]*models.InboundDeadLetter, error) {
+	rows, err := r.statements.QueryContext(ctx, listDeadLettersQuery, provider, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	letters := []*models.InboundDeadLetter{}
+	for rows.Next() {
+		letter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dead letters: %w", err)
+	}
+
+	return letters, nil
+}
+
+// ReplayDeadLetter marks a dead letter replayed and returns its webhook to
+// processing with its attempts reset
+func (r *inboundWebhookRepository) ReplayDeadLetter(ctx context.Context, id uuid.UUID, now time.Time) (*models.InboundDeadLetter, error) {
+	result, err := r.statements.ExecContext(ctx, inboundWebhookReplayQuery, id, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay dead letter: %w", err)
+	}
+	replayed, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replayed count: %w", err)
+	}
+
+	letter, err := scanDeadLetter(r.statements.QueryRowContext(ctx, getDeadLetterQuery, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if replayed == 0 {
+		return nil, ErrDeadLetterReplayed
+	}
+
+	return letter, nil
+}
+
+// scanInboundWebhook scans a row of inboundWebhookColumns
+func scanInboundWebhook(row rowScanner) (*models.InboundWebhook, error) {
+	webhook := &models.InboundWebhook{}
+	var (
+		payload     []byte
+		processedAt sql.NullTime
+	)
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.Provider,
+		&webhook.EventID,
+		&webhook.EventType,
+		&webhook.OrderingKey,
+		&webhook.Status,
+		&payload,
+		&webhook.Attempts,
+		&webhook.LastError,
+		&webhook.NextAttemptAt,
+		&webhook.ReceivedAt,
+		&processedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan inbound webhook: %w", err)
+	}
+	webhook.Payload = payload
+	if processedAt.Valid {
+		webhook.ProcessedAt = &processedAt.Time
+	}
+
+	return webhook, nil
+}
+
+// scanDeadLetter scans a row of deadLetterColumns
+func scanDeadLetter(row rowScanner) (*models.InboundDeadLetter, error) {
+	letter := &models.InboundDeadLetter{}
+	var (
+		payload    []byte
+		replayedAt sql.NullTime
+	)
+	err := row.Scan(
+		&letter.ID,
+		&letter.WebhookID,
+		&letter.Provider,
+		&letter.EventID,
+		&letter.EventType,
+		&payload,
+		&letter.Attempts,
+		&letter.Error,
+		&letter.DeadLetteredAt,
+		&replayedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+	}
+	letter.Payload = payload
+	if replayedAt.Valid {
+		letter.ReplayedAt = &replayedAt.Time
+	}
+
+	return letter, nil
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"         // v1.3.0
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+
+	"internal/models"
+)
+
+// Incident errors
+var (
+	ErrIncidentNotFound = errors.New("no incident is active")
+	ErrIncidentConflict = errors.New("an incident is already active")
+)
+
+// IncidentRepository defines the interface for incident switches
+type IncidentRepository interface {
+	// GetActiveIncident retrieves the incident not yet resumed
+	GetActiveIncident(ctx context.Context) (*models.Incident, error)
+	// StartIncident stores a new active incident, failing while another one
+	// is active
+	StartIncident(ctx context.Context, incident *models.Incident) error
+	// UpdateIncident changes what an active incident pauses
+	UpdateIncident(ctx context.Context, incident *models.Incident) error
+	// ResumeIncident resumes an active incident, once only
+	ResumeIncident(ctx context.Context, id uuid.UUID, actor string, now time.Time) (*models.Incident, error)
+	Close() error
+}
+
+// incidentRepository implements IncidentRepository interface
+type incidentRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewIncidentRepository creates a new instance of IncidentRepository
+func NewIncidentRepository(db *sql.DB) (IncidentRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &incidentRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *incidentRepository) Close() error {
+	return r.statements.Close()
+}
+
+const incidentColumns = `id, reason, pause_debits, pause_credits, pause_refunds, pause_adjustments, allowed_sources,
+            resume_at, started_by, started_at, updated_by, updated_at, COALESCE(resumed_by, ''), resumed_at`
+
+var (
+	getActiveQuery = namedQuery{name: "getActive", sql: `
+            SELECT ` + incidentColumns + `
+            FROM incidents
+            WHERE resumed_at IS NULL`}
+
+	incidentStartQuery = namedQuery{name: "incidentStart", sql: `
+            INSERT INTO incidents (id, reason, pause_debits, pause_credits, pause_refunds, pause_adjustments,
+                allowed_sources, resume_at, started_by, started_at, updated_by, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`}
+
+	incidentUpdateQuery = namedQuery{name: "incidentUpdate", sql: `
+            UPDATE incidents
+            SET reason = $2, pause_debits = $3, pause_credits = $4, pause_refunds = $5, pause_adjustments = $6,
+                allowed_sources = $7, resume_at = $8, updated_by = $9, updated_at = $10
+            WHERE id = $1 AND resumed_at IS NULL`}
+
+	incidentResumeQuery = namedQuery{name: "incidentResume", sql: `
+            UPDATE incidents
+            SET resumed_by = $2, resumed_at = $3
+            WHERE id = $1 AND resumed_at IS NULL
+            RETURNING ` + incidentColumns}
+)
+
+// scanIncident scans an incident row
+func scanIncident(row rowScanner) (*models.Incident, error) {
+	var incident models.Incident
+	var resumeAt, resumedAt sql.NullTime
+	err := row.Scan(
+		&incident.ID,
+		&incident.Reason,
+		&incident.PauseDebits,
+		&incident.PauseCredits,
+		&incident.PauseRefunds,
+		&incident.PauseAdjustments,
+		pgArray(&incident.AllowedSources),
+		&resumeAt,
+		&incident.StartedBy,
+		&incident.StartedAt,
+		&incident.UpdatedBy,
+		&incident.UpdatedAt,
+		&incident.ResumedBy,
+		&resumedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if resumeAt.Valid {
+		incident.ResumeAt = &resumeAt.Time
+	}
+	if resumedAt.Valid {
+		incident.ResumedAt = &resumedAt.Time
+	}
+	return &incident, nil
+}
+
+// GetActiveIncident retrieves the incident not yet resumed
+func (r *incidentRepository) GetActiveIncident(ctx context.Context) (*models.Incident, error) {
+	incident, err := scanIncident(r.statements.QueryRowContext(ctx, getActiveQuery))
+	if err == sql.ErrNoRows {
+		return nil, ErrIncidentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active incident: %w", err)
+	}
+	return incident, nil
+}
+
+// StartIncident stores a new active incident. The unique index on active
+// incidents makes starting one while another is active a conflict.
+func (r *incidentRepository) StartIncident(ctx context.Context, incident *models.Incident) error {
+	_, err := r.statements.ExecContext(ctx, incidentStartQuery,
+		incident.ID,
+		incident.Reason,
+		incident.PauseDebits,
+		incident.PauseCredits,
+		incident.PauseRefunds,
+		incident.PauseAdjustments,
+		incident.AllowedSources,
+		incident.ResumeAt,
+		incident.StartedBy,
+		incident.StartedAt,
+		incident.UpdatedBy,
+		incident.UpdatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrIncidentConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to start incident: %w", err)
+	}
+	return nil
+}
+
+// UpdateIncident changes what an active incident pauses. An incident
+// resumed meanwhile is not found.
+func (r *incidentRepository) UpdateIncident(ctx context.Context, incident *models.Incident) error {
+	result, err := r.statements.ExecContext(ctx, incidentUpdateQuery,
+		incident.ID,
+		incident.Reason,
+		incident.PauseDebits,
+		incident.PauseCredits,
+		incident.PauseRefunds,
+		incident.PauseAdjustments,
+		incident.AllowedSources,
+		incident.ResumeAt,
+		incident.UpdatedBy,
+		incident.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update incident: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get updated count: %w", err)
+	}
+	if updated == 0 {
+		return ErrIncidentNotFound
+	}
+	return nil
+}
+
+// ResumeIncident resumes an active incident. An incident already resumed,
+// by an operator or by another replica applying its schedule, is not found.
+func (r *incidentRepository) ResumeIncident(ctx context.Context, id uuid.UUID, actor string, now time.Time) (*models.Incident, error) {
+	incident, err := scanIncident(r.statements.QueryRowContext(ctx, incidentResumeQuery, id, actor, now))
+	if err == sql.ErrNoRows {
+		return nil, ErrIncidentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume incident: %w", err)
+	}
+	return incident, nil
+}
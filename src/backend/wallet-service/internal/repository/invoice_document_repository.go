@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"         // v1.3.0
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+
+	"internal/models"
+)
+
+// Invoice document errors
+var (
+	// ErrInvoiceDocumentNotFound is returned when no PDF is stored for an
+	// invoice
+	ErrInvoiceDocumentNotFound = errors.New("invoice document not found")
+	// ErrInvoiceDocumentExists is returned when a PDF is already stored for
+	// the invoice or for its number
+	ErrInvoiceDocumentExists = errors.New("invoice document already exists")
+)
+
+// InvoiceDocumentRepository defines the interface for stored invoice PDFs
+type InvoiceDocumentRepository interface {
+	CreateInvoiceDocument(ctx context.Context, doc *models.InvoicePDF, pdf []byte) error
+	GetInvoiceDocument(ctx context.Context, invoiceID uuid.UUID) (*models.InvoicePDF, []byte, error)
+	RecordInvoiceDelivery(ctx context.Context, invoiceID uuid.UUID, at time.Time) (*models.InvoicePDF, error)
+	Close() error
+}
+
+// invoiceDocumentRepository implements InvoiceDocumentRepository interface
+type invoiceDocumentRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewInvoiceDocumentRepository creates a new instance of
+// InvoiceDocumentRepository
+func NewInvoiceDocumentRepository(db *sql.DB) (InvoiceDocumentRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &invoiceDocumentRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *invoiceDocumentRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	invoiceDocumentCreateQuery = namedQuery{name: "invoiceDocumentCreate", sql: `
+            INSERT INTO invoice_documents (invoice_id, customer_id, invoice_number, currency, total_amount, due_date,
+                                           pdf, rendered_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`}
+
+	invoiceDocumentGetQuery = namedQuery{name: "invoiceDocumentGet", sql: `
+            SELECT invoice_id, customer_id, invoice_number, currency, total_amount, due_date, pdf, rendered_at,
+                   deliveries, last_delivered_at
+            FROM invoice_documents
+            WHERE invoice_id = $1`}
+
+	invoiceDocumentRecordDeliveryQuery = namedQuery{name: "invoiceDocumentRecordDelivery", sql: `
+            UPDATE invoice_documents
+            SET deliveries = deliveries + 1, last_delivered_at = $2
+            WHERE invoice_id = $1
+            RETURNING invoice_id, customer_id, invoice_number, currency, total_amount, due_date, octet_length(pdf),
+                      rendered_at, deliveries, last_delivered_at`}
+)
+
+// CreateInvoiceDocument stores the PDF of an issued invoice
+func (r *invoiceDocumentRepository) CreateInvoiceDocument(ctx context.Context, doc *models.InvoicePDF, pdf []byte) error {
+	_, err := r.statements.ExecContext(ctx, invoiceDocumentCreateQuery,
+		doc.InvoiceID,
+		doc.CustomerID,
+		doc.InvoiceNumber,
+		doc.Currency,
+		doc.TotalAmount,
+		doc.DueDate,
+		pdf,
+		doc.RenderedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrInvoiceDocumentExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create invoice document: %w", err)
+	}
+
+	doc.SizeBytes = len(pdf)
+	return nil
+}
+
+// GetInvoiceDocument retrieves an invoice's stored PDF
+func (r *invoiceDocumentRepository) GetInvoiceDocument(ctx context.Context, invoiceID uuid.UUID) (*models.InvoicePDF, []byte, error) {
+	doc := &models.InvoicePDF{}
+	var pdf []byte
+	err := r.statements.QueryRowContext(ctx, invoiceDocumentGetQuery, invoiceID).Scan(
+		&doc.InvoiceID,
+		&doc.CustomerID,
+		&doc.InvoiceNumber,
+		&doc.Currency,
+		&doc.TotalAmount,
+		&doc.DueDate,
+		&pdf,
+		&doc.RenderedAt,
+		&doc.Deliveries,
+		&doc.LastDeliveredAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrInvoiceDocumentNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get invoice document: %w", err)
+	}
+
+	doc.SizeBytes = len(pdf)
+	return doc, pdf, nil
+}
+
+// RecordInvoiceDelivery counts a delivery of an invoice to the customer
+func (r *invoiceDocumentRepository) RecordInvoiceDelivery(ctx context.Context, invoiceID uuid.UUID, at time.Time) (*models.InvoicePDF, error) {
+	doc := &models.InvoicePDF{}
+	err := r.statements.QueryRowContext(ctx, invoiceDocumentRecordDeliveryQuery, invoiceID, at).Scan(
+		&doc.InvoiceID,
+		&doc.CustomerID,
+		&doc.InvoiceNumber,
+		&doc.Currency,
+		&doc.TotalAmount,
+		&doc.DueDate,
+		&doc.SizeBytes,
+		&doc.RenderedAt,
+		&doc.Deliveries,
+		&doc.LastDeliveredAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvoiceDocumentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record invoice delivery: %w", err)
+	}
+
+	return doc, nil
+}
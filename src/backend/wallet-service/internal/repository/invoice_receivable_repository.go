@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+)
+
+// Invoice receivable errors
+var (
+	// ErrInvoiceReceivableNotFound is returned when an invoice is not
+	// tracked, or was paid in full when updating its late charges
+	ErrInvoiceReceivableNotFound = errors.New("invoice receivable not found")
+	// ErrInvoiceOverpaid is returned when a payment exceeds what is left
+	// to pay of an invoice
+	ErrInvoiceOverpaid = errors.New("payment exceeds the amount outstanding")
+)
+
+// InvoiceReceivableRepository defines the interface for tracking issued
+// invoices until they are paid
+type InvoiceReceivableRepository interface {
+	// CreateInvoiceReceivable starts tracking an invoice. It reports false
+	// with the tracked invoice when the invoice was tracked already.
+	CreateInvoiceReceivable(ctx context.Context, receivable *models.InvoiceReceivable) (*models.InvoiceReceivable, bool, error)
+	GetInvoiceReceivable(ctx context.Context, invoiceID uuid.UUID) (*models.InvoiceReceivable, error)
+	// ListUnpaidInvoiceReceivables lists the invoices not paid in full,
+	// earliest due first, of a currency and customer when set
+	ListUnpaidInvoiceReceivables(ctx context.Context, currency string, customerID uuid.UUID) ([]*models.InvoiceReceivable, error)
+	// UpdateInvoiceLateCharges stores the status, late fee and interest of
+	// an unpaid invoice
+	UpdateInvoiceLateCharges(ctx context.Context, receivable *models.InvoiceReceivable) (*models.InvoiceReceivable, error)
+	// RecordInvoicePayment adds a payment to an invoice, marking it paid
+	// when nothing is left to pay
+	RecordInvoicePayment(ctx context.Context, invoiceID uuid.UUID, amount decimal.Decimal, paidAt time.Time) (*models.InvoiceReceivable, error)
+	Close() error
+}
+
+// invoiceReceivableColumns are the columns scanned by scanInvoiceReceivable
+const invoiceReceivableColumns = `invoice_id, customer_id, invoice_number, currency, total_amount, due_date,
+                   payment_terms, status, amount_paid, paid_at, late_fee, interest, interest_through,
+                   created_at, updated_at`
+
+// invoiceReceivableRepository implements InvoiceReceivableRepository
+// interface
+type invoiceReceivableRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewInvoiceReceivableRepository creates a new instance of
+// InvoiceReceivableRepository
+func NewInvoiceReceivableRepository(db *sql.DB) (InvoiceReceivableRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &invoiceReceivableRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *invoiceReceivableRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	invoiceReceivableCreateQuery = namedQuery{name: "invoiceReceivableCreate", sql: `
+            INSERT INTO invoice_receivables (invoice_id, customer_id, invoice_number, currency, total_amount,
+                                             due_date, payment_terms, status, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+            ON CONFLICT (invoice_id) DO NOTHING
+            RETURNING ` + invoiceReceivableColumns}
+
+	invoiceReceivableGetQuery = namedQuery{name: "invoiceReceivableGet", sql: `
+            SELECT ` + invoiceReceivableColumns + `
+            FROM invoice_receivables
+            WHERE invoice_id = $1`}
+
+	listUnpaidQuery = namedQuery{name: "listUnpaid", sql: `
+            SELECT ` + invoiceReceivableColumns + `
+            FROM invoice_receivables
+            WHERE status <> 'PAID'
+              AND ($1 = '' OR currency = $1)
+              AND ($2::uuid IS NULL OR customer_id = $2)
+            ORDER BY due_date, invoice_id`}
+
+	updateLateChargesQuery = namedQuery{name: "updateLateCharges", sql: `
+            UPDATE invoice_receivables
+            SET status = $2,
+                late_fee = $3,
+                interest = $4,
+                interest_through = $5,
+                updated_at = $6
+            WHERE invoice_id = $1 AND status <> 'PAID'
+            RETURNING ` + invoiceReceivableColumns}
+
+	// Column references on the right are the values before the
+	// update, so the payment is checked against what was outstanding
+	invoiceReceivableRecordPaymentQuery = namedQuery{name: "invoiceReceivableRecordPayment", sql: `
+            UPDATE invoice_receivables
+            SET amount_paid = amount_paid + $2,
+                status = CASE WHEN amount_paid + $2 >= total_amount + late_fee + interest THEN 'PAID' ELSE status END,
+                paid_at = CASE WHEN amount_paid + $2 >= total_amount + late_fee + interest THEN $3 ELSE paid_at END,
+                updated_at = $4
+            WHERE invoice_id = $1 AND amount_paid + $2 <= total_amount + late_fee + interest
+            RETURNING ` + invoiceReceivableColumns}
+)
+
+// CreateInvoiceReceivable starts tracking an issued invoice
+func (r *invoiceReceivableRepository) CreateInvoiceReceivable(ctx context.Context, receivable *models.InvoiceReceivable) (*models.InvoiceReceivable, bool, error) {
+	created, err := scanInvoiceReceivable(r.statements.QueryRowContext(ctx, invoiceReceivableCreateQuery,
+		receivable.InvoiceID,
+		receivable.CustomerID,
+		receivable.InvoiceNumber,
+		receivable.Currency,
+		receivable.TotalAmount,
+		receivable.DueDate,
+		receivable.PaymentTerms,
+		receivable.Status,
+		receivable.CreatedAt,
+	))
+	if errors.Is(err, ErrInvoiceReceivableNotFound) {
+		existing, err := r.GetInvoiceReceivable(ctx, receivable.InvoiceID)
+		return existing, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create invoice receivable: %w", err)
+	}
+
+	return created, true, nil
+}
+
+// GetInvoiceReceivable retrieves a tracked invoice
+func (r *invoiceReceivableRepository) GetInvoiceReceivable(ctx context.Context, invoiceID uuid.UUID) (*models.InvoiceReceivable, error) {
+	return scanInvoiceReceivable(r.statements.QueryRowContext(ctx, invoiceReceivableGetQuery, invoiceID))
+}
+
+// ListUnpaidInvoiceReceivables lists the invoices not paid in full
+func (r *invoiceReceivableRepository) ListUnpaidInvoiceReceivables(ctx context.Context, currency string, customerID uuid.UUID) ([]*models.InvoiceReceivable, error) {
+	var customer *uuid.UUID
+	if customerID != uuid.Nil {
+		customer = &customerID
+	}
+
+	rows, err := r.statements.QueryContext(ctx, listUnpaidQuery, currency, customer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unpaid invoices: %w", err)
+	}
+	defer rows.Close()
+
+	var receivables []*models.InvoiceReceivable
+	for rows.Next() {
+		receivable, err := scanInvoiceReceivable(rows)
+		if err != nil {
+			return nil, err
+		}
+		receivables = append(receivables, receivable)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate unpaid invoices: %w", err)
+	}
+
+	return receivables, nil
+}
+
+// UpdateInvoiceLateCharges stores the status and late charges of an
+// unpaid invoice
+func (r *invoiceReceivableRepository) UpdateInvoiceLateCharges(ctx context.Context, receivable *models.InvoiceReceivable) (*models.InvoiceReceivable, error) {
+	return scanInvoiceReceivable(r.statements.QueryRowContext(ctx, updateLateChargesQuery,
+		receivable.InvoiceID,
+		receivable.Status,
+		receivable.LateFee,
+		receivable.Interest,
+		receivable.InterestThrough,
+		time.Now().UTC(),
+	))
+}
+
+// RecordInvoicePayment adds a payment to an invoice
+func (r *invoiceReceivableRepository) RecordInvoicePayment(ctx context.Context, invoiceID uuid.UUID, amount decimal.Decimal, paidAt time.Time) (*models.InvoiceReceivable, error) {
+	receivable, err := scanInvoiceReceivable(r.statements.QueryRowContext(ctx, invoiceReceivableRecordPaymentQuery,
+		invoiceID,
+		amount,
+		paidAt,
+		time.Now().UTC(),
+	))
+	if !errors.Is(err, ErrInvoiceReceivableNotFound) {
+		return receivable, err
+	}
+
+	if _, err := r.GetInvoiceReceivable(ctx, invoiceID); err != nil {
+		return nil, err
+	}
+	return nil, ErrInvoiceOverpaid
+}
+
+// scanInvoiceReceivable scans an invoice receivable row
+func scanInvoiceReceivable(row rowScanner) (*models.InvoiceReceivable, error) {
+	receivable := &models.InvoiceReceivable{}
+	err := row.Scan(
+		&receivable.InvoiceID,
+		&receivable.CustomerID,
+		&receivable.InvoiceNumber,
+		&receivable.Currency,
+		&receivable.TotalAmount,
+		&receivable.DueDate,
+		&receivable.PaymentTerms,
+		&receivable.Status,
+		&receivable.AmountPaid,
+		&receivable.PaidAt,
+		&receivable.LateFee,
+		&receivable.Interest,
+		&receivable.InterestThrough,
+		&receivable.CreatedAt,
+		&receivable.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvoiceReceivableNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan invoice receivable: %w", err)
+	}
+
+	return receivable, nil
+}
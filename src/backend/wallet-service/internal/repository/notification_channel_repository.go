@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"         // v1.3.0
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+	"github.com/shopspring/decimal"  // v1.3.1
+
+	"internal/models"
+)
+
+// Notification channel errors
+var (
+	// ErrNotificationChannelNotFound is returned when a notification channel
+	// does not exist or belongs to another customer
+	ErrNotificationChannelNotFound = errors.New("notification channel not found")
+	// ErrNotificationChannelExists is returned when the customer already has
+	// a channel of the kind to the destination
+	ErrNotificationChannelExists = errors.New("notification channel already exists")
+)
+
+// notificationChannelColumns is the column list scanned by
+// scanNotificationChannel
+const notificationChannelColumns = `id, customer_id, kind, destination, event_types, large_transaction_amount, enabled,
+                                    created_at, updated_at`
+
+// NotificationChannelRepository defines the interface for customer
+// notification channel persistence
+type NotificationChannelRepository interface {
+	CreateNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error
+	UpdateNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error
+	DeleteNotificationChannel(ctx context.Context, customerID, id uuid.UUID) error
+	GetNotificationChannel(ctx context.Context, customerID, id uuid.UUID) (*models.NotificationChannel, error)
+	ListNotificationChannels(ctx context.Context, customerID uuid.UUID) ([]*models.NotificationChannel, error)
+	ListActiveNotificationChannels(ctx context.Context, customerID uuid.UUID) ([]*models.NotificationChannel, error)
+	ListActiveNotificationChannelsForWallet(ctx context.Context, walletID uuid.UUID) ([]*models.NotificationChannel, error)
+	Close() error
+}
+
+// notificationChannelRepository implements NotificationChannelRepository
+// interface
+type notificationChannelRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewNotificationChannelRepository creates a new instance of
+// NotificationChannelRepository
+func NewNotificationChannelRepository(db *sql.DB) (NotificationChannelRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &notificationChannelRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *notificationChannelRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	notificationChannelCreateQuery = namedQuery{name: "notificationChannelCreate", sql: `
+            INSERT INTO notification_channels (id, customer_id, kind, destination, event_types,
+                                               large_transaction_amount, enabled, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)`}
+
+	notificationChannelUpdateQuery = namedQuery{name: "notificationChannelUpdate", sql: `
+            UPDATE notification_channels
+            SET destination = $3, event_types = $4, large_transaction_amount = $5, enabled = $6, updated_at = $7
+            WHERE id = $1 AND customer_id = $2
+            RETURNING kind, created_at`}
+
+	notificationChannelDeleteQuery = namedQuery{name: "notificationChannelDelete", sql: `
+            DELETE FROM notification_channels
+            WHERE id = $1 AND customer_id = $2`}
+
+	notificationChannelGetQuery = namedQuery{name: "notificationChannelGet", sql: `
+            SELECT ` + notificationChannelColumns + `
+            FROM notification_channels
+            WHERE id = $1 AND customer_id = $2`}
+
+	notificationChannelListQuery = namedQuery{name: "notificationChannelList", sql: `
+            SELECT ` + notificationChannelColumns + `
+            FROM notification_channels
+            WHERE customer_id = $1
+            ORDER BY created_at`}
+
+	listActiveQuery = namedQuery{name: "listActive", sql: `
+            SELECT ` + notificationChannelColumns + `
+            FROM notification_channels
+            WHERE enabled AND customer_id = $1`}
+
+	notificationChannelListActiveForWalletQuery = namedQuery{name: "notificationChannelListActiveForWallet", sql: `
+            SELECT ` + notificationChannelColumns + `
+            FROM notification_channels
+            WHERE enabled AND customer_id = (SELECT customer_id FROM wallets WHERE id = $1)`}
+)
+
+// CreateNotificationChannel stores a new notification channel
+func (r *notificationChannelRepository) CreateNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error {
+	channel.ID = uuid.New()
+	channel.CreatedAt = time.Now().UTC()
+	channel.UpdatedAt = channel.CreatedAt
+
+	_, err := r.statements.ExecContext(ctx, notificationChannelCreateQuery,
+		channel.ID,
+		channel.CustomerID,
+		channel.Kind,
+		channel.Destination,
+		channel.EventTypes,
+		channel.LargeTransactionAmount,
+		channel.Enabled,
+		channel.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrNotificationChannelExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create notification channel: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateNotificationChannel replaces the destination and rules of one of a
+// customer's channels. The kind of a channel is never changed.
+func (r *notificationChannelRepository) UpdateNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error {
+	channel.UpdatedAt = time.Now().UTC()
+
+	err := r.statements.QueryRowContext(ctx, notificationChannelUpdateQuery,
+		channel.ID,
+		channel.CustomerID,
+		channel.Destination,
+		channel.EventTypes,
+		channel.LargeTransactionAmount,
+		channel.Enabled,
+		channel.UpdatedAt,
+	).Scan(&channel.Kind, &channel.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrNotificationChannelExists
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotificationChannelNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update notification channel: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteNotificationChannel removes one of a customer's channels
+func (r *notificationChannelRepository) DeleteNotificationChannel(ctx context.Context, customerID, id uuid.UUID) error {
+	result, err := r.statements.ExecContext(ctx, notificationChannelDeleteQuery, id, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get deleted count: %w", err)
+	}
+	if deleted == 0 {
+		return ErrNotificationChannelNotFound
+	}
+
+	return nil
+}
+
+// GetNotificationChannel retrieves one of a customer's channels
+func (r *notificationChannelRepository) GetNotificationChannel(ctx context.Context, customerID, id uuid.UUID) (*models.NotificationChannel, error) {
+	channel, err := scanNotificationChannel(r.statements.QueryRowContext(ctx, notificationChannelGetQuery, id, customerID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotificationChannelNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+// ListNotificationChannels retrieves all of a customer's channels
+func (r *notificationChannelRepository) ListNotificationChannels(ctx context.Context, customerID uuid.UUID) ([]*models.NotificationChannel, error) {
+	return r.query(ctx, notificationChannelListQuery, customerID)
+}
+
+// ListActiveNotificationChannels retrieves a customer's enabled channels.
+// Event filters are applied by the caller.
+func (r *notificationChannelRepository) ListActiveNotificationChannels(ctx context.Context, customerID uuid.UUID) ([]*models.NotificationChannel, error) {
+	return r.query(ctx, listActiveQuery, customerID)
+}
+
+// ListActiveNotificationChannelsForWallet retrieves the enabled channels of
+// a wallet's owner, for events that only carry the wallet
+func (r *notificationChannelRepository) ListActiveNotificationChannelsForWallet(ctx context.Context, walletID uuid.UUID) ([]*models.NotificationChannel, error) {
+	return r.query(ctx, notificationChannelListActiveForWalletQuery, walletID)
+}
+
+// query runs a prepared channel query and scans every row
+func (r *notificationChannelRepository) query(ctx context.Context, q namedQuery, args ...interface{}) ([]*models.NotificationChannel, error) {
+	rows, err := r.statements.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification channels: %w", err)
+	}
+	defer rows.Close()
+
+	var channels []*models.NotificationChannel
+	for rows.Next() {
+		channel, err := scanNotificationChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification channels: %w", err)
+	}
+
+	return channels, nil
+}
+
+// scanNotificationChannel scans a channel selected with
+// notificationChannelColumns
+func scanNotificationChannel(row rowScanner) (*models.NotificationChannel, error) {
+	channel := &models.NotificationChannel{}
+	var largeAmount decimal.NullDecimal
+	err := row.Scan(
+		&channel.ID,
+		&channel.CustomerID,
+		&channel.Kind,
+		&channel.Destination,
+		pgArray(&channel.EventTypes),
+		&largeAmount,
+		&channel.Enabled,
+		&channel.CreatedAt,
+		&channel.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan notification channel: %w", err)
+	}
+
+	if largeAmount.Valid {
+		channel.LargeTransactionAmount = &largeAmount.Decimal
+	}
+	return channel, nil
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// queuedNotificationColumns is the column list scanned by
+// scanQueuedNotification
+const queuedNotificationColumns = `id, event_id, event_type, status, envelope, attempts, COALESCE(last_error, ''),
+                                   next_attempt_at, expires_at, created_at, updated_at`
+
+// NotificationQueueRepository defines the interface for the queue of
+// notifications awaiting redelivery to the notification pipeline
+type NotificationQueueRepository interface {
+	// EnqueueNotification stores a pending notification, reporting false
+	// when its event was already queued
+	EnqueueNotification(ctx context.Context, notification *models.QueuedNotification) (bool, error)
+	// ListDueNotifications lists up to limit pending notifications due for
+	// another attempt and not yet expired, longest due first
+	ListDueNotifications(ctx context.Context, now time.Time, limit int) ([]*models.QueuedNotification, error)
+	// DeleteNotification removes a delivered notification
+	DeleteNotification(ctx context.Context, id uuid.UUID) error
+	// RescheduleNotification counts a failed attempt and schedules the next
+	RescheduleNotification(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, lastError string) error
+	// ExpireNotifications marks the pending notifications expired by now
+	ExpireNotifications(ctx context.Context, now time.Time) (int64, error)
+	// GetNotificationBacklog counts the queue and lists up to limit of its
+	// notifications, oldest first, optionally of one status or event type
+	GetNotificationBacklog(ctx context.Context, status models.QueuedNotificationStatus, eventType string, limit int) (*models.NotificationBacklog, error)
+	// PurgeNotifications deletes the notifications expired before a time
+	PurgeNotifications(ctx context.Context, before time.Time) (int64, error)
+	Close() error
+}
+
+// notificationQueueRepository implements NotificationQueueRepository
+// interface
+type notificationQueueRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewNotificationQueueRepository creates a new instance of
+// NotificationQueueRepository
+func NewNotificationQueueRepository(db *sql.DB) (NotificationQueueRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &notificationQueueRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *notificationQueueRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	notificationQueueEnqueueQuery = namedQuery{name: "notificationQueueEnqueue", sql: `
+            INSERT INTO notification_queue (id, event_id, event_type, envelope, next_attempt_at, expires_at,
+                                            created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+            ON CONFLICT (event_id) DO NOTHING`}
+
+	notificationQueueListDueQuery = namedQuery{name: "notificationQueueListDue", sql: `
+            SELECT ` + queuedNotificationColumns + `
+            FROM notification_queue
+            WHERE status = 'PENDING' AND next_attempt_at <= $1 AND expires_at > $1
+            ORDER BY next_attempt_at
+            LIMIT $2`}
+
+	notificationQueueDeleteQuery = namedQuery{name: "notificationQueueDelete", sql: `
+            DELETE FROM notification_queue
+            WHERE id = $1`}
+
+	notificationQueueRescheduleQuery = namedQuery{name: "notificationQueueReschedule", sql: `
+            UPDATE notification_queue
+            SET attempts = attempts + 1, next_attempt_at = $2, last_error = NULLIF($3, ''),
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1 AND status = 'PENDING'`}
+
+	notificationQueueExpireQuery = namedQuery{name: "notificationQueueExpire", sql: `
+            UPDATE notification_queue
+            SET status = 'EXPIRED', updated_at = $1
+            WHERE status = 'PENDING' AND expires_at <= $1`}
+
+	notificationQueueSummaryQuery = namedQuery{name: "notificationQueueSummary", sql: `
+            SELECT status, event_type, COUNT(*), MIN(created_at)
+            FROM notification_queue
+            GROUP BY status, event_type`}
+
+	notificationQueueListQuery = namedQuery{name: "notificationQueueList", sql: `
+            SELECT ` + queuedNotificationColumns + `
+            FROM notification_queue
+            WHERE ($1 = '' OR status = $1) AND ($2 = '' OR event_type = $2)
+            ORDER BY created_at, id
+            LIMIT $3`}
+
+	notificationQueuePurgeQuery = namedQuery{name: "notificationQueuePurge", sql: `
+            DELETE FROM notification_queue
+            WHERE status = 'EXPIRED' AND expires_at < $1`}
+)
+
+// EnqueueNotification stores a notification as pending, assigning its ID.
+// The event ID is unique, so an event the bus redelivers is queued once.
+func (r *notificationQueueRepository) EnqueueNotification(ctx context.Context, notification *models.QueuedNotification) (bool, error) {
+	notification.ID = uuid.New()
+	notification.Status = models.QueuedNotificationPending
+	notification.UpdatedAt = notification.CreatedAt
+
+	result, err := r.statements.ExecContext(ctx, notificationQueueEnqueueQuery,
+		notification.ID,
+		notification.EventID,
+		notification.EventType,
+		[]byte(notification.Envelope),
+		notification.NextAttemptAt,
+		notification.ExpiresAt,
+		notification.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue notification: %w", err)
+	}
+
+	queued, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get queued count: %w", err)
+	}
+
+	return queued > 0, nil
+}
+
+// ListDueNotifications retrieves the pending notifications due for another
+// attempt
+func (r *notificationQueueRepository) ListDueNotifications(ctx context.Context, now time.Time, limit int) ([]*models.QueuedNotification, error) {
+	return r.list(ctx, notificationQueueListDueQuery, now, limit)
+}
+
+// DeleteNotification removes a notification once the pipeline took it
+func (r *notificationQueueRepository) DeleteNotification(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.statements.ExecContext(ctx, notificationQueueDeleteQuery, id); err != nil {
+		return fmt.Errorf("failed to delete notification: %w", err)
+	}
+	return nil
+}
+
+// RescheduleNotification records a failed attempt of a pending notification
+func (r *notificationQueueRepository) RescheduleNotification(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	if _, err := r.statements.ExecContext(ctx, notificationQueueRescheduleQuery, id, nextAttemptAt, lastError); err != nil {
+		return fmt.Errorf("failed to reschedule notification: %w", err)
+	}
+	return nil
+}
+
+// ExpireNotifications marks the pending notifications past their expiry
+func (r *notificationQueueRepository) ExpireNotifications(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.statements.ExecContext(ctx, notificationQueueExpireQuery, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire notifications: %w", err)
+	}
+
+	expired, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired count: %w", err)
+	}
+
+	return expired, nil
+}
+
+// GetNotificationBacklog counts the queued notifications by status and event
+// type and lists the oldest matching ones
+func (r *notificationQueueRepository) GetNotificationBacklog(ctx context.Context, status models.QueuedNotificationStatus, eventType string, limit int) (*models.NotificationBacklog, error) {
+	rows, err := r.statements.QueryContext(ctx, notificationQueueSummaryQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	defer rows.Close()
+
+	backlog := &models.NotificationBacklog{PendingByType: make(map[string]int64)}
+	for rows.Next() {
+		var (
+			rowStatus models.QueuedNotificationStatus
+			rowType   string
+			count     int64
+			oldest    time.Time
+		)
+		if err := rows.Scan(&rowStatus, &rowType, &count, &oldest); err != nil {
+			return nil, fmt.Errorf("failed to scan notification count: %w", err)
+		}
+		if rowStatus == models.QueuedNotificationExpired {
+			backlog.Expired += count
+			continue
+		}
+		backlog.Pending += count
+		backlog.PendingByType[rowType] = count
+		if backlog.OldestPending == nil || oldest.Before(*backlog.OldestPending) {
+			backlog.OldestPending = &oldest
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification counts: %w", err)
+	}
+
+	backlog.Notifications, err = r.list(ctx, notificationQueueListQuery, string(status), eventType, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return backlog, nil
+}
+
+// PurgeNotifications deletes the expired notifications past the retention
+func (r *notificationQueueRepository) PurgeNotifications(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.statements.ExecContext(ctx, notificationQueuePurgeQuery, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge notifications: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get purged count: %w", err)
+	}
+
+	return purged, nil
+}
+
+// list runs a query returning queued notifications
+func (r *notificationQueueRepository) list(ctx context.Context, q namedQuery, args ...interface{}) ([]*models.QueuedNotification, error) {
+	rows, err := r.statements.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*models.QueuedNotification{}
+	for rows.Next() {
+		notification, err := scanQueuedNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// scanQueuedNotification scans a row of queuedNotificationColumns
+func scanQueuedNotification(row rowScanner) (*models.QueuedNotification, error) {
+	notification := &models.QueuedNotification{}
+	var envelope []byte
+	err := row.Scan(
+		&notification.ID,
+		&notification.EventID,
+		&notification.EventType,
+		&notification.Status,
+		&envelope,
+		&notification.Attempts,
+		&notification.LastError,
+		&notification.NextAttemptAt,
+		&notification.ExpiresAt,
+		&notification.CreatedAt,
+		&notification.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan notification: %w", err)
+	}
+	notification.Envelope = envelope
+
+	return notification, nil
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+	"internal/tenancy"
+)
+
+// ErrOrganizationNotFound is returned when an organization does not exist
+// or is outside the organization a context is confined to
+var ErrOrganizationNotFound = errors.New("organization not found")
+
+// OrganizationRepository defines the interface for organizations and the
+// customers and wallets they own. Organizations other than the one a context
+// is confined to are not found.
+type OrganizationRepository interface {
+	CreateOrganization(ctx context.Context, org *models.Organization) error
+	GetOrganization(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	// AssignCustomerOrganization moves a customer and its wallets to an
+	// organization, or out of any when orgID is nil
+	AssignCustomerOrganization(ctx context.Context, customerID uuid.UUID, orgID *uuid.UUID) (*models.CustomerSettings, error)
+	// SummarizeOrganization counts an organization's customers and wallets
+	// and adds up its wallets' balances by currency
+	SummarizeOrganization(ctx context.Context, orgID uuid.UUID) (*models.OrganizationSummary, error)
+	// ListOrganizationWallets pages through an organization's wallets,
+	// oldest first
+	ListOrganizationWallets(ctx context.Context, orgID uuid.UUID, limit, offset int) ([]*models.Wallet, error)
+	// ListOrganizationInvoices lists the invoices issued to an
+	// organization's customers in [from, to), by invoice number
+	ListOrganizationInvoices(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]*models.InvoiceReceivable, error)
+	Close() error
+}
+
+// organizationRepository implements OrganizationRepository interface
+type organizationRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewOrganizationRepository creates a new instance of OrganizationRepository
+func NewOrganizationRepository(db *sql.DB) (OrganizationRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &organizationRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *organizationRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	organizationCreateQuery = namedQuery{name: "organizationCreate", sql: `
+            INSERT INTO organizations (id, name, created_at, updated_at)
+            VALUES ($1, $2, $3, $3)`}
+
+	organizationGetQuery = namedQuery{name: "organizationGet", sql: `
+            SELECT id, name, created_at, updated_at
+            FROM organizations
+            WHERE id = $1 AND ($2::uuid IS NULL OR id = $2)`}
+
+	assignCustomerQuery = namedQuery{name: "assignCustomer", sql: `
+            UPDATE customers
+            SET org_id = $2,
+                updated_at = $3,
+                version = version + 1
+            WHERE id = $1
+            RETURNING ` + customerSettingsColumns}
+
+	assignWalletsQuery = namedQuery{name: "assignWallets", sql: `
+            UPDATE wallets
+            SET org_id = $2
+            WHERE customer_id = $1`}
+
+	countCustomersQuery = namedQuery{name: "countCustomers", sql: `
+            SELECT COUNT(*)
+            FROM customers
+            WHERE org_id = $1`}
+
+	sumBalancesQuery = namedQuery{name: "sumBalances", sql: `
+            SELECT currency, COUNT(*), COALESCE(SUM(balance), 0)
+            FROM wallets
+            WHERE org_id = $1 AND deleted_at IS NULL
+            GROUP BY currency
+            ORDER BY currency`}
+
+	listWalletsQuery = namedQuery{name: "listWallets", sql: `
+            SELECT id, customer_id, balance, currency, low_balance_threshold, credit_limit,
+                   created_at, updated_at, last_activity_at, inactive_since, version
+            FROM wallets
+            WHERE org_id = $1 AND deleted_at IS NULL
+            ORDER BY created_at, id
+            LIMIT $2 OFFSET $3`}
+
+	listInvoicesQuery = namedQuery{name: "listInvoices", sql: `
+            SELECT ` + invoiceReceivableColumns + `
+            FROM invoice_receivables
+            WHERE customer_id IN (SELECT id FROM customers WHERE org_id = $1)
+              AND created_at >= $2 AND created_at < $3
+            ORDER BY invoice_number`}
+)
+
+// CreateOrganization stores a new organization
+func (r *organizationRepository) CreateOrganization(ctx context.Context, org *models.Organization) error {
+	if _, err := r.statements.ExecContext(ctx, organizationCreateQuery, org.ID, org.Name, org.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+	return nil
+}
+
+// GetOrganization retrieves an organization by ID
+func (r *organizationRepository) GetOrganization(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	org := &models.Organization{}
+	err := r.statements.QueryRowContext(ctx, organizationGetQuery, id, tenancy.Scope(ctx)).Scan(
+		&org.ID,
+		&org.Name,
+		&org.CreatedAt,
+		&org.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	return org, nil
+}
+
+// AssignCustomerOrganization moves the customer and its wallets in one
+// transaction so the wallets never disagree with their customer
+func (r *organizationRepository) AssignCustomerOrganization(ctx context.Context, customerID uuid.UUID, orgID *uuid.UUID) (*models.CustomerSettings, error) {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	settings, err := scanCustomerSettings(statements.QueryRowContext(ctx, assignCustomerQuery,
+		customerID,
+		orgID,
+		time.Now().UTC(),
+	))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := statements.ExecContext(ctx, assignWalletsQuery, customerID, orgID); err != nil {
+		return nil, fmt.Errorf("failed to assign wallets: %w", err)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit organization assignment: %w", err)
+	}
+	return settings, nil
+}
+
+// SummarizeOrganization rolls up an organization's customers and wallets
+func (r *organizationRepository) SummarizeOrganization(ctx context.Context, orgID uuid.UUID) (*models.OrganizationSummary, error) {
+	org, err := r.GetOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.OrganizationSummary{Organization: org, Balances: []*models.OrganizationBalance{}}
+	if err := r.statements.QueryRowContext(ctx, countCustomersQuery, orgID).Scan(&summary.Customers); err != nil {
+		return nil, fmt.Errorf("failed to count customers: %w", err)
+	}
+
+	rows, err := r.statements.QueryContext(ctx, sumBalancesQuery, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum balances: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		balance := &models.OrganizationBalance{}
+		if err := rows.Scan(&balance.Currency, &balance.Wallets, &balance.Balance); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		summary.Wallets += balance.Wallets
+		summary.Balances = append(summary.Balances, balance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate balances: %w", err)
+	}
+
+	return summary, nil
+}
+
+// ListOrganizationWallets pages through an organization's wallets
+func (r *organizationRepository) ListOrganizationWallets(ctx context.Context, orgID uuid.UUID, limit, offset int) ([]*models.Wallet, error) {
+	if _, err := r.GetOrganization(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.statements.QueryContext(ctx, listWalletsQuery, orgID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization wallets: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*models.Wallet
+	for rows.Next() {
+		wallet := &models.Wallet{}
+		err := rows.Scan(
+			&wallet.ID,
+			&wallet.CustomerID,
+			&wallet.Balance,
+			&wallet.Currency,
+			&wallet.LowBalanceThreshold,
+			&wallet.CreditLimit,
+			&wallet.CreatedAt,
+			&wallet.UpdatedAt,
+			&wallet.LastActivityAt,
+			&wallet.InactiveSince,
+			&wallet.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet: %w", err)
+		}
+		wallets = append(wallets, wallet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate wallets: %w", err)
+	}
+
+	return wallets, nil
+}
+
+// ListOrganizationInvoices lists the invoices of an organization's
+// customers tracked in a period
+func (r *organizationRepository) ListOrganizationInvoices(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]*models.InvoiceReceivable, error) {
+	if _, err := r.GetOrganization(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.statements.QueryContext(ctx, listInvoicesQuery, orgID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization invoices: %w", err)
+	}
+	defer rows.Close()
+
+	var invoices []*models.InvoiceReceivable
+	for rows.Next() {
+		receivable, err := scanInvoiceReceivable(rows)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, receivable)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate organization invoices: %w", err)
+	}
+
+	return invoices, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"         // v1.3.0
+	"github.com/jackc/pgx/v5"        // v5.3.1
+	"github.com/jackc/pgx/v5/pgtype" // v5.3.1
+	"github.com/jackc/pgx/v5/stdlib" // v5.3.1
+)
+
+// arrayTypes decodes PostgreSQL arrays, which database/sql cannot scan into
+// slices by itself. The map caches its scan plans, so it is used under a
+// lock.
+var (
+	arrayTypesMu sync.Mutex
+	arrayTypes   = newArrayTypes()
+)
+
+// newArrayTypes returns the default type map, with UUIDs registered so that
+// uuid[] columns scan into []uuid.UUID
+func newArrayTypes() *pgtype.Map {
+	types := pgtype.NewMap()
+	var ids []uuid.UUID
+	types.RegisterDefaultPgType(uuid.UUID{}, "uuid")
+	types.RegisterDefaultPgType(ids, "_uuid")
+	types.RegisterDefaultPgType(&ids, "_uuid")
+	return types
+}
+
+// pgArray returns a scanner copying an array column into dest, a pointer to
+// a slice. A NULL array leaves the slice nil.
+func pgArray(dest interface{}) sql.Scanner {
+	return arrayScanner{dest: dest}
+}
+
+// arrayScanner scans an array column with the shared type map
+type arrayScanner struct {
+	dest interface{}
+}
+
+// Scan copies an array column into the destination slice
+func (s arrayScanner) Scan(src interface{}) error {
+	arrayTypesMu.Lock()
+	defer arrayTypesMu.Unlock()
+
+	return arrayTypes.SQLScanner(s.dest).Scan(src)
+}
+
+// wrappedConn is a driver connection wrapping another, as the metered
+// connections of the usage package do
+type wrappedConn interface {
+	Unwrap() driver.Conn
+}
+
+// observedConn meters calls made on the connection it wraps directly
+type observedConn interface {
+	Observe(ctx context.Context, query string, start time.Time)
+}
+
+// execBatch runs the queued queries of a batch in one round trip, in a
+// transaction so that they apply whole or not at all. The queries are
+// prepared and cached by the connection.
+func execBatch(ctx context.Context, db *sql.DB, q namedQuery, batch *pgx.Batch) error {
+	return inPgxTx(ctx, db, q, func(tx pgx.Tx) error {
+		return tx.SendBatch(ctx, batch).Close()
+	})
+}
+
+// inPgxTx runs fn in a transaction on the pgx connection under a pooled
+// connection, metered as the named query. database/sql sends statements one
+// at a time, so work sending batches runs here.
+func inPgxTx(ctx context.Context, db *sql.DB, q namedQuery, fn func(tx pgx.Tx) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		if observed, ok := driverConn.(observedConn); ok {
+			defer observed.Observe(ctx, q.text(), time.Now())
+		}
+
+		pgxConn, err := unwrapPgx(driverConn)
+		if err != nil {
+			return err
+		}
+		return pgx.BeginFunc(ctx, pgxConn, fn)
+	})
+}
+
+// unwrapPgx returns the pgx connection under a driver connection
+func unwrapPgx(driverConn interface{}) (*pgx.Conn, error) {
+	for {
+		switch conn := driverConn.(type) {
+		case *stdlib.Conn:
+			return conn.Conn(), nil
+		case wrappedConn:
+			driverConn = conn.Unwrap()
+		default:
+			return nil, fmt.Errorf("batches need a pgx connection, got %T", driverConn)
+		}
+	}
+}
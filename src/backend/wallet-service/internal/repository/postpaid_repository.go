@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+)
+
+// ErrPostpaidStatementNotFound is returned when a statement does not exist,
+// or was settled already when settling it
+var ErrPostpaidStatementNotFound = errors.New("postpaid statement not found")
+
+// PostpaidRepository defines the interface for the accrued charges of
+// postpaid customers and the statements billing them
+type PostpaidRepository interface {
+	CreatePostpaidCharge(ctx context.Context, charge *models.PostpaidCharge) error
+	// SumUnbilledPostpaidCharges counts and totals the charges of a wallet
+	// no statement billed yet
+	SumUnbilledPostpaidCharges(ctx context.Context, walletID uuid.UUID) (int, decimal.Decimal, error)
+	// CreatePostpaidStatements bills the unbilled charges accrued before
+	// periodEnd, one pending statement per wallet and currency
+	CreatePostpaidStatements(ctx context.Context, periodStart, periodEnd, createdAt time.Time) ([]*models.PostpaidStatement, error)
+	// ListPendingPostpaidStatements lists the statements not settled yet,
+	// oldest first
+	ListPendingPostpaidStatements(ctx context.Context) ([]*models.PostpaidStatement, error)
+	// ListPostpaidStatements lists the newest statements of a wallet
+	ListPostpaidStatements(ctx context.Context, walletID uuid.UUID, limit int) ([]*models.PostpaidStatement, error)
+	// SettlePostpaidStatement marks a pending statement settled how
+	// settlement says, by the debit transactionID when from the wallet
+	SettlePostpaidStatement(ctx context.Context, id uuid.UUID, settlement models.PostpaidSettlement, transactionID *uuid.UUID, settledAt time.Time) (*models.PostpaidStatement, error)
+	Close() error
+}
+
+// postpaidStatementColumns are the columns scanned by scanPostpaidStatement
+const postpaidStatementColumns = `id, customer_id, wallet_id, currency, invoice_number, period_start, period_end,
+                   charges, total_amount, status, COALESCE(settlement, ''), transaction_id, settled_at, created_at`
+
+// postpaidRepository implements PostpaidRepository interface
+type postpaidRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewPostpaidRepository creates a new instance of PostpaidRepository
+func NewPostpaidRepository(db *sql.DB) (PostpaidRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &postpaidRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *postpaidRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	createChargeQuery = namedQuery{name: "createCharge", sql: `
+            INSERT INTO postpaid_charges (id, customer_id, wallet_id, amount, currency, description,
+                                          reference_id, metadata, created_at)
+            VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9)`}
+
+	sumUnbilledQuery = namedQuery{name: "sumUnbilled", sql: `
+            SELECT COUNT(*), COALESCE(SUM(amount), 0)
+            FROM postpaid_charges
+            WHERE wallet_id = $1 AND statement_id IS NULL`}
+
+	listUnbilledWalletsQuery = namedQuery{name: "listUnbilledWallets", sql: `
+            SELECT DISTINCT wallet_id, customer_id, currency
+            FROM postpaid_charges
+            WHERE statement_id IS NULL AND created_at < $1
+            ORDER BY wallet_id, currency`}
+
+	createStatementQuery = namedQuery{name: "createStatement", sql: `
+            INSERT INTO postpaid_statements (id, customer_id, wallet_id, currency, invoice_number,
+                                             period_start, period_end, status, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, 'PENDING', $8)`}
+
+	// The charges are billed and the statement totalled in one
+	// statement, so the total is of exactly the charges billed
+	billChargesQuery = namedQuery{name: "billCharges", sql: `
+            WITH billed AS (
+                UPDATE postpaid_charges
+                SET statement_id = $1
+                WHERE wallet_id = $2 AND currency = $3 AND statement_id IS NULL AND created_at < $4
+                RETURNING amount
+            )
+            UPDATE postpaid_statements
+            SET charges = (SELECT COUNT(*) FROM billed),
+                total_amount = (SELECT COALESCE(SUM(amount), 0) FROM billed)
+            WHERE id = $1
+            RETURNING ` + postpaidStatementColumns}
+
+	listPendingQuery = namedQuery{name: "listPending", sql: `
+            SELECT ` + postpaidStatementColumns + `
+            FROM postpaid_statements
+            WHERE status = 'PENDING'
+            ORDER BY created_at, id`}
+
+	listByWalletQuery = namedQuery{name: "listByWallet", sql: `
+            SELECT ` + postpaidStatementColumns + `
+            FROM postpaid_statements
+            WHERE wallet_id = $1
+            ORDER BY period_start DESC, created_at DESC
+            LIMIT $2`}
+
+	postpaidSettleQuery = namedQuery{name: "postpaidSettle", sql: `
+            UPDATE postpaid_statements
+            SET status = $2,
+                settlement = $3,
+                transaction_id = $4,
+                settled_at = $5
+            WHERE id = $1 AND status = 'PENDING'
+            RETURNING ` + postpaidStatementColumns}
+)
+
+// CreatePostpaidCharge records an accrued charge
+func (r *postpaidRepository) CreatePostpaidCharge(ctx context.Context, charge *models.PostpaidCharge) error {
+	metadata, err := encodeMetadata(charge.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode charge metadata: %w", err)
+	}
+
+	_, err = r.statements.ExecContext(ctx, createChargeQuery,
+		charge.ID,
+		charge.CustomerID,
+		charge.WalletID,
+		charge.Amount,
+		charge.Currency,
+		charge.Description,
+		charge.ReferenceID,
+		metadata,
+		charge.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create postpaid charge: %w", err)
+	}
+	return nil
+}
+
+// SumUnbilledPostpaidCharges counts and totals a wallet's unbilled charges
+func (r *postpaidRepository) SumUnbilledPostpaidCharges(ctx context.Context, walletID uuid.UUID) (int, decimal.Decimal, error) {
+	var count int
+	var total decimal.Decimal
+	if err := r.statements.QueryRowContext(ctx, sumUnbilledQuery, walletID).Scan(&count, &total); err != nil {
+		return 0, decimal.Zero, fmt.Errorf("failed to sum unbilled postpaid charges: %w", err)
+	}
+	return count, total, nil
+}
+
+// CreatePostpaidStatements bills the unbilled charges accrued before
+// periodEnd in one transaction
+func (r *postpaidRepository) CreatePostpaidStatements(ctx context.Context, periodStart, periodEnd, createdAt time.Time) ([]*models.PostpaidStatement, error) {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	type unbilled struct {
+		walletID   uuid.UUID
+		customerID uuid.UUID
+		currency   string
+	}
+	rows, err := statements.QueryContext(ctx, listUnbilledWalletsQuery, periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unbilled postpaid wallets: %w", err)
+	}
+	var wallets []unbilled
+	for rows.Next() {
+		var wallet unbilled
+		if err := rows.Scan(&wallet.walletID, &wallet.customerID, &wallet.currency); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan unbilled postpaid wallet: %w", err)
+		}
+		wallets = append(wallets, wallet)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate unbilled postpaid wallets: %w", err)
+	}
+
+	billed := make([]*models.PostpaidStatement, 0, len(wallets))
+	for _, wallet := range wallets {
+		id := uuid.New()
+		_, err := statements.ExecContext(ctx, createStatementQuery,
+			id,
+			wallet.customerID,
+			wallet.walletID,
+			wallet.currency,
+			models.PostpaidInvoiceNumber(periodStart, id),
+			periodStart,
+			periodEnd,
+			createdAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create postpaid statement: %w", err)
+		}
+
+		statement, err := scanPostpaidStatement(statements.QueryRowContext(ctx, billChargesQuery,
+			id,
+			wallet.walletID,
+			wallet.currency,
+			periodEnd,
+		))
+		if err != nil {
+			return nil, err
+		}
+		billed = append(billed, statement)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit postpaid statements: %w", err)
+	}
+	return billed, nil
+}
+
+// ListPendingPostpaidStatements lists the statements not settled yet
+func (r *postpaidRepository) ListPendingPostpaidStatements(ctx context.Context) ([]*models.PostpaidStatement, error) {
+	rows, err := r.statements.QueryContext(ctx, listPendingQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending postpaid statements: %w", err)
+	}
+	return scanPostpaidStatements(rows)
+}
+
+// ListPostpaidStatements lists the newest statements of a wallet
+func (r *postpaidRepository) ListPostpaidStatements(ctx context.Context, walletID uuid.UUID, limit int) ([]*models.PostpaidStatement, error) {
+	rows, err := r.statements.QueryContext(ctx, listByWalletQuery, walletID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list postpaid statements: %w", err)
+	}
+	return scanPostpaidStatements(rows)
+}
+
+// SettlePostpaidStatement marks a pending statement settled
+func (r *postpaidRepository) SettlePostpaidStatement(ctx context.Context, id uuid.UUID, settlement models.PostpaidSettlement, transactionID *uuid.UUID, settledAt time.Time) (*models.PostpaidStatement, error) {
+	status := models.StatementSettled
+	if settlement == models.SettleExternal {
+		status = models.StatementInvoiced
+	}
+
+	return scanPostpaidStatement(r.statements.QueryRowContext(ctx, postpaidSettleQuery,
+		id,
+		status,
+		settlement,
+		transactionID,
+		settledAt,
+	))
+}
+
+// scanPostpaidStatements scans and closes postpaid statement rows
+func scanPostpaidStatements(rows *sql.Rows) ([]*models.PostpaidStatement, error) {
+	defer rows.Close()
+
+	statements := []*models.PostpaidStatement{}
+	for rows.Next() {
+		statement, err := scanPostpaidStatement(rows)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate postpaid statements: %w", err)
+	}
+
+	return statements, nil
+}
+
+// scanPostpaidStatement scans a postpaid statement row
+func scanPostpaidStatement(row rowScanner) (*models.PostpaidStatement, error) {
+	statement := &models.PostpaidStatement{}
+	err := row.Scan(
+		&statement.ID,
+		&statement.CustomerID,
+		&statement.WalletID,
+		&statement.Currency,
+		&statement.InvoiceNumber,
+		&statement.PeriodStart,
+		&statement.PeriodEnd,
+		&statement.Charges,
+		&statement.TotalAmount,
+		&statement.Status,
+		&statement.Settlement,
+		&statement.TransactionID,
+		&statement.SettledAt,
+		&statement.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPostpaidStatementNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan postpaid statement: %w", err)
+	}
+
+	return statement, nil
+}
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"         // v1.3.0
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+
+	"internal/models"
+)
+
+// Rate card repository errors
+var (
+	ErrRateCardChangeNotFound = errors.New("rate card change not found")
+	// ErrRateCardChangeConflict is returned when a change is reviewed or
+	// activated out of order, or proposed while another is still open
+	ErrRateCardChangeConflict = errors.New("rate card change is not in the required state")
+)
+
+// rateCardChangeColumns are the columns scanned by scanRateCardChange
+const rateCardChangeColumns = `id, status, per_thousand_requests, per_db_second, per_gb,
+            previous_per_thousand_requests, previous_per_db_second, previous_per_gb, diff,
+            effective_at, reason, proposed_by, COALESCE(reviewed_by, ''), COALESCE(review_note, ''),
+            reviewed_at, finance_notified_at, activated_at, created_at`
+
+// RateCardRepository defines the interface for rate card change persistence
+type RateCardRepository interface {
+	CreateChange(ctx context.Context, change *models.RateCardChange) error
+	GetChange(ctx context.Context, id uuid.UUID) (*models.RateCardChange, error)
+	ListChanges(ctx context.Context, limit int) ([]*models.RateCardChange, error)
+	ListApproved(ctx context.Context) ([]*models.RateCardChange, error)
+	ReviewChange(ctx context.Context, id uuid.UUID, status models.RateCardChangeStatus, reviewer, note string, now time.Time) (*models.RateCardChange, error)
+	MarkFinanceNotified(ctx context.Context, id uuid.UUID, now time.Time) error
+	ActivateChange(ctx context.Context, id uuid.UUID, now time.Time) error
+	GetRatesAt(ctx context.Context, at time.Time) (*models.RateCard, error)
+	Close() error
+}
+
+// rateCardRepository implements RateCardRepository interface
+type rateCardRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewRateCardRepository creates a new instance of RateCardRepository
+func NewRateCardRepository(db *sql.DB) (RateCardRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &rateCardRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *rateCardRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	// The unique index on open changes refuses a second proposal while
+	// one is pending or approved
+	createChangeQuery = namedQuery{name: "createChange", sql: `
+            INSERT INTO rate_card_changes (
+                id, status, per_thousand_requests, per_db_second, per_gb,
+                previous_per_thousand_requests, previous_per_db_second, previous_per_gb, diff,
+                effective_at, reason, proposed_by, created_at
+            ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`}
+
+	getChangeQuery = namedQuery{name: "getChange", sql: `
+            SELECT ` + rateCardChangeColumns + `
+            FROM rate_card_changes
+            WHERE id = $1`}
+
+	listChangesQuery = namedQuery{name: "listChanges", sql: `
+            SELECT ` + rateCardChangeColumns + `
+            FROM rate_card_changes
+            ORDER BY created_at DESC
+            LIMIT $1`}
+
+	listApprovedQuery = namedQuery{name: "listApproved", sql: `
+            SELECT ` + rateCardChangeColumns + `
+            FROM rate_card_changes
+            WHERE status = 'APPROVED'
+            ORDER BY effective_at`}
+
+	reviewChangeQuery = namedQuery{name: "reviewChange", sql: `
+            UPDATE rate_card_changes
+            SET status = $2, reviewed_by = $3, review_note = NULLIF($4, ''), reviewed_at = $5
+            WHERE id = $1 AND status = 'PENDING'
+            RETURNING ` + rateCardChangeColumns}
+
+	markFinanceNotifiedQuery = namedQuery{name: "markFinanceNotified", sql: `
+            UPDATE rate_card_changes
+            SET finance_notified_at = $2
+            WHERE id = $1 AND status = 'APPROVED' AND finance_notified_at IS NULL`}
+
+	supersedeActiveQuery = namedQuery{name: "supersedeActive", sql: `
+            UPDATE rate_card_changes
+            SET status = 'SUPERSEDED'
+            WHERE status = 'ACTIVE'`}
+
+	activateChangeQuery = namedQuery{name: "activateChange", sql: `
+            UPDATE rate_card_changes
+            SET status = 'ACTIVE', activated_at = $2
+            WHERE id = $1 AND status = 'APPROVED' AND finance_notified_at IS NOT NULL AND effective_at <= $2`}
+
+	getRatesAtQuery = namedQuery{name: "getRatesAt", sql: `
+            SELECT per_thousand_requests, per_db_second, per_gb
+            FROM rate_card_changes
+            WHERE status IN ('ACTIVE', 'SUPERSEDED') AND activated_at <= $1
+            ORDER BY activated_at DESC
+            LIMIT 1`}
+)
+
+// CreateChange stores a proposed change. ErrRateCardChangeConflict is
+// returned while another change is pending or approved.
+func (r *rateCardRepository) CreateChange(ctx context.Context, change *models.RateCardChange) error {
+	diff, err := json.Marshal(change.Diff)
+	if err != nil {
+		return fmt.Errorf("failed to encode rate card diff: %w", err)
+	}
+
+	_, err = r.statements.ExecContext(ctx, createChangeQuery,
+		change.ID,
+		change.Status,
+		change.Rates.PerThousandRequests,
+		change.Rates.PerDBSecond,
+		change.Rates.PerGB,
+		change.Previous.PerThousandRequests,
+		change.Previous.PerDBSecond,
+		change.Previous.PerGB,
+		diff,
+		change.EffectiveAt,
+		change.Reason,
+		change.ProposedBy,
+		change.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrRateCardChangeConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create rate card change: %w", err)
+	}
+	return nil
+}
+
+// GetChange retrieves a rate card change by ID
+func (r *rateCardRepository) GetChange(ctx context.Context, id uuid.UUID) (*models.RateCardChange, error) {
+	change, err := scanRateCardChange(r.statements.QueryRowContext(ctx, getChangeQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRateCardChangeNotFound
+		}
+		return nil, fmt.Errorf("failed to get rate card change: %w", err)
+	}
+	return change, nil
+}
+
+// ListChanges retrieves the latest rate card changes, newest first
+func (r *rateCardRepository) ListChanges(ctx context.Context, limit int) ([]*models.RateCardChange, error) {
+	return r.queryChanges(ctx, listChangesQuery, limit)
+}
+
+// ListApproved retrieves the approved changes awaiting activation, earliest
+// effective date first
+func (r *rateCardRepository) ListApproved(ctx context.Context) ([]*models.RateCardChange, error) {
+	return r.queryChanges(ctx, listApprovedQuery)
+}
+
+// queryChanges runs a statement selecting rateCardChangeColumns
+func (r *rateCardRepository) queryChanges(ctx context.Context, q namedQuery, args ...interface{}) ([]*models.RateCardChange, error) {
+	rows, err := r.statements.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rate card changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*models.RateCardChange
+	for rows.Next() {
+		change, err := scanRateCardChange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rate card change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rate card changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// ReviewChange approves or rejects a pending change
+func (r *rateCardRepository) ReviewChange(ctx context.Context, id uuid.UUID, status models.RateCardChangeStatus, reviewer, note string, now time.Time) (*models.RateCardChange, error) {
+	change, err := scanRateCardChange(r.statements.QueryRowContext(ctx, reviewChangeQuery, id, status, reviewer, note, now))
+	if err == nil {
+		return change, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to review rate card change: %w", err)
+	}
+
+	if _, err := r.GetChange(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, ErrRateCardChangeConflict
+}
+
+// MarkFinanceNotified records that finance was sent an approved change
+func (r *rateCardRepository) MarkFinanceNotified(ctx context.Context, id uuid.UUID, now time.Time) error {
+	_, err := r.statements.ExecContext(ctx, markFinanceNotifiedQuery, id, now)
+	if err != nil {
+		return fmt.Errorf("failed to mark rate card change notified: %w", err)
+	}
+	return nil
+}
+
+// ActivateChange puts an approved change whose effective date has come into
+// effect, superseding the active one. ErrRateCardChangeConflict is returned
+// when the change is not yet due, finance was not notified or another
+// replica activated it first.
+func (r *rateCardRepository) ActivateChange(ctx context.Context, id uuid.UUID, now time.Time) error {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	if _, err := statements.ExecContext(ctx, supersedeActiveQuery); err != nil {
+		return fmt.Errorf("failed to supersede active rate card: %w", err)
+	}
+
+	result, err := statements.ExecContext(ctx, activateChangeQuery, id, now)
+	if err != nil {
+		return fmt.Errorf("failed to activate rate card change: %w", err)
+	}
+	activated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get activated count: %w", err)
+	}
+	if activated == 0 {
+		return ErrRateCardChangeConflict
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetRatesAt returns the rates of the change in effect at the given time,
+// or nil when no change was ever activated by then
+func (r *rateCardRepository) GetRatesAt(ctx context.Context, at time.Time) (*models.RateCard, error) {
+	var rates models.RateCard
+	err := r.statements.QueryRowContext(ctx, getRatesAtQuery, at).Scan(
+		&rates.PerThousandRequests,
+		&rates.PerDBSecond,
+		&rates.PerGB,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate card: %w", err)
+	}
+	return &rates, nil
+}
+
+// scanRateCardChange scans a rate card change row
+func scanRateCardChange(row rowScanner) (*models.RateCardChange, error) {
+	var change models.RateCardChange
+	var diff []byte
+	err := row.Scan(
+		&change.ID,
+		&change.Status,
+		&change.Rates.PerThousandRequests,
+		&change.Rates.PerDBSecond,
+		&change.Rates.PerGB,
+		&change.Previous.PerThousandRequests,
+		&change.Previous.PerDBSecond,
+		&change.Previous.PerGB,
+		&diff,
+		&change.EffectiveAt,
+		&change.Reason,
+		&change.ProposedBy,
+		&change.ReviewedBy,
+		&change.ReviewNote,
+		&change.ReviewedAt,
+		&change.FinanceNotifiedAt,
+		&change.ActivatedAt,
+		&change.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(diff, &change.Diff); err != nil {
+		return nil, fmt.Errorf("failed to decode rate card diff: %w", err)
+	}
+	return &change, nil
+}
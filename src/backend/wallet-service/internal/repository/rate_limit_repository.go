@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// ErrRateLimitPolicyNotFound is returned when no tier applies to a customer
+var ErrRateLimitPolicyNotFound = errors.New("rate limit policy not found")
+
+// RateLimitRepository defines the interface for rate limit policy lookups
+type RateLimitRepository interface {
+	GetCustomerPolicy(ctx context.Context, customerID uuid.UUID) (*models.RateLimitPolicy, error)
+	Close() error
+}
+
+// rateLimitRepository implements RateLimitRepository interface
+type rateLimitRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewRateLimitRepository creates a new instance of RateLimitRepository
+func NewRateLimitRepository(db *sql.DB) (RateLimitRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &rateLimitRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *rateLimitRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	// Resolution order: explicit customer tier, active price plan type, standard tier.
+	// Explicit customer quota overrides win over the resolved tier quota.
+	getCustomerPolicyQuery = namedQuery{name: "getCustomerPolicy", sql: `
+            WITH customer_override AS (
+                SELECT tier, requests_per_window, window_seconds
+                FROM customer_rate_limits
+                WHERE customer_id = $1
+            ), plan_tier AS (
+                SELECT pp.type::text AS tier
+                FROM accounts a
+                JOIN account_price_plans app ON app.account_id = a.id
+                JOIN price_plans pp ON pp.id = app.price_plan_id
+                WHERE a.customer_id = $1
+                  AND app.start_date <= NOW()
+                  AND (app.end_date IS NULL OR app.end_date > NOW())
+                ORDER BY app.start_date DESC
+                LIMIT 1
+            )
+            SELECT t.name,
+                   COALESCE((SELECT requests_per_window FROM customer_override), t.requests_per_window),
+                   COALESCE((SELECT window_seconds FROM customer_override), t.window_seconds)
+            FROM rate_limit_tiers t
+            WHERE t.name = COALESCE(
+                (SELECT tier FROM customer_override),
+                (SELECT tier FROM plan_tier),
+                'standard')`}
+)
+
+// GetCustomerPolicy resolves the rate limit policy for a customer
+func (r *rateLimitRepository) GetCustomerPolicy(ctx context.Context, customerID uuid.UUID) (*models.RateLimitPolicy, error) {
+	policy := &models.RateLimitPolicy{}
+	var windowSeconds int
+
+	err := r.statements.QueryRowContext(ctx, getCustomerPolicyQuery, customerID).Scan(
+		&policy.Tier,
+		&policy.Limit,
+		&windowSeconds,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrRateLimitPolicyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate limit policy: %w", err)
+	}
+
+	policy.Window = time.Duration(windowSeconds) * time.Second
+	return policy, nil
+}
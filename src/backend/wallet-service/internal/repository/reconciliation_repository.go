@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// ErrReconciliationIssueNotFound is returned when an issue ID does not exist
+var ErrReconciliationIssueNotFound = errors.New("reconciliation issue not found")
+
+// ReconciliationRepository defines the interface for ledger reconciliation persistence
+type ReconciliationRepository interface {
+	FindDiscrepancies(ctx context.Context) (int64, []*models.ReconciliationIssue, error)
+	UpsertOpenIssue(ctx context.Context, issue *models.ReconciliationIssue) error
+	ResolveIssues(ctx context.Context, stillOpen []uuid.UUID, resolvedAt time.Time) (int64, error)
+	ListIssues(ctx context.Context, status models.ReconciliationIssueStatus, limit, offset int) ([]*models.ReconciliationIssue, error)
+	GetIssue(ctx context.Context, id uuid.UUID) (*models.ReconciliationIssue, error)
+	GetLedgerSummary(ctx context.Context, walletID uuid.UUID) ([]*models.LedgerEntrySummary, error)
+	Close() error
+}
+
+// reconciliationRepository implements ReconciliationRepository interface
+type reconciliationRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewReconciliationRepository creates a new instance of ReconciliationRepository
+func NewReconciliationRepository(db *sql.DB) (ReconciliationRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &reconciliationRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *reconciliationRepository) Close() error {
+	return r.statements.Close()
+}
+
+// issueColumns is the column list shared by the issue queries
+const issueColumns = `id, wallet_id, currency, stored_balance, ledger_balance, difference,
+                   transaction_count, status, detected_at, last_checked_at, resolved_at`
+
+var (
+	countWalletsQuery = namedQuery{name: "countWallets", sql: `
+            SELECT COUNT(*) FROM wallets`}
+
+	// Dispute and refund holds are PROCESSING debits whose amount left
+	// the balance when the hold was placed, so they count as posted
+	findDiscrepanciesQuery = namedQuery{name: "findDiscrepancies", sql: `
+            SELECT w.id, w.currency, w.balance, l.ledger_balance, w.balance - l.ledger_balance, l.transaction_count
+            FROM wallets w
+            CROSS JOIN LATERAL (
+                SELECT COALESCE(SUM(` + signedAmountSQL + `), 0) AS ledger_balance,
+                       COUNT(t.id) AS transaction_count
+                FROM wallet_transactions t
+                WHERE t.wallet_id = w.id
+                  AND (t.status = 'COMPLETED' OR (t.type = 'DEBIT' AND t.status = 'PROCESSING'))
+            ) l
+            WHERE w.balance <> l.ledger_balance`}
+
+	upsertOpenIssueQuery = namedQuery{name: "upsertOpenIssue", sql: `
+            INSERT INTO reconciliation_issues (id, wallet_id, currency, stored_balance, ledger_balance,
+                                               difference, transaction_count, status, detected_at, last_checked_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, 'OPEN', $8, $8)
+            ON CONFLICT (wallet_id) WHERE status = 'OPEN' DO UPDATE
+            SET stored_balance = EXCLUDED.stored_balance,
+                ledger_balance = EXCLUDED.ledger_balance,
+                difference = EXCLUDED.difference,
+                transaction_count = EXCLUDED.transaction_count,
+                last_checked_at = EXCLUDED.last_checked_at
+            RETURNING id, detected_at`}
+
+	resolveIssuesQuery = namedQuery{name: "resolveIssues", sql: `
+            UPDATE reconciliation_issues
+            SET status = 'RESOLVED', resolved_at = $2
+            WHERE status = 'OPEN' AND NOT (wallet_id = ANY($1::uuid[]))`}
+
+	listIssuesQuery = namedQuery{name: "listIssues", sql: `
+            SELECT ` + issueColumns + `
+            FROM reconciliation_issues
+            WHERE ($1 = '' OR status = $1)
+            ORDER BY detected_at DESC
+            LIMIT $2 OFFSET $3`}
+
+	getIssueQuery = namedQuery{name: "getIssue", sql: `
+            SELECT ` + issueColumns + `
+            FROM reconciliation_issues
+            WHERE id = $1`}
+
+	getLedgerSummaryQuery = namedQuery{name: "getLedgerSummary", sql: `
+            SELECT type, status, COUNT(*), COALESCE(SUM(amount), 0)
+            FROM wallet_transactions
+            WHERE wallet_id = $1
+            GROUP BY type, status
+            ORDER BY type, status`}
+)
+
+// FindDiscrepancies recomputes every wallet balance from its completed
+// transactions and open holds and returns the number of wallets checked along with those
+// whose stored balance disagrees. Both reads share one snapshot so concurrent
+// transactions cannot produce false positives.
+func (r *reconciliationRepository) FindDiscrepancies(ctx context.Context) (int64, []*models.ReconciliationIssue, error) {
+	dbTx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	var checked int64
+	if err := statements.QueryRowContext(ctx, countWalletsQuery).Scan(&checked); err != nil {
+		return 0, nil, fmt.Errorf("failed to count wallets: %w", err)
+	}
+
+	rows, err := statements.QueryContext(ctx, findDiscrepanciesQuery)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to find discrepancies: %w", err)
+	}
+	defer rows.Close()
+
+	var issues []*models.ReconciliationIssue
+	for rows.Next() {
+		issue := &models.ReconciliationIssue{Status: models.ReconciliationIssueOpen}
+		err := rows.Scan(
+			&issue.WalletID,
+			&issue.Currency,
+			&issue.StoredBalance,
+			&issue.LedgerBalance,
+			&issue.Difference,
+			&issue.TransactionCount,
+		)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to scan discrepancy: %w", err)
+		}
+		issues = append(issues, issue)
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("error iterating discrepancies: %w", err)
+	}
+
+	return checked, issues, nil
+}
+
+// UpsertOpenIssue records a discrepancy, refreshing the wallet's open issue
+// when one already exists so repeated runs do not duplicate it
+func (r *reconciliationRepository) UpsertOpenIssue(ctx context.Context, issue *models.ReconciliationIssue) error {
+	if issue.ID == uuid.Nil {
+		issue.ID = uuid.New()
+	}
+	if issue.LastCheckedAt.IsZero() {
+		issue.LastCheckedAt = time.Now().UTC()
+	}
+
+	err := r.statements.QueryRowContext(ctx, upsertOpenIssueQuery,
+		issue.ID,
+		issue.WalletID,
+		issue.Currency,
+		issue.StoredBalance,
+		issue.LedgerBalance,
+		issue.Difference,
+		issue.TransactionCount,
+		issue.LastCheckedAt,
+	).Scan(&issue.ID, &issue.DetectedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record reconciliation issue: %w", err)
+	}
+
+	issue.Status = models.ReconciliationIssueOpen
+	return nil
+}
+
+// ResolveIssues marks open issues as resolved for every wallet not listed in
+// stillOpen and returns how many issues were resolved
+func (r *reconciliationRepository) ResolveIssues(ctx context.Context, stillOpen []uuid.UUID, resolvedAt time.Time) (int64, error) {
+	ids := make([]string, len(stillOpen))
+	for i, id := range stillOpen {
+		ids[i] = id.String()
+	}
+
+	result, err := r.statements.ExecContext(ctx, resolveIssuesQuery, ids, resolvedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve reconciliation issues: %w", err)
+	}
+
+	resolved, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get resolved issue count: %w", err)
+	}
+
+	return resolved, nil
+}
+
+// ListIssues retrieves paginated reconciliation issues, optionally by status
+func (r *reconciliationRepository) ListIssues(ctx context.Context, status models.ReconciliationIssueStatus, limit, offset int) ([]*models.ReconciliationIssue, error) {
+	rows, err := r.statements.QueryContext(ctx, listIssuesQuery, string(status), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reconciliation issues: %w", err)
+	}
+	defer rows.Close()
+
+	var issues []*models.ReconciliationIssue
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, issue)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reconciliation issues: %w", err)
+	}
+
+	return issues, nil
+}
+
+// GetIssue retrieves a single reconciliation issue by ID
+func (r *reconciliationRepository) GetIssue(ctx context.Context, id uuid.UUID) (*models.ReconciliationIssue, error) {
+	issue, err := scanIssue(r.statements.QueryRowContext(ctx, getIssueQuery, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReconciliationIssueNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+// GetLedgerSummary totals a wallet's transactions by type and status
+func (r *reconciliationRepository) GetLedgerSummary(ctx context.Context, walletID uuid.UUID) ([]*models.LedgerEntrySummary, error) {
+	rows, err := r.statements.QueryContext(ctx, getLedgerSummaryQuery, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger summary: %w", err)
+	}
+	defer rows.Close()
+
+	var summary []*models.LedgerEntrySummary
+	for rows.Next() {
+		entry := &models.LedgerEntrySummary{}
+		if err := rows.Scan(&entry.Type, &entry.Status, &entry.Count, &entry.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger summary: %w", err)
+		}
+		summary = append(summary, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ledger summary: %w", err)
+	}
+
+	return summary, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanIssue scans a reconciliation issue selected with issueColumns
+func scanIssue(row rowScanner) (*models.ReconciliationIssue, error) {
+	issue := &models.ReconciliationIssue{}
+	var resolvedAt sql.NullTime
+	err := row.Scan(
+		&issue.ID,
+		&issue.WalletID,
+		&issue.Currency,
+		&issue.StoredBalance,
+		&issue.LedgerBalance,
+		&issue.Difference,
+		&issue.TransactionCount,
+		&issue.Status,
+		&issue.DetectedAt,
+		&issue.LastCheckedAt,
+		&resolvedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan reconciliation issue: %w", err)
+	}
+	if resolvedAt.Valid {
+		issue.ResolvedAt = &resolvedAt.Time
+	}
+
+	return issue, nil
+}
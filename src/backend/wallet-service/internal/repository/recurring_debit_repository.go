@@ -0,0 +1,373 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// ErrRecurringDebitNotFound is returned when a recurring debit does not exist
+// or belongs to another customer
+var ErrRecurringDebitNotFound = errors.New("recurring debit not found")
+
+// recurringDebitColumns is the column list scanned by scanRecurringDebit
+const recurringDebitColumns = `id, customer_id, wallet_id, amount, currency, COALESCE(description, ''), cadence,
+            start_at, end_at, run_count, next_run_at, cancelled_at, created_at, updated_at`
+
+// recurringRunColumns is the column list scanned by scanRecurringRun
+const recurringRunColumns = `recurring_debit_id, scheduled_for, idempotency_key, status, transaction_id,
+            COALESCE(error, ''), claimed_at, finished_at`
+
+// RecurringDebitRepository defines the interface for recurring debit persistence
+type RecurringDebitRepository interface {
+	CreateRecurringDebit(ctx context.Context, debit *models.RecurringDebit) error
+	GetRecurringDebit(ctx context.Context, customerID, id uuid.UUID) (*models.RecurringDebit, error)
+	ListRecurringDebits(ctx context.Context, customerID uuid.UUID) ([]*models.RecurringDebit, error)
+	CancelRecurringDebit(ctx context.Context, customerID, id uuid.UUID, now time.Time) (*models.RecurringDebit, error)
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*models.RecurringDebit, error)
+	ListRuns(ctx context.Context, id uuid.UUID, limit int) ([]*models.RecurringDebitRun, error)
+	ClaimRun(ctx context.Context, run *models.RecurringDebitRun, staleBefore time.Time) (bool, error)
+	ReleaseRun(ctx context.Context, run *models.RecurringDebitRun) error
+	FinishRun(ctx context.Context, run *models.RecurringDebitRun, runCount int, nextRunAt *time.Time) error
+	// RecoverRun records that a failed run was debited on a retry
+	RecoverRun(ctx context.Context, run *models.RecurringDebitRun) error
+	Close() error
+}
+
+// recurringDebitRepository implements RecurringDebitRepository interface
+type recurringDebitRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewRecurringDebitRepository creates a new instance of RecurringDebitRepository
+func NewRecurringDebitRepository(db *sql.DB) (RecurringDebitRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &recurringDebitRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *recurringDebitRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	createRecurringDebitQuery = namedQuery{name: "createRecurringDebit", sql: `
+            INSERT INTO recurring_debits (id, customer_id, wallet_id, amount, currency, description, cadence,
+                                          start_at, end_at, next_run_at, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, $11, $11)`}
+
+	getRecurringDebitQuery = namedQuery{name: "getRecurringDebit", sql: `
+            SELECT ` + recurringDebitColumns + `
+            FROM recurring_debits
+            WHERE id = $1 AND customer_id = $2`}
+
+	listRecurringDebitsQuery = namedQuery{name: "listRecurringDebits", sql: `
+            SELECT ` + recurringDebitColumns + `
+            FROM recurring_debits
+            WHERE customer_id = $1
+            ORDER BY created_at`}
+
+	cancelRecurringDebitQuery = namedQuery{name: "cancelRecurringDebit", sql: `
+            UPDATE recurring_debits
+            SET cancelled_at = COALESCE(cancelled_at, $3),
+                next_run_at = NULL,
+                updated_at = $3
+            WHERE id = $1 AND customer_id = $2
+            RETURNING ` + recurringDebitColumns}
+
+	recurringDebitListDueQuery = namedQuery{name: "recurringDebitListDue", sql: `
+            SELECT ` + recurringDebitColumns + `
+            FROM recurring_debits
+            WHERE next_run_at <= $1
+            ORDER BY next_run_at
+            LIMIT $2`}
+
+	listRunsQuery = namedQuery{name: "listRuns", sql: `
+            SELECT ` + recurringRunColumns + `
+            FROM recurring_debit_runs
+            WHERE recurring_debit_id = $1
+            ORDER BY scheduled_for DESC
+            LIMIT $2`}
+
+	// A pending claim older than staleBefore belongs to a scheduler that
+	// stopped mid-run and is taken over
+	claimRunQuery = namedQuery{name: "claimRun", sql: `
+            INSERT INTO recurring_debit_runs (recurring_debit_id, scheduled_for, idempotency_key, status, claimed_at)
+            VALUES ($1, $2, $3, 'PENDING', $4)
+            ON CONFLICT (recurring_debit_id, scheduled_for) DO UPDATE
+            SET claimed_at = EXCLUDED.claimed_at
+            WHERE recurring_debit_runs.status = 'PENDING' AND recurring_debit_runs.claimed_at < $5`}
+
+	releaseRunQuery = namedQuery{name: "releaseRun", sql: `
+            DELETE FROM recurring_debit_runs
+            WHERE recurring_debit_id = $1 AND scheduled_for = $2 AND status = 'PENDING'`}
+
+	finishRunQuery = namedQuery{name: "finishRun", sql: `
+            UPDATE recurring_debit_runs
+            SET status = $3, transaction_id = $4, error = NULLIF($5, ''), finished_at = $6
+            WHERE recurring_debit_id = $1 AND scheduled_for = $2 AND status = 'PENDING'`}
+
+	recoverRunQuery = namedQuery{name: "recoverRun", sql: `
+            UPDATE recurring_debit_runs
+            SET status = 'SUCCEEDED', transaction_id = $3, error = NULL, finished_at = $4
+            WHERE recurring_debit_id = $1 AND scheduled_for = $2 AND status = 'FAILED'`}
+
+	// A debit cancelled during the run keeps no next run
+	recurringDebitAdvanceQuery = namedQuery{name: "recurringDebitAdvance", sql: `
+            UPDATE recurring_debits
+            SET run_count = run_count + 1,
+                next_run_at = CASE WHEN cancelled_at IS NULL THEN $3::timestamptz END,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1 AND run_count = $2`}
+)
+
+// CreateRecurringDebit stores a new recurring debit, due at its start
+func (r *recurringDebitRepository) CreateRecurringDebit(ctx context.Context, debit *models.RecurringDebit) error {
+	debit.ID = uuid.New()
+	debit.CreatedAt = time.Now().UTC()
+	debit.UpdatedAt = debit.CreatedAt
+
+	_, err := r.statements.ExecContext(ctx, createRecurringDebitQuery,
+		debit.ID,
+		debit.CustomerID,
+		debit.WalletID,
+		debit.Amount,
+		debit.Currency,
+		debit.Description,
+		debit.Cadence,
+		debit.StartAt,
+		debit.EndAt,
+		debit.NextRunAt,
+		debit.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create recurring debit: %w", err)
+	}
+
+	return nil
+}
+
+// GetRecurringDebit retrieves one of a customer's recurring debits
+func (r *recurringDebitRepository) GetRecurringDebit(ctx context.Context, customerID, id uuid.UUID) (*models.RecurringDebit, error) {
+	debit, err := scanRecurringDebit(r.statements.QueryRowContext(ctx, getRecurringDebitQuery, id, customerID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecurringDebitNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return debit, nil
+}
+
+// ListRecurringDebits retrieves all of a customer's recurring debits
+func (r *recurringDebitRepository) ListRecurringDebits(ctx context.Context, customerID uuid.UUID) ([]*models.RecurringDebit, error) {
+	return r.query(ctx, listRecurringDebitsQuery, customerID)
+}
+
+// CancelRecurringDebit stops a customer's recurring debit from running
+// again. Cancelling twice keeps the first cancellation time.
+func (r *recurringDebitRepository) CancelRecurringDebit(ctx context.Context, customerID, id uuid.UUID, now time.Time) (*models.RecurringDebit, error) {
+	debit, err := scanRecurringDebit(r.statements.QueryRowContext(ctx, cancelRecurringDebitQuery, id, customerID, now))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecurringDebitNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return debit, nil
+}
+
+// ListDue retrieves the recurring debits whose next run is due, oldest first
+func (r *recurringDebitRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*models.RecurringDebit, error) {
+	return r.query(ctx, recurringDebitListDueQuery, now, limit)
+}
+
+// ListRuns retrieves the latest runs of a recurring debit, newest first
+func (r *recurringDebitRepository) ListRuns(ctx context.Context, id uuid.UUID, limit int) ([]*models.RecurringDebitRun, error) {
+	rows, err := r.statements.QueryContext(ctx, listRunsQuery, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recurring debit runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*models.RecurringDebitRun
+	for rows.Next() {
+		run := &models.RecurringDebitRun{}
+		var transactionID uuid.NullUUID
+		err := rows.Scan(
+			&run.RecurringDebitID,
+			&run.ScheduledFor,
+			&run.IdempotencyKey,
+			&run.Status,
+			&transactionID,
+			&run.Error,
+			&run.ClaimedAt,
+			&run.FinishedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan recurring debit run: %w", err)
+		}
+		if transactionID.Valid {
+			run.TransactionID = &transactionID.UUID
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recurring debit runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// ClaimRun records a run as pending and reports whether the caller may
+// execute it: the run was not claimed before, or its pending claim predates
+// staleBefore. Finished runs are never claimed again.
+func (r *recurringDebitRepository) ClaimRun(ctx context.Context, run *models.RecurringDebitRun, staleBefore time.Time) (bool, error) {
+	result, err := r.statements.ExecContext(ctx, claimRunQuery,
+		run.RecurringDebitID,
+		run.ScheduledFor,
+		run.IdempotencyKey,
+		run.ClaimedAt,
+		staleBefore,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim recurring debit run: %w", err)
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get claimed count: %w", err)
+	}
+
+	return claimed > 0, nil
+}
+
+// ReleaseRun removes the pending claim of a run so the next scheduler pass
+// executes it
+func (r *recurringDebitRepository) ReleaseRun(ctx context.Context, run *models.RecurringDebitRun) error {
+	_, err := r.statements.ExecContext(ctx, releaseRunQuery, run.RecurringDebitID, run.ScheduledFor)
+	if err != nil {
+		return fmt.Errorf("failed to release recurring debit run: %w", err)
+	}
+
+	return nil
+}
+
+// FinishRun records the outcome of a pending run and advances its recurring
+// debit past run runCount to the next run, nil when none is left, in one
+// transaction
+func (r *recurringDebitRepository) FinishRun(ctx context.Context, run *models.RecurringDebitRun, runCount int, nextRunAt *time.Time) error {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	_, err = statements.ExecContext(ctx, finishRunQuery,
+		run.RecurringDebitID,
+		run.ScheduledFor,
+		run.Status,
+		run.TransactionID,
+		run.Error,
+		run.FinishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish recurring debit run: %w", err)
+	}
+
+	_, err = statements.ExecContext(ctx, recurringDebitAdvanceQuery, run.RecurringDebitID, runCount, nextRunAt)
+	if err != nil {
+		return fmt.Errorf("failed to advance recurring debit: %w", err)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit recurring debit run: %w", err)
+	}
+
+	return nil
+}
+
+// RecoverRun marks a failed run succeeded with the transaction debited on a
+// retry. Runs no longer failed are left as they are.
+func (r *recurringDebitRepository) RecoverRun(ctx context.Context, run *models.RecurringDebitRun) error {
+	_, err := r.statements.ExecContext(ctx, recoverRunQuery,
+		run.RecurringDebitID,
+		run.ScheduledFor,
+		run.TransactionID,
+		run.FinishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to recover recurring debit run: %w", err)
+	}
+
+	return nil
+}
+
+// query runs a statement selecting recurringDebitColumns
+func (r *recurringDebitRepository) query(ctx context.Context, q namedQuery, args ...interface{}) ([]*models.RecurringDebit, error) {
+	rows, err := r.statements.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recurring debits: %w", err)
+	}
+	defer rows.Close()
+
+	var debits []*models.RecurringDebit
+	for rows.Next() {
+		debit, err := scanRecurringDebit(rows)
+		if err != nil {
+			return nil, err
+		}
+		debits = append(debits, debit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recurring debits: %w", err)
+	}
+
+	return debits, nil
+}
+
+// scanRecurringDebit scans a recurring debit selected with recurringDebitColumns
+func scanRecurringDebit(row rowScanner) (*models.RecurringDebit, error) {
+	debit := &models.RecurringDebit{}
+	err := row.Scan(
+		&debit.ID,
+		&debit.CustomerID,
+		&debit.WalletID,
+		&debit.Amount,
+		&debit.Currency,
+		&debit.Description,
+		&debit.Cadence,
+		&debit.StartAt,
+		&debit.EndAt,
+		&debit.RunCount,
+		&debit.NextRunAt,
+		&debit.CancelledAt,
+		&debit.CreatedAt,
+		&debit.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan recurring debit: %w", err)
+	}
+
+	return debit, nil
+}
@@ -0,0 +1,401 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+)
+
+// Refund repository errors
+var (
+	ErrPaymentNotFound      = errors.New("top-up has no provider payment")
+	ErrPaymentNotLinkable   = errors.New("transaction is not an unlinked top-up of the wallet")
+	ErrRefundExceedsPayment = errors.New("refund exceeds the unrefunded payment amount")
+	ErrRefundNotFound       = errors.New("refund not found")
+	ErrRefundNotPending     = errors.New("refund is no longer pending")
+)
+
+// refundColumns is the column list scanned by scanRefund
+const refundColumns = `id, transaction_id, original_transaction_id, wallet_id, provider, provider_payment_id,
+                   COALESCE(provider_refund_id, ''), amount, currency, status, COALESCE(failure_reason, ''),
+                   attempts, next_check_at, created_at, updated_at`
+
+// RefundRepository defines the interface for refund-to-source persistence
+type RefundRepository interface {
+	RecordPayment(ctx context.Context, walletID uuid.UUID, payment *models.ProviderPayment) error
+	GetPaymentCurrency(ctx context.Context, walletID, transactionID uuid.UUID) (string, error)
+	CreateRefund(ctx context.Context, refund *models.ProviderRefund, description string) error
+	GetRefund(ctx context.Context, walletID, id uuid.UUID) (*models.ProviderRefund, error)
+	ListDueRefunds(ctx context.Context, now time.Time, limit int) ([]*models.ProviderRefund, error)
+	MarkSubmitted(ctx context.Context, id uuid.UUID, providerRefundID string, nextCheckAt time.Time) error
+	ScheduleCheck(ctx context.Context, id uuid.UUID, nextCheckAt time.Time) error
+	DeferRefund(ctx context.Context, id uuid.UUID, nextCheckAt time.Time) error
+	CompleteRefund(ctx context.Context, id uuid.UUID) error
+	FailRefund(ctx context.Context, id uuid.UUID, reason string) error
+	Close() error
+}
+
+// refundRepository implements RefundRepository interface
+type refundRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewRefundRepository creates a new instance of RefundRepository
+func NewRefundRepository(db *sql.DB) (RefundRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &refundRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *refundRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	// recordPaymentQuery links a top-up of a wallet to its provider payment
+	recordPaymentQuery = namedQuery{name: "recordPayment", sql: `
+            INSERT INTO provider_payments (transaction_id, provider, provider_payment_id, created_at)
+            SELECT id, $3, $4, $5
+            FROM wallet_transactions
+            WHERE id = $1 AND wallet_id = $2 AND type = 'CREDIT'
+            ON CONFLICT DO NOTHING`}
+
+	// paymentCurrencyQuery reads the currency of a top-up with a provider
+	// payment
+	paymentCurrencyQuery = namedQuery{name: "paymentCurrency", sql: `
+            SELECT t.currency
+            FROM wallet_transactions t
+            JOIN provider_payments p ON p.transaction_id = t.id
+            WHERE t.id = $1 AND t.wallet_id = $2`}
+
+	// lockPaymentQuery reads a top-up and its provider payment, locking the
+	// top-up until the transaction ends
+	lockPaymentQuery = namedQuery{name: "lockPayment", sql: `
+            SELECT t.amount, t.currency, p.provider, p.provider_payment_id
+            FROM wallet_transactions t
+            JOIN provider_payments p ON p.transaction_id = t.id
+            WHERE t.id = $1 AND t.wallet_id = $2
+            FOR UPDATE OF t`}
+
+	// refundedAmountQuery sums the refunds of a top-up not failed
+	refundedAmountQuery = namedQuery{name: "refundedAmount", sql: `
+            SELECT COALESCE(SUM(amount), 0)
+            FROM provider_refunds
+            WHERE original_transaction_id = $1 AND status <> 'FAILED'`}
+
+	// insertRefundQuery records a pending refund
+	insertRefundQuery = namedQuery{name: "insertRefund", sql: `
+            INSERT INTO provider_refunds (id, transaction_id, original_transaction_id, wallet_id, provider,
+                                          provider_payment_id, amount, currency, status, next_check_at,
+                                          created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'PENDING', $9, $9, $9)`}
+
+	// getRefundQuery reads a refund of a wallet
+	getRefundQuery = namedQuery{name: "getRefund", sql: `
+            SELECT ` + refundColumns + `
+            FROM provider_refunds
+            WHERE id = $1 AND wallet_id = $2`}
+
+	// listDueRefundsQuery reads the pending refunds due for submission or a
+	// status check
+	listDueRefundsQuery = namedQuery{name: "listDueRefunds", sql: `
+            SELECT ` + refundColumns + `
+            FROM provider_refunds
+            WHERE status = 'PENDING' AND next_check_at <= $1
+              AND NOT EXISTS (
+                  SELECT 1 FROM wallet_migrations m
+                  WHERE m.wallet_id = provider_refunds.wallet_id AND m.status = 'FROZEN'
+              )
+            ORDER BY next_check_at
+            LIMIT $2`}
+
+	// markSubmittedQuery records the provider's ID of a pending refund
+	markSubmittedQuery = namedQuery{name: "markSubmitted", sql: `
+            UPDATE provider_refunds
+            SET provider_refund_id = $2, attempts = attempts + 1, next_check_at = $3, updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1 AND status = 'PENDING'`}
+
+	// scheduleCheckQuery counts an attempt of a pending refund
+	scheduleCheckQuery = namedQuery{name: "scheduleCheck", sql: `
+            UPDATE provider_refunds
+            SET attempts = attempts + 1, next_check_at = $2, updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1 AND status = 'PENDING'`}
+
+	// deferRefundQuery postpones a pending refund
+	deferRefundQuery = namedQuery{name: "deferRefund", sql: `
+            UPDATE provider_refunds
+            SET next_check_at = $2, updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1 AND status = 'PENDING'`}
+
+	// settleRefundQuery moves a pending refund to a final status
+	settleRefundQuery = namedQuery{name: "settleRefund", sql: `
+            UPDATE provider_refunds
+            SET status = $2, failure_reason = NULLIF($3, ''), updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1 AND status = 'PENDING'
+            RETURNING transaction_id, wallet_id, amount, currency`}
+
+	// settleRefundTransactionQuery completes or fails the hold of a refund
+	settleRefundTransactionQuery = namedQuery{name: "settleRefundTransaction", sql: `
+            UPDATE wallet_transactions
+            SET status = $2
+            WHERE id = $1 AND status = 'PROCESSING'`}
+)
+
+// RecordPayment links a wallet's top-up to the provider payment behind it.
+// Each top-up and each provider payment can be linked once.
+func (r *refundRepository) RecordPayment(ctx context.Context, walletID uuid.UUID, payment *models.ProviderPayment) error {
+	payment.CreatedAt = time.Now().UTC()
+
+	result, err := r.statements.ExecContext(ctx, recordPaymentQuery,
+		payment.TransactionID,
+		walletID,
+		payment.Provider,
+		payment.ProviderPaymentID,
+		payment.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record provider payment: %w", err)
+	}
+
+	recorded, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get recorded count: %w", err)
+	}
+	if recorded == 0 {
+		return ErrPaymentNotLinkable
+	}
+
+	return nil
+}
+
+// GetPaymentCurrency returns the currency of a wallet's top-up linked to a
+// provider payment
+func (r *refundRepository) GetPaymentCurrency(ctx context.Context, walletID, transactionID uuid.UUID) (string, error) {
+	var code string
+	err := r.statements.QueryRowContext(ctx, paymentCurrencyQuery, transactionID, walletID).Scan(&code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrPaymentNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get top-up currency: %w", err)
+	}
+
+	return code, nil
+}
+
+// CreateRefund holds the refund amount from the wallet with a PROCESSING
+// debit transaction and records a PENDING refund of the top-up's payment in
+// one unit of work. The refund's WalletID, OriginalTransactionID and Amount
+// must be set; the remaining fields are filled in.
+func (r *refundRepository) CreateRefund(ctx context.Context, refund *models.ProviderRefund, description string) error {
+	return inWalletTx(ctx, r.db, r.statements, func(tx *walletTx) error {
+		// Lock the top-up so concurrent refunds cannot exceed its amount
+		var paid decimal.Decimal
+		err := tx.statements.QueryRowContext(ctx, lockPaymentQuery,
+			refund.OriginalTransactionID,
+			refund.WalletID,
+		).Scan(&paid, &refund.Currency, &refund.Provider, &refund.ProviderPaymentID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPaymentNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock top-up: %w", err)
+		}
+
+		var refunded decimal.Decimal
+		err = tx.statements.QueryRowContext(ctx, refundedAmountQuery,
+			refund.OriginalTransactionID,
+		).Scan(&refunded)
+		if err != nil {
+			return fmt.Errorf("failed to sum refunds: %w", err)
+		}
+		if refunded.Add(refund.Amount).GreaterThan(paid) {
+			return ErrRefundExceedsPayment
+		}
+
+		hold, err := placeHold(ctx, tx, refund.WalletID, refund.Amount, refund.Currency,
+			description, refund.OriginalTransactionID.String())
+		if err != nil {
+			return err
+		}
+
+		refund.ID = uuid.New()
+		refund.TransactionID = hold.ID
+		refund.Status = models.ProviderRefundPending
+		refund.NextCheckAt = hold.CreatedAt
+		refund.CreatedAt = hold.CreatedAt
+		refund.UpdatedAt = hold.CreatedAt
+
+		_, err = tx.statements.ExecContext(ctx, insertRefundQuery,
+			refund.ID,
+			refund.TransactionID,
+			refund.OriginalTransactionID,
+			refund.WalletID,
+			refund.Provider,
+			refund.ProviderPaymentID,
+			refund.Amount,
+			refund.Currency,
+			refund.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert refund: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetRefund retrieves one of a wallet's refunds
+func (r *refundRepository) GetRefund(ctx context.Context, walletID, id uuid.UUID) (*models.ProviderRefund, error) {
+	refund, err := scanRefund(r.statements.QueryRowContext(ctx, getRefundQuery, id, walletID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRefundNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// ListDueRefunds retrieves pending refunds due for submission or a status
+// check. Refunds of wallets frozen for migration wait for their activation.
+func (r *refundRepository) ListDueRefunds(ctx context.Context, now time.Time, limit int) ([]*models.ProviderRefund, error) {
+	rows, err := r.statements.QueryContext(ctx, listDueRefundsQuery, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due refunds: %w", err)
+	}
+	defer rows.Close()
+
+	var refunds []*models.ProviderRefund
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating refunds: %w", err)
+	}
+
+	return refunds, nil
+}
+
+// MarkSubmitted records the provider's refund ID and when to check it next
+func (r *refundRepository) MarkSubmitted(ctx context.Context, id uuid.UUID, providerRefundID string, nextCheckAt time.Time) error {
+	return r.execPending(ctx, markSubmittedQuery, id, providerRefundID, nextCheckAt)
+}
+
+// ScheduleCheck counts a submission or status check attempt and schedules the next
+func (r *refundRepository) ScheduleCheck(ctx context.Context, id uuid.UUID, nextCheckAt time.Time) error {
+	return r.execPending(ctx, scheduleCheckQuery, id, nextCheckAt)
+}
+
+// DeferRefund postpones a refund's next submission or check without
+// counting an attempt
+func (r *refundRepository) DeferRefund(ctx context.Context, id uuid.UUID, nextCheckAt time.Time) error {
+	return r.execPending(ctx, deferRefundQuery, id, nextCheckAt)
+}
+
+// CompleteRefund marks a pending refund SUCCEEDED and its wallet transaction COMPLETED
+func (r *refundRepository) CompleteRefund(ctx context.Context, id uuid.UUID) error {
+	return r.settle(ctx, id, models.ProviderRefundSucceeded, "")
+}
+
+// FailRefund marks a pending refund and its wallet transaction FAILED and
+// returns the held funds to the wallet
+func (r *refundRepository) FailRefund(ctx context.Context, id uuid.UUID, reason string) error {
+	return r.settle(ctx, id, models.ProviderRefundFailed, reason)
+}
+
+// settle moves a pending refund to a final status in one unit of work
+func (r *refundRepository) settle(ctx context.Context, id uuid.UUID, status models.ProviderRefundStatus, reason string) error {
+	return inWalletTx(ctx, r.db, r.statements, func(tx *walletTx) error {
+		var transactionID, walletID uuid.UUID
+		var amount decimal.Decimal
+		var currency string
+		err := tx.statements.QueryRowContext(ctx, settleRefundQuery, id, status, reason).
+			Scan(&transactionID, &walletID, &amount, &currency)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRefundNotPending
+		}
+		if err != nil {
+			return fmt.Errorf("failed to settle refund: %w", err)
+		}
+
+		txStatus := models.TransactionStatusCompleted
+		if status == models.ProviderRefundFailed {
+			txStatus = models.TransactionStatusFailed
+			if err := releaseHold(ctx, tx, walletID, amount, currency); err != nil {
+				return fmt.Errorf("failed to release refund funds: %w", err)
+			}
+		}
+
+		if _, err := tx.statements.ExecContext(ctx, settleRefundTransactionQuery, transactionID, txStatus.String()); err != nil {
+			return fmt.Errorf("failed to settle refund transaction: %w", err)
+		}
+		return nil
+	})
+}
+
+// execPending runs an update of a pending refund
+func (r *refundRepository) execPending(ctx context.Context, q namedQuery, args ...interface{}) error {
+	result, err := r.statements.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update refund: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get updated count: %w", err)
+	}
+	if updated == 0 {
+		return ErrRefundNotPending
+	}
+
+	return nil
+}
+
+// scanRefund scans a refund selected with refundColumns
+func scanRefund(row rowScanner) (*models.ProviderRefund, error) {
+	refund := &models.ProviderRefund{}
+	err := row.Scan(
+		&refund.ID,
+		&refund.TransactionID,
+		&refund.OriginalTransactionID,
+		&refund.WalletID,
+		&refund.Provider,
+		&refund.ProviderPaymentID,
+		&refund.ProviderRefundID,
+		&refund.Amount,
+		&refund.Currency,
+		&refund.Status,
+		&refund.FailureReason,
+		&refund.Attempts,
+		&refund.NextCheckAt,
+		&refund.CreatedAt,
+		&refund.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan refund: %w", err)
+	}
+
+	return refund, nil
+}
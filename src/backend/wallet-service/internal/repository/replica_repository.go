@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ReplicaRepository reads the replication state of a read replica
+type ReplicaRepository interface {
+	// ReplicationLag returns how far the replica's replayed data is behind
+	// the primary
+	ReplicationLag(ctx context.Context) (time.Duration, error)
+}
+
+// replicaRepository implements ReplicaRepository interface
+type replicaRepository struct {
+	db *sql.DB
+}
+
+// NewReplicaRepository creates a new instance of ReplicaRepository for a
+// connection to a read replica
+func NewReplicaRepository(db *sql.DB) (ReplicaRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &replicaRepository{db: db}, nil
+}
+
+// ReplicationLag returns the age of the last replayed transaction. A replica
+// that has replayed everything it received reports no lag, so an idle primary
+// does not make it look stale.
+func (r *replicaRepository) ReplicationLag(ctx context.Context) (time.Duration, error) {
+	var seconds float64
+	err := r.db.QueryRowContext(ctx, `
+        SELECT CASE
+            WHEN NOT pg_is_in_recovery() THEN 0
+            WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+            ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+        END`).Scan(&seconds)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get replication lag: %w", err)
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// primaryKey is the context key marking reads that must see the primary
+type primaryKey struct{}
+
+// ReadPrimary returns a context whose reads are served by the primary, for
+// reads that a write is about to be based on
+func ReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// RequiresPrimary reports whether the reads of a context must be served by
+// the primary
+func RequiresPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// Report job repository errors
+var (
+	ErrReportJobNotFound   = errors.New("report job not found")
+	ErrReportJobNotRunning = errors.New("report job is no longer running")
+)
+
+// reportJobColumns are the columns scanned by scanReportJob
+const reportJobColumns = `id, kind, status, params, progress, attempts, max_attempts, result,
+            COALESCE(error, ''), submitted_by, cancel_requested, run_after, heartbeat_at,
+            created_at, started_at, finished_at`
+
+// ReportJobRepository defines the interface for report job persistence
+type ReportJobRepository interface {
+	CreateJob(ctx context.Context, job *models.ReportJob) error
+	GetJob(ctx context.Context, id uuid.UUID) (*models.ReportJob, error)
+	ListJobs(ctx context.Context, kind string, status models.ReportJobStatus, limit, offset int) ([]*models.ReportJob, error)
+	ClaimJob(ctx context.Context, now, staleBefore time.Time) (*models.ReportJob, error)
+	Heartbeat(ctx context.Context, id uuid.UUID, progress int, now time.Time) (bool, error)
+	FinishJob(ctx context.Context, job *models.ReportJob) error
+	RequestCancel(ctx context.Context, id uuid.UUID, now time.Time) (*models.ReportJob, error)
+	PurgeJobs(ctx context.Context, before time.Time) (int64, error)
+	Close() error
+}
+
+// reportJobRepository implements ReportJobRepository interface
+type reportJobRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewReportJobRepository creates a new instance of ReportJobRepository
+func NewReportJobRepository(db *sql.DB) (ReportJobRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &reportJobRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *reportJobRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	createJobQuery = namedQuery{name: "createJob", sql: `
+            INSERT INTO report_jobs (id, kind, status, params, max_attempts, submitted_by, run_after, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`}
+
+	getJobQuery = namedQuery{name: "getJob", sql: `
+            SELECT ` + reportJobColumns + `
+            FROM report_jobs
+            WHERE id = $1`}
+
+	listJobsQuery = namedQuery{name: "listJobs", sql: `
+            SELECT ` + reportJobColumns + `
+            FROM report_jobs
+            WHERE ($1 = '' OR kind = $1) AND ($2 = '' OR status = $2)
+            ORDER BY created_at DESC, id
+            LIMIT $3 OFFSET $4`}
+
+	// A running job whose heartbeat is older than staleBefore belongs to
+	// a worker that stopped, and is taken over as another attempt
+	claimJobQuery = namedQuery{name: "claimJob", sql: `
+            UPDATE report_jobs
+            SET status = 'RUNNING',
+                attempts = attempts + 1,
+                progress = 0,
+                heartbeat_at = $1,
+                started_at = COALESCE(started_at, $1)
+            WHERE id = (
+                SELECT id FROM report_jobs
+                WHERE (status = 'QUEUED' AND run_after <= $1)
+                   OR (status = 'RUNNING' AND heartbeat_at < $2)
+                ORDER BY run_after, created_at
+                LIMIT 1
+                FOR UPDATE SKIP LOCKED
+            )
+            RETURNING ` + reportJobColumns}
+
+	reportJobHeartbeatQuery = namedQuery{name: "reportJobHeartbeat", sql: `
+            UPDATE report_jobs
+            SET progress = $2, heartbeat_at = $3
+            WHERE id = $1 AND status = 'RUNNING'
+            RETURNING cancel_requested`}
+
+	finishJobQuery = namedQuery{name: "finishJob", sql: `
+            UPDATE report_jobs
+            SET status = $2, progress = $3, result = $4, error = NULLIF($5, ''),
+                run_after = $6, finished_at = $7, heartbeat_at = NULL
+            WHERE id = $1 AND status = 'RUNNING'`}
+
+	requestCancelQuery = namedQuery{name: "requestCancel", sql: `
+            UPDATE report_jobs
+            SET cancel_requested = true,
+                status = CASE WHEN status = 'QUEUED' THEN 'CANCELLED' ELSE status END,
+                finished_at = CASE WHEN status = 'QUEUED' THEN $2 ELSE finished_at END
+            WHERE id = $1 AND status IN ('QUEUED', 'RUNNING')
+            RETURNING ` + reportJobColumns}
+
+	purgeJobsQuery = namedQuery{name: "purgeJobs", sql: `
+            DELETE FROM report_jobs
+            WHERE status IN ('SUCCEEDED', 'FAILED', 'CANCELLED') AND finished_at < $1`}
+)
+
+// CreateJob queues a new job
+func (r *reportJobRepository) CreateJob(ctx context.Context, job *models.ReportJob) error {
+	_, err := r.statements.ExecContext(ctx, createJobQuery,
+		job.ID,
+		job.Kind,
+		job.Status,
+		nullJSON(job.Params),
+		job.MaxAttempts,
+		job.SubmittedBy,
+		job.RunAfter,
+		job.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create report job: %w", err)
+	}
+	return nil
+}
+
+// GetJob retrieves a job with its result
+func (r *reportJobRepository) GetJob(ctx context.Context, id uuid.UUID) (*models.ReportJob, error) {
+	job, err := scanReportJob(r.statements.QueryRowContext(ctx, getJobQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReportJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get report job: %w", err)
+	}
+	return job, nil
+}
+
+// ListJobs retrieves jobs newest first, optionally of one kind or status
+func (r *reportJobRepository) ListJobs(ctx context.Context, kind string, status models.ReportJobStatus, limit, offset int) ([]*models.ReportJob, error) {
+	rows, err := r.statements.QueryContext(ctx, listJobsQuery, kind, string(status), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list report jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*models.ReportJob
+	for rows.Next() {
+		job, err := scanReportJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan report job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate report jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// ClaimJob takes the next runnable job for the caller, returning nil when
+// there is none. Concurrent workers never claim the same job.
+func (r *reportJobRepository) ClaimJob(ctx context.Context, now, staleBefore time.Time) (*models.ReportJob, error) {
+	job, err := scanReportJob(r.statements.QueryRowContext(ctx, claimJobQuery, now, staleBefore))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim report job: %w", err)
+	}
+	return job, nil
+}
+
+// Heartbeat records the progress of a running job and reports whether its
+// cancellation was requested. ErrReportJobNotRunning is returned once the
+// job was finished or cancelled elsewhere.
+func (r *reportJobRepository) Heartbeat(ctx context.Context, id uuid.UUID, progress int, now time.Time) (bool, error) {
+	var cancelRequested bool
+	err := r.statements.QueryRowContext(ctx, reportJobHeartbeatQuery, id, progress, now).Scan(&cancelRequested)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrReportJobNotRunning
+		}
+		return false, fmt.Errorf("failed to record report job heartbeat: %w", err)
+	}
+	return cancelRequested, nil
+}
+
+// FinishJob stores the outcome of a run: a final status, or QUEUED with a
+// later run time for a retry
+func (r *reportJobRepository) FinishJob(ctx context.Context, job *models.ReportJob) error {
+	result, err := r.statements.ExecContext(ctx, finishJobQuery,
+		job.ID,
+		job.Status,
+		job.Progress,
+		nullJSON(job.Result),
+		job.Error,
+		job.RunAfter,
+		job.FinishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish report job: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get updated count: %w", err)
+	}
+	if updated == 0 {
+		return ErrReportJobNotRunning
+	}
+	return nil
+}
+
+// RequestCancel cancels a queued job at once and flags a running job to
+// stop at its next heartbeat. ErrReportJobNotRunning is returned for
+// finished jobs.
+func (r *reportJobRepository) RequestCancel(ctx context.Context, id uuid.UUID, now time.Time) (*models.ReportJob, error) {
+	job, err := scanReportJob(r.statements.QueryRowContext(ctx, requestCancelQuery, id, now))
+	if err == nil {
+		return job, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to cancel report job: %w", err)
+	}
+
+	if _, err := r.GetJob(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, ErrReportJobNotRunning
+}
+
+// PurgeJobs deletes jobs finished before the given time and returns how
+// many were deleted
+func (r *reportJobRepository) PurgeJobs(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.statements.ExecContext(ctx, purgeJobsQuery, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge report jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// scanReportJob scans a report job row
+func scanReportJob(row rowScanner) (*models.ReportJob, error) {
+	var job models.ReportJob
+	var params, result []byte
+	err := row.Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Status,
+		&params,
+		&job.Progress,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&result,
+		&job.Error,
+		&job.SubmittedBy,
+		&job.CancelRequested,
+		&job.RunAfter,
+		&job.HeartbeatAt,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	job.Params = params
+	job.Result = result
+	return &job, nil
+}
+
+// nullJSON stores empty JSON documents as NULL
+func nullJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"internal/models"
+)
+
+// RequestCaptureRepository defines the interface for the audit store of
+// captured request bodies
+type RequestCaptureRepository interface {
+	RecordCapture(ctx context.Context, capture *models.RequestCapture) error
+	PurgeCaptures(ctx context.Context, before time.Time) (int64, error)
+	Close() error
+}
+
+// requestCaptureRepository implements RequestCaptureRepository interface
+type requestCaptureRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewRequestCaptureRepository creates a new instance of RequestCaptureRepository
+func NewRequestCaptureRepository(db *sql.DB) (RequestCaptureRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &requestCaptureRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *requestCaptureRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	recordCaptureQuery = namedQuery{name: "recordCapture", sql: `
+            INSERT INTO request_captures (id, correlation_id, method, route, path, status, customer_id, subject,
+                                          request_body, response_body, truncated, duration_ms, captured_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, $11, $12, $13)`}
+
+	purgeCapturesQuery = namedQuery{name: "purgeCaptures", sql: `
+            DELETE FROM request_captures
+            WHERE captured_at < $1`}
+)
+
+// RecordCapture stores a redacted capture
+func (r *requestCaptureRepository) RecordCapture(ctx context.Context, capture *models.RequestCapture) error {
+	_, err := r.statements.ExecContext(ctx, recordCaptureQuery,
+		capture.ID,
+		capture.CorrelationID,
+		capture.Method,
+		capture.Route,
+		capture.Path,
+		capture.Status,
+		capture.CustomerID,
+		capture.Subject,
+		capture.RequestBody,
+		capture.ResponseBody,
+		capture.Truncated,
+		capture.DurationMs,
+		capture.CapturedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record request capture: %w", err)
+	}
+	return nil
+}
+
+// PurgeCaptures deletes captures taken before the given time and returns
+// how many were deleted
+func (r *requestCaptureRepository) PurgeCaptures(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.statements.ExecContext(ctx, purgeCapturesQuery, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge request captures: %w", err)
+	}
+	return result.RowsAffected()
+}
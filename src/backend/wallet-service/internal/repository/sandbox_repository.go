@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"internal/models"
+)
+
+// SandboxRepository defines the interface for sandbox demo data
+type SandboxRepository interface {
+	CloneWallet(ctx context.Context, wallet *models.Wallet, transactions []*models.Transaction) error
+	Close() error
+}
+
+// sandboxRepository implements SandboxRepository interface
+type sandboxRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewSandboxRepository creates a new instance of SandboxRepository
+func NewSandboxRepository(db *sql.DB) (SandboxRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &sandboxRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *sandboxRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	sandboxInsertWalletQuery = namedQuery{name: "sandboxInsertWallet", sql: `
+            INSERT INTO wallets (id, customer_id, balance, currency, low_balance_threshold, credit_limit,
+                               created_at, updated_at, version)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 1)`}
+
+	sandboxInsertTransactionQuery = namedQuery{name: "sandboxInsertTransaction", sql: `
+            INSERT INTO wallet_transactions (id, wallet_id, type, status, amount,
+                                          currency, description, reference_id, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`}
+)
+
+// CloneWallet atomically stores a wallet and its transactions as given,
+// keeping their IDs and timestamps. The transactions must add up to the
+// wallet balance so the ledger reconciles.
+func (r *sandboxRepository) CloneWallet(ctx context.Context, wallet *models.Wallet, transactions []*models.Transaction) error {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	_, err = statements.ExecContext(ctx, sandboxInsertWalletQuery,
+		wallet.ID,
+		wallet.CustomerID,
+		wallet.Balance,
+		wallet.Currency,
+		wallet.LowBalanceThreshold,
+		wallet.CreditLimit,
+		wallet.CreatedAt,
+		wallet.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert wallet: %w", err)
+	}
+	wallet.Version = 1
+
+	for _, tx := range transactions {
+		_, err = statements.ExecContext(ctx, sandboxInsertTransactionQuery,
+			tx.ID,
+			tx.WalletID,
+			tx.Type,
+			tx.Status,
+			tx.Amount,
+			tx.Currency,
+			tx.Description,
+			tx.ReferenceID,
+			tx.CreatedAt,
+			tx.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert transaction: %w", err)
+		}
+	}
+
+	return dbTx.Commit()
+}
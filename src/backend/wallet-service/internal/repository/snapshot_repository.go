@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// SnapshotRepository defines the interface for wallet balance snapshots
+type SnapshotRepository interface {
+	CreateSnapshots(ctx context.Context, asOf time.Time) (int64, error)
+	LatestSnapshotAt(ctx context.Context) (time.Time, error)
+	GetBalanceAsOf(ctx context.Context, walletID uuid.UUID, asOf time.Time) (*models.HistoricalBalance, error)
+	Close() error
+}
+
+// snapshotRepository implements SnapshotRepository interface
+type snapshotRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewSnapshotRepository creates a new instance of SnapshotRepository
+func NewSnapshotRepository(db *sql.DB) (SnapshotRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &snapshotRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *snapshotRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	createSnapshotsQuery = namedQuery{name: "createSnapshots", sql: `
+            INSERT INTO wallet_balance_snapshots (wallet_id, as_of, balance, currency)
+            SELECT w.id, $1, COALESCE(s.balance, 0) + COALESCE(d.delta, 0), w.currency
+            FROM wallets w
+            LEFT JOIN LATERAL (
+                SELECT balance, as_of
+                FROM wallet_balance_snapshots
+                WHERE wallet_id = w.id AND as_of < $1
+                ORDER BY as_of DESC
+                LIMIT 1
+            ) s ON true
+            LEFT JOIN LATERAL (
+                SELECT SUM(` + signedAmountSQL + `) AS delta
+                FROM wallet_transactions t
+                WHERE t.wallet_id = w.id AND t.status = 'COMPLETED'
+                  AND t.created_at >= COALESCE(s.as_of, '-infinity') AND t.created_at < $1
+            ) d ON true
+            WHERE w.created_at < $1
+            ON CONFLICT (wallet_id, as_of) DO UPDATE
+            SET balance = EXCLUDED.balance`}
+
+	latestSnapshotAtQuery = namedQuery{name: "latestSnapshotAt", sql: `
+            SELECT MAX(as_of)
+            FROM wallet_balance_snapshots`}
+
+	getBalanceAsOfQuery = namedQuery{name: "getBalanceAsOf", sql: `
+            SELECT w.currency, s.as_of, COALESCE(s.balance, 0) + COALESCE(d.delta, 0), COALESCE(d.replayed, 0)
+            FROM wallets w
+            LEFT JOIN LATERAL (
+                SELECT balance, as_of
+                FROM wallet_balance_snapshots
+                WHERE wallet_id = w.id AND as_of <= $2
+                ORDER BY as_of DESC
+                LIMIT 1
+            ) s ON true
+            LEFT JOIN LATERAL (
+                SELECT SUM(` + signedAmountSQL + `) AS delta,
+                       COUNT(*) AS replayed
+                FROM wallet_transactions t
+                WHERE t.wallet_id = w.id AND t.status = 'COMPLETED'
+                  AND t.created_at >= COALESCE(s.as_of, '-infinity') AND t.created_at < $2
+            ) d ON true
+            WHERE w.id = $1`}
+)
+
+// CreateSnapshots records every wallet's balance at asOf by rolling its
+// previous snapshot forward with the completed transactions since. Running it
+// again for the same instant overwrites the snapshots.
+func (r *snapshotRepository) CreateSnapshots(ctx context.Context, asOf time.Time) (int64, error) {
+	result, err := r.statements.ExecContext(ctx, createSnapshotsQuery, asOf)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create balance snapshots: %w", err)
+	}
+
+	created, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get snapshot count: %w", err)
+	}
+
+	return created, nil
+}
+
+// LatestSnapshotAt returns the instant of the most recent snapshot, or the
+// zero time when no snapshot exists
+func (r *snapshotRepository) LatestSnapshotAt(ctx context.Context) (time.Time, error) {
+	var latest sql.NullTime
+	if err := r.statements.QueryRowContext(ctx, latestSnapshotAtQuery).Scan(&latest); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get latest snapshot: %w", err)
+	}
+	if !latest.Valid {
+		return time.Time{}, nil
+	}
+
+	return latest.Time, nil
+}
+
+// GetBalanceAsOf returns the wallet balance at asOf from the latest snapshot
+// at or before asOf plus the completed transactions created since
+func (r *snapshotRepository) GetBalanceAsOf(ctx context.Context, walletID uuid.UUID, asOf time.Time) (*models.HistoricalBalance, error) {
+	balance := &models.HistoricalBalance{
+		WalletID: walletID,
+		AsOf:     asOf,
+	}
+
+	var snapshotAt sql.NullTime
+	err := r.statements.QueryRowContext(ctx, getBalanceAsOfQuery, walletID, asOf).Scan(
+		&balance.Currency,
+		&snapshotAt,
+		&balance.Balance,
+		&balance.ReplayedTransactions,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWalletNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance as of %s: %w", asOf.Format(time.RFC3339), err)
+	}
+	if snapshotAt.Valid {
+		balance.SnapshotAt = &snapshotAt.Time
+	}
+
+	return balance, nil
+}
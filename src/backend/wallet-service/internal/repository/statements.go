@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+)
+
+// ErrStatementsClosed is returned for queries run after the prepared
+// statements of a repository were closed
+var ErrStatementsClosed = errors.New("prepared statements are closed")
+
+// namedQuery is a SQL statement named after the repository method running
+// it. The name is sent with the statement, so the slow query log and the
+// database's statement statistics show it.
+type namedQuery struct {
+	name string
+	sql  string
+}
+
+// text returns the statement as prepared, led by its name
+func (q namedQuery) text() string {
+	return "-- name: " + q.name + "\n" + q.sql
+}
+
+// preparedStatements prepares named queries on first use and keeps them
+// until closed. A statement the database no longer knows, as after a
+// connection reset by a pooler or a schema change, is prepared again and the
+// call retried once. Replaced statements are kept until closed, as calls may
+// still be running on them.
+type preparedStatements struct {
+	db *sql.DB
+
+	mu       sync.RWMutex
+	prepared map[string]*sql.Stmt
+	replaced []*sql.Stmt
+	closed   bool
+}
+
+// newPreparedStatements creates the prepared statements of a repository
+func newPreparedStatements(db *sql.DB) *preparedStatements {
+	return &preparedStatements{db: db, prepared: make(map[string]*sql.Stmt)}
+}
+
+// prepare returns the prepared statement of a query, preparing it on first
+// use
+func (p *preparedStatements) prepare(ctx context.Context, q namedQuery) (*sql.Stmt, error) {
+	p.mu.RLock()
+	stmt, ok := p.prepared[q.name]
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return nil, ErrStatementsClosed
+	}
+	if ok {
+		return stmt, nil
+	}
+
+	return p.replace(ctx, q, nil)
+}
+
+// replace prepares a query again, unless another call already replaced the
+// stale statement
+func (p *preparedStatements) replace(ctx context.Context, q namedQuery, stale *sql.Stmt) (*sql.Stmt, error) {
+	stmt, err := p.db.PrepareContext(ctx, q.text())
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement %s: %w", q.name, err)
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		stmt.Close()
+		return nil, ErrStatementsClosed
+	}
+	if current := p.prepared[q.name]; current != stale {
+		p.mu.Unlock()
+		stmt.Close()
+		return current, nil
+	}
+	p.prepared[q.name] = stmt
+	if stale != nil {
+		p.replaced = append(p.replaced, stale)
+	}
+	p.mu.Unlock()
+
+	return stmt, nil
+}
+
+// run calls fn with the prepared statement of a query, retrying once on a
+// statement prepared again when the database no longer knows it. The
+// statement did not run then, so retrying is safe for writes too.
+func (p *preparedStatements) run(ctx context.Context, q namedQuery, fn func(stmt *sql.Stmt) error) error {
+	stmt, err := p.prepare(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	err = fn(stmt)
+	if !invalidStatement(err) {
+		return err
+	}
+
+	stmt, err = p.replace(ctx, q, stmt)
+	if err != nil {
+		return err
+	}
+	return fn(stmt)
+}
+
+// ExecContext runs a named query returning no rows
+func (p *preparedStatements) ExecContext(ctx context.Context, q namedQuery, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := p.run(ctx, q, func(stmt *sql.Stmt) error {
+		var err error
+		result, err = stmt.ExecContext(ctx, args...)
+		return err
+	})
+	return result, err
+}
+
+// QueryContext runs a named query returning rows
+func (p *preparedStatements) QueryContext(ctx context.Context, q namedQuery, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := p.run(ctx, q, func(stmt *sql.Stmt) error {
+		var err error
+		rows, err = stmt.QueryContext(ctx, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowContext runs a named query returning at most one row when the
+// row is scanned
+func (p *preparedStatements) QueryRowContext(ctx context.Context, q namedQuery, args ...interface{}) *preparedRow {
+	return &preparedRow{scan: func(dest ...interface{}) error {
+		return p.run(ctx, q, func(stmt *sql.Stmt) error {
+			return stmt.QueryRowContext(ctx, args...).Scan(dest...)
+		})
+	}}
+}
+
+// Tx returns the prepared statements bound to a database transaction.
+// Statements are not retried there, as a failed statement aborts the
+// transaction.
+func (p *preparedStatements) Tx(tx *sql.Tx) *txStatements {
+	return &txStatements{statements: p, tx: tx}
+}
+
+// Close closes every prepared statement. Queries fail with
+// ErrStatementsClosed afterwards. The Close method of every repository
+// delegates here and is called once at shutdown, when the repository is no
+// longer used.
+func (p *preparedStatements) Close() error {
+	p.mu.Lock()
+	prepared, replaced := p.prepared, p.replaced
+	p.prepared, p.replaced = make(map[string]*sql.Stmt), nil
+	p.closed = true
+	p.mu.Unlock()
+
+	var errs []error
+	for name, stmt := range prepared {
+		if err := stmt.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close statement %s: %w", name, err))
+		}
+	}
+	for _, stmt := range replaced {
+		if err := stmt.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close replaced statement: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// txStatements are prepared statements bound to a database transaction
+type txStatements struct {
+	statements *preparedStatements
+	tx         *sql.Tx
+}
+
+// stmt returns the prepared statement of a query bound to the transaction
+func (t *txStatements) stmt(ctx context.Context, q namedQuery) (*sql.Stmt, error) {
+	stmt, err := t.statements.prepare(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return t.tx.StmtContext(ctx, stmt), nil
+}
+
+// ExecContext runs a named query returning no rows in the transaction
+func (t *txStatements) ExecContext(ctx context.Context, q namedQuery, args ...interface{}) (sql.Result, error) {
+	stmt, err := t.stmt(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+// QueryContext runs a named query returning rows in the transaction
+func (t *txStatements) QueryContext(ctx context.Context, q namedQuery, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := t.stmt(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+// QueryRowContext runs a named query returning at most one row in the
+// transaction when the row is scanned
+func (t *txStatements) QueryRowContext(ctx context.Context, q namedQuery, args ...interface{}) *preparedRow {
+	return &preparedRow{scan: func(dest ...interface{}) error {
+		stmt, err := t.stmt(ctx, q)
+		if err != nil {
+			return err
+		}
+		return stmt.QueryRowContext(ctx, args...).Scan(dest...)
+	}}
+}
+
+// preparedRow is the result of a named query returning at most one row,
+// scanned like a *sql.Row
+type preparedRow struct {
+	scan func(dest ...interface{}) error
+}
+
+// Scan runs the query and copies the columns of its row into dest,
+// returning sql.ErrNoRows when there is no row
+func (r *preparedRow) Scan(dest ...interface{}) error {
+	return r.scan(dest...)
+}
+
+// invalidStatement reports whether err is the database no longer knowing a
+// prepared statement, or refusing its cached plan after a schema change
+func invalidStatement(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "26000" || (pgErr.Code == "0A000" && strings.Contains(pgErr.Message, "cached plan"))
+}
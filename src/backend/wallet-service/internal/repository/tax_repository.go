@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"internal/models"
+)
+
+// taxRecordColumns are the columns scanned by scanTaxRecord
+const taxRecordColumns = `id, customer_id, source, reference, currency, region, tax_name, taxable, total, lines, created_at`
+
+// TaxRepository defines the interface for recorded tax breakdowns
+type TaxRepository interface {
+	// RecordTax stores a tax record unless its source and reference were
+	// recorded already, returning the stored record and whether it is new
+	RecordTax(ctx context.Context, record *models.TaxRecord) (*models.TaxRecord, bool, error)
+	// SummarizeTax totals the tax recorded in [from, to) by region,
+	// component, source and currency
+	SummarizeTax(ctx context.Context, from, to time.Time) ([]*models.TaxReportRow, error)
+	Close() error
+}
+
+// taxRepository implements TaxRepository interface
+type taxRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewTaxRepository creates a new instance of TaxRepository
+func NewTaxRepository(db *sql.DB) (TaxRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &taxRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *taxRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	recordTaxQuery = namedQuery{name: "recordTax", sql: `
+            INSERT INTO tax_records (
+                id, customer_id, source, reference, currency, region, tax_name,
+                taxable, total, lines, created_at
+            ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+            ON CONFLICT (source, reference) DO NOTHING
+            RETURNING ` + taxRecordColumns}
+
+	getTaxRecordQuery = namedQuery{name: "getTaxRecord", sql: `
+            SELECT ` + taxRecordColumns + `
+            FROM tax_records
+            WHERE source = $1 AND reference = $2`}
+
+	// Served by idx_tax_records_created
+	summarizeTaxQuery = namedQuery{name: "summarizeTax", sql: `
+            SELECT r.region, r.tax_name, line->>'name', r.source, r.currency,
+                   COUNT(*), SUM(r.taxable), SUM((line->>'amount')::DECIMAL)
+            FROM tax_records r, jsonb_array_elements(r.lines) AS line
+            WHERE r.created_at >= $1 AND r.created_at < $2
+            GROUP BY r.region, r.tax_name, line->>'name', r.source, r.currency
+            ORDER BY r.region, r.tax_name, line->>'name', r.source, r.currency`}
+)
+
+// RecordTax stores a tax record, returning the one stored first for its
+// source and reference
+func (r *taxRepository) RecordTax(ctx context.Context, record *models.TaxRecord) (*models.TaxRecord, bool, error) {
+	lines, err := json.Marshal(record.Breakdown.Lines)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal tax lines: %w", err)
+	}
+
+	stored, err := scanTaxRecord(r.statements.QueryRowContext(ctx, recordTaxQuery,
+		record.ID,
+		record.CustomerID,
+		record.Source,
+		record.Reference,
+		record.Currency,
+		record.Breakdown.Region,
+		record.Breakdown.Tax,
+		record.Breakdown.Taxable,
+		record.Breakdown.Total,
+		lines,
+		record.CreatedAt,
+	))
+	if err == nil {
+		return stored, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("failed to record tax: %w", err)
+	}
+
+	stored, err = scanTaxRecord(r.statements.QueryRowContext(ctx, getTaxRecordQuery, record.Source, record.Reference))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get tax record: %w", err)
+	}
+	return stored, false, nil
+}
+
+// SummarizeTax totals the tax recorded in a window
+func (r *taxRepository) SummarizeTax(ctx context.Context, from, to time.Time) ([]*models.TaxReportRow, error) {
+	rows, err := r.statements.QueryContext(ctx, summarizeTaxQuery, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize tax: %w", err)
+	}
+	defer rows.Close()
+
+	var report []*models.TaxReportRow
+	for rows.Next() {
+		row := &models.TaxReportRow{}
+		if err := rows.Scan(&row.Region, &row.Tax, &row.Component, &row.Source, &row.Currency, &row.Count, &row.Taxable, &row.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan tax report row: %w", err)
+		}
+		report = append(report, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tax report rows: %w", err)
+	}
+
+	return report, nil
+}
+
+// scanTaxRecord scans a tax record row selected with taxRecordColumns
+func scanTaxRecord(row rowScanner) (*models.TaxRecord, error) {
+	var record models.TaxRecord
+	var lines []byte
+	err := row.Scan(
+		&record.ID,
+		&record.CustomerID,
+		&record.Source,
+		&record.Reference,
+		&record.Currency,
+		&record.Breakdown.Region,
+		&record.Breakdown.Tax,
+		&record.Breakdown.Taxable,
+		&record.Breakdown.Total,
+		&lines,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(lines, &record.Breakdown.Lines); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tax lines: %w", err)
+	}
+	return &record, nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// TransactionExportRepository defines the interface for reading the
+// transactions of bulk exports
+type TransactionExportRepository interface {
+	// StreamTransactions calls fn for each transaction matching the filter,
+	// oldest first, stopping at the first error fn returns
+	StreamTransactions(ctx context.Context, filter models.TransactionExportFilter, fn func(*models.TransactionExportRow) error) error
+	Close() error
+}
+
+// transactionExportRepository implements TransactionExportRepository
+// interface
+type transactionExportRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewTransactionExportRepository creates a new instance of
+// TransactionExportRepository
+func NewTransactionExportRepository(db *sql.DB) (TransactionExportRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &transactionExportRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *transactionExportRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	// The bounds on created_at limit the scan to the partitions of the
+	// exported months
+	streamTransactionsQuery = namedQuery{name: "streamTransactions", sql: `
+            SELECT t.id, t.wallet_id, w.customer_id, t.type, t.status, t.amount::TEXT, t.currency,
+                   COALESCE(t.reference_id, ''), COALESCE(t.description, ''), t.effective_at, t.created_at
+            FROM wallet_transactions t
+            JOIN wallets w ON w.id = t.wallet_id
+            WHERE t.created_at >= $1 AND t.created_at < $2
+              AND ($3::UUID IS NULL OR w.customer_id = $3)
+              AND ($4 = '' OR t.type = $4)
+            ORDER BY t.created_at, t.id`}
+)
+
+// StreamTransactions reads the matching transactions row by row, so exports
+// of any size run in constant memory
+func (r *transactionExportRepository) StreamTransactions(ctx context.Context, filter models.TransactionExportFilter, fn func(*models.TransactionExportRow) error) error {
+	var customerID uuid.NullUUID
+	if filter.CustomerID != nil {
+		customerID = uuid.NullUUID{UUID: *filter.CustomerID, Valid: true}
+	}
+
+	rows, err := r.statements.QueryContext(ctx, streamTransactionsQuery, filter.From, filter.To, customerID, filter.Type)
+	if err != nil {
+		return fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := &models.TransactionExportRow{}
+		err := rows.Scan(
+			&row.TransactionID,
+			&row.WalletID,
+			&row.CustomerID,
+			&row.Type,
+			&row.Status,
+			&row.Amount,
+			&row.Currency,
+			&row.ReferenceID,
+			&row.Description,
+			&row.EffectiveAt,
+			&row.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating transactions: %w", err)
+	}
+
+	return nil
+}
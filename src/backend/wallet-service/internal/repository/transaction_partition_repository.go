@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5" // v5.3.1
+
+	"internal/models"
+)
+
+// transactionPartitionLayout is the time layout of monthly partition names,
+// as created by create_wallet_transactions_partition
+const transactionPartitionLayout = "wallet_transactions_y2006m01"
+
+// TransactionPartitionRepository manages the monthly partitions of wallet
+// transactions
+type TransactionPartitionRepository interface {
+	// CreateTransactionPartition creates the partition of a month unless it
+	// exists
+	CreateTransactionPartition(ctx context.Context, month time.Time) error
+	// ListTransactionPartitions lists the monthly partitions, oldest first
+	ListTransactionPartitions(ctx context.Context) ([]*models.TransactionPartition, error)
+	// ArchiveTransactionPartition detaches a partition and moves its
+	// transactions to the archive table, returning how many were moved
+	ArchiveTransactionPartition(ctx context.Context, partition *models.TransactionPartition) (int64, error)
+	Close() error
+}
+
+// transactionPartitionRepository implements TransactionPartitionRepository
+// interface
+type transactionPartitionRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewTransactionPartitionRepository creates a new instance of
+// TransactionPartitionRepository
+func NewTransactionPartitionRepository(db *sql.DB) (TransactionPartitionRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &transactionPartitionRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *transactionPartitionRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	createPartitionQuery = namedQuery{name: "createPartition", sql: `
+            SELECT create_wallet_transactions_partition($1::DATE)`}
+
+	listPartitionsQuery = namedQuery{name: "listPartitions", sql: `
+            SELECT c.relname
+            FROM pg_inherits i
+            JOIN pg_class c ON c.oid = i.inhrelid
+            WHERE i.inhparent = 'wallet_transactions'::regclass
+            ORDER BY c.relname`}
+)
+
+// CreateTransactionPartition creates the partition of a month unless it
+// exists
+func (r *transactionPartitionRepository) CreateTransactionPartition(ctx context.Context, month time.Time) error {
+	var name string
+	err := r.statements.QueryRowContext(ctx, createPartitionQuery, models.MonthStart(month).Format("2006-01-02")).Scan(&name)
+	if err != nil {
+		return fmt.Errorf("failed to create transaction partition: %w", err)
+	}
+	return nil
+}
+
+// ListTransactionPartitions lists the monthly partitions, oldest first. The
+// default partition is not a month and is left out.
+func (r *transactionPartitionRepository) ListTransactionPartitions(ctx context.Context) ([]*models.TransactionPartition, error) {
+	rows, err := r.statements.QueryContext(ctx, listPartitionsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transaction partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []*models.TransactionPartition
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction partition: %w", err)
+		}
+		month, err := time.Parse(transactionPartitionLayout, name)
+		if err != nil {
+			continue
+		}
+		partitions = append(partitions, &models.TransactionPartition{Name: name, Month: month})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transaction partitions: %w", err)
+	}
+
+	return partitions, nil
+}
+
+// ArchiveTransactionPartition detaches a partition, copies its transactions
+// to the archive table and drops it, all in one transaction so a failure
+// leaves the month in place
+func (r *transactionPartitionRepository) ArchiveTransactionPartition(ctx context.Context, partition *models.TransactionPartition) (int64, error) {
+	table := pgx.Identifier{partition.Name}.Sanitize()
+
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	if _, err := dbTx.ExecContext(ctx, `ALTER TABLE wallet_transactions DETACH PARTITION `+table); err != nil {
+		return 0, fmt.Errorf("failed to detach transaction partition: %w", err)
+	}
+
+	result, err := dbTx.ExecContext(ctx, `
+        INSERT INTO wallet_transactions_archive
+        SELECT *, CURRENT_TIMESTAMP FROM `+table)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive transactions: %w", err)
+	}
+	archived, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get archived count: %w", err)
+	}
+
+	if _, err := dbTx.ExecContext(ctx, `DROP TABLE `+table); err != nil {
+		return 0, fmt.Errorf("failed to drop transaction partition: %w", err)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return archived, nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// UsageRepository defines the interface for internal service usage persistence
+type UsageRepository interface {
+	AddUsage(ctx context.Context, usage *models.ServiceUsage) error
+	ListUsage(ctx context.Context, month time.Time) ([]*models.ServiceUsage, error)
+	ClaimCharge(ctx context.Context, month time.Time, caller string, now time.Time) (bool, error)
+	ReleaseCharge(ctx context.Context, month time.Time, caller string) error
+	SetChargedTransaction(ctx context.Context, month time.Time, caller string, transactionID uuid.UUID) error
+	Close() error
+}
+
+// usageRepository implements UsageRepository interface
+type usageRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewUsageRepository creates a new instance of UsageRepository
+func NewUsageRepository(db *sql.DB) (UsageRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &usageRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *usageRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	usageAddUsageQuery = namedQuery{name: "usageAddUsage", sql: `
+            INSERT INTO service_usage (month, caller, requests, db_time_us, bytes_served, updated_at)
+            VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+            ON CONFLICT (month, caller) DO UPDATE
+            SET requests = service_usage.requests + EXCLUDED.requests,
+                db_time_us = service_usage.db_time_us + EXCLUDED.db_time_us,
+                bytes_served = service_usage.bytes_served + EXCLUDED.bytes_served,
+                updated_at = EXCLUDED.updated_at`}
+
+	usageListUsageQuery = namedQuery{name: "usageListUsage", sql: `
+            SELECT month, caller, requests, db_time_us, bytes_served, charged_transaction_id
+            FROM service_usage
+            WHERE month = $1
+            ORDER BY caller`}
+
+	claimChargeQuery = namedQuery{name: "claimCharge", sql: `
+            UPDATE service_usage
+            SET charged_at = $3
+            WHERE month = $1 AND caller = $2 AND charged_at IS NULL`}
+
+	releaseChargeQuery = namedQuery{name: "releaseCharge", sql: `
+            UPDATE service_usage
+            SET charged_at = NULL
+            WHERE month = $1 AND caller = $2 AND charged_transaction_id IS NULL`}
+
+	setChargedTransactionQuery = namedQuery{name: "setChargedTransaction", sql: `
+            UPDATE service_usage
+            SET charged_transaction_id = $3
+            WHERE month = $1 AND caller = $2`}
+)
+
+// AddUsage adds requests, database time and bytes served to a service's
+// usage of a month
+func (r *usageRepository) AddUsage(ctx context.Context, usage *models.ServiceUsage) error {
+	_, err := r.statements.ExecContext(ctx, usageAddUsageQuery,
+		usage.Month.Format(dateLayout),
+		usage.Caller,
+		usage.Requests,
+		usage.DBTime.Microseconds(),
+		usage.BytesServed,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add service usage: %w", err)
+	}
+
+	return nil
+}
+
+// ListUsage retrieves the usage of every service in a month, ordered by caller
+func (r *usageRepository) ListUsage(ctx context.Context, month time.Time) ([]*models.ServiceUsage, error) {
+	rows, err := r.statements.QueryContext(ctx, usageListUsageQuery, month.Format(dateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usages []*models.ServiceUsage
+	for rows.Next() {
+		u := &models.ServiceUsage{}
+		var dbTimeMicros int64
+		var chargedTransactionID uuid.NullUUID
+		if err := rows.Scan(&u.Month, &u.Caller, &u.Requests, &dbTimeMicros, &u.BytesServed, &chargedTransactionID); err != nil {
+			return nil, fmt.Errorf("failed to scan service usage: %w", err)
+		}
+		u.DBTime = time.Duration(dbTimeMicros) * time.Microsecond
+		if chargedTransactionID.Valid {
+			u.ChargedTransactionID = &chargedTransactionID.UUID
+		}
+		usages = append(usages, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating service usage: %w", err)
+	}
+
+	return usages, nil
+}
+
+// ClaimCharge marks a service's usage of a month as being charged and
+// reports whether it was not claimed before, so that replicas running the
+// chargeback concurrently bill each service once
+func (r *usageRepository) ClaimCharge(ctx context.Context, month time.Time, caller string, now time.Time) (bool, error) {
+	result, err := r.statements.ExecContext(ctx, claimChargeQuery, month.Format(dateLayout), caller, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim usage charge: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// ReleaseCharge releases a claim whose wallet debit failed so that the next
+// chargeback run retries it
+func (r *usageRepository) ReleaseCharge(ctx context.Context, month time.Time, caller string) error {
+	if _, err := r.statements.ExecContext(ctx, releaseChargeQuery, month.Format(dateLayout), caller); err != nil {
+		return fmt.Errorf("failed to release usage charge: %w", err)
+	}
+
+	return nil
+}
+
+// SetChargedTransaction records the wallet debit that billed a service's
+// usage of a month
+func (r *usageRepository) SetChargedTransaction(ctx context.Context, month time.Time, caller string, transactionID uuid.UUID) error {
+	if _, err := r.statements.ExecContext(ctx, setChargedTransactionQuery, month.Format(dateLayout), caller, transactionID); err != nil {
+		return fmt.Errorf("failed to set charged transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"internal/models"
+)
+
+// walletActivityColumns is the column list scanned by scanActivityWallet,
+// qualified by the wallets alias w
+const walletActivityColumns = `w.id, w.customer_id, w.balance, w.currency, w.low_balance_threshold, w.credit_limit,
+                               w.created_at, w.updated_at, w.last_activity_at, w.inactive_since, w.version`
+
+// WalletActivityRepository defines the interface for flagging wallets
+// inactive after a period without transactions and active again once they
+// transact. Wallet last activity moves with every recorded transaction.
+type WalletActivityRepository interface {
+	// MarkInactiveWallets flags up to limit active wallets whose last
+	// activity is before idleBefore as inactive since now, returning them.
+	// Wallets merged into another are skipped.
+	MarkInactiveWallets(ctx context.Context, idleBefore, now time.Time, limit int) ([]*models.Wallet, error)
+	// MarkReactivatedWallets clears the flag of up to limit inactive
+	// wallets that transacted since they were flagged, returning them
+	MarkReactivatedWallets(ctx context.Context, limit int) ([]*models.WalletReactivation, error)
+	Close() error
+}
+
+// walletActivityRepository implements WalletActivityRepository interface
+type walletActivityRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewWalletActivityRepository creates a new instance of
+// WalletActivityRepository
+func NewWalletActivityRepository(db *sql.DB) (WalletActivityRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &walletActivityRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *walletActivityRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	markInactiveQuery = namedQuery{name: "markInactive", sql: `
+            WITH idle AS (
+                SELECT id
+                FROM wallets
+                WHERE inactive_since IS NULL AND last_activity_at < $1 AND deleted_at IS NULL
+                  AND NOT EXISTS (
+                      SELECT 1 FROM wallet_merges m
+                      WHERE m.source_wallet_id = wallets.id AND m.status = 'MERGED')
+                ORDER BY last_activity_at
+                LIMIT $3
+                FOR UPDATE SKIP LOCKED
+            )
+            UPDATE wallets w
+            SET inactive_since = $2
+            FROM idle
+            WHERE w.id = idle.id
+            RETURNING ` + walletActivityColumns}
+
+	markReactivatedQuery = namedQuery{name: "markReactivated", sql: `
+            WITH active AS (
+                SELECT id, inactive_since
+                FROM wallets
+                WHERE inactive_since IS NOT NULL AND last_activity_at > inactive_since AND deleted_at IS NULL
+                ORDER BY inactive_since
+                LIMIT $1
+                FOR UPDATE SKIP LOCKED
+            )
+            UPDATE wallets w
+            SET inactive_since = NULL
+            FROM active
+            WHERE w.id = active.id
+            RETURNING ` + walletActivityColumns + `, active.inactive_since`}
+)
+
+// MarkInactiveWallets flags the longest idle wallets inactive
+func (r *walletActivityRepository) MarkInactiveWallets(ctx context.Context, idleBefore, now time.Time, limit int) ([]*models.Wallet, error) {
+	rows, err := r.statements.QueryContext(ctx, markInactiveQuery, idleBefore, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark inactive wallets: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*models.Wallet
+	for rows.Next() {
+		wallet, err := scanActivityWallet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet: %w", err)
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating wallets: %w", err)
+	}
+
+	return wallets, nil
+}
+
+// MarkReactivatedWallets clears the inactive flag of wallets that
+// transacted since, longest inactive first
+func (r *walletActivityRepository) MarkReactivatedWallets(ctx context.Context, limit int) ([]*models.WalletReactivation, error) {
+	rows, err := r.statements.QueryContext(ctx, markReactivatedQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark reactivated wallets: %w", err)
+	}
+	defer rows.Close()
+
+	var reactivations []*models.WalletReactivation
+	for rows.Next() {
+		reactivation := &models.WalletReactivation{}
+		reactivation.Wallet, err = scanActivityWallet(rows, &reactivation.InactiveSince)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet: %w", err)
+		}
+		reactivations = append(reactivations, reactivation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating wallets: %w", err)
+	}
+
+	return reactivations, nil
+}
+
+// scanActivityWallet scans a row of walletActivityColumns followed by the
+// extra columns
+func scanActivityWallet(row rowScanner, extra ...interface{}) (*models.Wallet, error) {
+	wallet := &models.Wallet{}
+	dest := append([]interface{}{
+		&wallet.ID,
+		&wallet.CustomerID,
+		&wallet.Balance,
+		&wallet.Currency,
+		&wallet.LowBalanceThreshold,
+		&wallet.CreditLimit,
+		&wallet.CreatedAt,
+		&wallet.UpdatedAt,
+		&wallet.LastActivityAt,
+		&wallet.InactiveSince,
+		&wallet.Version,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// WalletAnalyticsRepository defines the interface for the spending
+// aggregates of a single wallet
+type WalletAnalyticsRepository interface {
+	// GetWalletFlows totals the wallet's completed debits and credits
+	// effective since a time per period, oldest first. Periods without
+	// transactions are left out, as are adjustments, which correct balances
+	// rather than being spend.
+	GetWalletFlows(ctx context.Context, walletID uuid.UUID, granularity models.AnalyticsGranularity, since time.Time) ([]*models.WalletFlow, error)
+	// GetSpendCategories returns the wallet's largest spend categories
+	// since a time, largest first
+	GetSpendCategories(ctx context.Context, walletID uuid.UUID, since time.Time, limit int) ([]*models.CategorySpend, error)
+	Close() error
+}
+
+// walletAnalyticsRepository implements WalletAnalyticsRepository interface
+type walletAnalyticsRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewWalletAnalyticsRepository creates a new instance of
+// WalletAnalyticsRepository
+func NewWalletAnalyticsRepository(db *sql.DB) (WalletAnalyticsRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &walletAnalyticsRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *walletAnalyticsRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	walletFlowsQuery = namedQuery{name: "walletFlows", sql: `
+            SELECT date_trunc($2, COALESCE(effective_at, created_at) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS period,
+                   COALESCE(SUM(amount) FILTER (WHERE type = 'DEBIT'), 0),
+                   COUNT(*) FILTER (WHERE type = 'DEBIT'),
+                   COALESCE(SUM(amount) FILTER (WHERE type IN ('CREDIT', 'REFUND')), 0),
+                   COUNT(*) FILTER (WHERE type IN ('CREDIT', 'REFUND'))
+            FROM wallet_transactions
+            WHERE wallet_id = $1 AND status = 'COMPLETED' AND type <> 'ADJUSTMENT'
+                  AND COALESCE(effective_at, created_at) >= $3
+            GROUP BY period
+            ORDER BY period`}
+
+	spendCategoriesQuery = namedQuery{name: "spendCategories", sql: `
+            SELECT COALESCE(NULLIF(metadata->>'category', ''), NULLIF(description, ''), '` + models.UncategorizedSpend + `') AS category,
+                   SUM(amount),
+                   COUNT(*)
+            FROM wallet_transactions
+            WHERE wallet_id = $1 AND status = 'COMPLETED' AND type = 'DEBIT'
+                  AND COALESCE(effective_at, created_at) >= $2
+            GROUP BY category
+            ORDER BY SUM(amount) DESC, category
+            LIMIT $3`}
+)
+
+// GetWalletFlows retrieves the wallet's debit and credit totals per period
+func (r *walletAnalyticsRepository) GetWalletFlows(ctx context.Context, walletID uuid.UUID, granularity models.AnalyticsGranularity, since time.Time) ([]*models.WalletFlow, error) {
+	rows, err := r.statements.QueryContext(ctx, walletFlowsQuery, walletID, string(granularity), since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet flows: %w", err)
+	}
+	defer rows.Close()
+
+	var flows []*models.WalletFlow
+	for rows.Next() {
+		flow := &models.WalletFlow{}
+		if err := rows.Scan(&flow.PeriodStart, &flow.Debits, &flow.DebitCount, &flow.Credits, &flow.CreditCount); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet flow: %w", err)
+		}
+		flow.PeriodStart = flow.PeriodStart.UTC()
+		flows = append(flows, flow)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating wallet flows: %w", err)
+	}
+
+	return flows, nil
+}
+
+// GetSpendCategories retrieves the wallet's largest spend categories
+func (r *walletAnalyticsRepository) GetSpendCategories(ctx context.Context, walletID uuid.UUID, since time.Time, limit int) ([]*models.CategorySpend, error) {
+	rows, err := r.statements.QueryContext(ctx, spendCategoriesQuery, walletID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spend categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []*models.CategorySpend
+	for rows.Next() {
+		category := &models.CategorySpend{}
+		if err := rows.Scan(&category.Category, &category.Total, &category.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan spend category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating spend categories: %w", err)
+	}
+
+	return categories, nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"  // v1.3.0
+	"github.com/jackc/pgx/v5" // v5.3.1
+
+	"internal/models"
+)
+
+// WalletBatchRepository defines the interface for the rows of wallet
+// provisioning batches
+type WalletBatchRepository interface {
+	// CreateWalletBatch stores the rows of a batch as pending
+	CreateWalletBatch(ctx context.Context, batchID uuid.UUID, rows []*models.WalletBatchRow) error
+	// ListWalletBatchRows lists the rows of a batch in order
+	ListWalletBatchRows(ctx context.Context, batchID uuid.UUID) ([]*models.WalletBatchRow, error)
+	// RecordWalletBatchRows stores the outcome of processed rows
+	RecordWalletBatchRows(ctx context.Context, batchID uuid.UUID, rows []*models.WalletBatchRow) error
+	// PurgeWalletBatches deletes the rows of batches created before a time
+	PurgeWalletBatches(ctx context.Context, before time.Time) (int64, error)
+	Close() error
+}
+
+// walletBatchRepository implements WalletBatchRepository interface
+type walletBatchRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewWalletBatchRepository creates a new instance of WalletBatchRepository
+func NewWalletBatchRepository(db *sql.DB) (WalletBatchRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &walletBatchRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *walletBatchRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	walletBatchCreateRowsQuery = namedQuery{name: "walletBatchCreateRows", sql: `
+            INSERT INTO wallet_batch_rows (batch_id, row_index, customer_id, currency, created_at)
+            SELECT $1, row_index, customer_id, currency, $5
+            FROM unnest($2::INTEGER[], $3::TEXT[], $4::TEXT[]) AS rows(row_index, customer_id, currency)`}
+
+	walletBatchListRowsQuery = namedQuery{name: "walletBatchListRows", sql: `
+            SELECT row_index, customer_id, currency, status, wallet_id, COALESCE(error, '')
+            FROM wallet_batch_rows
+            WHERE batch_id = $1
+            ORDER BY row_index`}
+
+	purgeBatchesQuery = namedQuery{name: "purgeBatches", sql: `
+            DELETE FROM wallet_batch_rows
+            WHERE created_at < $1`}
+)
+
+// CreateWalletBatch stores the rows of a batch as pending in one statement
+func (r *walletBatchRepository) CreateWalletBatch(ctx context.Context, batchID uuid.UUID, rows []*models.WalletBatchRow) error {
+	indexes := make([]int64, len(rows))
+	customers := make([]string, len(rows))
+	currencies := make([]string, len(rows))
+	for i, row := range rows {
+		indexes[i] = int64(row.Index)
+		customers[i] = row.CustomerID
+		currencies[i] = row.Currency
+	}
+
+	_, err := r.statements.ExecContext(ctx, walletBatchCreateRowsQuery,
+		batchID,
+		indexes,
+		customers,
+		currencies,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create wallet batch: %w", err)
+	}
+	return nil
+}
+
+// ListWalletBatchRows lists the rows of a batch in order
+func (r *walletBatchRepository) ListWalletBatchRows(ctx context.Context, batchID uuid.UUID) ([]*models.WalletBatchRow, error) {
+	rows, err := r.statements.QueryContext(ctx, walletBatchListRowsQuery, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wallet batch rows: %w", err)
+	}
+	defer rows.Close()
+
+	var batch []*models.WalletBatchRow
+	for rows.Next() {
+		var row models.WalletBatchRow
+		var walletID uuid.NullUUID
+		if err := rows.Scan(&row.Index, &row.CustomerID, &row.Currency, &row.Status, &walletID, &row.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet batch row: %w", err)
+		}
+		if walletID.Valid {
+			row.WalletID = &walletID.UUID
+		}
+		batch = append(batch, &row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating wallet batch rows: %w", err)
+	}
+
+	return batch, nil
+}
+
+// recordWalletBatchRowQuery stores the outcome of a processed row
+var recordWalletBatchRowQuery = namedQuery{name: "RecordWalletBatchRows", sql: `
+            UPDATE wallet_batch_rows
+            SET status = $3, wallet_id = $4, error = NULLIF($5, ''), processed_at = $6
+            WHERE batch_id = $1 AND row_index = $2`}
+
+// RecordWalletBatchRows stores the outcome of processed rows in one batch
+// and transaction, so a chunk is recorded in a single round trip, whole or
+// not at all
+func (r *walletBatchRepository) RecordWalletBatchRows(ctx context.Context, batchID uuid.UUID, rows []*models.WalletBatchRow) error {
+	now := time.Now().UTC()
+	batch := &pgx.Batch{}
+	for _, row := range rows {
+		batch.Queue(recordWalletBatchRowQuery.text(),
+			batchID, row.Index, row.Status, nullUUID(row.WalletID), row.Error, now)
+	}
+
+	if err := execBatch(ctx, r.db, recordWalletBatchRowQuery, batch); err != nil {
+		return fmt.Errorf("failed to record wallet batch rows: %w", err)
+	}
+	return nil
+}
+
+// PurgeWalletBatches deletes the rows of batches created before a time
+func (r *walletBatchRepository) PurgeWalletBatches(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.statements.ExecContext(ctx, purgeBatchesQuery, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge wallet batches: %w", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get purged count: %w", err)
+	}
+	return purged, nil
+}
@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"         // v1.3.0
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+	"github.com/shopspring/decimal"  // v1.3.1
+
+	"internal/models"
+)
+
+// Wallet bucket errors
+var (
+	// ErrBucketNotFound is returned when a wallet has no bucket of the name
+	ErrBucketNotFound = errors.New("bucket not found")
+	// ErrBucketExists is returned when a wallet already has a bucket of the
+	// name
+	ErrBucketExists = errors.New("bucket already exists")
+	// ErrInsufficientBucketBalance is returned when a bucket, or the
+	// unallocated balance, holds less than is taken out of it
+	ErrInsufficientBucketBalance = errors.New("insufficient bucket balance")
+)
+
+// walletBucketColumns is the column list scanned by scanWalletBucket
+const walletBucketColumns = `id, wallet_id, name, balance, low_balance_threshold, fallback, low_balance,
+                             created_at, updated_at`
+
+// WalletBucketRepository defines the interface for the named sub-balances
+// of wallets. Bucket balances never go negative, and together never exceed
+// the balance of their wallet when funded from it.
+type WalletBucketRepository interface {
+	CreateBucket(ctx context.Context, bucket *models.WalletBucket) error
+	GetBucket(ctx context.Context, walletID uuid.UUID, name string) (*models.WalletBucket, error)
+	// ListBuckets lists the buckets of a wallet by name
+	ListBuckets(ctx context.Context, walletID uuid.UUID) ([]*models.WalletBucket, error)
+	// UpdateBucket replaces the low balance threshold and fallback of a
+	// bucket
+	UpdateBucket(ctx context.Context, bucket *models.WalletBucket) error
+	// DeleteBucket removes a bucket, leaving its balance unallocated
+	DeleteBucket(ctx context.Context, walletID uuid.UUID, name string) error
+	// TransferBucketFunds moves amount from one bucket to another, an empty
+	// name standing for the unallocated balance, and returns the buckets
+	// it moved funds in or out of
+	TransferBucketFunds(ctx context.Context, walletID uuid.UUID, from, to string, amount decimal.Decimal) ([]*models.WalletBucket, error)
+	// TakeFromBucket takes a debit's amount out of a bucket, returning what
+	// it took and the bucket after. Buckets falling back to the unallocated
+	// balance give what they hold; others refuse debits they cannot cover.
+	TakeFromBucket(ctx context.Context, walletID uuid.UUID, name string, amount decimal.Decimal) (decimal.Decimal, *models.WalletBucket, error)
+	// ReturnToBucket gives back what was taken for a debit that did not post
+	ReturnToBucket(ctx context.Context, walletID uuid.UUID, name string, amount decimal.Decimal) error
+	// SetBucketLowBalance flags a bucket as alerted or re-armed, reporting
+	// whether its state changed
+	SetBucketLowBalance(ctx context.Context, id uuid.UUID, lowBalance bool) (bool, error)
+	Close() error
+}
+
+// walletBucketRepository implements WalletBucketRepository interface
+type walletBucketRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewWalletBucketRepository creates a new instance of WalletBucketRepository
+func NewWalletBucketRepository(db *sql.DB) (WalletBucketRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &walletBucketRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *walletBucketRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	walletBucketCreateQuery = namedQuery{name: "walletBucketCreate", sql: `
+            INSERT INTO wallet_buckets (id, wallet_id, name, balance, low_balance_threshold, fallback, low_balance,
+                                        created_at, updated_at)
+            VALUES ($1, $2, $3, 0, $4, $5, FALSE, $6, $6)`}
+
+	walletBucketGetQuery = namedQuery{name: "walletBucketGet", sql: `
+            SELECT ` + walletBucketColumns + `
+            FROM wallet_buckets
+            WHERE wallet_id = $1 AND name = $2`}
+
+	walletBucketLockQuery = namedQuery{name: "walletBucketLock", sql: `
+            SELECT ` + walletBucketColumns + `
+            FROM wallet_buckets
+            WHERE wallet_id = $1 AND name = $2
+            FOR UPDATE`}
+
+	walletBucketListQuery = namedQuery{name: "walletBucketList", sql: `
+            SELECT ` + walletBucketColumns + `
+            FROM wallet_buckets
+            WHERE wallet_id = $1
+            ORDER BY name`}
+
+	walletBucketUpdateQuery = namedQuery{name: "walletBucketUpdate", sql: `
+            UPDATE wallet_buckets
+            SET low_balance_threshold = $3,
+                fallback = $4,
+                low_balance = low_balance AND balance <= $3,
+                updated_at = $5
+            WHERE wallet_id = $1 AND name = $2
+            RETURNING ` + walletBucketColumns}
+
+	walletBucketDeleteQuery = namedQuery{name: "walletBucketDelete", sql: `
+            DELETE FROM wallet_buckets
+            WHERE wallet_id = $1 AND name = $2`}
+
+	walletBucketUnallocatedQuery = namedQuery{name: "walletBucketUnallocated", sql: `
+            SELECT w.balance - COALESCE((SELECT SUM(b.balance) FROM wallet_buckets b WHERE b.wallet_id = w.id), 0)
+            FROM wallets w
+            WHERE w.id = $1
+            FOR UPDATE OF w`}
+
+	walletBucketMoveQuery = namedQuery{name: "walletBucketMove", sql: `
+            UPDATE wallet_buckets
+            SET balance = balance + $3,
+                updated_at = $4
+            WHERE wallet_id = $1 AND name = $2
+            RETURNING ` + walletBucketColumns}
+
+	setLowBalanceQuery = namedQuery{name: "setLowBalance", sql: `
+            UPDATE wallet_buckets
+            SET low_balance = $2
+            WHERE id = $1 AND low_balance <> $2`}
+)
+
+// CreateBucket stores a new, empty bucket
+func (r *walletBucketRepository) CreateBucket(ctx context.Context, bucket *models.WalletBucket) error {
+	_, err := r.statements.ExecContext(ctx, walletBucketCreateQuery,
+		bucket.ID,
+		bucket.WalletID,
+		bucket.Name,
+		bucket.LowBalanceThreshold,
+		bucket.Fallback,
+		bucket.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrBucketExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create bucket: %w", err)
+	}
+
+	return nil
+}
+
+// GetBucket retrieves a bucket of a wallet by name
+func (r *walletBucketRepository) GetBucket(ctx context.Context, walletID uuid.UUID, name string) (*models.WalletBucket, error) {
+	bucket, err := scanWalletBucket(r.statements.QueryRowContext(ctx, walletBucketGetQuery, walletID, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBucketNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket: %w", err)
+	}
+	return bucket, nil
+}
+
+// ListBuckets lists the buckets of a wallet
+func (r *walletBucketRepository) ListBuckets(ctx context.Context, walletID uuid.UUID) ([]*models.WalletBucket, error) {
+	rows, err := r.statements.QueryContext(ctx, walletBucketListQuery, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []*models.WalletBucket
+	for rows.Next() {
+		bucket, err := scanWalletBucket(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bucket: %w", err)
+		}
+		buckets = append(buckets, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate buckets: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// UpdateBucket replaces a bucket's threshold and fallback. A bucket that
+// alerted is re-armed when its balance is above the new threshold.
+func (r *walletBucketRepository) UpdateBucket(ctx context.Context, bucket *models.WalletBucket) error {
+	updated, err := scanWalletBucket(r.statements.QueryRowContext(ctx, walletBucketUpdateQuery,
+		bucket.WalletID,
+		bucket.Name,
+		bucket.LowBalanceThreshold,
+		bucket.Fallback,
+		time.Now().UTC(),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrBucketNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update bucket: %w", err)
+	}
+
+	*bucket = *updated
+	return nil
+}
+
+// DeleteBucket removes a bucket
+func (r *walletBucketRepository) DeleteBucket(ctx context.Context, walletID uuid.UUID, name string) error {
+	result, err := r.statements.ExecContext(ctx, walletBucketDeleteQuery, walletID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete bucket: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrBucketNotFound
+	}
+	return nil
+}
+
+// TransferBucketFunds locks the source and moves the amount in one
+// transaction. Funding a bucket from the unallocated balance locks the
+// wallet, so concurrent transfers cannot allocate the same funds twice.
+func (r *walletBucketRepository) TransferBucketFunds(ctx context.Context, walletID uuid.UUID, from, to string, amount decimal.Decimal) ([]*models.WalletBucket, error) {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	if from == "" {
+		var unallocated decimal.Decimal
+		err := statements.QueryRowContext(ctx, walletBucketUnallocatedQuery, walletID).Scan(&unallocated)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWalletNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get unallocated balance: %w", err)
+		}
+		if unallocated.LessThan(amount) {
+			return nil, ErrInsufficientBucketBalance
+		}
+	} else {
+		source, err := scanWalletBucket(statements.QueryRowContext(ctx, walletBucketLockQuery, walletID, from))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBucketNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock bucket: %w", err)
+		}
+		if source.Balance.LessThan(amount) {
+			return nil, ErrInsufficientBucketBalance
+		}
+	}
+
+	now := time.Now().UTC()
+	var moved []*models.WalletBucket
+	for _, move := range []struct {
+		name   string
+		amount decimal.Decimal
+	}{{from, amount.Neg()}, {to, amount}} {
+		if move.name == "" {
+			continue
+		}
+		bucket, err := scanWalletBucket(statements.QueryRowContext(ctx, walletBucketMoveQuery, walletID, move.name, move.amount, now))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBucketNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to move bucket funds: %w", err)
+		}
+		moved = append(moved, bucket)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit bucket transfer: %w", err)
+	}
+	return moved, nil
+}
+
+// TakeFromBucket locks the bucket so concurrent debits cannot spend the
+// same funds
+func (r *walletBucketRepository) TakeFromBucket(ctx context.Context, walletID uuid.UUID, name string, amount decimal.Decimal) (decimal.Decimal, *models.WalletBucket, error) {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return decimal.Zero, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	bucket, err := scanWalletBucket(statements.QueryRowContext(ctx, walletBucketLockQuery, walletID, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return decimal.Zero, nil, ErrBucketNotFound
+	}
+	if err != nil {
+		return decimal.Zero, nil, fmt.Errorf("failed to lock bucket: %w", err)
+	}
+
+	taken := decimal.Min(bucket.Balance, amount)
+	if taken.LessThan(amount) && bucket.Fallback != models.BucketFallbackUnallocated {
+		return decimal.Zero, nil, ErrInsufficientBucketBalance
+	}
+	if !taken.IsPositive() {
+		return decimal.Zero, bucket, nil
+	}
+	bucket, err = scanWalletBucket(statements.QueryRowContext(ctx, walletBucketMoveQuery, walletID, name, taken.Neg(), time.Now().UTC()))
+	if err != nil {
+		return decimal.Zero, nil, fmt.Errorf("failed to take from bucket: %w", err)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return decimal.Zero, nil, fmt.Errorf("failed to commit bucket debit: %w", err)
+	}
+	return taken, bucket, nil
+}
+
+// ReturnToBucket gives back what a debit took. A bucket deleted meanwhile
+// leaves the amount unallocated.
+func (r *walletBucketRepository) ReturnToBucket(ctx context.Context, walletID uuid.UUID, name string, amount decimal.Decimal) error {
+	_, err := scanWalletBucket(r.statements.QueryRowContext(ctx, walletBucketMoveQuery, walletID, name, amount, time.Now().UTC()))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to return to bucket: %w", err)
+	}
+	return nil
+}
+
+// SetBucketLowBalance flips the alert state of a bucket only when it
+// differs, so concurrent debits alert once
+func (r *walletBucketRepository) SetBucketLowBalance(ctx context.Context, id uuid.UUID, lowBalance bool) (bool, error) {
+	result, err := r.statements.ExecContext(ctx, setLowBalanceQuery, id, lowBalance)
+	if err != nil {
+		return false, fmt.Errorf("failed to set bucket low balance: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// scanWalletBucket scans a row of walletBucketColumns
+func scanWalletBucket(row rowScanner) (*models.WalletBucket, error) {
+	bucket := &models.WalletBucket{}
+	err := row.Scan(
+		&bucket.ID,
+		&bucket.WalletID,
+		&bucket.Name,
+		&bucket.Balance,
+		&bucket.LowBalanceThreshold,
+		&bucket.Fallback,
+		&bucket.LowBalance,
+		&bucket.CreatedAt,
+		&bucket.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return bucket, nil
+}
@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// Wallet merge repository errors
+var (
+	ErrWalletMergeNotFound = errors.New("wallet merge not found")
+	ErrWalletMergeConflict = errors.New("wallet merge is not allowed")
+	ErrWalletMerged        = errors.New("wallet was merged into another wallet")
+)
+
+// mergeColumns is the column list scanned by scanWalletMerge
+const mergeColumns = `id, source_wallet_id, target_wallet_id, status, amount, transaction_ids, merged_at,
+                      reversible_until, reversed_at`
+
+// WalletMergeRepository defines the interface for merging duplicate wallets.
+// A merge moves the source wallet's balance and history to the target and
+// leaves the source redirecting to it until the merge is reversed.
+type WalletMergeRepository interface {
+	MergeWallets(ctx context.Context, sourceID, targetID uuid.UUID, now, reversibleUntil time.Time) (*models.WalletMerge, error)
+	ReverseMerge(ctx context.Context, id uuid.UUID, now time.Time) (*models.WalletMerge, error)
+	GetWalletMerge(ctx context.Context, id uuid.UUID) (*models.WalletMerge, error)
+	GetMergeOf(ctx context.Context, walletID uuid.UUID) (*models.WalletMerge, error)
+	Close() error
+}
+
+// walletMergeRepository implements WalletMergeRepository interface
+type walletMergeRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewWalletMergeRepository creates a new instance of WalletMergeRepository
+func NewWalletMergeRepository(db *sql.DB) (WalletMergeRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &walletMergeRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *walletMergeRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	// mergeBlockersQuery reports what keeps a pair of wallets from merging.
+	// Open holds, unresolved disputes and scheduled debits would keep
+	// acting on the source wallet, and a source still receiving reversible
+	// merges would lose their history on its own reversal.
+	mergeBlockersQuery = namedQuery{name: "mergeBlockers", sql: `
+            SELECT
+                EXISTS (SELECT 1 FROM wallet_migrations WHERE wallet_id IN ($1, $2) AND status = 'FROZEN'),
+                EXISTS (SELECT 1 FROM wallet_merges WHERE source_wallet_id IN ($1, $2) AND status = 'MERGED'),
+                EXISTS (SELECT 1 FROM wallet_merges
+                        WHERE target_wallet_id = $1 AND status = 'MERGED' AND reversible_until > $3),
+                EXISTS (SELECT 1 FROM provider_refunds WHERE wallet_id = $1 AND status = 'PENDING'),
+                EXISTS (SELECT 1 FROM wallet_disputes
+                        WHERE wallet_id = $1 AND status IN ('OPEN', 'EVIDENCE_SUBMITTED')),
+                EXISTS (SELECT 1 FROM recurring_debits WHERE wallet_id = $1 AND next_run_at IS NOT NULL)`}
+
+	// moveTransactionsQuery moves the transactions of a wallet to another
+	moveTransactionsQuery = namedQuery{name: "moveTransactions", sql: `
+            UPDATE wallet_transactions
+            SET wallet_id = $2
+            WHERE wallet_id = $1
+            RETURNING id`}
+
+	// restoreTransactionsQuery moves merged transactions back to their wallet
+	restoreTransactionsQuery = namedQuery{name: "restoreTransactions", sql: `
+            UPDATE wallet_transactions
+            SET wallet_id = $1
+            WHERE wallet_id = $2 AND id = ANY($3::uuid[])`}
+
+	// insertMergeQuery records a merge
+	insertMergeQuery = namedQuery{name: "insertMerge", sql: `
+            INSERT INTO wallet_merges (id, source_wallet_id, target_wallet_id, status, amount, transaction_ids,
+                                       merged_at, reversible_until)
+            VALUES ($1, $2, $3, 'MERGED', $4, $5::uuid[], $6, $7)
+            RETURNING ` + mergeColumns}
+
+	// getMergeForUpdateQuery reads a merge, locking it until the
+	// transaction ends
+	getMergeForUpdateQuery = namedQuery{name: "getMergeForUpdate", sql: `
+            SELECT ` + mergeColumns + `
+            FROM wallet_merges
+            WHERE id = $1
+            FOR UPDATE`}
+
+	// reverseMergeQuery marks a merge reversed
+	reverseMergeQuery = namedQuery{name: "reverseMerge", sql: `
+            UPDATE wallet_merges
+            SET status = 'REVERSED', reversed_at = $2
+            WHERE id = $1
+            RETURNING ` + mergeColumns}
+
+	// getMergeQuery reads a merge
+	getMergeQuery = namedQuery{name: "getMerge", sql: `
+            SELECT ` + mergeColumns + `
+            FROM wallet_merges
+            WHERE id = $1`}
+
+	// getMergeOfQuery reads the merge redirecting a wallet
+	getMergeOfQuery = namedQuery{name: "getMergeOf", sql: `
+            SELECT ` + mergeColumns + `
+            FROM wallet_merges
+            WHERE source_wallet_id = $1 AND status = 'MERGED'`}
+)
+
+// MergeWallets moves the source wallet's balance and transactions to the
+// target wallet, which must belong to the same customer and hold the same
+// currency, and records the merge as reversible until the given time
+func (r *walletMergeRepository) MergeWallets(ctx context.Context, sourceID, targetID uuid.UUID, now, reversibleUntil time.Time) (*models.WalletMerge, error) {
+	var merge *models.WalletMerge
+	err := inWalletTx(ctx, r.db, r.statements, func(tx *walletTx) error {
+		source, target, err := lockPair(ctx, tx, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+		if source.CustomerID != target.CustomerID {
+			return fmt.Errorf("%w: wallets belong to different customers", ErrWalletMergeConflict)
+		}
+		if source.Currency != target.Currency {
+			return fmt.Errorf("%w: wallets hold different currencies", ErrWalletMergeConflict)
+		}
+
+		var frozen, merged, receiving, holds, disputes, scheduled bool
+		err = tx.statements.QueryRowContext(ctx, mergeBlockersQuery, sourceID, targetID, now).
+			Scan(&frozen, &merged, &receiving, &holds, &disputes, &scheduled)
+		if err != nil {
+			return fmt.Errorf("failed to check wallet merge: %w", err)
+		}
+		switch {
+		case frozen:
+			return fmt.Errorf("%w: a wallet is frozen for migration", ErrWalletMergeConflict)
+		case merged:
+			return fmt.Errorf("%w: a wallet is already merged", ErrWalletMergeConflict)
+		case receiving:
+			return fmt.Errorf("%w: the source has reversible merges into it", ErrWalletMergeConflict)
+		case holds:
+			return fmt.Errorf("%w: the source has pending refunds", ErrWalletMergeConflict)
+		case disputes:
+			return fmt.Errorf("%w: the source has open disputes", ErrWalletMergeConflict)
+		case scheduled:
+			return fmt.Errorf("%w: the source has scheduled recurring debits", ErrWalletMergeConflict)
+		}
+
+		if !target.CanChangeBalance(source.MinorBalance()) {
+			return fmt.Errorf("%w: the merged balance exceeds the target's credit limit", ErrWalletMergeConflict)
+		}
+
+		rows, err := tx.statements.QueryContext(ctx, moveTransactionsQuery, sourceID, targetID)
+		if err != nil {
+			return fmt.Errorf("failed to move transactions: %w", err)
+		}
+		moved := []uuid.UUID{}
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan moved transaction: %w", err)
+			}
+			moved = append(moved, id)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("error iterating moved transactions: %w", err)
+		}
+
+		amount := source.Balance
+		if err := moveBalance(ctx, tx, source, target, amount, now); err != nil {
+			return err
+		}
+
+		merge, err = scanWalletMerge(tx.statements.QueryRowContext(ctx, insertMergeQuery,
+			uuid.New(),
+			sourceID,
+			targetID,
+			amount,
+			moved,
+			now,
+			reversibleUntil,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merge, nil
+}
+
+// ReverseMerge moves a merge's transactions and balance back to the source
+// wallet. Postings made to the target since the merge stay on the target.
+func (r *walletMergeRepository) ReverseMerge(ctx context.Context, id uuid.UUID, now time.Time) (*models.WalletMerge, error) {
+	var merge *models.WalletMerge
+	err := inWalletTx(ctx, r.db, r.statements, func(tx *walletTx) error {
+		var err error
+		merge, err = scanWalletMerge(tx.statements.QueryRowContext(ctx, getMergeForUpdateQuery, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWalletMergeNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if merge.Status != models.WalletMerged {
+			return fmt.Errorf("%w: the merge was already reversed", ErrWalletMergeConflict)
+		}
+		if !merge.Reversible(now) {
+			return fmt.Errorf("%w: the reversal window has ended", ErrWalletMergeConflict)
+		}
+
+		source, target, err := lockPair(ctx, tx, merge.SourceWalletID, merge.TargetWalletID)
+		if err != nil {
+			return err
+		}
+		if amount, err := models.MinorOfFloat(merge.Amount); err != nil || !target.CanChangeBalance(amount.Neg()) {
+			return fmt.Errorf("%w: the target no longer holds the merged balance", ErrWalletMergeConflict)
+		}
+
+		_, err = tx.statements.ExecContext(ctx, restoreTransactionsQuery,
+			merge.SourceWalletID,
+			merge.TargetWalletID,
+			merge.TransactionIDs,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to restore transactions: %w", err)
+		}
+
+		// The source takes postings again once the merge is reversed
+		merge, err = scanWalletMerge(tx.statements.QueryRowContext(ctx, reverseMergeQuery, id, now))
+		if err != nil {
+			return err
+		}
+
+		return moveBalance(ctx, tx, target, source, merge.Amount, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merge, nil
+}
+
+// GetWalletMerge retrieves a merge by ID
+func (r *walletMergeRepository) GetWalletMerge(ctx context.Context, id uuid.UUID) (*models.WalletMerge, error) {
+	merge, err := scanWalletMerge(r.statements.QueryRowContext(ctx, getMergeQuery, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWalletMergeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return merge, nil
+}
+
+// GetMergeOf retrieves the merge redirecting a wallet to its surviving wallet
+func (r *walletMergeRepository) GetMergeOf(ctx context.Context, walletID uuid.UUID) (*models.WalletMerge, error) {
+	merge, err := scanWalletMerge(r.statements.QueryRowContext(ctx, getMergeOfQuery, walletID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWalletMergeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return merge, nil
+}
+
+// lockPair locks the source and target wallets in ID order, so concurrent
+// merges of the same pair cannot deadlock
+func lockPair(ctx context.Context, tx WalletTx, sourceID, targetID uuid.UUID) (*models.Wallet, *models.Wallet, error) {
+	if sourceID == targetID {
+		return nil, nil, fmt.Errorf("%w: a wallet cannot be merged into itself", ErrWalletMergeConflict)
+	}
+
+	first, second := sourceID, targetID
+	if bytes.Compare(first[:], second[:]) > 0 {
+		first, second = second, first
+	}
+
+	locked := make(map[uuid.UUID]*models.Wallet, 2)
+	for _, id := range []uuid.UUID{first, second} {
+		wallet, err := tx.GetWalletForUpdate(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		locked[id] = wallet
+	}
+
+	return locked[sourceID], locked[targetID], nil
+}
+
+// moveBalance moves an amount between two wallets locked in the unit of
+// work, debiting one and crediting the other. The postings move balances
+// only: the transactions making up the amount move with the merge.
+func moveBalance(ctx context.Context, tx WalletTx, from, to *models.Wallet, amount float64, now time.Time) error {
+	if amount < 0 {
+		from, to, amount = to, from, -amount
+	}
+	if amount == 0 {
+		return nil
+	}
+
+	postings := []struct {
+		wallet *models.Wallet
+		typ    models.TransactionType
+	}{
+		{from, models.TransactionTypeDebit},
+		{to, models.TransactionTypeCredit},
+	}
+	for _, posting := range postings {
+		err := tx.ApplyTransaction(ctx, posting.wallet, &models.Transaction{
+			WalletID:    posting.wallet.ID,
+			Type:        posting.typ,
+			Status:      models.TransactionStatusCompleted,
+			Amount:      amount,
+			Currency:    posting.wallet.Currency,
+			EffectiveAt: &now,
+			CreatedAt:   now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move merged balance: %w", err)
+		}
+	}
+	return nil
+}
+
+// scanWalletMerge scans a merge selected with mergeColumns
+func scanWalletMerge(row rowScanner) (*models.WalletMerge, error) {
+	merge := &models.WalletMerge{}
+	var reversedAt sql.NullTime
+	err := row.Scan(
+		&merge.ID,
+		&merge.SourceWalletID,
+		&merge.TargetWalletID,
+		&merge.Status,
+		&merge.Amount,
+		pgArray(&merge.TransactionIDs),
+		&merge.MergedAt,
+		&merge.ReversibleUntil,
+		&reversedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan wallet merge: %w", err)
+	}
+	if reversedAt.Valid {
+		merge.ReversedAt = &reversedAt.Time
+	}
+
+	return merge, nil
+}
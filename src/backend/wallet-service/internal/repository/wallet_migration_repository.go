@@ -0,0 +1,446 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"         // v1.3.0
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+
+	"internal/models"
+)
+
+// Wallet migration repository errors
+var (
+	ErrWalletMigrationNotFound = errors.New("wallet migration not found")
+	ErrWalletMigrationConflict = errors.New("wallet migration is not in the required state")
+	ErrWalletExists            = errors.New("wallet already exists")
+)
+
+// migrationColumns is the column list scanned by scanWalletMigration
+const migrationColumns = `wallet_id, direction, status, checksum, exported_at, created_at, activated_at`
+
+// transactionColumns is the column list scanned by scanTransactions
+const transactionColumns = `id, wallet_id, type, status, amount, currency, description,
+                   reference_id, metadata, effective_at, created_at, updated_at`
+
+// WalletMigrationRepository defines the interface for moving wallets
+// between deployments. An exported wallet stays frozen on the source; an
+// imported wallet is frozen on the target until it is activated.
+type WalletMigrationRepository interface {
+	ExportWallet(ctx context.Context, walletID uuid.UUID, since time.Time, limit int, now time.Time) (*models.WalletExport, error)
+	ImportWallet(ctx context.Context, export *models.WalletExport, opening *models.Transaction, now time.Time) (*models.WalletMigration, error)
+	ActivateWallet(ctx context.Context, walletID uuid.UUID, checksum string, now time.Time) (*models.WalletMigration, error)
+	CancelExport(ctx context.Context, walletID uuid.UUID) error
+	GetWalletMigration(ctx context.Context, walletID uuid.UUID) (*models.WalletMigration, error)
+	Close() error
+}
+
+// walletMigrationRepository implements WalletMigrationRepository interface
+type walletMigrationRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewWalletMigrationRepository creates a new instance of WalletMigrationRepository
+func NewWalletMigrationRepository(db *sql.DB) (WalletMigrationRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &walletMigrationRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *walletMigrationRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	// Bumping the version locks the wallet and fails the optimistic
+	// locking of postings racing the export
+	lockWalletQuery = namedQuery{name: "lockWallet", sql: `
+            UPDATE wallets
+            SET version = version + 1
+            WHERE id = $1 AND deleted_at IS NULL
+            RETURNING id, customer_id, balance, currency, low_balance_threshold, credit_limit,
+                      created_at, updated_at, version`}
+
+	// Holds are pending refunds: their debit and the top-up they refund
+	// travel with the recent history
+	exportTransactionsQuery = namedQuery{name: "exportTransactions", sql: `
+            SELECT ` + transactionColumns + `
+            FROM wallet_transactions
+            WHERE wallet_id = $1 AND (
+                id IN (
+                    SELECT id FROM wallet_transactions
+                    WHERE wallet_id = $1 AND created_at >= $2
+                    ORDER BY created_at DESC
+                    LIMIT $3
+                )
+                OR id IN (
+                    SELECT transaction_id FROM provider_refunds WHERE wallet_id = $1 AND status = 'PENDING'
+                    UNION
+                    SELECT original_transaction_id FROM provider_refunds WHERE wallet_id = $1 AND status = 'PENDING'
+                )
+            )
+            ORDER BY created_at, id`}
+
+	exportPaymentsQuery = namedQuery{name: "exportPayments", sql: `
+            SELECT p.transaction_id, p.provider, p.provider_payment_id, p.created_at
+            FROM provider_payments p
+            JOIN provider_refunds r ON r.original_transaction_id = p.transaction_id
+            WHERE r.wallet_id = $1 AND r.status = 'PENDING'
+            GROUP BY p.transaction_id, p.provider, p.provider_payment_id, p.created_at
+            ORDER BY p.created_at, p.transaction_id`}
+
+	exportHoldsQuery = namedQuery{name: "exportHolds", sql: `
+            SELECT ` + refundColumns + `
+            FROM provider_refunds
+            WHERE wallet_id = $1 AND status = 'PENDING'
+            ORDER BY created_at, id`}
+
+	// A wallet may be exported again only after it was imported and
+	// activated here
+	freezeExportQuery = namedQuery{name: "freezeExport", sql: `
+            INSERT INTO wallet_migrations (wallet_id, direction, status, checksum, exported_at, created_at)
+            VALUES ($1, 'EXPORT', 'FROZEN', $2, $3, $3)
+            ON CONFLICT (wallet_id) DO UPDATE
+            SET direction = 'EXPORT', status = 'FROZEN', checksum = EXCLUDED.checksum,
+                exported_at = EXCLUDED.exported_at, created_at = EXCLUDED.created_at, activated_at = NULL
+            WHERE wallet_migrations.direction = 'IMPORT' AND wallet_migrations.status = 'ACTIVE'`}
+
+	walletMigrationInsertWalletQuery = namedQuery{name: "walletMigrationInsertWallet", sql: `
+            INSERT INTO wallets (id, customer_id, balance, currency, low_balance_threshold, credit_limit,
+                                 created_at, updated_at, version)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 1)`}
+
+	walletMigrationInsertTransactionQuery = namedQuery{name: "walletMigrationInsertTransaction", sql: `
+            INSERT INTO wallet_transactions (id, wallet_id, type, status, amount, currency, description,
+                                             reference_id, metadata, effective_at, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`}
+
+	insertPaymentQuery = namedQuery{name: "insertPayment", sql: `
+            INSERT INTO provider_payments (transaction_id, provider, provider_payment_id, created_at)
+            VALUES ($1, $2, $3, $4)`}
+
+	insertHoldQuery = namedQuery{name: "insertHold", sql: `
+            INSERT INTO provider_refunds (id, transaction_id, original_transaction_id, wallet_id, provider,
+                                          provider_payment_id, provider_refund_id, amount, currency, status,
+                                          attempts, next_check_at, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, 'PENDING', $10, $11, $12, $13)`}
+
+	insertImportQuery = namedQuery{name: "insertImport", sql: `
+            INSERT INTO wallet_migrations (wallet_id, direction, status, checksum, exported_at, created_at)
+            VALUES ($1, 'IMPORT', 'FROZEN', $2, $3, $4)
+            RETURNING ` + migrationColumns}
+
+	activateImportQuery = namedQuery{name: "activateImport", sql: `
+            UPDATE wallet_migrations
+            SET status = 'ACTIVE', activated_at = $3
+            WHERE wallet_id = $1 AND direction = 'IMPORT' AND status = 'FROZEN' AND checksum = $2
+            RETURNING ` + migrationColumns}
+
+	cancelExportQuery = namedQuery{name: "cancelExport", sql: `
+            DELETE FROM wallet_migrations
+            WHERE wallet_id = $1 AND direction = 'EXPORT' AND status = 'FROZEN'`}
+
+	getMigrationQuery = namedQuery{name: "getMigration", sql: `
+            SELECT ` + migrationColumns + `
+            FROM wallet_migrations
+            WHERE wallet_id = $1`}
+)
+
+// ExportWallet freezes a wallet and returns it with its open holds and its
+// transactions created since, up to limit, plus those the holds reference.
+// The export's checksum is recorded so the target can prove what it imported.
+func (r *walletMigrationRepository) ExportWallet(ctx context.Context, walletID uuid.UUID, since time.Time, limit int, now time.Time) (*models.WalletExport, error) {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	export := &models.WalletExport{
+		Version:    models.WalletExportVersion,
+		ExportedAt: now,
+	}
+	wallet := &export.Wallet
+	err = statements.QueryRowContext(ctx, lockWalletQuery, walletID).Scan(
+		&wallet.ID,
+		&wallet.CustomerID,
+		&wallet.Balance,
+		&wallet.Currency,
+		&wallet.LowBalanceThreshold,
+		&wallet.CreditLimit,
+		&wallet.CreatedAt,
+		&wallet.UpdatedAt,
+		&wallet.Version,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWalletNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock wallet: %w", err)
+	}
+
+	rows, err := statements.QueryContext(ctx, exportTransactionsQuery, walletID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export transactions: %w", err)
+	}
+	export.Transactions, err = scanTransactions(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = statements.QueryContext(ctx, exportPaymentsQuery, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export provider payments: %w", err)
+	}
+	for rows.Next() {
+		payment := &models.ProviderPayment{}
+		if err := rows.Scan(&payment.TransactionID, &payment.Provider, &payment.ProviderPaymentID, &payment.CreatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan provider payment: %w", err)
+		}
+		export.Payments = append(export.Payments, payment)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error iterating provider payments: %w", err)
+	}
+
+	rows, err = statements.QueryContext(ctx, exportHoldsQuery, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export holds: %w", err)
+	}
+	for rows.Next() {
+		hold, err := scanRefund(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		export.Holds = append(export.Holds, hold)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error iterating holds: %w", err)
+	}
+
+	if export.Checksum, err = export.ComputeChecksum(); err != nil {
+		return nil, fmt.Errorf("failed to compute export checksum: %w", err)
+	}
+
+	result, err := statements.ExecContext(ctx, freezeExportQuery, walletID, export.Checksum, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to freeze wallet: %w", err)
+	}
+	if frozen, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to get frozen count: %w", err)
+	} else if frozen == 0 {
+		return nil, ErrWalletMigrationConflict
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit export: %w", err)
+	}
+	return export, nil
+}
+
+// ImportWallet stores an exported wallet as given, with its transactions,
+// the opening balance transaction standing for its older history when set,
+// its provider payments and its holds, and freezes it until activation.
+// Holds are due for a status check straight away.
+func (r *walletMigrationRepository) ImportWallet(ctx context.Context, export *models.WalletExport, opening *models.Transaction, now time.Time) (*models.WalletMigration, error) {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	statements := r.statements.Tx(dbTx)
+
+	wallet := export.Wallet
+	_, err = statements.ExecContext(ctx, walletMigrationInsertWalletQuery,
+		wallet.ID,
+		wallet.CustomerID,
+		wallet.Balance,
+		wallet.Currency,
+		wallet.LowBalanceThreshold,
+		wallet.CreditLimit,
+		wallet.CreatedAt,
+		wallet.UpdatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrWalletExists
+	}
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert wallet: %w", err)
+	}
+
+	transactions := export.Transactions
+	if opening != nil {
+		transactions = append([]*models.Transaction{opening}, transactions...)
+	}
+	for _, tx := range transactions {
+		metadata, err := encodeMetadata(tx.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+		}
+		_, err = statements.ExecContext(ctx, walletMigrationInsertTransactionQuery,
+			tx.ID,
+			wallet.ID,
+			tx.Type.String(),
+			tx.Status.String(),
+			tx.Amount,
+			tx.Currency,
+			tx.Description,
+			tx.ReferenceID,
+			metadata,
+			tx.EffectiveAt,
+			tx.CreatedAt,
+			tx.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert transaction %s: %w", tx.ID, err)
+		}
+	}
+
+	for _, payment := range export.Payments {
+		_, err = statements.ExecContext(ctx, insertPaymentQuery,
+			payment.TransactionID,
+			payment.Provider,
+			payment.ProviderPaymentID,
+			payment.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert provider payment: %w", err)
+		}
+	}
+
+	for _, hold := range export.Holds {
+		_, err = statements.ExecContext(ctx, insertHoldQuery,
+			hold.ID,
+			hold.TransactionID,
+			hold.OriginalTransactionID,
+			wallet.ID,
+			hold.Provider,
+			hold.ProviderPaymentID,
+			hold.ProviderRefundID,
+			hold.Amount,
+			hold.Currency,
+			hold.Attempts,
+			now,
+			hold.CreatedAt,
+			hold.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert hold %s: %w", hold.ID, err)
+		}
+	}
+
+	migration, err := scanWalletMigration(statements.QueryRowContext(ctx, insertImportQuery,
+		wallet.ID,
+		export.Checksum,
+		export.ExportedAt,
+		now,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return migration, nil
+}
+
+// ActivateWallet unfreezes an imported wallet. The checksum must be that of
+// the import, proving the operator activates the export they cut over.
+func (r *walletMigrationRepository) ActivateWallet(ctx context.Context, walletID uuid.UUID, checksum string, now time.Time) (*models.WalletMigration, error) {
+	migration, err := scanWalletMigration(r.statements.QueryRowContext(ctx, activateImportQuery, walletID, checksum, now))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, r.stateError(ctx, walletID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return migration, nil
+}
+
+// CancelExport unfreezes an exported wallet whose import was abandoned
+func (r *walletMigrationRepository) CancelExport(ctx context.Context, walletID uuid.UUID) error {
+	result, err := r.statements.ExecContext(ctx, cancelExportQuery, walletID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel export: %w", err)
+	}
+
+	cancelled, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get cancelled count: %w", err)
+	}
+	if cancelled == 0 {
+		return r.stateError(ctx, walletID)
+	}
+	return nil
+}
+
+// GetWalletMigration returns the latest move of a wallet
+func (r *walletMigrationRepository) GetWalletMigration(ctx context.Context, walletID uuid.UUID) (*models.WalletMigration, error) {
+	migration, err := scanWalletMigration(r.statements.QueryRowContext(ctx, getMigrationQuery, walletID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWalletMigrationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return migration, nil
+}
+
+// stateError tells a wallet never migrated from one whose migration is in
+// another state
+func (r *walletMigrationRepository) stateError(ctx context.Context, walletID uuid.UUID) error {
+	_, err := r.GetWalletMigration(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	return ErrWalletMigrationConflict
+}
+
+// scanWalletMigration scans a migration selected with migrationColumns
+func scanWalletMigration(row rowScanner) (*models.WalletMigration, error) {
+	migration := &models.WalletMigration{}
+	var activatedAt sql.NullTime
+	err := row.Scan(
+		&migration.WalletID,
+		&migration.Direction,
+		&migration.Status,
+		&migration.Checksum,
+		&migration.ExportedAt,
+		&migration.CreatedAt,
+		&activatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan wallet migration: %w", err)
+	}
+	if activatedAt.Valid {
+		migration.ActivatedAt = &activatedAt.Time
+	}
+
+	return migration, nil
+}
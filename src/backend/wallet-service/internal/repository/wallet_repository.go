@@ -10,10 +10,11 @@ import (
     "time"
 
     "github.com/google/uuid"      // v1.3.0
-    "github.com/lib/pq"           // v1.10.9
+    "github.com/jackc/pgx/v5/pgconn" // v5.3.1
     "github.com/shopspring/decimal" // v1.3.1
 
     "internal/models"
+    "internal/tenancy"
 )
 
 // Common repository errors
@@ -22,21 +23,45 @@ var (
     ErrOptimisticLock = errors.New("wallet version conflict")
     ErrInvalidTransaction = errors.New("invalid transaction data")
     ErrInsufficientBalance = errors.New("insufficient wallet balance")
+    ErrPeriodClosed = errors.New("billing period is closed")
+    ErrWalletFrozen = errors.New("wallet is frozen for migration")
+    ErrCurrencyMismatch = errors.New("currency mismatch between wallet and transaction")
 )
 
-// WalletRepository defines the interface for wallet data operations
+// WalletRepository defines the interface for wallet data operations.
+// Balances change only within a unit of work. Wallets outside the
+// organization a context is confined to are not found.
 type WalletRepository interface {
+    UnitOfWork
+    // PostTransactions applies and records completed transactions in one
+    // database transaction sent as a batch, as bulk operations post them. A
+    // posting refused on its own, as to a missing, frozen or merged wallet,
+    // is left out with its error at its index; the others post.
+    PostTransactions(ctx context.Context, txs []*models.Transaction) ([]error, error)
     GetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
     CreateWallet(ctx context.Context, wallet *models.Wallet) error
-    UpdateBalance(ctx context.Context, tx *models.Transaction) error
+    UpdateCreditLimit(ctx context.Context, wallet *models.Wallet, creditLimit float64) error
     GetTransactions(ctx context.Context, walletID uuid.UUID, limit, offset int) ([]*models.Transaction, error)
+    // FindTransactions pages through the transactions matching a filter,
+    // returning the page and the number of matches
+    FindTransactions(ctx context.Context, walletID uuid.UUID, filter TransactionFilter, limit, offset int) ([]*models.Transaction, int, error)
     GetTransactionByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error)
+    GetCustomerWallets(ctx context.Context, customerID uuid.UUID) ([]*models.Wallet, error)
+    // GetWalletOverview reads a wallet and its newest transactions from one
+    // snapshot
+    GetWalletOverview(ctx context.Context, walletID uuid.UUID, limit int) (*models.WalletOverview, error)
+    GetRecentTransactions(ctx context.Context, walletIDs []uuid.UUID, limit int) ([]*models.Transaction, error)
+    FindTransactionsByReference(ctx context.Context, referenceID string, limit int) ([]*models.TransactionMatch, error)
+    GetWalletSettings(ctx context.Context, walletID uuid.UUID) (*models.WalletSettings, error)
+    UpdateWalletSettings(ctx context.Context, settings *models.WalletSettings) error
+    CountTransactionOutcomes(ctx context.Context, walletID uuid.UUID, since time.Time) (total, failed int, err error)
+    Close() error
 }
 
 // walletRepository implements WalletRepository interface
 type walletRepository struct {
     db         *sql.DB
-    statements map[string]*sql.Stmt
+    statements *preparedStatements
 }
 
 // NewWalletRepository creates a new instance of WalletRepository
@@ -45,76 +70,43 @@ func NewWalletRepository(db *sql.DB) (WalletRepository, error) {
         return nil, errors.New("database connection is required")
     }
 
-    repo := &walletRepository{
+    return &walletRepository{
         db:         db,
-        statements: make(map[string]*sql.Stmt),
-    }
-
-    if err := repo.prepareStatements(); err != nil {
-        return nil, fmt.Errorf("failed to prepare statements: %w", err)
-    }
+        statements: newPreparedStatements(db),
+    }, nil
+}
 
-    return repo, nil
+func (r *walletRepository) Close() error {
+    return r.statements.Close()
 }
 
-// prepareStatements prepares SQL statements for reuse
-func (r *walletRepository) prepareStatements() error {
-    statements := map[string]string{
-        "getWallet": `
-            SELECT id, customer_id, balance, currency, low_balance_threshold, 
-                   created_at, updated_at, version 
+// getWalletQuery reads a wallet of the organization the context is
+// confined to, if any
+var getWalletQuery = namedQuery{name: "getWallet", sql: `
+            SELECT id, customer_id, balance, currency, low_balance_threshold, credit_limit, 
+                   balance_minor, credit_limit_minor, amount_exponent,
+                   created_at, updated_at, last_activity_at, inactive_since, version 
             FROM wallets 
-            WHERE id = $1 AND deleted_at IS NULL`,
-        "createWallet": `
-            INSERT INTO wallets (id, customer_id, balance, currency, low_balance_threshold, 
-                               created_at, updated_at, version) 
-            VALUES ($1, $2, $3, $4, $5, $6, $6, 1)`,
-        "updateWallet": `
-            UPDATE wallets 
-            SET balance = $1, updated_at = $2, version = version + 1 
-            WHERE id = $3 AND version = $4 AND deleted_at IS NULL 
-            RETURNING version`,
-        "insertTransaction": `
-            INSERT INTO wallet_transactions (id, wallet_id, type, status, amount, 
-                                          currency, description, reference_id, created_at, updated_at) 
-            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)`,
-        "getTransaction": `
-            SELECT id, wallet_id, type, status, amount, currency, description, 
-                   reference_id, created_at, updated_at 
-            FROM wallet_transactions 
-            WHERE id = $1`,
-        "getTransactions": `
-            SELECT id, wallet_id, type, status, amount, currency, description, 
-                   reference_id, created_at, updated_at 
-            FROM wallet_transactions 
-            WHERE wallet_id = $1 
-            ORDER BY created_at DESC 
-            LIMIT $2 OFFSET $3`,
-    }
-
-    for name, query := range statements {
-        stmt, err := r.db.Prepare(query)
-        if err != nil {
-            return fmt.Errorf("failed to prepare statement %s: %w", name, err)
-        }
-        r.statements[name] = stmt
-    }
-
-    return nil
-}
+            WHERE id = $1 AND deleted_at IS NULL AND ($2::uuid IS NULL OR org_id = $2)`}
 
 // GetWallet retrieves a wallet by ID
 func (r *walletRepository) GetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
     wallet := &models.Wallet{}
     
-    err := r.statements["getWallet"].QueryRowContext(ctx, id).Scan(
+    err := r.statements.QueryRowContext(ctx, getWalletQuery, id, tenancy.Scope(ctx)).Scan(
         &wallet.ID,
         &wallet.CustomerID,
         &wallet.Balance,
         &wallet.Currency,
         &wallet.LowBalanceThreshold,
+        &wallet.CreditLimit,
+        &wallet.BalanceMinor.Units,
+        &wallet.CreditLimitMinor.Units,
+        &wallet.BalanceMinor.Exponent,
         &wallet.CreatedAt,
         &wallet.UpdatedAt,
+        &wallet.LastActivityAt,
+        &wallet.InactiveSince,
         &wallet.Version,
     )
 
@@ -124,116 +116,181 @@ func (r *walletRepository) GetWallet(ctx context.Context, id uuid.UUID) (*models
     if err != nil {
         return nil, fmt.Errorf("failed to get wallet: %w", err)
     }
+    wallet.CreditLimitMinor.Exponent = wallet.BalanceMinor.Exponent
 
     return wallet, nil
 }
 
+// createWalletQuery inserts a wallet. Wallets belong to the organization of
+// their customer.
+var createWalletQuery = namedQuery{name: "createWallet", sql: `
+            INSERT INTO wallets (id, customer_id, balance, currency, low_balance_threshold, credit_limit, 
+                               created_at, updated_at, version, org_id) 
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $7, 1, (SELECT org_id FROM customers WHERE id = $2))`}
+
 // CreateWallet creates a new wallet
 func (r *walletRepository) CreateWallet(ctx context.Context, wallet *models.Wallet) error {
     wallet.ID = uuid.New()
     wallet.CreatedAt = time.Now().UTC()
     wallet.Version = 1
 
-    _, err := r.statements["createWallet"].ExecContext(ctx,
+    _, err := r.statements.ExecContext(ctx, createWalletQuery,
         wallet.ID,
         wallet.CustomerID,
         wallet.Balance,
         wallet.Currency,
         wallet.LowBalanceThreshold,
+        wallet.CreditLimit,
         wallet.CreatedAt,
     )
 
     if err != nil {
-        if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+        var pgErr *pgconn.PgError
+        if errors.As(err, &pgErr) && pgErr.Code == "23505" {
             return fmt.Errorf("wallet already exists for customer: %w", err)
         }
+        if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+            return ErrCustomerNotFound
+        }
         return fmt.Errorf("failed to create wallet: %w", err)
     }
 
     return nil
 }
 
-// UpdateBalance updates wallet balance with optimistic locking
-func (r *walletRepository) UpdateBalance(ctx context.Context, tx *models.Transaction) error {
-    if err := tx.Validate(); err != nil {
-        return fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
-    }
+// updateCreditLimitQuery sets the credit limit of a wallet at a version,
+// unless the wallet is overdrawn beyond it
+var updateCreditLimitQuery = namedQuery{name: "updateCreditLimit", sql: `
+            UPDATE wallets 
+            SET credit_limit = $1, updated_at = $2, version = version + 1 
+            WHERE id = $3 AND version = $4 AND balance >= -$1 AND deleted_at IS NULL 
+            RETURNING version, updated_at`}
+
+// UpdateCreditLimit sets the overdraft limit of a wallet with optimistic
+// locking. The update is refused if the wallet is overdrawn beyond the new limit.
+func (r *walletRepository) UpdateCreditLimit(ctx context.Context, wallet *models.Wallet, creditLimit float64) error {
+    err := r.statements.QueryRowContext(ctx, updateCreditLimitQuery,
+        creditLimit,
+        time.Now().UTC(),
+        wallet.ID,
+        wallet.Version,
+    ).Scan(&wallet.Version, &wallet.UpdatedAt)
 
-    dbTx, err := r.db.BeginTx(ctx, &sql.TxOptions{
-        Isolation: sql.LevelSerializable,
-    })
-    if err != nil {
-        return fmt.Errorf("failed to begin transaction: %w", err)
+    if err == sql.ErrNoRows {
+        return ErrOptimisticLock
     }
-    defer dbTx.Rollback()
-
-    // Get current wallet state with lock
-    wallet, err := r.GetWallet(ctx, tx.WalletID)
     if err != nil {
-        return err
+        return fmt.Errorf("failed to update credit limit: %w", err)
     }
 
-    // Validate balance for debit transactions
-    if tx.Type == models.TransactionTypeDebit {
-        if !wallet.HasSufficientBalance(tx.Amount) {
-            return ErrInsufficientBalance
-        }
-    }
+    wallet.CreditLimit = creditLimit
+    return nil
+}
 
-    // Calculate new balance
-    newBalance := wallet.Balance
-    switch tx.Type {
-    case models.TransactionTypeCredit, models.TransactionTypeRefund:
-        newBalance += tx.Amount
-    case models.TransactionTypeDebit:
-        newBalance -= tx.Amount
+// getWalletSettingsQuery reads the settings of a wallet
+var getWalletSettingsQuery = namedQuery{name: "getWalletSettings", sql: `
+            SELECT id, low_balance_threshold, credit_limit, notify_low_balance, notify_credit_limit_warning,
+                   auto_topup_enabled, settings_version, updated_at
+            FROM wallets
+            WHERE id = $1 AND deleted_at IS NULL AND ($2::uuid IS NULL OR org_id = $2)`}
+
+// GetWalletSettings retrieves the settings of a wallet
+func (r *walletRepository) GetWalletSettings(ctx context.Context, walletID uuid.UUID) (*models.WalletSettings, error) {
+    settings := &models.WalletSettings{}
+
+    err := r.statements.QueryRowContext(ctx, getWalletSettingsQuery, walletID, tenancy.Scope(ctx)).Scan(
+        &settings.WalletID,
+        &settings.LowBalanceThreshold,
+        &settings.CreditLimit,
+        &settings.Notifications.LowBalance,
+        &settings.Notifications.CreditLimitWarning,
+        &settings.AutoTopUp,
+        &settings.Version,
+        &settings.UpdatedAt,
+    )
+
+    if err == sql.ErrNoRows {
+        return nil, ErrWalletNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get wallet settings: %w", err)
     }
 
-    // Update wallet balance with optimistic locking
-    var newVersion int64
-    err = dbTx.QueryRowContext(ctx,
-        "updateWallet",
-        newBalance,
+    return settings, nil
+}
+
+// updateWalletSettingsQuery stores the settings of a wallet at a settings
+// version. A changed credit limit also bumps the balance version, so debits
+// checked against the previous limit are retried.
+var updateWalletSettingsQuery = namedQuery{name: "updateWalletSettings", sql: `
+            UPDATE wallets
+            SET low_balance_threshold = $1, credit_limit = $2, notify_low_balance = $3,
+                notify_credit_limit_warning = $4, auto_topup_enabled = $5, updated_at = $6,
+                settings_version = settings_version + 1,
+                version = version + CASE WHEN credit_limit <> $2 THEN 1 ELSE 0 END
+            WHERE id = $7 AND settings_version = $8 AND balance >= -$2 AND deleted_at IS NULL
+            RETURNING settings_version, updated_at`}
+
+// UpdateWalletSettings stores the settings of a wallet if their version is
+// unchanged, refusing credit limits the wallet is already overdrawn beyond
+func (r *walletRepository) UpdateWalletSettings(ctx context.Context, settings *models.WalletSettings) error {
+    err := r.statements.QueryRowContext(ctx, updateWalletSettingsQuery,
+        settings.LowBalanceThreshold,
+        settings.CreditLimit,
+        settings.Notifications.LowBalance,
+        settings.Notifications.CreditLimitWarning,
+        settings.AutoTopUp,
         time.Now().UTC(),
-        wallet.ID,
-        wallet.Version,
-    ).Scan(&newVersion)
+        settings.WalletID,
+        settings.Version,
+    ).Scan(&settings.Version, &settings.UpdatedAt)
 
     if err == sql.ErrNoRows {
         return ErrOptimisticLock
     }
     if err != nil {
-        return fmt.Errorf("failed to update wallet balance: %w", err)
-    }
-
-    // Insert transaction record
-    tx.ID = uuid.New()
-    tx.CreatedAt = time.Now().UTC()
-    tx.UpdatedAt = tx.CreatedAt
-
-    _, err = r.statements["insertTransaction"].ExecContext(ctx,
-        tx.ID,
-        tx.WalletID,
-        tx.Type,
-        tx.Status,
-        tx.Amount,
-        tx.Currency,
-        tx.Description,
-        tx.ReferenceID,
-        tx.CreatedAt,
-    )
+        return fmt.Errorf("failed to update wallet settings: %w", err)
+    }
+
+    return nil
+}
+
+// countTransactionOutcomesQuery counts the transactions of a wallet since a
+// time, and those with a status
+var countTransactionOutcomesQuery = namedQuery{name: "countTransactionOutcomes", sql: `
+            SELECT COUNT(*), COUNT(*) FILTER (WHERE status = $3)
+            FROM wallet_transactions
+            WHERE wallet_id = $1 AND created_at >= $2`}
+
+// CountTransactionOutcomes counts the transactions of a wallet created since
+// the given time and how many of them failed
+func (r *walletRepository) CountTransactionOutcomes(ctx context.Context, walletID uuid.UUID, since time.Time) (int, int, error) {
+    var total, failed int
+    err := r.statements.QueryRowContext(ctx, countTransactionOutcomesQuery,
+        walletID,
+        since,
+        models.TransactionStatusFailed.String(),
+    ).Scan(&total, &failed)
     if err != nil {
-        return fmt.Errorf("failed to insert transaction: %w", err)
+        return 0, 0, fmt.Errorf("failed to count transaction outcomes: %w", err)
     }
 
-    return dbTx.Commit()
+    return total, failed, nil
 }
 
+// getTransactionQuery reads a transaction
+var getTransactionQuery = namedQuery{name: "getTransaction", sql: `
+            SELECT id, wallet_id, type, status, amount, currency, description, 
+                   reference_id, metadata, effective_at, created_at, updated_at 
+            FROM wallet_transactions 
+            WHERE id = $1 AND ($2::uuid IS NULL OR wallet_id IN (SELECT id FROM wallets WHERE org_id = $2))`}
+
 // GetTransactionByID retrieves a transaction by ID
 func (r *walletRepository) GetTransactionByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
     tx := &models.Transaction{}
+    var metadata []byte
     
-    err := r.statements["getTransaction"].QueryRowContext(ctx, id).Scan(
+    err := r.statements.QueryRowContext(ctx, getTransactionQuery, id, tenancy.Scope(ctx)).Scan(
         &tx.ID,
         &tx.WalletID,
         &tx.Type,
@@ -242,6 +299,8 @@ func (r *walletRepository) GetTransactionByID(ctx context.Context, id uuid.UUID)
         &tx.Currency,
         &tx.Description,
         &tx.ReferenceID,
+        &metadata,
+        &tx.EffectiveAt,
         &tx.CreatedAt,
         &tx.UpdatedAt,
     )
@@ -252,21 +311,381 @@ func (r *walletRepository) GetTransactionByID(ctx context.Context, id uuid.UUID)
     if err != nil {
         return nil, fmt.Errorf("failed to get transaction: %w", err)
     }
+    if tx.Metadata, err = decodeMetadata(metadata); err != nil {
+        return nil, fmt.Errorf("failed to decode transaction metadata: %w", err)
+    }
 
     return tx, nil
 }
 
+// getTransactionsQuery pages through the transactions of a wallet, newest
+// first
+var getTransactionsQuery = namedQuery{name: "getTransactions", sql: `
+            SELECT id, wallet_id, type, status, amount, currency, description, 
+                   reference_id, metadata, effective_at, created_at, updated_at 
+            FROM wallet_transactions 
+            WHERE wallet_id = $1 AND ($4::uuid IS NULL OR wallet_id IN (SELECT id FROM wallets WHERE org_id = $4)) 
+            ORDER BY COALESCE(effective_at, created_at) DESC, created_at DESC 
+            LIMIT $2 OFFSET $3`}
+
 // GetTransactions retrieves paginated transactions for a wallet
 func (r *walletRepository) GetTransactions(ctx context.Context, walletID uuid.UUID, limit, offset int) ([]*models.Transaction, error) {
-    rows, err := r.statements["getTransactions"].QueryContext(ctx, walletID, limit, offset)
+    rows, err := r.statements.QueryContext(ctx, getTransactionsQuery, walletID, limit, offset, tenancy.Scope(ctx))
     if err != nil {
         return nil, fmt.Errorf("failed to get transactions: %w", err)
     }
     defer rows.Close()
 
+    return scanTransactions(rows)
+}
+
+// TransactionFilter narrows the transactions read by FindTransactions.
+// Zero fields do not filter.
+type TransactionFilter struct {
+    // Since bounds created_at, which lets the planner skip the partitions of
+    // earlier months
+    Since    time.Time
+    // Horizon bounds created_at to within it of now when Since is unset, so
+    // the bound follows the clock that stamped the transactions
+    Horizon  time.Duration
+    Types    []models.TransactionType
+    Statuses []models.TransactionStatus
+    // EffectiveFrom and EffectiveTo bound the effective date, so backdated
+    // corrections count in the period they apply to
+    EffectiveFrom time.Time
+    EffectiveTo   time.Time
+    // MetadataKey keeps transactions carrying the key, with MetadataValue
+    // when that is set too
+    MetadataKey   string
+    MetadataValue string
+}
+
+// transactionFilterWhere selects the transactions of a wallet matching a
+// filter. Key and value pairs are matched by containment, which the
+// metadata GIN index serves; a key alone is matched by existence, which
+// it cannot.
+const transactionFilterWhere = `
+            WHERE wallet_id = $1 AND created_at >= $2
+              AND ($3::text[] IS NULL OR type = ANY($3))
+              AND ($4::text[] IS NULL OR status = ANY($4))
+              AND ($5::timestamptz IS NULL OR COALESCE(effective_at, created_at) >= $5)
+              AND ($6::timestamptz IS NULL OR COALESCE(effective_at, created_at) <= $6)
+              AND metadata @> $7::jsonb
+              AND ($8::text IS NULL OR metadata ? $8)
+              AND ($9::uuid IS NULL OR wallet_id IN (SELECT id FROM wallets WHERE org_id = $9))`
+
+var (
+    // findTransactionsQuery pages through the transactions of a wallet
+    // matching a filter, latest effective first
+    findTransactionsQuery = namedQuery{name: "findTransactions", sql: `
+            SELECT id, wallet_id, type, status, amount, currency, description, 
+                   reference_id, metadata, effective_at, created_at, updated_at 
+            FROM wallet_transactions` + transactionFilterWhere + `
+            ORDER BY COALESCE(effective_at, created_at) DESC, created_at DESC 
+            LIMIT $10 OFFSET $11`}
+
+    // countTransactionsQuery counts the transactions of a wallet matching a
+    // filter
+    countTransactionsQuery = namedQuery{name: "countTransactions", sql: `
+            SELECT COUNT(*)
+            FROM wallet_transactions` + transactionFilterWhere}
+)
+
+// FindTransactions retrieves a page of the transactions of a wallet matching
+// a filter and the number of matches. Every condition is applied by the
+// database, so pages are full and the total counts all matches.
+func (r *walletRepository) FindTransactions(ctx context.Context, walletID uuid.UUID, filter TransactionFilter, limit, offset int) ([]*models.Transaction, int, error) {
+    args, err := filter.args(walletID, tenancy.Scope(ctx))
+    if err != nil {
+        return nil, 0, err
+    }
+
+    var total int
+    if err := r.statements.QueryRowContext(ctx, countTransactionsQuery, args...).Scan(&total); err != nil {
+        return nil, 0, fmt.Errorf("failed to count transactions: %w", err)
+    }
+    if total == 0 {
+        return nil, 0, nil
+    }
+
+    rows, err := r.statements.QueryContext(ctx, findTransactionsQuery, append(args, limit, offset)...)
+    if err != nil {
+        return nil, 0, fmt.Errorf("failed to get transactions: %w", err)
+    }
+    defer rows.Close()
+
+    transactions, err := scanTransactions(rows)
+    if err != nil {
+        return nil, 0, err
+    }
+    return transactions, total, nil
+}
+
+// args returns the parameters of transactionFilterWhere
+func (f TransactionFilter) args(walletID uuid.UUID, org *uuid.UUID) ([]interface{}, error) {
+    var types, statuses []string
+    for _, t := range f.Types {
+        types = append(types, t.String())
+    }
+    for _, s := range f.Statuses {
+        statuses = append(statuses, s.String())
+    }
+
+    contains := map[string]string{}
+    var key sql.NullString
+    switch {
+    case f.MetadataKey != "" && f.MetadataValue != "":
+        contains[f.MetadataKey] = f.MetadataValue
+    case f.MetadataKey != "":
+        key = sql.NullString{String: f.MetadataKey, Valid: true}
+    }
+    metadata, err := encodeMetadata(contains)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+    }
+
+    since := f.Since
+    if since.IsZero() && f.Horizon > 0 {
+        since = time.Now().Add(-f.Horizon)
+    }
+
+    return []interface{}{
+        walletID,
+        since,
+        types,
+        statuses,
+        sql.NullTime{Time: f.EffectiveFrom, Valid: !f.EffectiveFrom.IsZero()},
+        sql.NullTime{Time: f.EffectiveTo, Valid: !f.EffectiveTo.IsZero()},
+        metadata,
+        key,
+        org,
+    }, nil
+}
+
+// snapshotTimeQuery reads the start time of the transaction
+var snapshotTimeQuery = namedQuery{name: "snapshotTime", sql: `
+            SELECT now()`}
+
+// GetWalletOverview reads a wallet and its newest transactions within one
+// read-only repeatable read transaction. Both reads see the same snapshot,
+// so a transaction committed in between cannot show in one but not the
+// other.
+func (r *walletRepository) GetWalletOverview(ctx context.Context, walletID uuid.UUID, limit int) (*models.WalletOverview, error) {
+    dbTx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+        Isolation: sql.LevelRepeatableRead,
+        ReadOnly:  true,
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer dbTx.Rollback()
+
+    stmts := r.statements.Tx(dbTx)
+    overview := &models.WalletOverview{Wallet: &models.Wallet{}}
+    if err := stmts.QueryRowContext(ctx, snapshotTimeQuery).Scan(&overview.AsOf); err != nil {
+        return nil, fmt.Errorf("failed to get snapshot time: %w", err)
+    }
+    overview.AsOf = overview.AsOf.UTC()
+
+    wallet := overview.Wallet
+    err = stmts.QueryRowContext(ctx, getWalletQuery, walletID, tenancy.Scope(ctx)).Scan(
+        &wallet.ID,
+        &wallet.CustomerID,
+        &wallet.Balance,
+        &wallet.Currency,
+        &wallet.LowBalanceThreshold,
+        &wallet.CreditLimit,
+        &wallet.BalanceMinor.Units,
+        &wallet.CreditLimitMinor.Units,
+        &wallet.BalanceMinor.Exponent,
+        &wallet.CreatedAt,
+        &wallet.UpdatedAt,
+        &wallet.LastActivityAt,
+        &wallet.InactiveSince,
+        &wallet.Version,
+    )
+    if err == sql.ErrNoRows {
+        return nil, ErrWalletNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get wallet: %w", err)
+    }
+    wallet.CreditLimitMinor.Exponent = wallet.BalanceMinor.Exponent
+
+    rows, err := stmts.QueryContext(ctx, getTransactionsQuery, walletID, limit, 0, tenancy.Scope(ctx))
+    if err != nil {
+        return nil, fmt.Errorf("failed to get transactions: %w", err)
+    }
+    defer rows.Close()
+
+    if overview.Transactions, err = scanTransactions(rows); err != nil {
+        return nil, err
+    }
+
+    return overview, nil
+}
+
+// getCustomerWalletsQuery reads the wallets of a customer, oldest first
+var getCustomerWalletsQuery = namedQuery{name: "getCustomerWallets", sql: `
+            SELECT id, customer_id, balance, currency, low_balance_threshold, credit_limit,
+                   balance_minor, credit_limit_minor, amount_exponent,
+                   created_at, updated_at, last_activity_at, inactive_since, version
+            FROM wallets
+            WHERE customer_id = $1 AND deleted_at IS NULL AND ($2::uuid IS NULL OR org_id = $2)
+            ORDER BY created_at`}
+
+// GetCustomerWallets retrieves every wallet of a customer, oldest first
+func (r *walletRepository) GetCustomerWallets(ctx context.Context, customerID uuid.UUID) ([]*models.Wallet, error) {
+    rows, err := r.statements.QueryContext(ctx, getCustomerWalletsQuery, customerID, tenancy.Scope(ctx))
+    if err != nil {
+        return nil, fmt.Errorf("failed to get customer wallets: %w", err)
+    }
+    defer rows.Close()
+
+    var wallets []*models.Wallet
+    for rows.Next() {
+        wallet := &models.Wallet{}
+        err := rows.Scan(
+            &wallet.ID,
+            &wallet.CustomerID,
+            &wallet.Balance,
+            &wallet.Currency,
+            &wallet.LowBalanceThreshold,
+            &wallet.CreditLimit,
+            &wallet.BalanceMinor.Units,
+            &wallet.CreditLimitMinor.Units,
+            &wallet.BalanceMinor.Exponent,
+            &wallet.CreatedAt,
+            &wallet.UpdatedAt,
+            &wallet.LastActivityAt,
+            &wallet.InactiveSince,
+            &wallet.Version,
+        )
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan wallet: %w", err)
+        }
+        wallet.CreditLimitMinor.Exponent = wallet.BalanceMinor.Exponent
+        wallets = append(wallets, wallet)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating wallets: %w", err)
+    }
+
+    return wallets, nil
+}
+
+// getRecentTransactionsQuery reads the newest transactions of each of
+// several wallets
+var getRecentTransactionsQuery = namedQuery{name: "getRecentTransactions", sql: `
+            SELECT id, wallet_id, type, status, amount, currency, description,
+                   reference_id, metadata, effective_at, created_at, updated_at
+            FROM (
+                SELECT t.*, ROW_NUMBER() OVER (PARTITION BY wallet_id ORDER BY created_at DESC) AS position
+                FROM wallet_transactions t
+                WHERE wallet_id = ANY($1::uuid[])
+            ) ranked
+            WHERE position <= $2
+            ORDER BY wallet_id, created_at DESC`}
+
+// GetRecentTransactions retrieves up to limit of the newest transactions of
+// each of several wallets in one query, grouped by wallet
+func (r *walletRepository) GetRecentTransactions(ctx context.Context, walletIDs []uuid.UUID, limit int) ([]*models.Transaction, error) {
+    ids := make([]string, len(walletIDs))
+    for i, id := range walletIDs {
+        ids[i] = id.String()
+    }
+
+    rows, err := r.statements.QueryContext(ctx, getRecentTransactionsQuery, ids, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get recent transactions: %w", err)
+    }
+    defer rows.Close()
+
+    return scanTransactions(rows)
+}
+
+// findTransactionsByReferenceQuery reads the newest transactions with a
+// reference ID, with their wallet and customer
+var findTransactionsByReferenceQuery = namedQuery{name: "findTransactionsByReference", sql: `
+            SELECT t.id, t.wallet_id, t.type, t.status, t.amount, t.currency, t.description,
+                   t.reference_id, t.metadata, t.effective_at, t.created_at, t.updated_at,
+                   w.id, w.customer_id, w.balance, w.currency, w.low_balance_threshold, w.credit_limit,
+                   w.created_at, w.updated_at, w.version,
+                   COALESCE(c.name, ''), COALESCE(c.email, ''), COALESCE(c.status::text, '')
+            FROM wallet_transactions t
+            JOIN wallets w ON w.id = t.wallet_id
+            LEFT JOIN customers c ON c.id = w.customer_id
+            WHERE t.reference_id = $1 AND ($3::uuid IS NULL OR w.org_id = $3)
+            ORDER BY t.created_at DESC
+            LIMIT $2`}
+
+// FindTransactionsByReference retrieves up to limit of the newest
+// transactions with a reference ID in any wallet, with their wallet and
+// customer. Soft-deleted wallets are included for support investigations.
+func (r *walletRepository) FindTransactionsByReference(ctx context.Context, referenceID string, limit int) ([]*models.TransactionMatch, error) {
+    rows, err := r.statements.QueryContext(ctx, findTransactionsByReferenceQuery, referenceID, limit, tenancy.Scope(ctx))
+    if err != nil {
+        return nil, fmt.Errorf("failed to find transactions by reference: %w", err)
+    }
+    defer rows.Close()
+
+    var matches []*models.TransactionMatch
+    for rows.Next() {
+        match := &models.TransactionMatch{
+            Transaction: &models.Transaction{},
+            Wallet:      &models.Wallet{},
+        }
+        tx, wallet := match.Transaction, match.Wallet
+        var metadata []byte
+        err := rows.Scan(
+            &tx.ID,
+            &tx.WalletID,
+            &tx.Type,
+            &tx.Status,
+            &tx.Amount,
+            &tx.Currency,
+            &tx.Description,
+            &tx.ReferenceID,
+            &metadata,
+            &tx.EffectiveAt,
+            &tx.CreatedAt,
+            &tx.UpdatedAt,
+            &wallet.ID,
+            &wallet.CustomerID,
+            &wallet.Balance,
+            &wallet.Currency,
+            &wallet.LowBalanceThreshold,
+            &wallet.CreditLimit,
+            &wallet.CreatedAt,
+            &wallet.UpdatedAt,
+            &wallet.Version,
+            &match.CustomerName,
+            &match.CustomerEmail,
+            &match.CustomerStatus,
+        )
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan transaction match: %w", err)
+        }
+        if tx.Metadata, err = decodeMetadata(metadata); err != nil {
+            return nil, fmt.Errorf("failed to decode transaction metadata: %w", err)
+        }
+        matches = append(matches, match)
+    }
+
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating transaction matches: %w", err)
+    }
+
+    return matches, nil
+}
+
+// scanTransactions reads every transaction row of a query
+func scanTransactions(rows *sql.Rows) ([]*models.Transaction, error) {
     var transactions []*models.Transaction
     for rows.Next() {
         tx := &models.Transaction{}
+        var metadata []byte
         err := rows.Scan(
             &tx.ID,
             &tx.WalletID,
@@ -276,18 +695,43 @@ func (r *walletRepository) GetTransactions(ctx context.Context, walletID uuid.UU
             &tx.Currency,
             &tx.Description,
             &tx.ReferenceID,
+            &metadata,
+            &tx.EffectiveAt,
             &tx.CreatedAt,
             &tx.UpdatedAt,
         )
         if err != nil {
             return nil, fmt.Errorf("failed to scan transaction: %w", err)
         }
+        if tx.Metadata, err = decodeMetadata(metadata); err != nil {
+            return nil, fmt.Errorf("failed to decode transaction metadata: %w", err)
+        }
         transactions = append(transactions, tx)
     }
 
-    if err = rows.Err(); err != nil {
+    if err := rows.Err(); err != nil {
         return nil, fmt.Errorf("error iterating transactions: %w", err)
     }
 
     return transactions, nil
-}
\ No newline at end of file
+}
+
+// encodeMetadata returns the JSONB column value of transaction metadata
+func encodeMetadata(metadata map[string]string) ([]byte, error) {
+    if len(metadata) == 0 {
+        return []byte("{}"), nil
+    }
+    return json.Marshal(metadata)
+}
+
+// decodeMetadata reads a JSONB metadata column, leaving empty metadata nil
+func decodeMetadata(data []byte) (map[string]string, error) {
+    var metadata map[string]string
+    if err := json.Unmarshal(data, &metadata); err != nil {
+        return nil, err
+    }
+    if len(metadata) == 0 {
+        return nil, nil
+    }
+    return metadata, nil
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"         // v1.3.0
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+
+	"internal/models"
+)
+
+// Wallet settings change repository errors
+var (
+	ErrSettingsChangeNotFound = errors.New("wallet settings change not found")
+	// ErrSettingsChangeConflict is returned when a change is reviewed or
+	// applied out of order, or requested while another change of the
+	// wallet is still open
+	ErrSettingsChangeConflict = errors.New("wallet settings change is not in the required state")
+)
+
+// settingsChangeColumns are the columns scanned by scanSettingsChange
+const settingsChangeColumns = `id, wallet_id, status, diff, reason, requested_by,
+            COALESCE(reviewed_by, ''), COALESCE(review_note, ''), reviewed_at, applied_at, created_at`
+
+// WalletSettingsChangeRepository defines the interface for staged wallet
+// settings change persistence
+type WalletSettingsChangeRepository interface {
+	// CreateSettingsChange stores a requested change, returning
+	// ErrSettingsChangeConflict while the wallet has another open change
+	CreateSettingsChange(ctx context.Context, change *models.WalletSettingsChange) error
+	GetSettingsChange(ctx context.Context, id uuid.UUID) (*models.WalletSettingsChange, error)
+	ListSettingsChanges(ctx context.Context, filter models.WalletSettingsChangeFilter) ([]*models.WalletSettingsChange, error)
+	// ReviewSettingsChange approves or rejects a pending change
+	ReviewSettingsChange(ctx context.Context, id uuid.UUID, status models.WalletSettingsChangeStatus, reviewer, note string, now time.Time) (*models.WalletSettingsChange, error)
+	// ReleaseSettingsChange returns an approved change that could not be
+	// applied to review
+	ReleaseSettingsChange(ctx context.Context, id uuid.UUID) error
+	MarkSettingsChangeApplied(ctx context.Context, id uuid.UUID, now time.Time) (*models.WalletSettingsChange, error)
+	Close() error
+}
+
+// walletSettingsChangeRepository implements WalletSettingsChangeRepository
+// interface
+type walletSettingsChangeRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewWalletSettingsChangeRepository creates a new instance of
+// WalletSettingsChangeRepository
+func NewWalletSettingsChangeRepository(db *sql.DB) (WalletSettingsChangeRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &walletSettingsChangeRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *walletSettingsChangeRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	createSettingsChangeQuery = namedQuery{name: "createSettingsChange", sql: `
+            INSERT INTO wallet_settings_changes (
+                id, wallet_id, status, diff, reason, requested_by, created_at
+            ) VALUES ($1, $2, $3, $4, $5, $6, $7)`}
+
+	getSettingsChangeQuery = namedQuery{name: "getSettingsChange", sql: `
+            SELECT ` + settingsChangeColumns + `
+            FROM wallet_settings_changes
+            WHERE id = $1`}
+
+	listSettingsChangesQuery = namedQuery{name: "listSettingsChanges", sql: `
+            SELECT ` + settingsChangeColumns + `
+            FROM wallet_settings_changes
+            WHERE ($1::uuid IS NULL OR wallet_id = $1) AND ($2 = '' OR status = $2)
+            ORDER BY created_at DESC
+            LIMIT $3`}
+
+	reviewSettingsChangeQuery = namedQuery{name: "reviewSettingsChange", sql: `
+            UPDATE wallet_settings_changes
+            SET status = $2, reviewed_by = $3, review_note = NULLIF($4, ''), reviewed_at = $5
+            WHERE id = $1 AND status = 'PENDING'
+            RETURNING ` + settingsChangeColumns}
+
+	releaseSettingsChangeQuery = namedQuery{name: "releaseSettingsChange", sql: `
+            UPDATE wallet_settings_changes
+            SET status = 'PENDING', reviewed_by = NULL, review_note = NULL, reviewed_at = NULL
+            WHERE id = $1 AND status = 'APPROVED'`}
+
+	markSettingsChangeAppliedQuery = namedQuery{name: "markSettingsChangeApplied", sql: `
+            UPDATE wallet_settings_changes
+            SET status = 'APPLIED', applied_at = $2
+            WHERE id = $1 AND status = 'APPROVED'
+            RETURNING ` + settingsChangeColumns}
+)
+
+// CreateSettingsChange stores a requested change
+func (r *walletSettingsChangeRepository) CreateSettingsChange(ctx context.Context, change *models.WalletSettingsChange) error {
+	diff, err := json.Marshal(change.Diff)
+	if err != nil {
+		return fmt.Errorf("failed to encode settings diff: %w", err)
+	}
+
+	_, err = r.statements.ExecContext(ctx, createSettingsChangeQuery,
+		change.ID,
+		change.WalletID,
+		change.Status,
+		diff,
+		change.Reason,
+		change.RequestedBy,
+		change.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrSettingsChangeConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create settings change: %w", err)
+	}
+	return nil
+}
+
+// GetSettingsChange retrieves a settings change by ID
+func (r *walletSettingsChangeRepository) GetSettingsChange(ctx context.Context, id uuid.UUID) (*models.WalletSettingsChange, error) {
+	change, err := scanSettingsChange(r.statements.QueryRowContext(ctx, getSettingsChangeQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSettingsChangeNotFound
+		}
+		return nil, fmt.Errorf("failed to get settings change: %w", err)
+	}
+	return change, nil
+}
+
+// ListSettingsChanges retrieves the latest settings changes matching the
+// filter, newest first
+func (r *walletSettingsChangeRepository) ListSettingsChanges(ctx context.Context, filter models.WalletSettingsChangeFilter) ([]*models.WalletSettingsChange, error) {
+	rows, err := r.statements.QueryContext(ctx, listSettingsChangesQuery, filter.WalletID, string(filter.Status), filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settings changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*models.WalletSettingsChange
+	for rows.Next() {
+		change, err := scanSettingsChange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan settings change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating settings changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// ReviewSettingsChange approves or rejects a pending change
+func (r *walletSettingsChangeRepository) ReviewSettingsChange(ctx context.Context, id uuid.UUID, status models.WalletSettingsChangeStatus, reviewer, note string, now time.Time) (*models.WalletSettingsChange, error) {
+	change, err := scanSettingsChange(r.statements.QueryRowContext(ctx, reviewSettingsChangeQuery, id, status, reviewer, note, now))
+	if err == nil {
+		return change, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to review settings change: %w", err)
+	}
+
+	if _, err := r.GetSettingsChange(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, ErrSettingsChangeConflict
+}
+
+// ReleaseSettingsChange returns an approved change to review
+func (r *walletSettingsChangeRepository) ReleaseSettingsChange(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.statements.ExecContext(ctx, releaseSettingsChangeQuery, id); err != nil {
+		return fmt.Errorf("failed to release settings change: %w", err)
+	}
+	return nil
+}
+
+// MarkSettingsChangeApplied records that an approved change was applied
+func (r *walletSettingsChangeRepository) MarkSettingsChangeApplied(ctx context.Context, id uuid.UUID, now time.Time) (*models.WalletSettingsChange, error) {
+	change, err := scanSettingsChange(r.statements.QueryRowContext(ctx, markSettingsChangeAppliedQuery, id, now))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSettingsChangeConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark settings change applied: %w", err)
+	}
+	return change, nil
+}
+
+// scanSettingsChange scans a settings change row selected with
+// settingsChangeColumns
+func scanSettingsChange(row rowScanner) (*models.WalletSettingsChange, error) {
+	var change models.WalletSettingsChange
+	var diff []byte
+	err := row.Scan(
+		&change.ID,
+		&change.WalletID,
+		&change.Status,
+		&diff,
+		&change.Reason,
+		&change.RequestedBy,
+		&change.ReviewedBy,
+		&change.ReviewNote,
+		&change.ReviewedAt,
+		&change.AppliedAt,
+		&change.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(diff, &change.Diff); err != nil {
+		return nil, fmt.Errorf("failed to decode settings diff: %w", err)
+	}
+	return &change, nil
+}
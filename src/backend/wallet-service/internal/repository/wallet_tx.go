@@ -0,0 +1,532 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"         // v1.3.0
+	"github.com/jackc/pgx/v5"        // v5.3.1
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+	"github.com/shopspring/decimal"  // v1.3.1
+
+	"internal/models"
+	"internal/tenancy"
+)
+
+// WalletTx is the wallet store seen from within a unit of work. Its
+// operations share one database transaction, so multi-step operations such
+// as transfers and holds commit or roll back as a whole.
+type WalletTx interface {
+	// GetWalletForUpdate retrieves a wallet, locking it until the unit of
+	// work ends
+	GetWalletForUpdate(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	// ApplyTransaction moves the balance of a wallet read in the unit of
+	// work by a transaction, updating the wallet's balance and version. It
+	// refuses postings into closed billing periods and to frozen or merged
+	// wallets.
+	ApplyTransaction(ctx context.Context, wallet *models.Wallet, tx *models.Transaction) error
+	// InsertTransaction records a transaction, assigning its ID and
+	// timestamps
+	InsertTransaction(ctx context.Context, tx *models.Transaction) error
+}
+
+// UnitOfWork runs wallet operations in a single database transaction
+type UnitOfWork interface {
+	// InTx runs fn in a serializable transaction, committing when fn returns
+	// nil and rolling back otherwise. Serialization failures are reported as
+	// ErrOptimisticLock.
+	InTx(ctx context.Context, fn func(tx WalletTx) error) error
+}
+
+// walletTx implements WalletTx on a database transaction
+type walletTx struct {
+	statements *txStatements
+}
+
+// InTx implements UnitOfWork
+func (r *walletRepository) InTx(ctx context.Context, fn func(tx WalletTx) error) error {
+	return inWalletTx(ctx, r.db, r.statements, func(tx *walletTx) error {
+		return fn(tx)
+	})
+}
+
+// inWalletTx runs fn in a serializable unit of work on the prepared
+// statements of a repository. Repositories recording their own rows along
+// with balance postings run their statements on the unit of work's
+// transaction, so both commit or roll back together.
+func inWalletTx(ctx context.Context, db *sql.DB, statements *preparedStatements, fn func(tx *walletTx) error) error {
+	dbTx, err := db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	if err := fn(&walletTx{statements: statements.Tx(dbTx)}); err != nil {
+		return serializationConflict(err)
+	}
+	if err := dbTx.Commit(); err != nil {
+		return serializationConflict(fmt.Errorf("failed to commit transaction: %w", err))
+	}
+	return nil
+}
+
+// getWalletForUpdateQuery reads a wallet, locking it until the transaction
+// ends
+var getWalletForUpdateQuery = namedQuery{name: "getWalletForUpdate", sql: `
+            SELECT id, customer_id, balance, currency, low_balance_threshold, credit_limit,
+                   balance_minor, credit_limit_minor, amount_exponent, created_at, updated_at, version
+            FROM wallets
+            WHERE id = $1 AND deleted_at IS NULL AND ($2::uuid IS NULL OR org_id = $2)
+            FOR UPDATE`}
+
+// GetWalletForUpdate implements WalletTx
+func (t *walletTx) GetWalletForUpdate(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	wallet := &models.Wallet{}
+
+	err := t.statements.QueryRowContext(ctx, getWalletForUpdateQuery, id, tenancy.Scope(ctx)).Scan(
+		&wallet.ID,
+		&wallet.CustomerID,
+		&wallet.Balance,
+		&wallet.Currency,
+		&wallet.LowBalanceThreshold,
+		&wallet.CreditLimit,
+		&wallet.BalanceMinor.Units,
+		&wallet.CreditLimitMinor.Units,
+		&wallet.BalanceMinor.Exponent,
+		&wallet.CreatedAt,
+		&wallet.UpdatedAt,
+		&wallet.Version,
+	)
+	wallet.CreditLimitMinor.Exponent = wallet.BalanceMinor.Exponent
+	if err == sql.ErrNoRows {
+		return nil, ErrWalletNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+
+	return wallet, nil
+}
+
+var (
+	// periodClosedQuery reports whether a billing period is closed
+	periodClosedQuery = namedQuery{name: "periodClosed", sql: `
+            SELECT EXISTS (
+                SELECT 1 FROM billing_periods
+                WHERE period = $1 AND status = 'CLOSED'
+                FOR SHARE
+            )`}
+
+	// walletFrozenQuery reports whether a wallet is frozen for migration
+	walletFrozenQuery = namedQuery{name: "walletFrozen", sql: `
+            SELECT EXISTS (
+                SELECT 1 FROM wallet_migrations
+                WHERE wallet_id = $1 AND status = 'FROZEN'
+                FOR SHARE
+            )`}
+
+	// walletMergedQuery reports whether a wallet was merged into another
+	walletMergedQuery = namedQuery{name: "walletMerged", sql: `
+            SELECT EXISTS (
+                SELECT 1 FROM wallet_merges
+                WHERE source_wallet_id = $1 AND status = 'MERGED'
+                FOR SHARE
+            )`}
+
+	// updateWalletQuery sets the balance of a wallet at a version. Its minor
+	// units follow from the decimal balance in the database.
+	updateWalletQuery = namedQuery{name: "updateWallet", sql: `
+            UPDATE wallets 
+            SET balance = $1, updated_at = $2, version = version + 1 
+            WHERE id = $3 AND version = $4 AND deleted_at IS NULL 
+            RETURNING version`}
+)
+
+// ApplyTransaction implements WalletTx
+func (t *walletTx) ApplyTransaction(ctx context.Context, wallet *models.Wallet, tx *models.Transaction) error {
+	if err := tx.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+	}
+
+	// Refuse postings into billing periods closed by finance. The shared
+	// lock keeps a reopened period from closing before this posting commits.
+	// Transactions not recorded yet have no creation time and post now.
+	now := time.Now().UTC()
+	posting := tx.EffectiveTime()
+	if posting.IsZero() {
+		posting = now
+	}
+	var closed bool
+	err := t.statements.QueryRowContext(ctx, periodClosedQuery,
+		models.BillingPeriodOf(posting),
+	).Scan(&closed)
+	if err != nil {
+		return fmt.Errorf("failed to check billing period: %w", err)
+	}
+	if closed {
+		return ErrPeriodClosed
+	}
+
+	// Refuse postings to wallets exported to or not yet activated from
+	// another deployment
+	var frozen bool
+	err = t.statements.QueryRowContext(ctx, walletFrozenQuery, wallet.ID).Scan(&frozen)
+	if err != nil {
+		return fmt.Errorf("failed to check wallet migration: %w", err)
+	}
+	if frozen {
+		return ErrWalletFrozen
+	}
+
+	// Refuse postings to duplicate wallets merged into a surviving wallet
+	var merged bool
+	err = t.statements.QueryRowContext(ctx, walletMergedQuery, wallet.ID).Scan(&merged)
+	if err != nil {
+		return fmt.Errorf("failed to check wallet merge: %w", err)
+	}
+	if merged {
+		return ErrWalletMerged
+	}
+
+	balance, err := wallet.BalanceAfter(tx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+	}
+	err = t.statements.QueryRowContext(ctx, updateWalletQuery,
+		balance.Decimal(),
+		now,
+		wallet.ID,
+		wallet.Version,
+	).Scan(&wallet.Version)
+	if err == sql.ErrNoRows {
+		return ErrOptimisticLock
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update wallet balance: %w", err)
+	}
+
+	wallet.SetBalance(balance)
+	wallet.UpdatedAt = now
+	return nil
+}
+
+// insertTransactionQuery records a transaction. Minor units left unset are
+// derived from the currency's precision by the database.
+var insertTransactionQuery = namedQuery{name: "insertTransaction", sql: `
+            INSERT INTO wallet_transactions (id, wallet_id, type, status, amount, 
+                                          currency, description, reference_id, metadata, effective_at, created_at, updated_at,
+                                          amount_minor, amount_exponent) 
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11, NULLIF($12, 0), $13)`}
+
+// InsertTransaction implements WalletTx
+func (t *walletTx) InsertTransaction(ctx context.Context, tx *models.Transaction) error {
+	metadata, err := encodeMetadata(tx.Metadata)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+	}
+
+	tx.ID = uuid.New()
+	tx.CreatedAt = time.Now().UTC()
+	tx.UpdatedAt = tx.CreatedAt
+
+	_, err = t.statements.ExecContext(ctx, insertTransactionQuery,
+		tx.ID,
+		tx.WalletID,
+		tx.Type,
+		tx.Status,
+		tx.Amount,
+		tx.Currency,
+		tx.Description,
+		tx.ReferenceID,
+		metadata,
+		tx.EffectiveAt,
+		tx.CreatedAt,
+		tx.Minor.Units,
+		tx.Minor.Exponent,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert transaction: %w", err)
+	}
+
+	return nil
+}
+
+var (
+	// lockPostingWalletsQuery reads the wallets of a batch of postings,
+	// locking them in ID order, with whether each is frozen for migration or
+	// merged into another wallet
+	lockPostingWalletsQuery = namedQuery{name: "lockPostingWallets", sql: `
+            SELECT w.id, w.balance, w.currency, w.credit_limit, w.balance_minor, w.credit_limit_minor,
+                   w.amount_exponent, w.version,
+                   EXISTS (
+                       SELECT 1 FROM wallet_migrations m
+                       WHERE m.wallet_id = w.id AND m.status = 'FROZEN'
+                       FOR SHARE
+                   ),
+                   EXISTS (
+                       SELECT 1 FROM wallet_merges g
+                       WHERE g.source_wallet_id = w.id AND g.status = 'MERGED'
+                       FOR SHARE
+                   )
+            FROM wallets w
+            WHERE w.id = ANY($1) AND w.deleted_at IS NULL AND ($2::uuid IS NULL OR w.org_id = $2)
+            ORDER BY w.id
+            FOR UPDATE OF w`}
+
+	// closedPeriodsQuery lists which of some billing periods are closed
+	closedPeriodsQuery = namedQuery{name: "closedPeriods", sql: `
+            SELECT period FROM billing_periods
+            WHERE period = ANY($1) AND status = 'CLOSED'
+            FOR SHARE`}
+)
+
+// postingWallet is a wallet locked for a batch of postings
+type postingWallet struct {
+	models.Wallet
+	frozen  bool
+	merged  bool
+	touched bool
+}
+
+// PostTransactions implements WalletRepository. The wallets and billing
+// periods of the postings are locked and checked in two queries, then the
+// inserts and balance updates go in one batch, so a batch of postings costs
+// a few round trips rather than several for each posting.
+func (r *walletRepository) PostTransactions(ctx context.Context, txs []*models.Transaction) ([]error, error) {
+	refused := make([]error, len(txs))
+	if len(txs) == 0 {
+		return refused, nil
+	}
+
+	now := time.Now().UTC()
+	periodOf := func(tx *models.Transaction) time.Time {
+		if tx.EffectiveAt != nil {
+			return models.BillingPeriodOf(*tx.EffectiveAt)
+		}
+		return models.BillingPeriodOf(now)
+	}
+
+	var ids []uuid.UUID
+	var periods []time.Time
+	seenWallets := make(map[uuid.UUID]bool)
+	seenPeriods := make(map[time.Time]bool)
+	for _, tx := range txs {
+		if !seenWallets[tx.WalletID] {
+			seenWallets[tx.WalletID] = true
+			ids = append(ids, tx.WalletID)
+		}
+		if period := periodOf(tx); !seenPeriods[period] {
+			seenPeriods[period] = true
+			periods = append(periods, period)
+		}
+	}
+
+	err := inPgxTx(ctx, r.db, insertTransactionQuery, func(dbTx pgx.Tx) error {
+		wallets := make(map[uuid.UUID]*postingWallet, len(ids))
+		rows, err := dbTx.Query(ctx, lockPostingWalletsQuery.text(), ids, tenancy.Scope(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to lock wallets: %w", err)
+		}
+		for rows.Next() {
+			wallet := &postingWallet{}
+			if err := rows.Scan(
+				&wallet.ID,
+				&wallet.Balance,
+				&wallet.Currency,
+				&wallet.CreditLimit,
+				&wallet.BalanceMinor.Units,
+				&wallet.CreditLimitMinor.Units,
+				&wallet.BalanceMinor.Exponent,
+				&wallet.Version,
+				&wallet.frozen,
+				&wallet.merged,
+			); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan wallet: %w", err)
+			}
+			wallet.CreditLimitMinor.Exponent = wallet.BalanceMinor.Exponent
+			wallets[wallet.ID] = wallet
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to lock wallets: %w", err)
+		}
+
+		closed := make(map[time.Time]bool)
+		rows, err = dbTx.Query(ctx, closedPeriodsQuery.text(), periods)
+		if err != nil {
+			return fmt.Errorf("failed to check billing periods: %w", err)
+		}
+		for rows.Next() {
+			var period time.Time
+			if err := rows.Scan(&period); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan billing period: %w", err)
+			}
+			closed[models.BillingPeriodOf(period)] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to check billing periods: %w", err)
+		}
+
+		// Refuse postings as ProcessTransaction and ApplyTransaction do,
+		// applying the others to the locked wallets in order
+		batch := &pgx.Batch{}
+		for i, tx := range txs {
+			if err := tx.Validate(); err != nil {
+				refused[i] = fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+				continue
+			}
+			wallet, ok := wallets[tx.WalletID]
+			switch {
+			case !ok:
+				refused[i] = ErrWalletNotFound
+			case wallet.Currency != tx.Currency:
+				refused[i] = ErrCurrencyMismatch
+			case tx.SignedAmount() < 0 && !wallet.HasSufficientMinorBalance(tx.SignedMinor().Neg()):
+				refused[i] = ErrInsufficientBalance
+			case closed[periodOf(tx)]:
+				refused[i] = ErrPeriodClosed
+			case wallet.frozen:
+				refused[i] = ErrWalletFrozen
+			case wallet.merged:
+				refused[i] = ErrWalletMerged
+			}
+			if refused[i] != nil {
+				continue
+			}
+			metadata, err := encodeMetadata(tx.Metadata)
+			if err != nil {
+				refused[i] = fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+				continue
+			}
+			balance, err := wallet.BalanceAfter(tx)
+			if err != nil {
+				refused[i] = fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+				continue
+			}
+
+			wallet.SetBalance(balance)
+			wallet.touched = true
+			tx.ID = uuid.New()
+			tx.CreatedAt = now
+			tx.UpdatedAt = now
+			batch.Queue(insertTransactionQuery.text(),
+				tx.ID,
+				tx.WalletID,
+				tx.Type,
+				tx.Status,
+				tx.Amount,
+				tx.Currency,
+				tx.Description,
+				tx.ReferenceID,
+				metadata,
+				tx.EffectiveAt,
+				tx.CreatedAt,
+				tx.Minor.Units,
+				tx.Minor.Exponent,
+			)
+		}
+		inserts := batch.Len()
+		if inserts == 0 {
+			return nil
+		}
+
+		var touched []*postingWallet
+		for _, id := range ids {
+			if wallet, ok := wallets[id]; ok && wallet.touched {
+				batch.Queue(updateWalletQuery.text(), wallet.BalanceMinor.Decimal(), now, wallet.ID, wallet.Version)
+				touched = append(touched, wallet)
+			}
+		}
+
+		results := dbTx.SendBatch(ctx, batch)
+		for i := 0; i < inserts; i++ {
+			if _, err := results.Exec(); err != nil {
+				results.Close()
+				return fmt.Errorf("failed to insert transaction: %w", err)
+			}
+		}
+		for _, wallet := range touched {
+			err := results.QueryRow().Scan(&wallet.Version)
+			if errors.Is(err, pgx.ErrNoRows) {
+				results.Close()
+				return ErrOptimisticLock
+			}
+			if err != nil {
+				results.Close()
+				return fmt.Errorf("failed to update wallet balance: %w", err)
+			}
+		}
+		return results.Close()
+	})
+	if err != nil {
+		return nil, serializationConflict(err)
+	}
+	return refused, nil
+}
+
+// placeHold holds an amount of a wallet with a PROCESSING debit posted in
+// the unit of work, into the wallet's credit limit if need be. The hold
+// settles as completed, or as failed or reversed along with releaseHold.
+func placeHold(ctx context.Context, tx WalletTx, walletID uuid.UUID, amount decimal.Decimal, currency, description, referenceID string) (*models.Transaction, error) {
+	wallet, err := tx.GetWalletForUpdate(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	held, _ := amount.Float64()
+	if !wallet.HasSufficientBalance(held) {
+		return nil, ErrInsufficientBalance
+	}
+
+	hold := &models.Transaction{
+		WalletID:    walletID,
+		Type:        models.TransactionTypeDebit,
+		Status:      models.TransactionStatusProcessing,
+		Amount:      held,
+		Currency:    currency,
+		Description: description,
+		ReferenceID: referenceID,
+	}
+	if err := tx.ApplyTransaction(ctx, wallet, hold); err != nil {
+		return nil, err
+	}
+	if err := tx.InsertTransaction(ctx, hold); err != nil {
+		return nil, fmt.Errorf("failed to insert hold: %w", err)
+	}
+	return hold, nil
+}
+
+// releaseHold credits a held amount back to its wallet in the unit of work.
+// The posting moves the balance only: the hold's own transaction records
+// the release by its final status.
+func releaseHold(ctx context.Context, tx WalletTx, walletID uuid.UUID, amount decimal.Decimal, currency string) error {
+	wallet, err := tx.GetWalletForUpdate(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	released, _ := amount.Float64()
+	return tx.ApplyTransaction(ctx, wallet, &models.Transaction{
+		WalletID: walletID,
+		Type:     models.TransactionTypeCredit,
+		Status:   models.TransactionStatusCompleted,
+		Amount:   released,
+		Currency: currency,
+	})
+}
+
+// serializationConflict reports serialization failures of concurrent units
+// of work as ErrOptimisticLock, so callers retry them like version conflicts
+func serializationConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "40001" {
+		return ErrOptimisticLock
+	}
+	return err
+}
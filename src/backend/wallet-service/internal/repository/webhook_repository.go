@@ -0,0 +1,383 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"         // v1.3.0
+	"github.com/jackc/pgx/v5/pgconn" // v5.3.1
+
+	"internal/models"
+)
+
+// ErrWebhookNotFound is returned when a webhook subscription does not exist
+// or belongs to another customer
+var ErrWebhookNotFound = errors.New("webhook subscription not found")
+
+// webhookColumns is the column list scanned by scanWebhook
+const webhookColumns = `id, customer_id, wallet_id, url, secret, event_types, wallet_ids, fields, enabled, created_at, updated_at`
+
+// webhookDeliveryColumns is the column list scanned by ListDeliveries
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, status, COALESCE(response_code, 0),
+                                COALESCE(error, ''), attempted_at`
+
+// WebhookRepository defines the interface for webhook subscription
+// persistence. Tenant-level subscriptions are addressed by customer and
+// wallet-level subscriptions by wallet.
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, customerID, id uuid.UUID) error
+	GetSubscription(ctx context.Context, customerID, id uuid.UUID) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, customerID uuid.UUID) ([]*models.WebhookSubscription, error)
+	DeleteWalletSubscription(ctx context.Context, walletID, id uuid.UUID) error
+	GetWalletSubscription(ctx context.Context, walletID, id uuid.UUID) (*models.WebhookSubscription, error)
+	ListWalletSubscriptions(ctx context.Context, walletID uuid.UUID) ([]*models.WebhookSubscription, error)
+	ListActiveForWallet(ctx context.Context, walletID uuid.UUID) ([]*models.WebhookSubscription, error)
+	ListActiveForCustomer(ctx context.Context, customerID uuid.UUID) ([]*models.WebhookSubscription, error)
+	RecordDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]*models.WebhookDelivery, error)
+	Close() error
+}
+
+// webhookRepository implements WebhookRepository interface
+type webhookRepository struct {
+	db         *sql.DB
+	statements *preparedStatements
+}
+
+// NewWebhookRepository creates a new instance of WebhookRepository
+func NewWebhookRepository(db *sql.DB) (WebhookRepository, error) {
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &webhookRepository{
+		db:         db,
+		statements: newPreparedStatements(db),
+	}, nil
+}
+
+func (r *webhookRepository) Close() error {
+	return r.statements.Close()
+}
+
+var (
+	createSubscriptionQuery = namedQuery{name: "createSubscription", sql: `
+            INSERT INTO webhook_subscriptions (id, customer_id, wallet_id, url, secret, event_types, wallet_ids,
+                                               fields, enabled, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7::uuid[], $8, $9, $10, $10)`}
+
+	updateSubscriptionQuery = namedQuery{name: "updateSubscription", sql: `
+            UPDATE webhook_subscriptions
+            SET url = $3, event_types = $4, wallet_ids = $5::uuid[], fields = $6, enabled = $7, updated_at = $8
+            WHERE id = $1 AND customer_id = $2 AND wallet_id IS NULL
+            RETURNING secret, created_at`}
+
+	updateWalletSubscriptionQuery = namedQuery{name: "updateWalletSubscription", sql: `
+            UPDATE webhook_subscriptions
+            SET url = $3, event_types = $4, wallet_ids = $5::uuid[], fields = $6, enabled = $7, updated_at = $8
+            WHERE id = $1 AND wallet_id = $2
+            RETURNING customer_id, secret, created_at`}
+
+	deleteSubscriptionQuery = namedQuery{name: "deleteSubscription", sql: `
+            DELETE FROM webhook_subscriptions
+            WHERE id = $1 AND customer_id = $2 AND wallet_id IS NULL`}
+
+	deleteWalletSubscriptionQuery = namedQuery{name: "deleteWalletSubscription", sql: `
+            DELETE FROM webhook_subscriptions
+            WHERE id = $1 AND wallet_id = $2`}
+
+	webhookGetSubscriptionQuery = namedQuery{name: "webhookGetSubscription", sql: `
+            SELECT ` + webhookColumns + `
+            FROM webhook_subscriptions
+            WHERE id = $1 AND customer_id = $2 AND wallet_id IS NULL`}
+
+	getWalletSubscriptionQuery = namedQuery{name: "getWalletSubscription", sql: `
+            SELECT ` + webhookColumns + `
+            FROM webhook_subscriptions
+            WHERE id = $1 AND wallet_id = $2`}
+
+	listSubscriptionsQuery = namedQuery{name: "listSubscriptions", sql: `
+            SELECT ` + webhookColumns + `
+            FROM webhook_subscriptions
+            WHERE customer_id = $1 AND wallet_id IS NULL
+            ORDER BY created_at`}
+
+	listWalletSubscriptionsQuery = namedQuery{name: "listWalletSubscriptions", sql: `
+            SELECT ` + webhookColumns + `
+            FROM webhook_subscriptions
+            WHERE wallet_id = $1
+            ORDER BY created_at`}
+
+	webhookListActiveForWalletQuery = namedQuery{name: "webhookListActiveForWallet", sql: `
+            SELECT ` + webhookColumns + `
+            FROM webhook_subscriptions
+            WHERE enabled
+              AND (wallet_id = $1
+                   OR (wallet_id IS NULL AND customer_id = (SELECT customer_id FROM wallets WHERE id = $1)))`}
+
+	listActiveForCustomerQuery = namedQuery{name: "listActiveForCustomer", sql: `
+            SELECT ` + webhookColumns + `
+            FROM webhook_subscriptions
+            WHERE enabled AND customer_id = $1 AND wallet_id IS NULL`}
+
+	webhookRecordDeliveryQuery = namedQuery{name: "webhookRecordDelivery", sql: `
+            INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, status, response_code,
+                                            error, attempted_at)
+            VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NULLIF($7, ''), $8)`}
+
+	listDeliveriesQuery = namedQuery{name: "listDeliveries", sql: `
+            SELECT ` + webhookDeliveryColumns + `
+            FROM webhook_deliveries
+            WHERE subscription_id = $1
+            ORDER BY attempted_at DESC
+            LIMIT $2`}
+)
+
+// CreateSubscription stores a new webhook subscription, owned by its wallet
+// when WalletID is set
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	sub.ID = uuid.New()
+	sub.CreatedAt = time.Now().UTC()
+	sub.UpdatedAt = sub.CreatedAt
+
+	_, err := r.statements.ExecContext(ctx, createSubscriptionQuery,
+		sub.ID,
+		sub.CustomerID,
+		sub.WalletID,
+		sub.URL,
+		sub.Secret,
+		sub.EventTypes,
+		sub.WalletIDs,
+		sub.Fields,
+		sub.Enabled,
+		sub.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" && sub.WalletID != nil {
+		return ErrWalletNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSubscription replaces the endpoint and rules of a customer's
+// subscription, or of a wallet's when WalletID is set. The signing secret is
+// never changed.
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	sub.UpdatedAt = time.Now().UTC()
+
+	args := []interface{}{
+		sub.ID,
+		sub.CustomerID,
+		sub.URL,
+		sub.EventTypes,
+		sub.WalletIDs,
+		sub.Fields,
+		sub.Enabled,
+		sub.UpdatedAt,
+	}
+
+	var err error
+	if sub.WalletID != nil {
+		args[1] = *sub.WalletID
+		err = r.statements.QueryRowContext(ctx, updateWalletSubscriptionQuery, args...).
+			Scan(&sub.CustomerID, &sub.Secret, &sub.CreatedAt)
+	} else {
+		err = r.statements.QueryRowContext(ctx, updateSubscriptionQuery, args...).
+			Scan(&sub.Secret, &sub.CreatedAt)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWebhookNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSubscription removes a customer's subscription and its delivery log
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, customerID, id uuid.UUID) error {
+	return r.delete(ctx, deleteSubscriptionQuery, id, customerID)
+}
+
+// DeleteWalletSubscription removes a wallet's subscription and its delivery log
+func (r *webhookRepository) DeleteWalletSubscription(ctx context.Context, walletID, id uuid.UUID) error {
+	return r.delete(ctx, deleteWalletSubscriptionQuery, id, walletID)
+}
+
+// delete runs a prepared subscription delete, reporting a missing subscription
+func (r *webhookRepository) delete(ctx context.Context, q namedQuery, id, ownerID uuid.UUID) error {
+	result, err := r.statements.ExecContext(ctx, q, id, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get deleted count: %w", err)
+	}
+	if deleted == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// GetSubscription retrieves one of a customer's subscriptions
+func (r *webhookRepository) GetSubscription(ctx context.Context, customerID, id uuid.UUID) (*models.WebhookSubscription, error) {
+	return r.get(ctx, webhookGetSubscriptionQuery, id, customerID)
+}
+
+// GetWalletSubscription retrieves one of a wallet's subscriptions
+func (r *webhookRepository) GetWalletSubscription(ctx context.Context, walletID, id uuid.UUID) (*models.WebhookSubscription, error) {
+	return r.get(ctx, getWalletSubscriptionQuery, id, walletID)
+}
+
+// get runs a prepared single subscription query
+func (r *webhookRepository) get(ctx context.Context, q namedQuery, id, ownerID uuid.UUID) (*models.WebhookSubscription, error) {
+	sub, err := scanWebhook(r.statements.QueryRowContext(ctx, q, id, ownerID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// ListSubscriptions retrieves all of a customer's subscriptions
+func (r *webhookRepository) ListSubscriptions(ctx context.Context, customerID uuid.UUID) ([]*models.WebhookSubscription, error) {
+	return r.query(ctx, listSubscriptionsQuery, customerID)
+}
+
+// ListWalletSubscriptions retrieves all of a wallet's subscriptions
+func (r *webhookRepository) ListWalletSubscriptions(ctx context.Context, walletID uuid.UUID) ([]*models.WebhookSubscription, error) {
+	return r.query(ctx, listWalletSubscriptionsQuery, walletID)
+}
+
+// ListActiveForWallet retrieves the enabled subscriptions of a wallet and of
+// its owner. Event and wallet filters are applied by the caller.
+func (r *webhookRepository) ListActiveForWallet(ctx context.Context, walletID uuid.UUID) ([]*models.WebhookSubscription, error) {
+	return r.query(ctx, webhookListActiveForWalletQuery, walletID)
+}
+
+// ListActiveForCustomer retrieves a customer's enabled subscriptions
+func (r *webhookRepository) ListActiveForCustomer(ctx context.Context, customerID uuid.UUID) ([]*models.WebhookSubscription, error) {
+	return r.query(ctx, listActiveForCustomerQuery, customerID)
+}
+
+// RecordDelivery stores the outcome of a delivery attempt
+func (r *webhookRepository) RecordDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	delivery.ID = uuid.New()
+
+	_, err := r.statements.ExecContext(ctx, webhookRecordDeliveryQuery,
+		delivery.ID,
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Status,
+		delivery.ResponseCode,
+		delivery.Error,
+		delivery.AttemptedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeliveries retrieves the most recent delivery attempts of a
+// subscription, newest first
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]*models.WebhookDelivery, error) {
+	rows, err := r.statements.QueryContext(ctx, listDeliveriesQuery, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		delivery := &models.WebhookDelivery{}
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.EventID,
+			&delivery.EventType,
+			&delivery.Status,
+			&delivery.ResponseCode,
+			&delivery.Error,
+			&delivery.AttemptedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// query runs a prepared subscription query and scans every row
+func (r *webhookRepository) query(ctx context.Context, q namedQuery, args ...interface{}) ([]*models.WebhookSubscription, error) {
+	rows, err := r.statements.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []*models.WebhookSubscription
+	for rows.Next() {
+		sub, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+// scanWebhook scans a webhook subscription selected with webhookColumns
+func scanWebhook(row rowScanner) (*models.WebhookSubscription, error) {
+	sub := &models.WebhookSubscription{}
+	err := row.Scan(
+		&sub.ID,
+		&sub.CustomerID,
+		&sub.WalletID,
+		&sub.URL,
+		&sub.Secret,
+		pgArray(&sub.EventTypes),
+		pgArray(&sub.WalletIDs),
+		pgArray(&sub.Fields),
+		&sub.Enabled,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+	}
+
+	return sub, nil
+}
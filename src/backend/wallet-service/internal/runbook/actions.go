@@ -0,0 +1,121 @@
+package runbook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8" // v8.11.5
+	"github.com/google/uuid"       // v1.3.0
+
+	"internal/models"
+)
+
+// CacheFlushAction returns an action deleting a single Redis key. Only keys
+// matching one of the allowed prefixes may be flushed so the action cannot be
+// used to wipe unrelated data.
+func CacheFlushAction(client *redis.Client, allowedPrefixes []string) Action {
+	return Action{
+		Name:        "flush-cache-key",
+		Description: "Delete a single cache key with an allowed prefix",
+		Params:      []string{"key"},
+		Run: func(ctx context.Context, params map[string]string) (interface{}, error) {
+			key := params["key"]
+
+			allowed := false
+			for _, prefix := range allowedPrefixes {
+				if strings.HasPrefix(key, prefix) {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return nil, fmt.Errorf("key %q does not match an allowed prefix", key)
+			}
+
+			deleted, err := client.Del(ctx, key).Result()
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete key: %w", err)
+			}
+
+			return map[string]interface{}{
+				"key":     key,
+				"deleted": deleted,
+			}, nil
+		},
+	}
+}
+
+// Reconciler runs a ledger reconciliation pass
+type Reconciler interface {
+	Run(ctx context.Context) (*models.ReconciliationRun, error)
+}
+
+// ReconciliationAction returns an action running the ledger reconciliation
+// immediately instead of waiting for the nightly run
+func ReconciliationAction(reconciler Reconciler) Action {
+	return Action{
+		Name:        "run-reconciliation",
+		Description: "Recompute wallet balances from the ledger and record discrepancies",
+		Run: func(ctx context.Context, params map[string]string) (interface{}, error) {
+			return reconciler.Run(ctx)
+		},
+	}
+}
+
+// SandboxCloner clones wallets into the sandbox
+type SandboxCloner interface {
+	CloneWallet(ctx context.Context, walletID, customerID uuid.UUID) (*models.SandboxClone, error)
+}
+
+// SandboxCloneAction returns an action giving sales engineering realistic
+// demo data: the wallet is cloned with scrubbed identifiers and a sample of
+// its transactions to a customer holding sandbox credentials
+func SandboxCloneAction(cloner SandboxCloner) Action {
+	return Action{
+		Name:        "clone-wallet-to-sandbox",
+		Description: "Clone a wallet and an anonymized sample of its transactions to a sandbox customer",
+		Params:      []string{"wallet_id", "sandbox_customer_id"},
+		Run: func(ctx context.Context, params map[string]string) (interface{}, error) {
+			walletID, err := uuid.Parse(params["wallet_id"])
+			if err != nil {
+				return nil, fmt.Errorf("invalid wallet_id: %w", err)
+			}
+			customerID, err := uuid.Parse(params["sandbox_customer_id"])
+			if err != nil {
+				return nil, fmt.Errorf("invalid sandbox_customer_id: %w", err)
+			}
+
+			return cloner.CloneWallet(ctx, walletID, customerID)
+		},
+	}
+}
+
+// UsageCharger bills the internal usage of a month to service wallets
+type UsageCharger interface {
+	Charge(ctx context.Context, month time.Time) error
+	GetMonthlyReport(ctx context.Context, month time.Time) (*models.UsageReport, error)
+}
+
+// UsageChargebackAction returns an action charging a month's internal usage,
+// retrying the services a scheduled chargeback failed to bill. Services
+// already billed are skipped.
+func UsageChargebackAction(charger UsageCharger) Action {
+	return Action{
+		Name:        "charge-internal-usage",
+		Description: "Debit a month's internal service usage from the service wallets",
+		Params:      []string{"month"},
+		Run: func(ctx context.Context, params map[string]string) (interface{}, error) {
+			month, err := time.Parse("2006-01", params["month"])
+			if err != nil {
+				return nil, fmt.Errorf("invalid month, expected YYYY-MM: %w", err)
+			}
+
+			if err := charger.Charge(ctx, month); err != nil {
+				return nil, err
+			}
+			return charger.GetMonthlyReport(ctx, month)
+		},
+	}
+}
@@ -0,0 +1,123 @@
+// Package runbook provides a registry of audited operator actions codifying
+// common on-call runbook steps so they can be run without direct cluster or
+// database access
+package runbook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"internal/models"
+	"internal/repository"
+)
+
+// Common runbook errors
+var (
+	ErrActionNotFound   = errors.New("runbook action not found")
+	ErrActionExists     = errors.New("runbook action already registered")
+	ErrMissingParameter = errors.New("missing required action parameter")
+	ErrReasonRequired   = errors.New("a reason is required for operator actions")
+)
+
+// Action describes a single safe operator action
+type Action struct {
+	Name        string                                                                   `json:"name"`
+	Description string                                                                   `json:"description"`
+	Params      []string                                                                 `json:"params"`
+	Run         func(ctx context.Context, params map[string]string) (interface{}, error) `json:"-"`
+}
+
+// Registry holds the available runbook actions and audits every execution
+type Registry struct {
+	mu      sync.RWMutex
+	actions map[string]Action
+	audit   repository.AuditRepository
+}
+
+// NewRegistry creates a new runbook registry backed by the given audit log
+func NewRegistry(audit repository.AuditRepository) (*Registry, error) {
+	if audit == nil {
+		return nil, errors.New("audit repository is required")
+	}
+
+	return &Registry{
+		actions: make(map[string]Action),
+		audit:   audit,
+	}, nil
+}
+
+// Register adds an action to the registry. Components register their actions
+// during startup so only runbook steps backed by a running component appear.
+func (r *Registry) Register(action Action) error {
+	if action.Name == "" || action.Run == nil {
+		return errors.New("action name and run function are required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.actions[action.Name]; exists {
+		return fmt.Errorf("%w: %s", ErrActionExists, action.Name)
+	}
+	r.actions[action.Name] = action
+	return nil
+}
+
+// List returns all registered actions sorted by name
+func (r *Registry) List() []Action {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	actions := make([]Action, 0, len(r.actions))
+	for _, action := range r.actions {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Name < actions[j].Name
+	})
+	return actions
+}
+
+// Execute runs the named action on behalf of actor and records the outcome in
+// the audit log regardless of whether the action succeeded
+func (r *Registry) Execute(ctx context.Context, name, actor, reason string, params map[string]string) (interface{}, error) {
+	r.mu.RLock()
+	action, ok := r.actions[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrActionNotFound, name)
+	}
+
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrReasonRequired
+	}
+	for _, param := range action.Params {
+		if params[param] == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMissingParameter, param)
+		}
+	}
+
+	result, runErr := action.Run(ctx, params)
+
+	entry := &models.OperatorAction{
+		Action: name,
+		Actor:  actor,
+		Reason: reason,
+		Params: params,
+		Status: models.OperatorActionSucceeded,
+	}
+	if runErr != nil {
+		entry.Status = models.OperatorActionFailed
+		entry.Error = runErr.Error()
+	}
+
+	if err := r.audit.RecordOperatorAction(ctx, entry); err != nil {
+		return result, fmt.Errorf("action %s executed but audit record failed: %w", name, err)
+	}
+
+	return result, runErr
+}
@@ -0,0 +1,58 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"                          // v1.18.1
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"             // v1.18.27
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"       // v1.19.10
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types" // v1.19.10
+)
+
+// AWSProvider reads secrets from a single AWS Secrets Manager secret holding
+// them as the fields of a JSON object, the layout AWS uses for rotated
+// database credentials. Credentials for AWS come from the default chain.
+type AWSProvider struct {
+	client   *secretsmanager.Client
+	secretID string
+}
+
+// NewAWSProvider creates a new AWS Secrets Manager secrets provider. An empty
+// region uses the region of the default AWS configuration.
+func NewAWSProvider(ctx context.Context, region, secretID string) (*AWSProvider, error) {
+	if secretID == "" {
+		return nil, errors.New("secret ID is required")
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	return &AWSProvider{client: secretsmanager.NewFromConfig(cfg), secretID: secretID}, nil
+}
+
+// Get reads the current version of the secret and returns its named field
+func (p *AWSProvider) Get(ctx context.Context, name string) (string, error) {
+	out, err := p.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(p.secretID),
+	})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, p.secretID)
+		}
+		return "", fmt.Errorf("failed to read secret %s: %w", p.secretID, err)
+	}
+	if out.SecretString == nil {
+		return "", fmt.Errorf("secret %s has no string value", p.secretID)
+	}
+
+	return field([]byte(*out.SecretString), name)
+}
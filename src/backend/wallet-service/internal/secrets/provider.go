@@ -0,0 +1,107 @@
+// Package secrets loads credentials such as the database password and the
+// JWT secret from the environment, mounted files or a secret manager, so they
+// need not be kept in the configuration file
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Secret names shared by every provider
+const (
+	DatabaseUser     = "database_user"
+	DatabasePassword = "database_password"
+	JWTSecret        = "jwt_secret"
+	SMTPPassword     = "smtp_password"
+	SMSAPIKey        = "sms_api_key"
+)
+
+// ErrNotFound is returned when a provider does not hold the secret
+var ErrNotFound = errors.New("secret not found")
+
+// Provider reads secrets by name
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// EnvProvider reads secrets from environment variables named after the
+// secret in upper case, behind a prefix: with the prefix WALLET_ the
+// database_password secret is read from WALLET_DATABASE_PASSWORD
+type EnvProvider struct {
+	prefix string
+}
+
+// NewEnvProvider creates a new environment variable secrets provider
+func NewEnvProvider(prefix string) *EnvProvider {
+	return &EnvProvider{prefix: prefix}
+}
+
+// Get reads the secret's environment variable
+func (p *EnvProvider) Get(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(p.prefix + strings.ToUpper(name))
+	if !ok || value == "" {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return value, nil
+}
+
+// FileProvider reads secrets from files named after the secret in a
+// directory, as mounted by Kubernetes secret volumes. Files are read on every
+// call so that rotated mounts are picked up.
+type FileProvider struct {
+	dir string
+}
+
+// NewFileProvider creates a new file mount secrets provider
+func NewFileProvider(dir string) (*FileProvider, error) {
+	if dir == "" {
+		return nil, errors.New("secrets directory is required")
+	}
+
+	return &FileProvider{dir: dir}, nil
+}
+
+// Get reads the secret's file, without its trailing newline
+func (p *FileProvider) Get(ctx context.Context, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(p.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return value, nil
+}
+
+// field reads a secret from a secret manager document holding secrets as
+// string fields of a JSON object
+func field(document []byte, name string) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(document, &fields); err != nil {
+		return "", fmt.Errorf("secret document is not a JSON object: %w", err)
+	}
+
+	raw, ok := fields[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	value, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("secret %s is not a string", name)
+	}
+	if value == "" {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return value, nil
+}
@@ -0,0 +1,140 @@
+package secrets
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+)
+
+// Credential refresh outcomes
+const (
+	refreshUnchanged = "unchanged"
+	refreshRotated   = "rotated"
+	refreshFailed    = "failed"
+)
+
+// credentialRefreshes counts database credential refreshes by outcome
+var credentialRefreshes = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_database_credential_refreshes_total",
+		Help: "Refreshes of the database credentials from the secrets provider, by outcome",
+	},
+	[]string{"outcome"},
+)
+
+// Credentials are database login credentials
+type Credentials struct {
+	User     string
+	Password string
+}
+
+// OpenFunc creates a database connector logging in with the credentials
+type OpenFunc func(creds Credentials) (driver.Connector, error)
+
+// RotatingConnector is a database connector whose credentials are refreshed
+// from a secrets provider. Connections opened after a refresh log in with
+// the new credentials; open connections keep their session.
+type RotatingConnector struct {
+	provider Provider
+	open     OpenFunc
+
+	mu        sync.RWMutex
+	creds     Credentials
+	connector driver.Connector
+}
+
+// NewRotatingConnector creates a new rotating connector logging in with the
+// initial credentials until the first refresh
+func NewRotatingConnector(provider Provider, initial Credentials, open OpenFunc) (*RotatingConnector, error) {
+	if provider == nil {
+		return nil, errors.New("secrets provider is required")
+	}
+	if open == nil {
+		return nil, errors.New("open function is required")
+	}
+
+	connector, err := open(initial)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RotatingConnector{
+		provider:  provider,
+		open:      open,
+		creds:     initial,
+		connector: connector,
+	}, nil
+}
+
+// Connect opens a connection with the current credentials
+func (c *RotatingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.RLock()
+	connector := c.connector
+	c.mu.RUnlock()
+
+	return connector.Connect(ctx)
+}
+
+// Driver returns the driver of the current connector
+func (c *RotatingConnector) Driver() driver.Driver {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.connector.Driver()
+}
+
+// Refresh reads the credentials from the provider and switches to them when
+// they changed, reporting whether they did. A user missing from the provider
+// keeps the current user.
+func (c *RotatingConnector) Refresh(ctx context.Context) (bool, error) {
+	rotated, err := c.refresh(ctx)
+	switch {
+	case err != nil:
+		credentialRefreshes.WithLabelValues(refreshFailed).Inc()
+	case rotated:
+		credentialRefreshes.WithLabelValues(refreshRotated).Inc()
+	default:
+		credentialRefreshes.WithLabelValues(refreshUnchanged).Inc()
+	}
+	return rotated, err
+}
+
+func (c *RotatingConnector) refresh(ctx context.Context) (bool, error) {
+	c.mu.RLock()
+	creds := c.creds
+	c.mu.RUnlock()
+
+	user, err := c.provider.Get(ctx, DatabaseUser)
+	switch {
+	case err == nil:
+		creds.User = user
+	case !errors.Is(err, ErrNotFound):
+		return false, err
+	}
+
+	password, err := c.provider.Get(ctx, DatabasePassword)
+	if err != nil {
+		return false, err
+	}
+	creds.Password = password
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if creds == c.creds {
+		return false, nil
+	}
+
+	connector, err := c.open(creds)
+	if err != nil {
+		return false, fmt.Errorf("failed to open connector with rotated credentials: %w", err)
+	}
+	c.creds = creds
+	c.connector = connector
+	return true, nil
+}
@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxVaultResponse bounds the response body read from Vault
+const maxVaultResponse = 1 << 20
+
+// VaultProvider reads secrets from the fields of a secret in a HashiCorp
+// Vault KV version 2 secrets engine, always reading its latest version
+type VaultProvider struct {
+	address string
+	token   string
+	mount   string
+	path    string
+	client  *http.Client
+}
+
+// NewVaultProvider creates a new Vault secrets provider. An empty token
+// falls back to the VAULT_TOKEN environment variable.
+func NewVaultProvider(address, token, mount, path string, timeout time.Duration) (*VaultProvider, error) {
+	if address == "" {
+		return nil, errors.New("vault address is required")
+	}
+	if mount == "" || path == "" {
+		return nil, errors.New("vault mount and secret path are required")
+	}
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if token == "" {
+		return nil, errors.New("vault token is required")
+	}
+
+	return &VaultProvider{
+		address: strings.TrimRight(address, "/"),
+		token:   token,
+		mount:   strings.Trim(mount, "/"),
+		path:    strings.Trim(path, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Get reads the latest version of the secret and returns its named field
+func (p *VaultProvider) Get(ctx context.Context, name string) (string, error) {
+	endpoint := p.address + "/v1/" + url.PathEscape(p.mount) + "/data/" + p.path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s from vault: %w", p.path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxVaultResponse))
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: %s", ErrNotFound, p.path)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("vault returned status %d for secret %s", resp.StatusCode, p.path)
+	}
+
+	var secret struct {
+		Data struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+
+	return field(secret.Data.Data, name)
+}
@@ -0,0 +1,124 @@
+package selfcheck
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// minJWTSecretLength is the minimum secret length considered safe
+const minJWTSecretLength = 32
+
+//go:embed schema_migrations.txt
+var schemaManifest string
+
+// RequiredMigrations returns the migration versions embedded in this build
+func RequiredMigrations() []string {
+	var versions []string
+	for _, line := range strings.Split(schemaManifest, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		versions = append(versions, line)
+	}
+	return versions
+}
+
+// checkConfig reports configuration that is valid but unsafe for production
+func (c *Checker) checkConfig(ctx context.Context) (Status, string) {
+	var warnings []string
+
+	if c.cfg.Database.SSLMode == "disable" {
+		warnings = append(warnings, "database TLS disabled")
+	}
+	if !c.cfg.Security.EnableTLS {
+		warnings = append(warnings, "API TLS disabled")
+	}
+	if c.cfg.Security.JWTSecret != "" && len(c.cfg.Security.JWTSecret) < minJWTSecretLength {
+		warnings = append(warnings, "JWT secret shorter than recommended")
+	}
+	if c.cfg.API.WriteTimeout < c.cfg.API.ReadTimeout {
+		warnings = append(warnings, "write timeout shorter than read timeout")
+	}
+
+	if len(warnings) > 0 {
+		return StatusDegraded, strings.Join(warnings, "; ")
+	}
+	return StatusOK, "configuration sane"
+}
+
+// checkSchema verifies that every embedded migration is applied to the database
+func (c *Checker) checkSchema(ctx context.Context) (Status, string) {
+	rows, err := c.db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("failed to read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return StatusFailed, fmt.Sprintf("failed to scan schema version: %v", err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return StatusFailed, fmt.Sprintf("error iterating schema versions: %v", err)
+	}
+
+	required := RequiredMigrations()
+	var missing []string
+	for _, version := range required {
+		if !applied[version] {
+			missing = append(missing, version)
+		}
+		delete(applied, version)
+	}
+
+	if len(missing) > 0 {
+		return StatusFailed, fmt.Sprintf("missing migrations: %s", strings.Join(missing, ", "))
+	}
+	if len(applied) > 0 {
+		return StatusDegraded, fmt.Sprintf("database has %d migrations unknown to this build", len(applied))
+	}
+	return StatusOK, fmt.Sprintf("schema at %s", required[len(required)-1])
+}
+
+// checkRedis verifies that Redis responds to a ping. Redis is a soft
+// dependency, so an unreachable Redis only degrades the service.
+func (c *Checker) checkRedis(ctx context.Context) (Status, string) {
+	if c.redis == nil {
+		return StatusFailed, "redis client not configured"
+	}
+
+	start := time.Now()
+	if err := c.redis.Ping(ctx).Err(); err != nil {
+		return StatusDegraded, fmt.Sprintf("ping failed, features apply degradation policies: %v", err)
+	}
+	return StatusOK, fmt.Sprintf("ping ok in %s", time.Since(start).Round(time.Millisecond))
+}
+
+// checkClock verifies that the local clock is within tolerance of NTP time
+func (c *Checker) checkClock(ctx context.Context) (Status, string) {
+	server := c.cfg.SelfCheck.NTPServer
+	if server == "" {
+		return StatusDegraded, "no NTP server configured, clock sync not verified"
+	}
+
+	offset, err := queryClockOffset(ctx, server)
+	if err != nil {
+		return StatusDegraded, fmt.Sprintf("NTP query failed: %v", err)
+	}
+
+	if offset < 0 {
+		offset = -offset
+	}
+	if offset > c.cfg.SelfCheck.MaxClockSkew {
+		return StatusFailed, fmt.Sprintf("clock offset %s exceeds tolerance %s", offset, c.cfg.SelfCheck.MaxClockSkew)
+	}
+	return StatusOK, fmt.Sprintf("clock offset %s", offset)
+}
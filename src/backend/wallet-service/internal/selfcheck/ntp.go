@@ -0,0 +1,73 @@
+package selfcheck
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+)
+
+const (
+	ntpPacketSize = 48
+	// ntpEpochOffset is the number of seconds between 1900-01-01 and 1970-01-01
+	ntpEpochOffset = 2208988800
+)
+
+// queryClockOffset performs a single SNTP request and returns the estimated
+// offset of the local clock relative to the server
+func queryClockOffset(ctx context.Context, server string) (time.Duration, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(server, "123"))
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return 0, err
+		}
+	}
+
+	// LI = 0, VN = 4, Mode = 3 (client)
+	req := make([]byte, ntpPacketSize)
+	req[0] = 0x23
+
+	sent := time.Now()
+	if _, err := conn.Write(req); err != nil {
+		return 0, err
+	}
+
+	resp := make([]byte, ntpPacketSize)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return 0, err
+	}
+	received := time.Now()
+
+	if n < ntpPacketSize {
+		return 0, errors.New("short NTP response")
+	}
+
+	serverReceive := ntpTime(resp[32:40])
+	serverTransmit := ntpTime(resp[40:48])
+	if serverTransmit.IsZero() {
+		return 0, errors.New("invalid NTP response")
+	}
+
+	// Standard SNTP offset: ((t2 - t1) + (t3 - t4)) / 2
+	return (serverReceive.Sub(sent) + serverTransmit.Sub(received)) / 2, nil
+}
+
+// ntpTime converts a 64-bit NTP timestamp to time.Time
+func ntpTime(b []byte) time.Time {
+	seconds := binary.BigEndian.Uint32(b[0:4])
+	fraction := binary.BigEndian.Uint32(b[4:8])
+	if seconds == 0 && fraction == 0 {
+		return time.Time{}
+	}
+
+	nanos := (int64(fraction) * 1e9) >> 32
+	return time.Unix(int64(seconds)-ntpEpochOffset, nanos).UTC()
+}
@@ -0,0 +1,235 @@
+// Package sensitive detects card numbers (PANs) and Aadhaar numbers pasted
+// into free-text fields so they can be rejected or masked before storage
+package sensitive
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrSensitiveData is returned when a field contains sensitive numbers and
+// the scanner policy is to reject it
+var ErrSensitiveData = errors.New("sensitive data detected in free-text field")
+
+// Kind identifies the type of sensitive number detected
+type Kind string
+
+const (
+	// KindPAN is a Luhn-valid payment card number
+	KindPAN Kind = "pan"
+	// KindAadhaar is a Verhoeff-valid Aadhaar number
+	KindAadhaar Kind = "aadhaar"
+)
+
+// Policy controls what the scanner does with detected numbers
+type Policy string
+
+const (
+	// PolicyReject fails validation of the field
+	PolicyReject Policy = "reject"
+	// PolicyMask replaces all but the last four digits
+	PolicyMask Policy = "mask"
+)
+
+// maskChar replaces masked digits
+const maskChar = 'X'
+
+// candidatePattern matches runs of 12 to 19 digits optionally separated by
+// single spaces or dashes, as numbers are commonly formatted in groups
+var candidatePattern = regexp.MustCompile(`\d(?:[ -]?\d){11,18}`)
+
+// Finding is a sensitive number detected in a text
+type Finding struct {
+	Kind  Kind
+	Start int
+	End   int
+}
+
+// Detect returns the sensitive numbers found in text
+func Detect(text string) []Finding {
+	var findings []Finding
+	for _, loc := range candidatePattern.FindAllStringIndex(text, -1) {
+		// Numbers embedded in longer alphanumeric tokens are identifiers
+		if loc[0] > 0 && isAlnum(text[loc[0]-1]) || loc[1] < len(text) && isAlnum(text[loc[1]]) {
+			continue
+		}
+
+		digits := digitsOf(text[loc[0]:loc[1]])
+		switch {
+		case len(digits) >= 13 && luhnValid(digits):
+			findings = append(findings, Finding{Kind: KindPAN, Start: loc[0], End: loc[1]})
+		case len(digits) == 12 && digits[0] >= '2' && verhoeffValid(digits):
+			findings = append(findings, Finding{Kind: KindAadhaar, Start: loc[0], End: loc[1]})
+		}
+	}
+	return findings
+}
+
+// Mask replaces every digit of the findings except the last four
+func Mask(text string, findings []Finding) string {
+	if len(findings) == 0 {
+		return text
+	}
+
+	masked := []byte(text)
+	for _, f := range findings {
+		keep := 4
+		for i := f.End - 1; i >= f.Start; i-- {
+			if !isDigit(masked[i]) {
+				continue
+			}
+			if keep > 0 {
+				keep--
+				continue
+			}
+			masked[i] = maskChar
+		}
+	}
+	return string(masked)
+}
+
+// Kinds returns the distinct kinds of the findings
+func Kinds(findings []Finding) []Kind {
+	seen := make(map[Kind]bool)
+	var kinds []Kind
+	for _, f := range findings {
+		if !seen[f.Kind] {
+			seen[f.Kind] = true
+			kinds = append(kinds, f.Kind)
+		}
+	}
+	return kinds
+}
+
+// Event describes a detection for compliance logging. It never carries the
+// detected value.
+type Event struct {
+	Field  string
+	Kinds  []Kind
+	Count  int
+	Action Policy
+}
+
+// Scanner applies a policy to free-text fields
+type Scanner struct {
+	policy   Policy
+	onDetect func(Event)
+}
+
+// NewScanner creates a new scanner. onDetect is called for every field with
+// findings so callers can record a compliance event.
+func NewScanner(policy Policy, onDetect func(Event)) (*Scanner, error) {
+	switch policy {
+	case PolicyReject, PolicyMask:
+	default:
+		return nil, fmt.Errorf("unknown sensitive data policy %q", policy)
+	}
+	if onDetect == nil {
+		return nil, errors.New("detection callback is required")
+	}
+
+	return &Scanner{
+		policy:   policy,
+		onDetect: onDetect,
+	}, nil
+}
+
+// Scan checks a field value and returns the value to store. With the reject
+// policy an error wrapping ErrSensitiveData is returned instead.
+func (s *Scanner) Scan(field, value string) (string, error) {
+	findings := Detect(value)
+	if len(findings) == 0 {
+		return value, nil
+	}
+
+	kinds := Kinds(findings)
+	s.onDetect(Event{
+		Field:  field,
+		Kinds:  kinds,
+		Count:  len(findings),
+		Action: s.policy,
+	})
+
+	if s.policy == PolicyReject {
+		names := make([]string, len(kinds))
+		for i, k := range kinds {
+			names[i] = string(k)
+		}
+		return "", fmt.Errorf("%w: %s contains %s", ErrSensitiveData, field, strings.Join(names, ", "))
+	}
+	return Mask(value, findings), nil
+}
+
+// luhnValid reports whether the digit string passes the Luhn checksum
+func luhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// Verhoeff checksum tables used by Aadhaar numbers
+var (
+	verhoeffD = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffP = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+// verhoeffValid reports whether the digit string passes the Verhoeff checksum
+func verhoeffValid(digits string) bool {
+	c := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		c = verhoeffD[c][verhoeffP[i%8][d]]
+	}
+	return c == 0
+}
+
+func digitsOf(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if isDigit(s[i]) {
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlnum(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+	"internal/repository"
+)
+
+// Adjustment listing bounds
+const (
+	defaultAdjustmentLimit = 50
+	maxAdjustmentLimit     = 500
+)
+
+// maxAdjustmentReportRange bounds the window of adjustment reports
+const maxAdjustmentReportRange = 366 * 24 * time.Hour
+
+// Adjustment audit actions
+const (
+	adjustmentRequestAction = "adjustment.request"
+	adjustmentApproveAction = "adjustment.approve"
+	adjustmentRejectAction  = "adjustment.reject"
+	adjustmentExpireAction  = "adjustment.expire"
+	// adjustmentExpiryActor expires adjustments nobody reviewed in time
+	adjustmentExpiryActor = "schedule"
+)
+
+// Adjustment errors
+var (
+	ErrInvalidAdjustment  = errors.New("invalid adjustment")
+	ErrAdjustmentNotFound = errors.New("adjustment not found")
+	ErrAdjustmentConflict = errors.New("adjustment is not in a state allowing this request")
+)
+
+// AdjustmentRequest is a manual correction requested by an operator
+type AdjustmentRequest struct {
+	WalletID   uuid.UUID
+	Direction  models.AdjustmentDirection
+	Amount     decimal.Decimal
+	ReasonCode models.AdjustmentReason
+	Note       string
+}
+
+// AdjustmentPolicy sets which adjustments need a second approver and how
+// long they await one
+type AdjustmentPolicy struct {
+	// ApprovalThresholds holds by currency the largest adjustment posted
+	// without a second approver. Adjustments in currencies without a
+	// threshold always need one.
+	ApprovalThresholds map[string]decimal.Decimal
+	// PendingTTL is how long an adjustment awaits approval before expiring
+	PendingTTL time.Duration
+}
+
+// AdjustmentService defines the interface for requesting, approving and
+// reporting manual balance corrections
+type AdjustmentService interface {
+	Request(ctx context.Context, request AdjustmentRequest, actor string) (*models.Adjustment, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.Adjustment, error)
+	List(ctx context.Context, filter models.AdjustmentFilter) ([]*models.Adjustment, error)
+	Approve(ctx context.Context, id uuid.UUID, actor, note string) (*models.Adjustment, error)
+	Reject(ctx context.Context, id uuid.UUID, actor, note string) (*models.Adjustment, error)
+	// Expire expires the pending adjustments nobody reviewed in time,
+	// returning how many expired
+	Expire(ctx context.Context, now time.Time) (int, error)
+	Report(ctx context.Context, from, to time.Time) (*models.AdjustmentReport, error)
+}
+
+// adjustmentService implements AdjustmentService interface. Approved
+// adjustments are posted through the wallet service, so they are checked
+// and recorded like every other transaction, and every request and review
+// is kept in the operator audit log.
+type adjustmentService struct {
+	repo    repository.AdjustmentRepository
+	wallets WalletService
+	audit   repository.AuditRepository
+	policy  AdjustmentPolicy
+	logger  Logger
+}
+
+// NewAdjustmentService creates a new instance of AdjustmentService
+func NewAdjustmentService(repo repository.AdjustmentRepository, wallets WalletService, audit repository.AuditRepository, policy AdjustmentPolicy, logger Logger) (AdjustmentService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if audit == nil {
+		return nil, errors.New("audit repository is required")
+	}
+	if policy.PendingTTL <= 0 {
+		return nil, errors.New("pending TTL must be positive")
+	}
+	for currency, threshold := range policy.ApprovalThresholds {
+		if threshold.IsNegative() {
+			return nil, fmt.Errorf("approval threshold of %s must not be negative", currency)
+		}
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &adjustmentService{
+		repo:    repo,
+		wallets: wallets,
+		audit:   audit,
+		policy:  policy,
+		logger:  logger,
+	}, nil
+}
+
+// Request records an adjustment of a wallet in the wallet's currency.
+// Adjustments above the currency's approval threshold await approval by
+// another operator until they expire; smaller ones are posted at once, and
+// rejected when their transaction is refused.
+func (s *adjustmentService) Request(ctx context.Context, request AdjustmentRequest, actor string) (*models.Adjustment, error) {
+	if !request.Direction.IsValid() {
+		return nil, fmt.Errorf("%w: direction must be %s or %s", ErrInvalidAdjustment, models.AdjustmentIncrease, models.AdjustmentDecrease)
+	}
+	if !request.ReasonCode.IsValid() {
+		return nil, fmt.Errorf("%w: unknown reason code %q", ErrInvalidAdjustment, request.ReasonCode)
+	}
+	if !request.Amount.IsPositive() || request.Amount.GreaterThan(decimal.NewFromFloat(models.MaxTransactionAmount)) {
+		return nil, fmt.Errorf("%w: amount must be positive and at most %.2f", ErrInvalidAdjustment, models.MaxTransactionAmount)
+	}
+	if strings.TrimSpace(request.Note) == "" {
+		return nil, fmt.Errorf("%w: a note is required", ErrInvalidAdjustment)
+	}
+
+	wallet, err := s.wallets.GetWallet(ctx, request.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	adjustment := &models.Adjustment{
+		ID:          uuid.New(),
+		WalletID:    wallet.ID,
+		Status:      models.AdjustmentPending,
+		Direction:   request.Direction,
+		Amount:      request.Amount,
+		Currency:    wallet.Currency,
+		ReasonCode:  request.ReasonCode,
+		Note:        request.Note,
+		RequestedBy: actor,
+		CreatedAt:   now,
+	}
+	threshold, ok := s.policy.ApprovalThresholds[wallet.Currency]
+	approval := !ok || adjustment.Amount.GreaterThan(threshold)
+	if approval {
+		expires := now.Add(s.policy.PendingTTL)
+		adjustment.ExpiresAt = &expires
+	}
+
+	if err := s.repo.CreateAdjustment(ctx, adjustment); err != nil {
+		s.logger.Error("failed to create adjustment", err, "walletID", wallet.ID)
+		err = fmt.Errorf("failed to create adjustment: %w", err)
+		return nil, s.audited(ctx, adjustmentRequestAction, actor, request.Note, adjustment, err)
+	}
+
+	s.logger.Info("adjustment requested",
+		"adjustmentID", adjustment.ID,
+		"walletID", wallet.ID,
+		"actor", actor,
+		"reasonCode", adjustment.ReasonCode,
+		"approval", approval)
+
+	if approval {
+		return adjustment, s.audited(ctx, adjustmentRequestAction, actor, request.Note, adjustment, nil)
+	}
+
+	posted, err := s.post(ctx, adjustment.ID, "", "", now)
+	if err != nil {
+		if rejected, rejectErr := s.repo.ReviewAdjustment(ctx, adjustment.ID, models.AdjustmentRejected, "", "Not posted: "+err.Error(), nil, now); rejectErr != nil {
+			s.logger.Error("failed to reject adjustment", rejectErr, "adjustmentID", adjustment.ID)
+		} else {
+			adjustment = rejected
+		}
+		return nil, s.audited(ctx, adjustmentRequestAction, actor, request.Note, adjustment, err)
+	}
+	return posted, s.audited(ctx, adjustmentRequestAction, actor, request.Note, posted, nil)
+}
+
+// Get returns an adjustment
+func (s *adjustmentService) Get(ctx context.Context, id uuid.UUID) (*models.Adjustment, error) {
+	adjustment, err := s.repo.GetAdjustment(ctx, id)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	return adjustment, nil
+}
+
+// List returns the latest adjustments matching the filter, newest first
+func (s *adjustmentService) List(ctx context.Context, filter models.AdjustmentFilter) ([]*models.Adjustment, error) {
+	if filter.Limit == 0 {
+		filter.Limit = defaultAdjustmentLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxAdjustmentLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidAdjustment, maxAdjustmentLimit)
+	}
+	switch filter.Status {
+	case "", models.AdjustmentPending, models.AdjustmentApproved, models.AdjustmentPosted, models.AdjustmentRejected, models.AdjustmentExpired:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidAdjustment, filter.Status)
+	}
+
+	adjustments, err := s.repo.ListAdjustments(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list adjustments: %w", err)
+	}
+	if adjustments == nil {
+		adjustments = []*models.Adjustment{}
+	}
+	return adjustments, nil
+}
+
+// Approve approves a pending adjustment requested by another operator and
+// posts it as an adjustment transaction linked to the adjustment. When the
+// transaction is refused, as for a balance too low to decrease, the
+// adjustment returns to review. Expired adjustments can no longer be
+// approved.
+func (s *adjustmentService) Approve(ctx context.Context, id uuid.UUID, actor, note string) (*models.Adjustment, error) {
+	adjustment, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if adjustment.RequestedBy == actor {
+		return nil, s.audited(ctx, adjustmentApproveAction, actor, note, adjustment, ErrSelfApproval)
+	}
+
+	posted, err := s.post(ctx, id, actor, note, time.Now().UTC())
+	if err != nil {
+		return nil, s.audited(ctx, adjustmentApproveAction, actor, note, adjustment, err)
+	}
+	return posted, s.audited(ctx, adjustmentApproveAction, actor, note, posted, nil)
+}
+
+// post approves a pending adjustment on behalf of the reviewer, empty for
+// adjustments needing no approval, and posts it as an adjustment
+// transaction. When the transaction is refused the adjustment is pending
+// again.
+func (s *adjustmentService) post(ctx context.Context, id uuid.UUID, actor, note string, now time.Time) (*models.Adjustment, error) {
+	transactionID := uuid.New()
+	adjustment, err := s.review(ctx, id, models.AdjustmentApproved, actor, note, &transactionID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, _ := adjustment.Amount.Float64()
+	tx := &models.Transaction{
+		ID:          transactionID,
+		WalletID:    adjustment.WalletID,
+		Type:        models.TransactionTypeAdjustment,
+		Status:      models.TransactionStatusCompleted,
+		Amount:      amount,
+		Currency:    adjustment.Currency,
+		Description: "Adjustment: " + string(adjustment.ReasonCode),
+		ReferenceID: "ADJ-" + adjustment.ID.String(),
+		Metadata: map[string]string{
+			models.MetadataAdjustmentID:        adjustment.ID.String(),
+			models.MetadataAdjustmentReason:    string(adjustment.ReasonCode),
+			models.MetadataAdjustmentDirection: string(adjustment.Direction),
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := s.wallets.ProcessTransaction(ctx, tx); err != nil {
+		if releaseErr := s.repo.ReleaseAdjustment(ctx, id); releaseErr != nil {
+			s.logger.Error("failed to release adjustment", releaseErr, "adjustmentID", id)
+		}
+		return nil, err
+	}
+
+	posted, err := s.repo.MarkPosted(ctx, id, now)
+	if err != nil {
+		// The transaction stands; the adjustment links to it already
+		s.logger.Error("failed to mark adjustment posted", err, "adjustmentID", id, "transactionID", transactionID)
+		return nil, s.mapError(err)
+	}
+
+	s.logger.Info("adjustment posted",
+		"adjustmentID", id,
+		"walletID", posted.WalletID,
+		"transactionID", transactionID,
+		"actor", actor)
+
+	return posted, nil
+}
+
+// Reject rejects a pending adjustment. Requesters may withdraw their own
+// adjustments.
+func (s *adjustmentService) Reject(ctx context.Context, id uuid.UUID, actor, note string) (*models.Adjustment, error) {
+	if strings.TrimSpace(note) == "" {
+		return nil, fmt.Errorf("%w: a note is required", ErrInvalidAdjustment)
+	}
+
+	adjustment, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rejected, err := s.review(ctx, id, models.AdjustmentRejected, actor, note, nil, time.Now().UTC())
+	if err != nil {
+		return nil, s.audited(ctx, adjustmentRejectAction, actor, note, adjustment, err)
+	}
+	return rejected, s.audited(ctx, adjustmentRejectAction, actor, note, rejected, nil)
+}
+
+// Expire expires the pending adjustments nobody reviewed in time. Each is
+// audited even when an earlier audit record failed.
+func (s *adjustmentService) Expire(ctx context.Context, now time.Time) (int, error) {
+	expired, err := s.repo.ExpireAdjustments(ctx, now.UTC())
+	if err != nil {
+		s.logger.Error("failed to expire adjustments", err)
+		return 0, fmt.Errorf("failed to expire adjustments: %w", err)
+	}
+
+	for _, adjustment := range expired {
+		s.logger.Info("adjustment expired",
+			"adjustmentID", adjustment.ID,
+			"walletID", adjustment.WalletID,
+			"requestedBy", adjustment.RequestedBy)
+
+		if auditErr := s.audited(ctx, adjustmentExpireAction, adjustmentExpiryActor, "not reviewed in time", adjustment, nil); auditErr != nil && err == nil {
+			err = auditErr
+		}
+	}
+	return len(expired), err
+}
+
+// review records the outcome of the review of a pending adjustment
+func (s *adjustmentService) review(ctx context.Context, id uuid.UUID, status models.AdjustmentStatus, actor, note string, transactionID *uuid.UUID, now time.Time) (*models.Adjustment, error) {
+	adjustment, err := s.repo.ReviewAdjustment(ctx, id, status, actor, note, transactionID, now)
+	if err != nil {
+		if !errors.Is(err, repository.ErrAdjustmentConflict) && !errors.Is(err, repository.ErrAdjustmentNotFound) {
+			s.logger.Error("failed to review adjustment", err, "adjustmentID", id)
+		}
+		return nil, s.mapError(err)
+	}
+
+	s.logger.Info("adjustment reviewed",
+		"adjustmentID", id,
+		"status", status,
+		"actor", actor)
+
+	return adjustment, nil
+}
+
+// Report totals the adjustments posted in [from, to) by reason code,
+// direction and currency, apart from the customer spend they correct
+func (s *adjustmentService) Report(ctx context.Context, from, to time.Time) (*models.AdjustmentReport, error) {
+	if !to.After(from) || to.Sub(from) > maxAdjustmentReportRange {
+		return nil, ErrInvalidAnalyticsWindow
+	}
+
+	totals, err := s.repo.SummarizeAdjustments(ctx, from.UTC(), to.UTC())
+	if err != nil {
+		s.logger.Error("failed to summarize adjustments", err)
+		return nil, fmt.Errorf("failed to summarize adjustments: %w", err)
+	}
+	if totals == nil {
+		totals = []*models.AdjustmentTotal{}
+	}
+
+	return &models.AdjustmentReport{
+		From:        from.UTC(),
+		To:          to.UTC(),
+		Totals:      totals,
+		GeneratedAt: time.Now().UTC(),
+	}, nil
+}
+
+// audited records an adjustment's request or review in the operator audit
+// log regardless of whether it succeeded. The entry names the adjustment and,
+// once approved, the transaction posting it, so the audit log, adjustment
+// and ledger link to each other.
+func (s *adjustmentService) audited(ctx context.Context, action, actor, note string, adjustment *models.Adjustment, err error) error {
+	params := map[string]string{
+		"adjustment_id": adjustment.ID.String(),
+		"wallet_id":     adjustment.WalletID.String(),
+		"status":        string(adjustment.Status),
+		"direction":     string(adjustment.Direction),
+		"amount":        adjustment.Amount.String(),
+		"currency":      adjustment.Currency,
+		"reason_code":   string(adjustment.ReasonCode),
+	}
+	if adjustment.TransactionID != nil {
+		params["transaction_id"] = adjustment.TransactionID.String()
+	}
+
+	entry := &models.OperatorAction{
+		Action: action,
+		Actor:  actor,
+		Reason: note,
+		Params: params,
+		Status: models.OperatorActionSucceeded,
+	}
+	if err != nil {
+		entry.Status = models.OperatorActionFailed
+		entry.Error = err.Error()
+	}
+
+	if auditErr := s.audit.RecordOperatorAction(ctx, entry); auditErr != nil {
+		s.logger.Error("failed to audit adjustment", auditErr, "action", action, "adjustmentID", adjustment.ID)
+		if err == nil {
+			err = fmt.Errorf("%s executed but audit record failed: %w", action, auditErr)
+		}
+	}
+	return err
+}
+
+// mapError maps repository errors to service errors
+func (s *adjustmentService) mapError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrAdjustmentNotFound):
+		return ErrAdjustmentNotFound
+	case errors.Is(err, repository.ErrAdjustmentConflict):
+		return ErrAdjustmentConflict
+	default:
+		return fmt.Errorf("adjustment failed: %w", err)
+	}
+}
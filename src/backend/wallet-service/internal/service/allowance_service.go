@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+	"github.com/shopspring/decimal"                           // v1.3.1
+
+	"internal/currency"
+	"internal/models"
+	"internal/repository"
+)
+
+// ErrInvalidQuantity is returned for a debit whose quantity metadata is not
+// a positive whole number
+var ErrInvalidQuantity = errors.New("invalid quantity")
+
+// allowanceUnits counts the free units debits used, by product
+var allowanceUnits = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_allowance_units_total",
+		Help: "Free-tier units used by debits, by product",
+	},
+	[]string{"product"},
+)
+
+// AllowanceUse is the part of a debit covered by the free allowance of its
+// product
+type AllowanceUse struct {
+	Product string
+	Period  string
+	// Quantity is the units the debit is for, Units the free ones
+	Quantity int64
+	Units    int64
+	// Amount is the part of the debit's amount the free units cover
+	Amount decimal.Decimal
+}
+
+// Full reports whether the allowance covers the whole debit
+func (u *AllowanceUse) Full() bool {
+	return u.Units == u.Quantity
+}
+
+// AllowanceService defines the interface for the free monthly allowances of
+// products, consumed by debits before the wallet is charged
+type AllowanceService interface {
+	// Allowances returns a wallet's allowances in the billing period holding
+	// at
+	Allowances(ctx context.Context, walletID uuid.UUID, at time.Time) (*models.WalletAllowances, error)
+	// Consume uses the free units left for a debit's product in the period
+	// it applies to, returning nil when nothing is free
+	Consume(ctx context.Context, tx *models.Transaction) (*AllowanceUse, error)
+	// Release gives back the units of a debit that did not post
+	Release(ctx context.Context, tx *models.Transaction, use *AllowanceUse) error
+}
+
+// allowanceService implements AllowanceService interface
+type allowanceService struct {
+	repo       repository.AllowanceRepository
+	wallets    WalletService
+	allowances map[string]int64
+	currencies *currency.Registry
+	logger     Logger
+}
+
+// NewAllowanceService creates a new instance of AllowanceService granting
+// every wallet the free units of each product in allowances per billing
+// period. Products are matched case-insensitively.
+func NewAllowanceService(repo repository.AllowanceRepository, wallets WalletService, allowances map[string]int64,
+	currencies *currency.Registry, logger Logger) (AllowanceService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currencies are required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	normalized := make(map[string]int64, len(allowances))
+	for product, units := range allowances {
+		product = normalizeProduct(product)
+		if product == "" {
+			return nil, errors.New("allowance product is required")
+		}
+		if units <= 0 {
+			return nil, fmt.Errorf("allowance of %s must be positive", product)
+		}
+		normalized[product] = units
+	}
+
+	return &allowanceService{
+		repo:       repo,
+		wallets:    wallets,
+		allowances: normalized,
+		currencies: currencies,
+		logger:     logger,
+	}, nil
+}
+
+// Allowances returns each product's allowance of a wallet with what it used
+// and has left in the billing period holding at
+func (s *allowanceService) Allowances(ctx context.Context, walletID uuid.UUID, at time.Time) (*models.WalletAllowances, error) {
+	if _, err := s.wallets.GetWallet(ctx, walletID); err != nil {
+		return nil, err
+	}
+
+	start := models.BillingPeriodOf(at)
+	period := start.Format(models.BillingPeriodLayout)
+	usage, err := s.repo.ListAllowanceUsage(ctx, walletID, period)
+	if err != nil {
+		s.logger.Error("failed to list allowance usage", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to list allowance usage: %w", err)
+	}
+
+	allowances := &models.WalletAllowances{
+		WalletID:   walletID,
+		Period:     period,
+		ResetsAt:   start.AddDate(0, 1, 0),
+		Allowances: make([]*models.ProductAllowance, 0, len(s.allowances)),
+	}
+	for product, units := range s.allowances {
+		used := usage[product]
+		if used > units {
+			used = units
+		}
+		allowances.Allowances = append(allowances.Allowances, &models.ProductAllowance{
+			Product:   product,
+			Allowance: units,
+			Used:      used,
+			Remaining: units - used,
+		})
+	}
+	sort.Slice(allowances.Allowances, func(i, j int) bool {
+		return allowances.Allowances[i].Product < allowances.Allowances[j].Product
+	})
+	return allowances, nil
+}
+
+// Consume uses the free units left for a debit's product. Debits of
+// products without an allowance, and debits the wallet service would refuse
+// anyway, use nothing.
+func (s *allowanceService) Consume(ctx context.Context, tx *models.Transaction) (*AllowanceUse, error) {
+	product := normalizeProduct(tx.Metadata[models.TransactionProductKey])
+	allowance, ok := s.allowances[product]
+	if !ok || tx.Amount <= 0 || !s.currencies.Supported(tx.Currency) {
+		return nil, nil
+	}
+	quantity, err := transactionQuantity(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	period := models.BillingPeriodOf(tx.EffectiveTime()).Format(models.BillingPeriodLayout)
+	units, err := s.repo.ConsumeAllowance(ctx, tx.WalletID, product, period, allowance, quantity)
+	if err != nil {
+		s.logger.Error("failed to consume allowance", err, "walletID", tx.WalletID, "product", product)
+		return nil, fmt.Errorf("failed to consume allowance: %w", err)
+	}
+	if units == 0 {
+		return nil, nil
+	}
+
+	// The units left to pay are charged at the debit's unit price; the
+	// allowance covers the rest, so the two add up to the amount
+	amount := decimal.NewFromFloat(tx.Amount)
+	charged := s.currencies.Round(tx.Currency, amount.Mul(decimal.NewFromInt(quantity-units)).Div(decimal.NewFromInt(quantity)))
+	return &AllowanceUse{
+		Product:  product,
+		Period:   period,
+		Quantity: quantity,
+		Units:    units,
+		Amount:   amount.Sub(charged),
+	}, nil
+}
+
+// Release gives back the units a debit consumed
+func (s *allowanceService) Release(ctx context.Context, tx *models.Transaction, use *AllowanceUse) error {
+	if err := s.repo.ReleaseAllowance(ctx, tx.WalletID, use.Product, use.Period, use.Units); err != nil {
+		s.logger.Error("failed to release allowance", err, "walletID", tx.WalletID, "product", use.Product)
+		return fmt.Errorf("failed to release allowance: %w", err)
+	}
+	return nil
+}
+
+// normalizeProduct returns the form products are matched in
+func normalizeProduct(product string) string {
+	return strings.ToLower(strings.TrimSpace(product))
+}
+
+// transactionQuantity returns the units of its product a debit is for
+func transactionQuantity(tx *models.Transaction) (int64, error) {
+	value, ok := tx.Metadata[models.TransactionQuantityKey]
+	if !ok {
+		return 1, nil
+	}
+	quantity, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || quantity <= 0 {
+		return 0, fmt.Errorf("%w: %s must be a positive whole number", ErrInvalidQuantity, models.TransactionQuantityKey)
+	}
+	return quantity, nil
+}
+
+// allowanceWalletService is a WalletService taking debits out of the free
+// allowance of their product before charging the wallet
+type allowanceWalletService struct {
+	WalletService
+	allowances AllowanceService
+	logger     Logger
+}
+
+// NewAllowanceWalletService wraps wallets so that the free units of a
+// debit's product are consumed first. A debit is posted for what is left
+// to pay, naming the free units and the amount they covered in its
+// metadata; a debit covered in full is completed without posting.
+func NewAllowanceWalletService(wallets WalletService, allowances AllowanceService, logger Logger) (WalletService, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if allowances == nil {
+		return nil, errors.New("allowance service is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &allowanceWalletService{
+		WalletService: wallets,
+		allowances:    allowances,
+		logger:        logger,
+	}, nil
+}
+
+// ProcessTransaction consumes the allowance of debits and posts what is left
+func (s *allowanceWalletService) ProcessTransaction(ctx context.Context, tx *models.Transaction) (*models.CreditLimitWarning, error) {
+	if tx == nil || tx.Type != models.TransactionTypeDebit {
+		return s.WalletService.ProcessTransaction(ctx, tx)
+	}
+
+	if _, err := s.GetWallet(ctx, tx.WalletID); err != nil {
+		return nil, err
+	}
+	use, err := s.allowances.Consume(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if use == nil {
+		return s.WalletService.ProcessTransaction(ctx, tx)
+	}
+
+	metadata := make(map[string]string, len(tx.Metadata)+2)
+	for key, value := range tx.Metadata {
+		metadata[key] = value
+	}
+	metadata[models.MetadataAllowanceUnits] = strconv.FormatInt(use.Units, 10)
+	metadata[models.MetadataAllowanceAmount] = use.Amount.String()
+	tx.Metadata = metadata
+	amount, _ := decimal.NewFromFloat(tx.Amount).Sub(use.Amount).Float64()
+	tx.Amount = amount
+
+	if use.Full() || amount <= 0 {
+		tx.Amount = 0
+		tx.Status = models.TransactionStatusCompleted
+		allowanceUnits.WithLabelValues(use.Product).Add(float64(use.Units))
+		s.logger.Info("debit covered by allowance",
+			"transactionID", tx.ID,
+			"walletID", tx.WalletID,
+			"product", use.Product,
+			"units", use.Units)
+		return nil, nil
+	}
+
+	warning, err := s.WalletService.ProcessTransaction(ctx, tx)
+	if err != nil {
+		// The debit did not post, so its free units are left for others
+		if releaseErr := s.allowances.Release(ctx, tx, use); releaseErr != nil {
+			s.logger.Error("failed to give back allowance of refused debit", releaseErr, "transactionID", tx.ID)
+		}
+		return nil, err
+	}
+	allowanceUnits.WithLabelValues(use.Product).Add(float64(use.Units))
+	return warning, nil
+}
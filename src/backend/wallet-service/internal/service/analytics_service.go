@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"internal/repository"
+)
+
+// maxAnalyticsWindow bounds how far back aggregate statistics may look
+const maxAnalyticsWindow = 365 * 24 * time.Hour
+
+// ErrInvalidAnalyticsWindow is returned for non-positive or excessive windows
+var ErrInvalidAnalyticsWindow = errors.New("invalid analytics window")
+
+// AdoptionStats is the privacy-safe view of adoption figures for one currency.
+// Figures are omitted when the underlying group is too small to publish.
+type AdoptionStats struct {
+	Currency      string   `json:"currency"`
+	Suppressed    bool     `json:"suppressed"`
+	ActiveWallets *int64   `json:"active_wallets,omitempty"`
+	TopUpCount    *int64   `json:"top_up_count,omitempty"`
+	MedianTopUp   *float64 `json:"median_top_up,omitempty"`
+}
+
+// AnalyticsOptions configures the privacy protections applied to aggregates
+type AnalyticsOptions struct {
+	// MinGroupSize is the smallest group whose figures may be published
+	MinGroupSize int
+	// NoiseEpsilon is the differential privacy budget used for Laplace noise
+	// on counts and medians; zero disables noise
+	NoiseEpsilon float64
+	// MedianBucket is the width of the buckets medians are coarsened to. The
+	// noise on a median is calibrated to a sensitivity of one bucket.
+	MedianBucket float64
+}
+
+// RandomSource draws the noise added to published aggregates; *rand.Rand
+// satisfies it
+type RandomSource interface {
+	// Float64 returns a uniformly distributed value in [0, 1)
+	Float64() float64
+}
+
+// AnalyticsService defines the interface for product analytics aggregates
+type AnalyticsService interface {
+	GetAdoptionStats(ctx context.Context, window time.Duration) ([]*AdoptionStats, error)
+}
+
+// analyticsService implements AnalyticsService interface
+type analyticsService struct {
+	repo   repository.AnalyticsRepository
+	opts   AnalyticsOptions
+	logger Logger
+
+	mu     sync.Mutex
+	random RandomSource
+}
+
+// NewAnalyticsService creates a new instance of AnalyticsService drawing
+// noise from random
+func NewAnalyticsService(repo repository.AnalyticsRepository, opts AnalyticsOptions, random RandomSource, logger Logger) (AnalyticsService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if random == nil {
+		return nil, errors.New("random source is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if opts.MinGroupSize < 2 {
+		return nil, errors.New("minimum group size must be at least 2")
+	}
+	if opts.NoiseEpsilon < 0 {
+		return nil, errors.New("noise epsilon must be non-negative")
+	}
+	if opts.MedianBucket <= 0 {
+		return nil, errors.New("median bucket must be positive")
+	}
+
+	return &analyticsService{
+		repo:   repo,
+		opts:   opts,
+		logger: logger,
+		random: random,
+	}, nil
+}
+
+// GetAdoptionStats returns per-currency adoption statistics over the window,
+// suppressing groups below the minimum size and adding noise to counts and
+// medians
+func (s *analyticsService) GetAdoptionStats(ctx context.Context, window time.Duration) ([]*AdoptionStats, error) {
+	if window <= 0 || window > maxAnalyticsWindow {
+		return nil, ErrInvalidAnalyticsWindow
+	}
+
+	aggregates, err := s.repo.GetAdoptionAggregates(ctx, time.Now().UTC().Add(-window))
+	if err != nil {
+		s.logger.Error("failed to get adoption aggregates", err)
+		return nil, fmt.Errorf("failed to get adoption aggregates: %w", err)
+	}
+
+	minSize := int64(s.opts.MinGroupSize)
+	stats := make([]*AdoptionStats, 0, len(aggregates))
+	for _, agg := range aggregates {
+		stat := &AdoptionStats{Currency: agg.Currency}
+
+		if agg.ActiveWallets < minSize {
+			stat.Suppressed = true
+			stats = append(stats, stat)
+			continue
+		}
+
+		activeWallets := s.noisyCount(agg.ActiveWallets)
+		topUpCount := s.noisyCount(agg.TopUpCount)
+		stat.ActiveWallets = &activeWallets
+		stat.TopUpCount = &topUpCount
+
+		// A median over few top-ups would reveal individual amounts
+		if agg.TopUpCount >= minSize {
+			median := s.noisyMedian(agg.MedianTopUp)
+			stat.MedianTopUp = &median
+		}
+
+		stats = append(stats, stat)
+	}
+
+	s.logger.Info("adoption stats computed",
+		"groups", len(stats),
+		"window", window)
+
+	return stats, nil
+}
+
+// noisyCount adds Laplace noise calibrated to a sensitivity of one to a count
+func (s *analyticsService) noisyCount(count int64) int64 {
+	if s.opts.NoiseEpsilon == 0 {
+		return count
+	}
+
+	noisy := int64(math.Round(float64(count) + s.laplace(1/s.opts.NoiseEpsilon)))
+	if noisy < 0 {
+		return 0
+	}
+	return noisy
+}
+
+// noisyMedian adds Laplace noise calibrated to a sensitivity of one bucket to
+// a median and rounds it to the nearest bucket
+func (s *analyticsService) noisyMedian(median float64) float64 {
+	bucket := s.opts.MedianBucket
+	if s.opts.NoiseEpsilon != 0 {
+		median += s.laplace(bucket / s.opts.NoiseEpsilon)
+	}
+
+	return math.Max(0, math.Round(median/bucket)*bucket)
+}
+
+// laplace draws from a zero-centered Laplace distribution of the given scale
+func (s *analyticsService) laplace(scale float64) float64 {
+	s.mu.Lock()
+	u := s.random.Float64() - 0.5
+	s.mu.Unlock()
+
+	return -scale * math.Copysign(1, u) * math.Log(1-2*math.Abs(u))
+}
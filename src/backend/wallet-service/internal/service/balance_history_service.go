@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+	"internal/repository"
+)
+
+// maxSnapshotCatchUpDays bounds how many missed midnights one run snapshots
+const maxSnapshotCatchUpDays = 31
+
+// ErrFutureBalanceDate is returned when a historical balance is requested for
+// a time that has not happened yet
+var ErrFutureBalanceDate = errors.New("balance date is in the future")
+
+// BalanceHistoryService defines the interface for historical wallet balances
+type BalanceHistoryService interface {
+	GetBalanceAsOf(ctx context.Context, walletID uuid.UUID, asOf time.Time) (*models.HistoricalBalance, error)
+	SnapshotDaily(ctx context.Context, now time.Time) (int64, error)
+	RunDaily(ctx context.Context, at time.Duration)
+}
+
+// balanceHistoryService implements BalanceHistoryService interface
+type balanceHistoryService struct {
+	repo   repository.SnapshotRepository
+	logger Logger
+}
+
+// NewBalanceHistoryService creates a new instance of BalanceHistoryService
+func NewBalanceHistoryService(repo repository.SnapshotRepository, logger Logger) (BalanceHistoryService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &balanceHistoryService{
+		repo:   repo,
+		logger: logger,
+	}, nil
+}
+
+// GetBalanceAsOf returns a wallet's balance at asOf from the nearest earlier
+// snapshot plus the transactions completed since
+func (s *balanceHistoryService) GetBalanceAsOf(ctx context.Context, walletID uuid.UUID, asOf time.Time) (*models.HistoricalBalance, error) {
+	if walletID == uuid.Nil {
+		return nil, ErrInvalidWalletID
+	}
+	if asOf.After(time.Now()) {
+		return nil, ErrFutureBalanceDate
+	}
+
+	balance, err := s.repo.GetBalanceAsOf(ctx, walletID, asOf.UTC())
+	if err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			return nil, ErrWalletNotFound
+		}
+		s.logger.Error("failed to get historical balance", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to get balance as of date: %w", err)
+	}
+
+	return balance, nil
+}
+
+// SnapshotDaily snapshots every wallet at each UTC midnight up to now that is
+// not yet covered, oldest first, so a missed night is caught up on the next
+// run. Returns the number of snapshots written.
+func (s *balanceHistoryService) SnapshotDaily(ctx context.Context, now time.Time) (int64, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	latest, err := s.repo.LatestSnapshotAt(ctx)
+	if err != nil {
+		s.logger.Error("failed to get latest balance snapshot", err)
+		return 0, err
+	}
+
+	next := today.AddDate(0, 0, -(maxSnapshotCatchUpDays - 1))
+	if !latest.IsZero() && latest.After(next) {
+		next = latest.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	}
+
+	var total int64
+	for ; !next.After(today); next = next.AddDate(0, 0, 1) {
+		created, err := s.repo.CreateSnapshots(ctx, next)
+		if err != nil {
+			s.logger.Error("failed to create balance snapshots", err, "asOf", next)
+			return total, err
+		}
+		total += created
+	}
+
+	if total > 0 {
+		s.logger.Info("balance snapshots created", "count", total, "through", today)
+	}
+
+	return total, nil
+}
+
+// RunDaily snapshots balances once a day at the given offset from midnight
+// UTC until the context is cancelled
+func (s *balanceHistoryService) RunDaily(ctx context.Context, at time.Duration) {
+	// Failures are logged by SnapshotDaily and caught up on the next day
+	runDailyAt(ctx, at, func(now time.Time) {
+		_, _ = s.SnapshotDaily(ctx, now)
+	})
+}
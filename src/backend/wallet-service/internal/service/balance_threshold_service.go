@@ -0,0 +1,567 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+	"github.com/shopspring/decimal"                           // v1.3.1
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+)
+
+// Balance threshold errors
+var (
+	ErrBalanceThresholdsNotFound = errors.New("balance thresholds not found")
+	ErrInvalidBalanceThresholds  = errors.New("invalid balance thresholds")
+	ErrInvalidBalanceAlerts      = errors.New("invalid balance alerts")
+)
+
+// Balance alert limits
+const (
+	// MaxBalanceAlerts is the most alert thresholds a wallet can have
+	MaxBalanceAlerts = 10
+	// maxBalanceAlertUsagePercent caps usage thresholds at ten months of
+	// average usage
+	maxBalanceAlertUsagePercent = 1000
+	// balanceAlertUsageWindow is the trailing window a wallet's average
+	// monthly debits are taken over, shortened to the wallet's age for
+	// wallets created within it
+	balanceAlertUsageWindow = 90 * 24 * time.Hour
+	// balanceAlertMonth is the month the average usage is scaled to
+	balanceAlertMonth = 30 * 24 * time.Hour
+)
+
+// balanceAlerts counts balance threshold alerts by level
+var balanceAlerts = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_balance_threshold_alerts_total",
+		Help: "Balance threshold alerts published as wallet balances fell below a threshold, by level",
+	},
+	[]string{"level"},
+)
+
+// balanceAlertsTriggered counts balance alert thresholds crossed by basis
+var balanceAlertsTriggered = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_balance_alerts_triggered_total",
+		Help: "Balance alert thresholds triggered as wallet balances fell below them, by basis",
+	},
+	[]string{"basis"},
+)
+
+// balanceAlertLevels are the levels evaluated on every balance change,
+// warning first so a single large debit alerts in escalating order
+var balanceAlertLevels = []models.BalanceAlertLevel{models.BalanceAlertWarning, models.BalanceAlertCritical}
+
+// BalanceThresholdPolicy holds the default trigger and reset percentages of
+// wallets configuring thresholds without their own
+type BalanceThresholdPolicy struct {
+	WarningPercent       float64
+	WarningResetPercent  float64
+	CriticalPercent      float64
+	CriticalResetPercent float64
+}
+
+// BalanceThresholdInput holds the fields of a balance thresholds update.
+// Omitted percentages take the policy defaults.
+type BalanceThresholdInput struct {
+	ReferenceBalance     decimal.Decimal
+	WarningPercent       *float64
+	WarningResetPercent  *float64
+	CriticalPercent      *float64
+	CriticalResetPercent *float64
+}
+
+// BalanceAlertInput holds one alert threshold of a balance alerts update
+type BalanceAlertInput struct {
+	Basis    models.BalanceAlertBasis
+	Value    decimal.Decimal
+	Channels []models.BalanceAlertChannel
+}
+
+// BalanceThresholdService defines the interface for the balance alert levels
+// of wallets. It subscribes to balance changes and publishes a warning or
+// critical event as the balance falls below a level, with hysteresis: a
+// level alerts again only after the balance rose above its reset amount.
+//
+// Wallets may also list up to MaxBalanceAlerts alert thresholds, each an
+// absolute balance or a percentage of the wallet's average monthly debits,
+// alerting on its own channels. A threshold alerts once as the balance
+// falls below it and re-arms when a recharge brings the balance back up.
+type BalanceThresholdService interface {
+	Get(ctx context.Context, walletID uuid.UUID) (*models.BalanceThresholds, error)
+	Update(ctx context.Context, walletID uuid.UUID, input BalanceThresholdInput) (*models.BalanceThresholds, error)
+	Delete(ctx context.Context, walletID uuid.UUID) error
+	// ListAlerts lists the alert thresholds of a wallet, highest first
+	ListAlerts(ctx context.Context, walletID uuid.UUID) ([]*models.BalanceAlertThreshold, error)
+	// SetAlerts replaces the alert thresholds of a wallet, an empty list
+	// removing them
+	SetAlerts(ctx context.Context, walletID uuid.UUID, inputs []BalanceAlertInput) ([]*models.BalanceAlertThreshold, error)
+	// Handle is the event bus subscriber evaluating the thresholds of a
+	// wallet whose balance changed
+	Handle(ctx context.Context, event eventbus.Event) error
+}
+
+// balanceThresholdService implements BalanceThresholdService interface
+type balanceThresholdService struct {
+	repo      repository.BalanceThresholdRepository
+	flows     repository.WalletAnalyticsRepository
+	wallets   WalletService
+	publisher eventbus.Publisher
+	policy    BalanceThresholdPolicy
+	logger    Logger
+}
+
+// NewBalanceThresholdService creates a new instance of
+// BalanceThresholdService
+func NewBalanceThresholdService(repo repository.BalanceThresholdRepository, flows repository.WalletAnalyticsRepository, wallets WalletService, publisher eventbus.Publisher, policy BalanceThresholdPolicy, logger Logger) (BalanceThresholdService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if flows == nil {
+		return nil, errors.New("wallet analytics repository is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	defaults := policy.levels(BalanceThresholdInput{})
+	if err := validateBalanceThresholdLevels(defaults.Warning, defaults.Critical); err != nil {
+		return nil, fmt.Errorf("invalid default thresholds: %w", err)
+	}
+
+	return &balanceThresholdService{
+		repo:      repo,
+		flows:     flows,
+		wallets:   wallets,
+		publisher: publisher,
+		policy:    policy,
+		logger:    logger,
+	}, nil
+}
+
+// Get retrieves the balance thresholds of a wallet
+func (s *balanceThresholdService) Get(ctx context.Context, walletID uuid.UUID) (*models.BalanceThresholds, error) {
+	if walletID == uuid.Nil {
+		return nil, ErrInvalidWalletID
+	}
+
+	thresholds, err := s.repo.GetBalanceThresholds(ctx, walletID)
+	if errors.Is(err, repository.ErrBalanceThresholdsNotFound) {
+		return nil, ErrBalanceThresholdsNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to get balance thresholds", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to get balance thresholds: %w", err)
+	}
+
+	return thresholds, nil
+}
+
+// Update replaces the balance thresholds of a wallet and evaluates them
+// against the current balance, so a wallet already below a new threshold
+// alerts right away rather than on its next debit
+func (s *balanceThresholdService) Update(ctx context.Context, walletID uuid.UUID, input BalanceThresholdInput) (*models.BalanceThresholds, error) {
+	maxAmount := decimal.NewFromFloat(models.MaxTransactionAmount)
+	if !input.ReferenceBalance.IsPositive() || input.ReferenceBalance.GreaterThan(maxAmount) {
+		return nil, fmt.Errorf("%w: reference_balance must be positive and at most %s", ErrInvalidBalanceThresholds, maxAmount)
+	}
+
+	thresholds := s.policy.levels(input)
+	if err := validateBalanceThresholdLevels(thresholds.Warning, thresholds.Critical); err != nil {
+		return nil, err
+	}
+
+	ctx = repository.ReadPrimary(ctx)
+	wallet, err := s.wallets.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	thresholds.WalletID = wallet.ID
+	thresholds.ReferenceBalance, _ = input.ReferenceBalance.Float64()
+	thresholds.UpdatedAt = time.Now().UTC()
+	if err := s.repo.UpsertBalanceThresholds(ctx, thresholds); err != nil {
+		s.logger.Error("failed to update balance thresholds", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to update balance thresholds: %w", err)
+	}
+
+	s.logger.Info("balance thresholds updated",
+		"walletID", walletID,
+		"warningBelow", thresholds.TriggerAmount(models.BalanceAlertWarning),
+		"criticalBelow", thresholds.TriggerAmount(models.BalanceAlertCritical))
+
+	if err := s.evaluate(ctx, wallet, thresholds); err != nil {
+		s.logger.Error("failed to evaluate balance thresholds", err, "walletID", walletID)
+	}
+
+	return thresholds, nil
+}
+
+// Delete removes the balance thresholds of a wallet, stopping its alerts
+func (s *balanceThresholdService) Delete(ctx context.Context, walletID uuid.UUID) error {
+	if walletID == uuid.Nil {
+		return ErrInvalidWalletID
+	}
+
+	err := s.repo.DeleteBalanceThresholds(ctx, walletID)
+	if errors.Is(err, repository.ErrBalanceThresholdsNotFound) {
+		return ErrBalanceThresholdsNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to delete balance thresholds", err, "walletID", walletID)
+		return fmt.Errorf("failed to delete balance thresholds: %w", err)
+	}
+
+	s.logger.Info("balance thresholds deleted", "walletID", walletID)
+	return nil
+}
+
+// ListAlerts lists the alert thresholds of a wallet, highest first
+func (s *balanceThresholdService) ListAlerts(ctx context.Context, walletID uuid.UUID) ([]*models.BalanceAlertThreshold, error) {
+	wallet, err := s.wallets.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := s.repo.ListBalanceAlerts(ctx, wallet.ID)
+	if err != nil {
+		s.logger.Error("failed to list balance alerts", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to list balance alerts: %w", err)
+	}
+	if alerts == nil {
+		alerts = []*models.BalanceAlertThreshold{}
+	}
+
+	return alerts, nil
+}
+
+// SetAlerts replaces the alert thresholds of a wallet, resolving the trigger
+// amount of usage thresholds from the wallet's current usage. The new
+// thresholds are evaluated right away, so a wallet already below one alerts
+// rather than waiting for its next debit.
+func (s *balanceThresholdService) SetAlerts(ctx context.Context, walletID uuid.UUID, inputs []BalanceAlertInput) ([]*models.BalanceAlertThreshold, error) {
+	if err := validateBalanceAlerts(inputs); err != nil {
+		return nil, err
+	}
+
+	ctx = repository.ReadPrimary(ctx)
+	wallet, err := s.wallets.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var usage *decimal.Decimal
+	alerts := make([]*models.BalanceAlertThreshold, 0, len(inputs))
+	for _, input := range inputs {
+		alert := &models.BalanceAlertThreshold{
+			ID:        uuid.New(),
+			WalletID:  wallet.ID,
+			Basis:     input.Basis,
+			Value:     input.Value,
+			Channels:  input.Channels,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if input.Basis == models.BalanceAlertUsagePercent && usage == nil {
+			average, err := s.averageMonthlyUsage(ctx, wallet, now)
+			if err != nil {
+				return nil, err
+			}
+			usage = &average
+		}
+		alert.TriggerAmount = triggerAmount(alert, usage)
+		alerts = append(alerts, alert)
+	}
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return alerts[i].TriggerAmount.GreaterThan(alerts[j].TriggerAmount)
+	})
+
+	if err := s.repo.ReplaceBalanceAlerts(ctx, wallet.ID, alerts); err != nil {
+		s.logger.Error("failed to set balance alerts", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to set balance alerts: %w", err)
+	}
+
+	s.logger.Info("balance alerts set", "walletID", walletID, "alerts", len(alerts))
+
+	if err := s.evaluateAlerts(ctx, wallet, alerts); err != nil {
+		s.logger.Error("failed to evaluate balance alerts", err, "walletID", walletID)
+	}
+
+	return alerts, nil
+}
+
+// Handle implements eventbus.Handler, evaluating both the levels and the
+// alert thresholds of the wallet. Wallets without either are skipped.
+func (s *balanceThresholdService) Handle(ctx context.Context, event eventbus.Event) error {
+	changed, ok := event.(eventbus.BalanceChanged)
+	if !ok {
+		return nil
+	}
+
+	ctx = repository.ReadPrimary(ctx)
+	thresholds, err := s.repo.GetBalanceThresholds(ctx, changed.WalletID)
+	if errors.Is(err, repository.ErrBalanceThresholdsNotFound) {
+		thresholds = nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get balance thresholds: %w", err)
+	}
+	alerts, err := s.repo.ListBalanceAlerts(ctx, changed.WalletID)
+	if err != nil {
+		return fmt.Errorf("failed to list balance alerts: %w", err)
+	}
+	if thresholds == nil && len(alerts) == 0 {
+		return nil
+	}
+
+	wallet, err := s.wallets.GetWallet(ctx, changed.WalletID)
+	if err != nil {
+		return err
+	}
+
+	if thresholds != nil {
+		if err := s.evaluate(ctx, wallet, thresholds); err != nil {
+			return err
+		}
+	}
+	return s.evaluateAlerts(ctx, wallet, alerts)
+}
+
+// evaluate moves each level of the thresholds to match the balance. A level
+// triggers below its trigger amount and re-arms above its reset amount;
+// between the two it keeps its state, which is what stops a balance
+// hovering around the trigger from alerting on every transaction. The
+// repository flips the state only when it differs, so concurrent balance
+// changes alert once.
+func (s *balanceThresholdService) evaluate(ctx context.Context, wallet *models.Wallet, thresholds *models.BalanceThresholds) error {
+	for _, level := range balanceAlertLevels {
+		state := thresholds.Level(level)
+
+		switch {
+		case !state.Triggered && wallet.Balance < thresholds.TriggerAmount(level):
+			flipped, err := s.repo.SetBalanceAlertTriggered(ctx, wallet.ID, level, true)
+			if err != nil {
+				return err
+			}
+			state.Triggered = true
+			if !flipped {
+				continue
+			}
+
+			balanceAlerts.WithLabelValues(string(level)).Inc()
+			s.logger.Warn("balance threshold crossed",
+				"walletID", wallet.ID,
+				"level", level,
+				"balance", wallet.Balance,
+				"threshold", thresholds.TriggerAmount(level))
+			publishWalletChanges(ctx, s.publisher, s.logger,
+				eventbus.BalanceThresholdCrossed{Wallet: wallet, Level: level, Thresholds: thresholds})
+
+		case state.Triggered && wallet.Balance > thresholds.ResetAmount(level):
+			if _, err := s.repo.SetBalanceAlertTriggered(ctx, wallet.ID, level, false); err != nil {
+				return err
+			}
+			state.Triggered = false
+			s.logger.Info("balance threshold re-armed",
+				"walletID", wallet.ID,
+				"level", level,
+				"balance", wallet.Balance)
+		}
+	}
+
+	return nil
+}
+
+// evaluateAlerts moves each alert threshold to match the balance, highest
+// first so a single large debit alerts in descending order. An armed
+// threshold triggers once the balance is below its trigger amount and stays
+// quiet until a recharge brings the balance back up to it. Usage thresholds
+// re-arm at the trigger amount of current usage, so a wallet whose usage
+// grew must recharge up to the new amount. As with the levels, the
+// repository flips the state only when it differs.
+func (s *balanceThresholdService) evaluateAlerts(ctx context.Context, wallet *models.Wallet, alerts []*models.BalanceAlertThreshold) error {
+	balance := decimal.NewFromFloat(wallet.Balance)
+	var usage *decimal.Decimal
+	for _, alert := range alerts {
+		switch {
+		case !alert.Triggered && balance.LessThan(alert.TriggerAmount):
+			now := time.Now().UTC()
+			flipped, err := s.repo.SetBalanceAlertState(ctx, alert.ID, true, alert.TriggerAmount, now)
+			if err != nil {
+				return err
+			}
+			alert.Triggered = true
+			alert.TriggeredAt = &now
+			if !flipped {
+				continue
+			}
+
+			balanceAlertsTriggered.WithLabelValues(string(alert.Basis)).Inc()
+			s.logger.Warn("balance alert triggered",
+				"walletID", wallet.ID,
+				"alertID", alert.ID,
+				"balance", wallet.Balance,
+				"threshold", alert.TriggerAmount)
+			publishWalletChanges(ctx, s.publisher, s.logger,
+				eventbus.BalanceAlertTriggered{Wallet: wallet, Alert: alert, Channels: alert.Channels})
+
+		case alert.Triggered && balance.GreaterThanOrEqual(alert.TriggerAmount):
+			if alert.Basis == models.BalanceAlertUsagePercent && usage == nil {
+				average, err := s.averageMonthlyUsage(ctx, wallet, time.Now().UTC())
+				if err != nil {
+					return err
+				}
+				usage = &average
+			}
+			amount := triggerAmount(alert, usage)
+			if balance.LessThan(amount) {
+				continue
+			}
+			if _, err := s.repo.SetBalanceAlertState(ctx, alert.ID, false, amount, time.Now().UTC()); err != nil {
+				return err
+			}
+			alert.Triggered = false
+			alert.TriggerAmount = amount
+			s.logger.Info("balance alert re-armed",
+				"walletID", wallet.ID,
+				"alertID", alert.ID,
+				"balance", wallet.Balance,
+				"threshold", amount)
+		}
+	}
+
+	return nil
+}
+
+// averageMonthlyUsage returns the wallet's debits over the trailing usage
+// window scaled to a month. Wallets younger than a month count as a month
+// old, so early usage is not extrapolated.
+func (s *balanceThresholdService) averageMonthlyUsage(ctx context.Context, wallet *models.Wallet, now time.Time) (decimal.Decimal, error) {
+	since := now.Add(-balanceAlertUsageWindow)
+	if wallet.CreatedAt.After(since) {
+		since = wallet.CreatedAt
+	}
+
+	flows, err := s.flows.GetWalletFlows(ctx, wallet.ID, models.GranularityMonth, since)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get wallet usage: %w", err)
+	}
+	debits := decimal.Zero
+	for _, flow := range flows {
+		debits = debits.Add(flow.Debits)
+	}
+
+	window := now.Sub(since)
+	if window < balanceAlertMonth {
+		window = balanceAlertMonth
+	}
+	return debits.Mul(decimal.NewFromInt(int64(balanceAlertMonth))).Div(decimal.NewFromInt(int64(window))), nil
+}
+
+// triggerAmount resolves the balance an alert threshold triggers below. A
+// usage threshold of a wallet without usage resolves to zero and so never
+// triggers until the wallet has spent.
+func triggerAmount(alert *models.BalanceAlertThreshold, usage *decimal.Decimal) decimal.Decimal {
+	if alert.Basis == models.BalanceAlertAbsolute {
+		return alert.Value
+	}
+	return alert.Value.Mul(*usage).Div(decimal.NewFromInt(100)).Round(4)
+}
+
+// validateBalanceAlerts checks the count, values and channels of alert
+// thresholds, refusing the same threshold twice
+func validateBalanceAlerts(inputs []BalanceAlertInput) error {
+	if len(inputs) > MaxBalanceAlerts {
+		return fmt.Errorf("%w: at most %d thresholds are allowed", ErrInvalidBalanceAlerts, MaxBalanceAlerts)
+	}
+
+	maxAmount := decimal.NewFromFloat(models.MaxTransactionAmount)
+	maxPercent := decimal.NewFromInt(maxBalanceAlertUsagePercent)
+	seen := make(map[string]bool, len(inputs))
+	for i, input := range inputs {
+		if !input.Basis.IsValid() {
+			return fmt.Errorf("%w: thresholds[%d].basis must be ABSOLUTE or USAGE_PERCENT", ErrInvalidBalanceAlerts, i)
+		}
+		if !input.Value.IsPositive() {
+			return fmt.Errorf("%w: thresholds[%d].value must be positive", ErrInvalidBalanceAlerts, i)
+		}
+		if input.Basis == models.BalanceAlertAbsolute && input.Value.GreaterThan(maxAmount) {
+			return fmt.Errorf("%w: thresholds[%d].value must be at most %s", ErrInvalidBalanceAlerts, i, maxAmount)
+		}
+		if input.Basis == models.BalanceAlertUsagePercent && input.Value.GreaterThan(maxPercent) {
+			return fmt.Errorf("%w: thresholds[%d].value must be at most %s percent", ErrInvalidBalanceAlerts, i, maxPercent)
+		}
+		key := string(input.Basis) + ":" + input.Value.String()
+		if seen[key] {
+			return fmt.Errorf("%w: thresholds[%d] repeats an earlier threshold", ErrInvalidBalanceAlerts, i)
+		}
+		seen[key] = true
+
+		if len(input.Channels) == 0 {
+			return fmt.Errorf("%w: thresholds[%d].channels must not be empty", ErrInvalidBalanceAlerts, i)
+		}
+		channels := make(map[models.BalanceAlertChannel]bool, len(input.Channels))
+		for _, channel := range input.Channels {
+			if !channel.IsValid() {
+				return fmt.Errorf("%w: thresholds[%d].channels must be EMAIL, SMS or WEBHOOK", ErrInvalidBalanceAlerts, i)
+			}
+			if channels[channel] {
+				return fmt.Errorf("%w: thresholds[%d].channels repeats %s", ErrInvalidBalanceAlerts, i, channel)
+			}
+			channels[channel] = true
+		}
+	}
+	return nil
+}
+
+// levels returns armed thresholds with the input percentages, falling back
+// to the policy for omitted ones
+func (p BalanceThresholdPolicy) levels(input BalanceThresholdInput) *models.BalanceThresholds {
+	pick := func(value *float64, fallback float64) float64 {
+		if value != nil {
+			return *value
+		}
+		return fallback
+	}
+
+	return &models.BalanceThresholds{
+		Warning: models.BalanceThresholdLevel{
+			TriggerPercent: pick(input.WarningPercent, p.WarningPercent),
+			ResetPercent:   pick(input.WarningResetPercent, p.WarningResetPercent),
+		},
+		Critical: models.BalanceThresholdLevel{
+			TriggerPercent: pick(input.CriticalPercent, p.CriticalPercent),
+			ResetPercent:   pick(input.CriticalResetPercent, p.CriticalResetPercent),
+		},
+	}
+}
+
+// validateBalanceThresholdLevels checks that each level resets above its
+// trigger, within 100 percent of the reference balance, and that the
+// critical level triggers below the warning
+func validateBalanceThresholdLevels(warning, critical models.BalanceThresholdLevel) error {
+	if warning.TriggerPercent <= 0 || warning.ResetPercent <= warning.TriggerPercent || warning.ResetPercent > 100 {
+		return fmt.Errorf("%w: warning must satisfy 0 < warning_percent < warning_reset_percent <= 100", ErrInvalidBalanceThresholds)
+	}
+	if critical.TriggerPercent <= 0 || critical.ResetPercent <= critical.TriggerPercent || critical.ResetPercent > 100 {
+		return fmt.Errorf("%w: critical must satisfy 0 < critical_percent < critical_reset_percent <= 100", ErrInvalidBalanceThresholds)
+	}
+	if critical.TriggerPercent >= warning.TriggerPercent {
+		return fmt.Errorf("%w: critical_percent must be below warning_percent", ErrInvalidBalanceThresholds)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"internal/models"
+	"internal/repository"
+)
+
+// billingPeriodHistory bounds the billing periods listed
+const billingPeriodHistory = 24
+
+// Billing period audit actions
+const (
+	billingPeriodCloseAction  = "billing_period.close"
+	billingPeriodReopenAction = "billing_period.reopen"
+)
+
+// Billing period errors
+var (
+	ErrInvalidBillingPeriod  = errors.New("invalid billing period")
+	ErrBillingPeriodConflict = errors.New("billing period is already in the requested state")
+)
+
+// BillingPeriodService defines the interface for closing billing periods.
+// Periods are calendar months in UTC, identified as YYYY-MM.
+type BillingPeriodService interface {
+	Get(ctx context.Context, period string) (*models.BillingPeriod, error)
+	List(ctx context.Context) ([]*models.BillingPeriod, error)
+	Close(ctx context.Context, period, actor, reason string) (*models.BillingPeriod, error)
+	Reopen(ctx context.Context, period, actor, reason string) (*models.BillingPeriod, error)
+}
+
+// billingPeriodService implements BillingPeriodService interface
+type billingPeriodService struct {
+	repo   repository.BillingPeriodRepository
+	audit  repository.AuditRepository
+	logger Logger
+}
+
+// NewBillingPeriodService creates a new instance of BillingPeriodService
+func NewBillingPeriodService(repo repository.BillingPeriodRepository, audit repository.AuditRepository, logger Logger) (BillingPeriodService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if audit == nil {
+		return nil, errors.New("audit repository is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &billingPeriodService{
+		repo:   repo,
+		audit:  audit,
+		logger: logger,
+	}, nil
+}
+
+// Get returns the close state of a billing period
+func (s *billingPeriodService) Get(ctx context.Context, period string) (*models.BillingPeriod, error) {
+	start, err := parseBillingPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := s.repo.GetBillingPeriod(ctx, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get billing period: %w", err)
+	}
+	return state, nil
+}
+
+// List returns the latest billing periods ever closed, newest first
+func (s *billingPeriodService) List(ctx context.Context) ([]*models.BillingPeriod, error) {
+	periods, err := s.repo.ListBillingPeriods(ctx, billingPeriodHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list billing periods: %w", err)
+	}
+	return periods, nil
+}
+
+// Close locks a billing period that has ended against further postings
+func (s *billingPeriodService) Close(ctx context.Context, period, actor, reason string) (*models.BillingPeriod, error) {
+	start, err := validatePeriodChange(period, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if now.Before(start.AddDate(0, 1, 0)) {
+		return nil, fmt.Errorf("%w: period %s has not ended", ErrInvalidBillingPeriod, period)
+	}
+
+	state, err := s.repo.CloseBillingPeriod(ctx, start, actor, reason, now)
+	return s.audited(ctx, billingPeriodCloseAction, period, actor, reason, state, err)
+}
+
+// Reopen unlocks a closed billing period, allowing postings into it again
+func (s *billingPeriodService) Reopen(ctx context.Context, period, actor, reason string) (*models.BillingPeriod, error) {
+	start, err := validatePeriodChange(period, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := s.repo.ReopenBillingPeriod(ctx, start, actor, reason, time.Now().UTC())
+	return s.audited(ctx, billingPeriodReopenAction, period, actor, reason, state, err)
+}
+
+// audited records a close or reopening in the operator audit log regardless
+// of whether it succeeded
+func (s *billingPeriodService) audited(ctx context.Context, action, period, actor, reason string, state *models.BillingPeriod, err error) (*models.BillingPeriod, error) {
+	if errors.Is(err, repository.ErrBillingPeriodConflict) {
+		err = ErrBillingPeriodConflict
+	} else if err != nil {
+		s.logger.Error("failed to change billing period", err, "action", action, "period", period)
+		err = fmt.Errorf("failed to change billing period: %w", err)
+	}
+
+	entry := &models.OperatorAction{
+		Action: action,
+		Actor:  actor,
+		Reason: reason,
+		Params: map[string]string{"period": period},
+		Status: models.OperatorActionSucceeded,
+	}
+	if err != nil {
+		entry.Status = models.OperatorActionFailed
+		entry.Error = err.Error()
+	}
+
+	if auditErr := s.audit.RecordOperatorAction(ctx, entry); auditErr != nil {
+		s.logger.Error("failed to audit billing period change", auditErr, "action", action, "period", period)
+		if err == nil {
+			err = fmt.Errorf("%s executed but audit record failed: %w", action, auditErr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("billing period changed",
+		"action", action,
+		"period", period,
+		"actor", actor)
+
+	return state, nil
+}
+
+// validatePeriodChange parses the period of a close or reopening, which
+// must give a reason
+func validatePeriodChange(period, reason string) (time.Time, error) {
+	start, err := parseBillingPeriod(period)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if strings.TrimSpace(reason) == "" {
+		return time.Time{}, fmt.Errorf("%w: a reason is required", ErrInvalidBillingPeriod)
+	}
+	return start, nil
+}
+
+// parseBillingPeriod parses a YYYY-MM billing period into its first instant
+func parseBillingPeriod(period string) (time.Time, error) {
+	start, err := time.Parse(models.BillingPeriodLayout, period)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s is not a YYYY-MM month", ErrInvalidBillingPeriod, period)
+	}
+	return start, nil
+}
@@ -0,0 +1,667 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/currency"
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+)
+
+// Report kinds of bulk credit jobs
+const (
+	// BulkCreditKind posts the credits of an operation
+	BulkCreditKind = "bulk-credit"
+	// BulkCreditRollbackKind reverses the posted credits of an operation
+	BulkCreditRollbackKind = "bulk-credit-rollback"
+)
+
+// Bulk credit errors
+var (
+	ErrInvalidBulkCredit  = errors.New("invalid bulk credit")
+	ErrBulkCreditNotFound = errors.New("bulk credit not found")
+	// ErrBulkCreditConflict is returned for rolling back an operation whose
+	// credits are still being posted, or that was already rolled back
+	ErrBulkCreditConflict = errors.New("bulk credit cannot be rolled back now")
+)
+
+// BulkCreditPolicy bounds bulk credit operations and the load their jobs
+// put on the database
+type BulkCreditPolicy struct {
+	// MaxRows is the quota of wallets one operation may credit
+	MaxRows int
+	// ChunkSize is the number of rows processed between recording their
+	// outcome and reporting progress, and the number of credits posted in
+	// one batch
+	ChunkSize int
+	// RowsPerSecond caps the rate rows are processed at, pausing between
+	// chunks that finish early
+	RowsPerSecond int
+}
+
+// BulkCreditRequest describes a bulk credit operation to submit
+type BulkCreditRequest struct {
+	// WalletIDs are the wallets to credit, checked per row when posted
+	WalletIDs   []string
+	Amount      decimal.Decimal
+	Currency    string
+	Description string
+}
+
+// bulkCreditParams are the parameters of bulk credit and rollback jobs. The
+// rows are stored with the operation rather than the job.
+type bulkCreditParams struct {
+	BulkCreditID uuid.UUID `json:"bulk_credit_id"`
+	Rows         int       `json:"rows"`
+}
+
+// BulkCreditService defines the interface for crediting many wallets in one
+// operation, such as goodwill credits after an incident
+type BulkCreditService interface {
+	// Submit stores an operation and queues the job posting its credits
+	Submit(ctx context.Context, req BulkCreditRequest, actor string) (*models.BulkCredit, error)
+	// Get retrieves an operation with the outcome of its rows so far
+	Get(ctx context.Context, id uuid.UUID) (*models.BulkCreditDetails, error)
+	// Rollback queues the job reversing the posted credits of an operation
+	Rollback(ctx context.Context, id uuid.UUID, actor string) (*models.BulkCredit, error)
+	// Post works through the pending rows of an operation
+	Post(ctx context.Context, id uuid.UUID, progress ReportProgress) (*models.BulkCreditResult, error)
+	// Reverse works through the posted rows of an operation
+	Reverse(ctx context.Context, id uuid.UUID, progress ReportProgress) (*models.BulkCreditResult, error)
+	// Purge deletes operations created before a time with their rows
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// bulkCreditService implements BulkCreditService interface. The credits
+// of a chunk are posted in one batch, paused by incidents and published
+// like any other; reversals are debits and go through the wallet service.
+type bulkCreditService struct {
+	repo       repository.BulkCreditRepository
+	wallets    WalletService
+	postings   repository.WalletRepository
+	incidents  IncidentService
+	jobs       ReportJobService
+	currencies *currency.Registry
+	publisher  eventbus.Publisher
+	policy     BulkCreditPolicy
+	logger     Logger
+}
+
+// NewBulkCreditService creates a new instance of BulkCreditService
+// submitting its operations as jobs
+func NewBulkCreditService(repo repository.BulkCreditRepository, wallets WalletService, postings repository.WalletRepository, incidents IncidentService, jobs ReportJobService, currencies *currency.Registry, publisher eventbus.Publisher, policy BulkCreditPolicy, logger Logger) (BulkCreditService, error) {
+	if repo == nil {
+		return nil, errors.New("bulk credit repository is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if postings == nil {
+		return nil, errors.New("wallet repository is required")
+	}
+	if incidents == nil {
+		return nil, errors.New("incident service is required")
+	}
+	if jobs == nil {
+		return nil, errors.New("report job service is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currency registry is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if policy.MaxRows <= 0 || policy.ChunkSize <= 0 || policy.RowsPerSecond <= 0 {
+		return nil, errors.New("bulk credit quota, chunk size and rate must be positive")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &bulkCreditService{
+		repo:       repo,
+		wallets:    wallets,
+		postings:   postings,
+		incidents:  incidents,
+		jobs:       jobs,
+		currencies: currencies,
+		publisher:  publisher,
+		policy:     policy,
+		logger:     logger,
+	}, nil
+}
+
+// parseBulkCreditParams decodes the parameters of bulk credit jobs
+func parseBulkCreditParams(params json.RawMessage) (bulkCreditParams, error) {
+	var p bulkCreditParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return p, err
+	}
+	if p.BulkCreditID == uuid.Nil {
+		return p, errors.New("bulk_credit_id is required")
+	}
+	return p, nil
+}
+
+// BulkCreditReport returns the report kind posting the credits of an
+// operation. Retries resume after the rows already recorded.
+func BulkCreditReport(bulk BulkCreditService) ReportKind {
+	return ReportKind{
+		Name: BulkCreditKind,
+		Validate: func(params json.RawMessage) error {
+			_, err := parseBulkCreditParams(params)
+			return err
+		},
+		Run: func(ctx context.Context, params json.RawMessage, progress ReportProgress) (interface{}, error) {
+			p, err := parseBulkCreditParams(params)
+			if err != nil {
+				return nil, err
+			}
+			return bulk.Post(ctx, p.BulkCreditID, progress)
+		},
+	}
+}
+
+// BulkCreditRollbackReport returns the report kind reversing the posted
+// credits of an operation. Retries resume after the rows already recorded.
+func BulkCreditRollbackReport(bulk BulkCreditService) ReportKind {
+	return ReportKind{
+		Name: BulkCreditRollbackKind,
+		Validate: func(params json.RawMessage) error {
+			_, err := parseBulkCreditParams(params)
+			return err
+		},
+		Run: func(ctx context.Context, params json.RawMessage, progress ReportProgress) (interface{}, error) {
+			p, err := parseBulkCreditParams(params)
+			if err != nil {
+				return nil, err
+			}
+			return bulk.Reverse(ctx, p.BulkCreditID, progress)
+		},
+	}
+}
+
+// Submit numbers and stores the rows of an operation, then queues its job.
+// Wallet IDs are checked per row when posted, so one bad row does not hold
+// up the rest.
+func (s *bulkCreditService) Submit(ctx context.Context, req BulkCreditRequest, actor string) (*models.BulkCredit, error) {
+	if len(req.WalletIDs) == 0 {
+		return nil, fmt.Errorf("%w: no wallets to credit", ErrInvalidBulkCredit)
+	}
+	if len(req.WalletIDs) > s.policy.MaxRows {
+		return nil, fmt.Errorf("%w: at most %d wallets per operation", ErrInvalidBulkCredit, s.policy.MaxRows)
+	}
+	code := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if !s.currencies.Supported(code) {
+		return nil, ErrUnsupportedCurrency
+	}
+	amount := s.currencies.Round(code, req.Amount)
+	if !amount.IsPositive() || amount.GreaterThan(decimal.NewFromFloat(models.MaxTransactionAmount)) {
+		return nil, ErrInvalidAmount
+	}
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		return nil, fmt.Errorf("%w: description is required", ErrInvalidBulkCredit)
+	}
+
+	rows := make([]*models.BulkCreditRow, len(req.WalletIDs))
+	for i, walletID := range req.WalletIDs {
+		rows[i] = &models.BulkCreditRow{
+			Index:    i,
+			WalletID: strings.TrimSpace(walletID),
+			Status:   models.BulkCreditRowPending,
+		}
+	}
+
+	credit := &models.BulkCredit{
+		ID:          uuid.New(),
+		Amount:      amount,
+		Currency:    code,
+		Description: description,
+		TotalRows:   len(rows),
+		CreatedBy:   actor,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := s.repo.CreateBulkCredit(ctx, credit, rows); err != nil {
+		s.logger.Error("failed to store bulk credit", err, "bulkCreditID", credit.ID)
+		return nil, fmt.Errorf("failed to store bulk credit: %w", err)
+	}
+
+	job, err := s.submitJob(ctx, BulkCreditKind, credit, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetBulkCreditJob(ctx, credit.ID, job.ID); err != nil {
+		s.logger.Error("failed to record bulk credit job", err, "bulkCreditID", credit.ID, "jobID", job.ID)
+		return nil, fmt.Errorf("failed to record bulk credit job: %w", err)
+	}
+	credit.JobID = &job.ID
+
+	s.logger.Info("bulk credit submitted",
+		"bulkCreditID", credit.ID,
+		"jobID", job.ID,
+		"rows", len(rows),
+		"amount", credit.Amount,
+		"currency", credit.Currency,
+		"actor", actor)
+
+	return credit, nil
+}
+
+// submitJob queues a job of a kind for an operation
+func (s *bulkCreditService) submitJob(ctx context.Context, kind string, credit *models.BulkCredit, actor string) (*models.ReportJob, error) {
+	params, err := json.Marshal(bulkCreditParams{BulkCreditID: credit.ID, Rows: credit.TotalRows})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bulk credit: %w", err)
+	}
+	return s.jobs.Submit(ctx, kind, params, actor)
+}
+
+// Get retrieves an operation with the outcome of its rows so far
+func (s *bulkCreditService) Get(ctx context.Context, id uuid.UUID) (*models.BulkCreditDetails, error) {
+	credit, err := s.getBulkCredit(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.ListBulkCreditRows(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BulkCreditDetails{BulkCredit: credit, Result: summarizeBulkCredit(id, rows)}, nil
+}
+
+// getBulkCredit retrieves an operation, mapping its absence
+func (s *bulkCreditService) getBulkCredit(ctx context.Context, id uuid.UUID) (*models.BulkCredit, error) {
+	credit, err := s.repo.GetBulkCredit(ctx, id)
+	if errors.Is(err, repository.ErrBulkCreditNotFound) {
+		return nil, ErrBulkCreditNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to get bulk credit", err, "bulkCreditID", id)
+		return nil, err
+	}
+	return credit, nil
+}
+
+// Rollback queues the job reversing the posted credits of an operation
+// once its credit job has finished, successful or not, so the two never
+// run at once. An operation is rolled back once only.
+func (s *bulkCreditService) Rollback(ctx context.Context, id uuid.UUID, actor string) (*models.BulkCredit, error) {
+	credit, err := s.getBulkCredit(repository.ReadPrimary(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	if credit.RollbackJobID != nil {
+		return nil, fmt.Errorf("%w: already rolled back", ErrBulkCreditConflict)
+	}
+	if credit.JobID == nil {
+		return nil, fmt.Errorf("%w: credits were never queued", ErrBulkCreditConflict)
+	}
+	creditJob, err := s.jobs.Get(ctx, *credit.JobID)
+	if err != nil && !errors.Is(err, ErrReportJobNotFound) {
+		return nil, err
+	}
+	if err == nil && !creditJob.Status.Finished() {
+		return nil, fmt.Errorf("%w: credits are still being posted", ErrBulkCreditConflict)
+	}
+
+	job, err := s.submitJob(ctx, BulkCreditRollbackKind, credit, actor)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.RequestBulkCreditRollback(ctx, id, job.ID, actor, time.Now().UTC())
+	if err != nil {
+		// Withdraw the job so a concurrent rollback is not run twice
+		if _, cancelErr := s.jobs.Cancel(ctx, job.ID); cancelErr != nil {
+			s.logger.Error("failed to cancel bulk credit rollback job", cancelErr, "bulkCreditID", id, "jobID", job.ID)
+		}
+		if errors.Is(err, repository.ErrBulkCreditConflict) {
+			return nil, fmt.Errorf("%w: already rolled back", ErrBulkCreditConflict)
+		}
+		if errors.Is(err, repository.ErrBulkCreditNotFound) {
+			return nil, ErrBulkCreditNotFound
+		}
+		s.logger.Error("failed to record bulk credit rollback", err, "bulkCreditID", id)
+		return nil, err
+	}
+
+	s.logger.Info("bulk credit rollback submitted",
+		"bulkCreditID", id,
+		"jobID", job.ID,
+		"actor", actor)
+
+	return updated, nil
+}
+
+// Post credits the pending rows of an operation
+func (s *bulkCreditService) Post(ctx context.Context, id uuid.UUID, progress ReportProgress) (*models.BulkCreditResult, error) {
+	return s.process(ctx, id, models.BulkCreditRowPending, s.postRows, progress)
+}
+
+// Reverse debits back the posted rows of an operation
+func (s *bulkCreditService) Reverse(ctx context.Context, id uuid.UUID, progress ReportProgress) (*models.BulkCreditResult, error) {
+	return s.process(ctx, id, models.BulkCreditRowPosted, s.reverseRows, progress)
+}
+
+// bulkCreditStep processes a chunk of an operation's rows, leaving the
+// outcome on the rows, and returns how many of the leading rows it
+// processed. recovering is set for the first chunk of a run, whose rows may
+// have been processed by a run that stopped before recording them. Only
+// errors that are not a row's own are returned.
+type bulkCreditStep func(ctx context.Context, credit *models.BulkCredit, rows []*models.BulkCreditRow, recovering bool) (int, error)
+
+// process works through the rows of an operation in a status in chunks,
+// recording the outcome of each chunk before reporting progress and pacing
+// the chunks to the policy's rate. An error other than a row's own stops
+// the run after recording the rows done so far, and the retry picks up
+// from there.
+func (s *bulkCreditService) process(ctx context.Context, id uuid.UUID, status models.BulkCreditRowStatus, step bulkCreditStep, progress ReportProgress) (*models.BulkCreditResult, error) {
+	credit, err := s.repo.GetBulkCredit(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.ListBulkCreditRows(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("bulk credit %s has no rows", id)
+	}
+
+	var todo []*models.BulkCreditRow
+	for _, row := range rows {
+		if row.Status == status {
+			todo = append(todo, row)
+		}
+	}
+
+	done := len(rows) - len(todo)
+	for start := 0; start < len(todo); start += s.policy.ChunkSize {
+		end := start + s.policy.ChunkSize
+		if end > len(todo) {
+			end = len(todo)
+		}
+		chunk := todo[start:end]
+		began := time.Now()
+
+		processed, runErr := step(ctx, credit, chunk, start == 0)
+		if processed > 0 {
+			if err := s.repo.RecordBulkCreditRows(ctx, id, chunk[:processed]); err != nil {
+				s.logger.Error("failed to record bulk credit rows", err, "bulkCreditID", id)
+				return nil, err
+			}
+		}
+		if runErr != nil {
+			s.logger.Error("failed to process bulk credit", runErr, "bulkCreditID", id, "status", status)
+			return nil, runErr
+		}
+
+		done += len(chunk)
+		if err := progress(done * 100 / len(rows)); err != nil {
+			return nil, err
+		}
+		if end < len(todo) {
+			if err := s.pace(ctx, began, len(chunk)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := summarizeBulkCredit(id, rows)
+	s.logger.Info("bulk credit processed",
+		"bulkCreditID", id,
+		"status", status,
+		"posted", result.Posted,
+		"failed", result.Failed,
+		"reversed", result.Reversed,
+		"notReversed", result.NotReversed)
+
+	return result, nil
+}
+
+// pace waits out the rest of the time a chunk of rows is allowed at the
+// policy's rate
+func (s *bulkCreditService) pace(ctx context.Context, began time.Time, rows int) error {
+	wait := time.Duration(rows)*time.Second/time.Duration(s.policy.RowsPerSecond) - time.Since(began)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// bulkCreditReference is the reference ID of a row's credit, or of its
+// reversal, identifying a posting left unrecorded by a stopped run
+func bulkCreditReference(credit *models.BulkCredit, row *models.BulkCreditRow, reversal bool) string {
+	reference := fmt.Sprintf("BULK-%s-%d", credit.ID, row.Index)
+	if reversal {
+		reference += "-RB"
+	}
+	return reference
+}
+
+// findPosted returns the ID of the transaction with a reference posted to
+// a wallet, if there is one
+func (s *bulkCreditService) findPosted(ctx context.Context, walletID uuid.UUID, reference string) (*uuid.UUID, error) {
+	matches, err := s.wallets.FindTransactionsByReference(repository.ReadPrimary(ctx), reference)
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range matches {
+		if match.Transaction.WalletID == walletID {
+			return &match.Transaction.ID, nil
+		}
+	}
+	return nil, nil
+}
+
+// isBulkCreditRowError reports whether a wallet service error is the row's
+// own, failing the row rather than the run
+func isBulkCreditRowError(err error) bool {
+	return errors.Is(err, ErrWalletNotFound) ||
+		errors.Is(err, ErrCurrencyMismatch) ||
+		errors.Is(err, ErrInsufficientBalance) ||
+		errors.Is(err, repository.ErrInsufficientBalance) ||
+		errors.Is(err, ErrWalletFrozen) ||
+		errors.Is(err, ErrWalletMerged) ||
+		errors.Is(err, ErrPeriodClosed)
+}
+
+// postRows credits the wallets of a chunk's rows in one batch. Rows
+// recovered from a stopped run keep the credit they already have.
+func (s *bulkCreditService) postRows(ctx context.Context, credit *models.BulkCredit, rows []*models.BulkCreditRow, recovering bool) (int, error) {
+	minor, err := s.currencies.ToMinorUnits(credit.Currency, credit.Amount)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+
+	var posting []*models.BulkCreditRow
+	var postings []*models.Transaction
+	for _, row := range rows {
+		walletID, err := uuid.Parse(row.WalletID)
+		if err != nil || walletID == uuid.Nil {
+			row.Status = models.BulkCreditRowFailed
+			row.Error = "invalid wallet ID"
+			continue
+		}
+
+		reference := bulkCreditReference(credit, row, false)
+		if recovering {
+			posted, err := s.findPosted(ctx, walletID, reference)
+			if err != nil {
+				return 0, err
+			}
+			if posted != nil {
+				row.Status = models.BulkCreditRowPosted
+				row.TransactionID = posted
+				continue
+			}
+		}
+
+		tx := s.transaction(credit, walletID, models.TransactionTypeCredit, reference)
+		tx.Minor = minor
+		tx.Amount, _ = minor.Decimal().Float64()
+		if err := s.incidents.Check(tx); err != nil {
+			return 0, err
+		}
+		posting = append(posting, row)
+		postings = append(postings, tx)
+	}
+
+	refused, err := s.postings.PostTransactions(ctx, postings)
+	if err != nil {
+		return 0, err
+	}
+	for i, row := range posting {
+		if refused[i] != nil {
+			row.Status = models.BulkCreditRowFailed
+			row.Error = postingError(refused[i]).Error()
+			continue
+		}
+		tx := postings[i]
+		row.Status = models.BulkCreditRowPosted
+		row.TransactionID = &tx.ID
+		publishWalletChanges(ctx, s.publisher, s.logger,
+			eventbus.TransactionStatusChanged{WalletID: tx.WalletID, TransactionID: tx.ID, Status: models.TransactionStatusCompleted},
+			eventbus.BalanceChanged{WalletID: tx.WalletID})
+	}
+	return len(rows), nil
+}
+
+// postingError reports a posting refused by the repository as the wallet
+// service error ProcessTransaction returns for it
+func postingError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrWalletNotFound):
+		return ErrWalletNotFound
+	case errors.Is(err, repository.ErrCurrencyMismatch):
+		return ErrCurrencyMismatch
+	case errors.Is(err, repository.ErrInsufficientBalance):
+		return ErrInsufficientBalance
+	case errors.Is(err, repository.ErrPeriodClosed):
+		return ErrPeriodClosed
+	case errors.Is(err, repository.ErrWalletFrozen):
+		return ErrWalletFrozen
+	case errors.Is(err, repository.ErrWalletMerged):
+		return ErrWalletMerged
+	}
+	return err
+}
+
+// reverseRows debits back the credits of a chunk's rows one at a time, as
+// debits go through the wallet service
+func (s *bulkCreditService) reverseRows(ctx context.Context, credit *models.BulkCredit, rows []*models.BulkCreditRow, recovering bool) (int, error) {
+	for i, row := range rows {
+		if err := s.reverseRow(ctx, credit, row, recovering); err != nil {
+			return i, err
+		}
+	}
+	return len(rows), nil
+}
+
+// reverseRow debits back the credit of a posted row. A wallet that has
+// since spent the credit keeps it, down to its credit limit.
+func (s *bulkCreditService) reverseRow(ctx context.Context, credit *models.BulkCredit, row *models.BulkCreditRow, recovering bool) error {
+	walletID, err := uuid.Parse(row.WalletID)
+	if err != nil {
+		return fmt.Errorf("posted bulk credit row %d has an invalid wallet ID", row.Index)
+	}
+
+	reference := bulkCreditReference(credit, row, true)
+	if recovering {
+		reversed, err := s.findPosted(ctx, walletID, reference)
+		if err != nil {
+			return err
+		}
+		if reversed != nil {
+			row.Status = models.BulkCreditRowReversed
+			row.ReversalID = reversed
+			return nil
+		}
+	}
+
+	tx := s.transaction(credit, walletID, models.TransactionTypeDebit, reference)
+	if _, err := s.wallets.ProcessTransaction(ctx, tx); err != nil {
+		if !isBulkCreditRowError(err) {
+			return err
+		}
+		row.Status = models.BulkCreditRowNotReversed
+		row.Error = err.Error()
+		return nil
+	}
+
+	row.Status = models.BulkCreditRowReversed
+	row.ReversalID = &tx.ID
+	return nil
+}
+
+// transaction builds a completed posting of an operation's amount
+func (s *bulkCreditService) transaction(credit *models.BulkCredit, walletID uuid.UUID, txType models.TransactionType, reference string) *models.Transaction {
+	description := credit.Description
+	if txType == models.TransactionTypeDebit {
+		description = "Reversal: " + description
+	}
+	amount, _ := credit.Amount.Float64()
+	now := time.Now().UTC()
+	return &models.Transaction{
+		ID:          uuid.New(),
+		WalletID:    walletID,
+		Type:        txType,
+		Status:      models.TransactionStatusCompleted,
+		Amount:      amount,
+		Currency:    credit.Currency,
+		Description: description,
+		ReferenceID: reference,
+		Metadata: map[string]string{
+			"bulk_credit_id": credit.ID.String(),
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// summarizeBulkCredit counts the rows of an operation by outcome
+func summarizeBulkCredit(id uuid.UUID, rows []*models.BulkCreditRow) *models.BulkCreditResult {
+	result := &models.BulkCreditResult{BulkCreditID: id, Total: len(rows), Rows: rows}
+	for _, row := range rows {
+		switch row.Status {
+		case models.BulkCreditRowPending:
+			result.Pending++
+		case models.BulkCreditRowPosted:
+			result.Posted++
+		case models.BulkCreditRowFailed:
+			result.Failed++
+		case models.BulkCreditRowReversed:
+			result.Reversed++
+		case models.BulkCreditRowNotReversed:
+			result.NotReversed++
+		}
+	}
+	return result
+}
+
+// Purge deletes operations created before a time with their rows, as their
+// jobs are purged
+func (s *bulkCreditService) Purge(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := s.repo.PurgeBulkCredits(ctx, before)
+	if err != nil {
+		s.logger.Error("failed to purge bulk credits", err)
+		return 0, err
+	}
+	return purged, nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+	"internal/repository"
+)
+
+// consentHistoryLimit bounds the consent changes returned by GetHistory
+const consentHistoryLimit = 100
+
+// Consent errors
+var (
+	ErrInvalidConsentChannel = errors.New("invalid consent channel")
+	ErrInvalidConsentSource  = errors.New("invalid consent source")
+)
+
+// ConsentService defines the interface for customer notification consent.
+// Customers are opted out of every channel until they record consent.
+type ConsentService interface {
+	GetConsents(ctx context.Context, customerID uuid.UUID) ([]*models.CustomerConsent, error)
+	UpdateConsent(ctx context.Context, customerID uuid.UUID, channel models.ConsentChannel, granted bool, source models.ConsentSource) (*models.CustomerConsent, error)
+	GetHistory(ctx context.Context, customerID uuid.UUID) ([]*models.CustomerConsent, error)
+	HasConsent(ctx context.Context, customerID uuid.UUID, channel models.ConsentChannel) (bool, error)
+}
+
+// consentService implements ConsentService interface
+type consentService struct {
+	repo   repository.ConsentRepository
+	logger Logger
+}
+
+// NewConsentService creates a new instance of ConsentService
+func NewConsentService(repo repository.ConsentRepository, logger Logger) (ConsentService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &consentService{
+		repo:   repo,
+		logger: logger,
+	}, nil
+}
+
+// GetConsents returns the customer's consent for every channel, reporting
+// channels never recorded as not granted
+func (s *consentService) GetConsents(ctx context.Context, customerID uuid.UUID) ([]*models.CustomerConsent, error) {
+	recorded, err := s.repo.ListConsents(ctx, customerID)
+	if err != nil {
+		s.logger.Error("failed to get consents", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to get consents: %w", err)
+	}
+
+	byChannel := make(map[models.ConsentChannel]*models.CustomerConsent, len(recorded))
+	for _, consent := range recorded {
+		byChannel[consent.Channel] = consent
+	}
+
+	consents := make([]*models.CustomerConsent, 0, len(models.ConsentChannels))
+	for _, channel := range models.ConsentChannels {
+		consent, ok := byChannel[channel]
+		if !ok {
+			consent = &models.CustomerConsent{CustomerID: customerID, Channel: channel}
+		}
+		consents = append(consents, consent)
+	}
+
+	return consents, nil
+}
+
+// UpdateConsent grants or withdraws the customer's consent to a channel,
+// recording where the change was made
+func (s *consentService) UpdateConsent(ctx context.Context, customerID uuid.UUID, channel models.ConsentChannel, granted bool, source models.ConsentSource) (*models.CustomerConsent, error) {
+	if !validConsentChannel(channel) {
+		return nil, ErrInvalidConsentChannel
+	}
+	switch source {
+	case models.ConsentSourcePortal, models.ConsentSourceSupport, models.ConsentSourceAPI:
+	default:
+		return nil, ErrInvalidConsentSource
+	}
+
+	consent := &models.CustomerConsent{
+		CustomerID: customerID,
+		Channel:    channel,
+		Granted:    granted,
+		Source:     source,
+	}
+	err := s.repo.RecordConsent(ctx, consent)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to record consent", err, "customerID", customerID, "channel", channel)
+		return nil, fmt.Errorf("failed to record consent: %w", err)
+	}
+
+	s.logger.Info("customer consent recorded",
+		"customerID", customerID,
+		"channel", channel,
+		"granted", granted,
+		"source", source)
+
+	return consent, nil
+}
+
+// GetHistory returns the customer's latest consent changes, newest first
+func (s *consentService) GetHistory(ctx context.Context, customerID uuid.UUID) ([]*models.CustomerConsent, error) {
+	history, err := s.repo.ListConsentHistory(ctx, customerID, consentHistoryLimit)
+	if err != nil {
+		s.logger.Error("failed to get consent history", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to get consent history: %w", err)
+	}
+	return history, nil
+}
+
+// HasConsent reports whether the customer may be notified on a channel
+func (s *consentService) HasConsent(ctx context.Context, customerID uuid.UUID, channel models.ConsentChannel) (bool, error) {
+	if !validConsentChannel(channel) {
+		return false, ErrInvalidConsentChannel
+	}
+	return s.repo.HasConsent(ctx, customerID, channel)
+}
+
+// validConsentChannel reports whether consent is recorded for channel
+func validConsentChannel(channel models.ConsentChannel) bool {
+	for _, known := range models.ConsentChannels {
+		if channel == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,391 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/currency"
+	"internal/models"
+	"internal/repository"
+)
+
+// Coupon audit actions
+const (
+	couponCreateAction = "coupon.create"
+	couponVoidAction   = "coupon.void"
+	couponRedeemAction = "coupon.redeem"
+)
+
+// Coupon limits
+const (
+	// defaultCouponListLimit and maxCouponListLimit bound the coupons listed
+	defaultCouponListLimit = 50
+	maxCouponListLimit     = 500
+	// couponRedemptionHistory bounds the redemptions returned with a coupon
+	couponRedemptionHistory = 100
+	// maxCouponCodeLength is the longest coupon code
+	maxCouponCodeLength = 40
+	// maxRedemptionReference is the longest charge or invoice reference a
+	// redemption can name
+	maxRedemptionReference = 64
+)
+
+// Coupon errors
+var (
+	ErrInvalidCoupon       = errors.New("invalid coupon")
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponConflict      = errors.New("coupon is not in the required state")
+	ErrCouponNotRedeemable = errors.New("coupon cannot be redeemed")
+)
+
+// couponCodePattern matches the codes coupons are redeemed by, once
+// upper-cased
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]*$`)
+
+// CouponApplication describes an amount a coupon is applied to
+type CouponApplication struct {
+	Code       string
+	CustomerID uuid.UUID
+	// Source and Reference name the rated charge or invoice the coupon is
+	// applied to; a coupon is redeemed once per source and reference
+	Source    models.RedemptionSource
+	Reference string
+	Currency  string
+	Amount    decimal.Decimal
+	// At is when the coupon is applied, defaulting to now
+	At time.Time
+}
+
+// CouponService defines the interface for managing coupons and applying
+// them at rating or invoice time
+type CouponService interface {
+	Create(ctx context.Context, coupon *models.Coupon, actor, reason string) (*models.Coupon, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.CouponDetails, error)
+	List(ctx context.Context, status models.CouponStatus, limit int) ([]*models.Coupon, error)
+	Void(ctx context.Context, id uuid.UUID, actor, reason string) (*models.Coupon, error)
+	// Quote returns the discount a coupon would give without redeeming it
+	Quote(ctx context.Context, application CouponApplication) (*models.CouponQuote, error)
+	// Redeem applies a coupon, recording and auditing the redemption. A
+	// retried redemption returns the first one.
+	Redeem(ctx context.Context, application CouponApplication, actor string) (*models.CouponRedemption, error)
+}
+
+// couponService implements CouponService interface
+type couponService struct {
+	repo       repository.CouponRepository
+	audit      repository.AuditRepository
+	currencies *currency.Registry
+	logger     Logger
+}
+
+// NewCouponService creates a new instance of CouponService
+func NewCouponService(repo repository.CouponRepository, audit repository.AuditRepository, currencies *currency.Registry, logger Logger) (CouponService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if audit == nil {
+		return nil, errors.New("audit repository is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currency registry is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &couponService{
+		repo:       repo,
+		audit:      audit,
+		currencies: currencies,
+		logger:     logger,
+	}, nil
+}
+
+// Create validates and stores a coupon, valid from now when unset
+func (s *couponService) Create(ctx context.Context, coupon *models.Coupon, actor, reason string) (*models.Coupon, error) {
+	now := time.Now().UTC()
+	if coupon.ValidFrom.IsZero() {
+		coupon.ValidFrom = now
+	}
+	coupon.Code = strings.ToUpper(strings.TrimSpace(coupon.Code))
+	coupon.Description = strings.TrimSpace(coupon.Description)
+	coupon.Currency = strings.ToUpper(strings.TrimSpace(coupon.Currency))
+	if err := s.validate(coupon, reason); err != nil {
+		return nil, err
+	}
+
+	coupon.ID = uuid.New()
+	coupon.Redemptions = 0
+	coupon.Status = models.CouponActive
+	coupon.CreatedBy = actor
+	coupon.CreatedAt = now
+	coupon.VoidedBy, coupon.VoidReason, coupon.VoidedAt = "", "", nil
+
+	err := s.repo.CreateCoupon(ctx, coupon)
+	params := map[string]string{
+		"coupon_id":     coupon.ID.String(),
+		"code":          coupon.Code,
+		"discount_type": string(coupon.Type),
+		"value":         coupon.Value.String(),
+	}
+	if coupon.CustomerID != nil {
+		params["customer_id"] = coupon.CustomerID.String()
+	}
+	if err := s.audited(ctx, couponCreateAction, actor, reason, params, err); err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// Get returns a coupon with its latest redemptions
+func (s *couponService) Get(ctx context.Context, id uuid.UUID) (*models.CouponDetails, error) {
+	coupon, err := s.repo.GetCoupon(ctx, id)
+	if errors.Is(err, repository.ErrCouponNotFound) {
+		return nil, ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+
+	redemptions, err := s.repo.ListRedemptions(ctx, id, couponRedemptionHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coupon redemptions: %w", err)
+	}
+	return &models.CouponDetails{Coupon: coupon, Redemptions: redemptions}, nil
+}
+
+// List returns the latest coupons in a status, or in any status when it is
+// empty, newest first
+func (s *couponService) List(ctx context.Context, status models.CouponStatus, limit int) ([]*models.Coupon, error) {
+	if status != "" && status != models.CouponActive && status != models.CouponVoided {
+		return nil, fmt.Errorf("%w: unknown status %s", ErrInvalidCoupon, status)
+	}
+	if limit <= 0 {
+		limit = defaultCouponListLimit
+	}
+	if limit > maxCouponListLimit {
+		limit = maxCouponListLimit
+	}
+
+	coupons, err := s.repo.ListCoupons(ctx, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coupons: %w", err)
+	}
+	return coupons, nil
+}
+
+// Void withdraws an active coupon; its past redemptions stand
+func (s *couponService) Void(ctx context.Context, id uuid.UUID, actor, reason string) (*models.Coupon, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a reason is required", ErrInvalidCoupon)
+	}
+
+	coupon, err := s.repo.VoidCoupon(ctx, id, actor, reason, time.Now().UTC())
+	params := map[string]string{"coupon_id": id.String()}
+	if err := s.audited(ctx, couponVoidAction, actor, reason, params, err); err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// Quote returns the discount a coupon would give an amount, rounded to its
+// currency
+func (s *couponService) Quote(ctx context.Context, application CouponApplication) (*models.CouponQuote, error) {
+	quote, coupon, err := s.quote(ctx, application)
+	if err != nil {
+		return nil, err
+	}
+	if coupon.MaxRedemptions != nil && coupon.Redemptions >= *coupon.MaxRedemptions {
+		return nil, fmt.Errorf("%w: redemption limit reached", ErrCouponNotRedeemable)
+	}
+	return quote, nil
+}
+
+// quote prices an application without checking the coupon's redemption
+// limits, which redemptions check as they are recorded so that retries of
+// a coupon's last redemption succeed
+func (s *couponService) quote(ctx context.Context, application CouponApplication) (*models.CouponQuote, *models.Coupon, error) {
+	coupon, application, err := s.applicable(ctx, application)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	discount := s.currencies.Round(application.Currency, coupon.Discount(application.Amount))
+	return &models.CouponQuote{
+		CouponID: coupon.ID,
+		Code:     coupon.Code,
+		Currency: application.Currency,
+		Amount:   application.Amount,
+		Discount: discount,
+		Total:    application.Amount.Sub(discount),
+	}, coupon, nil
+}
+
+// Redeem applies a coupon to a rated charge or an invoice, within the
+// coupon's redemption limits
+func (s *couponService) Redeem(ctx context.Context, application CouponApplication, actor string) (*models.CouponRedemption, error) {
+	application.Reference = strings.TrimSpace(application.Reference)
+	if application.Source != models.RedemptionRating && application.Source != models.RedemptionInvoice {
+		return nil, fmt.Errorf("%w: source must be %s or %s", ErrInvalidCoupon, models.RedemptionRating, models.RedemptionInvoice)
+	}
+	if application.Reference == "" || len(application.Reference) > maxRedemptionReference {
+		return nil, fmt.Errorf("%w: reference must be 1 to %d characters", ErrInvalidCoupon, maxRedemptionReference)
+	}
+
+	quote, _, err := s.quote(ctx, application)
+	if err != nil {
+		return nil, err
+	}
+
+	redemption, created, err := s.repo.RedeemCoupon(ctx, &models.CouponRedemption{
+		ID:         uuid.New(),
+		CouponID:   quote.CouponID,
+		Code:       quote.Code,
+		CustomerID: application.CustomerID,
+		Source:     application.Source,
+		Reference:  application.Reference,
+		Currency:   quote.Currency,
+		Amount:     quote.Amount,
+		Discount:   quote.Discount,
+		RedeemedBy: actor,
+		CreatedAt:  time.Now().UTC(),
+	})
+	switch {
+	case errors.Is(err, repository.ErrCouponNotFound):
+		return nil, ErrCouponNotFound
+	case errors.Is(err, repository.ErrCouponConflict):
+		return nil, fmt.Errorf("%w: coupon is voided", ErrCouponNotRedeemable)
+	case errors.Is(err, repository.ErrCouponExhausted):
+		return nil, fmt.Errorf("%w: redemption limit reached", ErrCouponNotRedeemable)
+	case err != nil:
+		s.logger.Error("failed to redeem coupon", err, "couponID", quote.CouponID, "reference", application.Reference)
+		return nil, fmt.Errorf("failed to redeem coupon: %w", err)
+	}
+	if !created {
+		return redemption, nil
+	}
+
+	params := map[string]string{
+		"coupon_id":     redemption.CouponID.String(),
+		"redemption_id": redemption.ID.String(),
+		"customer_id":   redemption.CustomerID.String(),
+		"source":        string(redemption.Source),
+		"reference":     redemption.Reference,
+		"currency":      redemption.Currency,
+		"discount":      redemption.Discount.String(),
+	}
+	reason := fmt.Sprintf("applied to %s %s", strings.ToLower(string(redemption.Source)), redemption.Reference)
+	if err := s.audited(ctx, couponRedeemAction, actor, reason, params, nil); err != nil {
+		return nil, err
+	}
+	return redemption, nil
+}
+
+// applicable returns the coupon an application names once it is checked to
+// apply, with the application normalized
+func (s *couponService) applicable(ctx context.Context, application CouponApplication) (*models.Coupon, CouponApplication, error) {
+	application.Code = strings.ToUpper(strings.TrimSpace(application.Code))
+	application.Currency = strings.ToUpper(strings.TrimSpace(application.Currency))
+	if !s.currencies.Supported(application.Currency) {
+		return nil, application, ErrUnsupportedCurrency
+	}
+	if !application.Amount.IsPositive() || application.Amount.GreaterThan(decimal.NewFromFloat(models.MaxTransactionAmount)) {
+		return nil, application, ErrInvalidAmount
+	}
+	application.Amount = s.currencies.Round(application.Currency, application.Amount)
+	if application.At.IsZero() {
+		application.At = time.Now().UTC()
+	}
+
+	coupon, err := s.repo.GetCouponByCode(ctx, application.Code)
+	if errors.Is(err, repository.ErrCouponNotFound) {
+		return nil, application, ErrCouponNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to get coupon", err, "code", application.Code)
+		return nil, application, fmt.Errorf("failed to get coupon: %w", err)
+	}
+
+	switch {
+	case coupon.Status != models.CouponActive:
+		return nil, application, fmt.Errorf("%w: coupon is voided", ErrCouponNotRedeemable)
+	case !coupon.ValidAt(application.At):
+		return nil, application, fmt.Errorf("%w: coupon is not valid at %s", ErrCouponNotRedeemable, application.At.Format(time.RFC3339))
+	case coupon.CustomerID != nil && *coupon.CustomerID != application.CustomerID:
+		return nil, application, fmt.Errorf("%w: coupon is for another customer", ErrCouponNotRedeemable)
+	case coupon.Currency != "" && coupon.Currency != application.Currency:
+		return nil, application, fmt.Errorf("%w: coupon applies to %s amounts", ErrCouponNotRedeemable, coupon.Currency)
+	}
+	return coupon, application, nil
+}
+
+// validate checks a coupon before it is stored
+func (s *couponService) validate(coupon *models.Coupon, reason string) error {
+	switch {
+	case strings.TrimSpace(reason) == "":
+		return fmt.Errorf("%w: a reason is required", ErrInvalidCoupon)
+	case len(coupon.Code) > maxCouponCodeLength || !couponCodePattern.MatchString(coupon.Code):
+		return fmt.Errorf("%w: code must be up to %d letters, digits, dashes or underscores", ErrInvalidCoupon, maxCouponCodeLength)
+	case !coupon.Type.Valid():
+		return fmt.Errorf("%w: discount type must be %s or %s", ErrInvalidCoupon, models.DiscountPercentage, models.DiscountFixed)
+	case !coupon.Value.IsPositive():
+		return fmt.Errorf("%w: value must be positive", ErrInvalidCoupon)
+	case coupon.Type == models.DiscountPercentage && coupon.Value.GreaterThan(decimal.NewFromInt(100)):
+		return fmt.Errorf("%w: percentage must not exceed 100", ErrInvalidCoupon)
+	case coupon.Type == models.DiscountPercentage && coupon.Currency != "":
+		return fmt.Errorf("%w: percentage coupons apply to any currency", ErrInvalidCoupon)
+	case coupon.Type == models.DiscountFixed && !s.currencies.Supported(coupon.Currency):
+		return fmt.Errorf("%w: fixed coupons need a supported currency", ErrInvalidCoupon)
+	case coupon.ValidUntil != nil && !coupon.ValidUntil.After(coupon.ValidFrom):
+		return fmt.Errorf("%w: a coupon must expire after it becomes valid", ErrInvalidCoupon)
+	case coupon.MaxRedemptions != nil && *coupon.MaxRedemptions <= 0,
+		coupon.MaxRedemptionsPerCustomer != nil && *coupon.MaxRedemptionsPerCustomer <= 0:
+		return fmt.Errorf("%w: redemption limits must be positive", ErrInvalidCoupon)
+	}
+	return nil
+}
+
+// audited records a coupon change or redemption in the operator audit log
+// regardless of whether it succeeded, returning the change's error mapped to
+// the service's
+func (s *couponService) audited(ctx context.Context, action, actor, reason string, params map[string]string, err error) error {
+	switch {
+	case err == nil:
+	case errors.Is(err, repository.ErrCouponNotFound):
+		err = ErrCouponNotFound
+	case errors.Is(err, repository.ErrCustomerNotFound):
+		err = ErrCustomerNotFound
+	case errors.Is(err, repository.ErrCouponConflict):
+		err = ErrCouponConflict
+	default:
+		s.logger.Error("failed to change coupon", err, "action", action, "couponID", params["coupon_id"])
+		err = fmt.Errorf("failed to change coupon: %w", err)
+	}
+
+	entry := &models.OperatorAction{
+		Action: action,
+		Actor:  actor,
+		Reason: reason,
+		Params: params,
+		Status: models.OperatorActionSucceeded,
+	}
+	if err != nil {
+		entry.Status = models.OperatorActionFailed
+		entry.Error = err.Error()
+	}
+
+	if auditErr := s.audit.RecordOperatorAction(ctx, entry); auditErr != nil {
+		s.logger.Error("failed to audit coupon change", auditErr, "action", action, "couponID", params["coupon_id"])
+		if err == nil {
+			err = fmt.Errorf("%s executed but audit record failed: %w", action, auditErr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/currency"
+	"internal/models"
+)
+
+// CustomerBalanceService defines the interface for the wallets of a customer
+// taken together
+type CustomerBalanceService interface {
+	// ListWallets lists every wallet of a customer, oldest first
+	ListWallets(ctx context.Context, customerID uuid.UUID) ([]*models.Wallet, error)
+	// GetBalance returns the balance of each wallet of a customer and their
+	// total in a reporting currency, the configured one when empty
+	GetBalance(ctx context.Context, customerID uuid.UUID, reportingCurrency string) (*models.CustomerBalance, error)
+}
+
+// customerBalanceService implements CustomerBalanceService interface
+type customerBalanceService struct {
+	wallets    WalletService
+	currencies *currency.Registry
+	rates      *currency.RateTable
+	logger     Logger
+}
+
+// NewCustomerBalanceService creates a new instance of CustomerBalanceService
+// converting balances at rates, whose base is the default reporting currency
+func NewCustomerBalanceService(wallets WalletService, currencies *currency.Registry, rates *currency.RateTable, logger Logger) (CustomerBalanceService, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currency registry is required")
+	}
+	if rates == nil {
+		return nil, errors.New("exchange rates are required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if !currencies.Supported(rates.Base()) {
+		return nil, fmt.Errorf("reporting currency %s is not supported", rates.Base())
+	}
+
+	return &customerBalanceService{
+		wallets:    wallets,
+		currencies: currencies,
+		rates:      rates,
+		logger:     logger,
+	}, nil
+}
+
+// ListWallets retrieves the wallets of a customer
+func (s *customerBalanceService) ListWallets(ctx context.Context, customerID uuid.UUID) ([]*models.Wallet, error) {
+	return s.wallets.ListCustomerWallets(ctx, customerID)
+}
+
+// GetBalance converts the balance of each wallet at the configured rates and
+// sums them. Wallets in a currency without a rate are listed unconverted and
+// named in UnconvertedCurrencies instead of failing the whole request.
+func (s *customerBalanceService) GetBalance(ctx context.Context, customerID uuid.UUID, reportingCurrency string) (*models.CustomerBalance, error) {
+	if reportingCurrency == "" {
+		reportingCurrency = s.rates.Base()
+	}
+	if !s.currencies.Supported(reportingCurrency) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, reportingCurrency)
+	}
+	if _, err := s.rates.Rate(reportingCurrency, s.rates.Base()); err != nil {
+		return nil, fmt.Errorf("%w: %s has no exchange rate", ErrUnsupportedCurrency, reportingCurrency)
+	}
+
+	wallets, err := s.wallets.ListCustomerWallets(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &models.CustomerBalance{
+		CustomerID:        customerID,
+		ReportingCurrency: reportingCurrency,
+		Total:             decimal.Zero,
+		Wallets:           make([]*models.CustomerWalletBalance, 0, len(wallets)),
+		AsOf:              time.Now().UTC(),
+	}
+	unconverted := make(map[string]bool)
+	for _, wallet := range wallets {
+		entry := &models.CustomerWalletBalance{
+			WalletID:         wallet.ID,
+			Currency:         wallet.Currency,
+			Balance:          s.currencies.Round(wallet.Currency, decimal.NewFromFloat(wallet.Balance)),
+			AvailableBalance: s.currencies.Round(wallet.Currency, decimal.NewFromFloat(wallet.AvailableBalance())),
+		}
+		balance.Wallets = append(balance.Wallets, entry)
+
+		rate, err := s.rates.Rate(wallet.Currency, reportingCurrency)
+		if err == nil {
+			var converted decimal.Decimal
+			converted, err = s.currencies.Convert(entry.Balance, wallet.Currency, reportingCurrency, rate)
+			if err == nil {
+				entry.Rate = &rate
+				entry.Converted = &converted
+				balance.Total = balance.Total.Add(converted)
+				continue
+			}
+		}
+
+		if !unconverted[wallet.Currency] {
+			unconverted[wallet.Currency] = true
+			balance.UnconvertedCurrencies = append(balance.UnconvertedCurrencies, wallet.Currency)
+			s.logger.Warn("wallet balance left out of customer total",
+				"customerID", customerID,
+				"currency", wallet.Currency,
+				"reportingCurrency", reportingCurrency,
+				"error", err)
+		}
+	}
+	sort.Strings(balance.UnconvertedCurrencies)
+
+	return balance, nil
+}
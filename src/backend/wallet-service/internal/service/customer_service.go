@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+	"internal/repository"
+)
+
+// Customer settings errors
+var (
+	ErrCustomerNotFound = errors.New("customer not found")
+	ErrInvalidTimezone  = errors.New("invalid timezone")
+)
+
+// CustomerService defines the interface for customer settings
+type CustomerService interface {
+	GetSettings(ctx context.Context, customerID uuid.UUID) (*models.CustomerSettings, error)
+	UpdateTimezone(ctx context.Context, customerID uuid.UUID, timezone string) (*models.CustomerSettings, error)
+}
+
+// customerService implements CustomerService interface
+type customerService struct {
+	repo   repository.CustomerRepository
+	logger Logger
+}
+
+// NewCustomerService creates a new instance of CustomerService
+func NewCustomerService(repo repository.CustomerRepository, logger Logger) (CustomerService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &customerService{
+		repo:   repo,
+		logger: logger,
+	}, nil
+}
+
+// GetSettings returns the customer's settings
+func (s *customerService) GetSettings(ctx context.Context, customerID uuid.UUID) (*models.CustomerSettings, error) {
+	settings, err := s.repo.GetSettings(ctx, customerID)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to get customer settings", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to get customer settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// UpdateTimezone sets the timezone the customer's digest periods and daily
+// activity aggregates are cut off in. Periods already sent are not resent.
+func (s *customerService) UpdateTimezone(ctx context.Context, customerID uuid.UUID, timezone string) (*models.CustomerSettings, error) {
+	if _, err := loadTimezone(timezone); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.repo.UpdateTimezone(ctx, customerID, timezone)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to update customer timezone", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to update customer timezone: %w", err)
+	}
+
+	s.logger.Info("customer timezone updated",
+		"customerID", customerID,
+		"timezone", timezone)
+
+	return settings, nil
+}
+
+// loadTimezone resolves an IANA timezone name. The server's local zone is
+// rejected because the database would interpret it differently.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTimezone, err)
+	}
+
+	return loc, nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/models"
+	"internal/repository"
+)
+
+// ErrInvalidDataAccessFilter is returned for access log filters with an
+// unknown action or an empty time range
+var ErrInvalidDataAccessFilter = errors.New("invalid data access filter")
+
+// Data access outcomes
+const (
+	dataAccessStored  = "stored"
+	dataAccessDropped = "dropped"
+	dataAccessFailed  = "failed"
+)
+
+// dataAccesses counts audited accesses to customers' financial data by
+// action and what became of them
+var dataAccesses = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_data_accesses_total",
+		Help: "Audited accesses to customer balances and transactions, by action and outcome",
+	},
+	[]string{"action", "outcome"},
+)
+
+// DataAccessService defines the interface for the customer-visible log of
+// accesses to their balances and transactions
+type DataAccessService interface {
+	Record(access *models.DataAccess)
+	Flush(ctx context.Context) error
+	RunFlusher(ctx context.Context, interval time.Duration)
+	ListAccesses(ctx context.Context, customerID uuid.UUID, filter models.DataAccessFilter, pagination Pagination) ([]*models.CustomerDataAccess, error)
+}
+
+// dataAccessService implements DataAccessService interface. Accesses are
+// held in memory and stored by the flusher, so requests never wait on the
+// audit store.
+type dataAccessService struct {
+	repo       repository.DataAccessRepository
+	maxPending int
+	logger     Logger
+
+	mu      sync.Mutex
+	pending []*models.DataAccess
+}
+
+// NewDataAccessService creates a new instance of DataAccessService holding
+// at most maxPending accesses between flushes
+func NewDataAccessService(repo repository.DataAccessRepository, maxPending int, logger Logger) (DataAccessService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if maxPending <= 0 {
+		return nil, errors.New("max pending accesses must be positive")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &dataAccessService{
+		repo:       repo,
+		maxPending: maxPending,
+		logger:     logger,
+	}, nil
+}
+
+// Record holds an access until the next flush. Accesses beyond the pending
+// limit are dropped and counted.
+func (s *dataAccessService) Record(access *models.DataAccess) {
+	if access.ID == uuid.Nil {
+		access.ID = uuid.New()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) >= s.maxPending {
+		dataAccesses.WithLabelValues(string(access.Action), dataAccessDropped).Inc()
+		return
+	}
+	s.pending = append(s.pending, access)
+}
+
+// Flush stores the accesses recorded since the last flush. Accesses that
+// fail to store are kept for the next flush, room permitting.
+func (s *dataAccessService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	var failed []*models.DataAccess
+	var lastErr error
+	for _, access := range pending {
+		if err := s.repo.RecordDataAccess(ctx, access); err != nil {
+			dataAccesses.WithLabelValues(string(access.Action), dataAccessFailed).Inc()
+			failed = append(failed, access)
+			lastErr = err
+			continue
+		}
+		dataAccesses.WithLabelValues(string(access.Action), dataAccessStored).Inc()
+	}
+	if lastErr == nil {
+		return nil
+	}
+
+	s.logger.Error("failed to store data accesses", lastErr, "failed", len(failed))
+	s.requeue(failed)
+	return fmt.Errorf("failed to flush data accesses: %w", lastErr)
+}
+
+// requeue returns accesses that failed to store ahead of those recorded
+// since, dropping the newest beyond the pending limit
+func (s *dataAccessService) requeue(failed []*models.DataAccess) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := append(failed, s.pending...)
+	if len(pending) > s.maxPending {
+		for _, access := range pending[s.maxPending:] {
+			dataAccesses.WithLabelValues(string(access.Action), dataAccessDropped).Inc()
+		}
+		pending = pending[:s.maxPending]
+	}
+	s.pending = pending
+}
+
+// RunFlusher flushes the recorded accesses at the given interval, and once
+// more when the context is cancelled
+func (s *dataAccessService) RunFlusher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			_ = s.Flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			// Failures are logged by Flush and retried on the next tick
+			_ = s.Flush(ctx)
+		}
+	}
+}
+
+// ListAccesses returns a customer's access log, newest first. Staff
+// identities, roles and request details stay internal; the subject is shown
+// only for the customer's own API keys.
+func (s *dataAccessService) ListAccesses(ctx context.Context, customerID uuid.UUID, filter models.DataAccessFilter, pagination Pagination) ([]*models.CustomerDataAccess, error) {
+	if filter.Action != "" && !filter.Action.IsValid() {
+		return nil, fmt.Errorf("%w: unknown action %s", ErrInvalidDataAccessFilter, filter.Action)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidDataAccessFilter)
+	}
+
+	accesses, err := s.repo.ListDataAccesses(ctx, customerID, filter, pagination.Limit, pagination.Offset)
+	if err != nil {
+		s.logger.Error("failed to list data accesses", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to list data accesses: %w", err)
+	}
+
+	entries := make([]*models.CustomerDataAccess, 0, len(accesses))
+	for _, access := range accesses {
+		entry := &models.CustomerDataAccess{
+			ID:           access.ID,
+			Action:       access.Action,
+			AccessorKind: access.AccessorKind,
+			WalletID:     access.WalletID,
+			AccessedAt:   access.AccessedAt,
+		}
+		if access.AccessorKind == models.DataAccessorAPIKey &&
+			access.AccessorCustomerID != nil && *access.AccessorCustomerID == customerID {
+			entry.Accessor = access.Subject
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
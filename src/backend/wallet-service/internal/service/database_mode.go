@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/eventbus"
+	"internal/events"
+	"internal/repository"
+)
+
+// Database mode metrics
+var (
+	databaseReadOnly = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "wallet_database_read_only",
+			Help: "Whether the service is in read-only mode as the primary database refuses writes",
+		},
+	)
+	databaseModeChanges = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_database_mode_changes_total",
+			Help: "Switches between the read-write and read-only database modes, by the mode entered",
+		},
+		[]string{"mode"},
+	)
+)
+
+// ErrDatabaseReadOnly is returned for writes refused while the primary
+// database is read-only
+var ErrDatabaseReadOnly = errors.New("database is read-only")
+
+// DatabaseMode tracks whether the primary database accepts writes. The
+// service enters read-only mode as soon as a write is refused for it, and
+// leaves it once a check finds the database writable again.
+type DatabaseMode interface {
+	// ReadOnly reports whether writes are currently refused
+	ReadOnly() bool
+	// Observe enters read-only mode when err is the database refusing a
+	// write because it is read-only
+	Observe(ctx context.Context, err error)
+	// Check reads the mode of the database, switching to it when it changed,
+	// and returns ErrDatabaseReadOnly while writes are refused
+	Check(ctx context.Context) error
+}
+
+// databaseMode implements DatabaseMode interface
+type databaseMode struct {
+	repo      repository.DatabaseModeRepository
+	publisher eventbus.Publisher
+	logger    Logger
+
+	mu       sync.RWMutex
+	readOnly bool
+}
+
+// NewDatabaseMode creates a new instance of DatabaseMode, starting in
+// read-write mode. Mode changes are published as events.
+func NewDatabaseMode(repo repository.DatabaseModeRepository, publisher eventbus.Publisher, logger Logger) (DatabaseMode, error) {
+	if repo == nil {
+		return nil, errors.New("database mode repository is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("event publisher is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &databaseMode{repo: repo, publisher: publisher, logger: logger}, nil
+}
+
+// ReadOnly reports whether writes are currently refused
+func (m *databaseMode) ReadOnly() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.readOnly
+}
+
+// Observe enters read-only mode on a refused write
+func (m *databaseMode) Observe(ctx context.Context, err error) {
+	if repository.IsReadOnly(err) {
+		m.switchMode(ctx, true, "write refused by the database")
+	}
+}
+
+// Check reads the mode of the database. A failed read leaves the mode as it
+// is, as an unreachable database is reported by its own probe.
+func (m *databaseMode) Check(ctx context.Context) error {
+	readOnly, err := m.repo.ReadOnly(ctx)
+	if err != nil {
+		return err
+	}
+
+	reason := "database accepts writes"
+	if readOnly {
+		reason = "database is read-only"
+	}
+	m.switchMode(ctx, readOnly, reason)
+
+	if readOnly {
+		return ErrDatabaseReadOnly
+	}
+	return nil
+}
+
+// switchMode enters the mode, publishing the change unless already in it
+func (m *databaseMode) switchMode(ctx context.Context, readOnly bool, reason string) {
+	m.mu.Lock()
+	if m.readOnly == readOnly {
+		m.mu.Unlock()
+		return
+	}
+	m.readOnly = readOnly
+	m.mu.Unlock()
+
+	mode := events.DatabaseModeReadWrite
+	if readOnly {
+		mode = events.DatabaseModeReadOnly
+		databaseReadOnly.Set(1)
+		m.logger.Warn("database is read-only, refusing writes", "reason", reason)
+	} else {
+		databaseReadOnly.Set(0)
+		m.logger.Info("database accepts writes again", "reason", reason)
+	}
+	databaseModeChanges.WithLabelValues(mode).Inc()
+
+	event := eventbus.DatabaseModeChanged{ReadOnly: readOnly, Reason: reason, ChangedAt: time.Now().UTC()}
+	if err := m.publisher.Publish(ctx, event); err != nil {
+		m.logger.Error("failed to publish database mode change", err, "mode", mode)
+	}
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+	"github.com/shopspring/decimal"                           // v1.3.1
+
+	"internal/models"
+	"internal/repository"
+)
+
+// Verification metrics, so drift can be graphed and alerted on across replicas
+var (
+	decimalChecks = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_decimal_verification_checks_total",
+			Help: "Amounts compared between their float64 and exact decimal readings, by entity",
+		},
+		[]string{"entity"},
+	)
+	decimalMismatches = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_decimal_verification_mismatches_total",
+			Help: "Amounts whose float64 reading drifted beyond the tolerance, by entity and field",
+		},
+		[]string{"entity", "field"},
+	)
+)
+
+// DecimalVerificationOptions configure the dual-read verification of the
+// float to decimal migration
+type DecimalVerificationOptions struct {
+	// SampleRate is the fraction of reads, from 0 to 1, that are verified
+	SampleRate float64
+	// Tolerance is the largest drift not recorded as a mismatch
+	Tolerance float64
+	// AlertDrift is the drift of a single value that raises an alert
+	AlertDrift float64
+	// AlertMismatches is the number of mismatches within AlertWindow that
+	// raises an alert
+	AlertMismatches int
+	AlertWindow     time.Duration
+}
+
+// decimalVerifyingRepository is a WalletRepository that re-reads a sample of
+// the amounts it returns as exact decimals and records those whose float64
+// value drifted. Reads never fail because of the verification.
+type decimalVerifyingRepository struct {
+	repository.WalletRepository
+	verification repository.DecimalVerificationRepository
+	opts         DecimalVerificationOptions
+	tolerance    decimal.Decimal
+	alertDrift   decimal.Decimal
+	logger       Logger
+
+	mu          sync.Mutex
+	windowStart time.Time
+	windowCount int
+	alerted     bool
+}
+
+// NewDecimalVerifyingRepository wraps repo so that its wallet and transaction
+// reads are verified against the exact decimal columns
+func NewDecimalVerifyingRepository(repo repository.WalletRepository, verification repository.DecimalVerificationRepository, opts DecimalVerificationOptions, logger Logger) (repository.WalletRepository, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if verification == nil {
+		return nil, errors.New("verification repository is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if opts.SampleRate < 0 || opts.SampleRate > 1 {
+		return nil, errors.New("sample rate must be between 0 and 1")
+	}
+	if opts.Tolerance < 0 || opts.AlertDrift < opts.Tolerance {
+		return nil, errors.New("alert drift must not be below a non-negative tolerance")
+	}
+	if opts.AlertMismatches <= 0 || opts.AlertWindow <= 0 {
+		return nil, errors.New("alert mismatches and window must be positive")
+	}
+
+	return &decimalVerifyingRepository{
+		WalletRepository: repo,
+		verification:     verification,
+		opts:             opts,
+		tolerance:        decimal.NewFromFloat(opts.Tolerance),
+		alertDrift:       decimal.NewFromFloat(opts.AlertDrift),
+		logger:           logger,
+	}, nil
+}
+
+// GetWallet retrieves a wallet and verifies its amounts when sampled
+func (r *decimalVerifyingRepository) GetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	wallet, err := r.WalletRepository.GetWallet(ctx, id)
+	if err == nil {
+		r.verifyWallets(ctx, []*models.Wallet{wallet})
+	}
+	return wallet, err
+}
+
+// GetCustomerWallets retrieves a customer's wallets and verifies their
+// amounts when sampled
+func (r *decimalVerifyingRepository) GetCustomerWallets(ctx context.Context, customerID uuid.UUID) ([]*models.Wallet, error) {
+	wallets, err := r.WalletRepository.GetCustomerWallets(ctx, customerID)
+	if err == nil {
+		r.verifyWallets(ctx, wallets)
+	}
+	return wallets, err
+}
+
+// GetWalletOverview retrieves a wallet with its newest transactions and
+// verifies their amounts when sampled
+func (r *decimalVerifyingRepository) GetWalletOverview(ctx context.Context, walletID uuid.UUID, limit int) (*models.WalletOverview, error) {
+	overview, err := r.WalletRepository.GetWalletOverview(ctx, walletID, limit)
+	if err == nil {
+		r.verifyWallets(ctx, []*models.Wallet{overview.Wallet})
+		r.verifyTransactions(ctx, overview.Transactions)
+	}
+	return overview, err
+}
+
+// GetTransactions retrieves a page of transactions and verifies their
+// amounts when sampled
+func (r *decimalVerifyingRepository) GetTransactions(ctx context.Context, walletID uuid.UUID, limit, offset int) ([]*models.Transaction, error) {
+	txs, err := r.WalletRepository.GetTransactions(ctx, walletID, limit, offset)
+	if err == nil {
+		r.verifyTransactions(ctx, txs)
+	}
+	return txs, err
+}
+
+// FindTransactions retrieves a page of matching transactions and verifies
+// their amounts when sampled
+func (r *decimalVerifyingRepository) FindTransactions(ctx context.Context, walletID uuid.UUID, filter repository.TransactionFilter, limit, offset int) ([]*models.Transaction, int, error) {
+	txs, total, err := r.WalletRepository.FindTransactions(ctx, walletID, filter, limit, offset)
+	if err == nil {
+		r.verifyTransactions(ctx, txs)
+	}
+	return txs, total, err
+}
+
+// GetTransactionByID retrieves a transaction and verifies its amount when
+// sampled
+func (r *decimalVerifyingRepository) GetTransactionByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
+	tx, err := r.WalletRepository.GetTransactionByID(ctx, id)
+	if err == nil {
+		r.verifyTransactions(ctx, []*models.Transaction{tx})
+	}
+	return tx, err
+}
+
+// GetRecentTransactions retrieves the newest transactions of several wallets
+// and verifies their amounts when sampled
+func (r *decimalVerifyingRepository) GetRecentTransactions(ctx context.Context, walletIDs []uuid.UUID, limit int) ([]*models.Transaction, error) {
+	txs, err := r.WalletRepository.GetRecentTransactions(ctx, walletIDs, limit)
+	if err == nil {
+		r.verifyTransactions(ctx, txs)
+	}
+	return txs, err
+}
+
+// sampled reports whether a read is verified
+func (r *decimalVerifyingRepository) sampled() bool {
+	return r.opts.SampleRate > 0 && rand.Float64() < r.opts.SampleRate
+}
+
+// verifyWallets compares the balance, credit limit and low balance threshold
+// of wallets with their exact readings
+func (r *decimalVerifyingRepository) verifyWallets(ctx context.Context, wallets []*models.Wallet) {
+	if len(wallets) == 0 || !r.sampled() {
+		return
+	}
+
+	ids := make([]uuid.UUID, len(wallets))
+	for i, w := range wallets {
+		ids[i] = w.ID
+	}
+
+	exact, err := r.verification.GetExactWalletValues(ctx, ids)
+	if err != nil {
+		r.logger.Error("failed to read exact wallet values", err)
+		return
+	}
+
+	for _, w := range wallets {
+		values, ok := exact[w.ID]
+		if !ok {
+			continue
+		}
+		decimalChecks.WithLabelValues(models.DecimalEntityWallet).Add(3)
+		r.compare(ctx, models.DecimalEntityWallet, w.ID, "balance", w.Balance, values.Balance)
+		r.compare(ctx, models.DecimalEntityWallet, w.ID, "credit_limit", w.CreditLimit, values.CreditLimit)
+		r.compare(ctx, models.DecimalEntityWallet, w.ID, "low_balance_threshold", w.LowBalanceThreshold, values.LowBalanceThreshold)
+	}
+}
+
+// verifyTransactions compares the amounts of transactions with their exact
+// readings
+func (r *decimalVerifyingRepository) verifyTransactions(ctx context.Context, txs []*models.Transaction) {
+	if len(txs) == 0 || !r.sampled() {
+		return
+	}
+
+	ids := make([]uuid.UUID, len(txs))
+	for i, tx := range txs {
+		ids[i] = tx.ID
+	}
+
+	exact, err := r.verification.GetExactTransactionAmounts(ctx, ids)
+	if err != nil {
+		r.logger.Error("failed to read exact transaction amounts", err)
+		return
+	}
+
+	for _, tx := range txs {
+		amount, ok := exact[tx.ID]
+		if !ok {
+			continue
+		}
+		decimalChecks.WithLabelValues(models.DecimalEntityTransaction).Inc()
+		r.compare(ctx, models.DecimalEntityTransaction, tx.ID, "amount", tx.Amount, amount)
+	}
+}
+
+// compare records a mismatch when the float value drifts beyond the tolerance
+// and alerts when the drift or the recent mismatch count exceed thresholds
+func (r *decimalVerifyingRepository) compare(ctx context.Context, entity string, id uuid.UUID, field string, floatValue float64, exact decimal.Decimal) {
+	drift := decimal.NewFromFloat(floatValue).Sub(exact).Abs()
+	if drift.LessThanOrEqual(r.tolerance) {
+		return
+	}
+
+	mismatch := &models.DecimalMismatch{
+		Entity:       entity,
+		EntityID:     id,
+		Field:        field,
+		FloatValue:   floatValue,
+		DecimalValue: exact,
+		Drift:        drift,
+		DetectedAt:   time.Now().UTC(),
+	}
+	if err := r.verification.RecordMismatch(ctx, mismatch); err != nil {
+		r.logger.Error("failed to record decimal mismatch", err, "entity", entity, "entityID", id, "field", field)
+	}
+	decimalMismatches.WithLabelValues(entity, field).Inc()
+
+	if drift.GreaterThan(r.alertDrift) {
+		r.logger.Error("decimal drift exceeds alert threshold", nil,
+			"entity", entity,
+			"entityID", id,
+			"field", field,
+			"floatValue", floatValue,
+			"decimalValue", exact.String(),
+			"drift", drift.String())
+	} else {
+		r.logger.Warn("decimal mismatch",
+			"entity", entity,
+			"entityID", id,
+			"field", field,
+			"drift", drift.String())
+	}
+
+	if count, alert := r.countMismatch(mismatch.DetectedAt); alert {
+		r.logger.Error("decimal mismatches exceed alert threshold", nil,
+			"mismatches", count,
+			"window", r.opts.AlertWindow)
+	}
+}
+
+// countMismatch counts a mismatch in the current alert window and reports
+// whether the window just reached the alert threshold, so each window alerts
+// at most once
+func (r *decimalVerifyingRepository) countMismatch(at time.Time) (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if at.Sub(r.windowStart) >= r.opts.AlertWindow {
+		r.windowStart = at
+		r.windowCount = 0
+		r.alerted = false
+	}
+	r.windowCount++
+
+	if r.alerted || r.windowCount < r.opts.AlertMismatches {
+		return r.windowCount, false
+	}
+	r.alerted = true
+	return r.windowCount, true
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/deprecation"
+	"internal/models"
+	"internal/repository"
+)
+
+// deprecatedRequests counts the calls to deprecated routes by route and
+// caller, for dashboards across replicas
+var deprecatedRequests = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_deprecated_route_requests_total",
+		Help: "Requests to deprecated API routes, by route and caller",
+	},
+	[]string{"route", "caller"},
+)
+
+// DeprecationService defines the interface for deprecated route usage
+// accounting
+type DeprecationService interface {
+	Record(def deprecation.Definition, caller string, at time.Time)
+	Flush(ctx context.Context) error
+	RunFlusher(ctx context.Context, interval time.Duration)
+	GetReport(ctx context.Context, since time.Time) (*models.DeprecationReport, error)
+}
+
+// deprecatedUsageKey identifies the use of a deprecated route by a caller
+type deprecatedUsageKey struct {
+	method string
+	route  string
+	caller string
+}
+
+// deprecationService implements DeprecationService interface. Like service
+// usage, calls are counted in memory and added to the stored totals by the
+// flusher.
+type deprecationService struct {
+	repo     repository.DeprecationRepository
+	registry *deprecation.Registry
+	logger   Logger
+
+	mu      sync.Mutex
+	pending map[deprecatedUsageKey]*models.DeprecatedRouteUsage
+}
+
+// NewDeprecationService creates a new instance of DeprecationService
+func NewDeprecationService(repo repository.DeprecationRepository, registry *deprecation.Registry, logger Logger) (DeprecationService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if registry == nil {
+		return nil, errors.New("deprecation registry is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &deprecationService{
+		repo:     repo,
+		registry: registry,
+		logger:   logger,
+		pending:  make(map[deprecatedUsageKey]*models.DeprecatedRouteUsage),
+	}, nil
+}
+
+// Record counts a call to a deprecated route
+func (s *deprecationService) Record(def deprecation.Definition, caller string, at time.Time) {
+	deprecatedRequests.WithLabelValues(def.Name, caller).Inc()
+
+	key := deprecatedUsageKey{method: def.Method, route: def.Route, caller: caller}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.pending[key]
+	if !ok {
+		u = &models.DeprecatedRouteUsage{
+			Method:    def.Method,
+			Route:     def.Route,
+			Caller:    caller,
+			FirstSeen: at,
+		}
+		s.pending[key] = u
+	}
+	u.Requests++
+	if at.Before(u.FirstSeen) {
+		u.FirstSeen = at
+	}
+	if at.After(u.LastSeen) {
+		u.LastSeen = at
+	}
+}
+
+// Flush adds the calls counted since the last flush to the stored totals.
+// Calls that fail to store are kept for the next flush.
+func (s *deprecationService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[deprecatedUsageKey]*models.DeprecatedRouteUsage)
+	s.mu.Unlock()
+
+	var failed error
+	for key, u := range pending {
+		if err := s.repo.AddUsage(ctx, u); err != nil {
+			s.logger.Error("failed to store deprecated route usage", err, "caller", u.Caller, "route", u.Route)
+			failed = err
+			s.requeue(key, u)
+		}
+	}
+	if failed != nil {
+		return fmt.Errorf("failed to flush deprecated route usage: %w", failed)
+	}
+
+	return nil
+}
+
+// requeue returns usage that failed to store to the pending totals
+func (s *deprecationService) requeue(key deprecatedUsageKey, u *models.DeprecatedRouteUsage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.pending[key]
+	if !ok {
+		s.pending[key] = u
+		return
+	}
+	existing.Requests += u.Requests
+	if u.FirstSeen.Before(existing.FirstSeen) {
+		existing.FirstSeen = u.FirstSeen
+	}
+	if u.LastSeen.After(existing.LastSeen) {
+		existing.LastSeen = u.LastSeen
+	}
+}
+
+// RunFlusher flushes the counted calls at the given interval, and once more
+// when the context is cancelled
+func (s *deprecationService) RunFlusher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			_ = s.Flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			// Failures are logged by Flush and retried on the next tick
+			_ = s.Flush(ctx)
+		}
+	}
+}
+
+// GetReport returns the integrations that called deprecated routes since the
+// given time, those facing the earliest sunset first. Calls to routes no
+// longer deprecated are left out.
+func (s *deprecationService) GetReport(ctx context.Context, since time.Time) (*models.DeprecationReport, error) {
+	usages, err := s.repo.ListUsage(ctx, since)
+	if err != nil {
+		s.logger.Error("failed to list deprecated route usage", err)
+		return nil, fmt.Errorf("failed to list deprecated route usage: %w", err)
+	}
+
+	report := &models.DeprecationReport{
+		Since:        since,
+		Integrations: []*models.DeprecatedIntegration{},
+	}
+	byCaller := make(map[string]*models.DeprecatedIntegration)
+	for _, u := range usages {
+		def, ok := s.registry.Lookup(u.Method, u.Route)
+		if !ok {
+			continue
+		}
+
+		call := &models.DeprecatedRouteCall{
+			Name:       def.Name,
+			Method:     def.Method,
+			Route:      def.Route,
+			Requests:   u.Requests,
+			FirstSeen:  u.FirstSeen,
+			LastSeen:   u.LastSeen,
+			Deprecated: def.Deprecated,
+			Link:       def.Link,
+		}
+		if !def.Sunset.IsZero() {
+			sunset := def.Sunset
+			call.Sunset = &sunset
+		}
+
+		integration, ok := byCaller[u.Caller]
+		if !ok {
+			integration = &models.DeprecatedIntegration{Caller: u.Caller}
+			byCaller[u.Caller] = integration
+			report.Integrations = append(report.Integrations, integration)
+		}
+		integration.Routes = append(integration.Routes, call)
+		if u.LastSeen.After(integration.LastSeen) {
+			integration.LastSeen = u.LastSeen
+		}
+		if call.Sunset != nil && (integration.Sunset == nil || call.Sunset.Before(*integration.Sunset)) {
+			integration.Sunset = call.Sunset
+		}
+	}
+
+	sort.SliceStable(report.Integrations, func(i, j int) bool {
+		a, b := report.Integrations[i], report.Integrations[j]
+		switch {
+		case a.Sunset == nil || b.Sunset == nil:
+			if (a.Sunset == nil) != (b.Sunset == nil) {
+				return a.Sunset != nil
+			}
+		case !a.Sunset.Equal(*b.Sunset):
+			return a.Sunset.Before(*b.Sunset)
+		}
+		return a.Caller < b.Caller
+	})
+
+	return report, nil
+}
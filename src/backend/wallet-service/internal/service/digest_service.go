@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+)
+
+// Digest generation constants
+const (
+	// digestBatchSize bounds the subscriptions loaded per query
+	digestBatchSize = 100
+)
+
+// The zones furthest behind and ahead of UTC, which bound the periods that
+// may be due across all customer timezones at any moment
+var (
+	westernmostZone = time.FixedZone("UTC-12", -12*60*60)
+	easternmostZone = time.FixedZone("UTC+14", 14*60*60)
+)
+
+// ErrInvalidDigestFrequency is returned for unknown digest frequencies
+var ErrInvalidDigestFrequency = errors.New("invalid digest frequency")
+
+// DigestService defines the interface for wallet activity digests
+type DigestService interface {
+	GetSubscription(ctx context.Context, customerID uuid.UUID) (*models.DigestSubscription, error)
+	UpdateSubscription(ctx context.Context, customerID uuid.UUID, frequency models.DigestFrequency, enabled bool) (*models.DigestSubscription, error)
+	SendDue(ctx context.Context, now time.Time) (int, error)
+	RunScheduled(ctx context.Context, interval time.Duration)
+}
+
+// digestService implements DigestService interface
+type digestService struct {
+	repo      repository.DigestRepository
+	publisher eventbus.Publisher
+	logger    Logger
+}
+
+// NewDigestService creates a new instance of DigestService
+func NewDigestService(repo repository.DigestRepository, publisher eventbus.Publisher, logger Logger) (DigestService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &digestService{
+		repo:      repo,
+		publisher: publisher,
+		logger:    logger,
+	}, nil
+}
+
+// GetSubscription returns the customer's digest subscription. Customers that
+// never opted in get a disabled weekly subscription.
+func (s *digestService) GetSubscription(ctx context.Context, customerID uuid.UUID) (*models.DigestSubscription, error) {
+	sub, err := s.repo.GetSubscription(ctx, customerID)
+	if errors.Is(err, repository.ErrDigestSubscriptionNotFound) {
+		return &models.DigestSubscription{
+			CustomerID: customerID,
+			Frequency:  models.DigestWeekly,
+			Enabled:    false,
+		}, nil
+	}
+	if err != nil {
+		s.logger.Error("failed to get digest subscription", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to get digest subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+// UpdateSubscription opts a customer in or out of activity digests
+func (s *digestService) UpdateSubscription(ctx context.Context, customerID uuid.UUID, frequency models.DigestFrequency, enabled bool) (*models.DigestSubscription, error) {
+	if frequency != models.DigestWeekly && frequency != models.DigestMonthly {
+		return nil, ErrInvalidDigestFrequency
+	}
+
+	sub := &models.DigestSubscription{
+		CustomerID: customerID,
+		Frequency:  frequency,
+		Enabled:    enabled,
+	}
+	if err := s.repo.UpsertSubscription(ctx, sub); err != nil {
+		s.logger.Error("failed to update digest subscription", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to update digest subscription: %w", err)
+	}
+
+	s.logger.Info("digest subscription updated",
+		"customerID", customerID,
+		"frequency", frequency,
+		"enabled", enabled)
+
+	return sub, nil
+}
+
+// SendDue publishes the digest of the latest completed period to every
+// subscriber that has not received it yet and returns the number sent.
+// Periods end at midnight in each customer's timezone. Each period is sent
+// once, so the job may run often and on every replica.
+func (s *digestService) SendDue(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	for _, frequency := range []models.DigestFrequency{models.DigestWeekly, models.DigestMonthly} {
+		// Refresh the dates of every period that may be due in any timezone
+		from, _ := digestPeriod(frequency, now, westernmostZone)
+		_, to := digestPeriod(frequency, now, easternmostZone)
+
+		if err := s.repo.RefreshDailyActivity(ctx, from, to); err != nil {
+			s.logger.Error("failed to refresh activity aggregates", err, "frequency", frequency)
+			return sent, fmt.Errorf("failed to refresh activity aggregates: %w", err)
+		}
+
+		for {
+			subs, err := s.repo.ListDueSubscriptions(ctx, frequency, now, digestBatchSize)
+			if err != nil {
+				s.logger.Error("failed to list due digests", err, "frequency", frequency)
+				return sent, fmt.Errorf("failed to list due digests: %w", err)
+			}
+
+			batchSent := 0
+			for _, sub := range subs {
+				loc, err := loadTimezone(sub.Timezone)
+				if err != nil {
+					s.logger.Warn("invalid customer timezone, using UTC", "customerID", sub.CustomerID, "timezone", sub.Timezone)
+					loc = time.UTC
+				}
+
+				start, end := digestPeriod(frequency, now, loc)
+				if sub.LastPeriodEnd != nil && !sub.LastPeriodEnd.Before(end) {
+					// Already sent; the database and Go disagree on the zone rules
+					continue
+				}
+
+				if err := s.send(ctx, sub, start, end); err != nil {
+					s.logger.Error("failed to send activity digest", err, "customerID", sub.CustomerID)
+					continue
+				}
+				batchSent++
+			}
+			sent += batchSent
+
+			// Stop when done, or when a whole batch failed and would be reloaded
+			if len(subs) < digestBatchSize || batchSent == 0 {
+				break
+			}
+		}
+	}
+
+	if sent > 0 {
+		s.logger.Info("activity digests sent", "count", sent)
+	}
+
+	return sent, nil
+}
+
+// send builds and publishes one customer's digest and records it as sent
+func (s *digestService) send(ctx context.Context, sub *models.DigestSubscription, start, end time.Time) error {
+	wallets, err := s.repo.GetWalletActivity(ctx, sub.CustomerID, start, end)
+	if err != nil {
+		return err
+	}
+
+	err = s.publisher.Publish(ctx, eventbus.ActivityDigest{
+		Subscription: sub,
+		PeriodStart:  start,
+		PeriodEnd:    end,
+		Wallets:      wallets,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.repo.MarkSent(ctx, sub.CustomerID, end)
+}
+
+// RunScheduled sends due digests every interval until the context is cancelled
+func (s *digestService) RunScheduled(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// Failures are logged by SendDue and retried on the next tick
+			_, _ = s.SendDue(ctx, now)
+		}
+	}
+}
+
+// digestPeriod returns the latest completed period of a frequency: the
+// previous Monday-to-Monday week or the previous calendar month, in loc
+func digestPeriod(frequency models.DigestFrequency, now time.Time, loc *time.Location) (time.Time, time.Time) {
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	if frequency == models.DigestMonthly {
+		end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+		return end.AddDate(0, -1, 0), end
+	}
+
+	daysSinceMonday := (int(today.Weekday()) + 6) % 7
+	end := today.AddDate(0, 0, -daysSinceMonday)
+	return end.AddDate(0, 0, -7), end
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+)
+
+// Dispute limits
+const (
+	// defaultDisputeLimit and maxDisputeLimit bound the disputes listed
+	defaultDisputeLimit = 50
+	maxDisputeLimit     = 500
+	// maxProviderCaseID is the longest provider case ID a dispute can name
+	maxProviderCaseID = 128
+)
+
+// Dispute errors
+var (
+	ErrInvalidDispute  = errors.New("invalid dispute")
+	ErrDisputeNotFound = errors.New("dispute not found")
+	ErrDisputeConflict = errors.New("dispute is not in a state allowing this request")
+	// ErrDisputeNotAllowed is returned for disputes of transactions other
+	// than completed top-ups, or for more than their amount
+	ErrDisputeNotAllowed = errors.New("transaction cannot be disputed")
+)
+
+// DisputeRequest is a chargeback of a wallet top-up reported by the payment
+// provider
+type DisputeRequest struct {
+	WalletID      uuid.UUID
+	TransactionID uuid.UUID
+	// Amount is the disputed amount, defaulting to the whole top-up
+	Amount         decimal.Decimal
+	ReasonCode     models.DisputeReason
+	Note           string
+	ProviderCaseID string
+}
+
+// DisputeService defines the interface for handling chargebacks of
+// top-ups, from the hold placed when they are opened to their settlement
+type DisputeService interface {
+	Open(ctx context.Context, request DisputeRequest, actor string) (*models.Dispute, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.Dispute, error)
+	List(ctx context.Context, filter models.DisputeFilter) ([]*models.Dispute, error)
+	SubmitEvidence(ctx context.Context, id uuid.UUID, evidence, actor string) (*models.Dispute, error)
+	// Resolve settles a dispute with the provider's decision, WON releasing
+	// the hold and LOST reversing the held amount out of the wallet
+	Resolve(ctx context.Context, id uuid.UUID, outcome models.DisputeStatus, actor, note string) (*models.Dispute, error)
+}
+
+// disputeService implements DisputeService interface. Every state change is
+// announced with wallet.dispute_updated, notifying the customer.
+type disputeService struct {
+	repo      repository.DisputeRepository
+	wallets   WalletService
+	publisher eventbus.Publisher
+	logger    Logger
+}
+
+// NewDisputeService creates a new instance of DisputeService
+func NewDisputeService(repo repository.DisputeRepository, wallets WalletService, publisher eventbus.Publisher, logger Logger) (DisputeService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &disputeService{
+		repo:      repo,
+		wallets:   wallets,
+		publisher: publisher,
+		logger:    logger,
+	}, nil
+}
+
+// Open records a dispute of a completed top-up and holds the disputed
+// amount out of the balance, down to the wallet's credit limit, until the
+// dispute is resolved
+func (s *disputeService) Open(ctx context.Context, request DisputeRequest, actor string) (*models.Dispute, error) {
+	if !request.ReasonCode.IsValid() {
+		return nil, fmt.Errorf("%w: unknown reason code %q", ErrInvalidDispute, request.ReasonCode)
+	}
+	if request.Amount.IsNegative() || request.Amount.GreaterThan(decimal.NewFromFloat(models.MaxTransactionAmount)) {
+		return nil, fmt.Errorf("%w: amount must be positive and at most %.2f", ErrInvalidDispute, models.MaxTransactionAmount)
+	}
+	if strings.TrimSpace(request.Note) == "" {
+		return nil, fmt.Errorf("%w: a note is required", ErrInvalidDispute)
+	}
+	if len(request.ProviderCaseID) > maxProviderCaseID {
+		return nil, fmt.Errorf("%w: provider case ID must be at most %d characters", ErrInvalidDispute, maxProviderCaseID)
+	}
+
+	wallet, err := s.wallets.GetWallet(ctx, request.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	dispute := &models.Dispute{
+		WalletID:       wallet.ID,
+		TransactionID:  request.TransactionID,
+		Amount:         request.Amount.Round(2),
+		ReasonCode:     request.ReasonCode,
+		Note:           request.Note,
+		ProviderCaseID: strings.TrimSpace(request.ProviderCaseID),
+		OpenedBy:       actor,
+	}
+	description := fmt.Sprintf("Hold for dispute of top-up %s", request.TransactionID)
+	if err := s.repo.OpenDispute(ctx, dispute, description); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTransactionNotDisputable), errors.Is(err, repository.ErrDisputeExceedsAmount):
+			return nil, fmt.Errorf("%w: %v", ErrDisputeNotAllowed, err)
+		case errors.Is(err, repository.ErrDisputeConflict):
+			return nil, fmt.Errorf("%w: transaction is already disputed", ErrDisputeConflict)
+		case errors.Is(err, repository.ErrInsufficientBalance):
+			return nil, ErrInsufficientBalance
+		case errors.Is(err, repository.ErrWalletFrozen):
+			return nil, ErrWalletFrozen
+		case errors.Is(err, repository.ErrWalletMerged):
+			return nil, ErrWalletMerged
+		case errors.Is(err, repository.ErrPeriodClosed):
+			return nil, ErrPeriodClosed
+		case errors.Is(err, repository.ErrOptimisticLock):
+			return nil, ErrOptimisticLock
+		}
+		s.logger.Error("failed to open dispute", err, "walletID", wallet.ID, "transactionID", request.TransactionID)
+		return nil, fmt.Errorf("failed to open dispute: %w", err)
+	}
+
+	s.logger.Info("dispute opened",
+		"disputeID", dispute.ID,
+		"walletID", wallet.ID,
+		"transactionID", dispute.TransactionID,
+		"amount", dispute.Amount,
+		"actor", actor)
+
+	publishWalletChanges(ctx, s.publisher, s.logger,
+		eventbus.TransactionStatusChanged{WalletID: wallet.ID, TransactionID: dispute.HoldTransactionID, Status: models.TransactionStatusProcessing},
+		eventbus.BalanceChanged{WalletID: wallet.ID},
+		eventbus.DisputeUpdated{Dispute: dispute, CustomerID: wallet.CustomerID})
+
+	return dispute, nil
+}
+
+// Get returns a dispute
+func (s *disputeService) Get(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	dispute, err := s.repo.GetDispute(ctx, id)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	return dispute, nil
+}
+
+// List returns the latest disputes matching the filter, newest first
+func (s *disputeService) List(ctx context.Context, filter models.DisputeFilter) ([]*models.Dispute, error) {
+	if filter.Limit == 0 {
+		filter.Limit = defaultDisputeLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxDisputeLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidDispute, maxDisputeLimit)
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidDispute, filter.Status)
+	}
+
+	disputes, err := s.repo.ListDisputes(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disputes: %w", err)
+	}
+	if disputes == nil {
+		disputes = []*models.Dispute{}
+	}
+	return disputes, nil
+}
+
+// SubmitEvidence records the evidence submitted to the provider for an open
+// dispute
+func (s *disputeService) SubmitEvidence(ctx context.Context, id uuid.UUID, evidence, actor string) (*models.Dispute, error) {
+	if strings.TrimSpace(evidence) == "" {
+		return nil, fmt.Errorf("%w: evidence is required", ErrInvalidDispute)
+	}
+
+	dispute, err := s.repo.SubmitEvidence(ctx, id, evidence, time.Now().UTC())
+	if err != nil {
+		if !errors.Is(err, repository.ErrDisputeConflict) && !errors.Is(err, repository.ErrDisputeNotFound) {
+			s.logger.Error("failed to submit dispute evidence", err, "disputeID", id)
+		}
+		return nil, s.mapError(err)
+	}
+
+	s.logger.Info("dispute evidence submitted",
+		"disputeID", id,
+		"actor", actor)
+
+	s.announce(ctx, dispute)
+	return dispute, nil
+}
+
+// Resolve settles an open dispute or one awaiting the provider's decision
+func (s *disputeService) Resolve(ctx context.Context, id uuid.UUID, outcome models.DisputeStatus, actor, note string) (*models.Dispute, error) {
+	if !outcome.IsResolved() {
+		return nil, fmt.Errorf("%w: outcome must be %s or %s", ErrInvalidDispute, models.DisputeWon, models.DisputeLost)
+	}
+
+	dispute, err := s.repo.ResolveDispute(ctx, id, outcome, actor, note, time.Now().UTC())
+	if err != nil {
+		if !errors.Is(err, repository.ErrDisputeConflict) && !errors.Is(err, repository.ErrDisputeNotFound) {
+			s.logger.Error("failed to resolve dispute", err, "disputeID", id)
+		}
+		return nil, s.mapError(err)
+	}
+
+	s.logger.Info("dispute resolved",
+		"disputeID", id,
+		"walletID", dispute.WalletID,
+		"outcome", outcome,
+		"actor", actor)
+
+	holdStatus := models.TransactionStatusCompleted
+	if outcome == models.DisputeWon {
+		holdStatus = models.TransactionStatusReversed
+	}
+	publishWalletChanges(ctx, s.publisher, s.logger,
+		eventbus.TransactionStatusChanged{WalletID: dispute.WalletID, TransactionID: dispute.HoldTransactionID, Status: holdStatus})
+	if outcome == models.DisputeWon {
+		publishWalletChanges(ctx, s.publisher, s.logger, eventbus.BalanceChanged{WalletID: dispute.WalletID})
+	}
+
+	s.announce(ctx, dispute)
+	return dispute, nil
+}
+
+// announce publishes the dispute's new state to its customer. The change is
+// already committed, so failures are logged rather than returned.
+func (s *disputeService) announce(ctx context.Context, dispute *models.Dispute) {
+	wallet, err := s.wallets.GetWallet(ctx, dispute.WalletID)
+	if err != nil {
+		s.logger.Error("failed to announce dispute update", err, "disputeID", dispute.ID)
+		return
+	}
+	publishWalletChanges(ctx, s.publisher, s.logger, eventbus.DisputeUpdated{Dispute: dispute, CustomerID: wallet.CustomerID})
+}
+
+// mapError maps repository errors to service errors
+func (s *disputeService) mapError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrDisputeNotFound):
+		return ErrDisputeNotFound
+	case errors.Is(err, repository.ErrDisputeConflict):
+		return ErrDisputeConflict
+	case errors.Is(err, repository.ErrPeriodClosed):
+		return ErrPeriodClosed
+	case errors.Is(err, repository.ErrOptimisticLock):
+		return ErrOptimisticLock
+	default:
+		return fmt.Errorf("dispute failed: %w", err)
+	}
+}
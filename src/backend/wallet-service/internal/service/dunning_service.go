@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"internal/calendar"
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+)
+
+// Dunning constants
+const (
+	// dunningBatchSize bounds the cases loaded per query
+	dunningBatchSize = 100
+)
+
+// DunningPolicy configures grace periods for wallets whose balance ran out
+type DunningPolicy struct {
+	// GracePeriod is how long service continues after the balance ran out
+	GracePeriod time.Duration
+	// NoticeSchedule holds the offsets from the start of the grace period at
+	// which escalating notices are sent, in ascending order
+	NoticeSchedule []time.Duration
+	// SuspendAfterGrace publishes customer.suspend when the grace period
+	// expires without a top-up
+	SuspendAfterGrace bool
+}
+
+// DunningService defines the interface for dunning of exhausted wallets
+type DunningService interface {
+	Evaluate(ctx context.Context, now time.Time) error
+	RunScheduled(ctx context.Context, interval time.Duration)
+}
+
+// dunningService implements DunningService interface
+type dunningService struct {
+	repo      repository.DunningRepository
+	publisher eventbus.Publisher
+	policy    DunningPolicy
+	calendar  *calendar.Calendar
+	logger    Logger
+}
+
+// NewDunningService creates a new instance of DunningService
+func NewDunningService(repo repository.DunningRepository, publisher eventbus.Publisher, policy DunningPolicy, cal *calendar.Calendar, logger Logger) (DunningService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if len(policy.NoticeSchedule) == 0 {
+		return nil, errors.New("notice schedule is required")
+	}
+	if cal == nil {
+		return nil, errors.New("calendar is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &dunningService{
+		repo:      repo,
+		publisher: publisher,
+		policy:    policy,
+		calendar:  cal,
+		logger:    logger,
+	}, nil
+}
+
+// Evaluate advances every dunning case: it resumes topped-up wallets, opens
+// cases for newly exhausted ones, sends due notices and suspends customers
+// whose grace period expired. Notices and suspensions wait for the next
+// business day, while top-ups are honoured every day. Events are published
+// before the case is updated, so a failure may repeat an event but never
+// lose one.
+func (s *dunningService) Evaluate(ctx context.Context, now time.Time) error {
+	now = now.UTC()
+
+	if err := s.resolveRecovered(ctx, now); err != nil {
+		return err
+	}
+
+	opened, err := s.repo.OpenCases(ctx, now, now.Add(s.policy.GracePeriod), now.Add(s.policy.NoticeSchedule[0]))
+	if err != nil {
+		s.logger.Error("failed to open dunning cases", err)
+		return fmt.Errorf("failed to open dunning cases: %w", err)
+	}
+	if opened > 0 {
+		s.logger.Info("dunning grace periods started", "count", opened)
+	}
+
+	if !s.calendar.IsBusinessDay(now) {
+		return nil
+	}
+
+	if err := s.sendDueNotices(ctx, now); err != nil {
+		return err
+	}
+
+	if s.policy.SuspendAfterGrace {
+		return s.suspendExpired(ctx, now)
+	}
+
+	return nil
+}
+
+// RunScheduled evaluates dunning cases every interval until the context is cancelled
+func (s *dunningService) RunScheduled(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// Failures are logged by Evaluate and retried on the next tick
+			_ = s.Evaluate(ctx, now)
+		}
+	}
+}
+
+// resolveRecovered closes the cases of wallets topped up to a positive
+// balance, lifting the suspension of those that were suspended
+func (s *dunningService) resolveRecovered(ctx context.Context, now time.Time) error {
+	cases, err := s.repo.ListRecovered(ctx, dunningBatchSize)
+	if err != nil {
+		s.logger.Error("failed to list recovered dunning cases", err)
+		return fmt.Errorf("failed to list recovered dunning cases: %w", err)
+	}
+
+	for _, dc := range cases {
+		if dc.SuspendedAt != nil {
+			err := s.publisher.Publish(ctx, eventbus.CustomerResumed{Case: dc, ResumedAt: now})
+			if err != nil {
+				s.logger.Error("failed to publish customer resume", err, "walletID", dc.WalletID)
+				continue
+			}
+		}
+
+		if err := s.repo.Resolve(ctx, dc.ID, now); err != nil {
+			s.logger.Error("failed to resolve dunning case", err, "walletID", dc.WalletID)
+			continue
+		}
+
+		s.logger.Info("dunning case resolved by top-up",
+			"walletID", dc.WalletID,
+			"customerID", dc.CustomerID,
+			"wasSuspended", dc.SuspendedAt != nil)
+	}
+
+	return nil
+}
+
+// sendDueNotices publishes the next escalating notice of every case due one
+func (s *dunningService) sendDueNotices(ctx context.Context, now time.Time) error {
+	cases, err := s.repo.ListDueNotices(ctx, now, dunningBatchSize)
+	if err != nil {
+		s.logger.Error("failed to list due dunning notices", err)
+		return fmt.Errorf("failed to list due dunning notices: %w", err)
+	}
+
+	schedule := s.policy.NoticeSchedule
+	for _, dc := range cases {
+		notice := dc.NoticesSent + 1
+		final := notice >= len(schedule)
+
+		err := s.publisher.Publish(ctx, eventbus.DunningNotice{Case: dc, Notice: notice, Final: final})
+		if err != nil {
+			s.logger.Error("failed to publish dunning notice", err, "walletID", dc.WalletID, "notice", notice)
+			continue
+		}
+
+		var next *time.Time
+		if !final {
+			at := dc.StartedAt.Add(schedule[notice])
+			next = &at
+		}
+		if err := s.repo.RecordNotice(ctx, dc.ID, dc.NoticesSent, next); err != nil {
+			s.logger.Error("failed to record dunning notice", err, "walletID", dc.WalletID, "notice", notice)
+		}
+	}
+
+	return nil
+}
+
+// suspendExpired publishes customer.suspend for every case whose grace period
+// expired without a top-up
+func (s *dunningService) suspendExpired(ctx context.Context, now time.Time) error {
+	cases, err := s.repo.ListExpired(ctx, now, dunningBatchSize)
+	if err != nil {
+		s.logger.Error("failed to list expired dunning cases", err)
+		return fmt.Errorf("failed to list expired dunning cases: %w", err)
+	}
+
+	for _, dc := range cases {
+		err := s.publisher.Publish(ctx, eventbus.CustomerSuspended{
+			Case:        dc,
+			Reason:      models.SuspendReasonGraceExpired,
+			SuspendedAt: now,
+		})
+		if err != nil {
+			s.logger.Error("failed to publish customer suspension", err, "walletID", dc.WalletID)
+			continue
+		}
+
+		if err := s.repo.MarkSuspended(ctx, dc.ID, now); err != nil {
+			s.logger.Error("failed to mark dunning case suspended", err, "walletID", dc.WalletID)
+			continue
+		}
+
+		s.logger.Warn("customer suspended after dunning grace period",
+			"walletID", dc.WalletID,
+			"customerID", dc.CustomerID,
+			"graceEndedAt", dc.GraceEndsAt)
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/currency"
+	"internal/models"
+)
+
+// ChargeRequest describes a debit to estimate
+type ChargeRequest struct {
+	WalletID uuid.UUID
+	Amount   decimal.Decimal
+	// Currency is the currency of the amount, defaulting to the wallet's
+	Currency string
+	// Source and Category are the source and category metadata the debit
+	// would carry, pricing its fee and tax
+	Source   string
+	Category string
+	// CouponCode names a coupon to discount the amount by, if any
+	CouponCode string
+	// At is when the debit would be made, defaulting to now
+	At time.Time
+}
+
+// EstimateService defines the interface for estimating debits before they
+// are committed
+type EstimateService interface {
+	Estimate(ctx context.Context, req ChargeRequest) (*models.ChargeEstimate, error)
+}
+
+// estimateService implements EstimateService interface by running the
+// pricing steps of a debit in order, reading but never writing: coupon
+// discount, fee, conversion into the wallet's currency and tax
+type estimateService struct {
+	wallets    WalletService
+	fees       FeeService
+	coupons    CouponService
+	taxes      TaxService
+	billing    BillingModeSource
+	currencies *currency.Registry
+	rates      *currency.RateTable
+	logger     Logger
+}
+
+// NewEstimateService creates a new instance of EstimateService converting
+// charges at rates. taxes is nil when debits are not taxed; billing tells
+// postpaid customers, whose debits never lack balance, from prepaid ones.
+func NewEstimateService(wallets WalletService, fees FeeService, coupons CouponService, taxes TaxService, billing BillingModeSource,
+	currencies *currency.Registry, rates *currency.RateTable, logger Logger) (EstimateService, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if fees == nil {
+		return nil, errors.New("fee service is required")
+	}
+	if coupons == nil {
+		return nil, errors.New("coupon service is required")
+	}
+	if billing == nil {
+		return nil, errors.New("billing mode source is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currency registry is required")
+	}
+	if rates == nil {
+		return nil, errors.New("exchange rates are required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &estimateService{
+		wallets:    wallets,
+		fees:       fees,
+		coupons:    coupons,
+		taxes:      taxes,
+		billing:    billing,
+		currencies: currencies,
+		rates:      rates,
+		logger:     logger,
+	}, nil
+}
+
+// Estimate prices a debit as it would be posted. A coupon that would not
+// apply fails the estimate, as it would fail the charge.
+func (s *estimateService) Estimate(ctx context.Context, req ChargeRequest) (*models.ChargeEstimate, error) {
+	wallet, err := s.wallets.GetWallet(ctx, req.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if code == "" {
+		code = wallet.Currency
+	}
+	if !s.currencies.Supported(code) {
+		return nil, ErrUnsupportedCurrency
+	}
+	if !req.Amount.IsPositive() || req.Amount.GreaterThan(decimal.NewFromFloat(models.MaxTransactionAmount)) {
+		return nil, ErrInvalidAmount
+	}
+	if req.At.IsZero() {
+		req.At = time.Now().UTC()
+	}
+
+	estimate := &models.ChargeEstimate{
+		WalletID:       wallet.ID,
+		Currency:       code,
+		Amount:         s.currencies.Round(code, req.Amount),
+		Discount:       decimal.Zero,
+		Fee:            decimal.Zero,
+		WalletCurrency: wallet.Currency,
+		At:             req.At,
+	}
+
+	if strings.TrimSpace(req.CouponCode) != "" {
+		quote, err := s.coupons.Quote(ctx, CouponApplication{
+			Code:       req.CouponCode,
+			CustomerID: wallet.CustomerID,
+			Currency:   code,
+			Amount:     estimate.Amount,
+			At:         req.At,
+		})
+		if err != nil {
+			return nil, err
+		}
+		estimate.Coupon = quote
+		estimate.Discount = quote.Discount
+	}
+
+	// A fully discounted charge incurs no fee
+	net := estimate.Amount.Sub(estimate.Discount)
+	if net.IsPositive() {
+		quote, err := s.fees.Preview(ctx, FeePreview{Amount: net, Currency: code, Source: req.Source, At: req.At})
+		if err != nil {
+			return nil, err
+		}
+		estimate.Fee = quote.Fee
+		if quote.Rule != nil {
+			estimate.FeeRuleID = &quote.Rule.ID
+		}
+	}
+	estimate.Subtotal = net.Add(estimate.Fee)
+
+	estimate.Converted = estimate.Subtotal
+	if code != wallet.Currency {
+		rate, err := s.rates.Rate(code, wallet.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s has no exchange rate to %s", ErrUnsupportedCurrency, code, wallet.Currency)
+		}
+		if estimate.Converted, err = s.currencies.Convert(estimate.Subtotal, code, wallet.Currency, rate); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedCurrency, err)
+		}
+		estimate.ExchangeRate = &rate
+	}
+
+	estimate.Total = estimate.Converted
+	if s.taxes != nil && estimate.Converted.IsPositive() {
+		breakdown, err := s.taxes.QuoteDebit(ctx, wallet.CustomerID, wallet.Currency, estimate.Converted, req.Category)
+		if err != nil {
+			return nil, err
+		}
+		estimate.Tax = breakdown
+		estimate.Total = breakdown.Taxable.Add(breakdown.Total)
+	}
+
+	// Postpaid debits are accrued and billed at the end of the cycle, so
+	// the balance never refuses them
+	mode, err := s.billing.BillingMode(ctx, wallet.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	estimate.BillingMode = mode
+	estimate.AvailableBalance = s.currencies.Round(wallet.Currency, decimal.NewFromFloat(wallet.AvailableBalance()))
+	estimate.SufficientBalance = mode == models.BillingPostpaid || !estimate.Total.GreaterThan(estimate.AvailableBalance)
+	return estimate, nil
+}
@@ -0,0 +1,480 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/models"
+	"internal/repository"
+)
+
+// Failed job constants
+const (
+	// failedJobBatch bounds the due jobs retried per run
+	failedJobBatch = 200
+	// maxFailedJobBulk bounds the jobs retried or discarded per request
+	maxFailedJobBulk = 100
+	// Failed job listing bounds
+	defaultFailedJobLimit = 50
+	maxFailedJobLimit     = 500
+)
+
+// Failed job audit actions
+const (
+	failedJobRetryAction   = "failed_job.retry"
+	failedJobDiscardAction = "failed_job.discard"
+)
+
+// Failed job errors
+var (
+	ErrInvalidFailedJob  = errors.New("invalid failed job request")
+	ErrFailedJobNotFound = errors.New("failed job not found")
+	ErrFailedJobConflict = errors.New("failed job is closed or changed meanwhile")
+	// ErrFailedJobObsolete is returned by retriers for work that no longer
+	// applies, such as a delivery to a deleted webhook subscription; the job
+	// is discarded rather than retried
+	ErrFailedJobObsolete = errors.New("failed job no longer applies")
+)
+
+// Failed job metrics
+var failedJobs = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_failed_jobs_total",
+		Help: "Failed background jobs, by kind and outcome",
+	},
+	[]string{"kind", "outcome"},
+)
+
+// FailedJobPolicy is the retry policy of a kind of failed job
+type FailedJobPolicy struct {
+	// MaxAttempts counts the attempt that failed first, so 1 dead-letters
+	// jobs without retrying them
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry, doubled for every
+	// further retry up to MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// FailedJobRetrier repeats the work of a failed job, returning
+// ErrFailedJobObsolete when it no longer applies
+type FailedJobRetrier func(ctx context.Context, job *models.FailedJob) error
+
+// FailedJobRecorder records background work that failed for retries
+type FailedJobRecorder interface {
+	// RecordFailure queues the failed work for retries. Work failing again
+	// while its job is open is not queued twice.
+	RecordFailure(ctx context.Context, kind models.FailedJobKind, reference string, payload interface{}, cause error) error
+}
+
+// FailedJobService defines the interface for retrying failed background
+// jobs and for the dead-letter console of operators
+type FailedJobService interface {
+	FailedJobRecorder
+	// Register sets the retry policy and retrier of a kind of job
+	Register(kind models.FailedJobKind, policy FailedJobPolicy, retrier FailedJobRetrier) error
+	// ProcessRetries retries the jobs due by now, returning the number that
+	// succeeded
+	ProcessRetries(ctx context.Context, now time.Time) (int, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.FailedJob, error)
+	List(ctx context.Context, filter models.FailedJobFilter) ([]*models.FailedJob, error)
+	// Retry schedules one more attempt of an open job right away
+	Retry(ctx context.Context, id uuid.UUID, actor string) (*models.FailedJob, error)
+	// Discard closes an open job without retrying it
+	Discard(ctx context.Context, id uuid.UUID, actor, reason string) (*models.FailedJob, error)
+	RetryMany(ctx context.Context, ids []uuid.UUID, actor string) ([]*models.FailedJobOutcome, error)
+	DiscardMany(ctx context.Context, ids []uuid.UUID, actor, reason string) ([]*models.FailedJobOutcome, error)
+}
+
+// failedJobKind is the registered policy and retrier of a kind of job
+type failedJobKind struct {
+	policy  FailedJobPolicy
+	retrier FailedJobRetrier
+}
+
+// failedJobService implements FailedJobService interface. Operator retries
+// and discards are kept in the operator audit log.
+type failedJobService struct {
+	repo   repository.FailedJobRepository
+	audit  repository.AuditRepository
+	logger Logger
+
+	mu    sync.RWMutex
+	kinds map[models.FailedJobKind]failedJobKind
+}
+
+// NewFailedJobService creates a new instance of FailedJobService
+func NewFailedJobService(repo repository.FailedJobRepository, audit repository.AuditRepository, logger Logger) (FailedJobService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if audit == nil {
+		return nil, errors.New("audit repository is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &failedJobService{
+		repo:   repo,
+		audit:  audit,
+		logger: logger,
+		kinds:  make(map[models.FailedJobKind]failedJobKind),
+	}, nil
+}
+
+// Register sets the retry policy and retrier of a kind of job
+func (s *failedJobService) Register(kind models.FailedJobKind, policy FailedJobPolicy, retrier FailedJobRetrier) error {
+	if !kind.IsValid() {
+		return fmt.Errorf("unknown failed job kind %q", kind)
+	}
+	if policy.MaxAttempts <= 0 {
+		return errors.New("max attempts must be positive")
+	}
+	if policy.MinBackoff <= 0 || policy.MaxBackoff < policy.MinBackoff {
+		return errors.New("backoff must be positive and max backoff at least min backoff")
+	}
+	if retrier == nil {
+		return errors.New("retrier is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.kinds[kind]; exists {
+		return fmt.Errorf("duplicate retrier of %s jobs", kind)
+	}
+	s.kinds[kind] = failedJobKind{policy: policy, retrier: retrier}
+	return nil
+}
+
+// kind returns the registration of a kind of job
+func (s *failedJobService) kind(kind models.FailedJobKind) (failedJobKind, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	registered, ok := s.kinds[kind]
+	return registered, ok
+}
+
+// RecordFailure queues failed work for retries under the policy of its kind
+func (s *failedJobService) RecordFailure(ctx context.Context, kind models.FailedJobKind, reference string, payload interface{}, cause error) error {
+	registered, ok := s.kind(kind)
+	if !ok {
+		return fmt.Errorf("no retrier of %s jobs", kind)
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode failed job payload: %w", err)
+	}
+
+	now := time.Now().UTC()
+	job := &models.FailedJob{
+		ID:          uuid.New(),
+		Kind:        kind,
+		Reference:   reference,
+		Payload:     encoded,
+		Attempts:    1,
+		MaxAttempts: registered.policy.MaxAttempts,
+		LastError:   cause.Error(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	s.schedule(job, registered.policy, now)
+
+	recorded, err := s.repo.RecordFailedJob(ctx, job)
+	if err != nil {
+		s.logger.Error("failed to record failed job", err, "kind", kind, "reference", reference)
+		return fmt.Errorf("failed to record failed job: %w", err)
+	}
+	if !recorded {
+		s.logger.Info("failed job already open", "kind", kind, "reference", reference)
+		return nil
+	}
+
+	failedJobs.WithLabelValues(string(kind), "recorded").Inc()
+	if job.Status == models.FailedJobDead {
+		failedJobs.WithLabelValues(string(kind), "dead").Inc()
+	}
+	return nil
+}
+
+// schedule sets the next attempt of a job that failed, or dead-letters it
+// once out of attempts
+func (s *failedJobService) schedule(job *models.FailedJob, policy FailedJobPolicy, now time.Time) {
+	if job.Attempts >= job.MaxAttempts {
+		job.Status = models.FailedJobDead
+		job.NextAttemptAt = nil
+		job.DeadAt = &now
+		return
+	}
+
+	next := now.Add(failedJobBackoff(policy, job.Attempts))
+	job.Status = models.FailedJobRetrying
+	job.NextAttemptAt = &next
+}
+
+// ProcessRetries retries the jobs due by now. Jobs of kinds without a
+// registered retrier are left for an instance that has one.
+func (s *failedJobService) ProcessRetries(ctx context.Context, now time.Time) (int, error) {
+	now = now.UTC()
+
+	due, err := s.repo.ListDueFailedJobs(ctx, now, failedJobBatch)
+	if err != nil {
+		s.logger.Error("failed to list due failed jobs", err)
+		return 0, fmt.Errorf("failed to list due failed jobs: %w", err)
+	}
+
+	succeeded := 0
+	for _, job := range due {
+		registered, ok := s.kind(job.Kind)
+		if !ok {
+			continue
+		}
+
+		err := registered.retrier(ctx, job)
+		job.UpdatedAt = now
+		job.Attempts++
+		outcome := "succeeded"
+		switch {
+		case err == nil:
+			job.Status = models.FailedJobSucceeded
+			job.NextAttemptAt = nil
+			job.ResolvedAt = &now
+		case errors.Is(err, ErrFailedJobObsolete):
+			outcome = "discarded"
+			job.Status = models.FailedJobDiscarded
+			job.NextAttemptAt = nil
+			job.ResolvedAt = &now
+			job.Resolution = err.Error()
+		default:
+			job.LastError = err.Error()
+			s.schedule(job, registered.policy, now)
+			outcome = "retrying"
+			if job.Status == models.FailedJobDead {
+				outcome = "dead"
+			}
+		}
+
+		if err := s.repo.UpdateFailedJob(ctx, job, models.FailedJobRetrying); err != nil {
+			// A job discarded by an operator during its retry stays
+			// discarded
+			s.logger.Error("failed to update failed job", err, "failedJobID", job.ID)
+			continue
+		}
+
+		failedJobs.WithLabelValues(string(job.Kind), outcome).Inc()
+		switch outcome {
+		case "succeeded":
+			succeeded++
+		case "dead":
+			s.logger.Error("failed job dead-lettered", err,
+				"failedJobID", job.ID,
+				"kind", job.Kind,
+				"reference", job.Reference,
+				"attempts", job.Attempts)
+		}
+	}
+
+	if succeeded > 0 {
+		s.logger.Info("failed jobs retried", "count", succeeded)
+	}
+
+	return succeeded, nil
+}
+
+// Get returns a failed job
+func (s *failedJobService) Get(ctx context.Context, id uuid.UUID) (*models.FailedJob, error) {
+	job, err := s.repo.GetFailedJob(ctx, id)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	return job, nil
+}
+
+// List returns the latest failed jobs matching the filter, newest first
+func (s *failedJobService) List(ctx context.Context, filter models.FailedJobFilter) ([]*models.FailedJob, error) {
+	if filter.Limit == 0 {
+		filter.Limit = defaultFailedJobLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxFailedJobLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFailedJob, maxFailedJobLimit)
+	}
+	if filter.Kind != "" && !filter.Kind.IsValid() {
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidFailedJob, filter.Kind)
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidFailedJob, filter.Status)
+	}
+
+	jobs, err := s.repo.ListFailedJobs(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed jobs: %w", err)
+	}
+	if jobs == nil {
+		jobs = []*models.FailedJob{}
+	}
+	return jobs, nil
+}
+
+// Retry grants an open job one more attempt, made by the next retry run
+func (s *failedJobService) Retry(ctx context.Context, id uuid.UUID, actor string) (*models.FailedJob, error) {
+	job, err := s.repo.GetFailedJob(ctx, id)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	if !job.Open() {
+		return nil, s.audited(ctx, failedJobRetryAction, actor, "", job, ErrFailedJobConflict)
+	}
+
+	from := job.Status
+	now := time.Now().UTC()
+	job.Status = models.FailedJobRetrying
+	job.MaxAttempts = job.Attempts + 1
+	job.NextAttemptAt = &now
+	job.UpdatedAt = now
+	if err := s.repo.UpdateFailedJob(ctx, job, from); err != nil {
+		return nil, s.audited(ctx, failedJobRetryAction, actor, "", job, s.mapError(err))
+	}
+
+	s.logger.Info("failed job retry requested", "failedJobID", id, "kind", job.Kind, "actor", actor)
+	return job, s.audited(ctx, failedJobRetryAction, actor, "", job, nil)
+}
+
+// Discard closes an open job for good
+func (s *failedJobService) Discard(ctx context.Context, id uuid.UUID, actor, reason string) (*models.FailedJob, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidFailedJob)
+	}
+
+	job, err := s.repo.GetFailedJob(ctx, id)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	if !job.Open() {
+		return nil, s.audited(ctx, failedJobDiscardAction, actor, reason, job, ErrFailedJobConflict)
+	}
+
+	from := job.Status
+	now := time.Now().UTC()
+	job.Status = models.FailedJobDiscarded
+	job.NextAttemptAt = nil
+	job.UpdatedAt = now
+	job.ResolvedAt = &now
+	job.ResolvedBy = actor
+	job.Resolution = reason
+	if err := s.repo.UpdateFailedJob(ctx, job, from); err != nil {
+		return nil, s.audited(ctx, failedJobDiscardAction, actor, reason, job, s.mapError(err))
+	}
+
+	failedJobs.WithLabelValues(string(job.Kind), "discarded").Inc()
+	s.logger.Info("failed job discarded", "failedJobID", id, "kind", job.Kind, "actor", actor)
+	return job, s.audited(ctx, failedJobDiscardAction, actor, reason, job, nil)
+}
+
+// RetryMany retries each of the jobs, reporting the outcome of each
+func (s *failedJobService) RetryMany(ctx context.Context, ids []uuid.UUID, actor string) ([]*models.FailedJobOutcome, error) {
+	return s.bulk(ids, func(id uuid.UUID) (*models.FailedJob, error) {
+		return s.Retry(ctx, id, actor)
+	})
+}
+
+// DiscardMany discards each of the jobs, reporting the outcome of each
+func (s *failedJobService) DiscardMany(ctx context.Context, ids []uuid.UUID, actor, reason string) ([]*models.FailedJobOutcome, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidFailedJob)
+	}
+	return s.bulk(ids, func(id uuid.UUID) (*models.FailedJob, error) {
+		return s.Discard(ctx, id, actor, reason)
+	})
+}
+
+// bulk applies an operation to each job; one job failing does not stop the
+// others
+func (s *failedJobService) bulk(ids []uuid.UUID, apply func(uuid.UUID) (*models.FailedJob, error)) ([]*models.FailedJobOutcome, error) {
+	if len(ids) == 0 || len(ids) > maxFailedJobBulk {
+		return nil, fmt.Errorf("%w: between 1 and %d job IDs are required", ErrInvalidFailedJob, maxFailedJobBulk)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(ids))
+	outcomes := make([]*models.FailedJobOutcome, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		outcome := &models.FailedJobOutcome{ID: id}
+		job, err := apply(id)
+		if err != nil {
+			outcome.Error = err.Error()
+		} else {
+			outcome.Job = job
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes, nil
+}
+
+// audited records an operator action on a job in the audit log
+func (s *failedJobService) audited(ctx context.Context, action, actor, reason string, job *models.FailedJob, err error) error {
+	entry := &models.OperatorAction{
+		Action: action,
+		Actor:  actor,
+		Reason: reason,
+		Params: map[string]string{
+			"failed_job_id": job.ID.String(),
+			"kind":          string(job.Kind),
+			"reference":     job.Reference,
+			"status":        string(job.Status),
+		},
+		Status: models.OperatorActionSucceeded,
+	}
+	if err != nil {
+		entry.Status = models.OperatorActionFailed
+		entry.Error = err.Error()
+	}
+
+	if auditErr := s.audit.RecordOperatorAction(ctx, entry); auditErr != nil {
+		s.logger.Error("failed to audit failed job action", auditErr, "action", action, "failedJobID", job.ID)
+		if err == nil {
+			err = fmt.Errorf("%s executed but audit record failed: %w", action, auditErr)
+		}
+	}
+	return err
+}
+
+// mapError maps repository errors to service errors
+func (s *failedJobService) mapError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrFailedJobNotFound):
+		return ErrFailedJobNotFound
+	case errors.Is(err, repository.ErrFailedJobConflict):
+		return ErrFailedJobConflict
+	default:
+		return fmt.Errorf("failed job operation failed: %w", err)
+	}
+}
+
+// failedJobBackoff doubles the policy's minimum backoff with every retry
+// after the first, up to its maximum
+func failedJobBackoff(policy FailedJobPolicy, attempts int) time.Duration {
+	backoff := policy.MinBackoff
+	for i := 1; i < attempts && backoff < policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/currency"
+	"internal/models"
+	"internal/repository"
+)
+
+// Fee rule audit actions
+const (
+	feeRuleCreateAction = "fee.create_rule"
+	feeRuleEndAction    = "fee.end_rule"
+)
+
+// Fee rule limits
+const (
+	// feeRuleHistory bounds the fee rules listed
+	feeRuleHistory = 100
+	// maxFeeSourceLength is the longest transaction source a rule can name
+	maxFeeSourceLength = 64
+)
+
+// Fee errors
+var (
+	ErrInvalidFeeRule  = errors.New("invalid fee rule")
+	ErrFeeRuleNotFound = errors.New("fee rule not found")
+	ErrFeeRuleConflict = errors.New("fee rule cannot be ended at that time")
+)
+
+// FeePreview describes a transaction to price
+type FeePreview struct {
+	Amount   decimal.Decimal
+	Currency string
+	// Source is where the transaction would come from, as in its source
+	// metadata
+	Source string
+	// At is when the transaction would be made, defaulting to now
+	At time.Time
+}
+
+// FeeService defines the interface for managing fee rules and pricing the
+// fees of transactions by them
+type FeeService interface {
+	CreateRule(ctx context.Context, rule *models.FeeRule, actor, reason string) (*models.FeeRule, error)
+	EndRule(ctx context.Context, id uuid.UUID, until time.Time, actor, reason string) (*models.FeeRule, error)
+	GetRule(ctx context.Context, id uuid.UUID) (*models.FeeRule, error)
+	ListRules(ctx context.Context) ([]*models.FeeRule, error)
+	Preview(ctx context.Context, preview FeePreview) (*models.FeeQuote, error)
+}
+
+// feeService implements FeeService interface
+type feeService struct {
+	repo       repository.FeeRuleRepository
+	audit      repository.AuditRepository
+	currencies *currency.Registry
+	logger     Logger
+}
+
+// NewFeeService creates a new instance of FeeService
+func NewFeeService(repo repository.FeeRuleRepository, audit repository.AuditRepository, currencies *currency.Registry, logger Logger) (FeeService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if audit == nil {
+		return nil, errors.New("audit repository is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currency registry is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &feeService{
+		repo:       repo,
+		audit:      audit,
+		currencies: currencies,
+		logger:     logger,
+	}, nil
+}
+
+// CreateRule validates and stores a fee rule. A rule starts now at the
+// earliest; rules in effect are changed by creating a rule starting later.
+func (s *feeService) CreateRule(ctx context.Context, rule *models.FeeRule, actor, reason string) (*models.FeeRule, error) {
+	now := time.Now().UTC()
+	if rule.EffectiveFrom.IsZero() {
+		rule.EffectiveFrom = now
+	}
+	rule.Source = strings.TrimSpace(rule.Source)
+	rule.Currency = strings.ToUpper(strings.TrimSpace(rule.Currency))
+	rule.Reason = reason
+	if err := s.validateRule(rule, now); err != nil {
+		return nil, err
+	}
+
+	rule.ID = uuid.New()
+	rule.CreatedBy = actor
+	rule.CreatedAt = now
+
+	err := s.repo.CreateFeeRule(ctx, rule)
+	params := map[string]string{
+		"rule_id":        rule.ID.String(),
+		"source":         rule.Source,
+		"currency":       rule.Currency,
+		"effective_from": rule.EffectiveFrom.Format(time.RFC3339),
+	}
+	if err := s.audited(ctx, feeRuleCreateAction, actor, reason, params, err); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// EndRule stops a rule from applying from a time on, now when unset
+func (s *feeService) EndRule(ctx context.Context, id uuid.UUID, until time.Time, actor, reason string) (*models.FeeRule, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a reason is required", ErrInvalidFeeRule)
+	}
+	now := time.Now().UTC()
+	if until.IsZero() {
+		until = now
+	}
+	if until.Before(now) {
+		return nil, fmt.Errorf("%w: a rule cannot be ended in the past", ErrInvalidFeeRule)
+	}
+
+	rule, err := s.repo.EndFeeRule(ctx, id, until)
+	params := map[string]string{
+		"rule_id":         id.String(),
+		"effective_until": until.Format(time.RFC3339),
+	}
+	if err := s.audited(ctx, feeRuleEndAction, actor, reason, params, err); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetRule returns a fee rule
+func (s *feeService) GetRule(ctx context.Context, id uuid.UUID) (*models.FeeRule, error) {
+	rule, err := s.repo.GetFeeRule(ctx, id)
+	if errors.Is(err, repository.ErrFeeRuleNotFound) {
+		return nil, ErrFeeRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee rule: %w", err)
+	}
+	return rule, nil
+}
+
+// ListRules returns the latest fee rules, newest first
+func (s *feeService) ListRules(ctx context.Context) ([]*models.FeeRule, error) {
+	rules, err := s.repo.ListFeeRules(ctx, feeRuleHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fee rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Preview returns the fee a transaction would incur under the rules in
+// effect at its time, rounded to its currency
+func (s *feeService) Preview(ctx context.Context, preview FeePreview) (*models.FeeQuote, error) {
+	preview.Currency = strings.ToUpper(strings.TrimSpace(preview.Currency))
+	if !s.currencies.Supported(preview.Currency) {
+		return nil, ErrUnsupportedCurrency
+	}
+	if !preview.Amount.IsPositive() || preview.Amount.GreaterThan(decimal.NewFromFloat(models.MaxTransactionAmount)) {
+		return nil, ErrInvalidAmount
+	}
+	if preview.At.IsZero() {
+		preview.At = time.Now().UTC()
+	}
+	source := strings.TrimSpace(preview.Source)
+
+	rules, err := s.repo.ListFeeRulesAt(ctx, source, preview.Currency, preview.At)
+	if err != nil {
+		s.logger.Error("failed to get fee rules", err, "currency", preview.Currency, "source", source)
+		return nil, fmt.Errorf("failed to get fee rules: %w", err)
+	}
+
+	quote := &models.FeeQuote{
+		Amount:   preview.Amount,
+		Currency: preview.Currency,
+		Source:   source,
+		Fee:      decimal.Zero,
+		At:       preview.At,
+	}
+	if rule := models.SelectFeeRule(rules, source, preview.Currency, preview.At); rule != nil {
+		quote.Fee = s.currencies.Round(preview.Currency, rule.Fee(preview.Amount))
+		quote.Rule = rule
+	}
+	return quote, nil
+}
+
+// validateRule checks a rule before it is stored
+func (s *feeService) validateRule(rule *models.FeeRule, now time.Time) error {
+	switch {
+	case strings.TrimSpace(rule.Reason) == "":
+		return fmt.Errorf("%w: a reason is required", ErrInvalidFeeRule)
+	case len(rule.Source) > maxFeeSourceLength:
+		return fmt.Errorf("%w: source is longer than %d characters", ErrInvalidFeeRule, maxFeeSourceLength)
+	case rule.Currency != "" && !s.currencies.Supported(rule.Currency):
+		return fmt.Errorf("%w: currency %s is not supported", ErrInvalidFeeRule, rule.Currency)
+	case rule.FixedAmount.IsNegative():
+		return fmt.Errorf("%w: fixed amount must not be negative", ErrInvalidFeeRule)
+	case rule.Percentage.IsNegative() || rule.Percentage.GreaterThan(decimal.NewFromInt(100)):
+		return fmt.Errorf("%w: percentage must be between 0 and 100", ErrInvalidFeeRule)
+	case rule.MinFee != nil && rule.MinFee.IsNegative(), rule.MaxFee != nil && rule.MaxFee.IsNegative():
+		return fmt.Errorf("%w: fee caps must not be negative", ErrInvalidFeeRule)
+	case rule.MinFee != nil && rule.MaxFee != nil && rule.MaxFee.LessThan(*rule.MinFee):
+		return fmt.Errorf("%w: maximum fee is below the minimum fee", ErrInvalidFeeRule)
+	case rule.Currency == "" && (!rule.FixedAmount.IsZero() || rule.MinFee != nil || rule.MaxFee != nil):
+		return fmt.Errorf("%w: fixed amounts and caps need a currency", ErrInvalidFeeRule)
+	case rule.EffectiveFrom.Before(now.Add(-time.Minute)):
+		return fmt.Errorf("%w: a rule cannot start in the past", ErrInvalidFeeRule)
+	case rule.EffectiveUntil != nil && !rule.EffectiveUntil.After(rule.EffectiveFrom):
+		return fmt.Errorf("%w: a rule must end after it starts", ErrInvalidFeeRule)
+	}
+	return nil
+}
+
+// audited records a fee rule change in the operator audit log regardless of
+// whether it succeeded, returning the change's error mapped to the service's
+func (s *feeService) audited(ctx context.Context, action, actor, reason string, params map[string]string, err error) error {
+	switch {
+	case err == nil:
+	case errors.Is(err, repository.ErrFeeRuleNotFound):
+		err = ErrFeeRuleNotFound
+	case errors.Is(err, repository.ErrFeeRuleConflict):
+		err = ErrFeeRuleConflict
+	default:
+		s.logger.Error("failed to change fee rule", err, "action", action, "ruleID", params["rule_id"])
+		err = fmt.Errorf("failed to change fee rule: %w", err)
+	}
+
+	entry := &models.OperatorAction{
+		Action: action,
+		Actor:  actor,
+		Reason: reason,
+		Params: params,
+		Status: models.OperatorActionSucceeded,
+	}
+	if err != nil {
+		entry.Status = models.OperatorActionFailed
+		entry.Error = err.Error()
+	}
+
+	if auditErr := s.audit.RecordOperatorAction(ctx, entry); auditErr != nil {
+		s.logger.Error("failed to audit fee rule change", auditErr, "action", action, "ruleID", params["rule_id"])
+		if err == nil {
+			err = fmt.Errorf("%s executed but audit record failed: %w", action, auditErr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("fee rule changed",
+		"action", action,
+		"ruleID", params["rule_id"],
+		"actor", actor)
+
+	return nil
+}
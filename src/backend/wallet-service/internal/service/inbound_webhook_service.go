@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/inbound"
+	"internal/models"
+	"internal/repository"
+)
+
+// Inbound webhook constants
+const (
+	// inboundWebhookBatch bounds the received webhooks processed per run
+	inboundWebhookBatch = 200
+	// inboundWebhookMinBackoff and inboundWebhookMaxBackoff bound the delay
+	// between processing attempts
+	inboundWebhookMinBackoff = 30 * time.Second
+	inboundWebhookMaxBackoff = time.Hour
+	// Dead letter page sizes
+	defaultDeadLetters = 50
+	maxDeadLetters     = 200
+)
+
+// Inbound webhook errors
+var (
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	ErrDeadLetterReplayed = errors.New("dead letter already replayed")
+)
+
+// Inbound webhook metrics
+var inboundWebhooks = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_inbound_webhooks_total",
+		Help: "Webhooks received from external providers, by provider and outcome",
+	},
+	[]string{"provider", "outcome"},
+)
+
+// InboundWebhookHandler processes a received webhook. A failed webhook is
+// retried with backoff, holding back the later events of its ordering key,
+// and dead-lettered once out of attempts.
+type InboundWebhookHandler func(ctx context.Context, webhook *models.InboundWebhook) error
+
+// InboundWebhookService defines the interface for receiving webhooks from
+// payment gateways and partner systems
+type InboundWebhookService interface {
+	// Receive verifies and records a provider's webhook, reporting false for
+	// an event the provider already delivered
+	Receive(ctx context.Context, provider string, header http.Header, body []byte) (*models.InboundWebhook, bool, error)
+	// Handle registers the handler of a provider's event type; events
+	// without one are recorded and marked processed
+	Handle(provider, eventType string, handler InboundWebhookHandler) error
+	// ProcessReceived processes the received webhooks due by now, returning
+	// the number processed
+	ProcessReceived(ctx context.Context, now time.Time) (int, error)
+	// ListDeadLetters lists the latest dead letters, optionally of one
+	// provider
+	ListDeadLetters(ctx context.Context, provider string, limit int) ([]*models.InboundDeadLetter, error)
+	// ReplayDeadLetter returns a dead-lettered webhook to processing
+	ReplayDeadLetter(ctx context.Context, id uuid.UUID, actor string) (*models.InboundDeadLetter, error)
+}
+
+// inboundWebhookService implements InboundWebhookService interface
+type inboundWebhookService struct {
+	repo        repository.InboundWebhookRepository
+	providers   *inbound.Registry
+	maxAttempts int
+	logger      Logger
+
+	mu       sync.RWMutex
+	handlers map[string]map[string]InboundWebhookHandler
+}
+
+// NewInboundWebhookService creates a new instance of InboundWebhookService
+// dead-lettering webhooks that failed maxAttempts times
+func NewInboundWebhookService(repo repository.InboundWebhookRepository, providers *inbound.Registry, maxAttempts int, logger Logger) (InboundWebhookService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if providers == nil {
+		return nil, errors.New("provider registry is required")
+	}
+	if maxAttempts <= 0 {
+		return nil, errors.New("max attempts must be positive")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &inboundWebhookService{
+		repo:        repo,
+		providers:   providers,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+		handlers:    make(map[string]map[string]InboundWebhookHandler),
+	}, nil
+}
+
+// Receive checks the webhook's signature and records it for processing.
+// Providers retry until answered, so a redelivered event is acknowledged
+// without being recorded again.
+func (s *inboundWebhookService) Receive(ctx context.Context, provider string, header http.Header, body []byte) (*models.InboundWebhook, bool, error) {
+	adapter, err := s.providers.Get(provider)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now().UTC()
+	if err := adapter.Verify(header, body, now); err != nil {
+		inboundWebhooks.WithLabelValues(provider, "refused").Inc()
+		s.logger.Warn("inbound webhook refused", "provider", provider, "error", err)
+		return nil, false, err
+	}
+	event, err := adapter.Parse(header, body)
+	if err != nil {
+		inboundWebhooks.WithLabelValues(provider, "refused").Inc()
+		return nil, false, err
+	}
+
+	webhook := &models.InboundWebhook{
+		Provider:    provider,
+		EventID:     event.ID,
+		EventType:   event.Type,
+		OrderingKey: event.OrderingKey,
+		Payload:     append([]byte(nil), body...),
+		ReceivedAt:  now,
+	}
+	recorded, err := s.repo.RecordInboundWebhook(ctx, webhook)
+	if err != nil {
+		s.logger.Error("failed to record inbound webhook", err, "provider", provider, "eventID", event.ID)
+		return nil, false, fmt.Errorf("failed to record inbound webhook: %w", err)
+	}
+
+	if !recorded {
+		inboundWebhooks.WithLabelValues(provider, "duplicate").Inc()
+		s.logger.Info("duplicate inbound webhook acknowledged", "provider", provider, "eventID", event.ID)
+		return webhook, false, nil
+	}
+
+	inboundWebhooks.WithLabelValues(provider, "received").Inc()
+	return webhook, true, nil
+}
+
+// Handle registers the handler of a configured provider's event type
+func (s *inboundWebhookService) Handle(provider, eventType string, handler InboundWebhookHandler) error {
+	if _, err := s.providers.Get(provider); err != nil {
+		return err
+	}
+	if eventType == "" || handler == nil {
+		return errors.New("event type and handler are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.handlers[provider] == nil {
+		s.handlers[provider] = make(map[string]InboundWebhookHandler)
+	}
+	if _, exists := s.handlers[provider][eventType]; exists {
+		return fmt.Errorf("duplicate handler of %s %s webhooks", provider, eventType)
+	}
+	s.handlers[provider][eventType] = handler
+	return nil
+}
+
+// ProcessReceived processes received webhooks in the order they arrived. A
+// webhook that is not due or fails holds back the later ones of its ordering
+// key until it is processed or dead-lettered; other keys carry on.
+func (s *inboundWebhookService) ProcessReceived(ctx context.Context, now time.Time) (int, error) {
+	received, err := s.repo.ListReceivedInboundWebhooks(ctx, inboundWebhookBatch)
+	if err != nil {
+		s.logger.Error("failed to list received webhooks", err)
+		return 0, err
+	}
+
+	blocked := make(map[string]bool)
+	processed := 0
+	for _, webhook := range received {
+		key := webhook.Provider + "/" + webhook.OrderingKey
+		if blocked[key] {
+			continue
+		}
+		if webhook.NextAttemptAt.After(now) {
+			blocked[key] = true
+			continue
+		}
+
+		if err := s.process(ctx, webhook); err != nil {
+			if webhook.Attempts+1 >= s.maxAttempts {
+				s.deadLetter(ctx, webhook, err, now)
+				continue
+			}
+
+			blocked[key] = true
+			next := now.Add(inboundWebhookBackoff(webhook.Attempts + 1))
+			if err := s.repo.RescheduleInboundWebhook(ctx, webhook.ID, next, err.Error()); err != nil {
+				s.logger.Error("failed to reschedule inbound webhook", err, "webhookID", webhook.ID)
+			}
+			s.logger.Warn("inbound webhook processing failed",
+				"webhookID", webhook.ID,
+				"provider", webhook.Provider,
+				"eventType", webhook.EventType,
+				"nextAttemptAt", next,
+				"error", err)
+			continue
+		}
+
+		if err := s.repo.MarkInboundWebhookProcessed(ctx, webhook.ID, now); err != nil {
+			// Left received, it is processed again; handlers tolerate
+			// redelivered events as providers redeliver them too
+			s.logger.Error("failed to mark inbound webhook processed", err, "webhookID", webhook.ID)
+			blocked[key] = true
+			continue
+		}
+		inboundWebhooks.WithLabelValues(webhook.Provider, "processed").Inc()
+		processed++
+	}
+
+	if processed > 0 {
+		s.logger.Info("inbound webhooks processed", "count", processed)
+	}
+
+	return processed, nil
+}
+
+// process runs the handler of the webhook's event type, if any
+func (s *inboundWebhookService) process(ctx context.Context, webhook *models.InboundWebhook) error {
+	s.mu.RLock()
+	handler := s.handlers[webhook.Provider][webhook.EventType]
+	s.mu.RUnlock()
+
+	if handler == nil {
+		s.logger.Info("inbound webhook has no handler",
+			"provider", webhook.Provider,
+			"eventType", webhook.EventType,
+			"eventID", webhook.EventID)
+		return nil
+	}
+	return handler(ctx, webhook)
+}
+
+// deadLetter moves a webhook out of attempts to the dead letters, letting
+// the later events of its ordering key through
+func (s *inboundWebhookService) deadLetter(ctx context.Context, webhook *models.InboundWebhook, cause error, now time.Time) {
+	if err := s.repo.DeadLetterInboundWebhook(ctx, webhook.ID, cause.Error(), now); err != nil {
+		s.logger.Error("failed to dead-letter inbound webhook", err, "webhookID", webhook.ID)
+		return
+	}
+
+	inboundWebhooks.WithLabelValues(webhook.Provider, "dead_lettered").Inc()
+	s.logger.Error("inbound webhook dead-lettered", cause,
+		"webhookID", webhook.ID,
+		"provider", webhook.Provider,
+		"eventType", webhook.EventType,
+		"eventID", webhook.EventID,
+		"attempts", webhook.Attempts+1)
+}
+
+// ListDeadLetters returns the latest dead letters, newest first
+func (s *inboundWebhookService) ListDeadLetters(ctx context.Context, provider string, limit int) ([]*models.InboundDeadLetter, error) {
+	if limit <= 0 {
+		limit = defaultDeadLetters
+	}
+	if limit > maxDeadLetters {
+		limit = maxDeadLetters
+	}
+
+	letters, err := s.repo.ListDeadLetters(ctx, provider, limit)
+	if err != nil {
+		s.logger.Error("failed to list dead letters", err)
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	return letters, nil
+}
+
+// ReplayDeadLetter returns a dead-lettered webhook to processing with fresh
+// attempts, typically once the cause of its failures was fixed
+func (s *inboundWebhookService) ReplayDeadLetter(ctx context.Context, id uuid.UUID, actor string) (*models.InboundDeadLetter, error) {
+	letter, err := s.repo.ReplayDeadLetter(ctx, id, time.Now().UTC())
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDeadLetterNotFound):
+			return nil, ErrDeadLetterNotFound
+		case errors.Is(err, repository.ErrDeadLetterReplayed):
+			return nil, ErrDeadLetterReplayed
+		}
+		s.logger.Error("failed to replay dead letter", err, "deadLetterID", id)
+		return nil, fmt.Errorf("failed to replay dead letter: %w", err)
+	}
+
+	s.logger.Info("dead letter replayed",
+		"deadLetterID", id,
+		"webhookID", letter.WebhookID,
+		"provider", letter.Provider,
+		"actor", actor)
+
+	return letter, nil
+}
+
+// inboundWebhookBackoff doubles the delay with every attempt up to
+// inboundWebhookMaxBackoff
+func inboundWebhookBackoff(attempts int) time.Duration {
+	backoff := inboundWebhookMinBackoff
+	for i := 1; i < attempts && backoff < inboundWebhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > inboundWebhookMaxBackoff {
+		backoff = inboundWebhookMaxBackoff
+	}
+	return backoff
+}
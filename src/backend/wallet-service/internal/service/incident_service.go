@@ -0,0 +1,373 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/models"
+	"internal/repository"
+)
+
+// Incident audit actions
+const (
+	incidentPauseAction  = "incident.pause"
+	incidentResumeAction = "incident.resume"
+)
+
+// Incident limits
+const (
+	// maxIncidentSources bounds the sources an incident allows through
+	maxIncidentSources = 20
+	// incidentScheduleActor resumes incidents at their scheduled time
+	incidentScheduleActor = "schedule"
+)
+
+// Incident errors
+var (
+	ErrInvalidIncident    = errors.New("invalid incident")
+	ErrIncidentNotFound   = errors.New("no incident is active")
+	ErrIncidentConflict   = errors.New("incident was changed concurrently")
+	ErrTransactionsPaused = errors.New("transactions are paused")
+)
+
+// pausedTransactions counts transactions refused by an incident by type
+var pausedTransactions = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_incident_paused_transactions_total",
+		Help: "Transactions refused while an incident paused their type, by type",
+	},
+	[]string{"type"},
+)
+
+// IncidentInput is what an incident pauses
+type IncidentInput struct {
+	Reason           string
+	PauseDebits      bool
+	PauseCredits     bool
+	PauseRefunds     bool
+	PauseAdjustments bool
+	// AllowedSources are the transaction sources let through while their
+	// type is paused
+	AllowedSources []string
+	// ResumeAt schedules the incident to resume by itself
+	ResumeAt *time.Time
+}
+
+// IncidentService defines the interface for the incident switch pausing
+// transaction types across the service
+type IncidentService interface {
+	// Get returns the active incident
+	Get(ctx context.Context) (*models.Incident, error)
+	// Pause starts an incident, or changes the active one
+	Pause(ctx context.Context, input IncidentInput, actor string) (*models.Incident, error)
+	// Resume resumes the active incident
+	Resume(ctx context.Context, actor, reason string) (*models.Incident, error)
+	// Refresh reloads the active incident and applies its scheduled resume
+	Refresh(ctx context.Context) error
+	// Check refuses a transaction whose type the active incident pauses
+	Check(tx *models.Transaction) error
+}
+
+// incidentService implements IncidentService interface. The active
+// incident is held in memory so checking transactions costs no query; each
+// replica reloads it every refresh, and at once after its own changes.
+type incidentService struct {
+	repo        repository.IncidentRepository
+	audit       repository.AuditRepository
+	maxDuration time.Duration
+	logger      Logger
+
+	mu     sync.RWMutex
+	active *models.Incident
+}
+
+// NewIncidentService creates a new instance of IncidentService. Resumes
+// can be scheduled up to maxDuration ahead.
+func NewIncidentService(repo repository.IncidentRepository, audit repository.AuditRepository, maxDuration time.Duration, logger Logger) (IncidentService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if audit == nil {
+		return nil, errors.New("audit repository is required")
+	}
+	if maxDuration <= 0 {
+		return nil, errors.New("max duration must be positive")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &incidentService{
+		repo:        repo,
+		audit:       audit,
+		maxDuration: maxDuration,
+		logger:      logger,
+	}, nil
+}
+
+// Get returns the active incident
+func (s *incidentService) Get(ctx context.Context) (*models.Incident, error) {
+	incident, err := s.repo.GetActiveIncident(ctx)
+	if errors.Is(err, repository.ErrIncidentNotFound) {
+		return nil, ErrIncidentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incident: %w", err)
+	}
+	return incident, nil
+}
+
+// Pause starts an incident pausing the input's transaction types, or
+// replaces what the active incident pauses. The incident applies on this
+// replica at once and on the others by their next refresh.
+func (s *incidentService) Pause(ctx context.Context, input IncidentInput, actor string) (*models.Incident, error) {
+	now := time.Now().UTC()
+	input.Reason = strings.TrimSpace(input.Reason)
+	sources, err := s.validate(&input, now)
+	if err != nil {
+		return nil, err
+	}
+
+	incident, err := s.repo.GetActiveIncident(ctx)
+	started := errors.Is(err, repository.ErrIncidentNotFound)
+	switch {
+	case started:
+		incident = &models.Incident{
+			ID:        uuid.New(),
+			StartedBy: actor,
+			StartedAt: now,
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to get incident: %w", err)
+	}
+
+	incident.Reason = input.Reason
+	incident.PauseDebits = input.PauseDebits
+	incident.PauseCredits = input.PauseCredits
+	incident.PauseRefunds = input.PauseRefunds
+	incident.PauseAdjustments = input.PauseAdjustments
+	incident.AllowedSources = sources
+	incident.ResumeAt = input.ResumeAt
+	incident.UpdatedBy = actor
+	incident.UpdatedAt = now
+
+	if started {
+		err = s.repo.StartIncident(ctx, incident)
+	} else {
+		err = s.repo.UpdateIncident(ctx, incident)
+	}
+	params := map[string]string{
+		"incident_id":       incident.ID.String(),
+		"pause_debits":      fmt.Sprint(incident.PauseDebits),
+		"pause_credits":     fmt.Sprint(incident.PauseCredits),
+		"pause_refunds":     fmt.Sprint(incident.PauseRefunds),
+		"pause_adjustments": fmt.Sprint(incident.PauseAdjustments),
+		"allowed_sources":   strings.Join(sources, ","),
+	}
+	if incident.ResumeAt != nil {
+		params["resume_at"] = incident.ResumeAt.Format(time.RFC3339)
+	}
+	if err := s.audited(ctx, incidentPauseAction, actor, input.Reason, params, err); err != nil {
+		return nil, err
+	}
+
+	s.setActive(incident)
+	s.logger.Warn("incident pausing transactions", "incidentID", incident.ID, "actor", actor,
+		"debits", incident.PauseDebits, "credits", incident.PauseCredits,
+		"refunds", incident.PauseRefunds, "adjustments", incident.PauseAdjustments)
+	return incident, nil
+}
+
+// Resume resumes the active incident, letting every transaction through
+// again
+func (s *incidentService) Resume(ctx context.Context, actor, reason string) (*models.Incident, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a reason is required", ErrInvalidIncident)
+	}
+
+	incident, err := s.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.resume(ctx, incident, actor, reason)
+}
+
+// Refresh reloads the active incident, resuming it once its scheduled
+// resume has passed. Every replica refreshes; the conditional resume lets
+// one of them apply and audit the schedule.
+func (s *incidentService) Refresh(ctx context.Context) error {
+	incident, err := s.repo.GetActiveIncident(ctx)
+	if errors.Is(err, repository.ErrIncidentNotFound) {
+		s.setActive(nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to refresh incident: %w", err)
+	}
+
+	if incident.InEffect(time.Now().UTC()) {
+		s.setActive(incident)
+		return nil
+	}
+	_, err = s.resume(ctx, incident, incidentScheduleActor, "scheduled resume")
+	if errors.Is(err, ErrIncidentNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Check refuses a transaction whose type the active incident pauses,
+// unless its source is allowed through. An incident past its scheduled
+// resume no longer pauses anything, even before the refresh resumes it.
+func (s *incidentService) Check(tx *models.Transaction) error {
+	s.mu.RLock()
+	incident := s.active
+	s.mu.RUnlock()
+
+	if incident == nil || !incident.Pauses(tx.Type) || !incident.InEffect(time.Now().UTC()) {
+		return nil
+	}
+	if incident.AllowsSource(tx.Metadata[models.TransactionSourceKey]) {
+		return nil
+	}
+	pausedTransactions.WithLabelValues(tx.Type.String()).Inc()
+	return fmt.Errorf("%w: %s transactions are paused by an incident", ErrTransactionsPaused, tx.Type)
+}
+
+// resume resumes an incident once only, a resume lost to another replica or
+// operator being not found
+func (s *incidentService) resume(ctx context.Context, incident *models.Incident, actor, reason string) (*models.Incident, error) {
+	resumed, err := s.repo.ResumeIncident(ctx, incident.ID, actor, time.Now().UTC())
+	if errors.Is(err, repository.ErrIncidentNotFound) {
+		s.setActive(nil)
+		return nil, ErrIncidentNotFound
+	}
+	params := map[string]string{"incident_id": incident.ID.String()}
+	if err := s.audited(ctx, incidentResumeAction, actor, reason, params, err); err != nil {
+		return nil, err
+	}
+
+	s.setActive(nil)
+	s.logger.Info("incident resumed", "incidentID", incident.ID, "actor", actor)
+	return resumed, nil
+}
+
+// setActive replaces the incident checked against transactions
+func (s *incidentService) setActive(incident *models.Incident) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = incident
+}
+
+// validate checks an incident's input, returning its allowed sources
+// trimmed, deduplicated and sorted
+func (s *incidentService) validate(input *IncidentInput, now time.Time) ([]string, error) {
+	if input.Reason == "" {
+		return nil, fmt.Errorf("%w: a reason is required", ErrInvalidIncident)
+	}
+	if !input.PauseDebits && !input.PauseCredits && !input.PauseRefunds && !input.PauseAdjustments {
+		return nil, fmt.Errorf("%w: at least one transaction type must be paused", ErrInvalidIncident)
+	}
+	if input.ResumeAt != nil {
+		resumeAt := input.ResumeAt.UTC()
+		if !resumeAt.After(now) {
+			return nil, fmt.Errorf("%w: resume_at must be in the future", ErrInvalidIncident)
+		}
+		if resumeAt.After(now.Add(s.maxDuration)) {
+			return nil, fmt.Errorf("%w: resume_at must be within %s", ErrInvalidIncident, s.maxDuration)
+		}
+		input.ResumeAt = &resumeAt
+	}
+
+	if len(input.AllowedSources) > maxIncidentSources {
+		return nil, fmt.Errorf("%w: at most %d allowed sources", ErrInvalidIncident, maxIncidentSources)
+	}
+	seen := make(map[string]bool, len(input.AllowedSources))
+	sources := make([]string, 0, len(input.AllowedSources))
+	for _, source := range input.AllowedSources {
+		source = strings.TrimSpace(source)
+		if source == "" || len(source) > maxFeeSourceLength {
+			return nil, fmt.Errorf("%w: allowed sources must be 1 to %d characters", ErrInvalidIncident, maxFeeSourceLength)
+		}
+		if !seen[source] {
+			seen[source] = true
+			sources = append(sources, source)
+		}
+	}
+	sort.Strings(sources)
+	return sources, nil
+}
+
+// audited records an incident change in the operator audit log regardless
+// of its outcome, returning the change's error
+func (s *incidentService) audited(ctx context.Context, action, actor, reason string, params map[string]string, err error) error {
+	switch {
+	case err == nil:
+	case errors.Is(err, repository.ErrIncidentNotFound), errors.Is(err, repository.ErrIncidentConflict):
+		err = ErrIncidentConflict
+	default:
+		s.logger.Error("failed to change incident", err, "action", action, "incidentID", params["incident_id"])
+		err = fmt.Errorf("failed to change incident: %w", err)
+	}
+
+	entry := &models.OperatorAction{
+		Action: action,
+		Actor:  actor,
+		Reason: reason,
+		Params: params,
+		Status: models.OperatorActionSucceeded,
+	}
+	if err != nil {
+		entry.Status = models.OperatorActionFailed
+		entry.Error = err.Error()
+	}
+
+	if auditErr := s.audit.RecordOperatorAction(ctx, entry); auditErr != nil {
+		s.logger.Error("failed to audit incident change", auditErr, "action", action, "incidentID", params["incident_id"])
+	}
+	return err
+}
+
+// incidentWalletService refuses the transactions the active incident
+// pauses before they reach the wallet service, so every caller of it, from
+// the API to recurring debits and usage chargebacks, is paused alike
+type incidentWalletService struct {
+	WalletService
+	incidents IncidentService
+}
+
+// NewIncidentWalletService wraps a wallet service so transactions are
+// checked against the active incident before being processed
+func NewIncidentWalletService(wallets WalletService, incidents IncidentService) (WalletService, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if incidents == nil {
+		return nil, errors.New("incident service is required")
+	}
+
+	return &incidentWalletService{
+		WalletService: wallets,
+		incidents:     incidents,
+	}, nil
+}
+
+// ProcessTransaction refuses a paused transaction with
+// ErrTransactionsPaused, processing any other
+func (s *incidentWalletService) ProcessTransaction(ctx context.Context, tx *models.Transaction) (*models.CreditLimitWarning, error) {
+	if tx != nil {
+		if err := s.incidents.Check(tx); err != nil {
+			return nil, err
+		}
+	}
+	return s.WalletService.ProcessTransaction(ctx, tx)
+}
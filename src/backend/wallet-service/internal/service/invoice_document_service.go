@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+	"github.com/shopspring/decimal"                           // v1.3.1
+
+	"internal/events"
+	"internal/invoicepdf"
+	"internal/models"
+	"internal/notify"
+	"internal/repository"
+)
+
+// maxInvoiceLineItems bounds the line items of an invoice document
+const maxInvoiceLineItems = 500
+
+// Invoice document errors
+var (
+	ErrInvalidInvoiceDocument  = errors.New("invalid invoice document")
+	ErrInvoiceDocumentNotFound = errors.New("invoice document not found")
+	ErrInvoiceConflict         = errors.New("invoice number already issued")
+)
+
+// invoiceDocuments counts invoice PDFs by what was done with them
+var invoiceDocuments = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_invoice_documents_total",
+		Help: "Invoice PDFs rendered, delivered and re-sent, and failures to render them",
+	},
+	[]string{"outcome"},
+)
+
+// InvoiceDocumentService defines the interface for the PDFs of issued
+// invoices and their delivery to customers
+type InvoiceDocumentService interface {
+	Issue(ctx context.Context, doc *models.InvoiceDocument) (*models.InvoicePDF, error)
+	Resend(ctx context.Context, invoiceID uuid.UUID) (*models.InvoicePDF, error)
+	GetPDF(ctx context.Context, invoiceID uuid.UUID) (*models.InvoicePDF, []byte, error)
+	Attachments(ctx context.Context, env *events.Envelope) ([]notify.Attachment, error)
+}
+
+// invoiceDocumentService implements InvoiceDocumentService interface.
+// Invoices are delivered as invoice.issued events through the notification
+// channel service, which emails the stored PDF with them.
+type invoiceDocumentService struct {
+	repo     repository.InvoiceDocumentRepository
+	renderer *invoicepdf.Renderer
+	channels NotificationChannelService
+	// receivables give the due date of invoices issued without one
+	receivables InvoiceReceivableService
+	// publicURL is the base URL of the API linked from notifications, none
+	// when empty
+	publicURL string
+	logger    Logger
+}
+
+// NewInvoiceDocumentService creates a new instance of
+// InvoiceDocumentService. Invoices issued without a due date fall due by
+// the customer's payment terms in receivables. Notifications link to the
+// PDF under publicURL when it is set.
+func NewInvoiceDocumentService(repo repository.InvoiceDocumentRepository, renderer *invoicepdf.Renderer,
+	channels NotificationChannelService, receivables InvoiceReceivableService, publicURL string, logger Logger) (InvoiceDocumentService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if renderer == nil {
+		return nil, errors.New("renderer is required")
+	}
+	if channels == nil {
+		return nil, errors.New("notification channel service is required")
+	}
+	if receivables == nil {
+		return nil, errors.New("invoice receivable service is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &invoiceDocumentService{
+		repo:        repo,
+		renderer:    renderer,
+		channels:    channels,
+		receivables: receivables,
+		publicURL:   strings.TrimRight(publicURL, "/"),
+		logger:      logger,
+	}, nil
+}
+
+// Issue renders and stores the PDF of an invoice closed by the billing cycle
+// and delivers it to the customer. Issuing an invoice again returns its
+// stored PDF, delivering it only if the first delivery failed, so retried
+// cycle closes do not notify customers twice. Invoices without a due date
+// fall due by the customer's payment terms.
+func (s *invoiceDocumentService) Issue(ctx context.Context, doc *models.InvoiceDocument) (*models.InvoicePDF, error) {
+	if doc != nil && doc.DueDate.IsZero() && !doc.IssueDate.IsZero() {
+		terms, err := s.receivables.PaymentTerms(ctx, doc.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		doc.DueDate = terms.DueDate(doc.IssueDate)
+	}
+	if err := validateInvoiceDocument(doc); err != nil {
+		return nil, err
+	}
+
+	stored, _, err := s.repo.GetInvoiceDocument(ctx, doc.InvoiceID)
+	switch {
+	case err == nil:
+		if stored.CustomerID != doc.CustomerID || stored.InvoiceNumber != doc.InvoiceNumber {
+			return nil, fmt.Errorf("%w: invoice %s was issued as %s", ErrInvoiceConflict, doc.InvoiceID, stored.InvoiceNumber)
+		}
+		if stored.Deliveries > 0 {
+			return stored, nil
+		}
+		return s.deliver(ctx, stored)
+	case !errors.Is(err, repository.ErrInvoiceDocumentNotFound):
+		s.logger.Error("failed to get invoice document", err, "invoiceID", doc.InvoiceID)
+		return nil, fmt.Errorf("failed to get invoice document: %w", err)
+	}
+
+	pdf, err := s.renderer.Render(doc)
+	if err != nil {
+		invoiceDocuments.WithLabelValues("render_failed").Inc()
+		s.logger.Error("failed to render invoice", err, "invoiceID", doc.InvoiceID)
+		return nil, fmt.Errorf("failed to render invoice: %w", err)
+	}
+
+	stored = &models.InvoicePDF{
+		InvoiceID:     doc.InvoiceID,
+		CustomerID:    doc.CustomerID,
+		InvoiceNumber: doc.InvoiceNumber,
+		Currency:      doc.Currency,
+		TotalAmount:   doc.TotalAmount,
+		DueDate:       doc.DueDate,
+		RenderedAt:    time.Now().UTC(),
+	}
+	if err := s.repo.CreateInvoiceDocument(ctx, stored, pdf); err != nil {
+		if errors.Is(err, repository.ErrInvoiceDocumentExists) {
+			return nil, fmt.Errorf("%w: %s was issued to the customer already", ErrInvoiceConflict, doc.InvoiceNumber)
+		}
+		s.logger.Error("failed to store invoice document", err, "invoiceID", doc.InvoiceID)
+		return nil, fmt.Errorf("failed to store invoice document: %w", err)
+	}
+	invoiceDocuments.WithLabelValues("rendered").Inc()
+
+	s.logger.Info("invoice document rendered",
+		"invoiceID", stored.InvoiceID,
+		"customerID", stored.CustomerID,
+		"invoiceNumber", stored.InvoiceNumber,
+		"sizeBytes", stored.SizeBytes)
+
+	return s.deliver(ctx, stored)
+}
+
+// Resend delivers the stored PDF of an invoice to the customer again
+func (s *invoiceDocumentService) Resend(ctx context.Context, invoiceID uuid.UUID) (*models.InvoicePDF, error) {
+	stored, _, err := s.GetPDF(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivered, err := s.deliver(ctx, stored)
+	if err != nil {
+		return nil, err
+	}
+	invoiceDocuments.WithLabelValues("resent").Inc()
+	return delivered, nil
+}
+
+// GetPDF returns an invoice's stored PDF, as first rendered
+func (s *invoiceDocumentService) GetPDF(ctx context.Context, invoiceID uuid.UUID) (*models.InvoicePDF, []byte, error) {
+	stored, pdf, err := s.repo.GetInvoiceDocument(ctx, invoiceID)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvoiceDocumentNotFound) {
+			return nil, nil, ErrInvoiceDocumentNotFound
+		}
+		s.logger.Error("failed to get invoice document", err, "invoiceID", invoiceID)
+		return nil, nil, fmt.Errorf("failed to get invoice document: %w", err)
+	}
+
+	return stored, pdf, nil
+}
+
+// Attachments returns the stored PDF of the invoice of an invoice.issued
+// event, for its email. Invoices notified without a stored PDF have none.
+func (s *invoiceDocumentService) Attachments(ctx context.Context, env *events.Envelope) ([]notify.Attachment, error) {
+	var payload events.InvoiceIssuedV1
+	if err := json.Unmarshal(env.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode issued invoice: %w", err)
+	}
+	invoiceID, err := uuid.Parse(payload.InvoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode issued invoice: %w", err)
+	}
+
+	stored, pdf, err := s.repo.GetInvoiceDocument(ctx, invoiceID)
+	if errors.Is(err, repository.ErrInvoiceDocumentNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice document: %w", err)
+	}
+
+	return []notify.Attachment{{
+		Name:        stored.FileName(),
+		ContentType: "application/pdf",
+		Data:        pdf,
+	}}, nil
+}
+
+// deliver publishes the invoice to the customer's channels and webhooks and
+// counts the delivery
+func (s *invoiceDocumentService) deliver(ctx context.Context, stored *models.InvoicePDF) (*models.InvoicePDF, error) {
+	notice := &models.InvoiceNotice{
+		InvoiceID:     stored.InvoiceID,
+		CustomerID:    stored.CustomerID,
+		InvoiceNumber: stored.InvoiceNumber,
+		Currency:      stored.Currency,
+		TotalAmount:   stored.TotalAmount,
+		DueDate:       stored.DueDate,
+	}
+	if s.publicURL != "" {
+		notice.URL = fmt.Sprintf("%s/api/v1/invoices/%s/pdf", s.publicURL, stored.InvoiceID)
+	}
+	if err := s.channels.NotifyInvoice(ctx, notice); err != nil {
+		return nil, err
+	}
+
+	delivered, err := s.repo.RecordInvoiceDelivery(ctx, stored.InvoiceID, time.Now().UTC())
+	if err != nil {
+		s.logger.Error("failed to record invoice delivery", err, "invoiceID", stored.InvoiceID)
+		return nil, fmt.Errorf("failed to record invoice delivery: %w", err)
+	}
+	invoiceDocuments.WithLabelValues("delivered").Inc()
+	return delivered, nil
+}
+
+// validateInvoiceDocument checks an invoice closed by the billing cycle. Its
+// line items must add up to the subtotal and the subtotal and tax to the
+// total, so the PDF never shows figures that disagree.
+func validateInvoiceDocument(doc *models.InvoiceDocument) error {
+	switch {
+	case doc == nil:
+		return fmt.Errorf("%w: invoice is required", ErrInvalidInvoiceDocument)
+	case doc.InvoiceID == uuid.Nil || doc.CustomerID == uuid.Nil:
+		return fmt.Errorf("%w: invoice_id and customer_id are required", ErrInvalidInvoiceDocument)
+	case strings.TrimSpace(doc.InvoiceNumber) == "" || len(doc.InvoiceNumber) > 64:
+		return fmt.Errorf("%w: invoice_number must be 1 to 64 characters", ErrInvalidInvoiceDocument)
+	case len(doc.Currency) != 3:
+		return fmt.Errorf("%w: currency must be a 3-letter code", ErrInvalidInvoiceDocument)
+	case doc.IssueDate.IsZero():
+		return fmt.Errorf("%w: issue_date is required", ErrInvalidInvoiceDocument)
+	case doc.DueDate.Before(doc.IssueDate):
+		return fmt.Errorf("%w: due_date must not be before issue_date", ErrInvalidInvoiceDocument)
+	case len(doc.LineItems) == 0 || len(doc.LineItems) > maxInvoiceLineItems:
+		return fmt.Errorf("%w: an invoice must have 1 to %d line items", ErrInvalidInvoiceDocument, maxInvoiceLineItems)
+	case doc.TaxAmount.IsNegative() || doc.TotalAmount.IsNegative():
+		return fmt.Errorf("%w: tax_amount and total_amount must not be negative", ErrInvalidInvoiceDocument)
+	}
+
+	sum := decimal.Zero
+	for i, item := range doc.LineItems {
+		if strings.TrimSpace(item.Description) == "" {
+			return fmt.Errorf("%w: line item %d has no description", ErrInvalidInvoiceDocument, i+1)
+		}
+		sum = sum.Add(item.Amount)
+	}
+	if !sum.Equal(doc.Subtotal) {
+		return fmt.Errorf("%w: line items add up to %s, not the subtotal %s", ErrInvalidInvoiceDocument, sum, doc.Subtotal)
+	}
+	if !doc.Subtotal.Add(doc.TaxAmount).Equal(doc.TotalAmount) {
+		return fmt.Errorf("%w: subtotal and tax add up to %s, not the total %s",
+			ErrInvalidInvoiceDocument, doc.Subtotal.Add(doc.TaxAmount), doc.TotalAmount)
+	}
+	return nil
+}
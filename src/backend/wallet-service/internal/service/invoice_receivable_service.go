@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+	"github.com/shopspring/decimal"                           // v1.3.1
+
+	"internal/currency"
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+)
+
+// daysPerYear is the day count of annual interest rates
+const daysPerYear = 365
+
+// Invoice receivable errors
+var (
+	ErrInvalidPaymentTerms       = errors.New("invalid payment terms")
+	ErrInvoiceReceivableNotFound = errors.New("invoice not found")
+	ErrInvalidInvoicePayment     = errors.New("invalid invoice payment")
+)
+
+// invoiceLateCharges counts the late fees and interest charged on overdue
+// invoices
+var invoiceLateCharges = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_invoice_late_charges_total",
+		Help: "Late fees charged and interest accruals on overdue invoices",
+	},
+	[]string{"mode"},
+)
+
+// LateFeePolicy is how invoices unpaid past their due date are charged
+type LateFeePolicy struct {
+	Mode models.LateFeeMode
+	// FeePercent is the one-off fee of FEE mode, a percentage of the
+	// invoice total
+	FeePercent decimal.Decimal
+	// AnnualRate is the yearly percentage of INTEREST mode
+	AnnualRate decimal.Decimal
+	// GracePeriod is how long after the due date charges start. Interest
+	// then accrues from the due date.
+	GracePeriod time.Duration
+}
+
+// InvoiceReceivableService defines the interface for payment terms and the
+// tracking of issued invoices until they are paid
+type InvoiceReceivableService interface {
+	// Handle tracks the invoices of invoice.issued events
+	Handle(ctx context.Context, event eventbus.Event) error
+	Track(ctx context.Context, notice *models.InvoiceNotice) (*models.InvoiceReceivable, error)
+	Get(ctx context.Context, invoiceID uuid.UUID) (*models.InvoiceReceivable, error)
+	RecordPayment(ctx context.Context, invoiceID uuid.UUID, amount decimal.Decimal, paidAt time.Time) (*models.InvoiceReceivable, error)
+	// AccrueLateCharges marks invoices unpaid past their due date overdue
+	// and charges them as of asOf, returning how many changed
+	AccrueLateCharges(ctx context.Context, asOf time.Time) (int, error)
+	Aging(ctx context.Context, asOf time.Time, currency string, customerID uuid.UUID) (*models.InvoiceAgingReport, error)
+	// PaymentTerms returns the terms a customer's invoices are issued with
+	PaymentTerms(ctx context.Context, customerID uuid.UUID) (models.PaymentTerms, error)
+	UpdatePaymentTerms(ctx context.Context, customerID uuid.UUID, terms models.PaymentTerms, actor string) (*models.CustomerSettings, error)
+}
+
+// invoiceReceivableService implements InvoiceReceivableService interface
+type invoiceReceivableService struct {
+	repo         repository.InvoiceReceivableRepository
+	customers    repository.CustomerRepository
+	currencies   *currency.Registry
+	defaultTerms models.PaymentTerms
+	policy       LateFeePolicy
+	logger       Logger
+}
+
+// NewInvoiceReceivableService creates a new instance of
+// InvoiceReceivableService. Customers without payment terms of their own
+// get defaultTerms; late charges are rounded to the precision of their
+// currency in currencies.
+func NewInvoiceReceivableService(repo repository.InvoiceReceivableRepository, customers repository.CustomerRepository,
+	currencies *currency.Registry, defaultTerms models.PaymentTerms, policy LateFeePolicy, logger Logger) (InvoiceReceivableService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if customers == nil {
+		return nil, errors.New("customer repository is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currencies are required")
+	}
+	if !defaultTerms.IsValid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPaymentTerms, defaultTerms)
+	}
+	if !policy.Mode.IsValid() {
+		return nil, fmt.Errorf("invalid late fee mode %q", policy.Mode)
+	}
+	if policy.FeePercent.IsNegative() || policy.AnnualRate.IsNegative() || policy.GracePeriod < 0 {
+		return nil, errors.New("late fee policy must not be negative")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &invoiceReceivableService{
+		repo:         repo,
+		customers:    customers,
+		currencies:   currencies,
+		defaultTerms: defaultTerms,
+		policy:       policy,
+		logger:       logger,
+	}, nil
+}
+
+// Handle tracks the invoice of an invoice.issued event, so invoices are
+// tracked whether rendered here or reported by the invoice service
+func (s *invoiceReceivableService) Handle(ctx context.Context, event eventbus.Event) error {
+	issued, ok := event.(eventbus.InvoiceIssued)
+	if !ok {
+		return nil
+	}
+
+	_, err := s.Track(ctx, issued.Notice)
+	return err
+}
+
+// Track starts tracking an issued invoice. Tracking an invoice again, as
+// re-sends do, returns it as tracked first.
+func (s *invoiceReceivableService) Track(ctx context.Context, notice *models.InvoiceNotice) (*models.InvoiceReceivable, error) {
+	terms, err := s.PaymentTerms(ctx, notice.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	dueDate := notice.DueDate
+	if dueDate.IsZero() {
+		dueDate = terms.DueDate(now)
+	}
+
+	receivable, created, err := s.repo.CreateInvoiceReceivable(ctx, &models.InvoiceReceivable{
+		InvoiceID:     notice.InvoiceID,
+		CustomerID:    notice.CustomerID,
+		InvoiceNumber: notice.InvoiceNumber,
+		Currency:      notice.Currency,
+		TotalAmount:   notice.TotalAmount,
+		DueDate:       dueDate.UTC(),
+		PaymentTerms:  terms,
+		Status:        models.InvoiceOpen,
+		AmountPaid:    decimal.Zero,
+		LateFee:       decimal.Zero,
+		Interest:      decimal.Zero,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		s.logger.Error("failed to track invoice", err, "invoiceID", notice.InvoiceID)
+		return nil, fmt.Errorf("failed to track invoice: %w", err)
+	}
+
+	if created {
+		s.logger.Info("invoice tracked",
+			"invoiceID", receivable.InvoiceID,
+			"customerID", receivable.CustomerID,
+			"dueDate", receivable.DueDate,
+			"paymentTerms", receivable.PaymentTerms)
+	}
+	return s.present(receivable), nil
+}
+
+// Get returns a tracked invoice with its late charges
+func (s *invoiceReceivableService) Get(ctx context.Context, invoiceID uuid.UUID) (*models.InvoiceReceivable, error) {
+	receivable, err := s.repo.GetInvoiceReceivable(ctx, invoiceID)
+	if errors.Is(err, repository.ErrInvoiceReceivableNotFound) {
+		return nil, ErrInvoiceReceivableNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to get invoice receivable", err, "invoiceID", invoiceID)
+		return nil, fmt.Errorf("failed to get invoice receivable: %w", err)
+	}
+
+	return s.present(receivable), nil
+}
+
+// RecordPayment records a payment towards an invoice and its late charges.
+// The invoice is paid once nothing is left to pay; payments above what is
+// left are refused.
+func (s *invoiceReceivableService) RecordPayment(ctx context.Context, invoiceID uuid.UUID, amount decimal.Decimal, paidAt time.Time) (*models.InvoiceReceivable, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidInvoicePayment)
+	}
+	if paidAt.IsZero() {
+		paidAt = time.Now()
+	}
+
+	receivable, err := s.repo.RecordInvoicePayment(ctx, invoiceID, amount, paidAt.UTC())
+	switch {
+	case errors.Is(err, repository.ErrInvoiceReceivableNotFound):
+		return nil, ErrInvoiceReceivableNotFound
+	case errors.Is(err, repository.ErrInvoiceOverpaid):
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInvoicePayment, err)
+	case err != nil:
+		s.logger.Error("failed to record invoice payment", err, "invoiceID", invoiceID)
+		return nil, fmt.Errorf("failed to record invoice payment: %w", err)
+	}
+
+	s.logger.Info("invoice payment recorded",
+		"invoiceID", invoiceID,
+		"amount", amount,
+		"status", receivable.Status)
+
+	return s.present(receivable), nil
+}
+
+// AccrueLateCharges marks unpaid invoices past their due date overdue and
+// charges those past the grace period. A one-off fee is charged once;
+// interest accrues by whole days since it was last accrued.
+func (s *invoiceReceivableService) AccrueLateCharges(ctx context.Context, asOf time.Time) (int, error) {
+	receivables, err := s.repo.ListUnpaidInvoiceReceivables(ctx, "", uuid.Nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list unpaid invoices: %w", err)
+	}
+
+	changed := 0
+	for _, receivable := range receivables {
+		if !receivable.DueDate.Before(asOf) {
+			// Listed by due date, so the remaining invoices are not due yet
+			break
+		}
+		charged := s.charge(receivable, asOf)
+		if !charged && receivable.Status == models.InvoiceOverdue {
+			continue
+		}
+		receivable.Status = models.InvoiceOverdue
+
+		_, err := s.repo.UpdateInvoiceLateCharges(ctx, receivable)
+		if errors.Is(err, repository.ErrInvoiceReceivableNotFound) {
+			// Paid in full meanwhile
+			continue
+		}
+		if err != nil {
+			return changed, fmt.Errorf("failed to update late charges of invoice %s: %w", receivable.InvoiceID, err)
+		}
+		if charged {
+			invoiceLateCharges.WithLabelValues(string(s.policy.Mode)).Inc()
+		}
+		changed++
+	}
+
+	if changed > 0 {
+		s.logger.Info("overdue invoices charged", "invoices", changed, "asOf", asOf)
+	}
+	return changed, nil
+}
+
+// Aging splits what is left to pay of unpaid invoices by how long they are
+// past due as of asOf, per currency and per customer. Currency and customer
+// narrow the report when set.
+func (s *invoiceReceivableService) Aging(ctx context.Context, asOf time.Time, currencyCode string, customerID uuid.UUID) (*models.InvoiceAgingReport, error) {
+	receivables, err := s.repo.ListUnpaidInvoiceReceivables(ctx, currencyCode, customerID)
+	if err != nil {
+		s.logger.Error("failed to list unpaid invoices", err)
+		return nil, fmt.Errorf("failed to list unpaid invoices: %w", err)
+	}
+
+	type customerKey struct {
+		customerID uuid.UUID
+		currency   string
+	}
+	totals := make(map[string]*models.CurrencyAging)
+	customers := make(map[customerKey]*models.CustomerAging)
+	report := &models.InvoiceAgingReport{
+		AsOf:      asOf.UTC(),
+		Totals:    []*models.CurrencyAging{},
+		Customers: []*models.CustomerAging{},
+	}
+	for _, receivable := range receivables {
+		outstanding := receivable.AmountDue().Sub(receivable.AmountPaid)
+		days := daysPastDue(receivable.DueDate, asOf)
+
+		total, ok := totals[receivable.Currency]
+		if !ok {
+			total = &models.CurrencyAging{Currency: receivable.Currency}
+			totals[receivable.Currency] = total
+			report.Totals = append(report.Totals, total)
+		}
+		total.Aging.Add(outstanding, days)
+
+		key := customerKey{receivable.CustomerID, receivable.Currency}
+		customer, ok := customers[key]
+		if !ok {
+			customer = &models.CustomerAging{CustomerID: receivable.CustomerID, Currency: receivable.Currency}
+			customers[key] = customer
+			report.Customers = append(report.Customers, customer)
+		}
+		customer.OpenInvoices++
+		customer.Aging.Add(outstanding, days)
+	}
+
+	sort.Slice(report.Totals, func(i, j int) bool {
+		return report.Totals[i].Currency < report.Totals[j].Currency
+	})
+	sort.Slice(report.Customers, func(i, j int) bool {
+		a, b := report.Customers[i], report.Customers[j]
+		if a.CustomerID != b.CustomerID {
+			return a.CustomerID.String() < b.CustomerID.String()
+		}
+		return a.Currency < b.Currency
+	})
+	return report, nil
+}
+
+// PaymentTerms returns the customer's payment terms, or the default for
+// customers without terms of their own
+func (s *invoiceReceivableService) PaymentTerms(ctx context.Context, customerID uuid.UUID) (models.PaymentTerms, error) {
+	settings, err := s.customers.GetSettings(ctx, customerID)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return s.defaultTerms, nil
+	}
+	if err != nil {
+		s.logger.Error("failed to get customer settings", err, "customerID", customerID)
+		return "", fmt.Errorf("failed to get customer settings: %w", err)
+	}
+
+	if settings.PaymentTerms == "" {
+		return s.defaultTerms, nil
+	}
+	return settings.PaymentTerms, nil
+}
+
+// UpdatePaymentTerms sets the payment terms of a customer's future
+// invoices; empty terms return the customer to the default. Invoices
+// already issued keep their due dates.
+func (s *invoiceReceivableService) UpdatePaymentTerms(ctx context.Context, customerID uuid.UUID, terms models.PaymentTerms, actor string) (*models.CustomerSettings, error) {
+	if terms != "" && !terms.IsValid() {
+		return nil, fmt.Errorf("%w: payment_terms must be NET_15, NET_30, NET_45 or NET_60", ErrInvalidPaymentTerms)
+	}
+
+	settings, err := s.customers.UpdatePaymentTerms(ctx, customerID, terms)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to update customer payment terms", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to update customer payment terms: %w", err)
+	}
+
+	s.logger.Info("customer payment terms updated",
+		"customerID", customerID,
+		"paymentTerms", terms,
+		"actor", actor)
+
+	return settings, nil
+}
+
+// charge applies the late fee policy to an unpaid invoice as of asOf and
+// reports whether it charged anything
+func (s *invoiceReceivableService) charge(receivable *models.InvoiceReceivable, asOf time.Time) bool {
+	if !asOf.After(receivable.DueDate.Add(s.policy.GracePeriod)) {
+		return false
+	}
+
+	switch s.policy.Mode {
+	case models.LateFeeFlat:
+		if !receivable.LateFee.IsZero() {
+			return false
+		}
+		fee := s.currencies.Round(receivable.Currency,
+			receivable.TotalAmount.Mul(s.policy.FeePercent).Div(decimal.NewFromInt(100)))
+		if !fee.IsPositive() {
+			return false
+		}
+		receivable.LateFee = fee
+		return true
+
+	case models.LateFeeInterest:
+		from := receivable.DueDate
+		if receivable.InterestThrough != nil && receivable.InterestThrough.After(from) {
+			from = *receivable.InterestThrough
+		}
+		days := int64(asOf.Sub(from) / (24 * time.Hour))
+		if days < 1 {
+			return false
+		}
+		// Interest accrues on the unpaid part of the invoice total;
+		// payments settle the total before the interest
+		principal := receivable.TotalAmount.Sub(receivable.AmountPaid)
+		if principal.IsPositive() {
+			interest := principal.Mul(s.policy.AnnualRate).Mul(decimal.NewFromInt(days)).
+				Div(decimal.NewFromInt(100 * daysPerYear))
+			receivable.Interest = s.currencies.Round(receivable.Currency, receivable.Interest.Add(interest))
+		}
+		through := from.Add(time.Duration(days) * 24 * time.Hour)
+		receivable.InterestThrough = &through
+		return true
+	}
+	return false
+}
+
+// present adds the late charges of an invoice as line items and what is
+// left to pay
+func (s *invoiceReceivableService) present(receivable *models.InvoiceReceivable) *models.InvoiceReceivable {
+	receivable.LateFeeItems = []models.InvoiceLineItem{}
+	one := decimal.NewFromInt(1)
+	if receivable.LateFee.IsPositive() {
+		receivable.LateFeeItems = append(receivable.LateFeeItems, models.InvoiceLineItem{
+			Description: "Late payment fee",
+			Quantity:    one,
+			UnitPrice:   receivable.LateFee,
+			Amount:      receivable.LateFee,
+		})
+	}
+	if receivable.Interest.IsPositive() && receivable.InterestThrough != nil {
+		receivable.LateFeeItems = append(receivable.LateFeeItems, models.InvoiceLineItem{
+			Description: fmt.Sprintf("Interest on overdue amount to %s", receivable.InterestThrough.Format("02 Jan 2006")),
+			Quantity:    one,
+			UnitPrice:   receivable.Interest,
+			Amount:      receivable.Interest,
+		})
+	}
+	receivable.Outstanding = receivable.AmountDue().Sub(receivable.AmountPaid)
+	return receivable
+}
+
+// daysPastDue returns the started days since an invoice fell due as of
+// asOf, zero when it is not yet due
+func daysPastDue(dueDate, asOf time.Time) int {
+	if !asOf.After(dueDate) {
+		return 0
+	}
+	past := asOf.Sub(dueDate)
+	days := int(past / (24 * time.Hour))
+	if past%(24*time.Hour) != 0 {
+		days++
+	}
+	return days
+}
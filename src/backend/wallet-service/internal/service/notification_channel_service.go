@@ -0,0 +1,484 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+	"github.com/shopspring/decimal"                           // v1.3.1
+
+	"internal/eventbus"
+	"internal/events"
+	"internal/execctx"
+	"internal/models"
+	"internal/notify"
+	"internal/repository"
+	"internal/webhook"
+)
+
+// maxNotificationChannels bounds the notification channels of a customer
+const maxNotificationChannels = 10
+
+// Notification channel errors
+var (
+	ErrNotificationChannelNotFound = errors.New("notification channel not found")
+	ErrNotificationChannelExists   = errors.New("notification channel already exists")
+	ErrInvalidNotificationChannel  = errors.New("invalid notification channel")
+	ErrInvalidInvoiceNotice        = errors.New("invalid invoice notice")
+)
+
+// notificationChannelSends counts notifications sent on customer channels by
+// channel kind, event type and outcome
+var notificationChannelSends = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_notification_channel_sends_total",
+		Help: "Notifications sent on customer email, SMS and Slack channels, by kind, event type and outcome",
+	},
+	[]string{"kind", "event_type", "outcome"},
+)
+
+// NotificationChannelInput holds the customer-editable fields of a
+// notification channel. The kind of a channel cannot be changed.
+type NotificationChannelInput struct {
+	Kind                   models.NotificationChannelKind
+	Destination            string
+	EventTypes             []string
+	LargeTransactionAmount *decimal.Decimal
+	Enabled                bool
+}
+
+// NotificationAttachments returns the files emailed with an event, such as
+// the PDF of an issued invoice
+type NotificationAttachments func(ctx context.Context, env *events.Envelope) ([]notify.Attachment, error)
+
+// NotificationChannelService defines the interface for customer
+// notification channels and the delivery of notifications on them
+type NotificationChannelService interface {
+	CreateChannel(ctx context.Context, customerID uuid.UUID, input NotificationChannelInput) (*models.NotificationChannel, error)
+	UpdateChannel(ctx context.Context, customerID, id uuid.UUID, input NotificationChannelInput) (*models.NotificationChannel, error)
+	DeleteChannel(ctx context.Context, customerID, id uuid.UUID) error
+	GetChannel(ctx context.Context, customerID, id uuid.UUID) (*models.NotificationChannel, error)
+	ListChannels(ctx context.Context, customerID uuid.UUID) ([]*models.NotificationChannel, error)
+	NotifyInvoice(ctx context.Context, notice *models.InvoiceNotice) error
+	RegisterAttachments(eventType string, attachments NotificationAttachments) error
+	Deliver(ctx context.Context, env *events.Envelope) error
+	RunDispatcher(ctx context.Context, consumer *events.StreamConsumer)
+}
+
+// notificationChannelService implements NotificationChannelService interface
+type notificationChannelService struct {
+	repo      repository.NotificationChannelRepository
+	senders   map[models.NotificationChannelKind]notify.Sender
+	templates *notify.Templates
+	publisher eventbus.Publisher
+	logger    Logger
+
+	mu          sync.RWMutex
+	attachments map[string]NotificationAttachments
+}
+
+// NewNotificationChannelService creates a new instance of
+// NotificationChannelService. Channels can only be created for the kinds
+// senders holds a Sender for.
+func NewNotificationChannelService(repo repository.NotificationChannelRepository, senders map[models.NotificationChannelKind]notify.Sender,
+	templates *notify.Templates, publisher eventbus.Publisher, logger Logger) (NotificationChannelService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if len(senders) == 0 {
+		return nil, errors.New("at least one sender is required")
+	}
+	if templates == nil {
+		return nil, errors.New("templates are required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &notificationChannelService{
+		repo:        repo,
+		senders:     senders,
+		templates:   templates,
+		publisher:   publisher,
+		logger:      logger,
+		attachments: make(map[string]NotificationAttachments),
+	}, nil
+}
+
+// CreateChannel registers a destination the customer receives notifications
+// on
+func (s *notificationChannelService) CreateChannel(ctx context.Context, customerID uuid.UUID, input NotificationChannelInput) (*models.NotificationChannel, error) {
+	if err := s.validate(input.Kind, input); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ListNotificationChannels(ctx, customerID)
+	if err != nil {
+		s.logger.Error("failed to list notification channels", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to list notification channels: %w", err)
+	}
+	if len(existing) >= maxNotificationChannels {
+		return nil, fmt.Errorf("%w: at most %d channels may be registered", ErrInvalidNotificationChannel, maxNotificationChannels)
+	}
+
+	channel := newNotificationChannel(customerID, input)
+	if err := s.repo.CreateNotificationChannel(ctx, channel); err != nil {
+		if errors.Is(err, repository.ErrNotificationChannelExists) {
+			return nil, ErrNotificationChannelExists
+		}
+		s.logger.Error("failed to create notification channel", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to create notification channel: %w", err)
+	}
+
+	s.logger.Info("notification channel created",
+		"customerID", customerID,
+		"channelID", channel.ID,
+		"kind", channel.Kind,
+		"eventTypes", channel.EventTypes)
+
+	return channel, nil
+}
+
+// UpdateChannel replaces the destination and rules of a channel
+func (s *notificationChannelService) UpdateChannel(ctx context.Context, customerID, id uuid.UUID, input NotificationChannelInput) (*models.NotificationChannel, error) {
+	current, err := s.GetChannel(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Kind != "" && input.Kind != current.Kind {
+		return nil, fmt.Errorf("%w: kind cannot be changed", ErrInvalidNotificationChannel)
+	}
+	if err := s.validate(current.Kind, input); err != nil {
+		return nil, err
+	}
+
+	channel := newNotificationChannel(customerID, input)
+	channel.ID = id
+	if err := s.repo.UpdateNotificationChannel(ctx, channel); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotificationChannelNotFound):
+			return nil, ErrNotificationChannelNotFound
+		case errors.Is(err, repository.ErrNotificationChannelExists):
+			return nil, ErrNotificationChannelExists
+		}
+		s.logger.Error("failed to update notification channel", err, "channelID", id)
+		return nil, fmt.Errorf("failed to update notification channel: %w", err)
+	}
+
+	return channel, nil
+}
+
+// DeleteChannel removes a channel
+func (s *notificationChannelService) DeleteChannel(ctx context.Context, customerID, id uuid.UUID) error {
+	if err := s.repo.DeleteNotificationChannel(ctx, customerID, id); err != nil {
+		if errors.Is(err, repository.ErrNotificationChannelNotFound) {
+			return ErrNotificationChannelNotFound
+		}
+		s.logger.Error("failed to delete notification channel", err, "channelID", id)
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+
+	return nil
+}
+
+// GetChannel returns one of the customer's channels
+func (s *notificationChannelService) GetChannel(ctx context.Context, customerID, id uuid.UUID) (*models.NotificationChannel, error) {
+	channel, err := s.repo.GetNotificationChannel(ctx, customerID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotificationChannelNotFound) {
+			return nil, ErrNotificationChannelNotFound
+		}
+		s.logger.Error("failed to get notification channel", err, "channelID", id)
+		return nil, fmt.Errorf("failed to get notification channel: %w", err)
+	}
+
+	return channel, nil
+}
+
+// ListChannels returns the customer's channels
+func (s *notificationChannelService) ListChannels(ctx context.Context, customerID uuid.UUID) ([]*models.NotificationChannel, error) {
+	channels, err := s.repo.ListNotificationChannels(ctx, customerID)
+	if err != nil {
+		s.logger.Error("failed to list notification channels", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to list notification channels: %w", err)
+	}
+	if channels == nil {
+		channels = []*models.NotificationChannel{}
+	}
+	return channels, nil
+}
+
+// NotifyInvoice publishes an invoice the invoice service issued, which is
+// delivered to the customer's channels and webhooks like any other event
+func (s *notificationChannelService) NotifyInvoice(ctx context.Context, notice *models.InvoiceNotice) error {
+	if err := validateInvoiceNotice(notice); err != nil {
+		return err
+	}
+
+	if err := s.publisher.Publish(ctx, eventbus.InvoiceIssued{Notice: notice}); err != nil {
+		s.logger.Error("failed to publish issued invoice", err, "invoiceID", notice.InvoiceID)
+		return fmt.Errorf("failed to publish issued invoice: %w", err)
+	}
+	return nil
+}
+
+// RegisterAttachments adds the files emailed with events of a type
+func (s *notificationChannelService) RegisterAttachments(eventType string, attachments NotificationAttachments) error {
+	if !s.templates.Supports(eventType) {
+		return fmt.Errorf("%s is not notified on channels", eventType)
+	}
+	if attachments == nil {
+		return errors.New("attachments function is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.attachments[eventType]; ok {
+		return fmt.Errorf("attachments of %s are already registered", eventType)
+	}
+	s.attachments[eventType] = attachments
+	return nil
+}
+
+// Deliver sends an event with a notification template to every enabled
+// channel of its customer whose filters it passes. Send failures are logged
+// and counted rather than returned; an error means the channels could not be
+// loaded and the event should be retried.
+func (s *notificationChannelService) Deliver(ctx context.Context, env *events.Envelope) error {
+	if !s.templates.Supports(env.Type) {
+		return nil
+	}
+	if execctx.IsLive(ctx) && env.ExecutionMode() != execctx.ModeLive {
+		ctx = execctx.WithMode(ctx, env.ExecutionMode())
+	}
+	if execctx.Suppress(ctx, execctx.ChannelNotification) {
+		return nil
+	}
+
+	subject, err := webhook.SubjectOf(env.Payload)
+	if err != nil {
+		s.logger.Warn("skipping notification of malformed event", "eventID", env.ID, "error", err)
+		return nil
+	}
+
+	var channels []*models.NotificationChannel
+	switch {
+	case subject.CustomerID != uuid.Nil:
+		channels, err = s.repo.ListActiveNotificationChannels(ctx, subject.CustomerID)
+	case subject.WalletID != uuid.Nil:
+		channels, err = s.repo.ListActiveNotificationChannelsForWallet(ctx, subject.WalletID)
+	default:
+		return nil
+	}
+	if err != nil {
+		s.logger.Error("failed to load notification channels", err, "eventID", env.ID)
+		return fmt.Errorf("failed to load notification channels: %w", err)
+	}
+
+	amount, err := transactionAmount(env)
+	if err != nil {
+		s.logger.Warn("skipping notification of malformed event", "eventID", env.ID, "error", err)
+		return nil
+	}
+
+	var msg, email *notify.Message
+	for _, channel := range channels {
+		if !notificationChannelMatches(channel, env.Type, amount) {
+			continue
+		}
+		if msg == nil {
+			rendered, err := s.templates.Render(env.Type, env.Payload)
+			if err != nil {
+				notificationChannelSends.WithLabelValues(string(channel.Kind), env.Type, "unrendered").Inc()
+				s.logger.Error("failed to render notification", err, "eventID", env.ID, "eventType", env.Type)
+				return nil
+			}
+			msg = &rendered
+		}
+		if channel.Kind != models.NotificationChannelEmail {
+			s.send(ctx, channel, env, *msg)
+			continue
+		}
+		if email == nil {
+			email = s.attach(ctx, env, *msg)
+		}
+		s.send(ctx, channel, env, *email)
+	}
+
+	return nil
+}
+
+// attach adds the files registered for the event's type to its email. An
+// email whose files cannot be loaded is sent without them, as its body still
+// notifies the customer.
+func (s *notificationChannelService) attach(ctx context.Context, env *events.Envelope, msg notify.Message) *notify.Message {
+	s.mu.RLock()
+	attachments, ok := s.attachments[env.Type]
+	s.mu.RUnlock()
+	if !ok {
+		return &msg
+	}
+
+	files, err := attachments(ctx, env)
+	if err != nil {
+		s.logger.Warn("sending notification without attachments", "eventID", env.ID, "eventType", env.Type, "error", err)
+		return &msg
+	}
+	msg.Attachments = files
+	return &msg
+}
+
+// send sends one message on one channel and counts the outcome
+func (s *notificationChannelService) send(ctx context.Context, channel *models.NotificationChannel, env *events.Envelope, msg notify.Message) {
+	sender, ok := s.senders[channel.Kind]
+	if !ok {
+		notificationChannelSends.WithLabelValues(string(channel.Kind), env.Type, "unavailable").Inc()
+		s.logger.Warn("no sender for notification channel", "channelID", channel.ID, "kind", channel.Kind)
+		return
+	}
+
+	if err := sender.Send(ctx, channel.Destination, msg); err != nil {
+		notificationChannelSends.WithLabelValues(string(channel.Kind), env.Type, "failed").Inc()
+		s.logger.Warn("notification send failed",
+			"channelID", channel.ID,
+			"eventID", env.ID,
+			"kind", channel.Kind,
+			"error", err)
+		return
+	}
+	notificationChannelSends.WithLabelValues(string(channel.Kind), env.Type, "sent").Inc()
+}
+
+// RunDispatcher delivers events read by the consumer until the context is
+// cancelled
+func (s *notificationChannelService) RunDispatcher(ctx context.Context, consumer *events.StreamConsumer) {
+	for ctx.Err() == nil {
+		if _, err := consumer.Consume(ctx, s.Deliver); err != nil && ctx.Err() == nil {
+			s.logger.Error("notification dispatch failed", err)
+			// Back off before retrying so an unavailable stream is not hammered
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+// validate checks a channel's destination and rules for its kind
+func (s *notificationChannelService) validate(kind models.NotificationChannelKind, input NotificationChannelInput) error {
+	if !kind.IsValid() {
+		return fmt.Errorf("%w: kind must be one of EMAIL, SMS or SLACK", ErrInvalidNotificationChannel)
+	}
+	if _, ok := s.senders[kind]; !ok {
+		return fmt.Errorf("%w: %s channels are not available", ErrInvalidNotificationChannel, kind)
+	}
+	if err := notify.ValidateDestination(kind, input.Destination); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidNotificationChannel, err)
+	}
+
+	for _, eventType := range input.EventTypes {
+		if !s.templates.Supports(eventType) {
+			return fmt.Errorf("%w: %q is not notified on channels", ErrInvalidNotificationChannel, eventType)
+		}
+		if eventType == eventbus.TypeTransactionCompleted && input.LargeTransactionAmount == nil {
+			return fmt.Errorf("%w: large_transaction_amount is required to notify %s", ErrInvalidNotificationChannel, eventType)
+		}
+	}
+
+	if input.LargeTransactionAmount != nil && !input.LargeTransactionAmount.IsPositive() {
+		return fmt.Errorf("%w: large_transaction_amount must be positive", ErrInvalidNotificationChannel)
+	}
+	return nil
+}
+
+// validateInvoiceNotice checks an invoice reported by the invoice service
+func validateInvoiceNotice(notice *models.InvoiceNotice) error {
+	switch {
+	case notice == nil:
+		return fmt.Errorf("%w: invoice is required", ErrInvalidInvoiceNotice)
+	case notice.InvoiceID == uuid.Nil || notice.CustomerID == uuid.Nil:
+		return fmt.Errorf("%w: invoice_id and customer_id are required", ErrInvalidInvoiceNotice)
+	case strings.TrimSpace(notice.InvoiceNumber) == "":
+		return fmt.Errorf("%w: invoice_number is required", ErrInvalidInvoiceNotice)
+	case len(notice.Currency) != 3:
+		return fmt.Errorf("%w: currency must be a 3-letter code", ErrInvalidInvoiceNotice)
+	case notice.TotalAmount.IsNegative():
+		return fmt.Errorf("%w: total_amount must not be negative", ErrInvalidInvoiceNotice)
+	case notice.DueDate.IsZero():
+		return fmt.Errorf("%w: due_date is required", ErrInvalidInvoiceNotice)
+	}
+	if notice.URL != "" {
+		link, err := url.Parse(notice.URL)
+		if err != nil || link.Scheme != "https" || link.Host == "" {
+			return fmt.Errorf("%w: url must be an absolute https URL", ErrInvalidInvoiceNotice)
+		}
+	}
+	return nil
+}
+
+// transactionAmount returns the amount of a transaction.completed event,
+// zero for other events
+func transactionAmount(env *events.Envelope) (decimal.Decimal, error) {
+	if env.Type != eventbus.TypeTransactionCompleted {
+		return decimal.Zero, nil
+	}
+
+	var payload struct {
+		Amount json.Number `json:"amount"`
+	}
+	if err := json.Unmarshal(env.Payload, &payload); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to decode transaction amount: %w", err)
+	}
+	return decimal.NewFromString(payload.Amount.String())
+}
+
+// notificationChannelMatches reports whether an event passes a channel's
+// filters. Completed transactions are only notified from the channel's large
+// transaction amount.
+func notificationChannelMatches(channel *models.NotificationChannel, eventType string, amount decimal.Decimal) bool {
+	if !channel.Enabled {
+		return false
+	}
+	if eventType == eventbus.TypeTransactionCompleted &&
+		(channel.LargeTransactionAmount == nil || amount.Abs().LessThan(*channel.LargeTransactionAmount)) {
+		return false
+	}
+	if len(channel.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range channel.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// newNotificationChannel builds a channel from customer input, normalizing
+// missing event types to an empty list
+func newNotificationChannel(customerID uuid.UUID, input NotificationChannelInput) *models.NotificationChannel {
+	channel := &models.NotificationChannel{
+		CustomerID:             customerID,
+		Kind:                   input.Kind,
+		Destination:            input.Destination,
+		EventTypes:             input.EventTypes,
+		LargeTransactionAmount: input.LargeTransactionAmount,
+		Enabled:                input.Enabled,
+	}
+	if channel.EventTypes == nil {
+		channel.EventTypes = []string{}
+	}
+	return channel
+}
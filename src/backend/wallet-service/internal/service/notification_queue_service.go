@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/events"
+	"internal/models"
+	"internal/repository"
+)
+
+// Notification queue constants
+const (
+	// notificationRetryBatch bounds the queued notifications retried per run
+	notificationRetryBatch = 100
+	// notificationMinBackoff and notificationMaxBackoff bound the delay
+	// between redelivery attempts
+	notificationMinBackoff = 30 * time.Second
+	notificationMaxBackoff = 15 * time.Minute
+	// Notification backlog page sizes
+	defaultNotificationBacklog = 50
+	maxNotificationBacklog     = 200
+)
+
+// ErrInvalidBacklogQuery is returned for backlog queries with an unknown
+// status or event type
+var ErrInvalidBacklogQuery = errors.New("invalid notification backlog query")
+
+// Notification queue metrics
+var (
+	notificationsQueued = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_notifications_queued_total",
+			Help: "Notifications queued for redelivery after the notification pipeline refused them, by event type",
+		},
+		[]string{"type"},
+	)
+	notificationsExpired = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "wallet_notifications_expired_total",
+			Help: "Queued notifications that expired before they could be delivered",
+		},
+	)
+)
+
+// timeSensitiveNotifications are the alerts that go stale quickly: a low
+// balance or credit limit warning hours late misleads more than it helps
+var timeSensitiveNotifications = map[string]bool{
+	events.TypeLowBalance:         true,
+	events.TypeCreditLimitWarning: true,
+}
+
+// NotificationQueueOptions configures how long queued notifications are
+// retried
+type NotificationQueueOptions struct {
+	// AlertTTL is how long time-sensitive alerts are retried
+	AlertTTL time.Duration
+	// NoticeTTL is how long other notifications, such as digests and
+	// dunning notices, are retried
+	NoticeTTL time.Duration
+}
+
+// NotificationQueueService defines the interface for the durable queue of
+// notifications the notification pipeline could not take. It publishes
+// envelopes like the publisher it wraps, queueing the notifications that
+// fail instead of dropping them.
+type NotificationQueueService interface {
+	events.Publisher
+	// RetryDue expires stale notifications and redelivers the due ones,
+	// returning the number delivered
+	RetryDue(ctx context.Context, now time.Time) (int, error)
+	// Backlog summarises the queue and lists its oldest notifications
+	Backlog(ctx context.Context, status models.QueuedNotificationStatus, eventType string, limit int) (*models.NotificationBacklog, error)
+	// Purge deletes the notifications expired before a time
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// notificationQueueService implements NotificationQueueService interface
+type notificationQueueService struct {
+	repo      repository.NotificationQueueRepository
+	publisher events.Publisher
+	opts      NotificationQueueOptions
+	logger    Logger
+}
+
+// NewNotificationQueueService creates a new instance of
+// NotificationQueueService publishing through publisher
+func NewNotificationQueueService(repo repository.NotificationQueueRepository, publisher events.Publisher, opts NotificationQueueOptions, logger Logger) (NotificationQueueService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if opts.AlertTTL <= 0 || opts.NoticeTTL <= 0 {
+		return nil, errors.New("notification expiries must be positive")
+	}
+
+	return &notificationQueueService{
+		repo:      repo,
+		publisher: publisher,
+		opts:      opts,
+		logger:    logger,
+	}, nil
+}
+
+// Publish hands an envelope to the notification pipeline. Notifications the
+// pipeline could not take are queued for redelivery and reported as
+// published; other events and envelopes that fail validation return the
+// error as before.
+func (s *notificationQueueService) Publish(ctx context.Context, env *events.Envelope) error {
+	err := s.publisher.Publish(ctx, env)
+	if err == nil || !events.IsNotification(env.Type) || !errors.Is(err, events.ErrPublishFailed) {
+		return err
+	}
+
+	data, encodeErr := json.Marshal(env)
+	if encodeErr != nil {
+		return fmt.Errorf("%w; failed to encode envelope for redelivery: %v", err, encodeErr)
+	}
+
+	now := time.Now().UTC()
+	ttl := s.opts.NoticeTTL
+	if timeSensitiveNotifications[env.Type] {
+		ttl = s.opts.AlertTTL
+	}
+	notification := &models.QueuedNotification{
+		EventID:       env.ID,
+		EventType:     env.Type,
+		Envelope:      data,
+		LastError:     err.Error(),
+		NextAttemptAt: now.Add(notificationMinBackoff),
+		ExpiresAt:     env.OccurredAt.Add(ttl),
+		CreatedAt:     now,
+	}
+	queued, queueErr := s.repo.EnqueueNotification(ctx, notification)
+	if queueErr != nil {
+		s.logger.Error("failed to queue undelivered notification", queueErr, "eventID", env.ID, "type", env.Type)
+		return fmt.Errorf("%w; failed to queue for redelivery: %v", err, queueErr)
+	}
+
+	if queued {
+		notificationsQueued.WithLabelValues(env.Type).Inc()
+		s.logger.Warn("notification queued for redelivery",
+			"eventID", env.ID,
+			"type", env.Type,
+			"expiresAt", notification.ExpiresAt,
+			"error", err)
+	}
+
+	return nil
+}
+
+// RetryDue redelivers due notifications oldest first. A failed attempt
+// means the pipeline is still down, so the run stops there and the
+// remaining notifications wait for the next run.
+func (s *notificationQueueService) RetryDue(ctx context.Context, now time.Time) (int, error) {
+	expired, err := s.repo.ExpireNotifications(ctx, now)
+	if err != nil {
+		s.logger.Error("failed to expire queued notifications", err)
+		return 0, err
+	}
+	if expired > 0 {
+		notificationsExpired.Add(float64(expired))
+		s.logger.Warn("queued notifications expired undelivered", "count", expired)
+	}
+
+	due, err := s.repo.ListDueNotifications(ctx, now, notificationRetryBatch)
+	if err != nil {
+		s.logger.Error("failed to list due notifications", err)
+		return 0, err
+	}
+
+	delivered := 0
+	for _, notification := range due {
+		var env events.Envelope
+		if err := json.Unmarshal(notification.Envelope, &env); err != nil {
+			return delivered, fmt.Errorf("failed to decode queued notification %s: %w", notification.ID, err)
+		}
+
+		if err := s.publisher.Publish(ctx, &env); err != nil {
+			next := now.Add(notificationBackoff(notification.Attempts + 1))
+			if err := s.repo.RescheduleNotification(ctx, notification.ID, next, err.Error()); err != nil {
+				s.logger.Error("failed to reschedule notification", err, "notificationID", notification.ID)
+			}
+			s.logger.Warn("notification redelivery failed",
+				"notificationID", notification.ID,
+				"type", notification.EventType,
+				"nextAttemptAt", next,
+				"error", err)
+			return delivered, nil
+		}
+
+		if err := s.repo.DeleteNotification(ctx, notification.ID); err != nil {
+			// Left queued, it is delivered again; consumers tolerate
+			// duplicate envelopes by ID
+			s.logger.Error("failed to dequeue delivered notification", err, "notificationID", notification.ID)
+			continue
+		}
+		delivered++
+	}
+
+	if delivered > 0 {
+		s.logger.Info("queued notifications delivered", "count", delivered)
+	}
+
+	return delivered, nil
+}
+
+// Backlog returns the state of the notification queue
+func (s *notificationQueueService) Backlog(ctx context.Context, status models.QueuedNotificationStatus, eventType string, limit int) (*models.NotificationBacklog, error) {
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("%w: status must be PENDING or EXPIRED", ErrInvalidBacklogQuery)
+	}
+	if eventType != "" && !events.IsNotification(eventType) {
+		return nil, fmt.Errorf("%w: %s is not a notification event type", ErrInvalidBacklogQuery, eventType)
+	}
+	if limit <= 0 {
+		limit = defaultNotificationBacklog
+	}
+	if limit > maxNotificationBacklog {
+		limit = maxNotificationBacklog
+	}
+
+	backlog, err := s.repo.GetNotificationBacklog(ctx, status, eventType, limit)
+	if err != nil {
+		s.logger.Error("failed to get notification backlog", err)
+		return nil, fmt.Errorf("failed to get notification backlog: %w", err)
+	}
+
+	return backlog, nil
+}
+
+// Purge deletes expired notifications once they are past review
+func (s *notificationQueueService) Purge(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := s.repo.PurgeNotifications(ctx, before)
+	if err != nil {
+		s.logger.Error("failed to purge expired notifications", err)
+		return 0, err
+	}
+	return purged, nil
+}
+
+// notificationBackoff doubles the delay with every attempt up to
+// notificationMaxBackoff
+func notificationBackoff(attempts int) time.Duration {
+	backoff := notificationMinBackoff
+	for i := 0; i < attempts && backoff < notificationMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > notificationMaxBackoff {
+		backoff = notificationMaxBackoff
+	}
+	return backoff
+}
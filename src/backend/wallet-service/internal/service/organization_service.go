@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+	"internal/repository"
+)
+
+// Organization errors
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrInvalidOrganization is returned for an organization without a
+	// name
+	ErrInvalidOrganization = errors.New("invalid organization")
+)
+
+// OrganizationService defines the interface for the organizations owning
+// customers and their wallets. Organization admins see their own
+// organization only; the context they act in is confined to it.
+type OrganizationService interface {
+	Create(ctx context.Context, name, actor string) (*models.Organization, error)
+	// Get returns an organization with the rollup of its customers' wallets
+	Get(ctx context.Context, id uuid.UUID) (*models.OrganizationSummary, error)
+	// AssignCustomer moves a customer and its wallets to an organization,
+	// or out of any when orgID is nil
+	AssignCustomer(ctx context.Context, customerID uuid.UUID, orgID *uuid.UUID, actor string) (*models.CustomerSettings, error)
+	// ListWallets pages through the wallets of an organization's customers
+	ListWallets(ctx context.Context, id uuid.UUID, pagination Pagination) ([]*models.Wallet, error)
+	// ConsolidatedInvoice combines the invoices issued to an
+	// organization's customers in a YYYY-MM billing period
+	ConsolidatedInvoice(ctx context.Context, id uuid.UUID, period string) (*models.OrganizationInvoice, error)
+}
+
+// organizationService implements OrganizationService interface
+type organizationService struct {
+	repo   repository.OrganizationRepository
+	logger Logger
+}
+
+// NewOrganizationService creates a new instance of OrganizationService
+func NewOrganizationService(repo repository.OrganizationRepository, logger Logger) (OrganizationService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &organizationService{
+		repo:   repo,
+		logger: logger,
+	}, nil
+}
+
+// Create creates an organization
+func (s *organizationService) Create(ctx context.Context, name, actor string) (*models.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidOrganization)
+	}
+
+	now := time.Now().UTC()
+	org := &models.Organization{
+		ID:        uuid.New(),
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.CreateOrganization(ctx, org); err != nil {
+		s.logger.Error("failed to create organization", err, "name", name)
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	s.logger.Info("organization created",
+		"organizationID", org.ID,
+		"name", name,
+		"actor", actor)
+
+	return org, nil
+}
+
+// Get returns an organization with its rollup
+func (s *organizationService) Get(ctx context.Context, id uuid.UUID) (*models.OrganizationSummary, error) {
+	summary, err := s.repo.SummarizeOrganization(ctx, id)
+	if err != nil {
+		return nil, s.organizationError(err, "failed to summarize organization", id)
+	}
+	return summary, nil
+}
+
+// AssignCustomer moves a customer to an organization
+func (s *organizationService) AssignCustomer(ctx context.Context, customerID uuid.UUID, orgID *uuid.UUID, actor string) (*models.CustomerSettings, error) {
+	if orgID != nil {
+		if _, err := s.repo.GetOrganization(ctx, *orgID); err != nil {
+			return nil, s.organizationError(err, "failed to get organization", *orgID)
+		}
+	}
+
+	settings, err := s.repo.AssignCustomerOrganization(ctx, customerID, orgID)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to assign customer organization", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to assign customer organization: %w", err)
+	}
+
+	s.logger.Info("customer organization assigned",
+		"customerID", customerID,
+		"organizationID", orgID,
+		"actor", actor)
+
+	return settings, nil
+}
+
+// ListWallets pages through an organization's wallets
+func (s *organizationService) ListWallets(ctx context.Context, id uuid.UUID, pagination Pagination) ([]*models.Wallet, error) {
+	wallets, err := s.repo.ListOrganizationWallets(ctx, id, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, s.organizationError(err, "failed to list organization wallets", id)
+	}
+	if wallets == nil {
+		wallets = []*models.Wallet{}
+	}
+	return wallets, nil
+}
+
+// ConsolidatedInvoice lists the invoices issued to the organization's
+// customers in the period with their totals by currency
+func (s *organizationService) ConsolidatedInvoice(ctx context.Context, id uuid.UUID, period string) (*models.OrganizationInvoice, error) {
+	start, err := parseBillingPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	invoices, err := s.repo.ListOrganizationInvoices(ctx, id, start, start.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, s.organizationError(err, "failed to list organization invoices", id)
+	}
+
+	consolidated := &models.OrganizationInvoice{
+		OrganizationID: id,
+		Period:         start.Format(models.BillingPeriodLayout),
+		Totals:         []*models.OrganizationInvoiceTotal{},
+		Invoices:       []*models.InvoiceReceivable{},
+	}
+	totals := make(map[string]*models.OrganizationInvoiceTotal)
+	for _, invoice := range invoices {
+		invoice.Outstanding = invoice.AmountDue().Sub(invoice.AmountPaid)
+		total, ok := totals[invoice.Currency]
+		if !ok {
+			total = &models.OrganizationInvoiceTotal{
+				Currency:    invoice.Currency,
+				TotalAmount: decimal.Zero,
+				Outstanding: decimal.Zero,
+			}
+			totals[invoice.Currency] = total
+			consolidated.Totals = append(consolidated.Totals, total)
+		}
+		total.Invoices++
+		total.TotalAmount = total.TotalAmount.Add(invoice.AmountDue())
+		total.Outstanding = total.Outstanding.Add(invoice.Outstanding)
+		consolidated.Invoices = append(consolidated.Invoices, invoice)
+	}
+	sort.Slice(consolidated.Totals, func(i, j int) bool {
+		return consolidated.Totals[i].Currency < consolidated.Totals[j].Currency
+	})
+
+	return consolidated, nil
+}
+
+// organizationError maps a repository error about an organization
+func (s *organizationService) organizationError(err error, msg string, id uuid.UUID) error {
+	if errors.Is(err, repository.ErrOrganizationNotFound) {
+		return ErrOrganizationNotFound
+	}
+	s.logger.Error(msg, err, "organizationID", id)
+	return fmt.Errorf("%s: %w", msg, err)
+}
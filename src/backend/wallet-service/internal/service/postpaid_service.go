@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+	"github.com/shopspring/decimal"                           // v1.3.1
+
+	"internal/currency"
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+)
+
+// postpaidStatementsShown is how many of a wallet's latest statements its
+// postpaid usage lists
+const postpaidStatementsShown = 12
+
+// ErrInvalidBillingMode is returned for an undefined billing mode or
+// postpaid settlement
+var ErrInvalidBillingMode = errors.New("invalid billing mode")
+
+// postpaidStatements counts the postpaid statements settled, by how they
+// were settled
+var postpaidStatements = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_postpaid_statements_total",
+		Help: "Postpaid cycle statements settled, by settlement",
+	},
+	[]string{"settlement"},
+)
+
+// BillingModeSource returns how customers are billed
+type BillingModeSource interface {
+	// BillingMode returns the customer's billing mode, PREPAID for unknown
+	// customers
+	BillingMode(ctx context.Context, customerID uuid.UUID) (models.BillingMode, error)
+}
+
+// PostpaidService defines the interface for customer billing modes and the
+// accrual, billing and settlement of postpaid usage
+type PostpaidService interface {
+	BillingModeSource
+	UpdateBillingMode(ctx context.Context, customerID uuid.UUID, mode models.BillingMode, settlement models.PostpaidSettlement, actor string) (*models.CustomerSettings, error)
+	// Accrue records a debit of a postpaid customer's wallet as a charge
+	// billed at the end of the cycle instead of posting it
+	Accrue(ctx context.Context, wallet *models.Wallet, tx *models.Transaction) error
+	GetUsage(ctx context.Context, walletID uuid.UUID) (*models.PostpaidUsage, error)
+	// CloseCycle bills the charges accrued before the cycle in progress at
+	// now and settles the pending statements, returning how many settled
+	CloseCycle(ctx context.Context, now time.Time) (int, error)
+}
+
+// postpaidService implements PostpaidService interface
+type postpaidService struct {
+	repo        repository.PostpaidRepository
+	customers   repository.CustomerRepository
+	wallets     WalletService
+	receivables InvoiceReceivableService
+	publisher   eventbus.Publisher
+	currencies  *currency.Registry
+	logger      Logger
+}
+
+// NewPostpaidService creates a new instance of PostpaidService. Statements
+// are settled by debiting wallets, which must post debits rather than
+// accrue them, or by publishing an invoice due by the customer's payment
+// terms in receivables.
+func NewPostpaidService(repo repository.PostpaidRepository, customers repository.CustomerRepository, wallets WalletService,
+	receivables InvoiceReceivableService, publisher eventbus.Publisher, currencies *currency.Registry, logger Logger) (PostpaidService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if customers == nil {
+		return nil, errors.New("customer repository is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if receivables == nil {
+		return nil, errors.New("invoice receivable service is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("event publisher is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currencies are required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &postpaidService{
+		repo:        repo,
+		customers:   customers,
+		wallets:     wallets,
+		receivables: receivables,
+		publisher:   publisher,
+		currencies:  currencies,
+		logger:      logger,
+	}, nil
+}
+
+// BillingMode returns the customer's billing mode
+func (s *postpaidService) BillingMode(ctx context.Context, customerID uuid.UUID) (models.BillingMode, error) {
+	settings, err := s.customers.GetSettings(ctx, customerID)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return models.BillingPrepaid, nil
+	}
+	if err != nil {
+		s.logger.Error("failed to get customer settings", err, "customerID", customerID)
+		return "", fmt.Errorf("failed to get customer settings: %w", err)
+	}
+
+	if settings.BillingMode == "" {
+		return models.BillingPrepaid, nil
+	}
+	return settings.BillingMode, nil
+}
+
+// UpdateBillingMode sets how a customer is billed from its next debit on.
+// Charges accrued while postpaid are still billed at the end of their cycle
+// after switching to prepaid.
+func (s *postpaidService) UpdateBillingMode(ctx context.Context, customerID uuid.UUID, mode models.BillingMode, settlement models.PostpaidSettlement, actor string) (*models.CustomerSettings, error) {
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("%w: billing_mode must be PREPAID or POSTPAID", ErrInvalidBillingMode)
+	}
+	if settlement != "" && !settlement.IsValid() {
+		return nil, fmt.Errorf("%w: postpaid_settlement must be WALLET or EXTERNAL", ErrInvalidBillingMode)
+	}
+	if settlement != "" && mode != models.BillingPostpaid {
+		return nil, fmt.Errorf("%w: postpaid_settlement only applies to POSTPAID customers", ErrInvalidBillingMode)
+	}
+
+	settings, err := s.customers.UpdateBillingMode(ctx, customerID, mode, settlement)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to update customer billing mode", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to update customer billing mode: %w", err)
+	}
+
+	s.logger.Info("customer billing mode updated",
+		"customerID", customerID,
+		"billingMode", mode,
+		"settlement", settlement,
+		"actor", actor)
+
+	return settings, nil
+}
+
+// Accrue validates a debit as it would be posted and records it as a
+// charge. The wallet balance does not move; the debit is left PROCESSING
+// until the statement of its cycle is settled.
+func (s *postpaidService) Accrue(ctx context.Context, wallet *models.Wallet, tx *models.Transaction) error {
+	if err := tx.Validate(); err != nil {
+		s.logger.Error("invalid transaction", err, "transactionID", tx.ID)
+		return fmt.Errorf("transaction validation failed: %w", err)
+	}
+	if !s.currencies.Supported(tx.Currency) {
+		return ErrUnsupportedCurrency
+	}
+	if tx.Currency != wallet.Currency {
+		return ErrCurrencyMismatch
+	}
+	minor, err := s.currencies.ToMinorUnits(tx.Currency, decimal.NewFromFloat(tx.Amount))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	if minor.Units <= 0 {
+		return ErrInvalidAmount
+	}
+	if tx.EffectiveAt != nil && tx.EffectiveAt.After(time.Now()) {
+		return ErrFutureEffectiveDate
+	}
+	tx.Minor = minor
+	tx.Amount, _ = minor.Decimal().Float64()
+
+	charge := &models.PostpaidCharge{
+		ID:          tx.ID,
+		CustomerID:  wallet.CustomerID,
+		WalletID:    wallet.ID,
+		Amount:      minor.Decimal(),
+		Currency:    tx.Currency,
+		Description: tx.Description,
+		ReferenceID: tx.ReferenceID,
+		Metadata:    tx.Metadata,
+		CreatedAt:   tx.EffectiveTime().UTC(),
+	}
+	if err := s.repo.CreatePostpaidCharge(ctx, charge); err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			return ErrWalletNotFound
+		}
+		s.logger.Error("failed to accrue postpaid charge", err, "walletID", wallet.ID, "transactionID", tx.ID)
+		return fmt.Errorf("failed to accrue postpaid charge: %w", err)
+	}
+	tx.Status = models.TransactionStatusProcessing
+
+	s.logger.Info("postpaid charge accrued",
+		"transactionID", tx.ID,
+		"walletID", wallet.ID,
+		"amount", charge.Amount)
+
+	return nil
+}
+
+// GetUsage returns what a wallet accrued and has not been billed for yet,
+// with its latest statements
+func (s *postpaidService) GetUsage(ctx context.Context, walletID uuid.UUID) (*models.PostpaidUsage, error) {
+	wallet, err := s.wallets.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := s.BillingMode(ctx, wallet.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	count, total, err := s.repo.SumUnbilledPostpaidCharges(ctx, walletID)
+	if err != nil {
+		s.logger.Error("failed to sum unbilled postpaid charges", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to sum unbilled postpaid charges: %w", err)
+	}
+	statements, err := s.repo.ListPostpaidStatements(ctx, walletID, postpaidStatementsShown)
+	if err != nil {
+		s.logger.Error("failed to list postpaid statements", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to list postpaid statements: %w", err)
+	}
+
+	return &models.PostpaidUsage{
+		WalletID:        walletID,
+		Currency:        wallet.Currency,
+		BillingMode:     mode,
+		UnbilledCharges: count,
+		UnbilledAmount:  s.currencies.Round(wallet.Currency, total),
+		Statements:      statements,
+	}, nil
+}
+
+// CloseCycle bills the charges accrued before the start of the UTC month
+// of now, which closes the previous monthly cycle, then settles every
+// pending statement. Statements that fail to settle stay pending and are
+// retried on the next run.
+func (s *postpaidService) CloseCycle(ctx context.Context, now time.Time) (int, error) {
+	now = now.UTC()
+	periodEnd := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	periodStart := periodEnd.AddDate(0, -1, 0)
+
+	billed, err := s.repo.CreatePostpaidStatements(ctx, periodStart, periodEnd, now)
+	if err != nil {
+		s.logger.Error("failed to bill postpaid charges", err, "periodEnd", periodEnd)
+		return 0, fmt.Errorf("failed to bill postpaid charges: %w", err)
+	}
+	if len(billed) > 0 {
+		s.logger.Info("postpaid cycle billed",
+			"periodStart", periodStart,
+			"statements", len(billed))
+	}
+
+	pending, err := s.repo.ListPendingPostpaidStatements(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pending postpaid statements: %w", err)
+	}
+
+	settled := 0
+	for _, statement := range pending {
+		if err := s.settle(ctx, statement, now); err != nil {
+			s.logger.Error("failed to settle postpaid statement", err,
+				"statementID", statement.ID,
+				"walletID", statement.WalletID)
+			continue
+		}
+		settled++
+	}
+	return settled, nil
+}
+
+// settle debits a statement from the wallet of a customer settling against
+// it, falling back to an invoice when the wallet cannot cover it, or
+// invoices it for external payment
+func (s *postpaidService) settle(ctx context.Context, statement *models.PostpaidStatement, now time.Time) error {
+	settings, err := s.customers.GetSettings(ctx, statement.CustomerID)
+	if err != nil && !errors.Is(err, repository.ErrCustomerNotFound) {
+		return fmt.Errorf("failed to get customer settings: %w", err)
+	}
+	settlement := models.SettleWallet
+	if settings != nil && settings.PostpaidSettlement != "" {
+		settlement = settings.PostpaidSettlement
+	}
+
+	if settlement == models.SettleWallet {
+		transactionID, err := s.debit(ctx, statement, now)
+		if err == nil {
+			return s.markSettled(ctx, statement, models.SettleWallet, &transactionID, now)
+		}
+		if !errors.Is(err, ErrInsufficientBalance) {
+			return err
+		}
+		s.logger.Warn("wallet cannot cover postpaid statement, invoicing it",
+			"statementID", statement.ID,
+			"walletID", statement.WalletID,
+			"amount", statement.TotalAmount)
+	}
+
+	terms, err := s.receivables.PaymentTerms(ctx, statement.CustomerID)
+	if err != nil {
+		return err
+	}
+	notice := &models.InvoiceNotice{
+		InvoiceID:     statement.ID,
+		CustomerID:    statement.CustomerID,
+		InvoiceNumber: statement.InvoiceNumber,
+		Currency:      statement.Currency,
+		TotalAmount:   statement.TotalAmount,
+		DueDate:       terms.DueDate(now),
+	}
+	if err := s.publisher.Publish(ctx, eventbus.InvoiceIssued{Notice: notice}); err != nil {
+		return fmt.Errorf("failed to publish postpaid invoice: %w", err)
+	}
+	return s.markSettled(ctx, statement, models.SettleExternal, nil, now)
+}
+
+// debit posts the debit settling a statement from its wallet. A debit
+// posted by an earlier run that failed to mark the statement settled is
+// found by its reference rather than posted again.
+func (s *postpaidService) debit(ctx context.Context, statement *models.PostpaidStatement, now time.Time) (uuid.UUID, error) {
+	matches, err := s.wallets.FindTransactionsByReference(ctx, statement.InvoiceNumber)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, match := range matches {
+		if match.Transaction.WalletID == statement.WalletID && match.Transaction.Type == models.TransactionTypeDebit {
+			return match.Transaction.ID, nil
+		}
+	}
+
+	amount, _ := statement.TotalAmount.Float64()
+	tx := &models.Transaction{
+		ID:          uuid.New(),
+		WalletID:    statement.WalletID,
+		Type:        models.TransactionTypeDebit,
+		Status:      models.TransactionStatusInitiated,
+		Amount:      amount,
+		Currency:    statement.Currency,
+		Description: fmt.Sprintf("Postpaid statement %s", statement.InvoiceNumber),
+		ReferenceID: statement.InvoiceNumber,
+		Metadata:    map[string]string{"postpaid_statement_id": statement.ID.String()},
+		CreatedAt:   now,
+	}
+	if _, err := s.wallets.ProcessTransaction(ctx, tx); err != nil {
+		return uuid.Nil, err
+	}
+	return tx.ID, nil
+}
+
+// markSettled records how a statement was settled
+func (s *postpaidService) markSettled(ctx context.Context, statement *models.PostpaidStatement, settlement models.PostpaidSettlement, transactionID *uuid.UUID, now time.Time) error {
+	if _, err := s.repo.SettlePostpaidStatement(ctx, statement.ID, settlement, transactionID, now); err != nil {
+		return fmt.Errorf("failed to mark postpaid statement settled: %w", err)
+	}
+	postpaidStatements.WithLabelValues(string(settlement)).Inc()
+
+	s.logger.Info("postpaid statement settled",
+		"statementID", statement.ID,
+		"walletID", statement.WalletID,
+		"settlement", settlement,
+		"amount", statement.TotalAmount)
+	return nil
+}
+
+// postpaidWalletService is a WalletService accruing the debits of postpaid
+// customers instead of posting them
+type postpaidWalletService struct {
+	WalletService
+	postpaid PostpaidService
+}
+
+// NewPostpaidWalletService wraps wallets so that debits of postpaid
+// customers are accrued for the end of the cycle; debits of prepaid
+// customers and all other transactions post as before
+func NewPostpaidWalletService(wallets WalletService, postpaid PostpaidService) (WalletService, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if postpaid == nil {
+		return nil, errors.New("postpaid service is required")
+	}
+
+	return &postpaidWalletService{
+		WalletService: wallets,
+		postpaid:      postpaid,
+	}, nil
+}
+
+// ProcessTransaction accrues debits of postpaid customers and posts the
+// rest
+func (s *postpaidWalletService) ProcessTransaction(ctx context.Context, tx *models.Transaction) (*models.CreditLimitWarning, error) {
+	if tx == nil || tx.Type != models.TransactionTypeDebit {
+		return s.WalletService.ProcessTransaction(ctx, tx)
+	}
+
+	wallet, err := s.GetWallet(ctx, tx.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := s.postpaid.BillingMode(ctx, wallet.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if mode != models.BillingPostpaid {
+		return s.WalletService.ProcessTransaction(ctx, tx)
+	}
+
+	return nil, s.postpaid.Accrue(ctx, wallet, tx)
+}
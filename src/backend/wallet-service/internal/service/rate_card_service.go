@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+)
+
+// rateCardHistory bounds the rate card changes listed
+const rateCardHistory = 50
+
+// Rate card errors
+var (
+	ErrInvalidRateCardChange  = errors.New("invalid rate card change")
+	ErrRateCardChangeNotFound = errors.New("rate card change not found")
+	ErrRateCardChangeConflict = errors.New("rate card change is not in a state allowing this request")
+	// ErrSelfApproval is returned when the proposer of a rate card change,
+	// adjustment or wallet settings change approves it; all need a second
+	// pair of eyes
+	ErrSelfApproval = errors.New("changes must be approved by another operator")
+)
+
+// RateCardPolicy configures the review of rate card changes
+type RateCardPolicy struct {
+	// MinNotice is the least time between the approval of a change and its
+	// effective date, so finance hears of it before it applies
+	MinNotice time.Duration
+}
+
+// RateCardSource returns the chargeback rates in effect at a time
+type RateCardSource interface {
+	RatesAt(ctx context.Context, at time.Time) (*models.RateCard, error)
+}
+
+// RateCardService defines the interface for proposing, reviewing and
+// activating rate card changes
+type RateCardService interface {
+	RateCardSource
+	Current(ctx context.Context) (*models.RateCard, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.RateCardChange, error)
+	List(ctx context.Context) ([]*models.RateCardChange, error)
+	Propose(ctx context.Context, rates models.RateCard, effectiveAt time.Time, actor, reason string) (*models.RateCardChange, error)
+	Approve(ctx context.Context, id uuid.UUID, actor, note string) (*models.RateCardChange, error)
+	Reject(ctx context.Context, id uuid.UUID, actor, note string) (*models.RateCardChange, error)
+	ActivateDue(ctx context.Context, now time.Time) error
+}
+
+// rateCardService implements RateCardService interface. The configured rates
+// apply until the first change is activated.
+type rateCardService struct {
+	repo      repository.RateCardRepository
+	publisher eventbus.Publisher
+	base      models.RateCard
+	policy    RateCardPolicy
+	logger    Logger
+}
+
+// NewRateCardService creates a new instance of RateCardService
+func NewRateCardService(repo repository.RateCardRepository, publisher eventbus.Publisher, base models.RateCard, policy RateCardPolicy, logger Logger) (RateCardService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if err := validateRates(base); err != nil {
+		return nil, err
+	}
+	if policy.MinNotice < 0 {
+		return nil, errors.New("minimum notice must be non-negative")
+	}
+
+	return &rateCardService{
+		repo:      repo,
+		publisher: publisher,
+		base:      base,
+		policy:    policy,
+		logger:    logger,
+	}, nil
+}
+
+// RatesAt returns the rates of the change in effect at the given time, or
+// the configured rates before any change was activated
+func (s *rateCardService) RatesAt(ctx context.Context, at time.Time) (*models.RateCard, error) {
+	rates, err := s.repo.GetRatesAt(ctx, at)
+	if err != nil {
+		s.logger.Error("failed to get rate card", err, "at", at)
+		return nil, fmt.Errorf("failed to get rate card: %w", err)
+	}
+	if rates == nil {
+		base := s.base
+		return &base, nil
+	}
+	return rates, nil
+}
+
+// Current returns the rates in effect now
+func (s *rateCardService) Current(ctx context.Context) (*models.RateCard, error) {
+	return s.RatesAt(ctx, time.Now().UTC())
+}
+
+// Get returns a rate card change
+func (s *rateCardService) Get(ctx context.Context, id uuid.UUID) (*models.RateCardChange, error) {
+	change, err := s.repo.GetChange(ctx, id)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	return change, nil
+}
+
+// List returns the latest rate card changes, newest first
+func (s *rateCardService) List(ctx context.Context) ([]*models.RateCardChange, error) {
+	changes, err := s.repo.ListChanges(ctx, rateCardHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rate card changes: %w", err)
+	}
+	return changes, nil
+}
+
+// Propose records a change of the rate card for review, storing its diff
+// against the rates in effect. Only one change may be open at a time.
+func (s *rateCardService) Propose(ctx context.Context, rates models.RateCard, effectiveAt time.Time, actor, reason string) (*models.RateCardChange, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a reason is required", ErrInvalidRateCardChange)
+	}
+	if err := validateRates(rates); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if err := s.checkNotice(effectiveAt, now); err != nil {
+		return nil, err
+	}
+
+	current, err := s.RatesAt(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	diff := current.Diff(rates)
+	if len(diff) == 0 {
+		return nil, fmt.Errorf("%w: the rates are already in effect", ErrInvalidRateCardChange)
+	}
+
+	change := &models.RateCardChange{
+		ID:          uuid.New(),
+		Status:      models.RateCardChangePending,
+		Rates:       rates,
+		Previous:    *current,
+		Diff:        diff,
+		EffectiveAt: effectiveAt.UTC(),
+		Reason:      reason,
+		ProposedBy:  actor,
+		CreatedAt:   now,
+	}
+	if err := s.repo.CreateChange(ctx, change); err != nil {
+		if !errors.Is(err, repository.ErrRateCardChangeConflict) {
+			s.logger.Error("failed to create rate card change", err)
+		}
+		return nil, s.mapError(err)
+	}
+
+	s.logger.Info("rate card change proposed",
+		"changeID", change.ID,
+		"actor", actor,
+		"effectiveAt", change.EffectiveAt)
+
+	return change, nil
+}
+
+// Approve approves a pending change proposed by another operator and
+// notifies finance. Changes whose effective date no longer leaves the
+// minimum notice must be proposed again.
+func (s *rateCardService) Approve(ctx context.Context, id uuid.UUID, actor, note string) (*models.RateCardChange, error) {
+	change, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if change.ProposedBy == actor {
+		return nil, ErrSelfApproval
+	}
+
+	now := time.Now().UTC()
+	if err := s.checkNotice(change.EffectiveAt, now); err != nil {
+		return nil, err
+	}
+
+	change, err = s.review(ctx, id, models.RateCardChangeApproved, actor, note, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failed notice is retried by ActivateDue, which activates no change
+	// before finance was notified
+	s.notifyFinance(ctx, change, now)
+	return change, nil
+}
+
+// Reject rejects a pending change. Proposers may withdraw their own changes.
+func (s *rateCardService) Reject(ctx context.Context, id uuid.UUID, actor, note string) (*models.RateCardChange, error) {
+	if strings.TrimSpace(note) == "" {
+		return nil, fmt.Errorf("%w: a note is required", ErrInvalidRateCardChange)
+	}
+	return s.review(ctx, id, models.RateCardChangeRejected, actor, note, time.Now().UTC())
+}
+
+// review records the outcome of the review of a pending change
+func (s *rateCardService) review(ctx context.Context, id uuid.UUID, status models.RateCardChangeStatus, actor, note string, now time.Time) (*models.RateCardChange, error) {
+	change, err := s.repo.ReviewChange(ctx, id, status, actor, note, now)
+	if err != nil {
+		if !errors.Is(err, repository.ErrRateCardChangeConflict) && !errors.Is(err, repository.ErrRateCardChangeNotFound) {
+			s.logger.Error("failed to review rate card change", err, "changeID", id)
+		}
+		return nil, s.mapError(err)
+	}
+
+	s.logger.Info("rate card change reviewed",
+		"changeID", id,
+		"status", status,
+		"actor", actor)
+
+	return change, nil
+}
+
+// ActivateDue notifies finance of approved changes it has not heard of yet
+// and activates the notified changes whose effective date has come
+func (s *rateCardService) ActivateDue(ctx context.Context, now time.Time) error {
+	now = now.UTC()
+
+	changes, err := s.repo.ListApproved(ctx)
+	if err != nil {
+		s.logger.Error("failed to list approved rate card changes", err)
+		return fmt.Errorf("failed to list approved rate card changes: %w", err)
+	}
+
+	var failed error
+	for _, change := range changes {
+		if change.FinanceNotifiedAt == nil && !s.notifyFinance(ctx, change, now) {
+			failed = fmt.Errorf("finance was not notified of rate card change %s", change.ID)
+			continue
+		}
+		if change.EffectiveAt.After(now) {
+			continue
+		}
+
+		err := s.repo.ActivateChange(ctx, change.ID, now)
+		if errors.Is(err, repository.ErrRateCardChangeConflict) {
+			// Activated by another replica
+			continue
+		}
+		if err != nil {
+			s.logger.Error("failed to activate rate card change", err, "changeID", change.ID)
+			failed = err
+			continue
+		}
+
+		s.logger.Info("rate card change activated",
+			"changeID", change.ID,
+			"effectiveAt", change.EffectiveAt)
+	}
+	if failed != nil {
+		return fmt.Errorf("failed to activate rate card changes: %w", failed)
+	}
+
+	return nil
+}
+
+// notifyFinance publishes an approved change to finance and records the
+// notice, reporting whether it was sent
+func (s *rateCardService) notifyFinance(ctx context.Context, change *models.RateCardChange, now time.Time) bool {
+	if err := s.publisher.Publish(ctx, eventbus.RateCardApproved{Change: change}); err != nil {
+		s.logger.Error("failed to notify finance of rate card change", err, "changeID", change.ID)
+		return false
+	}
+	if err := s.repo.MarkFinanceNotified(ctx, change.ID, now); err != nil {
+		// The notice is repeated on the next run rather than lost
+		s.logger.Error("failed to record rate card change notice", err, "changeID", change.ID)
+		return false
+	}
+
+	notified := now
+	change.FinanceNotifiedAt = &notified
+	return true
+}
+
+// checkNotice refuses effective dates leaving finance less than the minimum
+// notice
+func (s *rateCardService) checkNotice(effectiveAt, now time.Time) error {
+	if effectiveAt.Before(now.Add(s.policy.MinNotice)) {
+		return fmt.Errorf("%w: the effective date must be at least %s away", ErrInvalidRateCardChange, s.policy.MinNotice)
+	}
+	return nil
+}
+
+// mapError maps repository errors to service errors
+func (s *rateCardService) mapError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrRateCardChangeNotFound):
+		return ErrRateCardChangeNotFound
+	case errors.Is(err, repository.ErrRateCardChangeConflict):
+		return ErrRateCardChangeConflict
+	default:
+		return fmt.Errorf("rate card change failed: %w", err)
+	}
+}
+
+// validateRates refuses negative rates
+func validateRates(rates models.RateCard) error {
+	if rates.PerThousandRequests.IsNegative() || rates.PerDBSecond.IsNegative() || rates.PerGB.IsNegative() {
+		return fmt.Errorf("%w: rates must be non-negative", ErrInvalidRateCardChange)
+	}
+	return nil
+}
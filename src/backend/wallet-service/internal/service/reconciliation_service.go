@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+	"internal/repository"
+)
+
+// ErrReconciliationIssueNotFound is returned when an issue ID does not exist
+var ErrReconciliationIssueNotFound = errors.New("reconciliation issue not found")
+
+// ReconciliationIssueDetail is the drill-down view of a reconciliation issue
+type ReconciliationIssueDetail struct {
+	Issue  *models.ReconciliationIssue  `json:"issue"`
+	Ledger []*models.LedgerEntrySummary `json:"ledger"`
+}
+
+// ReconciliationService defines the interface for ledger reconciliation
+type ReconciliationService interface {
+	Run(ctx context.Context) (*models.ReconciliationRun, error)
+	RunNightly(ctx context.Context, at time.Duration)
+	ListIssues(ctx context.Context, status models.ReconciliationIssueStatus, pagination Pagination) ([]*models.ReconciliationIssue, error)
+	GetIssueDetail(ctx context.Context, id uuid.UUID) (*ReconciliationIssueDetail, error)
+}
+
+// reconciliationService implements ReconciliationService interface
+type reconciliationService struct {
+	repo   repository.ReconciliationRepository
+	logger Logger
+}
+
+// NewReconciliationService creates a new instance of ReconciliationService
+func NewReconciliationService(repo repository.ReconciliationRepository, logger Logger) (ReconciliationService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &reconciliationService{
+		repo:   repo,
+		logger: logger,
+	}, nil
+}
+
+// Run compares every stored wallet balance with the balance recomputed from
+// its completed transactions, records discrepancies and resolves issues whose
+// wallets are back in agreement. Runs are idempotent, so overlapping runs on
+// several replicas only refresh the same open issues.
+func (s *reconciliationService) Run(ctx context.Context) (*models.ReconciliationRun, error) {
+	run := &models.ReconciliationRun{StartedAt: time.Now().UTC()}
+
+	checked, issues, err := s.repo.FindDiscrepancies(ctx)
+	if err != nil {
+		s.logger.Error("reconciliation failed", err)
+		return nil, fmt.Errorf("failed to find discrepancies: %w", err)
+	}
+	run.WalletsChecked = checked
+
+	openWallets := make([]uuid.UUID, 0, len(issues))
+	for _, issue := range issues {
+		issue.LastCheckedAt = run.StartedAt
+		if err := s.repo.UpsertOpenIssue(ctx, issue); err != nil {
+			s.logger.Error("failed to record reconciliation issue", err, "walletID", issue.WalletID)
+			return nil, fmt.Errorf("failed to record issue: %w", err)
+		}
+		openWallets = append(openWallets, issue.WalletID)
+
+		s.logger.Warn("wallet balance discrepancy",
+			"walletID", issue.WalletID,
+			"storedBalance", issue.StoredBalance,
+			"ledgerBalance", issue.LedgerBalance,
+			"difference", issue.Difference)
+	}
+	run.IssuesOpen = len(issues)
+
+	resolved, err := s.repo.ResolveIssues(ctx, openWallets, run.StartedAt)
+	if err != nil {
+		s.logger.Error("failed to resolve reconciliation issues", err)
+		return nil, fmt.Errorf("failed to resolve issues: %w", err)
+	}
+	run.IssuesResolved = resolved
+	run.CompletedAt = time.Now().UTC()
+
+	s.logger.Info("reconciliation completed",
+		"walletsChecked", run.WalletsChecked,
+		"issuesOpen", run.IssuesOpen,
+		"issuesResolved", run.IssuesResolved,
+		"duration", run.CompletedAt.Sub(run.StartedAt))
+
+	return run, nil
+}
+
+// RunNightly runs the reconciliation once a day at the given offset from
+// midnight UTC until the context is cancelled
+func (s *reconciliationService) RunNightly(ctx context.Context, at time.Duration) {
+	// Failures are logged by Run and retried on the next night
+	runDailyAt(ctx, at, func(time.Time) {
+		_, _ = s.Run(ctx)
+	})
+}
+
+// ListIssues retrieves paginated reconciliation issues, optionally by status
+func (s *reconciliationService) ListIssues(ctx context.Context, status models.ReconciliationIssueStatus, pagination Pagination) ([]*models.ReconciliationIssue, error) {
+	issues, err := s.repo.ListIssues(ctx, status, pagination.Limit, pagination.Offset)
+	if err != nil {
+		s.logger.Error("failed to list reconciliation issues", err)
+		return nil, fmt.Errorf("failed to list issues: %w", err)
+	}
+
+	return issues, nil
+}
+
+// GetIssueDetail returns an issue with the wallet's ledger totals by type and
+// status so operators can see which transactions explain the difference
+func (s *reconciliationService) GetIssueDetail(ctx context.Context, id uuid.UUID) (*ReconciliationIssueDetail, error) {
+	issue, err := s.repo.GetIssue(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrReconciliationIssueNotFound) {
+			return nil, ErrReconciliationIssueNotFound
+		}
+		s.logger.Error("failed to get reconciliation issue", err, "issueID", id)
+		return nil, fmt.Errorf("failed to get issue: %w", err)
+	}
+
+	ledger, err := s.repo.GetLedgerSummary(ctx, issue.WalletID)
+	if err != nil {
+		s.logger.Error("failed to get ledger summary", err, "walletID", issue.WalletID)
+		return nil, fmt.Errorf("failed to get ledger summary: %w", err)
+	}
+
+	return &ReconciliationIssueDetail{
+		Issue:  issue,
+		Ledger: ledger,
+	}, nil
+}
@@ -0,0 +1,468 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+	"github.com/shopspring/decimal"                           // v1.3.1
+
+	"internal/models"
+	"internal/repository"
+)
+
+// Recurring debit constants
+const (
+	// recurringBatchSize bounds the due recurring debits loaded per pass
+	recurringBatchSize = 100
+	// recurringRunHistory bounds the runs returned for a recurring debit
+	recurringRunHistory = 100
+)
+
+// Recurring debit errors
+var (
+	ErrRecurringDebitNotFound = errors.New("recurring debit not found")
+	ErrInvalidRecurringDebit  = errors.New("invalid recurring debit")
+)
+
+// recurringRuns counts finished runs of recurring debits by outcome
+var recurringRuns = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_recurring_debit_runs_total",
+		Help: "Finished runs of recurring debits, by status",
+	},
+	[]string{"status"},
+)
+
+// RecurringDebitPolicy configures the execution of recurring debits
+type RecurringDebitPolicy struct {
+	// CatchUpWindow is how late a run missed during downtime may still
+	// debit the wallet; older missed runs are skipped. Zero catches up every
+	// missed run.
+	CatchUpWindow time.Duration
+	// ClaimTimeout is how long a run claimed by a scheduler that stopped
+	// mid-run waits before another scheduler takes it over
+	ClaimTimeout time.Duration
+}
+
+// RecurringDebitInput holds the customer-provided fields of a recurring debit
+type RecurringDebitInput struct {
+	WalletID    uuid.UUID
+	Amount      decimal.Decimal
+	Cadence     models.RecurringCadence
+	Description string
+	// StartAt is the first run, now when zero
+	StartAt time.Time
+	EndAt   *time.Time
+}
+
+// RecurringDebitService defines the interface for recurring debit schedules
+type RecurringDebitService interface {
+	Create(ctx context.Context, customerID uuid.UUID, input RecurringDebitInput) (*models.RecurringDebit, error)
+	Get(ctx context.Context, customerID, id uuid.UUID) (*models.RecurringDebit, error)
+	List(ctx context.Context, customerID uuid.UUID) ([]*models.RecurringDebit, error)
+	Cancel(ctx context.Context, customerID, id uuid.UUID) (*models.RecurringDebit, error)
+	ListRuns(ctx context.Context, customerID, id uuid.UUID) ([]*models.RecurringDebitRun, error)
+	ProcessDue(ctx context.Context, now time.Time) (int, error)
+	// RetryRun debits a failed run queued as a failed job again
+	RetryRun(ctx context.Context, job *models.FailedJob) error
+	RunScheduler(ctx context.Context, interval time.Duration)
+}
+
+// recurringDebitService implements RecurringDebitService interface
+type recurringDebitService struct {
+	repo     repository.RecurringDebitRepository
+	wallets  WalletService
+	policy   RecurringDebitPolicy
+	failures FailedJobRecorder
+	logger   Logger
+}
+
+// recurringRetry is the failed job payload of a recurring debit run
+type recurringRetry struct {
+	RecurringDebitID uuid.UUID `json:"recurring_debit_id"`
+	CustomerID       uuid.UUID `json:"customer_id"`
+	ScheduledFor     time.Time `json:"scheduled_for"`
+}
+
+// NewRecurringDebitService creates a new instance of RecurringDebitService
+// queueing runs the wallet rejected for retries with failures
+func NewRecurringDebitService(repo repository.RecurringDebitRepository, wallets WalletService, policy RecurringDebitPolicy, failures FailedJobRecorder, logger Logger) (RecurringDebitService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if policy.CatchUpWindow < 0 {
+		return nil, errors.New("catch-up window must not be negative")
+	}
+	if policy.ClaimTimeout <= 0 {
+		return nil, errors.New("claim timeout must be positive")
+	}
+	if failures == nil {
+		return nil, errors.New("failed job recorder is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &recurringDebitService{
+		repo:     repo,
+		wallets:  wallets,
+		policy:   policy,
+		failures: failures,
+		logger:   logger,
+	}, nil
+}
+
+// Create schedules a recurring debit of one of the customer's wallets, in
+// the wallet's currency
+func (s *recurringDebitService) Create(ctx context.Context, customerID uuid.UUID, input RecurringDebitInput) (*models.RecurringDebit, error) {
+	now := time.Now().UTC()
+	if input.StartAt.IsZero() {
+		input.StartAt = now
+	}
+	if err := validateRecurringDebit(input, now); err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.wallets.GetWallet(ctx, input.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.CustomerID != customerID {
+		return nil, ErrWalletNotFound
+	}
+
+	debit := &models.RecurringDebit{
+		CustomerID:  customerID,
+		WalletID:    wallet.ID,
+		Amount:      input.Amount,
+		Currency:    wallet.Currency,
+		Description: input.Description,
+		Cadence:     input.Cadence,
+		StartAt:     input.StartAt.UTC(),
+		EndAt:       input.EndAt,
+	}
+	debit.NextRunAt = debit.NextRun(0)
+
+	if err := s.repo.CreateRecurringDebit(ctx, debit); err != nil {
+		s.logger.Error("failed to create recurring debit", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to create recurring debit: %w", err)
+	}
+
+	s.logger.Info("recurring debit created",
+		"customerID", customerID,
+		"recurringDebitID", debit.ID,
+		"walletID", debit.WalletID,
+		"cadence", debit.Cadence)
+
+	return debit, nil
+}
+
+// validateRecurringDebit checks the customer-provided fields of a recurring debit
+func validateRecurringDebit(input RecurringDebitInput, now time.Time) error {
+	switch input.Cadence {
+	case models.CadenceDaily, models.CadenceWeekly, models.CadenceMonthly:
+	default:
+		return fmt.Errorf("%w: cadence must be DAILY, WEEKLY or MONTHLY", ErrInvalidRecurringDebit)
+	}
+	if !input.Amount.IsPositive() || input.Amount.Exponent() < -2 {
+		return fmt.Errorf("%w: amount must be positive with at most 2 decimal places", ErrInvalidRecurringDebit)
+	}
+	if input.StartAt.Before(now.Add(-time.Minute)) {
+		return fmt.Errorf("%w: start_at must not be in the past", ErrInvalidRecurringDebit)
+	}
+	if input.EndAt != nil && input.EndAt.Before(input.StartAt) {
+		return fmt.Errorf("%w: end_at must not be before start_at", ErrInvalidRecurringDebit)
+	}
+	if len(input.Description) > 255 {
+		return fmt.Errorf("%w: description must be at most 255 characters", ErrInvalidRecurringDebit)
+	}
+	return nil
+}
+
+// Get returns one of the customer's recurring debits
+func (s *recurringDebitService) Get(ctx context.Context, customerID, id uuid.UUID) (*models.RecurringDebit, error) {
+	debit, err := s.repo.GetRecurringDebit(ctx, customerID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecurringDebitNotFound) {
+			return nil, ErrRecurringDebitNotFound
+		}
+		s.logger.Error("failed to get recurring debit", err, "recurringDebitID", id)
+		return nil, fmt.Errorf("failed to get recurring debit: %w", err)
+	}
+
+	return debit, nil
+}
+
+// List returns the customer's recurring debits
+func (s *recurringDebitService) List(ctx context.Context, customerID uuid.UUID) ([]*models.RecurringDebit, error) {
+	debits, err := s.repo.ListRecurringDebits(ctx, customerID)
+	if err != nil {
+		s.logger.Error("failed to list recurring debits", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to list recurring debits: %w", err)
+	}
+
+	return debits, nil
+}
+
+// Cancel stops a recurring debit. A run already in progress completes.
+func (s *recurringDebitService) Cancel(ctx context.Context, customerID, id uuid.UUID) (*models.RecurringDebit, error) {
+	debit, err := s.repo.CancelRecurringDebit(ctx, customerID, id, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, repository.ErrRecurringDebitNotFound) {
+			return nil, ErrRecurringDebitNotFound
+		}
+		s.logger.Error("failed to cancel recurring debit", err, "recurringDebitID", id)
+		return nil, fmt.Errorf("failed to cancel recurring debit: %w", err)
+	}
+
+	s.logger.Info("recurring debit cancelled",
+		"customerID", customerID,
+		"recurringDebitID", id)
+
+	return debit, nil
+}
+
+// ListRuns returns the latest runs of one of the customer's recurring debits
+func (s *recurringDebitService) ListRuns(ctx context.Context, customerID, id uuid.UUID) ([]*models.RecurringDebitRun, error) {
+	if _, err := s.Get(ctx, customerID, id); err != nil {
+		return nil, err
+	}
+
+	runs, err := s.repo.ListRuns(ctx, id, recurringRunHistory)
+	if err != nil {
+		s.logger.Error("failed to list recurring debit runs", err, "recurringDebitID", id)
+		return nil, fmt.Errorf("failed to list recurring debit runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// ProcessDue executes every due run of the recurring debits, catching up on
+// runs missed while no scheduler was running, and returns the number of runs
+// finished. A debit whose run fails transiently stops for this pass and is
+// retried on the next one.
+func (s *recurringDebitService) ProcessDue(ctx context.Context, now time.Time) (int, error) {
+	now = now.UTC()
+
+	debits, err := s.repo.ListDue(ctx, now, recurringBatchSize)
+	if err != nil {
+		s.logger.Error("failed to list due recurring debits", err)
+		return 0, fmt.Errorf("failed to list due recurring debits: %w", err)
+	}
+
+	finished := 0
+	for _, debit := range debits {
+		for debit.NextRunAt != nil && !debit.NextRunAt.After(now) {
+			ran, err := s.execute(ctx, debit, *debit.NextRunAt, now)
+			if err != nil || !ran {
+				break
+			}
+			finished++
+		}
+	}
+
+	return finished, nil
+}
+
+// execute claims and runs one scheduled run of a debit, advancing the debit
+// to its next run. It reports false when another scheduler holds the run.
+func (s *recurringDebitService) execute(ctx context.Context, debit *models.RecurringDebit, scheduledFor, now time.Time) (bool, error) {
+	run := &models.RecurringDebitRun{
+		RecurringDebitID: debit.ID,
+		ScheduledFor:     scheduledFor,
+		IdempotencyKey:   recurringRunKey(debit.ID, scheduledFor),
+		Status:           models.RecurringRunPending,
+		ClaimedAt:        now,
+	}
+
+	claimed, err := s.repo.ClaimRun(ctx, run, now.Add(-s.policy.ClaimTimeout))
+	if err != nil {
+		s.logger.Error("failed to claim recurring debit run", err, "recurringDebitID", debit.ID)
+		return false, err
+	}
+	if !claimed {
+		return false, nil
+	}
+
+	if s.policy.CatchUpWindow > 0 && now.Sub(scheduledFor) > s.policy.CatchUpWindow {
+		run.Status = models.RecurringRunSkipped
+	} else if err := s.debit(ctx, debit, run); err != nil {
+		if releaseErr := s.repo.ReleaseRun(ctx, run); releaseErr != nil {
+			s.logger.Error("failed to release recurring debit run", releaseErr, "recurringDebitID", debit.ID)
+		}
+		return false, err
+	}
+
+	finishedAt := time.Now().UTC()
+	run.FinishedAt = &finishedAt
+	next := debit.NextRun(debit.RunCount + 1)
+	if err := s.repo.FinishRun(ctx, run, debit.RunCount, next); err != nil {
+		// The run stays claimed; the scheduler taking it over finds the
+		// debit by its idempotency key rather than repeating it
+		s.logger.Error("failed to finish recurring debit run", err,
+			"recurringDebitID", debit.ID,
+			"scheduledFor", scheduledFor)
+		return false, err
+	}
+	debit.RunCount++
+	debit.NextRunAt = next
+
+	if run.Status == models.RecurringRunFailed {
+		retry := recurringRetry{RecurringDebitID: debit.ID, CustomerID: debit.CustomerID, ScheduledFor: scheduledFor}
+		if err := s.failures.RecordFailure(ctx, models.FailedJobRecurringDebitRun, run.IdempotencyKey, retry, errors.New(run.Error)); err != nil {
+			s.logger.Error("failed to queue recurring debit run retry", err, "recurringDebitID", debit.ID)
+		}
+	}
+
+	recurringRuns.WithLabelValues(string(run.Status)).Inc()
+	s.logger.Info("recurring debit run finished",
+		"recurringDebitID", debit.ID,
+		"walletID", debit.WalletID,
+		"scheduledFor", scheduledFor,
+		"status", run.Status)
+
+	return true, nil
+}
+
+// debit debits the wallet for a claimed run, recording the outcome on the
+// run. A run taken over from a stopped scheduler may have debited already,
+// which the transaction carrying its idempotency key shows. Rejections by
+// the wallet fail the run; other errors are returned for a retry.
+func (s *recurringDebitService) debit(ctx context.Context, debit *models.RecurringDebit, run *models.RecurringDebitRun) error {
+	matches, err := s.wallets.FindTransactionsByReference(ctx, run.IdempotencyKey)
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		if match.Transaction.WalletID == debit.WalletID {
+			run.Status = models.RecurringRunSucceeded
+			run.TransactionID = &match.Transaction.ID
+			return nil
+		}
+	}
+
+	description := debit.Description
+	if description == "" {
+		description = "Recurring debit"
+	}
+	amount, _ := debit.Amount.Float64()
+	now := time.Now().UTC()
+	tx := &models.Transaction{
+		ID:          uuid.New(),
+		WalletID:    debit.WalletID,
+		Type:        models.TransactionTypeDebit,
+		Status:      models.TransactionStatusInitiated,
+		Amount:      amount,
+		Currency:    debit.Currency,
+		Description: description,
+		ReferenceID: run.IdempotencyKey,
+		Metadata: map[string]string{
+			"recurring_debit_id": debit.ID.String(),
+			"scheduled_for":      run.ScheduledFor.Format(time.RFC3339),
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	_, err = s.wallets.ProcessTransaction(ctx, tx)
+	switch {
+	case err == nil:
+		run.Status = models.RecurringRunSucceeded
+		run.TransactionID = &tx.ID
+		return nil
+	case errors.Is(err, ErrInsufficientBalance), errors.Is(err, repository.ErrInsufficientBalance),
+		errors.Is(err, ErrWalletNotFound), errors.Is(err, ErrCurrencyMismatch):
+		s.logger.Warn("recurring debit run rejected",
+			"recurringDebitID", debit.ID,
+			"walletID", debit.WalletID,
+			"error", err)
+		run.Status = models.RecurringRunFailed
+		run.Error = err.Error()
+		return nil
+	default:
+		s.logger.Error("failed to debit recurring debit run", err,
+			"recurringDebitID", debit.ID,
+			"walletID", debit.WalletID)
+		return err
+	}
+}
+
+// RetryRun debits a failed run again under its idempotency key, marking the
+// run succeeded once debited. Runs of debits since cancelled no longer
+// apply.
+func (s *recurringDebitService) RetryRun(ctx context.Context, job *models.FailedJob) error {
+	var retry recurringRetry
+	if err := json.Unmarshal(job.Payload, &retry); err != nil {
+		return fmt.Errorf("%w: malformed recurring debit run", ErrFailedJobObsolete)
+	}
+
+	debit, err := s.repo.GetRecurringDebit(ctx, retry.CustomerID, retry.RecurringDebitID)
+	if errors.Is(err, repository.ErrRecurringDebitNotFound) {
+		return fmt.Errorf("%w: recurring debit not found", ErrFailedJobObsolete)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load recurring debit: %w", err)
+	}
+	if debit.CancelledAt != nil {
+		return fmt.Errorf("%w: recurring debit cancelled", ErrFailedJobObsolete)
+	}
+
+	run := &models.RecurringDebitRun{
+		RecurringDebitID: debit.ID,
+		ScheduledFor:     retry.ScheduledFor,
+		IdempotencyKey:   recurringRunKey(debit.ID, retry.ScheduledFor),
+	}
+	if err := s.debit(ctx, debit, run); err != nil {
+		return err
+	}
+	if run.Status == models.RecurringRunFailed {
+		return errors.New(run.Error)
+	}
+
+	finishedAt := time.Now().UTC()
+	run.FinishedAt = &finishedAt
+	if err := s.repo.RecoverRun(ctx, run); err != nil {
+		// The next retry finds the debit by its idempotency key
+		return err
+	}
+
+	s.logger.Info("recurring debit run recovered",
+		"recurringDebitID", debit.ID,
+		"walletID", debit.WalletID,
+		"scheduledFor", run.ScheduledFor)
+	return nil
+}
+
+// RunScheduler executes due recurring debit runs every interval until the
+// context is cancelled. The first pass runs immediately so runs missed while
+// the service was down are caught up on start.
+func (s *recurringDebitService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Failures are logged by ProcessDue and retried on the next tick
+	_, _ = s.ProcessDue(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			_, _ = s.ProcessDue(ctx, now)
+		}
+	}
+}
+
+// recurringRunKey derives the idempotency key of a run, used as the reference
+// ID of its debit
+func recurringRunKey(id uuid.UUID, scheduledFor time.Time) string {
+	return "RD-" + id.String() + "-" + scheduledFor.UTC().Format("20060102T150405Z")
+}
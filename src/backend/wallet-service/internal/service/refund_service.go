@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/breaker"
+	"internal/calendar"
+	"internal/currency"
+	"internal/eventbus"
+	"internal/models"
+	"internal/payment"
+	"internal/repository"
+)
+
+// Refund processing constants
+const (
+	// refundBatchSize bounds the due refunds processed per poll
+	refundBatchSize = 100
+	// refundMinBackoff and refundMaxBackoff bound the delay between
+	// submission retries and provider status checks
+	refundMinBackoff = time.Minute
+	refundMaxBackoff = time.Hour
+	// maxRefundSubmitAttempts bounds retries of refunds the provider never
+	// accepted before they are failed and their funds released
+	maxRefundSubmitAttempts = 10
+)
+
+// Refund-to-source errors
+var (
+	ErrRefundNotAllowed = errors.New("top-up cannot be refunded to its payment method")
+	ErrRefundNotFound   = errors.New("refund not found")
+	ErrInvalidPayment   = errors.New("invalid provider payment")
+)
+
+// RefundService defines the interface for refunding top-ups to their
+// original payment method
+type RefundService interface {
+	RecordTopUpPayment(ctx context.Context, walletID, transactionID uuid.UUID, provider, paymentID string) (*models.ProviderPayment, error)
+	RefundToSource(ctx context.Context, walletID, transactionID uuid.UUID, amount decimal.Decimal) (*models.ProviderRefund, error)
+	GetRefund(ctx context.Context, walletID, id uuid.UUID) (*models.ProviderRefund, error)
+	ProcessDue(ctx context.Context, now time.Time) (int, error)
+	RunPoller(ctx context.Context, interval time.Duration)
+}
+
+// refundService implements RefundService interface
+type refundService struct {
+	repo       repository.RefundRepository
+	providers  *payment.Registry
+	calendar   *calendar.Calendar
+	currencies *currency.Registry
+	publisher  eventbus.Publisher
+	logger     Logger
+}
+
+// NewRefundService creates a new instance of RefundService
+func NewRefundService(repo repository.RefundRepository, providers *payment.Registry, cal *calendar.Calendar, currencies *currency.Registry, publisher eventbus.Publisher, logger Logger) (RefundService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if providers == nil {
+		return nil, errors.New("payment provider registry is required")
+	}
+	if cal == nil {
+		return nil, errors.New("calendar is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currency registry is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &refundService{
+		repo:       repo,
+		providers:  providers,
+		calendar:   cal,
+		currencies: currencies,
+		publisher:  publisher,
+		logger:     logger,
+	}, nil
+}
+
+// RecordTopUpPayment links a top-up to the provider payment that funded it so
+// it can later be refunded to that payment method
+func (s *refundService) RecordTopUpPayment(ctx context.Context, walletID, transactionID uuid.UUID, provider, paymentID string) (*models.ProviderPayment, error) {
+	if paymentID == "" || len(paymentID) > 128 {
+		return nil, fmt.Errorf("%w: payment ID must be 1 to 128 characters", ErrInvalidPayment)
+	}
+	if _, err := s.providers.Get(provider); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayment, err)
+	}
+
+	p := &models.ProviderPayment{
+		TransactionID:     transactionID,
+		Provider:          provider,
+		ProviderPaymentID: paymentID,
+	}
+	if err := s.repo.RecordPayment(ctx, walletID, p); err != nil {
+		if errors.Is(err, repository.ErrPaymentNotLinkable) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPayment, err)
+		}
+		s.logger.Error("failed to record top-up payment", err, "transactionID", transactionID)
+		return nil, fmt.Errorf("failed to record top-up payment: %w", err)
+	}
+
+	return p, nil
+}
+
+// RefundToSource holds the amount from the wallet and pushes it back to the
+// top-up's payment method. The refund's wallet transaction stays PROCESSING
+// until the provider confirms, and is failed with its funds released if the
+// provider refuses.
+func (s *refundService) RefundToSource(ctx context.Context, walletID, transactionID uuid.UUID, amount decimal.Decimal) (*models.ProviderRefund, error) {
+	if !amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+
+	// Round the amount to the precision of the top-up's currency in the
+	// currency's rounding mode, refusing amounts that round away to nothing
+	code, err := s.repo.GetPaymentCurrency(ctx, walletID, transactionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			return nil, fmt.Errorf("%w: %v", ErrRefundNotAllowed, err)
+		}
+		s.logger.Error("failed to get top-up currency", err, "transactionID", transactionID)
+		return nil, fmt.Errorf("failed to get top-up currency: %w", err)
+	}
+	minor, err := s.currencies.ToMinorUnits(code, amount)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	if minor.Units <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	refund := &models.ProviderRefund{
+		WalletID:              walletID,
+		OriginalTransactionID: transactionID,
+		Amount:                minor.Decimal(),
+	}
+	description := fmt.Sprintf("Refund of top-up %s to original payment method", transactionID)
+	if err := s.repo.CreateRefund(ctx, refund, description); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPaymentNotFound), errors.Is(err, repository.ErrRefundExceedsPayment):
+			return nil, fmt.Errorf("%w: %v", ErrRefundNotAllowed, err)
+		case errors.Is(err, repository.ErrInsufficientBalance):
+			return nil, ErrInsufficientBalance
+		case errors.Is(err, repository.ErrWalletFrozen):
+			return nil, ErrWalletFrozen
+		case errors.Is(err, repository.ErrWalletMerged):
+			return nil, ErrWalletMerged
+		case errors.Is(err, repository.ErrPeriodClosed):
+			return nil, ErrPeriodClosed
+		case errors.Is(err, repository.ErrOptimisticLock):
+			return nil, ErrOptimisticLock
+		}
+		s.logger.Error("failed to create refund", err, "walletID", walletID, "transactionID", transactionID)
+		return nil, fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	s.logger.Info("refund to source created",
+		"refundID", refund.ID,
+		"walletID", walletID,
+		"provider", refund.Provider,
+		"amount", refund.Amount)
+
+	publishWalletChanges(ctx, s.publisher, s.logger,
+		eventbus.TransactionStatusChanged{WalletID: walletID, TransactionID: refund.TransactionID, Status: models.TransactionStatusProcessing},
+		eventbus.BalanceChanged{WalletID: walletID})
+
+	// Submit right away; failures are retried by the poller
+	s.process(ctx, refund, time.Now().UTC())
+
+	return s.GetRefund(ctx, walletID, refund.ID)
+}
+
+// GetRefund returns one of a wallet's refunds
+func (s *refundService) GetRefund(ctx context.Context, walletID, id uuid.UUID) (*models.ProviderRefund, error) {
+	refund, err := s.repo.GetRefund(ctx, walletID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrRefundNotFound) {
+			return nil, ErrRefundNotFound
+		}
+		s.logger.Error("failed to get refund", err, "refundID", id)
+		return nil, fmt.Errorf("failed to get refund: %w", err)
+	}
+
+	return refund, nil
+}
+
+// ProcessDue submits pending refunds the provider has not accepted yet and
+// polls the status of those it has, returning the number processed.
+// Submissions due outside business days are deferred to the next one.
+func (s *refundService) ProcessDue(ctx context.Context, now time.Time) (int, error) {
+	refunds, err := s.repo.ListDueRefunds(ctx, now, refundBatchSize)
+	if err != nil {
+		s.logger.Error("failed to list due refunds", err)
+		return 0, err
+	}
+
+	businessDay := s.calendar.IsBusinessDay(now)
+	for _, refund := range refunds {
+		if !businessDay && refund.ProviderRefundID == "" {
+			s.deferToBusinessDay(ctx, refund, now)
+			continue
+		}
+		s.process(ctx, refund, now)
+	}
+
+	return len(refunds), nil
+}
+
+// RunPoller processes due refunds every interval until the context is cancelled
+func (s *refundService) RunPoller(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// Failures are logged by ProcessDue and retried on the next tick
+			_, _ = s.ProcessDue(ctx, now.UTC())
+		}
+	}
+}
+
+// process advances one pending refund: submitting it to the provider when
+// not yet accepted, otherwise applying its current provider status
+func (s *refundService) process(ctx context.Context, refund *models.ProviderRefund, now time.Time) {
+	provider, err := s.providers.Get(refund.Provider)
+	if err != nil {
+		s.fail(ctx, refund, err.Error())
+		return
+	}
+
+	var result *payment.RefundResult
+	if refund.ProviderRefundID == "" {
+		result, err = provider.Refund(ctx, payment.RefundRequest{
+			PaymentID:      refund.ProviderPaymentID,
+			Amount:         refund.Amount,
+			Currency:       refund.Currency,
+			IdempotencyKey: refund.ID.String(),
+		})
+		switch {
+		case errors.Is(err, payment.ErrRefundRejected):
+			s.fail(ctx, refund, err.Error())
+			return
+		case err != nil && !errors.Is(err, breaker.ErrOpen) && refund.Attempts+1 >= maxRefundSubmitAttempts:
+			s.fail(ctx, refund, fmt.Sprintf("not accepted after %d attempts: %v", maxRefundSubmitAttempts, err))
+			return
+		}
+	} else {
+		// Submitted refunds are never failed locally, as the provider may
+		// still settle them; they are polled until it reports an outcome
+		result, err = provider.GetRefund(ctx, refund.ProviderRefundID)
+	}
+	if errors.Is(err, breaker.ErrOpen) {
+		// The provider is known to be down, so the call was not made and
+		// does not count as an attempt
+		if err := s.repo.DeferRefund(ctx, refund.ID, now.Add(refundMinBackoff)); err != nil {
+			s.logger.Error("failed to defer refund", err, "refundID", refund.ID)
+		}
+		return
+	}
+	if err != nil {
+		s.logger.Warn("refund provider call failed",
+			"refundID", refund.ID,
+			"provider", refund.Provider,
+			"error", err)
+		s.schedule(ctx, refund, now)
+		return
+	}
+
+	switch result.Status {
+	case payment.RefundSucceeded:
+		if err := s.repo.CompleteRefund(ctx, refund.ID); err != nil {
+			s.logger.Error("failed to complete refund", err, "refundID", refund.ID)
+			return
+		}
+		s.logger.Info("refund to source completed", "refundID", refund.ID, "walletID", refund.WalletID)
+		publishWalletChanges(ctx, s.publisher, s.logger,
+			eventbus.TransactionStatusChanged{WalletID: refund.WalletID, TransactionID: refund.TransactionID, Status: models.TransactionStatusCompleted})
+	case payment.RefundFailed:
+		s.fail(ctx, refund, result.FailureReason)
+	default:
+		if refund.ProviderRefundID == "" {
+			next := now.Add(refundBackoff(refund.Attempts))
+			if err := s.repo.MarkSubmitted(ctx, refund.ID, result.RefundID, next); err != nil {
+				s.logger.Error("failed to record refund submission", err, "refundID", refund.ID)
+			}
+			return
+		}
+		s.schedule(ctx, refund, now)
+	}
+}
+
+// fail fails a refund and releases its held funds to the wallet
+func (s *refundService) fail(ctx context.Context, refund *models.ProviderRefund, reason string) {
+	if err := s.repo.FailRefund(ctx, refund.ID, reason); err != nil {
+		s.logger.Error("failed to fail refund", err, "refundID", refund.ID)
+		return
+	}
+	s.logger.Warn("refund to source failed, funds returned to wallet",
+		"refundID", refund.ID,
+		"walletID", refund.WalletID,
+		"reason", reason)
+
+	publishWalletChanges(ctx, s.publisher, s.logger,
+		eventbus.TransactionStatusChanged{WalletID: refund.WalletID, TransactionID: refund.TransactionID, Status: models.TransactionStatusFailed},
+		eventbus.BalanceChanged{WalletID: refund.WalletID})
+}
+
+// deferToBusinessDay postpones the submission of a refund to the start of
+// the next business day
+func (s *refundService) deferToBusinessDay(ctx context.Context, refund *models.ProviderRefund, now time.Time) {
+	next := s.calendar.NextBusinessDay(now)
+	if err := s.repo.DeferRefund(ctx, refund.ID, next); err != nil {
+		s.logger.Error("failed to defer refund", err, "refundID", refund.ID)
+	}
+}
+
+// schedule backs off the next submission or status check of a refund
+func (s *refundService) schedule(ctx context.Context, refund *models.ProviderRefund, now time.Time) {
+	if err := s.repo.ScheduleCheck(ctx, refund.ID, now.Add(refundBackoff(refund.Attempts))); err != nil {
+		s.logger.Error("failed to schedule refund check", err, "refundID", refund.ID)
+	}
+}
+
+// refundBackoff doubles the delay with every attempt up to refundMaxBackoff
+func refundBackoff(attempts int) time.Duration {
+	backoff := refundMinBackoff
+	for i := 0; i < attempts && backoff < refundMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > refundMaxBackoff {
+		backoff = refundMaxBackoff
+	}
+	return backoff
+}
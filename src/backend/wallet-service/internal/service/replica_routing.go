@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/models"
+	"internal/repository"
+)
+
+// Read replica metrics
+var (
+	replicaLag = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "wallet_db_replica_lag_seconds",
+			Help: "How far the read replica is behind the primary, as of the last check",
+		},
+	)
+	replicaReads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_db_replica_reads_total",
+			Help: "Routed repository reads, by method and the database that served them",
+		},
+		[]string{"method", "target"},
+	)
+)
+
+// Databases serving routed reads
+const (
+	readTargetReplica  = "replica"
+	readTargetPrimary  = "primary"
+	readTargetFallback = "fallback"
+)
+
+// ReplicaRouter is a WalletRepository that serves read-only queries from a
+// read replica and everything else from the primary
+type ReplicaRouter interface {
+	repository.WalletRepository
+	// CheckLag refreshes the replica's lag. Until a check succeeds, and
+	// after one fails, reads are served by the primary.
+	CheckLag(ctx context.Context) error
+}
+
+// replicaRouter implements ReplicaRouter interface
+type replicaRouter struct {
+	repository.WalletRepository
+	replica       repository.WalletRepository
+	status        repository.ReplicaRepository
+	maxBalanceLag time.Duration
+	logger        Logger
+
+	mu        sync.RWMutex
+	lag       time.Duration
+	available bool
+}
+
+// NewReplicaRouter wraps primary so that transaction history and other
+// read-only queries are served by replica. Balance reads are served by the
+// replica only while it is at most maxBalanceLag behind. A replica read that
+// fails is retried on the primary.
+func NewReplicaRouter(primary, replica repository.WalletRepository, status repository.ReplicaRepository, maxBalanceLag time.Duration, logger Logger) (ReplicaRouter, error) {
+	if primary == nil {
+		return nil, errors.New("primary repository is required")
+	}
+	if replica == nil {
+		return nil, errors.New("replica repository is required")
+	}
+	if status == nil {
+		return nil, errors.New("replica status repository is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if maxBalanceLag <= 0 {
+		return nil, errors.New("max balance lag must be positive")
+	}
+
+	return &replicaRouter{
+		WalletRepository: primary,
+		replica:          replica,
+		status:           status,
+		maxBalanceLag:    maxBalanceLag,
+		logger:           logger,
+	}, nil
+}
+
+// Close closes the prepared statements of the primary and the replica
+func (r *replicaRouter) Close() error {
+	return errors.Join(r.WalletRepository.Close(), r.replica.Close())
+}
+
+// CheckLag refreshes the replica's lag and the lag metric
+func (r *replicaRouter) CheckLag(ctx context.Context) error {
+	lag, err := r.status.ReplicationLag(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wasAvailable := r.available
+	r.available = err == nil
+	if err != nil {
+		if wasAvailable {
+			r.logger.Warn("read replica unavailable, reading from primary", "error", err.Error())
+		}
+		return err
+	}
+
+	r.lag = lag
+	replicaLag.Set(lag.Seconds())
+	if !wasAvailable {
+		r.logger.Info("read replica available", "lag", lag.String())
+	}
+	return nil
+}
+
+// GetWallet retrieves a wallet, from the replica when its balance is fresh
+// enough
+func (r *replicaRouter) GetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	return routeRead(ctx, r, "GetWallet", true, func(repo repository.WalletRepository) (*models.Wallet, error) {
+		return repo.GetWallet(ctx, id)
+	})
+}
+
+// GetCustomerWallets retrieves a customer's wallets, from the replica when
+// their balances are fresh enough
+func (r *replicaRouter) GetCustomerWallets(ctx context.Context, customerID uuid.UUID) ([]*models.Wallet, error) {
+	return routeRead(ctx, r, "GetCustomerWallets", true, func(repo repository.WalletRepository) ([]*models.Wallet, error) {
+		return repo.GetCustomerWallets(ctx, customerID)
+	})
+}
+
+// GetWalletOverview reads a wallet with its newest transactions, from the
+// replica when its balance is fresh enough. Either database serves both
+// reads from one snapshot.
+func (r *replicaRouter) GetWalletOverview(ctx context.Context, walletID uuid.UUID, limit int) (*models.WalletOverview, error) {
+	return routeRead(ctx, r, "GetWalletOverview", true, func(repo repository.WalletRepository) (*models.WalletOverview, error) {
+		return repo.GetWalletOverview(ctx, walletID, limit)
+	})
+}
+
+// GetTransactions retrieves a page of a wallet's transactions from the replica
+func (r *replicaRouter) GetTransactions(ctx context.Context, walletID uuid.UUID, limit, offset int) ([]*models.Transaction, error) {
+	return routeRead(ctx, r, "GetTransactions", false, func(repo repository.WalletRepository) ([]*models.Transaction, error) {
+		return repo.GetTransactions(ctx, walletID, limit, offset)
+	})
+}
+
+// transactionPage is a page of transactions with the number of matches
+type transactionPage struct {
+	transactions []*models.Transaction
+	total        int
+}
+
+// FindTransactions retrieves a page of a wallet's transactions matching a
+// filter from the replica
+func (r *replicaRouter) FindTransactions(ctx context.Context, walletID uuid.UUID, filter repository.TransactionFilter, limit, offset int) ([]*models.Transaction, int, error) {
+	page, err := routeRead(ctx, r, "FindTransactions", false, func(repo repository.WalletRepository) (transactionPage, error) {
+		transactions, total, err := repo.FindTransactions(ctx, walletID, filter, limit, offset)
+		return transactionPage{transactions: transactions, total: total}, err
+	})
+	return page.transactions, page.total, err
+}
+
+// GetTransactionByID retrieves a transaction from the replica
+func (r *replicaRouter) GetTransactionByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
+	return routeRead(ctx, r, "GetTransactionByID", false, func(repo repository.WalletRepository) (*models.Transaction, error) {
+		return repo.GetTransactionByID(ctx, id)
+	})
+}
+
+// GetRecentTransactions retrieves the newest transactions of several wallets
+// from the replica
+func (r *replicaRouter) GetRecentTransactions(ctx context.Context, walletIDs []uuid.UUID, limit int) ([]*models.Transaction, error) {
+	return routeRead(ctx, r, "GetRecentTransactions", false, func(repo repository.WalletRepository) ([]*models.Transaction, error) {
+		return repo.GetRecentTransactions(ctx, walletIDs, limit)
+	})
+}
+
+// FindTransactionsByReference finds transactions by reference, from the
+// replica when the balances of their wallets are fresh enough
+func (r *replicaRouter) FindTransactionsByReference(ctx context.Context, referenceID string, limit int) ([]*models.TransactionMatch, error) {
+	return routeRead(ctx, r, "FindTransactionsByReference", true, func(repo repository.WalletRepository) ([]*models.TransactionMatch, error) {
+		return repo.FindTransactionsByReference(ctx, referenceID, limit)
+	})
+}
+
+// CountTransactionOutcomes counts a wallet's recent transaction outcomes from
+// the replica
+func (r *replicaRouter) CountTransactionOutcomes(ctx context.Context, walletID uuid.UUID, since time.Time) (int, int, error) {
+	type outcomes struct{ total, failed int }
+	counted, err := routeRead(ctx, r, "CountTransactionOutcomes", false, func(repo repository.WalletRepository) (outcomes, error) {
+		total, failed, err := repo.CountTransactionOutcomes(ctx, walletID, since)
+		return outcomes{total, failed}, err
+	})
+	return counted.total, counted.failed, err
+}
+
+// useReplica reports whether a read may be served by the replica
+func (r *replicaRouter) useReplica(ctx context.Context, balance bool) bool {
+	if repository.RequiresPrimary(ctx) {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.available && (!balance || r.lag <= r.maxBalanceLag)
+}
+
+// routeRead serves a read from the replica when allowed, retrying it on the
+// primary when the replica fails. A wallet or transaction missing from the
+// replica may not have been replicated yet, so any error is retried.
+func routeRead[T any](ctx context.Context, r *replicaRouter, method string, balance bool, read func(repository.WalletRepository) (T, error)) (T, error) {
+	if !r.useReplica(ctx, balance) {
+		replicaReads.WithLabelValues(method, readTargetPrimary).Inc()
+		return read(r.WalletRepository)
+	}
+
+	result, err := read(r.replica)
+	if err == nil {
+		replicaReads.WithLabelValues(method, readTargetReplica).Inc()
+		return result, nil
+	}
+	if ctx.Err() != nil {
+		return result, err
+	}
+
+	if !errors.Is(err, repository.ErrWalletNotFound) {
+		r.logger.Warn("replica read failed, retrying on primary", "method", method, "error", err.Error())
+	}
+	replicaReads.WithLabelValues(method, readTargetFallback).Inc()
+	return read(r.WalletRepository)
+}
@@ -0,0 +1,452 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/models"
+	"internal/repository"
+)
+
+// reportFinishTimeout bounds storing the outcome of a job, which happens
+// even when the worker is stopping
+const reportFinishTimeout = 10 * time.Second
+
+// Report job errors
+var (
+	ErrReportJobNotFound    = errors.New("report job not found")
+	ErrReportJobFinished    = errors.New("report job has already finished")
+	ErrReportJobCancelled   = errors.New("report job was cancelled")
+	ErrReportResultNotReady = errors.New("report job has no result yet")
+	ErrUnknownReportKind    = errors.New("unknown report job kind")
+	ErrInvalidReportParams  = errors.New("invalid report job parameters")
+)
+
+// reportJobs counts finished report job runs by kind and outcome
+var reportJobs = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_report_jobs_total",
+		Help: "Finished report job runs, by kind and status",
+	},
+	[]string{"kind", "status"},
+)
+
+// ReportProgress records the percentage of a job's work done. It returns
+// ErrReportJobCancelled once the job is cancelled, which the report should
+// return promptly.
+type ReportProgress func(percent int) error
+
+// ReportKind is a kind of long-running report that jobs can be submitted for
+type ReportKind struct {
+	// Name identifies the kind in submissions, such as "reconciliation"
+	Name string
+	// Validate checks the parameters of a submission; optional
+	Validate func(params json.RawMessage) error
+	// Run computes the report. Its result is stored with the job as JSON.
+	// The context is cancelled when the job is cancelled.
+	Run func(ctx context.Context, params json.RawMessage, progress ReportProgress) (interface{}, error)
+	// MaxAttempts bounds the runs of a job, retries included; the policy's
+	// default applies when zero
+	MaxAttempts int
+}
+
+// ReportJobPolicy configures how report jobs are run and retried
+type ReportJobPolicy struct {
+	// MaxAttempts bounds the runs of a job, retries included
+	MaxAttempts int
+	// RetryDelay is the wait before the first retry, doubled for each
+	// further one
+	RetryDelay time.Duration
+	// HeartbeatInterval is how often a running job records its progress
+	// and checks for cancellation
+	HeartbeatInterval time.Duration
+	// StaleAfter is how long a running job may miss heartbeats before
+	// another worker takes it over
+	StaleAfter time.Duration
+}
+
+// ReportJobService defines the interface for long-running report jobs
+type ReportJobService interface {
+	Register(kind ReportKind) error
+	Kinds() []string
+	Submit(ctx context.Context, kind string, params json.RawMessage, submittedBy string) (*models.ReportJob, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.ReportJob, error)
+	List(ctx context.Context, kind string, status models.ReportJobStatus, pagination Pagination) ([]*models.ReportJob, error)
+	Cancel(ctx context.Context, id uuid.UUID) (*models.ReportJob, error)
+	GetResult(ctx context.Context, id uuid.UUID) (json.RawMessage, error)
+	RunNext(ctx context.Context) (bool, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// reportJobService implements ReportJobService interface
+type reportJobService struct {
+	repo   repository.ReportJobRepository
+	policy ReportJobPolicy
+	logger Logger
+
+	mu    sync.RWMutex
+	kinds map[string]ReportKind
+}
+
+// NewReportJobService creates a new instance of ReportJobService. Report
+// kinds are registered before workers start running jobs.
+func NewReportJobService(repo repository.ReportJobRepository, policy ReportJobPolicy, logger Logger) (ReportJobService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if policy.MaxAttempts < 1 {
+		return nil, errors.New("max attempts must be at least 1")
+	}
+	if policy.RetryDelay < 0 {
+		return nil, errors.New("retry delay must not be negative")
+	}
+	if policy.HeartbeatInterval <= 0 || policy.StaleAfter <= policy.HeartbeatInterval {
+		return nil, errors.New("stale-after must exceed a positive heartbeat interval")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &reportJobService{
+		repo:   repo,
+		policy: policy,
+		logger: logger,
+		kinds:  make(map[string]ReportKind),
+	}, nil
+}
+
+// Register adds a kind of report jobs can be submitted for
+func (s *reportJobService) Register(kind ReportKind) error {
+	if kind.Name == "" || len(kind.Name) > 64 {
+		return errors.New("report kind name must be 1 to 64 characters")
+	}
+	if kind.Run == nil {
+		return errors.New("report kind must have a run function")
+	}
+	if kind.MaxAttempts < 0 {
+		return errors.New("report kind max attempts must not be negative")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.kinds[kind.Name]; ok {
+		return fmt.Errorf("report kind %s is already registered", kind.Name)
+	}
+	s.kinds[kind.Name] = kind
+	return nil
+}
+
+// Kinds returns the registered report kinds in order
+func (s *reportJobService) Kinds() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	kinds := make([]string, 0, len(s.kinds))
+	for name := range s.kinds {
+		kinds = append(kinds, name)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// kind returns a registered report kind
+func (s *reportJobService) kind(name string) (ReportKind, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	kind, ok := s.kinds[name]
+	return kind, ok
+}
+
+// Submit queues a job for a report
+func (s *reportJobService) Submit(ctx context.Context, kindName string, params json.RawMessage, submittedBy string) (*models.ReportJob, error) {
+	kind, ok := s.kind(kindName)
+	if !ok {
+		return nil, ErrUnknownReportKind
+	}
+	if len(params) > 0 && !json.Valid(params) {
+		return nil, fmt.Errorf("%w: params must be JSON", ErrInvalidReportParams)
+	}
+	if kind.Validate != nil {
+		if err := kind.Validate(params); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidReportParams, err)
+		}
+	}
+
+	maxAttempts := kind.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = s.policy.MaxAttempts
+	}
+
+	now := time.Now().UTC()
+	job := &models.ReportJob{
+		ID:          uuid.New(),
+		Kind:        kind.Name,
+		Status:      models.ReportJobQueued,
+		Params:      params,
+		MaxAttempts: maxAttempts,
+		SubmittedBy: submittedBy,
+		RunAfter:    now,
+		CreatedAt:   now,
+	}
+	if err := s.repo.CreateJob(ctx, job); err != nil {
+		s.logger.Error("failed to create report job", err, "kind", kind.Name)
+		return nil, fmt.Errorf("failed to create report job: %w", err)
+	}
+
+	s.logger.Info("report job submitted",
+		"jobID", job.ID,
+		"kind", job.Kind,
+		"submittedBy", submittedBy)
+
+	return job, nil
+}
+
+// Get returns a job and its progress
+func (s *reportJobService) Get(ctx context.Context, id uuid.UUID) (*models.ReportJob, error) {
+	job, err := s.repo.GetJob(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrReportJobNotFound) {
+			return nil, ErrReportJobNotFound
+		}
+		s.logger.Error("failed to get report job", err, "jobID", id)
+		return nil, fmt.Errorf("failed to get report job: %w", err)
+	}
+	return job, nil
+}
+
+// List returns jobs newest first, optionally of one kind or status
+func (s *reportJobService) List(ctx context.Context, kind string, status models.ReportJobStatus, pagination Pagination) ([]*models.ReportJob, error) {
+	jobs, err := s.repo.ListJobs(ctx, kind, status, pagination.Limit, pagination.Offset)
+	if err != nil {
+		s.logger.Error("failed to list report jobs", err)
+		return nil, fmt.Errorf("failed to list report jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Cancel cancels a queued job at once. A running job stops at its next
+// heartbeat, reported by the job turning CANCELLED.
+func (s *reportJobService) Cancel(ctx context.Context, id uuid.UUID) (*models.ReportJob, error) {
+	job, err := s.repo.RequestCancel(ctx, id, time.Now().UTC())
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrReportJobNotFound):
+			return nil, ErrReportJobNotFound
+		case errors.Is(err, repository.ErrReportJobNotRunning):
+			return nil, ErrReportJobFinished
+		}
+		s.logger.Error("failed to cancel report job", err, "jobID", id)
+		return nil, fmt.Errorf("failed to cancel report job: %w", err)
+	}
+
+	s.logger.Info("report job cancellation requested",
+		"jobID", id,
+		"status", job.Status)
+
+	return job, nil
+}
+
+// GetResult returns the result of a succeeded job
+func (s *reportJobService) GetResult(ctx context.Context, id uuid.UUID) (json.RawMessage, error) {
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.ReportJobSucceeded {
+		return nil, ErrReportResultNotReady
+	}
+	return job.Result, nil
+}
+
+// Purge deletes jobs finished before the given time, with their results
+func (s *reportJobService) Purge(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := s.repo.PurgeJobs(ctx, before)
+	if err != nil {
+		s.logger.Error("failed to purge report jobs", err)
+		return 0, fmt.Errorf("failed to purge report jobs: %w", err)
+	}
+	return purged, nil
+}
+
+// RunNext claims and runs the next runnable job, reporting whether there
+// was one. Failed runs are retried with backoff until the job runs out of
+// attempts; a run interrupted by ctx is queued again.
+func (s *reportJobService) RunNext(ctx context.Context) (bool, error) {
+	job, err := s.repo.ClaimJob(ctx, time.Now().UTC(), time.Now().UTC().Add(-s.policy.StaleAfter))
+	if err != nil {
+		s.logger.Error("failed to claim report job", err)
+		return false, err
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	kind, ok := s.kind(job.Kind)
+	switch {
+	case job.CancelRequested:
+		// Cancelled while held by a worker that stopped
+		job.Status = models.ReportJobCancelled
+	case !ok:
+		job.Status = models.ReportJobFailed
+		job.Error = ErrUnknownReportKind.Error()
+	case job.Attempts > job.MaxAttempts:
+		job.Status = models.ReportJobFailed
+		job.Error = "worker stopped during the last attempt"
+	default:
+		s.run(ctx, kind, job)
+	}
+
+	if job.Status == models.ReportJobRunning {
+		// Taken over by another worker after missed heartbeats
+		return true, nil
+	}
+	return true, s.finish(job)
+}
+
+// run runs a claimed job, leaving its outcome on the job. The job stays
+// RUNNING when another worker took it over.
+func (s *reportJobService) run(ctx context.Context, kind ReportKind, job *models.ReportJob) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var progress atomic.Int64
+	var cancelled, lost atomic.Bool
+	report := func(percent int) error {
+		if percent < 0 {
+			percent = 0
+		} else if percent > 100 {
+			percent = 100
+		}
+		progress.Store(int64(percent))
+		if cancelled.Load() {
+			return ErrReportJobCancelled
+		}
+		return runCtx.Err()
+	}
+
+	// Heartbeats record progress and stop the run once it is cancelled or
+	// taken over
+	heartbeats := make(chan struct{})
+	go func() {
+		defer close(heartbeats)
+		ticker := time.NewTicker(s.policy.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			cancelRequested, err := s.repo.Heartbeat(runCtx, job.ID, int(progress.Load()), time.Now().UTC())
+			switch {
+			case errors.Is(err, repository.ErrReportJobNotRunning):
+				lost.Store(true)
+				cancel()
+				return
+			case err != nil:
+				if runCtx.Err() == nil {
+					s.logger.Warn("failed to record report job heartbeat", "jobID", job.ID, "error", err.Error())
+				}
+			case cancelRequested:
+				cancelled.Store(true)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	result, err := s.call(runCtx, kind, job.Params, report)
+	cancel()
+	<-heartbeats
+
+	now := time.Now().UTC()
+	job.Progress = int(progress.Load())
+	switch {
+	case lost.Load():
+		return
+	case cancelled.Load():
+		job.Status = models.ReportJobCancelled
+		job.FinishedAt = &now
+	case ctx.Err() != nil:
+		// The worker is stopping; the job runs again on another pass
+		job.Status = models.ReportJobQueued
+		job.Error = "interrupted by worker shutdown"
+		job.RunAfter = now
+	case err != nil:
+		job.Error = err.Error()
+		if job.Attempts < job.MaxAttempts {
+			job.Status = models.ReportJobQueued
+			job.RunAfter = now.Add(s.policy.RetryDelay << uint(job.Attempts-1))
+		} else {
+			job.Status = models.ReportJobFailed
+			job.FinishedAt = &now
+		}
+		s.logger.Warn("report job run failed",
+			"jobID", job.ID,
+			"kind", job.Kind,
+			"attempt", job.Attempts,
+			"error", err.Error())
+	default:
+		data, err := json.Marshal(result)
+		if err != nil {
+			job.Status = models.ReportJobFailed
+			job.Error = fmt.Sprintf("failed to encode result: %v", err)
+		} else {
+			job.Status = models.ReportJobSucceeded
+			job.Progress = 100
+			job.Result = data
+			job.Error = ""
+		}
+		job.FinishedAt = &now
+	}
+}
+
+// call runs a report, turning a panic into a failed run
+func (s *reportJobService) call(ctx context.Context, kind ReportKind, params json.RawMessage, progress ReportProgress) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("report panicked: %v", r)
+		}
+	}()
+	return kind.Run(ctx, params, progress)
+}
+
+// finish stores the outcome of a run
+func (s *reportJobService) finish(job *models.ReportJob) error {
+	ctx, cancel := context.WithTimeout(context.Background(), reportFinishTimeout)
+	defer cancel()
+
+	if job.Status.Finished() && job.FinishedAt == nil {
+		now := time.Now().UTC()
+		job.FinishedAt = &now
+	}
+
+	if err := s.repo.FinishJob(ctx, job); err != nil {
+		if errors.Is(err, repository.ErrReportJobNotRunning) {
+			return nil
+		}
+		s.logger.Error("failed to store report job outcome", err, "jobID", job.ID)
+		return fmt.Errorf("failed to store report job outcome: %w", err)
+	}
+
+	reportJobs.WithLabelValues(job.Kind, string(job.Status)).Inc()
+	s.logger.Info("report job run finished",
+		"jobID", job.ID,
+		"kind", job.Kind,
+		"attempt", job.Attempts,
+		"status", job.Status)
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ReconciliationReport returns the report kind running a ledger
+// reconciliation on demand, outside the nightly schedule
+func ReconciliationReport(recon ReconciliationService) ReportKind {
+	return ReportKind{
+		Name: "reconciliation",
+		Run: func(ctx context.Context, params json.RawMessage, progress ReportProgress) (interface{}, error) {
+			return recon.Run(ctx)
+		},
+		// A reconciliation is cheap to repeat on the next night
+		MaxAttempts: 1,
+	}
+}
+
+// usageReportParams are the parameters of usage report jobs
+type usageReportParams struct {
+	// Month is YYYY-MM
+	Month string `json:"month"`
+}
+
+// parse returns the month of the report
+func (p usageReportParams) parse(params json.RawMessage) (time.Time, error) {
+	if len(params) == 0 {
+		return time.Time{}, errors.New("month is required")
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return time.Time{}, err
+	}
+	month, err := time.Parse("2006-01", p.Month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("month must be YYYY-MM: %w", err)
+	}
+	return month, nil
+}
+
+// UsageReport returns the report kind computing a month's internal service
+// usage and chargeback costs
+func UsageReport(usage UsageService) ReportKind {
+	return ReportKind{
+		Name: "usage-report",
+		Validate: func(params json.RawMessage) error {
+			_, err := usageReportParams{}.parse(params)
+			return err
+		},
+		Run: func(ctx context.Context, params json.RawMessage, progress ReportProgress) (interface{}, error) {
+			month, err := usageReportParams{}.parse(params)
+			if err != nil {
+				return nil, err
+			}
+			return usage.GetMonthlyReport(ctx, month)
+		},
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+	"internal/repository"
+)
+
+// SandboxSampleSize is how many of a wallet's latest transactions a sandbox
+// clone copies
+const SandboxSampleSize = 50
+
+// ErrSandboxCustomerIsOwner is returned when a wallet would be cloned to the
+// customer owning it
+var ErrSandboxCustomerIsOwner = errors.New("sandbox customer must differ from the wallet's customer")
+
+// sandboxDescriptions replace the free-text descriptions of cloned
+// transactions, which may identify the customer
+var sandboxDescriptions = map[models.TransactionType]string{
+	models.TransactionTypeCredit:     "Demo top-up",
+	models.TransactionTypeDebit:      "Demo purchase",
+	models.TransactionTypeRefund:     "Demo refund",
+	models.TransactionTypeAdjustment: "Demo adjustment",
+}
+
+// SandboxService defines the interface for sandbox demo data
+type SandboxService interface {
+	CloneWallet(ctx context.Context, walletID, customerID uuid.UUID) (*models.SandboxClone, error)
+}
+
+// sandboxService implements SandboxService interface
+type sandboxService struct {
+	wallets repository.WalletRepository
+	repo    repository.SandboxRepository
+	logger  Logger
+}
+
+// NewSandboxService creates a new instance of SandboxService
+func NewSandboxService(wallets repository.WalletRepository, repo repository.SandboxRepository, logger Logger) (SandboxService, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet repository is required")
+	}
+	if repo == nil {
+		return nil, errors.New("sandbox repository is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &sandboxService{
+		wallets: wallets,
+		repo:    repo,
+		logger:  logger,
+	}, nil
+}
+
+// CloneWallet copies a wallet's settings, balance and latest transactions to
+// a new wallet of the sandbox customer. Every identifier is replaced,
+// descriptions and reference IDs are scrubbed and the history is shifted to
+// end now. An opening transaction makes the sample add up to the balance,
+// so the clone reconciles like a real wallet.
+func (s *sandboxService) CloneWallet(ctx context.Context, walletID, customerID uuid.UUID) (*models.SandboxClone, error) {
+	if walletID == uuid.Nil {
+		return nil, ErrInvalidWalletID
+	}
+	if customerID == uuid.Nil {
+		return nil, errors.New("sandbox customer ID is required")
+	}
+
+	source, err := s.wallets.GetWallet(ctx, walletID)
+	if err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			return nil, ErrWalletNotFound
+		}
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	if source.CustomerID == customerID {
+		return nil, ErrSandboxCustomerIsOwner
+	}
+
+	// Newest first
+	sample, err := s.wallets.GetTransactions(ctx, walletID, SandboxSampleSize, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	now := time.Now().UTC()
+	var shift time.Duration
+	if len(sample) > 0 {
+		shift = now.Sub(sample[0].CreatedAt)
+	}
+
+	clone := &models.Wallet{
+		ID:                  uuid.New(),
+		CustomerID:          customerID,
+		Balance:             source.Balance,
+		Currency:            source.Currency,
+		LowBalanceThreshold: source.LowBalanceThreshold,
+		CreditLimit:         source.CreditLimit,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+
+	transactions := make([]*models.Transaction, 0, len(sample)+1)
+	net := decimal.Zero
+	for i := len(sample) - 1; i >= 0; i-- {
+		tx := sample[i]
+		cloned := &models.Transaction{
+			ID:          uuid.New(),
+			WalletID:    clone.ID,
+			Type:        tx.Type,
+			Status:      tx.Status,
+			Amount:      tx.Amount,
+			Currency:    tx.Currency,
+			Description: sandboxDescriptions[tx.Type],
+			CreatedAt:   tx.CreatedAt.Add(shift),
+			UpdatedAt:   tx.UpdatedAt.Add(shift),
+		}
+		// Adjustments keep their linkage, which names no customer
+		if tx.Type == models.TransactionTypeAdjustment {
+			cloned.Metadata = map[string]string{
+				models.MetadataAdjustmentID:        tx.Metadata[models.MetadataAdjustmentID],
+				models.MetadataAdjustmentReason:    tx.Metadata[models.MetadataAdjustmentReason],
+				models.MetadataAdjustmentDirection: tx.Metadata[models.MetadataAdjustmentDirection],
+			}
+		}
+		transactions = append(transactions, cloned)
+
+		// Every transaction moved the balance when posted, except those that
+		// failed or were reversed since
+		if tx.Status == models.TransactionStatusFailed || tx.Status == models.TransactionStatusReversed {
+			continue
+		}
+		net = net.Add(decimal.NewFromFloat(tx.SignedAmount()))
+	}
+
+	if len(transactions) > 0 {
+		clone.CreatedAt = transactions[0].CreatedAt.Add(-time.Second)
+	}
+	if opening := decimal.NewFromFloat(source.Balance).Sub(net); !opening.IsZero() {
+		tx := &models.Transaction{
+			ID:          uuid.New(),
+			WalletID:    clone.ID,
+			Type:        models.TransactionTypeCredit,
+			Status:      models.TransactionStatusCompleted,
+			Amount:      opening.Abs().InexactFloat64(),
+			Currency:    clone.Currency,
+			Description: "Demo opening balance",
+			CreatedAt:   clone.CreatedAt,
+			UpdatedAt:   clone.CreatedAt,
+		}
+		if opening.IsNegative() {
+			tx.Type = models.TransactionTypeDebit
+		}
+		transactions = append([]*models.Transaction{tx}, transactions...)
+	}
+
+	if err := s.repo.CloneWallet(ctx, clone, transactions); err != nil {
+		s.logger.Error("failed to clone wallet to sandbox", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to clone wallet: %w", err)
+	}
+
+	s.logger.Info("wallet cloned to sandbox",
+		"walletID", walletID,
+		"cloneID", clone.ID,
+		"transactions", len(transactions))
+
+	return &models.SandboxClone{
+		WalletID:     clone.ID,
+		CustomerID:   customerID,
+		Currency:     clone.Currency,
+		Balance:      clone.Balance,
+		Transactions: len(transactions),
+	}, nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// runDailyAt calls fn once a day at the given offset from midnight UTC until
+// the context is cancelled. fn receives the time it was scheduled for.
+func runDailyAt(ctx context.Context, at time.Duration, fn func(time.Time)) {
+	for {
+		now := time.Now().UTC()
+		next := now.Truncate(24 * time.Hour).Add(at)
+		if !next.After(now) {
+			next = next.Add(24 * time.Hour)
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		fn(next)
+	}
+}
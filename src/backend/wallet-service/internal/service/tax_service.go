@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/currency"
+	"internal/models"
+	"internal/repository"
+	"internal/tax"
+)
+
+// Tax limits
+const (
+	// maxInvoiceTaxItems bounds the items of an invoice taxed at once
+	maxInvoiceTaxItems = 500
+	// maxTaxReference is the longest invoice number recorded
+	maxTaxReference = 64
+	// maxTaxReportRange bounds the window of tax reports
+	maxTaxReportRange = 366 * 24 * time.Hour
+)
+
+// ErrInvalidTaxRequest is returned for invoices and tax profiles that
+// cannot be taxed as given
+var ErrInvalidTaxRequest = errors.New("invalid tax request")
+
+// InvoiceTax describes the items of an invoice to tax
+type InvoiceTax struct {
+	CustomerID    uuid.UUID
+	InvoiceNumber string
+	Currency      string
+	Items         []models.TaxableItem
+}
+
+// TaxService defines the interface for charging tax by the rules of the
+// customer's region and recording it for reporting
+type TaxService interface {
+	// QuoteInvoice returns the tax an invoice would be charged
+	QuoteInvoice(ctx context.Context, invoice InvoiceTax) (*models.TaxBreakdown, error)
+	// TaxInvoice charges tax on an invoice and records it. An invoice
+	// taxed already returns its first record, so retried invoice runs are
+	// not taxed twice.
+	TaxInvoice(ctx context.Context, invoice InvoiceTax) (*models.TaxRecord, error)
+	// QuoteDebit returns the tax a debit of a category would be charged
+	QuoteDebit(ctx context.Context, customerID uuid.UUID, currency string, amount decimal.Decimal, category string) (*models.TaxBreakdown, error)
+	// RecordDebit records the tax charged on a posted debit
+	RecordDebit(ctx context.Context, customerID uuid.UUID, tx *models.Transaction, breakdown *models.TaxBreakdown) error
+	// UpdateProfile sets a customer's tax region and exemption
+	UpdateProfile(ctx context.Context, customerID uuid.UUID, region string, exempt bool, actor string) (*models.CustomerSettings, error)
+	// Report totals the tax recorded in a window
+	Report(ctx context.Context, from, to time.Time) (*models.TaxReport, error)
+}
+
+// taxService implements TaxService interface
+type taxService struct {
+	repo       repository.TaxRepository
+	customers  repository.CustomerRepository
+	rules      *tax.Registry
+	currencies *currency.Registry
+	logger     Logger
+}
+
+// NewTaxService creates a new instance of TaxService. Tax lines are
+// rounded to the precision of their currency in currencies.
+func NewTaxService(repo repository.TaxRepository, customers repository.CustomerRepository, rules *tax.Registry, currencies *currency.Registry, logger Logger) (TaxService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if customers == nil {
+		return nil, errors.New("customer repository is required")
+	}
+	if rules == nil {
+		return nil, errors.New("tax rules are required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currencies are required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &taxService{
+		repo:       repo,
+		customers:  customers,
+		rules:      rules,
+		currencies: currencies,
+		logger:     logger,
+	}, nil
+}
+
+// QuoteInvoice totals the invoice's taxable items, leaving out the
+// categories exempt in the customer's region, and taxes the total
+func (s *taxService) QuoteInvoice(ctx context.Context, invoice InvoiceTax) (*models.TaxBreakdown, error) {
+	if len(invoice.Items) == 0 || len(invoice.Items) > maxInvoiceTaxItems {
+		return nil, fmt.Errorf("%w: an invoice has 1 to %d items", ErrInvalidTaxRequest, maxInvoiceTaxItems)
+	}
+	for _, item := range invoice.Items {
+		if item.Amount.IsNegative() {
+			return nil, fmt.Errorf("%w: item amounts must not be negative", ErrInvalidTaxRequest)
+		}
+	}
+
+	rules, breakdown, err := s.rulesFor(ctx, invoice.CustomerID, invoice.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if breakdown != nil {
+		breakdown.Taxable = s.sum(invoice.Currency, invoice.Items, func(models.TaxableItem) bool { return true })
+		return breakdown, nil
+	}
+
+	taxable := s.sum(invoice.Currency, invoice.Items, func(item models.TaxableItem) bool { return !rules.Exempts(item.Category) })
+	if taxable.IsZero() {
+		return exempt(models.TaxExemptCategory, decimal.Zero), nil
+	}
+	return s.compute(rules, invoice.Currency, taxable), nil
+}
+
+// TaxInvoice charges and records the tax of an invoice
+func (s *taxService) TaxInvoice(ctx context.Context, invoice InvoiceTax) (*models.TaxRecord, error) {
+	invoice.InvoiceNumber = strings.TrimSpace(invoice.InvoiceNumber)
+	if invoice.InvoiceNumber == "" || len(invoice.InvoiceNumber) > maxTaxReference {
+		return nil, fmt.Errorf("%w: invoice number must be 1 to %d characters", ErrInvalidTaxRequest, maxTaxReference)
+	}
+
+	breakdown, err := s.QuoteInvoice(ctx, invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.record(ctx, &models.TaxRecord{
+		ID:         uuid.New(),
+		CustomerID: invoice.CustomerID,
+		Source:     models.TaxSourceInvoice,
+		Reference:  invoice.InvoiceNumber,
+		Currency:   invoice.Currency,
+		Breakdown:  *breakdown,
+		CreatedAt:  time.Now().UTC(),
+	})
+}
+
+// QuoteDebit returns the tax of a debit
+func (s *taxService) QuoteDebit(ctx context.Context, customerID uuid.UUID, currency string, amount decimal.Decimal, category string) (*models.TaxBreakdown, error) {
+	rules, breakdown, err := s.rulesFor(ctx, customerID, currency)
+	if err != nil {
+		return nil, err
+	}
+	amount = s.currencies.Round(currency, amount)
+	if breakdown != nil {
+		breakdown.Taxable = amount
+		return breakdown, nil
+	}
+	if rules.Exempts(category) {
+		return exempt(models.TaxExemptCategory, amount), nil
+	}
+	return s.compute(rules, currency, amount), nil
+}
+
+// RecordDebit records the tax of a posted debit, by its transaction ID
+func (s *taxService) RecordDebit(ctx context.Context, customerID uuid.UUID, tx *models.Transaction, breakdown *models.TaxBreakdown) error {
+	_, err := s.record(ctx, &models.TaxRecord{
+		ID:         uuid.New(),
+		CustomerID: customerID,
+		Source:     models.TaxSourceDebit,
+		Reference:  tx.ID.String(),
+		Currency:   tx.Currency,
+		Breakdown:  *breakdown,
+		CreatedAt:  time.Now().UTC(),
+	})
+	return err
+}
+
+// UpdateProfile sets the region whose tax rules apply to a customer and
+// whether the customer holds an exemption. Regions need no rules of their
+// own; customers in regions without rules are not taxed.
+func (s *taxService) UpdateProfile(ctx context.Context, customerID uuid.UUID, region string, exempt bool, actor string) (*models.CustomerSettings, error) {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if region != "" && !validRegion(region) {
+		return nil, fmt.Errorf("%w: region must be an ISO 3166 country or subdivision code", ErrInvalidTaxRequest)
+	}
+
+	settings, err := s.customers.UpdateTaxProfile(ctx, customerID, region, exempt)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to update customer tax profile", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to update customer tax profile: %w", err)
+	}
+
+	s.logger.Info("customer tax profile updated",
+		"customerID", customerID,
+		"region", region,
+		"exempt", exempt,
+		"actor", actor)
+
+	return settings, nil
+}
+
+// Report totals the tax recorded in [from, to) by region, component,
+// source and currency
+func (s *taxService) Report(ctx context.Context, from, to time.Time) (*models.TaxReport, error) {
+	if !to.After(from) || to.Sub(from) > maxTaxReportRange {
+		return nil, ErrInvalidAnalyticsWindow
+	}
+
+	rows, err := s.repo.SummarizeTax(ctx, from.UTC(), to.UTC())
+	if err != nil {
+		s.logger.Error("failed to summarize tax", err)
+		return nil, fmt.Errorf("failed to summarize tax: %w", err)
+	}
+	if rows == nil {
+		rows = []*models.TaxReportRow{}
+	}
+
+	return &models.TaxReport{
+		From:        from.UTC(),
+		To:          to.UTC(),
+		Rows:        rows,
+		GeneratedAt: time.Now().UTC(),
+	}, nil
+}
+
+// rulesFor returns the tax rules of a customer, or the breakdown of a
+// customer charged no tax
+func (s *taxService) rulesFor(ctx context.Context, customerID uuid.UUID, code string) (*tax.Rules, *models.TaxBreakdown, error) {
+	if !s.currencies.Supported(code) {
+		return nil, nil, ErrUnsupportedCurrency
+	}
+
+	settings, err := s.customers.GetSettings(ctx, customerID)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return nil, nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to get customer settings", err, "customerID", customerID)
+		return nil, nil, fmt.Errorf("failed to get customer settings: %w", err)
+	}
+
+	if settings.TaxExempt {
+		return nil, exempt(models.TaxExemptCustomer, decimal.Zero), nil
+	}
+	rules, ok := s.rules.For(settings.TaxRegion)
+	if !ok {
+		return nil, exempt(models.TaxExemptRegion, decimal.Zero), nil
+	}
+	return rules, nil, nil
+}
+
+// compute taxes an amount, rounding each line in its currency
+func (s *taxService) compute(rules *tax.Rules, code string, taxable decimal.Decimal) *models.TaxBreakdown {
+	return rules.Compute(taxable, func(amount decimal.Decimal) decimal.Decimal {
+		return s.currencies.Round(code, amount)
+	})
+}
+
+// sum totals the rounded amounts of the items kept
+func (s *taxService) sum(code string, items []models.TaxableItem, keep func(models.TaxableItem) bool) decimal.Decimal {
+	total := decimal.Zero
+	for _, item := range items {
+		if keep(item) {
+			total = total.Add(s.currencies.Round(code, item.Amount))
+		}
+	}
+	return total
+}
+
+// record stores a tax record, returning the one recorded first for its
+// reference
+func (s *taxService) record(ctx context.Context, record *models.TaxRecord) (*models.TaxRecord, error) {
+	stored, created, err := s.repo.RecordTax(ctx, record)
+	if err != nil {
+		s.logger.Error("failed to record tax", err,
+			"source", record.Source,
+			"reference", record.Reference)
+		return nil, fmt.Errorf("failed to record tax: %w", err)
+	}
+
+	if created {
+		s.logger.Info("tax recorded",
+			"customerID", record.CustomerID,
+			"source", record.Source,
+			"reference", record.Reference,
+			"total", record.Breakdown.Total)
+	}
+
+	return stored, nil
+}
+
+// exempt returns the breakdown of an amount charged no tax
+func exempt(reason string, taxable decimal.Decimal) *models.TaxBreakdown {
+	return &models.TaxBreakdown{
+		Taxable:      taxable,
+		Lines:        []*models.TaxLine{},
+		Total:        decimal.Zero,
+		ExemptReason: reason,
+	}
+}
+
+// validRegion checks an ISO 3166 country code, optionally followed by a
+// subdivision, e.g. IN or IN-KA
+func validRegion(region string) bool {
+	country, subdivision, found := strings.Cut(region, "-")
+	if len(country) != 2 || !isUpperAlpha(country) {
+		return false
+	}
+	if !found {
+		return true
+	}
+	if len(subdivision) < 1 || len(subdivision) > 3 {
+		return false
+	}
+	for _, r := range subdivision {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isUpperAlpha reports whether s consists of ASCII capital letters only
+func isUpperAlpha(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// taxedWalletService is a WalletService charging the tax of the customer's
+// region on debits
+type taxedWalletService struct {
+	WalletService
+	taxes  TaxService
+	logger Logger
+}
+
+// NewTaxedWalletService wraps wallets so that debits are charged tax on top
+// of their amount, by the category in their category metadata. The debit
+// is posted for its amount plus the tax, naming both in its metadata, and
+// the tax breakdown is recorded once it posted.
+func NewTaxedWalletService(wallets WalletService, taxes TaxService, logger Logger) (WalletService, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if taxes == nil {
+		return nil, errors.New("tax service is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &taxedWalletService{
+		WalletService: wallets,
+		taxes:         taxes,
+		logger:        logger,
+	}, nil
+}
+
+// ProcessTransaction charges tax on debits and posts the transaction
+func (s *taxedWalletService) ProcessTransaction(ctx context.Context, tx *models.Transaction) (*models.CreditLimitWarning, error) {
+	if tx == nil || tx.Type != models.TransactionTypeDebit {
+		return s.WalletService.ProcessTransaction(ctx, tx)
+	}
+
+	wallet, err := s.GetWallet(ctx, tx.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	breakdown, err := s.taxes.QuoteDebit(ctx, wallet.CustomerID, tx.Currency, decimal.NewFromFloat(tx.Amount), tx.Metadata["category"])
+	if err != nil {
+		return nil, err
+	}
+	if !breakdown.Total.IsPositive() {
+		return s.WalletService.ProcessTransaction(ctx, tx)
+	}
+
+	metadata := make(map[string]string, len(tx.Metadata)+3)
+	for key, value := range tx.Metadata {
+		metadata[key] = value
+	}
+	metadata[models.MetadataTaxableAmount] = breakdown.Taxable.String()
+	metadata[models.MetadataTaxAmount] = breakdown.Total.String()
+	metadata[models.MetadataTaxRegion] = breakdown.Region
+	tx.Metadata = metadata
+	tx.Amount, _ = breakdown.Taxable.Add(breakdown.Total).Float64()
+
+	warning, err := s.WalletService.ProcessTransaction(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The debit stands; its metadata names the tax for reconstruction
+	if err := s.taxes.RecordDebit(ctx, wallet.CustomerID, tx, breakdown); err != nil {
+		s.logger.Error("failed to record debit tax", err, "transactionID", tx.ID)
+	}
+
+	return warning, nil
+}
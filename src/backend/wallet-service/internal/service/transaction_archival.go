@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/models"
+	"internal/repository"
+)
+
+// Transaction archival metrics
+var transactionsArchived = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Name: "wallet_transactions_archived_total",
+		Help: "Transactions moved from their monthly partition to the archive table",
+	},
+)
+
+// TransactionArchiver maintains the monthly partitions of wallet
+// transactions: it creates the partitions of the coming months, so inserts
+// never fall into the default partition, and archives the months past
+// retention
+type TransactionArchiver interface {
+	// Maintain creates and archives partitions as of now, returning how
+	// many transactions were archived
+	Maintain(ctx context.Context, now time.Time) (int64, error)
+}
+
+// transactionArchiver implements TransactionArchiver interface
+type transactionArchiver struct {
+	repo            repository.TransactionPartitionRepository
+	retentionMonths int
+	monthsAhead     int
+	logger          Logger
+}
+
+// NewTransactionArchiver creates a new instance of TransactionArchiver
+// keeping retentionMonths full months before the current one and creating
+// partitions monthsAhead months past it
+func NewTransactionArchiver(repo repository.TransactionPartitionRepository, retentionMonths, monthsAhead int, logger Logger) (TransactionArchiver, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if retentionMonths < 1 {
+		return nil, errors.New("retention must be at least one month")
+	}
+	if monthsAhead < 1 {
+		return nil, errors.New("partitions must be created at least one month ahead")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &transactionArchiver{
+		repo:            repo,
+		retentionMonths: retentionMonths,
+		monthsAhead:     monthsAhead,
+		logger:          logger,
+	}, nil
+}
+
+// Maintain creates the partitions of the current and coming months, then
+// archives the months ending before the retention cutoff, oldest first. A
+// failed archive stops the run so months are archived in order.
+func (a *transactionArchiver) Maintain(ctx context.Context, now time.Time) (int64, error) {
+	current := models.MonthStart(now)
+	for i := 0; i <= a.monthsAhead; i++ {
+		if err := a.repo.CreateTransactionPartition(ctx, current.AddDate(0, i, 0)); err != nil {
+			return 0, err
+		}
+	}
+
+	partitions, err := a.repo.ListTransactionPartitions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := current.AddDate(0, -a.retentionMonths, 0)
+	var total int64
+	for _, partition := range partitions {
+		if partition.End().After(cutoff) {
+			continue
+		}
+
+		archived, err := a.repo.ArchiveTransactionPartition(ctx, partition)
+		if err != nil {
+			a.logger.Error("failed to archive transaction partition", err, "partition", partition.Name)
+			return total, fmt.Errorf("failed to archive %s: %w", partition.Name, err)
+		}
+		transactionsArchived.Add(float64(archived))
+		total += archived
+
+		a.logger.Info("transaction partition archived",
+			"partition", partition.Name,
+			"month", partition.Month.Format("2006-01"),
+			"transactions", archived)
+	}
+
+	return total, nil
+}
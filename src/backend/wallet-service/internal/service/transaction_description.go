@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/descriptions"
+	"internal/models"
+)
+
+// descriptionTemplateMisses counts transactions that kept their own
+// description because they lacked a variable of their template, by source
+var descriptionTemplateMisses = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_description_template_misses_total",
+		Help: "Transactions posted with their own description as they lacked a variable of their template, by source",
+	},
+	[]string{"source"},
+)
+
+// describedWalletService writes the descriptions of transactions from the
+// configured templates before they are posted, so every caller's
+// transactions read alike in statements and exports
+type describedWalletService struct {
+	WalletService
+	descriptions *descriptions.Registry
+	logger       Logger
+}
+
+// NewDescribedWalletService wraps wallets so that transactions are posted
+// with descriptions rendered from the template of their source and product
+// metadata, or with their own description normalized where no template
+// applies
+func NewDescribedWalletService(wallets WalletService, registry *descriptions.Registry, logger Logger) (WalletService, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if registry == nil {
+		return nil, errors.New("description registry is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &describedWalletService{
+		WalletService: wallets,
+		descriptions:  registry,
+		logger:        logger,
+	}, nil
+}
+
+// ProcessTransaction describes the transaction and posts it
+func (s *describedWalletService) ProcessTransaction(ctx context.Context, tx *models.Transaction) (*models.CreditLimitWarning, error) {
+	if tx != nil {
+		description, err := s.descriptions.Describe(tx)
+		if err != nil {
+			source := tx.Metadata[models.TransactionSourceKey]
+			descriptionTemplateMisses.WithLabelValues(source).Inc()
+			s.logger.Warn("transaction kept its own description", "transactionID", tx.ID, "source", source, "error", err.Error())
+		}
+		tx.Description = description
+	}
+	return s.WalletService.ProcessTransaction(ctx, tx)
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go" // v0.20.0
+
+	"internal/models"
+)
+
+// exportColumns are the columns of CSV exports, in the order of the
+// TransactionExportRow fields
+var exportColumns = []string{
+	"transaction_id", "wallet_id", "customer_id", "type", "status", "amount",
+	"currency", "reference_id", "description", "effective_at", "created_at",
+}
+
+// exportWriter encodes the rows of an export file
+type exportWriter interface {
+	Write(row *models.TransactionExportRow) error
+	// Close writes whatever the format keeps until the end of the file
+	Close() error
+}
+
+// newExportWriter returns a writer encoding rows in format to w
+func newExportWriter(format models.ExportFormat, w io.Writer) (exportWriter, error) {
+	switch format {
+	case models.ExportCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(exportColumns); err != nil {
+			return nil, err
+		}
+		return &csvExportWriter{w: writer}, nil
+	case models.ExportParquet:
+		return &parquetExportWriter{w: parquet.NewGenericWriter[models.TransactionExportRow](w)}, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidExport, format)
+	}
+}
+
+// csvExportWriter writes rows as CSV records with RFC 3339 timestamps
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvExportWriter) Write(row *models.TransactionExportRow) error {
+	effectiveAt := ""
+	if row.EffectiveAt != nil {
+		effectiveAt = row.EffectiveAt.UTC().Format(time.RFC3339)
+	}
+	return c.w.Write([]string{
+		row.TransactionID, row.WalletID, row.CustomerID, row.Type, row.Status, row.Amount,
+		row.Currency, row.ReferenceID, row.Description, effectiveAt, row.CreatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (c *csvExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// parquetExportWriter writes rows to row groups of a Parquet file
+type parquetExportWriter struct {
+	w *parquet.GenericWriter[models.TransactionExportRow]
+}
+
+func (p *parquetExportWriter) Write(row *models.TransactionExportRow) error {
+	_, err := p.w.Write([]models.TransactionExportRow{*row})
+	return err
+}
+
+func (p *parquetExportWriter) Close() error {
+	return p.w.Close()
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/descriptions"
+	"internal/models"
+	"internal/objectstore"
+	"internal/repository"
+)
+
+// TransactionExportKind is the report kind of transaction export jobs
+const TransactionExportKind = "transaction-export"
+
+// Transaction export limits
+const (
+	// MaxExportRange bounds the created_at range of one export
+	MaxExportRange = 366 * 24 * time.Hour
+	// exportProgressRows is the number of rows written between progress
+	// reports
+	exportProgressRows = 1000
+)
+
+// ErrInvalidExport is returned for export requests with a bad format or
+// filter
+var ErrInvalidExport = errors.New("invalid export")
+
+// transactionsExported counts the rows written to export files
+var transactionsExported = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_transactions_exported_total",
+		Help: "Transactions written to export files, by format",
+	},
+	[]string{"format"},
+)
+
+// TransactionExportRequest is an export to run
+type TransactionExportRequest struct {
+	// Format defaults to CSV
+	Format models.ExportFormat
+	Filter models.TransactionExportFilter
+}
+
+// TransactionExportOptions configures where export files are written
+type TransactionExportOptions struct {
+	// Prefix is prepended to the keys of export files
+	Prefix string
+	// URLExpiry is how long download links stay valid
+	URLExpiry time.Duration
+	// NormalizeDescriptions tidies the descriptions of exported rows as
+	// posted transactions are, so rows posted before normalization read
+	// like the rest
+	NormalizeDescriptions bool
+}
+
+// transactionExportParams are the parameters of export jobs. The key is
+// chosen on submission so that retries overwrite the same file.
+type transactionExportParams struct {
+	Format models.ExportFormat            `json:"format"`
+	Filter models.TransactionExportFilter `json:"filter"`
+	Key    string                         `json:"key"`
+}
+
+// TransactionExportService defines the interface for bulk transaction
+// exports written to object storage
+type TransactionExportService interface {
+	// Submit queues an export job
+	Submit(ctx context.Context, req TransactionExportRequest, actor string) (*models.TransactionExport, error)
+	// Get returns the state of an export, with a fresh download link once
+	// its file is written
+	Get(ctx context.Context, id uuid.UUID) (*models.TransactionExport, error)
+	// Export writes the file of an export job to key
+	Export(ctx context.Context, key string, req TransactionExportRequest, progress ReportProgress) (*models.TransactionExportFile, error)
+}
+
+// transactionExportService implements TransactionExportService interface
+type transactionExportService struct {
+	repo   repository.TransactionExportRepository
+	store  objectstore.Store
+	jobs   ReportJobService
+	opts   TransactionExportOptions
+	logger Logger
+}
+
+// NewTransactionExportService creates a new instance of
+// TransactionExportService running its exports as report jobs
+func NewTransactionExportService(repo repository.TransactionExportRepository, store objectstore.Store, jobs ReportJobService, opts TransactionExportOptions, logger Logger) (TransactionExportService, error) {
+	if repo == nil {
+		return nil, errors.New("transaction export repository is required")
+	}
+	if store == nil {
+		return nil, errors.New("object store is required")
+	}
+	if jobs == nil {
+		return nil, errors.New("report job service is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if opts.URLExpiry <= 0 {
+		return nil, errors.New("download URL expiry must be positive")
+	}
+
+	return &transactionExportService{
+		repo:   repo,
+		store:  store,
+		jobs:   jobs,
+		opts:   opts,
+		logger: logger,
+	}, nil
+}
+
+// TransactionExportReport returns the report kind writing export files.
+// Retries overwrite the file of the failed run.
+func TransactionExportReport(exports TransactionExportService) ReportKind {
+	parse := func(params json.RawMessage) (transactionExportParams, error) {
+		var p transactionExportParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return p, err
+		}
+		if p.Key == "" {
+			return p, errors.New("key is required")
+		}
+		return p, validateExport(p.Format, p.Filter)
+	}
+
+	return ReportKind{
+		Name: TransactionExportKind,
+		Validate: func(params json.RawMessage) error {
+			_, err := parse(params)
+			return err
+		},
+		Run: func(ctx context.Context, params json.RawMessage, progress ReportProgress) (interface{}, error) {
+			p, err := parse(params)
+			if err != nil {
+				return nil, err
+			}
+			return exports.Export(ctx, p.Key, TransactionExportRequest{Format: p.Format, Filter: p.Filter}, progress)
+		},
+	}
+}
+
+// validateExport checks the format and filter of an export
+func validateExport(format models.ExportFormat, filter models.TransactionExportFilter) error {
+	switch format {
+	case models.ExportCSV, models.ExportParquet:
+	default:
+		return fmt.Errorf("%w: format must be csv or parquet", ErrInvalidExport)
+	}
+	if filter.From.IsZero() || filter.To.IsZero() {
+		return fmt.Errorf("%w: from and to are required", ErrInvalidExport)
+	}
+	if !filter.To.After(filter.From) {
+		return fmt.Errorf("%w: to must be after from", ErrInvalidExport)
+	}
+	if filter.To.Sub(filter.From) > MaxExportRange {
+		return fmt.Errorf("%w: at most %d days per export", ErrInvalidExport, int(MaxExportRange.Hours()/24))
+	}
+	switch filter.Type {
+	case "", models.TransactionTypeCredit.String(), models.TransactionTypeDebit.String(), models.TransactionTypeRefund.String(),
+		models.TransactionTypeAdjustment.String():
+	default:
+		return fmt.Errorf("%w: type must be CREDIT, DEBIT, REFUND or ADJUSTMENT", ErrInvalidExport)
+	}
+	if filter.CustomerID != nil && *filter.CustomerID == uuid.Nil {
+		return fmt.Errorf("%w: invalid customer ID", ErrInvalidExport)
+	}
+	return nil
+}
+
+// Submit validates an export and queues its job
+func (s *transactionExportService) Submit(ctx context.Context, req TransactionExportRequest, actor string) (*models.TransactionExport, error) {
+	if req.Format == "" {
+		req.Format = models.ExportCSV
+	}
+	req.Filter.From = req.Filter.From.UTC()
+	req.Filter.To = req.Filter.To.UTC()
+	if err := validateExport(req.Format, req.Filter); err != nil {
+		return nil, err
+	}
+
+	key := path.Join(s.opts.Prefix, "transactions",
+		req.Filter.From.Format("20060102")+"-"+req.Filter.To.Format("20060102"),
+		uuid.NewString()+"."+string(req.Format))
+	params, err := json.Marshal(transactionExportParams{Format: req.Format, Filter: req.Filter, Key: key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export: %w", err)
+	}
+
+	job, err := s.jobs.Submit(ctx, TransactionExportKind, params, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("transaction export submitted",
+		"jobID", job.ID,
+		"format", req.Format,
+		"from", req.Filter.From,
+		"to", req.Filter.To,
+		"actor", actor)
+
+	return s.view(ctx, job)
+}
+
+// Get returns the state of an export job
+func (s *transactionExportService) Get(ctx context.Context, id uuid.UUID) (*models.TransactionExport, error) {
+	job, err := s.jobs.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Kind != TransactionExportKind {
+		return nil, ErrReportJobNotFound
+	}
+	return s.view(ctx, job)
+}
+
+// view describes an export job, signing a download link to the file of a
+// succeeded one
+func (s *transactionExportService) view(ctx context.Context, job *models.ReportJob) (*models.TransactionExport, error) {
+	var params transactionExportParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return nil, fmt.Errorf("failed to decode export: %w", err)
+	}
+
+	export := &models.TransactionExport{
+		ID:          job.ID,
+		Status:      job.Status,
+		Progress:    job.Progress,
+		Format:      params.Format,
+		Filter:      params.Filter,
+		Error:       job.Error,
+		SubmittedBy: job.SubmittedBy,
+		CreatedAt:   job.CreatedAt,
+		FinishedAt:  job.FinishedAt,
+	}
+	if job.Status != models.ReportJobSucceeded {
+		return export, nil
+	}
+
+	raw, err := s.jobs.GetResult(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	var result models.TransactionExportFile
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode export result: %w", err)
+	}
+
+	url, err := s.store.SignedURL(ctx, result.Key, s.opts.URLExpiry)
+	if err != nil {
+		s.logger.Error("failed to sign export download", err, "jobID", job.ID)
+		return nil, err
+	}
+	expiresAt := time.Now().UTC().Add(s.opts.URLExpiry)
+	export.Rows = result.Rows
+	export.Bytes = result.Bytes
+	export.DownloadURL = url
+	export.URLExpiresAt = &expiresAt
+
+	return export, nil
+}
+
+// Export streams the matching transactions through the file format into
+// object storage, never holding more than the format buffers in memory.
+// Progress is the share of the date range written so far.
+func (s *transactionExportService) Export(ctx context.Context, key string, req TransactionExportRequest, progress ReportProgress) (*models.TransactionExportFile, error) {
+	pr, pw := io.Pipe()
+	counted := &countingWriter{w: pw}
+
+	filter := req.Filter
+	span := filter.To.Sub(filter.From)
+	var rows int64
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		writer, err := newExportWriter(req.Format, counted)
+		if err == nil {
+			err = s.repo.StreamTransactions(ctx, filter, func(row *models.TransactionExportRow) error {
+				if s.opts.NormalizeDescriptions {
+					row.Description = descriptions.Normalize(row.Description, 0)
+				}
+				if err := writer.Write(row); err != nil {
+					return err
+				}
+				rows++
+				if rows%exportProgressRows == 0 {
+					return progress(int(row.CreatedAt.Sub(filter.From) * 100 / span))
+				}
+				return nil
+			})
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	err := s.store.Upload(ctx, key, req.Format.ContentType(), pr)
+	// Unblock the writer should the upload stop reading early
+	pr.CloseWithError(errors.New("upload stopped"))
+	<-written
+	if err != nil {
+		s.logger.Error("failed to export transactions", err, "key", key)
+		return nil, err
+	}
+
+	transactionsExported.WithLabelValues(string(req.Format)).Add(float64(rows))
+	s.logger.Info("transactions exported",
+		"key", key,
+		"rows", rows,
+		"bytes", counted.n)
+
+	return &models.TransactionExportFile{Key: key, Rows: rows, Bytes: counted.n}, nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
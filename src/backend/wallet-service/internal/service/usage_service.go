@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.3.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+	"github.com/shopspring/decimal"                           // v1.3.1
+
+	"internal/currency"
+	"internal/models"
+	"internal/repository"
+)
+
+// UsageMonthLayout formats the month of usage reports
+const UsageMonthLayout = "2006-01"
+
+// Usage metrics of internal services, for dashboards across replicas
+var (
+	serviceRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_service_usage_requests_total",
+			Help: "Requests made by internal services, by caller",
+		},
+		[]string{"caller"},
+	)
+	serviceDBSeconds = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_service_usage_db_seconds_total",
+			Help: "Database time spent serving internal services, by caller",
+		},
+		[]string{"caller"},
+	)
+	serviceBytes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_service_usage_bytes_served_total",
+			Help: "Response bytes served to internal services, by caller",
+		},
+		[]string{"caller"},
+	)
+)
+
+// ChargebackPolicy names the currency internal services are billed in and
+// the wallets they are billed through. Services without a wallet are
+// reported but not billed. Their usage is priced at the rate card.
+type ChargebackPolicy struct {
+	Currency string
+	// Wallets maps each caller to the wallet its usage is debited from
+	Wallets map[string]uuid.UUID
+}
+
+// UsageService defines the interface for internal usage accounting
+type UsageService interface {
+	Record(caller string, at time.Time, dbTime time.Duration, bytesServed int64)
+	Flush(ctx context.Context) error
+	RunFlusher(ctx context.Context, interval time.Duration)
+	GetMonthlyReport(ctx context.Context, month time.Time) (*models.UsageReport, error)
+	Charge(ctx context.Context, month time.Time) error
+	RunMonthlyChargeback(ctx context.Context, at time.Duration)
+}
+
+// usageKey identifies the usage of a caller in a month
+type usageKey struct {
+	month  time.Time
+	caller string
+}
+
+// usageService implements UsageService interface. Requests are counted in
+// memory and added to the stored monthly totals by the flusher, so request
+// handling never waits for the database.
+type usageService struct {
+	repo       repository.UsageRepository
+	wallets    WalletService
+	currencies *currency.Registry
+	rates      RateCardSource
+	policy     ChargebackPolicy
+	logger     Logger
+
+	mu      sync.Mutex
+	pending map[usageKey]*models.ServiceUsage
+}
+
+// NewUsageService creates a new instance of UsageService
+func NewUsageService(repo repository.UsageRepository, wallets WalletService, currencies *currency.Registry, rates RateCardSource, policy ChargebackPolicy, logger Logger) (UsageService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currencies are required")
+	}
+	if rates == nil {
+		return nil, errors.New("rate card is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if len(policy.Wallets) > 0 && policy.Currency == "" {
+		return nil, errors.New("chargeback currency is required to bill wallets")
+	}
+	if policy.Currency != "" && !currencies.Supported(policy.Currency) {
+		return nil, fmt.Errorf("chargeback currency %s is not supported", policy.Currency)
+	}
+
+	return &usageService{
+		repo:       repo,
+		wallets:    wallets,
+		currencies: currencies,
+		rates:      rates,
+		policy:     policy,
+		logger:     logger,
+		pending:    make(map[usageKey]*models.ServiceUsage),
+	}, nil
+}
+
+// Record counts a request of an internal service
+func (s *usageService) Record(caller string, at time.Time, dbTime time.Duration, bytesServed int64) {
+	serviceRequests.WithLabelValues(caller).Inc()
+	serviceDBSeconds.WithLabelValues(caller).Add(dbTime.Seconds())
+	serviceBytes.WithLabelValues(caller).Add(float64(bytesServed))
+
+	key := usageKey{month: monthOf(at), caller: caller}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.pending[key]
+	if !ok {
+		u = &models.ServiceUsage{Month: key.month, Caller: caller}
+		s.pending[key] = u
+	}
+	u.Requests++
+	u.DBTime += dbTime
+	u.BytesServed += bytesServed
+}
+
+// Flush adds the usage counted since the last flush to the stored totals.
+// Usage that fails to store is kept for the next flush.
+func (s *usageService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[usageKey]*models.ServiceUsage)
+	s.mu.Unlock()
+
+	var failed error
+	for key, u := range pending {
+		if err := s.repo.AddUsage(ctx, u); err != nil {
+			s.logger.Error("failed to store service usage", err, "caller", u.Caller)
+			failed = err
+			s.requeue(key, u)
+		}
+	}
+	if failed != nil {
+		return fmt.Errorf("failed to flush service usage: %w", failed)
+	}
+
+	return nil
+}
+
+// requeue returns usage that failed to store to the pending totals
+func (s *usageService) requeue(key usageKey, u *models.ServiceUsage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.pending[key]
+	if !ok {
+		s.pending[key] = u
+		return
+	}
+	existing.Requests += u.Requests
+	existing.DBTime += u.DBTime
+	existing.BytesServed += u.BytesServed
+}
+
+// RunFlusher flushes the counted usage at the given interval, and once more
+// when the context is cancelled
+func (s *usageService) RunFlusher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			_ = s.Flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			// Failures are logged by Flush and retried on the next tick
+			_ = s.Flush(ctx)
+		}
+	}
+}
+
+// GetMonthlyReport returns the stored usage of every internal service in a
+// month with its cost at the rate card in effect at the end of the month,
+// or now for the current month
+func (s *usageService) GetMonthlyReport(ctx context.Context, month time.Time) (*models.UsageReport, error) {
+	month = monthOf(month)
+
+	pricedAt := month.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	if now := time.Now().UTC(); now.Before(pricedAt) {
+		pricedAt = now
+	}
+	rates, err := s.rates.RatesAt(ctx, pricedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	usages, err := s.repo.ListUsage(ctx, month)
+	if err != nil {
+		s.logger.Error("failed to list service usage", err, "month", month)
+		return nil, fmt.Errorf("failed to list service usage: %w", err)
+	}
+
+	report := &models.UsageReport{
+		Month:    month.Format(UsageMonthLayout),
+		Currency: s.policy.Currency,
+		Services: make([]*models.ServiceUsageCost, 0, len(usages)),
+	}
+	for _, u := range usages {
+		entry := &models.ServiceUsageCost{
+			Caller:               u.Caller,
+			Requests:             u.Requests,
+			DBSeconds:            u.DBTime.Seconds(),
+			BytesServed:          u.BytesServed,
+			Cost:                 s.cost(u, rates),
+			ChargedTransactionID: u.ChargedTransactionID,
+		}
+		if walletID, ok := s.policy.Wallets[u.Caller]; ok {
+			entry.WalletID = &walletID
+		}
+		report.Services = append(report.Services, entry)
+	}
+
+	return report, nil
+}
+
+// cost prices a service's usage, rounded to the precision of the chargeback
+// currency in its rounding mode so the report shows the amount debited
+func (s *usageService) cost(u *models.ServiceUsage, rates *models.RateCard) decimal.Decimal {
+	requests := decimal.NewFromInt(u.Requests).Div(decimal.NewFromInt(1000)).Mul(rates.PerThousandRequests)
+	dbTime := decimal.NewFromFloat(u.DBTime.Seconds()).Mul(rates.PerDBSecond)
+	bytes := decimal.NewFromInt(u.BytesServed).Div(decimal.NewFromInt(1e9)).Mul(rates.PerGB)
+	return s.currencies.Round(s.policy.Currency, requests.Add(dbTime).Add(bytes))
+}
+
+// Charge debits the usage of a month from the wallet of every internal
+// service that has one. Services are claimed before their debit so each is
+// billed once even when several replicas run the chargeback; claims whose
+// debit fails are released for the next run.
+func (s *usageService) Charge(ctx context.Context, month time.Time) error {
+	report, err := s.GetMonthlyReport(ctx, month)
+	if err != nil {
+		return err
+	}
+	month = monthOf(month)
+
+	var failed error
+	for _, entry := range report.Services {
+		if entry.WalletID == nil || entry.ChargedTransactionID != nil || !entry.Cost.IsPositive() {
+			continue
+		}
+		if err := s.chargeService(ctx, month, entry); err != nil {
+			failed = err
+		}
+	}
+	if failed != nil {
+		return fmt.Errorf("failed to charge service usage: %w", failed)
+	}
+
+	return nil
+}
+
+// chargeService debits one service's monthly cost from its wallet
+func (s *usageService) chargeService(ctx context.Context, month time.Time, entry *models.ServiceUsageCost) error {
+	claimed, err := s.repo.ClaimCharge(ctx, month, entry.Caller, time.Now().UTC())
+	if err != nil {
+		s.logger.Error("failed to claim usage charge", err, "caller", entry.Caller)
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+
+	amount, _ := entry.Cost.Float64()
+	now := time.Now().UTC()
+	tx := &models.Transaction{
+		ID:          uuid.New(),
+		WalletID:    *entry.WalletID,
+		Type:        models.TransactionTypeDebit,
+		Status:      models.TransactionStatusInitiated,
+		Amount:      amount,
+		Currency:    s.policy.Currency,
+		Description: "Internal usage chargeback " + month.Format(UsageMonthLayout),
+		ReferenceID: "CHARGEBACK-" + month.Format(UsageMonthLayout),
+		Metadata: map[string]string{
+			"caller": entry.Caller,
+			"month":  month.Format(UsageMonthLayout),
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if _, err := s.wallets.ProcessTransaction(ctx, tx); err != nil {
+		s.logger.Error("failed to debit usage charge", err, "caller", entry.Caller, "walletID", entry.WalletID)
+		if releaseErr := s.repo.ReleaseCharge(ctx, month, entry.Caller); releaseErr != nil {
+			s.logger.Error("failed to release usage charge", releaseErr, "caller", entry.Caller)
+		}
+		return err
+	}
+
+	if err := s.repo.SetChargedTransaction(ctx, month, entry.Caller, tx.ID); err != nil {
+		// The claim stays, so the debit is not repeated
+		s.logger.Error("failed to record usage charge", err, "caller", entry.Caller, "transactionID", tx.ID)
+		return err
+	}
+
+	s.logger.Info("internal usage charged",
+		"caller", entry.Caller,
+		"month", month.Format(UsageMonthLayout),
+		"walletID", entry.WalletID,
+		"amount", entry.Cost.String())
+
+	return nil
+}
+
+// RunMonthlyChargeback charges the previous month's usage on the first day
+// of every month at the given offset from midnight UTC until the context is
+// cancelled
+func (s *usageService) RunMonthlyChargeback(ctx context.Context, at time.Duration) {
+	runDailyAt(ctx, at, func(scheduled time.Time) {
+		if scheduled.Day() != 1 {
+			return
+		}
+		// Failures are logged by Charge and retried with the
+		// charge-internal-usage runbook action
+		_ = s.Charge(ctx, scheduled.AddDate(0, -1, 0))
+	})
+}
+
+// monthOf returns the first day of the month of t in UTC
+func monthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
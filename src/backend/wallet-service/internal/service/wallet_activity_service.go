@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/eventbus"
+	"internal/repository"
+)
+
+// walletActivityTransitions counts wallets flagged inactive and reactivated
+var walletActivityTransitions = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_activity_transitions_total",
+		Help: "Wallets flagged inactive after the inactivity period, and reactivated as they transacted again",
+	},
+	[]string{"transition"},
+)
+
+// InactivityPolicy configures when wallets are flagged inactive
+type InactivityPolicy struct {
+	// After is how long a wallet goes without transactions before it is
+	// flagged inactive
+	After time.Duration
+	// BatchSize bounds the wallets flagged either way per sweep
+	BatchSize int
+}
+
+// WalletActivityService defines the interface for the inactivity lifecycle
+// of wallets. Wallets without transactions for the inactivity period are
+// flagged and announced with wallet.inactive, and flagged wallets that
+// transact again are announced with wallet.reactivated.
+type WalletActivityService interface {
+	Sweep(ctx context.Context, now time.Time) error
+}
+
+// walletActivityService implements WalletActivityService interface
+type walletActivityService struct {
+	repo      repository.WalletActivityRepository
+	publisher eventbus.Publisher
+	policy    InactivityPolicy
+	logger    Logger
+}
+
+// NewWalletActivityService creates a new instance of WalletActivityService
+func NewWalletActivityService(repo repository.WalletActivityRepository, publisher eventbus.Publisher, policy InactivityPolicy, logger Logger) (WalletActivityService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if policy.After <= 0 {
+		return nil, errors.New("inactivity period must be positive")
+	}
+	if policy.BatchSize <= 0 {
+		return nil, errors.New("batch size must be positive")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &walletActivityService{
+		repo:      repo,
+		publisher: publisher,
+		policy:    policy,
+		logger:    logger,
+	}, nil
+}
+
+// Sweep reactivates flagged wallets that transacted since, then flags the
+// wallets idle for the inactivity period. Reactivations go first so a wallet
+// flagged and reactivated in one sweep cannot be announced out of order.
+// The repository flips each wallet once, so concurrent sweeps announce it
+// once; wallets beyond the batch size are left for the next sweep.
+func (s *walletActivityService) Sweep(ctx context.Context, now time.Time) error {
+	reactivations, err := s.repo.MarkReactivatedWallets(ctx, s.policy.BatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to reactivate wallets: %w", err)
+	}
+	for _, reactivation := range reactivations {
+		walletActivityTransitions.WithLabelValues("reactivated").Inc()
+		publishWalletChanges(ctx, s.publisher, s.logger, eventbus.WalletReactivated{Reactivation: reactivation})
+	}
+
+	inactive, err := s.repo.MarkInactiveWallets(ctx, now.Add(-s.policy.After), now, s.policy.BatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to flag inactive wallets: %w", err)
+	}
+	for _, wallet := range inactive {
+		walletActivityTransitions.WithLabelValues("inactive").Inc()
+		publishWalletChanges(ctx, s.publisher, s.logger, eventbus.WalletInactive{Wallet: wallet})
+	}
+
+	if len(reactivations) > 0 || len(inactive) > 0 {
+		s.logger.Info("wallet activity swept",
+			"reactivated", len(reactivations),
+			"inactive", len(inactive))
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"  // v8.11.5
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+	"internal/repository"
+)
+
+// walletAnalyticsCachePrefix is the Redis key prefix for cached wallet
+// analytics
+const walletAnalyticsCachePrefix = "analytics:wallet:"
+
+// maxTopSpendCategories bounds the spend categories a summary may list
+const maxTopSpendCategories = 20
+
+// maxBurnProjectionDays bounds burn rate projections; balances lasting
+// longer at the current spend get none
+const maxBurnProjectionDays = 3650
+
+// ErrInvalidAnalyticsQuery is returned for unknown granularities and
+// category counts out of range
+var ErrInvalidAnalyticsQuery = errors.New("invalid analytics query")
+
+// WalletAnalyticsQuery selects the window and grouping of wallet analytics
+type WalletAnalyticsQuery struct {
+	Granularity models.AnalyticsGranularity
+	// Window is how far back aggregates look, at most a year
+	Window time.Duration
+	// TopCategories is how many spend categories to list
+	TopCategories int
+}
+
+// WalletAnalyticsService defines the interface for the spending summaries
+// of a wallet
+type WalletAnalyticsService interface {
+	GetWalletAnalytics(ctx context.Context, walletID uuid.UUID, query WalletAnalyticsQuery) (*models.WalletAnalytics, error)
+}
+
+// walletAnalyticsService implements WalletAnalyticsService interface
+type walletAnalyticsService struct {
+	repo     repository.WalletAnalyticsRepository
+	wallets  WalletService
+	redis    *redis.Client
+	cacheTTL time.Duration
+	logger   Logger
+}
+
+// NewWalletAnalyticsService creates a new instance of WalletAnalyticsService.
+// Summaries are cached in Redis for cacheTTL, so they may lag postings by
+// up to that long; a nil client or zero TTL disables caching.
+func NewWalletAnalyticsService(repo repository.WalletAnalyticsRepository, wallets WalletService, client *redis.Client, cacheTTL time.Duration, logger Logger) (WalletAnalyticsService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if cacheTTL < 0 {
+		return nil, errors.New("cache TTL must be non-negative")
+	}
+
+	return &walletAnalyticsService{
+		repo:     repo,
+		wallets:  wallets,
+		redis:    client,
+		cacheTTL: cacheTTL,
+		logger:   logger,
+	}, nil
+}
+
+// GetWalletAnalytics returns the debits and credits of a wallet per period
+// over the window, its largest spend categories and the projection of when
+// its balance runs out at the window's average daily net spend. Periods
+// without transactions are listed with zero totals so trends are contiguous.
+func (s *walletAnalyticsService) GetWalletAnalytics(ctx context.Context, walletID uuid.UUID, query WalletAnalyticsQuery) (*models.WalletAnalytics, error) {
+	if query.Window <= 0 || query.Window > maxAnalyticsWindow {
+		return nil, ErrInvalidAnalyticsWindow
+	}
+	if !query.Granularity.IsValid() {
+		return nil, fmt.Errorf("%w: unknown granularity %q", ErrInvalidAnalyticsQuery, query.Granularity)
+	}
+	if query.TopCategories < 1 || query.TopCategories > maxTopSpendCategories {
+		return nil, fmt.Errorf("%w: top categories must be between 1 and %d", ErrInvalidAnalyticsQuery, maxTopSpendCategories)
+	}
+
+	key := fmt.Sprintf("%s%s:%s:%d:%d", walletAnalyticsCachePrefix, walletID, query.Granularity, int64(query.Window/time.Second), query.TopCategories)
+	if cached := s.cached(ctx, key); cached != nil {
+		return cached, nil
+	}
+
+	wallet, err := s.wallets.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	since := now.Add(-query.Window)
+	flows, err := s.repo.GetWalletFlows(ctx, walletID, query.Granularity, since)
+	if err != nil {
+		s.logger.Error("failed to get wallet flows", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to get wallet flows: %w", err)
+	}
+	categories, err := s.repo.GetSpendCategories(ctx, walletID, since, query.TopCategories)
+	if err != nil {
+		s.logger.Error("failed to get spend categories", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to get spend categories: %w", err)
+	}
+	if categories == nil {
+		categories = []*models.CategorySpend{}
+	}
+
+	analytics := &models.WalletAnalytics{
+		WalletID:      walletID,
+		Currency:      wallet.Currency,
+		Granularity:   query.Granularity,
+		From:          since,
+		To:            now,
+		Balance:       decimal.NewFromFloat(wallet.Balance),
+		TotalDebits:   decimal.Zero,
+		TotalCredits:  decimal.Zero,
+		Periods:       fillPeriods(flows, query.Granularity, since, now),
+		TopCategories: categories,
+		GeneratedAt:   now,
+	}
+	for _, flow := range analytics.Periods {
+		analytics.TotalDebits = analytics.TotalDebits.Add(flow.Debits)
+		analytics.TotalCredits = analytics.TotalCredits.Add(flow.Credits)
+	}
+	analytics.BurnRate = burnRate(analytics.Balance, analytics.TotalDebits.Sub(analytics.TotalCredits), query.Window, now)
+
+	s.cache(ctx, key, analytics)
+	return analytics, nil
+}
+
+// cached returns the summary stored under key, or nil when caching is off
+// or the summary is missing or unreadable
+func (s *walletAnalyticsService) cached(ctx context.Context, key string) *models.WalletAnalytics {
+	if s.redis == nil || s.cacheTTL == 0 {
+		return nil
+	}
+
+	data, err := s.redis.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil
+	}
+	var analytics models.WalletAnalytics
+	if err := json.Unmarshal(data, &analytics); err != nil {
+		return nil
+	}
+	return &analytics
+}
+
+// cache stores a summary under key. Redis is a soft dependency, so failures
+// are only logged.
+func (s *walletAnalyticsService) cache(ctx context.Context, key string, analytics *models.WalletAnalytics) {
+	if s.redis == nil || s.cacheTTL == 0 {
+		return
+	}
+
+	data, err := json.Marshal(analytics)
+	if err != nil {
+		return
+	}
+	if err := s.redis.Set(ctx, key, data, s.cacheTTL).Err(); err != nil {
+		s.logger.Warn("failed to cache wallet analytics", "key", key, "error", err)
+	}
+}
+
+// fillPeriods returns a flow for every period from the one holding since to
+// the one holding now, taking the totals of flows and zero elsewhere
+func fillPeriods(flows []*models.WalletFlow, granularity models.AnalyticsGranularity, since, now time.Time) []*models.WalletFlow {
+	byStart := make(map[time.Time]*models.WalletFlow, len(flows))
+	for _, flow := range flows {
+		byStart[granularity.Truncate(flow.PeriodStart)] = flow
+	}
+
+	var periods []*models.WalletFlow
+	for start := granularity.Truncate(since); !start.After(now); start = granularity.Next(start) {
+		flow, ok := byStart[start]
+		if !ok {
+			flow = &models.WalletFlow{PeriodStart: start, Debits: decimal.Zero, Credits: decimal.Zero}
+		}
+		periods = append(periods, flow)
+	}
+	return periods
+}
+
+// burnRate projects when balance runs out at the average daily net spend
+// over the window. Wallets that took in at least as much as they spent, or
+// would last beyond maxBurnProjectionDays, get no projection.
+func burnRate(balance, netSpend decimal.Decimal, window time.Duration, now time.Time) models.BurnRate {
+	days := decimal.NewFromFloat(window.Hours() / 24)
+	daily := netSpend.Div(days)
+
+	rate := models.BurnRate{DailyNetSpend: daily.Round(2)}
+	if !daily.IsPositive() {
+		return rate
+	}
+
+	remaining := decimal.Max(balance, decimal.Zero).Div(daily)
+	if remaining.GreaterThan(decimal.NewFromInt(maxBurnProjectionDays)) {
+		return rate
+	}
+	daysRemaining, _ := remaining.Round(1).Float64()
+	depletesAt := now.Add(time.Duration(remaining.Mul(decimal.NewFromInt(int64(24 * time.Hour))).IntPart()))
+	rate.DaysRemaining = &daysRemaining
+	rate.DepletesAt = &depletesAt
+	return rate
+}
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/currency"
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+)
+
+// Wallet bucket errors
+var (
+	ErrBucketNotFound = errors.New("bucket not found")
+	ErrBucketExists   = errors.New("bucket already exists")
+	// ErrInvalidBucket is returned for bucket names, thresholds, fallbacks
+	// and transfers that are not allowed
+	ErrInvalidBucket = errors.New("invalid bucket")
+	// ErrInsufficientBucketBalance is returned when a bucket, or the
+	// unallocated balance, cannot cover a transfer or a debit targeted to
+	// a bucket without fallback
+	ErrInsufficientBucketBalance = errors.New("insufficient bucket balance")
+)
+
+// BucketInput holds the settings of a bucket. An empty fallback is
+// BucketFallbackNone.
+type BucketInput struct {
+	LowBalanceThreshold decimal.Decimal
+	Fallback            models.BucketFallback
+}
+
+// BucketUse is the part of a debit taken out of the bucket it names
+type BucketUse struct {
+	// Bucket is the bucket after the debit
+	Bucket *models.WalletBucket
+	Amount decimal.Decimal
+}
+
+// WalletBucketService defines the interface for splitting wallet balances
+// into named buckets, such as an SMS budget, that debits can be targeted
+// to
+type WalletBucketService interface {
+	// List returns a wallet's buckets with its unallocated balance
+	List(ctx context.Context, walletID uuid.UUID) (*models.WalletBuckets, error)
+	// Create adds an empty bucket to a wallet
+	Create(ctx context.Context, walletID uuid.UUID, name string, input BucketInput) (*models.WalletBucket, error)
+	// Update replaces the threshold and fallback of a bucket
+	Update(ctx context.Context, walletID uuid.UUID, name string, input BucketInput) (*models.WalletBucket, error)
+	// Delete removes a bucket, leaving its balance unallocated
+	Delete(ctx context.Context, walletID uuid.UUID, name string) error
+	// Transfer moves funds between buckets or between a bucket and the
+	// unallocated balance
+	Transfer(ctx context.Context, transfer *models.BucketTransfer) ([]*models.WalletBucket, error)
+	// Take takes a debit's amount out of the bucket it names, returning nil
+	// for debits naming none
+	Take(ctx context.Context, tx *models.Transaction) (*BucketUse, error)
+	// Return gives back what was taken for a debit that did not post
+	Return(ctx context.Context, tx *models.Transaction, use *BucketUse) error
+	// Evaluate alerts once a bucket is at its low balance threshold and
+	// re-arms it once it is above
+	Evaluate(ctx context.Context, bucket *models.WalletBucket) error
+}
+
+// walletBucketService implements WalletBucketService interface
+type walletBucketService struct {
+	repo       repository.WalletBucketRepository
+	wallets    WalletService
+	currencies *currency.Registry
+	publisher  eventbus.Publisher
+	logger     Logger
+}
+
+// NewWalletBucketService creates a new instance of WalletBucketService
+func NewWalletBucketService(repo repository.WalletBucketRepository, wallets WalletService, currencies *currency.Registry,
+	publisher eventbus.Publisher, logger Logger) (WalletBucketService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currencies are required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &walletBucketService{
+		repo:       repo,
+		wallets:    wallets,
+		currencies: currencies,
+		publisher:  publisher,
+		logger:     logger,
+	}, nil
+}
+
+// List returns the buckets of a wallet. The unallocated balance is what the
+// buckets leave of the wallet's balance; debits naming no bucket may take
+// it below zero down to the credit limit.
+func (s *walletBucketService) List(ctx context.Context, walletID uuid.UUID) (*models.WalletBuckets, error) {
+	wallet, err := s.wallets.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets, err := s.repo.ListBuckets(ctx, walletID)
+	if err != nil {
+		s.logger.Error("failed to list buckets", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+	if buckets == nil {
+		buckets = []*models.WalletBucket{}
+	}
+
+	balance := decimal.NewFromFloat(wallet.Balance)
+	unallocated := balance
+	for _, bucket := range buckets {
+		unallocated = unallocated.Sub(bucket.Balance)
+	}
+	return &models.WalletBuckets{
+		WalletID:    walletID,
+		Currency:    wallet.Currency,
+		Balance:     balance,
+		Unallocated: unallocated,
+		Buckets:     buckets,
+	}, nil
+}
+
+// Create adds an empty bucket, funded by transfers
+func (s *walletBucketService) Create(ctx context.Context, walletID uuid.UUID, name string, input BucketInput) (*models.WalletBucket, error) {
+	wallet, err := s.wallets.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	name, err = validateBucketName(name)
+	if err != nil {
+		return nil, err
+	}
+	input, err = s.validateBucketInput(wallet, input)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	bucket := &models.WalletBucket{
+		ID:                  uuid.New(),
+		WalletID:            walletID,
+		Name:                name,
+		Balance:             decimal.Zero,
+		LowBalanceThreshold: input.LowBalanceThreshold,
+		Fallback:            input.Fallback,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if err := s.repo.CreateBucket(ctx, bucket); err != nil {
+		return nil, s.bucketError(err, "failed to create bucket", walletID, name)
+	}
+
+	s.logger.Info("bucket created",
+		"walletID", walletID,
+		"bucket", name,
+		"fallback", input.Fallback)
+
+	return bucket, nil
+}
+
+// Update replaces the settings of a bucket, alerting or re-arming it by
+// the new threshold
+func (s *walletBucketService) Update(ctx context.Context, walletID uuid.UUID, name string, input BucketInput) (*models.WalletBucket, error) {
+	wallet, err := s.wallets.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	input, err = s.validateBucketInput(wallet, input)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := &models.WalletBucket{
+		WalletID:            walletID,
+		Name:                strings.TrimSpace(name),
+		LowBalanceThreshold: input.LowBalanceThreshold,
+		Fallback:            input.Fallback,
+	}
+	if err := s.repo.UpdateBucket(ctx, bucket); err != nil {
+		return nil, s.bucketError(err, "failed to update bucket", walletID, name)
+	}
+	if err := s.Evaluate(ctx, bucket); err != nil {
+		return nil, err
+	}
+
+	return bucket, nil
+}
+
+// Delete removes a bucket
+func (s *walletBucketService) Delete(ctx context.Context, walletID uuid.UUID, name string) error {
+	if _, err := s.wallets.GetWallet(ctx, walletID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteBucket(ctx, walletID, strings.TrimSpace(name)); err != nil {
+		return s.bucketError(err, "failed to delete bucket", walletID, name)
+	}
+
+	s.logger.Info("bucket deleted",
+		"walletID", walletID,
+		"bucket", name)
+
+	return nil
+}
+
+// Transfer moves funds between two buckets, an empty name standing for the
+// unallocated balance. Buckets the transfer takes to their threshold alert,
+// and those it tops up above it re-arm.
+func (s *walletBucketService) Transfer(ctx context.Context, transfer *models.BucketTransfer) ([]*models.WalletBucket, error) {
+	wallet, err := s.wallets.GetWallet(ctx, transfer.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer.From = strings.TrimSpace(transfer.From)
+	transfer.To = strings.TrimSpace(transfer.To)
+	switch {
+	case transfer.From == transfer.To:
+		return nil, fmt.Errorf("%w: transfers must be between two different buckets", ErrInvalidBucket)
+	case !transfer.Amount.IsPositive():
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidBucket)
+	case !s.currencies.Round(wallet.Currency, transfer.Amount).Equal(transfer.Amount):
+		return nil, fmt.Errorf("%w: amount has more decimal places than %s allows", ErrInvalidBucket, wallet.Currency)
+	}
+
+	buckets, err := s.repo.TransferBucketFunds(ctx, transfer.WalletID, transfer.From, transfer.To, transfer.Amount)
+	if err != nil {
+		return nil, s.bucketError(err, "failed to transfer bucket funds", transfer.WalletID, transfer.From)
+	}
+	for _, bucket := range buckets {
+		if err := s.Evaluate(ctx, bucket); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.Info("bucket funds transferred",
+		"walletID", transfer.WalletID,
+		"from", transfer.From,
+		"to", transfer.To,
+		"amount", transfer.Amount)
+
+	return buckets, nil
+}
+
+// Take takes a debit's amount, rounded to its currency, out of the bucket
+// named in its metadata
+func (s *walletBucketService) Take(ctx context.Context, tx *models.Transaction) (*BucketUse, error) {
+	name := strings.TrimSpace(tx.Metadata[models.MetadataBucket])
+	if name == "" || tx.Amount <= 0 {
+		return nil, nil
+	}
+
+	amount := s.currencies.Round(tx.Currency, decimal.NewFromFloat(tx.Amount))
+	taken, bucket, err := s.repo.TakeFromBucket(ctx, tx.WalletID, name, amount)
+	if err != nil {
+		return nil, s.bucketError(err, "failed to take from bucket", tx.WalletID, name)
+	}
+	return &BucketUse{Bucket: bucket, Amount: taken}, nil
+}
+
+// Return gives back what a debit took
+func (s *walletBucketService) Return(ctx context.Context, tx *models.Transaction, use *BucketUse) error {
+	if !use.Amount.IsPositive() {
+		return nil
+	}
+	if err := s.repo.ReturnToBucket(ctx, tx.WalletID, use.Bucket.Name, use.Amount); err != nil {
+		s.logger.Error("failed to return to bucket", err, "walletID", tx.WalletID, "bucket", use.Bucket.Name)
+		return fmt.Errorf("failed to return to bucket: %w", err)
+	}
+	return nil
+}
+
+// Evaluate flips the low balance state of a bucket when it no longer
+// matches its balance. The repository flips it only when it differs, so a
+// bucket alerts once however many debits take it below its threshold.
+func (s *walletBucketService) Evaluate(ctx context.Context, bucket *models.WalletBucket) error {
+	low := bucket.IsLowBalance()
+	if low == bucket.LowBalance {
+		return nil
+	}
+
+	flipped, err := s.repo.SetBucketLowBalance(ctx, bucket.ID, low)
+	if err != nil {
+		s.logger.Error("failed to set bucket low balance", err, "bucketID", bucket.ID)
+		return fmt.Errorf("failed to set bucket low balance: %w", err)
+	}
+	bucket.LowBalance = low
+	if !flipped || !low {
+		return nil
+	}
+
+	wallet, err := s.wallets.GetWallet(ctx, bucket.WalletID)
+	if err != nil {
+		return err
+	}
+	s.logger.Warn("bucket balance low",
+		"walletID", bucket.WalletID,
+		"bucket", bucket.Name,
+		"balance", bucket.Balance,
+		"threshold", bucket.LowBalanceThreshold)
+	publishWalletChanges(ctx, s.publisher, s.logger, eventbus.BucketLowBalance{Wallet: wallet, Bucket: bucket})
+	return nil
+}
+
+// validateBucketInput checks a bucket's threshold and fallback, defaulting
+// the fallback to none
+func (s *walletBucketService) validateBucketInput(wallet *models.Wallet, input BucketInput) (BucketInput, error) {
+	if input.Fallback == "" {
+		input.Fallback = models.BucketFallbackNone
+	}
+	switch {
+	case !input.Fallback.IsValid():
+		return input, fmt.Errorf("%w: fallback must be %s or %s", ErrInvalidBucket, models.BucketFallbackNone, models.BucketFallbackUnallocated)
+	case input.LowBalanceThreshold.IsNegative():
+		return input, fmt.Errorf("%w: low_balance_threshold must not be negative", ErrInvalidBucket)
+	case !s.currencies.Round(wallet.Currency, input.LowBalanceThreshold).Equal(input.LowBalanceThreshold):
+		return input, fmt.Errorf("%w: low_balance_threshold has more decimal places than %s allows", ErrInvalidBucket, wallet.Currency)
+	}
+	return input, nil
+}
+
+// validateBucketName trims a bucket name and checks its length
+func validateBucketName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > models.MaxBucketNameLength {
+		return "", fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidBucket, models.MaxBucketNameLength)
+	}
+	return name, nil
+}
+
+// bucketError maps a repository error about a bucket
+func (s *walletBucketService) bucketError(err error, msg string, walletID uuid.UUID, name string) error {
+	switch {
+	case errors.Is(err, repository.ErrBucketNotFound):
+		return ErrBucketNotFound
+	case errors.Is(err, repository.ErrBucketExists):
+		return ErrBucketExists
+	case errors.Is(err, repository.ErrInsufficientBucketBalance):
+		return ErrInsufficientBucketBalance
+	case errors.Is(err, repository.ErrWalletNotFound):
+		return ErrWalletNotFound
+	}
+	s.logger.Error(msg, err, "walletID", walletID, "bucket", name)
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// bucketWalletService is a WalletService taking debits targeted to a
+// bucket out of it
+type bucketWalletService struct {
+	WalletService
+	buckets WalletBucketService
+	logger  Logger
+}
+
+// NewBucketWalletService wraps wallets so that debits naming a bucket in
+// their metadata are taken out of it. A bucket falling back to the
+// unallocated balance gives what it holds and the rest is charged to the
+// wallet like any debit; the part the bucket covered is set in the debit's
+// metadata.
+func NewBucketWalletService(wallets WalletService, buckets WalletBucketService, logger Logger) (WalletService, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if buckets == nil {
+		return nil, errors.New("bucket service is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &bucketWalletService{
+		WalletService: wallets,
+		buckets:       buckets,
+		logger:        logger,
+	}, nil
+}
+
+// ProcessTransaction takes targeted debits out of their bucket before
+// posting them
+func (s *bucketWalletService) ProcessTransaction(ctx context.Context, tx *models.Transaction) (*models.CreditLimitWarning, error) {
+	if tx == nil || tx.Type != models.TransactionTypeDebit || tx.Metadata[models.MetadataBucket] == "" {
+		return s.WalletService.ProcessTransaction(ctx, tx)
+	}
+
+	if _, err := s.GetWallet(ctx, tx.WalletID); err != nil {
+		return nil, err
+	}
+	use, err := s.buckets.Take(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if use == nil {
+		return s.WalletService.ProcessTransaction(ctx, tx)
+	}
+
+	metadata := make(map[string]string, len(tx.Metadata)+1)
+	for key, value := range tx.Metadata {
+		metadata[key] = value
+	}
+	metadata[models.MetadataBucketAmount] = use.Amount.String()
+	tx.Metadata = metadata
+
+	warning, err := s.WalletService.ProcessTransaction(ctx, tx)
+	if err != nil {
+		// The debit did not post, so the bucket keeps its funds
+		if returnErr := s.buckets.Return(ctx, tx, use); returnErr != nil {
+			s.logger.Error("failed to give back bucket funds of refused debit", returnErr, "transactionID", tx.ID)
+		}
+		return nil, err
+	}
+	if err := s.buckets.Evaluate(ctx, use.Bucket); err != nil {
+		s.logger.Error("failed to evaluate bucket low balance", err, "transactionID", tx.ID)
+	}
+	return warning, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+	"internal/repository"
+)
+
+// Wallet merge audit actions
+const (
+	walletMergeAction   = "wallet.merge"
+	walletUnmergeAction = "wallet.reverse_merge"
+)
+
+// Wallet merge errors
+var (
+	ErrInvalidWalletMerge  = errors.New("invalid wallet merge")
+	ErrWalletMergeConflict = errors.New("wallet merge is not allowed")
+	ErrWalletMergeNotFound = errors.New("wallet merge not found")
+)
+
+// WalletMergeService defines the interface for consolidating duplicate
+// wallets of a customer and currency. The merged wallet keeps a redirect to
+// the surviving wallet and the merge can be reversed within a window.
+type WalletMergeService interface {
+	Merge(ctx context.Context, sourceID, targetID uuid.UUID, actor, reason string) (*models.WalletMerge, error)
+	Reverse(ctx context.Context, id uuid.UUID, actor, reason string) (*models.WalletMerge, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.WalletMerge, error)
+	GetRedirect(ctx context.Context, walletID uuid.UUID) (*models.WalletMerge, error)
+}
+
+// walletMergeService implements WalletMergeService interface
+type walletMergeService struct {
+	repo   repository.WalletMergeRepository
+	audit  repository.AuditRepository
+	window time.Duration
+	logger Logger
+}
+
+// NewWalletMergeService creates a new instance of WalletMergeService.
+// Merges are reversible for window after they are made.
+func NewWalletMergeService(repo repository.WalletMergeRepository, audit repository.AuditRepository, window time.Duration, logger Logger) (WalletMergeService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if audit == nil {
+		return nil, errors.New("audit repository is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if window <= 0 {
+		return nil, errors.New("merge reversal window must be positive")
+	}
+
+	return &walletMergeService{
+		repo:   repo,
+		audit:  audit,
+		window: window,
+		logger: logger,
+	}, nil
+}
+
+// Merge moves the balance and history of a duplicate wallet into the
+// surviving wallet. The duplicate refuses postings from then on and
+// redirects to the surviving wallet.
+func (s *walletMergeService) Merge(ctx context.Context, sourceID, targetID uuid.UUID, actor, reason string) (*models.WalletMerge, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a reason is required", ErrInvalidWalletMerge)
+	}
+	if sourceID == targetID {
+		return nil, fmt.Errorf("%w: a wallet cannot be merged into itself", ErrInvalidWalletMerge)
+	}
+
+	now := time.Now().UTC()
+	merge, err := s.repo.MergeWallets(ctx, sourceID, targetID, now, now.Add(s.window))
+	params := map[string]string{
+		"source_wallet_id": sourceID.String(),
+		"target_wallet_id": targetID.String(),
+	}
+	if merge != nil {
+		params["merge_id"] = merge.ID.String()
+	}
+	if err := s.audited(ctx, walletMergeAction, actor, reason, params, err); err != nil {
+		return nil, err
+	}
+	return merge, nil
+}
+
+// Reverse puts a merge's balance and history back on the duplicate wallet,
+// as long as its reversal window is open
+func (s *walletMergeService) Reverse(ctx context.Context, id uuid.UUID, actor, reason string) (*models.WalletMerge, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a reason is required", ErrInvalidWalletMerge)
+	}
+
+	merge, err := s.repo.ReverseMerge(ctx, id, time.Now().UTC())
+	params := map[string]string{"merge_id": id.String()}
+	if merge != nil {
+		params["source_wallet_id"] = merge.SourceWalletID.String()
+		params["target_wallet_id"] = merge.TargetWalletID.String()
+	}
+	if err := s.audited(ctx, walletUnmergeAction, actor, reason, params, err); err != nil {
+		return nil, err
+	}
+	return merge, nil
+}
+
+// Get returns a merge
+func (s *walletMergeService) Get(ctx context.Context, id uuid.UUID) (*models.WalletMerge, error) {
+	merge, err := s.repo.GetWalletMerge(ctx, id)
+	if errors.Is(err, repository.ErrWalletMergeNotFound) {
+		return nil, ErrWalletMergeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet merge: %w", err)
+	}
+	return merge, nil
+}
+
+// GetRedirect returns the merge redirecting a wallet to its surviving wallet
+func (s *walletMergeService) GetRedirect(ctx context.Context, walletID uuid.UUID) (*models.WalletMerge, error) {
+	merge, err := s.repo.GetMergeOf(ctx, walletID)
+	if errors.Is(err, repository.ErrWalletMergeNotFound) {
+		return nil, ErrWalletMergeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet merge: %w", err)
+	}
+	return merge, nil
+}
+
+// audited records a merge step in the operator audit log regardless of
+// whether it succeeded, returning the step's error mapped to the service's
+func (s *walletMergeService) audited(ctx context.Context, action, actor, reason string, params map[string]string, err error) error {
+	switch {
+	case err == nil:
+	case errors.Is(err, repository.ErrWalletNotFound):
+		err = ErrWalletNotFound
+	case errors.Is(err, repository.ErrWalletMergeNotFound):
+		err = ErrWalletMergeNotFound
+	case errors.Is(err, repository.ErrWalletMergeConflict):
+		err = fmt.Errorf("%w: %v", ErrWalletMergeConflict, err)
+	case errors.Is(err, repository.ErrOptimisticLock):
+		err = fmt.Errorf("%w: a wallet changed during the merge, retry it", ErrWalletMergeConflict)
+	case errors.Is(err, repository.ErrPeriodClosed):
+		err = fmt.Errorf("%w: the current billing period is closed", ErrWalletMergeConflict)
+	default:
+		s.logger.Error("failed to merge wallets", err, "action", action, "sourceWalletID", params["source_wallet_id"])
+		err = fmt.Errorf("failed to merge wallets: %w", err)
+	}
+
+	entry := &models.OperatorAction{
+		Action: action,
+		Actor:  actor,
+		Reason: reason,
+		Params: params,
+		Status: models.OperatorActionSucceeded,
+	}
+	if err != nil {
+		entry.Status = models.OperatorActionFailed
+		entry.Error = err.Error()
+	}
+
+	if auditErr := s.audit.RecordOperatorAction(ctx, entry); auditErr != nil {
+		s.logger.Error("failed to audit wallet merge", auditErr, "action", action, "sourceWalletID", params["source_wallet_id"])
+		if err == nil {
+			err = fmt.Errorf("%s executed but audit record failed: %w", action, auditErr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("wallet merge step completed",
+		"action", action,
+		"sourceWalletID", params["source_wallet_id"],
+		"targetWalletID", params["target_wallet_id"],
+		"actor", actor)
+
+	return nil
+}
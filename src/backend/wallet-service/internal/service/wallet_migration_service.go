@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+	"internal/repository"
+)
+
+// Wallet migration audit actions
+const (
+	walletExportAction   = "wallet.export"
+	walletImportAction   = "wallet.import"
+	walletActivateAction = "wallet.activate"
+	walletCancelAction   = "wallet.cancel_export"
+)
+
+// openingBalanceDescription describes the transaction standing for the
+// history of an imported wallet older than its export
+const openingBalanceDescription = "Opening balance migrated from another deployment"
+
+// Wallet migration errors
+var (
+	ErrInvalidWalletExport     = errors.New("invalid wallet export")
+	ErrWalletMigrationConflict = errors.New("wallet migration is not in the required state")
+	ErrWalletMigrationNotFound = errors.New("wallet migration not found")
+)
+
+// WalletMigrationOptions configures the history carried by wallet exports
+type WalletMigrationOptions struct {
+	// HistoryWindow is how far back exported transactions go
+	HistoryWindow time.Duration
+	// HistoryLimit bounds the exported transactions within the window
+	HistoryLimit int
+}
+
+// WalletMigrationService defines the interface for moving wallets between
+// deployments. Cutover freezes the wallet on the source at export; the
+// import stays frozen on the target until the operator activates it with
+// the export's checksum.
+type WalletMigrationService interface {
+	Export(ctx context.Context, walletID uuid.UUID, actor, reason string) (*models.WalletExport, error)
+	Import(ctx context.Context, export *models.WalletExport, actor, reason string) (*models.WalletMigration, error)
+	Activate(ctx context.Context, walletID uuid.UUID, checksum, actor, reason string) (*models.WalletMigration, error)
+	CancelExport(ctx context.Context, walletID uuid.UUID, actor, reason string) error
+	Get(ctx context.Context, walletID uuid.UUID) (*models.WalletMigration, error)
+}
+
+// walletMigrationService implements WalletMigrationService interface
+type walletMigrationService struct {
+	repo   repository.WalletMigrationRepository
+	audit  repository.AuditRepository
+	opts   WalletMigrationOptions
+	logger Logger
+}
+
+// NewWalletMigrationService creates a new instance of WalletMigrationService
+func NewWalletMigrationService(repo repository.WalletMigrationRepository, audit repository.AuditRepository, opts WalletMigrationOptions, logger Logger) (WalletMigrationService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if audit == nil {
+		return nil, errors.New("audit repository is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if opts.HistoryWindow < 0 || opts.HistoryLimit < 1 {
+		return nil, errors.New("history window must not be negative and history limit must be at least 1")
+	}
+
+	return &walletMigrationService{
+		repo:   repo,
+		audit:  audit,
+		opts:   opts,
+		logger: logger,
+	}, nil
+}
+
+// Export freezes a wallet on this deployment and returns it for import
+// elsewhere. The wallet refuses postings until the export is cancelled.
+func (s *walletMigrationService) Export(ctx context.Context, walletID uuid.UUID, actor, reason string) (*models.WalletExport, error) {
+	if err := requireMigrationReason(reason); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	export, err := s.repo.ExportWallet(ctx, walletID, now.Add(-s.opts.HistoryWindow), s.opts.HistoryLimit, now)
+	params := map[string]string{"wallet_id": walletID.String()}
+	if export != nil {
+		params["checksum"] = export.Checksum
+	}
+	if err := s.audited(ctx, walletExportAction, actor, reason, params, err); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// Import stores a wallet exported from another deployment, frozen until it
+// is activated. Importing the same export again returns its migration.
+func (s *walletMigrationService) Import(ctx context.Context, export *models.WalletExport, actor, reason string) (*models.WalletMigration, error) {
+	if err := requireMigrationReason(reason); err != nil {
+		return nil, err
+	}
+	if err := validateWalletExport(export); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetWalletMigration(ctx, export.Wallet.ID)
+	if err == nil && existing.Direction == models.WalletMigrationImport && existing.Checksum == export.Checksum {
+		return existing, nil
+	}
+	if err != nil && !errors.Is(err, repository.ErrWalletMigrationNotFound) {
+		return nil, fmt.Errorf("failed to get wallet migration: %w", err)
+	}
+
+	migration, err := s.repo.ImportWallet(ctx, export, openingBalance(export), time.Now().UTC())
+	params := map[string]string{
+		"wallet_id": export.Wallet.ID.String(),
+		"checksum":  export.Checksum,
+	}
+	if err := s.audited(ctx, walletImportAction, actor, reason, params, err); err != nil {
+		return nil, err
+	}
+	return migration, nil
+}
+
+// Activate unfreezes an imported wallet once the source is frozen. The
+// checksum must be that of the imported export.
+func (s *walletMigrationService) Activate(ctx context.Context, walletID uuid.UUID, checksum, actor, reason string) (*models.WalletMigration, error) {
+	if err := requireMigrationReason(reason); err != nil {
+		return nil, err
+	}
+	if checksum == "" {
+		return nil, fmt.Errorf("%w: the export checksum is required", ErrInvalidWalletExport)
+	}
+
+	migration, err := s.repo.ActivateWallet(ctx, walletID, checksum, time.Now().UTC())
+	params := map[string]string{
+		"wallet_id": walletID.String(),
+		"checksum":  checksum,
+	}
+	if err := s.audited(ctx, walletActivateAction, actor, reason, params, err); err != nil {
+		return nil, err
+	}
+	return migration, nil
+}
+
+// CancelExport unfreezes an exported wallet whose import was abandoned. It
+// must only be used when the wallet was not activated on the target.
+func (s *walletMigrationService) CancelExport(ctx context.Context, walletID uuid.UUID, actor, reason string) error {
+	if err := requireMigrationReason(reason); err != nil {
+		return err
+	}
+
+	err := s.repo.CancelExport(ctx, walletID)
+	params := map[string]string{"wallet_id": walletID.String()}
+	return s.audited(ctx, walletCancelAction, actor, reason, params, err)
+}
+
+// Get returns the latest move of a wallet
+func (s *walletMigrationService) Get(ctx context.Context, walletID uuid.UUID) (*models.WalletMigration, error) {
+	migration, err := s.repo.GetWalletMigration(ctx, walletID)
+	if errors.Is(err, repository.ErrWalletMigrationNotFound) {
+		return nil, ErrWalletMigrationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet migration: %w", err)
+	}
+	return migration, nil
+}
+
+// audited records a migration step in the operator audit log regardless of
+// whether it succeeded, returning the step's error mapped to the service's
+func (s *walletMigrationService) audited(ctx context.Context, action, actor, reason string, params map[string]string, err error) error {
+	switch {
+	case err == nil:
+	case errors.Is(err, repository.ErrWalletNotFound):
+		err = ErrWalletNotFound
+	case errors.Is(err, repository.ErrCustomerNotFound):
+		err = ErrCustomerNotFound
+	case errors.Is(err, repository.ErrWalletMigrationNotFound):
+		err = ErrWalletMigrationNotFound
+	case errors.Is(err, repository.ErrWalletMigrationConflict), errors.Is(err, repository.ErrWalletExists):
+		err = fmt.Errorf("%w: %v", ErrWalletMigrationConflict, err)
+	default:
+		s.logger.Error("failed to migrate wallet", err, "action", action, "walletID", params["wallet_id"])
+		err = fmt.Errorf("failed to migrate wallet: %w", err)
+	}
+
+	entry := &models.OperatorAction{
+		Action: action,
+		Actor:  actor,
+		Reason: reason,
+		Params: params,
+		Status: models.OperatorActionSucceeded,
+	}
+	if err != nil {
+		entry.Status = models.OperatorActionFailed
+		entry.Error = err.Error()
+	}
+
+	if auditErr := s.audit.RecordOperatorAction(ctx, entry); auditErr != nil {
+		s.logger.Error("failed to audit wallet migration", auditErr, "action", action, "walletID", params["wallet_id"])
+		if err == nil {
+			err = fmt.Errorf("%s executed but audit record failed: %w", action, auditErr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("wallet migration step completed",
+		"action", action,
+		"walletID", params["wallet_id"],
+		"actor", actor)
+
+	return nil
+}
+
+// requireMigrationReason refuses migration steps without a reason
+func requireMigrationReason(reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("%w: a reason is required", ErrInvalidWalletExport)
+	}
+	return nil
+}
+
+// validateWalletExport checks an export's format, its checksum and that
+// every record belongs to the exported wallet, with holds and payments
+// referencing exported transactions
+func validateWalletExport(export *models.WalletExport) error {
+	if export == nil {
+		return fmt.Errorf("%w: the export is required", ErrInvalidWalletExport)
+	}
+	if export.Version != models.WalletExportVersion {
+		return fmt.Errorf("%w: unsupported export version %d", ErrInvalidWalletExport, export.Version)
+	}
+
+	checksum, err := export.ComputeChecksum()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWalletExport, err)
+	}
+	if export.Checksum == "" || checksum != export.Checksum {
+		return fmt.Errorf("%w: checksum mismatch", ErrInvalidWalletExport)
+	}
+
+	walletID := export.Wallet.ID
+	if walletID == uuid.Nil || export.Wallet.CustomerID == uuid.Nil {
+		return fmt.Errorf("%w: wallet and customer IDs are required", ErrInvalidWalletExport)
+	}
+
+	transactions := make(map[uuid.UUID]*models.Transaction, len(export.Transactions))
+	for _, tx := range export.Transactions {
+		if tx.WalletID != walletID {
+			return fmt.Errorf("%w: transaction %s belongs to another wallet", ErrInvalidWalletExport, tx.ID)
+		}
+		transactions[tx.ID] = tx
+	}
+	for _, payment := range export.Payments {
+		if _, ok := transactions[payment.TransactionID]; !ok {
+			return fmt.Errorf("%w: payment of transaction %s not exported", ErrInvalidWalletExport, payment.TransactionID)
+		}
+	}
+	for _, hold := range export.Holds {
+		if hold.WalletID != walletID {
+			return fmt.Errorf("%w: hold %s belongs to another wallet", ErrInvalidWalletExport, hold.ID)
+		}
+		if transactions[hold.TransactionID] == nil || transactions[hold.OriginalTransactionID] == nil {
+			return fmt.Errorf("%w: transactions of hold %s not exported", ErrInvalidWalletExport, hold.ID)
+		}
+	}
+
+	return nil
+}
+
+// openingBalance returns the transaction reconciling an imported wallet's
+// balance with its exported transactions, standing for the history left
+// behind, or nil when they already agree. Every transaction moved the
+// balance when posted, including the debits of open holds, except those
+// that failed or were reversed since.
+func openingBalance(export *models.WalletExport) *models.Transaction {
+	explained := decimal.Zero
+	openedAt := export.ExportedAt
+	for _, tx := range export.Transactions {
+		if tx.CreatedAt.Before(openedAt) {
+			openedAt = tx.CreatedAt
+		}
+		if tx.Status == models.TransactionStatusFailed || tx.Status == models.TransactionStatusReversed {
+			continue
+		}
+		explained = explained.Add(decimal.NewFromFloat(tx.SignedAmount()))
+	}
+
+	unexplained := decimal.NewFromFloat(export.Wallet.Balance).Sub(explained).Round(2)
+	if unexplained.IsZero() {
+		return nil
+	}
+	amount, _ := unexplained.Abs().Float64()
+
+	opening := &models.Transaction{
+		ID:          uuid.New(),
+		WalletID:    export.Wallet.ID,
+		Type:        models.TransactionTypeCredit,
+		Status:      models.TransactionStatusCompleted,
+		Amount:      amount,
+		Currency:    export.Wallet.Currency,
+		Description: openingBalanceDescription,
+		ReferenceID: "migration:" + export.Checksum,
+		CreatedAt:   openedAt.Add(-time.Second),
+	}
+	if unexplained.IsNegative() {
+		opening.Type = models.TransactionTypeDebit
+	}
+	opening.UpdatedAt = opening.CreatedAt
+	return opening
+}
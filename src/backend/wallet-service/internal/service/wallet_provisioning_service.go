@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/currency"
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+)
+
+// WalletProvisioningKind is the report kind of wallet provisioning jobs
+const WalletProvisioningKind = "wallet-provisioning"
+
+// Wallet provisioning limits
+const (
+	// MaxWalletBatchSize bounds the rows of one provisioning batch
+	MaxWalletBatchSize = 10000
+	// walletBatchChunk is the number of rows processed between recording
+	// their outcome and reporting progress
+	walletBatchChunk = 100
+)
+
+// ErrInvalidWalletBatch is returned for batches without rows or with too many
+var ErrInvalidWalletBatch = errors.New("invalid wallet batch")
+
+// walletBatchParams are the parameters of wallet provisioning jobs. The rows
+// are stored with the batch rather than the job.
+type walletBatchParams struct {
+	BatchID uuid.UUID `json:"batch_id"`
+	Rows    int       `json:"rows"`
+}
+
+// WalletProvisioningService defines the interface for provisioning wallets
+// in bulk, as for enterprise onboarding
+type WalletProvisioningService interface {
+	// Submit stores a batch and queues the job provisioning it
+	Submit(ctx context.Context, rows []*models.WalletBatchRow, actor string) (*models.ReportJob, error)
+	// Provision works through the pending rows of a batch
+	Provision(ctx context.Context, batchID uuid.UUID, progress ReportProgress) (*models.WalletBatchResult, error)
+	// Purge deletes the rows of batches created before a time
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// walletProvisioningService implements WalletProvisioningService interface
+type walletProvisioningService struct {
+	wallets    repository.WalletRepository
+	batches    repository.WalletBatchRepository
+	jobs       ReportJobService
+	currencies *currency.Registry
+	publisher  eventbus.Publisher
+	logger     Logger
+}
+
+// NewWalletProvisioningService creates a new instance of
+// WalletProvisioningService submitting its batches as jobs
+func NewWalletProvisioningService(wallets repository.WalletRepository, batches repository.WalletBatchRepository, jobs ReportJobService, currencies *currency.Registry, publisher eventbus.Publisher, logger Logger) (WalletProvisioningService, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet repository is required")
+	}
+	if batches == nil {
+		return nil, errors.New("wallet batch repository is required")
+	}
+	if jobs == nil {
+		return nil, errors.New("report job service is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currency registry is required")
+	}
+	if publisher == nil {
+		return nil, errors.New("publisher is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &walletProvisioningService{
+		wallets:    wallets,
+		batches:    batches,
+		jobs:       jobs,
+		currencies: currencies,
+		publisher:  publisher,
+		logger:     logger,
+	}, nil
+}
+
+// WalletProvisioningReport returns the report kind provisioning the wallets
+// of a batch. Retries resume after the rows already recorded.
+func WalletProvisioningReport(provisioning WalletProvisioningService) ReportKind {
+	parse := func(params json.RawMessage) (walletBatchParams, error) {
+		var p walletBatchParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return p, err
+		}
+		if p.BatchID == uuid.Nil {
+			return p, errors.New("batch_id is required")
+		}
+		return p, nil
+	}
+
+	return ReportKind{
+		Name: WalletProvisioningKind,
+		Validate: func(params json.RawMessage) error {
+			_, err := parse(params)
+			return err
+		},
+		Run: func(ctx context.Context, params json.RawMessage, progress ReportProgress) (interface{}, error) {
+			p, err := parse(params)
+			if err != nil {
+				return nil, err
+			}
+			return provisioning.Provision(ctx, p.BatchID, progress)
+		},
+	}
+}
+
+// Submit numbers and stores the rows of a batch, then queues its job.
+// Customer IDs and currencies are checked per row when provisioned, so one
+// bad row does not hold up the rest.
+func (s *walletProvisioningService) Submit(ctx context.Context, rows []*models.WalletBatchRow, actor string) (*models.ReportJob, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no wallets to provision", ErrInvalidWalletBatch)
+	}
+	if len(rows) > MaxWalletBatchSize {
+		return nil, fmt.Errorf("%w: at most %d wallets per batch", ErrInvalidWalletBatch, MaxWalletBatchSize)
+	}
+
+	for i, row := range rows {
+		row.Index = i
+		row.CustomerID = strings.TrimSpace(row.CustomerID)
+		row.Currency = strings.ToUpper(strings.TrimSpace(row.Currency))
+		row.Status = models.WalletBatchRowPending
+	}
+
+	batchID := uuid.New()
+	if err := s.batches.CreateWalletBatch(ctx, batchID, rows); err != nil {
+		s.logger.Error("failed to store wallet batch", err, "batchID", batchID)
+		return nil, fmt.Errorf("failed to store wallet batch: %w", err)
+	}
+
+	params, err := json.Marshal(walletBatchParams{BatchID: batchID, Rows: len(rows)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode wallet batch: %w", err)
+	}
+	job, err := s.jobs.Submit(ctx, WalletProvisioningKind, params, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("wallet batch submitted",
+		"batchID", batchID,
+		"jobID", job.ID,
+		"rows", len(rows),
+		"actor", actor)
+
+	return job, nil
+}
+
+// Provision works through the pending rows of a batch in chunks, recording
+// the outcome of each chunk before reporting progress. An error other than
+// a row's own stops the run after recording the rows done so far, and the
+// retry picks up from there.
+func (s *walletProvisioningService) Provision(ctx context.Context, batchID uuid.UUID, progress ReportProgress) (*models.WalletBatchResult, error) {
+	rows, err := s.batches.ListWalletBatchRows(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("wallet batch %s has no rows", batchID)
+	}
+
+	var pending []*models.WalletBatchRow
+	for _, row := range rows {
+		if row.Status == models.WalletBatchRowPending {
+			pending = append(pending, row)
+		}
+	}
+
+	done := len(rows) - len(pending)
+	for start := 0; start < len(pending); start += walletBatchChunk {
+		end := start + walletBatchChunk
+		if end > len(pending) {
+			end = len(pending)
+		}
+		chunk := pending[start:end]
+
+		processed := 0
+		var runErr error
+		for _, row := range chunk {
+			if runErr = s.provisionRow(ctx, row); runErr != nil {
+				break
+			}
+			processed++
+		}
+		if processed > 0 {
+			if err := s.batches.RecordWalletBatchRows(ctx, batchID, chunk[:processed]); err != nil {
+				s.logger.Error("failed to record wallet batch rows", err, "batchID", batchID)
+				return nil, err
+			}
+		}
+		if runErr != nil {
+			s.logger.Error("failed to provision wallet batch", runErr, "batchID", batchID)
+			return nil, runErr
+		}
+
+		done += len(chunk)
+		if err := progress(done * 100 / len(rows)); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &models.WalletBatchResult{BatchID: batchID, Total: len(rows), Rows: rows}
+	for _, row := range rows {
+		switch row.Status {
+		case models.WalletBatchRowCreated:
+			result.Created++
+		case models.WalletBatchRowExists:
+			result.Existing++
+		case models.WalletBatchRowFailed:
+			result.Failed++
+		}
+	}
+
+	s.logger.Info("wallet batch provisioned",
+		"batchID", batchID,
+		"created", result.Created,
+		"existing", result.Existing,
+		"failed", result.Failed)
+
+	return result, nil
+}
+
+// provisionRow creates the wallet of a row unless the customer already has
+// one in the currency, leaving the outcome on the row. Only errors that are
+// not the row's own are returned.
+func (s *walletProvisioningService) provisionRow(ctx context.Context, row *models.WalletBatchRow) error {
+	fail := func(reason string) error {
+		row.Status = models.WalletBatchRowFailed
+		row.Error = reason
+		return nil
+	}
+
+	customerID, err := uuid.Parse(row.CustomerID)
+	if err != nil || customerID == uuid.Nil {
+		return fail("invalid customer ID")
+	}
+	if !s.currencies.Supported(row.Currency) {
+		return fail(fmt.Sprintf("currency %q is not supported", row.Currency))
+	}
+
+	// Read from the primary so a wallet created by an earlier row of the
+	// batch is seen
+	existing, err := s.wallets.GetCustomerWallets(repository.ReadPrimary(ctx), customerID)
+	if err != nil {
+		return fmt.Errorf("failed to get customer wallets: %w", err)
+	}
+	for _, wallet := range existing {
+		if wallet.Currency == row.Currency {
+			row.Status = models.WalletBatchRowExists
+			row.WalletID = &wallet.ID
+			return nil
+		}
+	}
+
+	wallet := &models.Wallet{CustomerID: customerID, Currency: row.Currency}
+	err = s.wallets.CreateWallet(ctx, wallet)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return fail("customer not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create wallet: %w", err)
+	}
+
+	row.Status = models.WalletBatchRowCreated
+	row.WalletID = &wallet.ID
+	publishWalletChanges(ctx, s.publisher, s.logger, eventbus.WalletCreated{Wallet: wallet})
+	return nil
+}
+
+// Purge deletes the rows of batches created before a time, as their jobs
+// are purged
+func (s *walletProvisioningService) Purge(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := s.batches.PurgeWalletBatches(ctx, before)
+	if err != nil {
+		s.logger.Error("failed to purge wallet batches", err)
+		return 0, err
+	}
+	return purged, nil
+}
@@ -5,11 +5,15 @@ import (
     "context"
     "errors"
     "fmt"
+    "math"
+    "sync"
     "time"
 
     "github.com/google/uuid"      // v1.3.0
     "github.com/shopspring/decimal" // v1.3.1
 
+    "internal/currency"
+    "internal/eventbus"
     "internal/models"
     "internal/repository"
 )
@@ -22,9 +26,31 @@ var (
     ErrCurrencyMismatch = errors.New("currency mismatch between wallet and transaction")
     ErrOptimisticLock = errors.New("concurrent modification detected")
     ErrInvalidStateTransition = errors.New("invalid transaction state transition")
+    ErrInvalidWalletID = errors.New("invalid wallet ID")
+    ErrInvalidDateRange = errors.New("invalid date range")
+    ErrInvalidCreditLimit = errors.New("invalid credit limit")
+    ErrCreditLimitBelowBalance = errors.New("wallet is overdrawn beyond the requested credit limit")
+    ErrInvalidTransactionLimit = errors.New("transaction limit must be between 1 and 100")
+    ErrInvalidReferenceID = errors.New("reference ID must be 1 to 255 characters")
+    ErrPeriodClosed = errors.New("billing period of the transaction is closed")
+    ErrFutureEffectiveDate = errors.New("transaction effective date cannot be in the future")
+    ErrWalletFrozen = errors.New("wallet is frozen for migration")
+    ErrWalletMerged = errors.New("wallet was merged into another wallet")
+    ErrUnsupportedCurrency = errors.New("currency is not supported")
+    ErrInvalidWalletSettings = errors.New("invalid wallet settings")
 )
 
-// Logger interface for service logging
+// walletHealthWindow is how far back wallet health counts failed transactions
+const walletHealthWindow = 24 * time.Hour
+
+// MaxReferenceMatches bounds the transactions returned for one reference ID
+const MaxReferenceMatches = 50
+
+// DefaultTransactionQueryHorizon is how far back transaction history goes
+// when no start date is given, until configured otherwise
+const DefaultTransactionQueryHorizon = 90 * 24 * time.Hour
+
+// Logger interface for service logging, implemented by logging.Logger
 type Logger interface {
     Info(msg string, fields ...interface{})
     Error(msg string, err error, fields ...interface{})
@@ -37,6 +63,42 @@ type TransactionFilter struct {
     Statuses []models.TransactionStatus
     FromDate time.Time
     ToDate   time.Time
+    // MetadataKey keeps transactions carrying the key, with MetadataValue
+    // when that is set too
+    MetadataKey   string
+    MetadataValue string
+}
+
+// WalletSettingsUpdate holds the wallet settings to change, leaving nil ones
+// as they are. Version must match the current settings version unless only
+// the credit limit changes.
+type WalletSettingsUpdate struct {
+    LowBalanceThreshold *decimal.Decimal
+    CreditLimit         *decimal.Decimal
+    NotifyLowBalance    *bool
+    NotifyCreditLimit   *bool
+    AutoTopUp           *bool
+    Version             *int64
+}
+
+// Validate checks each field of the update, naming the offending field
+func (u WalletSettingsUpdate) Validate() error {
+    maxAmount := decimal.NewFromFloat(models.MaxTransactionAmount)
+    if t := u.LowBalanceThreshold; t != nil && (t.IsNegative() || t.GreaterThan(maxAmount)) {
+        return fmt.Errorf("%w: low_balance_threshold must be between 0 and %s", ErrInvalidWalletSettings, maxAmount)
+    }
+    if l := u.CreditLimit; l != nil && (l.IsNegative() || l.GreaterThan(maxAmount)) {
+        return fmt.Errorf("%w: credit_limit must be between 0 and %s", ErrInvalidWalletSettings, maxAmount)
+    }
+
+    lockable := u.LowBalanceThreshold != nil || u.NotifyLowBalance != nil || u.NotifyCreditLimit != nil || u.AutoTopUp != nil
+    if lockable && u.Version == nil {
+        return fmt.Errorf("%w: version is required", ErrInvalidWalletSettings)
+    }
+    if u.Version != nil && *u.Version < 1 {
+        return fmt.Errorf("%w: version must be positive", ErrInvalidWalletSettings)
+    }
+    return nil
 }
 
 // Pagination defines pagination parameters
@@ -48,22 +110,45 @@ type Pagination struct {
 // WalletService defines the interface for wallet operations
 type WalletService interface {
     GetWalletBalance(ctx context.Context, walletID uuid.UUID) (decimal.Decimal, string, error)
-    ProcessTransaction(ctx context.Context, tx *models.Transaction) error
+    GetWallet(ctx context.Context, walletID uuid.UUID) (*models.Wallet, error)
+    GetWalletOverview(ctx context.Context, walletID uuid.UUID, limit int) (*models.WalletOverview, error)
+    UpdateCreditLimit(ctx context.Context, walletID uuid.UUID, creditLimit decimal.Decimal) (*models.Wallet, error)
+    GetWalletSettings(ctx context.Context, walletID uuid.UUID) (*models.WalletSettings, error)
+    UpdateWalletSettings(ctx context.Context, walletID uuid.UUID, update WalletSettingsUpdate) (*models.WalletSettings, error)
+    GetWalletHealth(ctx context.Context, walletID uuid.UUID) (*models.WalletHealth, error)
+    ProcessTransaction(ctx context.Context, tx *models.Transaction) (*models.CreditLimitWarning, error)
     GetTransactionHistory(ctx context.Context, walletID uuid.UUID, filter TransactionFilter, pagination Pagination) ([]*models.Transaction, int, error)
+    ListCustomerWallets(ctx context.Context, customerID uuid.UUID) ([]*models.Wallet, error)
+    GetRecentTransactions(ctx context.Context, walletIDs []uuid.UUID, limit int) (map[uuid.UUID][]*models.Transaction, error)
+    FindTransactionsByReference(ctx context.Context, referenceID string) ([]*models.TransactionMatch, error)
+    SetLowBalanceThreshold(threshold decimal.Decimal) error
+    SetTransactionQueryHorizon(horizon time.Duration) error
 }
 
 // walletService implements WalletService interface
 type walletService struct {
     repo               repository.WalletRepository
-    lowBalanceThreshold decimal.Decimal
+    currencies         *currency.Registry
+    publisher          eventbus.Publisher
     logger             Logger
+
+    mu                  sync.RWMutex
+    lowBalanceThreshold decimal.Decimal
+    queryHorizon        time.Duration
 }
 
-// NewWalletService creates a new instance of WalletService
-func NewWalletService(repo repository.WalletRepository, lowBalanceThreshold decimal.Decimal, logger Logger) (WalletService, error) {
+// NewWalletService creates a new instance of WalletService. Transaction
+// amounts are rounded to the precision of their currency in currencies.
+func NewWalletService(repo repository.WalletRepository, currencies *currency.Registry, lowBalanceThreshold decimal.Decimal, publisher eventbus.Publisher, logger Logger) (WalletService, error) {
     if repo == nil {
         return nil, errors.New("repository is required")
     }
+    if currencies == nil {
+        return nil, errors.New("currencies are required")
+    }
+    if publisher == nil {
+        return nil, errors.New("publisher is required")
+    }
     if logger == nil {
         return nil, errors.New("logger is required")
     }
@@ -73,7 +158,10 @@ func NewWalletService(repo repository.WalletRepository, lowBalanceThreshold deci
 
     return &walletService{
         repo:               repo,
+        currencies:         currencies,
         lowBalanceThreshold: lowBalanceThreshold,
+        queryHorizon:       DefaultTransactionQueryHorizon,
+        publisher:          publisher,
         logger:             logger,
     }, nil
 }
@@ -81,7 +169,7 @@ func NewWalletService(repo repository.WalletRepository, lowBalanceThreshold deci
 // GetWalletBalance retrieves current wallet balance with currency information
 func (s *walletService) GetWalletBalance(ctx context.Context, walletID uuid.UUID) (decimal.Decimal, string, error) {
     if walletID == uuid.Nil {
-        return decimal.Zero, "", errors.New("invalid wallet ID")
+        return decimal.Zero, "", ErrInvalidWalletID
     }
 
     wallet, err := s.repo.GetWallet(ctx, walletID)
@@ -101,82 +189,493 @@ func (s *walletService) GetWalletBalance(ctx context.Context, walletID uuid.UUID
     return decimal.NewFromFloat(wallet.Balance), wallet.Currency, nil
 }
 
-// ProcessTransaction handles wallet transaction with comprehensive validation
-func (s *walletService) ProcessTransaction(ctx context.Context, tx *models.Transaction) error {
+// GetWallet retrieves a wallet with its balance and credit limit
+func (s *walletService) GetWallet(ctx context.Context, walletID uuid.UUID) (*models.Wallet, error) {
+    if walletID == uuid.Nil {
+        return nil, ErrInvalidWalletID
+    }
+
+    wallet, err := s.repo.GetWallet(ctx, walletID)
+    if err != nil {
+        if errors.Is(err, repository.ErrWalletNotFound) {
+            return nil, ErrWalletNotFound
+        }
+        s.logger.Error("failed to get wallet", err, "walletID", walletID)
+        return nil, fmt.Errorf("failed to get wallet: %w", err)
+    }
+
+    return wallet, nil
+}
+
+// GetWalletOverview retrieves a wallet with its newest transactions, read
+// from one snapshot so the balance reconciles with them
+func (s *walletService) GetWalletOverview(ctx context.Context, walletID uuid.UUID, limit int) (*models.WalletOverview, error) {
+    if walletID == uuid.Nil {
+        return nil, ErrInvalidWalletID
+    }
+    if limit <= 0 || limit > 100 {
+        return nil, ErrInvalidTransactionLimit
+    }
+
+    overview, err := s.repo.GetWalletOverview(ctx, walletID, limit)
+    if err != nil {
+        if errors.Is(err, repository.ErrWalletNotFound) {
+            return nil, ErrWalletNotFound
+        }
+        s.logger.Error("failed to get wallet overview", err, "walletID", walletID)
+        return nil, fmt.Errorf("failed to get wallet overview: %w", err)
+    }
+
+    return overview, nil
+}
+
+// ListCustomerWallets retrieves every wallet owned by a customer
+func (s *walletService) ListCustomerWallets(ctx context.Context, customerID uuid.UUID) ([]*models.Wallet, error) {
+    wallets, err := s.repo.GetCustomerWallets(ctx, customerID)
+    if err != nil {
+        s.logger.Error("failed to list customer wallets", err, "customerID", customerID)
+        return nil, fmt.Errorf("failed to list customer wallets: %w", err)
+    }
+
+    return wallets, nil
+}
+
+// GetRecentTransactions retrieves the newest transactions of several wallets
+// at once, keyed by wallet ID, so callers rendering many wallets avoid one
+// query per wallet
+func (s *walletService) GetRecentTransactions(ctx context.Context, walletIDs []uuid.UUID, limit int) (map[uuid.UUID][]*models.Transaction, error) {
+    if limit <= 0 || limit > 100 {
+        return nil, ErrInvalidTransactionLimit
+    }
+
+    byWallet := make(map[uuid.UUID][]*models.Transaction, len(walletIDs))
+    if len(walletIDs) == 0 {
+        return byWallet, nil
+    }
+
+    transactions, err := s.repo.GetRecentTransactions(ctx, walletIDs, limit)
+    if err != nil {
+        s.logger.Error("failed to get recent transactions", err, "wallets", len(walletIDs))
+        return nil, fmt.Errorf("failed to get recent transactions: %w", err)
+    }
+
+    for _, tx := range transactions {
+        byWallet[tx.WalletID] = append(byWallet[tx.WalletID], tx)
+    }
+
+    return byWallet, nil
+}
+
+// FindTransactionsByReference retrieves the newest transactions carrying a
+// reference ID across all wallets, with their wallet and customer
+func (s *walletService) FindTransactionsByReference(ctx context.Context, referenceID string) ([]*models.TransactionMatch, error) {
+    if referenceID == "" || len(referenceID) > 255 {
+        return nil, ErrInvalidReferenceID
+    }
+
+    matches, err := s.repo.FindTransactionsByReference(ctx, referenceID, MaxReferenceMatches)
+    if err != nil {
+        s.logger.Error("failed to find transactions by reference", err, "referenceID", referenceID)
+        return nil, fmt.Errorf("failed to find transactions by reference: %w", err)
+    }
+
+    s.logger.Info("transactions found by reference",
+        "referenceID", referenceID,
+        "count", len(matches))
+
+    return matches, nil
+}
+
+// UpdateCreditLimit sets how far below zero a wallet balance may go. A limit
+// cannot be lowered below what the wallet is already overdrawn by.
+func (s *walletService) UpdateCreditLimit(ctx context.Context, walletID uuid.UUID, creditLimit decimal.Decimal) (*models.Wallet, error) {
+    if creditLimit.IsNegative() || creditLimit.GreaterThan(decimal.NewFromFloat(models.MaxTransactionAmount)) {
+        return nil, ErrInvalidCreditLimit
+    }
+
+    // The update is based on the wallet's version, which a read replica
+    // may not have caught up with
+    wallet, err := s.GetWallet(repository.ReadPrimary(ctx), walletID)
+    if err != nil {
+        return nil, err
+    }
+
+    limit, _ := s.currencies.Round(wallet.Currency, creditLimit).Float64()
+    if wallet.Balance < -limit {
+        return nil, ErrCreditLimitBelowBalance
+    }
+
+    if err := s.repo.UpdateCreditLimit(ctx, wallet, limit); err != nil {
+        if errors.Is(err, repository.ErrOptimisticLock) {
+            return nil, ErrOptimisticLock
+        }
+        s.logger.Error("failed to update credit limit", err, "walletID", walletID)
+        return nil, fmt.Errorf("failed to update credit limit: %w", err)
+    }
+
+    s.logger.Info("wallet credit limit updated",
+        "walletID", walletID,
+        "creditLimit", limit)
+
+    return wallet, nil
+}
+
+// GetWalletSettings retrieves a wallet's threshold, credit limit,
+// notification preferences and auto top-up toggle
+func (s *walletService) GetWalletSettings(ctx context.Context, walletID uuid.UUID) (*models.WalletSettings, error) {
+    if walletID == uuid.Nil {
+        return nil, ErrInvalidWalletID
+    }
+
+    settings, err := s.repo.GetWalletSettings(ctx, walletID)
+    if err != nil {
+        if errors.Is(err, repository.ErrWalletNotFound) {
+            return nil, ErrWalletNotFound
+        }
+        s.logger.Error("failed to get wallet settings", err, "walletID", walletID)
+        return nil, fmt.Errorf("failed to get wallet settings: %w", err)
+    }
+
+    return settings, nil
+}
+
+// UpdateWalletSettings applies a settings update with optimistic locking.
+// Amounts are rounded to the wallet currency, and the credit limit cannot be
+// lowered below what the wallet is already overdrawn by.
+func (s *walletService) UpdateWalletSettings(ctx context.Context, walletID uuid.UUID, update WalletSettingsUpdate) (*models.WalletSettings, error) {
+    if err := update.Validate(); err != nil {
+        return nil, err
+    }
+
+    ctx = repository.ReadPrimary(ctx)
+    wallet, err := s.GetWallet(ctx, walletID)
+    if err != nil {
+        return nil, err
+    }
+    settings, err := s.GetWalletSettings(ctx, walletID)
+    if err != nil {
+        return nil, err
+    }
+    if update.Version != nil && *update.Version != settings.Version {
+        return nil, ErrOptimisticLock
+    }
+
+    if update.LowBalanceThreshold != nil {
+        settings.LowBalanceThreshold, _ = s.currencies.Round(wallet.Currency, *update.LowBalanceThreshold).Float64()
+    }
+    if update.CreditLimit != nil {
+        settings.CreditLimit, _ = s.currencies.Round(wallet.Currency, *update.CreditLimit).Float64()
+        if wallet.Balance < -settings.CreditLimit {
+            return nil, ErrCreditLimitBelowBalance
+        }
+    }
+    if update.NotifyLowBalance != nil {
+        settings.Notifications.LowBalance = *update.NotifyLowBalance
+    }
+    if update.NotifyCreditLimit != nil {
+        settings.Notifications.CreditLimitWarning = *update.NotifyCreditLimit
+    }
+    if update.AutoTopUp != nil {
+        settings.AutoTopUp = *update.AutoTopUp
+    }
+
+    if err := s.repo.UpdateWalletSettings(ctx, settings); err != nil {
+        if errors.Is(err, repository.ErrOptimisticLock) {
+            return nil, ErrOptimisticLock
+        }
+        s.logger.Error("failed to update wallet settings", err, "walletID", walletID)
+        return nil, fmt.Errorf("failed to update wallet settings: %w", err)
+    }
+
+    s.logger.Info("wallet settings updated",
+        "walletID", walletID,
+        "version", settings.Version)
+
+    return settings, nil
+}
+
+// GetWalletHealth reports a wallet's balance status against its low balance
+// threshold, the failure rate of its recent transactions and how much of its
+// credit limit is in use
+func (s *walletService) GetWalletHealth(ctx context.Context, walletID uuid.UUID) (*models.WalletHealth, error) {
+    wallet, err := s.GetWallet(ctx, walletID)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now().UTC()
+    since := now.Add(-walletHealthWindow)
+    total, failed, err := s.repo.CountTransactionOutcomes(ctx, walletID, since)
+    if err != nil {
+        s.logger.Error("failed to count transaction outcomes", err, "walletID", walletID)
+        return nil, fmt.Errorf("failed to count transaction outcomes: %w", err)
+    }
+
+    threshold := s.lowBalanceThresholdOf(wallet)
+    health := &models.WalletHealth{
+        WalletID:            wallet.ID,
+        BalanceStatus:       models.BalanceHealthy,
+        Balance:             wallet.Balance,
+        AvailableBalance:    wallet.AvailableBalance(),
+        LowBalanceThreshold: threshold,
+        RecentTransactions:  total,
+        RecentFailures:      failed,
+        WindowStart:         since,
+        LimitUtilization:    math.Round(wallet.CreditUtilization()*10000) / 10000,
+        CheckedAt:           now,
+    }
+    switch {
+    case wallet.Balance < 0:
+        health.BalanceStatus = models.BalanceOverdrawn
+    case wallet.Balance <= threshold:
+        health.BalanceStatus = models.BalanceLow
+    }
+    if total > 0 {
+        health.FailureRate = math.Round(float64(failed)/float64(total)*10000) / 10000
+    }
+
+    return health, nil
+}
+
+// SetLowBalanceThreshold replaces the default low balance threshold, as on
+// configuration reload
+func (s *walletService) SetLowBalanceThreshold(threshold decimal.Decimal) error {
+    if threshold.IsNegative() {
+        return errors.New("low balance threshold must be non-negative")
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.lowBalanceThreshold = threshold
+    return nil
+}
+
+// SetTransactionQueryHorizon replaces how far back transaction history goes
+// without a start date, as on configuration reload
+func (s *walletService) SetTransactionQueryHorizon(horizon time.Duration) error {
+    if horizon <= 0 {
+        return errors.New("transaction query horizon must be positive")
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.queryHorizon = horizon
+    return nil
+}
+
+// transactionQueryHorizon returns the current transaction query horizon
+func (s *walletService) transactionQueryHorizon() time.Duration {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    return s.queryHorizon
+}
+
+// defaultLowBalanceThreshold returns the current default low balance threshold
+func (s *walletService) defaultLowBalanceThreshold() decimal.Decimal {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    return s.lowBalanceThreshold
+}
+
+// lowBalanceThresholdOf returns a wallet's low balance threshold, the
+// service default for wallets without a threshold of their own
+func (s *walletService) lowBalanceThresholdOf(wallet *models.Wallet) float64 {
+    if wallet.LowBalanceThreshold != 0 {
+        return wallet.LowBalanceThreshold
+    }
+    threshold, _ := s.defaultLowBalanceThreshold().Float64()
+    return threshold
+}
+
+// notificationPreferences returns the alerts a wallet's customer chose,
+// falling back to every alert when the settings cannot be read
+func (s *walletService) notificationPreferences(ctx context.Context, walletID uuid.UUID) models.WalletNotificationPreferences {
+    settings, err := s.repo.GetWalletSettings(ctx, walletID)
+    if err != nil {
+        s.logger.Error("failed to get wallet settings", err, "walletID", walletID)
+        return models.WalletNotificationPreferences{LowBalance: true, CreditLimitWarning: true}
+    }
+    return settings.Notifications
+}
+
+// ProcessTransaction handles wallet transaction with comprehensive validation.
+// Debits leaving the wallet's credit limit close to exhausted return a warning,
+// and crossing a warning level also notifies the customer.
+func (s *walletService) ProcessTransaction(ctx context.Context, tx *models.Transaction) (*models.CreditLimitWarning, error) {
     if tx == nil {
-        return errors.New("transaction is required")
+        return nil, errors.New("transaction is required")
     }
 
     // Validate transaction data
     if err := tx.Validate(); err != nil {
         s.logger.Error("invalid transaction", err, "transactionID", tx.ID)
-        return fmt.Errorf("transaction validation failed: %w", err)
+        return nil, fmt.Errorf("transaction validation failed: %w", err)
     }
 
-    // Get wallet for validation and processing
-    wallet, err := s.repo.GetWallet(ctx, tx.WalletID)
+    // Round the amount to the precision of its currency in the currency's
+    // rounding mode, refusing amounts that round away to nothing. The
+    // amount is stored in minor units as well.
+    if !s.currencies.Supported(tx.Currency) {
+        return nil, ErrUnsupportedCurrency
+    }
+    minor, err := s.currencies.ToMinorUnits(tx.Currency, decimal.NewFromFloat(tx.Amount))
     if err != nil {
-        if errors.Is(err, repository.ErrWalletNotFound) {
-            return ErrWalletNotFound
-        }
-        s.logger.Error("failed to get wallet", err, "walletID", tx.WalletID)
-        return fmt.Errorf("failed to get wallet: %w", err)
+        return nil, fmt.Errorf("%w: %v", ErrInvalidAmount, err)
     }
-
-    // Validate currency match
-    if wallet.Currency != tx.Currency {
-        s.logger.Error("currency mismatch", nil,
-            "walletCurrency", wallet.Currency,
-            "transactionCurrency", tx.Currency)
-        return ErrCurrencyMismatch
+    if minor.Units <= 0 {
+        return nil, ErrInvalidAmount
     }
+    tx.Minor = minor
+    tx.Amount, _ = minor.Decimal().Float64()
 
-    // Validate sufficient balance for debit transactions
-    if tx.Type == models.TransactionTypeDebit && !wallet.HasSufficientBalance(tx.Amount) {
-        s.logger.Warn("insufficient balance",
-            "walletID", wallet.ID,
-            "balance", wallet.Balance,
-            "requestedAmount", tx.Amount)
-        return ErrInsufficientBalance
+    // Backdated transactions may not post ahead of time
+    if tx.EffectiveAt != nil && tx.EffectiveAt.After(time.Now()) {
+        return nil, ErrFutureEffectiveDate
     }
 
-    // Process transaction with optimistic locking
-    err = s.repo.UpdateBalance(ctx, tx)
+    // Validate, apply and record the transaction in one unit of work. The
+    // wallet stays locked, so the checks still hold when it commits.
+    var before, after models.Wallet
+    err = s.repo.InTx(ctx, func(uow repository.WalletTx) error {
+        locked, err := uow.GetWalletForUpdate(ctx, tx.WalletID)
+        if err != nil {
+            return err
+        }
+        before = *locked
+
+        // Validate currency match
+        if locked.Currency != tx.Currency {
+            s.logger.Error("currency mismatch", nil,
+                "walletCurrency", locked.Currency,
+                "transactionCurrency", tx.Currency)
+            return ErrCurrencyMismatch
+        }
+
+        // Validate sufficient balance for debits and decreasing adjustments
+        if tx.SignedAmount() < 0 && !locked.HasSufficientMinorBalance(tx.Minor) {
+            s.logger.Warn("insufficient balance",
+                "walletID", locked.ID,
+                "balance", locked.Balance,
+                "requestedAmount", tx.Amount)
+            return ErrInsufficientBalance
+        }
+
+        if err := uow.ApplyTransaction(ctx, locked, tx); err != nil {
+            return err
+        }
+        after = *locked
+        return uow.InsertTransaction(ctx, tx)
+    })
     if err != nil {
+        if errors.Is(err, ErrCurrencyMismatch) || errors.Is(err, ErrInsufficientBalance) {
+            return nil, err
+        }
+        if errors.Is(err, repository.ErrWalletNotFound) {
+            return nil, ErrWalletNotFound
+        }
         if errors.Is(err, repository.ErrOptimisticLock) {
             s.logger.Warn("concurrent modification detected",
-                "walletID", wallet.ID,
+                "walletID", tx.WalletID,
+                "transactionID", tx.ID)
+            return nil, ErrOptimisticLock
+        }
+        if errors.Is(err, repository.ErrPeriodClosed) {
+            s.logger.Warn("transaction refused in closed billing period",
+                "walletID", tx.WalletID,
+                "transactionID", tx.ID,
+                "effectiveAt", tx.EffectiveTime())
+            return nil, ErrPeriodClosed
+        }
+        if errors.Is(err, repository.ErrWalletFrozen) {
+            s.logger.Warn("transaction refused on wallet frozen for migration",
+                "walletID", tx.WalletID,
+                "transactionID", tx.ID)
+            return nil, ErrWalletFrozen
+        }
+        if errors.Is(err, repository.ErrWalletMerged) {
+            s.logger.Warn("transaction refused on merged wallet",
+                "walletID", tx.WalletID,
                 "transactionID", tx.ID)
-            return ErrOptimisticLock
+            return nil, ErrWalletMerged
         }
         s.logger.Error("failed to process transaction", err,
-            "walletID", wallet.ID,
+            "walletID", tx.WalletID,
             "transactionID", tx.ID)
-        return fmt.Errorf("failed to process transaction: %w", err)
+        return nil, fmt.Errorf("failed to process transaction: %w", err)
     }
 
-    // Check for low balance condition after transaction
-    if wallet.IsLowBalance() {
+    // Check for low balance condition after transaction. Wallets without a
+    // threshold of their own use the service default.
+    threshold := s.lowBalanceThresholdOf(&after)
+    if after.Balance <= threshold {
         s.logger.Warn("low balance alert",
-            "walletID", wallet.ID,
-            "balance", wallet.Balance,
-            "threshold", wallet.LowBalanceThreshold)
-        // Additional low balance handling could be implemented here
+            "walletID", after.ID,
+            "balance", after.Balance,
+            "threshold", threshold)
     }
 
     s.logger.Info("transaction processed successfully",
         "transactionID", tx.ID,
-        "walletID", wallet.ID,
+        "walletID", after.ID,
         "type", tx.Type,
         "amount", tx.Amount)
 
-    return nil
+    publishWalletChanges(ctx, s.publisher, s.logger,
+        eventbus.TransactionStatusChanged{WalletID: tx.WalletID, TransactionID: tx.ID, Status: models.TransactionStatusCompleted},
+        eventbus.BalanceChanged{WalletID: tx.WalletID})
+
+    if tx.SignedAmount() >= 0 {
+        return nil, nil
+    }
+
+    previous := before.CreditLimitWarning()
+    after.LowBalanceThreshold = threshold
+    warning := after.CreditLimitWarning()
+    warned := warning != nil && (previous == nil || warning.Level > previous.Level)
+    if warned {
+        s.logger.Warn("credit limit warning",
+            "walletID", after.ID,
+            "level", warning.Level,
+            "utilization", warning.Utilization)
+    }
+
+    // Alert the customer as the balance crosses the threshold or a warning
+    // level, unless they turned the alert off in the wallet settings
+    crossedThreshold := before.Balance > threshold && after.Balance <= threshold
+    if crossedThreshold || warned {
+        notify := s.notificationPreferences(ctx, after.ID)
+        if crossedThreshold && notify.LowBalance {
+            publishWalletChanges(ctx, s.publisher, s.logger, eventbus.LowBalance{Wallet: &after})
+        }
+        if warned && notify.CreditLimitWarning {
+            publishWalletChanges(ctx, s.publisher, s.logger,
+                eventbus.CreditLimitWarning{Wallet: &after, Warning: warning})
+        }
+    }
+
+    return warning, nil
 }
 
-// GetTransactionHistory retrieves paginated and filtered transaction history
+// publishWalletChanges announces changes of a wallet's state. The changes
+// are already committed, so failures are logged rather than returned.
+func publishWalletChanges(ctx context.Context, publisher eventbus.Publisher, logger Logger, events ...eventbus.Event) {
+    for _, event := range events {
+        if err := publisher.Publish(ctx, event); err != nil {
+            logger.Error("failed to publish wallet change", err, "event", event.EventType())
+        }
+    }
+}
+
+// GetTransactionHistory retrieves paginated and filtered transaction history.
+// Without a start date only the transactions of the query horizon are read;
+// older history needs a from date, which bounds the partitions scanned.
 func (s *walletService) GetTransactionHistory(ctx context.Context, walletID uuid.UUID, filter TransactionFilter, pagination Pagination) ([]*models.Transaction, int, error) {
     if walletID == uuid.Nil {
-        return nil, 0, errors.New("invalid wallet ID")
+        return nil, 0, ErrInvalidWalletID
     }
 
     // Validate pagination parameters
@@ -192,69 +691,33 @@ func (s *walletService) GetTransactionHistory(ctx context.Context, walletID uuid
 
     // Validate date range if provided
     if !filter.FromDate.IsZero() && !filter.ToDate.IsZero() && filter.FromDate.After(filter.ToDate) {
-        return nil, 0, errors.New("invalid date range")
+        return nil, 0, ErrInvalidDateRange
     }
 
-    transactions, err := s.repo.GetTransactions(ctx, walletID, pagination.Limit, pagination.Offset)
+    // Backdated transactions are created after their effective date, so
+    // those effective from a date were created since then too. Without one,
+    // history goes back as far as the query horizon.
+    transactions, total, err := s.repo.FindTransactions(ctx, walletID, repository.TransactionFilter{
+        Since:         filter.FromDate,
+        Horizon:       s.transactionQueryHorizon(),
+        Types:         filter.Types,
+        Statuses:      filter.Statuses,
+        EffectiveFrom: filter.FromDate,
+        EffectiveTo:   filter.ToDate,
+        MetadataKey:   filter.MetadataKey,
+        MetadataValue: filter.MetadataValue,
+    }, pagination.Limit, pagination.Offset)
     if err != nil {
         s.logger.Error("failed to get transactions", err, "walletID", walletID)
         return nil, 0, fmt.Errorf("failed to get transactions: %w", err)
     }
 
-    // Apply filters
-    var filtered []*models.Transaction
-    for _, tx := range transactions {
-        if s.matchesFilter(tx, filter) {
-            filtered = append(filtered, tx)
-        }
-    }
-
     s.logger.Info("transaction history retrieved",
         "walletID", walletID,
-        "count", len(filtered),
+        "count", len(transactions),
+        "total", total,
         "limit", pagination.Limit,
         "offset", pagination.Offset)
 
-    return filtered, len(filtered), nil
-}
-
-// matchesFilter checks if a transaction matches the provided filter criteria
-func (s *walletService) matchesFilter(tx *models.Transaction, filter TransactionFilter) bool {
-    // Check transaction type
-    if len(filter.Types) > 0 {
-        typeMatch := false
-        for _, t := range filter.Types {
-            if tx.Type == t {
-                typeMatch = true
-                break
-            }
-        }
-        if !typeMatch {
-            return false
-        }
-    }
-
-    // Check transaction status
-    if len(filter.Statuses) > 0 {
-        statusMatch := false
-        for _, s := range filter.Statuses {
-            if tx.Status == s {
-                statusMatch = true
-                break
-            }
-        }
-        if !statusMatch {
-            return false
-        }
-    }
-
-    // Check date range
-    if !filter.FromDate.IsZero() && tx.CreatedAt.Before(filter.FromDate) {
-        return false
-    }
-    if !filter.ToDate.IsZero() && tx.CreatedAt.After(filter.ToDate) {
-        return false
-    }
-
-    return true
+    return transactions, total, nil
 }
\ No newline at end of file
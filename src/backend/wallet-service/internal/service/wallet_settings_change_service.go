@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/currency"
+	"internal/models"
+	"internal/repository"
+)
+
+// Wallet settings change listing bounds
+const (
+	defaultSettingsChangeLimit = 50
+	maxSettingsChangeLimit     = 500
+)
+
+// Wallet settings change audit actions
+const (
+	settingsChangeRequestAction = "wallet_settings.request"
+	settingsChangeApproveAction = "wallet_settings.approve"
+	settingsChangeRejectAction  = "wallet_settings.reject"
+)
+
+// Wallet settings change errors
+var (
+	ErrInvalidSettingsChange  = errors.New("invalid wallet settings change")
+	ErrSettingsChangeNotFound = errors.New("wallet settings change not found")
+	ErrSettingsChangeConflict = errors.New("wallet settings change is not in a state allowing this request")
+	// ErrApprovalRequired is returned for settings changes that must be
+	// staged for approval by a second operator instead of applied
+	ErrApprovalRequired = errors.New("change needs approval by a second operator")
+)
+
+// WalletSettingsChangeService defines the interface for staging sensitive
+// wallet settings changes and reviewing them
+type WalletSettingsChangeService interface {
+	// RequestCreditLimit stages an increase of a wallet's credit limit
+	RequestCreditLimit(ctx context.Context, walletID uuid.UUID, creditLimit decimal.Decimal, actor, reason string) (*models.WalletSettingsChange, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.WalletSettingsChange, error)
+	List(ctx context.Context, filter models.WalletSettingsChangeFilter) ([]*models.WalletSettingsChange, error)
+	Approve(ctx context.Context, id uuid.UUID, actor, note string) (*models.WalletSettingsChange, error)
+	Reject(ctx context.Context, id uuid.UUID, actor, note string) (*models.WalletSettingsChange, error)
+}
+
+// walletSettingsChangeService implements WalletSettingsChangeService
+// interface. Approved changes are applied through the unwrapped wallet
+// service, and every request and review is kept in the operator audit log
+// with the settings it moves.
+type walletSettingsChangeService struct {
+	repo       repository.WalletSettingsChangeRepository
+	wallets    WalletService
+	audit      repository.AuditRepository
+	currencies *currency.Registry
+	logger     Logger
+}
+
+// NewWalletSettingsChangeService creates a new instance of
+// WalletSettingsChangeService
+func NewWalletSettingsChangeService(repo repository.WalletSettingsChangeRepository, wallets WalletService, audit repository.AuditRepository,
+	currencies *currency.Registry, logger Logger) (WalletSettingsChangeService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	if audit == nil {
+		return nil, errors.New("audit repository is required")
+	}
+	if currencies == nil {
+		return nil, errors.New("currency registry is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &walletSettingsChangeService{
+		repo:       repo,
+		wallets:    wallets,
+		audit:      audit,
+		currencies: currencies,
+		logger:     logger,
+	}, nil
+}
+
+// RequestCreditLimit stages raising a wallet's credit limit, rounded to the
+// wallet currency, for approval by another operator. Lowering the limit
+// needs no approval and is refused here.
+func (s *walletSettingsChangeService) RequestCreditLimit(ctx context.Context, walletID uuid.UUID, creditLimit decimal.Decimal, actor, reason string) (*models.WalletSettingsChange, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a reason is required", ErrInvalidSettingsChange)
+	}
+	if creditLimit.IsNegative() || creditLimit.GreaterThan(decimal.NewFromFloat(models.MaxTransactionAmount)) {
+		return nil, fmt.Errorf("%w: credit_limit must be between 0 and %.2f", ErrInvalidSettingsChange, models.MaxTransactionAmount)
+	}
+
+	wallet, err := s.wallets.GetWallet(repository.ReadPrimary(ctx), walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := decimal.NewFromFloat(wallet.CreditLimit)
+	to := s.currencies.Round(wallet.Currency, creditLimit)
+	if !to.GreaterThan(from) {
+		return nil, fmt.Errorf("%w: credit_limit must be above the current %s; lower limits apply without approval", ErrInvalidSettingsChange, from)
+	}
+
+	change := &models.WalletSettingsChange{
+		ID:          uuid.New(),
+		WalletID:    wallet.ID,
+		Status:      models.WalletSettingsChangePending,
+		Diff:        []models.SettingDiff{{Field: models.SettingCreditLimit, From: from, To: to}},
+		Reason:      reason,
+		RequestedBy: actor,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := s.repo.CreateSettingsChange(ctx, change); err != nil {
+		if !errors.Is(err, repository.ErrSettingsChangeConflict) {
+			s.logger.Error("failed to create settings change", err, "walletID", wallet.ID)
+		}
+		return nil, s.audited(ctx, settingsChangeRequestAction, actor, reason, change, s.mapError(err))
+	}
+
+	s.logger.Info("wallet settings change requested",
+		"changeID", change.ID,
+		"walletID", wallet.ID,
+		"actor", actor)
+
+	return change, s.audited(ctx, settingsChangeRequestAction, actor, reason, change, nil)
+}
+
+// Get returns a settings change
+func (s *walletSettingsChangeService) Get(ctx context.Context, id uuid.UUID) (*models.WalletSettingsChange, error) {
+	change, err := s.repo.GetSettingsChange(ctx, id)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	return change, nil
+}
+
+// List returns the latest settings changes matching the filter, newest
+// first
+func (s *walletSettingsChangeService) List(ctx context.Context, filter models.WalletSettingsChangeFilter) ([]*models.WalletSettingsChange, error) {
+	if filter.Limit == 0 {
+		filter.Limit = defaultSettingsChangeLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxSettingsChangeLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidSettingsChange, maxSettingsChangeLimit)
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidSettingsChange, filter.Status)
+	}
+
+	changes, err := s.repo.ListSettingsChanges(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settings changes: %w", err)
+	}
+	if changes == nil {
+		changes = []*models.WalletSettingsChange{}
+	}
+	return changes, nil
+}
+
+// Approve approves a pending change requested by another operator and
+// applies it. A change whose settings moved since it was requested must be
+// requested again, and a change the wallet refuses returns to review.
+func (s *walletSettingsChangeService) Approve(ctx context.Context, id uuid.UUID, actor, note string) (*models.WalletSettingsChange, error) {
+	change, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if change.RequestedBy == actor {
+		return nil, s.audited(ctx, settingsChangeApproveAction, actor, note, change, ErrSelfApproval)
+	}
+
+	applied, err := s.apply(ctx, change, actor, note)
+	if err != nil {
+		return nil, s.audited(ctx, settingsChangeApproveAction, actor, note, change, err)
+	}
+	return applied, s.audited(ctx, settingsChangeApproveAction, actor, note, applied, nil)
+}
+
+// apply approves a pending change on behalf of the reviewer and applies it
+// to the wallet
+func (s *walletSettingsChangeService) apply(ctx context.Context, change *models.WalletSettingsChange, actor, note string) (*models.WalletSettingsChange, error) {
+	wallet, err := s.wallets.GetWallet(repository.ReadPrimary(ctx), change.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	for _, diff := range change.Diff {
+		if diff.Field == models.SettingCreditLimit && !decimal.NewFromFloat(wallet.CreditLimit).Equal(diff.From) {
+			return nil, fmt.Errorf("%w: the credit limit changed since the request", ErrSettingsChangeConflict)
+		}
+	}
+
+	now := time.Now().UTC()
+	if _, err := s.review(ctx, change.ID, models.WalletSettingsChangeApproved, actor, note, now); err != nil {
+		return nil, err
+	}
+
+	for _, diff := range change.Diff {
+		if diff.Field != models.SettingCreditLimit {
+			continue
+		}
+		if _, err := s.wallets.UpdateCreditLimit(ctx, change.WalletID, diff.To); err != nil {
+			if releaseErr := s.repo.ReleaseSettingsChange(ctx, change.ID); releaseErr != nil {
+				s.logger.Error("failed to release settings change", releaseErr, "changeID", change.ID)
+			}
+			return nil, err
+		}
+	}
+
+	applied, err := s.repo.MarkSettingsChangeApplied(ctx, change.ID, now)
+	if err != nil {
+		// The settings stand; the change records their approval already
+		s.logger.Error("failed to mark settings change applied", err, "changeID", change.ID)
+		return nil, s.mapError(err)
+	}
+
+	s.logger.Info("wallet settings change applied",
+		"changeID", change.ID,
+		"walletID", change.WalletID,
+		"actor", actor)
+
+	return applied, nil
+}
+
+// Reject rejects a pending change. Requesters may withdraw their own
+// changes.
+func (s *walletSettingsChangeService) Reject(ctx context.Context, id uuid.UUID, actor, note string) (*models.WalletSettingsChange, error) {
+	if strings.TrimSpace(note) == "" {
+		return nil, fmt.Errorf("%w: a note is required", ErrInvalidSettingsChange)
+	}
+
+	change, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rejected, err := s.review(ctx, id, models.WalletSettingsChangeRejected, actor, note, time.Now().UTC())
+	if err != nil {
+		return nil, s.audited(ctx, settingsChangeRejectAction, actor, note, change, err)
+	}
+	return rejected, s.audited(ctx, settingsChangeRejectAction, actor, note, rejected, nil)
+}
+
+// review records the outcome of the review of a pending change
+func (s *walletSettingsChangeService) review(ctx context.Context, id uuid.UUID, status models.WalletSettingsChangeStatus, actor, note string, now time.Time) (*models.WalletSettingsChange, error) {
+	change, err := s.repo.ReviewSettingsChange(ctx, id, status, actor, note, now)
+	if err != nil {
+		if !errors.Is(err, repository.ErrSettingsChangeConflict) && !errors.Is(err, repository.ErrSettingsChangeNotFound) {
+			s.logger.Error("failed to review settings change", err, "changeID", id)
+		}
+		return nil, s.mapError(err)
+	}
+
+	s.logger.Info("wallet settings change reviewed",
+		"changeID", id,
+		"status", status,
+		"actor", actor)
+
+	return change, nil
+}
+
+// audited records a settings change's request or review in the operator
+// audit log regardless of whether it succeeded, with the settings it moves
+// as <field>.from and <field>.to
+func (s *walletSettingsChangeService) audited(ctx context.Context, action, actor, note string, change *models.WalletSettingsChange, err error) error {
+	params := map[string]string{
+		"change_id": change.ID.String(),
+		"wallet_id": change.WalletID.String(),
+		"status":    string(change.Status),
+	}
+	for _, diff := range change.Diff {
+		params[diff.Field+".from"] = diff.From.String()
+		params[diff.Field+".to"] = diff.To.String()
+	}
+
+	entry := &models.OperatorAction{
+		Action: action,
+		Actor:  actor,
+		Reason: note,
+		Params: params,
+		Status: models.OperatorActionSucceeded,
+	}
+	if err != nil {
+		entry.Status = models.OperatorActionFailed
+		entry.Error = err.Error()
+	}
+
+	if auditErr := s.audit.RecordOperatorAction(ctx, entry); auditErr != nil {
+		s.logger.Error("failed to audit settings change", auditErr, "action", action, "changeID", change.ID)
+		if err == nil {
+			err = fmt.Errorf("%s executed but audit record failed: %w", action, auditErr)
+		}
+	}
+	return err
+}
+
+// mapError maps repository errors to service errors
+func (s *walletSettingsChangeService) mapError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrSettingsChangeNotFound):
+		return ErrSettingsChangeNotFound
+	case errors.Is(err, repository.ErrSettingsChangeConflict):
+		return ErrSettingsChangeConflict
+	default:
+		return fmt.Errorf("settings change failed: %w", err)
+	}
+}
+
+// settingsApprovalWalletService refuses settings changes that must be
+// staged for approval
+type settingsApprovalWalletService struct {
+	WalletService
+}
+
+// NewSettingsApprovalWalletService wraps wallets so that credit limits can
+// only be lowered directly. Raising one lets the balance go further
+// negative, so it is staged through WalletSettingsChangeService and applied
+// once a second operator approved it.
+func NewSettingsApprovalWalletService(wallets WalletService) (WalletService, error) {
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+	return &settingsApprovalWalletService{WalletService: wallets}, nil
+}
+
+// UpdateCreditLimit refuses raising the credit limit
+func (s *settingsApprovalWalletService) UpdateCreditLimit(ctx context.Context, walletID uuid.UUID, creditLimit decimal.Decimal) (*models.Wallet, error) {
+	if err := s.checkCreditLimit(ctx, walletID, creditLimit); err != nil {
+		return nil, err
+	}
+	return s.WalletService.UpdateCreditLimit(ctx, walletID, creditLimit)
+}
+
+// UpdateWalletSettings refuses updates raising the credit limit
+func (s *settingsApprovalWalletService) UpdateWalletSettings(ctx context.Context, walletID uuid.UUID, update WalletSettingsUpdate) (*models.WalletSettings, error) {
+	if update.CreditLimit != nil {
+		if err := s.checkCreditLimit(ctx, walletID, *update.CreditLimit); err != nil {
+			return nil, err
+		}
+	}
+	return s.WalletService.UpdateWalletSettings(ctx, walletID, update)
+}
+
+// checkCreditLimit returns ErrApprovalRequired for a limit above the
+// wallet's current one
+func (s *settingsApprovalWalletService) checkCreditLimit(ctx context.Context, walletID uuid.UUID, creditLimit decimal.Decimal) error {
+	wallet, err := s.GetWallet(repository.ReadPrimary(ctx), walletID)
+	if err != nil {
+		return err
+	}
+	if creditLimit.GreaterThan(decimal.NewFromFloat(wallet.CreditLimit)) {
+		return fmt.Errorf("%w: credit limit increases are requested as settings changes", ErrApprovalRequired)
+	}
+	return nil
+}
@@ -0,0 +1,509 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/events"
+	"internal/models"
+	"internal/repository"
+	"internal/webhook"
+)
+
+// webhookSecretBytes is the entropy of generated webhook signing secrets
+const webhookSecretBytes = 32
+
+// Webhook delivery log page sizes
+const (
+	defaultWebhookDeliveries = 50
+	maxWebhookDeliveries     = 200
+)
+
+// Webhook subscription errors
+var (
+	ErrWebhookNotFound = errors.New("webhook subscription not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook subscription")
+)
+
+// WebhookInput holds the customer-editable fields of a webhook subscription
+type WebhookInput struct {
+	URL        string
+	EventTypes []string
+	WalletIDs  []uuid.UUID
+	Fields     []string
+	Enabled    bool
+}
+
+// WebhookService defines the interface for customer webhooks
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, customerID uuid.UUID, input WebhookInput) (*models.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, customerID, id uuid.UUID, input WebhookInput) (*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, customerID, id uuid.UUID) error
+	GetSubscription(ctx context.Context, customerID, id uuid.UUID) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, customerID uuid.UUID) ([]*models.WebhookSubscription, error)
+	ListDeliveries(ctx context.Context, customerID, id uuid.UUID, limit int) ([]*models.WebhookDelivery, error)
+	CreateWalletSubscription(ctx context.Context, customerID, walletID uuid.UUID, input WebhookInput) (*models.WebhookSubscription, error)
+	UpdateWalletSubscription(ctx context.Context, walletID, id uuid.UUID, input WebhookInput) (*models.WebhookSubscription, error)
+	DeleteWalletSubscription(ctx context.Context, walletID, id uuid.UUID) error
+	GetWalletSubscription(ctx context.Context, walletID, id uuid.UUID) (*models.WebhookSubscription, error)
+	ListWalletSubscriptions(ctx context.Context, walletID uuid.UUID) ([]*models.WebhookSubscription, error)
+	ListWalletDeliveries(ctx context.Context, walletID, id uuid.UUID, limit int) ([]*models.WebhookDelivery, error)
+	Deliver(ctx context.Context, env *events.Envelope) error
+	// RetryDelivery repeats a failed delivery queued as a failed job
+	RetryDelivery(ctx context.Context, job *models.FailedJob) error
+	RunDispatcher(ctx context.Context, consumer *events.StreamConsumer)
+}
+
+// webhookService implements WebhookService interface
+type webhookService struct {
+	repo     repository.WebhookRepository
+	registry *events.Registry
+	sender   *webhook.Sender
+	failures FailedJobRecorder
+	logger   Logger
+}
+
+// webhookRetry is the failed job payload of a webhook delivery
+type webhookRetry struct {
+	SubscriptionID uuid.UUID        `json:"subscription_id"`
+	CustomerID     uuid.UUID        `json:"customer_id"`
+	WalletID       *uuid.UUID       `json:"wallet_id,omitempty"`
+	Event          *events.Envelope `json:"event"`
+}
+
+// NewWebhookService creates a new instance of WebhookService queueing failed
+// deliveries for retries with failures
+func NewWebhookService(repo repository.WebhookRepository, registry *events.Registry, sender *webhook.Sender, failures FailedJobRecorder, logger Logger) (WebhookService, error) {
+	if repo == nil {
+		return nil, errors.New("repository is required")
+	}
+	if registry == nil {
+		return nil, errors.New("schema registry is required")
+	}
+	if sender == nil {
+		return nil, errors.New("sender is required")
+	}
+	if failures == nil {
+		return nil, errors.New("failed job recorder is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &webhookService{
+		repo:     repo,
+		registry: registry,
+		sender:   sender,
+		failures: failures,
+		logger:   logger,
+	}, nil
+}
+
+// CreateSubscription registers a webhook endpoint for the customer. The
+// generated signing secret is only returned here.
+func (s *webhookService) CreateSubscription(ctx context.Context, customerID uuid.UUID, input WebhookInput) (*models.WebhookSubscription, error) {
+	if err := s.validate(input); err != nil {
+		return nil, err
+	}
+
+	return s.create(ctx, newSubscription(customerID, input))
+}
+
+// CreateWalletSubscription registers a webhook endpoint receiving only the
+// events of one wallet, with its own signing secret. The generated secret is
+// only returned here.
+func (s *webhookService) CreateWalletSubscription(ctx context.Context, customerID, walletID uuid.UUID, input WebhookInput) (*models.WebhookSubscription, error) {
+	if err := s.validateWallet(input); err != nil {
+		return nil, err
+	}
+
+	sub := newSubscription(customerID, input)
+	sub.WalletID = &walletID
+	return s.create(ctx, sub)
+}
+
+// create generates a signing secret for a new subscription and stores it
+func (s *webhookService) create(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	secret := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	sub.Secret = "whsec_" + hex.EncodeToString(secret)
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		if errors.Is(err, repository.ErrWalletNotFound) {
+			return nil, ErrWalletNotFound
+		}
+		s.logger.Error("failed to create webhook subscription", err, "customerID", sub.CustomerID)
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	s.logger.Info("webhook subscription created",
+		"customerID", sub.CustomerID,
+		"walletID", sub.WalletID,
+		"subscriptionID", sub.ID,
+		"eventTypes", sub.EventTypes)
+
+	return sub, nil
+}
+
+// UpdateSubscription replaces the endpoint and rules of a subscription
+func (s *webhookService) UpdateSubscription(ctx context.Context, customerID, id uuid.UUID, input WebhookInput) (*models.WebhookSubscription, error) {
+	if err := s.validate(input); err != nil {
+		return nil, err
+	}
+
+	sub := newSubscription(customerID, input)
+	sub.ID = id
+	return s.update(ctx, sub)
+}
+
+// UpdateWalletSubscription replaces the endpoint and rules of a wallet's
+// subscription
+func (s *webhookService) UpdateWalletSubscription(ctx context.Context, walletID, id uuid.UUID, input WebhookInput) (*models.WebhookSubscription, error) {
+	if err := s.validateWallet(input); err != nil {
+		return nil, err
+	}
+
+	sub := newSubscription(uuid.Nil, input)
+	sub.ID = id
+	sub.WalletID = &walletID
+	return s.update(ctx, sub)
+}
+
+// update stores the replaced rules of a subscription
+func (s *webhookService) update(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		s.logger.Error("failed to update webhook subscription", err, "subscriptionID", sub.ID)
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+
+	sub.Secret = ""
+	return sub, nil
+}
+
+// DeleteSubscription removes a subscription
+func (s *webhookService) DeleteSubscription(ctx context.Context, customerID, id uuid.UUID) error {
+	if err := s.repo.DeleteSubscription(ctx, customerID, id); err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			return ErrWebhookNotFound
+		}
+		s.logger.Error("failed to delete webhook subscription", err, "subscriptionID", id)
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteWalletSubscription removes a wallet's subscription
+func (s *webhookService) DeleteWalletSubscription(ctx context.Context, walletID, id uuid.UUID) error {
+	if err := s.repo.DeleteWalletSubscription(ctx, walletID, id); err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			return ErrWebhookNotFound
+		}
+		s.logger.Error("failed to delete webhook subscription", err, "subscriptionID", id)
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+// GetSubscription returns a subscription without its signing secret
+func (s *webhookService) GetSubscription(ctx context.Context, customerID, id uuid.UUID) (*models.WebhookSubscription, error) {
+	return s.get(id, func() (*models.WebhookSubscription, error) {
+		return s.repo.GetSubscription(ctx, customerID, id)
+	})
+}
+
+// GetWalletSubscription returns a wallet's subscription without its signing
+// secret
+func (s *webhookService) GetWalletSubscription(ctx context.Context, walletID, id uuid.UUID) (*models.WebhookSubscription, error) {
+	return s.get(id, func() (*models.WebhookSubscription, error) {
+		return s.repo.GetWalletSubscription(ctx, walletID, id)
+	})
+}
+
+// get loads a subscription and strips its signing secret
+func (s *webhookService) get(id uuid.UUID, load func() (*models.WebhookSubscription, error)) (*models.WebhookSubscription, error) {
+	sub, err := load()
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		s.logger.Error("failed to get webhook subscription", err, "subscriptionID", id)
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	sub.Secret = ""
+	return sub, nil
+}
+
+// ListSubscriptions returns the customer's subscriptions without their secrets
+func (s *webhookService) ListSubscriptions(ctx context.Context, customerID uuid.UUID) ([]*models.WebhookSubscription, error) {
+	subs, err := s.repo.ListSubscriptions(ctx, customerID)
+	if err != nil {
+		s.logger.Error("failed to list webhook subscriptions", err, "customerID", customerID)
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+	return subs, nil
+}
+
+// ListWalletSubscriptions returns a wallet's subscriptions without their
+// secrets
+func (s *webhookService) ListWalletSubscriptions(ctx context.Context, walletID uuid.UUID) ([]*models.WebhookSubscription, error) {
+	subs, err := s.repo.ListWalletSubscriptions(ctx, walletID)
+	if err != nil {
+		s.logger.Error("failed to list webhook subscriptions", err, "walletID", walletID)
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+	return subs, nil
+}
+
+// ListDeliveries returns the most recent delivery attempts of one of the
+// customer's subscriptions, newest first
+func (s *webhookService) ListDeliveries(ctx context.Context, customerID, id uuid.UUID, limit int) ([]*models.WebhookDelivery, error) {
+	if _, err := s.GetSubscription(ctx, customerID, id); err != nil {
+		return nil, err
+	}
+	return s.deliveries(ctx, id, limit)
+}
+
+// ListWalletDeliveries returns the most recent delivery attempts of one of a
+// wallet's subscriptions, newest first
+func (s *webhookService) ListWalletDeliveries(ctx context.Context, walletID, id uuid.UUID, limit int) ([]*models.WebhookDelivery, error) {
+	if _, err := s.GetWalletSubscription(ctx, walletID, id); err != nil {
+		return nil, err
+	}
+	return s.deliveries(ctx, id, limit)
+}
+
+// deliveries loads a subscription's delivery log, bounding the page size
+func (s *webhookService) deliveries(ctx context.Context, id uuid.UUID, limit int) ([]*models.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = defaultWebhookDeliveries
+	}
+	if limit > maxWebhookDeliveries {
+		limit = maxWebhookDeliveries
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, id, limit)
+	if err != nil {
+		s.logger.Error("failed to list webhook deliveries", err, "subscriptionID", id)
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	if deliveries == nil {
+		deliveries = []*models.WebhookDelivery{}
+	}
+	return deliveries, nil
+}
+
+// Deliver sends an event to every subscription of the wallet, its owner or
+// the customer whose filters it passes, trimmed to the subscription's selected
+// fields. Delivery failures are recorded rather than returned; an error means
+// the subscriptions could not be loaded and the event should be retried.
+func (s *webhookService) Deliver(ctx context.Context, env *events.Envelope) error {
+	subject, err := webhook.SubjectOf(env.Payload)
+	if err != nil {
+		s.logger.Warn("skipping webhook delivery of malformed event", "eventID", env.ID, "error", err)
+		return nil
+	}
+
+	var subs []*models.WebhookSubscription
+	switch {
+	case subject.WalletID != uuid.Nil:
+		subs, err = s.repo.ListActiveForWallet(ctx, subject.WalletID)
+	case subject.CustomerID != uuid.Nil:
+		subs, err = s.repo.ListActiveForCustomer(ctx, subject.CustomerID)
+	default:
+		return nil
+	}
+	if err != nil {
+		s.logger.Error("failed to load webhook subscriptions", err, "eventID", env.ID)
+		return fmt.Errorf("failed to load webhook subscriptions: %w", err)
+	}
+
+	for _, sub := range subs {
+		if !webhook.Matches(sub, env.Type, subject.WalletID) {
+			continue
+		}
+		s.send(ctx, sub, env)
+	}
+
+	return nil
+}
+
+// send delivers one event to one subscription, queueing it for retries when
+// the endpoint does not accept it
+func (s *webhookService) send(ctx context.Context, sub *models.WebhookSubscription, env *events.Envelope) {
+	err := s.deliver(ctx, sub, env)
+	if err == nil {
+		return
+	}
+
+	retry := webhookRetry{SubscriptionID: sub.ID, CustomerID: sub.CustomerID, WalletID: sub.WalletID, Event: env}
+	if err := s.failures.RecordFailure(ctx, models.FailedJobWebhookDelivery, webhookRetryReference(sub.ID, env.ID), retry, err); err != nil {
+		s.logger.Error("failed to queue webhook delivery retry", err,
+			"subscriptionID", sub.ID,
+			"eventID", env.ID)
+	}
+}
+
+// deliver makes one attempt to deliver an event to a subscription and
+// records its outcome in the delivery log
+func (s *webhookService) deliver(ctx context.Context, sub *models.WebhookSubscription, env *events.Envelope) error {
+	delivery := &models.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventID:        env.ID,
+		EventType:      env.Type,
+		Status:         models.WebhookDelivered,
+		AttemptedAt:    time.Now().UTC(),
+	}
+
+	payload, err := webhook.SelectFields(env.Payload, sub.Fields)
+	if err == nil {
+		selected := *env
+		selected.Payload = payload
+		delivery.ResponseCode, err = s.sender.Send(ctx, sub.URL, sub.Secret, &selected)
+	}
+	if errors.Is(err, webhook.ErrSuppressed) {
+		// Suppressed sends are counted by the sender and leave no delivery log
+		return nil
+	}
+	if err != nil {
+		delivery.Status = models.WebhookFailed
+		delivery.Error = err.Error()
+		s.logger.Warn("webhook delivery failed",
+			"subscriptionID", sub.ID,
+			"eventID", env.ID,
+			"error", err)
+	}
+
+	if recordErr := s.repo.RecordDelivery(ctx, delivery); recordErr != nil {
+		s.logger.Error("failed to record webhook delivery", recordErr, "subscriptionID", sub.ID)
+	}
+	return err
+}
+
+// RetryDelivery delivers the event of a failed delivery again. Deliveries
+// to subscriptions since deleted or disabled no longer apply.
+func (s *webhookService) RetryDelivery(ctx context.Context, job *models.FailedJob) error {
+	var retry webhookRetry
+	if err := json.Unmarshal(job.Payload, &retry); err != nil || retry.Event == nil {
+		return fmt.Errorf("%w: malformed webhook delivery", ErrFailedJobObsolete)
+	}
+
+	var sub *models.WebhookSubscription
+	var err error
+	if retry.WalletID != nil {
+		sub, err = s.repo.GetWalletSubscription(ctx, *retry.WalletID, retry.SubscriptionID)
+	} else {
+		sub, err = s.repo.GetSubscription(ctx, retry.CustomerID, retry.SubscriptionID)
+	}
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		return fmt.Errorf("%w: webhook subscription deleted", ErrFailedJobObsolete)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load webhook subscription: %w", err)
+	}
+	if !sub.Enabled {
+		return fmt.Errorf("%w: webhook subscription disabled", ErrFailedJobObsolete)
+	}
+
+	return s.deliver(ctx, sub, retry.Event)
+}
+
+// RunDispatcher delivers events read by the consumer until the context is
+// cancelled
+func (s *webhookService) RunDispatcher(ctx context.Context, consumer *events.StreamConsumer) {
+	for ctx.Err() == nil {
+		if _, err := consumer.Consume(ctx, s.Deliver); err != nil && ctx.Err() == nil {
+			s.logger.Error("webhook dispatch failed", err)
+			// Back off before retrying so an unavailable stream is not hammered
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+// webhookRetryReference identifies the delivery of an event to a
+// subscription among failed jobs
+func webhookRetryReference(subscriptionID, eventID uuid.UUID) string {
+	return subscriptionID.String() + "/" + eventID.String()
+}
+
+// validate checks a subscription's endpoint and rules
+func (s *webhookService) validate(input WebhookInput) error {
+	endpoint, err := url.Parse(input.URL)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute https URL", ErrInvalidWebhook)
+	}
+
+	for _, eventType := range input.EventTypes {
+		if _, err := s.registry.Latest(eventType); err != nil {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, eventType)
+		}
+	}
+
+	if len(input.WalletIDs) > webhook.MaxWalletScope {
+		return fmt.Errorf("%w: at most %d wallets may be scoped", ErrInvalidWebhook, webhook.MaxWalletScope)
+	}
+
+	if err := webhook.ValidateFields(input.Fields); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+
+	return nil
+}
+
+// validateWallet checks the endpoint and rules of a wallet-level
+// subscription, which is already scoped to its wallet
+func (s *webhookService) validateWallet(input WebhookInput) error {
+	if len(input.WalletIDs) > 0 {
+		return fmt.Errorf("%w: wallet_ids cannot be set on wallet webhooks", ErrInvalidWebhook)
+	}
+	return s.validate(input)
+}
+
+// newSubscription builds a subscription from customer input, normalizing
+// missing rules to empty lists
+func newSubscription(customerID uuid.UUID, input WebhookInput) *models.WebhookSubscription {
+	sub := &models.WebhookSubscription{
+		CustomerID: customerID,
+		URL:        input.URL,
+		EventTypes: input.EventTypes,
+		WalletIDs:  input.WalletIDs,
+		Fields:     input.Fields,
+		Enabled:    input.Enabled,
+	}
+	if sub.EventTypes == nil {
+		sub.EventTypes = []string{}
+	}
+	if sub.WalletIDs == nil {
+		sub.WalletIDs = []uuid.UUID{}
+	}
+	if sub.Fields == nil {
+		sub.Fields = []string{}
+	}
+	return sub
+}
@@ -0,0 +1,355 @@
+// Package slo tracks the service level objectives of API endpoints. Each
+// endpoint may have an availability objective, the fraction of requests not
+// failing with a server error, and a latency objective, the fraction of
+// requests served within a threshold. Burn rates, how fast each objective's
+// error budget is spent, are computed over the multiwindow alerting windows
+// and exported for dashboards and alert rules.
+package slo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+)
+
+// SLO metrics. The event counters are summed across replicas by the alert
+// rules; the burn rate gauges are this replica's view.
+var (
+	sloEvents = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_slo_events_total",
+			Help: "Requests counted against an objective, by SLO, objective and outcome (good or bad)",
+		},
+		[]string{"slo", "objective", "outcome"},
+	)
+	sloTarget = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wallet_slo_target",
+			Help: "Target fraction of good requests of an objective",
+		},
+		[]string{"slo", "objective"},
+	)
+	sloBurnRate = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wallet_slo_burn_rate",
+			Help: "Error budget burn rate of an objective over a window, as seen by this replica",
+		},
+		[]string{"slo", "objective", "window"},
+	)
+)
+
+// Objective is the kind of an SLO objective
+type Objective string
+
+const (
+	// ObjectiveAvailability counts requests failing with a 5xx status as bad
+	ObjectiveAvailability Objective = "availability"
+	// ObjectiveLatency counts requests slower than the threshold as bad
+	ObjectiveLatency Objective = "latency"
+)
+
+// Alert is a multiwindow burn rate alert: it fires when both windows burn
+// the error budget faster than BurnRate, the long window showing the burn
+// is significant and the short one that it is still going on
+type Alert struct {
+	Severity string
+	Long     time.Duration
+	Short    time.Duration
+	BurnRate float64
+}
+
+// Alerts are the burn rate alerts of every objective. A page spends 2% of a
+// 30-day budget in an hour, a ticket 5% in six hours.
+var Alerts = []Alert{
+	{Severity: "page", Long: time.Hour, Short: 5 * time.Minute, BurnRate: 14.4},
+	{Severity: "ticket", Long: 6 * time.Hour, Short: 30 * time.Minute, BurnRate: 6},
+}
+
+// Windows are the burn rate windows, shortest first
+var Windows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour}
+
+// bucketCount is the number of one-minute buckets kept, covering the
+// longest window
+const bucketCount = 6 * 60
+
+// Definition declares the objectives of an endpoint. A zero target leaves
+// the objective undefined.
+type Definition struct {
+	Name string
+	// Method and Route identify the endpoint by its router path, e.g.
+	// /api/v1/wallets/:id/transactions
+	Method string
+	Route  string
+	// Availability is the target fraction of requests without a 5xx status
+	Availability float64
+	// Latency is the target fraction of requests served within
+	// LatencyThreshold
+	Latency          float64
+	LatencyThreshold time.Duration
+}
+
+// Validate checks the targets of a definition
+func (d Definition) Validate() error {
+	if d.Name == "" || d.Method == "" || d.Route == "" {
+		return errors.New("name, method and route are required")
+	}
+	if d.Availability == 0 && d.Latency == 0 {
+		return fmt.Errorf("SLO %s has no objective", d.Name)
+	}
+	for _, target := range []float64{d.Availability, d.Latency} {
+		if target < 0 || target >= 1 {
+			return fmt.Errorf("SLO %s targets must be between 0 and 1", d.Name)
+		}
+	}
+	if d.Latency > 0 && d.LatencyThreshold <= 0 {
+		return fmt.Errorf("SLO %s latency objective requires a threshold", d.Name)
+	}
+	return nil
+}
+
+// objectives returns the objectives of a definition with their targets
+func (d Definition) objectives() map[Objective]float64 {
+	objectives := make(map[Objective]float64, 2)
+	if d.Availability > 0 {
+		objectives[ObjectiveAvailability] = d.Availability
+	}
+	if d.Latency > 0 {
+		objectives[ObjectiveLatency] = d.Latency
+	}
+	return objectives
+}
+
+// Status is the state of one objective of an SLO
+type Status struct {
+	SLO       string    `json:"slo"`
+	Method    string    `json:"method"`
+	Route     string    `json:"route"`
+	Objective Objective `json:"objective"`
+	Target    float64   `json:"target"`
+	// ThresholdMs is the latency threshold of latency objectives
+	ThresholdMs int64 `json:"threshold_ms,omitempty"`
+	// Compliance is the fraction of good requests over the longest window,
+	// 1 without requests
+	Compliance float64 `json:"compliance"`
+	// BurnRates holds the budget burn rate over each window, keyed like 5m
+	BurnRates map[string]float64 `json:"burn_rates"`
+	// Alerts lists the severities of the burn rate alerts firing
+	Alerts []string `json:"alerts"`
+}
+
+// bucket counts the requests of one minute
+type bucket struct {
+	minute int64
+	total  int64
+	failed int64
+	slow   int64
+}
+
+// series holds the recent requests of one SLO
+type series struct {
+	def     Definition
+	buckets [bucketCount]bucket
+}
+
+// Tracker records the requests of endpoints with SLOs and computes their
+// burn rates. It is safe for concurrent use.
+type Tracker struct {
+	mu      sync.Mutex
+	series  []*series
+	byRoute map[string]*series
+}
+
+// NewTracker creates a tracker for the given SLO definitions
+func NewTracker(defs []Definition) (*Tracker, error) {
+	t := &Tracker{byRoute: make(map[string]*series, len(defs))}
+
+	names := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if err := def.Validate(); err != nil {
+			return nil, err
+		}
+		if names[def.Name] {
+			return nil, fmt.Errorf("SLO %s is defined twice", def.Name)
+		}
+		key := routeKey(def.Method, def.Route)
+		if _, ok := t.byRoute[key]; ok {
+			return nil, fmt.Errorf("endpoint %s %s has several SLOs", def.Method, def.Route)
+		}
+		names[def.Name] = true
+
+		s := &series{def: def}
+		t.series = append(t.series, s)
+		t.byRoute[key] = s
+
+		for objective, target := range def.objectives() {
+			sloTarget.WithLabelValues(def.Name, string(objective)).Set(target)
+		}
+	}
+	sort.Slice(t.series, func(i, j int) bool { return t.series[i].def.Name < t.series[j].def.Name })
+
+	return t, nil
+}
+
+// Record counts a request to an endpoint. Requests to endpoints without an
+// SLO are ignored.
+func (t *Tracker) Record(method, route string, status int, latency time.Duration, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.byRoute[routeKey(method, route)]
+	if !ok {
+		return
+	}
+
+	minute := at.Unix() / 60
+	b := &s.buckets[minute%bucketCount]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+	b.total++
+
+	failed := status >= 500
+	slow := latency > s.def.LatencyThreshold
+	if failed {
+		b.failed++
+	}
+	if slow {
+		b.slow++
+	}
+
+	if s.def.Availability > 0 {
+		sloEvents.WithLabelValues(s.def.Name, string(ObjectiveAvailability), outcome(failed)).Inc()
+	}
+	if s.def.Latency > 0 {
+		sloEvents.WithLabelValues(s.def.Name, string(ObjectiveLatency), outcome(slow)).Inc()
+	}
+}
+
+// Status returns the state of every objective, sorted by SLO name, and
+// refreshes the burn rate gauges
+func (t *Tracker) Status(now time.Time) []Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var statuses []Status
+	for _, s := range t.series {
+		objectives := s.def.objectives()
+		for _, objective := range []Objective{ObjectiveAvailability, ObjectiveLatency} {
+			target, ok := objectives[objective]
+			if !ok {
+				continue
+			}
+			status := s.status(objective, target, now)
+			for window, rate := range status.BurnRates {
+				sloBurnRate.WithLabelValues(s.def.Name, string(objective), window).Set(rate)
+			}
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
+// Run refreshes the burn rate gauges at the given interval until the context
+// is cancelled, so they stay current when the status is not requested
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			t.Status(now)
+		}
+	}
+}
+
+// status computes the state of one objective of the series
+func (s *series) status(objective Objective, target float64, now time.Time) Status {
+	status := Status{
+		SLO:        s.def.Name,
+		Method:     s.def.Method,
+		Route:      s.def.Route,
+		Objective:  objective,
+		Target:     target,
+		Compliance: 1,
+		BurnRates:  make(map[string]float64, len(Windows)),
+		Alerts:     []string{},
+	}
+	if objective == ObjectiveLatency {
+		status.ThresholdMs = s.def.LatencyThreshold.Milliseconds()
+	}
+
+	burnRates := make(map[time.Duration]float64, len(Windows))
+	for _, window := range Windows {
+		total, bad := s.count(objective, window, now)
+		rate := 0.0
+		if total > 0 {
+			rate = float64(bad) / float64(total) / (1 - target)
+		}
+		burnRates[window] = rate
+		status.BurnRates[windowName(window)] = rate
+
+		if window == Windows[len(Windows)-1] && total > 0 {
+			status.Compliance = 1 - float64(bad)/float64(total)
+		}
+	}
+
+	for _, alert := range Alerts {
+		if burnRates[alert.Long] > alert.BurnRate && burnRates[alert.Short] > alert.BurnRate {
+			status.Alerts = append(status.Alerts, alert.Severity)
+		}
+	}
+
+	return status
+}
+
+// count returns the requests and bad requests of an objective within the
+// window ending now
+func (s *series) count(objective Objective, window time.Duration, now time.Time) (int64, int64) {
+	current := now.Unix() / 60
+	oldest := current - int64(window/time.Minute) + 1
+
+	var total, bad int64
+	for _, b := range s.buckets {
+		if b.minute < oldest || b.minute > current {
+			continue
+		}
+		total += b.total
+		if objective == ObjectiveAvailability {
+			bad += b.failed
+		} else {
+			bad += b.slow
+		}
+	}
+	return total, bad
+}
+
+// routeKey identifies an endpoint
+func routeKey(method, route string) string {
+	return method + " " + route
+}
+
+// outcome labels a request as good or bad for an objective
+func outcome(bad bool) string {
+	if bad {
+		return "bad"
+	}
+	return "good"
+}
+
+// windowName formats a window like 5m or 6h
+func windowName(window time.Duration) string {
+	if window%time.Hour == 0 {
+		return fmt.Sprintf("%dh", window/time.Hour)
+	}
+	return fmt.Sprintf("%dm", window/time.Minute)
+}
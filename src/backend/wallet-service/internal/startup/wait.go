@@ -0,0 +1,85 @@
+// Package startup waits for the dependencies of the service to become
+// reachable, so that the service survives starting before its database or
+// cache, as when a whole environment comes up at once
+package startup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Logger defines the logging used while waiting for dependencies
+type Logger interface {
+	Info(msg string, fields ...interface{})
+	Warn(msg string, fields ...interface{})
+}
+
+// Backoff holds the delays between connection attempts to a dependency
+type Backoff struct {
+	// Min is the delay before the second attempt, doubled for every further
+	// attempt up to Max
+	Min time.Duration
+	Max time.Duration
+	// AttemptTimeout bounds each attempt
+	AttemptTimeout time.Duration
+}
+
+// Validate checks that the delays make progress
+func (b Backoff) Validate() error {
+	if b.Min <= 0 || b.Max < b.Min {
+		return errors.New("minimum backoff must be positive and maximum backoff at least the minimum")
+	}
+	if b.AttemptTimeout <= 0 {
+		return errors.New("attempt timeout must be positive")
+	}
+	return nil
+}
+
+// Delay returns the delay after the given failed attempt, counted from one
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Min
+	for i := 1; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
+
+// Wait calls check until it succeeds, backing off between attempts, and
+// returns the last error once ctx is done before the dependency was reached
+func Wait(ctx context.Context, name string, backoff Backoff, check func(ctx context.Context) error, logger Logger) error {
+	if err := backoff.Validate(); err != nil {
+		return fmt.Errorf("invalid backoff for %s: %w", name, err)
+	}
+
+	started := time.Now()
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, backoff.AttemptTimeout)
+		err := check(attemptCtx)
+		cancel()
+		if err == nil {
+			if attempt > 1 {
+				logger.Info("dependency reachable", "dependency", name,
+					"attempts", attempt, "waited", time.Since(started).String())
+			}
+			return nil
+		}
+
+		delay := backoff.Delay(attempt)
+		logger.Warn("dependency unreachable, retrying", "dependency", name,
+			"attempt", attempt, "retry_in", delay.String(), "error", err.Error())
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s unreachable after %d attempts over %s: %w",
+				name, attempt, time.Since(started).Round(time.Millisecond), err)
+		case <-timer.C:
+		}
+	}
+}
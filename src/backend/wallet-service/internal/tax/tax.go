@@ -0,0 +1,179 @@
+// Package tax computes the sales taxes, such as GST and VAT, charged by the
+// rules of a customer's region, so invoices and debits carry tax lines that
+// finance can report on.
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+)
+
+// hundred converts percentage rates to fractions
+var hundred = decimal.NewFromInt(100)
+
+// ErrInvalidRule is returned for tax rules that cannot be applied
+var ErrInvalidRule = errors.New("invalid tax rule")
+
+// Component is a named rate of a tax, e.g. CGST of Indian GST
+type Component struct {
+	Name string
+	// Rate is a percentage, e.g. 9 for 9%
+	Rate decimal.Decimal
+}
+
+// Definition describes the tax of a region in configuration form
+type Definition struct {
+	// Name of the tax, e.g. GST or VAT
+	Name string
+	// Components split the tax into named rates charged together, e.g.
+	// CGST and SGST; a single-rate tax has one
+	Components []Component
+	// ExemptCategories are the categories of supplies charged no tax
+	ExemptCategories []string
+}
+
+// Rules are the tax rules of one region
+type Rules struct {
+	region     string
+	name       string
+	components []Component
+	exempt     map[string]bool
+}
+
+// New creates the rules of a region from a definition
+func New(region string, def Definition) (*Rules, error) {
+	if strings.TrimSpace(def.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+	if len(def.Components) == 0 {
+		return nil, fmt.Errorf("%w: at least one component is required", ErrInvalidRule)
+	}
+
+	r := &Rules{
+		region:     normalize(region),
+		name:       strings.TrimSpace(def.Name),
+		components: make([]Component, 0, len(def.Components)),
+		exempt:     make(map[string]bool, len(def.ExemptCategories)),
+	}
+	names := make(map[string]bool, len(def.Components))
+	for _, component := range def.Components {
+		name := strings.TrimSpace(component.Name)
+		if name == "" || names[strings.ToUpper(name)] {
+			return nil, fmt.Errorf("%w: component names must be set and unique", ErrInvalidRule)
+		}
+		if !component.Rate.IsPositive() || component.Rate.GreaterThan(hundred) {
+			return nil, fmt.Errorf("%w: rate of %s must be above 0 and at most 100 percent", ErrInvalidRule, name)
+		}
+		names[strings.ToUpper(name)] = true
+		r.components = append(r.components, Component{Name: name, Rate: component.Rate})
+	}
+	for _, category := range def.ExemptCategories {
+		r.exempt[strings.ToLower(strings.TrimSpace(category))] = true
+	}
+
+	return r, nil
+}
+
+// Region returns the region the rules apply in
+func (r *Rules) Region() string {
+	return r.region
+}
+
+// Name returns the name of the tax
+func (r *Rules) Name() string {
+	return r.name
+}
+
+// Rate returns the combined rate of the tax's components as a percentage
+func (r *Rules) Rate() decimal.Decimal {
+	rate := decimal.Zero
+	for _, component := range r.components {
+		rate = rate.Add(component.Rate)
+	}
+	return rate
+}
+
+// Exempts reports whether supplies of a category are charged no tax
+func (r *Rules) Exempts(category string) bool {
+	return r.exempt[strings.ToLower(strings.TrimSpace(category))]
+}
+
+// Compute returns the tax lines of a taxable amount, one per component,
+// each rounded by round
+func (r *Rules) Compute(taxable decimal.Decimal, round func(decimal.Decimal) decimal.Decimal) *models.TaxBreakdown {
+	breakdown := &models.TaxBreakdown{
+		Region:  r.region,
+		Tax:     r.name,
+		Taxable: taxable,
+		Lines:   make([]*models.TaxLine, 0, len(r.components)),
+		Total:   decimal.Zero,
+	}
+	for _, component := range r.components {
+		amount := round(taxable.Mul(component.Rate).Div(hundred))
+		breakdown.Lines = append(breakdown.Lines, &models.TaxLine{
+			Name:   component.Name,
+			Rate:   component.Rate,
+			Amount: amount,
+		})
+		breakdown.Total = breakdown.Total.Add(amount)
+	}
+	return breakdown
+}
+
+// Registry holds the tax rules of each configured region
+type Registry struct {
+	rules map[string]*Rules
+}
+
+// NewRegistry creates the rules of the given regions, keyed by ISO 3166
+// country code or by ISO 3166-2 subdivision code, such as IN-KA, where a
+// subdivision's rules differ from its country's
+func NewRegistry(defs map[string]Definition) (*Registry, error) {
+	r := &Registry{rules: make(map[string]*Rules, len(defs))}
+	for region, def := range defs {
+		rules, err := New(region, def)
+		if err != nil {
+			return nil, fmt.Errorf("tax %s: %w", normalize(region), err)
+		}
+		r.rules[rules.region] = rules
+	}
+	return r, nil
+}
+
+// For returns the rules of a region, falling back from a subdivision to its
+// country. Regions without rules are not taxed.
+func (r *Registry) For(region string) (*Rules, bool) {
+	region = normalize(region)
+	if region == "" {
+		return nil, false
+	}
+	if rules, ok := r.rules[region]; ok {
+		return rules, true
+	}
+	if country, _, found := strings.Cut(region, "-"); found {
+		rules, ok := r.rules[country]
+		return rules, ok
+	}
+	return nil, false
+}
+
+// Regions returns the configured regions in order
+func (r *Registry) Regions() []string {
+	regions := make([]string, 0, len(r.rules))
+	for region := range r.rules {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// normalize returns a region code in canonical upper case
+func normalize(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
@@ -0,0 +1,53 @@
+// Package tenancy carries the organization an operation is confined to in
+// its context. Requests made with organization-scoped credentials only see
+// the customers and wallets of their organization: the wallet and customer
+// repositories filter every lookup by the org_id column, so records reached
+// through a wallet or customer are confined too. Operations without an
+// organization, such as platform operators and background jobs, see every
+// tenant.
+package tenancy
+
+import (
+	"context"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// orgKey is the context key of the organization scope
+type orgKey struct{}
+
+// WithOrganization returns a context confined to the organization
+func WithOrganization(ctx context.Context, orgID uuid.UUID) context.Context {
+	return context.WithValue(ctx, orgKey{}, orgID)
+}
+
+// OrganizationOf returns the organization a context is confined to, and
+// false when it sees every tenant
+func OrganizationOf(ctx context.Context) (uuid.UUID, bool) {
+	orgID, ok := ctx.Value(orgKey{}).(uuid.UUID)
+	if !ok || orgID == uuid.Nil {
+		return uuid.Nil, false
+	}
+	return orgID, true
+}
+
+// Scope returns the organization of a context as a query parameter, nil
+// when it sees every tenant, for filters of the form
+// ($n::uuid IS NULL OR org_id = $n)
+func Scope(ctx context.Context) *uuid.UUID {
+	orgID, ok := OrganizationOf(ctx)
+	if !ok {
+		return nil
+	}
+	return &orgID
+}
+
+// Allows reports whether a context may see a record of the organization,
+// which is nil for records belonging to none
+func Allows(ctx context.Context, orgID *uuid.UUID) bool {
+	scope, ok := OrganizationOf(ctx)
+	if !ok {
+		return true
+	}
+	return orgID != nil && *orgID == scope
+}
@@ -0,0 +1,110 @@
+package testkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/walletfeed"
+)
+
+// Feed is an in-memory implementation of the wallet feed. Like the Redis
+// feed it numbers each wallet's updates and keeps the latest of them for
+// reconnecting subscribers.
+type Feed struct {
+	mu          sync.Mutex
+	history     int
+	sequences   map[uuid.UUID]int64
+	updates     map[uuid.UUID][]walletfeed.Update
+	subscribers map[uuid.UUID]map[chan walletfeed.Update]struct{}
+}
+
+// Compile-time check that Feed satisfies the feed interface
+var _ walletfeed.Feed = (*Feed)(nil)
+
+// NewFeed creates a feed keeping the last history updates of each wallet
+func NewFeed(history int) *Feed {
+	return &Feed{
+		history:     history,
+		sequences:   make(map[uuid.UUID]int64),
+		updates:     make(map[uuid.UUID][]walletfeed.Update),
+		subscribers: make(map[uuid.UUID]map[chan walletfeed.Update]struct{}),
+	}
+}
+
+// Publish implements walletfeed.Feed
+func (f *Feed) Publish(ctx context.Context, walletID uuid.UUID, kind string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s update: %w", kind, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sequences[walletID]++
+	update := walletfeed.Update{ID: f.sequences[walletID], Kind: kind, Data: raw}
+
+	history := append(f.updates[walletID], update)
+	if len(history) > f.history {
+		history = history[len(history)-f.history:]
+	}
+	f.updates[walletID] = history
+
+	for ch := range f.subscribers[walletID] {
+		// Subscribers buffer generously; a full one misses the update
+		select {
+		case ch <- update:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Subscribe implements walletfeed.Feed
+func (f *Feed) Subscribe(ctx context.Context, walletID uuid.UUID, lastID int64) (<-chan walletfeed.Update, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current := f.sequences[walletID]
+	complete := true
+	var missed []walletfeed.Update
+	if lastID > 0 {
+		for _, update := range f.updates[walletID] {
+			if update.ID > lastID {
+				missed = append(missed, update)
+			}
+		}
+		switch {
+		case lastID > current:
+			complete = false
+		case len(missed) == 0:
+			complete = lastID == current
+		default:
+			complete = missed[0].ID == lastID+1
+		}
+	}
+
+	ch := make(chan walletfeed.Update, len(missed)+f.history)
+	for _, update := range missed {
+		ch <- update
+	}
+	if f.subscribers[walletID] == nil {
+		f.subscribers[walletID] = make(map[chan walletfeed.Update]struct{})
+	}
+	f.subscribers[walletID][ch] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		delete(f.subscribers[walletID], ch)
+		f.mu.Unlock()
+		close(ch)
+	}()
+
+	return ch, complete, nil
+}
@@ -0,0 +1,4156 @@
+package testkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/models"
+	"internal/repository"
+	"internal/tenancy"
+)
+
+// Store is an in-memory implementation of the wallet, snapshot, sandbox,
+// billing period, wallet migration, wallet merge, consent, report job, rate
+// card, fee rule, wallet batch, transaction export, notification queue,
+// balance threshold, wallet analytics, adjustment, customer, tax, coupon,
+// wallet activity, dispute, bulk credit, incident, notification channel, data
+// access, invoice document, invoice receivable, postpaid, allowance,
+// organization, wallet bucket, wallet settings change, inbound webhook and
+// failed job repositories. It
+// follows the PostgreSQL repositories' semantics: balances move on every stored transaction, optimistic locking
+// bumps the wallet version, closed billing periods and frozen or merged
+// wallets refuse postings, historical balances only replay completed
+// transactions and wallets and customers outside the organization of a
+// confined context are not found.
+// Exports carry no holds and merges check none as the store keeps no refunds.
+type Store struct {
+	mu            sync.RWMutex
+	clock         *Clock
+	wallets       map[uuid.UUID]*models.Wallet
+	settings      map[uuid.UUID]*models.WalletSettings
+	transactions  map[uuid.UUID][]*models.Transaction
+	snapshots     map[uuid.UUID][]snapshot
+	periods       map[time.Time]*models.BillingPeriod
+	migrations    map[uuid.UUID]*models.WalletMigration
+	merges        map[uuid.UUID]*models.WalletMerge
+	consents      []*models.CustomerConsent
+	jobs          map[uuid.UUID]*models.ReportJob
+	rateCards     map[uuid.UUID]*models.RateCardChange
+	feeRules      map[uuid.UUID]*models.FeeRule
+	batches       map[uuid.UUID]*walletBatch
+	notifications map[uuid.UUID]*models.QueuedNotification
+	thresholds    map[uuid.UUID]*models.BalanceThresholds
+	balanceAlerts map[uuid.UUID]*models.BalanceAlertThreshold
+	adjustments   map[uuid.UUID]*models.Adjustment
+	customers     map[uuid.UUID]*models.CustomerSettings
+	taxRecords    []*models.TaxRecord
+	coupons       map[uuid.UUID]*models.Coupon
+	redemptions   []*models.CouponRedemption
+	disputes      map[uuid.UUID]*models.Dispute
+	bulkCredits   map[uuid.UUID]*bulkCredit
+	incidents     []*models.Incident
+	channels      []*models.NotificationChannel
+	dataAccesses  []*models.DataAccess
+	invoices      map[uuid.UUID]*invoiceDocument
+	receivables   map[uuid.UUID]*models.InvoiceReceivable
+	charges       []*models.PostpaidCharge
+	statements    []*models.PostpaidStatement
+	allowances    map[allowanceKey]int64
+	organizations map[uuid.UUID]*models.Organization
+	buckets       []*models.WalletBucket
+	walletChanges map[uuid.UUID]*models.WalletSettingsChange
+	inbound       []*models.InboundWebhook
+	deadLetters   []*models.InboundDeadLetter
+	failedJobs    []*models.FailedJob
+}
+
+// allowanceKey identifies the usage of a product's allowance by a wallet in
+// a billing period
+type allowanceKey struct {
+	walletID uuid.UUID
+	product  string
+	period   string
+}
+
+// invoiceDocument is a stored invoice PDF
+type invoiceDocument struct {
+	doc *models.InvoicePDF
+	pdf []byte
+}
+
+// walletBatch is a stored wallet provisioning batch
+type walletBatch struct {
+	createdAt time.Time
+	rows      []*models.WalletBatchRow
+}
+
+// bulkCredit is a stored bulk credit operation with its rows
+type bulkCredit struct {
+	credit *models.BulkCredit
+	rows   []*models.BulkCreditRow
+}
+
+// snapshot is a stored wallet balance at a point in time
+type snapshot struct {
+	asOf    time.Time
+	balance decimal.Decimal
+}
+
+// Compile-time checks that Store satisfies the repository interfaces
+var (
+	_ repository.WalletRepository               = (*Store)(nil)
+	_ repository.SnapshotRepository             = (*Store)(nil)
+	_ repository.SandboxRepository              = (*Store)(nil)
+	_ repository.BillingPeriodRepository        = (*Store)(nil)
+	_ repository.WalletMigrationRepository      = (*Store)(nil)
+	_ repository.WalletMergeRepository          = (*Store)(nil)
+	_ repository.ConsentRepository              = (*Store)(nil)
+	_ repository.ReportJobRepository            = (*Store)(nil)
+	_ repository.RateCardRepository             = (*Store)(nil)
+	_ repository.FeeRuleRepository              = (*Store)(nil)
+	_ repository.WalletBatchRepository          = (*Store)(nil)
+	_ repository.TransactionExportRepository    = (*Store)(nil)
+	_ repository.NotificationQueueRepository    = (*Store)(nil)
+	_ repository.BalanceThresholdRepository     = (*Store)(nil)
+	_ repository.WalletAnalyticsRepository      = (*Store)(nil)
+	_ repository.AdjustmentRepository           = (*Store)(nil)
+	_ repository.CustomerRepository             = (*Store)(nil)
+	_ repository.TaxRepository                  = (*Store)(nil)
+	_ repository.CouponRepository               = (*Store)(nil)
+	_ repository.WalletActivityRepository       = (*Store)(nil)
+	_ repository.DisputeRepository              = (*Store)(nil)
+	_ repository.BulkCreditRepository           = (*Store)(nil)
+	_ repository.IncidentRepository             = (*Store)(nil)
+	_ repository.NotificationChannelRepository  = (*Store)(nil)
+	_ repository.DataAccessRepository           = (*Store)(nil)
+	_ repository.InvoiceDocumentRepository      = (*Store)(nil)
+	_ repository.InvoiceReceivableRepository    = (*Store)(nil)
+	_ repository.PostpaidRepository             = (*Store)(nil)
+	_ repository.AllowanceRepository            = (*Store)(nil)
+	_ repository.OrganizationRepository         = (*Store)(nil)
+	_ repository.WalletBucketRepository         = (*Store)(nil)
+	_ repository.WalletSettingsChangeRepository = (*Store)(nil)
+	_ repository.InboundWebhookRepository       = (*Store)(nil)
+	_ repository.FailedJobRepository            = (*Store)(nil)
+	_ repository.WalletTx                       = (*storeTx)(nil)
+)
+
+// NewStore creates an empty store stamping records with the clock
+func NewStore(clock *Clock) *Store {
+	return &Store{
+		clock:         clock,
+		wallets:       make(map[uuid.UUID]*models.Wallet),
+		settings:      make(map[uuid.UUID]*models.WalletSettings),
+		transactions:  make(map[uuid.UUID][]*models.Transaction),
+		snapshots:     make(map[uuid.UUID][]snapshot),
+		periods:       make(map[time.Time]*models.BillingPeriod),
+		migrations:    make(map[uuid.UUID]*models.WalletMigration),
+		merges:        make(map[uuid.UUID]*models.WalletMerge),
+		jobs:          make(map[uuid.UUID]*models.ReportJob),
+		rateCards:     make(map[uuid.UUID]*models.RateCardChange),
+		feeRules:      make(map[uuid.UUID]*models.FeeRule),
+		batches:       make(map[uuid.UUID]*walletBatch),
+		notifications: make(map[uuid.UUID]*models.QueuedNotification),
+		thresholds:    make(map[uuid.UUID]*models.BalanceThresholds),
+		balanceAlerts: make(map[uuid.UUID]*models.BalanceAlertThreshold),
+		adjustments:   make(map[uuid.UUID]*models.Adjustment),
+		customers:     make(map[uuid.UUID]*models.CustomerSettings),
+		coupons:       make(map[uuid.UUID]*models.Coupon),
+		disputes:      make(map[uuid.UUID]*models.Dispute),
+		bulkCredits:   make(map[uuid.UUID]*bulkCredit),
+		invoices:      make(map[uuid.UUID]*invoiceDocument),
+		receivables:   make(map[uuid.UUID]*models.InvoiceReceivable),
+		allowances:    make(map[allowanceKey]int64),
+		organizations: make(map[uuid.UUID]*models.Organization),
+		walletChanges: make(map[uuid.UUID]*models.WalletSettingsChange),
+	}
+}
+
+// GetWallet retrieves a copy of a wallet by ID
+func (s *Store) GetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wallet, ok := s.wallets[id]
+	if !ok || !s.visible(ctx, wallet.CustomerID) {
+		return nil, repository.ErrWalletNotFound
+	}
+
+	copied := *wallet
+	return &copied, nil
+}
+
+// visible reports whether a confined context may see the customer and its
+// wallets. Callers hold the lock.
+func (s *Store) visible(ctx context.Context, customerID uuid.UUID) bool {
+	var orgID *uuid.UUID
+	if settings, ok := s.customers[customerID]; ok {
+		orgID = settings.OrgID
+	}
+	return tenancy.Allows(ctx, orgID)
+}
+
+// CreateWallet stores a new wallet, assigning its ID and timestamps
+func (s *Store) CreateWallet(ctx context.Context, wallet *models.Wallet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet.ID = uuid.New()
+	wallet.CreatedAt = s.clock.Now()
+	wallet.UpdatedAt = wallet.CreatedAt
+	lastActivity := wallet.CreatedAt
+	wallet.LastActivityAt = &lastActivity
+	wallet.Version = 1
+
+	copied := *wallet
+	s.wallets[wallet.ID] = &copied
+	return nil
+}
+
+// storeTx implements repository.WalletTx, staging its changes until the
+// unit of work commits. The store stays locked meanwhile.
+type storeTx struct {
+	store        *Store
+	wallets      map[uuid.UUID]*models.Wallet
+	transactions []*models.Transaction
+}
+
+// InTx runs fn with the store locked, applying its changes when it succeeds
+func (s *Store) InTx(ctx context.Context, fn func(tx repository.WalletTx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	staged := &storeTx{store: s, wallets: make(map[uuid.UUID]*models.Wallet)}
+	if err := fn(staged); err != nil {
+		return err
+	}
+
+	s.commit(staged)
+	return nil
+}
+
+// commit applies the changes staged by a unit of work
+func (s *Store) commit(staged *storeTx) {
+	for id, wallet := range staged.wallets {
+		s.wallets[id] = wallet
+	}
+	for _, tx := range staged.transactions {
+		s.transactions[tx.WalletID] = append(s.transactions[tx.WalletID], tx)
+		s.recordActivity(tx)
+	}
+}
+
+// recordActivity moves a wallet's last activity forward to a transaction,
+// as the wallet_transactions trigger does
+func (s *Store) recordActivity(tx *models.Transaction) {
+	wallet, ok := s.wallets[tx.WalletID]
+	if !ok || (wallet.LastActivityAt != nil && !wallet.LastActivityAt.Before(tx.CreatedAt)) {
+		return
+	}
+	at := tx.CreatedAt
+	wallet.LastActivityAt = &at
+}
+
+// GetWalletForUpdate retrieves a copy of a wallet as staged in the unit of work
+func (t *storeTx) GetWalletForUpdate(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	wallet, ok := t.wallets[id]
+	if !ok {
+		wallet, ok = t.store.wallets[id]
+	}
+	if !ok || !t.store.visible(ctx, wallet.CustomerID) {
+		return nil, repository.ErrWalletNotFound
+	}
+
+	copied := *wallet
+	return &copied, nil
+}
+
+// ApplyTransaction stages a wallet's balance after a transaction if its
+// version matches
+func (t *storeTx) ApplyTransaction(ctx context.Context, wallet *models.Wallet, tx *models.Transaction) error {
+	if err := tx.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", repository.ErrInvalidTransaction, err)
+	}
+
+	posting := t.store.clock.Now()
+	if tx.EffectiveAt != nil {
+		posting = *tx.EffectiveAt
+	}
+	if period, ok := t.store.periods[models.BillingPeriodOf(posting)]; ok && period.Status == models.BillingPeriodClosed {
+		return repository.ErrPeriodClosed
+	}
+	if migration, ok := t.store.migrations[wallet.ID]; ok && migration.Status == models.WalletMigrationFrozen {
+		return repository.ErrWalletFrozen
+	}
+	if t.store.mergeOf(wallet.ID) != nil {
+		return repository.ErrWalletMerged
+	}
+
+	current, err := t.GetWalletForUpdate(ctx, wallet.ID)
+	if err != nil {
+		return err
+	}
+	if current.Version != wallet.Version {
+		return repository.ErrOptimisticLock
+	}
+
+	balance, err := wallet.BalanceAfter(tx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", repository.ErrInvalidTransaction, err)
+	}
+	wallet.SetBalance(balance)
+	wallet.UpdatedAt = t.store.clock.Now()
+	wallet.Version++
+
+	copied := *wallet
+	t.wallets[wallet.ID] = &copied
+	return nil
+}
+
+// InsertTransaction stages a transaction, assigning its timestamps and its
+// ID unless the caller chose one
+func (t *storeTx) InsertTransaction(ctx context.Context, tx *models.Transaction) error {
+	now := t.store.clock.Now()
+	if tx.ID == uuid.Nil {
+		tx.ID = uuid.New()
+	}
+	tx.CreatedAt = now
+	tx.UpdatedAt = now
+
+	copied := *tx
+	t.transactions = append(t.transactions, &copied)
+	return nil
+}
+
+// PostTransactions applies and records postings in one unit of work,
+// refusing each on its own as the SQL repository does
+func (s *Store) PostTransactions(ctx context.Context, txs []*models.Transaction) ([]error, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	staged := &storeTx{store: s, wallets: make(map[uuid.UUID]*models.Wallet)}
+	refused := make([]error, len(txs))
+	for i, tx := range txs {
+		wallet, err := staged.GetWalletForUpdate(ctx, tx.WalletID)
+		switch {
+		case err != nil:
+		case wallet.Currency != tx.Currency:
+			err = repository.ErrCurrencyMismatch
+		case tx.SignedAmount() < 0 && !wallet.HasSufficientMinorBalance(tx.SignedMinor().Neg()):
+			err = repository.ErrInsufficientBalance
+		default:
+			if err = staged.ApplyTransaction(ctx, wallet, tx); err == nil {
+				err = staged.InsertTransaction(ctx, tx)
+			}
+		}
+		refused[i] = err
+	}
+
+	s.commit(staged)
+	return refused, nil
+}
+
+// UpdateCreditLimit sets a wallet's overdraft limit if its version matches
+func (s *Store) UpdateCreditLimit(ctx context.Context, wallet *models.Wallet, creditLimit float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.wallets[wallet.ID]
+	if !ok || stored.Version != wallet.Version || stored.Balance < -creditLimit {
+		return repository.ErrOptimisticLock
+	}
+
+	stored.CreditLimit = creditLimit
+	stored.UpdatedAt = s.clock.Now()
+	stored.Version++
+
+	*wallet = *stored
+	return nil
+}
+
+// GetTransactions retrieves a page of a wallet's transactions, latest
+// effective first
+func (s *Store) GetTransactions(ctx context.Context, walletID uuid.UUID, limit, offset int) ([]*models.Transaction, error) {
+	transactions, _, err := s.FindTransactions(ctx, walletID, repository.TransactionFilter{}, limit, offset)
+	return transactions, err
+}
+
+// FindTransactions retrieves a page of a wallet's transactions matching a
+// filter, latest effective first, and the number of matches
+func (s *Store) FindTransactions(ctx context.Context, walletID uuid.UUID, filter repository.TransactionFilter, limit, offset int) ([]*models.Transaction, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if wallet, ok := s.wallets[walletID]; ok && !s.visible(ctx, wallet.CustomerID) {
+		return nil, 0, nil
+	}
+	matches := s.matchingTransactions(walletID, filter)
+	return copyPage(matches, limit, offset), len(matches), nil
+}
+
+// GetWalletOverview returns copies of a wallet and its newest transactions,
+// read under one lock so no posting lands in between
+func (s *Store) GetWalletOverview(ctx context.Context, walletID uuid.UUID, limit int) (*models.WalletOverview, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wallet, ok := s.wallets[walletID]
+	if !ok || !s.visible(ctx, wallet.CustomerID) {
+		return nil, repository.ErrWalletNotFound
+	}
+	copied := *wallet
+
+	return &models.WalletOverview{
+		Wallet:       &copied,
+		Transactions: copyPage(s.matchingTransactions(walletID, repository.TransactionFilter{}), limit, 0),
+		AsOf:         s.clock.Now(),
+	}, nil
+}
+
+// matchingTransactions returns a wallet's transactions matching a filter,
+// latest effective first. Callers hold the lock.
+func (s *Store) matchingTransactions(walletID uuid.UUID, filter repository.TransactionFilter) []*models.Transaction {
+	if filter.Since.IsZero() && filter.Horizon > 0 {
+		filter.Since = s.clock.Now().Add(-filter.Horizon)
+	}
+	stored := s.transactions[walletID]
+	ordered := make([]*models.Transaction, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		if matchesFilter(stored[i], filter) {
+			ordered = append(ordered, stored[i])
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].EffectiveTime().After(ordered[j].EffectiveTime())
+	})
+	return ordered
+}
+
+// matchesFilter reports whether a transaction matches a filter, as the
+// conditions of the PostgreSQL query do
+func matchesFilter(tx *models.Transaction, filter repository.TransactionFilter) bool {
+	if tx.CreatedAt.Before(filter.Since) {
+		return false
+	}
+	if len(filter.Types) > 0 && !containsValue(filter.Types, tx.Type) {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !containsValue(filter.Statuses, tx.Status) {
+		return false
+	}
+	if !filter.EffectiveFrom.IsZero() && tx.EffectiveTime().Before(filter.EffectiveFrom) {
+		return false
+	}
+	if !filter.EffectiveTo.IsZero() && tx.EffectiveTime().After(filter.EffectiveTo) {
+		return false
+	}
+	if filter.MetadataKey != "" {
+		value, ok := tx.Metadata[filter.MetadataKey]
+		if !ok || filter.MetadataValue != "" && value != filter.MetadataValue {
+			return false
+		}
+	}
+	return true
+}
+
+// containsValue reports whether values holds v
+func containsValue[T comparable](values []T, v T) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
+
+// copyPage returns copies of a page of transactions
+func copyPage(transactions []*models.Transaction, limit, offset int) []*models.Transaction {
+	var copies []*models.Transaction
+	for i := offset; i < len(transactions) && len(copies) < limit; i++ {
+		copied := *transactions[i]
+		copies = append(copies, &copied)
+	}
+	return copies
+}
+
+// GetTransactionByID retrieves a transaction by ID
+func (s *Store) GetTransactionByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for walletID, stored := range s.transactions {
+		if wallet, ok := s.wallets[walletID]; ok && !s.visible(ctx, wallet.CustomerID) {
+			continue
+		}
+		for _, tx := range stored {
+			if tx.ID == id {
+				copied := *tx
+				return &copied, nil
+			}
+		}
+	}
+
+	return nil, errors.New("transaction not found")
+}
+
+// GetCustomerWallets retrieves copies of a customer's wallets, oldest first
+func (s *Store) GetCustomerWallets(ctx context.Context, customerID uuid.UUID) ([]*models.Wallet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var wallets []*models.Wallet
+	if !s.visible(ctx, customerID) {
+		return wallets, nil
+	}
+	for _, wallet := range s.wallets {
+		if wallet.CustomerID == customerID {
+			copied := *wallet
+			wallets = append(wallets, &copied)
+		}
+	}
+	sort.Slice(wallets, func(i, j int) bool {
+		if !wallets[i].CreatedAt.Equal(wallets[j].CreatedAt) {
+			return wallets[i].CreatedAt.Before(wallets[j].CreatedAt)
+		}
+		return wallets[i].ID.String() < wallets[j].ID.String()
+	})
+
+	return wallets, nil
+}
+
+// GetRecentTransactions retrieves up to limit of the newest transactions of
+// each wallet, grouped by wallet
+func (s *Store) GetRecentTransactions(ctx context.Context, walletIDs []uuid.UUID, limit int) ([]*models.Transaction, error) {
+	var transactions []*models.Transaction
+	for _, walletID := range walletIDs {
+		page, err := s.GetTransactions(ctx, walletID, limit, 0)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, page...)
+	}
+
+	return transactions, nil
+}
+
+// FindTransactionsByReference retrieves up to limit of the newest
+// transactions with a reference ID in any wallet. The store keeps no customer
+// records, so the customer details are empty.
+func (s *Store) FindTransactionsByReference(ctx context.Context, referenceID string, limit int) ([]*models.TransactionMatch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matches []*models.TransactionMatch
+	for walletID, stored := range s.transactions {
+		if !s.visible(ctx, s.wallets[walletID].CustomerID) {
+			continue
+		}
+		for _, tx := range stored {
+			if tx.ReferenceID != referenceID {
+				continue
+			}
+			copiedTx := *tx
+			copiedWallet := *s.wallets[walletID]
+			matches = append(matches, &models.TransactionMatch{
+				Transaction: &copiedTx,
+				Wallet:      &copiedWallet,
+			})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Transaction.CreatedAt.After(matches[j].Transaction.CreatedAt)
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches, nil
+}
+
+// GetWalletSettings retrieves a copy of a wallet's settings, the defaults
+// until they were first updated
+func (s *Store) GetWalletSettings(ctx context.Context, walletID uuid.UUID) (*models.WalletSettings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wallet, ok := s.wallets[walletID]
+	if !ok || !s.visible(ctx, wallet.CustomerID) {
+		return nil, repository.ErrWalletNotFound
+	}
+
+	settings := models.DefaultWalletSettings(wallet)
+	if stored, ok := s.settings[walletID]; ok {
+		*settings = *stored
+	}
+	settings.LowBalanceThreshold = wallet.LowBalanceThreshold
+	settings.CreditLimit = wallet.CreditLimit
+	return settings, nil
+}
+
+// UpdateWalletSettings stores a wallet's settings if their version matches
+func (s *Store) UpdateWalletSettings(ctx context.Context, settings *models.WalletSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet, ok := s.wallets[settings.WalletID]
+	if !ok {
+		return repository.ErrOptimisticLock
+	}
+	current := models.DefaultWalletSettings(wallet)
+	if stored, ok := s.settings[settings.WalletID]; ok {
+		current = stored
+	}
+	if current.Version != settings.Version || wallet.Balance < -settings.CreditLimit {
+		return repository.ErrOptimisticLock
+	}
+
+	now := s.clock.Now()
+	if wallet.CreditLimit != settings.CreditLimit {
+		wallet.Version++
+	}
+	wallet.LowBalanceThreshold = settings.LowBalanceThreshold
+	wallet.CreditLimit = settings.CreditLimit
+	wallet.UpdatedAt = now
+
+	settings.Version++
+	settings.UpdatedAt = now
+	copied := *settings
+	s.settings[settings.WalletID] = &copied
+	return nil
+}
+
+// CountTransactionOutcomes counts a wallet's transactions created since the
+// given time and how many of them failed
+func (s *Store) CountTransactionOutcomes(ctx context.Context, walletID uuid.UUID, since time.Time) (int, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var total, failed int
+	for _, tx := range s.transactions[walletID] {
+		if tx.CreatedAt.Before(since) {
+			continue
+		}
+		total++
+		if tx.Status == models.TransactionStatusFailed {
+			failed++
+		}
+	}
+	return total, failed, nil
+}
+
+// Close implements WalletRepository; the store holds no statements
+func (s *Store) Close() error {
+	return nil
+}
+
+// CloneWallet stores a wallet and its transactions as given
+func (s *Store) CloneWallet(ctx context.Context, wallet *models.Wallet, transactions []*models.Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet.Version = 1
+	copied := *wallet
+	s.wallets[wallet.ID] = &copied
+
+	for _, tx := range transactions {
+		copied := *tx
+		s.transactions[wallet.ID] = append(s.transactions[wallet.ID], &copied)
+	}
+	return nil
+}
+
+// CreateSnapshots records every wallet created before asOf at its balance at asOf
+func (s *Store) CreateSnapshots(ctx context.Context, asOf time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var created int64
+	for id, wallet := range s.wallets {
+		if !wallet.CreatedAt.Before(asOf) {
+			continue
+		}
+
+		balance, _, _ := s.balanceAt(id, asOf, false)
+		snapshots := s.snapshots[id]
+		replaced := false
+		for i := range snapshots {
+			if snapshots[i].asOf.Equal(asOf) {
+				snapshots[i].balance = balance
+				replaced = true
+			}
+		}
+		if !replaced {
+			snapshots = append(snapshots, snapshot{asOf: asOf, balance: balance})
+			sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].asOf.Before(snapshots[j].asOf) })
+		}
+		s.snapshots[id] = snapshots
+		created++
+	}
+
+	return created, nil
+}
+
+// LatestSnapshotAt returns the most recent snapshot time, or the zero time
+func (s *Store) LatestSnapshotAt(ctx context.Context) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest time.Time
+	for _, snapshots := range s.snapshots {
+		if n := len(snapshots); n > 0 && snapshots[n-1].asOf.After(latest) {
+			latest = snapshots[n-1].asOf
+		}
+	}
+
+	return latest, nil
+}
+
+// GetBalanceAsOf returns the wallet balance at asOf from the latest snapshot
+// at or before asOf plus the completed transactions created since
+func (s *Store) GetBalanceAsOf(ctx context.Context, walletID uuid.UUID, asOf time.Time) (*models.HistoricalBalance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wallet, ok := s.wallets[walletID]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+
+	balance, snapshotAt, replayed := s.balanceAt(walletID, asOf, true)
+	return &models.HistoricalBalance{
+		WalletID:             walletID,
+		AsOf:                 asOf,
+		Balance:              balance,
+		Currency:             wallet.Currency,
+		SnapshotAt:           snapshotAt,
+		ReplayedTransactions: replayed,
+	}, nil
+}
+
+// balanceAt rolls the latest snapshot before asOf, or at it when inclusive,
+// forward with the completed transactions created before asOf
+func (s *Store) balanceAt(walletID uuid.UUID, asOf time.Time, inclusive bool) (decimal.Decimal, *time.Time, int64) {
+	balance := decimal.Zero
+	var from *time.Time
+	for _, snap := range s.snapshots[walletID] {
+		if snap.asOf.After(asOf) || (!inclusive && snap.asOf.Equal(asOf)) {
+			break
+		}
+		at := snap.asOf
+		balance, from = snap.balance, &at
+	}
+
+	var replayed int64
+	for _, tx := range s.transactions[walletID] {
+		if tx.Status != models.TransactionStatusCompleted || !tx.CreatedAt.Before(asOf) {
+			continue
+		}
+		if from != nil && tx.CreatedAt.Before(*from) {
+			continue
+		}
+
+		balance = balance.Add(decimal.NewFromFloat(tx.SignedAmount()))
+		replayed++
+	}
+
+	return balance, from, replayed
+}
+
+// GetBillingPeriod returns the close state of a period, open when never closed
+func (s *Store) GetBillingPeriod(ctx context.Context, period time.Time) (*models.BillingPeriod, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.periods[period]
+	if !ok {
+		return &models.BillingPeriod{
+			Period: period.Format(models.BillingPeriodLayout),
+			Status: models.BillingPeriodOpen,
+		}, nil
+	}
+
+	copied := *state
+	return &copied, nil
+}
+
+// ListBillingPeriods returns up to limit of the periods ever closed, newest first
+func (s *Store) ListBillingPeriods(ctx context.Context, limit int) ([]*models.BillingPeriod, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	periods := make([]*models.BillingPeriod, 0, len(s.periods))
+	for _, state := range s.periods {
+		copied := *state
+		periods = append(periods, &copied)
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Period > periods[j].Period
+	})
+	if len(periods) > limit {
+		periods = periods[:limit]
+	}
+	return periods, nil
+}
+
+// CloseBillingPeriod closes an open period
+func (s *Store) CloseBillingPeriod(ctx context.Context, period time.Time, actor, reason string, now time.Time) (*models.BillingPeriod, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.periods[period]
+	if ok && state.Status != models.BillingPeriodOpen {
+		return nil, repository.ErrBillingPeriodConflict
+	}
+	if !ok {
+		state = &models.BillingPeriod{Period: period.Format(models.BillingPeriodLayout)}
+		s.periods[period] = state
+	}
+
+	state.Status = models.BillingPeriodClosed
+	state.ClosedBy = actor
+	state.ClosedAt = &now
+	state.Reason = reason
+	state.UpdatedAt = &now
+
+	copied := *state
+	return &copied, nil
+}
+
+// ReopenBillingPeriod reopens a closed period
+func (s *Store) ReopenBillingPeriod(ctx context.Context, period time.Time, actor, reason string, now time.Time) (*models.BillingPeriod, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.periods[period]
+	if !ok || state.Status != models.BillingPeriodClosed {
+		return nil, repository.ErrBillingPeriodConflict
+	}
+
+	state.Status = models.BillingPeriodOpen
+	state.ReopenedBy = actor
+	state.ReopenedAt = &now
+	state.Reason = reason
+	state.UpdatedAt = &now
+
+	copied := *state
+	return &copied, nil
+}
+
+// ExportWallet freezes a wallet and returns it with up to limit of its
+// newest transactions created since
+func (s *Store) ExportWallet(ctx context.Context, walletID uuid.UUID, since time.Time, limit int, now time.Time) (*models.WalletExport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet, ok := s.wallets[walletID]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	if migration, ok := s.migrations[walletID]; ok &&
+		(migration.Direction != models.WalletMigrationImport || migration.Status != models.WalletMigrationActive) {
+		return nil, repository.ErrWalletMigrationConflict
+	}
+	wallet.Version++
+
+	export := &models.WalletExport{
+		Version:    models.WalletExportVersion,
+		ExportedAt: now,
+		Wallet:     *wallet,
+	}
+	stored := s.transactions[walletID]
+	for i := len(stored) - 1; i >= 0 && len(export.Transactions) < limit; i-- {
+		if stored[i].CreatedAt.Before(since) {
+			break
+		}
+		copied := *stored[i]
+		export.Transactions = append([]*models.Transaction{&copied}, export.Transactions...)
+	}
+
+	checksum, err := export.ComputeChecksum()
+	if err != nil {
+		return nil, err
+	}
+	export.Checksum = checksum
+
+	s.migrations[walletID] = &models.WalletMigration{
+		WalletID:   walletID,
+		Direction:  models.WalletMigrationExport,
+		Status:     models.WalletMigrationFrozen,
+		Checksum:   checksum,
+		ExportedAt: now,
+		CreatedAt:  now,
+	}
+	return export, nil
+}
+
+// ImportWallet stores an exported wallet and its transactions as given,
+// preceded by the opening balance transaction when set, frozen until activation
+func (s *Store) ImportWallet(ctx context.Context, export *models.WalletExport, opening *models.Transaction, now time.Time) (*models.WalletMigration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	walletID := export.Wallet.ID
+	if _, ok := s.wallets[walletID]; ok {
+		return nil, repository.ErrWalletExists
+	}
+
+	wallet := export.Wallet
+	wallet.Version = 1
+	s.wallets[walletID] = &wallet
+
+	if opening != nil {
+		copied := *opening
+		s.transactions[walletID] = append(s.transactions[walletID], &copied)
+	}
+	for _, tx := range export.Transactions {
+		copied := *tx
+		s.transactions[walletID] = append(s.transactions[walletID], &copied)
+	}
+
+	migration := &models.WalletMigration{
+		WalletID:   walletID,
+		Direction:  models.WalletMigrationImport,
+		Status:     models.WalletMigrationFrozen,
+		Checksum:   export.Checksum,
+		ExportedAt: export.ExportedAt,
+		CreatedAt:  now,
+	}
+	s.migrations[walletID] = migration
+
+	copied := *migration
+	return &copied, nil
+}
+
+// ActivateWallet unfreezes an imported wallet given the checksum of its import
+func (s *Store) ActivateWallet(ctx context.Context, walletID uuid.UUID, checksum string, now time.Time) (*models.WalletMigration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	migration, ok := s.migrations[walletID]
+	if !ok {
+		return nil, repository.ErrWalletMigrationNotFound
+	}
+	if migration.Direction != models.WalletMigrationImport || migration.Status != models.WalletMigrationFrozen ||
+		migration.Checksum != checksum {
+		return nil, repository.ErrWalletMigrationConflict
+	}
+
+	migration.Status = models.WalletMigrationActive
+	migration.ActivatedAt = &now
+
+	copied := *migration
+	return &copied, nil
+}
+
+// CancelExport unfreezes an exported wallet
+func (s *Store) CancelExport(ctx context.Context, walletID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	migration, ok := s.migrations[walletID]
+	if !ok {
+		return repository.ErrWalletMigrationNotFound
+	}
+	if migration.Direction != models.WalletMigrationExport || migration.Status != models.WalletMigrationFrozen {
+		return repository.ErrWalletMigrationConflict
+	}
+
+	delete(s.migrations, walletID)
+	return nil
+}
+
+// GetWalletMigration returns the latest move of a wallet
+func (s *Store) GetWalletMigration(ctx context.Context, walletID uuid.UUID) (*models.WalletMigration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	migration, ok := s.migrations[walletID]
+	if !ok {
+		return nil, repository.ErrWalletMigrationNotFound
+	}
+
+	copied := *migration
+	return &copied, nil
+}
+
+// ListConsents returns the latest consent of a customer for each channel
+func (s *Store) ListConsents(ctx context.Context, customerID uuid.UUID) ([]*models.CustomerConsent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	latest := make(map[models.ConsentChannel]*models.CustomerConsent)
+	for _, consent := range s.consents {
+		if consent.CustomerID == customerID {
+			copied := *consent
+			latest[consent.Channel] = &copied
+		}
+	}
+
+	consents := make([]*models.CustomerConsent, 0, len(latest))
+	for _, consent := range latest {
+		consents = append(consents, consent)
+	}
+	sort.Slice(consents, func(i, j int) bool { return consents[i].Channel < consents[j].Channel })
+	return consents, nil
+}
+
+// RecordConsent appends a consent change stamped with the clock's time
+func (s *Store) RecordConsent(ctx context.Context, consent *models.CustomerConsent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	consent.RecordedAt = &now
+	copied := *consent
+	s.consents = append(s.consents, &copied)
+	return nil
+}
+
+// ListConsentHistory returns a customer's consent changes, newest first
+func (s *Store) ListConsentHistory(ctx context.Context, customerID uuid.UUID, limit int) ([]*models.CustomerConsent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var history []*models.CustomerConsent
+	for i := len(s.consents) - 1; i >= 0 && len(history) < limit; i-- {
+		if s.consents[i].CustomerID == customerID {
+			copied := *s.consents[i]
+			history = append(history, &copied)
+		}
+	}
+	return history, nil
+}
+
+// HasConsent reports whether the latest consent of a customer for a channel
+// was granted
+func (s *Store) HasConsent(ctx context.Context, customerID uuid.UUID, channel models.ConsentChannel) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.consents) - 1; i >= 0; i-- {
+		if consent := s.consents[i]; consent.CustomerID == customerID && consent.Channel == channel {
+			return consent.Granted, nil
+		}
+	}
+	return false, nil
+}
+
+// CreateJob stores a queued report job
+func (s *Store) CreateJob(ctx context.Context, job *models.ReportJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *job
+	s.jobs[job.ID] = &copied
+	return nil
+}
+
+// GetJob retrieves a copy of a report job
+func (s *Store) GetJob(ctx context.Context, id uuid.UUID) (*models.ReportJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, repository.ErrReportJobNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// ListJobs returns report jobs newest first, optionally of one kind or status
+func (s *Store) ListJobs(ctx context.Context, kind string, status models.ReportJobStatus, limit, offset int) ([]*models.ReportJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var jobs []*models.ReportJob
+	for _, job := range s.jobs {
+		if (kind == "" || job.Kind == kind) && (status == "" || job.Status == status) {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+
+	if offset >= len(jobs) {
+		return nil, nil
+	}
+	jobs = jobs[offset:]
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+// ClaimJob takes the runnable report job queued first, or one whose worker
+// stopped sending heartbeats
+func (s *Store) ClaimJob(ctx context.Context, now, staleBefore time.Time) (*models.ReportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next *models.ReportJob
+	for _, job := range s.jobs {
+		runnable := (job.Status == models.ReportJobQueued && !job.RunAfter.After(now)) ||
+			(job.Status == models.ReportJobRunning && job.HeartbeatAt != nil && job.HeartbeatAt.Before(staleBefore))
+		if runnable && (next == nil || job.RunAfter.Before(next.RunAfter) ||
+			(job.RunAfter.Equal(next.RunAfter) && job.CreatedAt.Before(next.CreatedAt))) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	next.Status = models.ReportJobRunning
+	next.Attempts++
+	next.Progress = 0
+	heartbeat := now
+	next.HeartbeatAt = &heartbeat
+	if next.StartedAt == nil {
+		started := now
+		next.StartedAt = &started
+	}
+	copied := *next
+	return &copied, nil
+}
+
+// Heartbeat records the progress of a running report job
+func (s *Store) Heartbeat(ctx context.Context, id uuid.UUID, progress int, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok || job.Status != models.ReportJobRunning {
+		return false, repository.ErrReportJobNotRunning
+	}
+	job.Progress = progress
+	heartbeat := now
+	job.HeartbeatAt = &heartbeat
+	return job.CancelRequested, nil
+}
+
+// FinishJob stores the outcome of a report job run
+func (s *Store) FinishJob(ctx context.Context, job *models.ReportJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.jobs[job.ID]
+	if !ok || stored.Status != models.ReportJobRunning {
+		return repository.ErrReportJobNotRunning
+	}
+	stored.Status = job.Status
+	stored.Progress = job.Progress
+	stored.Result = job.Result
+	stored.Error = job.Error
+	stored.RunAfter = job.RunAfter
+	stored.FinishedAt = job.FinishedAt
+	stored.HeartbeatAt = nil
+	return nil
+}
+
+// RequestCancel cancels a queued report job and flags a running one
+func (s *Store) RequestCancel(ctx context.Context, id uuid.UUID, now time.Time) (*models.ReportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, repository.ErrReportJobNotFound
+	}
+	switch job.Status {
+	case models.ReportJobQueued:
+		job.Status = models.ReportJobCancelled
+		finished := now
+		job.FinishedAt = &finished
+	case models.ReportJobRunning:
+	default:
+		return nil, repository.ErrReportJobNotRunning
+	}
+	job.CancelRequested = true
+	copied := *job
+	return &copied, nil
+}
+
+// PurgeJobs deletes report jobs finished before the given time
+func (s *Store) PurgeJobs(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, job := range s.jobs {
+		if job.Status.Finished() && job.FinishedAt != nil && job.FinishedAt.Before(before) {
+			delete(s.jobs, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// CreateChange stores a proposed rate card change, refusing it while another
+// change is pending or approved
+func (s *Store) CreateChange(ctx context.Context, change *models.RateCardChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stored := range s.rateCards {
+		if stored.Status == models.RateCardChangePending || stored.Status == models.RateCardChangeApproved {
+			return repository.ErrRateCardChangeConflict
+		}
+	}
+	copied := *change
+	s.rateCards[change.ID] = &copied
+	return nil
+}
+
+// GetChange retrieves a copy of a rate card change
+func (s *Store) GetChange(ctx context.Context, id uuid.UUID) (*models.RateCardChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	change, ok := s.rateCards[id]
+	if !ok {
+		return nil, repository.ErrRateCardChangeNotFound
+	}
+	copied := *change
+	return &copied, nil
+}
+
+// ListChanges returns the latest rate card changes, newest first
+func (s *Store) ListChanges(ctx context.Context, limit int) ([]*models.RateCardChange, error) {
+	changes := s.rateCardChanges(func(*models.RateCardChange) bool { return true })
+	sort.Slice(changes, func(i, j int) bool { return changes[i].CreatedAt.After(changes[j].CreatedAt) })
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes, nil
+}
+
+// ListApproved returns the approved rate card changes, earliest effective
+// date first
+func (s *Store) ListApproved(ctx context.Context) ([]*models.RateCardChange, error) {
+	changes := s.rateCardChanges(func(c *models.RateCardChange) bool { return c.Status == models.RateCardChangeApproved })
+	sort.Slice(changes, func(i, j int) bool { return changes[i].EffectiveAt.Before(changes[j].EffectiveAt) })
+	return changes, nil
+}
+
+// rateCardChanges returns copies of the rate card changes matching keep
+func (s *Store) rateCardChanges(keep func(*models.RateCardChange) bool) []*models.RateCardChange {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var changes []*models.RateCardChange
+	for _, change := range s.rateCards {
+		if keep(change) {
+			copied := *change
+			changes = append(changes, &copied)
+		}
+	}
+	return changes
+}
+
+// ReviewChange approves or rejects a pending rate card change
+func (s *Store) ReviewChange(ctx context.Context, id uuid.UUID, status models.RateCardChangeStatus, reviewer, note string, now time.Time) (*models.RateCardChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change, ok := s.rateCards[id]
+	if !ok {
+		return nil, repository.ErrRateCardChangeNotFound
+	}
+	if change.Status != models.RateCardChangePending {
+		return nil, repository.ErrRateCardChangeConflict
+	}
+	change.Status = status
+	change.ReviewedBy = reviewer
+	change.ReviewNote = note
+	reviewed := now
+	change.ReviewedAt = &reviewed
+	copied := *change
+	return &copied, nil
+}
+
+// MarkFinanceNotified records that finance was sent an approved change
+func (s *Store) MarkFinanceNotified(ctx context.Context, id uuid.UUID, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change, ok := s.rateCards[id]
+	if ok && change.Status == models.RateCardChangeApproved && change.FinanceNotifiedAt == nil {
+		notified := now
+		change.FinanceNotifiedAt = &notified
+	}
+	return nil
+}
+
+// ActivateChange puts a notified, due change into effect, superseding the
+// active one
+func (s *Store) ActivateChange(ctx context.Context, id uuid.UUID, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change, ok := s.rateCards[id]
+	if !ok || change.Status != models.RateCardChangeApproved || change.FinanceNotifiedAt == nil || change.EffectiveAt.After(now) {
+		return repository.ErrRateCardChangeConflict
+	}
+	for _, stored := range s.rateCards {
+		if stored.Status == models.RateCardChangeActive {
+			stored.Status = models.RateCardChangeSuperseded
+		}
+	}
+	change.Status = models.RateCardChangeActive
+	activated := now
+	change.ActivatedAt = &activated
+	return nil
+}
+
+// GetRatesAt returns the rates of the change in effect at the given time
+func (s *Store) GetRatesAt(ctx context.Context, at time.Time) (*models.RateCard, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest *models.RateCardChange
+	for _, change := range s.rateCards {
+		if change.ActivatedAt == nil || change.ActivatedAt.After(at) {
+			continue
+		}
+		if latest == nil || change.ActivatedAt.After(*latest.ActivatedAt) {
+			latest = change
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	rates := latest.Rates
+	return &rates, nil
+}
+
+// MergeWallets moves the source wallet's balance and transactions to the
+// target wallet of the same customer and currency
+func (s *Store) MergeWallets(ctx context.Context, sourceID, targetID uuid.UUID, now, reversibleUntil time.Time) (*models.WalletMerge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sourceID == targetID {
+		return nil, fmt.Errorf("%w: a wallet cannot be merged into itself", repository.ErrWalletMergeConflict)
+	}
+	source, ok := s.wallets[sourceID]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	target, ok := s.wallets[targetID]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	if source.CustomerID != target.CustomerID || source.Currency != target.Currency {
+		return nil, fmt.Errorf("%w: wallets differ in customer or currency", repository.ErrWalletMergeConflict)
+	}
+	for _, id := range []uuid.UUID{sourceID, targetID} {
+		if migration, ok := s.migrations[id]; ok && migration.Status == models.WalletMigrationFrozen {
+			return nil, fmt.Errorf("%w: a wallet is frozen for migration", repository.ErrWalletMergeConflict)
+		}
+		if s.mergeOf(id) != nil {
+			return nil, fmt.Errorf("%w: a wallet is already merged", repository.ErrWalletMergeConflict)
+		}
+	}
+	for _, merge := range s.merges {
+		if merge.TargetWalletID == sourceID && merge.Reversible(now) {
+			return nil, fmt.Errorf("%w: the source has reversible merges into it", repository.ErrWalletMergeConflict)
+		}
+	}
+	for _, dispute := range s.disputes {
+		if dispute.WalletID == sourceID && !dispute.Status.IsResolved() {
+			return nil, fmt.Errorf("%w: the source has open disputes", repository.ErrWalletMergeConflict)
+		}
+	}
+	if !target.CanChangeBalance(source.MinorBalance()) {
+		return nil, fmt.Errorf("%w: the merged balance exceeds the target's credit limit", repository.ErrWalletMergeConflict)
+	}
+	amount := source.Balance
+	if err := s.moveBalance(ctx, source, target, amount, now); err != nil {
+		return nil, err
+	}
+
+	merge := &models.WalletMerge{
+		ID:              uuid.New(),
+		SourceWalletID:  sourceID,
+		TargetWalletID:  targetID,
+		Status:          models.WalletMerged,
+		Amount:          amount,
+		TransactionIDs:  []uuid.UUID{},
+		MergedAt:        now,
+		ReversibleUntil: reversibleUntil,
+	}
+	for _, tx := range s.transactions[sourceID] {
+		tx.WalletID = targetID
+		merge.TransactionIDs = append(merge.TransactionIDs, tx.ID)
+	}
+	s.transactions[targetID] = append(s.transactions[targetID], s.transactions[sourceID]...)
+	s.sortTransactions(targetID)
+	delete(s.transactions, sourceID)
+
+	s.merges[merge.ID] = merge
+
+	copied := *merge
+	return &copied, nil
+}
+
+// ReverseMerge moves a merge's transactions and balance back to the source
+// wallet within its reversal window
+func (s *Store) ReverseMerge(ctx context.Context, id uuid.UUID, now time.Time) (*models.WalletMerge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merge, ok := s.merges[id]
+	if !ok {
+		return nil, repository.ErrWalletMergeNotFound
+	}
+	if !merge.Reversible(now) {
+		return nil, fmt.Errorf("%w: the merge is reversed or its window has ended", repository.ErrWalletMergeConflict)
+	}
+	source, target := s.wallets[merge.SourceWalletID], s.wallets[merge.TargetWalletID]
+	if amount, err := models.MinorOfFloat(merge.Amount); err != nil || !target.CanChangeBalance(amount.Neg()) {
+		return nil, fmt.Errorf("%w: the target no longer holds the merged balance", repository.ErrWalletMergeConflict)
+	}
+
+	// The source takes postings again once the merge is reversed
+	merge.Status = models.WalletMergeReversed
+	if err := s.moveBalance(ctx, target, source, merge.Amount, now); err != nil {
+		merge.Status = models.WalletMerged
+		return nil, err
+	}
+	merge.ReversedAt = &now
+
+	moved := make(map[uuid.UUID]bool, len(merge.TransactionIDs))
+	for _, txID := range merge.TransactionIDs {
+		moved[txID] = true
+	}
+	var kept []*models.Transaction
+	for _, tx := range s.transactions[target.ID] {
+		if moved[tx.ID] {
+			tx.WalletID = source.ID
+			s.transactions[source.ID] = append(s.transactions[source.ID], tx)
+			continue
+		}
+		kept = append(kept, tx)
+	}
+	s.transactions[target.ID] = kept
+	s.sortTransactions(source.ID)
+
+	copied := *merge
+	return &copied, nil
+}
+
+// moveBalance applies the postings moving a merged balance between two
+// wallets, as the repository does in its unit of work
+func (s *Store) moveBalance(ctx context.Context, from, to *models.Wallet, amount float64, now time.Time) error {
+	if amount < 0 {
+		from, to, amount = to, from, -amount
+	}
+	if amount == 0 {
+		return nil
+	}
+
+	staged := &storeTx{store: s, wallets: make(map[uuid.UUID]*models.Wallet)}
+	for _, posting := range []struct {
+		wallet *models.Wallet
+		typ    models.TransactionType
+	}{
+		{from, models.TransactionTypeDebit},
+		{to, models.TransactionTypeCredit},
+	} {
+		wallet := *posting.wallet
+		err := staged.ApplyTransaction(ctx, &wallet, &models.Transaction{
+			WalletID:    wallet.ID,
+			Type:        posting.typ,
+			Status:      models.TransactionStatusCompleted,
+			Amount:      amount,
+			Currency:    wallet.Currency,
+			EffectiveAt: &now,
+			CreatedAt:   now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move merged balance: %w", err)
+		}
+	}
+
+	s.commit(staged)
+	return nil
+}
+
+// GetWalletMerge returns a merge by ID
+func (s *Store) GetWalletMerge(ctx context.Context, id uuid.UUID) (*models.WalletMerge, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	merge, ok := s.merges[id]
+	if !ok {
+		return nil, repository.ErrWalletMergeNotFound
+	}
+
+	copied := *merge
+	return &copied, nil
+}
+
+// GetMergeOf returns the merge redirecting a wallet to its surviving wallet
+func (s *Store) GetMergeOf(ctx context.Context, walletID uuid.UUID) (*models.WalletMerge, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	merge := s.mergeOf(walletID)
+	if merge == nil {
+		return nil, repository.ErrWalletMergeNotFound
+	}
+
+	copied := *merge
+	return &copied, nil
+}
+
+// sortTransactions keeps a wallet's transactions in creation order after
+// moving some between wallets. The caller holds the lock.
+func (s *Store) sortTransactions(walletID uuid.UUID) {
+	stored := s.transactions[walletID]
+	sort.SliceStable(stored, func(i, j int) bool {
+		return stored[i].CreatedAt.Before(stored[j].CreatedAt)
+	})
+}
+
+// mergeOf returns the merge redirecting a wallet, if any. The caller holds
+// the lock.
+func (s *Store) mergeOf(walletID uuid.UUID) *models.WalletMerge {
+	for _, merge := range s.merges {
+		if merge.SourceWalletID == walletID && merge.Status == models.WalletMerged {
+			return merge
+		}
+	}
+	return nil
+}
+
+// CreateFeeRule stores a fee rule
+func (s *Store) CreateFeeRule(ctx context.Context, rule *models.FeeRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *rule
+	s.feeRules[rule.ID] = &copied
+	return nil
+}
+
+// GetFeeRule retrieves a copy of a fee rule
+func (s *Store) GetFeeRule(ctx context.Context, id uuid.UUID) (*models.FeeRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rule, ok := s.feeRules[id]
+	if !ok {
+		return nil, repository.ErrFeeRuleNotFound
+	}
+	copied := *rule
+	return &copied, nil
+}
+
+// ListFeeRules returns the latest fee rules, newest first
+func (s *Store) ListFeeRules(ctx context.Context, limit int) ([]*models.FeeRule, error) {
+	rules := s.matchingFeeRules(func(*models.FeeRule) bool { return true })
+	sort.Slice(rules, func(i, j int) bool { return rules[i].CreatedAt.After(rules[j].CreatedAt) })
+	if len(rules) > limit {
+		rules = rules[:limit]
+	}
+	return rules, nil
+}
+
+// ListFeeRulesAt returns the rules matching a source and currency that are
+// in effect at a time
+func (s *Store) ListFeeRulesAt(ctx context.Context, source, currency string, at time.Time) ([]*models.FeeRule, error) {
+	return s.matchingFeeRules(func(rule *models.FeeRule) bool {
+		return rule.Matches(source, currency) && rule.InEffect(at)
+	}), nil
+}
+
+// EndFeeRule stops a rule from applying from a time on
+func (s *Store) EndFeeRule(ctx context.Context, id uuid.UUID, until time.Time) (*models.FeeRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rule, ok := s.feeRules[id]
+	if !ok {
+		return nil, repository.ErrFeeRuleNotFound
+	}
+	if !rule.EffectiveFrom.Before(until) || rule.EffectiveUntil != nil && !rule.EffectiveUntil.After(until) {
+		return nil, repository.ErrFeeRuleConflict
+	}
+	rule.EffectiveUntil = &until
+	copied := *rule
+	return &copied, nil
+}
+
+// matchingFeeRules returns copies of the fee rules matching keep
+func (s *Store) matchingFeeRules(keep func(*models.FeeRule) bool) []*models.FeeRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var rules []*models.FeeRule
+	for _, rule := range s.feeRules {
+		if keep(rule) {
+			copied := *rule
+			rules = append(rules, &copied)
+		}
+	}
+	return rules
+}
+
+// CreateWalletBatch stores copies of the rows of a batch as pending
+func (s *Store) CreateWalletBatch(ctx context.Context, batchID uuid.UUID, rows []*models.WalletBatchRow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch := &walletBatch{createdAt: s.clock.Now()}
+	for _, row := range rows {
+		copied := *row
+		copied.Status = models.WalletBatchRowPending
+		batch.rows = append(batch.rows, &copied)
+	}
+	s.batches[batchID] = batch
+	return nil
+}
+
+// ListWalletBatchRows returns copies of the rows of a batch in order
+func (s *Store) ListWalletBatchRows(ctx context.Context, batchID uuid.UUID) ([]*models.WalletBatchRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	batch, ok := s.batches[batchID]
+	if !ok {
+		return nil, nil
+	}
+	rows := make([]*models.WalletBatchRow, 0, len(batch.rows))
+	for _, row := range batch.rows {
+		copied := *row
+		rows = append(rows, &copied)
+	}
+	return rows, nil
+}
+
+// RecordWalletBatchRows stores the outcome of processed rows
+func (s *Store) RecordWalletBatchRows(ctx context.Context, batchID uuid.UUID, rows []*models.WalletBatchRow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch, ok := s.batches[batchID]
+	if !ok {
+		return fmt.Errorf("wallet batch %s not found", batchID)
+	}
+	for _, row := range rows {
+		if row.Index < 0 || row.Index >= len(batch.rows) {
+			return fmt.Errorf("wallet batch %s has no row %d", batchID, row.Index)
+		}
+		copied := *row
+		batch.rows[row.Index] = &copied
+	}
+	return nil
+}
+
+// PurgeWalletBatches deletes the batches created before a time
+func (s *Store) PurgeWalletBatches(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, batch := range s.batches {
+		if batch.createdAt.Before(before) {
+			purged += int64(len(batch.rows))
+			delete(s.batches, id)
+		}
+	}
+	return purged, nil
+}
+
+// StreamTransactions calls fn for copies of the transactions matching the
+// filter, oldest first. The store is read under one lock before fn is called.
+func (s *Store) StreamTransactions(ctx context.Context, filter models.TransactionExportFilter, fn func(*models.TransactionExportRow) error) error {
+	s.mu.RLock()
+	var rows []*models.TransactionExportRow
+	for walletID, stored := range s.transactions {
+		wallet := s.wallets[walletID]
+		if wallet == nil || (filter.CustomerID != nil && wallet.CustomerID != *filter.CustomerID) {
+			continue
+		}
+		for _, tx := range stored {
+			if tx.CreatedAt.Before(filter.From) || !tx.CreatedAt.Before(filter.To) {
+				continue
+			}
+			if filter.Type != "" && tx.Type.String() != filter.Type {
+				continue
+			}
+			rows = append(rows, &models.TransactionExportRow{
+				TransactionID: tx.ID.String(),
+				WalletID:      walletID.String(),
+				CustomerID:    wallet.CustomerID.String(),
+				Type:          tx.Type.String(),
+				Status:        tx.Status.String(),
+				Amount:        decimal.NewFromFloat(tx.Amount).String(),
+				Currency:      tx.Currency,
+				ReferenceID:   tx.ReferenceID,
+				Description:   tx.Description,
+				EffectiveAt:   tx.EffectiveAt,
+				CreatedAt:     tx.CreatedAt,
+			})
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].CreatedAt.Equal(rows[j].CreatedAt) {
+			return rows[i].TransactionID < rows[j].TransactionID
+		}
+		return rows[i].CreatedAt.Before(rows[j].CreatedAt)
+	})
+	for _, row := range rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnqueueNotification stores a copy of a notification as pending unless its
+// event is already queued
+func (s *Store) EnqueueNotification(ctx context.Context, notification *models.QueuedNotification) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.notifications[notification.EventID]; ok {
+		return false, nil
+	}
+	notification.ID = uuid.New()
+	notification.Status = models.QueuedNotificationPending
+	notification.UpdatedAt = notification.CreatedAt
+	copied := *notification
+	s.notifications[notification.EventID] = &copied
+	return true, nil
+}
+
+// ListDueNotifications returns copies of up to limit pending notifications
+// due by now and not yet expired, longest due first
+func (s *Store) ListDueNotifications(ctx context.Context, now time.Time, limit int) ([]*models.QueuedNotification, error) {
+	due := s.queuedNotifications(func(n *models.QueuedNotification) bool {
+		return n.Status == models.QueuedNotificationPending && !n.NextAttemptAt.After(now) && n.ExpiresAt.After(now)
+	})
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// DeleteNotification removes a notification
+func (s *Store) DeleteNotification(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for eventID, n := range s.notifications {
+		if n.ID == id {
+			delete(s.notifications, eventID)
+		}
+	}
+	return nil
+}
+
+// RescheduleNotification counts a failed attempt of a pending notification
+func (s *Store) RescheduleNotification(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, n := range s.notifications {
+		if n.ID == id && n.Status == models.QueuedNotificationPending {
+			n.Attempts++
+			n.NextAttemptAt = nextAttemptAt
+			n.LastError = lastError
+			n.UpdatedAt = s.clock.Now()
+		}
+	}
+	return nil
+}
+
+// ExpireNotifications marks the pending notifications expired by now
+func (s *Store) ExpireNotifications(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired int64
+	for _, n := range s.notifications {
+		if n.Status == models.QueuedNotificationPending && !n.ExpiresAt.After(now) {
+			n.Status = models.QueuedNotificationExpired
+			n.UpdatedAt = now
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// GetNotificationBacklog counts the queue and returns copies of up to limit
+// of the matching notifications, oldest first
+func (s *Store) GetNotificationBacklog(ctx context.Context, status models.QueuedNotificationStatus, eventType string, limit int) (*models.NotificationBacklog, error) {
+	all := s.queuedNotifications(func(*models.QueuedNotification) bool { return true })
+	sort.SliceStable(all, func(i, j int) bool { return all[i].CreatedAt.Before(all[j].CreatedAt) })
+
+	backlog := &models.NotificationBacklog{
+		PendingByType: make(map[string]int64),
+		Notifications: []*models.QueuedNotification{},
+	}
+	for _, n := range all {
+		if n.Status == models.QueuedNotificationExpired {
+			backlog.Expired++
+		} else {
+			backlog.Pending++
+			backlog.PendingByType[n.EventType]++
+			if backlog.OldestPending == nil {
+				oldest := n.CreatedAt
+				backlog.OldestPending = &oldest
+			}
+		}
+		if (status == "" || n.Status == status) && (eventType == "" || n.EventType == eventType) && len(backlog.Notifications) < limit {
+			backlog.Notifications = append(backlog.Notifications, n)
+		}
+	}
+	return backlog, nil
+}
+
+// PurgeNotifications deletes the notifications expired before a time
+func (s *Store) PurgeNotifications(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for eventID, n := range s.notifications {
+		if n.Status == models.QueuedNotificationExpired && n.ExpiresAt.Before(before) {
+			delete(s.notifications, eventID)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// queuedNotifications returns copies of the notifications matching keep
+func (s *Store) queuedNotifications(keep func(*models.QueuedNotification) bool) []*models.QueuedNotification {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var notifications []*models.QueuedNotification
+	for _, n := range s.notifications {
+		if keep(n) {
+			copied := *n
+			notifications = append(notifications, &copied)
+		}
+	}
+	return notifications
+}
+
+// GetBalanceThresholds returns a copy of the balance thresholds of a wallet
+func (s *Store) GetBalanceThresholds(ctx context.Context, walletID uuid.UUID) (*models.BalanceThresholds, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	thresholds, ok := s.thresholds[walletID]
+	if !ok {
+		return nil, repository.ErrBalanceThresholdsNotFound
+	}
+	copied := *thresholds
+	return &copied, nil
+}
+
+// UpsertBalanceThresholds stores a copy of the balance thresholds of a
+// wallet with both levels armed
+func (s *Store) UpsertBalanceThresholds(ctx context.Context, thresholds *models.BalanceThresholds) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	thresholds.Warning.Triggered = false
+	thresholds.Critical.Triggered = false
+	copied := *thresholds
+	s.thresholds[thresholds.WalletID] = &copied
+	return nil
+}
+
+// DeleteBalanceThresholds removes the balance thresholds of a wallet
+func (s *Store) DeleteBalanceThresholds(ctx context.Context, walletID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.thresholds[walletID]; !ok {
+		return repository.ErrBalanceThresholdsNotFound
+	}
+	delete(s.thresholds, walletID)
+	return nil
+}
+
+// SetBalanceAlertTriggered flips the triggered state of a level when it
+// differs
+func (s *Store) SetBalanceAlertTriggered(ctx context.Context, walletID uuid.UUID, level models.BalanceAlertLevel, triggered bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	thresholds, ok := s.thresholds[walletID]
+	if !ok {
+		return false, nil
+	}
+	state := thresholds.Level(level)
+	if state.Triggered == triggered {
+		return false, nil
+	}
+	state.Triggered = triggered
+	thresholds.UpdatedAt = s.clock.Now()
+	return true, nil
+}
+
+// ListBalanceAlerts returns copies of the alert thresholds of a wallet,
+// highest trigger amount first
+func (s *Store) ListBalanceAlerts(ctx context.Context, walletID uuid.UUID) ([]*models.BalanceAlertThreshold, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var alerts []*models.BalanceAlertThreshold
+	for _, alert := range s.balanceAlerts {
+		if alert.WalletID == walletID {
+			copied := *alert
+			alerts = append(alerts, &copied)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].TriggerAmount.Equal(alerts[j].TriggerAmount) {
+			return alerts[i].TriggerAmount.GreaterThan(alerts[j].TriggerAmount)
+		}
+		return alerts[i].CreatedAt.Before(alerts[j].CreatedAt)
+	})
+	return alerts, nil
+}
+
+// ReplaceBalanceAlerts replaces the alert thresholds of a wallet with armed
+// copies
+func (s *Store) ReplaceBalanceAlerts(ctx context.Context, walletID uuid.UUID, alerts []*models.BalanceAlertThreshold) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, alert := range s.balanceAlerts {
+		if alert.WalletID == walletID {
+			delete(s.balanceAlerts, id)
+		}
+	}
+	for _, alert := range alerts {
+		alert.Triggered = false
+		alert.TriggeredAt = nil
+		copied := *alert
+		copied.WalletID = walletID
+		s.balanceAlerts[alert.ID] = &copied
+	}
+	return nil
+}
+
+// SetBalanceAlertState flips the triggered state of an alert threshold when
+// it differs
+func (s *Store) SetBalanceAlertState(ctx context.Context, id uuid.UUID, triggered bool, triggerAmount decimal.Decimal, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alert, ok := s.balanceAlerts[id]
+	if !ok || alert.Triggered == triggered {
+		return false, nil
+	}
+	alert.Triggered = triggered
+	alert.TriggerAmount = triggerAmount
+	if triggered {
+		alert.TriggeredAt = &at
+	}
+	alert.UpdatedAt = at
+	return true, nil
+}
+
+// GetWalletFlows totals a wallet's completed debits and credits effective
+// since a time per period, oldest first
+func (s *Store) GetWalletFlows(ctx context.Context, walletID uuid.UUID, granularity models.AnalyticsGranularity, since time.Time) ([]*models.WalletFlow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byPeriod := make(map[time.Time]*models.WalletFlow)
+	var flows []*models.WalletFlow
+	for _, tx := range s.completedSince(walletID, since) {
+		if tx.Type == models.TransactionTypeAdjustment {
+			continue
+		}
+		start := granularity.Truncate(tx.EffectiveTime())
+		flow, ok := byPeriod[start]
+		if !ok {
+			flow = &models.WalletFlow{PeriodStart: start, Debits: decimal.Zero, Credits: decimal.Zero}
+			byPeriod[start] = flow
+			flows = append(flows, flow)
+		}
+		amount := decimal.NewFromFloat(tx.Amount)
+		if tx.Type == models.TransactionTypeDebit {
+			flow.Debits = flow.Debits.Add(amount)
+			flow.DebitCount++
+		} else {
+			flow.Credits = flow.Credits.Add(amount)
+			flow.CreditCount++
+		}
+	}
+
+	sort.Slice(flows, func(i, j int) bool {
+		return flows[i].PeriodStart.Before(flows[j].PeriodStart)
+	})
+	return flows, nil
+}
+
+// GetSpendCategories returns a wallet's largest spend categories since a
+// time, largest first
+func (s *Store) GetSpendCategories(ctx context.Context, walletID uuid.UUID, since time.Time, limit int) ([]*models.CategorySpend, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byName := make(map[string]*models.CategorySpend)
+	var categories []*models.CategorySpend
+	for _, tx := range s.completedSince(walletID, since) {
+		if tx.Type != models.TransactionTypeDebit {
+			continue
+		}
+		name := tx.Metadata["category"]
+		if name == "" {
+			name = tx.Description
+		}
+		if name == "" {
+			name = models.UncategorizedSpend
+		}
+		category, ok := byName[name]
+		if !ok {
+			category = &models.CategorySpend{Category: name, Total: decimal.Zero}
+			byName[name] = category
+			categories = append(categories, category)
+		}
+		category.Total = category.Total.Add(decimal.NewFromFloat(tx.Amount))
+		category.Count++
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		if !categories[i].Total.Equal(categories[j].Total) {
+			return categories[i].Total.GreaterThan(categories[j].Total)
+		}
+		return categories[i].Category < categories[j].Category
+	})
+	if len(categories) > limit {
+		categories = categories[:limit]
+	}
+	return categories, nil
+}
+
+// completedSince returns a wallet's completed transactions effective since
+// a time. Callers hold the lock.
+func (s *Store) completedSince(walletID uuid.UUID, since time.Time) []*models.Transaction {
+	var completed []*models.Transaction
+	for _, tx := range s.transactions[walletID] {
+		if tx.Status == models.TransactionStatusCompleted && !tx.EffectiveTime().Before(since) {
+			completed = append(completed, tx)
+		}
+	}
+	return completed
+}
+
+// CreateAdjustment stores a requested adjustment
+func (s *Store) CreateAdjustment(ctx context.Context, adjustment *models.Adjustment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *adjustment
+	s.adjustments[adjustment.ID] = &copied
+	return nil
+}
+
+// GetAdjustment retrieves a copy of an adjustment
+func (s *Store) GetAdjustment(ctx context.Context, id uuid.UUID) (*models.Adjustment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	adjustment, ok := s.adjustments[id]
+	if !ok {
+		return nil, repository.ErrAdjustmentNotFound
+	}
+	copied := *adjustment
+	return &copied, nil
+}
+
+// ListAdjustments returns the latest adjustments matching the filter,
+// newest first
+func (s *Store) ListAdjustments(ctx context.Context, filter models.AdjustmentFilter) ([]*models.Adjustment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var adjustments []*models.Adjustment
+	for _, adjustment := range s.adjustments {
+		if filter.WalletID != nil && adjustment.WalletID != *filter.WalletID {
+			continue
+		}
+		if filter.Status != "" && adjustment.Status != filter.Status {
+			continue
+		}
+		copied := *adjustment
+		adjustments = append(adjustments, &copied)
+	}
+	sort.Slice(adjustments, func(i, j int) bool { return adjustments[i].CreatedAt.After(adjustments[j].CreatedAt) })
+	if len(adjustments) > filter.Limit {
+		adjustments = adjustments[:filter.Limit]
+	}
+	return adjustments, nil
+}
+
+// ReviewAdjustment approves or rejects a pending adjustment that has not
+// expired
+func (s *Store) ReviewAdjustment(ctx context.Context, id uuid.UUID, status models.AdjustmentStatus, reviewer, note string, transactionID *uuid.UUID, now time.Time) (*models.Adjustment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	adjustment, ok := s.adjustments[id]
+	if !ok {
+		return nil, repository.ErrAdjustmentNotFound
+	}
+	if adjustment.Status != models.AdjustmentPending || (adjustment.ExpiresAt != nil && !adjustment.ExpiresAt.After(now)) {
+		return nil, repository.ErrAdjustmentConflict
+	}
+	adjustment.Status = status
+	adjustment.ReviewedBy = reviewer
+	adjustment.ReviewNote = note
+	adjustment.TransactionID = transactionID
+	reviewed := now
+	adjustment.ReviewedAt = &reviewed
+	copied := *adjustment
+	return &copied, nil
+}
+
+// ReleaseAdjustment returns an approved adjustment to review
+func (s *Store) ReleaseAdjustment(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	adjustment, ok := s.adjustments[id]
+	if ok && adjustment.Status == models.AdjustmentApproved {
+		adjustment.Status = models.AdjustmentPending
+		adjustment.ReviewedBy = ""
+		adjustment.ReviewNote = ""
+		adjustment.ReviewedAt = nil
+		adjustment.TransactionID = nil
+	}
+	return nil
+}
+
+// MarkPosted records that an approved adjustment was posted
+func (s *Store) MarkPosted(ctx context.Context, id uuid.UUID, now time.Time) (*models.Adjustment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	adjustment, ok := s.adjustments[id]
+	if !ok || adjustment.Status != models.AdjustmentApproved {
+		return nil, repository.ErrAdjustmentConflict
+	}
+	adjustment.Status = models.AdjustmentPosted
+	posted := now
+	adjustment.PostedAt = &posted
+	copied := *adjustment
+	return &copied, nil
+}
+
+// ExpireAdjustments expires the pending adjustments due to expire by now
+func (s *Store) ExpireAdjustments(ctx context.Context, now time.Time) ([]*models.Adjustment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []*models.Adjustment
+	for _, adjustment := range s.adjustments {
+		if adjustment.Status != models.AdjustmentPending || adjustment.ExpiresAt == nil || adjustment.ExpiresAt.After(now) {
+			continue
+		}
+		adjustment.Status = models.AdjustmentExpired
+		copied := *adjustment
+		expired = append(expired, &copied)
+	}
+	return expired, nil
+}
+
+// SummarizeAdjustments totals the adjustments posted in [from, to) by
+// reason code, direction and currency
+func (s *Store) SummarizeAdjustments(ctx context.Context, from, to time.Time) ([]*models.AdjustmentTotal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type key struct {
+		reason    models.AdjustmentReason
+		direction models.AdjustmentDirection
+		currency  string
+	}
+	byKey := make(map[key]*models.AdjustmentTotal)
+	var totals []*models.AdjustmentTotal
+	for _, adjustment := range s.adjustments {
+		if adjustment.Status != models.AdjustmentPosted || adjustment.PostedAt.Before(from) || !adjustment.PostedAt.Before(to) {
+			continue
+		}
+		k := key{adjustment.ReasonCode, adjustment.Direction, adjustment.Currency}
+		total, ok := byKey[k]
+		if !ok {
+			total = &models.AdjustmentTotal{ReasonCode: k.reason, Direction: k.direction, Currency: k.currency, Total: decimal.Zero}
+			byKey[k] = total
+			totals = append(totals, total)
+		}
+		total.Count++
+		total.Total = total.Total.Add(adjustment.Amount)
+	}
+
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].ReasonCode != totals[j].ReasonCode {
+			return totals[i].ReasonCode < totals[j].ReasonCode
+		}
+		if totals[i].Direction != totals[j].Direction {
+			return totals[i].Direction < totals[j].Direction
+		}
+		return totals[i].Currency < totals[j].Currency
+	})
+	return totals, nil
+}
+
+// GetSettings retrieves a copy of a customer's settings. Customers owning a
+// wallet exist with default settings until changed.
+func (s *Store) GetSettings(ctx context.Context, customerID uuid.UUID) (*models.CustomerSettings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	settings, err := s.customerSettings(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if !tenancy.Allows(ctx, settings.OrgID) {
+		return nil, repository.ErrCustomerNotFound
+	}
+	copied := *settings
+	return &copied, nil
+}
+
+// UpdateTimezone sets the timezone of a customer
+func (s *Store) UpdateTimezone(ctx context.Context, customerID uuid.UUID, timezone string) (*models.CustomerSettings, error) {
+	return s.updateCustomer(ctx, customerID, func(settings *models.CustomerSettings) {
+		settings.Timezone = timezone
+	})
+}
+
+// UpdateTaxProfile sets the tax region and exemption of a customer
+func (s *Store) UpdateTaxProfile(ctx context.Context, customerID uuid.UUID, region string, exempt bool) (*models.CustomerSettings, error) {
+	return s.updateCustomer(ctx, customerID, func(settings *models.CustomerSettings) {
+		settings.TaxRegion = region
+		settings.TaxExempt = exempt
+	})
+}
+
+// UpdatePaymentTerms sets the payment terms of a customer
+func (s *Store) UpdatePaymentTerms(ctx context.Context, customerID uuid.UUID, terms models.PaymentTerms) (*models.CustomerSettings, error) {
+	return s.updateCustomer(ctx, customerID, func(settings *models.CustomerSettings) {
+		settings.PaymentTerms = terms
+	})
+}
+
+// UpdateBillingMode sets the billing mode of a customer
+func (s *Store) UpdateBillingMode(ctx context.Context, customerID uuid.UUID, mode models.BillingMode, settlement models.PostpaidSettlement) (*models.CustomerSettings, error) {
+	return s.updateCustomer(ctx, customerID, func(settings *models.CustomerSettings) {
+		settings.BillingMode = mode
+		settings.PostpaidSettlement = settlement
+	})
+}
+
+// updateCustomer applies update to a customer's settings
+func (s *Store) updateCustomer(ctx context.Context, customerID uuid.UUID, update func(*models.CustomerSettings)) (*models.CustomerSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, err := s.customerSettings(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if !tenancy.Allows(ctx, settings.OrgID) {
+		return nil, repository.ErrCustomerNotFound
+	}
+	update(settings)
+	settings.UpdatedAt = s.clock.Now()
+	s.customers[customerID] = settings
+	copied := *settings
+	return &copied, nil
+}
+
+// customerSettings returns the stored settings of a customer, or defaults
+// for a customer owning a wallet. Callers hold the lock.
+func (s *Store) customerSettings(customerID uuid.UUID) (*models.CustomerSettings, error) {
+	if settings, ok := s.customers[customerID]; ok {
+		return settings, nil
+	}
+	for _, wallet := range s.wallets {
+		if wallet.CustomerID == customerID {
+			return &models.CustomerSettings{
+				CustomerID:  customerID,
+				Timezone:    models.DefaultTimezone,
+				BillingMode: models.BillingPrepaid,
+				UpdatedAt:   wallet.CreatedAt,
+			}, nil
+		}
+	}
+	return nil, repository.ErrCustomerNotFound
+}
+
+// RecordTax stores a tax record unless its source and reference were
+// recorded already
+func (s *Store) RecordTax(ctx context.Context, record *models.TaxRecord) (*models.TaxRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stored := range s.taxRecords {
+		if stored.Source == record.Source && stored.Reference == record.Reference {
+			copied := *stored
+			return &copied, false, nil
+		}
+	}
+	stored := *record
+	s.taxRecords = append(s.taxRecords, &stored)
+	copied := stored
+	return &copied, true, nil
+}
+
+// SummarizeTax totals the tax recorded in [from, to) by region, component,
+// source and currency
+func (s *Store) SummarizeTax(ctx context.Context, from, to time.Time) ([]*models.TaxReportRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type key struct {
+		region, tax, component string
+		source                 models.TaxSource
+		currency               string
+	}
+	byKey := make(map[key]*models.TaxReportRow)
+	var rows []*models.TaxReportRow
+	for _, record := range s.taxRecords {
+		if record.CreatedAt.Before(from) || !record.CreatedAt.Before(to) {
+			continue
+		}
+		for _, line := range record.Breakdown.Lines {
+			k := key{record.Breakdown.Region, record.Breakdown.Tax, line.Name, record.Source, record.Currency}
+			row, ok := byKey[k]
+			if !ok {
+				row = &models.TaxReportRow{
+					Region:    k.region,
+					Tax:       k.tax,
+					Component: k.component,
+					Source:    k.source,
+					Currency:  k.currency,
+					Taxable:   decimal.Zero,
+					Total:     decimal.Zero,
+				}
+				byKey[k] = row
+				rows = append(rows, row)
+			}
+			row.Count++
+			row.Taxable = row.Taxable.Add(record.Breakdown.Taxable)
+			row.Total = row.Total.Add(line.Amount)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.Tax != b.Tax {
+			return a.Tax < b.Tax
+		}
+		if a.Component != b.Component {
+			return a.Component < b.Component
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Currency < b.Currency
+	})
+	return rows, nil
+}
+
+// CreateCoupon stores a coupon unless its code is taken
+func (s *Store) CreateCoupon(ctx context.Context, coupon *models.Coupon) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stored := range s.coupons {
+		if stored.Code == coupon.Code {
+			return repository.ErrCouponConflict
+		}
+	}
+	if coupon.CustomerID != nil {
+		if _, err := s.customerSettings(*coupon.CustomerID); err != nil {
+			return err
+		}
+	}
+	copied := *coupon
+	s.coupons[coupon.ID] = &copied
+	return nil
+}
+
+// GetCoupon retrieves a copy of a coupon
+func (s *Store) GetCoupon(ctx context.Context, id uuid.UUID) (*models.Coupon, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	coupon, ok := s.coupons[id]
+	if !ok {
+		return nil, repository.ErrCouponNotFound
+	}
+	copied := *coupon
+	return &copied, nil
+}
+
+// GetCouponByCode retrieves a copy of the coupon with a code
+func (s *Store) GetCouponByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, coupon := range s.coupons {
+		if coupon.Code == code {
+			copied := *coupon
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrCouponNotFound
+}
+
+// ListCoupons returns the latest coupons in a status, or in any status when
+// it is empty, newest first
+func (s *Store) ListCoupons(ctx context.Context, status models.CouponStatus, limit int) ([]*models.Coupon, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var coupons []*models.Coupon
+	for _, coupon := range s.coupons {
+		if status == "" || coupon.Status == status {
+			copied := *coupon
+			coupons = append(coupons, &copied)
+		}
+	}
+	sort.Slice(coupons, func(i, j int) bool { return coupons[i].CreatedAt.After(coupons[j].CreatedAt) })
+	if len(coupons) > limit {
+		coupons = coupons[:limit]
+	}
+	return coupons, nil
+}
+
+// VoidCoupon voids an active coupon
+func (s *Store) VoidCoupon(ctx context.Context, id uuid.UUID, actor, reason string, now time.Time) (*models.Coupon, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	coupon, ok := s.coupons[id]
+	if !ok {
+		return nil, repository.ErrCouponNotFound
+	}
+	if coupon.Status != models.CouponActive {
+		return nil, repository.ErrCouponConflict
+	}
+	coupon.Status = models.CouponVoided
+	coupon.VoidedBy = actor
+	coupon.VoidReason = reason
+	coupon.VoidedAt = &now
+	copied := *coupon
+	return &copied, nil
+}
+
+// RedeemCoupon stores a redemption of an active coupon within its limits,
+// unless its source and reference were redeemed already
+func (s *Store) RedeemCoupon(ctx context.Context, redemption *models.CouponRedemption) (*models.CouponRedemption, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	coupon, ok := s.coupons[redemption.CouponID]
+	if !ok {
+		return nil, false, repository.ErrCouponNotFound
+	}
+	var byCustomer int64
+	for _, stored := range s.redemptions {
+		if stored.CouponID != coupon.ID {
+			continue
+		}
+		if stored.Source == redemption.Source && stored.Reference == redemption.Reference {
+			copied := *stored
+			return &copied, false, nil
+		}
+		if stored.CustomerID == redemption.CustomerID {
+			byCustomer++
+		}
+	}
+	switch {
+	case coupon.Status != models.CouponActive:
+		return nil, false, repository.ErrCouponConflict
+	case coupon.MaxRedemptions != nil && coupon.Redemptions >= *coupon.MaxRedemptions,
+		coupon.MaxRedemptionsPerCustomer != nil && byCustomer >= *coupon.MaxRedemptionsPerCustomer:
+		return nil, false, repository.ErrCouponExhausted
+	}
+
+	stored := *redemption
+	stored.Code = coupon.Code
+	s.redemptions = append(s.redemptions, &stored)
+	coupon.Redemptions++
+	copied := stored
+	return &copied, true, nil
+}
+
+// ListRedemptions returns the latest redemptions of a coupon, newest first
+func (s *Store) ListRedemptions(ctx context.Context, couponID uuid.UUID, limit int) ([]*models.CouponRedemption, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var redemptions []*models.CouponRedemption
+	for i := len(s.redemptions) - 1; i >= 0 && len(redemptions) < limit; i-- {
+		if s.redemptions[i].CouponID == couponID {
+			copied := *s.redemptions[i]
+			redemptions = append(redemptions, &copied)
+		}
+	}
+	return redemptions, nil
+}
+
+// MarkInactiveWallets flags the longest idle active wallets inactive,
+// skipping merged ones
+func (s *Store) MarkInactiveWallets(ctx context.Context, idleBefore, now time.Time, limit int) ([]*models.Wallet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var idle []*models.Wallet
+	for _, wallet := range s.wallets {
+		if wallet.InactiveSince == nil && wallet.LastActivityAt != nil && wallet.LastActivityAt.Before(idleBefore) && s.mergeOf(wallet.ID) == nil {
+			idle = append(idle, wallet)
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool {
+		return idle[i].LastActivityAt.Before(*idle[j].LastActivityAt)
+	})
+	if len(idle) > limit {
+		idle = idle[:limit]
+	}
+
+	flagged := make([]*models.Wallet, 0, len(idle))
+	for _, wallet := range idle {
+		since := now
+		wallet.InactiveSince = &since
+		wallet.UpdatedAt = s.clock.Now()
+		copied := *wallet
+		flagged = append(flagged, &copied)
+	}
+	return flagged, nil
+}
+
+// MarkReactivatedWallets clears the inactive flag of wallets that
+// transacted since, longest inactive first
+func (s *Store) MarkReactivatedWallets(ctx context.Context, limit int) ([]*models.WalletReactivation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var active []*models.Wallet
+	for _, wallet := range s.wallets {
+		if wallet.InactiveSince != nil && wallet.LastActivityAt != nil && wallet.LastActivityAt.After(*wallet.InactiveSince) {
+			active = append(active, wallet)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].InactiveSince.Before(*active[j].InactiveSince)
+	})
+	if len(active) > limit {
+		active = active[:limit]
+	}
+
+	reactivations := make([]*models.WalletReactivation, 0, len(active))
+	for _, wallet := range active {
+		inactiveSince := *wallet.InactiveSince
+		wallet.InactiveSince = nil
+		wallet.UpdatedAt = s.clock.Now()
+		copied := *wallet
+		reactivations = append(reactivations, &models.WalletReactivation{Wallet: &copied, InactiveSince: inactiveSince})
+	}
+	return reactivations, nil
+}
+
+// OpenDispute records a dispute of a completed top-up and holds the
+// disputed amount with a PROCESSING debit
+func (s *Store) OpenDispute(ctx context.Context, dispute *models.Dispute, description string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var disputed *models.Transaction
+	for _, tx := range s.transactions[dispute.WalletID] {
+		if tx.ID == dispute.TransactionID && tx.Type == models.TransactionTypeCredit && tx.Status == models.TransactionStatusCompleted {
+			disputed = tx
+		}
+	}
+	if disputed == nil {
+		return repository.ErrTransactionNotDisputable
+	}
+	paid := decimal.NewFromFloat(disputed.Amount)
+	if dispute.Amount.IsZero() {
+		dispute.Amount = paid
+	}
+	if dispute.Amount.GreaterThan(paid) {
+		return repository.ErrDisputeExceedsAmount
+	}
+	for _, existing := range s.disputes {
+		if existing.TransactionID == dispute.TransactionID {
+			return repository.ErrDisputeConflict
+		}
+	}
+
+	// The hold posts like a debit in a unit of work, into the wallet's
+	// credit limit if need be
+	staged := &storeTx{store: s, wallets: make(map[uuid.UUID]*models.Wallet)}
+	wallet, err := staged.GetWalletForUpdate(ctx, dispute.WalletID)
+	if err != nil {
+		return err
+	}
+	amount, _ := dispute.Amount.Float64()
+	if !wallet.HasSufficientBalance(amount) {
+		return repository.ErrInsufficientBalance
+	}
+	hold := &models.Transaction{
+		WalletID:    dispute.WalletID,
+		Type:        models.TransactionTypeDebit,
+		Status:      models.TransactionStatusProcessing,
+		Amount:      amount,
+		Currency:    disputed.Currency,
+		Description: description,
+		ReferenceID: dispute.TransactionID.String(),
+	}
+	if err := staged.ApplyTransaction(ctx, wallet, hold); err != nil {
+		return err
+	}
+	if err := staged.InsertTransaction(ctx, hold); err != nil {
+		return err
+	}
+	s.commit(staged)
+
+	dispute.ID = uuid.New()
+	dispute.HoldTransactionID = hold.ID
+	dispute.Currency = disputed.Currency
+	dispute.Status = models.DisputeOpen
+	dispute.CreatedAt = hold.CreatedAt
+	dispute.UpdatedAt = hold.CreatedAt
+	copied := *dispute
+	s.disputes[dispute.ID] = &copied
+	return nil
+}
+
+// GetDispute retrieves a copy of a dispute
+func (s *Store) GetDispute(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dispute, ok := s.disputes[id]
+	if !ok {
+		return nil, repository.ErrDisputeNotFound
+	}
+	copied := *dispute
+	return &copied, nil
+}
+
+// ListDisputes returns the latest disputes matching the filter, newest first
+func (s *Store) ListDisputes(ctx context.Context, filter models.DisputeFilter) ([]*models.Dispute, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var disputes []*models.Dispute
+	for _, dispute := range s.disputes {
+		if filter.WalletID != nil && dispute.WalletID != *filter.WalletID {
+			continue
+		}
+		if filter.Status != "" && dispute.Status != filter.Status {
+			continue
+		}
+		copied := *dispute
+		disputes = append(disputes, &copied)
+	}
+	sort.Slice(disputes, func(i, j int) bool { return disputes[i].CreatedAt.After(disputes[j].CreatedAt) })
+	if len(disputes) > filter.Limit {
+		disputes = disputes[:filter.Limit]
+	}
+	return disputes, nil
+}
+
+// SubmitEvidence records the evidence submitted for an open dispute
+func (s *Store) SubmitEvidence(ctx context.Context, id uuid.UUID, evidence string, now time.Time) (*models.Dispute, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dispute, ok := s.disputes[id]
+	if !ok {
+		return nil, repository.ErrDisputeNotFound
+	}
+	if dispute.Status != models.DisputeOpen {
+		return nil, repository.ErrDisputeConflict
+	}
+	dispute.Status = models.DisputeEvidenceSubmitted
+	dispute.Evidence = evidence
+	submitted := now
+	dispute.EvidenceSubmittedAt = &submitted
+	dispute.UpdatedAt = now
+	copied := *dispute
+	return &copied, nil
+}
+
+// ResolveDispute settles an unresolved dispute, releasing the hold of won
+// disputes and completing the hold of lost ones
+func (s *Store) ResolveDispute(ctx context.Context, id uuid.UUID, status models.DisputeStatus, resolver, note string, now time.Time) (*models.Dispute, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dispute, ok := s.disputes[id]
+	if !ok {
+		return nil, repository.ErrDisputeNotFound
+	}
+	if dispute.Status.IsResolved() {
+		return nil, repository.ErrDisputeConflict
+	}
+
+	// A won dispute credits the held amount back in a unit of work
+	holdStatus := models.TransactionStatusCompleted
+	if status == models.DisputeWon {
+		holdStatus = models.TransactionStatusReversed
+		staged := &storeTx{store: s, wallets: make(map[uuid.UUID]*models.Wallet)}
+		wallet, err := staged.GetWalletForUpdate(ctx, dispute.WalletID)
+		if err != nil {
+			return nil, err
+		}
+		amount, _ := dispute.Amount.Float64()
+		err = staged.ApplyTransaction(ctx, wallet, &models.Transaction{
+			WalletID: dispute.WalletID,
+			Type:     models.TransactionTypeCredit,
+			Status:   models.TransactionStatusCompleted,
+			Amount:   amount,
+			Currency: dispute.Currency,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to release disputed funds: %w", err)
+		}
+		s.commit(staged)
+	}
+
+	dispute.Status = status
+	dispute.ResolvedBy = resolver
+	dispute.ResolutionNote = note
+	resolved := now
+	dispute.ResolvedAt = &resolved
+	dispute.UpdatedAt = now
+	for _, tx := range s.transactions[dispute.WalletID] {
+		if tx.ID == dispute.HoldTransactionID && tx.Status == models.TransactionStatusProcessing {
+			tx.Status = holdStatus
+		}
+	}
+
+	copied := *dispute
+	return &copied, nil
+}
+
+// CreateBulkCredit stores copies of an operation and its rows as pending
+func (s *Store) CreateBulkCredit(ctx context.Context, credit *models.BulkCredit, rows []*models.BulkCreditRow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *credit
+	stored := &bulkCredit{credit: &copied}
+	for _, row := range rows {
+		copiedRow := *row
+		copiedRow.Status = models.BulkCreditRowPending
+		stored.rows = append(stored.rows, &copiedRow)
+	}
+	s.bulkCredits[credit.ID] = stored
+	return nil
+}
+
+// SetBulkCreditJob records the job posting an operation's credits
+func (s *Store) SetBulkCreditJob(ctx context.Context, id, jobID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.bulkCredits[id]
+	if !ok {
+		return repository.ErrBulkCreditNotFound
+	}
+	stored.credit.JobID = &jobID
+	return nil
+}
+
+// GetBulkCredit retrieves a copy of a bulk credit operation by ID
+func (s *Store) GetBulkCredit(ctx context.Context, id uuid.UUID) (*models.BulkCredit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.bulkCredits[id]
+	if !ok {
+		return nil, repository.ErrBulkCreditNotFound
+	}
+	copied := *stored.credit
+	return &copied, nil
+}
+
+// ListBulkCreditRows returns copies of the rows of an operation in order
+func (s *Store) ListBulkCreditRows(ctx context.Context, id uuid.UUID) ([]*models.BulkCreditRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.bulkCredits[id]
+	if !ok {
+		return nil, nil
+	}
+	rows := make([]*models.BulkCreditRow, 0, len(stored.rows))
+	for _, row := range stored.rows {
+		copied := *row
+		rows = append(rows, &copied)
+	}
+	return rows, nil
+}
+
+// RecordBulkCreditRows stores the outcome of processed rows
+func (s *Store) RecordBulkCreditRows(ctx context.Context, id uuid.UUID, rows []*models.BulkCreditRow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.bulkCredits[id]
+	if !ok {
+		return fmt.Errorf("bulk credit %s not found", id)
+	}
+	for _, row := range rows {
+		if row.Index < 0 || row.Index >= len(stored.rows) {
+			return fmt.Errorf("bulk credit %s has no row %d", id, row.Index)
+		}
+		copied := *row
+		stored.rows[row.Index] = &copied
+	}
+	return nil
+}
+
+// RequestBulkCreditRollback records the job rolling an operation back,
+// once only
+func (s *Store) RequestBulkCreditRollback(ctx context.Context, id, jobID uuid.UUID, actor string, now time.Time) (*models.BulkCredit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.bulkCredits[id]
+	if !ok {
+		return nil, repository.ErrBulkCreditNotFound
+	}
+	if stored.credit.RollbackJobID != nil {
+		return nil, repository.ErrBulkCreditConflict
+	}
+	stored.credit.RollbackJobID = &jobID
+	stored.credit.RollbackRequestedBy = actor
+	requested := now
+	stored.credit.RollbackRequestedAt = &requested
+	copied := *stored.credit
+	return &copied, nil
+}
+
+// PurgeBulkCredits deletes the operations created before a time with their
+// rows
+func (s *Store) PurgeBulkCredits(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, stored := range s.bulkCredits {
+		if stored.credit.CreatedAt.Before(before) {
+			purged++
+			delete(s.bulkCredits, id)
+		}
+	}
+	return purged, nil
+}
+
+// activeIncident returns the stored incident not yet resumed, with s.mu
+// held
+func (s *Store) activeIncident() *models.Incident {
+	for _, incident := range s.incidents {
+		if incident.ResumedAt == nil {
+			return incident
+		}
+	}
+	return nil
+}
+
+// copyIncident copies an incident with its allowed sources
+func copyIncident(incident *models.Incident) *models.Incident {
+	copied := *incident
+	copied.AllowedSources = append([]string(nil), incident.AllowedSources...)
+	return &copied
+}
+
+// GetActiveIncident retrieves a copy of the incident not yet resumed
+func (s *Store) GetActiveIncident(ctx context.Context) (*models.Incident, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	incident := s.activeIncident()
+	if incident == nil {
+		return nil, repository.ErrIncidentNotFound
+	}
+	return copyIncident(incident), nil
+}
+
+// StartIncident stores a copy of a new incident, failing while another one
+// is active
+func (s *Store) StartIncident(ctx context.Context, incident *models.Incident) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.activeIncident() != nil {
+		return repository.ErrIncidentConflict
+	}
+	s.incidents = append(s.incidents, copyIncident(incident))
+	return nil
+}
+
+// UpdateIncident changes what the active incident pauses
+func (s *Store) UpdateIncident(ctx context.Context, incident *models.Incident) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := s.activeIncident()
+	if active == nil || active.ID != incident.ID {
+		return repository.ErrIncidentNotFound
+	}
+	updated := copyIncident(incident)
+	updated.StartedBy = active.StartedBy
+	updated.StartedAt = active.StartedAt
+	*active = *updated
+	return nil
+}
+
+// ResumeIncident resumes the active incident, once only
+func (s *Store) ResumeIncident(ctx context.Context, id uuid.UUID, actor string, now time.Time) (*models.Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := s.activeIncident()
+	if active == nil || active.ID != id {
+		return nil, repository.ErrIncidentNotFound
+	}
+	active.ResumedBy = actor
+	active.ResumedAt = &now
+	return copyIncident(active), nil
+}
+
+// copyNotificationChannel copies a notification channel with its event
+// types
+func copyNotificationChannel(channel *models.NotificationChannel) *models.NotificationChannel {
+	copied := *channel
+	copied.EventTypes = append([]string{}, channel.EventTypes...)
+	return &copied
+}
+
+// CreateNotificationChannel stores a copy of a new notification channel,
+// one per customer, kind and destination
+func (s *Store) CreateNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stored := range s.channels {
+		if stored.CustomerID == channel.CustomerID && stored.Kind == channel.Kind && stored.Destination == channel.Destination {
+			return repository.ErrNotificationChannelExists
+		}
+	}
+	channel.ID = uuid.New()
+	channel.CreatedAt = s.clock.Now()
+	channel.UpdatedAt = channel.CreatedAt
+	s.channels = append(s.channels, copyNotificationChannel(channel))
+	return nil
+}
+
+// UpdateNotificationChannel replaces the destination and rules of one of a
+// customer's channels, keeping its kind
+func (s *Store) UpdateNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current *models.NotificationChannel
+	for _, stored := range s.channels {
+		if stored.ID == channel.ID && stored.CustomerID == channel.CustomerID {
+			current = stored
+		}
+	}
+	if current == nil {
+		return repository.ErrNotificationChannelNotFound
+	}
+	for _, stored := range s.channels {
+		if stored != current && stored.CustomerID == channel.CustomerID && stored.Kind == current.Kind &&
+			stored.Destination == channel.Destination {
+			return repository.ErrNotificationChannelExists
+		}
+	}
+
+	channel.Kind = current.Kind
+	channel.CreatedAt = current.CreatedAt
+	channel.UpdatedAt = s.clock.Now()
+	*current = *copyNotificationChannel(channel)
+	return nil
+}
+
+// DeleteNotificationChannel removes one of a customer's channels
+func (s *Store) DeleteNotificationChannel(ctx context.Context, customerID, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, stored := range s.channels {
+		if stored.ID == id && stored.CustomerID == customerID {
+			s.channels = append(s.channels[:i], s.channels[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotificationChannelNotFound
+}
+
+// GetNotificationChannel retrieves a copy of one of a customer's channels
+func (s *Store) GetNotificationChannel(ctx context.Context, customerID, id uuid.UUID) (*models.NotificationChannel, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, stored := range s.channels {
+		if stored.ID == id && stored.CustomerID == customerID {
+			return copyNotificationChannel(stored), nil
+		}
+	}
+	return nil, repository.ErrNotificationChannelNotFound
+}
+
+// ListNotificationChannels retrieves copies of a customer's channels in the
+// order they were created
+func (s *Store) ListNotificationChannels(ctx context.Context, customerID uuid.UUID) ([]*models.NotificationChannel, error) {
+	return s.notificationChannels(customerID, false), nil
+}
+
+// ListActiveNotificationChannels retrieves copies of a customer's enabled
+// channels
+func (s *Store) ListActiveNotificationChannels(ctx context.Context, customerID uuid.UUID) ([]*models.NotificationChannel, error) {
+	return s.notificationChannels(customerID, true), nil
+}
+
+// ListActiveNotificationChannelsForWallet retrieves copies of the enabled
+// channels of a wallet's owner
+func (s *Store) ListActiveNotificationChannelsForWallet(ctx context.Context, walletID uuid.UUID) ([]*models.NotificationChannel, error) {
+	s.mu.RLock()
+	wallet, ok := s.wallets[walletID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	return s.notificationChannels(wallet.CustomerID, true), nil
+}
+
+// notificationChannels copies a customer's channels, only the enabled ones
+// when active is set
+func (s *Store) notificationChannels(customerID uuid.UUID, active bool) []*models.NotificationChannel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var channels []*models.NotificationChannel
+	for _, stored := range s.channels {
+		if stored.CustomerID == customerID && (stored.Enabled || !active) {
+			channels = append(channels, copyNotificationChannel(stored))
+		}
+	}
+	return channels
+}
+
+// RecordDataAccess stores a copy of an access, attributing accesses naming a
+// wallet to its owner and skipping those to unknown wallets
+func (s *Store) RecordDataAccess(ctx context.Context, access *models.DataAccess) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if access.ID == uuid.Nil {
+		access.ID = uuid.New()
+	}
+	if access.WalletID != nil {
+		wallet, ok := s.wallets[*access.WalletID]
+		if !ok {
+			return nil
+		}
+		customerID := wallet.CustomerID
+		access.CustomerID = &customerID
+	}
+
+	copied := *access
+	copied.Roles = append([]string(nil), access.Roles...)
+	s.dataAccesses = append(s.dataAccesses, &copied)
+	return nil
+}
+
+// ListDataAccesses returns copies of a customer's accesses matching the
+// filter, newest first, including accesses to every customer's data
+func (s *Store) ListDataAccesses(ctx context.Context, customerID uuid.UUID, filter models.DataAccessFilter, limit, offset int) ([]*models.DataAccess, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var accesses []*models.DataAccess
+	for _, stored := range s.dataAccesses {
+		if stored.CustomerID != nil && *stored.CustomerID != customerID {
+			continue
+		}
+		if !filter.From.IsZero() && stored.AccessedAt.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !stored.AccessedAt.Before(filter.To) {
+			continue
+		}
+		if filter.Action != "" && stored.Action != filter.Action {
+			continue
+		}
+		copied := *stored
+		accesses = append(accesses, &copied)
+	}
+
+	sort.SliceStable(accesses, func(i, j int) bool {
+		return accesses[i].AccessedAt.After(accesses[j].AccessedAt)
+	})
+	if offset >= len(accesses) {
+		return nil, nil
+	}
+	accesses = accesses[offset:]
+	if limit < len(accesses) {
+		accesses = accesses[:limit]
+	}
+	return accesses, nil
+}
+
+// PurgeDataAccesses deletes accesses older than the given time
+func (s *Store) PurgeDataAccesses(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.dataAccesses[:0]
+	for _, access := range s.dataAccesses {
+		if access.AccessedAt.Before(before) {
+			continue
+		}
+		kept = append(kept, access)
+	}
+	purged := int64(len(s.dataAccesses) - len(kept))
+	s.dataAccesses = kept
+	return purged, nil
+}
+
+// CreateInvoiceDocument stores a copy of an invoice's PDF
+func (s *Store) CreateInvoiceDocument(ctx context.Context, doc *models.InvoicePDF, pdf []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, stored := range s.invoices {
+		if id == doc.InvoiceID ||
+			(stored.doc.CustomerID == doc.CustomerID && stored.doc.InvoiceNumber == doc.InvoiceNumber) {
+			return repository.ErrInvoiceDocumentExists
+		}
+	}
+
+	doc.SizeBytes = len(pdf)
+	copied := *doc
+	s.invoices[doc.InvoiceID] = &invoiceDocument{doc: &copied, pdf: append([]byte(nil), pdf...)}
+	return nil
+}
+
+// GetInvoiceDocument returns a copy of an invoice's stored PDF
+func (s *Store) GetInvoiceDocument(ctx context.Context, invoiceID uuid.UUID) (*models.InvoicePDF, []byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.invoices[invoiceID]
+	if !ok {
+		return nil, nil, repository.ErrInvoiceDocumentNotFound
+	}
+	copied := *stored.doc
+	return &copied, append([]byte(nil), stored.pdf...), nil
+}
+
+// RecordInvoiceDelivery counts a delivery of an invoice to the customer
+func (s *Store) RecordInvoiceDelivery(ctx context.Context, invoiceID uuid.UUID, at time.Time) (*models.InvoicePDF, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.invoices[invoiceID]
+	if !ok {
+		return nil, repository.ErrInvoiceDocumentNotFound
+	}
+	stored.doc.Deliveries++
+	stored.doc.LastDeliveredAt = &at
+	copied := *stored.doc
+	return &copied, nil
+}
+
+// CreateInvoiceReceivable starts tracking an invoice unless it is tracked
+// already
+func (s *Store) CreateInvoiceReceivable(ctx context.Context, receivable *models.InvoiceReceivable) (*models.InvoiceReceivable, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, ok := s.receivables[receivable.InvoiceID]; ok {
+		copied := *stored
+		return &copied, false, nil
+	}
+	stored := *receivable
+	stored.UpdatedAt = stored.CreatedAt
+	s.receivables[stored.InvoiceID] = &stored
+	copied := stored
+	return &copied, true, nil
+}
+
+// GetInvoiceReceivable retrieves a tracked invoice
+func (s *Store) GetInvoiceReceivable(ctx context.Context, invoiceID uuid.UUID) (*models.InvoiceReceivable, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.receivables[invoiceID]
+	if !ok {
+		return nil, repository.ErrInvoiceReceivableNotFound
+	}
+	copied := *stored
+	return &copied, nil
+}
+
+// ListUnpaidInvoiceReceivables lists the invoices not paid in full,
+// earliest due first
+func (s *Store) ListUnpaidInvoiceReceivables(ctx context.Context, currency string, customerID uuid.UUID) ([]*models.InvoiceReceivable, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var receivables []*models.InvoiceReceivable
+	for _, stored := range s.receivables {
+		if stored.Status == models.InvoicePaid ||
+			(currency != "" && stored.Currency != currency) ||
+			(customerID != uuid.Nil && stored.CustomerID != customerID) {
+			continue
+		}
+		copied := *stored
+		receivables = append(receivables, &copied)
+	}
+	sort.Slice(receivables, func(i, j int) bool {
+		if !receivables[i].DueDate.Equal(receivables[j].DueDate) {
+			return receivables[i].DueDate.Before(receivables[j].DueDate)
+		}
+		return receivables[i].InvoiceID.String() < receivables[j].InvoiceID.String()
+	})
+	return receivables, nil
+}
+
+// UpdateInvoiceLateCharges stores the status and late charges of an
+// unpaid invoice
+func (s *Store) UpdateInvoiceLateCharges(ctx context.Context, receivable *models.InvoiceReceivable) (*models.InvoiceReceivable, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.receivables[receivable.InvoiceID]
+	if !ok || stored.Status == models.InvoicePaid {
+		return nil, repository.ErrInvoiceReceivableNotFound
+	}
+	stored.Status = receivable.Status
+	stored.LateFee = receivable.LateFee
+	stored.Interest = receivable.Interest
+	stored.InterestThrough = receivable.InterestThrough
+	stored.UpdatedAt = s.clock.Now()
+	copied := *stored
+	return &copied, nil
+}
+
+// RecordInvoicePayment adds a payment to an invoice, refusing payments
+// above what is left to pay
+func (s *Store) RecordInvoicePayment(ctx context.Context, invoiceID uuid.UUID, amount decimal.Decimal, paidAt time.Time) (*models.InvoiceReceivable, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.receivables[invoiceID]
+	if !ok {
+		return nil, repository.ErrInvoiceReceivableNotFound
+	}
+	paid := stored.AmountPaid.Add(amount)
+	if paid.GreaterThan(stored.AmountDue()) {
+		return nil, repository.ErrInvoiceOverpaid
+	}
+	stored.AmountPaid = paid
+	if paid.Equal(stored.AmountDue()) {
+		stored.Status = models.InvoicePaid
+		stored.PaidAt = &paidAt
+	}
+	stored.UpdatedAt = s.clock.Now()
+	copied := *stored
+	return &copied, nil
+}
+
+// CreatePostpaidCharge records an accrued charge
+func (s *Store) CreatePostpaidCharge(ctx context.Context, charge *models.PostpaidCharge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.wallets[charge.WalletID]; !ok {
+		return repository.ErrWalletNotFound
+	}
+	copied := *charge
+	s.charges = append(s.charges, &copied)
+	return nil
+}
+
+// SumUnbilledPostpaidCharges counts and totals a wallet's unbilled charges
+func (s *Store) SumUnbilledPostpaidCharges(ctx context.Context, walletID uuid.UUID) (int, decimal.Decimal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count, total := 0, decimal.Zero
+	for _, charge := range s.charges {
+		if charge.WalletID == walletID && charge.StatementID == nil {
+			count++
+			total = total.Add(charge.Amount)
+		}
+	}
+	return count, total, nil
+}
+
+// CreatePostpaidStatements bills the unbilled charges accrued before
+// periodEnd, one statement per wallet and currency
+func (s *Store) CreatePostpaidStatements(ctx context.Context, periodStart, periodEnd, createdAt time.Time) ([]*models.PostpaidStatement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type key struct {
+		walletID uuid.UUID
+		currency string
+	}
+	byWallet := make(map[key]*models.PostpaidStatement)
+	statements := []*models.PostpaidStatement{}
+	for _, charge := range s.charges {
+		if charge.StatementID != nil || !charge.CreatedAt.Before(periodEnd) {
+			continue
+		}
+		statement, ok := byWallet[key{charge.WalletID, charge.Currency}]
+		if !ok {
+			id := uuid.New()
+			statement = &models.PostpaidStatement{
+				ID:            id,
+				CustomerID:    charge.CustomerID,
+				WalletID:      charge.WalletID,
+				Currency:      charge.Currency,
+				InvoiceNumber: models.PostpaidInvoiceNumber(periodStart, id),
+				PeriodStart:   periodStart,
+				PeriodEnd:     periodEnd,
+				TotalAmount:   decimal.Zero,
+				Status:        models.StatementPending,
+				CreatedAt:     createdAt,
+			}
+			byWallet[key{charge.WalletID, charge.Currency}] = statement
+			statements = append(statements, statement)
+		}
+		statementID := statement.ID
+		charge.StatementID = &statementID
+		statement.Charges++
+		statement.TotalAmount = statement.TotalAmount.Add(charge.Amount)
+	}
+
+	created := make([]*models.PostpaidStatement, 0, len(statements))
+	for _, statement := range statements {
+		s.statements = append(s.statements, statement)
+		copied := *statement
+		created = append(created, &copied)
+	}
+	return created, nil
+}
+
+// ListPendingPostpaidStatements lists the statements not settled yet,
+// oldest first
+func (s *Store) ListPendingPostpaidStatements(ctx context.Context) ([]*models.PostpaidStatement, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statements := []*models.PostpaidStatement{}
+	for _, statement := range s.statements {
+		if statement.Status == models.StatementPending {
+			copied := *statement
+			statements = append(statements, &copied)
+		}
+	}
+	return statements, nil
+}
+
+// ListPostpaidStatements lists the newest statements of a wallet
+func (s *Store) ListPostpaidStatements(ctx context.Context, walletID uuid.UUID, limit int) ([]*models.PostpaidStatement, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statements := []*models.PostpaidStatement{}
+	for i := len(s.statements) - 1; i >= 0 && len(statements) < limit; i-- {
+		if s.statements[i].WalletID == walletID {
+			copied := *s.statements[i]
+			statements = append(statements, &copied)
+		}
+	}
+	return statements, nil
+}
+
+// SettlePostpaidStatement marks a pending statement settled
+func (s *Store) SettlePostpaidStatement(ctx context.Context, id uuid.UUID, settlement models.PostpaidSettlement, transactionID *uuid.UUID, settledAt time.Time) (*models.PostpaidStatement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, statement := range s.statements {
+		if statement.ID != id || statement.Status != models.StatementPending {
+			continue
+		}
+		statement.Status = models.StatementSettled
+		if settlement == models.SettleExternal {
+			statement.Status = models.StatementInvoiced
+		}
+		statement.Settlement = settlement
+		statement.TransactionID = transactionID
+		statement.SettledAt = &settledAt
+		copied := *statement
+		return &copied, nil
+	}
+	return nil, repository.ErrPostpaidStatementNotFound
+}
+
+// ConsumeAllowance uses up to units of what is left of a wallet's allowance
+// of a product in a period
+func (s *Store) ConsumeAllowance(ctx context.Context, walletID uuid.UUID, product, period string, allowance, units int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := allowanceKey{walletID, product, period}
+	consumed := allowance - s.allowances[key]
+	if consumed > units {
+		consumed = units
+	}
+	if consumed <= 0 {
+		return 0, nil
+	}
+	s.allowances[key] += consumed
+	return consumed, nil
+}
+
+// ReleaseAllowance gives back consumed units
+func (s *Store) ReleaseAllowance(ctx context.Context, walletID uuid.UUID, product, period string, units int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := allowanceKey{walletID, product, period}
+	s.allowances[key] -= units
+	if s.allowances[key] < 0 {
+		s.allowances[key] = 0
+	}
+	return nil
+}
+
+// ListAllowanceUsage returns the units a wallet used in a period by product
+func (s *Store) ListAllowanceUsage(ctx context.Context, walletID uuid.UUID, period string) (map[string]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usage := make(map[string]int64)
+	for key, used := range s.allowances {
+		if key.walletID == walletID && key.period == period {
+			usage[key.product] = used
+		}
+	}
+	return usage, nil
+}
+
+// CreateOrganization stores a new organization
+func (s *Store) CreateOrganization(ctx context.Context, org *models.Organization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *org
+	s.organizations[org.ID] = &copied
+	return nil
+}
+
+// GetOrganization retrieves a copy of an organization by ID
+func (s *Store) GetOrganization(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	org, err := s.organization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	copied := *org
+	return &copied, nil
+}
+
+// organization returns a stored organization a context may see. Callers
+// hold the lock.
+func (s *Store) organization(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	org, ok := s.organizations[id]
+	if !ok || !tenancy.Allows(ctx, &id) {
+		return nil, repository.ErrOrganizationNotFound
+	}
+	return org, nil
+}
+
+// AssignCustomerOrganization moves a customer, and so its wallets, to an
+// organization
+func (s *Store) AssignCustomerOrganization(ctx context.Context, customerID uuid.UUID, orgID *uuid.UUID) (*models.CustomerSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, err := s.customerSettings(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if orgID != nil {
+		id := *orgID
+		orgID = &id
+	}
+	settings.OrgID = orgID
+	settings.UpdatedAt = s.clock.Now()
+	s.customers[customerID] = settings
+	copied := *settings
+	return &copied, nil
+}
+
+// SummarizeOrganization rolls up an organization's customers and wallets
+func (s *Store) SummarizeOrganization(ctx context.Context, orgID uuid.UUID) (*models.OrganizationSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	org, err := s.organization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	copied := *org
+	summary := &models.OrganizationSummary{Organization: &copied, Balances: []*models.OrganizationBalance{}}
+
+	members := s.members(orgID)
+	summary.Customers = len(members)
+	balances := make(map[string]*models.OrganizationBalance)
+	for _, wallet := range s.wallets {
+		if !members[wallet.CustomerID] {
+			continue
+		}
+		balance, ok := balances[wallet.Currency]
+		if !ok {
+			balance = &models.OrganizationBalance{Currency: wallet.Currency}
+			balances[wallet.Currency] = balance
+			summary.Balances = append(summary.Balances, balance)
+		}
+		balance.Wallets++
+		balance.Balance = balance.Balance.Add(decimal.NewFromFloat(wallet.Balance))
+		summary.Wallets++
+	}
+	sort.Slice(summary.Balances, func(i, j int) bool {
+		return summary.Balances[i].Currency < summary.Balances[j].Currency
+	})
+	return summary, nil
+}
+
+// ListOrganizationWallets pages through copies of an organization's
+// wallets, oldest first
+func (s *Store) ListOrganizationWallets(ctx context.Context, orgID uuid.UUID, limit, offset int) ([]*models.Wallet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.organization(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	members := s.members(orgID)
+	var wallets []*models.Wallet
+	for _, wallet := range s.wallets {
+		if members[wallet.CustomerID] {
+			copied := *wallet
+			wallets = append(wallets, &copied)
+		}
+	}
+	sort.Slice(wallets, func(i, j int) bool {
+		if !wallets[i].CreatedAt.Equal(wallets[j].CreatedAt) {
+			return wallets[i].CreatedAt.Before(wallets[j].CreatedAt)
+		}
+		return wallets[i].ID.String() < wallets[j].ID.String()
+	})
+
+	if offset >= len(wallets) {
+		return nil, nil
+	}
+	wallets = wallets[offset:]
+	if len(wallets) > limit {
+		wallets = wallets[:limit]
+	}
+	return wallets, nil
+}
+
+// ListOrganizationInvoices lists copies of the invoices of an
+// organization's customers tracked in [from, to), by invoice number
+func (s *Store) ListOrganizationInvoices(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]*models.InvoiceReceivable, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.organization(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	members := s.members(orgID)
+	var invoices []*models.InvoiceReceivable
+	for _, receivable := range s.receivables {
+		if members[receivable.CustomerID] && !receivable.CreatedAt.Before(from) && receivable.CreatedAt.Before(to) {
+			copied := *receivable
+			invoices = append(invoices, &copied)
+		}
+	}
+	sort.Slice(invoices, func(i, j int) bool {
+		return invoices[i].InvoiceNumber < invoices[j].InvoiceNumber
+	})
+	return invoices, nil
+}
+
+// members returns the customers of an organization. Callers hold the lock.
+func (s *Store) members(orgID uuid.UUID) map[uuid.UUID]bool {
+	members := make(map[uuid.UUID]bool)
+	for customerID, settings := range s.customers {
+		if settings.OrgID != nil && *settings.OrgID == orgID {
+			members[customerID] = true
+		}
+	}
+	return members
+}
+
+// CreateBucket stores a new, empty bucket
+func (s *Store) CreateBucket(ctx context.Context, bucket *models.WalletBucket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bucket(bucket.WalletID, bucket.Name) != nil {
+		return repository.ErrBucketExists
+	}
+	stored := *bucket
+	stored.Balance = decimal.Zero
+	s.buckets = append(s.buckets, &stored)
+	return nil
+}
+
+// GetBucket retrieves a bucket of a wallet by name
+func (s *Store) GetBucket(ctx context.Context, walletID uuid.UUID, name string) (*models.WalletBucket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bucket := s.bucket(walletID, name)
+	if bucket == nil {
+		return nil, repository.ErrBucketNotFound
+	}
+	copied := *bucket
+	return &copied, nil
+}
+
+// bucket returns the stored bucket of a wallet by name, or nil. Callers
+// hold the lock.
+func (s *Store) bucket(walletID uuid.UUID, name string) *models.WalletBucket {
+	for _, bucket := range s.buckets {
+		if bucket.WalletID == walletID && bucket.Name == name {
+			return bucket
+		}
+	}
+	return nil
+}
+
+// ListBuckets lists the buckets of a wallet by name
+func (s *Store) ListBuckets(ctx context.Context, walletID uuid.UUID) ([]*models.WalletBucket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var buckets []*models.WalletBucket
+	for _, bucket := range s.buckets {
+		if bucket.WalletID == walletID {
+			copied := *bucket
+			buckets = append(buckets, &copied)
+		}
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Name < buckets[j].Name })
+	return buckets, nil
+}
+
+// UpdateBucket replaces a bucket's threshold and fallback, re-arming it
+// when its balance is above the new threshold
+func (s *Store) UpdateBucket(ctx context.Context, bucket *models.WalletBucket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.bucket(bucket.WalletID, bucket.Name)
+	if stored == nil {
+		return repository.ErrBucketNotFound
+	}
+	stored.LowBalanceThreshold = bucket.LowBalanceThreshold
+	stored.Fallback = bucket.Fallback
+	stored.LowBalance = stored.LowBalance && stored.Balance.LessThanOrEqual(bucket.LowBalanceThreshold)
+	stored.UpdatedAt = s.clock.Now()
+	*bucket = *stored
+	return nil
+}
+
+// DeleteBucket removes a bucket
+func (s *Store) DeleteBucket(ctx context.Context, walletID uuid.UUID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, bucket := range s.buckets {
+		if bucket.WalletID == walletID && bucket.Name == name {
+			s.buckets = append(s.buckets[:i], s.buckets[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrBucketNotFound
+}
+
+// TransferBucketFunds moves funds between buckets, an empty name standing
+// for the unallocated balance
+func (s *Store) TransferBucketFunds(ctx context.Context, walletID uuid.UUID, from, to string, amount decimal.Decimal) ([]*models.WalletBucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet, ok := s.wallets[walletID]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	var source, target *models.WalletBucket
+	if from != "" {
+		if source = s.bucket(walletID, from); source == nil {
+			return nil, repository.ErrBucketNotFound
+		}
+	}
+	if to != "" {
+		if target = s.bucket(walletID, to); target == nil {
+			return nil, repository.ErrBucketNotFound
+		}
+	}
+
+	available := decimal.NewFromFloat(wallet.Balance)
+	if source != nil {
+		available = source.Balance
+	} else {
+		for _, bucket := range s.buckets {
+			if bucket.WalletID == walletID {
+				available = available.Sub(bucket.Balance)
+			}
+		}
+	}
+	if available.LessThan(amount) {
+		return nil, repository.ErrInsufficientBucketBalance
+	}
+
+	var moved []*models.WalletBucket
+	for _, move := range []struct {
+		bucket *models.WalletBucket
+		amount decimal.Decimal
+	}{{source, amount.Neg()}, {target, amount}} {
+		if move.bucket == nil {
+			continue
+		}
+		move.bucket.Balance = move.bucket.Balance.Add(move.amount)
+		move.bucket.UpdatedAt = s.clock.Now()
+		copied := *move.bucket
+		moved = append(moved, &copied)
+	}
+	return moved, nil
+}
+
+// TakeFromBucket takes a debit's amount out of a bucket, or what it holds
+// when it falls back to the unallocated balance
+func (s *Store) TakeFromBucket(ctx context.Context, walletID uuid.UUID, name string, amount decimal.Decimal) (decimal.Decimal, *models.WalletBucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket := s.bucket(walletID, name)
+	if bucket == nil {
+		return decimal.Zero, nil, repository.ErrBucketNotFound
+	}
+	taken := decimal.Min(bucket.Balance, amount)
+	if taken.LessThan(amount) && bucket.Fallback != models.BucketFallbackUnallocated {
+		return decimal.Zero, nil, repository.ErrInsufficientBucketBalance
+	}
+	if taken.IsPositive() {
+		bucket.Balance = bucket.Balance.Sub(taken)
+		bucket.UpdatedAt = s.clock.Now()
+	} else {
+		taken = decimal.Zero
+	}
+	copied := *bucket
+	return taken, &copied, nil
+}
+
+// ReturnToBucket gives back what a debit took, if the bucket still exists
+func (s *Store) ReturnToBucket(ctx context.Context, walletID uuid.UUID, name string, amount decimal.Decimal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if bucket := s.bucket(walletID, name); bucket != nil {
+		bucket.Balance = bucket.Balance.Add(amount)
+		bucket.UpdatedAt = s.clock.Now()
+	}
+	return nil
+}
+
+// SetBucketLowBalance flips the alert state of a bucket only when it
+// differs
+func (s *Store) SetBucketLowBalance(ctx context.Context, id uuid.UUID, lowBalance bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, bucket := range s.buckets {
+		if bucket.ID == id {
+			if bucket.LowBalance == lowBalance {
+				return false, nil
+			}
+			bucket.LowBalance = lowBalance
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CreateSettingsChange stores a requested settings change, refusing it while
+// the wallet has another open change
+func (s *Store) CreateSettingsChange(ctx context.Context, change *models.WalletSettingsChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, open := range s.walletChanges {
+		if open.WalletID == change.WalletID && (open.Status == models.WalletSettingsChangePending || open.Status == models.WalletSettingsChangeApproved) {
+			return repository.ErrSettingsChangeConflict
+		}
+	}
+	copied := *change
+	copied.Diff = append([]models.SettingDiff(nil), change.Diff...)
+	s.walletChanges[change.ID] = &copied
+	return nil
+}
+
+// GetSettingsChange retrieves a copy of a settings change
+func (s *Store) GetSettingsChange(ctx context.Context, id uuid.UUID) (*models.WalletSettingsChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	change, ok := s.walletChanges[id]
+	if !ok {
+		return nil, repository.ErrSettingsChangeNotFound
+	}
+	copied := *change
+	return &copied, nil
+}
+
+// ListSettingsChanges returns the latest settings changes matching the
+// filter, newest first
+func (s *Store) ListSettingsChanges(ctx context.Context, filter models.WalletSettingsChangeFilter) ([]*models.WalletSettingsChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var changes []*models.WalletSettingsChange
+	for _, change := range s.walletChanges {
+		if filter.WalletID != nil && change.WalletID != *filter.WalletID {
+			continue
+		}
+		if filter.Status != "" && change.Status != filter.Status {
+			continue
+		}
+		copied := *change
+		changes = append(changes, &copied)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].CreatedAt.After(changes[j].CreatedAt) })
+	if len(changes) > filter.Limit {
+		changes = changes[:filter.Limit]
+	}
+	return changes, nil
+}
+
+// ReviewSettingsChange approves or rejects a pending settings change
+func (s *Store) ReviewSettingsChange(ctx context.Context, id uuid.UUID, status models.WalletSettingsChangeStatus, reviewer, note string, now time.Time) (*models.WalletSettingsChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change, ok := s.walletChanges[id]
+	if !ok {
+		return nil, repository.ErrSettingsChangeNotFound
+	}
+	if change.Status != models.WalletSettingsChangePending {
+		return nil, repository.ErrSettingsChangeConflict
+	}
+	change.Status = status
+	change.ReviewedBy = reviewer
+	change.ReviewNote = note
+	reviewed := now
+	change.ReviewedAt = &reviewed
+	copied := *change
+	return &copied, nil
+}
+
+// ReleaseSettingsChange returns an approved settings change to review
+func (s *Store) ReleaseSettingsChange(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change, ok := s.walletChanges[id]
+	if ok && change.Status == models.WalletSettingsChangeApproved {
+		change.Status = models.WalletSettingsChangePending
+		change.ReviewedBy = ""
+		change.ReviewNote = ""
+		change.ReviewedAt = nil
+	}
+	return nil
+}
+
+// MarkSettingsChangeApplied records that an approved settings change was
+// applied
+func (s *Store) MarkSettingsChangeApplied(ctx context.Context, id uuid.UUID, now time.Time) (*models.WalletSettingsChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change, ok := s.walletChanges[id]
+	if !ok || change.Status != models.WalletSettingsChangeApproved {
+		return nil, repository.ErrSettingsChangeConflict
+	}
+	change.Status = models.WalletSettingsChangeApplied
+	applied := now
+	change.AppliedAt = &applied
+	copied := *change
+	return &copied, nil
+}
+
+// RecordInboundWebhook stores a received webhook, reporting false when its
+// provider already delivered the event
+func (s *Store) RecordInboundWebhook(ctx context.Context, webhook *models.InboundWebhook) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, recorded := range s.inbound {
+		if recorded.Provider == webhook.Provider && recorded.EventID == webhook.EventID {
+			return false, nil
+		}
+	}
+	webhook.ID = uuid.New()
+	webhook.Status = models.InboundWebhookReceived
+	webhook.NextAttemptAt = webhook.ReceivedAt
+	copied := *webhook
+	s.inbound = append(s.inbound, &copied)
+	return true, nil
+}
+
+// ListReceivedInboundWebhooks returns copies of the webhooks awaiting
+// processing in the order received
+func (s *Store) ListReceivedInboundWebhooks(ctx context.Context, limit int) ([]*models.InboundWebhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var webhooks []*models.InboundWebhook
+	for _, webhook := range s.inbound {
+		if webhook.Status == models.InboundWebhookReceived {
+			copied := *webhook
+			webhooks = append(webhooks, &copied)
+		}
+	}
+	sort.SliceStable(webhooks, func(i, j int) bool { return webhooks[i].ReceivedAt.Before(webhooks[j].ReceivedAt) })
+	if len(webhooks) > limit {
+		webhooks = webhooks[:limit]
+	}
+	return webhooks, nil
+}
+
+// MarkInboundWebhookProcessed records that a received webhook was processed
+func (s *Store) MarkInboundWebhookProcessed(ctx context.Context, id uuid.UUID, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if webhook := s.receivedWebhook(id); webhook != nil {
+		webhook.Status = models.InboundWebhookProcessed
+		webhook.Attempts++
+		webhook.LastError = ""
+		processed := now
+		webhook.ProcessedAt = &processed
+	}
+	return nil
+}
+
+// RescheduleInboundWebhook counts a failed attempt and schedules the next
+func (s *Store) RescheduleInboundWebhook(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if webhook := s.receivedWebhook(id); webhook != nil {
+		webhook.Attempts++
+		webhook.NextAttemptAt = nextAttemptAt
+		webhook.LastError = lastError
+	}
+	return nil
+}
+
+// DeadLetterInboundWebhook counts a last failed attempt and moves the
+// webhook to the dead letters
+func (s *Store) DeadLetterInboundWebhook(ctx context.Context, id uuid.UUID, lastError string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook := s.receivedWebhook(id)
+	if webhook == nil {
+		return nil
+	}
+	webhook.Status = models.InboundWebhookDeadLettered
+	webhook.Attempts++
+	webhook.LastError = lastError
+	s.deadLetters = append(s.deadLetters, &models.InboundDeadLetter{
+		ID:             uuid.New(),
+		WebhookID:      webhook.ID,
+		Provider:       webhook.Provider,
+		EventID:        webhook.EventID,
+		EventType:      webhook.EventType,
+		Payload:        webhook.Payload,
+		Attempts:       webhook.Attempts,
+		Error:          lastError,
+		DeadLetteredAt: now,
+	})
+	return nil
+}
+
+// ListDeadLetters returns copies of the latest dead letters, newest first
+func (s *Store) ListDeadLetters(ctx context.Context, provider string, limit int) ([]*models.InboundDeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	letters := []*models.InboundDeadLetter{}
+	for i := len(s.deadLetters) - 1; i >= 0 && len(letters) < limit; i-- {
+		if provider == "" || s.deadLetters[i].Provider == provider {
+			copied := *s.deadLetters[i]
+			letters = append(letters, &copied)
+		}
+	}
+	return letters, nil
+}
+
+// ReplayDeadLetter returns a dead-lettered webhook to processing
+func (s *Store) ReplayDeadLetter(ctx context.Context, id uuid.UUID, now time.Time) (*models.InboundDeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, letter := range s.deadLetters {
+		if letter.ID != id {
+			continue
+		}
+		if letter.ReplayedAt != nil {
+			return nil, repository.ErrDeadLetterReplayed
+		}
+		replayed := now
+		letter.ReplayedAt = &replayed
+		for _, webhook := range s.inbound {
+			if webhook.ID == letter.WebhookID {
+				webhook.Status = models.InboundWebhookReceived
+				webhook.Attempts = 0
+				webhook.LastError = ""
+				webhook.NextAttemptAt = now
+			}
+		}
+		copied := *letter
+		return &copied, nil
+	}
+	return nil, repository.ErrDeadLetterNotFound
+}
+
+// receivedWebhook returns the stored webhook awaiting processing with the
+// ID, or nil; callers hold the lock
+func (s *Store) receivedWebhook(id uuid.UUID) *models.InboundWebhook {
+	for _, webhook := range s.inbound {
+		if webhook.ID == id && webhook.Status == models.InboundWebhookReceived {
+			return webhook
+		}
+	}
+	return nil
+}
+
+// RecordFailedJob stores a failed job, reporting false while a job of the
+// same reference is open
+func (s *Store) RecordFailedJob(ctx context.Context, job *models.FailedJob) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.failedJobs {
+		if existing.Kind == job.Kind && existing.Reference == job.Reference && existing.Open() {
+			return false, nil
+		}
+	}
+	copied := *job
+	s.failedJobs = append(s.failedJobs, &copied)
+	return true, nil
+}
+
+// GetFailedJob returns a copy of a failed job
+func (s *Store) GetFailedJob(ctx context.Context, id uuid.UUID) (*models.FailedJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, job := range s.failedJobs {
+		if job.ID == id {
+			copied := *job
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrFailedJobNotFound
+}
+
+// ListFailedJobs returns copies of the latest failed jobs matching the
+// filter, newest first
+func (s *Store) ListFailedJobs(ctx context.Context, filter models.FailedJobFilter) ([]*models.FailedJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var jobs []*models.FailedJob
+	for i := len(s.failedJobs) - 1; i >= 0 && len(jobs) < filter.Limit; i-- {
+		job := s.failedJobs[i]
+		if (filter.Kind == "" || job.Kind == filter.Kind) && (filter.Status == "" || job.Status == filter.Status) {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	return jobs, nil
+}
+
+// ListDueFailedJobs returns copies of the retrying jobs due by now,
+// earliest first
+func (s *Store) ListDueFailedJobs(ctx context.Context, now time.Time, limit int) ([]*models.FailedJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var jobs []*models.FailedJob
+	for _, job := range s.failedJobs {
+		if job.Status == models.FailedJobRetrying && !job.NextAttemptAt.After(now) {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].NextAttemptAt.Before(*jobs[j].NextAttemptAt) })
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+// UpdateFailedJob saves a job still in status from
+func (s *Store) UpdateFailedJob(ctx context.Context, job *models.FailedJob, from models.FailedJobStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.failedJobs {
+		if existing.ID != job.ID {
+			continue
+		}
+		if existing.Status != from {
+			return repository.ErrFailedJobConflict
+		}
+		copied := *job
+		s.failedJobs[i] = &copied
+		return nil
+	}
+	return repository.ErrFailedJobNotFound
+}
@@ -0,0 +1,335 @@
+// Package testkit assembles the full wallet service HTTP stack over an
+// in-memory store and a fake clock behind an httptest.Server, so services
+// integrating with the wallet API can test against realistic wallet
+// behavior without PostgreSQL, Redis or Docker.
+//
+// The stack runs the production router, handlers, services and error
+// mapping. Authentication is replaced by bearer tokens that are the customer
+// ID itself, and rate limiting and idempotent replay, which need Redis, are
+// disabled. The Idempotency-Key header is still required. Live wallet
+// updates travel through an in-memory feed instead of Redis pub/sub. GraphQL
+// invoice queries fail as no invoice service is configured.
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"      // v1.9.1
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/api"
+	"internal/apierror"
+	"internal/classification"
+	"internal/config"
+	"internal/currency"
+	"internal/eventbus"
+	"internal/graphql"
+	"internal/models"
+	"internal/sensitive"
+	"internal/service"
+	"internal/walletfeed"
+)
+
+// RolesHeader lists comma-separated roles granted to a testkit request,
+// e.g. admin for operator endpoints
+const RolesHeader = "X-Testkit-Roles"
+
+// Options customizes the assembled service
+type Options struct {
+	// Start is the initial fake time, defaulting to 2024-01-01 UTC
+	Start time.Time
+	// LowBalanceThreshold is the default wallet low balance threshold
+	LowBalanceThreshold decimal.Decimal
+	// Currencies are the supported currency codes at their ISO 4217
+	// precision, defaulting to USD, INR and IDR
+	Currencies []string
+	// SensitiveDataPolicy is "reject" (default) or "mask"
+	SensitiveDataPolicy sensitive.Policy
+	// MaxRequestSize bounds request bodies, defaulting to 1MB
+	MaxRequestSize int
+	// SwaggerUI serves the API explorer under /api/v1/docs
+	SwaggerUI bool
+	// Heartbeat is the idle interval of wallet event streams and the ping
+	// interval of WebSocket connections, defaulting to 15 seconds
+	Heartbeat time.Duration
+	// EnforceDataClassification removes classified response fields the
+	// caller's roles do not grant instead of only logging the access
+	EnforceDataClassification bool
+	// ReadOnly refuses mutating routes as read-only deployments do
+	ReadOnly bool
+}
+
+// Kit is a running wallet service backed by memory
+type Kit struct {
+	Server *httptest.Server
+	Clock  *Clock
+	Store  *Store
+	// Bus carries the domain events, such as notifications, the service publishes
+	Bus *eventbus.Bus
+	// Feed carries the live wallet updates streamed to clients
+	Feed *Feed
+	// WebSocket serves the transaction event channel, exposed so tests can
+	// drain its connections
+	WebSocket *api.WebSocketHandler
+	// History exposes snapshotting so tests can build balance history
+	History service.BalanceHistoryService
+	// Sandbox clones wallets for demos like the operator runbook action
+	Sandbox service.SandboxService
+}
+
+// New assembles and starts the service. It is closed when the test ends.
+func New(t testing.TB, opts Options) *Kit {
+	t.Helper()
+
+	if opts.Start.IsZero() {
+		opts.Start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if opts.SensitiveDataPolicy == "" {
+		opts.SensitiveDataPolicy = sensitive.PolicyReject
+	}
+	if opts.MaxRequestSize == 0 {
+		opts.MaxRequestSize = 1 << 20
+	}
+	if opts.Heartbeat == 0 {
+		opts.Heartbeat = 15 * time.Second
+	}
+	if len(opts.Currencies) == 0 {
+		opts.Currencies = []string{"USD", "INR", "IDR"}
+	}
+
+	clock := NewClock(opts.Start)
+	store := NewStore(clock)
+	logger := &testLogger{t: t}
+
+	currencies, err := currency.FromCodes(opts.Currencies, nil, nil)
+	if err != nil {
+		t.Fatalf("testkit: invalid currencies: %v", err)
+	}
+
+	bus := eventbus.New()
+	walletService, err := service.NewWalletService(store, currencies, opts.LowBalanceThreshold, bus, logger)
+	if err != nil {
+		t.Fatalf("testkit: failed to create wallet service: %v", err)
+	}
+
+	feed := NewFeed(100)
+	relay, err := walletfeed.NewRelay(feed, walletService)
+	if err != nil {
+		t.Fatalf("testkit: failed to create wallet feed relay: %v", err)
+	}
+	if err := bus.Subscribe("wallet-feed", relay.Handle, relay.EventTypes()...); err != nil {
+		t.Fatalf("testkit: failed to subscribe wallet feed: %v", err)
+	}
+
+	historyService, err := service.NewBalanceHistoryService(store, logger)
+	if err != nil {
+		t.Fatalf("testkit: failed to create balance history service: %v", err)
+	}
+
+	sandboxService, err := service.NewSandboxService(store, store, logger)
+	if err != nil {
+		t.Fatalf("testkit: failed to create sandbox service: %v", err)
+	}
+
+	scanner, err := sensitive.NewScanner(opts.SensitiveDataPolicy, func(e sensitive.Event) {
+		t.Logf("testkit: sensitive data in %s: %v (%s)", e.Field, e.Kinds, e.Action)
+	})
+	if err != nil {
+		t.Fatalf("testkit: failed to create sensitive data scanner: %v", err)
+	}
+
+	handler, err := api.NewWalletHandler(walletService, historyService, currencies, scanner)
+	if err != nil {
+		t.Fatalf("testkit: failed to create wallet handler: %v", err)
+	}
+
+	streamHandler, err := api.NewStreamHandler(walletService, feed, opts.Heartbeat)
+	if err != nil {
+		t.Fatalf("testkit: failed to create stream handler: %v", err)
+	}
+
+	wsHandler, err := api.NewWebSocketHandler(walletService, feed, api.WebSocketOptions{
+		MaxSubscriptions: 20,
+		MessageRate:      5,
+		MessageBurst:     10,
+		PingInterval:     opts.Heartbeat,
+		WriteTimeout:     10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("testkit: failed to create WebSocket handler: %v", err)
+	}
+
+	dataAccessHandler, err := api.NewDataAccessHandler(classification.Policy{
+		Enforce: opts.EnforceDataClassification,
+		Scopes:  classification.DefaultScopes(),
+	})
+	if err != nil {
+		t.Fatalf("testkit: failed to create data access handler: %v", err)
+	}
+
+	graphqlHandler, err := graphql.NewHandler(walletService, nil, graphql.Options{ComplexityLimit: 200})
+	if err != nil {
+		t.Fatalf("testkit: failed to create GraphQL handler: %v", err)
+	}
+
+	cfg := &config.Config{
+		API: config.APIConfig{
+			MaxRequestSize: opts.MaxRequestSize,
+			SwaggerUI:      opts.SwaggerUI,
+		},
+		ReadOnly: config.ReadOnlyConfig{Enabled: opts.ReadOnly},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := api.SetupRouter(gin.New(), cfg, api.Middleware{
+		Auth:        authMiddleware,
+		RateLimit:   passThrough,
+		Idempotency: passThrough,
+	}, api.Handlers{
+		Wallet:     handler,
+		Stream:     streamHandler,
+		WebSocket:  wsHandler,
+		DataAccess: dataAccessHandler,
+		GraphQL:    graphqlHandler,
+	})
+
+	kit := &Kit{
+		Server:    httptest.NewServer(router),
+		Clock:     clock,
+		Store:     store,
+		Bus:       bus,
+		Feed:      feed,
+		WebSocket: wsHandler,
+		History:   historyService,
+		Sandbox:   sandboxService,
+	}
+	t.Cleanup(kit.Server.Close)
+	t.Cleanup(func() {
+		// Hijacked connections outlive the server; close them first
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = wsHandler.Drain(ctx)
+	})
+
+	return kit
+}
+
+// CreateWallet seeds a wallet for the customer with an opening balance
+func (k *Kit) CreateWallet(t testing.TB, customerID uuid.UUID, currency string, balance float64) *models.Wallet {
+	t.Helper()
+
+	wallet := &models.Wallet{
+		CustomerID: customerID,
+		Balance:    balance,
+		Currency:   currency,
+	}
+	if err := k.Store.CreateWallet(context.Background(), wallet); err != nil {
+		t.Fatalf("testkit: failed to create wallet: %v", err)
+	}
+
+	return wallet
+}
+
+// Do sends an authenticated request for the customer and returns the
+// response status and body. A non-nil body is encoded as JSON.
+func (k *Kit) Do(t testing.TB, customerID uuid.UUID, method, path string, body interface{}, headers ...string) (int, []byte) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("testkit: failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, k.Server.URL+path, reader)
+	if err != nil {
+		t.Fatalf("testkit: failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+customerID.String())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := k.Server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("testkit: request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("testkit: failed to read response: %v", err)
+	}
+
+	return resp.StatusCode, data
+}
+
+// authMiddleware treats the bearer token as the authenticated customer ID
+func authMiddleware(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if _, err := uuid.Parse(token); err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, api.Response{
+			Status: "error",
+			Code:   string(apierror.CodeUnauthorized),
+			Error:  "testkit bearer token must be a customer ID",
+		})
+		return
+	}
+
+	var roles []string
+	if header := c.GetHeader(RolesHeader); header != "" {
+		roles = strings.Split(header, ",")
+	}
+
+	c.Set("subject", token)
+	c.Set("customer_id", token)
+	c.Set("roles", roles)
+	c.Next()
+}
+
+// passThrough replaces middleware the testkit does not run
+func passThrough(c *gin.Context) {
+	c.Next()
+}
+
+// testLogger writes service logs to the test log
+type testLogger struct {
+	t testing.TB
+}
+
+func (l *testLogger) Info(msg string, fields ...interface{}) {
+	l.t.Logf("INFO %s %s", msg, formatFields(fields))
+}
+
+func (l *testLogger) Error(msg string, err error, fields ...interface{}) {
+	l.t.Logf("ERROR %s: %v %s", msg, err, formatFields(fields))
+}
+
+func (l *testLogger) Warn(msg string, fields ...interface{}) {
+	l.t.Logf("WARN %s %s", msg, formatFields(fields))
+}
+
+// formatFields renders alternating keys and values
+func formatFields(fields []interface{}) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(fields); i += 2 {
+		fmt.Fprintf(&b, "%v=%v ", fields[i], fields[i+1])
+	}
+	return strings.TrimSpace(b.String())
+}
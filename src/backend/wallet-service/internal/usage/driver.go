@@ -0,0 +1,200 @@
+package usage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// MeteredConnector wraps a database connector so that the duration of every
+// statement executed with a metered context is added to its meter, and every
+// statement is recorded in the slow query log unless it is nil. Queries are
+// measured until their first rows are available; reading further rows is not
+// counted.
+func MeteredConnector(connector driver.Connector, slow *SlowQueryLog) driver.Connector {
+	return &meteredConnector{connector: connector, slow: slow}
+}
+
+type meteredConnector struct {
+	connector driver.Connector
+	slow      *SlowQueryLog
+}
+
+func (c *meteredConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &meteredConn{conn: conn, slow: c.slow}, nil
+}
+
+func (c *meteredConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// meteredConn forwards to the wrapped connection, falling back with
+// driver.ErrSkip where the wrapped driver lacks an optional interface
+type meteredConn struct {
+	conn driver.Conn
+	slow *SlowQueryLog
+}
+
+func (c *meteredConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *meteredConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &meteredStmt{stmt: stmt, query: query, slow: c.slow}, nil
+}
+
+func (c *meteredConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *meteredConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *meteredConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.conn.Begin()
+}
+
+func (c *meteredConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer finish(ctx, c.slow, query, time.Now())
+	return q.QueryContext(ctx, query, args)
+}
+
+func (c *meteredConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer finish(ctx, c.slow, query, time.Now())
+	return e.ExecContext(ctx, query, args)
+}
+
+func (c *meteredConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if ch, ok := c.conn.(driver.NamedValueChecker); ok {
+		return ch.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *meteredConn) Ping(ctx context.Context) error {
+	if p, ok := c.conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *meteredConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *meteredConn) IsValid() bool {
+	if v, ok := c.conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// Unwrap returns the wrapped connection, for features of the driver that
+// database/sql does not expose, such as pgx batches
+func (c *meteredConn) Unwrap() driver.Conn {
+	return c.conn
+}
+
+// Observe meters a call started at start that ran on the wrapped connection
+// directly, recording it in the slow query log when it was slow
+func (c *meteredConn) Observe(ctx context.Context, query string, start time.Time) {
+	finish(ctx, c.slow, query, start)
+}
+
+// meteredStmt measures the executions of a prepared statement
+type meteredStmt struct {
+	stmt  driver.Stmt
+	query string
+	slow  *SlowQueryLog
+}
+
+func (s *meteredStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *meteredStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *meteredStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.stmt.Exec(args)
+}
+
+func (s *meteredStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.stmt.Query(args)
+}
+
+func (s *meteredStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	defer finish(ctx, s.slow, s.query, time.Now())
+	if e, ok := s.stmt.(driver.StmtExecContext); ok {
+		return e.ExecContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Exec(values)
+}
+
+func (s *meteredStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	defer finish(ctx, s.slow, s.query, time.Now())
+	if q, ok := s.stmt.(driver.StmtQueryContext); ok {
+		return q.QueryContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Query(values)
+}
+
+// finish meters a statement started at start, recording it in the slow
+// query log when it was slow
+func finish(ctx context.Context, slow *SlowQueryLog, query string, start time.Time) {
+	elapsed := time.Since(start)
+	measure(ctx, elapsed)
+	slow.observe(ctx, query, elapsed)
+}
+
+// namedValues converts arguments for drivers without context support, which
+// cannot take named parameters
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("driver does not support named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
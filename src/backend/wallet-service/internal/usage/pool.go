@@ -0,0 +1,87 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+)
+
+// Connection pool metrics, by database
+var (
+	poolOpenConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wallet_db_pool_open_connections",
+			Help: "Open connections of the database pool, in use or idle",
+		},
+		[]string{"database"},
+	)
+	poolInUseConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wallet_db_pool_in_use_connections",
+			Help: "Connections of the database pool currently in use",
+		},
+		[]string{"database"},
+	)
+	poolIdleConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wallet_db_pool_idle_connections",
+			Help: "Idle connections of the database pool",
+		},
+		[]string{"database"},
+	)
+	poolMaxOpenConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wallet_db_pool_max_open_connections",
+			Help: "Maximum open connections of the database pool, 0 when unlimited",
+		},
+		[]string{"database"},
+	)
+	poolWaitCount = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wallet_db_pool_wait_count",
+			Help: "Connections waited for since the pool was opened, as the pool was exhausted",
+		},
+		[]string{"database"},
+	)
+	poolWaitDuration = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wallet_db_pool_wait_duration_seconds",
+			Help: "Time spent waiting for connections since the pool was opened",
+		},
+		[]string{"database"},
+	)
+)
+
+// PoolStats exports the statistics of a database connection pool as
+// metrics each time it is recorded
+type PoolStats struct {
+	database string
+	db       *sql.DB
+}
+
+// NewPoolStats creates the pool statistics of the named database
+func NewPoolStats(database string, db *sql.DB) (*PoolStats, error) {
+	if database == "" {
+		return nil, errors.New("database name is required")
+	}
+	if db == nil {
+		return nil, errors.New("database connection is required")
+	}
+
+	return &PoolStats{database: database, db: db}, nil
+}
+
+// Record exports the current pool statistics, run on a ticker by the workers
+func (p *PoolStats) Record(ctx context.Context) error {
+	stats := p.db.Stats()
+	poolOpenConnections.WithLabelValues(p.database).Set(float64(stats.OpenConnections))
+	poolInUseConnections.WithLabelValues(p.database).Set(float64(stats.InUse))
+	poolIdleConnections.WithLabelValues(p.database).Set(float64(stats.Idle))
+	poolMaxOpenConnections.WithLabelValues(p.database).Set(float64(stats.MaxOpenConnections))
+	poolWaitCount.WithLabelValues(p.database).Set(float64(stats.WaitCount))
+	poolWaitDuration.WithLabelValues(p.database).Set(stats.WaitDuration.Seconds())
+	return nil
+}
@@ -0,0 +1,106 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+
+	"internal/logging"
+)
+
+// slowQueries counts statements slower than the slow query threshold
+var slowQueries = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wallet_db_slow_queries_total",
+		Help: "Database statements slower than the slow query threshold, by database and statement name",
+	},
+	[]string{"database", "statement"},
+)
+
+// Logger defines the logging of slow queries
+type Logger interface {
+	Warn(msg string, fields ...interface{})
+}
+
+// SlowQueryLog logs and counts the statements of a database slower than a
+// threshold, with the correlation ID of the request that made them
+type SlowQueryLog struct {
+	database  string
+	threshold time.Duration
+	logger    Logger
+}
+
+// NewSlowQueryLog creates the slow query log of the named database
+func NewSlowQueryLog(database string, threshold time.Duration, logger Logger) (*SlowQueryLog, error) {
+	if database == "" {
+		return nil, errors.New("database name is required")
+	}
+	if threshold <= 0 {
+		return nil, errors.New("slow query threshold must be positive")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &SlowQueryLog{database: database, threshold: threshold, logger: logger}, nil
+}
+
+// observe records a statement that took elapsed, if it was slow
+func (l *SlowQueryLog) observe(ctx context.Context, query string, elapsed time.Duration) {
+	if l == nil || elapsed < l.threshold {
+		return
+	}
+
+	statement := StatementName(query)
+	slowQueries.WithLabelValues(l.database, statement).Inc()
+
+	fields := []interface{}{
+		"database", l.database,
+		"statement", statement,
+		"duration_ms", elapsed.Milliseconds(),
+		"threshold_ms", l.threshold.Milliseconds(),
+	}
+	if correlationID := logging.CorrelationID(ctx); correlationID != "" {
+		fields = append(fields, "correlation_id", correlationID)
+	}
+	l.logger.Warn("slow database query", fields...)
+}
+
+// StatementName names a statement by its command and the first table it
+// reads or writes, such as "SELECT wallets". A leading "-- name: <name>"
+// comment names the statement explicitly. Arguments and literals are never
+// part of the name, which is bounded by the tables of the schema.
+func StatementName(query string) string {
+	query = strings.TrimSpace(query)
+	for strings.HasPrefix(query, "--") {
+		line, rest, _ := strings.Cut(query, "\n")
+		comment := strings.TrimSpace(strings.TrimPrefix(line, "--"))
+		if name, ok := strings.CutPrefix(comment, "name:"); ok && strings.TrimSpace(name) != "" {
+			return strings.TrimSpace(name)
+		}
+		query = strings.TrimSpace(rest)
+	}
+
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return r == ' ' || r == '\n' || r == '\t' || r == '\r' || r == '(' || r == ')' || r == ',' || r == ';'
+	})
+	if len(words) == 0 {
+		return "unknown"
+	}
+
+	command := strings.ToUpper(words[0])
+	for i, word := range words[:len(words)-1] {
+		switch strings.ToUpper(word) {
+		case "FROM", "INTO", "UPDATE", "JOIN":
+			table := strings.ToLower(strings.Trim(words[i+1], `"`))
+			if table != "" && !strings.HasPrefix(table, "$") && table != "select" {
+				return command + " " + table
+			}
+		}
+	}
+	return command
+}
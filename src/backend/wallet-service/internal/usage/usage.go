@@ -0,0 +1,40 @@
+// Package usage meters the load each request puts on the service. A Meter
+// carried in the request context accumulates the time spent in database
+// calls made with that context, measured by a metered database driver. The
+// driver also logs slow statements, and the connection pools are exported
+// as metrics.
+package usage
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Meter accumulates the database time of one request. It is safe for
+// concurrent use by the goroutines serving the request.
+type Meter struct {
+	dbTime atomic.Int64
+}
+
+// DBTime returns the time spent in database calls so far
+func (m *Meter) DBTime() time.Duration {
+	return time.Duration(m.dbTime.Load())
+}
+
+// meterKey is the context key of the request meter
+type meterKey struct{}
+
+// WithMeter returns a context carrying a new meter
+func WithMeter(ctx context.Context) (context.Context, *Meter) {
+	m := &Meter{}
+	return context.WithValue(ctx, meterKey{}, m), m
+}
+
+// measure adds the elapsed time of a database call to the meter of ctx, if
+// any
+func measure(ctx context.Context, elapsed time.Duration) {
+	if m, ok := ctx.Value(meterKey{}).(*Meter); ok {
+		m.dbTime.Add(int64(elapsed))
+	}
+}
@@ -0,0 +1,252 @@
+// Package walletfeed carries real-time wallet updates, such as balance
+// changes and transaction status changes, from the service layer to API
+// clients streaming them. Updates are published to Redis pub/sub so a
+// client connected to any replica sees changes made on every replica, and
+// each wallet keeps a short history so reconnecting clients can resume.
+package walletfeed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8" // v8.11.5
+	"github.com/google/uuid"       // v1.3.0
+)
+
+// Update kinds streamed to clients
+const (
+	KindBalance     = "balance"
+	KindTransaction = "transaction"
+)
+
+// Redis key prefixes of the feed
+const (
+	channelPrefix  = "walletfeed:"
+	historyPrefix  = "walletfeed:history:"
+	sequencePrefix = "walletfeed:seq:"
+)
+
+// subscriptionBuffer bounds the live updates queued for a slow subscriber
+const subscriptionBuffer = 64
+
+// publishScript numbers the update, appends it to the wallet's history and
+// publishes it in one atomic step, so history and live order always agree.
+// Sequences never expire, so IDs held by clients are never reused.
+//
+// KEYS[1] sequence key, KEYS[2] history key, KEYS[3] channel,
+// ARGV[1] encoded update, ARGV[2] history length, ARGV[3] history TTL in
+// milliseconds. Returns the update ID.
+var publishScript = redis.NewScript(`
+local id = redis.call('INCR', KEYS[1])
+local message = id .. ' ' .. ARGV[1]
+
+redis.call('RPUSH', KEYS[2], message)
+redis.call('LTRIM', KEYS[2], -tonumber(ARGV[2]), -1)
+redis.call('PEXPIRE', KEYS[2], ARGV[3])
+redis.call('PUBLISH', KEYS[3], message)
+
+return id
+`)
+
+// Update is one change of a wallet. IDs increase per wallet, so a client
+// resumes after the last ID it saw.
+type Update struct {
+	ID   int64           `json:"-"`
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Feed publishes wallet updates and streams them to subscribers
+type Feed interface {
+	// Publish numbers and broadcasts an update of a wallet
+	Publish(ctx context.Context, walletID uuid.UUID, kind string, data interface{}) error
+	// Subscribe streams the wallet's updates after lastID, replaying those
+	// still in its history first, until ctx is done. The returned flag is
+	// false when updates after lastID were already dropped from the history.
+	Subscribe(ctx context.Context, walletID uuid.UUID, lastID int64) (<-chan Update, bool, error)
+}
+
+// RedisFeed is the Feed shared by every replica through Redis
+type RedisFeed struct {
+	client     *redis.Client
+	history    int64
+	historyTTL time.Duration
+}
+
+// NewRedisFeed creates a feed keeping the last history updates of each
+// wallet for historyTTL after its latest update
+func NewRedisFeed(client *redis.Client, history int64, historyTTL time.Duration) (*RedisFeed, error) {
+	if client == nil {
+		return nil, errors.New("redis client is required")
+	}
+	if history <= 0 {
+		return nil, errors.New("history must be positive")
+	}
+	if historyTTL <= 0 {
+		return nil, errors.New("history TTL must be positive")
+	}
+
+	return &RedisFeed{
+		client:     client,
+		history:    history,
+		historyTTL: historyTTL,
+	}, nil
+}
+
+// Publish implements Feed
+func (f *RedisFeed) Publish(ctx context.Context, walletID uuid.UUID, kind string, data interface{}) error {
+	encoded, err := encodeUpdate(kind, data)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{
+		sequencePrefix + walletID.String(),
+		historyPrefix + walletID.String(),
+		channelPrefix + walletID.String(),
+	}
+	err = publishScript.Run(ctx, f.client, keys, encoded, f.history, f.historyTTL.Milliseconds()).Err()
+	if err != nil {
+		return fmt.Errorf("failed to publish %s update: %w", kind, err)
+	}
+
+	return nil
+}
+
+// Subscribe implements Feed
+func (f *RedisFeed) Subscribe(ctx context.Context, walletID uuid.UUID, lastID int64) (<-chan Update, bool, error) {
+	// Subscribe before reading the history so no update falls between them
+	pubsub := f.client.Subscribe(ctx, channelPrefix+walletID.String())
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, false, fmt.Errorf("failed to subscribe to wallet updates: %w", err)
+	}
+
+	var missed []Update
+	complete := true
+	if lastID > 0 {
+		var history *redis.StringSliceCmd
+		var sequence *redis.StringCmd
+		_, err := f.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			history = pipe.LRange(ctx, historyPrefix+walletID.String(), 0, -1)
+			sequence = pipe.Get(ctx, sequencePrefix+walletID.String())
+			return nil
+		})
+		if err != nil && !errors.Is(err, redis.Nil) {
+			pubsub.Close()
+			return nil, false, fmt.Errorf("failed to read wallet update history: %w", err)
+		}
+
+		missed = replay(history.Val(), lastID)
+		current, _ := sequence.Int64()
+		switch {
+		case lastID > current:
+			// An ID this wallet never issued; stream from the current update
+			lastID = current
+			complete = false
+		case len(missed) == 0:
+			complete = lastID == current
+		default:
+			complete = missed[0].ID == lastID+1
+		}
+	}
+
+	updates := make(chan Update, subscriptionBuffer)
+	go func() {
+		defer close(updates)
+		defer pubsub.Close()
+
+		last := lastID
+		for _, update := range missed {
+			if !send(ctx, updates, update) {
+				return
+			}
+			last = update.ID
+		}
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				update, err := decodeUpdate(message.Payload)
+				// Skip replayed updates that were also delivered live
+				if err != nil || update.ID <= last {
+					continue
+				}
+				if !send(ctx, updates, update) {
+					return
+				}
+				last = update.ID
+			}
+		}
+	}()
+
+	return updates, complete, nil
+}
+
+// replay returns the updates of a history after lastID
+func replay(messages []string, lastID int64) []Update {
+	var updates []Update
+	for _, message := range messages {
+		update, err := decodeUpdate(message)
+		if err != nil || update.ID <= lastID {
+			continue
+		}
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// send delivers an update unless ctx is done first
+func send(ctx context.Context, updates chan<- Update, update Update) bool {
+	select {
+	case updates <- update:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// encodeUpdate encodes an update without its ID, which Redis assigns
+func encodeUpdate(kind string, data interface{}) (string, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s update: %w", kind, err)
+	}
+
+	encoded, err := json.Marshal(Update{Kind: kind, Data: raw})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s update: %w", kind, err)
+	}
+	return string(encoded), nil
+}
+
+// decodeUpdate decodes an update message: its ID, a space and the update
+func decodeUpdate(message string) (Update, error) {
+	idPart, encoded, ok := strings.Cut(message, " ")
+	if !ok {
+		return Update{}, errors.New("malformed wallet update")
+	}
+
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		return Update{}, fmt.Errorf("malformed wallet update ID: %w", err)
+	}
+
+	var update Update
+	if err := json.Unmarshal([]byte(encoded), &update); err != nil {
+		return Update{}, fmt.Errorf("malformed wallet update: %w", err)
+	}
+	update.ID = id
+	return update, nil
+}
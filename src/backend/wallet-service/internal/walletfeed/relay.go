@@ -0,0 +1,94 @@
+package walletfeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+)
+
+// Balance is the data of a balance update
+type Balance struct {
+	WalletID         uuid.UUID       `json:"wallet_id"`
+	Balance          decimal.Decimal `json:"balance"`
+	Currency         string          `json:"currency"`
+	AvailableBalance decimal.Decimal `json:"available_balance"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// TransactionStatus is the data of a transaction update
+type TransactionStatus struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	WalletID      uuid.UUID `json:"wallet_id"`
+	Status        string    `json:"status"`
+}
+
+// NewBalance builds the balance update data of a wallet
+func NewBalance(wallet *models.Wallet) Balance {
+	return Balance{
+		WalletID:         wallet.ID,
+		Balance:          decimal.NewFromFloat(wallet.Balance),
+		Currency:         wallet.Currency,
+		AvailableBalance: decimal.NewFromFloat(wallet.AvailableBalance()),
+		UpdatedAt:        wallet.UpdatedAt,
+	}
+}
+
+// Relay is the event bus subscriber that publishes wallet state changes to
+// the feed. Balance updates carry the balance read when the event is
+// handled, so a late update never reports an older balance than the event.
+type Relay struct {
+	feed    Feed
+	wallets service.WalletService
+}
+
+// NewRelay creates a relay publishing to feed
+func NewRelay(feed Feed, wallets service.WalletService) (*Relay, error) {
+	if feed == nil {
+		return nil, errors.New("feed is required")
+	}
+	if wallets == nil {
+		return nil, errors.New("wallet service is required")
+	}
+
+	return &Relay{
+		feed:    feed,
+		wallets: wallets,
+	}, nil
+}
+
+// EventTypes lists the domain event types the relay publishes, for use as
+// the subscription filter
+func (r *Relay) EventTypes() []string {
+	return []string{
+		eventbus.TypeBalanceChanged,
+		eventbus.TypeTransactionStatusChanged,
+	}
+}
+
+// Handle implements eventbus.Handler
+func (r *Relay) Handle(ctx context.Context, event eventbus.Event) error {
+	switch e := event.(type) {
+	case eventbus.BalanceChanged:
+		wallet, err := r.wallets.GetWallet(ctx, e.WalletID)
+		if err != nil {
+			return fmt.Errorf("failed to read changed balance: %w", err)
+		}
+		return r.feed.Publish(ctx, e.WalletID, KindBalance, NewBalance(wallet))
+	case eventbus.TransactionStatusChanged:
+		return r.feed.Publish(ctx, e.WalletID, KindTransaction, TransactionStatus{
+			TransactionID: e.TransactionID,
+			WalletID:      e.WalletID,
+			Status:        e.Status.String(),
+		})
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,208 @@
+// Package webhook delivers wallet events to customer webhook subscriptions,
+// applying each subscription's event filters, wallet scope and payload field
+// selection so consumers only receive what they asked for
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"internal/models"
+)
+
+// Rule limits
+const (
+	// MaxFields bounds the payload fields a subscription may select
+	MaxFields = 32
+	// MaxWalletScope bounds the wallets a subscription may be scoped to
+	MaxWalletScope = 100
+)
+
+// ErrInvalidFieldPath is returned for malformed payload field paths
+var ErrInvalidFieldPath = errors.New("invalid payload field path")
+
+// fieldPathPattern matches dotted paths of JSON object keys, e.g. wallet_id
+// or wallets.closing_balance
+var fieldPathPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)*$`)
+
+// Subject identifies who an event is about, extracted from its payload
+type Subject struct {
+	WalletID   uuid.UUID
+	CustomerID uuid.UUID
+}
+
+// SubjectOf extracts the wallet and customer an event payload refers to.
+// Either may be nil when the payload does not carry it.
+func SubjectOf(payload json.RawMessage) (Subject, error) {
+	var ids struct {
+		WalletID   string `json:"wallet_id"`
+		CustomerID string `json:"customer_id"`
+	}
+	if err := json.Unmarshal(payload, &ids); err != nil {
+		return Subject{}, fmt.Errorf("failed to decode event subject: %w", err)
+	}
+
+	var subject Subject
+	if ids.WalletID != "" {
+		id, err := uuid.Parse(ids.WalletID)
+		if err != nil {
+			return Subject{}, fmt.Errorf("invalid event wallet ID: %w", err)
+		}
+		subject.WalletID = id
+	}
+	if ids.CustomerID != "" {
+		id, err := uuid.Parse(ids.CustomerID)
+		if err != nil {
+			return Subject{}, fmt.Errorf("invalid event customer ID: %w", err)
+		}
+		subject.CustomerID = id
+	}
+
+	return subject, nil
+}
+
+// Matches reports whether an event passes a subscription's filters. A
+// subscription scoped to wallets, or owned by one, only receives events about
+// those wallets, so customer-wide events without a wallet are excluded from it.
+func Matches(sub *models.WebhookSubscription, eventType string, walletID uuid.UUID) bool {
+	if !sub.Enabled {
+		return false
+	}
+
+	if sub.WalletID != nil && *sub.WalletID != walletID {
+		return false
+	}
+
+	if len(sub.EventTypes) > 0 && !containsString(sub.EventTypes, eventType) {
+		return false
+	}
+
+	if len(sub.WalletIDs) > 0 {
+		if walletID == uuid.Nil {
+			return false
+		}
+		for _, id := range sub.WalletIDs {
+			if id == walletID {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// ValidateFields checks the payload field paths a subscription selects
+func ValidateFields(fields []string) error {
+	if len(fields) > MaxFields {
+		return fmt.Errorf("%w: at most %d fields may be selected", ErrInvalidFieldPath, MaxFields)
+	}
+	for _, field := range fields {
+		if !fieldPathPattern.MatchString(field) {
+			return fmt.Errorf("%w: %q", ErrInvalidFieldPath, field)
+		}
+	}
+	return nil
+}
+
+// SelectFields returns a payload containing only the given dotted field
+// paths, keeping their nesting. Paths through arrays apply to every element
+// and paths that do not exist are omitted. No fields selects the full payload.
+func SelectFields(payload json.RawMessage, fields []string) (json.RawMessage, error) {
+	if len(fields) == 0 {
+		return payload, nil
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+
+	var selected interface{} = map[string]interface{}{}
+	for _, field := range fields {
+		selected = merge(selected, project(doc, strings.Split(field, ".")))
+	}
+
+	data, err := json.Marshal(selected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode selected payload: %w", err)
+	}
+
+	return data, nil
+}
+
+// project returns the parts of value along path, or nil if it does not exist
+func project(value interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		child, ok := v[path[0]]
+		if !ok {
+			return nil
+		}
+		projected := project(child, path[1:])
+		if projected == nil {
+			return nil
+		}
+		return map[string]interface{}{path[0]: projected}
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = project(item, path)
+		}
+		return items
+	default:
+		return nil
+	}
+}
+
+// merge combines two projections of the same document
+func merge(a, b interface{}) interface{} {
+	if b == nil {
+		return a
+	}
+	if a == nil {
+		return b
+	}
+
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			return a
+		}
+		for key, value := range bv {
+			av[key] = merge(av[key], value)
+		}
+		return av
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return a
+		}
+		for i := range av {
+			av[i] = merge(av[i], bv[i])
+		}
+		return av
+	default:
+		return a
+	}
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
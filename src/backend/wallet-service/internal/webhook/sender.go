@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"internal/events"
+	"internal/execctx"
+)
+
+// Delivery request headers
+const (
+	// HeaderSignature carries the hex HMAC-SHA256 of "<timestamp>.<body>"
+	HeaderSignature = "X-Webhook-Signature"
+	// HeaderTimestamp carries the Unix time the request was signed at
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderEventID carries the envelope ID, stable across redeliveries
+	HeaderEventID = "X-Webhook-Event-Id"
+	// HeaderEventType carries the envelope event type
+	HeaderEventType = "X-Webhook-Event-Type"
+)
+
+// maxResponseBody bounds how much of an endpoint's response is read
+const maxResponseBody = 4 << 10
+
+// Sender posts signed envelopes to webhook endpoints
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a new Sender whose requests time out after timeout
+func NewSender(timeout time.Duration) (*Sender, error) {
+	if timeout <= 0 {
+		return nil, errors.New("delivery timeout must be positive")
+	}
+
+	return &Sender{
+		client: &http.Client{
+			Timeout: timeout,
+			// Endpoints are registered as https URLs and must not bounce
+			// deliveries elsewhere
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+// ErrSuppressed is returned instead of sending outside live execution
+var ErrSuppressed = errors.New("webhook send suppressed outside live execution")
+
+// Send posts the envelope to url signed with secret and returns the response
+// status. Any status outside 2xx is reported as an error. Nothing is sent
+// unless ctx and the envelope are live.
+func (s *Sender) Send(ctx context.Context, url, secret string, env *events.Envelope) (int, error) {
+	if execctx.IsLive(ctx) && env.ExecutionMode() != execctx.ModeLive {
+		ctx = execctx.WithMode(ctx, env.ExecutionMode())
+	}
+	if execctx.Suppress(ctx, execctx.ChannelWebhook) {
+		return 0, ErrSuppressed
+	}
+
+	body, err := json.Marshal(env)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", env.ContentType())
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+	req.Header.Set(HeaderEventID, env.ID.String())
+	req.Header.Set(HeaderEventType, env.Type)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature receivers recompute to verify a delivery: the
+// hex HMAC-SHA256 of the timestamp and body joined by a dot
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the hex HMAC-SHA256 Sign produces for
+// the timestamp and body, comparing in constant time
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
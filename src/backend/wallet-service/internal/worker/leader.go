@@ -0,0 +1,167 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8" // v8.11.5
+)
+
+// leaseKeyPrefix is the Redis key prefix for singleton worker leases
+const leaseKeyPrefix = "worker:lease:"
+
+// acquireScript takes the lease when it is free and extends it when this
+// replica already holds it, in a single atomic step.
+//
+// KEYS[1] lease key, ARGV[1] holder ID, ARGV[2] TTL in milliseconds.
+// Returns 1 when the holder has the lease.
+var acquireScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes the lease only when this replica holds it
+//
+// KEYS[1] lease key, ARGV[1] holder ID.
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Elector grants leases that make one replica the leader of a singleton
+// worker. A lease expires unless renewed within its TTL, so a replica that
+// stops without releasing it hands over to another after at most one TTL.
+type Elector interface {
+	// Acquire takes or renews the named lease, reporting whether this
+	// replica holds it
+	Acquire(ctx context.Context, name string) (bool, error)
+	// Release gives up the named lease when this replica holds it
+	Release(ctx context.Context, name string) error
+	// TTL is how long a lease lasts without renewal
+	TTL() time.Duration
+}
+
+// RedisElector grants leases stored in Redis, shared by every replica
+type RedisElector struct {
+	redis *redis.Client
+	id    string
+	ttl   time.Duration
+}
+
+// NewRedisElector creates a new Redis backed leader elector. The ID must be
+// unique to the replica.
+func NewRedisElector(client *redis.Client, id string, ttl time.Duration) (*RedisElector, error) {
+	if client == nil {
+		return nil, errors.New("redis client is required")
+	}
+	if id == "" {
+		return nil, errors.New("elector ID is required")
+	}
+	if ttl < time.Second {
+		return nil, errors.New("lease TTL must be at least one second")
+	}
+
+	return &RedisElector{redis: client, id: id, ttl: ttl}, nil
+}
+
+// Acquire takes or renews the named lease
+func (e *RedisElector) Acquire(ctx context.Context, name string) (bool, error) {
+	held, err := acquireScript.Run(ctx, e.redis, []string{leaseKeyPrefix + name}, e.id, e.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	return held == 1, nil
+}
+
+// Release gives up the named lease when held
+func (e *RedisElector) Release(ctx context.Context, name string) error {
+	if err := releaseScript.Run(ctx, e.redis, []string{leaseKeyPrefix + name}, e.id).Err(); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+	return nil
+}
+
+// TTL returns how long a lease lasts without renewal
+func (e *RedisElector) TTL() time.Duration {
+	return e.ttl
+}
+
+// leadership is a singleton worker running while its lease is held
+type leadership struct {
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// lead starts a singleton worker on acquiring its lease
+func (r *Runner) lead(ctx context.Context, w Worker) *leadership {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	l := &leadership{cancel: cancel, stopped: make(chan struct{})}
+	go func() {
+		defer close(l.stopped)
+		r.supervise(leaderCtx, w)
+	}()
+
+	workerLeader.WithLabelValues(w.Name).Set(1)
+	r.logger.Info("worker lease acquired", "worker", w.Name)
+	return l
+}
+
+// resign stops the worker and waits for it to return
+func (l *leadership) resign(w Worker) {
+	l.cancel()
+	<-l.stopped
+	workerLeader.WithLabelValues(w.Name).Set(0)
+}
+
+// campaign runs a singleton worker while this replica holds its lease. The
+// lease is renewed at a third of its TTL; the worker is stopped as soon as a
+// renewal fails, before the lease can expire and pass to another replica.
+func (r *Runner) campaign(ctx context.Context, w Worker) {
+	ticker := time.NewTicker(r.elector.TTL() / 3)
+	defer ticker.Stop()
+
+	var lead *leadership
+	defer func() {
+		if lead == nil {
+			return
+		}
+		lead.resign(w)
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := r.elector.Release(releaseCtx, w.Name); err != nil {
+			r.logger.Warn("failed to release worker lease", "worker", w.Name, "error", err)
+		}
+	}()
+
+	for {
+		held, err := r.elector.Acquire(ctx, w.Name)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil || !held:
+			if lead != nil {
+				r.logger.Warn("worker lease lost", "worker", w.Name, "error", err)
+				lead.resign(w)
+				lead = nil
+			}
+		case lead == nil:
+			lead = r.lead(ctx, w)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,307 @@
+// Package worker runs the background jobs of the service: periodic jobs,
+// long-running loops and singleton jobs that only one replica may run at a
+// time, with panic recovery and graceful shutdown
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+)
+
+// Worker run outcomes
+const (
+	runSucceeded = "succeeded"
+	runFailed    = "failed"
+	runPanicked  = "panicked"
+	runPaused    = "paused"
+)
+
+// Metrics
+var (
+	workerRuns = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_worker_runs_total",
+			Help: "Finished runs of background workers, by outcome",
+		},
+		[]string{"worker", "outcome"},
+	)
+
+	workerLeader = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wallet_worker_leader",
+			Help: "Whether this replica holds the lease of a singleton worker",
+		},
+		[]string{"worker"},
+	)
+)
+
+// Logger defines the logging used by the runner
+type Logger interface {
+	Info(msg string, fields ...interface{})
+	Error(msg string, err error, fields ...interface{})
+	Warn(msg string, fields ...interface{})
+}
+
+// Job is the work of a worker. It must return once the context is cancelled.
+type Job func(ctx context.Context) error
+
+// Worker is a named background job
+type Worker struct {
+	Name string
+	// Interval between runs of the job, the first of which starts right away.
+	// Zero runs the job once as a loop of its own, restarted after the
+	// restart delay when it panics or fails.
+	Interval time.Duration
+	// Singleton runs the worker only on the replica holding its lease
+	Singleton bool
+	// ReadOnly marks workers that change no data, the only ones run by
+	// read-only deployments
+	ReadOnly bool
+	Job      Job
+}
+
+// hook is a function run on shutdown once every worker has stopped
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Runner supervises the workers of the service
+type Runner struct {
+	elector      Elector
+	restartDelay time.Duration
+	logger       Logger
+	readOnly     bool
+	paused       func() bool
+
+	mu      sync.Mutex
+	workers []Worker
+	hooks   []hook
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewRunner creates a new worker runner. The elector grants the leases of
+// singleton workers and may be nil when no worker is a singleton.
+func NewRunner(elector Elector, restartDelay time.Duration, logger Logger) (*Runner, error) {
+	if restartDelay <= 0 {
+		return nil, errors.New("restart delay must be positive")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+
+	return &Runner{
+		elector:      elector,
+		restartDelay: restartDelay,
+		logger:       logger,
+	}, nil
+}
+
+// Add registers a worker. Workers added after Start are not run.
+func (r *Runner) Add(w Worker) error {
+	if w.Name == "" {
+		return errors.New("worker name is required")
+	}
+	if w.Job == nil {
+		return fmt.Errorf("worker %s has no job", w.Name)
+	}
+	if w.Interval < 0 {
+		return fmt.Errorf("worker %s interval must not be negative", w.Name)
+	}
+	if w.Singleton && r.elector == nil {
+		return fmt.Errorf("worker %s is a singleton but no leader elector is configured", w.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.workers {
+		if existing.Name == w.Name {
+			return fmt.Errorf("worker %s is already registered", w.Name)
+		}
+	}
+	r.workers = append(r.workers, w)
+	return nil
+}
+
+// SetReadOnly makes Start skip the workers not marked ReadOnly, for
+// deployments that must not change data
+func (r *Runner) SetReadOnly(readOnly bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.readOnly = readOnly
+}
+
+// OnShutdown registers a hook run by Shutdown after every worker stopped.
+// Hooks run in reverse order of registration.
+func (r *Runner) OnShutdown(name string, fn func(ctx context.Context) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hooks = append(r.hooks, hook{name: name, fn: fn})
+}
+
+// errPaused is returned for runs skipped while workers are paused
+var errPaused = errors.New("worker paused")
+
+// PauseWhile makes the runs of workers not marked ReadOnly skip while paused
+// reports true, as while the database refuses writes. Long-running jobs are
+// retried after the restart delay.
+func (r *Runner) PauseWhile(paused func() bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.paused = paused
+}
+
+// isPaused reports whether the runs of workers changing data are skipped
+func (r *Runner) isPaused() bool {
+	r.mu.Lock()
+	paused := r.paused
+	r.mu.Unlock()
+
+	return paused != nil && paused()
+}
+
+// Start runs every registered worker until Shutdown is called or the
+// context is cancelled
+func (r *Runner) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cancel != nil {
+		return
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	started := 0
+	for _, w := range r.workers {
+		if r.readOnly && !w.ReadOnly {
+			r.logger.Info("worker disabled in read-only mode", "worker", w.Name)
+			continue
+		}
+
+		w := w
+		started++
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			if w.Singleton {
+				r.campaign(ctx, w)
+			} else {
+				r.supervise(ctx, w)
+			}
+		}()
+	}
+
+	r.logger.Info("workers started", "count", started)
+}
+
+// Shutdown stops the workers, waits for them to return and runs the shutdown
+// hooks. Workers still running when the context expires are reported and
+// left behind.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	cancel := r.cancel
+	hooks := r.hooks
+	r.mu.Unlock()
+
+	var errs []error
+	if cancel != nil {
+		cancel()
+
+		stopped := make(chan struct{})
+		go func() {
+			r.wg.Wait()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+			r.logger.Info("workers stopped")
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("workers did not stop: %w", ctx.Err()))
+		}
+	}
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook %s: %w", hooks[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// supervise runs a worker until the context is cancelled
+func (r *Runner) supervise(ctx context.Context, w Worker) {
+	if w.Interval == 0 {
+		r.loop(ctx, w)
+		return
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		// Failures are logged by run and retried on the next tick
+		_ = r.run(ctx, w)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// loop runs a long-running job, restarting it after the restart delay when
+// it panics or fails. A job returning without error is done.
+func (r *Runner) loop(ctx context.Context, w Worker) {
+	for {
+		if err := r.run(ctx, w); err == nil || ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.restartDelay):
+		}
+	}
+}
+
+// run runs the job of a worker once, recovering from panics
+func (r *Runner) run(ctx context.Context, w Worker) (err error) {
+	outcome := runSucceeded
+	defer func() {
+		if p := recover(); p != nil {
+			outcome = runPanicked
+			err = fmt.Errorf("worker panicked: %v", p)
+			r.logger.Error("worker panicked", err,
+				"worker", w.Name,
+				"stack", string(debug.Stack()))
+		}
+		workerRuns.WithLabelValues(w.Name, outcome).Inc()
+	}()
+
+	if !w.ReadOnly && r.isPaused() {
+		outcome = runPaused
+		return errPaused
+	}
+
+	if err = w.Job(ctx); err != nil && ctx.Err() == nil {
+		outcome = runFailed
+		r.logger.Error("worker failed", err, "worker", w.Name)
+	}
+	return err
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestDataAccessLog tests that accesses to balances and transactions are
+// attributed to the owner of the wallet and listed to that customer alone,
+// with staff identities and request details redacted
+func TestDataAccessLog(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+
+	accesses, err := service.NewDataAccessService(kit.Store, 5, &alertLogger{})
+	require.NoError(t, err)
+
+	customerID, otherID := uuid.New(), uuid.New()
+	wallet := &models.Wallet{CustomerID: customerID, Currency: "INR"}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+	other := &models.Wallet{CustomerID: otherID, Currency: "INR"}
+	require.NoError(t, kit.Store.CreateWallet(ctx, other))
+	unknown := uuid.New()
+
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	record := func(minutes int, walletID, target *uuid.UUID, action models.DataAccessAction, kind models.DataAccessorKind, subject string, accessor *uuid.UUID) {
+		accesses.Record(&models.DataAccess{
+			CustomerID:         target,
+			WalletID:           walletID,
+			Action:             action,
+			AccessorKind:       kind,
+			Subject:            subject,
+			AccessorCustomerID: accessor,
+			Roles:              []string{"admin"},
+			Method:             "GET",
+			Route:              "/api/v1/wallets/:id/balance",
+			CorrelationID:      "corr-" + subject,
+			AccessedAt:         start.Add(time.Duration(minutes) * time.Minute),
+		})
+	}
+	record(0, &wallet.ID, nil, models.DataAccessBalanceViewed, models.DataAccessorAPIKey, "key_live_1", &customerID)
+	record(5, &wallet.ID, nil, models.DataAccessTransactionsViewed, models.DataAccessorSupport, "asha@support", nil)
+	record(10, &other.ID, nil, models.DataAccessBalanceViewed, models.DataAccessorAdmin, "ravi@ops", nil)
+	record(15, nil, nil, models.DataAccessTransactionsExported, models.DataAccessorAnalytics, "finance-export", nil)
+	record(20, &unknown, nil, models.DataAccessBalanceViewed, models.DataAccessorAPIKey, "key_live_2", &customerID)
+	// Beyond the pending limit, dropped
+	record(25, &wallet.ID, nil, models.DataAccessBalanceViewed, models.DataAccessorAPIKey, "key_live_1", &customerID)
+	require.NoError(t, accesses.Flush(ctx))
+
+	log, err := accesses.ListAccesses(ctx, customerID, models.DataAccessFilter{}, service.Pagination{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, log, 3)
+
+	// Newest first, with the unfiltered export listed to every customer
+	require.Equal(t, models.DataAccessTransactionsExported, log[0].Action)
+	require.Equal(t, models.DataAccessorAnalytics, log[0].AccessorKind)
+	require.Nil(t, log[0].WalletID)
+	require.Equal(t, models.DataAccessorSupport, log[1].AccessorKind)
+	require.Empty(t, log[1].Accessor)
+	require.Equal(t, wallet.ID, *log[1].WalletID)
+	require.Equal(t, "key_live_1", log[2].Accessor)
+
+	body, err := json.Marshal(log)
+	require.NoError(t, err)
+	for _, internal := range []string{"asha@support", "finance-export", "corr-", "roles", "route"} {
+		require.NotContains(t, string(body), internal)
+	}
+
+	// The other customer sees their own access and the export only; the
+	// subject of an API key of another customer is not shown
+	record(30, &other.ID, nil, models.DataAccessBalanceViewed, models.DataAccessorAPIKey, "key_live_1", &customerID)
+	require.NoError(t, accesses.Flush(ctx))
+	log, err = accesses.ListAccesses(ctx, otherID, models.DataAccessFilter{}, service.Pagination{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, log, 3)
+	require.Equal(t, models.DataAccessorAPIKey, log[0].AccessorKind)
+	require.Empty(t, log[0].Accessor)
+	require.Equal(t, models.DataAccessorAdmin, log[2].AccessorKind)
+
+	// Filters and pages
+	log, err = accesses.ListAccesses(ctx, customerID, models.DataAccessFilter{
+		From:   start.Add(time.Minute),
+		To:     start.Add(time.Hour),
+		Action: models.DataAccessTransactionsViewed,
+	}, service.Pagination{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, log, 1)
+	require.Equal(t, models.DataAccessorSupport, log[0].AccessorKind)
+
+	log, err = accesses.ListAccesses(ctx, customerID, models.DataAccessFilter{}, service.Pagination{Limit: 1, Offset: 2})
+	require.NoError(t, err)
+	require.Len(t, log, 1)
+	require.Equal(t, "key_live_1", log[0].Accessor)
+
+	for _, filter := range []models.DataAccessFilter{
+		{Action: "BALANCE_CHANGED"},
+		{From: start.Add(time.Hour), To: start},
+	} {
+		_, err = accesses.ListAccesses(ctx, customerID, filter, service.Pagination{Limit: 10})
+		require.ErrorIs(t, err, service.ErrInvalidDataAccessFilter)
+	}
+
+	// Accesses are purged after the retention period
+	purged, err := kit.Store.PurgeDataAccesses(ctx, start.Add(12*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), purged)
+	log, err = accesses.ListAccesses(ctx, customerID, models.DataAccessFilter{}, service.Pagination{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, log, 1)
+}
@@ -0,0 +1,232 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestAdjustmentFourEyes tests that adjustments need a reason code and
+// approval by a second operator, are posted as linked adjustment
+// transactions, return to review when refused and are reported apart from
+// customer spend
+func TestAdjustmentFourEyes(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	adjustments, err := service.NewAdjustmentService(kit.Store, wallets, &auditLog{}, service.AdjustmentPolicy{PendingTTL: time.Hour}, &alertLogger{})
+	require.NoError(t, err)
+	analytics, err := service.NewWalletAnalyticsService(kit.Store, wallets, nil, 0, &alertLogger{})
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "USD", Balance: 100}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+
+	request := service.AdjustmentRequest{
+		WalletID:   wallet.ID,
+		Direction:  models.AdjustmentIncrease,
+		Amount:     decimal.NewFromInt(25),
+		ReasonCode: models.AdjustmentReasonDuplicateCharge,
+		Note:       "Invoice 42 was charged twice",
+	}
+	invalid := request
+	invalid.ReasonCode = "MISC"
+	_, err = adjustments.Request(ctx, invalid, "alice")
+	require.ErrorIs(t, err, service.ErrInvalidAdjustment)
+
+	increase, err := adjustments.Request(ctx, request, "alice")
+	require.NoError(t, err)
+	require.Equal(t, models.AdjustmentPending, increase.Status)
+	require.Equal(t, "USD", increase.Currency)
+
+	_, err = adjustments.Approve(ctx, increase.ID, "alice", "")
+	require.ErrorIs(t, err, service.ErrSelfApproval)
+
+	posted, err := adjustments.Approve(ctx, increase.ID, "bob", "Checked against the invoice")
+	require.NoError(t, err)
+	require.Equal(t, models.AdjustmentPosted, posted.Status)
+	require.NotNil(t, posted.TransactionID)
+	_, err = adjustments.Approve(ctx, increase.ID, "carol", "")
+	require.ErrorIs(t, err, service.ErrAdjustmentConflict)
+
+	tx, err := kit.Store.GetTransactionByID(ctx, *posted.TransactionID)
+	require.NoError(t, err)
+	require.Equal(t, models.TransactionTypeAdjustment, tx.Type)
+	require.Equal(t, increase.ID.String(), tx.Metadata[models.MetadataAdjustmentID])
+	require.Equal(t, string(models.AdjustmentReasonDuplicateCharge), tx.Metadata[models.MetadataAdjustmentReason])
+	require.Equal(t, 25.0, tx.SignedAmount())
+
+	stored, err := kit.Store.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, 125.0, stored.Balance)
+
+	// A decrease beyond the balance is refused and awaits review again
+	request.Direction = models.AdjustmentDecrease
+	request.Amount = decimal.NewFromInt(500)
+	request.ReasonCode = models.AdjustmentReasonWriteOff
+	decrease, err := adjustments.Request(ctx, request, "alice")
+	require.NoError(t, err)
+	_, err = adjustments.Approve(ctx, decrease.ID, "bob", "")
+	require.Error(t, err)
+	released, err := adjustments.Get(ctx, decrease.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.AdjustmentPending, released.Status)
+	require.Nil(t, released.TransactionID)
+
+	_, err = adjustments.Reject(ctx, decrease.ID, "bob", "")
+	require.ErrorIs(t, err, service.ErrInvalidAdjustment)
+	rejected, err := adjustments.Reject(ctx, decrease.ID, "bob", "Write-off exceeds the balance")
+	require.NoError(t, err)
+	require.Equal(t, models.AdjustmentRejected, rejected.Status)
+
+	pending, err := adjustments.List(ctx, models.AdjustmentFilter{WalletID: &wallet.ID, Status: models.AdjustmentPending})
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	// Only the posted adjustment is reported
+	report, err := adjustments.Report(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, report.Totals, 1)
+	require.Equal(t, models.AdjustmentReasonDuplicateCharge, report.Totals[0].ReasonCode)
+	require.Equal(t, models.AdjustmentIncrease, report.Totals[0].Direction)
+	require.Equal(t, int64(1), report.Totals[0].Count)
+	require.Equal(t, "25", report.Totals[0].Total.String())
+
+	// Adjustments move the balance but are no customer spend or top-up
+	summary, err := analytics.GetWalletAnalytics(ctx, wallet.ID, service.WalletAnalyticsQuery{
+		Granularity:   models.GranularityDay,
+		Window:        7 * 24 * time.Hour,
+		TopCategories: 5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "125", summary.Balance.String())
+	require.True(t, summary.TotalCredits.IsZero())
+	require.True(t, summary.TotalDebits.IsZero())
+	require.Empty(t, summary.TopCategories)
+}
+
+// TestAdjustmentApprovalThreshold tests that adjustments up to their
+// currency's threshold post without a second approver, that larger ones
+// expire when not reviewed in time, and that each step is audited with the
+// adjustment and its transaction
+func TestAdjustmentApprovalThreshold(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	audit := &auditLog{}
+	adjustments, err := service.NewAdjustmentService(kit.Store, wallets, audit, service.AdjustmentPolicy{
+		ApprovalThresholds: map[string]decimal.Decimal{"USD": decimal.NewFromInt(50)},
+		PendingTTL:         time.Hour,
+	}, &alertLogger{})
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "USD", Balance: 100}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+	request := service.AdjustmentRequest{
+		WalletID:   wallet.ID,
+		Direction:  models.AdjustmentIncrease,
+		Amount:     decimal.NewFromInt(50),
+		ReasonCode: models.AdjustmentReasonGoodwill,
+		Note:       "Outage credit",
+	}
+
+	// At the threshold the adjustment is posted on request
+	posted, err := adjustments.Request(ctx, request, "alice")
+	require.NoError(t, err)
+	require.Equal(t, models.AdjustmentPosted, posted.Status)
+	require.Empty(t, posted.ReviewedBy)
+	require.Nil(t, posted.ExpiresAt)
+	require.NotNil(t, posted.TransactionID)
+	require.Len(t, audit.actions, 1)
+	require.Equal(t, "adjustment.request", audit.actions[0].Action)
+	require.Equal(t, posted.ID.String(), audit.actions[0].Params["adjustment_id"])
+	require.Equal(t, posted.TransactionID.String(), audit.actions[0].Params["transaction_id"])
+
+	// A refused small adjustment is rejected rather than left for review
+	request.Direction = models.AdjustmentDecrease
+	request.Amount = decimal.NewFromInt(20)
+	empty := &models.Wallet{CustomerID: uuid.New(), Currency: "USD", Balance: 10}
+	require.NoError(t, kit.Store.CreateWallet(ctx, empty))
+	refused := request
+	refused.WalletID = empty.ID
+	_, err = adjustments.Request(ctx, refused, "alice")
+	require.Error(t, err)
+	rejected, err := adjustments.List(ctx, models.AdjustmentFilter{WalletID: &empty.ID, Status: models.AdjustmentRejected})
+	require.NoError(t, err)
+	require.Len(t, rejected, 1)
+	require.Equal(t, models.OperatorActionFailed, audit.actions[1].Status)
+
+	// Above it the adjustment awaits approval until it expires
+	request.Amount = decimal.NewFromFloat(50.01)
+	pending, err := adjustments.Request(ctx, request, "alice")
+	require.NoError(t, err)
+	require.Equal(t, models.AdjustmentPending, pending.Status)
+	require.NotNil(t, pending.ExpiresAt)
+
+	expired, err := adjustments.Expire(ctx, time.Now())
+	require.NoError(t, err)
+	require.Zero(t, expired)
+	expired, err = adjustments.Expire(ctx, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, expired)
+
+	_, err = adjustments.Approve(ctx, pending.ID, "bob", "")
+	require.ErrorIs(t, err, service.ErrAdjustmentConflict)
+	stale, err := adjustments.Get(ctx, pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.AdjustmentExpired, stale.Status)
+
+	stored, err := kit.Store.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, 150.0, stored.Balance)
+
+	var actions []string
+	for _, action := range audit.actions {
+		require.NotEmpty(t, action.Params["adjustment_id"])
+		actions = append(actions, action.Action+":"+action.Actor)
+	}
+	require.Equal(t, []string{
+		"adjustment.request:alice",
+		"adjustment.request:alice",
+		"adjustment.request:alice",
+		"adjustment.expire:schedule",
+		"adjustment.approve:bob",
+	}, actions)
+}
+
+// TestAdjustmentTransactionValidation tests that adjustment transactions
+// must link their approved adjustment, reason code and direction
+func TestAdjustmentTransactionValidation(t *testing.T) {
+	tx := &models.Transaction{
+		ID:       uuid.New(),
+		WalletID: uuid.New(),
+		Type:     models.TransactionTypeAdjustment,
+		Status:   models.TransactionStatusCompleted,
+		Amount:   10,
+		Currency: "USD",
+	}
+	require.ErrorIs(t, tx.Validate(), models.ErrInvalidAdjustment)
+	require.Zero(t, tx.SignedAmount())
+
+	tx.Metadata = map[string]string{
+		models.MetadataAdjustmentID:        uuid.NewString(),
+		models.MetadataAdjustmentReason:    string(models.AdjustmentReasonGoodwill),
+		models.MetadataAdjustmentDirection: string(models.AdjustmentDecrease),
+	}
+	require.NoError(t, tx.Validate())
+	require.Equal(t, -10.0, tx.SignedAmount())
+	require.Equal(t, "ADJUSTMENT", tx.Type.String())
+}
@@ -0,0 +1,141 @@
+package test
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/service"
+)
+
+// adoptionAggregates is an AnalyticsRepository serving fixed aggregates
+type adoptionAggregates []*models.AdoptionAggregate
+
+func (a adoptionAggregates) GetAdoptionAggregates(ctx context.Context, since time.Time) ([]*models.AdoptionAggregate, error) {
+	return a, nil
+}
+
+func (a adoptionAggregates) Close() error {
+	return nil
+}
+
+// fixedDraws is a RandomSource returning its values in turn, over and over
+type fixedDraws struct {
+	values []float64
+	next   int
+}
+
+func (f *fixedDraws) Float64() float64 {
+	value := f.values[f.next%len(f.values)]
+	f.next++
+	return value
+}
+
+// TestAdoptionStatsSuppression tests that groups below the minimum size are
+// published without figures and medians over few top-ups are withheld
+func TestAdoptionStatsSuppression(t *testing.T) {
+	ctx := context.Background()
+	repo := adoptionAggregates{
+		{Currency: "INR", ActiveWallets: 250, TopUpCount: 900, MedianTopUp: 1234.5},
+		{Currency: "USD", ActiveWallets: 9, TopUpCount: 40, MedianTopUp: 20},
+		{Currency: "EUR", ActiveWallets: 12, TopUpCount: 9, MedianTopUp: 35},
+	}
+	// Draws of one half add no noise
+	analytics, err := service.NewAnalyticsService(repo, service.AnalyticsOptions{
+		MinGroupSize: 10,
+		NoiseEpsilon: 1,
+		MedianBucket: 10,
+	}, &fixedDraws{values: []float64{0.5}}, &alertLogger{})
+	require.NoError(t, err)
+
+	stats, err := analytics.GetAdoptionStats(ctx, 30*24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+
+	require.False(t, stats[0].Suppressed)
+	require.Equal(t, int64(250), *stats[0].ActiveWallets)
+	require.Equal(t, int64(900), *stats[0].TopUpCount)
+	require.Equal(t, 1230.0, *stats[0].MedianTopUp)
+
+	require.Equal(t, &service.AdoptionStats{Currency: "USD", Suppressed: true}, stats[1])
+
+	require.False(t, stats[2].Suppressed)
+	require.Equal(t, int64(12), *stats[2].ActiveWallets)
+	require.Nil(t, stats[2].MedianTopUp)
+
+	_, err = analytics.GetAdoptionStats(ctx, 0)
+	require.ErrorIs(t, err, service.ErrInvalidAnalyticsWindow)
+	_, err = analytics.GetAdoptionStats(ctx, 400*24*time.Hour)
+	require.ErrorIs(t, err, service.ErrInvalidAnalyticsWindow)
+
+	_, err = service.NewAnalyticsService(repo, service.AnalyticsOptions{MinGroupSize: 10, MedianBucket: 10}, nil, &alertLogger{})
+	require.Error(t, err)
+	_, err = service.NewAnalyticsService(repo, service.AnalyticsOptions{MinGroupSize: 10}, rand.New(rand.NewSource(1)), &alertLogger{})
+	require.Error(t, err)
+}
+
+// TestAdoptionStatsNoise tests that counts and medians both carry Laplace
+// noise of the configured scale, and that published figures stay
+// non-negative and medians stay on bucket boundaries
+func TestAdoptionStatsNoise(t *testing.T) {
+	ctx := context.Background()
+	repo := adoptionAggregates{
+		{Currency: "INR", ActiveWallets: 1000, TopUpCount: 5000, MedianTopUp: 500},
+	}
+	opts := service.AnalyticsOptions{MinGroupSize: 10, NoiseEpsilon: 0.5, MedianBucket: 10}
+
+	// A draw of 0.01 is noise of ln(0.02) scales, about -3.9
+	analytics, err := service.NewAnalyticsService(repo, opts, &fixedDraws{values: []float64{0.01}}, &alertLogger{})
+	require.NoError(t, err)
+	stats, err := analytics.GetAdoptionStats(ctx, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, int64(992), *stats[0].ActiveWallets)
+	require.Equal(t, int64(4992), *stats[0].TopUpCount)
+	require.Equal(t, 420.0, *stats[0].MedianTopUp)
+
+	// Noise never takes a figure below zero
+	small := adoptionAggregates{{Currency: "INR", ActiveWallets: 10, TopUpCount: 10, MedianTopUp: 20}}
+	analytics, err = service.NewAnalyticsService(small, opts, &fixedDraws{values: []float64{0.01}}, &alertLogger{})
+	require.NoError(t, err)
+	stats, err = analytics.GetAdoptionStats(ctx, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), *stats[0].ActiveWallets)
+	require.Zero(t, *stats[0].MedianTopUp)
+
+	// Over many draws the noise has the Laplace spread of its scale: a mean
+	// absolute deviation of one scale and about 1% of draws beyond ln(100)
+	// scales
+	analytics, err = service.NewAnalyticsService(repo, opts, rand.New(rand.NewSource(42)), &alertLogger{})
+	require.NoError(t, err)
+	const draws = 5000
+	countScale, medianScale := 1/opts.NoiseEpsilon, opts.MedianBucket/opts.NoiseEpsilon
+	var countDeviation, medianDeviation float64
+	var countOutliers, medianOutliers int
+	for i := 0; i < draws; i++ {
+		stats, err := analytics.GetAdoptionStats(ctx, time.Hour)
+		require.NoError(t, err)
+
+		median := *stats[0].MedianTopUp
+		require.Zero(t, math.Mod(median, opts.MedianBucket))
+
+		countNoise := math.Abs(float64(*stats[0].ActiveWallets - 1000))
+		medianNoise := math.Abs(median - 500)
+		countDeviation += countNoise
+		medianDeviation += medianNoise
+		if countNoise > countScale*math.Log(100)+1 {
+			countOutliers++
+		}
+		if medianNoise > medianScale*math.Log(100)+opts.MedianBucket {
+			medianOutliers++
+		}
+	}
+	require.InDelta(t, countScale, countDeviation/draws, 0.25*countScale)
+	require.InDelta(t, medianScale, medianDeviation/draws, 0.25*medianScale)
+	require.Less(t, countOutliers, draws*2/100)
+	require.Less(t, medianOutliers, draws*2/100)
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestFreeTierAllowances tests that debits use the free units of their
+// product before the wallet is charged, and that allowances reset with the
+// next cycle
+func TestFreeTierAllowances(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+	currencies := supportedCurrencies(t)
+
+	base, err := service.NewWalletService(kit.Store, currencies, decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	_, err = service.NewAllowanceService(kit.Store, base, map[string]int64{"otp": 0}, currencies, &alertLogger{})
+	require.Error(t, err)
+	allowances, err := service.NewAllowanceService(kit.Store, base, map[string]int64{" OTP ": 10}, currencies, &alertLogger{})
+	require.NoError(t, err)
+	wallets, err := service.NewAllowanceWalletService(base, allowances, &alertLogger{})
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "INR", Balance: 100}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+	debit := func(product, quantity string, amount float64, at time.Time) (*models.Transaction, error) {
+		tx := &models.Transaction{
+			ID:          uuid.New(),
+			WalletID:    wallet.ID,
+			Type:        models.TransactionTypeDebit,
+			Status:      models.TransactionStatusInitiated,
+			Amount:      amount,
+			Currency:    "INR",
+			Description: "OTP verifications",
+			Metadata:    map[string]string{models.TransactionProductKey: product},
+			CreatedAt:   at,
+		}
+		if quantity != "" {
+			tx.Metadata[models.TransactionQuantityKey] = quantity
+		}
+		_, err := wallets.ProcessTransaction(ctx, tx)
+		return tx, err
+	}
+	balance := func() float64 {
+		stored, err := kit.Store.GetWallet(ctx, wallet.ID)
+		require.NoError(t, err)
+		return stored.Balance
+	}
+	now := time.Now().UTC()
+
+	// Debits covered in full complete without touching the balance
+	tx, err := debit("otp", "4", 2, now)
+	require.NoError(t, err)
+	require.Equal(t, models.TransactionStatusCompleted, tx.Status)
+	require.Zero(t, tx.Amount)
+	require.Equal(t, "4", tx.Metadata[models.MetadataAllowanceUnits])
+	require.Equal(t, "2", tx.Metadata[models.MetadataAllowanceAmount])
+	require.Equal(t, 100.0, balance())
+
+	// Debits going past the allowance pay for the units left over
+	tx, err = debit("OTP", "10", 5, now)
+	require.NoError(t, err)
+	require.Equal(t, "6", tx.Metadata[models.MetadataAllowanceUnits])
+	require.Equal(t, "3", tx.Metadata[models.MetadataAllowanceAmount])
+	require.Equal(t, 2.0, tx.Amount)
+	require.Equal(t, 98.0, balance())
+
+	// Once the allowance is used, debits are charged in full, as are those
+	// of products without one
+	tx, err = debit("otp", "", 1.5, now)
+	require.NoError(t, err)
+	require.Empty(t, tx.Metadata[models.MetadataAllowanceUnits])
+	_, err = debit("sms", "3", 1.5, now)
+	require.NoError(t, err)
+	require.Equal(t, 95.0, balance())
+
+	_, err = debit("otp", "-2", 1, now)
+	require.ErrorIs(t, err, service.ErrInvalidQuantity)
+
+	current, err := allowances.Allowances(ctx, wallet.ID, now)
+	require.NoError(t, err)
+	require.Equal(t, models.BillingPeriodOf(now).Format(models.BillingPeriodLayout), current.Period)
+	require.True(t, current.ResetsAt.Equal(models.BillingPeriodOf(now).AddDate(0, 1, 0)))
+	require.Len(t, current.Allowances, 1)
+	require.Equal(t, "otp", current.Allowances[0].Product)
+	require.Equal(t, int64(10), current.Allowances[0].Used)
+	require.Zero(t, current.Allowances[0].Remaining)
+
+	// The next cycle starts with the full allowance; units of debits the
+	// wallet refuses are given back
+	next := current.ResetsAt.Add(time.Hour)
+	_, err = debit("otp", "20", 1000, next)
+	require.ErrorIs(t, err, service.ErrInsufficientBalance)
+	upcoming, err := allowances.Allowances(ctx, wallet.ID, next)
+	require.NoError(t, err)
+	require.Zero(t, upcoming.Allowances[0].Used)
+	require.Equal(t, int64(10), upcoming.Allowances[0].Remaining)
+	tx, err = debit("otp", "1", 0.5, next)
+	require.NoError(t, err)
+	require.Equal(t, models.TransactionStatusCompleted, tx.Status)
+	upcoming, err = allowances.Allowances(ctx, wallet.ID, next)
+	require.NoError(t, err)
+	require.Equal(t, int64(9), upcoming.Allowances[0].Remaining)
+
+	_, err = allowances.Allowances(ctx, uuid.New(), now)
+	require.ErrorIs(t, err, service.ErrWalletNotFound)
+}
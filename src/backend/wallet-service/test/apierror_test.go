@@ -0,0 +1,71 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/apierror"
+	"internal/repository"
+	"internal/service"
+)
+
+// TestFromError tests mapping of domain errors to API error codes and statuses
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   apierror.Code
+		wantStatus int
+	}{
+		{
+			name:       "service wallet not found",
+			err:        service.ErrWalletNotFound,
+			wantCode:   apierror.CodeWalletNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "wrapped repository insufficient balance",
+			err:        fmt.Errorf("failed to process transaction: %w", repository.ErrInsufficientBalance),
+			wantCode:   apierror.CodeInsufficientBalance,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "currency mismatch",
+			err:        service.ErrCurrencyMismatch,
+			wantCode:   apierror.CodeCurrencyMismatch,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "existing api error",
+			err:        apierror.New(apierror.CodeIdempotencyConflict),
+			wantCode:   apierror.CodeIdempotencyConflict,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "unknown error",
+			err:        errors.New("connection reset"),
+			wantCode:   apierror.CodeInternal,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := apierror.FromError(tt.err)
+			require.Equal(t, tt.wantCode, apiErr.Code)
+			require.Equal(t, tt.wantStatus, apiErr.Status)
+		})
+	}
+}
+
+// TestParseLanguage tests Accept-Language negotiation for localized messages
+func TestParseLanguage(t *testing.T) {
+	require.Equal(t, "hi", apierror.ParseLanguage("hi-IN,hi;q=0.9,en;q=0.8"))
+	require.Equal(t, "en", apierror.ParseLanguage("fr-FR,de;q=0.5"))
+	require.Equal(t, "en", apierror.ParseLanguage(""))
+	require.Equal(t, "Wallet not found", apierror.Message(apierror.CodeWalletNotFound, "fr"))
+}
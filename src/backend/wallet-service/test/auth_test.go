@@ -0,0 +1,293 @@
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"        // v5.2.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/auth"
+	"internal/config"
+)
+
+const (
+	testIssuer   = "https://id.example.com"
+	testAudience = "wallet-service"
+	testSecret   = "shared-test-secret"
+)
+
+// jwksServer serves a JWKS document holding the public keys it is given and
+// counts how often it is fetched
+type jwksServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetches int
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{keys: make(map[string]*rsa.PublicKey)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.fetches++
+		var doc struct {
+			Keys []map[string]string `json:"keys"`
+		}
+		for kid, key := range s.keys {
+			doc.Keys = append(doc.Keys, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// publish replaces the served key set
+func (s *jwksServer) publish(keys map[string]*rsa.PrivateKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = make(map[string]*rsa.PublicKey, len(keys))
+	for kid, key := range keys {
+		s.keys[kid] = &key.PublicKey
+	}
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+// securityConfig returns settings validating RS256 tokens of testIssuer for
+// testAudience against the keys of server
+func securityConfig(server *jwksServer) config.SecurityConfig {
+	return config.SecurityConfig{
+		JWTAlgorithms:          []string{"RS256"},
+		JWTIssuer:              testIssuer,
+		JWTAudience:            testAudience,
+		JWTClockSkew:           30 * time.Second,
+		JWKSURL:                server.URL,
+		JWKSRefreshInterval:    time.Hour,
+		JWKSMinRefreshInterval: 50 * time.Millisecond,
+	}
+}
+
+// tokenClaims returns valid claims for the test issuer and audience
+func tokenClaims() *auth.Claims {
+	now := time.Now()
+	return &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testIssuer,
+			Subject:   "customer-1",
+			Audience:  jwt.ClaimStrings{testAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		CustomerID: "customer-1",
+		Roles:      []string{"customer"},
+	}
+}
+
+// sign signs claims with method and key, naming kid in the header when set
+func sign(t *testing.T, method jwt.SigningMethod, kid string, claims *auth.Claims, key interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// TestTokenClaimsValidation tests that tokens must name the configured issuer
+// and audience and be valid now, give or take the clock skew
+func TestTokenClaimsValidation(t *testing.T) {
+	ctx := context.Background()
+	key := newRSAKey(t)
+	server := newJWKSServer(t)
+	server.publish(map[string]*rsa.PrivateKey{"k1": key})
+	validator, err := auth.NewValidator(securityConfig(server))
+	require.NoError(t, err)
+
+	now := time.Now()
+	tests := []struct {
+		name    string
+		modify  func(c *auth.Claims)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(c *auth.Claims) {}},
+		{name: "other issuer", modify: func(c *auth.Claims) { c.Issuer = "https://evil.example.com" }, wantErr: true},
+		{name: "missing issuer", modify: func(c *auth.Claims) { c.Issuer = "" }, wantErr: true},
+		{name: "other audience", modify: func(c *auth.Claims) { c.Audience = jwt.ClaimStrings{"billing-admin"} }, wantErr: true},
+		{name: "audience among others", modify: func(c *auth.Claims) { c.Audience = jwt.ClaimStrings{"billing-admin", testAudience} }},
+		{name: "missing expiry", modify: func(c *auth.Claims) { c.ExpiresAt = nil }, wantErr: true},
+		{name: "expired within skew", modify: func(c *auth.Claims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-10 * time.Second)) }},
+		{name: "expired beyond skew", modify: func(c *auth.Claims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute)) }, wantErr: true},
+		{name: "issued ahead within skew", modify: func(c *auth.Claims) { c.IssuedAt = jwt.NewNumericDate(now.Add(10 * time.Second)) }},
+		{name: "issued ahead beyond skew", modify: func(c *auth.Claims) { c.IssuedAt = jwt.NewNumericDate(now.Add(time.Minute)) }, wantErr: true},
+		{name: "not yet valid within skew", modify: func(c *auth.Claims) { c.NotBefore = jwt.NewNumericDate(now.Add(10 * time.Second)) }},
+		{name: "not yet valid beyond skew", modify: func(c *auth.Claims) { c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute)) }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := tokenClaims()
+			tt.modify(claims)
+			validated, err := validator.Validate(ctx, sign(t, jwt.SigningMethodRS256, "k1", claims, key))
+			if tt.wantErr {
+				require.ErrorIs(t, err, auth.ErrInvalidToken)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "customer-1", validated.CustomerID)
+			require.True(t, validated.HasRole("customer"))
+		})
+	}
+}
+
+// TestTokenAlgorithms tests that only the configured algorithms are
+// accepted, so unsigned tokens and HMAC tokens keyed with the public RSA key
+// are refused
+func TestTokenAlgorithms(t *testing.T) {
+	ctx := context.Background()
+	key := newRSAKey(t)
+	server := newJWKSServer(t)
+	server.publish(map[string]*rsa.PrivateKey{"k1": key})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})
+
+	// Unsupported or unkeyed algorithms are refused at startup
+	cfg := securityConfig(server)
+	cfg.JWTAlgorithms = []string{"none"}
+	_, err := auth.NewValidator(cfg)
+	require.ErrorIs(t, err, auth.ErrUnsupportedAlgorithm)
+	cfg.JWTAlgorithms = []string{"HS256"}
+	_, err = auth.NewValidator(cfg)
+	require.Error(t, err)
+	cfg = securityConfig(server)
+	cfg.JWKSURL = ""
+	_, err = auth.NewValidator(cfg)
+	require.Error(t, err)
+
+	rsOnly, err := auth.NewValidator(securityConfig(server))
+	require.NoError(t, err)
+	cfg = securityConfig(server)
+	cfg.JWTAlgorithms = []string{"RS256", "HS256"}
+	cfg.JWTSecret = testSecret
+	mixed, err := auth.NewValidator(cfg)
+	require.NoError(t, err)
+	cfg.JWTAlgorithms = []string{"HS256"}
+	hsOnly, err := auth.NewValidator(cfg)
+	require.NoError(t, err)
+
+	unsigned := sign(t, jwt.SigningMethodNone, "k1", tokenClaims(), jwt.UnsafeAllowNoneSignatureType)
+	confused := sign(t, jwt.SigningMethodHS256, "k1", tokenClaims(), publicPEM)
+	hmac := sign(t, jwt.SigningMethodHS256, "", tokenClaims(), []byte(testSecret))
+	rs256 := sign(t, jwt.SigningMethodRS256, "k1", tokenClaims(), key)
+	rs384 := sign(t, jwt.SigningMethodRS384, "k1", tokenClaims(), key)
+
+	tests := []struct {
+		name      string
+		validator *auth.Validator
+		token     string
+		wantErr   bool
+	}{
+		{name: "none", validator: rsOnly, token: unsigned, wantErr: true},
+		{name: "none with HMAC allowed", validator: mixed, token: unsigned, wantErr: true},
+		{name: "HMAC keyed with the public key", validator: rsOnly, token: confused, wantErr: true},
+		{name: "HMAC keyed with the public key with HMAC allowed", validator: mixed, token: confused, wantErr: true},
+		{name: "HMAC not allowed", validator: rsOnly, token: hmac, wantErr: true},
+		{name: "HMAC allowed", validator: mixed, token: hmac},
+		{name: "RSA not allowed", validator: hsOnly, token: rs256, wantErr: true},
+		{name: "RSA allowed", validator: mixed, token: rs256},
+		{name: "RSA with other hash", validator: rsOnly, token: rs384, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.validator.Validate(ctx, tt.token)
+			if tt.wantErr {
+				require.ErrorIs(t, err, auth.ErrInvalidToken)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+// TestJWKSKeyRotation tests that keys published by the identity provider are
+// picked up when tokens name them, retired keys stop validating, and
+// refreshes triggered by unknown key IDs are throttled
+func TestJWKSKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	first, second := newRSAKey(t), newRSAKey(t)
+	server := newJWKSServer(t)
+	server.publish(map[string]*rsa.PrivateKey{"k1": first})
+	validator, err := auth.NewValidator(securityConfig(server))
+	require.NoError(t, err)
+
+	require.NoError(t, validator.Warm(ctx))
+	require.Equal(t, 1, server.fetchCount())
+	_, err = validator.Validate(ctx, sign(t, jwt.SigningMethodRS256, "k1", tokenClaims(), first))
+	require.NoError(t, err)
+	require.Equal(t, 1, server.fetchCount())
+
+	// Forged key IDs trigger no refresh within the minimum interval
+	for i := 0; i < 10; i++ {
+		_, err = validator.Validate(ctx, sign(t, jwt.SigningMethodRS256, "forged", tokenClaims(), second))
+		require.ErrorIs(t, err, auth.ErrInvalidToken)
+	}
+	require.Equal(t, 1, server.fetchCount())
+
+	// The provider rotates to a new key; tokens naming it wait for the
+	// throttle to pass
+	server.publish(map[string]*rsa.PrivateKey{"k2": second})
+	rotated := sign(t, jwt.SigningMethodRS256, "k2", tokenClaims(), second)
+	_, err = validator.Validate(ctx, rotated)
+	require.ErrorIs(t, err, auth.ErrInvalidToken)
+	require.Equal(t, 1, server.fetchCount())
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = validator.Validate(ctx, rotated)
+	require.NoError(t, err)
+	require.Equal(t, 2, server.fetchCount())
+
+	// The retired key no longer validates, and a burst of unknown key IDs
+	// costs at most one refresh
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		_, err = validator.Validate(ctx, sign(t, jwt.SigningMethodRS256, "k1", tokenClaims(), first))
+		require.ErrorIs(t, err, auth.ErrInvalidToken)
+	}
+	require.Equal(t, 3, server.fetchCount())
+	_, err = validator.Validate(ctx, rotated)
+	require.NoError(t, err)
+	require.Equal(t, 3, server.fetchCount())
+}
@@ -0,0 +1,277 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/events"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestBalanceThresholds tests that a balance hovering around a threshold
+// alerts once, that a level alerts again only after the balance recovered
+// above its reset, and that a drop through both levels alerts on each
+func TestBalanceThresholds(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	bus := eventbus.New()
+	var alerts []eventbus.BalanceThresholdCrossed
+	require.NoError(t, bus.Subscribe("alerts", func(ctx context.Context, event eventbus.Event) error {
+		alerts = append(alerts, event.(eventbus.BalanceThresholdCrossed))
+		return nil
+	}, eventbus.TypeBalanceWarning, eventbus.TypeBalanceCritical))
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, bus, &alertLogger{})
+	require.NoError(t, err)
+	thresholds, err := service.NewBalanceThresholdService(kit.Store, kit.Store, wallets, bus, service.BalanceThresholdPolicy{
+		WarningPercent:       20,
+		WarningResetPercent:  25,
+		CriticalPercent:      5,
+		CriticalResetPercent: 10,
+	}, &alertLogger{})
+	require.NoError(t, err)
+	require.NoError(t, bus.Subscribe("balance-thresholds", thresholds.Handle, eventbus.TypeBalanceChanged))
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "USD", Balance: 1000}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+
+	post := func(txType models.TransactionType, amount float64) {
+		_, err := wallets.ProcessTransaction(ctx, &models.Transaction{
+			ID:       uuid.New(),
+			WalletID: wallet.ID,
+			Type:     txType,
+			Status:   models.TransactionStatusInitiated,
+			Amount:   amount,
+			Currency: "USD",
+		})
+		require.NoError(t, err)
+	}
+
+	_, err = thresholds.Get(ctx, wallet.ID)
+	require.ErrorIs(t, err, service.ErrBalanceThresholdsNotFound)
+
+	// Invalid levels are refused
+	critical := 30.0
+	_, err = thresholds.Update(ctx, wallet.ID, service.BalanceThresholdInput{
+		ReferenceBalance: decimal.NewFromInt(1000),
+		CriticalPercent:  &critical,
+	})
+	require.ErrorIs(t, err, service.ErrInvalidBalanceThresholds)
+	_, err = thresholds.Update(ctx, wallet.ID, service.BalanceThresholdInput{})
+	require.ErrorIs(t, err, service.ErrInvalidBalanceThresholds)
+
+	// Omitted percentages take the defaults: warning below 200, re-armed
+	// above 250, critical below 50, re-armed above 100
+	configured, err := thresholds.Update(ctx, wallet.ID, service.BalanceThresholdInput{
+		ReferenceBalance: decimal.NewFromInt(1000),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 200.0, configured.TriggerAmount(models.BalanceAlertWarning))
+	require.Equal(t, 100.0, configured.ResetAmount(models.BalanceAlertCritical))
+	require.Empty(t, alerts)
+
+	post(models.TransactionTypeDebit, 810)
+	require.Len(t, alerts, 1)
+	require.Equal(t, models.BalanceAlertWarning, alerts[0].Level)
+	require.Equal(t, 190.0, alerts[0].Wallet.Balance)
+
+	// Hovering around the trigger below the reset stays quiet
+	post(models.TransactionTypeCredit, 20)
+	post(models.TransactionTypeDebit, 20)
+	post(models.TransactionTypeCredit, 50)
+	post(models.TransactionTypeDebit, 50)
+	require.Len(t, alerts, 1)
+
+	// Recovering above the reset re-arms the warning
+	post(models.TransactionTypeCredit, 70)
+	current, err := thresholds.Get(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.False(t, current.Warning.Triggered)
+	post(models.TransactionTypeDebit, 70)
+	require.Len(t, alerts, 2)
+	require.Equal(t, eventbus.TypeBalanceWarning, alerts[1].EventType())
+
+	post(models.TransactionTypeDebit, 150)
+	require.Len(t, alerts, 3)
+	require.Equal(t, eventbus.TypeBalanceCritical, alerts[2].EventType())
+	require.Equal(t, 40.0, alerts[2].Wallet.Balance)
+
+	env, err := events.NewBalanceThreshold(alerts[2].Wallet, alerts[2].Level, alerts[2].Thresholds, 1)
+	require.NoError(t, err)
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+	require.NoError(t, registry.Validate(env))
+	require.Equal(t, events.TypeBalanceCritical, env.Type)
+
+	// Replacing the thresholds re-arms both levels, and a wallet already
+	// below them alerts on each right away
+	alerts = nil
+	_, err = thresholds.Update(ctx, wallet.ID, service.BalanceThresholdInput{
+		ReferenceBalance: decimal.NewFromInt(1000),
+	})
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	require.Equal(t, models.BalanceAlertWarning, alerts[0].Level)
+	require.Equal(t, models.BalanceAlertCritical, alerts[1].Level)
+
+	// Wallets without thresholds never alert
+	require.NoError(t, thresholds.Delete(ctx, wallet.ID))
+	require.ErrorIs(t, thresholds.Delete(ctx, wallet.ID), service.ErrBalanceThresholdsNotFound)
+	post(models.TransactionTypeCredit, 500)
+	post(models.TransactionTypeDebit, 530)
+	require.Len(t, alerts, 2)
+}
+
+// TestBalanceAlerts tests that each alert threshold of a wallet alerts once
+// on its consented channels as the balance falls below it, that a recharge
+// re-arms it, and that usage thresholds follow the wallet's usage
+func TestBalanceAlerts(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+	publisher := &recordingPublisher{}
+	forwarder, err := events.NewForwarder(registry, publisher, grantedConsents{models.ConsentSMS: true})
+	require.NoError(t, err)
+
+	bus := eventbus.New()
+	var alerts []eventbus.BalanceAlertTriggered
+	require.NoError(t, bus.Subscribe("alerts", func(ctx context.Context, event eventbus.Event) error {
+		alerts = append(alerts, event.(eventbus.BalanceAlertTriggered))
+		return nil
+	}, eventbus.TypeBalanceAlert))
+	require.NoError(t, bus.Subscribe("event-stream", forwarder.Handle, eventbus.TypeBalanceAlert))
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, bus, &alertLogger{})
+	require.NoError(t, err)
+	thresholds, err := service.NewBalanceThresholdService(kit.Store, kit.Store, wallets, bus, service.BalanceThresholdPolicy{
+		WarningPercent:       20,
+		WarningResetPercent:  25,
+		CriticalPercent:      5,
+		CriticalResetPercent: 10,
+	}, &alertLogger{})
+	require.NoError(t, err)
+	require.NoError(t, bus.Subscribe("balance-thresholds", thresholds.Handle, eventbus.TypeBalanceChanged))
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "USD", Balance: 1000}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+
+	post := func(txType models.TransactionType, amount float64) {
+		_, err := wallets.ProcessTransaction(ctx, &models.Transaction{
+			ID:       uuid.New(),
+			WalletID: wallet.ID,
+			Type:     txType,
+			Status:   models.TransactionStatusCompleted,
+			Amount:   amount,
+			Currency: "USD",
+		})
+		require.NoError(t, err)
+	}
+	threshold := func(basis models.BalanceAlertBasis, value int64, channels ...models.BalanceAlertChannel) service.BalanceAlertInput {
+		return service.BalanceAlertInput{Basis: basis, Value: decimal.NewFromInt(value), Channels: channels}
+	}
+
+	// A month's usage of 300 for a wallet younger than a month
+	post(models.TransactionTypeDebit, 300)
+
+	// Invalid thresholds are refused
+	invalid := [][]service.BalanceAlertInput{
+		make([]service.BalanceAlertInput, service.MaxBalanceAlerts+1),
+		{threshold("DAYS", 5, models.BalanceAlertEmail)},
+		{threshold(models.BalanceAlertAbsolute, 0, models.BalanceAlertEmail)},
+		{threshold(models.BalanceAlertUsagePercent, 1001, models.BalanceAlertEmail)},
+		{threshold(models.BalanceAlertAbsolute, 100)},
+		{threshold(models.BalanceAlertAbsolute, 100, "PIGEON")},
+		{threshold(models.BalanceAlertAbsolute, 100, models.BalanceAlertSMS, models.BalanceAlertSMS)},
+		{threshold(models.BalanceAlertAbsolute, 100, models.BalanceAlertSMS), threshold(models.BalanceAlertAbsolute, 100, models.BalanceAlertEmail)},
+	}
+	for _, inputs := range invalid {
+		_, err := thresholds.SetAlerts(ctx, wallet.ID, inputs)
+		require.ErrorIs(t, err, service.ErrInvalidBalanceAlerts)
+	}
+	_, err = thresholds.SetAlerts(ctx, uuid.New(), nil)
+	require.ErrorIs(t, err, service.ErrWalletNotFound)
+
+	// Usage thresholds resolve to 150 and 60, listed highest first
+	set, err := thresholds.SetAlerts(ctx, wallet.ID, []service.BalanceAlertInput{
+		threshold(models.BalanceAlertUsagePercent, 50, models.BalanceAlertEmail),
+		threshold(models.BalanceAlertAbsolute, 400, models.BalanceAlertWebhook),
+		threshold(models.BalanceAlertUsagePercent, 20, models.BalanceAlertSMS, models.BalanceAlertEmail),
+	})
+	require.NoError(t, err)
+	require.Len(t, set, 3)
+	require.Equal(t, "400", set[0].TriggerAmount.String())
+	require.Equal(t, "150", set[1].TriggerAmount.String())
+	require.Equal(t, "60", set[2].TriggerAmount.String())
+	require.Empty(t, alerts)
+
+	// Each threshold alerts once as the balance falls below it
+	post(models.TransactionTypeDebit, 350)
+	require.Len(t, alerts, 1)
+	require.Equal(t, set[0].ID, alerts[0].Alert.ID)
+	post(models.TransactionTypeDebit, 10)
+	post(models.TransactionTypeCredit, 20)
+	post(models.TransactionTypeDebit, 20)
+	require.Len(t, alerts, 1)
+
+	// A single debit through two thresholds alerts on both, highest first
+	post(models.TransactionTypeDebit, 300)
+	require.Len(t, alerts, 3)
+	require.Equal(t, set[1].ID, alerts[1].Alert.ID)
+	require.Equal(t, set[2].ID, alerts[2].Alert.ID)
+	require.Equal(t, 40.0, alerts[2].Wallet.Balance)
+
+	// Only consented channels are forwarded, and alerts left without one
+	// are dropped
+	require.Len(t, publisher.envelopes, 2)
+	for _, env := range publisher.envelopes {
+		require.Equal(t, events.TypeBalanceAlert, env.Type)
+		require.NoError(t, registry.Validate(env))
+	}
+	var payload events.BalanceAlertV1
+	require.NoError(t, json.Unmarshal(publisher.envelopes[1].Payload, &payload))
+	require.Equal(t, []string{"SMS"}, payload.Channels)
+	require.Equal(t, 60.0, payload.Threshold)
+
+	// A recharge re-arms the thresholds, usage ones at current usage
+	listed, err := thresholds.ListAlerts(ctx, wallet.ID)
+	require.NoError(t, err)
+	for _, alert := range listed {
+		require.True(t, alert.Triggered)
+		require.NotNil(t, alert.TriggeredAt)
+	}
+	post(models.TransactionTypeCredit, 1000)
+	listed, err = thresholds.ListAlerts(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Len(t, listed, 3)
+	require.Equal(t, "490", listed[0].TriggerAmount.String())
+	require.Equal(t, "400", listed[1].TriggerAmount.String())
+	require.Equal(t, "196", listed[2].TriggerAmount.String())
+	for _, alert := range listed {
+		require.False(t, alert.Triggered)
+	}
+
+	post(models.TransactionTypeDebit, 600)
+	require.Len(t, alerts, 4)
+	require.Equal(t, set[1].ID, alerts[3].Alert.ID)
+
+	// An empty list removes the alerts
+	_, err = thresholds.SetAlerts(ctx, wallet.ID, []service.BalanceAlertInput{})
+	require.NoError(t, err)
+	post(models.TransactionTypeDebit, 400)
+	require.Len(t, alerts, 4)
+	listed, err = thresholds.ListAlerts(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Empty(t, listed)
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// auditLog is an in-memory AuditRepository
+type auditLog struct {
+	actions []*models.OperatorAction
+}
+
+func (l *auditLog) RecordOperatorAction(ctx context.Context, action *models.OperatorAction) error {
+	l.actions = append(l.actions, action)
+	return nil
+}
+
+func (l *auditLog) ListOperatorActions(ctx context.Context, limit, offset int) ([]*models.OperatorAction, error) {
+	return l.actions, nil
+}
+
+func (l *auditLog) Close() error {
+	return nil
+}
+
+// TestBillingPeriodClose tests that a closed period refuses backdated
+// transactions until it is reopened, and that closes and reopenings are
+// audited
+func TestBillingPeriodClose(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	ctx := context.Background()
+	wallet := kit.CreateWallet(t, uuid.New(), "INR", 100)
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+
+	audit := &auditLog{}
+	periods, err := service.NewBillingPeriodService(kit.Store, audit, &alertLogger{})
+	require.NoError(t, err)
+
+	_, err = periods.Close(ctx, "2023-13", "finance", "month end")
+	require.ErrorIs(t, err, service.ErrInvalidBillingPeriod)
+	_, err = periods.Close(ctx, "2023-11", "finance", " ")
+	require.ErrorIs(t, err, service.ErrInvalidBillingPeriod)
+	_, err = periods.Close(ctx, time.Now().UTC().Format(models.BillingPeriodLayout), "finance", "month end")
+	require.ErrorIs(t, err, service.ErrInvalidBillingPeriod)
+
+	closed, err := periods.Close(ctx, "2023-11", "finance", "month end")
+	require.NoError(t, err)
+	require.Equal(t, models.BillingPeriodClosed, closed.Status)
+	require.Equal(t, "finance", closed.ClosedBy)
+
+	_, err = periods.Close(ctx, "2023-11", "finance", "month end")
+	require.ErrorIs(t, err, service.ErrBillingPeriodConflict)
+
+	backdated := func() *models.Transaction {
+		effectiveAt := time.Date(2023, 11, 15, 12, 0, 0, 0, time.UTC)
+		return &models.Transaction{
+			WalletID:    wallet.ID,
+			Type:        models.TransactionTypeCredit,
+			Status:      models.TransactionStatusInitiated,
+			Amount:      10,
+			Currency:    "INR",
+			EffectiveAt: &effectiveAt,
+		}
+	}
+
+	_, err = wallets.ProcessTransaction(ctx, backdated())
+	require.ErrorIs(t, err, service.ErrPeriodClosed)
+
+	// Postings into open periods are unaffected
+	current := backdated()
+	current.EffectiveAt = nil
+	_, err = wallets.ProcessTransaction(ctx, current)
+	require.NoError(t, err)
+
+	future := backdated()
+	effectiveAt := time.Now().Add(time.Hour)
+	future.EffectiveAt = &effectiveAt
+	_, err = wallets.ProcessTransaction(ctx, future)
+	require.ErrorIs(t, err, service.ErrFutureEffectiveDate)
+
+	reopened, err := periods.Reopen(ctx, "2023-11", "controller", "late invoice correction")
+	require.NoError(t, err)
+	require.Equal(t, models.BillingPeriodOpen, reopened.Status)
+	require.Equal(t, "controller", reopened.ReopenedBy)
+
+	tx := backdated()
+	_, err = wallets.ProcessTransaction(ctx, tx)
+	require.NoError(t, err)
+	require.Equal(t, "2023-11", tx.EffectiveTime().Format(models.BillingPeriodLayout))
+
+	balance, _, err := wallets.GetWalletBalance(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, "120", balance.String())
+
+	listed, err := periods.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+
+	var statuses []models.OperatorActionStatus
+	for _, action := range audit.actions {
+		require.Equal(t, "2023-11", action.Params["period"])
+		statuses = append(statuses, action.Status)
+	}
+	require.Equal(t, []models.OperatorActionStatus{
+		models.OperatorActionSucceeded,
+		models.OperatorActionFailed,
+		models.OperatorActionSucceeded,
+	}, statuses)
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"            // v1.9.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/api"
+	"internal/bodycapture"
+	"internal/models"
+)
+
+// captureSink collects stored captures
+type captureSink struct {
+	captures chan *models.RequestCapture
+}
+
+func (s *captureSink) RecordCapture(ctx context.Context, capture *models.RequestCapture) error {
+	s.captures <- capture
+	return nil
+}
+
+// TestBodyRedaction tests field masks at any depth, patterns in string
+// values and the masking of card numbers in JSON and text bodies
+func TestBodyRedaction(t *testing.T) {
+	redactor, err := bodycapture.NewRedactor(bodycapture.Rules{
+		FieldMasks: []string{"email"},
+		Patterns:   []string{`\b[6-9]\d{9}\b`},
+	})
+	require.NoError(t, err)
+
+	redacted := string(redactor.Redact([]byte(`{
+		"amount": 12.50,
+		"description": "card 4111 1111 1111 1111, call 9876543210",
+		"customer": {"Email": "asha@example.com", "tier": "gold"},
+		"items": [{"email": "ravi@example.com"}]
+	}`)))
+	require.JSONEq(t, `{
+		"amount": 12.50,
+		"description": "card XXXX XXXX XXXX 1111, call [REDACTED]",
+		"customer": {"Email": "[REDACTED]", "tier": "gold"},
+		"items": [{"email": "[REDACTED]"}]
+	}`, redacted)
+
+	require.Equal(t, "paid with XXXXXXXXXXXX1111 from [REDACTED]",
+		string(redactor.Redact([]byte("paid with 4111111111111111 from 9876543210"))))
+
+	_, err = bodycapture.NewRedactor(bodycapture.Rules{Patterns: []string{"("}})
+	require.Error(t, err)
+}
+
+// TestBodyCaptureMiddleware tests that sampled requests are stored redacted
+// and cut to the size cap while unsampled requests are not captured
+func TestBodyCaptureMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	redactor, err := bodycapture.NewRedactor(bodycapture.Rules{FieldMasks: []string{"email"}})
+	require.NoError(t, err)
+
+	newRouter := func(opts bodycapture.Options) (*gin.Engine, *captureSink) {
+		sink := &captureSink{captures: make(chan *models.RequestCapture, 10)}
+		recorder, err := bodycapture.NewRecorder(redactor, sink, opts, &alertLogger{})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		go recorder.Run(ctx)
+
+		router := gin.New()
+		router.Use(api.BodyCaptureMiddleware(recorder))
+		router.POST("/customers/:id", func(c *gin.Context) {
+			c.JSON(http.StatusCreated, gin.H{"email": "asha@example.com", "note": strings.Repeat("x", 100)})
+		})
+		return router, sink
+	}
+
+	post := func(router *gin.Engine) {
+		req := httptest.NewRequest(http.MethodPost, "/customers/42?email=asha@example.com",
+			strings.NewReader(`{"email":"asha@example.com","amount":10}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		// The client gets the unredacted response
+		require.Contains(t, rec.Body.String(), "asha@example.com")
+	}
+
+	router, sink := newRouter(bodycapture.Options{SampleRate: 1, MaxBodyBytes: 64, QueueSize: 10})
+	post(router)
+
+	select {
+	case capture := <-sink.captures:
+		require.Equal(t, http.MethodPost, capture.Method)
+		require.Equal(t, "/customers/:id", capture.Route)
+		require.Equal(t, "/customers/42", capture.Path)
+		require.Equal(t, http.StatusCreated, capture.Status)
+		require.JSONEq(t, `{"email":"[REDACTED]","amount":10}`, capture.RequestBody)
+		require.True(t, strings.HasPrefix(capture.ResponseBody, `{"email":"[REDACTED]"`))
+		require.Len(t, capture.ResponseBody, 64)
+		require.True(t, capture.Truncated)
+	case <-time.After(time.Second):
+		t.Fatal("capture was not stored")
+	}
+
+	router, sink = newRouter(bodycapture.Options{SampleRate: 0, MaxBodyBytes: 64, QueueSize: 10})
+	post(router)
+	select {
+	case <-sink.captures:
+		t.Fatal("unsampled request was captured")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
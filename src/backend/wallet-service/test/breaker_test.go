@@ -0,0 +1,130 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"        // v8.11.5
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/breaker"
+	"internal/payment"
+)
+
+// flakyProvider is a payment provider adapter returning err from every call
+type flakyProvider struct {
+	stubProvider
+	err   error
+	calls int
+}
+
+func (p *flakyProvider) Refund(ctx context.Context, req payment.RefundRequest) (*payment.RefundResult, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.stubProvider.Refund(ctx, req)
+}
+
+// TestCircuitBreaker tests that a breaker opens after consecutive failures,
+// refuses calls while open and closes once a trial call succeeds
+func TestCircuitBreaker(t *testing.T) {
+	_, err := breaker.New("fx", breaker.Settings{OpenTimeout: time.Second, HalfOpenRequests: 1}, nil, &alertLogger{})
+	require.Error(t, err)
+
+	b, err := breaker.New("fx", breaker.Settings{
+		ConsecutiveFailures: 2,
+		OpenTimeout:         50 * time.Millisecond,
+		HalfOpenRequests:    1,
+	}, nil, &alertLogger{})
+	require.NoError(t, err)
+
+	outage := errors.New("connection refused")
+	calls := 0
+	failing := func() error { calls++; return outage }
+
+	require.ErrorIs(t, b.Do(failing), outage)
+	require.Equal(t, "closed", b.State())
+	require.ErrorIs(t, b.Do(failing), outage)
+	require.Equal(t, "open", b.State())
+
+	err = b.Do(failing)
+	require.ErrorIs(t, err, breaker.ErrOpen)
+	require.Equal(t, 2, calls)
+
+	// A failed trial call opens the breaker again, a successful one closes it
+	time.Sleep(60 * time.Millisecond)
+	require.Equal(t, "half-open", b.State())
+	require.ErrorIs(t, b.Do(failing), outage)
+	require.Equal(t, "open", b.State())
+
+	time.Sleep(60 * time.Millisecond)
+	require.NoError(t, b.Do(func() error { return nil }))
+	require.Equal(t, "closed", b.State())
+}
+
+// TestPaymentProviderBreaker tests that a guarded provider stops calling a
+// provider that keeps failing, while rejected refunds leave it closed
+func TestPaymentProviderBreaker(t *testing.T) {
+	ctx := context.Background()
+	b, err := breaker.New("payment:razorpay", breaker.Settings{
+		ConsecutiveFailures: 3,
+		OpenTimeout:         time.Minute,
+		HalfOpenRequests:    1,
+	}, payment.Successful, &alertLogger{})
+	require.NoError(t, err)
+
+	flaky := &flakyProvider{stubProvider: stubProvider{name: "razorpay"}, err: payment.ErrRefundRejected}
+	provider, err := payment.WithBreaker(flaky, b)
+	require.NoError(t, err)
+	require.Equal(t, "razorpay", provider.Name())
+
+	for i := 0; i < 5; i++ {
+		_, err = provider.Refund(ctx, payment.RefundRequest{PaymentID: "pay_1"})
+		require.ErrorIs(t, err, payment.ErrRefundRejected)
+	}
+	require.Equal(t, "closed", b.State())
+
+	flaky.err = errors.New("gateway timeout")
+	for i := 0; i < 5; i++ {
+		_, err = provider.Refund(ctx, payment.RefundRequest{PaymentID: "pay_1"})
+	}
+	require.ErrorIs(t, err, breaker.ErrOpen)
+	require.Equal(t, 8, flaky.calls)
+
+	// Status checks share the breaker of their provider
+	_, err = provider.GetRefund(ctx, "rf_1")
+	require.ErrorIs(t, err, breaker.ErrOpen)
+}
+
+// TestRedisBreaker tests that Redis commands fail fast once the breaker has
+// seen Redis fail
+func TestRedisBreaker(t *testing.T) {
+	ctx := context.Background()
+	b, err := breaker.New("redis", breaker.Settings{
+		ConsecutiveFailures: 2,
+		OpenTimeout:         time.Minute,
+		HalfOpenRequests:    1,
+	}, breaker.RedisSuccessful, &alertLogger{})
+	require.NoError(t, err)
+
+	// Nothing listens on the discard port
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:9", MaxRetries: -1, DialTimeout: time.Second})
+	defer client.Close()
+	client.AddHook(breaker.RedisHook(b))
+
+	require.Error(t, client.Get(ctx, "key").Err())
+	_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, "counter")
+		return nil
+	})
+	require.Error(t, err)
+	require.Equal(t, "open", b.State())
+
+	require.ErrorIs(t, client.Set(ctx, "key", "value", 0).Err(), breaker.ErrOpen)
+	require.ErrorIs(t, client.Ping(ctx).Err(), breaker.ErrOpen)
+
+	require.True(t, breaker.RedisSuccessful(redis.Nil))
+}
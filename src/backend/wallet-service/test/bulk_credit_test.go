@@ -0,0 +1,215 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+	"internal/service"
+	"internal/testkit"
+)
+
+// lostPostings loses the reply to the batch of postings numbered failAt
+// after it is committed, as a connection dropped mid-request would
+type lostPostings struct {
+	repository.WalletRepository
+	batches int
+	failAt  int
+}
+
+func (w *lostPostings) PostTransactions(ctx context.Context, txs []*models.Transaction) ([]error, error) {
+	w.batches++
+	refused, err := w.WalletRepository.PostTransactions(ctx, txs)
+	if err == nil && w.batches == w.failAt {
+		return nil, errors.New("connection reset")
+	}
+	return refused, err
+}
+
+// TestBulkCredits tests that bulk credits are posted a chunk at a time in
+// one batch, report bad rows per row, resume without posting twice when a
+// run fails part way, are paused by incidents and are rolled back once
+// their job has finished
+func TestBulkCredits(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+	jobs := newReportJobService(t, kit)
+
+	currencies := supportedCurrencies(t)
+	wallets, err := service.NewWalletService(kit.Store, currencies, decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	incidents, err := service.NewIncidentService(kit.Store, &auditLog{}, 72*time.Hour, &alertLogger{})
+	require.NoError(t, err)
+	postings := &lostPostings{WalletRepository: kit.Store, failAt: 2}
+	bulk, err := service.NewBulkCreditService(kit.Store, wallets, postings, incidents, jobs, currencies, eventbus.New(), service.BulkCreditPolicy{
+		MaxRows:       200,
+		ChunkSize:     50,
+		RowsPerSecond: 100000,
+	}, &alertLogger{})
+	require.NoError(t, err)
+	require.NoError(t, jobs.Register(service.BulkCreditReport(bulk)))
+	require.NoError(t, jobs.Register(service.BulkCreditRollbackReport(bulk)))
+
+	request := func(walletIDs []string) service.BulkCreditRequest {
+		return service.BulkCreditRequest{
+			WalletIDs:   walletIDs,
+			Amount:      decimal.NewFromInt(10),
+			Currency:    "inr",
+			Description: "Goodwill credit for the March outage",
+		}
+	}
+
+	// Operations are bounded by the quota and validated
+	_, err = bulk.Submit(ctx, request(nil), "support")
+	require.ErrorIs(t, err, service.ErrInvalidBulkCredit)
+	_, err = bulk.Submit(ctx, request(make([]string, 201)), "support")
+	require.ErrorIs(t, err, service.ErrInvalidBulkCredit)
+	invalid := request([]string{uuid.New().String()})
+	invalid.Description = " "
+	_, err = bulk.Submit(ctx, invalid, "support")
+	require.ErrorIs(t, err, service.ErrInvalidBulkCredit)
+	invalid = request([]string{uuid.New().String()})
+	invalid.Currency = "XYZ"
+	_, err = bulk.Submit(ctx, invalid, "support")
+	require.ErrorIs(t, err, service.ErrUnsupportedCurrency)
+	invalid = request([]string{uuid.New().String()})
+	invalid.Amount = decimal.Zero
+	_, err = bulk.Submit(ctx, invalid, "support")
+	require.ErrorIs(t, err, service.ErrInvalidAmount)
+
+	usd := &models.Wallet{CustomerID: uuid.New(), Currency: "USD"}
+	require.NoError(t, kit.Store.CreateWallet(ctx, usd))
+	walletIDs := []string{"not-a-wallet", uuid.New().String(), usd.ID.String()}
+	var credited []uuid.UUID
+	for i := 0; i < 120; i++ {
+		wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "INR"}
+		require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+		credited = append(credited, wallet.ID)
+		walletIDs = append(walletIDs, wallet.ID.String())
+	}
+
+	credit, err := bulk.Submit(ctx, request(walletIDs), "support")
+	require.NoError(t, err)
+	require.Equal(t, "INR", credit.Currency)
+	require.Equal(t, 123, credit.TotalRows)
+	require.NotNil(t, credit.JobID)
+
+	// Credits still being posted cannot be rolled back
+	_, err = bulk.Rollback(ctx, credit.ID, "support")
+	require.ErrorIs(t, err, service.ErrBulkCreditConflict)
+
+	// The first run stops at the lost second chunk, after recording the
+	// first
+	ran, err := jobs.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	retried, err := jobs.Get(ctx, *credit.JobID)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobQueued, retried.Status)
+	require.Equal(t, "connection reset", retried.Error)
+	details, err := bulk.Get(ctx, credit.ID)
+	require.NoError(t, err)
+	require.Equal(t, 47, details.Result.Posted)
+	require.Equal(t, 73, details.Result.Pending)
+
+	ran, err = jobs.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	done, err := jobs.Get(ctx, *credit.JobID)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobSucceeded, done.Status)
+	require.Equal(t, 100, done.Progress)
+
+	details, err = bulk.Get(ctx, credit.ID)
+	require.NoError(t, err)
+	require.Equal(t, 123, details.Result.Total)
+	require.Equal(t, 120, details.Result.Posted)
+	require.Equal(t, 3, details.Result.Failed)
+	require.Equal(t, "invalid wallet ID", details.Result.Rows[0].Error)
+	require.Equal(t, service.ErrWalletNotFound.Error(), details.Result.Rows[1].Error)
+	require.Equal(t, service.ErrCurrencyMismatch.Error(), details.Result.Rows[2].Error)
+
+	// The lost chunk was found rather than posted again
+	for _, walletID := range credited {
+		wallet, err := kit.Store.GetWallet(ctx, walletID)
+		require.NoError(t, err)
+		require.Equal(t, 10.0, wallet.Balance)
+	}
+
+	// A wallet that spent its credit keeps it when rolled back
+	_, err = wallets.ProcessTransaction(ctx, &models.Transaction{
+		ID:       uuid.New(),
+		WalletID: credited[0],
+		Type:     models.TransactionTypeDebit,
+		Status:   models.TransactionStatusCompleted,
+		Amount:   8,
+		Currency: "INR",
+	})
+	require.NoError(t, err)
+
+	rolledBack, err := bulk.Rollback(ctx, credit.ID, "support")
+	require.NoError(t, err)
+	require.NotNil(t, rolledBack.RollbackJobID)
+	require.Equal(t, "support", rolledBack.RollbackRequestedBy)
+	_, err = bulk.Rollback(ctx, credit.ID, "support")
+	require.ErrorIs(t, err, service.ErrBulkCreditConflict)
+
+	ran, err = jobs.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	reversed, err := jobs.Get(ctx, *rolledBack.RollbackJobID)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobSucceeded, reversed.Status)
+
+	details, err = bulk.Get(ctx, credit.ID)
+	require.NoError(t, err)
+	require.Zero(t, details.Result.Posted)
+	require.Equal(t, 119, details.Result.Reversed)
+	require.Equal(t, 1, details.Result.NotReversed)
+	require.Equal(t, models.BulkCreditRowNotReversed, details.Result.Rows[3].Status)
+	for _, walletID := range credited[1:] {
+		wallet, err := kit.Store.GetWallet(ctx, walletID)
+		require.NoError(t, err)
+		require.Zero(t, wallet.Balance)
+	}
+
+	_, err = bulk.Get(ctx, uuid.New())
+	require.ErrorIs(t, err, service.ErrBulkCreditNotFound)
+
+	// Operations are purged with their jobs
+	purged, err := bulk.Purge(ctx, time.Now().UTC().Add(time.Minute))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, purged)
+
+	// Credits paused by an incident wait for it to resume
+	_, err = incidents.Pause(ctx, service.IncidentInput{Reason: "Ledger drift", PauseCredits: true}, "ops")
+	require.NoError(t, err)
+	paused, err := bulk.Submit(ctx, request([]string{credited[1].String()}), "support")
+	require.NoError(t, err)
+	ran, err = jobs.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	retried, err = jobs.Get(ctx, *paused.JobID)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobQueued, retried.Status)
+	wallet, err := kit.Store.GetWallet(ctx, credited[1])
+	require.NoError(t, err)
+	require.Zero(t, wallet.Balance)
+
+	_, err = incidents.Resume(ctx, "ops", "Drift explained")
+	require.NoError(t, err)
+	ran, err = jobs.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	wallet, err = kit.Store.GetWallet(ctx, credited[1])
+	require.NoError(t, err)
+	require.Equal(t, 10.0, wallet.Balance)
+}
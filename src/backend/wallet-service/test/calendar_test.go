@@ -0,0 +1,57 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/calendar"
+)
+
+// TestCalendarBusinessDays tests weekends, one-off and annual holidays and
+// business-day arithmetic in the calendar's time zone
+func TestCalendarBusinessDays(t *testing.T) {
+	registry, err := calendar.NewRegistry(map[string]calendar.Definition{
+		"in": {
+			Timezone: "Asia/Kolkata",
+			Weekend:  []string{"saturday", "Sunday"},
+			Holidays: []string{"2024-03-25", "01-26"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"IN"}, registry.Countries())
+
+	cal := registry.For("IN")
+	ist := cal.Location()
+
+	// Friday 2024-01-26 is an annual holiday and the weekend follows it
+	thursday := time.Date(2024, 1, 25, 10, 0, 0, 0, ist)
+	require.True(t, cal.IsBusinessDay(thursday))
+	require.False(t, cal.IsBusinessDay(thursday.AddDate(0, 0, 1)))
+	require.Equal(t, time.Date(2024, 1, 29, 10, 0, 0, 0, ist), cal.AddBusinessDays(thursday, 1))
+	require.Equal(t, thursday, cal.AddBusinessDays(time.Date(2024, 1, 29, 10, 0, 0, 0, ist), -1))
+
+	// Late Friday UTC is already Saturday in India
+	fridayUTC := time.Date(2024, 2, 2, 20, 0, 0, 0, time.UTC)
+	require.False(t, cal.IsBusinessDay(fridayUTC))
+	next := cal.NextBusinessDay(fridayUTC)
+	require.Equal(t, time.UTC, next.Location())
+	require.True(t, next.Equal(time.Date(2024, 2, 5, 0, 0, 0, 0, ist)))
+
+	// One-off holiday on a Monday
+	require.False(t, cal.IsBusinessDay(time.Date(2024, 3, 25, 12, 0, 0, 0, ist)))
+	require.True(t, cal.IsBusinessDay(time.Date(2025, 3, 25, 12, 0, 0, 0, ist)))
+
+	// Unconfigured countries fall back to a Saturday and Sunday weekend in UTC
+	standard := registry.For("US")
+	require.False(t, standard.IsBusinessDay(time.Date(2024, 1, 27, 12, 0, 0, 0, time.UTC)))
+	require.True(t, standard.IsBusinessDay(time.Date(2024, 1, 26, 12, 0, 0, 0, time.UTC)))
+
+	_, err = calendar.New(calendar.Definition{Weekend: []string{"Caturday"}})
+	require.ErrorIs(t, err, calendar.ErrInvalidWeekday)
+	_, err = calendar.New(calendar.Definition{Holidays: []string{"2024-13-01"}})
+	require.ErrorIs(t, err, calendar.ErrInvalidHoliday)
+	_, err = calendar.New(calendar.Definition{Holidays: []string{"02-29"}})
+	require.NoError(t, err)
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"            // v1.9.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/api"
+	"internal/health"
+)
+
+// TestCapabilityMatrix tests that a soft dependency outage degrades or takes
+// down the capabilities relying on it, and that responses of affected routes
+// carry the degraded capabilities header
+func TestCapabilityMatrix(t *testing.T) {
+	redisDown := false
+	monitor := health.NewMonitor(time.Second)
+	monitor.RegisterDependency("database", true, func(ctx context.Context) error { return nil })
+	monitor.RegisterDependency("redis", false, func(ctx context.Context) error {
+		if redisDown {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	database := health.Requirement{Dependency: "database", Impact: health.CapabilityDown}
+	monitor.RegisterCapability(health.CapabilityBalances, database)
+	monitor.RegisterCapability(health.CapabilityTransactions, database,
+		health.Requirement{Dependency: "redis", Impact: health.ImpactOf(health.FailOpen)})
+	monitor.RegisterCapability(health.CapabilityWebhooks, database,
+		health.Requirement{Dependency: "redis", Impact: health.ImpactOf(health.FailClosed)})
+
+	handler, err := api.NewHealthHandler(monitor)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/capabilities", handler.GetCapabilities)
+	router.GET("/balance", handler.Capability(health.CapabilityBalances), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/webhooks", handler.Capability(health.CapabilityTransactions, health.CapabilityWebhooks), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+	matrix := func() health.CapabilityMatrix {
+		var body struct {
+			Data health.CapabilityMatrix `json:"data"`
+		}
+		recorder := get("/capabilities")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		return body.Data
+	}
+
+	// Every capability is ok until the first check
+	before := matrix()
+	require.Nil(t, before.CheckedAt)
+	require.Equal(t, health.CapabilityOK, before.Capabilities[health.CapabilityWebhooks])
+
+	redisDown = true
+	report := monitor.Check(context.Background())
+	require.Equal(t, health.StatusDegraded, report.Status)
+
+	after := matrix()
+	require.NotNil(t, after.CheckedAt)
+	require.Equal(t, map[string]string{
+		health.CapabilityBalances:     health.CapabilityOK,
+		health.CapabilityTransactions: health.CapabilityDegraded,
+		health.CapabilityWebhooks:     health.CapabilityDown,
+	}, after.Capabilities)
+
+	require.Empty(t, get("/balance").Header().Get("X-Degraded-Capabilities"))
+	require.Equal(t, "transactions=degraded, webhooks=down", get("/webhooks").Header().Get("X-Degraded-Capabilities"))
+
+	redisDown = false
+	monitor.Check(context.Background())
+	require.Empty(t, get("/webhooks").Header().Get("X-Degraded-Capabilities"))
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/classification"
+	"internal/testkit"
+)
+
+// TestDataClassificationEnforced tests that classified fields are removed
+// for callers lacking their scope and counted in the access report
+func TestDataClassificationEnforced(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{EnforceDataClassification: true})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+	path := "/api/v1/wallets/" + wallet.ID.String() + "/balance"
+
+	var balance struct {
+		Data map[string]interface{} `json:"data"`
+	}
+
+	status, body := kit.Do(t, customerID, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, status, string(body))
+	require.NoError(t, json.Unmarshal(body, &balance))
+	require.Equal(t, "INR", balance.Data["currency"])
+	require.NotContains(t, balance.Data, "balance")
+	require.NotContains(t, balance.Data, "available_balance")
+
+	status, body = kit.Do(t, customerID, http.MethodGet, path, nil, testkit.RolesHeader, "data:financial")
+	require.Equal(t, http.StatusOK, status, string(body))
+	require.NoError(t, json.Unmarshal(body, &balance))
+	require.Equal(t, "100", balance.Data["balance"])
+
+	status, body = kit.Do(t, customerID, http.MethodGet, "/api/v1/admin/data-access", nil, testkit.RolesHeader, "admin")
+	require.Equal(t, http.StatusOK, status, string(body))
+	var report struct {
+		Data struct {
+			Enforced bool                         `json:"enforced"`
+			Fields   []classification.ReportEntry `json:"fields"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &report))
+	require.True(t, report.Data.Enforced)
+
+	entries := make(map[string]classification.ReportEntry)
+	for _, entry := range report.Data.Fields {
+		entries[entry.Field] = entry
+	}
+	require.Equal(t, classification.ReportEntry{Field: "balance", Class: classification.ClassFinancial, Allowed: 1, Denied: 1}, entries["balance"])
+	require.Equal(t, classification.ClassPII, entries["customer_id"].Class)
+
+	status, _ = kit.Do(t, customerID, http.MethodGet, "/api/v1/admin/data-access", nil)
+	require.Equal(t, http.StatusForbidden, status)
+}
+
+// TestDataClassificationLogOnly tests that unenforced policies only record
+// accesses and leave the response untouched
+func TestDataClassificationLogOnly(t *testing.T) {
+	catalog, err := classification.NewCatalog(struct {
+		Balance float64 `json:"balance" class:"financial"`
+		Note    string  `json:"note"`
+	}{})
+	require.NoError(t, err)
+
+	body := []byte(`{"data":[{"balance":12.50,"note":"a"},{"balance":1,"note":"b"}]}`)
+	policy := classification.Policy{Scopes: classification.DefaultScopes()}
+
+	filtered, accesses, err := catalog.Filter(body, policy, nil)
+	require.NoError(t, err)
+	require.Equal(t, body, filtered)
+	require.Equal(t, []classification.Access{{Field: "balance", Class: classification.ClassFinancial, Decision: classification.DecisionLogged}}, accesses)
+
+	policy.Enforce = true
+	filtered, _, err = catalog.Filter(body, policy, nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"data":[{"note":"a"},{"note":"b"}]}`, string(filtered))
+
+	_, err = classification.NewCatalog(struct {
+		Amount float64 `json:"amount" class:"financial"`
+	}{}, struct {
+		Amount string `json:"amount" class:"internal"`
+	}{})
+	require.Error(t, err)
+}
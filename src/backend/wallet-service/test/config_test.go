@@ -0,0 +1,100 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/config"
+)
+
+// baseConfig is the minimal valid configuration file of the watcher tests
+const baseConfig = `
+database:
+  host: db-1
+  user: wallet
+  password: secret
+  dbname: wallet_db
+security:
+  jwtsecret: test-secret
+  enabletls: false
+`
+
+// writeConfig writes a configuration file made of the base configuration
+// and the given extra settings, which may continue its security section
+func writeConfig(t *testing.T, path, extra string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(baseConfig+extra), 0o600))
+}
+
+// TestConfigWatcherReload tests that reloads apply operational settings
+// only, and that invalid or rejected configurations leave the previous one
+// in effect
+func TestConfigWatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "")
+
+	watcher, err := config.NewWatcher(path, &alertLogger{})
+	require.NoError(t, err)
+	require.Equal(t, "info", watcher.Current().Logging.Level)
+
+	var applied []string
+	watcher.Subscribe("log-level", func(cfg *config.Config) error {
+		applied = append(applied, cfg.Logging.Level)
+		return nil
+	})
+	watcher.Subscribe("rate-limit", func(cfg *config.Config) error {
+		if cfg.Security.RateLimit > 1000 {
+			return errors.New("rate limit too high")
+		}
+		return nil
+	})
+
+	// Restart-only settings keep their startup values
+	require.NoError(t, os.WriteFile(path, []byte(`
+database:
+  host: db-2
+  user: wallet
+  password: secret
+  dbname: wallet_db
+security:
+  jwtsecret: test-secret
+  enabletls: false
+  ratelimit: 250
+wallet:
+  lowbalancethreshold: 50
+logging:
+  level: debug
+`), 0o600))
+	require.NoError(t, watcher.Reload())
+
+	cfg := watcher.Current()
+	require.Equal(t, 250, cfg.Security.RateLimit)
+	require.Equal(t, 50.0, cfg.Wallet.LowBalanceThreshold)
+	require.Equal(t, "debug", cfg.Logging.Level)
+	require.Equal(t, "db-1", cfg.Database.Host)
+	require.Equal(t, []string{"debug"}, applied)
+
+	// Invalid configurations are not applied
+	writeConfig(t, path, `
+logging:
+  level: verbose
+`)
+	require.Error(t, watcher.Reload())
+	require.Equal(t, "debug", watcher.Current().Logging.Level)
+	require.Equal(t, []string{"debug"}, applied)
+
+	// A rejected configuration rolls back the subscribers that accepted it
+	writeConfig(t, path, `
+  ratelimit: 5000
+logging:
+  level: warn
+`)
+	require.Error(t, watcher.Reload())
+	require.Equal(t, 250, watcher.Current().Security.RateLimit)
+	require.Equal(t, "debug", watcher.Current().Logging.Level)
+	require.Equal(t, []string{"debug", "warn", "debug"}, applied)
+}
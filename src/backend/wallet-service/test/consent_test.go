@@ -0,0 +1,101 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/events"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestCustomerConsent tests that customers start opted out of every channel,
+// that consent changes are kept as history and that optional notifications
+// only reach customers that consented to their channel
+func TestCustomerConsent(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+	consents, err := service.NewConsentService(kit.Store, &alertLogger{})
+	require.NoError(t, err)
+
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+	publisher := &recordingPublisher{}
+	forwarder, err := events.NewForwarder(registry, publisher, consents)
+	require.NoError(t, err)
+
+	customerID := uuid.New()
+	current, err := consents.GetConsents(ctx, customerID)
+	require.NoError(t, err)
+	require.Len(t, current, len(models.ConsentChannels))
+	for _, consent := range current {
+		require.False(t, consent.Granted)
+		require.Nil(t, consent.RecordedAt)
+	}
+
+	_, err = consents.UpdateConsent(ctx, customerID, "PIGEON", true, models.ConsentSourcePortal)
+	require.ErrorIs(t, err, service.ErrInvalidConsentChannel)
+	_, err = consents.UpdateConsent(ctx, customerID, models.ConsentSMS, true, "NEWSLETTER")
+	require.ErrorIs(t, err, service.ErrInvalidConsentSource)
+
+	wallet := &models.Wallet{ID: uuid.New(), CustomerID: customerID, Currency: defaultCurrency}
+	lowBalance := eventbus.LowBalance{Wallet: wallet}
+
+	// Without consent the alert is dropped
+	require.NoError(t, forwarder.Handle(ctx, lowBalance))
+	require.Empty(t, publisher.envelopes)
+
+	granted, err := consents.UpdateConsent(ctx, customerID, models.ConsentSMS, true, models.ConsentSourcePortal)
+	require.NoError(t, err)
+	require.NotNil(t, granted.RecordedAt)
+	require.NoError(t, forwarder.Handle(ctx, lowBalance))
+	require.Len(t, publisher.envelopes, 1)
+	require.Equal(t, events.TypeLowBalance, publisher.envelopes[0].Type)
+
+	// SMS consent does not cover email digests
+	digest := eventbus.ActivityDigest{
+		Subscription: &models.DigestSubscription{CustomerID: customerID, Frequency: models.DigestWeekly},
+		PeriodStart:  time.Now().AddDate(0, 0, -7),
+		PeriodEnd:    time.Now(),
+	}
+	require.NoError(t, forwarder.Handle(ctx, digest))
+	require.Len(t, publisher.envelopes, 1)
+
+	kit.Clock.Advance(time.Hour)
+	_, err = consents.UpdateConsent(ctx, customerID, models.ConsentSMS, false, models.ConsentSourceSupport)
+	require.NoError(t, err)
+	require.NoError(t, forwarder.Handle(ctx, lowBalance))
+	require.Len(t, publisher.envelopes, 1)
+
+	// Dunning notices are sent regardless of consent
+	dc := &models.DunningCase{
+		WalletID:    wallet.ID,
+		CustomerID:  customerID,
+		Currency:    defaultCurrency,
+		StartedAt:   time.Now(),
+		GraceEndsAt: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, forwarder.Handle(ctx, eventbus.DunningNotice{Case: dc, Notice: 1}))
+	require.Len(t, publisher.envelopes, 2)
+
+	history, err := consents.GetHistory(ctx, customerID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.False(t, history[0].Granted)
+	require.Equal(t, models.ConsentSourceSupport, history[0].Source)
+	require.True(t, history[1].Granted)
+	require.Equal(t, models.ConsentSourcePortal, history[1].Source)
+	require.True(t, history[1].RecordedAt.Before(*history[0].RecordedAt))
+
+	current, err = consents.GetConsents(ctx, customerID)
+	require.NoError(t, err)
+	for _, consent := range current {
+		require.False(t, consent.Granted, consent.Channel)
+	}
+}
@@ -0,0 +1,194 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3" // v0.118.0
+	"github.com/google/uuid"                 // v1.3.0
+	"github.com/stretchr/testify/require"    // v1.8.4
+
+	"internal/api"
+	"internal/testkit"
+)
+
+// contractExample is a response example of the served OpenAPI document
+// with the request that gets it
+type contractExample struct {
+	path      string
+	method    string
+	operation *openapi3.Operation
+	name      string
+	status    int
+	media     *openapi3.MediaType
+}
+
+// contractExamples collects the response examples of the document in a
+// stable order
+func contractExamples(t *testing.T, spec *openapi3.T) []contractExample {
+	var examples []contractExample
+	for path, item := range spec.Paths {
+		for method, operation := range item.Operations() {
+			for code, response := range operation.Responses {
+				media := response.Value.Content.Get("application/json")
+				if media == nil || len(media.Examples) == 0 {
+					continue
+				}
+				status, err := strconv.Atoi(code)
+				require.NoError(t, err, "%s %s documents a %s example", method, path, code)
+				for name := range media.Examples {
+					examples = append(examples, contractExample{path, method, operation, name, status, media})
+				}
+			}
+		}
+	}
+
+	sort.Slice(examples, func(i, j int) bool {
+		a, b := examples[i], examples[j]
+		if a.operation.OperationID != b.operation.OperationID {
+			return a.operation.OperationID < b.operation.OperationID
+		}
+		return a.name < b.name
+	})
+	return examples
+}
+
+// TestOpenAPIContract replays the examples of the served OpenAPI document
+// against the service and fails when a response drifts from its example or
+// its schema, including fields the schema does not document, so that
+// generated clients and the dashboard do not break silently
+func TestOpenAPIContract(t *testing.T) {
+	spec, err := openapi3.NewLoader().LoadFromData(api.OpenAPIDocument())
+	require.NoError(t, err)
+	// Validation covers the examples themselves against their schemas
+	require.NoError(t, spec.Validate(context.Background()))
+
+	examples := contractExamples(t, spec)
+	require.NotEmpty(t, examples)
+
+	for _, example := range examples {
+		example := example
+		t.Run(example.operation.OperationID+"/"+example.name, func(t *testing.T) {
+			kit := testkit.New(t, testkit.Options{})
+			customerID := uuid.New()
+			wallet := kit.CreateWallet(t, customerID, "USD", 100)
+
+			path := "/api/" + api.Version + strings.ReplaceAll(example.path, "{id}", wallet.ID.String())
+			require.NotContains(t, path, "{", "examples replay against a wallet only")
+
+			var body interface{}
+			if example.operation.RequestBody != nil {
+				request, ok := example.operation.RequestBody.Value.Content.Get("application/json").Examples[example.name]
+				require.True(t, ok, "the request of example %s is not documented", example.name)
+				body = request.Value.Value
+			}
+			var headers []string
+			if example.operation.Parameters.GetByInAndName(openapi3.ParameterInHeader, "Idempotency-Key") != nil {
+				headers = append(headers, "Idempotency-Key", uuid.NewString())
+			}
+
+			status, data := kit.Do(t, customerID, example.method, path, body, headers...)
+			require.Equal(t, example.status, status, string(data))
+
+			var actual interface{}
+			require.NoError(t, json.Unmarshal(data, &actual))
+			schema := example.media.Schema.Value
+			require.NoError(t, schema.VisitJSON(actual))
+			require.Empty(t, undocumentedFields(schema, actual, "$"), "fields missing from the schema")
+			requireMatchesExample(t, "$", example.media.Examples[example.name].Value.Value, actual)
+		})
+	}
+}
+
+// undocumentedFields returns the paths of the object fields in value that
+// schema does not describe, which schema validation accepts as additional
+// properties
+func undocumentedFields(schema *openapi3.Schema, value interface{}, path string) []string {
+	for _, alt := range schema.OneOf {
+		if alt.Value.VisitJSON(value) == nil {
+			return undocumentedFields(alt.Value, value, path)
+		}
+	}
+
+	var fields []string
+	switch value := value.(type) {
+	case map[string]interface{}:
+		additional := schema.AdditionalProperties.Schema
+		if len(schema.Properties) == 0 && additional == nil {
+			// Free-form objects document no fields
+			return nil
+		}
+		for name, field := range value {
+			switch property, ok := schema.Properties[name]; {
+			case ok:
+				fields = append(fields, undocumentedFields(property.Value, field, path+"."+name)...)
+			case additional != nil:
+				fields = append(fields, undocumentedFields(additional.Value, field, path+"."+name)...)
+			default:
+				fields = append(fields, path+"."+name)
+			}
+		}
+	case []interface{}:
+		if schema.Items == nil {
+			return nil
+		}
+		for i, item := range value {
+			fields = append(fields, undocumentedFields(schema.Items.Value, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// requireMatchesExample compares a response with its example. IDs and
+// timestamps of the example match any ID and timestamp respectively, as
+// the service generates them.
+func requireMatchesExample(t *testing.T, path string, expected, actual interface{}) {
+	t.Helper()
+
+	switch expected := expected.(type) {
+	case map[string]interface{}:
+		object, ok := actual.(map[string]interface{})
+		require.True(t, ok, "%s: expected an object, got %v", path, actual)
+		require.ElementsMatch(t, keysOf(expected), keysOf(object), "%s: fields differ", path)
+		for name, field := range expected {
+			requireMatchesExample(t, path+"."+name, field, object[name])
+		}
+	case []interface{}:
+		array, ok := actual.([]interface{})
+		require.True(t, ok, "%s: expected an array, got %v", path, actual)
+		require.Len(t, array, len(expected), path)
+		for i, item := range expected {
+			requireMatchesExample(t, fmt.Sprintf("%s[%d]", path, i), item, array[i])
+		}
+	case string:
+		if _, err := uuid.Parse(expected); err == nil {
+			_, err := uuid.Parse(fmt.Sprint(actual))
+			require.NoError(t, err, "%s: expected an ID", path)
+			return
+		}
+		if _, err := time.Parse(time.RFC3339, expected); err == nil {
+			_, err := time.Parse(time.RFC3339, fmt.Sprint(actual))
+			require.NoError(t, err, "%s: expected a timestamp", path)
+			return
+		}
+		require.Equal(t, expected, actual, path)
+	default:
+		require.Equal(t, expected, actual, path)
+	}
+}
+
+// keysOf returns the field names of an object
+func keysOf(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	return keys
+}
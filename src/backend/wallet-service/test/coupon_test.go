@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestCoupons tests coupon validation, percentage and fixed discounts, and
+// that every redemption is audited once within the coupon's limits
+func TestCoupons(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	audit := &auditLog{}
+	coupons, err := service.NewCouponService(kit.Store, audit, supportedCurrencies(t), &alertLogger{})
+	require.NoError(t, err)
+
+	amount := decimal.RequireFromString
+	limit := func(n int64) *int64 { return &n }
+	customer, other := uuid.New(), uuid.New()
+	require.NoError(t, kit.Store.CreateWallet(ctx, &models.Wallet{CustomerID: customer, Currency: "INR"}))
+
+	// Refused coupons
+	_, err = coupons.Create(ctx, &models.Coupon{Code: "SAVE10", Type: models.DiscountPercentage, Value: amount("10")}, "finance", " ")
+	require.ErrorIs(t, err, service.ErrInvalidCoupon)
+	_, err = coupons.Create(ctx, &models.Coupon{Code: "SAVE 10", Type: models.DiscountPercentage, Value: amount("10")}, "finance", "launch")
+	require.ErrorIs(t, err, service.ErrInvalidCoupon)
+	_, err = coupons.Create(ctx, &models.Coupon{Code: "HALF", Type: models.DiscountPercentage, Value: amount("101")}, "finance", "launch")
+	require.ErrorIs(t, err, service.ErrInvalidCoupon)
+	_, err = coupons.Create(ctx, &models.Coupon{Code: "FLAT", Type: models.DiscountFixed, Value: amount("50")}, "finance", "launch")
+	require.ErrorIs(t, err, service.ErrInvalidCoupon)
+
+	launch, err := coupons.Create(ctx, &models.Coupon{
+		Code:                      " save10 ",
+		Type:                      models.DiscountPercentage,
+		Value:                     amount("10"),
+		MaxRedemptions:            limit(2),
+		MaxRedemptionsPerCustomer: limit(1),
+	}, "finance", "launch")
+	require.NoError(t, err)
+	require.Equal(t, "SAVE10", launch.Code)
+	require.Equal(t, models.CouponActive, launch.Status)
+	_, err = coupons.Create(ctx, &models.Coupon{Code: "SAVE10", Type: models.DiscountPercentage, Value: amount("5")}, "finance", "again")
+	require.ErrorIs(t, err, service.ErrCouponConflict)
+
+	apply := func(code string, customerID uuid.UUID, reference, value string) service.CouponApplication {
+		return service.CouponApplication{
+			Code:       code,
+			CustomerID: customerID,
+			Source:     models.RedemptionInvoice,
+			Reference:  reference,
+			Currency:   "INR",
+			Amount:     amount(value),
+		}
+	}
+
+	// Percentage discounts are rounded to the currency
+	quote, err := coupons.Quote(ctx, apply("save10", customer, "", "99.99"))
+	require.NoError(t, err)
+	require.Equal(t, "10", quote.Discount.String())
+	require.Equal(t, "89.99", quote.Total.String())
+
+	redemption, err := coupons.Redeem(ctx, apply("SAVE10", customer, "INV-1", "200"), "invoicing")
+	require.NoError(t, err)
+	require.Equal(t, "20", redemption.Discount.String())
+
+	// A retried redemption returns the first and is audited once
+	again, err := coupons.Redeem(ctx, apply("SAVE10", customer, "INV-1", "200"), "invoicing")
+	require.NoError(t, err)
+	require.Equal(t, redemption.ID, again.ID)
+
+	// Limits are enforced per customer and overall
+	_, err = coupons.Redeem(ctx, apply("SAVE10", customer, "INV-2", "200"), "invoicing")
+	require.ErrorIs(t, err, service.ErrCouponNotRedeemable)
+	_, err = coupons.Redeem(ctx, apply("SAVE10", other, "INV-3", "200"), "invoicing")
+	require.NoError(t, err)
+	_, err = coupons.Quote(ctx, apply("SAVE10", uuid.New(), "", "200"))
+	require.ErrorIs(t, err, service.ErrCouponNotRedeemable)
+
+	details, err := coupons.Get(ctx, launch.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), details.Coupon.Redemptions)
+	require.Len(t, details.Redemptions, 2)
+	require.Equal(t, "INV-3", details.Redemptions[0].Reference)
+
+	// Negotiated discounts apply to their customer only, and fixed
+	// discounts to their currency, at most the whole amount
+	negotiated, err := coupons.Create(ctx, &models.Coupon{
+		Code:       "ACME-CREDIT",
+		Type:       models.DiscountFixed,
+		Value:      amount("500"),
+		Currency:   "inr",
+		CustomerID: &customer,
+	}, "finance", "contract renewal")
+	require.NoError(t, err)
+	_, err = coupons.Quote(ctx, apply("ACME-CREDIT", other, "", "100"))
+	require.ErrorIs(t, err, service.ErrCouponNotRedeemable)
+	usd := apply("ACME-CREDIT", customer, "", "100")
+	usd.Currency = "USD"
+	_, err = coupons.Quote(ctx, usd)
+	require.ErrorIs(t, err, service.ErrCouponNotRedeemable)
+	redemption, err = coupons.Redeem(ctx, apply("ACME-CREDIT", customer, "INV-4", "120"), "invoicing")
+	require.NoError(t, err)
+	require.Equal(t, "120", redemption.Discount.String())
+
+	// Voided coupons are no longer redeemable
+	_, err = coupons.Void(ctx, negotiated.ID, "finance", "")
+	require.ErrorIs(t, err, service.ErrInvalidCoupon)
+	voided, err := coupons.Void(ctx, negotiated.ID, "finance", "contract ended")
+	require.NoError(t, err)
+	require.Equal(t, models.CouponVoided, voided.Status)
+	_, err = coupons.Void(ctx, negotiated.ID, "finance", "contract ended")
+	require.ErrorIs(t, err, service.ErrCouponConflict)
+	_, err = coupons.Redeem(ctx, apply("ACME-CREDIT", customer, "INV-5", "120"), "invoicing")
+	require.ErrorIs(t, err, service.ErrCouponNotRedeemable)
+
+	active, err := coupons.List(ctx, models.CouponActive, 0)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	require.Equal(t, launch.ID, active[0].ID)
+
+	// Creates, voids and redemptions are audited, failures included
+	var actions []string
+	for _, action := range audit.actions {
+		actions = append(actions, action.Action+":"+string(action.Status))
+	}
+	require.Equal(t, []string{
+		"coupon.create:" + string(models.OperatorActionSucceeded),
+		"coupon.create:" + string(models.OperatorActionFailed),
+		"coupon.redeem:" + string(models.OperatorActionSucceeded),
+		"coupon.redeem:" + string(models.OperatorActionSucceeded),
+		"coupon.create:" + string(models.OperatorActionSucceeded),
+		"coupon.redeem:" + string(models.OperatorActionSucceeded),
+		"coupon.void:" + string(models.OperatorActionSucceeded),
+		"coupon.void:" + string(models.OperatorActionFailed),
+	}, actions)
+}
@@ -0,0 +1,245 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/apierror"
+	"internal/currency"
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// supportedCurrencies returns the default currencies at ISO 4217 precision
+func supportedCurrencies(t testing.TB) *currency.Registry {
+	t.Helper()
+	currencies, err := currency.FromCodes([]string{"USD", "INR", "IDR"}, nil, nil)
+	require.NoError(t, err)
+	return currencies
+}
+
+// TestCurrencyRegistry tests ISO 4217 validation, precision overrides and
+// per-currency rounding
+func TestCurrencyRegistry(t *testing.T) {
+	currencies, err := currency.FromCodes([]string{"usd", "JPY", "KWD", "IDR"}, map[string]int32{"idr": 0}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"IDR", "JPY", "KWD", "USD"}, currencies.Codes())
+	require.True(t, currencies.Supported("USD"))
+	require.False(t, currencies.Supported("EUR"))
+
+	for _, tt := range []struct {
+		code   string
+		amount string
+		want   string
+	}{
+		{"USD", "10.005", "10.01"},
+		{"JPY", "149.5", "150"},
+		{"KWD", "1.2345", "1.235"},
+		{"IDR", "15000.50", "15001"},
+	} {
+		got := currencies.Round(tt.code, decimal.RequireFromString(tt.amount))
+		require.Equal(t, tt.want, got.String(), tt.code)
+	}
+
+	_, err = currency.FromCodes([]string{"XYZ"}, nil, nil)
+	require.Error(t, err)
+	_, err = currency.FromCodes([]string{"USD"}, map[string]int32{"EUR": 2}, nil)
+	require.Error(t, err)
+	_, err = currency.New([]models.Currency{{Code: "USD", Precision: 5}})
+	require.Error(t, err)
+	_, err = currency.New([]models.Currency{{Code: "USD", Precision: 2}, {Code: "USD", Precision: 2}})
+	require.Error(t, err)
+	_, err = currency.New(nil)
+	require.ErrorIs(t, err, currency.ErrNoCurrencies)
+}
+
+// TestCurrencyFormats tests the display hints of supported currencies at
+// their configured precision and the amounts rendered by them
+func TestCurrencyFormats(t *testing.T) {
+	currencies, err := currency.FromCodes([]string{"INR", "EUR", "JPY", "KWD", "IDR"}, map[string]int32{"IDR": 0}, nil)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		code   string
+		amount string
+		want   string
+	}{
+		{"INR", "1234567.891", "₹12,34,567.89"},
+		{"EUR", "-1234.5", "-1.234,50 €"},
+		{"JPY", "1234567", "¥1,234,567"},
+		{"KWD", "1234.5", "KWD 1,234.500"},
+		{"IDR", "15000.50", "Rp15.001"},
+		{"INR", "-0.001", "₹0.00"},
+	} {
+		format, ok := currencies.Format(tt.code)
+		require.True(t, ok, tt.code)
+		require.Equal(t, tt.code, format.Code)
+		require.Equal(t, tt.want, format.Format(decimal.RequireFromString(tt.amount)), tt.code)
+	}
+
+	_, ok := currencies.Format("USD")
+	require.False(t, ok)
+	formats := currencies.Formats("INR", "USD", "INR")
+	require.Len(t, formats, 1)
+	require.Equal(t, []int{3, 2}, formats["INR"].Grouping)
+	require.Equal(t, models.SymbolBefore, formats["INR"].SymbolPosition)
+}
+
+// TestTransactionCurrencyRounding tests that transaction amounts are rounded
+// to the precision of their currency and that unsupported currencies and
+// amounts rounding to zero are refused
+func TestTransactionCurrencyRounding(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+
+	currencies, err := currency.FromCodes([]string{"USD", "JPY"}, nil, nil)
+	require.NoError(t, err)
+	wallets, err := service.NewWalletService(kit.Store, currencies, decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+
+	newTransaction := func(wallet *models.Wallet, currencyCode string, amount float64) *models.Transaction {
+		return &models.Transaction{
+			ID:        uuid.New(),
+			WalletID:  wallet.ID,
+			Type:      models.TransactionTypeCredit,
+			Status:    models.TransactionStatusInitiated,
+			Amount:    amount,
+			Currency:  currencyCode,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		}
+	}
+
+	yen := kit.CreateWallet(t, uuid.New(), "JPY", 0)
+	tx := newTransaction(yen, "JPY", 99.6)
+	_, err = wallets.ProcessTransaction(ctx, tx)
+	require.NoError(t, err)
+	require.Equal(t, 100.0, tx.Amount)
+	require.Equal(t, models.MinorAmount{Units: 100, Exponent: 0}, tx.Minor)
+
+	_, err = wallets.ProcessTransaction(ctx, newTransaction(yen, "JPY", 0.4))
+	require.ErrorIs(t, err, service.ErrInvalidAmount)
+
+	dollars := kit.CreateWallet(t, uuid.New(), "USD", 0)
+	tx = newTransaction(dollars, "USD", 12.345)
+	_, err = wallets.ProcessTransaction(ctx, tx)
+	require.NoError(t, err)
+	require.Equal(t, 12.35, tx.Amount)
+	require.Equal(t, models.MinorAmount{Units: 1235, Exponent: 2}, tx.Minor)
+
+	rupees := kit.CreateWallet(t, uuid.New(), "INR", 0)
+	_, err = wallets.ProcessTransaction(ctx, newTransaction(rupees, "INR", 10))
+	require.ErrorIs(t, err, service.ErrUnsupportedCurrency)
+	require.Equal(t, apierror.CodeUnsupportedCurrency, apierror.FromError(err).Code)
+}
+
+// TestMinorUnitsAndRoundingModes tests the conversion of amounts to minor
+// units in each rounding mode, per-currency rounding and FX conversion
+// rounded in the target currency's mode
+func TestMinorUnitsAndRoundingModes(t *testing.T) {
+	for _, tt := range []struct {
+		mode   models.RoundingMode
+		amount string
+		want   int64
+	}{
+		{models.RoundHalfUp, "10.505", 1051},
+		{models.RoundHalfEven, "10.505", 1050},
+		{models.RoundHalfEven, "10.515", 1052},
+		{models.RoundDown, "10.509", 1050},
+		{models.RoundUp, "10.501", 1051},
+		{models.RoundCeiling, "-10.505", -1050},
+		{models.RoundFloor, "-10.505", -1051},
+	} {
+		minor, err := models.ToMinorUnits(decimal.RequireFromString(tt.amount), 2, tt.mode)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, minor.Units, "%s %s", tt.mode, tt.amount)
+		require.EqualValues(t, 2, minor.Exponent)
+	}
+
+	require.Equal(t, "10.5", models.MinorAmount{Units: 1050, Exponent: 2}.Decimal().String())
+	require.Equal(t, "15000", models.MinorAmount{Units: 15000, Exponent: 0}.Decimal().String())
+	_, err := models.ToMinorUnits(decimal.RequireFromString("1e20"), 2, models.RoundHalfUp)
+	require.ErrorIs(t, err, models.ErrAmountOutOfRange)
+
+	currencies, err := currency.FromCodes([]string{"USD", "IDR"}, map[string]int32{"IDR": 0}, map[string]models.RoundingMode{"usd": models.RoundHalfEven})
+	require.NoError(t, err)
+	require.Equal(t, "10.12", currencies.Round("USD", decimal.RequireFromString("10.125")).String())
+	mode, ok := currencies.Rounding("IDR")
+	require.True(t, ok)
+	require.Equal(t, models.RoundHalfUp, mode)
+
+	minor, err := currencies.ToMinorUnits("IDR", decimal.RequireFromString("15000.5"))
+	require.NoError(t, err)
+	require.Equal(t, models.MinorAmount{Units: 15001, Exponent: 0}, minor)
+	_, err = currencies.ToMinorUnits("EUR", decimal.NewFromInt(1))
+	require.ErrorIs(t, err, currency.ErrUnsupported)
+
+	converted, err := currencies.Convert(decimal.RequireFromString("10.01"), "USD", "IDR", decimal.RequireFromString("15500.5"))
+	require.NoError(t, err)
+	require.Equal(t, "155160", converted.String())
+	converted, err = currencies.Convert(decimal.NewFromInt(1000), "IDR", "USD", decimal.RequireFromString("0.0000645"))
+	require.NoError(t, err)
+	require.Equal(t, "0.06", converted.String())
+	_, err = currencies.Convert(decimal.NewFromInt(1), "USD", "EUR", decimal.NewFromInt(1))
+	require.ErrorIs(t, err, currency.ErrUnsupported)
+	_, err = currencies.Convert(decimal.NewFromInt(1), "USD", "IDR", decimal.Zero)
+	require.Error(t, err)
+
+	_, err = currency.FromCodes([]string{"USD"}, nil, map[string]models.RoundingMode{"EUR": models.RoundDown})
+	require.Error(t, err)
+	_, err = currency.New([]models.Currency{{Code: "USD", Precision: 2, Rounding: "SIDEWAYS"}})
+	require.Error(t, err)
+}
+
+// TestWalletMinorUnitBalances tests that balances move in exact minor units
+// and that debits are checked against the credit limit in minor units
+func TestWalletMinorUnitBalances(t *testing.T) {
+	minor, err := models.MinorOf(decimal.RequireFromString("0.3"))
+	require.NoError(t, err)
+	require.Equal(t, models.MinorAmount{Units: 3, Exponent: 1}, minor)
+	sum, err := minor.Add(models.MinorAmount{Units: 5, Exponent: 2})
+	require.NoError(t, err)
+	require.Equal(t, models.MinorAmount{Units: 35, Exponent: 2}, sum)
+	require.Zero(t, sum.Cmp(models.MinorAmount{Units: 350, Exponent: 3}))
+	require.Equal(t, -1, sum.Neg().Cmp(sum))
+
+	// Ten credits of 0.1 land on exactly 1, which float addition misses
+	wallet := &models.Wallet{Currency: "USD", BalanceMinor: models.MinorAmount{Exponent: 2}}
+	for i := 0; i < 10; i++ {
+		balance, err := wallet.BalanceAfter(&models.Transaction{Type: models.TransactionTypeCredit, Amount: 0.1})
+		require.NoError(t, err)
+		wallet.SetBalance(balance)
+	}
+	require.Equal(t, models.MinorAmount{Units: 100, Exponent: 2}, wallet.BalanceMinor)
+	require.Equal(t, 1.0, wallet.Balance)
+
+	// Debits may take the balance down to the credit limit and no further
+	wallet.CreditLimit = 0.2
+	wallet.CreditLimitMinor = models.MinorAmount{Units: 20, Exponent: 2}
+	require.True(t, wallet.HasSufficientMinorBalance(models.MinorAmount{Units: 120, Exponent: 2}))
+	require.False(t, wallet.HasSufficientMinorBalance(models.MinorAmount{Units: 121, Exponent: 2}))
+	require.False(t, wallet.HasSufficientMinorBalance(models.MinorAmount{}))
+	require.True(t, wallet.HasSufficientBalance(1.2))
+	require.Equal(t, 1.2, wallet.AvailableBalance())
+
+	// Rupiah balances have no minor units
+	rupiah := &models.Wallet{Currency: "IDR", Balance: 15000, BalanceMinor: models.MinorAmount{Units: 15000}}
+	balance, err := rupiah.BalanceAfter(&models.Transaction{
+		Type:   models.TransactionTypeDebit,
+		Amount: 500,
+		Minor:  models.MinorAmount{Units: 500},
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.MinorAmount{Units: 14500}, balance)
+
+	// Minor units left stale by a decimal write are derived again
+	rupiah.Balance = 14000
+	require.Equal(t, "14000", rupiah.MinorBalance().Decimal().String())
+}
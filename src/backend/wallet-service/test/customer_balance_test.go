@@ -0,0 +1,85 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/currency"
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestCustomerBalance tests that a customer's wallets are totalled in the
+// reporting currency at crossed rates and that wallets in a currency without
+// a rate are listed but left out of the total
+func TestCustomerBalance(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+	currencies := supportedCurrencies(t)
+
+	wallets, err := service.NewWalletService(kit.Store, currencies, decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	rates, err := currency.NewRateTable("usd", map[string]decimal.Decimal{
+		"inr": decimal.RequireFromString("0.012"),
+	})
+	require.NoError(t, err)
+	customers, err := service.NewCustomerBalanceService(wallets, currencies, rates, &alertLogger{})
+	require.NoError(t, err)
+
+	customerID := uuid.New()
+	for _, wallet := range []*models.Wallet{
+		{CustomerID: customerID, Currency: "USD", Balance: 100},
+		{CustomerID: customerID, Currency: "INR", Balance: 10000},
+		{CustomerID: customerID, Currency: "IDR", Balance: 50000},
+		{CustomerID: uuid.New(), Currency: "USD", Balance: 999},
+	} {
+		require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+	}
+
+	listed, err := customers.ListWallets(ctx, customerID)
+	require.NoError(t, err)
+	require.Len(t, listed, 3)
+
+	byCurrency := func(balance *models.CustomerBalance, code string) *models.CustomerWalletBalance {
+		for _, wallet := range balance.Wallets {
+			if wallet.Currency == code {
+				return wallet
+			}
+		}
+		t.Fatalf("no %s wallet in customer balance", code)
+		return nil
+	}
+
+	// The configured base is the default reporting currency
+	balance, err := customers.GetBalance(ctx, customerID, "")
+	require.NoError(t, err)
+	require.Equal(t, "USD", balance.ReportingCurrency)
+	require.Len(t, balance.Wallets, 3)
+	require.Equal(t, "220", balance.Total.String())
+	require.Equal(t, "120", byCurrency(balance, "INR").Converted.String())
+	require.Nil(t, byCurrency(balance, "IDR").Converted)
+	require.Equal(t, []string{"IDR"}, balance.UnconvertedCurrencies)
+
+	// Other currencies are crossed through the base
+	balance, err = customers.GetBalance(ctx, customerID, "INR")
+	require.NoError(t, err)
+	require.Equal(t, "8333.33", byCurrency(balance, "USD").Converted.String())
+	require.Equal(t, "18333.33", balance.Total.String())
+
+	_, err = customers.GetBalance(ctx, customerID, "EUR")
+	require.ErrorIs(t, err, service.ErrUnsupportedCurrency)
+	_, err = customers.GetBalance(ctx, customerID, "IDR")
+	require.ErrorIs(t, err, service.ErrUnsupportedCurrency)
+
+	_, err = rates.Rate("IDR", "USD")
+	require.ErrorIs(t, err, currency.ErrNoRate)
+	_, err = currency.NewRateTable("USD", map[string]decimal.Decimal{"INR": decimal.Zero})
+	require.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"            // v1.9.1
+	"github.com/jackc/pgx/v5/pgconn"      // v5.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/api"
+	"internal/eventbus"
+	"internal/events"
+	"internal/service"
+	"internal/worker"
+)
+
+// failoverDatabase is a DatabaseModeRepository reporting the mode it is set to
+type failoverDatabase struct {
+	readOnly atomic.Bool
+}
+
+func (d *failoverDatabase) ReadOnly(ctx context.Context) (bool, error) {
+	return d.readOnly.Load(), nil
+}
+
+// TestDatabaseReadOnlyMode tests that a write refused by a read-only
+// database switches the service into read-only mode, refusing writes with a
+// Retry-After while reads are served, until a check finds the database
+// writable again, and that each switch is published
+func TestDatabaseReadOnlyMode(t *testing.T) {
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+	publisher := &recordingPublisher{}
+	forwarder, err := events.NewForwarder(registry, publisher, grantedConsents{})
+	require.NoError(t, err)
+	bus := eventbus.New()
+	require.NoError(t, bus.Subscribe("event-stream", forwarder.Handle, forwarder.EventTypes()...))
+
+	database := &failoverDatabase{}
+	mode, err := service.NewDatabaseMode(database, bus, &alertLogger{})
+	require.NoError(t, err)
+	require.NoError(t, mode.Check(context.Background()))
+	require.False(t, mode.ReadOnly())
+
+	writes := 0
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(api.DatabaseModeMiddleware(mode, 30*time.Second))
+	router.GET("/balance", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/transactions", func(c *gin.Context) {
+		writes++
+		if database.readOnly.Load() {
+			err := fmt.Errorf("failed to insert transaction: %w",
+				&pgconn.PgError{Code: "25006", Message: "cannot execute INSERT in a read-only transaction"})
+			_ = c.Error(err)
+			c.AbortWithStatus(http.StatusServiceUnavailable)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		return recorder
+	}
+
+	require.Equal(t, http.StatusCreated, serve(http.MethodPost, "/transactions").Code)
+
+	// The primary is demoted: the first refused write switches the mode
+	database.readOnly.Store(true)
+	serve(http.MethodPost, "/transactions")
+	require.True(t, mode.ReadOnly())
+	require.Equal(t, 2, writes)
+
+	refused := serve(http.MethodPost, "/transactions")
+	require.Equal(t, http.StatusServiceUnavailable, refused.Code)
+	require.Equal(t, "30", refused.Header().Get("Retry-After"))
+	var body api.Response
+	require.NoError(t, json.Unmarshal(refused.Body.Bytes(), &body))
+	require.Equal(t, "DATABASE_READ_ONLY", body.Code)
+	require.Equal(t, 2, writes)
+	require.Equal(t, http.StatusOK, serve(http.MethodGet, "/balance").Code)
+
+	require.ErrorIs(t, mode.Check(context.Background()), service.ErrDatabaseReadOnly)
+
+	// The failover completes
+	database.readOnly.Store(false)
+	require.NoError(t, mode.Check(context.Background()))
+	require.False(t, mode.ReadOnly())
+	require.Equal(t, http.StatusCreated, serve(http.MethodPost, "/transactions").Code)
+
+	require.Len(t, publisher.envelopes, 2)
+	var modes []string
+	for _, env := range publisher.envelopes {
+		require.Equal(t, events.TypeDatabaseModeChanged, env.Type)
+		require.NoError(t, registry.Validate(env))
+		var payload events.DatabaseModeChangedV1
+		require.NoError(t, json.Unmarshal(env.Payload, &payload))
+		modes = append(modes, payload.Mode)
+	}
+	require.Equal(t, []string{events.DatabaseModeReadOnly, events.DatabaseModeReadWrite}, modes)
+}
+
+// TestWorkerRunnerPause tests that workers changing data skip their runs
+// while the runner is paused
+func TestWorkerRunnerPause(t *testing.T) {
+	runner, err := worker.NewRunner(nil, time.Millisecond, &workerLogger{})
+	require.NoError(t, err)
+
+	var paused atomic.Bool
+	paused.Store(true)
+	runner.PauseWhile(paused.Load)
+
+	var reads, writes int64
+	require.NoError(t, runner.Add(worker.Worker{
+		Name:     "health-monitor",
+		Interval: time.Millisecond,
+		ReadOnly: true,
+		Job: func(ctx context.Context) error {
+			atomic.AddInt64(&reads, 1)
+			return nil
+		},
+	}))
+	require.NoError(t, runner.Add(worker.Worker{
+		Name:     "recurring-debits",
+		Interval: time.Millisecond,
+		Job: func(ctx context.Context) error {
+			atomic.AddInt64(&writes, 1)
+			return nil
+		},
+	}))
+
+	runner.Start(context.Background())
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&reads) > 2 }, time.Second, time.Millisecond)
+	require.Zero(t, atomic.LoadInt64(&writes))
+
+	paused.Store(false)
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&writes) > 0 }, time.Second, time.Millisecond)
+	require.NoError(t, runner.Shutdown(context.Background()))
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/logging"
+	"internal/usage"
+)
+
+// sleepyConnector connects to a database executing statements mentioning
+// pg_sleep slowly and every other statement at once
+type sleepyConnector struct{}
+
+func (sleepyConnector) Connect(ctx context.Context) (driver.Conn, error) { return sleepyConn{}, nil }
+func (sleepyConnector) Driver() driver.Driver                            { return nil }
+
+type sleepyConn struct{}
+
+func (sleepyConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements not supported")
+}
+func (sleepyConn) Close() error              { return nil }
+func (sleepyConn) Begin() (driver.Tx, error) { return nil, errors.New("transactions not supported") }
+
+func (sleepyConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "pg_sleep") {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+// slowQueryRecorder records the fields of slow query warnings
+type slowQueryRecorder struct {
+	warnings []map[string]interface{}
+}
+
+func (r *slowQueryRecorder) Warn(msg string, fields ...interface{}) {
+	entry := map[string]interface{}{"msg": msg}
+	for i := 0; i+1 < len(fields); i += 2 {
+		entry[fields[i].(string)] = fields[i+1]
+	}
+	r.warnings = append(r.warnings, entry)
+}
+
+// TestSlowQueryLog tests that statements slower than the threshold are
+// logged with their statement name and the correlation ID of the request,
+// while still metered
+func TestSlowQueryLog(t *testing.T) {
+	_, err := usage.NewSlowQueryLog("primary", 0, &slowQueryRecorder{})
+	require.Error(t, err)
+
+	recorder := &slowQueryRecorder{}
+	slowQueries, err := usage.NewSlowQueryLog("primary", 10*time.Millisecond, recorder)
+	require.NoError(t, err)
+	db := sql.OpenDB(usage.MeteredConnector(sleepyConnector{}, slowQueries))
+	defer db.Close()
+
+	ctx, meter := usage.WithMeter(logging.WithCorrelationID(context.Background(), "req-42"))
+	_, err = db.ExecContext(ctx, "UPDATE wallets SET balance = $1 WHERE id = $2", 10, 1)
+	require.NoError(t, err)
+	require.Empty(t, recorder.warnings)
+
+	_, err = db.ExecContext(ctx, "-- name: debit-wallet\nSELECT pg_sleep(0.02)")
+	require.NoError(t, err)
+	require.Len(t, recorder.warnings, 1)
+	warning := recorder.warnings[0]
+	require.Equal(t, "slow database query", warning["msg"])
+	require.Equal(t, "debit-wallet", warning["statement"])
+	require.Equal(t, "primary", warning["database"])
+	require.Equal(t, "req-42", warning["correlation_id"])
+	require.GreaterOrEqual(t, meter.DBTime(), 20*time.Millisecond)
+
+	// Statements outside requests are logged without a correlation ID
+	_, err = db.ExecContext(context.Background(), "SELECT pg_sleep(0.02) FROM transactions")
+	require.NoError(t, err)
+	require.Len(t, recorder.warnings, 2)
+	require.Equal(t, "SELECT transactions", recorder.warnings[1]["statement"])
+	require.NotContains(t, recorder.warnings[1], "correlation_id")
+}
+
+// TestStatementName tests that statements are named by their command and
+// first table, never by their arguments
+func TestStatementName(t *testing.T) {
+	for query, name := range map[string]string{
+		"SELECT id, balance FROM wallets WHERE id = $1":                          "SELECT wallets",
+		"\n  insert into transactions (id, amount) values ($1, $2)":              "INSERT transactions",
+		"UPDATE wallets SET balance = balance - $1 WHERE id = $2":                "UPDATE wallets",
+		"DELETE FROM idempotency_keys WHERE expires_at < now()":                  "DELETE idempotency_keys",
+		"SELECT count(*) FROM (SELECT id FROM refunds) AS pending":               "SELECT refunds",
+		`SELECT current_setting('transaction_read_only') = 'on'`:                 "SELECT",
+		"-- reconciliation\n-- name: sum-ledger\nSELECT sum(amount) FROM ledger": "sum-ledger",
+		"": "unknown",
+	} {
+		require.Equal(t, name, usage.StatementName(query), query)
+	}
+}
+
+// TestPoolStats tests that pool statistics require a database and record
+// without error
+func TestPoolStats(t *testing.T) {
+	_, err := usage.NewPoolStats("primary", nil)
+	require.Error(t, err)
+
+	db := sql.OpenDB(usage.MeteredConnector(sleepyConnector{}, nil))
+	defer db.Close()
+	_, err = db.ExecContext(context.Background(), "UPDATE wallets SET balance = 0")
+	require.NoError(t, err)
+
+	poolStats, err := usage.NewPoolStats("primary", db)
+	require.NoError(t, err)
+	require.NoError(t, poolStats.Record(context.Background()))
+	require.Equal(t, 1, db.Stats().OpenConnections)
+}
+
+// TestMeteredConnUnwrap tests that the driver connection under a metered
+// connection is reachable, and that calls made on it directly are metered
+func TestMeteredConnUnwrap(t *testing.T) {
+	recorder := &slowQueryRecorder{}
+	slowQueries, err := usage.NewSlowQueryLog("primary", 10*time.Millisecond, recorder)
+	require.NoError(t, err)
+	db := sql.OpenDB(usage.MeteredConnector(sleepyConnector{}, slowQueries))
+	defer db.Close()
+
+	ctx, meter := usage.WithMeter(context.Background())
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		metered, ok := driverConn.(interface {
+			Unwrap() driver.Conn
+			Observe(ctx context.Context, query string, start time.Time)
+		})
+		require.True(t, ok)
+		require.Equal(t, sleepyConn{}, metered.Unwrap())
+
+		metered.Observe(ctx, "-- name: RecordBulkCreditRows\nUPDATE bulk_credit_rows SET status = $3", time.Now().Add(-20*time.Millisecond))
+		return nil
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, meter.DBTime(), 20*time.Millisecond)
+	require.Len(t, recorder.warnings, 1)
+	require.Equal(t, "RecordBulkCreditRows", recorder.warnings[0]["statement"])
+}
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// exactValues is a DecimalVerificationRepository serving fixed exact readings
+type exactValues struct {
+	wallets    map[uuid.UUID]*models.ExactWalletValues
+	amounts    map[uuid.UUID]decimal.Decimal
+	mismatches []*models.DecimalMismatch
+}
+
+func (e *exactValues) GetExactWalletValues(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.ExactWalletValues, error) {
+	return e.wallets, nil
+}
+
+func (e *exactValues) GetExactTransactionAmounts(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]decimal.Decimal, error) {
+	return e.amounts, nil
+}
+
+func (e *exactValues) RecordMismatch(ctx context.Context, mismatch *models.DecimalMismatch) error {
+	e.mismatches = append(e.mismatches, mismatch)
+	return nil
+}
+
+func (e *exactValues) Close() error {
+	return nil
+}
+
+// alertLogger counts the alerts logged at error level
+type alertLogger struct {
+	alerts []string
+}
+
+func (l *alertLogger) Info(msg string, fields ...interface{}) {}
+
+func (l *alertLogger) Error(msg string, err error, fields ...interface{}) {
+	l.alerts = append(l.alerts, msg)
+}
+
+func (l *alertLogger) Warn(msg string, fields ...interface{}) {}
+
+// TestDecimalVerification tests that drift beyond the tolerance is recorded
+// and that alerts follow the drift and mismatch count thresholds
+func TestDecimalVerification(t *testing.T) {
+	ctx := context.Background()
+	store := testkit.NewStore(testkit.NewClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	wallet := &models.Wallet{CustomerID: uuid.New(), Balance: 100.25, Currency: "INR"}
+	require.NoError(t, store.CreateWallet(ctx, wallet))
+
+	exact := &exactValues{
+		wallets: map[uuid.UUID]*models.ExactWalletValues{
+			wallet.ID: {
+				Balance:             decimal.RequireFromString("100.26"),
+				CreditLimit:         decimal.Zero,
+				LowBalanceThreshold: decimal.Zero,
+			},
+		},
+	}
+	logger := &alertLogger{}
+	repo, err := service.NewDecimalVerifyingRepository(store, exact, service.DecimalVerificationOptions{
+		SampleRate:      1,
+		Tolerance:       0.001,
+		AlertDrift:      0.05,
+		AlertMismatches: 2,
+		AlertWindow:     time.Hour,
+	}, logger)
+	require.NoError(t, err)
+
+	got, err := repo.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, 100.25, got.Balance)
+	require.Len(t, exact.mismatches, 1)
+	require.Equal(t, "balance", exact.mismatches[0].Field)
+	require.Equal(t, "0.01", exact.mismatches[0].Drift.String())
+	require.Empty(t, logger.alerts)
+
+	exact.wallets[wallet.ID].Balance = decimal.RequireFromString("101.25")
+	_, err = repo.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Len(t, exact.mismatches, 2)
+	require.Equal(t, []string{
+		"decimal drift exceeds alert threshold",
+		"decimal mismatches exceed alert threshold",
+	}, logger.alerts)
+
+	exact.wallets[wallet.ID].Balance = decimal.RequireFromString("100.25")
+	_, err = repo.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Len(t, exact.mismatches, 2)
+}
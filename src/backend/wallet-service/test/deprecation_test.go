@@ -0,0 +1,157 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"            // v1.9.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/api"
+	"internal/deprecation"
+	"internal/models"
+	"internal/service"
+)
+
+// deprecationStore is an in-memory DeprecationRepository
+type deprecationStore struct {
+	usage map[string]*models.DeprecatedRouteUsage
+}
+
+func (s *deprecationStore) AddUsage(ctx context.Context, u *models.DeprecatedRouteUsage) error {
+	key := u.Method + " " + u.Route + " " + u.Caller
+	stored, ok := s.usage[key]
+	if !ok {
+		copied := *u
+		s.usage[key] = &copied
+		return nil
+	}
+	stored.Requests += u.Requests
+	if u.FirstSeen.Before(stored.FirstSeen) {
+		stored.FirstSeen = u.FirstSeen
+	}
+	if u.LastSeen.After(stored.LastSeen) {
+		stored.LastSeen = u.LastSeen
+	}
+	return nil
+}
+
+func (s *deprecationStore) ListUsage(ctx context.Context, since time.Time) ([]*models.DeprecatedRouteUsage, error) {
+	var usages []*models.DeprecatedRouteUsage
+	for _, u := range s.usage {
+		if !u.LastSeen.Before(since) {
+			copied := *u
+			usages = append(usages, &copied)
+		}
+	}
+	return usages, nil
+}
+
+func (s *deprecationStore) Close() error {
+	return nil
+}
+
+// TestDeprecatedRoutes tests that deprecated routes carry the Deprecation,
+// Sunset and Link headers, and that the report lists their callers, those
+// facing the earliest sunset first
+func TestDeprecatedRoutes(t *testing.T) {
+	ctx := context.Background()
+	deprecated := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	registry, err := deprecation.NewRegistry([]deprecation.Definition{
+		{
+			Name:       "get-balance-v1",
+			Method:     http.MethodGet,
+			Route:      "/api/v1/wallets/:id/balance",
+			Deprecated: deprecated,
+			Sunset:     sunset,
+			Link:       "https://docs.example.com/migrate/balance",
+		},
+		{
+			Name:       "list-transactions-v1",
+			Method:     http.MethodGet,
+			Route:      "/api/v1/wallets/:id/transactions",
+			Deprecated: deprecated,
+		},
+	})
+	require.NoError(t, err)
+
+	store := &deprecationStore{usage: make(map[string]*models.DeprecatedRouteUsage)}
+	deprecations, err := service.NewDeprecationService(store, registry, &alertLogger{})
+	require.NoError(t, err)
+	handler, err := api.NewDeprecationHandler(registry, deprecations)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("subject", c.GetHeader("X-Subject"))
+	})
+	router.Use(handler.Middleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/v1/wallets/:id/balance", ok)
+	router.GET("/api/v1/wallets/:id/transactions", ok)
+	router.GET("/api/v1/wallets/:id/health", ok)
+
+	call := func(subject, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Subject", subject)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec
+	}
+
+	rec := call("billing-api", "/api/v1/wallets/1/balance")
+	require.Equal(t, "@1780272000", rec.Header().Get("Deprecation"))
+	require.Equal(t, "Tue, 01 Dec 2026 00:00:00 GMT", rec.Header().Get("Sunset"))
+	require.Equal(t, `<https://docs.example.com/migrate/balance>; rel="deprecation"`, rec.Header().Get("Link"))
+	call("billing-api", "/api/v1/wallets/2/balance")
+
+	rec = call("reports", "/api/v1/wallets/1/transactions")
+	require.NotEmpty(t, rec.Header().Get("Deprecation"))
+	require.Empty(t, rec.Header().Get("Sunset"))
+	require.Empty(t, rec.Header().Get("Link"))
+	call("billing-api", "/api/v1/wallets/1/transactions")
+
+	rec = call("dashboard", "/api/v1/wallets/1/health")
+	require.Empty(t, rec.Header().Get("Deprecation"))
+
+	require.NoError(t, deprecations.Flush(ctx))
+
+	report, err := deprecations.GetReport(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, report.Integrations, 2)
+
+	// The caller of the route with a sunset comes first
+	billing := report.Integrations[0]
+	require.Equal(t, "billing-api", billing.Caller)
+	require.True(t, billing.Sunset.Equal(sunset))
+	require.Len(t, billing.Routes, 2)
+	requests := make(map[string]int64)
+	for _, route := range billing.Routes {
+		requests[route.Name] = route.Requests
+	}
+	require.Equal(t, map[string]int64{"get-balance-v1": 2, "list-transactions-v1": 1}, requests)
+
+	reports := report.Integrations[1]
+	require.Equal(t, "reports", reports.Caller)
+	require.Nil(t, reports.Sunset)
+
+	// Callers not seen since the cutoff have migrated
+	report, err = deprecations.GetReport(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Empty(t, report.Integrations)
+
+	_, err = deprecation.NewRegistry([]deprecation.Definition{{
+		Name:       "sunset-first",
+		Method:     http.MethodGet,
+		Route:      "/api/v1/wallets/:id/balance",
+		Deprecated: sunset,
+		Sunset:     deprecated,
+	}})
+	require.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/descriptions"
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestDescriptionNormalize tests that free-text descriptions are tidied and
+// cut to the maximum length
+func TestDescriptionNormalize(t *testing.T) {
+	for input, want := range map[string]string{
+		"  payment   for\torder 42. ": "Payment for order 42",
+		"refund\r\nof duplicate;":     "Refund of duplicate",
+		"UPI top-up":                  "UPI top-up",
+		"ééé":                         "Ééé",
+		" .- ":                        "",
+	} {
+		require.Equal(t, want, descriptions.Normalize(input, 0), input)
+	}
+
+	cut := descriptions.Normalize("monthly subscription renewal", 16)
+	require.Equal(t, "Monthly subscri…", cut)
+	require.Equal(t, 16, len([]rune(cut)))
+}
+
+// TestDescriptionTemplates tests that transactions are posted with the
+// description of the most specific template of their source and product,
+// keeping their own where no template applies or a variable is missing
+func TestDescriptionTemplates(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+
+	for _, defs := range [][]descriptions.Definition{
+		{{Source: "upi", Template: " "}},
+		{{Source: "upi", Template: "Top-up {order"}},
+		{{Source: "upi", Template: "Top-up } {order}"}},
+		{{Source: "upi", Template: "Top-up {}"}},
+		{{Source: "upi", Template: "Top-up"}, {Source: "upi", Template: "Recharge"}},
+	} {
+		_, err := descriptions.NewRegistry(defs, 255)
+		require.ErrorIs(t, err, descriptions.ErrInvalidTemplate)
+	}
+
+	registry, err := descriptions.NewRegistry([]descriptions.Definition{
+		{Template: "{description}"},
+		{Source: "upi", Template: "UPI top-up of {amount} {currency} ({vpa})"},
+		{Source: "upi", Product: "sms", Template: "SMS pack {pack} via UPI"},
+		{Product: "sms", Template: "SMS pack {pack}"},
+	}, 60)
+	require.NoError(t, err)
+	require.Equal(t, 4, registry.Templates())
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	wallets, err = service.NewDescribedWalletService(wallets, registry, &alertLogger{})
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "INR"}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+	post := func(description string, metadata map[string]string) string {
+		tx := &models.Transaction{
+			ID:          uuid.New(),
+			WalletID:    wallet.ID,
+			Type:        models.TransactionTypeCredit,
+			Status:      models.TransactionStatusCompleted,
+			Amount:      250.5,
+			Currency:    "INR",
+			Description: description,
+			Metadata:    metadata,
+		}
+		_, err := wallets.ProcessTransaction(ctx, tx)
+		require.NoError(t, err)
+		return tx.Description
+	}
+
+	require.Equal(t, "UPI top-up of 250.50 INR (user@bank)",
+		post("topup", map[string]string{models.TransactionSourceKey: "upi", "vpa": "user@bank"}))
+	require.Equal(t, "SMS pack Starter 500 via UPI",
+		post("", map[string]string{models.TransactionSourceKey: "upi", models.TransactionProductKey: "sms", "pack": "Starter 500"}))
+	require.Equal(t, "SMS pack Starter 500",
+		post("", map[string]string{models.TransactionSourceKey: "card", models.TransactionProductKey: "sms", "pack": "Starter 500"}))
+
+	// The default template keeps the caller's description, normalized
+	require.Equal(t, "Goodwill credit", post("  goodwill   credit. ", nil))
+
+	// A missing variable falls back to the caller's description
+	require.Equal(t, "Wallet recharge", post("wallet recharge", map[string]string{models.TransactionSourceKey: "upi"}))
+
+	// Long descriptions are cut to the maximum length
+	long := post(strings.Repeat("renewal ", 20), nil)
+	require.Equal(t, 60, len([]rune(long)))
+	require.True(t, strings.HasSuffix(long, "…"))
+
+	history, _, err := wallets.GetTransactionHistory(ctx, wallet.ID, service.TransactionFilter{}, service.Pagination{Limit: 10})
+	require.NoError(t, err)
+	posted := make(map[string]bool)
+	for _, tx := range history {
+		posted[tx.Description] = true
+	}
+	require.True(t, posted["UPI top-up of 250.50 INR (user@bank)"])
+	require.True(t, posted["Goodwill credit"])
+}
@@ -0,0 +1,193 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestDisputes tests that opening a dispute holds the disputed amount, that
+// won disputes release the hold and lost ones reverse it, and that every
+// state change is announced
+func TestDisputes(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	bus := eventbus.New()
+	var announced []models.DisputeStatus
+	require.NoError(t, bus.Subscribe("disputes", func(ctx context.Context, event eventbus.Event) error {
+		announced = append(announced, event.(eventbus.DisputeUpdated).Dispute.Status)
+		return nil
+	}, eventbus.TypeDisputeUpdated))
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, bus, &alertLogger{})
+	require.NoError(t, err)
+	disputes, err := service.NewDisputeService(kit.Store, wallets, bus, &alertLogger{})
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "INR"}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+	post := func(txType models.TransactionType, amount float64) uuid.UUID {
+		id := uuid.New()
+		_, err := wallets.ProcessTransaction(ctx, &models.Transaction{
+			ID:       id,
+			WalletID: wallet.ID,
+			Type:     txType,
+			Status:   models.TransactionStatusCompleted,
+			Amount:   amount,
+			Currency: "INR",
+		})
+		require.NoError(t, err)
+		return id
+	}
+	balance := func() float64 {
+		stored, err := kit.Store.GetWallet(ctx, wallet.ID)
+		require.NoError(t, err)
+		return stored.Balance
+	}
+	holdStatus := func(dispute *models.Dispute) models.TransactionStatus {
+		hold, err := kit.Store.GetTransactionByID(ctx, dispute.HoldTransactionID)
+		require.NoError(t, err)
+		return hold.Status
+	}
+	open := func(transactionID uuid.UUID, amount string) (*models.Dispute, error) {
+		request := service.DisputeRequest{
+			WalletID:       wallet.ID,
+			TransactionID:  transactionID,
+			ReasonCode:     models.DisputeReasonFraud,
+			Note:           "cardholder denies the top-up",
+			ProviderCaseID: "cb_1",
+		}
+		if amount != "" {
+			request.Amount = decimal.RequireFromString(amount)
+		}
+		return disputes.Open(ctx, request, "risk")
+	}
+
+	first := post(models.TransactionTypeCredit, 500)
+	second := post(models.TransactionTypeCredit, 300)
+	spend := post(models.TransactionTypeDebit, 100)
+
+	// Only completed top-ups can be disputed, for at most their amount
+	_, err = disputes.Open(ctx, service.DisputeRequest{WalletID: wallet.ID, TransactionID: first, ReasonCode: "CHANGED_MIND", Note: "n"}, "risk")
+	require.ErrorIs(t, err, service.ErrInvalidDispute)
+	_, err = open(spend, "")
+	require.ErrorIs(t, err, service.ErrDisputeNotAllowed)
+	_, err = open(first, "600")
+	require.ErrorIs(t, err, service.ErrDisputeNotAllowed)
+
+	// Opening holds the whole top-up with a PROCESSING debit
+	won, err := open(first, "")
+	require.NoError(t, err)
+	require.Equal(t, models.DisputeOpen, won.Status)
+	require.Equal(t, "500", won.Amount.String())
+	require.Equal(t, "INR", won.Currency)
+	require.Equal(t, 200.0, balance())
+	require.Equal(t, models.TransactionStatusProcessing, holdStatus(won))
+	_, err = open(first, "")
+	require.ErrorIs(t, err, service.ErrDisputeConflict)
+
+	// Holds beyond the balance are refused
+	_, err = open(second, "")
+	require.ErrorIs(t, err, service.ErrInsufficientBalance)
+	lost, err := open(second, "150")
+	require.NoError(t, err)
+	require.Equal(t, 50.0, balance())
+
+	_, err = disputes.SubmitEvidence(ctx, won.ID, " ", "risk")
+	require.ErrorIs(t, err, service.ErrInvalidDispute)
+	won, err = disputes.SubmitEvidence(ctx, won.ID, "delivery logs and IP match", "risk")
+	require.NoError(t, err)
+	require.Equal(t, models.DisputeEvidenceSubmitted, won.Status)
+	require.NotNil(t, won.EvidenceSubmittedAt)
+	_, err = disputes.SubmitEvidence(ctx, won.ID, "more logs", "risk")
+	require.ErrorIs(t, err, service.ErrDisputeConflict)
+
+	// Won disputes release the hold, lost ones keep it as the reversal
+	_, err = disputes.Resolve(ctx, won.ID, models.DisputeOpen, "risk", "")
+	require.ErrorIs(t, err, service.ErrInvalidDispute)
+	won, err = disputes.Resolve(ctx, won.ID, models.DisputeWon, "risk", "issuer accepted evidence")
+	require.NoError(t, err)
+	require.Equal(t, "risk", won.ResolvedBy)
+	require.Equal(t, 550.0, balance())
+	require.Equal(t, models.TransactionStatusReversed, holdStatus(won))
+	_, err = disputes.Resolve(ctx, won.ID, models.DisputeLost, "risk", "")
+	require.ErrorIs(t, err, service.ErrDisputeConflict)
+
+	lost, err = disputes.Resolve(ctx, lost.ID, models.DisputeLost, "risk", "")
+	require.NoError(t, err)
+	require.Equal(t, 550.0, balance())
+	require.Equal(t, models.TransactionStatusCompleted, holdStatus(lost))
+
+	_, err = disputes.Get(ctx, uuid.New())
+	require.ErrorIs(t, err, service.ErrDisputeNotFound)
+	listed, err := disputes.List(ctx, models.DisputeFilter{WalletID: &wallet.ID, Status: models.DisputeWon})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.Equal(t, won.ID, listed[0].ID)
+	_, err = disputes.List(ctx, models.DisputeFilter{Limit: 1000})
+	require.ErrorIs(t, err, service.ErrInvalidDispute)
+
+	require.Equal(t, []models.DisputeStatus{
+		models.DisputeOpen,
+		models.DisputeOpen,
+		models.DisputeEvidenceSubmitted,
+		models.DisputeWon,
+		models.DisputeLost,
+	}, announced)
+}
+
+// TestDisputeBlocksMerge tests that a wallet with an unresolved dispute
+// cannot be merged away, as the hold must settle on the wallet it was
+// placed on
+func TestDisputeBlocksMerge(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now().UTC()})
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	disputes, err := service.NewDisputeService(kit.Store, wallets, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	merges, err := service.NewWalletMergeService(kit.Store, &auditLog{}, 24*time.Hour, &alertLogger{})
+	require.NoError(t, err)
+
+	customerID := uuid.New()
+	survivor := kit.CreateWallet(t, customerID, "INR", 0)
+	duplicate := kit.CreateWallet(t, customerID, "INR", 0)
+	topUp := uuid.New()
+	_, err = wallets.ProcessTransaction(ctx, &models.Transaction{
+		ID:       topUp,
+		WalletID: duplicate.ID,
+		Type:     models.TransactionTypeCredit,
+		Status:   models.TransactionStatusCompleted,
+		Amount:   80,
+		Currency: "INR",
+	})
+	require.NoError(t, err)
+
+	dispute, err := disputes.Open(ctx, service.DisputeRequest{
+		WalletID:      duplicate.ID,
+		TransactionID: topUp,
+		ReasonCode:    models.DisputeReasonFraud,
+		Note:          "cardholder denies the top-up",
+	}, "risk")
+	require.NoError(t, err)
+
+	_, err = merges.Merge(ctx, duplicate.ID, survivor.ID, "ops", "duplicate signup")
+	require.ErrorIs(t, err, service.ErrWalletMergeConflict)
+
+	_, err = disputes.Resolve(ctx, dispute.ID, models.DisputeWon, "risk", "")
+	require.NoError(t, err)
+	merge, err := merges.Merge(ctx, duplicate.ID, survivor.ID, "ops", "duplicate signup")
+	require.NoError(t, err)
+	require.Equal(t, 80.0, merge.Amount)
+}
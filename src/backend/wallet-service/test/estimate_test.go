@@ -0,0 +1,101 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/currency"
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestChargeEstimate tests that estimates discount, charge fees, convert and
+// tax a debit in order, without redeeming the coupon or moving the balance
+func TestChargeEstimate(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	currencies := supportedCurrencies(t)
+	wallets, err := service.NewWalletService(kit.Store, currencies, decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	fees, err := service.NewFeeService(kit.Store, &auditLog{}, currencies, &alertLogger{})
+	require.NoError(t, err)
+	coupons, err := service.NewCouponService(kit.Store, &auditLog{}, currencies, &alertLogger{})
+	require.NoError(t, err)
+	taxes, err := service.NewTaxService(kit.Store, kit.Store, gstRules(t), currencies, &alertLogger{})
+	require.NoError(t, err)
+	rates, err := currency.NewRateTable("INR", map[string]decimal.Decimal{"USD": decimal.NewFromInt(80)})
+	require.NoError(t, err)
+	receivables, err := service.NewInvoiceReceivableService(kit.Store, kit.Store, currencies, models.PaymentTermsNet30, service.LateFeePolicy{}, &alertLogger{})
+	require.NoError(t, err)
+	postpaid, err := service.NewPostpaidService(kit.Store, kit.Store, wallets, receivables, eventbus.New(), currencies, &alertLogger{})
+	require.NoError(t, err)
+	estimates, err := service.NewEstimateService(wallets, fees, coupons, taxes, postpaid, currencies, rates, &alertLogger{})
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "INR", Balance: 1000}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+	_, err = taxes.UpdateProfile(ctx, wallet.CustomerID, "IN", false, "alice")
+	require.NoError(t, err)
+	_, err = fees.CreateRule(ctx, &models.FeeRule{Currency: "INR", Percentage: decimal.NewFromInt(2)}, "finance", "card fees")
+	require.NoError(t, err)
+	_, err = coupons.Create(ctx, &models.Coupon{Code: "WELCOME", Type: models.DiscountPercentage, Value: decimal.NewFromInt(10)}, "finance", "launch")
+	require.NoError(t, err)
+
+	// 500 less 10% is 450, plus a 2% fee of 9 is 459, plus 18% GST of 82.62
+	estimate, err := estimates.Estimate(ctx, service.ChargeRequest{WalletID: wallet.ID, Amount: decimal.NewFromInt(500), CouponCode: "welcome", Category: "api"})
+	require.NoError(t, err)
+	require.Equal(t, "INR", estimate.Currency)
+	require.Equal(t, "50", estimate.Discount.String())
+	require.Equal(t, "9", estimate.Fee.String())
+	require.NotNil(t, estimate.FeeRuleID)
+	require.Equal(t, "459", estimate.Subtotal.String())
+	require.Nil(t, estimate.ExchangeRate)
+	require.Equal(t, "82.62", estimate.Tax.Total.String())
+	require.Equal(t, "541.62", estimate.Total.String())
+	require.True(t, estimate.SufficientBalance)
+	require.Equal(t, models.BillingPrepaid, estimate.BillingMode)
+
+	// Charges in another currency are converted before they are taxed; USD
+	// has no fee rule
+	estimate, err = estimates.Estimate(ctx, service.ChargeRequest{WalletID: wallet.ID, Amount: decimal.NewFromInt(10), Currency: "usd", Category: "education"})
+	require.NoError(t, err)
+	require.True(t, estimate.Fee.IsZero())
+	require.Equal(t, "80", estimate.ExchangeRate.String())
+	require.Equal(t, "800", estimate.Converted.String())
+	require.Equal(t, models.TaxExemptCategory, estimate.Tax.ExemptReason)
+	require.Equal(t, "800", estimate.Total.String())
+
+	estimate, err = estimates.Estimate(ctx, service.ChargeRequest{WalletID: wallet.ID, Amount: decimal.NewFromInt(15), Currency: "USD"})
+	require.NoError(t, err)
+	require.False(t, estimate.SufficientBalance)
+
+	// Postpaid debits are billed at the end of the cycle, so the balance
+	// does not limit them
+	_, err = postpaid.UpdateBillingMode(ctx, wallet.CustomerID, models.BillingPostpaid, "", "finance")
+	require.NoError(t, err)
+	estimate, err = estimates.Estimate(ctx, service.ChargeRequest{WalletID: wallet.ID, Amount: decimal.NewFromInt(15), Currency: "USD"})
+	require.NoError(t, err)
+	require.True(t, estimate.SufficientBalance)
+	require.Equal(t, models.BillingPostpaid, estimate.BillingMode)
+
+	_, err = estimates.Estimate(ctx, service.ChargeRequest{WalletID: wallet.ID, Amount: decimal.NewFromInt(10), Currency: "IDR"})
+	require.ErrorIs(t, err, service.ErrUnsupportedCurrency)
+	_, err = estimates.Estimate(ctx, service.ChargeRequest{WalletID: wallet.ID, Amount: decimal.NewFromInt(10), CouponCode: "MISSING"})
+	require.ErrorIs(t, err, service.ErrCouponNotFound)
+
+	// Nothing was redeemed or debited
+	listed, err := coupons.List(ctx, models.CouponActive, 0)
+	require.NoError(t, err)
+	require.Zero(t, listed[0].Redemptions)
+	stored, err := kit.Store.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1000.0, stored.Balance)
+}
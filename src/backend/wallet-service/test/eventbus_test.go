@@ -0,0 +1,137 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/events"
+	"internal/execctx"
+	"internal/models"
+)
+
+// recordingPublisher collects the envelopes published to it
+type recordingPublisher struct {
+	envelopes []*events.Envelope
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, env *events.Envelope) error {
+	p.envelopes = append(p.envelopes, env)
+	return nil
+}
+
+// grantedConsents consents every customer to the channels set to true
+type grantedConsents map[models.ConsentChannel]bool
+
+func (g grantedConsents) HasConsent(ctx context.Context, customerID uuid.UUID, channel models.ConsentChannel) (bool, error) {
+	return g[channel], nil
+}
+
+// TestEventBusDispatch tests type filters, typed handlers and failure isolation
+func TestEventBusDispatch(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+
+	var notices []int
+	var all []string
+	require.NoError(t, bus.Subscribe("failing", func(ctx context.Context, event eventbus.Event) error {
+		return errors.New("transport down")
+	}, eventbus.TypeDunningNotice))
+	require.NoError(t, bus.Subscribe("notices", eventbus.Typed(func(ctx context.Context, e eventbus.DunningNotice) error {
+		notices = append(notices, e.Notice)
+		return nil
+	})))
+	require.NoError(t, bus.Subscribe("audit", func(ctx context.Context, event eventbus.Event) error {
+		all = append(all, event.EventType())
+		return nil
+	}))
+	require.Error(t, bus.Subscribe("audit", func(ctx context.Context, event eventbus.Event) error { return nil }))
+
+	dc := &models.DunningCase{WalletID: testWalletID, CustomerID: uuid.New()}
+	err := bus.Publish(ctx, eventbus.DunningNotice{Case: dc, Notice: 2})
+	require.ErrorContains(t, err, "subscriber failing")
+	require.NoError(t, bus.Publish(ctx, eventbus.CustomerResumed{Case: dc}))
+
+	require.Equal(t, []int{2}, notices)
+	require.Equal(t, []string{eventbus.TypeDunningNotice, eventbus.TypeCustomerResumed}, all)
+}
+
+// TestEventForwarder tests that forwarded domain events become valid envelopes
+// at the latest schema version
+func TestEventForwarder(t *testing.T) {
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+
+	publisher := &recordingPublisher{}
+	forwarder, err := events.NewForwarder(registry, publisher, grantedConsents{})
+	require.NoError(t, err)
+
+	bus := eventbus.New()
+	require.NoError(t, bus.Subscribe("event-stream", forwarder.Handle, forwarder.EventTypes()...))
+
+	tx := &models.Transaction{
+		ID:       uuid.New(),
+		WalletID: testWalletID,
+		Type:     models.TransactionTypeCredit,
+		Status:   models.TransactionStatusCompleted,
+		Amount:   10,
+		Currency: defaultCurrency,
+	}
+	dc := &models.DunningCase{
+		WalletID:    testWalletID,
+		CustomerID:  uuid.New(),
+		Currency:    defaultCurrency,
+		StartedAt:   time.Now(),
+		GraceEndsAt: time.Now().Add(time.Hour),
+	}
+
+	require.NoError(t, bus.Publish(context.Background(), eventbus.TransactionCompleted{Transaction: tx}))
+	require.NoError(t, bus.Publish(context.Background(), eventbus.CustomerSuspended{
+		Case:        dc,
+		Reason:      models.SuspendReasonGraceExpired,
+		SuspendedAt: time.Now(),
+	}))
+
+	require.Len(t, publisher.envelopes, 2)
+	for _, env := range publisher.envelopes {
+		latest, err := registry.Latest(env.Type)
+		require.NoError(t, err)
+		require.Equal(t, latest, env.SchemaVersion)
+		require.NoError(t, registry.Validate(env))
+	}
+	require.Equal(t, events.TypeSuspendCustomer, publisher.envelopes[1].Type)
+}
+
+// TestEventForwarderSuppression tests that customer notification events are
+// dropped outside live mode while account state changes are still forwarded
+func TestEventForwarderSuppression(t *testing.T) {
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+
+	publisher := &recordingPublisher{}
+	forwarder, err := events.NewForwarder(registry, publisher, grantedConsents{})
+	require.NoError(t, err)
+
+	ctx := execctx.WithMode(context.Background(), execctx.ModeSandbox)
+	dc := &models.DunningCase{
+		WalletID:    testWalletID,
+		CustomerID:  uuid.New(),
+		Currency:    defaultCurrency,
+		StartedAt:   time.Now(),
+		GraceEndsAt: time.Now().Add(time.Hour),
+	}
+
+	require.NoError(t, forwarder.Handle(ctx, eventbus.DunningNotice{Case: dc, Notice: 1}))
+	require.Empty(t, publisher.envelopes)
+
+	require.NoError(t, forwarder.Handle(ctx, eventbus.CustomerResumed{Case: dc, ResumedAt: time.Now()}))
+	require.Len(t, publisher.envelopes, 1)
+
+	require.NoError(t, forwarder.Handle(context.Background(), eventbus.DunningNotice{Case: dc, Notice: 1}))
+	require.Len(t, publisher.envelopes, 2)
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/events"
+	"internal/models"
+)
+
+// TestEventSchemaCompatibility fails when an embedded schema breaks consumers
+func TestEventSchemaCompatibility(t *testing.T) {
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+	require.Empty(t, registry.CheckCompatibility())
+}
+
+// TestEventNegotiationAndUpgrade tests version negotiation and replay upgrades
+func TestEventNegotiationAndUpgrade(t *testing.T) {
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+
+	version, err := registry.Negotiate(events.TypeTransactionCompleted, []int{1})
+	require.NoError(t, err)
+	require.Equal(t, 1, version)
+
+	_, err = registry.Negotiate(events.TypeTransactionCompleted, []int{99})
+	require.ErrorIs(t, err, events.ErrUnsupportedVersion)
+
+	tx := &models.Transaction{
+		ID:       uuid.New(),
+		WalletID: testWalletID,
+		Type:     models.TransactionTypeCredit,
+		Status:   models.TransactionStatusCompleted,
+		Amount:   250.00,
+		Currency: defaultCurrency,
+	}
+	env, err := events.NewTransactionCompleted(tx, version)
+	require.NoError(t, err)
+
+	latest, err := registry.Latest(events.TypeTransactionCompleted)
+	require.NoError(t, err)
+
+	upgraded, err := registry.Upgrade(env, latest)
+	require.NoError(t, err)
+	require.Equal(t, latest, upgraded.SchemaVersion)
+	require.Equal(t, 1, env.SchemaVersion)
+
+	var payload events.TransactionCompletedV2
+	require.NoError(t, json.Unmarshal(upgraded.Payload, &payload))
+	require.Equal(t, "COMPLETED", payload.Status)
+	require.Equal(t, tx.ID.String(), payload.TransactionID)
+}
+
+// TestActivityDigestEnvelope tests that digest envelopes match their schema
+func TestActivityDigestEnvelope(t *testing.T) {
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+
+	sub := &models.DigestSubscription{
+		CustomerID: uuid.New(),
+		Frequency:  models.DigestWeekly,
+		Enabled:    true,
+		Timezone:   "Asia/Kolkata",
+	}
+	ist, err := time.LoadLocation(sub.Timezone)
+	require.NoError(t, err)
+	end := time.Date(2024, time.March, 4, 0, 0, 0, 0, ist)
+
+	env, err := events.NewActivityDigest(sub, end.AddDate(0, 0, -7), end, nil, 1)
+	require.NoError(t, err)
+	require.NoError(t, registry.Validate(env))
+
+	var payload events.ActivityDigestV1
+	require.NoError(t, json.Unmarshal(env.Payload, &payload))
+	require.Equal(t, "2024-02-25T18:30:00Z", payload.PeriodStart)
+	require.Equal(t, "Asia/Kolkata", payload.Timezone)
+	require.NotNil(t, payload.Wallets)
+}
+
+// TestDunningEnvelopes tests that dunning envelopes match their schemas
+func TestDunningEnvelopes(t *testing.T) {
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+
+	started := time.Date(2024, time.March, 4, 9, 30, 0, 0, time.UTC)
+	dc := &models.DunningCase{
+		ID:          uuid.New(),
+		WalletID:    testWalletID,
+		CustomerID:  uuid.New(),
+		Currency:    defaultCurrency,
+		StartedAt:   started,
+		GraceEndsAt: started.Add(72 * time.Hour),
+	}
+	require.Equal(t, models.DunningInGrace, dc.Status())
+
+	notice, err := events.NewDunningNotice(dc, 3, true, 1)
+	require.NoError(t, err)
+	require.NoError(t, registry.Validate(notice))
+
+	var payload events.DunningNoticeV1
+	require.NoError(t, json.Unmarshal(notice.Payload, &payload))
+	require.Equal(t, 3, payload.Notice)
+	require.True(t, payload.Final)
+	require.Equal(t, "2024-03-07T09:30:00Z", payload.GraceEndsAt)
+
+	suspend, err := events.NewSuspendCustomer(dc, models.SuspendReasonGraceExpired, dc.GraceEndsAt, 1)
+	require.NoError(t, err)
+	require.NoError(t, registry.Validate(suspend))
+
+	resume, err := events.NewResumeCustomer(dc, dc.GraceEndsAt.Add(time.Hour), 1)
+	require.NoError(t, err)
+	require.NoError(t, registry.Validate(resume))
+
+	_, err = events.NewSuspendCustomer(dc, models.SuspendReasonGraceExpired, dc.GraceEndsAt, 2)
+	require.ErrorIs(t, err, events.ErrUnsupportedVersion)
+}
+
+// TestCreditLimitWarningEnvelope tests that credit limit warnings match their schema
+func TestCreditLimitWarningEnvelope(t *testing.T) {
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{
+		ID:          testWalletID,
+		CustomerID:  uuid.New(),
+		Balance:     -85,
+		Currency:    defaultCurrency,
+		CreditLimit: 100,
+	}
+	warning := wallet.CreditLimitWarning()
+	require.NotNil(t, warning)
+
+	env, err := events.NewCreditLimitWarning(wallet, warning, 1)
+	require.NoError(t, err)
+	require.NoError(t, registry.Validate(env))
+
+	var payload events.CreditLimitWarningV1
+	require.NoError(t, json.Unmarshal(env.Payload, &payload))
+	require.Equal(t, 0.8, payload.Level)
+	require.Equal(t, float64(15), payload.AvailableBalance)
+
+	wallet.Balance = -50
+	require.Nil(t, wallet.CreditLimitWarning())
+}
+
+// TestTransactionCompletedMetadata tests that v3 transaction events carry the
+// transaction metadata
+func TestTransactionCompletedMetadata(t *testing.T) {
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+
+	tx := &models.Transaction{
+		ID:       uuid.New(),
+		WalletID: testWalletID,
+		Type:     models.TransactionTypeDebit,
+		Status:   models.TransactionStatusCompleted,
+		Amount:   99.50,
+		Currency: defaultCurrency,
+		Metadata: map[string]string{"order_id": "ORD-1", "channel": "app"},
+	}
+	env, err := events.NewTransactionCompleted(tx, 3)
+	require.NoError(t, err)
+	require.NoError(t, registry.Validate(env))
+
+	var payload events.TransactionCompletedV3
+	require.NoError(t, json.Unmarshal(env.Payload, &payload))
+	require.Equal(t, tx.Metadata, payload.Metadata)
+
+	v2, err := events.NewTransactionCompleted(tx, 2)
+	require.NoError(t, err)
+	upgraded, err := registry.Upgrade(v2, 3)
+	require.NoError(t, err)
+	require.NotContains(t, string(upgraded.Payload), "metadata")
+}
@@ -0,0 +1,217 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestFailedJobRetries tests that failed jobs are retried with backoff,
+// dead-lettered once out of attempts and retried or discarded by operators,
+// individually or in bulk, with every operator action audited
+func TestFailedJobRetries(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+	audit := &auditLog{}
+
+	jobs, err := service.NewFailedJobService(kit.Store, audit, &alertLogger{})
+	require.NoError(t, err)
+
+	attempts := map[string]int{}
+	failing := map[string]bool{"evt_1": true, "evt_2": true, "evt_3": true}
+	require.NoError(t, jobs.Register(models.FailedJobWebhookDelivery, service.FailedJobPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Minute,
+		MaxBackoff:  time.Hour,
+	}, func(ctx context.Context, job *models.FailedJob) error {
+		attempts[job.Reference]++
+		switch {
+		case job.Reference == "evt_gone":
+			return service.ErrFailedJobObsolete
+		case failing[job.Reference]:
+			return errors.New("endpoint returned 503")
+		}
+		return nil
+	}))
+	require.Error(t, jobs.Register(models.FailedJobWebhookDelivery, service.FailedJobPolicy{
+		MaxAttempts: 1, MinBackoff: time.Minute, MaxBackoff: time.Minute,
+	}, func(context.Context, *models.FailedJob) error { return nil }))
+
+	// Unregistered kinds cannot be recorded, and work failing again while
+	// its job is open is queued once
+	require.Error(t, jobs.RecordFailure(ctx, models.FailedJobRecurringDebitRun, "run", nil, errors.New("rejected")))
+	for _, reference := range []string{"evt_1", "evt_1", "evt_2", "evt_3", "evt_gone"} {
+		require.NoError(t, jobs.RecordFailure(ctx, models.FailedJobWebhookDelivery, reference,
+			map[string]string{"event": reference}, errors.New("endpoint returned 503")))
+	}
+	open, err := jobs.List(ctx, models.FailedJobFilter{Status: models.FailedJobRetrying})
+	require.NoError(t, err)
+	require.Len(t, open, 4)
+	_, err = jobs.List(ctx, models.FailedJobFilter{Kind: "OUTBOX"})
+	require.ErrorIs(t, err, service.ErrInvalidFailedJob)
+
+	// Retries wait for their backoff, doubling after every failure, and
+	// jobs that no longer apply are discarded
+	now := time.Now()
+	succeeded, err := jobs.ProcessRetries(ctx, now)
+	require.NoError(t, err)
+	require.Zero(t, succeeded)
+	require.Empty(t, attempts)
+
+	now = now.Add(time.Minute)
+	_, err = jobs.ProcessRetries(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, attempts["evt_1"])
+	require.Equal(t, 1, attempts["evt_gone"])
+	discarded, err := jobs.List(ctx, models.FailedJobFilter{Status: models.FailedJobDiscarded})
+	require.NoError(t, err)
+	require.Len(t, discarded, 1)
+	require.Equal(t, "evt_gone", discarded[0].Reference)
+
+	_, err = jobs.ProcessRetries(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, attempts["evt_1"])
+	now = now.Add(2 * time.Minute)
+	_, err = jobs.ProcessRetries(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts["evt_1"])
+
+	dead, err := jobs.List(ctx, models.FailedJobFilter{Kind: models.FailedJobWebhookDelivery, Status: models.FailedJobDead})
+	require.NoError(t, err)
+	require.Len(t, dead, 3)
+	for _, job := range dead {
+		require.Equal(t, 3, job.Attempts)
+		require.Equal(t, "endpoint returned 503", job.LastError)
+		require.Nil(t, job.NextAttemptAt)
+		require.NotNil(t, job.DeadAt)
+	}
+	_, err = jobs.ProcessRetries(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts["evt_1"])
+
+	// An operator retry grants one more attempt, made by the next run
+	ids := map[string]uuid.UUID{}
+	for _, job := range dead {
+		ids[job.Reference] = job.ID
+	}
+	failing["evt_1"] = false
+	retried, err := jobs.Retry(ctx, ids["evt_1"], "ops")
+	require.NoError(t, err)
+	require.Equal(t, models.FailedJobRetrying, retried.Status)
+	require.Equal(t, 4, retried.MaxAttempts)
+	succeeded, err = jobs.ProcessRetries(ctx, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, succeeded)
+	job, err := jobs.Get(ctx, ids["evt_1"])
+	require.NoError(t, err)
+	require.Equal(t, models.FailedJobSucceeded, job.Status)
+	require.NotNil(t, job.ResolvedAt)
+	_, err = jobs.Retry(ctx, ids["evt_1"], "ops")
+	require.ErrorIs(t, err, service.ErrFailedJobConflict)
+	_, err = jobs.Get(ctx, uuid.New())
+	require.ErrorIs(t, err, service.ErrFailedJobNotFound)
+
+	// Bulk discards need a reason and report each job, one closed job not
+	// stopping the others
+	_, err = jobs.DiscardMany(ctx, []uuid.UUID{ids["evt_2"]}, "ops", " ")
+	require.ErrorIs(t, err, service.ErrInvalidFailedJob)
+	_, err = jobs.RetryMany(ctx, nil, "ops")
+	require.ErrorIs(t, err, service.ErrInvalidFailedJob)
+	outcomes, err := jobs.DiscardMany(ctx, []uuid.UUID{ids["evt_2"], ids["evt_1"], ids["evt_3"], ids["evt_2"]}, "ops", "customer endpoint retired")
+	require.NoError(t, err)
+	require.Len(t, outcomes, 3)
+	require.Equal(t, models.FailedJobDiscarded, outcomes[0].Job.Status)
+	require.Equal(t, "ops", outcomes[0].Job.ResolvedBy)
+	require.Equal(t, "customer endpoint retired", outcomes[0].Job.Resolution)
+	require.Nil(t, outcomes[1].Job)
+	require.NotEmpty(t, outcomes[1].Error)
+	require.Equal(t, models.FailedJobDiscarded, outcomes[2].Job.Status)
+
+	// One retry and three discards were attempted, one of them refused
+	require.Len(t, audit.actions, 5)
+	failed := 0
+	for _, action := range audit.actions {
+		if action.Status == models.OperatorActionFailed {
+			failed++
+		}
+	}
+	require.Equal(t, 2, failed)
+}
+
+// TestRecurringDebitRunRetry tests that a recurring debit run the wallet
+// rejects is queued for retries and debited once the wallet is topped up
+func TestRecurringDebitRunRetry(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 5)
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	jobs, err := service.NewFailedJobService(kit.Store, &auditLog{}, &alertLogger{})
+	require.NoError(t, err)
+	store := newRecurringStore()
+	recurring, err := service.NewRecurringDebitService(store, wallets, service.RecurringDebitPolicy{
+		ClaimTimeout: 10 * time.Minute,
+	}, jobs, &alertLogger{})
+	require.NoError(t, err)
+	require.NoError(t, jobs.Register(models.FailedJobRecurringDebitRun, service.FailedJobPolicy{
+		MaxAttempts: 4,
+		MinBackoff:  time.Hour,
+		MaxBackoff:  12 * time.Hour,
+	}, recurring.RetryRun))
+
+	debit, err := recurring.Create(ctx, customerID, service.RecurringDebitInput{
+		WalletID: wallet.ID,
+		Amount:   decimal.NewFromInt(10),
+		Cadence:  models.CadenceMonthly,
+	})
+	require.NoError(t, err)
+
+	now := debit.StartAt
+	_, err = recurring.ProcessDue(ctx, now)
+	require.NoError(t, err)
+	queued, err := jobs.List(ctx, models.FailedJobFilter{Kind: models.FailedJobRecurringDebitRun})
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	require.Equal(t, models.FailedJobRetrying, queued[0].Status)
+
+	// Still short of funds, the retry fails and is rescheduled
+	now = now.Add(2 * time.Hour)
+	succeeded, err := jobs.ProcessRetries(ctx, now)
+	require.NoError(t, err)
+	require.Zero(t, succeeded)
+
+	_, err = wallets.ProcessTransaction(ctx, &models.Transaction{
+		ID:       uuid.New(),
+		WalletID: wallet.ID,
+		Type:     models.TransactionTypeCredit,
+		Status:   models.TransactionStatusInitiated,
+		Amount:   20,
+		Currency: "INR",
+	})
+	require.NoError(t, err)
+
+	succeeded, err = jobs.ProcessRetries(ctx, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, succeeded)
+
+	balance, _, err := wallets.GetWalletBalance(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, "15", balance.String())
+	runs, err := recurring.ListRuns(ctx, customerID, debit.ID)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, models.RecurringRunSucceeded, runs[0].Status)
+	require.NotNil(t, runs[0].TransactionID)
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/currency"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestFeeRules tests that fees are priced by the most specific rule in
+// effect, with percentage caps, per-currency rounding and effective-dated
+// changes
+func TestFeeRules(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now().UTC()})
+
+	// IDR amounts have no decimals
+	currencies, err := currency.FromCodes([]string{"USD", "INR", "IDR"}, map[string]int32{"IDR": 0}, nil)
+	require.NoError(t, err)
+
+	audit := &auditLog{}
+	fees, err := service.NewFeeService(kit.Store, audit, currencies, &alertLogger{})
+	require.NoError(t, err)
+
+	amount := decimal.RequireFromString
+	limit := func(value string) *decimal.Decimal {
+		d := amount(value)
+		return &d
+	}
+	create := func(rule *models.FeeRule) *models.FeeRule {
+		created, err := fees.CreateRule(ctx, rule, "finance", "pricing update")
+		require.NoError(t, err)
+		return created
+	}
+	preview := func(value, code, source string, at time.Time) *models.FeeQuote {
+		quote, err := fees.Preview(ctx, service.FeePreview{Amount: amount(value), Currency: code, Source: source, At: at})
+		require.NoError(t, err)
+		return quote
+	}
+
+	// Refused rules
+	_, err = fees.CreateRule(ctx, &models.FeeRule{Percentage: amount("1")}, "finance", " ")
+	require.ErrorIs(t, err, service.ErrInvalidFeeRule)
+	_, err = fees.CreateRule(ctx, &models.FeeRule{FixedAmount: amount("5")}, "finance", "flat fee")
+	require.ErrorIs(t, err, service.ErrInvalidFeeRule)
+	_, err = fees.CreateRule(ctx, &models.FeeRule{Currency: "INR", Percentage: amount("101")}, "finance", "typo")
+	require.ErrorIs(t, err, service.ErrInvalidFeeRule)
+	_, err = fees.CreateRule(ctx, &models.FeeRule{Currency: "INR", MinFee: limit("10"), MaxFee: limit("5")}, "finance", "typo")
+	require.ErrorIs(t, err, service.ErrInvalidFeeRule)
+	_, err = fees.CreateRule(ctx, &models.FeeRule{Currency: "INR", EffectiveFrom: time.Now().UTC().Add(-time.Hour)}, "finance", "backdated")
+	require.ErrorIs(t, err, service.ErrInvalidFeeRule)
+
+	now := time.Now().UTC()
+	create(&models.FeeRule{Percentage: amount("1")})
+	inr := create(&models.FeeRule{Currency: "INR", Percentage: amount("2"), MinFee: limit("5"), MaxFee: limit("50")})
+	waiver := create(&models.FeeRule{Source: "upi", Currency: "INR"})
+	raise := create(&models.FeeRule{Currency: "INR", Percentage: amount("3"), MinFee: limit("5"), MaxFee: limit("50"), EffectiveFrom: now.Add(2 * time.Hour)})
+
+	// Percentage fees are held between the minimum and maximum
+	quote := preview("100", "INR", "", time.Time{})
+	require.Equal(t, "5", quote.Fee.String())
+	require.Equal(t, inr.ID, quote.Rule.ID)
+	require.Equal(t, "20", preview("1000", "INR", "card", time.Time{}).Fee.String())
+	require.Equal(t, "50", preview("10000", "INR", "", time.Time{}).Fee.String())
+
+	// A rule for the source wins over one for its currency
+	quote = preview("1000", "INR", "upi", time.Time{})
+	require.True(t, quote.Fee.IsZero())
+	require.Equal(t, waiver.ID, quote.Rule.ID)
+
+	// Other currencies fall back to the rule for every currency, rounded to
+	// their precision
+	require.Equal(t, "1.23", preview("123.45", "USD", "", time.Time{}).Fee.String())
+	require.Equal(t, "123", preview("12345", "IDR", "", time.Time{}).Fee.String())
+
+	// The later rule takes over from its effective date
+	require.Equal(t, "30", preview("1000", "INR", "", now.Add(3*time.Hour)).Fee.String())
+
+	// Ending a rule leaves the next most specific rule in effect
+	_, err = fees.EndRule(ctx, raise.ID, now.Add(time.Hour), "finance", "ends before it starts")
+	require.ErrorIs(t, err, service.ErrFeeRuleConflict)
+	_, err = fees.EndRule(ctx, uuid.New(), time.Time{}, "finance", "unknown")
+	require.ErrorIs(t, err, service.ErrFeeRuleNotFound)
+	ended, err := fees.EndRule(ctx, inr.ID, now.Add(time.Hour), "finance", "promotion")
+	require.NoError(t, err)
+	require.NotNil(t, ended.EffectiveUntil)
+	require.Equal(t, "10", preview("1000", "INR", "", now.Add(90*time.Minute)).Fee.String())
+	require.Equal(t, "20", preview("1000", "INR", "", now.Add(30*time.Minute)).Fee.String())
+
+	_, err = fees.Preview(ctx, service.FeePreview{Amount: amount("10"), Currency: "XYZ"})
+	require.ErrorIs(t, err, service.ErrUnsupportedCurrency)
+	_, err = fees.Preview(ctx, service.FeePreview{Amount: decimal.Zero, Currency: "INR"})
+	require.ErrorIs(t, err, service.ErrInvalidAmount)
+
+	rules, err := fees.ListRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 4)
+
+	// Rule changes are audited, refused ends included
+	require.Len(t, audit.actions, 7)
+	require.Equal(t, models.OperatorActionFailed, audit.actions[4].Status)
+}
@@ -0,0 +1,104 @@
+package test
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/testkit"
+	"internal/webhook"
+)
+
+// FuzzTransactionValidate checks that transactions passing validation satisfy
+// the constraints of the wallet_transactions schema
+func FuzzTransactionValidate(f *testing.F) {
+	f.Add(int(models.TransactionTypeDebit), int(models.TransactionStatusInitiated), 40.0, "USD", "")
+	f.Add(int(models.TransactionTypeCredit), int(models.TransactionStatusCompleted), 0.01, "INR", "ref-00001")
+	f.Add(int(models.TransactionTypeRefund), int(models.TransactionStatusReversed), models.MaxTransactionAmount, "EUR", strings.Repeat("r", 64))
+	f.Add(-1, 99, math.NaN(), "usd", "short")
+	f.Add(0, 0, math.Inf(1), "€", "")
+
+	f.Fuzz(func(t *testing.T, txType, status int, amount float64, currency, referenceID string) {
+		tx := &models.Transaction{
+			ID:          uuid.New(),
+			WalletID:    testWalletID,
+			Type:        models.TransactionType(txType),
+			Status:      models.TransactionStatus(status),
+			Amount:      amount,
+			Currency:    currency,
+			ReferenceID: referenceID,
+		}
+		if err := tx.Validate(); err != nil {
+			return
+		}
+
+		require.True(t, models.IsValidTransactionType(tx.Type))
+		require.True(t, models.IsValidTransactionStatus(tx.Status))
+		require.False(t, math.IsNaN(amount) || math.IsInf(amount, 0))
+		require.Greater(t, amount, 0.0)
+		require.LessOrEqual(t, amount, models.MaxTransactionAmount)
+		require.Regexp(t, `^[A-Z]{3}$`, currency)
+		if referenceID != "" {
+			require.True(t, len(referenceID) >= 8 && len(referenceID) <= 64)
+		}
+	})
+}
+
+// FuzzTransactionAmount posts arbitrary JSON amounts and currencies to the
+// transaction endpoint, which must reject bad money input as a client error
+func FuzzTransactionAmount(f *testing.F) {
+	for _, amount := range []string{"40", "0.01", "0", "-5", "1e400", "1e-400", "9999999999.99", "10000000000", `"40"`, "null", "[1]"} {
+		f.Add(amount, "USD")
+	}
+	f.Add("40", "usd")
+	f.Add("40", "US")
+
+	f.Fuzz(func(t *testing.T, amount, currency string) {
+		if !json.Valid([]byte(amount)) {
+			t.Skip("amount is not a JSON value")
+		}
+
+		kit := testkit.New(t, testkit.Options{})
+		customerID := uuid.New()
+		wallet := kit.CreateWallet(t, customerID, defaultCurrency, 100)
+		path := "/api/v1/wallets/" + wallet.ID.String()
+
+		status, body := kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+			"type":     "CREDIT",
+			"amount":   json.RawMessage(amount),
+			"currency": currency,
+		}, "Idempotency-Key", uuid.NewString())
+		require.Less(t, status, http.StatusInternalServerError, string(body))
+
+		// Whatever was accepted must leave a readable balance
+		status, body = kit.Do(t, customerID, http.MethodGet, path+"/balance", nil)
+		require.Equal(t, http.StatusOK, status, string(body))
+	})
+}
+
+// FuzzWebhookSignature checks that signatures verify only for the signed
+// body and that malformed signatures are rejected without panicking
+func FuzzWebhookSignature(f *testing.F) {
+	f.Add("whsec_test", "1700000000", []byte(`{"type":"transaction.completed"}`), "")
+	f.Add("", "", []byte{}, "zz")
+	f.Add("s", "1", []byte("x"), strings.Repeat("0", 64))
+
+	f.Fuzz(func(t *testing.T, secret, timestamp string, body []byte, signature string) {
+		signed := webhook.Sign(secret, timestamp, body)
+		require.True(t, webhook.Verify(secret, timestamp, body, signed))
+		require.True(t, webhook.Verify(secret, timestamp, body, strings.ToUpper(signed)))
+
+		tampered := append(append([]byte{}, body...), '!')
+		require.False(t, webhook.Verify(secret, timestamp, tampered, signed))
+
+		if webhook.Verify(secret, timestamp, body, signature) {
+			require.True(t, strings.EqualFold(signature, signed))
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/api"
+	"internal/testkit"
+)
+
+// updateGolden rewrites the golden responses of the current API version:
+// go test ./test -run TestGoldenResponses -update
+var updateGolden = flag.Bool("update", false, "rewrite golden API responses")
+
+// uuidPattern matches generated IDs masked in golden files
+var uuidPattern = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+
+// goldenResponse is the canonical response of one endpoint scenario
+type goldenResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// TestGoldenResponses captures the canonical response of every wallet
+// endpoint and fails when a response shape changes within an API version.
+// Field names, nesting, JSON types and HTTP statuses form the contract;
+// values such as IDs, amounts and messages may change freely.
+func TestGoldenResponses(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+	wallets := "/api/" + api.Version + "/wallets/"
+	path := wallets + wallet.ID.String()
+	idempotencyKey := func() []string { return []string{"Idempotency-Key", uuid.NewString()} }
+
+	kit.Clock.Advance(26 * time.Hour)
+
+	status, body := kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+		"type":         "CREDIT",
+		"amount":       25.5,
+		"currency":     "INR",
+		"description":  "Top up",
+		"reference_id": "ORDER-0001",
+	}, idempotencyKey()...)
+	assertGolden(t, "process_transaction", status, body)
+
+	status, body = kit.Do(t, customerID, http.MethodGet, path+"/balance", nil)
+	assertGolden(t, "get_balance", status, body)
+
+	status, body = kit.Do(t, customerID, http.MethodGet, path+"/transactions", nil)
+	assertGolden(t, "get_transactions", status, body)
+
+	_, err := kit.History.SnapshotDaily(context.Background(), kit.Clock.Now())
+	require.NoError(t, err)
+	status, body = kit.Do(t, customerID, http.MethodGet, path+"/balance?as_of=2024-01-02T12:00:00Z", nil)
+	assertGolden(t, "get_balance_as_of", status, body)
+
+	// Error responses share one shape, with details under meta when present
+	status, body = kit.Do(t, customerID, http.MethodGet, wallets+"not-a-uuid/balance", nil)
+	assertGolden(t, "error_invalid_wallet_id", status, body)
+
+	status, body = kit.Do(t, customerID, http.MethodGet, wallets+uuid.NewString()+"/balance", nil)
+	assertGolden(t, "error_wallet_not_found", status, body)
+
+	status, body = kit.Do(t, customerID, http.MethodGet, path+"/balance?as_of=yesterday", nil)
+	assertGolden(t, "error_invalid_as_of", status, body)
+
+	status, body = kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+		"type":     "CREDIT",
+		"amount":   10,
+		"currency": "INR",
+	})
+	assertGolden(t, "error_idempotency_key_required", status, body)
+
+	status, body = kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+		"type":     "DEBIT",
+		"amount":   1000,
+		"currency": "INR",
+	}, idempotencyKey()...)
+	assertGolden(t, "error_insufficient_balance", status, body)
+}
+
+// assertGolden compares a response with its golden file for the current API
+// version, or rewrites the file when -update is set
+func assertGolden(t *testing.T, name string, status int, body []byte) {
+	t.Helper()
+
+	file := filepath.Join("testdata", "golden", api.Version, name+".json")
+
+	if *updateGolden {
+		masked := uuidPattern.ReplaceAll(body, []byte("00000000-0000-0000-0000-000000000000"))
+		data, err := json.MarshalIndent(goldenResponse{Status: status, Body: masked}, "", "  ")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(file, append(data, '\n'), 0o644))
+		return
+	}
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err, "missing golden response %s, run with -update to create it", file)
+
+	var golden goldenResponse
+	require.NoError(t, json.Unmarshal(data, &golden))
+
+	var want, got interface{}
+	require.NoError(t, json.Unmarshal(golden.Body, &want))
+	require.NoError(t, json.Unmarshal(body, &got), "%s: response is not JSON: %s", name, body)
+
+	require.Equal(t, golden.Status, status, "%s: HTTP status changed within API %s", name, api.Version)
+	require.Equal(t, shapeOf(want), shapeOf(got),
+		"%s: response shape changed within API %s; bump api.Version for breaking changes, "+
+			"or run with -update if the change is backward compatible", name, api.Version)
+}
+
+// shapeOf describes the structure of a decoded JSON value: object keys with
+// the shapes of their values, array element shapes and scalar JSON types
+func shapeOf(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fields := make([]string, len(keys))
+		for i, key := range keys {
+			fields[i] = fmt.Sprintf("%q:%s", key, shapeOf(v[key]))
+		}
+		return "{" + strings.Join(fields, ",") + "}"
+	case []interface{}:
+		if len(v) == 0 {
+			return "[]"
+		}
+		return "[" + shapeOf(v[0]) + "]"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/graphql"
+	"internal/testkit"
+)
+
+// graphqlResponse is the envelope of a GraphQL response
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// queryGraphQL runs a GraphQL query as the customer
+func queryGraphQL(t *testing.T, kit *testkit.Kit, customerID uuid.UUID, query string) graphqlResponse {
+	t.Helper()
+
+	status, body := kit.Do(t, customerID, http.MethodPost, "/graphql", map[string]interface{}{
+		"query": query,
+	})
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	var resp graphqlResponse
+	require.NoError(t, json.Unmarshal(body, &resp))
+	return resp
+}
+
+// TestGraphQLWallets tests wallets with recent transactions in one query and
+// that other customers' wallets are not visible
+func TestGraphQLWallets(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	first := kit.CreateWallet(t, customerID, "INR", 100)
+	kit.CreateWallet(t, customerID, "USD", 5)
+	other := kit.CreateWallet(t, uuid.New(), "INR", 10)
+
+	for _, amount := range []int{10, 20} {
+		status, body := kit.Do(t, customerID, http.MethodPost, "/api/v1/wallets/"+first.ID.String()+"/transactions", map[string]interface{}{
+			"type":     "DEBIT",
+			"amount":   amount,
+			"currency": "INR",
+		}, "Idempotency-Key", uuid.NewString())
+		require.Equal(t, http.StatusCreated, status, string(body))
+	}
+
+	resp := queryGraphQL(t, kit, customerID, `{
+		wallets {
+			id
+			currency
+			balance
+			recentTransactions(limit: 1) { type status amount }
+		}
+	}`)
+	require.Empty(t, resp.Errors)
+
+	var data struct {
+		Wallets []struct {
+			ID                 uuid.UUID `json:"id"`
+			Currency           string    `json:"currency"`
+			Balance            string    `json:"balance"`
+			RecentTransactions []struct {
+				Type   string `json:"type"`
+				Status string `json:"status"`
+				Amount string `json:"amount"`
+			} `json:"recentTransactions"`
+		} `json:"wallets"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	require.Len(t, data.Wallets, 2)
+
+	byCurrency := make(map[string]int)
+	for i, w := range data.Wallets {
+		byCurrency[w.Currency] = i
+	}
+	inr := data.Wallets[byCurrency["INR"]]
+	require.Equal(t, first.ID, inr.ID)
+	require.Equal(t, "70", inr.Balance)
+	require.Len(t, inr.RecentTransactions, 1)
+	require.Equal(t, "DEBIT", inr.RecentTransactions[0].Type)
+	require.Equal(t, "20", inr.RecentTransactions[0].Amount)
+	require.Empty(t, data.Wallets[byCurrency["USD"]].RecentTransactions)
+
+	resp = queryGraphQL(t, kit, customerID, `{ wallet(id: "`+other.ID.String()+`") { id } }`)
+	require.Empty(t, resp.Errors)
+	require.JSONEq(t, `{"wallet": null}`, string(resp.Data))
+
+	resp = queryGraphQL(t, kit, customerID, `{ wallets { recentTransactions(limit: 500) { id } } }`)
+	require.NotEmpty(t, resp.Errors)
+	require.Equal(t, "INVALID_REQUEST", resp.Errors[0].Extensions["code"])
+
+	// The testkit has no invoice service
+	resp = queryGraphQL(t, kit, customerID, `{ invoices { id } }`)
+	require.NotEmpty(t, resp.Errors)
+	require.Equal(t, "SERVICE_UNAVAILABLE", resp.Errors[0].Extensions["code"])
+}
+
+// TestGraphQLLoaderBatching tests that concurrent loads share one fetch and
+// repeated keys are served from the cache
+func TestGraphQLLoaderBatching(t *testing.T) {
+	var fetches int32
+	loader := graphql.NewLoader(50*time.Millisecond, func(ctx context.Context, keys []int) (map[int]int, error) {
+		atomic.AddInt32(&fetches, 1)
+		results := make(map[int]int, len(keys))
+		for _, key := range keys {
+			results[key] = key * 10
+		}
+		return results, nil
+	})
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(key int) {
+			defer wg.Done()
+			value, err := loader.Load(ctx, key)
+			require.NoError(t, err)
+			require.Equal(t, key*10, value)
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	value, err := loader.Load(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, 70, value)
+	require.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
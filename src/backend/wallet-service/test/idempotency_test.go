@@ -0,0 +1,191 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"    // v2.30.4
+	"github.com/gin-gonic/gin"            // v1.9.1
+	"github.com/go-redis/redis/v8"        // v8.11.5
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/api"
+	"internal/apierror"
+	"internal/health"
+)
+
+// idempotentRoute is a router posting to a counting handler behind the
+// idempotency middleware. The handler answers with the status in the
+// X-Status header, 201 by default.
+type idempotentRoute struct {
+	router *gin.Engine
+	calls  int
+}
+
+func newIdempotentRoute(t *testing.T, server *miniredis.Miniredis, policy health.FailurePolicy) *idempotentRoute {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	route := &idempotentRoute{router: gin.New()}
+	route.router.Use(func(c *gin.Context) {
+		c.Set("customer_id", c.GetHeader("X-Customer-ID"))
+		c.Next()
+	}, api.IdempotencyMiddleware(client, time.Hour, policy))
+	route.router.POST("/api/v1/wallets/:id/transactions", func(c *gin.Context) {
+		route.calls++
+		status := http.StatusCreated
+		if c.GetHeader("X-Status") == "500" {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"call": route.calls})
+	})
+	return route
+}
+
+// post sends a request with an idempotency key on behalf of a customer
+func (r *idempotentRoute) post(customerID, key, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/w1/transactions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Customer-ID", customerID)
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	r.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// errorCode returns the API error code of a response
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) apierror.Code {
+	t.Helper()
+
+	var resp api.Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), rec.Body.String())
+	return apierror.Code(resp.Code)
+}
+
+// TestIdempotencyReplay tests that retries replay the stored response, that
+// keys are scoped to the customer and bound to the request they were first
+// used with, and that keys are released when the request fails on the server
+func TestIdempotencyReplay(t *testing.T) {
+	server := miniredis.RunT(t)
+	route := newIdempotentRoute(t, server, health.FailClosed)
+	body := `{"type":"CREDIT","amount":10}`
+
+	first := route.post("c1", "key-1", body)
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	retry := route.post("c1", "key-1", body)
+	require.Equal(t, http.StatusCreated, retry.Code)
+	require.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	require.JSONEq(t, first.Body.String(), retry.Body.String())
+	require.Equal(t, 1, route.calls)
+
+	// Another customer's key of the same name is its own
+	require.Equal(t, http.StatusCreated, route.post("c2", "key-1", body).Code)
+	require.Equal(t, 2, route.calls)
+
+	// A key cannot be reused for a different request
+	conflict := route.post("c1", "key-1", `{"type":"CREDIT","amount":20}`)
+	require.Equal(t, http.StatusConflict, conflict.Code)
+	require.Equal(t, apierror.CodeIdempotencyConflict, errorCode(t, conflict))
+
+	// Requests without a key are not deduplicated
+	route.post("c1", "", body)
+	route.post("c1", "", body)
+	require.Equal(t, 4, route.calls)
+
+	// A server error releases the key so the retry runs again
+	failed := route.post("c1", "key-2", body, "X-Status", "500")
+	require.Equal(t, http.StatusInternalServerError, failed.Code)
+	require.False(t, server.Exists("idempotency:c1:key-2"))
+	retry = route.post("c1", "key-2", body)
+	require.Equal(t, http.StatusCreated, retry.Code)
+	require.Empty(t, retry.Header().Get("Idempotent-Replayed"))
+	require.Equal(t, 6, route.calls)
+	require.Equal(t, time.Hour, server.TTL("idempotency:c1:key-2"))
+}
+
+// TestIdempotencyInProgress tests that a retry arriving while the first
+// request is still being processed is refused rather than run twice
+func TestIdempotencyInProgress(t *testing.T) {
+	server := miniredis.RunT(t)
+	route := newIdempotentRoute(t, server, health.FailClosed)
+	body := `{"type":"CREDIT","amount":10}`
+
+	// Take the reservation the first request would hold while it runs
+	require.Equal(t, http.StatusCreated, route.post("c1", "key-1", body).Code)
+	stored, err := server.Get("idempotency:c1:key-1")
+	require.NoError(t, err)
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(stored), &record))
+	pending, err := json.Marshal(map[string]interface{}{"request_hash": record["request_hash"], "status": 0})
+	require.NoError(t, err)
+	require.NoError(t, server.Set("idempotency:c1:key-1", string(pending)))
+
+	rec := route.post("c1", "key-1", body)
+	require.Equal(t, http.StatusConflict, rec.Code)
+	require.Equal(t, apierror.CodeConcurrentModification, errorCode(t, rec))
+	require.Equal(t, 1, route.calls)
+}
+
+// TestIdempotencyStoreUnavailable tests the failure policies when Redis is
+// down: fail-closed rejects keyed requests with 503 and fail-open processes
+// them without duplicate protection
+func TestIdempotencyStoreUnavailable(t *testing.T) {
+	body := `{"type":"CREDIT","amount":10}`
+
+	t.Run("fail-closed", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		route := newIdempotentRoute(t, server, health.FailClosed)
+		server.SetError("LOADING Redis is loading the dataset in memory")
+
+		rec := route.post("c1", "key-1", body)
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		require.Equal(t, apierror.CodeServiceUnavailable, errorCode(t, rec))
+		require.Zero(t, route.calls)
+
+		// Requests without a key do not need the store
+		require.Equal(t, http.StatusCreated, route.post("c1", "", body).Code)
+		require.Equal(t, 1, route.calls)
+	})
+
+	t.Run("fail-open", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		route := newIdempotentRoute(t, server, health.FailOpen)
+		server.SetError("LOADING Redis is loading the dataset in memory")
+
+		require.Equal(t, http.StatusCreated, route.post("c1", "key-1", body).Code)
+		require.Equal(t, http.StatusCreated, route.post("c1", "key-1", body).Code)
+		require.Equal(t, 2, route.calls)
+
+		// Once Redis is back retries are deduplicated again
+		server.SetError("")
+		require.Equal(t, http.StatusCreated, route.post("c1", "key-2", body).Code)
+		rec := route.post("c1", "key-2", body)
+		require.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+		require.Equal(t, 3, route.calls)
+	})
+
+	t.Run("fail-closed on replay", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		route := newIdempotentRoute(t, server, health.FailClosed)
+		require.Equal(t, http.StatusCreated, route.post("c1", "key-1", body).Code)
+
+		// The key is taken but its record cannot be read
+		require.NoError(t, server.Set("idempotency:c1:key-1", "not json"))
+		rec := route.post("c1", "key-1", body)
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.Equal(t, 1, route.calls)
+	})
+}
@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/inbound"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+	"internal/webhook"
+)
+
+// TestInboundWebhooks tests that inbound webhooks are verified, recorded
+// once per event, processed in order within their ordering key and
+// dead-lettered once out of attempts until replayed
+func TestInboundWebhooks(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	providers, err := inbound.NewRegistry(&inbound.HMACProvider{
+		ProviderName:     "gateway",
+		Secret:           "whsec_test",
+		SignatureHeader:  "X-Signature",
+		SignaturePrefix:  "v1=",
+		TimestampHeader:  "X-Timestamp",
+		Tolerance:        5 * time.Minute,
+		EventIDField:     "id",
+		EventTypeField:   "type",
+		OrderingKeyField: "data.payment_id",
+	})
+	require.NoError(t, err)
+	webhooks, err := service.NewInboundWebhookService(kit.Store, providers, 3, &alertLogger{})
+	require.NoError(t, err)
+
+	var handled []string
+	failing := map[string]bool{}
+	require.NoError(t, webhooks.Handle("gateway", "payment.updated", func(ctx context.Context, webhook *models.InboundWebhook) error {
+		if failing[webhook.EventID] {
+			return errors.New("ledger unavailable")
+		}
+		handled = append(handled, webhook.EventID)
+		return nil
+	}))
+	require.ErrorIs(t, webhooks.Handle("acquirer", "payment.updated", func(context.Context, *models.InboundWebhook) error { return nil }), inbound.ErrUnknownProvider)
+
+	signed := func(body string, at time.Time) http.Header {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		header := http.Header{}
+		header.Set("X-Timestamp", timestamp)
+		header.Set("X-Signature", "v1="+webhook.Sign("whsec_test", timestamp, []byte(body)))
+		return header
+	}
+	receive := func(id, payment string) (bool, error) {
+		body := `{"id":"` + id + `","type":"payment.updated","data":{"payment_id":"` + payment + `"}}`
+		_, recorded, err := webhooks.Receive(ctx, "gateway", signed(body, time.Now()), []byte(body))
+		return recorded, err
+	}
+
+	// Unknown providers, forged or stale signatures and unidentified
+	// events are refused
+	body := []byte(`{"id":"evt_0","type":"payment.updated"}`)
+	_, _, err = webhooks.Receive(ctx, "acquirer", signed(string(body), time.Now()), body)
+	require.ErrorIs(t, err, inbound.ErrUnknownProvider)
+	forged := signed(string(body), time.Now())
+	forged.Set("X-Signature", "v1="+webhook.Sign("guessed", forged.Get("X-Timestamp"), body))
+	_, _, err = webhooks.Receive(ctx, "gateway", forged, body)
+	require.ErrorIs(t, err, inbound.ErrInvalidSignature)
+	_, _, err = webhooks.Receive(ctx, "gateway", signed(string(body), time.Now().Add(-time.Hour)), body)
+	require.ErrorIs(t, err, inbound.ErrInvalidSignature)
+	untyped := []byte(`{"id":"evt_0"}`)
+	_, _, err = webhooks.Receive(ctx, "gateway", signed(string(untyped), time.Now()), untyped)
+	require.ErrorIs(t, err, inbound.ErrInvalidEvent)
+
+	// Redelivered events are acknowledged without being recorded again
+	recorded, err := receive("evt_1", "pay_a")
+	require.NoError(t, err)
+	require.True(t, recorded)
+	recorded, err = receive("evt_1", "pay_a")
+	require.NoError(t, err)
+	require.False(t, recorded)
+	for _, event := range [][2]string{{"evt_2", "pay_a"}, {"evt_3", "pay_b"}} {
+		_, err := receive(event[0], event[1])
+		require.NoError(t, err)
+	}
+
+	// A failing event holds back the later events of its payment only
+	failing["evt_1"] = true
+	now := time.Now()
+	processed, err := webhooks.ProcessReceived(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, processed)
+	require.Equal(t, []string{"evt_3"}, handled)
+
+	// Retries wait for their backoff, and the event is dead-lettered once
+	// out of attempts, letting the next event of its payment through
+	processed, err = webhooks.ProcessReceived(ctx, now)
+	require.NoError(t, err)
+	require.Zero(t, processed)
+	now = now.Add(time.Minute)
+	_, err = webhooks.ProcessReceived(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, []string{"evt_3"}, handled)
+	now = now.Add(2 * time.Minute)
+	processed, err = webhooks.ProcessReceived(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, processed)
+	require.Equal(t, []string{"evt_3", "evt_2"}, handled)
+
+	letters, err := webhooks.ListDeadLetters(ctx, "gateway", 0)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	require.Equal(t, "evt_1", letters[0].EventID)
+	require.Equal(t, 3, letters[0].Attempts)
+	require.Equal(t, "ledger unavailable", letters[0].Error)
+	require.JSONEq(t, `{"id":"evt_1","type":"payment.updated","data":{"payment_id":"pay_a"}}`, string(letters[0].Payload))
+
+	// A replayed dead letter is processed again, once
+	failing["evt_1"] = false
+	replayed, err := webhooks.ReplayDeadLetter(ctx, letters[0].ID, "ops")
+	require.NoError(t, err)
+	require.NotNil(t, replayed.ReplayedAt)
+	_, err = webhooks.ReplayDeadLetter(ctx, letters[0].ID, "ops")
+	require.ErrorIs(t, err, service.ErrDeadLetterReplayed)
+	processed, err = webhooks.ProcessReceived(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, processed)
+	require.Equal(t, []string{"evt_3", "evt_2", "evt_1"}, handled)
+}
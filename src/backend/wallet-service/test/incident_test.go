@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestIncidentPausesTransactions tests that an incident refuses the
+// transaction types it pauses unless their source is allowed, lets the
+// others through, and resumes on request or at its scheduled time
+func TestIncidentPausesTransactions(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+	audit := &auditLog{}
+
+	incidents, err := service.NewIncidentService(kit.Store, audit, 72*time.Hour, &alertLogger{})
+	require.NoError(t, err)
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	wallets, err = service.NewIncidentWalletService(wallets, incidents)
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "INR"}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+	post := func(txType models.TransactionType, source string) error {
+		tx := &models.Transaction{
+			ID:       uuid.New(),
+			WalletID: wallet.ID,
+			Type:     txType,
+			Status:   models.TransactionStatusCompleted,
+			Amount:   10,
+			Currency: "INR",
+		}
+		if source != "" {
+			tx.Metadata = map[string]string{models.TransactionSourceKey: source}
+		}
+		_, err := wallets.ProcessTransaction(ctx, tx)
+		return err
+	}
+	require.NoError(t, post(models.TransactionTypeCredit, ""))
+
+	_, err = incidents.Get(ctx)
+	require.ErrorIs(t, err, service.ErrIncidentNotFound)
+
+	// Incidents need a reason, something to pause and a near resume
+	_, err = incidents.Pause(ctx, service.IncidentInput{PauseDebits: true}, "ops")
+	require.ErrorIs(t, err, service.ErrInvalidIncident)
+	_, err = incidents.Pause(ctx, service.IncidentInput{Reason: "Rating bug"}, "ops")
+	require.ErrorIs(t, err, service.ErrInvalidIncident)
+	tooLate := time.Now().Add(73 * time.Hour)
+	_, err = incidents.Pause(ctx, service.IncidentInput{Reason: "Rating bug", PauseDebits: true, ResumeAt: &tooLate}, "ops")
+	require.ErrorIs(t, err, service.ErrInvalidIncident)
+	_, err = incidents.Pause(ctx, service.IncidentInput{Reason: "Rating bug", PauseDebits: true, AllowedSources: []string{" "}}, "ops")
+	require.ErrorIs(t, err, service.ErrInvalidIncident)
+
+	resumeAt := time.Now().Add(time.Hour)
+	incident, err := incidents.Pause(ctx, service.IncidentInput{
+		Reason:         "Suspected rating bug",
+		PauseDebits:    true,
+		AllowedSources: []string{" manual ", "manual"},
+		ResumeAt:       &resumeAt,
+	}, "ops")
+	require.NoError(t, err)
+	require.Equal(t, []string{"manual"}, incident.AllowedSources)
+	require.Equal(t, "ops", incident.StartedBy)
+
+	// Debits are paused unless allowlisted; top-ups and refunds go through
+	require.ErrorIs(t, post(models.TransactionTypeDebit, ""), service.ErrTransactionsPaused)
+	require.ErrorIs(t, post(models.TransactionTypeDebit, "recurring"), service.ErrTransactionsPaused)
+	require.NoError(t, post(models.TransactionTypeDebit, "manual"))
+	require.NoError(t, post(models.TransactionTypeCredit, ""))
+	require.NoError(t, post(models.TransactionTypeRefund, ""))
+
+	// Changing the active incident keeps who started it
+	updated, err := incidents.Pause(ctx, service.IncidentInput{
+		Reason:       "Suspected rating bug, refunds too",
+		PauseDebits:  true,
+		PauseRefunds: true,
+		ResumeAt:     &resumeAt,
+	}, "lead")
+	require.NoError(t, err)
+	require.Equal(t, incident.ID, updated.ID)
+	require.Equal(t, "ops", updated.StartedBy)
+	require.Equal(t, "lead", updated.UpdatedBy)
+	require.ErrorIs(t, post(models.TransactionTypeRefund, ""), service.ErrTransactionsPaused)
+	require.ErrorIs(t, post(models.TransactionTypeDebit, "manual"), service.ErrTransactionsPaused)
+
+	balance, _, err := wallets.GetWalletBalance(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.True(t, decimal.NewFromInt(20).Equal(balance), balance.String())
+
+	// A replica that has not refreshed since keeps pausing until it does
+	other, err := service.NewIncidentService(kit.Store, audit, 72*time.Hour, &alertLogger{})
+	require.NoError(t, err)
+	require.NoError(t, other.Check(&models.Transaction{Type: models.TransactionTypeDebit}))
+	require.NoError(t, other.Refresh(ctx))
+	require.ErrorIs(t, other.Check(&models.Transaction{Type: models.TransactionTypeDebit}), service.ErrTransactionsPaused)
+
+	// The incident resumes at its scheduled time, once only
+	past := time.Now().Add(-time.Minute)
+	updated.ResumeAt = &past
+	require.NoError(t, kit.Store.UpdateIncident(ctx, updated))
+	require.NoError(t, incidents.Refresh(ctx))
+	require.NoError(t, other.Refresh(ctx))
+	require.NoError(t, post(models.TransactionTypeDebit, ""))
+	require.NoError(t, other.Check(&models.Transaction{Type: models.TransactionTypeDebit}))
+	_, err = incidents.Get(ctx)
+	require.ErrorIs(t, err, service.ErrIncidentNotFound)
+
+	// Operators resume incidents with a reason
+	_, err = incidents.Pause(ctx, service.IncidentInput{Reason: "Rating bug again", PauseDebits: true}, "ops")
+	require.NoError(t, err)
+	require.ErrorIs(t, post(models.TransactionTypeDebit, ""), service.ErrTransactionsPaused)
+	_, err = incidents.Resume(ctx, "ops", "")
+	require.ErrorIs(t, err, service.ErrInvalidIncident)
+	resumed, err := incidents.Resume(ctx, "ops", "Rating fixed")
+	require.NoError(t, err)
+	require.Equal(t, "ops", resumed.ResumedBy)
+	require.NoError(t, post(models.TransactionTypeDebit, ""))
+	_, err = incidents.Resume(ctx, "ops", "Rating fixed")
+	require.ErrorIs(t, err, service.ErrIncidentNotFound)
+
+	// Every change was audited
+	var actions []string
+	for _, action := range audit.actions {
+		require.Equal(t, models.OperatorActionSucceeded, action.Status)
+		actions = append(actions, action.Action+" by "+action.Actor)
+	}
+	require.Equal(t, []string{
+		"incident.pause by ops",
+		"incident.pause by lead",
+		"incident.resume by schedule",
+		"incident.pause by ops",
+		"incident.resume by ops",
+	}, actions)
+}
@@ -0,0 +1,279 @@
+//go:build integration
+
+// The integration tests run the repositories and the idempotency middleware
+// against PostgreSQL and Redis in containers, which the mock-backed tests
+// cannot cover: the SQL itself, the shared migrations, row locking and
+// serialization failures. They need Docker and run with
+//
+//	go test -tags integration -run Integration ./test/
+package test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"                     // v8.11.5
+	_ "github.com/jackc/pgx/v5/stdlib"                 // v5.3.1
+	"github.com/stretchr/testify/require"              // v1.8.4
+	"github.com/testcontainers/testcontainers-go"      // v0.14.0
+	"github.com/testcontainers/testcontainers-go/wait" // v0.14.0
+)
+
+// templateDatabase is migrated once and copied for every test
+const templateDatabase = "wallet_template"
+
+// integrationEnv is the PostgreSQL and Redis containers shared by the
+// integration tests of a run. They are removed by the testcontainers reaper
+// once the test binary exits.
+type integrationEnv struct {
+	postgres  testcontainers.Container
+	redis     testcontainers.Container
+	pgHost    string
+	pgPort    string
+	redisAddr string
+}
+
+var (
+	integrationOnce sync.Once
+	integration     *integrationEnv
+	integrationErr  error
+
+	// integrationDatabases numbers the databases created by the tests
+	integrationDatabases atomic.Int64
+)
+
+// integrationContainers starts the containers on first use, with a
+// template database carrying every up migration
+func integrationContainers(t testing.TB) *integrationEnv {
+	t.Helper()
+
+	integrationOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+		defer cancel()
+
+		integration, integrationErr = startIntegrationEnv(ctx)
+		if integrationErr != nil {
+			return
+		}
+		integrationErr = integration.createTemplate(ctx)
+	})
+	require.NoError(t, integrationErr, "integration tests need Docker")
+	return integration
+}
+
+// startIntegrationEnv starts the containers with the images of the backend
+// docker-compose setup
+func startIntegrationEnv(ctx context.Context) (*integrationEnv, error) {
+	env := &integrationEnv{}
+
+	var err error
+	env.postgres, err = testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "postgres:15-alpine",
+			ExposedPorts: []string{"5432/tcp"},
+			Env: map[string]string{
+				"POSTGRES_USER":     "postgres",
+				"POSTGRES_PASSWORD": "postgres",
+				"POSTGRES_DB":       "postgres",
+			},
+			// The server logs readiness once for the init scripts and once
+			// when it accepts connections for good
+			WaitingFor: wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(time.Minute),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start postgres: %w", err)
+	}
+	env.pgHost, err = env.postgres.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pgPort, err := env.postgres.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		return nil, err
+	}
+	env.pgPort = pgPort.Port()
+
+	env.redis, err = testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "redis:7.0-alpine",
+			ExposedPorts: []string{"6379/tcp"},
+			WaitingFor:   wait.ForLog("Ready to accept connections").WithStartupTimeout(time.Minute),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start redis: %w", err)
+	}
+	redisHost, err := env.redis.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+	redisPort, err := env.redis.MappedPort(ctx, "6379/tcp")
+	if err != nil {
+		return nil, err
+	}
+	env.redisAddr = redisHost + ":" + redisPort.Port()
+
+	return env, nil
+}
+
+// dsn returns the connection string of a database of the container
+func (e *integrationEnv) dsn(database string) string {
+	return fmt.Sprintf("postgres://postgres:postgres@%s:%s/%s?sslmode=disable", e.pgHost, e.pgPort, database)
+}
+
+// createTemplate creates the template database and migrates it up. Its
+// connections are closed, as PostgreSQL copies only unused templates.
+func (e *integrationEnv) createTemplate(ctx context.Context) error {
+	if err := e.createDatabase(ctx, templateDatabase, ""); err != nil {
+		return err
+	}
+
+	db, err := sql.Open("pgx", e.dsn(templateDatabase))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return applyMigrations(ctx, db, "up")
+}
+
+// createDatabase creates a database, copying template unless it is empty
+func (e *integrationEnv) createDatabase(ctx context.Context, name, template string) error {
+	admin, err := sql.Open("pgx", e.dsn("postgres"))
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	query := "CREATE DATABASE " + name
+	if template != "" {
+		query += " TEMPLATE " + template
+	}
+	if _, err := admin.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	return nil
+}
+
+// newDatabase creates a database of its own for a test, migrated up unless
+// empty is set, and returns its connection string
+func (e *integrationEnv) newDatabase(t testing.TB, empty bool) string {
+	t.Helper()
+
+	template := templateDatabase
+	if empty {
+		template = ""
+	}
+	name := fmt.Sprintf("wallet_test_%d", integrationDatabases.Add(1))
+	require.NoError(t, e.createDatabase(context.Background(), name, template))
+	return e.dsn(name)
+}
+
+// connectIntegrationDatabase sizes the pool of a test database for
+// concurrent callers and checks that it is reachable
+func connectIntegrationDatabase(t testing.TB, db *sql.DB) *sql.DB {
+	t.Helper()
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(32)
+	db.SetMaxIdleConns(32)
+	require.NoError(t, db.PingContext(context.Background()))
+	return db
+}
+
+// newIntegrationDatabase returns a migrated database of the test's own
+func newIntegrationDatabase(t testing.TB) *sql.DB {
+	db, err := sql.Open("pgx", integrationContainers(t).newDatabase(t, false))
+	require.NoError(t, err)
+	return connectIntegrationDatabase(t, db)
+}
+
+// newIntegrationRedis returns a client of the shared Redis. Tests keep
+// apart by keying their data with fresh IDs.
+func newIntegrationRedis(t testing.TB) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{Addr: integrationContainers(t).redisAddr})
+	t.Cleanup(func() { rdb.Close() })
+	require.NoError(t, rdb.Ping(context.Background()).Err())
+	return rdb
+}
+
+// applyMigrations runs the migrations of a direction. Each file is sent as
+// one simple-protocol query, which may hold several statements.
+func applyMigrations(ctx context.Context, db *sql.DB, direction string) error {
+	files, err := migrationFiles(direction)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		script, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, string(script)); err != nil {
+			return fmt.Errorf("migration %s failed: %w", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// publicTables returns the tables of the public schema
+func publicTables(t *testing.T, db *sql.DB) []string {
+	rows, err := db.Query(`SELECT tablename FROM pg_tables WHERE schemaname = 'public' ORDER BY tablename`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		require.NoError(t, rows.Scan(&table))
+		tables = append(tables, table)
+	}
+	require.NoError(t, rows.Err())
+	return tables
+}
+
+// TestIntegrationMigrations tests that every migration has a down
+// migration, and that the shared migrations apply up, undo completely and
+// apply again
+func TestIntegrationMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("pgx", integrationContainers(t).newDatabase(t, true))
+	require.NoError(t, err)
+	connectIntegrationDatabase(t, db)
+
+	up, err := migrationFiles("up")
+	require.NoError(t, err)
+	down, err := migrationFiles("down")
+	require.NoError(t, err)
+	require.Len(t, down, len(up))
+	for i, file := range up {
+		require.Equal(t,
+			strings.TrimSuffix(filepath.Base(file), ".up.sql"),
+			strings.TrimSuffix(filepath.Base(down[len(down)-1-i]), ".down.sql"))
+	}
+
+	require.NoError(t, applyMigrations(ctx, db, "up"))
+	migrated := publicTables(t, db)
+	require.Contains(t, migrated, "wallets")
+	require.Contains(t, migrated, "wallet_transactions")
+
+	require.NoError(t, applyMigrations(ctx, db, "down"))
+	require.Empty(t, publicTables(t, db))
+
+	// Down migrations leave nothing behind that would stop a fresh run,
+	// such as types or functions
+	require.NoError(t, applyMigrations(ctx, db, "up"))
+	require.Equal(t, migrated, publicTables(t, db))
+}
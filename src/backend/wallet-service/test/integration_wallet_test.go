@@ -0,0 +1,321 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"            // v1.9.1
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/jackc/pgx/v5"             // v5.3.1
+	"github.com/jackc/pgx/v5/stdlib"      // v5.3.1
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/api"
+	"internal/eventbus"
+	"internal/health"
+	"internal/loadtest"
+	"internal/logging"
+	"internal/models"
+	"internal/repository"
+	"internal/service"
+	"internal/usage"
+)
+
+// newIntegrationWallets returns the wallet repository of a migrated
+// database and the database itself
+func newIntegrationWallets(t *testing.T) (*sql.DB, repository.WalletRepository) {
+	db := newIntegrationDatabase(t)
+	repo, err := repository.NewWalletRepository(db)
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return db, repo
+}
+
+// TestIntegrationWalletRepository tests the SQL of wallet creation and of
+// balance updates in a unit of work, including updates from a wallet read
+// outside the unit of work, as the former UpdateBalance did
+func TestIntegrationWalletRepository(t *testing.T) {
+	ctx := context.Background()
+	db, repo := newIntegrationWallets(t)
+
+	customers, err := loadtest.SeedCustomers(ctx, db, 1)
+	require.NoError(t, err)
+
+	// Wallets belong to existing customers
+	err = repo.CreateWallet(ctx, &models.Wallet{CustomerID: uuid.New(), Currency: "USD"})
+	require.ErrorIs(t, err, repository.ErrCustomerNotFound)
+
+	wallet := &models.Wallet{CustomerID: customers[0], Currency: "USD", LowBalanceThreshold: 10}
+	require.NoError(t, repo.CreateWallet(ctx, wallet))
+	stored, err := repo.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Zero(t, stored.Balance)
+	require.EqualValues(t, 1, stored.Version)
+	require.Equal(t, 10.0, stored.LowBalanceThreshold)
+
+	// A credit moves the balance and version and is recorded with its type
+	// and status
+	credit := loadtest.Transaction(wallet.ID, models.TransactionTypeCredit, 100, "USD")
+	err = repo.InTx(ctx, func(uow repository.WalletTx) error {
+		locked, err := uow.GetWalletForUpdate(ctx, wallet.ID)
+		if err != nil {
+			return err
+		}
+		if err := uow.ApplyTransaction(ctx, locked, credit); err != nil {
+			return err
+		}
+		return uow.InsertTransaction(ctx, credit)
+	})
+	require.NoError(t, err)
+
+	stored, err = repo.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, 100.0, stored.Balance)
+	require.EqualValues(t, 2, stored.Version)
+
+	recorded, err := repo.GetTransactionByID(ctx, credit.ID)
+	require.NoError(t, err)
+	require.Equal(t, wallet.ID, recorded.WalletID)
+	require.Equal(t, models.TransactionTypeCredit, recorded.Type)
+	require.Equal(t, models.TransactionStatusCompleted, recorded.Status)
+	require.Equal(t, 100.0, recorded.Amount)
+
+	// A wallet read before another update committed is stale: its balance
+	// update is refused rather than overwriting the newer balance
+	stale := *stored
+	stale.Version--
+	err = repo.InTx(ctx, func(uow repository.WalletTx) error {
+		return uow.ApplyTransaction(ctx, &stale, loadtest.Transaction(wallet.ID, models.TransactionTypeDebit, 10, "USD"))
+	})
+	require.ErrorIs(t, err, repository.ErrOptimisticLock)
+
+	// A failing unit of work leaves the wallet as it was
+	errAbort := errors.New("abort")
+	err = repo.InTx(ctx, func(uow repository.WalletTx) error {
+		locked, err := uow.GetWalletForUpdate(ctx, wallet.ID)
+		if err != nil {
+			return err
+		}
+		if err := uow.ApplyTransaction(ctx, locked, loadtest.Transaction(wallet.ID, models.TransactionTypeDebit, 30, "USD")); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	require.ErrorIs(t, err, errAbort)
+
+	stored, err = repo.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, 100.0, stored.Balance)
+	require.EqualValues(t, 2, stored.Version)
+
+	transactions, err := repo.GetTransactions(ctx, wallet.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, transactions, 1)
+}
+
+// TestIntegrationConcurrentBalanceUpdates tests that concurrent debits and
+// credits on one wallet are serialized: none is lost, the balance never
+// goes below zero, and it always matches the recorded transactions
+func TestIntegrationConcurrentBalanceUpdates(t *testing.T) {
+	ctx := context.Background()
+	db, repo := newIntegrationWallets(t)
+
+	wallets, err := service.NewWalletService(repo, supportedCurrencies(t), decimal.Zero, eventbus.New(), logging.NewNop())
+	require.NoError(t, err)
+	customers, err := loadtest.SeedCustomers(ctx, db, 1)
+	require.NoError(t, err)
+	walletIDs, err := loadtest.CreateWallets(ctx, repo, wallets, customers, "USD", 20)
+	require.NoError(t, err)
+
+	// Debits only, ten times more than the balance covers
+	debits, err := loadtest.Run(ctx, wallets, walletIDs, loadtest.Options{
+		Workers: 16, Transactions: 200, Amount: 1, Currency: "USD",
+	})
+	require.NoError(t, err)
+	require.Zero(t, debits.Failed, "%v", debits.FirstError)
+	require.Positive(t, debits.Succeeded)
+	require.LessOrEqual(t, debits.Succeeded, int64(20))
+	t.Logf("debits:\n%s", debits)
+
+	wallet, err := repo.GetWallet(ctx, walletIDs[0])
+	require.NoError(t, err)
+	require.Equal(t, float64(20-debits.Succeeded), wallet.Balance)
+
+	// Debits and credits mixed
+	mixed, err := loadtest.Run(ctx, wallets, walletIDs, loadtest.Options{
+		Workers: 16, Transactions: 200, CreditRatio: 0.5, Amount: 1, Currency: "USD", Seed: 2,
+	})
+	require.NoError(t, err)
+	require.Zero(t, mixed.Failed, "%v", mixed.FirstError)
+	t.Logf("mixed:\n%s", mixed)
+
+	// Every committed update recorded its transaction and bumped the
+	// version once
+	wallet, err = repo.GetWallet(ctx, walletIDs[0])
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, wallet.Balance, 0.0)
+
+	transactions, err := repo.GetTransactions(ctx, walletIDs[0], 1000, 0)
+	require.NoError(t, err)
+	// The opening credit and every successful transaction of both runs
+	require.Len(t, transactions, int(1+debits.Succeeded+mixed.Succeeded))
+
+	var balance float64
+	for _, tx := range transactions {
+		balance += tx.SignedAmount()
+	}
+	require.Equal(t, balance, wallet.Balance)
+	require.EqualValues(t, 1+len(transactions), wallet.Version)
+}
+
+// TestIntegrationBulkCreditRows tests that bulk credit rows are recorded in
+// one pgx batch through the metered connections of the server, whole or not
+// at all
+func TestIntegrationBulkCreditRows(t *testing.T) {
+	ctx := context.Background()
+
+	config, err := pgx.ParseConfig(integrationContainers(t).newDatabase(t, false))
+	require.NoError(t, err)
+	db := connectIntegrationDatabase(t, sql.OpenDB(usage.MeteredConnector(stdlib.GetConnector(*config), nil)))
+
+	repo, err := repository.NewBulkCreditRepository(db)
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+
+	credit := &models.BulkCredit{
+		ID:          uuid.New(),
+		Amount:      decimal.NewFromInt(5),
+		Currency:    "USD",
+		Description: "Goodwill credit",
+		TotalRows:   3,
+		CreatedBy:   "ops@example.com",
+		CreatedAt:   time.Now().UTC(),
+	}
+	require.NoError(t, repo.CreateBulkCredit(ctx, credit, []*models.BulkCreditRow{
+		{Index: 0, WalletID: uuid.NewString()},
+		{Index: 1, WalletID: "not-a-wallet"},
+		{Index: 2, WalletID: uuid.NewString()},
+	}))
+
+	txID := uuid.New()
+	require.NoError(t, repo.RecordBulkCreditRows(ctx, credit.ID, []*models.BulkCreditRow{
+		{Index: 0, Status: models.BulkCreditRowPosted, TransactionID: &txID},
+		{Index: 1, Status: models.BulkCreditRowFailed, Error: "invalid wallet ID"},
+	}))
+
+	rows, err := repo.ListBulkCreditRows(ctx, credit.ID)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	require.Equal(t, models.BulkCreditRowPosted, rows[0].Status)
+	require.Equal(t, &txID, rows[0].TransactionID)
+	require.Equal(t, models.BulkCreditRowFailed, rows[1].Status)
+	require.Equal(t, "invalid wallet ID", rows[1].Error)
+	require.Equal(t, models.BulkCreditRowPending, rows[2].Status)
+	require.Empty(t, rows[2].Error)
+
+	// A posted row needs its transaction, so the batch fails and the row
+	// before it is not recorded either
+	err = repo.RecordBulkCreditRows(ctx, credit.ID, []*models.BulkCreditRow{
+		{Index: 2, Status: models.BulkCreditRowPosted, TransactionID: &txID},
+		{Index: 1, Status: models.BulkCreditRowPosted},
+	})
+	require.Error(t, err)
+
+	rows, err = repo.ListBulkCreditRows(ctx, credit.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.BulkCreditRowPending, rows[2].Status)
+	require.Equal(t, models.BulkCreditRowFailed, rows[1].Status)
+}
+
+// TestIntegrationIdempotency tests that the idempotency middleware replays
+// retries from Redis, refuses reused and concurrent keys, and releases keys
+// on server errors
+func TestIntegrationIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	rdb := newIntegrationRedis(t)
+	customerID := uuid.NewString()
+
+	var charges, flaky atomic.Int64
+	release := make(chan struct{})
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("customer_id", customerID) })
+	router.Use(api.IdempotencyMiddleware(rdb, time.Minute, health.FailClosed))
+	router.POST("/charges", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"charge": charges.Add(1)})
+	})
+	router.POST("/slow", func(c *gin.Context) {
+		<-release
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+	router.POST("/flaky", func(c *gin.Context) {
+		if flaky.Add(1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	post := func(path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	errorCode := func(rec *httptest.ResponseRecorder) string {
+		var resp api.Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp.Code
+	}
+
+	// A retry replays the stored response without charging again
+	first := post("/charges", "charge-1", `{"amount":10}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	retry := post("/charges", "charge-1", `{"amount":10}`)
+	require.Equal(t, http.StatusCreated, retry.Code)
+	require.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	require.JSONEq(t, first.Body.String(), retry.Body.String())
+	require.EqualValues(t, 1, charges.Load())
+
+	// The response is kept per customer for the TTL
+	ttl, err := rdb.TTL(ctx, "idempotency:"+customerID+":charge-1").Result()
+	require.NoError(t, err)
+	require.Positive(t, ttl)
+	require.LessOrEqual(t, ttl, time.Minute)
+
+	// The key may not be reused for another request
+	reused := post("/charges", "charge-1", `{"amount":20}`)
+	require.Equal(t, http.StatusConflict, reused.Code)
+	require.Equal(t, "IDEMPOTENCY_CONFLICT", errorCode(reused))
+	require.EqualValues(t, 1, charges.Load())
+
+	// A retry while the request still runs is refused
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post("/slow", "slow-1", `{}`) }()
+	require.Eventually(t, func() bool {
+		return rdb.Exists(ctx, "idempotency:"+customerID+":slow-1").Val() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	concurrent := post("/slow", "slow-1", `{}`)
+	require.Equal(t, http.StatusConflict, concurrent.Code)
+	require.Equal(t, "CONCURRENT_MODIFICATION", errorCode(concurrent))
+	close(release)
+	require.Equal(t, http.StatusCreated, (<-done).Code)
+
+	// A server error releases the key so the client can retry
+	require.Equal(t, http.StatusInternalServerError, post("/flaky", "flaky-1", `{}`).Code)
+	require.Equal(t, http.StatusCreated, post("/flaky", "flaky-1", `{}`).Code)
+	require.EqualValues(t, 2, flaky.Load())
+}
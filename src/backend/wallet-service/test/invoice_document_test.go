@@ -0,0 +1,202 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/events"
+	"internal/invoicepdf"
+	"internal/models"
+	"internal/notify"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestInvoiceDocuments tests that invoices closed by the billing cycle are
+// rendered once, stored, and emailed with their PDF, and that re-sends
+// deliver the stored copy again
+func TestInvoiceDocuments(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+
+	_, err := invoicepdf.NewRenderer(invoicepdf.Branding{}, invoicepdf.Templates{})
+	require.Error(t, err)
+	_, err = invoicepdf.NewRenderer(invoicepdf.Branding{CompanyName: "Acme", AccentColor: "blue"}, invoicepdf.Templates{})
+	require.Error(t, err)
+	_, err = invoicepdf.NewRenderer(invoicepdf.Branding{CompanyName: "Acme"}, invoicepdf.Templates{Footer: "{{.DueDate"})
+	require.ErrorIs(t, err, invoicepdf.ErrInvalidTemplate)
+
+	renderer, err := invoicepdf.NewRenderer(invoicepdf.Branding{
+		CompanyName: "Acme Billing",
+		Address:     []string{"12 MG Road", "Bengaluru 560001"},
+		TaxID:       "29ABCDE1234F1Z5",
+	}, invoicepdf.Templates{Footer: "Pay {{money .Currency .TotalAmount}} to {{.Company}} by {{date .DueDate}}"})
+	require.NoError(t, err)
+
+	templates, err := notify.NewTemplates(nil)
+	require.NoError(t, err)
+	email, slack := &recordingSender{}, &recordingSender{}
+	bus := eventbus.New()
+	var issued []*models.InvoiceNotice
+	require.NoError(t, bus.Subscribe("invoices", eventbus.Typed(func(ctx context.Context, e eventbus.InvoiceIssued) error {
+		issued = append(issued, e.Notice)
+		return nil
+	})))
+	channels, err := service.NewNotificationChannelService(kit.Store, map[models.NotificationChannelKind]notify.Sender{
+		models.NotificationChannelEmail: email,
+		models.NotificationChannelSlack: slack,
+	}, templates, bus, &alertLogger{})
+	require.NoError(t, err)
+
+	receivables, err := service.NewInvoiceReceivableService(kit.Store, kit.Store, supportedCurrencies(t), models.PaymentTermsNet30,
+		service.LateFeePolicy{}, &alertLogger{})
+	require.NoError(t, err)
+	invoices, err := service.NewInvoiceDocumentService(kit.Store, renderer, channels, receivables, "https://billing.example.com/", &alertLogger{})
+	require.NoError(t, err)
+	require.NoError(t, channels.RegisterAttachments(eventbus.TypeInvoiceIssued, invoices.Attachments))
+	require.Error(t, channels.RegisterAttachments(eventbus.TypeInvoiceIssued, invoices.Attachments))
+	require.Error(t, channels.RegisterAttachments(eventbus.TypeWalletCreated, invoices.Attachments))
+
+	customerID := uuid.New()
+	for _, input := range []service.NotificationChannelInput{
+		{Kind: models.NotificationChannelEmail, Destination: "billing@example.com", Enabled: true},
+		{Kind: models.NotificationChannelSlack, Destination: "https://hooks.slack.com/services/T000/B000/XXXX", Enabled: true},
+	} {
+		_, err := channels.CreateChannel(ctx, customerID, input)
+		require.NoError(t, err)
+	}
+
+	invoice := func(number string) *models.InvoiceDocument {
+		return &models.InvoiceDocument{
+			InvoiceID:     uuid.New(),
+			CustomerID:    customerID,
+			InvoiceNumber: number,
+			Currency:      "INR",
+			IssueDate:     time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC),
+			DueDate:       time.Date(2026, 11, 15, 0, 0, 0, 0, time.UTC),
+			BillTo:        []string{"Globex Pvt Ltd", "4 Park Street, Kolkata"},
+			LineItems: []models.InvoiceLineItem{
+				{Description: "OTP verifications (SMS)", Quantity: decimal.NewFromInt(1000), UnitPrice: decimal.RequireFromString("0.80"), Amount: decimal.NewFromInt(800)},
+				{Description: "WhatsApp authentication", Quantity: decimal.NewFromInt(200), UnitPrice: decimal.NewFromInt(1), Amount: decimal.NewFromInt(200)},
+			},
+			Subtotal:    decimal.NewFromInt(1000),
+			TaxType:     "GST 18%",
+			TaxAmount:   decimal.NewFromInt(180),
+			TotalAmount: decimal.NewFromInt(1180),
+			Notes:       "Thank you (for) your business",
+		}
+	}
+
+	// Invoices whose figures disagree are refused
+	for _, mutate := range []func(doc *models.InvoiceDocument){
+		func(doc *models.InvoiceDocument) { doc.LineItems = nil },
+		func(doc *models.InvoiceDocument) { doc.Subtotal = decimal.NewFromInt(999) },
+		func(doc *models.InvoiceDocument) { doc.TotalAmount = decimal.NewFromInt(1000) },
+		func(doc *models.InvoiceDocument) { doc.DueDate = doc.IssueDate.AddDate(0, 0, -1) },
+		func(doc *models.InvoiceDocument) { doc.Currency = "RUPEES" },
+	} {
+		doc := invoice("INV-2026-0100")
+		mutate(doc)
+		_, err := invoices.Issue(ctx, doc)
+		require.ErrorIs(t, err, service.ErrInvalidInvoiceDocument)
+	}
+
+	doc := invoice("INV/2026/0101")
+	stored, err := invoices.Issue(ctx, doc)
+	require.NoError(t, err)
+	require.Equal(t, 1, stored.Deliveries)
+	require.NotNil(t, stored.LastDeliveredAt)
+	require.Len(t, issued, 1)
+	require.Equal(t, "https://billing.example.com/api/v1/invoices/"+doc.InvoiceID.String()+"/pdf", issued[0].URL)
+
+	meta, pdf, err := invoices.GetPDF(ctx, doc.InvoiceID)
+	require.NoError(t, err)
+	require.Equal(t, len(pdf), meta.SizeBytes)
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4")))
+	require.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	for _, text := range []string{"(Acme Billing)", "(TAX INVOICE)", "(INR 1,180.00)", "(GST 18%)", "(Globex Pvt Ltd)",
+		`(Thank you \(for\) your business)`, "(Pay INR 1,180.00 to Acme Billing by 15 Nov 2026)", "/Count 1 "} {
+		require.Contains(t, string(pdf), text)
+	}
+
+	// The notification emails the stored PDF; other channels get the link
+	env, err := events.NewInvoiceIssued(issued[0], 1)
+	require.NoError(t, err)
+	require.NoError(t, channels.Deliver(ctx, env))
+	require.Len(t, email.sent, 1)
+	require.Len(t, email.sent[0].msg.Attachments, 1)
+	attachment := email.sent[0].msg.Attachments[0]
+	require.Equal(t, "invoice-INV-2026-0101.pdf", attachment.Name)
+	require.Equal(t, "application/pdf", attachment.ContentType)
+	require.Equal(t, pdf, attachment.Data)
+	require.Len(t, slack.sent, 1)
+	require.Empty(t, slack.sent[0].msg.Attachments)
+
+	// Invoices notified without a PDF are emailed without attachments
+	env, err = events.NewInvoiceIssued(&models.InvoiceNotice{
+		InvoiceID:     uuid.New(),
+		CustomerID:    customerID,
+		InvoiceNumber: "INV-LEGACY-1",
+		Currency:      "INR",
+		TotalAmount:   decimal.NewFromInt(10),
+		DueDate:       doc.DueDate,
+	}, 1)
+	require.NoError(t, err)
+	require.NoError(t, channels.Deliver(ctx, env))
+	require.Len(t, email.sent, 2)
+	require.Empty(t, email.sent[1].msg.Attachments)
+
+	// Retried cycle closes return the stored PDF without notifying again;
+	// another invoice cannot reuse the number
+	again, err := invoices.Issue(ctx, doc)
+	require.NoError(t, err)
+	require.Equal(t, 1, again.Deliveries)
+	require.Len(t, issued, 1)
+	_, err = invoices.Issue(ctx, invoice("INV/2026/0101"))
+	require.ErrorIs(t, err, service.ErrInvoiceConflict)
+
+	// Re-sends deliver the stored copy again
+	resent, err := invoices.Resend(ctx, doc.InvoiceID)
+	require.NoError(t, err)
+	require.Equal(t, 2, resent.Deliveries)
+	require.Len(t, issued, 2)
+	_, err = invoices.Resend(ctx, uuid.New())
+	require.ErrorIs(t, err, service.ErrInvoiceDocumentNotFound)
+	_, _, err = invoices.GetPDF(ctx, uuid.New())
+	require.ErrorIs(t, err, service.ErrInvoiceDocumentNotFound)
+
+	// Long invoices continue on further pages. Invoices without a due date
+	// fall due by the customer's payment terms.
+	long := invoice("INV-2026-0102")
+	long.DueDate = time.Time{}
+	long.LineItems = nil
+	long.Subtotal = decimal.Zero
+	for i := 0; i < 80; i++ {
+		amount := decimal.NewFromInt(int64(i + 1))
+		long.LineItems = append(long.LineItems, models.InvoiceLineItem{
+			Description: fmt.Sprintf("Usage on day %d", i+1),
+			Quantity:    decimal.NewFromInt(1),
+			UnitPrice:   amount,
+			Amount:      amount,
+		})
+		long.Subtotal = long.Subtotal.Add(amount)
+	}
+	long.TaxAmount = decimal.Zero
+	long.TotalAmount = long.Subtotal
+	issuedLong, err := invoices.Issue(ctx, long)
+	require.NoError(t, err)
+	require.True(t, issuedLong.DueDate.Equal(long.IssueDate.AddDate(0, 0, 30)))
+	_, pdf, err = invoices.GetPDF(ctx, long.InvoiceID)
+	require.NoError(t, err)
+	require.Contains(t, string(pdf), "/Count 3 ")
+	require.Contains(t, string(pdf), "(Page 3 of 3)")
+	require.Contains(t, string(pdf), "(Usage on day 80)")
+}
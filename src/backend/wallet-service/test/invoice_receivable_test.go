@@ -0,0 +1,190 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestInvoiceReceivables tests that issued invoices fall due by the
+// customer's payment terms, are charged late fees or interest once overdue,
+// are settled by payments, and are aged by how long they are past due
+func TestInvoiceReceivables(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+	currencies := supportedCurrencies(t)
+
+	_, err := service.NewInvoiceReceivableService(kit.Store, kit.Store, currencies, "NET_7", service.LateFeePolicy{}, &alertLogger{})
+	require.ErrorIs(t, err, service.ErrInvalidPaymentTerms)
+	_, err = service.NewInvoiceReceivableService(kit.Store, kit.Store, currencies, models.PaymentTermsNet30,
+		service.LateFeePolicy{Mode: "PENALTY"}, &alertLogger{})
+	require.Error(t, err)
+
+	fees, err := service.NewInvoiceReceivableService(kit.Store, kit.Store, currencies, models.PaymentTermsNet30, service.LateFeePolicy{
+		Mode:        models.LateFeeFlat,
+		FeePercent:  decimal.NewFromInt(2),
+		GracePeriod: 5 * 24 * time.Hour,
+	}, &alertLogger{})
+	require.NoError(t, err)
+	bus := eventbus.New()
+	require.NoError(t, bus.Subscribe("invoice-receivables", fees.Handle, eventbus.TypeInvoiceIssued))
+
+	customerID := uuid.New()
+	require.NoError(t, kit.Store.CreateWallet(ctx, &models.Wallet{CustomerID: customerID, Currency: "INR"}))
+
+	// Customers have the default terms until operators set their own
+	terms, err := fees.PaymentTerms(ctx, customerID)
+	require.NoError(t, err)
+	require.Equal(t, models.PaymentTermsNet30, terms)
+	_, err = fees.UpdatePaymentTerms(ctx, customerID, "NET_7", "ops@example.com")
+	require.ErrorIs(t, err, service.ErrInvalidPaymentTerms)
+	_, err = fees.UpdatePaymentTerms(ctx, uuid.New(), models.PaymentTermsNet15, "ops@example.com")
+	require.ErrorIs(t, err, service.ErrCustomerNotFound)
+	settings, err := fees.UpdatePaymentTerms(ctx, customerID, models.PaymentTermsNet15, "ops@example.com")
+	require.NoError(t, err)
+	require.Equal(t, models.PaymentTermsNet15, settings.PaymentTerms)
+	require.Equal(t, time.Date(2026, 11, 15, 0, 0, 0, 0, time.UTC),
+		models.PaymentTermsNet15.DueDate(time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)))
+
+	due := time.Date(2026, 11, 15, 0, 0, 0, 0, time.UTC)
+	notice := func(customerID uuid.UUID, number, currency string, total decimal.Decimal, dueDate time.Time) *models.InvoiceNotice {
+		return &models.InvoiceNotice{
+			InvoiceID:     uuid.New(),
+			CustomerID:    customerID,
+			InvoiceNumber: number,
+			Currency:      currency,
+			TotalAmount:   total,
+			DueDate:       dueDate,
+		}
+	}
+
+	// Issued invoices are tracked from the event bus once, with the terms
+	// in force when they were issued
+	first := notice(customerID, "INV-2026-0201", "INR", decimal.NewFromInt(1000), due)
+	require.NoError(t, bus.Publish(ctx, eventbus.InvoiceIssued{Notice: first}))
+	require.NoError(t, bus.Publish(ctx, eventbus.InvoiceIssued{Notice: first}))
+	tracked, err := fees.Get(ctx, first.InvoiceID)
+	require.NoError(t, err)
+	require.Equal(t, models.InvoiceOpen, tracked.Status)
+	require.Equal(t, models.PaymentTermsNet15, tracked.PaymentTerms)
+	require.True(t, tracked.Outstanding.Equal(decimal.NewFromInt(1000)))
+	require.Empty(t, tracked.LateFeeItems)
+	_, err = fees.Get(ctx, uuid.New())
+	require.ErrorIs(t, err, service.ErrInvoiceReceivableNotFound)
+
+	// Unpaid invoices turn overdue after their due date and are charged
+	// the fee once after the grace period
+	for _, step := range []struct {
+		asOf    time.Time
+		changed int
+	}{
+		{due.Add(-time.Hour), 0},
+		{due.AddDate(0, 0, 1), 1},
+		{due.AddDate(0, 0, 2), 0},
+		{due.AddDate(0, 0, 6), 1},
+		{due.AddDate(0, 0, 7), 0},
+	} {
+		changed, err := fees.AccrueLateCharges(ctx, step.asOf)
+		require.NoError(t, err)
+		require.Equal(t, step.changed, changed, "as of %s", step.asOf)
+	}
+	tracked, err = fees.Get(ctx, first.InvoiceID)
+	require.NoError(t, err)
+	require.Equal(t, models.InvoiceOverdue, tracked.Status)
+	require.True(t, tracked.LateFee.Equal(decimal.NewFromInt(20)))
+	require.True(t, tracked.Outstanding.Equal(decimal.NewFromInt(1020)))
+	require.Len(t, tracked.LateFeeItems, 1)
+	require.Equal(t, "Late payment fee", tracked.LateFeeItems[0].Description)
+
+	// Payments settle the invoice and its fee; overpayments are refused
+	_, err = fees.RecordPayment(ctx, first.InvoiceID, decimal.Zero, time.Time{})
+	require.ErrorIs(t, err, service.ErrInvalidInvoicePayment)
+	paidAt := due.AddDate(0, 0, 8)
+	paid, err := fees.RecordPayment(ctx, first.InvoiceID, decimal.NewFromInt(1000), paidAt)
+	require.NoError(t, err)
+	require.Equal(t, models.InvoiceOverdue, paid.Status)
+	require.True(t, paid.Outstanding.Equal(decimal.NewFromInt(20)))
+	_, err = fees.RecordPayment(ctx, first.InvoiceID, decimal.NewFromInt(21), paidAt)
+	require.ErrorIs(t, err, service.ErrInvalidInvoicePayment)
+	paid, err = fees.RecordPayment(ctx, first.InvoiceID, decimal.NewFromInt(20), paidAt)
+	require.NoError(t, err)
+	require.Equal(t, models.InvoicePaid, paid.Status)
+	require.True(t, paid.Outstanding.IsZero())
+	require.NotNil(t, paid.PaidAt)
+	require.True(t, paid.PaidAt.Equal(paidAt))
+	_, err = fees.RecordPayment(ctx, uuid.New(), decimal.NewFromInt(1), paidAt)
+	require.ErrorIs(t, err, service.ErrInvoiceReceivableNotFound)
+
+	// Interest accrues by whole days from the due date on the unpaid total
+	interest, err := service.NewInvoiceReceivableService(kit.Store, kit.Store, currencies, models.PaymentTermsNet30, service.LateFeePolicy{
+		Mode:       models.LateFeeInterest,
+		AnnualRate: decimal.NewFromInt(18),
+	}, &alertLogger{})
+	require.NoError(t, err)
+	second, err := interest.Track(ctx, notice(customerID, "INV-2026-0202", "INR", decimal.NewFromInt(36500), due))
+	require.NoError(t, err)
+	_, err = interest.AccrueLateCharges(ctx, due.AddDate(0, 0, 10).Add(12*time.Hour))
+	require.NoError(t, err)
+	tracked, err = interest.Get(ctx, second.InvoiceID)
+	require.NoError(t, err)
+	require.True(t, tracked.Interest.Equal(decimal.NewFromInt(180)), tracked.Interest.String())
+	require.True(t, tracked.InterestThrough.Equal(due.AddDate(0, 0, 10)))
+	require.Len(t, tracked.LateFeeItems, 1)
+	require.Equal(t, "Interest on overdue amount to 25 Nov 2026", tracked.LateFeeItems[0].Description)
+
+	_, err = interest.RecordPayment(ctx, second.InvoiceID, decimal.NewFromInt(18250), due.AddDate(0, 0, 11))
+	require.NoError(t, err)
+	_, err = interest.AccrueLateCharges(ctx, due.AddDate(0, 0, 20))
+	require.NoError(t, err)
+	tracked, err = interest.Get(ctx, second.InvoiceID)
+	require.NoError(t, err)
+	require.True(t, tracked.Interest.Equal(decimal.NewFromInt(270)), tracked.Interest.String())
+	require.True(t, tracked.Outstanding.Equal(decimal.NewFromInt(18520)), tracked.Outstanding.String())
+
+	// Aging splits what is left to pay by days past due, per currency and
+	// per customer; paid invoices are left out
+	otherID := uuid.New()
+	_, err = interest.Track(ctx, notice(customerID, "INV-2026-0203", "INR", decimal.NewFromInt(500), due.AddDate(0, 0, 40)))
+	require.NoError(t, err)
+	other, err := interest.Track(ctx, notice(otherID, "INV-2026-0099", "USD", decimal.RequireFromString("75.50"), due.AddDate(0, 0, -100)))
+	require.NoError(t, err)
+	require.Equal(t, models.PaymentTermsNet30, other.PaymentTerms)
+
+	report, err := interest.Aging(ctx, due.AddDate(0, 0, 20), "", uuid.Nil)
+	require.NoError(t, err)
+	require.Len(t, report.Totals, 2)
+	require.Equal(t, "INR", report.Totals[0].Currency)
+	require.True(t, report.Totals[0].Aging.Current.Equal(decimal.NewFromInt(500)))
+	require.True(t, report.Totals[0].Aging.Days1To30.Equal(decimal.NewFromInt(18520)))
+	require.True(t, report.Totals[0].Aging.Total.Equal(decimal.NewFromInt(19020)))
+	require.Equal(t, "USD", report.Totals[1].Currency)
+	require.True(t, report.Totals[1].Aging.Over90.Equal(decimal.RequireFromString("75.50")))
+	require.Len(t, report.Customers, 2)
+	for _, customer := range report.Customers {
+		if customer.CustomerID == customerID {
+			require.Equal(t, 2, customer.OpenInvoices)
+			require.True(t, customer.Aging.Total.Equal(decimal.NewFromInt(19020)))
+		} else {
+			require.Equal(t, otherID, customer.CustomerID)
+			require.Equal(t, 1, customer.OpenInvoices)
+		}
+	}
+
+	report, err = interest.Aging(ctx, due.AddDate(0, 0, 20), "USD", uuid.Nil)
+	require.NoError(t, err)
+	require.Len(t, report.Totals, 1)
+	require.Len(t, report.Customers, 1)
+	report, err = interest.Aging(ctx, due.AddDate(0, 0, 20), "", customerID)
+	require.NoError(t, err)
+	require.Len(t, report.Customers, 1)
+	require.Equal(t, customerID, report.Customers[0].CustomerID)
+}
@@ -0,0 +1,197 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	_ "github.com/jackc/pgx/v5/stdlib"    // v5.3.1
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/loadtest"
+	"internal/logging"
+	"internal/models"
+	"internal/repository"
+	"internal/service"
+	"internal/testkit"
+)
+
+// conflictingWallets refuses every fourth transaction as a concurrent
+// modification
+type conflictingWallets struct {
+	service.WalletService
+	calls atomic.Int64
+}
+
+func (w *conflictingWallets) ProcessTransaction(ctx context.Context, tx *models.Transaction) (*models.CreditLimitWarning, error) {
+	if w.calls.Add(1)%4 == 0 {
+		return nil, service.ErrOptimisticLock
+	}
+	return w.WalletService.ProcessTransaction(ctx, tx)
+}
+
+// newLoadTestWallets creates funded wallets in the in-memory store
+func newLoadTestWallets(t testing.TB, count int, balance float64) (service.WalletService, []uuid.UUID) {
+	store := testkit.NewStore(testkit.NewClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	wallets, err := service.NewWalletService(store, supportedCurrencies(t), decimal.Zero, eventbus.New(), logging.NewNop())
+	require.NoError(t, err)
+
+	customers := make([]uuid.UUID, count)
+	for i := range customers {
+		customers[i] = uuid.New()
+	}
+	walletIDs, err := loadtest.CreateWallets(context.Background(), store, wallets, customers, "USD", balance)
+	require.NoError(t, err)
+	return wallets, walletIDs
+}
+
+// TestLoadTestRun tests that a run posts the requested transactions from
+// concurrent workers and counts each outcome once
+func TestLoadTestRun(t *testing.T) {
+	ctx := context.Background()
+	opts := loadtest.Options{Workers: 4, Transactions: 20, Amount: 1, Currency: "USD"}
+
+	for _, invalid := range []func(o *loadtest.Options){
+		func(o *loadtest.Options) { o.Workers = 0 },
+		func(o *loadtest.Options) { o.Transactions = 0 },
+		func(o *loadtest.Options) { o.CreditRatio = 1.5 },
+		func(o *loadtest.Options) { o.Amount = 0 },
+	} {
+		o := opts
+		invalid(&o)
+		require.Error(t, o.Validate())
+	}
+
+	// Debits only: ten fit in the two wallets, the rest are refused
+	wallets, walletIDs := newLoadTestWallets(t, 2, 5)
+	report, err := loadtest.Run(ctx, wallets, walletIDs, opts)
+	require.NoError(t, err)
+	require.EqualValues(t, 20, report.Transactions)
+	require.EqualValues(t, 10, report.Succeeded)
+	require.EqualValues(t, 10, report.Insufficient)
+	require.Zero(t, report.Failed)
+	require.Zero(t, report.ConflictRate())
+	require.LessOrEqual(t, report.P50, report.P99)
+	require.LessOrEqual(t, report.P99, report.Max)
+	require.Positive(t, report.Throughput())
+	for _, id := range walletIDs {
+		wallet, err := wallets.GetWallet(ctx, id)
+		require.NoError(t, err)
+		require.Zero(t, wallet.Balance)
+	}
+
+	// Conflicts are counted apart from other refusals
+	wallets, walletIDs = newLoadTestWallets(t, 3, 100)
+	conflicting := &conflictingWallets{WalletService: wallets}
+	report, err = loadtest.Run(ctx, conflicting, walletIDs, opts)
+	require.NoError(t, err)
+	require.EqualValues(t, 5, report.Conflicts)
+	require.EqualValues(t, 15, report.Succeeded)
+	require.InDelta(t, 0.25, report.ConflictRate(), 1e-9)
+
+	// Without a transaction count the run lasts its duration
+	report, err = loadtest.Run(ctx, wallets, walletIDs, loadtest.Options{
+		Workers: 2, Duration: 20 * time.Millisecond, CreditRatio: 1, Amount: 1, Currency: "USD",
+	})
+	require.NoError(t, err)
+	require.Positive(t, report.Transactions)
+	require.Equal(t, report.Transactions, report.Succeeded, "%v", report.FirstError)
+	require.GreaterOrEqual(t, report.Elapsed, 20*time.Millisecond)
+}
+
+// loadTestBackend is a wallet store benchmarks run against, with the
+// customers its wallets belong to
+type loadTestBackend struct {
+	repo      repository.WalletRepository
+	customers func(n int) ([]uuid.UUID, error)
+}
+
+// memoryBackend returns the in-memory store, which serializes units of
+// work and so never conflicts
+func memoryBackend(b *testing.B) loadTestBackend {
+	store := testkit.NewStore(testkit.NewClock(time.Now().UTC()))
+	return loadTestBackend{repo: store, customers: func(n int) ([]uuid.UUID, error) {
+		customers := make([]uuid.UUID, n)
+		for i := range customers {
+			customers[i] = uuid.New()
+		}
+		return customers, nil
+	}}
+}
+
+// postgresBackend connects to the database named by WALLET_LOADTEST_DSN,
+// such as the one of the backend docker-compose setup with the shared
+// migrations applied, skipping the benchmark when it is unset
+func postgresBackend(b *testing.B) loadTestBackend {
+	dsn := os.Getenv("WALLET_LOADTEST_DSN")
+	if dsn == "" {
+		b.Skip("WALLET_LOADTEST_DSN is not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(b, err)
+	b.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(32)
+	db.SetMaxIdleConns(32)
+	require.NoError(b, db.Ping())
+
+	repo, err := repository.NewWalletRepository(db)
+	require.NoError(b, err)
+	b.Cleanup(func() { repo.Close() })
+
+	return loadTestBackend{repo: repo, customers: func(n int) ([]uuid.UUID, error) {
+		return loadtest.SeedCustomers(context.Background(), db, n)
+	}}
+}
+
+// BenchmarkProcessTransaction posts concurrent debits and credits over one,
+// a few and many wallets, reporting the p99 latency and conflict rate
+// beside the time per transaction. The postgres backend measures the
+// locking of the database and runs only with WALLET_LOADTEST_DSN set.
+func BenchmarkProcessTransaction(b *testing.B) {
+	ctx := context.Background()
+
+	for _, backend := range []struct {
+		name string
+		open func(b *testing.B) loadTestBackend
+	}{
+		{"memory", memoryBackend},
+		{"postgres", postgresBackend},
+	} {
+		b.Run(backend.name, func(b *testing.B) {
+			store := backend.open(b)
+			wallets, err := service.NewWalletService(store.repo, supportedCurrencies(b), decimal.Zero, eventbus.New(), logging.NewNop())
+			require.NoError(b, err)
+
+			for _, count := range []int{1, 16, 256} {
+				customers, err := store.customers(count)
+				require.NoError(b, err)
+				walletIDs, err := loadtest.CreateWallets(ctx, store.repo, wallets, customers, "USD", 1000000)
+				require.NoError(b, err)
+
+				b.Run(fmt.Sprintf("wallets=%d", count), func(b *testing.B) {
+					report, err := loadtest.Run(ctx, wallets, walletIDs, loadtest.Options{
+						Workers:      16,
+						Transactions: int64(b.N),
+						CreditRatio:  0.5,
+						Amount:       1,
+						Currency:     "USD",
+					})
+					require.NoError(b, err)
+					require.Zero(b, report.Failed, "%v", report.FirstError)
+
+					b.ReportMetric(report.ConflictRate(), "conflicts/op")
+					b.ReportMetric(float64(report.P99.Microseconds()), "p99-µs")
+					b.ReportMetric(report.Throughput(), "tx/s")
+				})
+			}
+		})
+	}
+}
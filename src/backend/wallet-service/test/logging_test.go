@@ -0,0 +1,96 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"            // v1.9.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/api"
+	"internal/logging"
+)
+
+// logEntries decodes the JSON log entries written to a buffer
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestLoggingLevelsAndFields tests key-value fields, errors and runtime
+// level changes shared by child loggers
+func TestLoggingLevelsAndFields(t *testing.T) {
+	_, err := logging.New(logging.Options{Encoding: "xml"})
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	logger, err := logging.New(logging.Options{Level: "warn", Output: &buf})
+	require.NoError(t, err)
+	child := logger.With("component", "test")
+
+	child.Info("dropped")
+	child.Error("failed", errors.New("boom"), "walletID", "w-1")
+	require.Error(t, logger.SetLevel("verbose"))
+	require.NoError(t, logger.SetLevel("debug"))
+	child.Debug("kept")
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 2)
+	require.Equal(t, "failed", entries[0]["msg"])
+	require.Equal(t, "boom", entries[0]["error"])
+	require.Equal(t, "w-1", entries[0]["walletID"])
+	require.Equal(t, "test", entries[0]["component"])
+	require.Equal(t, "kept", entries[1]["msg"])
+}
+
+// TestLoggerMiddlewareCorrelation tests that request loggers carry the
+// correlation ID, taken from the client when valid
+func TestLoggerMiddlewareCorrelation(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(logging.Options{Output: &buf})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(api.LoggerMiddleware(logger))
+	router.GET("/work", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Info("handled")
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/work", nil)
+	req.Header.Set("X-Request-ID", "client-req-42")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, "client-req-42", rec.Header().Get("X-Request-ID"))
+
+	req = httptest.NewRequest(http.MethodGet, "/work", nil)
+	req.Header.Set("X-Request-ID", "bad id\nwith newline")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	generated := rec.Header().Get("X-Request-ID")
+	require.NotEmpty(t, generated)
+	require.NotEqual(t, "bad id\nwith newline", generated)
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 4)
+	for i, want := range []string{"client-req-42", "client-req-42", generated, generated} {
+		require.Equal(t, want, entries[i]["correlation_id"])
+	}
+	require.Equal(t, "handled", entries[0]["msg"])
+	require.Equal(t, "request processed", entries[1]["msg"])
+	require.EqualValues(t, http.StatusNoContent, entries[1]["status"])
+}
@@ -0,0 +1,254 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/events"
+	"internal/execctx"
+	"internal/models"
+	"internal/notify"
+	"internal/service"
+	"internal/testkit"
+)
+
+// sentMessage is a message a recordingSender was asked to send
+type sentMessage struct {
+	destination string
+	msg         notify.Message
+}
+
+// recordingSender collects the messages sent on one channel kind, failing
+// them when err is set
+type recordingSender struct {
+	sent []sentMessage
+	err  error
+}
+
+func (s *recordingSender) Send(ctx context.Context, destination string, msg notify.Message) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, sentMessage{destination: destination, msg: msg})
+	return nil
+}
+
+// TestNotificationTemplates tests that the default notification templates
+// render event payloads and that configured ones replace them field by field
+func TestNotificationTemplates(t *testing.T) {
+	_, err := notify.NewTemplates([]notify.Template{{EventType: eventbus.TypeWalletCreated, Body: "Welcome"}})
+	require.ErrorIs(t, err, notify.ErrInvalidTemplate)
+	_, err = notify.NewTemplates([]notify.Template{{EventType: eventbus.TypeLowBalance, Body: "{{.balance"}})
+	require.ErrorIs(t, err, notify.ErrInvalidTemplate)
+
+	templates, err := notify.NewTemplates([]notify.Template{
+		{EventType: eventbus.TypeLowBalance, Body: "Balance {{.balance}} {{.currency}}, top up soon"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		eventbus.TypeInvoiceIssued,
+		eventbus.TypeTransactionCompleted,
+		eventbus.TypeBucketLowBalance,
+		eventbus.TypeDunningNotice,
+		eventbus.TypeLowBalance,
+	}, templates.EventTypes())
+
+	wallet := &models.Wallet{ID: uuid.New(), CustomerID: uuid.New(), Balance: 42.5, LowBalanceThreshold: 50, Currency: "INR"}
+	env, err := events.NewLowBalance(wallet, 1)
+	require.NoError(t, err)
+	msg, err := templates.Render(env.Type, env.Payload)
+	require.NoError(t, err)
+	require.Equal(t, notify.Message{Subject: "Low wallet balance", Body: "Balance 42.5 INR, top up soon"}, msg)
+
+	env, err = events.NewBucketLowBalance(wallet, &models.WalletBucket{
+		Name:                "SMS budget",
+		Balance:             decimal.RequireFromString("8.5"),
+		LowBalanceThreshold: decimal.NewFromInt(10),
+	}, 1)
+	require.NoError(t, err)
+	msg, err = templates.Render(env.Type, env.Payload)
+	require.NoError(t, err)
+	require.Equal(t, "Low SMS budget balance", msg.Subject)
+	require.Contains(t, msg.Body, "Your SMS budget balance is 8.5 INR")
+
+	env, err = events.NewInvoiceIssued(&models.InvoiceNotice{
+		InvoiceID:     uuid.New(),
+		CustomerID:    wallet.CustomerID,
+		InvoiceNumber: "INV-2026-0042",
+		Currency:      "INR",
+		TotalAmount:   decimal.RequireFromString("1180.00"),
+		DueDate:       time.Date(2026, 11, 15, 0, 0, 0, 0, time.UTC),
+	}, 1)
+	require.NoError(t, err)
+	msg, err = templates.Render(env.Type, env.Payload)
+	require.NoError(t, err)
+	require.Equal(t, "Invoice INV-2026-0042", msg.Subject)
+	require.Equal(t, "Invoice INV-2026-0042 for 1180 INR is due on 2026-11-15.", msg.Body)
+
+	// Payloads lacking a field the template refers to fail to render
+	_, err = templates.Render(eventbus.TypeLowBalance, []byte(`{"wallet_id":"x"}`))
+	require.ErrorIs(t, err, notify.ErrInvalidTemplate)
+}
+
+// TestNotificationChannels tests that customers' channels are validated and
+// receive the notifications their filters select, and that issued invoices
+// are published for delivery
+func TestNotificationChannels(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+
+	templates, err := notify.NewTemplates(nil)
+	require.NoError(t, err)
+	email, slack := &recordingSender{}, &recordingSender{}
+	bus := eventbus.New()
+	var issued []*models.InvoiceNotice
+	require.NoError(t, bus.Subscribe("invoices", eventbus.Typed(func(ctx context.Context, e eventbus.InvoiceIssued) error {
+		issued = append(issued, e.Notice)
+		return nil
+	})))
+	channels, err := service.NewNotificationChannelService(kit.Store, map[models.NotificationChannelKind]notify.Sender{
+		models.NotificationChannelEmail: email,
+		models.NotificationChannelSlack: slack,
+	}, templates, bus, &alertLogger{})
+	require.NoError(t, err)
+
+	customerID := uuid.New()
+	wallet := &models.Wallet{CustomerID: customerID, Currency: "INR", Balance: 20, LowBalanceThreshold: 50}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+
+	large := decimal.NewFromInt(1000)
+	for _, input := range []service.NotificationChannelInput{
+		{Kind: "FAX", Destination: "+911234567890"},
+		{Kind: models.NotificationChannelSMS, Destination: "+911234567890"},
+		{Kind: models.NotificationChannelEmail, Destination: "Billing <billing@example.com>"},
+		{Kind: models.NotificationChannelSlack, Destination: "https://example.com/hooks/T000"},
+		{Kind: models.NotificationChannelEmail, Destination: "billing@example.com", EventTypes: []string{eventbus.TypeWalletCreated}},
+		{Kind: models.NotificationChannelEmail, Destination: "billing@example.com", EventTypes: []string{eventbus.TypeTransactionCompleted}},
+	} {
+		_, err := channels.CreateChannel(ctx, customerID, input)
+		require.ErrorIs(t, err, service.ErrInvalidNotificationChannel, input.Destination)
+	}
+
+	billing, err := channels.CreateChannel(ctx, customerID, service.NotificationChannelInput{
+		Kind:        models.NotificationChannelEmail,
+		Destination: "billing@example.com",
+		EventTypes:  []string{eventbus.TypeLowBalance, eventbus.TypeInvoiceIssued},
+		Enabled:     true,
+	})
+	require.NoError(t, err)
+	_, err = channels.CreateChannel(ctx, customerID, service.NotificationChannelInput{
+		Kind:        models.NotificationChannelEmail,
+		Destination: "billing@example.com",
+		Enabled:     true,
+	})
+	require.ErrorIs(t, err, service.ErrNotificationChannelExists)
+	ops, err := channels.CreateChannel(ctx, customerID, service.NotificationChannelInput{
+		Kind:                   models.NotificationChannelSlack,
+		Destination:            "https://hooks.slack.com/services/T000/B000/XXXX",
+		LargeTransactionAmount: &large,
+		Enabled:                true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{}, ops.EventTypes)
+
+	deliver := func(env *events.Envelope) {
+		require.NoError(t, channels.Deliver(ctx, env))
+	}
+
+	// Low balance alerts reach both channels, by the wallet's owner
+	env, err := events.NewLowBalance(wallet, 1)
+	require.NoError(t, err)
+	deliver(env)
+	require.Len(t, email.sent, 1)
+	require.Equal(t, "billing@example.com", email.sent[0].destination)
+	require.Equal(t, "Low wallet balance", email.sent[0].msg.Subject)
+	require.Len(t, slack.sent, 1)
+
+	// Only transactions from the large transaction amount are notified, and
+	// only to the channel that set it
+	for _, amount := range []float64{999.99, 1500} {
+		env, err = events.NewTransactionCompleted(&models.Transaction{
+			ID:       uuid.New(),
+			WalletID: wallet.ID,
+			Type:     models.TransactionTypeDebit,
+			Status:   models.TransactionStatusCompleted,
+			Amount:   amount,
+			Currency: "INR",
+		}, 3)
+		require.NoError(t, err)
+		deliver(env)
+	}
+	require.Len(t, email.sent, 1)
+	require.Len(t, slack.sent, 2)
+	require.Equal(t, "A debit of 1500 INR was completed on wallet "+wallet.ID.String()+".", slack.sent[1].msg.Body)
+
+	// Events outside live execution and events without a template are not
+	// notified
+	env, err = events.NewLowBalance(wallet, 1)
+	require.NoError(t, err)
+	env.Mode = execctx.ModeReplay
+	deliver(env)
+	env, err = events.NewWalletCreated(wallet, 1)
+	require.NoError(t, err)
+	deliver(env)
+	require.Len(t, email.sent, 1)
+	require.Len(t, slack.sent, 2)
+
+	// Disabled channels and failed sends do not hold up the others
+	_, err = channels.UpdateChannel(ctx, customerID, ops.ID, service.NotificationChannelInput{
+		Kind:        models.NotificationChannelEmail,
+		Destination: "https://hooks.slack.com/services/T000/B000/XXXX",
+	})
+	require.ErrorIs(t, err, service.ErrInvalidNotificationChannel)
+	updated, err := channels.UpdateChannel(ctx, customerID, ops.ID, service.NotificationChannelInput{
+		Destination:            "https://hooks.slack.com/services/T000/B000/XXXX",
+		LargeTransactionAmount: &large,
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.NotificationChannelSlack, updated.Kind)
+	require.False(t, updated.Enabled)
+	email.err = errors.New("mailbox unavailable")
+	env, err = events.NewLowBalance(wallet, 1)
+	require.NoError(t, err)
+	deliver(env)
+	require.Len(t, slack.sent, 2)
+	email.err = nil
+
+	// Issued invoices are validated and published for delivery
+	notice := &models.InvoiceNotice{
+		InvoiceID:     uuid.New(),
+		CustomerID:    customerID,
+		InvoiceNumber: "INV-2026-0042",
+		Currency:      "INR",
+		TotalAmount:   decimal.RequireFromString("1180.00"),
+		DueDate:       time.Date(2026, 11, 15, 0, 0, 0, 0, time.UTC),
+		URL:           "http://invoices.example.com/INV-2026-0042",
+	}
+	require.ErrorIs(t, channels.NotifyInvoice(ctx, notice), service.ErrInvalidInvoiceNotice)
+	notice.URL = "https://invoices.example.com/INV-2026-0042"
+	require.NoError(t, channels.NotifyInvoice(ctx, notice))
+	require.Len(t, issued, 1)
+
+	env, err = events.NewInvoiceIssued(issued[0], 1)
+	require.NoError(t, err)
+	deliver(env)
+	require.Len(t, email.sent, 2)
+	require.Equal(t, "Invoice INV-2026-0042 for 1180 INR is due on 2026-11-15. "+
+		"View it at https://invoices.example.com/INV-2026-0042", email.sent[1].msg.Body)
+
+	// Channels belong to their customer
+	_, err = channels.GetChannel(ctx, uuid.New(), billing.ID)
+	require.ErrorIs(t, err, service.ErrNotificationChannelNotFound)
+	require.NoError(t, channels.DeleteChannel(ctx, customerID, billing.ID))
+	require.ErrorIs(t, channels.DeleteChannel(ctx, customerID, billing.ID), service.ErrNotificationChannelNotFound)
+	listed, err := channels.ListChannels(ctx, customerID)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+}
@@ -0,0 +1,112 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/events"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// outagePublisher fails like the stream publisher while the notification
+// pipeline is down
+type outagePublisher struct {
+	down      bool
+	published []*events.Envelope
+}
+
+func (p *outagePublisher) Publish(ctx context.Context, env *events.Envelope) error {
+	if p.down {
+		return fmt.Errorf("%w %s event: %w", events.ErrPublishFailed, env.Type, errors.New("connection refused"))
+	}
+	p.published = append(p.published, env)
+	return nil
+}
+
+// TestNotificationQueue tests that notifications refused during an outage
+// are queued once, retried with backoff until delivered and expired when
+// time-sensitive alerts go stale
+func TestNotificationQueue(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+	publisher := &outagePublisher{down: true}
+
+	queue, err := service.NewNotificationQueueService(kit.Store, publisher, service.NotificationQueueOptions{
+		AlertTTL:  2 * time.Hour,
+		NoticeTTL: 72 * time.Hour,
+	}, &alertLogger{})
+	require.NoError(t, err)
+
+	lowBalance, err := events.NewEnvelope(events.TypeLowBalance, 1, map[string]string{"wallet_id": "w-1"})
+	require.NoError(t, err)
+	digest, err := events.NewEnvelope(events.TypeActivityDigest, 1, map[string]string{"customer_id": "c-1"})
+	require.NoError(t, err)
+	completed, err := events.NewEnvelope(events.TypeTransactionCompleted, 2, map[string]string{"id": "t-1"})
+	require.NoError(t, err)
+
+	// Notifications are queued instead of failing; other events still fail
+	require.NoError(t, queue.Publish(ctx, lowBalance))
+	require.NoError(t, queue.Publish(ctx, lowBalance))
+	require.NoError(t, queue.Publish(ctx, digest))
+	require.ErrorIs(t, queue.Publish(ctx, completed), events.ErrPublishFailed)
+
+	backlog, err := queue.Backlog(ctx, "", "", 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, backlog.Pending)
+	require.EqualValues(t, 1, backlog.PendingByType[events.TypeLowBalance])
+	require.NotNil(t, backlog.OldestPending)
+	require.Len(t, backlog.Notifications, 2)
+
+	// Nothing is due before the first backoff, and a failed attempt stops
+	// the run
+	now := time.Now().UTC()
+	delivered, err := queue.RetryDue(ctx, now)
+	require.NoError(t, err)
+	require.Zero(t, delivered)
+
+	delivered, err = queue.RetryDue(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Zero(t, delivered)
+	backlog, err = queue.Backlog(ctx, models.QueuedNotificationPending, "", 0)
+	require.NoError(t, err)
+	attempts := 0
+	for _, n := range backlog.Notifications {
+		attempts += n.Attempts
+		if n.Attempts > 0 {
+			require.Contains(t, n.LastError, "connection refused")
+		}
+	}
+	require.Equal(t, 1, attempts)
+
+	// Once the pipeline is back, stale alerts expire and the rest are sent
+	publisher.down = false
+	delivered, err = queue.RetryDue(ctx, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, delivered)
+	require.Len(t, publisher.published, 1)
+	require.Equal(t, digest.ID, publisher.published[0].ID)
+	require.Equal(t, events.TypeActivityDigest, publisher.published[0].Type)
+
+	backlog, err = queue.Backlog(ctx, models.QueuedNotificationExpired, "", 0)
+	require.NoError(t, err)
+	require.Zero(t, backlog.Pending)
+	require.EqualValues(t, 1, backlog.Expired)
+	require.Len(t, backlog.Notifications, 1)
+	require.Equal(t, lowBalance.ID, backlog.Notifications[0].EventID)
+
+	_, err = queue.Backlog(ctx, "DELIVERED", "", 0)
+	require.ErrorIs(t, err, service.ErrInvalidBacklogQuery)
+	_, err = queue.Backlog(ctx, "", events.TypeTransactionCompleted, 0)
+	require.ErrorIs(t, err, service.ErrInvalidBacklogQuery)
+
+	purged, err := queue.Purge(ctx, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, purged)
+}
@@ -0,0 +1,113 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"            // v1.9.1
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/api"
+	"internal/config"
+	"internal/testkit"
+)
+
+// TestOpenAPIDocumentUpToDate tests that the embedded document matches the
+// handler types; run go generate in internal/api when it fails
+func TestOpenAPIDocumentUpToDate(t *testing.T) {
+	generated, err := api.GenerateOpenAPI()
+	require.NoError(t, err)
+	require.Equal(t, string(generated), string(api.OpenAPIDocument()))
+}
+
+// routeParam matches the parameters of a gin route
+var routeParam = regexp.MustCompile(`:([a-z_]+)`)
+
+// TestOpenAPIDocumentsEveryRoute tests that every API route has an operation
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	spec, err := api.OpenAPISpec()
+	require.NoError(t, err)
+
+	noop := func(c *gin.Context) {}
+	router := api.SetupRouter(gin.New(), &config.Config{}, api.Middleware{
+		Auth:        noop,
+		RateLimit:   noop,
+		Idempotency: noop,
+	}, api.Handlers{
+		Wallet:         &api.WalletHandler{},
+		Admin:          &api.AdminHandler{},
+		Analytics:      &api.AnalyticsHandler{},
+		Health:         &api.HealthHandler{},
+		Reconciliation: &api.ReconciliationHandler{},
+		Digest:         &api.DigestHandler{},
+		Customer:       &api.CustomerHandler{},
+		Consent:        &api.ConsentHandler{},
+		Webhook:        &api.WebhookHandler{},
+		Channels:       &api.NotificationChannelHandler{},
+		AccessLog:      &api.AccessLogHandler{},
+		Invoices:       &api.InvoiceHandler{},
+		Receivables:    &api.InvoiceReceivableHandler{},
+		Postpaid:       &api.PostpaidHandler{},
+		Allowances:     &api.AllowanceHandler{},
+		Buckets:        &api.WalletBucketHandler{},
+		Organizations:  &api.OrganizationHandler{},
+		Recurring:      &api.RecurringDebitHandler{},
+		Refund:         &api.RefundHandler{},
+		Stream:         &api.StreamHandler{},
+		WebSocket:      &api.WebSocketHandler{},
+		DataAccess:     &api.DataAccessHandler{},
+		Usage:          &api.UsageHandler{},
+		RateCard:       &api.RateCardHandler{},
+		SLO:            &api.SLOHandler{},
+		BillingPeriod:  &api.BillingPeriodHandler{},
+		Migration:      &api.MigrationHandler{},
+		ReportJob:      &api.ReportJobHandler{},
+		Deprecation:    &api.DeprecationHandler{},
+		Tax:            &api.TaxHandler{},
+		Coupon:         &api.CouponHandler{},
+		Dispute:        &api.DisputeHandler{},
+		BulkCredit:     &api.BulkCreditHandler{},
+		Incident:       &api.IncidentHandler{},
+		Thresholds:     &api.BalanceThresholdHandler{},
+		Estimate:       &api.EstimateHandler{},
+		GraphQL:        http.NotFoundHandler(),
+	})
+
+	for _, route := range router.Routes() {
+		path, ok := strings.CutPrefix(route.Path, "/api/"+api.Version)
+		if !ok || path == "/openapi.json" || path == "/graphql" {
+			continue
+		}
+
+		item := spec.Paths.Find(routeParam.ReplaceAllString(path, "{$1}"))
+		require.NotNil(t, item, "route %s %s is not documented", route.Method, route.Path)
+		require.NotNil(t, item.GetOperation(route.Method), "route %s %s is not documented", route.Method, route.Path)
+	}
+}
+
+// TestServeOpenAPI tests the served document and the optional Swagger UI
+func TestServeOpenAPI(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+
+	status, body := kit.Do(t, uuid.New(), http.MethodGet, "/api/v1/openapi.json", nil)
+	require.Equal(t, http.StatusOK, status)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &doc))
+	require.Equal(t, "3.0.3", doc["openapi"])
+
+	status, _ = kit.Do(t, uuid.New(), http.MethodGet, "/api/v1/docs/", nil)
+	require.Equal(t, http.StatusNotFound, status)
+
+	kit = testkit.New(t, testkit.Options{SwaggerUI: true})
+	status, body = kit.Do(t, uuid.New(), http.MethodGet, "/api/v1/docs/", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, string(body), "swagger-ui")
+
+	status, body = kit.Do(t, uuid.New(), http.MethodGet, "/api/v1/docs/swagger-initializer.js", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, string(body), "../openapi.json")
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/tenancy"
+	"internal/testkit"
+)
+
+// TestOrganizationTenancy tests that organizations roll up the wallets and
+// invoices of their customers, and that contexts confined to an
+// organization only see its customers and wallets
+func TestOrganizationTenancy(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+	currencies := supportedCurrencies(t)
+
+	wallets, err := service.NewWalletService(kit.Store, currencies, decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	customers, err := service.NewCustomerService(kit.Store, &alertLogger{})
+	require.NoError(t, err)
+	organizations, err := service.NewOrganizationService(kit.Store, &alertLogger{})
+	require.NoError(t, err)
+
+	_, err = organizations.Create(ctx, "  ", "ops")
+	require.ErrorIs(t, err, service.ErrInvalidOrganization)
+	acme, err := organizations.Create(ctx, "Acme", "ops")
+	require.NoError(t, err)
+	globex, err := organizations.Create(ctx, "Globex", "ops")
+	require.NoError(t, err)
+
+	member := &models.Wallet{CustomerID: uuid.New(), Currency: "INR", Balance: 100}
+	require.NoError(t, kit.Store.CreateWallet(ctx, member))
+	kit.Clock.Advance(time.Minute)
+	second := &models.Wallet{CustomerID: member.CustomerID, Currency: "USD", Balance: 5}
+	require.NoError(t, kit.Store.CreateWallet(ctx, second))
+	outsider := &models.Wallet{CustomerID: uuid.New(), Currency: "INR", Balance: 40}
+	require.NoError(t, kit.Store.CreateWallet(ctx, outsider))
+
+	unknown := uuid.New()
+	_, err = organizations.AssignCustomer(ctx, member.CustomerID, &unknown, "ops")
+	require.ErrorIs(t, err, service.ErrOrganizationNotFound)
+	_, err = organizations.AssignCustomer(ctx, uuid.New(), &acme.ID, "ops")
+	require.ErrorIs(t, err, service.ErrCustomerNotFound)
+	settings, err := organizations.AssignCustomer(ctx, member.CustomerID, &acme.ID, "ops")
+	require.NoError(t, err)
+	require.Equal(t, acme.ID, *settings.OrgID)
+
+	// The rollup covers every wallet of the organization's customers
+	summary, err := organizations.Get(ctx, acme.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Acme", summary.Organization.Name)
+	require.Equal(t, 1, summary.Customers)
+	require.Equal(t, 2, summary.Wallets)
+	require.Len(t, summary.Balances, 2)
+	require.Equal(t, "INR", summary.Balances[0].Currency)
+	require.Equal(t, "100", summary.Balances[0].Balance.String())
+	require.Equal(t, "USD", summary.Balances[1].Currency)
+	listed, err := organizations.ListWallets(ctx, acme.ID, service.Pagination{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.Equal(t, member.ID, listed[0].ID)
+
+	// Invoices of the organization's customers are consolidated by period
+	issued := time.Now().UTC()
+	for customerID, amount := range map[uuid.UUID]int64{member.CustomerID: 300, outsider.CustomerID: 50} {
+		_, _, err := kit.Store.CreateInvoiceReceivable(ctx, &models.InvoiceReceivable{
+			InvoiceID:     uuid.New(),
+			CustomerID:    customerID,
+			InvoiceNumber: "INV-" + customerID.String()[:8],
+			Currency:      "INR",
+			TotalAmount:   decimal.NewFromInt(amount),
+			DueDate:       issued.AddDate(0, 0, 30),
+			Status:        models.InvoiceOpen,
+			CreatedAt:     issued,
+		})
+		require.NoError(t, err)
+	}
+	period := issued.Format(models.BillingPeriodLayout)
+	invoice, err := organizations.ConsolidatedInvoice(ctx, acme.ID, period)
+	require.NoError(t, err)
+	require.Len(t, invoice.Invoices, 1)
+	require.Len(t, invoice.Totals, 1)
+	require.Equal(t, "300", invoice.Totals[0].TotalAmount.String())
+	require.Equal(t, "300", invoice.Totals[0].Outstanding.String())
+	invoice, err = organizations.ConsolidatedInvoice(ctx, acme.ID, issued.AddDate(0, 1, 0).Format(models.BillingPeriodLayout))
+	require.NoError(t, err)
+	require.Empty(t, invoice.Invoices)
+	_, err = organizations.ConsolidatedInvoice(ctx, acme.ID, "last-month")
+	require.ErrorIs(t, err, service.ErrInvalidBillingPeriod)
+
+	// Confined contexts see their organization's customers and wallets only
+	confined := tenancy.WithOrganization(ctx, acme.ID)
+	_, err = wallets.GetWallet(confined, member.ID)
+	require.NoError(t, err)
+	_, err = wallets.GetWallet(confined, outsider.ID)
+	require.ErrorIs(t, err, service.ErrWalletNotFound)
+	_, err = customers.GetSettings(confined, outsider.CustomerID)
+	require.ErrorIs(t, err, service.ErrCustomerNotFound)
+	_, err = organizations.Get(confined, globex.ID)
+	require.ErrorIs(t, err, service.ErrOrganizationNotFound)
+	_, err = organizations.Get(tenancy.WithOrganization(ctx, globex.ID), globex.ID)
+	require.NoError(t, err)
+
+	// Customers moved out of the organization leave its scope
+	_, err = organizations.AssignCustomer(ctx, member.CustomerID, nil, "ops")
+	require.NoError(t, err)
+	_, err = wallets.GetWallet(confined, member.ID)
+	require.ErrorIs(t, err, service.ErrWalletNotFound)
+	summary, err = organizations.Get(ctx, acme.ID)
+	require.NoError(t, err)
+	require.Zero(t, summary.Wallets)
+	require.Empty(t, summary.Balances)
+}
@@ -0,0 +1,45 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/payment"
+)
+
+// stubProvider is a payment provider adapter that accepts every refund
+type stubProvider struct {
+	name string
+}
+
+func (p stubProvider) Name() string { return p.name }
+
+func (p stubProvider) Refund(ctx context.Context, req payment.RefundRequest) (*payment.RefundResult, error) {
+	return &payment.RefundResult{RefundID: "rf_" + req.IdempotencyKey, Status: payment.RefundPending}, nil
+}
+
+func (p stubProvider) GetRefund(ctx context.Context, refundID string) (*payment.RefundResult, error) {
+	return &payment.RefundResult{RefundID: refundID, Status: payment.RefundSucceeded}, nil
+}
+
+// TestPaymentRegistry tests provider lookup and registration errors
+func TestPaymentRegistry(t *testing.T) {
+	registry, err := payment.NewRegistry(stubProvider{name: "razorpay"}, stubProvider{name: "cashfree"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"cashfree", "razorpay"}, registry.Names())
+
+	provider, err := registry.Get("razorpay")
+	require.NoError(t, err)
+	require.Equal(t, "razorpay", provider.Name())
+
+	_, err = registry.Get("stripe")
+	require.ErrorIs(t, err, payment.ErrProviderNotConfigured)
+
+	_, err = payment.NewRegistry(stubProvider{name: "razorpay"}, stubProvider{name: "razorpay"})
+	require.Error(t, err)
+
+	_, err = payment.NewRegistry(nil)
+	require.Error(t, err)
+}
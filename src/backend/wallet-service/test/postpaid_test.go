@@ -0,0 +1,151 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestPostpaidBilling tests that debits of postpaid customers are accrued
+// rather than posted, billed at the end of the cycle and settled against the
+// wallet, or invoiced when the wallet cannot cover them
+func TestPostpaidBilling(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+	currencies := supportedCurrencies(t)
+
+	bus := eventbus.New()
+	base, err := service.NewWalletService(kit.Store, currencies, decimal.Zero, bus, &alertLogger{})
+	require.NoError(t, err)
+	receivables, err := service.NewInvoiceReceivableService(kit.Store, kit.Store, currencies, models.PaymentTermsNet30, service.LateFeePolicy{}, &alertLogger{})
+	require.NoError(t, err)
+	require.NoError(t, bus.Subscribe("invoice-receivables", receivables.Handle, eventbus.TypeInvoiceIssued))
+	postpaid, err := service.NewPostpaidService(kit.Store, kit.Store, base, receivables, bus, currencies, &alertLogger{})
+	require.NoError(t, err)
+	wallets, err := service.NewPostpaidWalletService(base, postpaid)
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "INR", Balance: 100}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+	debit := func(amount float64) *models.Transaction {
+		tx := &models.Transaction{
+			ID:          uuid.New(),
+			WalletID:    wallet.ID,
+			Type:        models.TransactionTypeDebit,
+			Status:      models.TransactionStatusInitiated,
+			Amount:      amount,
+			Currency:    "INR",
+			Description: "API usage",
+			CreatedAt:   time.Now().UTC(),
+		}
+		_, err := wallets.ProcessTransaction(ctx, tx)
+		require.NoError(t, err)
+		return tx
+	}
+	balance := func() float64 {
+		stored, err := kit.Store.GetWallet(ctx, wallet.ID)
+		require.NoError(t, err)
+		return stored.Balance
+	}
+
+	// Customers are prepaid until switched, debited as they use
+	mode, err := postpaid.BillingMode(ctx, wallet.CustomerID)
+	require.NoError(t, err)
+	require.Equal(t, models.BillingPrepaid, mode)
+	debit(20)
+	require.Equal(t, 80.0, balance())
+
+	_, err = postpaid.UpdateBillingMode(ctx, wallet.CustomerID, "CREDIT", "", "finance")
+	require.ErrorIs(t, err, service.ErrInvalidBillingMode)
+	_, err = postpaid.UpdateBillingMode(ctx, wallet.CustomerID, models.BillingPrepaid, models.SettleExternal, "finance")
+	require.ErrorIs(t, err, service.ErrInvalidBillingMode)
+	_, err = postpaid.UpdateBillingMode(ctx, uuid.New(), models.BillingPostpaid, "", "finance")
+	require.ErrorIs(t, err, service.ErrCustomerNotFound)
+	settings, err := postpaid.UpdateBillingMode(ctx, wallet.CustomerID, models.BillingPostpaid, "", "finance")
+	require.NoError(t, err)
+	require.Equal(t, models.BillingPostpaid, settings.BillingMode)
+
+	// Postpaid debits are accrued whatever the balance; the balance stays
+	accrued := debit(300)
+	require.Equal(t, models.TransactionStatusProcessing, accrued.Status)
+	debit(25.5)
+	require.Equal(t, 80.0, balance())
+	usage, err := postpaid.GetUsage(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.BillingPostpaid, usage.BillingMode)
+	require.Equal(t, 2, usage.UnbilledCharges)
+	require.Equal(t, "325.5", usage.UnbilledAmount.String())
+	require.Empty(t, usage.Statements)
+
+	// Charges of the cycle in progress wait for its end
+	settled, err := postpaid.CloseCycle(ctx, time.Now())
+	require.NoError(t, err)
+	require.Zero(t, settled)
+
+	// The wallet cannot cover the statement, so it is invoiced and tracked
+	// until paid
+	nextCycle := time.Now().UTC().AddDate(0, 1, 0)
+	settled, err = postpaid.CloseCycle(ctx, nextCycle)
+	require.NoError(t, err)
+	require.Equal(t, 1, settled)
+	require.Equal(t, 80.0, balance())
+	usage, err = postpaid.GetUsage(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Zero(t, usage.UnbilledCharges)
+	require.Len(t, usage.Statements, 1)
+	statement := usage.Statements[0]
+	require.Equal(t, models.StatementInvoiced, statement.Status)
+	require.Equal(t, models.SettleExternal, statement.Settlement)
+	require.Equal(t, 2, statement.Charges)
+	require.Equal(t, "325.5", statement.TotalAmount.String())
+	invoice, err := receivables.Get(ctx, statement.ID)
+	require.NoError(t, err)
+	require.Equal(t, statement.InvoiceNumber, invoice.InvoiceNumber)
+	require.True(t, invoice.DueDate.Equal(models.PaymentTermsNet30.DueDate(nextCycle)))
+
+	// Statements the wallet covers are debited from it, once
+	debit(50)
+	settled, err = postpaid.CloseCycle(ctx, nextCycle.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	require.Equal(t, 1, settled)
+	require.Equal(t, 30.0, balance())
+	settled, err = postpaid.CloseCycle(ctx, nextCycle.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	require.Zero(t, settled)
+	usage, err = postpaid.GetUsage(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Len(t, usage.Statements, 2)
+	statement = usage.Statements[0]
+	require.Equal(t, models.StatementSettled, statement.Status)
+	require.NotNil(t, statement.TransactionID)
+	matches, err := base.FindTransactionsByReference(ctx, statement.InvoiceNumber)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	require.Equal(t, *statement.TransactionID, matches[0].Transaction.ID)
+
+	// Statements of customers settling externally are always invoiced;
+	// credits post as before
+	_, err = postpaid.UpdateBillingMode(ctx, wallet.CustomerID, models.BillingPostpaid, models.SettleExternal, "finance")
+	require.NoError(t, err)
+	credit := &models.Transaction{ID: uuid.New(), WalletID: wallet.ID, Type: models.TransactionTypeCredit, Amount: 500, Currency: "INR", CreatedAt: time.Now().UTC()}
+	_, err = wallets.ProcessTransaction(ctx, credit)
+	require.NoError(t, err)
+	require.Equal(t, 530.0, balance())
+	debit(10)
+	settled, err = postpaid.CloseCycle(ctx, nextCycle.AddDate(0, 2, 0))
+	require.NoError(t, err)
+	require.Equal(t, 1, settled)
+	require.Equal(t, 530.0, balance())
+	usage, err = postpaid.GetUsage(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.StatementInvoiced, usage.Statements[0].Status)
+}
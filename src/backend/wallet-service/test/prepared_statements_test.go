@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/jackc/pgx/v5/pgconn"      // v5.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/repository"
+)
+
+// poolerDatabase is a database answering every query with one row of two
+// counts, which forgets its prepared statements when reset, as behind a
+// transaction pooler
+type poolerDatabase struct {
+	mu       sync.Mutex
+	prepared []string
+	known    map[int]bool
+}
+
+func (d *poolerDatabase) Connect(ctx context.Context) (driver.Conn, error) {
+	return &poolerConn{db: d}, nil
+}
+func (d *poolerDatabase) Driver() driver.Driver { return nil }
+
+// reset forgets every prepared statement
+func (d *poolerDatabase) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.known = nil
+}
+
+type poolerConn struct {
+	db *poolerDatabase
+}
+
+func (c *poolerConn) Prepare(query string) (driver.Stmt, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.prepared = append(c.db.prepared, query)
+	if c.db.known == nil {
+		c.db.known = make(map[int]bool)
+	}
+	id := len(c.db.prepared)
+	c.db.known[id] = true
+	return &poolerStmt{db: c.db, id: id}, nil
+}
+func (c *poolerConn) Close() error              { return nil }
+func (c *poolerConn) Begin() (driver.Tx, error) { return nil, errors.New("transactions not supported") }
+
+type poolerStmt struct {
+	db *poolerDatabase
+	id int
+}
+
+func (s *poolerStmt) Close() error  { return nil }
+func (s *poolerStmt) NumInput() int { return -1 }
+func (s *poolerStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec not supported")
+}
+
+func (s *poolerStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if !s.db.known[s.id] {
+		return nil, &pgconn.PgError{Code: "26000", Message: "prepared statement does not exist"}
+	}
+	return &countRows{}, nil
+}
+
+// countRows is one row of a total and a failed count
+type countRows struct {
+	done bool
+}
+
+func (r *countRows) Columns() []string { return []string{"total", "failed"} }
+func (r *countRows) Close() error      { return nil }
+func (r *countRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0], dest[1] = int64(3), int64(1)
+	return nil
+}
+
+// TestPreparedStatements tests that statements are prepared on first use,
+// prepared again once the database forgets them, and refused once closed
+func TestPreparedStatements(t *testing.T) {
+	ctx := context.Background()
+	database := &poolerDatabase{}
+	db := sql.OpenDB(database)
+	defer db.Close()
+
+	repo, err := repository.NewWalletRepository(db)
+	require.NoError(t, err)
+	require.Empty(t, database.prepared)
+
+	walletID := uuid.New()
+	since := time.Now().Add(-time.Hour)
+	total, failed, err := repo.CountTransactionOutcomes(ctx, walletID, since)
+	require.NoError(t, err)
+	require.Equal(t, 3, total)
+	require.Equal(t, 1, failed)
+	require.Len(t, database.prepared, 1)
+	require.True(t, strings.HasPrefix(database.prepared[0], "-- name: countTransactionOutcomes\n"))
+
+	// Statements are reused until the database forgets them
+	_, _, err = repo.CountTransactionOutcomes(ctx, walletID, since)
+	require.NoError(t, err)
+	require.Len(t, database.prepared, 1)
+
+	database.reset()
+	total, _, err = repo.CountTransactionOutcomes(ctx, walletID, since)
+	require.NoError(t, err)
+	require.Equal(t, 3, total)
+	require.Len(t, database.prepared, 2)
+
+	require.NoError(t, repo.Close())
+	_, _, err = repo.CountTransactionOutcomes(ctx, walletID, since)
+	require.ErrorIs(t, err, repository.ErrStatementsClosed)
+}
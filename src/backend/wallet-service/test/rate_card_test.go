@@ -0,0 +1,140 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/events"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// financeOutbox collects the envelopes sent to finance, failing while down
+type financeOutbox struct {
+	down      bool
+	envelopes []*events.Envelope
+}
+
+func (o *financeOutbox) Publish(ctx context.Context, env *events.Envelope) error {
+	if o.down {
+		return errors.New("notification pipeline unavailable")
+	}
+	o.envelopes = append(o.envelopes, env)
+	return nil
+}
+
+// TestRateCardFourEyes tests that rate card changes are stored with their
+// diff, need approval by a second operator and only take effect at their
+// effective date once finance was notified
+func TestRateCardFourEyes(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	ctx := context.Background()
+
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+	outbox := &financeOutbox{}
+	forwarder, err := events.NewForwarder(registry, outbox, grantedConsents{})
+	require.NoError(t, err)
+	bus := eventbus.New()
+	require.NoError(t, bus.Subscribe("event-stream", forwarder.Handle, forwarder.EventTypes()...))
+
+	base := models.RateCard{
+		PerThousandRequests: decimal.NewFromInt(10),
+		PerDBSecond:         decimal.NewFromInt(2),
+		PerGB:               decimal.NewFromInt(5),
+	}
+	rateCards, err := service.NewRateCardService(kit.Store, bus, base, service.RateCardPolicy{MinNotice: time.Hour}, &alertLogger{})
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	proposed := base
+	proposed.PerThousandRequests = decimal.RequireFromString("12.5")
+
+	_, err = rateCards.Propose(ctx, proposed, now.Add(30*time.Minute), "alice", "Database costs rose")
+	require.ErrorIs(t, err, service.ErrInvalidRateCardChange)
+	_, err = rateCards.Propose(ctx, base, now.Add(2*time.Hour), "alice", "No change")
+	require.ErrorIs(t, err, service.ErrInvalidRateCardChange)
+
+	change, err := rateCards.Propose(ctx, proposed, now.Add(2*time.Hour), "alice", "Database costs rose")
+	require.NoError(t, err)
+	require.Equal(t, models.RateCardChangePending, change.Status)
+	require.Len(t, change.Diff, 1)
+	require.Equal(t, models.RatePerThousandRequests, change.Diff[0].Field)
+	require.Equal(t, "10", change.Diff[0].From.String())
+	require.Equal(t, "12.5", change.Diff[0].To.String())
+
+	_, err = rateCards.Propose(ctx, proposed, now.Add(3*time.Hour), "bob", "Competing change")
+	require.ErrorIs(t, err, service.ErrRateCardChangeConflict)
+
+	_, err = rateCards.Approve(ctx, change.ID, "alice", "")
+	require.ErrorIs(t, err, service.ErrSelfApproval)
+
+	// An approval while finance cannot be notified activates nothing
+	outbox.down = true
+	change, err = rateCards.Approve(ctx, change.ID, "bob", "Matches the finance forecast")
+	require.NoError(t, err)
+	require.Equal(t, models.RateCardChangeApproved, change.Status)
+	require.Nil(t, change.FinanceNotifiedAt)
+	require.Error(t, rateCards.ActivateDue(ctx, now.Add(3*time.Hour)))
+	rates, err := rateCards.RatesAt(ctx, now.Add(4*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, "10", rates.PerThousandRequests.String())
+
+	// The notice is retried before the effective date
+	outbox.down = false
+	require.NoError(t, rateCards.ActivateDue(ctx, now.Add(time.Hour)))
+	require.Len(t, outbox.envelopes, 1)
+	env := outbox.envelopes[0]
+	require.Equal(t, events.TypeRateCardApproved, env.Type)
+	require.NoError(t, registry.Validate(env))
+	var notice events.RateCardApprovedV1
+	require.NoError(t, json.Unmarshal(env.Payload, &notice))
+	require.Equal(t, change.ID.String(), notice.ChangeID)
+	require.Equal(t, "alice", notice.ProposedBy)
+	require.Equal(t, "bob", notice.ApprovedBy)
+	require.Len(t, notice.Diff, 1)
+
+	change, err = rateCards.Get(ctx, change.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.RateCardChangeApproved, change.Status)
+	require.NotNil(t, change.FinanceNotifiedAt)
+
+	require.NoError(t, rateCards.ActivateDue(ctx, now.Add(3*time.Hour)))
+	require.Len(t, outbox.envelopes, 1)
+	change, err = rateCards.Get(ctx, change.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.RateCardChangeActive, change.Status)
+
+	rates, err = rateCards.RatesAt(ctx, now.Add(4*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, "12.5", rates.PerThousandRequests.String())
+	rates, err = rateCards.Current(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "10", rates.PerThousandRequests.String())
+
+	// Proposers may withdraw their change, which can then not be approved
+	cheaper := base
+	cheaper.PerGB = decimal.NewFromInt(4)
+	withdrawn, err := rateCards.Propose(ctx, cheaper, now.Add(2*time.Hour), "bob", "Cheaper egress")
+	require.NoError(t, err)
+	_, err = rateCards.Reject(ctx, withdrawn.ID, "bob", "")
+	require.ErrorIs(t, err, service.ErrInvalidRateCardChange)
+	withdrawn, err = rateCards.Reject(ctx, withdrawn.ID, "bob", "Proposed by mistake")
+	require.NoError(t, err)
+	require.Equal(t, models.RateCardChangeRejected, withdrawn.Status)
+	_, err = rateCards.Approve(ctx, withdrawn.ID, "alice", "")
+	require.ErrorIs(t, err, service.ErrRateCardChangeConflict)
+
+	changes, err := rateCards.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, withdrawn.ID, changes[0].ID)
+}
@@ -0,0 +1,276 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"    // v2.30.4
+	"github.com/gin-gonic/gin"            // v1.9.1
+	"github.com/go-redis/redis/v8"        // v8.11.5
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/api"
+	"internal/health"
+	"internal/models"
+	"internal/ratelimit"
+	"internal/repository"
+)
+
+// newWindowRedis returns a Redis server with its clock frozen at start and a
+// client connected to it
+func newWindowRedis(t *testing.T, start time.Time) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	server.SetTime(start)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+// TestSlidingWindowLimiter tests that requests are admitted up to the limit
+// within the window, that rejections consume no capacity and that capacity
+// returns as the oldest requests slide out of the window
+func TestSlidingWindowLimiter(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	server, client := newWindowRedis(t, start)
+	limiter, err := ratelimit.NewLimiter(client, "")
+	require.NoError(t, err)
+	policy := models.RateLimitPolicy{Limit: 3, Window: time.Second}
+
+	for i, offset := range []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond} {
+		server.SetTime(start.Add(offset))
+		result, err := limiter.Allow(ctx, "customer-1", policy)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, 3, result.Limit)
+		require.Equal(t, 2-i, result.Remaining)
+		require.Equal(t, start.Add(time.Second), result.ResetAt.UTC())
+	}
+
+	// Rejected requests are not recorded in the window
+	server.SetTime(start.Add(500 * time.Millisecond))
+	for i := 0; i < 2; i++ {
+		result, err := limiter.Allow(ctx, "customer-1", policy)
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+		require.Zero(t, result.Remaining)
+		require.Equal(t, start.Add(time.Second), result.ResetAt.UTC())
+	}
+	members, err := server.ZMembers("ratelimit:customer-1")
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+	require.Equal(t, time.Second, server.TTL("ratelimit:customer-1"))
+
+	// Other subjects count in their own window
+	result, err := limiter.Allow(ctx, "customer-2", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 2, result.Remaining)
+
+	// Once the first request slides out one more request fits
+	server.SetTime(start.Add(time.Second + time.Microsecond))
+	result, err = limiter.Allow(ctx, "customer-1", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+	require.Equal(t, start.Add(1100*time.Millisecond), result.ResetAt.UTC())
+
+	result, err = limiter.Allow(ctx, "customer-1", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+
+	_, err = limiter.Allow(ctx, "customer-1", models.RateLimitPolicy{Limit: 0, Window: time.Second})
+	require.Error(t, err)
+	_, err = limiter.Allow(ctx, "customer-1", models.RateLimitPolicy{Limit: 1})
+	require.Error(t, err)
+}
+
+// TestSlidingWindowLimiterNamespaces tests that limiters in different
+// namespaces keep separate windows for the same subject
+func TestSlidingWindowLimiterNamespaces(t *testing.T) {
+	ctx := context.Background()
+	server, client := newWindowRedis(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	policy := models.RateLimitPolicy{Limit: 1, Window: time.Minute}
+
+	_, err := ratelimit.NewLimiter(nil, "")
+	require.Error(t, err)
+
+	shared, err := ratelimit.NewLimiter(client, "")
+	require.NoError(t, err)
+	staging, err := ratelimit.NewLimiter(client, "staging")
+	require.NoError(t, err)
+
+	result, err := shared.Allow(ctx, "customer-1", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	result, err = shared.Allow(ctx, "customer-1", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+
+	result, err = staging.Allow(ctx, "customer-1", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	require.True(t, server.Exists("ratelimit:customer-1"))
+	require.True(t, server.Exists("ratelimit:staging:customer-1"))
+}
+
+// tierPolicies is a RateLimitRepository resolving configured customer
+// policies and counting lookups
+type tierPolicies struct {
+	policies map[uuid.UUID]*models.RateLimitPolicy
+	err      error
+	lookups  int
+}
+
+func (r *tierPolicies) GetCustomerPolicy(ctx context.Context, customerID uuid.UUID) (*models.RateLimitPolicy, error) {
+	r.lookups++
+	if r.err != nil {
+		return nil, r.err
+	}
+	policy, ok := r.policies[customerID]
+	if !ok {
+		return nil, repository.ErrRateLimitPolicyNotFound
+	}
+	copied := *policy
+	return &copied, nil
+}
+
+func (r *tierPolicies) Close() error {
+	return nil
+}
+
+// TestPolicyResolver tests that customers get their override or plan tier,
+// that customers without either get the default policy, and that both are
+// cached so a customer is looked up once per cache TTL
+func TestPolicyResolver(t *testing.T) {
+	ctx := context.Background()
+	server, client := newWindowRedis(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	override, planned, untiered := uuid.New(), uuid.New(), uuid.New()
+	repo := &tierPolicies{policies: map[uuid.UUID]*models.RateLimitPolicy{
+		override: {Tier: "enterprise", Limit: 5000, Window: time.Minute},
+		planned:  {Tier: "premium", Limit: 1000, Window: time.Minute},
+	}}
+	defaults := models.RateLimitPolicy{Tier: "default", Limit: 100, Window: time.Minute}
+	resolver, err := ratelimit.NewPolicyResolver(repo, client, time.Minute, defaults)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		policy, err := resolver.Resolve(ctx, override.String())
+		require.NoError(t, err)
+		require.Equal(t, models.RateLimitPolicy{Tier: "enterprise", Limit: 5000, Window: time.Minute}, policy)
+
+		policy, err = resolver.Resolve(ctx, planned.String())
+		require.NoError(t, err)
+		require.Equal(t, "premium", policy.Tier)
+		require.Equal(t, 1000, policy.Limit)
+
+		policy, err = resolver.Resolve(ctx, untiered.String())
+		require.NoError(t, err)
+		require.Equal(t, defaults, policy)
+	}
+	require.Equal(t, 3, repo.lookups)
+
+	// Cached customers without a tier follow a reloaded default policy
+	reloaded := models.RateLimitPolicy{Tier: "default", Limit: 200, Window: time.Minute}
+	require.NoError(t, resolver.SetDefaultPolicy(reloaded))
+	policy, err := resolver.Resolve(ctx, untiered.String())
+	require.NoError(t, err)
+	require.Equal(t, reloaded, policy)
+	require.Equal(t, 3, repo.lookups)
+
+	// Tier changes apply once the cache is invalidated or expires
+	repo.policies[untiered] = &models.RateLimitPolicy{Tier: "premium", Limit: 1000, Window: time.Minute}
+	require.NoError(t, resolver.Invalidate(ctx, untiered))
+	policy, err = resolver.Resolve(ctx, untiered.String())
+	require.NoError(t, err)
+	require.Equal(t, "premium", policy.Tier)
+
+	repo.policies[planned].Limit = 2000
+	server.FastForward(time.Minute)
+	policy, err = resolver.Resolve(ctx, planned.String())
+	require.NoError(t, err)
+	require.Equal(t, 2000, policy.Limit)
+	require.Equal(t, 5, repo.lookups)
+
+	// Callers without a customer get the default policy, scaled like the rest
+	require.NoError(t, resolver.SetLimitMultiplier(3))
+	policy, err = resolver.Resolve(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, 600, policy.Limit)
+	policy, err = resolver.Resolve(ctx, planned.String())
+	require.NoError(t, err)
+	require.Equal(t, 6000, policy.Limit)
+	require.Equal(t, 5, repo.lookups)
+
+	// Lookup failures fall back to the default policy without caching it
+	repo.err = errors.New("connection refused")
+	failed := uuid.New()
+	for i := 0; i < 2; i++ {
+		policy, err = resolver.Resolve(ctx, failed.String())
+		require.ErrorIs(t, err, repo.err)
+		require.Equal(t, 600, policy.Limit)
+	}
+	require.Equal(t, 7, repo.lookups)
+}
+
+// TestRateLimitMiddleware tests the rate limit headers, and that callers
+// without a customer are limited by their token subject under the default
+// policy
+func TestRateLimitMiddleware(t *testing.T) {
+	_, client := newWindowRedis(t, time.Now())
+	customerID := uuid.New()
+	repo := &tierPolicies{policies: map[uuid.UUID]*models.RateLimitPolicy{
+		customerID: {Tier: "premium", Limit: 2, Window: time.Minute},
+	}}
+	resolver, err := ratelimit.NewPolicyResolver(repo, client, time.Minute, models.RateLimitPolicy{Tier: "default", Limit: 1, Window: time.Minute})
+	require.NoError(t, err)
+	limiter, err := ratelimit.NewLimiter(client, "")
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("subject", c.GetHeader("X-Subject"))
+		c.Set("customer_id", c.GetHeader("X-Customer-ID"))
+		c.Next()
+	}, api.RateLimitMiddleware(limiter, resolver, health.FailOpen))
+	router.GET("/api/v1/wallets", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	send := func(subject, customerID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets", nil)
+		req.Header.Set("X-Subject", subject)
+		req.Header.Set("X-Customer-ID", customerID)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("user-1", customerID.String())
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+	require.Equal(t, "1", rec.Header().Get("X-RateLimit-Remaining"))
+	reset, err := strconv.ParseInt(rec.Header().Get("X-RateLimit-Reset"), 10, 64)
+	require.NoError(t, err)
+	require.InDelta(t, time.Now().Add(time.Minute).Unix(), reset, 2)
+
+	require.Equal(t, http.StatusOK, send("user-1", customerID.String()).Code)
+	rec = send("user-1", customerID.String())
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+	require.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	// Operator tokens carry no customer and count per subject
+	rec = send("ops", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "1", rec.Header().Get("X-RateLimit-Limit"))
+	require.Equal(t, http.StatusTooManyRequests, send("ops", "").Code)
+	require.Equal(t, http.StatusOK, send("billing-job", "").Code)
+}
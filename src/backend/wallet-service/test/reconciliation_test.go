@@ -0,0 +1,199 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/repository"
+	"internal/service"
+)
+
+// reconciliationLedger is a ReconciliationRepository reporting a configured
+// set of discrepancies and keeping issues with the open/resolved lifecycle
+// of the Postgres repository
+type reconciliationLedger struct {
+	wallets       int64
+	discrepancies []*models.ReconciliationIssue
+	findErr       error
+	issues        []*models.ReconciliationIssue
+	ledger        map[uuid.UUID][]*models.LedgerEntrySummary
+}
+
+func (r *reconciliationLedger) FindDiscrepancies(ctx context.Context) (int64, []*models.ReconciliationIssue, error) {
+	if r.findErr != nil {
+		return 0, nil, r.findErr
+	}
+	issues := make([]*models.ReconciliationIssue, len(r.discrepancies))
+	for i, discrepancy := range r.discrepancies {
+		issue := *discrepancy
+		issue.Status = models.ReconciliationIssueOpen
+		issues[i] = &issue
+	}
+	return r.wallets, issues, nil
+}
+
+func (r *reconciliationLedger) UpsertOpenIssue(ctx context.Context, issue *models.ReconciliationIssue) error {
+	for _, open := range r.issues {
+		if open.WalletID == issue.WalletID && open.Status == models.ReconciliationIssueOpen {
+			open.StoredBalance = issue.StoredBalance
+			open.LedgerBalance = issue.LedgerBalance
+			open.Difference = issue.Difference
+			open.LastCheckedAt = issue.LastCheckedAt
+			issue.ID, issue.DetectedAt = open.ID, open.DetectedAt
+			return nil
+		}
+	}
+	stored := *issue
+	stored.ID = uuid.New()
+	stored.DetectedAt = issue.LastCheckedAt
+	r.issues = append(r.issues, &stored)
+	issue.ID, issue.DetectedAt = stored.ID, stored.DetectedAt
+	return nil
+}
+
+func (r *reconciliationLedger) ResolveIssues(ctx context.Context, stillOpen []uuid.UUID, resolvedAt time.Time) (int64, error) {
+	open := make(map[uuid.UUID]bool, len(stillOpen))
+	for _, id := range stillOpen {
+		open[id] = true
+	}
+	var resolved int64
+	for _, issue := range r.issues {
+		if issue.Status == models.ReconciliationIssueOpen && !open[issue.WalletID] {
+			issue.Status = models.ReconciliationIssueResolved
+			at := resolvedAt
+			issue.ResolvedAt = &at
+			resolved++
+		}
+	}
+	return resolved, nil
+}
+
+func (r *reconciliationLedger) ListIssues(ctx context.Context, status models.ReconciliationIssueStatus, limit, offset int) ([]*models.ReconciliationIssue, error) {
+	var issues []*models.ReconciliationIssue
+	for _, issue := range r.issues {
+		if status == "" || issue.Status == status {
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+func (r *reconciliationLedger) GetIssue(ctx context.Context, id uuid.UUID) (*models.ReconciliationIssue, error) {
+	for _, issue := range r.issues {
+		if issue.ID == id {
+			return issue, nil
+		}
+	}
+	return nil, repository.ErrReconciliationIssueNotFound
+}
+
+func (r *reconciliationLedger) GetLedgerSummary(ctx context.Context, walletID uuid.UUID) ([]*models.LedgerEntrySummary, error) {
+	return r.ledger[walletID], nil
+}
+
+func (r *reconciliationLedger) Close() error {
+	return nil
+}
+
+// TestReconciliationRuns tests that a run opens an issue per discrepancy,
+// that later runs refresh issues still in disagreement without duplicating
+// them and resolve the others
+func TestReconciliationRuns(t *testing.T) {
+	ctx := context.Background()
+	drifted, repaired := uuid.New(), uuid.New()
+	repo := &reconciliationLedger{
+		wallets: 10,
+		discrepancies: []*models.ReconciliationIssue{
+			{WalletID: drifted, Currency: "INR", StoredBalance: decimal.NewFromInt(100), LedgerBalance: decimal.NewFromInt(90), Difference: decimal.NewFromInt(10)},
+			{WalletID: repaired, Currency: "USD", StoredBalance: decimal.NewFromInt(5), LedgerBalance: decimal.NewFromInt(7), Difference: decimal.NewFromInt(-2)},
+		},
+	}
+	logger := &alertLogger{}
+	reconciliation, err := service.NewReconciliationService(repo, logger)
+	require.NoError(t, err)
+
+	run, err := reconciliation.Run(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 10, run.WalletsChecked)
+	require.Equal(t, 2, run.IssuesOpen)
+	require.Zero(t, run.IssuesResolved)
+	require.False(t, run.CompletedAt.Before(run.StartedAt))
+	require.Len(t, repo.issues, 2)
+	detected := repo.issues[0].DetectedAt
+	require.Equal(t, run.StartedAt, detected)
+
+	// The drifted wallet moves further apart and the other is repaired
+	repo.discrepancies = repo.discrepancies[:1]
+	repo.discrepancies[0].StoredBalance = decimal.NewFromInt(120)
+	repo.discrepancies[0].Difference = decimal.NewFromInt(30)
+	run, err = reconciliation.Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, run.IssuesOpen)
+	require.EqualValues(t, 1, run.IssuesResolved)
+
+	open, err := reconciliation.ListIssues(ctx, models.ReconciliationIssueOpen, service.Pagination{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	require.Equal(t, drifted, open[0].WalletID)
+	require.Equal(t, "30", open[0].Difference.String())
+	require.Equal(t, detected, open[0].DetectedAt)
+	require.Equal(t, run.StartedAt, open[0].LastCheckedAt)
+
+	resolved, err := reconciliation.ListIssues(ctx, models.ReconciliationIssueResolved, service.Pagination{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, resolved, 1)
+	require.Equal(t, repaired, resolved[0].WalletID)
+	require.Equal(t, run.StartedAt, *resolved[0].ResolvedAt)
+
+	// A failed run leaves the issues as they were
+	repo.discrepancies = nil
+	repo.findErr = errors.New("connection reset")
+	_, err = reconciliation.Run(ctx)
+	require.ErrorIs(t, err, repo.findErr)
+	require.Equal(t, []string{"reconciliation failed"}, logger.alerts)
+	require.Equal(t, models.ReconciliationIssueOpen, open[0].Status)
+
+	repo.findErr = nil
+	run, err = reconciliation.Run(ctx)
+	require.NoError(t, err)
+	require.Zero(t, run.IssuesOpen)
+	require.EqualValues(t, 1, run.IssuesResolved)
+	require.Equal(t, models.ReconciliationIssueResolved, open[0].Status)
+}
+
+// TestReconciliationIssueDetail tests that an issue is shown with the ledger
+// totals of its wallet
+func TestReconciliationIssueDetail(t *testing.T) {
+	ctx := context.Background()
+	walletID := uuid.New()
+	ledger := []*models.LedgerEntrySummary{
+		{Type: "CREDIT", Status: "COMPLETED", Count: 2, Total: decimal.NewFromInt(100)},
+		{Type: "DEBIT", Status: "PROCESSING", Count: 1, Total: decimal.NewFromInt(10)},
+	}
+	repo := &reconciliationLedger{
+		wallets: 1,
+		discrepancies: []*models.ReconciliationIssue{
+			{WalletID: walletID, Currency: "INR", StoredBalance: decimal.NewFromInt(100), LedgerBalance: decimal.NewFromInt(90), Difference: decimal.NewFromInt(10)},
+		},
+		ledger: map[uuid.UUID][]*models.LedgerEntrySummary{walletID: ledger},
+	}
+	reconciliation, err := service.NewReconciliationService(repo, &alertLogger{})
+	require.NoError(t, err)
+	_, err = reconciliation.Run(ctx)
+	require.NoError(t, err)
+
+	detail, err := reconciliation.GetIssueDetail(ctx, repo.issues[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, walletID, detail.Issue.WalletID)
+	require.Equal(t, ledger, detail.Ledger)
+
+	_, err = reconciliation.GetIssueDetail(ctx, uuid.New())
+	require.ErrorIs(t, err, service.ErrReconciliationIssueNotFound)
+}
@@ -0,0 +1,226 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+	"internal/service"
+	"internal/testkit"
+)
+
+// recurringStore is an in-memory RecurringDebitRepository
+type recurringStore struct {
+	debits map[uuid.UUID]*models.RecurringDebit
+	runs   map[string]*models.RecurringDebitRun
+}
+
+func newRecurringStore() *recurringStore {
+	return &recurringStore{
+		debits: make(map[uuid.UUID]*models.RecurringDebit),
+		runs:   make(map[string]*models.RecurringDebitRun),
+	}
+}
+
+func (s *recurringStore) CreateRecurringDebit(ctx context.Context, debit *models.RecurringDebit) error {
+	debit.ID = uuid.New()
+	stored := *debit
+	s.debits[debit.ID] = &stored
+	return nil
+}
+
+func (s *recurringStore) GetRecurringDebit(ctx context.Context, customerID, id uuid.UUID) (*models.RecurringDebit, error) {
+	debit, ok := s.debits[id]
+	if !ok || debit.CustomerID != customerID {
+		return nil, repository.ErrRecurringDebitNotFound
+	}
+	copied := *debit
+	return &copied, nil
+}
+
+func (s *recurringStore) ListRecurringDebits(ctx context.Context, customerID uuid.UUID) ([]*models.RecurringDebit, error) {
+	var debits []*models.RecurringDebit
+	for _, debit := range s.debits {
+		if debit.CustomerID == customerID {
+			copied := *debit
+			debits = append(debits, &copied)
+		}
+	}
+	return debits, nil
+}
+
+func (s *recurringStore) CancelRecurringDebit(ctx context.Context, customerID, id uuid.UUID, now time.Time) (*models.RecurringDebit, error) {
+	debit, ok := s.debits[id]
+	if !ok || debit.CustomerID != customerID {
+		return nil, repository.ErrRecurringDebitNotFound
+	}
+	debit.CancelledAt = &now
+	debit.NextRunAt = nil
+	copied := *debit
+	return &copied, nil
+}
+
+func (s *recurringStore) ListDue(ctx context.Context, now time.Time, limit int) ([]*models.RecurringDebit, error) {
+	var debits []*models.RecurringDebit
+	for _, debit := range s.debits {
+		if debit.NextRunAt != nil && !debit.NextRunAt.After(now) {
+			copied := *debit
+			debits = append(debits, &copied)
+		}
+	}
+	return debits, nil
+}
+
+func (s *recurringStore) ListRuns(ctx context.Context, id uuid.UUID, limit int) ([]*models.RecurringDebitRun, error) {
+	var runs []*models.RecurringDebitRun
+	for _, run := range s.runs {
+		if run.RecurringDebitID == id {
+			copied := *run
+			runs = append(runs, &copied)
+		}
+	}
+	return runs, nil
+}
+
+func (s *recurringStore) ClaimRun(ctx context.Context, run *models.RecurringDebitRun, staleBefore time.Time) (bool, error) {
+	existing, ok := s.runs[run.IdempotencyKey]
+	if ok && (existing.Status != models.RecurringRunPending || !existing.ClaimedAt.Before(staleBefore)) {
+		return false, nil
+	}
+	copied := *run
+	s.runs[run.IdempotencyKey] = &copied
+	return true, nil
+}
+
+func (s *recurringStore) ReleaseRun(ctx context.Context, run *models.RecurringDebitRun) error {
+	delete(s.runs, run.IdempotencyKey)
+	return nil
+}
+
+func (s *recurringStore) FinishRun(ctx context.Context, run *models.RecurringDebitRun, runCount int, nextRunAt *time.Time) error {
+	copied := *run
+	s.runs[run.IdempotencyKey] = &copied
+
+	debit := s.debits[run.RecurringDebitID]
+	if debit.RunCount == runCount {
+		debit.RunCount++
+		if debit.CancelledAt == nil {
+			debit.NextRunAt = nextRunAt
+		}
+	}
+	return nil
+}
+
+func (s *recurringStore) RecoverRun(ctx context.Context, run *models.RecurringDebitRun) error {
+	existing, ok := s.runs[run.IdempotencyKey]
+	if ok && existing.Status == models.RecurringRunFailed {
+		existing.Status = models.RecurringRunSucceeded
+		existing.TransactionID = run.TransactionID
+		existing.Error = ""
+		existing.FinishedAt = run.FinishedAt
+	}
+	return nil
+}
+
+func (s *recurringStore) Close() error {
+	return nil
+}
+
+// TestRecurringDebitCatchUp tests that runs missed during downtime are
+// caught up within the catch-up window and skipped beyond it, and that each
+// run debits the wallet once
+func TestRecurringDebitCatchUp(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	ctx := context.Background()
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+
+	failures, err := service.NewFailedJobService(kit.Store, &auditLog{}, &alertLogger{})
+	require.NoError(t, err)
+
+	store := newRecurringStore()
+	recurring, err := service.NewRecurringDebitService(store, wallets, service.RecurringDebitPolicy{
+		CatchUpWindow: 36 * time.Hour,
+		ClaimTimeout:  10 * time.Minute,
+	}, failures, &alertLogger{})
+	require.NoError(t, err)
+
+	_, err = recurring.Create(ctx, uuid.New(), service.RecurringDebitInput{
+		WalletID: wallet.ID,
+		Amount:   decimal.NewFromInt(10),
+		Cadence:  models.CadenceDaily,
+	})
+	require.ErrorIs(t, err, service.ErrWalletNotFound)
+
+	debit, err := recurring.Create(ctx, customerID, service.RecurringDebitInput{
+		WalletID: wallet.ID,
+		Amount:   decimal.NewFromInt(10),
+		Cadence:  models.CadenceDaily,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "INR", debit.Currency)
+	require.Equal(t, models.RecurringDebitActive, debit.Status())
+
+	// Back after three days of downtime: the runs due 72h and 48h ago are
+	// skipped, the runs due 24h ago and now are debited
+	now := debit.StartAt.Add(72 * time.Hour)
+	finished, err := recurring.ProcessDue(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 4, finished)
+
+	finished, err = recurring.ProcessDue(ctx, now)
+	require.NoError(t, err)
+	require.Zero(t, finished)
+
+	balance, _, err := wallets.GetWalletBalance(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, "80", balance.String())
+
+	runs, err := recurring.ListRuns(ctx, customerID, debit.ID)
+	require.NoError(t, err)
+	statuses := make(map[models.RecurringRunStatus]int)
+	for _, run := range runs {
+		statuses[run.Status]++
+		if run.Status == models.RecurringRunSucceeded {
+			require.NotNil(t, run.TransactionID)
+		}
+	}
+	require.Equal(t, 2, statuses[models.RecurringRunSkipped])
+	require.Equal(t, 2, statuses[models.RecurringRunSucceeded])
+
+	cancelled, err := recurring.Cancel(ctx, customerID, debit.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.RecurringDebitCancelled, cancelled.Status())
+
+	finished, err = recurring.ProcessDue(ctx, now.Add(48*time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, finished)
+}
+
+// TestRecurringDebitMonthlyRuns tests that monthly runs keep the day of the
+// start, falling back to the last day of shorter months
+func TestRecurringDebitMonthlyRuns(t *testing.T) {
+	debit := &models.RecurringDebit{
+		Cadence: models.CadenceMonthly,
+		StartAt: time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC),
+	}
+
+	require.Equal(t, time.Date(2026, 2, 28, 9, 0, 0, 0, time.UTC), debit.RunAt(1))
+	require.Equal(t, time.Date(2026, 3, 31, 9, 0, 0, 0, time.UTC), debit.RunAt(2))
+	require.Equal(t, time.Date(2027, 1, 31, 9, 0, 0, 0, time.UTC), debit.RunAt(12))
+
+	end := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	debit.EndAt = &end
+	require.NotNil(t, debit.NextRun(1))
+	require.Nil(t, debit.NextRun(2))
+}
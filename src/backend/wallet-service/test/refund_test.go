@@ -0,0 +1,137 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/calendar"
+	"internal/currency"
+	"internal/eventbus"
+	"internal/models"
+	"internal/payment"
+	"internal/repository"
+	"internal/service"
+)
+
+// refundLedger is a RefundRepository over top-ups paid in fixed currencies
+type refundLedger struct {
+	currencies map[uuid.UUID]string
+	refunds    map[uuid.UUID]*models.ProviderRefund
+}
+
+func (l *refundLedger) RecordPayment(ctx context.Context, walletID uuid.UUID, p *models.ProviderPayment) error {
+	return nil
+}
+
+func (l *refundLedger) GetPaymentCurrency(ctx context.Context, walletID, transactionID uuid.UUID) (string, error) {
+	code, ok := l.currencies[transactionID]
+	if !ok {
+		return "", repository.ErrPaymentNotFound
+	}
+	return code, nil
+}
+
+func (l *refundLedger) CreateRefund(ctx context.Context, refund *models.ProviderRefund, description string) error {
+	refund.ID = uuid.New()
+	refund.TransactionID = uuid.New()
+	refund.Currency = l.currencies[refund.OriginalTransactionID]
+	refund.Provider = "razorpay"
+	refund.ProviderPaymentID = "pay_1"
+	refund.Status = models.ProviderRefundPending
+	l.refunds[refund.ID] = refund
+	return nil
+}
+
+func (l *refundLedger) GetRefund(ctx context.Context, walletID, id uuid.UUID) (*models.ProviderRefund, error) {
+	refund, ok := l.refunds[id]
+	if !ok {
+		return nil, repository.ErrRefundNotFound
+	}
+	return refund, nil
+}
+
+func (l *refundLedger) ListDueRefunds(ctx context.Context, now time.Time, limit int) ([]*models.ProviderRefund, error) {
+	return nil, nil
+}
+
+func (l *refundLedger) MarkSubmitted(ctx context.Context, id uuid.UUID, providerRefundID string, nextCheckAt time.Time) error {
+	l.refunds[id].ProviderRefundID = providerRefundID
+	return nil
+}
+
+func (l *refundLedger) ScheduleCheck(ctx context.Context, id uuid.UUID, nextCheckAt time.Time) error {
+	return nil
+}
+
+func (l *refundLedger) DeferRefund(ctx context.Context, id uuid.UUID, nextCheckAt time.Time) error {
+	return nil
+}
+
+func (l *refundLedger) CompleteRefund(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+func (l *refundLedger) FailRefund(ctx context.Context, id uuid.UUID, reason string) error {
+	return nil
+}
+
+func (l *refundLedger) Close() error {
+	return nil
+}
+
+// recordingProvider is a payment provider adapter keeping the refunds it
+// was asked for
+type recordingProvider struct {
+	stubProvider
+	requests []payment.RefundRequest
+}
+
+func (p *recordingProvider) Refund(ctx context.Context, req payment.RefundRequest) (*payment.RefundResult, error) {
+	p.requests = append(p.requests, req)
+	return p.stubProvider.Refund(ctx, req)
+}
+
+// TestRefundToSourceRounding tests that refund amounts are rounded to the
+// precision of the top-up's currency before funds are held
+func TestRefundToSourceRounding(t *testing.T) {
+	ctx := context.Background()
+	rupees, rupiah := uuid.New(), uuid.New()
+	ledger := &refundLedger{
+		currencies: map[uuid.UUID]string{rupees: "INR", rupiah: "IDR"},
+		refunds:    make(map[uuid.UUID]*models.ProviderRefund),
+	}
+	provider := &recordingProvider{stubProvider: stubProvider{name: "razorpay"}}
+	providers, err := payment.NewRegistry(provider)
+	require.NoError(t, err)
+	// Rupiah have no minor units
+	currencies, err := currency.FromCodes([]string{"INR", "IDR"}, map[string]int32{"IDR": 0}, nil)
+	require.NoError(t, err)
+	refunds, err := service.NewRefundService(ledger, providers, calendar.Standard(), currencies, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	walletID := uuid.New()
+
+	refund, err := refunds.RefundToSource(ctx, walletID, rupees, decimal.RequireFromString("10.555"))
+	require.NoError(t, err)
+	require.Equal(t, "10.56", refund.Amount.String())
+
+	refund, err = refunds.RefundToSource(ctx, walletID, rupiah, decimal.RequireFromString("1000.6"))
+	require.NoError(t, err)
+	require.Equal(t, "1001", refund.Amount.String())
+	require.Equal(t, "IDR", refund.Currency)
+
+	require.Len(t, provider.requests, 2)
+	require.Equal(t, "10.56", provider.requests[0].Amount.String())
+	require.Equal(t, "1001", provider.requests[1].Amount.String())
+
+	// Amounts rounding away to nothing are refused before anything is held
+	_, err = refunds.RefundToSource(ctx, walletID, rupiah, decimal.RequireFromString("0.4"))
+	require.ErrorIs(t, err, service.ErrInvalidAmount)
+	_, err = refunds.RefundToSource(ctx, walletID, uuid.New(), decimal.NewFromInt(10))
+	require.ErrorIs(t, err, service.ErrRefundNotAllowed)
+	require.Len(t, ledger.refunds, 2)
+}
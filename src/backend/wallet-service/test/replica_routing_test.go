@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/repository"
+	"internal/service"
+	"internal/testkit"
+)
+
+// replicaStatus reports a fixed replication lag, or an error when the
+// replica is down
+type replicaStatus struct {
+	lag time.Duration
+	err error
+}
+
+func (s *replicaStatus) ReplicationLag(ctx context.Context) (time.Duration, error) {
+	return s.lag, s.err
+}
+
+// TestReplicaRouting tests that reads go to the replica while it is healthy,
+// balances only while it is fresh enough, and that everything falls back to
+// the primary otherwise
+func TestReplicaRouting(t *testing.T) {
+	ctx := context.Background()
+	clock := testkit.NewClock(time.Now().UTC())
+	primary := testkit.NewStore(clock)
+	replica := testkit.NewStore(clock)
+
+	// The replica has the wallet from before a credit of 40, but not a wallet
+	// created since
+	stale := &models.Wallet{CustomerID: uuid.New(), Balance: 100, Currency: "INR"}
+	require.NoError(t, primary.CreateWallet(ctx, stale))
+	replicated := *stale
+	replicated.Balance = 60
+	require.NoError(t, replica.CloneWallet(ctx, &replicated, nil))
+	wallet := &models.Wallet{CustomerID: uuid.New(), Balance: 100, Currency: "INR"}
+	require.NoError(t, primary.CreateWallet(ctx, wallet))
+
+	status := &replicaStatus{lag: time.Second}
+	router, err := service.NewReplicaRouter(primary, replica, status, 2*time.Second, &alertLogger{})
+	require.NoError(t, err)
+
+	balance := func(ctx context.Context) float64 {
+		got, err := router.GetWallet(ctx, stale.ID)
+		require.NoError(t, err)
+		return got.Balance
+	}
+
+	// Nothing is read from the replica before its lag is known
+	require.Equal(t, 100.0, balance(ctx))
+
+	require.NoError(t, router.CheckLag(ctx))
+	require.Equal(t, 60.0, balance(ctx))
+	require.Equal(t, 100.0, balance(repository.ReadPrimary(ctx)))
+
+	// Wallets not replicated yet are read from the primary
+	got, err := router.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, 100.0, got.Balance)
+
+	// A lagging replica still serves history but not balances
+	status.lag = 3 * time.Second
+	require.NoError(t, router.CheckLag(ctx))
+	require.Equal(t, 100.0, balance(ctx))
+	txs, err := router.GetTransactions(ctx, stale.ID, 10, 0)
+	require.NoError(t, err)
+	require.Empty(t, txs)
+
+	// An unreachable replica serves nothing until it is back
+	status.err = errors.New("connection refused")
+	require.Error(t, router.CheckLag(ctx))
+	status.lag = 0
+	require.Equal(t, 100.0, balance(ctx))
+
+	status.err = nil
+	require.NoError(t, router.CheckLag(ctx))
+	require.Equal(t, 60.0, balance(ctx))
+
+	_, err = service.NewReplicaRouter(primary, replica, status, 0, &alertLogger{})
+	require.Error(t, err)
+}
@@ -0,0 +1,188 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// newReportJobService returns a report job service with fast heartbeats and
+// no retry delay
+func newReportJobService(t *testing.T, kit *testkit.Kit) service.ReportJobService {
+	t.Helper()
+	jobs, err := service.NewReportJobService(kit.Store, service.ReportJobPolicy{
+		MaxAttempts:       2,
+		HeartbeatInterval: 10 * time.Millisecond,
+		StaleAfter:        time.Second,
+	}, &alertLogger{})
+	require.NoError(t, err)
+	return jobs
+}
+
+// TestReportJobLifecycle tests that submitted jobs run to a stored result,
+// that failed runs are retried until they run out of attempts and that
+// parameters are validated on submission
+func TestReportJobLifecycle(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+	jobs := newReportJobService(t, kit)
+
+	runs := 0
+	require.NoError(t, jobs.Register(service.ReportKind{
+		Name: "echo",
+		Validate: func(params json.RawMessage) error {
+			if len(params) == 0 {
+				return errors.New("params are required")
+			}
+			return nil
+		},
+		Run: func(ctx context.Context, params json.RawMessage, progress service.ReportProgress) (interface{}, error) {
+			require.NoError(t, progress(50))
+			return params, nil
+		},
+	}))
+	require.NoError(t, jobs.Register(service.ReportKind{
+		Name: "broken",
+		Run: func(ctx context.Context, params json.RawMessage, progress service.ReportProgress) (interface{}, error) {
+			runs++
+			return nil, errors.New("upstream unavailable")
+		},
+	}))
+	require.Error(t, jobs.Register(service.ReportKind{Name: "echo", Run: func(context.Context, json.RawMessage, service.ReportProgress) (interface{}, error) {
+		return nil, nil
+	}}))
+	require.Equal(t, []string{"broken", "echo"}, jobs.Kinds())
+
+	_, err := jobs.Submit(ctx, "missing", nil, "ops")
+	require.ErrorIs(t, err, service.ErrUnknownReportKind)
+	_, err = jobs.Submit(ctx, "echo", nil, "ops")
+	require.ErrorIs(t, err, service.ErrInvalidReportParams)
+	_, err = jobs.Submit(ctx, "echo", json.RawMessage(`{`), "ops")
+	require.ErrorIs(t, err, service.ErrInvalidReportParams)
+
+	job, err := jobs.Submit(ctx, "echo", json.RawMessage(`{"month":"2023-11"}`), "ops")
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobQueued, job.Status)
+
+	_, err = jobs.GetResult(ctx, job.ID)
+	require.ErrorIs(t, err, service.ErrReportResultNotReady)
+
+	ran, err := jobs.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+
+	done, err := jobs.Get(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobSucceeded, done.Status)
+	require.Equal(t, 100, done.Progress)
+	require.Equal(t, 1, done.Attempts)
+	require.NotNil(t, done.FinishedAt)
+
+	result, err := jobs.GetResult(ctx, job.ID)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"month":"2023-11"}`, string(result))
+
+	_, err = jobs.Cancel(ctx, job.ID)
+	require.ErrorIs(t, err, service.ErrReportJobFinished)
+
+	// A failing job is retried once, then fails
+	failing, err := jobs.Submit(ctx, "broken", nil, "ops")
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		ran, err = jobs.RunNext(ctx)
+		require.NoError(t, err)
+		require.True(t, ran)
+	}
+	ran, err = jobs.RunNext(ctx)
+	require.NoError(t, err)
+	require.False(t, ran)
+	require.Equal(t, 2, runs)
+
+	failed, err := jobs.Get(ctx, failing.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobFailed, failed.Status)
+	require.Equal(t, "upstream unavailable", failed.Error)
+
+	listed, err := jobs.List(ctx, "", models.ReportJobFailed, service.Pagination{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.Equal(t, failing.ID, listed[0].ID)
+
+	purged, err := jobs.Purge(ctx, time.Now().UTC().Add(time.Minute))
+	require.NoError(t, err)
+	require.EqualValues(t, 2, purged)
+}
+
+// TestReportJobCancellation tests that queued jobs are cancelled at once and
+// running jobs stop at their next heartbeat
+func TestReportJobCancellation(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+	jobs := newReportJobService(t, kit)
+
+	started := make(chan struct{})
+	require.NoError(t, jobs.Register(service.ReportKind{
+		Name: "slow",
+		Run: func(ctx context.Context, params json.RawMessage, progress service.ReportProgress) (interface{}, error) {
+			close(started)
+			for percent := 0; ; percent++ {
+				if err := progress(percent); err != nil {
+					return nil, err
+				}
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(time.Millisecond):
+				}
+			}
+		},
+	}))
+
+	queued, err := jobs.Submit(ctx, "slow", nil, "ops")
+	require.NoError(t, err)
+	cancelled, err := jobs.Cancel(ctx, queued.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobCancelled, cancelled.Status)
+
+	ran, err := jobs.RunNext(ctx)
+	require.NoError(t, err)
+	require.False(t, ran)
+
+	running, err := jobs.Submit(ctx, "slow", nil, "ops")
+	require.NoError(t, err)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := jobs.RunNext(ctx)
+		result <- err
+	}()
+	<-started
+
+	requested, err := jobs.Cancel(ctx, running.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobRunning, requested.Status)
+	require.True(t, requested.CancelRequested)
+
+	select {
+	case err := <-result:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("running job was not cancelled")
+	}
+
+	stopped, err := jobs.Get(ctx, running.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobCancelled, stopped.Status)
+	require.NotNil(t, stopped.FinishedAt)
+
+	_, err = jobs.GetResult(ctx, running.ID)
+	require.ErrorIs(t, err, service.ErrReportResultNotReady)
+}
@@ -0,0 +1,154 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"            // v1.9.1
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/api"
+	"internal/apierror"
+	"internal/config"
+	"internal/service"
+	"internal/testkit"
+)
+
+// requestBodyLimit is the request size limit of the body test router
+const requestBodyLimit = 256
+
+// newBodyRouter returns a router limiting request bodies to
+// requestBodyLimit, with wallet provisioning as a route taking JSON through
+// bindJSON as well as CSV
+func newBodyRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	kit := testkit.New(t, testkit.Options{})
+	jobs := newReportJobService(t, kit)
+	provisioning, err := service.NewWalletProvisioningService(kit.Store, kit.Store, jobs, supportedCurrencies(t), kit.Bus, &alertLogger{})
+	require.NoError(t, err)
+	require.NoError(t, jobs.Register(service.WalletProvisioningReport(provisioning)))
+	provisioningHandler, err := api.NewProvisioningHandler(provisioning)
+	require.NoError(t, err)
+
+	admin := func(c *gin.Context) {
+		c.Set("subject", "ops")
+		c.Set("roles", []string{"admin"})
+		c.Next()
+	}
+	noop := func(c *gin.Context) {}
+	cfg := &config.Config{API: config.APIConfig{MaxRequestSize: requestBodyLimit}}
+	return api.SetupRouter(gin.New(), cfg, api.Middleware{
+		Auth:        admin,
+		RateLimit:   noop,
+		Idempotency: noop,
+	}, api.Handlers{
+		Wallet:       &api.WalletHandler{},
+		Provisioning: provisioningHandler,
+	})
+}
+
+// sendBody sends body to the router with a content type and returns the
+// response status and error code. A negative length streams the body
+// without a Content-Length.
+func sendBody(t *testing.T, router *gin.Engine, path, contentType, body string, length int64) (int, apierror.Code) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, path, struct{ io.Reader }{strings.NewReader(body)})
+	req.ContentLength = length
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp api.Response
+	if rec.Code >= http.StatusBadRequest {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), rec.Body.String())
+	}
+	return rec.Code, apierror.Code(resp.Code)
+}
+
+// TestRequestBodyLimit tests that bodies over the size limit are refused
+// whether they declare their length or stream past it
+func TestRequestBodyLimit(t *testing.T) {
+	router := newBodyRouter(t)
+	path := "/api/v1/admin/wallets/batch"
+	oversized := `{"currency":"INR","customer_ids":["` + strings.Repeat("a", requestBodyLimit) + `"]}`
+
+	status, code := sendBody(t, router, path, "application/json", oversized, int64(len(oversized)))
+	require.Equal(t, http.StatusRequestEntityTooLarge, status)
+	require.Equal(t, apierror.CodePayloadTooLarge, code)
+
+	status, code = sendBody(t, router, path, "application/json", oversized, -1)
+	require.Equal(t, http.StatusRequestEntityTooLarge, status)
+	require.Equal(t, apierror.CodePayloadTooLarge, code)
+
+	// A body at the limit is read whole
+	fits := `{"currency":"INR","customer_ids":["` + uuid.NewString() + `"]}`
+	fits += strings.Repeat(" ", requestBodyLimit-len(fits))
+	status, _ = sendBody(t, router, path, "application/json", fits, -1)
+	require.Equal(t, http.StatusAccepted, status)
+}
+
+// TestRequestBodyContentType tests that bodies must be JSON, or CSV on the
+// routes taking it
+func TestRequestBodyContentType(t *testing.T) {
+	router := newBodyRouter(t)
+	path := "/api/v1/admin/wallets/batch"
+	rows := "customer_id\n" + uuid.NewString() + "\n"
+
+	status, code := sendBody(t, router, path, "text/plain", `{"currency":"INR"}`, -1)
+	require.Equal(t, http.StatusUnsupportedMediaType, status)
+	require.Equal(t, apierror.CodeUnsupportedMediaType, code)
+	status, code = sendBody(t, router, path, "", `{"currency":"INR"}`, -1)
+	require.Equal(t, http.StatusUnsupportedMediaType, status)
+	require.Equal(t, apierror.CodeUnsupportedMediaType, code)
+
+	// CSV reaches the routes allowed to take it, and only those
+	status, _ = sendBody(t, router, path+"?currency=INR", "text/csv", rows, -1)
+	require.Equal(t, http.StatusAccepted, status)
+	status, code = sendBody(t, router, "/api/v1/wallets/"+uuid.NewString()+"/transactions", "text/csv", rows, -1)
+	require.Equal(t, http.StatusUnsupportedMediaType, status)
+	require.Equal(t, apierror.CodeUnsupportedMediaType, code)
+
+	// JSON still reaches them
+	status, _ = sendBody(t, router, path, "application/json; charset=utf-8", `{"currency":"INR","customer_ids":["`+uuid.NewString()+`"]}`, -1)
+	require.Equal(t, http.StatusAccepted, status)
+}
+
+// TestRequestBodyStrictJSON tests that JSON bodies must be well formed, a
+// single object, and name only the fields of the request
+func TestRequestBodyStrictJSON(t *testing.T) {
+	router := newBodyRouter(t)
+	path := "/api/v1/admin/wallets/batch"
+	customerID := uuid.NewString()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "malformed", body: `{"currency":"INR","customer_ids":[`},
+		{name: "unknown field", body: `{"currency":"INR","customer_ids":["` + customerID + `"],"priority":1}`},
+		{name: "trailing object", body: `{"currency":"INR","customer_ids":["` + customerID + `"]}{"currency":"USD"}`},
+		{name: "trailing value", body: `{"currency":"INR","customer_ids":["` + customerID + `"]} 1`},
+		{name: "missing required field", body: `{"customer_ids":["` + customerID + `"]}`},
+		{name: "wrong type", body: `{"currency":"INR","customer_ids":"` + customerID + `"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := sendBody(t, router, path, "application/json", tt.body, -1)
+			require.Equal(t, http.StatusBadRequest, status)
+			require.Equal(t, apierror.CodeInvalidRequest, code)
+		})
+	}
+
+	// Trailing whitespace is not trailing data
+	status, _ := sendBody(t, router, path, "application/json", `{"currency":"INR","customer_ids":["`+customerID+`"]}`+"\n", -1)
+	require.Equal(t, http.StatusAccepted, status)
+}
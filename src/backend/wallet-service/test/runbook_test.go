@@ -0,0 +1,133 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"    // v2.30.4
+	"github.com/go-redis/redis/v8"        // v8.11.5
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/runbook"
+)
+
+// operatorLog is an AuditRepository keeping operator actions in memory
+type operatorLog struct {
+	actions []*models.OperatorAction
+	err     error
+}
+
+func (l *operatorLog) RecordOperatorAction(ctx context.Context, action *models.OperatorAction) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.actions = append(l.actions, action)
+	return nil
+}
+
+func (l *operatorLog) ListOperatorActions(ctx context.Context, limit, offset int) ([]*models.OperatorAction, error) {
+	return l.actions, nil
+}
+
+func (l *operatorLog) Close() error {
+	return nil
+}
+
+// TestRunbookRegistry tests that actions run only with a reason and their
+// parameters, and that every run is audited with its outcome
+func TestRunbookRegistry(t *testing.T) {
+	ctx := context.Background()
+	audit := &operatorLog{}
+	registry, err := runbook.NewRegistry(audit)
+	require.NoError(t, err)
+
+	failure := errors.New("replica unreachable")
+	require.NoError(t, registry.Register(runbook.Action{
+		Name:   "resync-replica",
+		Params: []string{"replica"},
+		Run: func(ctx context.Context, params map[string]string) (interface{}, error) {
+			if params["replica"] == "down" {
+				return nil, failure
+			}
+			return params["replica"], nil
+		},
+	}))
+	require.NoError(t, registry.Register(runbook.Action{
+		Name: "drain-queue",
+		Run: func(ctx context.Context, params map[string]string) (interface{}, error) {
+			return nil, nil
+		},
+	}))
+	require.ErrorIs(t, registry.Register(runbook.Action{
+		Name: "drain-queue",
+		Run: func(ctx context.Context, params map[string]string) (interface{}, error) {
+			return nil, nil
+		},
+	}), runbook.ErrActionExists)
+	require.Error(t, registry.Register(runbook.Action{Name: "no-run"}))
+
+	var names []string
+	for _, action := range registry.List() {
+		names = append(names, action.Name)
+	}
+	require.Equal(t, []string{"drain-queue", "resync-replica"}, names)
+
+	// Refused runs are not audited
+	_, err = registry.Execute(ctx, "restart-cluster", "ops", "stuck", nil)
+	require.ErrorIs(t, err, runbook.ErrActionNotFound)
+	_, err = registry.Execute(ctx, "resync-replica", "ops", "  ", map[string]string{"replica": "r1"})
+	require.ErrorIs(t, err, runbook.ErrReasonRequired)
+	_, err = registry.Execute(ctx, "resync-replica", "ops", "lagging", map[string]string{"replica": ""})
+	require.ErrorIs(t, err, runbook.ErrMissingParameter)
+	require.Empty(t, audit.actions)
+
+	result, err := registry.Execute(ctx, "resync-replica", "ops", "lagging", map[string]string{"replica": "r1"})
+	require.NoError(t, err)
+	require.Equal(t, "r1", result)
+	require.Len(t, audit.actions, 1)
+	require.Equal(t, "resync-replica", audit.actions[0].Action)
+	require.Equal(t, "ops", audit.actions[0].Actor)
+	require.Equal(t, "lagging", audit.actions[0].Reason)
+	require.Equal(t, map[string]string{"replica": "r1"}, audit.actions[0].Params)
+	require.Equal(t, models.OperatorActionSucceeded, audit.actions[0].Status)
+
+	// A failed run is audited with its error
+	_, err = registry.Execute(ctx, "resync-replica", "ops", "lagging", map[string]string{"replica": "down"})
+	require.ErrorIs(t, err, failure)
+	require.Len(t, audit.actions, 2)
+	require.Equal(t, models.OperatorActionFailed, audit.actions[1].Status)
+	require.Equal(t, failure.Error(), audit.actions[1].Error)
+
+	// A run whose audit record is lost still reports what it did
+	audit.err = errors.New("audit log unavailable")
+	result, err = registry.Execute(ctx, "resync-replica", "ops", "lagging", map[string]string{"replica": "r2"})
+	require.ErrorIs(t, err, audit.err)
+	require.Equal(t, "r2", result)
+}
+
+// TestRunbookCacheFlush tests that only keys with an allowed prefix can be
+// flushed
+func TestRunbookCacheFlush(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	registry, err := runbook.NewRegistry(&operatorLog{})
+	require.NoError(t, err)
+	require.NoError(t, registry.Register(runbook.CacheFlushAction(client, []string{"wallet:"})))
+
+	require.NoError(t, server.Set("wallet:42", "cached"))
+	require.NoError(t, server.Set("session:42", "cached"))
+
+	result, err := registry.Execute(ctx, "flush-cache-key", "ops", "stale balance", map[string]string{"key": "wallet:42"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"key": "wallet:42", "deleted": int64(1)}, result)
+	require.False(t, server.Exists("wallet:42"))
+
+	_, err = registry.Execute(ctx, "flush-cache-key", "ops", "stale session", map[string]string{"key": "session:42"})
+	require.Error(t, err)
+	require.True(t, server.Exists("session:42"))
+}
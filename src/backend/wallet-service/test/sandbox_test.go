@@ -0,0 +1,73 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestSandboxCloneWallet tests that clones keep the balance and shape of the
+// history but none of the source wallet's identifiers or free text
+func TestSandboxCloneWallet(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	ctx := context.Background()
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+	path := "/api/v1/wallets/" + wallet.ID.String() + "/transactions"
+
+	for _, tx := range []map[string]interface{}{
+		{"type": "DEBIT", "amount": 30, "description": "Order for Asha Rao", "reference_id": "ORD-1001"},
+		{"type": "CREDIT", "amount": 50, "description": "Top-up by Asha Rao", "reference_id": "PAY-2002"},
+	} {
+		tx["currency"] = "INR"
+		status, body := kit.Do(t, customerID, http.MethodPost, path, tx, "Idempotency-Key", uuid.NewString())
+		require.Equal(t, http.StatusCreated, status, string(body))
+	}
+
+	_, err := kit.Sandbox.CloneWallet(ctx, wallet.ID, customerID)
+	require.ErrorIs(t, err, service.ErrSandboxCustomerIsOwner)
+
+	sandboxCustomerID := uuid.New()
+	clone, err := kit.Sandbox.CloneWallet(ctx, wallet.ID, sandboxCustomerID)
+	require.NoError(t, err)
+	require.NotEqual(t, wallet.ID, clone.WalletID)
+	require.Equal(t, 3, clone.Transactions)
+
+	status, body := kit.Do(t, sandboxCustomerID, http.MethodGet, "/api/v1/wallets/"+clone.WalletID.String()+"/balance", nil)
+	require.Equal(t, http.StatusOK, status, string(body))
+	var balance struct {
+		Data struct {
+			Balance string `json:"balance"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &balance))
+	require.Equal(t, "120", balance.Data.Balance)
+
+	original, err := kit.Store.GetTransactions(ctx, wallet.ID, 10, 0)
+	require.NoError(t, err)
+	cloned, err := kit.Store.GetTransactions(ctx, clone.WalletID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, cloned, 3)
+
+	// The sample is preceded by an opening balance that makes it add up
+	require.Equal(t, "Demo opening balance", cloned[2].Description)
+	require.Equal(t, models.TransactionTypeCredit, cloned[2].Type)
+	require.Equal(t, float64(100), cloned[2].Amount)
+
+	for i, tx := range cloned[:2] {
+		require.NotEqual(t, original[i].ID, tx.ID)
+		require.Equal(t, original[i].Type, tx.Type)
+		require.Equal(t, original[i].Amount, tx.Amount)
+		require.NotContains(t, tx.Description, "Asha")
+		require.Empty(t, tx.ReferenceID)
+	}
+	require.Equal(t, original[0].CreatedAt.Sub(original[1].CreatedAt), cloned[0].CreatedAt.Sub(cloned[1].CreatedAt))
+}
@@ -0,0 +1,139 @@
+package test
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/config"
+	"internal/secrets"
+)
+
+// TestSecretsProviders tests reading secrets from the environment, file
+// mounts and Vault
+func TestSecretsProviders(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("TEST_SECRET_DATABASE_PASSWORD", "from-env")
+	env := secrets.NewEnvProvider("TEST_SECRET_")
+	value, err := env.Get(ctx, secrets.DatabasePassword)
+	require.NoError(t, err)
+	require.Equal(t, "from-env", value)
+	_, err = env.Get(ctx, secrets.JWTSecret)
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, secrets.DatabasePassword), []byte("from-file\n"), 0o600))
+	files, err := secrets.NewFileProvider(dir)
+	require.NoError(t, err)
+	value, err = files.Get(ctx, secrets.DatabasePassword)
+	require.NoError(t, err)
+	require.Equal(t, "from-file", value)
+	_, err = files.Get(ctx, secrets.JWTSecret)
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/wallet/database" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"database_password":"from-vault"}}}`))
+	}))
+	defer server.Close()
+
+	vault, err := secrets.NewVaultProvider(server.URL, "vault-token", "secret", "wallet/database", time.Second)
+	require.NoError(t, err)
+	value, err = vault.Get(ctx, secrets.DatabasePassword)
+	require.NoError(t, err)
+	require.Equal(t, "from-vault", value)
+	_, err = vault.Get(ctx, secrets.DatabaseUser)
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+
+	missing, err := secrets.NewVaultProvider(server.URL, "vault-token", "secret", "wallet/other", time.Second)
+	require.NoError(t, err)
+	_, err = missing.Get(ctx, secrets.DatabasePassword)
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+}
+
+// TestConfigSecretsResolution tests that secrets from the provider replace
+// those of the configuration file
+func TestConfigSecretsResolution(t *testing.T) {
+	t.Setenv("TEST_RESOLVE_JWT_SECRET", "jwt-from-env")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, `
+secrets:
+  provider: env
+  envprefix: TEST_RESOLVE_
+`)
+	watcher, err := config.NewWatcher(path, &alertLogger{})
+	require.NoError(t, err)
+	require.Equal(t, "jwt-from-env", watcher.Current().Security.JWTSecret)
+	require.Equal(t, "secret", watcher.Current().Database.Password)
+
+	writeConfig(t, path, `
+secrets:
+  provider: keychain
+`)
+	_, err = config.NewWatcher(path, &alertLogger{})
+	require.Error(t, err)
+}
+
+// idleConnector is a connector that is never connected
+type idleConnector struct{}
+
+func (c *idleConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return nil, driver.ErrBadConn
+}
+
+func (c *idleConnector) Driver() driver.Driver {
+	return nil
+}
+
+// TestRotatingConnectorRefresh tests that rotated database credentials
+// switch the connector, keeping the user when the provider has none
+func TestRotatingConnectorRefresh(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	files, err := secrets.NewFileProvider(dir)
+	require.NoError(t, err)
+
+	var opened []secrets.Credentials
+	connector, err := secrets.NewRotatingConnector(files, secrets.Credentials{User: "wallet", Password: "initial"},
+		func(creds secrets.Credentials) (driver.Connector, error) {
+			opened = append(opened, creds)
+			return &idleConnector{}, nil
+		})
+	require.NoError(t, err)
+
+	// A provider without the password fails the refresh and keeps the
+	// current credentials
+	rotated, err := connector.Refresh(ctx)
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+	require.False(t, rotated)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, secrets.DatabasePassword), []byte("rotated"), 0o600))
+	rotated, err = connector.Refresh(ctx)
+	require.NoError(t, err)
+	require.True(t, rotated)
+
+	rotated, err = connector.Refresh(ctx)
+	require.NoError(t, err)
+	require.False(t, rotated)
+
+	require.Equal(t, []secrets.Credentials{
+		{User: "wallet", Password: "initial"},
+		{User: "wallet", Password: "rotated"},
+	}, opened)
+}
@@ -0,0 +1,200 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/config"
+	"internal/selfcheck"
+)
+
+// migrationsDir holds the migrations shared by the backend services
+const migrationsDir = "../../shared/migrations"
+
+// migrationFiles lists the migrations of a direction in the order they
+// apply: up in version order, down in reverse
+func migrationFiles(direction string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*."+direction+".sql"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no %s migrations in %s", direction, migrationsDir)
+	}
+
+	// Versions are zero-padded, so names sort in version order
+	sort.Strings(files)
+	if direction == "down" {
+		sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	}
+	return files, nil
+}
+
+// TestSchemaManifest tests that the migrations the self-check requires are
+// exactly the shared migrations, in version order
+func TestSchemaManifest(t *testing.T) {
+	up, err := migrationFiles("up")
+	require.NoError(t, err)
+
+	var versions []string
+	for _, file := range up {
+		versions = append(versions, strings.TrimSuffix(filepath.Base(file), ".up.sql"))
+	}
+	require.Equal(t, versions, selfcheck.RequiredMigrations())
+}
+
+// migrationsDatabase is a database answering every query with its applied
+// schema versions, or with err when set
+type migrationsDatabase struct {
+	versions []string
+	err      error
+}
+
+func (d *migrationsDatabase) Connect(ctx context.Context) (driver.Conn, error) {
+	return &migrationsConn{db: d}, nil
+}
+func (d *migrationsDatabase) Driver() driver.Driver { return nil }
+
+type migrationsConn struct {
+	db *migrationsDatabase
+}
+
+func (c *migrationsConn) Prepare(query string) (driver.Stmt, error) {
+	return &migrationsStmt{db: c.db}, nil
+}
+func (c *migrationsConn) Close() error { return nil }
+func (c *migrationsConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+type migrationsStmt struct {
+	db *migrationsDatabase
+}
+
+func (s *migrationsStmt) Close() error  { return nil }
+func (s *migrationsStmt) NumInput() int { return -1 }
+func (s *migrationsStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec not supported")
+}
+
+func (s *migrationsStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.db.err != nil {
+		return nil, s.db.err
+	}
+	return &versionRows{versions: s.db.versions}, nil
+}
+
+// versionRows are the rows of schema_migrations
+type versionRows struct {
+	versions []string
+}
+
+func (r *versionRows) Columns() []string { return []string{"version"} }
+func (r *versionRows) Close() error      { return nil }
+func (r *versionRows) Next(dest []driver.Value) error {
+	if len(r.versions) == 0 {
+		return io.EOF
+	}
+	dest[0], r.versions = r.versions[0], r.versions[1:]
+	return nil
+}
+
+// TestSchemaCheck tests that the self-check fails when a listed migration is
+// not applied, and only degrades on applied migrations this build does not
+// list, as after a rollback to an older build
+func TestSchemaCheck(t *testing.T) {
+	required := selfcheck.RequiredMigrations()
+	require.NotEmpty(t, required)
+	latest := required[len(required)-1]
+
+	schemaResult := func(database *migrationsDatabase) selfcheck.Result {
+		cfg := &config.Config{SelfCheck: config.SelfCheckConfig{Timeout: time.Second}}
+		checker, err := selfcheck.NewChecker(cfg, sql.OpenDB(database), nil)
+		require.NoError(t, err)
+		for _, result := range checker.Run(context.Background()).Results {
+			if result.Name == "schema" {
+				return result
+			}
+		}
+		t.Fatal("no schema result")
+		return selfcheck.Result{}
+	}
+	without := func(versions []string, skip string) []string {
+		var kept []string
+		for _, version := range versions {
+			if version != skip {
+				kept = append(kept, version)
+			}
+		}
+		return kept
+	}
+	unlisted := "999999_added_by_a_newer_build"
+
+	tests := []struct {
+		name        string
+		database    *migrationsDatabase
+		wantStatus  selfcheck.Status
+		wantMessage string
+	}{
+		{
+			name:        "all applied",
+			database:    &migrationsDatabase{versions: required},
+			wantStatus:  selfcheck.StatusOK,
+			wantMessage: "schema at " + latest,
+		},
+		{
+			name:        "applied but unlisted",
+			database:    &migrationsDatabase{versions: append([]string{unlisted}, required...)},
+			wantStatus:  selfcheck.StatusDegraded,
+			wantMessage: "database has 1 migrations unknown to this build",
+		},
+		{
+			name:        "listed but missing",
+			database:    &migrationsDatabase{versions: without(required, required[0])},
+			wantStatus:  selfcheck.StatusFailed,
+			wantMessage: "missing migrations: " + required[0],
+		},
+		{
+			name:        "latest missing",
+			database:    &migrationsDatabase{versions: without(required, latest)},
+			wantStatus:  selfcheck.StatusFailed,
+			wantMessage: "missing migrations: " + latest,
+		},
+		{
+			name:        "missing and unlisted",
+			database:    &migrationsDatabase{versions: append(without(required, latest), unlisted)},
+			wantStatus:  selfcheck.StatusFailed,
+			wantMessage: "missing migrations: " + latest,
+		},
+		{
+			name:        "nothing applied",
+			database:    &migrationsDatabase{},
+			wantStatus:  selfcheck.StatusFailed,
+			wantMessage: "missing migrations: " + strings.Join(required, ", "),
+		},
+		{
+			name:        "no migrations table",
+			database:    &migrationsDatabase{err: errors.New(`relation "schema_migrations" does not exist`)},
+			wantStatus:  selfcheck.StatusFailed,
+			wantMessage: "failed to read schema_migrations",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := schemaResult(tt.database)
+			require.Equal(t, tt.wantStatus, result.Status, result.Message)
+			require.Contains(t, result.Message, tt.wantMessage)
+		})
+	}
+}
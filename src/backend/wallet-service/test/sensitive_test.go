@@ -0,0 +1,71 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/sensitive"
+)
+
+// TestDetectSensitiveNumbers tests PAN and Aadhaar detection in free text
+func TestDetectSensitiveNumbers(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantKinds []sensitive.Kind
+	}{
+		{
+			name:      "spaced card number",
+			text:      "refund for card 4111 1111 1111 1111",
+			wantKinds: []sensitive.Kind{sensitive.KindPAN},
+		},
+		{
+			name:      "dashed aadhaar number",
+			text:      "kyc 2345-6789-0124",
+			wantKinds: []sensitive.Kind{sensitive.KindAadhaar},
+		},
+		{
+			name: "card number failing luhn",
+			text: "order 4111111111111112",
+		},
+		{
+			name: "digits inside reference token",
+			text: "ref INV4111111111111111",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.wantKinds, sensitive.Kinds(sensitive.Detect(tt.text)))
+		})
+	}
+}
+
+// TestScannerPolicies tests the reject and mask policies and compliance events
+func TestScannerPolicies(t *testing.T) {
+	var events []sensitive.Event
+	record := func(e sensitive.Event) { events = append(events, e) }
+
+	masker, err := sensitive.NewScanner(sensitive.PolicyMask, record)
+	require.NoError(t, err)
+
+	masked, err := masker.Scan("description", "card 4111-1111-1111-1111 declined")
+	require.NoError(t, err)
+	require.Equal(t, "card XXXX-XXXX-XXXX-1111 declined", masked)
+
+	rejecter, err := sensitive.NewScanner(sensitive.PolicyReject, record)
+	require.NoError(t, err)
+
+	_, err = rejecter.Scan("description", "aadhaar 234567890124")
+	require.True(t, errors.Is(err, sensitive.ErrSensitiveData))
+
+	clean, err := rejecter.Scan("description", "monthly subscription")
+	require.NoError(t, err)
+	require.Equal(t, "monthly subscription", clean)
+
+	require.Len(t, events, 2)
+	require.Equal(t, sensitive.PolicyMask, events[0].Action)
+	require.Equal(t, []sensitive.Kind{sensitive.KindAadhaar}, events[1].Kinds)
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/slo"
+)
+
+// TestSLOBurnRates tests that requests burn the error budgets of their
+// endpoint's objectives and that fast burns raise both alert severities
+func TestSLOBurnRates(t *testing.T) {
+	tracker, err := slo.NewTracker([]slo.Definition{{
+		Name:             "process-transaction",
+		Method:           http.MethodPost,
+		Route:            "/api/v1/wallets/:id/transactions",
+		Availability:     0.999,
+		Latency:          0.9,
+		LatencyThreshold: 500 * time.Millisecond,
+	}})
+	require.NoError(t, err)
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		status := http.StatusOK
+		if i < 2 {
+			status = http.StatusInternalServerError
+		}
+		tracker.Record(http.MethodPost, "/api/v1/wallets/:id/transactions", status, 100*time.Millisecond, now.Add(-time.Duration(i)*time.Second))
+	}
+	// Slow requests outside the longest window and requests to other
+	// endpoints are not counted
+	tracker.Record(http.MethodPost, "/api/v1/wallets/:id/transactions", http.StatusOK, time.Second, now.Add(-7*time.Hour))
+	tracker.Record(http.MethodGet, "/api/v1/wallets/:id/balance", http.StatusInternalServerError, time.Second, now)
+
+	statuses := tracker.Status(now)
+	require.Len(t, statuses, 2)
+
+	availability := statuses[0]
+	require.Equal(t, slo.ObjectiveAvailability, availability.Objective)
+	require.InDelta(t, 0.98, availability.Compliance, 1e-9)
+	// 2% of requests failed against a 0.1% budget
+	for _, window := range []string{"5m", "30m", "1h", "6h"} {
+		require.InDelta(t, 20, availability.BurnRates[window], 1e-9)
+	}
+	require.Equal(t, []string{"page", "ticket"}, availability.Alerts)
+
+	latency := statuses[1]
+	require.Equal(t, slo.ObjectiveLatency, latency.Objective)
+	require.EqualValues(t, 500, latency.ThresholdMs)
+	require.Equal(t, 1.0, latency.Compliance)
+	require.Zero(t, latency.BurnRates["6h"])
+	require.Empty(t, latency.Alerts)
+
+	_, err = slo.NewTracker([]slo.Definition{{Name: "no-objective", Method: http.MethodGet, Route: "/"}})
+	require.Error(t, err)
+}
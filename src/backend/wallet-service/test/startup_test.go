@@ -0,0 +1,62 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/startup"
+)
+
+// TestStartupBackoff tests that the delay between attempts doubles up to
+// the maximum
+func TestStartupBackoff(t *testing.T) {
+	backoff := startup.Backoff{Min: time.Second, Max: 5 * time.Second, AttemptTimeout: time.Second}
+	require.NoError(t, backoff.Validate())
+
+	var delays []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		delays = append(delays, backoff.Delay(attempt))
+	}
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, delays)
+
+	require.Error(t, startup.Backoff{Min: time.Second, Max: time.Millisecond, AttemptTimeout: time.Second}.Validate())
+	require.Error(t, startup.Backoff{Min: time.Second, Max: time.Second}.Validate())
+}
+
+// TestStartupWait tests that the service waits for a dependency coming up
+// late, and gives up on one still down at the deadline with its last error
+func TestStartupWait(t *testing.T) {
+	backoff := startup.Backoff{Min: time.Millisecond, Max: 4 * time.Millisecond, AttemptTimeout: time.Second}
+	refused := errors.New("connection refused")
+
+	attempts := 0
+	err := startup.Wait(context.Background(), "database", backoff, func(ctx context.Context) error {
+		attempts++
+		if attempts < 4 {
+			return refused
+		}
+		_, hasDeadline := ctx.Deadline()
+		require.True(t, hasDeadline)
+		return nil
+	}, &alertLogger{})
+	require.NoError(t, err)
+	require.Equal(t, 4, attempts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	attempts = 0
+	err = startup.Wait(ctx, "redis", backoff, func(ctx context.Context) error {
+		attempts++
+		return refused
+	}, &alertLogger{})
+	require.ErrorIs(t, err, refused)
+	require.Contains(t, err.Error(), "redis unreachable")
+	require.Greater(t, attempts, 1)
+
+	err = startup.Wait(context.Background(), "redis", startup.Backoff{}, func(ctx context.Context) error { return nil }, &alertLogger{})
+	require.Error(t, err)
+}
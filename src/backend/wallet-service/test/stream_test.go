@@ -0,0 +1,178 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/testkit"
+)
+
+// sseEvent is one parsed server-sent event; comments have no kind
+type sseEvent struct {
+	ID   string
+	Kind string
+	Data string
+}
+
+// openStream opens the wallet's event stream and returns its parsed events.
+// The stream is closed when the test ends.
+func openStream(t *testing.T, kit *testkit.Kit, customerID, walletID uuid.UUID, lastEventID string) <-chan sseEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, kit.Server.URL+"/api/v1/wallets/"+walletID.String()+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+customerID.String())
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := kit.Server.Client().Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	t.Cleanup(func() {
+		cancel()
+		resp.Body.Close()
+	})
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var event sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if event.Kind != "" {
+					events <- event
+				}
+				event = sseEvent{}
+			case strings.HasPrefix(line, ":"):
+				events <- sseEvent{Data: strings.TrimSpace(line[1:])}
+			case strings.HasPrefix(line, "id: "):
+				event.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.Kind = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.Data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+
+	return events
+}
+
+// nextEvent waits for the next event of the stream
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "stream closed")
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event within 5s")
+		return sseEvent{}
+	}
+}
+
+// requireBalanceEvent checks the ID and balance of a balance event
+func requireBalanceEvent(t *testing.T, event sseEvent, id, balance string) {
+	t.Helper()
+
+	require.Equal(t, "balance", event.Kind)
+	require.Equal(t, id, event.ID)
+	var data struct {
+		Balance string `json:"balance"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(event.Data), &data))
+	require.Equal(t, balance, data.Balance)
+}
+
+// debit posts a debit of the wallet
+func debit(t *testing.T, kit *testkit.Kit, customerID uuid.UUID, wallet *models.Wallet, amount int) {
+	t.Helper()
+
+	status, body := kit.Do(t, customerID, http.MethodPost, "/api/v1/wallets/"+wallet.ID.String()+"/transactions", map[string]interface{}{
+		"type":     "DEBIT",
+		"amount":   amount,
+		"currency": wallet.Currency,
+	}, "Idempotency-Key", uuid.NewString())
+	require.Equal(t, http.StatusCreated, status, string(body))
+}
+
+// TestWalletEventStream tests the opening balance and live updates
+func TestWalletEventStream(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+
+	events := openStream(t, kit, customerID, wallet.ID, "")
+	requireBalanceEvent(t, nextEvent(t, events), "", "100")
+
+	debit(t, kit, customerID, wallet, 10)
+
+	event := nextEvent(t, events)
+	require.Equal(t, "transaction", event.Kind)
+	require.Equal(t, "1", event.ID)
+	require.Contains(t, event.Data, `"status":"COMPLETED"`)
+	requireBalanceEvent(t, nextEvent(t, events), "2", "90")
+}
+
+// TestWalletEventStreamResume tests that reconnecting clients get the
+// updates they missed, or the current balance for unknown event IDs
+func TestWalletEventStreamResume(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+
+	debit(t, kit, customerID, wallet, 10)
+	debit(t, kit, customerID, wallet, 20)
+
+	events := openStream(t, kit, customerID, wallet.ID, "2")
+	event := nextEvent(t, events)
+	require.Equal(t, "transaction", event.Kind)
+	require.Equal(t, "3", event.ID)
+	requireBalanceEvent(t, nextEvent(t, events), "4", "70")
+
+	events = openStream(t, kit, customerID, wallet.ID, "99")
+	requireBalanceEvent(t, nextEvent(t, events), "", "70")
+}
+
+// TestWalletEventStreamHeartbeat tests heartbeats of idle streams
+func TestWalletEventStreamHeartbeat(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{Heartbeat: 20 * time.Millisecond})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+
+	events := openStream(t, kit, customerID, wallet.ID, "")
+	requireBalanceEvent(t, nextEvent(t, events), "", "100")
+
+	event := nextEvent(t, events)
+	require.Empty(t, event.Kind)
+	require.Equal(t, "heartbeat", event.Data)
+}
+
+// TestWalletEventStreamForbidden tests that other customers' wallets and
+// malformed event IDs are rejected before the stream opens
+func TestWalletEventStreamForbidden(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	wallet := kit.CreateWallet(t, uuid.New(), "INR", 100)
+
+	status, _ := kit.Do(t, uuid.New(), http.MethodGet, "/api/v1/wallets/"+wallet.ID.String()+"/events", nil)
+	require.Equal(t, http.StatusNotFound, status)
+
+	status, _ = kit.Do(t, wallet.CustomerID, http.MethodGet, "/api/v1/wallets/"+wallet.ID.String()+"/events", nil, "Last-Event-ID", "latest")
+	require.Equal(t, http.StatusBadRequest, status)
+}
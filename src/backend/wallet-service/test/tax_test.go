@@ -0,0 +1,190 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/tax"
+	"internal/testkit"
+)
+
+// gstRules returns Indian GST split into its central and state components,
+// with education supplies exempt
+func gstRules(t testing.TB) *tax.Registry {
+	t.Helper()
+	rules, err := tax.NewRegistry(map[string]tax.Definition{
+		"in": {
+			Name: "GST",
+			Components: []tax.Component{
+				{Name: "CGST", Rate: decimal.NewFromInt(9)},
+				{Name: "SGST", Rate: decimal.NewFromInt(9)},
+			},
+			ExemptCategories: []string{"education"},
+		},
+		"GB": {
+			Name:       "VAT",
+			Components: []tax.Component{{Name: "VAT", Rate: decimal.NewFromInt(20)}},
+		},
+	})
+	require.NoError(t, err)
+	return rules
+}
+
+// TestTaxRules tests tax rule validation, component lines and the fallback
+// from a subdivision to its country
+func TestTaxRules(t *testing.T) {
+	_, err := tax.New("IN", tax.Definition{Name: "GST"})
+	require.ErrorIs(t, err, tax.ErrInvalidRule)
+	_, err = tax.New("IN", tax.Definition{Name: "GST", Components: []tax.Component{{Name: "IGST", Rate: decimal.NewFromInt(120)}}})
+	require.ErrorIs(t, err, tax.ErrInvalidRule)
+
+	rules := gstRules(t)
+	require.Equal(t, []string{"GB", "IN"}, rules.Regions())
+
+	gst, ok := rules.For("in-ka")
+	require.True(t, ok)
+	require.Equal(t, "IN", gst.Region())
+	require.True(t, gst.Rate().Equal(decimal.NewFromInt(18)))
+	require.True(t, gst.Exempts(" Education "))
+	_, ok = rules.For("US")
+	require.False(t, ok)
+
+	breakdown := gst.Compute(decimal.RequireFromString("10.05"), func(d decimal.Decimal) decimal.Decimal { return d.Round(2) })
+	require.Len(t, breakdown.Lines, 2)
+	require.Equal(t, "CGST", breakdown.Lines[0].Name)
+	require.Equal(t, "0.9", breakdown.Lines[0].Amount.String())
+	require.Equal(t, "1.8", breakdown.Total.String())
+}
+
+// TestInvoiceTax tests that invoices are taxed by the customer's region,
+// leaving out exempt categories and exempt customers, and are recorded once
+// per invoice number
+func TestInvoiceTax(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	taxes, err := service.NewTaxService(kit.Store, kit.Store, gstRules(t), supportedCurrencies(t), &alertLogger{})
+	require.NoError(t, err)
+
+	customer := uuid.New()
+	require.NoError(t, kit.Store.CreateWallet(ctx, &models.Wallet{CustomerID: customer, Currency: "INR"}))
+	invoice := service.InvoiceTax{
+		CustomerID:    customer,
+		InvoiceNumber: "INV-2026-001",
+		Currency:      "INR",
+		Items: []models.TaxableItem{
+			{Amount: decimal.RequireFromString("1000.004"), Category: "api"},
+			{Amount: decimal.NewFromInt(500), Category: "education"},
+		},
+	}
+
+	// Customers without a region are not taxed
+	breakdown, err := taxes.QuoteInvoice(ctx, invoice)
+	require.NoError(t, err)
+	require.Equal(t, models.TaxExemptRegion, breakdown.ExemptReason)
+	require.True(t, breakdown.Total.IsZero())
+
+	_, err = taxes.UpdateProfile(ctx, customer, "India", false, "alice")
+	require.ErrorIs(t, err, service.ErrInvalidTaxRequest)
+	_, err = taxes.UpdateProfile(ctx, uuid.New(), "IN", false, "alice")
+	require.ErrorIs(t, err, service.ErrCustomerNotFound)
+	settings, err := taxes.UpdateProfile(ctx, customer, "in-ka", false, "alice")
+	require.NoError(t, err)
+	require.Equal(t, "IN-KA", settings.TaxRegion)
+
+	breakdown, err = taxes.QuoteInvoice(ctx, invoice)
+	require.NoError(t, err)
+	require.Empty(t, breakdown.ExemptReason)
+	require.Equal(t, "GST", breakdown.Tax)
+	require.Equal(t, "1000", breakdown.Taxable.String())
+	require.Equal(t, "180", breakdown.Total.String())
+
+	_, err = taxes.TaxInvoice(ctx, service.InvoiceTax{CustomerID: customer, Currency: "INR", Items: invoice.Items})
+	require.ErrorIs(t, err, service.ErrInvalidTaxRequest)
+	record, err := taxes.TaxInvoice(ctx, invoice)
+	require.NoError(t, err)
+	require.Equal(t, models.TaxSourceInvoice, record.Source)
+	require.Equal(t, "180", record.Breakdown.Total.String())
+
+	// A retried invoice run returns the first record
+	again, err := taxes.TaxInvoice(ctx, invoice)
+	require.NoError(t, err)
+	require.Equal(t, record.ID, again.ID)
+
+	// Exempt customers keep their region but are charged no tax
+	_, err = taxes.UpdateProfile(ctx, customer, "IN-KA", true, "alice")
+	require.NoError(t, err)
+	breakdown, err = taxes.QuoteInvoice(ctx, invoice)
+	require.NoError(t, err)
+	require.Equal(t, models.TaxExemptCustomer, breakdown.ExemptReason)
+
+	report, err := taxes.Report(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 2)
+	require.Equal(t, "CGST", report.Rows[0].Component)
+	require.Equal(t, int64(1), report.Rows[0].Count)
+	require.Equal(t, "90", report.Rows[0].Total.String())
+	require.Equal(t, "SGST", report.Rows[1].Component)
+}
+
+// TestTaxedDebits tests that debits are charged tax on top of their amount,
+// naming it in their metadata, unless their category is exempt
+func TestTaxedDebits(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	taxes, err := service.NewTaxService(kit.Store, kit.Store, gstRules(t), supportedCurrencies(t), &alertLogger{})
+	require.NoError(t, err)
+	taxed, err := service.NewTaxedWalletService(wallets, taxes, &alertLogger{})
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "INR", Balance: 1000}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+	_, err = taxes.UpdateProfile(ctx, wallet.CustomerID, "IN", false, "alice")
+	require.NoError(t, err)
+
+	debit := func(amount float64, category string) *models.Transaction {
+		tx := &models.Transaction{
+			ID:       uuid.New(),
+			WalletID: wallet.ID,
+			Type:     models.TransactionTypeDebit,
+			Status:   models.TransactionStatusInitiated,
+			Amount:   amount,
+			Currency: "INR",
+			Metadata: map[string]string{"category": category},
+		}
+		_, err := taxed.ProcessTransaction(ctx, tx)
+		require.NoError(t, err)
+		return tx
+	}
+
+	tx := debit(200, "api")
+	require.Equal(t, 236.0, tx.Amount)
+	require.Equal(t, "200", tx.Metadata[models.MetadataTaxableAmount])
+	require.Equal(t, "36", tx.Metadata[models.MetadataTaxAmount])
+	require.Equal(t, "IN", tx.Metadata[models.MetadataTaxRegion])
+
+	exempt := debit(100, "education")
+	require.Equal(t, 100.0, exempt.Amount)
+	require.NotContains(t, exempt.Metadata, models.MetadataTaxAmount)
+
+	stored, err := kit.Store.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, 664.0, stored.Balance)
+
+	report, err := taxes.Report(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 2)
+	require.Equal(t, models.TaxSourceDebit, report.Rows[0].Source)
+	require.Equal(t, "18", report.Rows[0].Total.String())
+}
@@ -0,0 +1,433 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+	"internal/testkit"
+)
+
+// TestTestkitWalletFlow tests the embedded service end to end over HTTP
+func TestTestkitWalletFlow(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+	path := "/api/v1/wallets/" + wallet.ID.String()
+
+	status, body := kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+		"type":     "DEBIT",
+		"amount":   40,
+		"currency": "INR",
+	}, "Idempotency-Key", uuid.NewString())
+	require.Equal(t, http.StatusCreated, status, string(body))
+
+	status, body = kit.Do(t, customerID, http.MethodGet, path+"/balance", nil)
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	var balance struct {
+		Data struct {
+			Balance  string `json:"balance"`
+			Currency string `json:"currency"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &balance))
+	require.Equal(t, "60", balance.Data.Balance)
+	require.Equal(t, "INR", balance.Data.Currency)
+
+	status, body = kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+		"type":     "DEBIT",
+		"amount":   500,
+		"currency": "INR",
+	}, "Idempotency-Key", uuid.NewString())
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+	require.Contains(t, string(body), "INSUFFICIENT_BALANCE")
+
+	status, _ = kit.Do(t, customerID, http.MethodGet, "/api/v1/wallets/"+uuid.NewString()+"/balance", nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+// TestTestkitCreditLimit tests overdraft limits set by operators through settings
+func TestTestkitCreditLimit(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+	path := "/api/v1/wallets/" + wallet.ID.String()
+
+	status, body := kit.Do(t, customerID, http.MethodPatch, path+"/settings", map[string]interface{}{
+		"credit_limit": 50,
+	})
+	require.Equal(t, http.StatusForbidden, status, string(body))
+
+	status, body = kit.Do(t, customerID, http.MethodPatch, path+"/settings", map[string]interface{}{
+		"credit_limit": 50,
+	}, testkit.RolesHeader, "admin")
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	status, body = kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+		"type":     "DEBIT",
+		"amount":   120,
+		"currency": "INR",
+	}, "Idempotency-Key", uuid.NewString())
+	require.Equal(t, http.StatusCreated, status, string(body))
+
+	status, body = kit.Do(t, customerID, http.MethodGet, path+"/balance", nil)
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	var balance struct {
+		Data struct {
+			Balance           string `json:"balance"`
+			CreditLimit       string `json:"credit_limit"`
+			AvailableBalance  string `json:"available_balance"`
+			CreditUtilization string `json:"credit_utilization"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &balance))
+	require.Equal(t, "-20", balance.Data.Balance)
+	require.Equal(t, "50", balance.Data.CreditLimit)
+	require.Equal(t, "30", balance.Data.AvailableBalance)
+	require.Equal(t, "0.4", balance.Data.CreditUtilization)
+
+	// The limit cannot drop below the current overdraft
+	status, body = kit.Do(t, customerID, http.MethodPatch, path+"/settings", map[string]interface{}{
+		"credit_limit": 10,
+	}, testkit.RolesHeader, "admin")
+	require.Equal(t, http.StatusBadRequest, status, string(body))
+
+	status, body = kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+		"type":     "DEBIT",
+		"amount":   31,
+		"currency": "INR",
+	}, "Idempotency-Key", uuid.NewString())
+	require.Equal(t, http.StatusUnprocessableEntity, status, string(body))
+}
+
+// TestTestkitCreditLimitWarnings tests the warnings of debits approaching the
+// credit limit and the notifications of crossing a warning level
+func TestTestkitCreditLimitWarnings(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 0)
+	path := "/api/v1/wallets/" + wallet.ID.String()
+
+	var notified []float64
+	require.NoError(t, kit.Bus.Subscribe("test", eventbus.Typed(func(ctx context.Context, e eventbus.CreditLimitWarning) error {
+		notified = append(notified, e.Warning.Level)
+		return nil
+	}), eventbus.TypeCreditLimitWarning))
+
+	status, body := kit.Do(t, customerID, http.MethodPatch, path+"/settings", map[string]interface{}{
+		"credit_limit": 100,
+	}, testkit.RolesHeader, "admin")
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	debitWarnings := func(amount float64) []models.CreditLimitWarning {
+		t.Helper()
+
+		status, body := kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+			"type":     "DEBIT",
+			"amount":   amount,
+			"currency": "INR",
+		}, "Idempotency-Key", uuid.NewString())
+		require.Equal(t, http.StatusCreated, status, string(body))
+
+		var resp struct {
+			Meta struct {
+				Warnings []models.CreditLimitWarning `json:"warnings"`
+			} `json:"meta"`
+		}
+		require.NoError(t, json.Unmarshal(body, &resp))
+		return resp.Meta.Warnings
+	}
+
+	require.Empty(t, debitWarnings(50))
+	require.Empty(t, notified)
+
+	warnings := debitWarnings(35)
+	require.Len(t, warnings, 1)
+	require.Equal(t, models.CreditLimitWarningCode, warnings[0].Code)
+	require.Equal(t, 0.8, warnings[0].Level)
+	require.InDelta(t, 0.85, warnings[0].Utilization, 1e-9)
+	require.InDelta(t, 15, warnings[0].AvailableBalance, 1e-9)
+	require.Equal(t, []float64{0.8}, notified)
+
+	// Staying within a level warns again without notifying again
+	warnings = debitWarnings(2)
+	require.Len(t, warnings, 1)
+	require.Equal(t, 0.8, warnings[0].Level)
+	require.Equal(t, []float64{0.8}, notified)
+
+	warnings = debitWarnings(5)
+	require.Len(t, warnings, 1)
+	require.Equal(t, 0.9, warnings[0].Level)
+	require.Equal(t, []float64{0.8, 0.9}, notified)
+
+	status, body = kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+		"type":     "DEBIT",
+		"amount":   9,
+		"currency": "INR",
+	}, "Idempotency-Key", uuid.NewString())
+	require.Equal(t, http.StatusUnprocessableEntity, status, string(body))
+}
+
+// TestTestkitTransactionMetadata tests that metadata is stored with
+// transactions, bounded, and usable as a history filter
+func TestTestkitTransactionMetadata(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+	path := "/api/v1/wallets/" + wallet.ID.String() + "/transactions"
+
+	for _, metadata := range []map[string]string{
+		{"order_id": "ORD-1", "channel": "app"},
+		{"order_id": "ORD-2", "channel": "web"},
+		nil,
+	} {
+		status, body := kit.Do(t, customerID, http.MethodPost, path, map[string]interface{}{
+			"type":     "DEBIT",
+			"amount":   10,
+			"currency": "INR",
+			"metadata": metadata,
+		}, "Idempotency-Key", uuid.NewString())
+		require.Equal(t, http.StatusCreated, status, string(body))
+	}
+
+	list := func(query string) []models.Transaction {
+		status, body := kit.Do(t, customerID, http.MethodGet, path+query, nil)
+		require.Equal(t, http.StatusOK, status, string(body))
+
+		var resp struct {
+			Data []models.Transaction `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(body, &resp))
+		return resp.Data
+	}
+
+	require.Len(t, list(""), 3)
+	require.Len(t, list("?metadata_key=order_id"), 2)
+
+	transactions := list("?metadata_key=channel&metadata_value=app")
+	require.Len(t, transactions, 1)
+	require.Equal(t, map[string]string{"order_id": "ORD-1", "channel": "app"}, transactions[0].Metadata)
+
+	// Filters apply before paging, so pages are full and the total counts
+	// every match
+	status, body := kit.Do(t, customerID, http.MethodGet, path+"?metadata_key=order_id&page=1&page_size=1", nil)
+	require.Equal(t, http.StatusOK, status, string(body))
+	var paged struct {
+		Data []models.Transaction   `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(body, &paged))
+	require.Len(t, paged.Data, 1)
+	require.Contains(t, paged.Data[0].Metadata, "order_id")
+	require.EqualValues(t, 2, paged.Meta["total"])
+	require.EqualValues(t, 2, paged.Meta["total_pages"])
+
+	tooMany := make(map[string]string)
+	for i := 0; i <= models.MaxMetadataKeys; i++ {
+		tooMany[uuid.NewString()[:8]] = "x"
+	}
+	status, body = kit.Do(t, customerID, http.MethodPost, path, map[string]interface{}{
+		"type":     "DEBIT",
+		"amount":   10,
+		"currency": "INR",
+		"metadata": tooMany,
+	}, "Idempotency-Key", uuid.NewString())
+	require.Equal(t, http.StatusBadRequest, status, string(body))
+	require.Contains(t, string(body), "INVALID_REQUEST")
+}
+
+// TestTestkitSearchTransactionsByReference tests support lookups of a
+// reference ID across wallets and customers
+func TestTestkitSearchTransactionsByReference(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+
+	var wallets []*models.Wallet
+	for i := 0; i < 2; i++ {
+		customerID := uuid.New()
+		wallet := kit.CreateWallet(t, customerID, "INR", 100)
+		wallets = append(wallets, wallet)
+
+		status, body := kit.Do(t, customerID, http.MethodPost, "/api/v1/wallets/"+wallet.ID.String()+"/transactions", map[string]interface{}{
+			"type":         "DEBIT",
+			"amount":       10,
+			"currency":     "INR",
+			"reference_id": "INV-2024-0042",
+		}, "Idempotency-Key", uuid.NewString())
+		require.Equal(t, http.StatusCreated, status, string(body))
+	}
+
+	support := uuid.New()
+	status, _ := kit.Do(t, support, http.MethodGet, "/api/v1/transactions?reference_id=INV-2024-0042", nil)
+	require.Equal(t, http.StatusForbidden, status)
+
+	status, body := kit.Do(t, support, http.MethodGet, "/api/v1/transactions?reference_id=INV-2024-0042", nil, testkit.RolesHeader, "support")
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	var resp struct {
+		Data []models.TransactionMatch `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &resp))
+	require.Len(t, resp.Data, 2)
+
+	found := make(map[uuid.UUID]uuid.UUID)
+	for _, match := range resp.Data {
+		require.Equal(t, "INV-2024-0042", match.Transaction.ReferenceID)
+		require.Equal(t, match.Wallet.ID, match.Transaction.WalletID)
+		found[match.Wallet.ID] = match.Wallet.CustomerID
+	}
+	for _, wallet := range wallets {
+		require.Equal(t, wallet.CustomerID, found[wallet.ID])
+	}
+
+	status, _ = kit.Do(t, support, http.MethodGet, "/api/v1/transactions", nil, testkit.RolesHeader, "support")
+	require.Equal(t, http.StatusBadRequest, status)
+}
+
+// TestTestkitBackdatedTransaction tests that operators can backdate a
+// correction, which statements then list by its effective date
+func TestTestkitBackdatedTransaction(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+	path := "/api/v1/wallets/" + wallet.ID.String()
+
+	correction := map[string]interface{}{
+		"type":         "CREDIT",
+		"amount":       15,
+		"currency":     "INR",
+		"effective_at": "2023-12-15T10:00:00Z",
+	}
+	status, body := kit.Do(t, customerID, http.MethodPost, path+"/transactions", correction,
+		"Idempotency-Key", uuid.NewString())
+	require.Equal(t, http.StatusForbidden, status, string(body))
+
+	status, body = kit.Do(t, customerID, http.MethodPost, path+"/transactions", correction,
+		"Idempotency-Key", uuid.NewString(), testkit.RolesHeader, "admin")
+	require.Equal(t, http.StatusCreated, status, string(body))
+
+	status, body = kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+		"type":     "DEBIT",
+		"amount":   5,
+		"currency": "INR",
+	}, "Idempotency-Key", uuid.NewString())
+	require.Equal(t, http.StatusCreated, status, string(body))
+
+	status, body = kit.Do(t, customerID, http.MethodGet,
+		path+"/transactions?from_date=2023-12-01T00:00:00Z&to_date=2023-12-31T23:59:59Z", nil)
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	var statement struct {
+		Data []models.Transaction `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &statement))
+	require.Len(t, statement.Data, 1)
+	require.Equal(t, models.TransactionTypeCredit, statement.Data[0].Type)
+	require.NotNil(t, statement.Data[0].EffectiveAt)
+	require.Equal(t, "2023-12", statement.Data[0].EffectiveTime().Format(models.BillingPeriodLayout))
+}
+
+// TestTestkitUnitOfWork tests that the steps of a unit of work commit
+// together, as for a transfer, and are discarded when it fails
+func TestTestkitUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+	from := kit.CreateWallet(t, uuid.New(), "INR", 100)
+	to := kit.CreateWallet(t, uuid.New(), "INR", 0)
+
+	transfer := func(amount float64, fail error) error {
+		return kit.Store.InTx(ctx, func(uow repository.WalletTx) error {
+			for _, leg := range []struct {
+				walletID uuid.UUID
+				txType   models.TransactionType
+			}{{from.ID, models.TransactionTypeDebit}, {to.ID, models.TransactionTypeCredit}} {
+				wallet, err := uow.GetWalletForUpdate(ctx, leg.walletID)
+				if err != nil {
+					return err
+				}
+				tx := &models.Transaction{
+					WalletID: wallet.ID,
+					Type:     leg.txType,
+					Status:   models.TransactionStatusCompleted,
+					Amount:   amount,
+					Currency: wallet.Currency,
+				}
+				if err := uow.ApplyTransaction(ctx, wallet, tx); err != nil {
+					return err
+				}
+				if err := uow.InsertTransaction(ctx, tx); err != nil {
+					return err
+				}
+			}
+			return fail
+		})
+	}
+
+	failure := errors.New("transfer aborted")
+	require.ErrorIs(t, transfer(30, failure), failure)
+	for _, id := range []uuid.UUID{from.ID, to.ID} {
+		transactions, err := kit.Store.GetTransactions(ctx, id, 10, 0)
+		require.NoError(t, err)
+		require.Empty(t, transactions)
+	}
+
+	require.NoError(t, transfer(30, nil))
+	wallet, err := kit.Store.GetWallet(ctx, from.ID)
+	require.NoError(t, err)
+	require.Equal(t, 70.0, wallet.Balance)
+	wallet, err = kit.Store.GetWallet(ctx, to.ID)
+	require.NoError(t, err)
+	require.Equal(t, 30.0, wallet.Balance)
+
+	// A wallet read outside the unit of work may be stale
+	stale := *wallet
+	stale.Version--
+	err = kit.Store.InTx(ctx, func(uow repository.WalletTx) error {
+		return uow.ApplyTransaction(ctx, &stale, &models.Transaction{
+			WalletID: stale.ID,
+			Type:     models.TransactionTypeCredit,
+			Status:   models.TransactionStatusCompleted,
+			Amount:   1,
+			Currency: stale.Currency,
+		})
+	})
+	require.ErrorIs(t, err, repository.ErrOptimisticLock)
+}
+
+// TestTestkitReadOnly tests that read-only deployments serve reads and refuse
+// changes
+func TestTestkitReadOnly(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{ReadOnly: true})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+	path := "/api/v1/wallets/" + wallet.ID.String()
+
+	status, body := kit.Do(t, customerID, http.MethodGet, path+"/balance", nil)
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	status, body = kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+		"type":     "DEBIT",
+		"amount":   40,
+		"currency": "INR",
+	}, "Idempotency-Key", uuid.NewString())
+	require.Equal(t, http.StatusMethodNotAllowed, status, string(body))
+	require.Contains(t, string(body), "READ_ONLY_DEPLOYMENT")
+
+	status, body = kit.Do(t, customerID, http.MethodPatch, path+"/settings", map[string]interface{}{
+		"credit_limit": 50,
+	}, testkit.RolesHeader, "admin")
+	require.Equal(t, http.StatusMethodNotAllowed, status, string(body))
+
+	balance, err := kit.Store.GetWallet(context.Background(), wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, float64(100), balance.Balance)
+}
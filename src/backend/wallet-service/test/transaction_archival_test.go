@@ -0,0 +1,156 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// partitionStore keeps monthly partitions as transaction counts by month
+type partitionStore struct {
+	months   map[time.Time]int64
+	archived []string
+	failOn   string
+}
+
+func (s *partitionStore) CreateTransactionPartition(ctx context.Context, month time.Time) error {
+	if _, ok := s.months[models.MonthStart(month)]; !ok {
+		s.months[models.MonthStart(month)] = 0
+	}
+	return nil
+}
+
+func (s *partitionStore) ListTransactionPartitions(ctx context.Context) ([]*models.TransactionPartition, error) {
+	var partitions []*models.TransactionPartition
+	for month := range s.months {
+		partitions = append(partitions, &models.TransactionPartition{Name: month.Format("wallet_transactions_y2006m01"), Month: month})
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].Month.Before(partitions[j].Month)
+	})
+	return partitions, nil
+}
+
+func (s *partitionStore) ArchiveTransactionPartition(ctx context.Context, partition *models.TransactionPartition) (int64, error) {
+	if partition.Name == s.failOn {
+		return 0, errors.New("lock timeout")
+	}
+	archived := s.months[partition.Month]
+	delete(s.months, partition.Month)
+	s.archived = append(s.archived, partition.Name)
+	return archived, nil
+}
+
+func (s *partitionStore) Close() error {
+	return nil
+}
+
+// TestTransactionArchiver tests that partitions are created ahead of time and
+// months past retention are archived oldest first
+func TestTransactionArchiver(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	month := func(year int, m time.Month) time.Time {
+		return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	store := &partitionStore{months: map[time.Time]int64{
+		month(2025, time.November): 4,
+		month(2025, time.December): 5,
+		month(2026, time.January):  6,
+		month(2026, time.February): 7,
+	}}
+	_, err := service.NewTransactionArchiver(store, 0, 3, &alertLogger{})
+	require.Error(t, err)
+	archiver, err := service.NewTransactionArchiver(store, 2, 2, &alertLogger{})
+	require.NoError(t, err)
+
+	// Two full months before March are kept
+	store.failOn = "wallet_transactions_y2025m12"
+	archived, err := archiver.Maintain(ctx, now)
+	require.Error(t, err)
+	require.Equal(t, int64(4), archived)
+	require.Equal(t, []string{"wallet_transactions_y2025m11"}, store.archived)
+
+	store.failOn = ""
+	archived, err = archiver.Maintain(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), archived)
+	require.Equal(t, []string{"wallet_transactions_y2025m11", "wallet_transactions_y2025m12"}, store.archived)
+
+	partitions, err := store.ListTransactionPartitions(ctx)
+	require.NoError(t, err)
+	var names []string
+	for _, partition := range partitions {
+		names = append(names, partition.Name)
+	}
+	require.Equal(t, []string{
+		"wallet_transactions_y2026m01",
+		"wallet_transactions_y2026m02",
+		"wallet_transactions_y2026m03",
+		"wallet_transactions_y2026m04",
+		"wallet_transactions_y2026m05",
+	}, names)
+
+	// Nothing more is due until the next month
+	archived, err = archiver.Maintain(ctx, now.AddDate(0, 0, 10))
+	require.NoError(t, err)
+	require.Zero(t, archived)
+}
+
+// TestTransactionQueryHorizon tests that history without a start date covers
+// the query horizon only, and a from date reaches further back
+func TestTransactionQueryHorizon(t *testing.T) {
+	ctx := context.Background()
+	store := testkit.NewStore(testkit.NewClock(time.Now().UTC()))
+	wallets, err := service.NewWalletService(store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	wallet := &models.Wallet{ID: uuid.New(), CustomerID: uuid.New(), Balance: 30, Currency: "INR"}
+	credit := func(age time.Duration) *models.Transaction {
+		return &models.Transaction{
+			ID:        uuid.New(),
+			WalletID:  wallet.ID,
+			Type:      models.TransactionTypeCredit,
+			Status:    models.TransactionStatusCompleted,
+			Amount:    10,
+			Currency:  "INR",
+			CreatedAt: now.Add(-age),
+		}
+	}
+	old, recent := credit(200*24*time.Hour), credit(10*24*time.Hour)
+	require.NoError(t, store.CloneWallet(ctx, wallet, []*models.Transaction{old, credit(40 * 24 * time.Hour), recent}))
+
+	history := func(filter service.TransactionFilter) []uuid.UUID {
+		txs, _, err := wallets.GetTransactionHistory(ctx, wallet.ID, filter, service.Pagination{Limit: 10})
+		require.NoError(t, err)
+		var ids []uuid.UUID
+		for _, tx := range txs {
+			ids = append(ids, tx.ID)
+		}
+		return ids
+	}
+
+	require.Len(t, history(service.TransactionFilter{}), 2)
+	require.Len(t, history(service.TransactionFilter{FromDate: now.Add(-365 * 24 * time.Hour)}), 3)
+
+	require.Error(t, wallets.SetTransactionQueryHorizon(0))
+	require.NoError(t, wallets.SetTransactionQueryHorizon(30*24*time.Hour))
+	require.Equal(t, []uuid.UUID{recent.ID}, history(service.TransactionFilter{}))
+	require.Equal(t, []uuid.UUID{old.ID}, history(service.TransactionFilter{
+		FromDate: now.Add(-250 * 24 * time.Hour),
+		ToDate:   now.Add(-100 * 24 * time.Hour),
+	}))
+}
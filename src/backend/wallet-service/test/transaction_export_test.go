@@ -0,0 +1,156 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// memoryObjects is an in-memory object store signing links that name the key
+type memoryObjects struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newMemoryObjects() *memoryObjects {
+	return &memoryObjects{objects: make(map[string][]byte), types: make(map[string]string)}
+}
+
+func (m *memoryObjects) Upload(ctx context.Context, key, contentType string, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = data
+	m.types[key] = contentType
+	return nil
+}
+
+func (m *memoryObjects) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return "https://objects.test/" + key + "?expires=" + expiry.String(), nil
+}
+
+// TestTransactionExport tests that export jobs write the transactions of the
+// filtered range as CSV and return a signed link to the file once done
+func TestTransactionExport(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+	jobs := newReportJobService(t, kit)
+	objects := newMemoryObjects()
+
+	exports, err := service.NewTransactionExportService(kit.Store, objects, jobs, service.TransactionExportOptions{
+		Prefix:    "exports",
+		URLExpiry: time.Hour,
+	}, &alertLogger{})
+	require.NoError(t, err)
+	require.NoError(t, jobs.Register(service.TransactionExportReport(exports)))
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	customerID := uuid.New()
+	wallet := &models.Wallet{ID: uuid.New(), CustomerID: customerID, Currency: "INR"}
+	other := &models.Wallet{ID: uuid.New(), CustomerID: uuid.New(), Currency: "INR"}
+	transaction := func(walletID uuid.UUID, txType models.TransactionType, amount float64, createdAt time.Time) *models.Transaction {
+		return &models.Transaction{
+			ID:          uuid.New(),
+			WalletID:    walletID,
+			Type:        txType,
+			Status:      models.TransactionStatusCompleted,
+			Amount:      amount,
+			Currency:    "INR",
+			Description: "top-up, March",
+			CreatedAt:   createdAt,
+		}
+	}
+	require.NoError(t, kit.Store.CloneWallet(ctx, wallet, []*models.Transaction{
+		transaction(wallet.ID, models.TransactionTypeCredit, 100.25, from.Add(-time.Hour)),
+		transaction(wallet.ID, models.TransactionTypeCredit, 50.5, from.Add(2*time.Hour)),
+		transaction(wallet.ID, models.TransactionTypeDebit, 20, from.Add(time.Hour)),
+		transaction(wallet.ID, models.TransactionTypeCredit, 10, from.AddDate(0, 1, 0)),
+	}))
+	require.NoError(t, kit.Store.CloneWallet(ctx, other, []*models.Transaction{
+		transaction(other.ID, models.TransactionTypeCredit, 75, from.Add(3*time.Hour)),
+	}))
+
+	// Filters are validated on submission
+	filter := models.TransactionExportFilter{From: from, To: from.AddDate(0, 1, 0)}
+	_, err = exports.Submit(ctx, service.TransactionExportRequest{Format: "xlsx", Filter: filter}, "finance")
+	require.ErrorIs(t, err, service.ErrInvalidExport)
+	_, err = exports.Submit(ctx, service.TransactionExportRequest{Filter: models.TransactionExportFilter{From: filter.To, To: filter.From}}, "finance")
+	require.ErrorIs(t, err, service.ErrInvalidExport)
+	_, err = exports.Submit(ctx, service.TransactionExportRequest{Filter: models.TransactionExportFilter{From: from, To: from.AddDate(2, 0, 0)}}, "finance")
+	require.ErrorIs(t, err, service.ErrInvalidExport)
+	_, err = exports.Submit(ctx, service.TransactionExportRequest{Filter: models.TransactionExportFilter{From: from, To: filter.To, Type: "HOLD"}}, "finance")
+	require.ErrorIs(t, err, service.ErrInvalidExport)
+
+	filter.CustomerID = &customerID
+	export, err := exports.Submit(ctx, service.TransactionExportRequest{Filter: filter}, "finance")
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobQueued, export.Status)
+	require.Equal(t, models.ExportCSV, export.Format)
+	require.Empty(t, export.DownloadURL)
+
+	ran, err := jobs.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+
+	done, err := exports.Get(ctx, export.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobSucceeded, done.Status)
+	require.EqualValues(t, 2, done.Rows)
+	require.NotNil(t, done.URLExpiresAt)
+	require.Contains(t, done.DownloadURL, "https://objects.test/exports/transactions/20260301-20260401/")
+
+	// The file holds the customer's transactions of the range, oldest first
+	require.Len(t, objects.objects, 1)
+	for key, data := range objects.objects {
+		require.Contains(t, done.DownloadURL, key)
+		require.Equal(t, "text/csv", objects.types[key])
+		require.EqualValues(t, len(data), done.Bytes)
+
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		require.Equal(t, "transaction_id", records[0][0])
+		require.Equal(t, []string{"DEBIT", "20", "top-up, March"}, []string{records[1][3], records[1][5], records[1][8]})
+		require.Equal(t, []string{"CREDIT", "50.5", customerID.String()}, []string{records[2][3], records[2][5], records[2][2]})
+		require.Equal(t, "2026-03-01T02:00:00Z", records[2][10])
+	}
+
+	// Type filters narrow the export further
+	filter.Type = "CREDIT"
+	credits, err := exports.Submit(ctx, service.TransactionExportRequest{Filter: filter}, "finance")
+	require.NoError(t, err)
+	ran, err = jobs.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	credits, err = exports.Get(ctx, credits.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, credits.Rows)
+
+	// Jobs of other kinds are not exports
+	require.NoError(t, jobs.Register(service.ReportKind{
+		Name: "echo",
+		Run: func(ctx context.Context, params json.RawMessage, progress service.ReportProgress) (interface{}, error) {
+			return params, nil
+		},
+	}))
+	job, err := jobs.Submit(ctx, "echo", json.RawMessage(`{}`), "finance")
+	require.NoError(t, err)
+	_, err = exports.Get(ctx, job.ID)
+	require.ErrorIs(t, err, service.ErrReportJobNotFound)
+}
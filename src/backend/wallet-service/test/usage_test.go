@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// usageStore is an in-memory UsageRepository
+type usageStore struct {
+	usage   map[string]*models.ServiceUsage
+	claimed map[string]bool
+}
+
+func newUsageStore() *usageStore {
+	return &usageStore{
+		usage:   make(map[string]*models.ServiceUsage),
+		claimed: make(map[string]bool),
+	}
+}
+
+func usageStoreKey(month time.Time, caller string) string {
+	return month.Format("2006-01") + "/" + caller
+}
+
+func (s *usageStore) AddUsage(ctx context.Context, u *models.ServiceUsage) error {
+	key := usageStoreKey(u.Month, u.Caller)
+	stored, ok := s.usage[key]
+	if !ok {
+		stored = &models.ServiceUsage{Month: u.Month, Caller: u.Caller}
+		s.usage[key] = stored
+	}
+	stored.Requests += u.Requests
+	stored.DBTime += u.DBTime
+	stored.BytesServed += u.BytesServed
+	return nil
+}
+
+func (s *usageStore) ListUsage(ctx context.Context, month time.Time) ([]*models.ServiceUsage, error) {
+	var usages []*models.ServiceUsage
+	for _, u := range s.usage {
+		if u.Month.Equal(month) {
+			copied := *u
+			usages = append(usages, &copied)
+		}
+	}
+	return usages, nil
+}
+
+func (s *usageStore) ClaimCharge(ctx context.Context, month time.Time, caller string, now time.Time) (bool, error) {
+	key := usageStoreKey(month, caller)
+	if s.claimed[key] {
+		return false, nil
+	}
+	s.claimed[key] = true
+	return true, nil
+}
+
+func (s *usageStore) ReleaseCharge(ctx context.Context, month time.Time, caller string) error {
+	delete(s.claimed, usageStoreKey(month, caller))
+	return nil
+}
+
+func (s *usageStore) SetChargedTransaction(ctx context.Context, month time.Time, caller string, transactionID uuid.UUID) error {
+	s.usage[usageStoreKey(month, caller)].ChargedTransactionID = &transactionID
+	return nil
+}
+
+func (s *usageStore) Close() error {
+	return nil
+}
+
+// TestUsageChargeback tests that internal service usage is priced in the
+// monthly report and debited once from the wallets of billed services
+func TestUsageChargeback(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	ctx := context.Background()
+	wallet := kit.CreateWallet(t, uuid.New(), "INR", 100)
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+
+	rateCards, err := service.NewRateCardService(kit.Store, eventbus.New(), models.RateCard{
+		PerThousandRequests: decimal.NewFromInt(10),
+		PerDBSecond:         decimal.NewFromInt(2),
+		PerGB:               decimal.NewFromInt(5),
+	}, service.RateCardPolicy{}, &alertLogger{})
+	require.NoError(t, err)
+
+	store := newUsageStore()
+	usage, err := service.NewUsageService(store, wallets, supportedCurrencies(t), rateCards, service.ChargebackPolicy{
+		Currency: "INR",
+		Wallets:  map[string]uuid.UUID{"billing-api": wallet.ID},
+	}, &alertLogger{})
+	require.NoError(t, err)
+
+	month := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 500; i++ {
+		usage.Record("billing-api", month.Add(time.Hour), 10*time.Millisecond, 2_000_000)
+	}
+	usage.Record("reports", month.Add(time.Hour), time.Second, 1000)
+	usage.Record("billing-api", month.AddDate(0, 1, 0), time.Second, 1000)
+	require.NoError(t, usage.Flush(ctx))
+
+	report, err := usage.GetMonthlyReport(ctx, month.Add(48*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, "2026-09", report.Month)
+	require.Len(t, report.Services, 2)
+
+	costs := make(map[string]*models.ServiceUsageCost)
+	for _, entry := range report.Services {
+		costs[entry.Caller] = entry
+	}
+	billing := costs["billing-api"]
+	require.EqualValues(t, 500, billing.Requests)
+	require.InDelta(t, 5.0, billing.DBSeconds, 1e-9)
+	// 0.5 thousand requests at 10, 5 seconds at 2 and 1 GB at 5
+	require.Equal(t, "20", billing.Cost.String())
+	require.Equal(t, wallet.ID, *billing.WalletID)
+	require.Nil(t, costs["reports"].WalletID)
+
+	require.NoError(t, usage.Charge(ctx, month))
+	require.NoError(t, usage.Charge(ctx, month))
+
+	balance, _, err := wallets.GetWalletBalance(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, "80", balance.String())
+
+	report, err = usage.GetMonthlyReport(ctx, month)
+	require.NoError(t, err)
+	for _, entry := range report.Services {
+		if entry.Caller == "billing-api" {
+			require.NotNil(t, entry.ChargedTransactionID)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/events"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestWalletActivity tests that wallets idle for the inactivity period are
+// flagged inactive once, longest idle first, and announced again once they
+// transact
+func TestWalletActivity(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)})
+
+	bus := eventbus.New()
+	var published []eventbus.Event
+	require.NoError(t, bus.Subscribe("activity", func(ctx context.Context, event eventbus.Event) error {
+		published = append(published, event)
+		return nil
+	}, eventbus.TypeWalletInactive, eventbus.TypeWalletReactivated))
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, bus, &alertLogger{})
+	require.NoError(t, err)
+	_, err = service.NewWalletActivityService(kit.Store, bus, service.InactivityPolicy{BatchSize: 1}, &alertLogger{})
+	require.Error(t, err)
+	activity, err := service.NewWalletActivityService(kit.Store, bus, service.InactivityPolicy{
+		After:     90 * 24 * time.Hour,
+		BatchSize: 1,
+	}, &alertLogger{})
+	require.NoError(t, err)
+
+	credit := func(wallet *models.Wallet) {
+		_, err := wallets.ProcessTransaction(ctx, &models.Transaction{
+			ID:       uuid.New(),
+			WalletID: wallet.ID,
+			Type:     models.TransactionTypeCredit,
+			Status:   models.TransactionStatusInitiated,
+			Amount:   10,
+			Currency: "USD",
+		})
+		require.NoError(t, err)
+	}
+	sweep := func() {
+		require.NoError(t, activity.Sweep(ctx, kit.Clock.Now()))
+	}
+
+	idle := &models.Wallet{CustomerID: uuid.New(), Currency: "USD"}
+	require.NoError(t, kit.Store.CreateWallet(ctx, idle))
+	kit.Clock.Advance(24 * time.Hour)
+	dormant := &models.Wallet{CustomerID: uuid.New(), Currency: "USD"}
+	require.NoError(t, kit.Store.CreateWallet(ctx, dormant))
+	busy := &models.Wallet{CustomerID: uuid.New(), Currency: "USD"}
+	require.NoError(t, kit.Store.CreateWallet(ctx, busy))
+
+	// Transactions move the last activity forward
+	kit.Clock.Advance(60 * 24 * time.Hour)
+	credit(busy)
+	stored, err := wallets.GetWallet(ctx, busy.ID)
+	require.NoError(t, err)
+	require.Equal(t, kit.Clock.Now(), *stored.LastActivityAt)
+
+	// Sweeps flag at most a batch, longest idle first
+	kit.Clock.Advance(31 * 24 * time.Hour)
+	sweep()
+	require.Len(t, published, 1)
+	inactive := published[0].(eventbus.WalletInactive)
+	require.Equal(t, idle.ID, inactive.Wallet.ID)
+	require.Equal(t, kit.Clock.Now(), *inactive.Wallet.InactiveSince)
+	flaggedAt := kit.Clock.Now()
+
+	sweep()
+	require.Len(t, published, 2)
+	require.Equal(t, dormant.ID, published[1].(eventbus.WalletInactive).Wallet.ID)
+	sweep()
+	require.Len(t, published, 2)
+
+	env, err := events.NewWalletInactive(inactive.Wallet, 1)
+	require.NoError(t, err)
+	registry, err := events.DefaultRegistry()
+	require.NoError(t, err)
+	require.NoError(t, registry.Validate(env))
+
+	// Flagged wallets transacting again are reactivated once
+	kit.Clock.Advance(time.Hour)
+	credit(idle)
+	sweep()
+	require.Len(t, published, 3)
+	reactivated := published[2].(eventbus.WalletReactivated)
+	require.Equal(t, idle.ID, reactivated.Reactivation.Wallet.ID)
+	require.Equal(t, flaggedAt, reactivated.Reactivation.InactiveSince)
+	require.Nil(t, reactivated.Reactivation.Wallet.InactiveSince)
+	sweep()
+	require.Len(t, published, 3)
+
+	env, err = events.NewWalletReactivated(reactivated.Reactivation, 1)
+	require.NoError(t, err)
+	require.NoError(t, registry.Validate(env))
+	require.Equal(t, events.TypeWalletReactivated, env.Type)
+
+	stored, err = wallets.GetWallet(ctx, idle.ID)
+	require.NoError(t, err)
+	require.Nil(t, stored.InactiveSince)
+	stored, err = wallets.GetWallet(ctx, dormant.ID)
+	require.NoError(t, err)
+	require.Equal(t, flaggedAt, *stored.InactiveSince)
+}
@@ -0,0 +1,130 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestWalletAnalytics tests per-period flows with empty periods filled, top
+// spend categories by metadata then description, the burn rate projection
+// and query validation
+func TestWalletAnalytics(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	analytics, err := service.NewWalletAnalyticsService(kit.Store, wallets, nil, 0, &alertLogger{})
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "USD", Balance: 1000}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+
+	now := time.Now().UTC()
+	post := func(txType models.TransactionType, amount float64, daysAgo int, description string, metadata map[string]string) {
+		effectiveAt := now.AddDate(0, 0, -daysAgo)
+		_, err := wallets.ProcessTransaction(ctx, &models.Transaction{
+			ID:          uuid.New(),
+			WalletID:    wallet.ID,
+			Type:        txType,
+			Status:      models.TransactionStatusCompleted,
+			Amount:      amount,
+			Currency:    "USD",
+			Description: description,
+			Metadata:    metadata,
+			EffectiveAt: &effectiveAt,
+		})
+		require.NoError(t, err)
+	}
+	post(models.TransactionTypeDebit, 100, 2, "GPU hours", map[string]string{"category": "compute"})
+	post(models.TransactionTypeDebit, 50, 1, "SMS", nil)
+	post(models.TransactionTypeCredit, 30, 1, "Top-up", nil)
+	post(models.TransactionTypeDebit, 20, 0, "", map[string]string{"category": "compute"})
+	post(models.TransactionTypeDebit, 10, 0, "", nil)
+
+	summary, err := analytics.GetWalletAnalytics(ctx, wallet.ID, service.WalletAnalyticsQuery{
+		Granularity:   models.GranularityDay,
+		Window:        7 * 24 * time.Hour,
+		TopCategories: 2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "850", summary.Balance.String())
+	require.Equal(t, "180", summary.TotalDebits.String())
+	require.Equal(t, "30", summary.TotalCredits.String())
+
+	// Every day of the window is listed, days without postings as zero
+	require.Len(t, summary.Periods, 8)
+	byDay := make(map[time.Time]*models.WalletFlow)
+	for _, flow := range summary.Periods {
+		byDay[flow.PeriodStart] = flow
+	}
+	twoDaysAgo := byDay[models.GranularityDay.Truncate(now.AddDate(0, 0, -2))]
+	require.Equal(t, "100", twoDaysAgo.Debits.String())
+	require.Equal(t, int64(1), twoDaysAgo.DebitCount)
+	yesterday := byDay[models.GranularityDay.Truncate(now.AddDate(0, 0, -1))]
+	require.Equal(t, "30", yesterday.Credits.String())
+	require.True(t, summary.Periods[0].Debits.IsZero())
+
+	// Category metadata wins over the description, and the smallest
+	// category falls outside the top two
+	require.Len(t, summary.TopCategories, 2)
+	require.Equal(t, "compute", summary.TopCategories[0].Category)
+	require.Equal(t, "120", summary.TopCategories[0].Total.String())
+	require.Equal(t, int64(2), summary.TopCategories[0].Count)
+	require.Equal(t, "SMS", summary.TopCategories[1].Category)
+
+	// 150 net spend over 7 days lasts the remaining 850 for 39.7 days
+	require.Equal(t, "21.43", summary.BurnRate.DailyNetSpend.String())
+	require.NotNil(t, summary.BurnRate.DaysRemaining)
+	require.Equal(t, 39.7, *summary.BurnRate.DaysRemaining)
+	require.WithinDuration(t, now.Add(time.Duration(39.6667*24*float64(time.Hour))), *summary.BurnRate.DepletesAt, time.Hour)
+
+	monthly, err := analytics.GetWalletAnalytics(ctx, wallet.ID, service.WalletAnalyticsQuery{
+		Granularity:   models.GranularityMonth,
+		Window:        7 * 24 * time.Hour,
+		TopCategories: 5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "180", monthly.TotalDebits.String())
+	require.Len(t, monthly.TopCategories, 3)
+	require.Equal(t, models.UncategorizedSpend, monthly.TopCategories[2].Category)
+
+	// A wallet taking in more than it spends gets no projection
+	post(models.TransactionTypeCredit, 500, 0, "Top-up", nil)
+	summary, err = analytics.GetWalletAnalytics(ctx, wallet.ID, service.WalletAnalyticsQuery{
+		Granularity:   models.GranularityWeek,
+		Window:        7 * 24 * time.Hour,
+		TopCategories: 5,
+	})
+	require.NoError(t, err)
+	require.True(t, summary.BurnRate.DailyNetSpend.IsNegative())
+	require.Nil(t, summary.BurnRate.DaysRemaining)
+	require.Nil(t, summary.BurnRate.DepletesAt)
+
+	_, err = analytics.GetWalletAnalytics(ctx, wallet.ID, service.WalletAnalyticsQuery{
+		Granularity: "hour", Window: time.Hour, TopCategories: 5,
+	})
+	require.ErrorIs(t, err, service.ErrInvalidAnalyticsQuery)
+	_, err = analytics.GetWalletAnalytics(ctx, wallet.ID, service.WalletAnalyticsQuery{
+		Granularity: models.GranularityDay, Window: 400 * 24 * time.Hour, TopCategories: 5,
+	})
+	require.ErrorIs(t, err, service.ErrInvalidAnalyticsWindow)
+	_, err = analytics.GetWalletAnalytics(ctx, wallet.ID, service.WalletAnalyticsQuery{
+		Granularity: models.GranularityDay, Window: time.Hour, TopCategories: 21,
+	})
+	require.ErrorIs(t, err, service.ErrInvalidAnalyticsQuery)
+	_, err = analytics.GetWalletAnalytics(ctx, uuid.New(), service.WalletAnalyticsQuery{
+		Granularity: models.GranularityDay, Window: time.Hour, TopCategories: 5,
+	})
+	require.ErrorIs(t, err, service.ErrWalletNotFound)
+}
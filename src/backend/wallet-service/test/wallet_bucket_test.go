@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestWalletBuckets tests that buckets are funded from the unallocated
+// balance, that targeted debits are taken out of their bucket by its
+// fallback, and that a bucket alerts once at its threshold until topped up
+func TestWalletBuckets(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	bus := eventbus.New()
+	var alerts []eventbus.BucketLowBalance
+	require.NoError(t, bus.Subscribe("bucket-alerts", func(ctx context.Context, event eventbus.Event) error {
+		alerts = append(alerts, event.(eventbus.BucketLowBalance))
+		return nil
+	}, eventbus.TypeBucketLowBalance))
+
+	currencies := supportedCurrencies(t)
+	wallets, err := service.NewWalletService(kit.Store, currencies, decimal.Zero, bus, &alertLogger{})
+	require.NoError(t, err)
+	buckets, err := service.NewWalletBucketService(kit.Store, wallets, currencies, bus, &alertLogger{})
+	require.NoError(t, err)
+	debits, err := service.NewBucketWalletService(wallets, buckets, &alertLogger{})
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "INR", Balance: 100}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+
+	_, err = buckets.Create(ctx, wallet.ID, " ", service.BucketInput{})
+	require.ErrorIs(t, err, service.ErrInvalidBucket)
+	_, err = buckets.Create(ctx, wallet.ID, "SMS budget", service.BucketInput{Fallback: "SOMETIMES"})
+	require.ErrorIs(t, err, service.ErrInvalidBucket)
+	sms, err := buckets.Create(ctx, wallet.ID, "SMS budget", service.BucketInput{LowBalanceThreshold: decimal.NewFromInt(10)})
+	require.NoError(t, err)
+	require.Equal(t, models.BucketFallbackNone, sms.Fallback)
+	_, err = buckets.Create(ctx, wallet.ID, "SMS budget", service.BucketInput{})
+	require.ErrorIs(t, err, service.ErrBucketExists)
+	_, err = buckets.Create(ctx, wallet.ID, "WhatsApp budget", service.BucketInput{Fallback: models.BucketFallbackUnallocated})
+	require.NoError(t, err)
+
+	transfer := func(from, to string, amount int64) error {
+		_, err := buckets.Transfer(ctx, &models.BucketTransfer{
+			WalletID: wallet.ID,
+			From:     from,
+			To:       to,
+			Amount:   decimal.NewFromInt(amount),
+		})
+		return err
+	}
+	debit := func(bucket string, amount float64) error {
+		_, err := debits.ProcessTransaction(ctx, &models.Transaction{
+			ID:       uuid.New(),
+			WalletID: wallet.ID,
+			Type:     models.TransactionTypeDebit,
+			Status:   models.TransactionStatusInitiated,
+			Amount:   amount,
+			Currency: "INR",
+			Metadata: map[string]string{models.MetadataBucket: bucket},
+		})
+		return err
+	}
+	balanceOf := func(name string) string {
+		listed, err := buckets.List(ctx, wallet.ID)
+		require.NoError(t, err)
+		for _, bucket := range listed.Buckets {
+			if bucket.Name == name {
+				return bucket.Balance.String()
+			}
+		}
+		return listed.Unallocated.String()
+	}
+
+	// Buckets are funded from what is unallocated, never beyond it
+	require.ErrorIs(t, transfer("", "SMS budget", 101), service.ErrInsufficientBucketBalance)
+	require.ErrorIs(t, transfer("", "", 10), service.ErrInvalidBucket)
+	require.ErrorIs(t, transfer("", "Email budget", 10), service.ErrBucketNotFound)
+	require.NoError(t, transfer("", "SMS budget", 60))
+	require.NoError(t, transfer("SMS budget", "WhatsApp budget", 20))
+	require.Equal(t, "40", balanceOf("SMS budget"))
+	require.Equal(t, "20", balanceOf("WhatsApp budget"))
+	require.Equal(t, "40", balanceOf(""))
+
+	// Without fallback a bucket refuses debits it cannot cover
+	require.ErrorIs(t, debit("SMS budget", 50), service.ErrInsufficientBucketBalance)
+	require.ErrorIs(t, debit("Push budget", 5), service.ErrBucketNotFound)
+	require.NoError(t, debit("SMS budget", 25))
+	require.Equal(t, "15", balanceOf("SMS budget"))
+	require.Empty(t, alerts)
+
+	// Falling to the threshold alerts once, and a top-up re-arms the bucket
+	require.NoError(t, debit("SMS budget", 5))
+	require.NoError(t, debit("SMS budget", 1))
+	require.Len(t, alerts, 1)
+	require.Equal(t, "SMS budget", alerts[0].Bucket.Name)
+	require.Equal(t, "10", alerts[0].Bucket.Balance.String())
+	require.NoError(t, transfer("", "SMS budget", 10))
+	require.NoError(t, debit("SMS budget", 10))
+	require.Len(t, alerts, 2)
+
+	// With fallback the bucket gives what it holds and the wallet the rest
+	require.NoError(t, debit("WhatsApp budget", 30))
+	require.Equal(t, "0", balanceOf("WhatsApp budget"))
+	current, err := wallets.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, 29.0, current.Balance)
+	require.Equal(t, "20", balanceOf(""))
+
+	// A debit the wallet refuses leaves the bucket's funds in it
+	require.NoError(t, transfer("SMS budget", "WhatsApp budget", 5))
+	require.Error(t, debit("WhatsApp budget", 500))
+	require.Equal(t, "5", balanceOf("WhatsApp budget"))
+
+	// Deleting a bucket leaves its balance unallocated
+	require.NoError(t, buckets.Delete(ctx, wallet.ID, "WhatsApp budget"))
+	require.ErrorIs(t, buckets.Delete(ctx, wallet.ID, "WhatsApp budget"), service.ErrBucketNotFound)
+	require.Equal(t, "25", balanceOf(""))
+}
@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestWalletMerge tests merging a duplicate wallet: balance and history
+// move to the surviving wallet, the duplicate refuses postings and
+// redirects, and a reversal puts back exactly what was merged
+func TestWalletMerge(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now().UTC()})
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	audit := &auditLog{}
+	merges, err := service.NewWalletMergeService(kit.Store, audit, 24*time.Hour, &alertLogger{})
+	require.NoError(t, err)
+
+	credit := func(walletID uuid.UUID, amount float64) (*models.CreditLimitWarning, error) {
+		return wallets.ProcessTransaction(ctx, &models.Transaction{
+			WalletID: walletID,
+			Type:     models.TransactionTypeCredit,
+			Status:   models.TransactionStatusInitiated,
+			Amount:   amount,
+			Currency: "INR",
+		})
+	}
+
+	customerID := uuid.New()
+	survivor := kit.CreateWallet(t, customerID, "INR", 100)
+	duplicate := kit.CreateWallet(t, customerID, "INR", 0)
+	_, err = credit(duplicate.ID, 40)
+	require.NoError(t, err)
+	_, err = credit(survivor.ID, 10)
+	require.NoError(t, err)
+
+	_, err = merges.Merge(ctx, duplicate.ID, survivor.ID, "ops", " ")
+	require.ErrorIs(t, err, service.ErrInvalidWalletMerge)
+	_, err = merges.Merge(ctx, duplicate.ID, duplicate.ID, "ops", "duplicate signup")
+	require.ErrorIs(t, err, service.ErrInvalidWalletMerge)
+
+	other := kit.CreateWallet(t, customerID, "USD", 0)
+	_, err = merges.Merge(ctx, duplicate.ID, other.ID, "ops", "duplicate signup")
+	require.ErrorIs(t, err, service.ErrWalletMergeConflict)
+
+	merge, err := merges.Merge(ctx, duplicate.ID, survivor.ID, "ops", "duplicate signup")
+	require.NoError(t, err)
+	require.Equal(t, models.WalletMerged, merge.Status)
+	require.Equal(t, 40.0, merge.Amount)
+	require.Len(t, merge.TransactionIDs, 1)
+
+	merged, err := wallets.GetWallet(ctx, survivor.ID)
+	require.NoError(t, err)
+	require.Equal(t, 150.0, merged.Balance)
+	history, total, err := wallets.GetTransactionHistory(ctx, survivor.ID, service.TransactionFilter{}, service.Pagination{Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+	require.Len(t, history, 2)
+
+	// The duplicate refuses postings and redirects to the survivor
+	_, err = credit(duplicate.ID, 5)
+	require.ErrorIs(t, err, service.ErrWalletMerged)
+	redirect, err := merges.GetRedirect(ctx, duplicate.ID)
+	require.NoError(t, err)
+	require.Equal(t, survivor.ID, redirect.TargetWalletID)
+	_, err = merges.Merge(ctx, survivor.ID, duplicate.ID, "ops", "merge back")
+	require.ErrorIs(t, err, service.ErrWalletMergeConflict)
+
+	// Postings to the survivor since the merge stay with it on reversal
+	_, err = credit(survivor.ID, 7)
+	require.NoError(t, err)
+	reversed, err := merges.Reverse(ctx, merge.ID, "ops", "wrong customer")
+	require.NoError(t, err)
+	require.Equal(t, models.WalletMergeReversed, reversed.Status)
+	require.NotNil(t, reversed.ReversedAt)
+
+	restored, err := wallets.GetWallet(ctx, duplicate.ID)
+	require.NoError(t, err)
+	require.Equal(t, 40.0, restored.Balance)
+	merged, err = wallets.GetWallet(ctx, survivor.ID)
+	require.NoError(t, err)
+	require.Equal(t, 117.0, merged.Balance)
+	history, _, err = wallets.GetTransactionHistory(ctx, duplicate.ID, service.TransactionFilter{}, service.Pagination{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, merge.TransactionIDs[0], history[0].ID)
+
+	_, err = merges.GetRedirect(ctx, duplicate.ID)
+	require.ErrorIs(t, err, service.ErrWalletMergeNotFound)
+	_, err = credit(duplicate.ID, 5)
+	require.NoError(t, err)
+	_, err = merges.Reverse(ctx, merge.ID, "ops", "again")
+	require.ErrorIs(t, err, service.ErrWalletMergeConflict)
+
+	// Every attempt is audited, refusals included
+	require.Len(t, audit.actions, 5)
+	require.Equal(t, models.OperatorActionFailed, audit.actions[0].Status)
+	require.Equal(t, models.OperatorActionSucceeded, audit.actions[1].Status)
+}
+
+// TestWalletMergeWindow tests that merges can no longer be reversed once
+// their window has ended
+func TestWalletMergeWindow(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now().UTC()})
+
+	merges, err := service.NewWalletMergeService(kit.Store, &auditLog{}, time.Nanosecond, &alertLogger{})
+	require.NoError(t, err)
+
+	customerID := uuid.New()
+	survivor := kit.CreateWallet(t, customerID, "INR", 100)
+	duplicate := kit.CreateWallet(t, customerID, "INR", 20)
+
+	merge, err := merges.Merge(ctx, duplicate.ID, survivor.ID, "ops", "duplicate signup")
+	require.NoError(t, err)
+
+	_, err = merges.Reverse(ctx, merge.ID, "ops", "too late")
+	require.ErrorIs(t, err, service.ErrWalletMergeConflict)
+
+	_, err = merges.Reverse(ctx, uuid.New(), "ops", "unknown")
+	require.ErrorIs(t, err, service.ErrWalletMergeNotFound)
+}
+
+// TestWalletMergePostings tests that merges move balances with postings:
+// an overdrawn duplicate moves its debt to the survivor, and no balance
+// moves into a closed billing period
+func TestWalletMergePostings(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	kit := testkit.New(t, testkit.Options{Start: now})
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	merges, err := service.NewWalletMergeService(kit.Store, &auditLog{}, 24*time.Hour, &alertLogger{})
+	require.NoError(t, err)
+
+	customerID := uuid.New()
+	survivor := kit.CreateWallet(t, customerID, "INR", 100)
+	duplicate := kit.CreateWallet(t, customerID, "INR", -30)
+
+	merge, err := merges.Merge(ctx, duplicate.ID, survivor.ID, "ops", "duplicate signup")
+	require.NoError(t, err)
+	require.Equal(t, -30.0, merge.Amount)
+
+	merged, err := wallets.GetWallet(ctx, survivor.ID)
+	require.NoError(t, err)
+	require.Equal(t, 70.0, merged.Balance)
+	require.Greater(t, merged.Version, survivor.Version)
+
+	// A reversal into a closed period leaves both wallets as they were
+	_, err = kit.Store.CloseBillingPeriod(ctx, models.BillingPeriodOf(now), "finance", "month end", now)
+	require.NoError(t, err)
+	_, err = merges.Reverse(ctx, merge.ID, "ops", "wrong customer")
+	require.ErrorIs(t, err, service.ErrWalletMergeConflict)
+
+	unchanged, err := wallets.GetWallet(ctx, survivor.ID)
+	require.NoError(t, err)
+	require.Equal(t, 70.0, unchanged.Balance)
+	redirect, err := merges.GetRedirect(ctx, duplicate.ID)
+	require.NoError(t, err)
+	require.Equal(t, merge.ID, redirect.ID)
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestWalletMigration tests moving a wallet between two deployments: the
+// source freezes at export, the target refuses tampered exports and stays
+// frozen until activated with the export's checksum
+func TestWalletMigration(t *testing.T) {
+	ctx := context.Background()
+	opts := service.WalletMigrationOptions{HistoryWindow: 24 * time.Hour, HistoryLimit: 10}
+
+	source := testkit.New(t, testkit.Options{Start: time.Now().UTC()})
+	sourceWallets, err := service.NewWalletService(source.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	sourceAudit := &auditLog{}
+	sourceMigrations, err := service.NewWalletMigrationService(source.Store, sourceAudit, opts, &alertLogger{})
+	require.NoError(t, err)
+
+	target := testkit.New(t, testkit.Options{Start: time.Now().UTC()})
+	targetWallets, err := service.NewWalletService(target.Store, supportedCurrencies(t), decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	targetMigrations, err := service.NewWalletMigrationService(target.Store, &auditLog{}, opts, &alertLogger{})
+	require.NoError(t, err)
+
+	credit := func(walletID uuid.UUID, amount float64) *models.Transaction {
+		return &models.Transaction{
+			WalletID: walletID,
+			Type:     models.TransactionTypeCredit,
+			Status:   models.TransactionStatusInitiated,
+			Amount:   amount,
+			Currency: "INR",
+		}
+	}
+
+	wallet := source.CreateWallet(t, uuid.New(), "INR", 100)
+	_, err = sourceWallets.ProcessTransaction(ctx, credit(wallet.ID, 50))
+	require.NoError(t, err)
+
+	_, err = sourceMigrations.Export(ctx, wallet.ID, "ops", " ")
+	require.ErrorIs(t, err, service.ErrInvalidWalletExport)
+
+	export, err := sourceMigrations.Export(ctx, wallet.ID, "ops", "move to eu deployment")
+	require.NoError(t, err)
+	require.Len(t, export.Transactions, 1)
+	require.Equal(t, 150.0, export.Wallet.Balance)
+
+	_, err = sourceWallets.ProcessTransaction(ctx, credit(wallet.ID, 10))
+	require.ErrorIs(t, err, service.ErrWalletFrozen)
+	_, err = sourceMigrations.Export(ctx, wallet.ID, "ops", "move again")
+	require.ErrorIs(t, err, service.ErrWalletMigrationConflict)
+
+	tampered := *export
+	tampered.Wallet.Balance = 1500
+	_, err = targetMigrations.Import(ctx, &tampered, "ops", "move to eu deployment")
+	require.ErrorIs(t, err, service.ErrInvalidWalletExport)
+
+	migration, err := targetMigrations.Import(ctx, export, "ops", "move to eu deployment")
+	require.NoError(t, err)
+	require.Equal(t, models.WalletMigrationImport, migration.Direction)
+	require.Equal(t, models.WalletMigrationFrozen, migration.Status)
+
+	// Retrying the import returns the same migration
+	again, err := targetMigrations.Import(ctx, export, "ops", "retry")
+	require.NoError(t, err)
+	require.Equal(t, migration.Checksum, again.Checksum)
+
+	_, err = targetWallets.ProcessTransaction(ctx, credit(wallet.ID, 10))
+	require.ErrorIs(t, err, service.ErrWalletFrozen)
+
+	_, err = targetMigrations.Activate(ctx, wallet.ID, "not-the-checksum", "ops", "cutover")
+	require.ErrorIs(t, err, service.ErrWalletMigrationConflict)
+	activated, err := targetMigrations.Activate(ctx, wallet.ID, export.Checksum, "ops", "cutover")
+	require.NoError(t, err)
+	require.Equal(t, models.WalletMigrationActive, activated.Status)
+	require.NotNil(t, activated.ActivatedAt)
+
+	err = targetMigrations.CancelExport(ctx, wallet.ID, "ops", "abandon")
+	require.ErrorIs(t, err, service.ErrWalletMigrationConflict)
+
+	// The history older than the export is carried by an opening balance
+	transactions, err := target.Store.GetTransactions(ctx, wallet.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	opening := transactions[1]
+	require.Equal(t, models.TransactionTypeCredit, opening.Type)
+	require.Equal(t, 100.0, opening.Amount)
+	require.True(t, opening.CreatedAt.Before(transactions[0].CreatedAt))
+
+	_, err = targetWallets.ProcessTransaction(ctx, credit(wallet.ID, 10))
+	require.NoError(t, err)
+	balance, _, err := targetWallets.GetWalletBalance(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, "160", balance.String())
+
+	// Cancelling the export unfreezes the source
+	require.NoError(t, sourceMigrations.CancelExport(ctx, wallet.ID, "ops", "abandon"))
+	_, err = sourceMigrations.Get(ctx, wallet.ID)
+	require.ErrorIs(t, err, service.ErrWalletMigrationNotFound)
+	_, err = sourceWallets.ProcessTransaction(ctx, credit(wallet.ID, 10))
+	require.NoError(t, err)
+
+	var statuses []models.OperatorActionStatus
+	for _, action := range sourceAudit.actions {
+		require.Equal(t, wallet.ID.String(), action.Params["wallet_id"])
+		statuses = append(statuses, action.Status)
+	}
+	require.Equal(t, []models.OperatorActionStatus{
+		models.OperatorActionSucceeded,
+		models.OperatorActionFailed,
+		models.OperatorActionSucceeded,
+	}, statuses)
+}
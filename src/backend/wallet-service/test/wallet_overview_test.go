@@ -0,0 +1,80 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/models"
+	"internal/testkit"
+)
+
+// TestWalletOverview tests that the overview's balance always reconciles
+// with its transactions, even while postings land between reads
+func TestWalletOverview(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, defaultCurrency, 100)
+	path := "/api/v1/wallets/" + wallet.ID.String()
+
+	credit := func() {
+		kit.Do(t, customerID, http.MethodPost, path+"/transactions", map[string]interface{}{
+			"type":     "CREDIT",
+			"amount":   1,
+			"currency": defaultCurrency,
+		}, "Idempotency-Key", uuid.NewString())
+	}
+
+	var overview struct {
+		Data struct {
+			Balance      decimal.Decimal       `json:"balance"`
+			Version      int64                 `json:"version"`
+			Transactions []*models.Transaction `json:"transactions"`
+		} `json:"data"`
+	}
+	read := func(query string) {
+		t.Helper()
+		status, body := kit.Do(t, customerID, http.MethodGet, path+"/overview"+query, nil)
+		require.Equal(t, http.StatusOK, status, string(body))
+		require.NoError(t, json.Unmarshal(body, &overview))
+	}
+
+	read("")
+	require.True(t, overview.Data.Balance.Equal(decimal.NewFromInt(100)))
+	require.NotNil(t, overview.Data.Transactions)
+	require.Empty(t, overview.Data.Transactions)
+
+	for i := 0; i < 3; i++ {
+		credit()
+	}
+	read("?limit=2")
+	require.True(t, overview.Data.Balance.Equal(decimal.NewFromInt(103)))
+	require.Len(t, overview.Data.Transactions, 2)
+
+	// Every credit of one unit is in the same snapshot as the balance it
+	// moved
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 40; i++ {
+			credit()
+		}
+	}()
+	for i := 0; i < 40; i++ {
+		read("?limit=100")
+		expected := decimal.NewFromInt(int64(100 + len(overview.Data.Transactions)))
+		require.True(t, overview.Data.Balance.Equal(expected), "balance %s with %d transactions", overview.Data.Balance, len(overview.Data.Transactions))
+	}
+	wg.Wait()
+
+	status, _ := kit.Do(t, customerID, http.MethodGet, path+"/overview?limit=101", nil)
+	require.Equal(t, http.StatusBadRequest, status)
+	status, _ = kit.Do(t, customerID, http.MethodGet, "/api/v1/wallets/"+uuid.NewString()+"/overview", nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,130 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/repository"
+	"internal/service"
+	"internal/testkit"
+)
+
+// flakyWallets fails the wallet creation numbered failAt, as a dropped
+// database connection would
+type flakyWallets struct {
+	repository.WalletRepository
+	creates int
+	failAt  int
+}
+
+func (w *flakyWallets) CreateWallet(ctx context.Context, wallet *models.Wallet) error {
+	w.creates++
+	if w.creates == w.failAt {
+		return errors.New("connection reset")
+	}
+	return w.WalletRepository.CreateWallet(ctx, wallet)
+}
+
+// TestWalletProvisioning tests that batches create the missing wallets,
+// report existing wallets and bad rows per row, and resume after the rows
+// already recorded when a run fails part way
+func TestWalletProvisioning(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{})
+	jobs := newReportJobService(t, kit)
+
+	var created []eventbus.WalletCreated
+	bus := eventbus.New()
+	require.NoError(t, bus.Subscribe("created", eventbus.Typed(func(ctx context.Context, e eventbus.WalletCreated) error {
+		created = append(created, e)
+		return nil
+	})))
+
+	wallets := &flakyWallets{WalletRepository: kit.Store, failAt: 120}
+	provisioning, err := service.NewWalletProvisioningService(wallets, kit.Store, jobs, supportedCurrencies(t), bus, &alertLogger{})
+	require.NoError(t, err)
+	require.NoError(t, jobs.Register(service.WalletProvisioningReport(provisioning)))
+
+	// Batches are bounded
+	_, err = provisioning.Submit(ctx, nil, "onboarding")
+	require.ErrorIs(t, err, service.ErrInvalidWalletBatch)
+	_, err = provisioning.Submit(ctx, make([]*models.WalletBatchRow, service.MaxWalletBatchSize+1), "onboarding")
+	require.ErrorIs(t, err, service.ErrInvalidWalletBatch)
+
+	existing := &models.Wallet{CustomerID: uuid.New(), Currency: "INR"}
+	require.NoError(t, kit.Store.CreateWallet(ctx, existing))
+
+	repeated := uuid.New().String()
+	rows := []*models.WalletBatchRow{
+		{CustomerID: existing.CustomerID.String(), Currency: "inr"},
+		{CustomerID: "not-a-customer", Currency: "INR"},
+		{CustomerID: uuid.New().String(), Currency: "XYZ"},
+		{CustomerID: repeated, Currency: "USD"},
+		{CustomerID: repeated, Currency: "USD"},
+	}
+	for i := 0; i < 200; i++ {
+		rows = append(rows, &models.WalletBatchRow{CustomerID: uuid.New().String(), Currency: "INR"})
+	}
+
+	job, err := provisioning.Submit(ctx, rows, "onboarding")
+	require.NoError(t, err)
+	require.Equal(t, service.WalletProvisioningKind, job.Kind)
+
+	// The first run stops at the failed creation, after recording the rows
+	// before it
+	ran, err := jobs.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	retried, err := jobs.Get(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobQueued, retried.Status)
+	require.Equal(t, "failed to create wallet: connection reset", retried.Error)
+	require.Len(t, created, 119)
+
+	ran, err = jobs.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	done, err := jobs.Get(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportJobSucceeded, done.Status)
+	require.Equal(t, 100, done.Progress)
+
+	raw, err := jobs.GetResult(ctx, job.ID)
+	require.NoError(t, err)
+	var result models.WalletBatchResult
+	require.NoError(t, json.Unmarshal(raw, &result))
+	require.Equal(t, 205, result.Total)
+	require.Equal(t, 201, result.Created)
+	require.Equal(t, 2, result.Existing)
+	require.Equal(t, 2, result.Failed)
+
+	// Rows already provisioned were not created twice
+	require.Len(t, created, 201)
+	seen := make(map[uuid.UUID]bool)
+	for _, e := range created {
+		key := e.Wallet.CustomerID
+		require.False(t, seen[key], "wallet created twice for %s", key)
+		seen[key] = true
+	}
+
+	require.Equal(t, models.WalletBatchRowExists, result.Rows[0].Status)
+	require.Equal(t, existing.ID, *result.Rows[0].WalletID)
+	require.Equal(t, "invalid customer ID", result.Rows[1].Error)
+	require.Equal(t, models.WalletBatchRowFailed, result.Rows[2].Status)
+	require.Equal(t, models.WalletBatchRowCreated, result.Rows[3].Status)
+	require.Equal(t, models.WalletBatchRowExists, result.Rows[4].Status)
+	require.Equal(t, *result.Rows[3].WalletID, *result.Rows[4].WalletID)
+
+	// Batches are purged with their jobs
+	purged, err := provisioning.Purge(ctx, time.Now().UTC().Add(time.Minute))
+	require.NoError(t, err)
+	require.EqualValues(t, 205, purged)
+}
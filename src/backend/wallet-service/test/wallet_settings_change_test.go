@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestWalletSettingsChangeFourEyes tests that credit limit increases are
+// refused directly, are staged until another operator approves them, are
+// refused once the limit moved since the request, and that requests and
+// reviews are audited with the limit they move
+func TestWalletSettingsChangeFourEyes(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	currencies := supportedCurrencies(t)
+	wallets, err := service.NewWalletService(kit.Store, currencies, decimal.Zero, eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+	audit := &auditLog{}
+	changes, err := service.NewWalletSettingsChangeService(kit.Store, wallets, audit, currencies, &alertLogger{})
+	require.NoError(t, err)
+	guarded, err := service.NewSettingsApprovalWalletService(wallets)
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "USD", Balance: 100, CreditLimit: 50}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+
+	// Raising the limit directly is refused, lowering it is not
+	_, err = guarded.UpdateCreditLimit(ctx, wallet.ID, decimal.NewFromInt(500))
+	require.ErrorIs(t, err, service.ErrApprovalRequired)
+	raised := decimal.NewFromInt(500)
+	_, err = guarded.UpdateWalletSettings(ctx, wallet.ID, service.WalletSettingsUpdate{CreditLimit: &raised})
+	require.ErrorIs(t, err, service.ErrApprovalRequired)
+	_, err = guarded.UpdateCreditLimit(ctx, wallet.ID, decimal.NewFromInt(40))
+	require.NoError(t, err)
+
+	// Only increases with a reason are staged, one at a time per wallet
+	_, err = changes.RequestCreditLimit(ctx, wallet.ID, decimal.NewFromInt(500), "alice", " ")
+	require.ErrorIs(t, err, service.ErrInvalidSettingsChange)
+	_, err = changes.RequestCreditLimit(ctx, wallet.ID, decimal.NewFromInt(30), "alice", "Seasonal campaign")
+	require.ErrorIs(t, err, service.ErrInvalidSettingsChange)
+	change, err := changes.RequestCreditLimit(ctx, wallet.ID, decimal.NewFromFloat(500.004), "alice", "Seasonal campaign")
+	require.NoError(t, err)
+	require.Equal(t, models.WalletSettingsChangePending, change.Status)
+	require.Len(t, change.Diff, 1)
+	require.Equal(t, "40", change.Diff[0].From.String())
+	require.Equal(t, "500", change.Diff[0].To.String())
+	_, err = changes.RequestCreditLimit(ctx, wallet.ID, decimal.NewFromInt(600), "carol", "Seasonal campaign")
+	require.ErrorIs(t, err, service.ErrSettingsChangeConflict)
+
+	// The requester cannot approve their own change
+	_, err = changes.Approve(ctx, change.ID, "alice", "")
+	require.ErrorIs(t, err, service.ErrSelfApproval)
+
+	applied, err := changes.Approve(ctx, change.ID, "bob", "Agreed with finance")
+	require.NoError(t, err)
+	require.Equal(t, models.WalletSettingsChangeApplied, applied.Status)
+	require.Equal(t, "bob", applied.ReviewedBy)
+	require.NotNil(t, applied.AppliedAt)
+	current, err := wallets.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, 500.0, current.CreditLimit)
+	_, err = changes.Approve(ctx, change.ID, "carol", "")
+	require.ErrorIs(t, err, service.ErrSettingsChangeConflict)
+
+	// A change is refused once the limit moved since it was requested
+	stale, err := changes.RequestCreditLimit(ctx, wallet.ID, decimal.NewFromInt(800), "alice", "Annual contract")
+	require.NoError(t, err)
+	_, err = guarded.UpdateCreditLimit(ctx, wallet.ID, decimal.NewFromInt(300))
+	require.NoError(t, err)
+	_, err = changes.Approve(ctx, stale.ID, "bob", "")
+	require.ErrorIs(t, err, service.ErrSettingsChangeConflict)
+
+	_, err = changes.Reject(ctx, stale.ID, "bob", "")
+	require.ErrorIs(t, err, service.ErrInvalidSettingsChange)
+	rejected, err := changes.Reject(ctx, stale.ID, "bob", "Limit was lowered meanwhile")
+	require.NoError(t, err)
+	require.Equal(t, models.WalletSettingsChangeRejected, rejected.Status)
+	current, err = wallets.GetWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, 300.0, current.CreditLimit)
+
+	listed, err := changes.List(ctx, models.WalletSettingsChangeFilter{WalletID: &wallet.ID, Status: models.WalletSettingsChangeApplied})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.Equal(t, change.ID, listed[0].ID)
+
+	// Every request and review is audited with the limit it moves,
+	// refused approvals included
+	var reviews []*models.OperatorAction
+	for _, action := range audit.actions {
+		if action.Action == "wallet_settings.approve" {
+			reviews = append(reviews, action)
+		}
+	}
+	require.Len(t, reviews, 4)
+	require.Equal(t, models.OperatorActionFailed, reviews[0].Status)
+	require.Equal(t, models.OperatorActionSucceeded, reviews[1].Status)
+	require.Equal(t, "40", reviews[1].Params["credit_limit.from"])
+	require.Equal(t, "500", reviews[1].Params["credit_limit.to"])
+	require.Equal(t, models.OperatorActionFailed, reviews[3].Status)
+	require.Equal(t, "alice", audit.actions[0].Actor)
+	require.Equal(t, "wallet_settings.request", audit.actions[0].Action)
+}
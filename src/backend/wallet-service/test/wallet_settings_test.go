@@ -0,0 +1,215 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/shopspring/decimal"       // v1.3.1
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/eventbus"
+	"internal/models"
+	"internal/service"
+	"internal/testkit"
+)
+
+// TestWalletSettings tests field validation and optimistic locking of wallet
+// settings and that low balance alerts follow the notification preference
+func TestWalletSettings(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	bus := eventbus.New()
+	var alerts []*models.Wallet
+	require.NoError(t, bus.Subscribe("alerts", func(ctx context.Context, event eventbus.Event) error {
+		alerts = append(alerts, event.(eventbus.LowBalance).Wallet)
+		return nil
+	}, eventbus.TypeLowBalance))
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.Zero, bus, &alertLogger{})
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "USD", Balance: 100}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+
+	settings, err := wallets.GetWalletSettings(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), settings.Version)
+	require.True(t, settings.Notifications.LowBalance)
+	require.False(t, settings.AutoTopUp)
+
+	threshold := decimal.RequireFromString("50.004")
+	off, on := false, true
+	version := settings.Version
+
+	_, err = wallets.UpdateWalletSettings(ctx, wallet.ID, service.WalletSettingsUpdate{LowBalanceThreshold: &threshold})
+	require.ErrorIs(t, err, service.ErrInvalidWalletSettings)
+	negative := decimal.NewFromInt(-1)
+	_, err = wallets.UpdateWalletSettings(ctx, wallet.ID, service.WalletSettingsUpdate{LowBalanceThreshold: &negative, Version: &version})
+	require.ErrorIs(t, err, service.ErrInvalidWalletSettings)
+	require.Contains(t, err.Error(), "low_balance_threshold")
+
+	settings, err = wallets.UpdateWalletSettings(ctx, wallet.ID, service.WalletSettingsUpdate{
+		LowBalanceThreshold: &threshold,
+		NotifyLowBalance:    &off,
+		AutoTopUp:           &on,
+		Version:             &version,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 50.0, settings.LowBalanceThreshold)
+	require.False(t, settings.Notifications.LowBalance)
+	require.True(t, settings.Notifications.CreditLimitWarning)
+	require.True(t, settings.AutoTopUp)
+	require.Equal(t, int64(2), settings.Version)
+
+	// A stale version is refused
+	_, err = wallets.UpdateWalletSettings(ctx, wallet.ID, service.WalletSettingsUpdate{AutoTopUp: &off, Version: &version})
+	require.ErrorIs(t, err, service.ErrOptimisticLock)
+
+	// The credit limit alone may be set without a version
+	limit := decimal.NewFromInt(20)
+	settings, err = wallets.UpdateWalletSettings(ctx, wallet.ID, service.WalletSettingsUpdate{CreditLimit: &limit})
+	require.NoError(t, err)
+	require.Equal(t, 20.0, settings.CreditLimit)
+	require.True(t, settings.AutoTopUp)
+
+	debit := func(amount float64) {
+		_, err := wallets.ProcessTransaction(ctx, &models.Transaction{
+			ID:       uuid.New(),
+			WalletID: wallet.ID,
+			Type:     models.TransactionTypeDebit,
+			Status:   models.TransactionStatusInitiated,
+			Amount:   amount,
+			Currency: "USD",
+		})
+		require.NoError(t, err)
+	}
+
+	debit(60)
+	require.Empty(t, alerts)
+
+	version = settings.Version
+	settings, err = wallets.UpdateWalletSettings(ctx, wallet.ID, service.WalletSettingsUpdate{NotifyLowBalance: &on, Version: &version})
+	require.NoError(t, err)
+	_, err = wallets.ProcessTransaction(ctx, &models.Transaction{
+		ID:       uuid.New(),
+		WalletID: wallet.ID,
+		Type:     models.TransactionTypeCredit,
+		Status:   models.TransactionStatusInitiated,
+		Amount:   30,
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+	debit(30)
+	require.Len(t, alerts, 1)
+	require.Equal(t, 40.0, alerts[0].Balance)
+	require.Equal(t, 50.0, alerts[0].LowBalanceThreshold)
+
+	// Only alerts on crossing the threshold
+	debit(10)
+	require.Len(t, alerts, 1)
+}
+
+// TestWalletHealth tests the balance status, recent failure rate and credit
+// limit utilization reported for a wallet
+func TestWalletHealth(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.NewFromInt(25), eventbus.New(), &alertLogger{})
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	transaction := func(walletID uuid.UUID, status models.TransactionStatus, age time.Duration) *models.Transaction {
+		return &models.Transaction{
+			ID:        uuid.New(),
+			WalletID:  walletID,
+			Type:      models.TransactionTypeDebit,
+			Status:    status,
+			Amount:    5,
+			Currency:  "USD",
+			CreatedAt: now.Add(-age),
+		}
+	}
+
+	healthy := &models.Wallet{ID: uuid.New(), CustomerID: uuid.New(), Currency: "USD", Balance: 100}
+	require.NoError(t, kit.Store.CloneWallet(ctx, healthy, []*models.Transaction{
+		transaction(healthy.ID, models.TransactionStatusCompleted, time.Hour),
+		transaction(healthy.ID, models.TransactionStatusFailed, 2*time.Hour),
+		transaction(healthy.ID, models.TransactionStatusCompleted, 3*time.Hour),
+		transaction(healthy.ID, models.TransactionStatusFailed, 48*time.Hour),
+	}))
+
+	health, err := wallets.GetWalletHealth(ctx, healthy.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.BalanceHealthy, health.BalanceStatus)
+	require.Equal(t, 25.0, health.LowBalanceThreshold)
+	require.Equal(t, 3, health.RecentTransactions)
+	require.Equal(t, 1, health.RecentFailures)
+	require.Equal(t, 0.3333, health.FailureRate)
+	require.Zero(t, health.LimitUtilization)
+
+	low := &models.Wallet{ID: uuid.New(), CustomerID: uuid.New(), Currency: "USD", Balance: 20}
+	require.NoError(t, kit.Store.CloneWallet(ctx, low, nil))
+	health, err = wallets.GetWalletHealth(ctx, low.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.BalanceLow, health.BalanceStatus)
+	require.Zero(t, health.FailureRate)
+
+	overdrawn := &models.Wallet{ID: uuid.New(), CustomerID: uuid.New(), Currency: "USD", Balance: -30, CreditLimit: 120}
+	require.NoError(t, kit.Store.CloneWallet(ctx, overdrawn, nil))
+	health, err = wallets.GetWalletHealth(ctx, overdrawn.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.BalanceOverdrawn, health.BalanceStatus)
+	require.Equal(t, 0.25, health.LimitUtilization)
+	require.Equal(t, 90.0, health.AvailableBalance)
+
+	_, err = wallets.GetWalletHealth(ctx, uuid.New())
+	require.ErrorIs(t, err, service.ErrWalletNotFound)
+}
+
+// warningLogger records the warnings logged besides the errors
+type warningLogger struct {
+	alertLogger
+	warnings []string
+}
+
+func (l *warningLogger) Warn(msg string, fields ...interface{}) {
+	l.warnings = append(l.warnings, msg)
+}
+
+// TestLowBalanceAlertAfterTransaction tests that the low balance alert
+// looks at the balance a transaction leaves, not the one it started from
+func TestLowBalanceAlertAfterTransaction(t *testing.T) {
+	ctx := context.Background()
+	kit := testkit.New(t, testkit.Options{Start: time.Now()})
+
+	logger := &warningLogger{}
+	wallets, err := service.NewWalletService(kit.Store, supportedCurrencies(t), decimal.NewFromInt(50), eventbus.New(), logger)
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{CustomerID: uuid.New(), Currency: "USD", Balance: 100}
+	require.NoError(t, kit.Store.CreateWallet(ctx, wallet))
+
+	post := func(txType models.TransactionType, amount float64) {
+		_, err := wallets.ProcessTransaction(ctx, &models.Transaction{
+			ID:       uuid.New(),
+			WalletID: wallet.ID,
+			Type:     txType,
+			Status:   models.TransactionStatusInitiated,
+			Amount:   amount,
+			Currency: "USD",
+		})
+		require.NoError(t, err)
+	}
+
+	// The debit crossing the threshold alerts
+	post(models.TransactionTypeDebit, 60)
+	require.Equal(t, []string{"low balance alert"}, logger.warnings)
+
+	// The credit lifting the balance above it again does not
+	post(models.TransactionTypeCredit, 60)
+	require.Len(t, logger.warnings, 1)
+}
@@ -11,6 +11,7 @@ import (
     "github.com/stretchr/testify/require" // v1.8.4
     "github.com/shopspring/decimal"    // v1.3.1
 
+    "internal/eventbus"
     "internal/models"
     "internal/service"
     "internal/repository"
@@ -37,11 +38,45 @@ func (m *mockWalletRepository) GetWallet(ctx context.Context, id uuid.UUID) (*mo
     return nil, args.Error(1)
 }
 
-func (m *mockWalletRepository) UpdateBalance(ctx context.Context, tx *models.Transaction) error {
+// InTx runs fn with the mock as its unit of work, or fails as set up
+func (m *mockWalletRepository) InTx(ctx context.Context, fn func(tx repository.WalletTx) error) error {
+    if err := m.Called(ctx).Error(0); err != nil {
+        return err
+    }
+    return fn(m)
+}
+
+func (m *mockWalletRepository) GetWalletForUpdate(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+    args := m.Called(ctx, id)
+    if wallet, ok := args.Get(0).(*models.Wallet); ok {
+        return wallet, args.Error(1)
+    }
+    return nil, args.Error(1)
+}
+
+func (m *mockWalletRepository) ApplyTransaction(ctx context.Context, wallet *models.Wallet, tx *models.Transaction) error {
+    args := m.Called(ctx, wallet, tx)
+    return args.Error(0)
+}
+
+func (m *mockWalletRepository) InsertTransaction(ctx context.Context, tx *models.Transaction) error {
     args := m.Called(ctx, tx)
     return args.Error(0)
 }
 
+func (m *mockWalletRepository) PostTransactions(ctx context.Context, txs []*models.Transaction) ([]error, error) {
+    args := m.Called(ctx, txs)
+    if refused, ok := args.Get(0).([]error); ok {
+        return refused, args.Error(1)
+    }
+    return nil, args.Error(1)
+}
+
+func (m *mockWalletRepository) UpdateCreditLimit(ctx context.Context, wallet *models.Wallet, creditLimit float64) error {
+    args := m.Called(ctx, wallet, creditLimit)
+    return args.Error(0)
+}
+
 func (m *mockWalletRepository) GetTransactions(ctx context.Context, walletID uuid.UUID, limit, offset int) ([]*models.Transaction, error) {
     args := m.Called(ctx, walletID, limit, offset)
     if txs, ok := args.Get(0).([]*models.Transaction); ok {
@@ -50,6 +85,22 @@ func (m *mockWalletRepository) GetTransactions(ctx context.Context, walletID uui
     return nil, args.Error(1)
 }
 
+func (m *mockWalletRepository) FindTransactions(ctx context.Context, walletID uuid.UUID, filter repository.TransactionFilter, limit, offset int) ([]*models.Transaction, int, error) {
+    args := m.Called(ctx, walletID, filter, limit, offset)
+    if txs, ok := args.Get(0).([]*models.Transaction); ok {
+        return txs, args.Int(1), args.Error(2)
+    }
+    return nil, args.Int(1), args.Error(2)
+}
+
+func (m *mockWalletRepository) GetWalletOverview(ctx context.Context, walletID uuid.UUID, limit int) (*models.WalletOverview, error) {
+    args := m.Called(ctx, walletID, limit)
+    if overview, ok := args.Get(0).(*models.WalletOverview); ok {
+        return overview, args.Error(1)
+    }
+    return nil, args.Error(1)
+}
+
 func (m *mockWalletRepository) CreateWallet(ctx context.Context, wallet *models.Wallet) error {
     args := m.Called(ctx, wallet)
     return args.Error(0)
@@ -63,6 +114,52 @@ func (m *mockWalletRepository) GetTransactionByID(ctx context.Context, id uuid.U
     return nil, args.Error(1)
 }
 
+func (m *mockWalletRepository) GetCustomerWallets(ctx context.Context, customerID uuid.UUID) ([]*models.Wallet, error) {
+    args := m.Called(ctx, customerID)
+    if wallets, ok := args.Get(0).([]*models.Wallet); ok {
+        return wallets, args.Error(1)
+    }
+    return nil, args.Error(1)
+}
+
+func (m *mockWalletRepository) GetRecentTransactions(ctx context.Context, walletIDs []uuid.UUID, limit int) ([]*models.Transaction, error) {
+    args := m.Called(ctx, walletIDs, limit)
+    if txs, ok := args.Get(0).([]*models.Transaction); ok {
+        return txs, args.Error(1)
+    }
+    return nil, args.Error(1)
+}
+
+func (m *mockWalletRepository) FindTransactionsByReference(ctx context.Context, referenceID string, limit int) ([]*models.TransactionMatch, error) {
+    args := m.Called(ctx, referenceID, limit)
+    if matches, ok := args.Get(0).([]*models.TransactionMatch); ok {
+        return matches, args.Error(1)
+    }
+    return nil, args.Error(1)
+}
+
+func (m *mockWalletRepository) GetWalletSettings(ctx context.Context, walletID uuid.UUID) (*models.WalletSettings, error) {
+    args := m.Called(ctx, walletID)
+    if args.Get(0) == nil {
+        return nil, args.Error(1)
+    }
+    return args.Get(0).(*models.WalletSettings), args.Error(1)
+}
+
+func (m *mockWalletRepository) UpdateWalletSettings(ctx context.Context, settings *models.WalletSettings) error {
+    args := m.Called(ctx, settings)
+    return args.Error(0)
+}
+
+func (m *mockWalletRepository) CountTransactionOutcomes(ctx context.Context, walletID uuid.UUID, since time.Time) (int, int, error) {
+    args := m.Called(ctx, walletID, since)
+    return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *mockWalletRepository) Close() error {
+    return nil
+}
+
 // TestMain handles test setup and teardown
 func TestMain(m *testing.M) {
     // Run tests
@@ -116,7 +213,7 @@ func TestGetWalletBalance(t *testing.T) {
             mockRepo.On("GetWallet", ctx, tt.walletID).Return(tt.mockWallet, tt.mockError)
 
             // Create service with mock repository
-            svc, err := service.NewWalletService(mockRepo, decimal.NewFromFloat(100), nil)
+            svc, err := service.NewWalletService(mockRepo, supportedCurrencies(t), decimal.NewFromFloat(100), eventbus.New(), nil)
             require.NoError(t, err)
 
             // Execute test
@@ -194,15 +291,17 @@ func TestProcessTransaction(t *testing.T) {
         t.Run(tt.name, func(t *testing.T) {
             // Setup mock repository
             mockRepo := new(mockWalletRepository)
-            mockRepo.On("GetWallet", ctx, tt.wallet.ID).Return(tt.wallet, nil)
-            mockRepo.On("UpdateBalance", ctx, tt.transaction).Return(tt.mockError)
+            mockRepo.On("InTx", ctx).Return(nil)
+            mockRepo.On("GetWalletForUpdate", ctx, tt.wallet.ID).Return(tt.wallet, nil)
+            mockRepo.On("ApplyTransaction", ctx, mock.Anything, tt.transaction).Return(tt.mockError).Maybe()
+            mockRepo.On("InsertTransaction", ctx, tt.transaction).Return(nil).Maybe()
 
             // Create service with mock repository
-            svc, err := service.NewWalletService(mockRepo, decimal.NewFromFloat(100), nil)
+            svc, err := service.NewWalletService(mockRepo, supportedCurrencies(t), decimal.NewFromFloat(100), eventbus.New(), nil)
             require.NoError(t, err)
 
             // Execute test
-            err = svc.ProcessTransaction(ctx, tt.transaction)
+            _, err = svc.ProcessTransaction(ctx, tt.transaction)
 
             // Verify results
             if tt.wantErr {
@@ -281,11 +380,12 @@ func TestConcurrentTransactions(t *testing.T) {
 
     // Setup mock repository
     mockRepo := new(mockWalletRepository)
-    mockRepo.On("GetWallet", ctx, wallet.ID).Return(wallet, nil)
-    mockRepo.On("UpdateBalance", ctx, mock.Anything).Return(repository.ErrOptimisticLock)
+    mockRepo.On("InTx", ctx).Return(nil)
+    mockRepo.On("GetWalletForUpdate", ctx, wallet.ID).Return(wallet, nil)
+    mockRepo.On("ApplyTransaction", ctx, mock.Anything, mock.Anything).Return(repository.ErrOptimisticLock)
 
     // Create service with mock repository
-    svc, err := service.NewWalletService(mockRepo, decimal.NewFromFloat(100), nil)
+    svc, err := service.NewWalletService(mockRepo, supportedCurrencies(t), decimal.NewFromFloat(100), eventbus.New(), nil)
     require.NoError(t, err)
 
     // Create concurrent transactions
@@ -299,9 +399,33 @@ func TestConcurrentTransactions(t *testing.T) {
     }
 
     // Execute test
-    err = svc.ProcessTransaction(ctx, tx1)
+    _, err = svc.ProcessTransaction(ctx, tx1)
     require.Error(t, err)
     require.Equal(t, service.ErrOptimisticLock, err)
 
     mockRepo.AssertExpectations(t)
+}
+
+// TestTransactionColumns tests that transaction types and statuses are
+// stored and read by the names the wallet_transactions columns hold
+func TestTransactionColumns(t *testing.T) {
+    value, err := models.TransactionTypeDebit.Value()
+    require.NoError(t, err)
+    require.Equal(t, "DEBIT", value)
+
+    var txType models.TransactionType
+    require.NoError(t, txType.Scan([]byte("ADJUSTMENT")))
+    require.Equal(t, models.TransactionTypeAdjustment, txType)
+    require.ErrorIs(t, txType.Scan("UNKNOWN"), models.ErrInvalidTransactionType)
+
+    value, err = models.TransactionStatusCompleted.Value()
+    require.NoError(t, err)
+    require.Equal(t, "COMPLETED", value)
+
+    var status models.TransactionStatus
+    require.NoError(t, status.Scan("REVERSED"))
+    require.Equal(t, models.TransactionStatusReversed, status)
+
+    _, err = models.TransactionStatus(-1).Value()
+    require.ErrorIs(t, err, models.ErrInvalidTransactionStatus)
 }
\ No newline at end of file
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/events"
+	"internal/execctx"
+	"internal/models"
+	"internal/webhook"
+)
+
+// TestWebhookMatches tests event type filters, wallet scopes and wallet webhooks
+func TestWebhookMatches(t *testing.T) {
+	otherWallet := uuid.New()
+
+	sub := &models.WebhookSubscription{Enabled: true}
+	require.True(t, webhook.Matches(sub, events.TypeLowBalance, testWalletID))
+	require.True(t, webhook.Matches(sub, events.TypeActivityDigest, uuid.Nil))
+
+	sub.EventTypes = []string{events.TypeTransactionCompleted}
+	require.True(t, webhook.Matches(sub, events.TypeTransactionCompleted, testWalletID))
+	require.False(t, webhook.Matches(sub, events.TypeLowBalance, testWalletID))
+
+	sub.WalletIDs = []uuid.UUID{testWalletID}
+	require.True(t, webhook.Matches(sub, events.TypeTransactionCompleted, testWalletID))
+	require.False(t, webhook.Matches(sub, events.TypeTransactionCompleted, otherWallet))
+	require.False(t, webhook.Matches(sub, events.TypeTransactionCompleted, uuid.Nil))
+
+	sub.Enabled = false
+	require.False(t, webhook.Matches(sub, events.TypeTransactionCompleted, testWalletID))
+
+	// Wallet webhooks only receive events of their own wallet
+	walletSub := &models.WebhookSubscription{Enabled: true, WalletID: &testWalletID}
+	require.True(t, webhook.Matches(walletSub, events.TypeLowBalance, testWalletID))
+	require.False(t, webhook.Matches(walletSub, events.TypeLowBalance, otherWallet))
+	require.False(t, webhook.Matches(walletSub, events.TypeActivityDigest, uuid.Nil))
+}
+
+// TestWebhookSelectFields tests payload field selection
+func TestWebhookSelectFields(t *testing.T) {
+	payload := json.RawMessage(`{
+		"customer_id": "c1",
+		"frequency": "WEEKLY",
+		"wallets": [
+			{"wallet_id": "w1", "currency": "INR", "top_up_total": "10"},
+			{"wallet_id": "w2", "currency": "USD", "top_up_total": "20"}
+		]
+	}`)
+
+	full, err := webhook.SelectFields(payload, nil)
+	require.NoError(t, err)
+	require.JSONEq(t, string(payload), string(full))
+
+	selected, err := webhook.SelectFields(payload, []string{"frequency", "wallets.wallet_id", "wallets.currency", "missing"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"frequency": "WEEKLY",
+		"wallets": [
+			{"wallet_id": "w1", "currency": "INR"},
+			{"wallet_id": "w2", "currency": "USD"}
+		]
+	}`, string(selected))
+
+	require.NoError(t, webhook.ValidateFields([]string{"amount", "wallets.closing_balance"}))
+	require.ErrorIs(t, webhook.ValidateFields([]string{"wallets..id"}), webhook.ErrInvalidFieldPath)
+	require.ErrorIs(t, webhook.ValidateFields([]string{"Amount"}), webhook.ErrInvalidFieldPath)
+}
+
+// TestWebhookSubjectAndSignature tests subject extraction and request signing
+func TestWebhookSubjectAndSignature(t *testing.T) {
+	subject, err := webhook.SubjectOf(json.RawMessage(`{"wallet_id": "` + testWalletID.String() + `"}`))
+	require.NoError(t, err)
+	require.Equal(t, testWalletID, subject.WalletID)
+	require.Equal(t, uuid.Nil, subject.CustomerID)
+
+	_, err = webhook.SubjectOf(json.RawMessage(`{"wallet_id": "not-a-uuid"}`))
+	require.Error(t, err)
+
+	body := []byte(`{"id":"1"}`)
+	signature := webhook.Sign("whsec_test", "1700000000", body)
+	require.Len(t, signature, 64)
+	require.Equal(t, signature, webhook.Sign("whsec_test", "1700000000", body))
+	require.NotEqual(t, signature, webhook.Sign("whsec_other", "1700000000", body))
+}
+
+// TestWebhookSendSuppressed tests that sandbox and replayed events never
+// reach subscriber endpoints
+func TestWebhookSendSuppressed(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender, err := webhook.NewSender(time.Second)
+	require.NoError(t, err)
+
+	env := &events.Envelope{ID: uuid.New(), Type: events.TypeLowBalance, SchemaVersion: 1, Payload: json.RawMessage(`{}`)}
+
+	_, err = sender.Send(execctx.WithMode(context.Background(), execctx.ModeSandbox), server.URL, "whsec_test", env)
+	require.ErrorIs(t, err, webhook.ErrSuppressed)
+
+	replayed := *env
+	replayed.Mode = execctx.ModeReplay
+	_, err = sender.Send(context.Background(), server.URL, "whsec_test", &replayed)
+	require.ErrorIs(t, err, webhook.ErrSuppressed)
+	require.Zero(t, hits)
+
+	status, err := sender.Send(context.Background(), server.URL, "whsec_test", env)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, status)
+	require.Equal(t, 1, hits)
+}
@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              // v1.3.0
+	"github.com/gorilla/websocket"        // v1.5.0
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/testkit"
+)
+
+// wsMessage is a message of the WebSocket channel
+type wsMessage struct {
+	Type     string `json:"type"`
+	WalletID string `json:"wallet_id"`
+	Code     string `json:"code"`
+	Data     struct {
+		TransactionID string `json:"transaction_id"`
+		Status        string `json:"status"`
+	} `json:"data"`
+}
+
+// dialWebSocket opens the customer's WebSocket connection, closed when the
+// test ends
+func dialWebSocket(t *testing.T, kit *testkit.Kit, customerID uuid.UUID) *websocket.Conn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(kit.Server.URL, "http") + "/api/v1/ws"
+	conn, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer " + customerID.String()}})
+	require.NoError(t, err)
+	resp.Body.Close()
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// sendWS sends a subscribe or unsubscribe message for the wallet
+func sendWS(t *testing.T, conn *websocket.Conn, msgType string, walletID uuid.UUID) {
+	t.Helper()
+
+	require.NoError(t, conn.WriteJSON(map[string]string{"type": msgType, "wallet_id": walletID.String()}))
+}
+
+// readWS waits for the next message of the connection
+func readWS(t *testing.T, conn *websocket.Conn) wsMessage {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var msg wsMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+// TestWebSocketTransactionEvents tests subscriptions and transaction events
+func TestWebSocketTransactionEvents(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	wallet := kit.CreateWallet(t, customerID, "INR", 100)
+	foreign := kit.CreateWallet(t, uuid.New(), "INR", 100)
+
+	conn := dialWebSocket(t, kit, customerID)
+
+	sendWS(t, conn, "subscribe", wallet.ID)
+	msg := readWS(t, conn)
+	require.Equal(t, "subscribed", msg.Type)
+	require.Equal(t, wallet.ID.String(), msg.WalletID)
+
+	sendWS(t, conn, "subscribe", foreign.ID)
+	msg = readWS(t, conn)
+	require.Equal(t, "error", msg.Type)
+	require.Equal(t, "WALLET_NOT_FOUND", msg.Code)
+
+	debit(t, kit, customerID, wallet, 10)
+	debit(t, kit, foreign.CustomerID, foreign, 10)
+
+	msg = readWS(t, conn)
+	require.Equal(t, "transaction.completed", msg.Type)
+	require.Equal(t, wallet.ID.String(), msg.WalletID)
+	require.Equal(t, "COMPLETED", msg.Data.Status)
+
+	sendWS(t, conn, "unsubscribe", wallet.ID)
+	require.Equal(t, "unsubscribed", readWS(t, conn).Type)
+}
+
+// TestWebSocketAuthAndRateLimit tests that connections are authenticated and
+// that each connection's messages are rate limited
+func TestWebSocketAuthAndRateLimit(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+
+	url := "ws" + strings.TrimPrefix(kit.Server.URL, "http") + "/api/v1/ws"
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	conn := dialWebSocket(t, kit, uuid.New())
+	for i := 0; i < 15; i++ {
+		sendWS(t, conn, "unsubscribe", uuid.New())
+	}
+
+	var limited int
+	for i := 0; i < 15; i++ {
+		if readWS(t, conn).Code == "RATE_LIMITED" {
+			limited++
+		}
+	}
+	require.NotZero(t, limited)
+}
+
+// TestWebSocketDrain tests that shutdown closes connections with going away
+// and refuses new ones
+func TestWebSocketDrain(t *testing.T) {
+	kit := testkit.New(t, testkit.Options{})
+	customerID := uuid.New()
+	conn := dialWebSocket(t, kit, customerID)
+
+	drained := make(chan error, 1)
+	go func() { drained <- kit.WebSocket.Drain(context.Background()) }()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, _, err := conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "unexpected error: %v", err)
+	require.NoError(t, <-drained)
+
+	status, _ := kit.Do(t, customerID, http.MethodGet, "/api/v1/ws", nil)
+	require.Equal(t, http.StatusServiceUnavailable, status)
+}
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" // v1.8.4
+
+	"internal/worker"
+)
+
+// workerLogger counts the errors logged by concurrently running workers
+type workerLogger struct {
+	errors int64
+}
+
+func (l *workerLogger) Info(msg string, fields ...interface{}) {}
+
+func (l *workerLogger) Error(msg string, err error, fields ...interface{}) {
+	atomic.AddInt64(&l.errors, 1)
+}
+
+func (l *workerLogger) Warn(msg string, fields ...interface{}) {}
+
+// leaseTable is an in-memory lease store shared by the electors of several
+// simulated replicas
+type leaseTable struct {
+	mu      sync.Mutex
+	holders map[string]string
+}
+
+// memoryElector grants leases from a leaseTable. Leases never expire; a
+// replica hands over by releasing.
+type memoryElector struct {
+	table *leaseTable
+	id    string
+}
+
+func (e *memoryElector) Acquire(ctx context.Context, name string) (bool, error) {
+	e.table.mu.Lock()
+	defer e.table.mu.Unlock()
+
+	if holder, ok := e.table.holders[name]; ok && holder != e.id {
+		return false, nil
+	}
+	e.table.holders[name] = e.id
+	return true, nil
+}
+
+func (e *memoryElector) Release(ctx context.Context, name string) error {
+	e.table.mu.Lock()
+	defer e.table.mu.Unlock()
+
+	if e.table.holders[name] == e.id {
+		delete(e.table.holders, name)
+	}
+	return nil
+}
+
+func (e *memoryElector) TTL() time.Duration {
+	return 30 * time.Millisecond
+}
+
+// TestWorkerRunnerSingleton tests that a singleton worker runs on one
+// replica only and moves to another when its leader shuts down
+func TestWorkerRunnerSingleton(t *testing.T) {
+	table := &leaseTable{holders: make(map[string]string)}
+	var runs [2]int64
+
+	runners := make([]*worker.Runner, 2)
+	for i := range runners {
+		i := i
+		runner, err := worker.NewRunner(&memoryElector{table: table, id: string(rune('a' + i))}, time.Millisecond, &workerLogger{})
+		require.NoError(t, err)
+		require.NoError(t, runner.Add(worker.Worker{
+			Name:      "singleton",
+			Interval:  5 * time.Millisecond,
+			Singleton: true,
+			Job: func(ctx context.Context) error {
+				atomic.AddInt64(&runs[i], 1)
+				return nil
+			},
+		}))
+		runners[i] = runner
+	}
+
+	runners[0].Start(context.Background())
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&runs[0]) > 0 }, time.Second, time.Millisecond)
+	runners[1].Start(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	require.Zero(t, atomic.LoadInt64(&runs[1]))
+
+	require.NoError(t, runners[0].Shutdown(context.Background()))
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&runs[1]) > 0 }, time.Second, time.Millisecond)
+	require.NoError(t, runners[1].Shutdown(context.Background()))
+}
+
+// TestWorkerRunnerRecovery tests that panicking and failing workers are
+// restarted and that shutdown hooks run in reverse order once the workers
+// have stopped
+func TestWorkerRunnerRecovery(t *testing.T) {
+	logger := &workerLogger{}
+	runner, err := worker.NewRunner(nil, time.Millisecond, logger)
+	require.NoError(t, err)
+
+	require.Error(t, runner.Add(worker.Worker{Name: "no-job"}))
+	require.Error(t, runner.Add(worker.Worker{
+		Name:      "singleton",
+		Singleton: true,
+		Job:       func(ctx context.Context) error { return nil },
+	}))
+
+	var periodic, loops int64
+	require.NoError(t, runner.Add(worker.Worker{
+		Name:     "panicking",
+		Interval: time.Millisecond,
+		Job: func(ctx context.Context) error {
+			atomic.AddInt64(&periodic, 1)
+			panic("boom")
+		},
+	}))
+	require.NoError(t, runner.Add(worker.Worker{
+		Name: "failing-loop",
+		Job: func(ctx context.Context) error {
+			atomic.AddInt64(&loops, 1)
+			return errors.New("loop failed")
+		},
+	}))
+	require.Error(t, runner.Add(worker.Worker{
+		Name: "failing-loop",
+		Job:  func(ctx context.Context) error { return nil },
+	}))
+
+	var order []string
+	runner.OnShutdown("first", func(context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+	runner.OnShutdown("second", func(context.Context) error {
+		order = append(order, "second")
+		return errors.New("close failed")
+	})
+
+	runner.Start(context.Background())
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&periodic) > 2 && atomic.LoadInt64(&loops) > 2
+	}, time.Second, time.Millisecond)
+
+	err = runner.Shutdown(context.Background())
+	require.ErrorContains(t, err, "close failed")
+	require.Equal(t, []string{"second", "first"}, order)
+	require.Positive(t, atomic.LoadInt64(&logger.errors))
+}
+
+// TestWorkerRunnerReadOnly tests that read-only runners start only the
+// workers marked as changing no data
+func TestWorkerRunnerReadOnly(t *testing.T) {
+	runner, err := worker.NewRunner(nil, time.Millisecond, &workerLogger{})
+	require.NoError(t, err)
+	runner.SetReadOnly(true)
+
+	var reads, writes int64
+	require.NoError(t, runner.Add(worker.Worker{
+		Name:     "monitor",
+		Interval: time.Millisecond,
+		ReadOnly: true,
+		Job: func(ctx context.Context) error {
+			atomic.AddInt64(&reads, 1)
+			return nil
+		},
+	}))
+	require.NoError(t, runner.Add(worker.Worker{
+		Name:     "snapshots",
+		Interval: time.Millisecond,
+		Job: func(ctx context.Context) error {
+			atomic.AddInt64(&writes, 1)
+			return nil
+		},
+	}))
+
+	runner.Start(context.Background())
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&reads) > 2 }, time.Second, time.Millisecond)
+	require.NoError(t, runner.Shutdown(context.Background()))
+	require.Zero(t, atomic.LoadInt64(&writes))
+}